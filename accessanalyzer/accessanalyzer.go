@@ -0,0 +1,30 @@
+package accessanalyzer
+
+import (
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/accessanalyzer"
+	"github.com/aws/aws-sdk-go/service/accessanalyzer/accessanalyzeriface"
+	log "github.com/sirupsen/logrus"
+)
+
+// AccessAnalyzer is a wrapper around the aws iam access analyzer service
+type AccessAnalyzer struct {
+	Service accessanalyzeriface.AccessAnalyzerAPI
+}
+
+// NewSession creates a new access analyzer session
+func NewSession(sess *session.Session, account common.Account) AccessAnalyzer {
+	a := AccessAnalyzer{}
+	if sess == nil {
+		log.Infof("creating new aws session for access analyzer with key id %s in region %s", account.Akid, account.Region)
+		sess = session.Must(session.NewSession(&aws.Config{
+			Credentials: credentials.NewStaticCredentials(account.Akid, account.Secret, ""),
+			Region:      aws.String(account.Region),
+		}))
+	}
+	a.Service = accessanalyzer.New(sess)
+	return a
+}
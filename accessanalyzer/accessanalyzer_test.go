@@ -0,0 +1,31 @@
+package accessanalyzer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/service/accessanalyzer/accessanalyzeriface"
+)
+
+// mockAccessAnalyzerClient is a fake access analyzer client
+type mockAccessAnalyzerClient struct {
+	accessanalyzeriface.AccessAnalyzerAPI
+	t   *testing.T
+	err error
+}
+
+func newMockAccessAnalyzerClient(t *testing.T, err error) accessanalyzeriface.AccessAnalyzerAPI {
+	return &mockAccessAnalyzerClient{
+		t:   t,
+		err: err,
+	}
+}
+
+func TestNewSession(t *testing.T) {
+	e := NewSession(nil, common.Account{})
+	to := reflect.TypeOf(e).String()
+	if to != "accessanalyzer.AccessAnalyzer" {
+		t.Errorf("expected type to be 'accessanalyzer.AccessAnalyzer', got %s", to)
+	}
+}
@@ -0,0 +1,60 @@
+package accessanalyzer
+
+import (
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/accessanalyzer"
+	"github.com/pkg/errors"
+)
+
+// ErrCode processes the error codes comming back from access analyzer and converts them into apierror, a
+// standardized form consumable by downstream systems.
+func ErrCode(msg string, err error) error {
+	if aerr, ok := errors.Cause(err).(awserr.Error); ok {
+		switch aerr.Code() {
+		case
+			// accessanalyzer.ErrCodeAccessDeniedException for service response error code
+			// "AccessDeniedException".
+			//
+			// You do not have sufficient access to perform this action.
+			accessanalyzer.ErrCodeAccessDeniedException:
+
+			return apierror.New(apierror.ErrForbidden, msg, aerr)
+		case
+			// accessanalyzer.ErrCodeResourceNotFoundException for service response error code
+			// "ResourceNotFoundException".
+			//
+			// The specified resource could not be found.
+			accessanalyzer.ErrCodeResourceNotFoundException:
+
+			return apierror.New(apierror.ErrNotFound, msg, aerr)
+		case
+			// accessanalyzer.ErrCodeThrottlingException for service response error code
+			// "ThrottlingException".
+			//
+			// Throttling limit exceeded error.
+			accessanalyzer.ErrCodeThrottlingException,
+
+			// accessanalyzer.ErrCodeServiceQuotaExceededException for service response error code
+			// "ServiceQuotaExceededException".
+			//
+			// Service quote met error.
+			accessanalyzer.ErrCodeServiceQuotaExceededException:
+
+			return apierror.New(apierror.ErrLimitExceeded, msg, aerr)
+		case
+			// accessanalyzer.ErrCodeInternalServerException for service response error code
+			// "InternalServerException".
+			//
+			// Internal server error.
+			accessanalyzer.ErrCodeInternalServerException:
+
+			return apierror.New(apierror.ErrServiceUnavailable, msg, aerr)
+		default:
+			m := msg + ": " + aerr.Message()
+			return apierror.New(apierror.ErrBadRequest, m, aerr)
+		}
+	}
+
+	return apierror.New(apierror.ErrInternalError, msg, err)
+}
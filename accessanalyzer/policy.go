@@ -0,0 +1,139 @@
+package accessanalyzer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/accessanalyzer"
+	log "github.com/sirupsen/logrus"
+)
+
+// FindingTypePublicAccess is the type of the findings for the statements that grant public access, the other finding
+// types are the access analyzer policy validation finding types
+const FindingTypePublicAccess = "PUBLIC_ACCESS"
+
+// Finding is an issue access analyzer found with a policy
+type Finding struct {
+	// Type is one of ERROR, SECURITY_WARNING, WARNING, SUGGESTION or PUBLIC_ACCESS
+	Type string
+	// IssueCode identifies the issue, ie. MISSING_VERSION
+	IssueCode     string `json:",omitempty"`
+	Details       string
+	LearnMoreLink string `json:",omitempty"`
+}
+
+// String returns the finding as a single line, ie. [ERROR] MISSING_VERSION: details
+func (f *Finding) String() string {
+	if f.IssueCode == "" {
+		return fmt.Sprintf("[%s] %s", f.Type, f.Details)
+	}
+	return fmt.Sprintf("[%s] %s: %s", f.Type, f.IssueCode, f.Details)
+}
+
+// ValidatePolicy runs the access analyzer policy checks against a resource policy document for a type of resource,
+// ie. AWS::S3::Bucket, and returns the findings
+func (a *AccessAnalyzer) ValidatePolicy(ctx context.Context, resourceType, document string) ([]*Finding, error) {
+	if resourceType == "" || document == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("validating %s policy with access analyzer", resourceType)
+
+	input := &accessanalyzer.ValidatePolicyInput{
+		PolicyDocument:             aws.String(document),
+		PolicyType:                 aws.String(accessanalyzer.PolicyTypeResourcePolicy),
+		ValidatePolicyResourceType: aws.String(resourceType),
+	}
+
+	findings := []*Finding{}
+	for {
+		output, err := a.Service.ValidatePolicyWithContext(ctx, input)
+		if err != nil {
+			return nil, ErrCode("failed to validate policy", err)
+		}
+
+		for _, f := range output.Findings {
+			findings = append(findings, &Finding{
+				Type:          aws.StringValue(f.FindingType),
+				IssueCode:     aws.StringValue(f.IssueCode),
+				Details:       aws.StringValue(f.FindingDetails),
+				LearnMoreLink: aws.StringValue(f.LearnMoreLink),
+			})
+		}
+
+		if aws.StringValue(output.NextToken) == "" {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+
+	log.Debugf("access analyzer found %d issues with %s policy", len(findings), resourceType)
+
+	return findings, nil
+}
+
+// CheckNoPublicAccess checks whether a resource policy document for a type of resource grants public access, it
+// returns a public access finding for each of the reasons it does
+func (a *AccessAnalyzer) CheckNoPublicAccess(ctx context.Context, resourceType, document string) ([]*Finding, error) {
+	if resourceType == "" || document == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("checking %s policy for public access with access analyzer", resourceType)
+
+	output, err := a.Service.CheckNoPublicAccessWithContext(ctx, &accessanalyzer.CheckNoPublicAccessInput{
+		PolicyDocument: aws.String(document),
+		ResourceType:   aws.String(resourceType),
+	})
+	if err != nil {
+		return nil, ErrCode("failed to check policy for public access", err)
+	}
+
+	findings := []*Finding{}
+	if aws.StringValue(output.Result) != accessanalyzer.CheckNoPublicAccessResultFail {
+		return findings, nil
+	}
+
+	for _, r := range output.Reasons {
+		details := aws.StringValue(r.Description)
+		if r.StatementId != nil {
+			details = fmt.Sprintf("statement %s: %s", aws.StringValue(r.StatementId), details)
+		} else if r.StatementIndex != nil {
+			details = fmt.Sprintf("statement %d: %s", aws.Int64Value(r.StatementIndex), details)
+		}
+
+		findings = append(findings, &Finding{Type: FindingTypePublicAccess, Details: details})
+	}
+
+	// a failed check should always have a reason, but make sure it's never reported as passing
+	if len(findings) == 0 {
+		findings = append(findings, &Finding{Type: FindingTypePublicAccess, Details: aws.StringValue(output.Message)})
+	}
+
+	return findings, nil
+}
+
+// ValidateBucketPolicy runs the policy checks and the public access check against a bucket policy document and
+// returns all of the findings
+func (a *AccessAnalyzer) ValidateBucketPolicy(ctx context.Context, document string) ([]*Finding, error) {
+	findings, err := a.ValidatePolicy(ctx, accessanalyzer.ValidatePolicyResourceTypeAwsS3Bucket, document)
+	if err != nil {
+		return nil, err
+	}
+
+	// the public access check can't evaluate a policy that has errors
+	for _, f := range findings {
+		if f.Type == accessanalyzer.ValidatePolicyFindingTypeError {
+			return findings, nil
+		}
+	}
+
+	public, err := a.CheckNoPublicAccess(ctx, accessanalyzer.AccessCheckResourceTypeAwsS3Bucket, document)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(findings, public...), nil
+}
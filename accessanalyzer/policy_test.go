@@ -0,0 +1,150 @@
+package accessanalyzer
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/accessanalyzer"
+)
+
+const (
+	testInvalidPolicy = `{"Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::foo/*"}]}`
+	testPublicPolicy  = `{"Version":"2012-10-17","Statement":[{"Sid":"Public","Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::foo/*"}]}`
+	testPrivatePolicy = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::012345678910:root"},"Action":"s3:*","Resource":"arn:aws:s3:::foo/*"}]}`
+)
+
+func (m *mockAccessAnalyzerClient) ValidatePolicyWithContext(ctx aws.Context, input *accessanalyzer.ValidatePolicyInput, opts ...request.Option) (*accessanalyzer.ValidatePolicyOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if aws.StringValue(input.ValidatePolicyResourceType) != accessanalyzer.ValidatePolicyResourceTypeAwsS3Bucket {
+		return nil, awserr.New(accessanalyzer.ErrCodeValidationException, "unexpected resource type", nil)
+	}
+
+	if !strings.Contains(aws.StringValue(input.PolicyDocument), "Version") {
+		return &accessanalyzer.ValidatePolicyOutput{Findings: []*accessanalyzer.ValidatePolicyFinding{
+			{
+				FindingType:    aws.String(accessanalyzer.ValidatePolicyFindingTypeError),
+				IssueCode:      aws.String("MISSING_VERSION"),
+				FindingDetails: aws.String("We recommend that you specify the Version element."),
+				LearnMoreLink:  aws.String("https://docs.aws.amazon.com/"),
+			},
+		}}, nil
+	}
+
+	// return the suggestion on a second page
+	if input.NextToken == nil {
+		return &accessanalyzer.ValidatePolicyOutput{Findings: []*accessanalyzer.ValidatePolicyFinding{}, NextToken: aws.String("next")}, nil
+	}
+
+	return &accessanalyzer.ValidatePolicyOutput{Findings: []*accessanalyzer.ValidatePolicyFinding{
+		{
+			FindingType:    aws.String(accessanalyzer.ValidatePolicyFindingTypeSuggestion),
+			IssueCode:      aws.String("EMPTY_OBJECT_PRINCIPAL"),
+			FindingDetails: aws.String("details"),
+		},
+	}}, nil
+}
+
+func (m *mockAccessAnalyzerClient) CheckNoPublicAccessWithContext(ctx aws.Context, input *accessanalyzer.CheckNoPublicAccessInput, opts ...request.Option) (*accessanalyzer.CheckNoPublicAccessOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if !strings.Contains(aws.StringValue(input.PolicyDocument), `"Principal":"*"`) {
+		return &accessanalyzer.CheckNoPublicAccessOutput{Result: aws.String(accessanalyzer.CheckNoPublicAccessResultPass)}, nil
+	}
+
+	return &accessanalyzer.CheckNoPublicAccessOutput{
+		Result:  aws.String(accessanalyzer.CheckNoPublicAccessResultFail),
+		Message: aws.String("The resource policy grants public access"),
+		Reasons: []*accessanalyzer.ReasonSummary{
+			{Description: aws.String("Public access granted"), StatementId: aws.String("Public")},
+		},
+	}, nil
+}
+
+func TestValidateBucketPolicy(t *testing.T) {
+	a := AccessAnalyzer{Service: newMockAccessAnalyzerClient(t, nil)}
+
+	tests := []struct {
+		name     string
+		policy   string
+		expected []*Finding
+	}{
+		{
+			name:   "invalid",
+			policy: testInvalidPolicy,
+			expected: []*Finding{
+				{Type: "ERROR", IssueCode: "MISSING_VERSION", Details: "We recommend that you specify the Version element.", LearnMoreLink: "https://docs.aws.amazon.com/"},
+			},
+		},
+		{
+			name:   "public",
+			policy: testPublicPolicy,
+			expected: []*Finding{
+				{Type: "SUGGESTION", IssueCode: "EMPTY_OBJECT_PRINCIPAL", Details: "details"},
+				{Type: FindingTypePublicAccess, Details: "statement Public: Public access granted"},
+			},
+		},
+		{
+			name:   "private",
+			policy: testPrivatePolicy,
+			expected: []*Finding{
+				{Type: "SUGGESTION", IssueCode: "EMPTY_OBJECT_PRINCIPAL", Details: "details"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		out, err := a.ValidateBucketPolicy(context.TODO(), test.policy)
+		if err != nil {
+			t.Errorf("%s: expected nil error, got %s", test.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(test.expected, out) {
+			t.Errorf("%s: expected %+v, got %+v", test.name, test.expected, out)
+		}
+	}
+
+	if _, err := a.ValidateBucketPolicy(context.TODO(), ""); err == nil {
+		t.Error("expected error for empty policy, got nil")
+	} else if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrBadRequest {
+		t.Errorf("expected error code %s, got: %s", apierror.ErrBadRequest, err)
+	}
+
+	a.Service.(*mockAccessAnalyzerClient).err = awserr.New(accessanalyzer.ErrCodeAccessDeniedException, "denied", nil)
+	if _, err := a.ValidateBucketPolicy(context.TODO(), testPrivatePolicy); err == nil {
+		t.Error("expected error, got nil")
+	} else if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrForbidden {
+		t.Errorf("expected error code %s, got: %s", apierror.ErrForbidden, err)
+	}
+
+	a.Service.(*mockAccessAnalyzerClient).err = errors.New("things blowing up")
+	if _, err := a.CheckNoPublicAccess(context.TODO(), accessanalyzer.AccessCheckResourceTypeAwsS3Bucket, testPrivatePolicy); err == nil {
+		t.Error("expected error, got nil")
+	} else if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrInternalError {
+		t.Errorf("expected error code %s, got: %s", apierror.ErrInternalError, err)
+	}
+}
+
+func TestFindingString(t *testing.T) {
+	f := &Finding{Type: "ERROR", IssueCode: "MISSING_VERSION", Details: "details"}
+	if out := f.String(); out != "[ERROR] MISSING_VERSION: details" {
+		t.Errorf("unexpected finding string %s", out)
+	}
+
+	f = &Finding{Type: FindingTypePublicAccess, Details: "details"}
+	if out := f.String(); out != "[PUBLIC_ACCESS] details" {
+		t.Errorf("unexpected finding string %s", out)
+	}
+}
@@ -0,0 +1,33 @@
+package acm
+
+import (
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/acm"
+	"github.com/aws/aws-sdk-go/service/acm/acmiface"
+	log "github.com/sirupsen/logrus"
+)
+
+// cloudFrontRegion is the region the certificates used by cloudfront distributions are kept in
+const cloudFrontRegion = "us-east-1"
+
+// ACM is a wrapper around the aws certificate manager service
+type ACM struct {
+	Service acmiface.ACMAPI
+}
+
+// NewSession creates a new acm session.  The certificates for cloudfront distributions have to be in us-east-1, so
+// the session is always for that region.
+func NewSession(sess *session.Session, account common.Account) ACM {
+	a := ACM{}
+	if sess == nil {
+		log.Infof("creating new aws session for acm with key id %s", account.Akid)
+		sess = session.Must(session.NewSession(&aws.Config{
+			Credentials: credentials.NewStaticCredentials(account.Akid, account.Secret, ""),
+		}))
+	}
+	a.Service = acm.New(sess, aws.NewConfig().WithRegion(cloudFrontRegion))
+	return a
+}
@@ -0,0 +1,31 @@
+package acm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/service/acm/acmiface"
+)
+
+// mockACMClient is a fake acm client
+type mockACMClient struct {
+	acmiface.ACMAPI
+	t   *testing.T
+	err error
+}
+
+func newMockACMClient(t *testing.T, err error) acmiface.ACMAPI {
+	return &mockACMClient{
+		t:   t,
+		err: err,
+	}
+}
+
+func TestNewSession(t *testing.T) {
+	e := NewSession(nil, common.Account{})
+	to := reflect.TypeOf(e).String()
+	if to != "acm.ACM" {
+		t.Errorf("expected type to be 'acm.ACM', got %s", to)
+	}
+}
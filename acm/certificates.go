@@ -0,0 +1,54 @@
+package acm
+
+import (
+	"context"
+	"strings"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/acm"
+	log "github.com/sirupsen/logrus"
+)
+
+// GetCertificate describes the certificate with the arn
+func (a *ACM) GetCertificate(ctx context.Context, arn string) (*acm.CertificateDetail, error) {
+	if arn == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("describing certificate %s", arn)
+
+	out, err := a.Service.DescribeCertificateWithContext(ctx, &acm.DescribeCertificateInput{
+		CertificateArn: aws.String(arn),
+	})
+	if err != nil {
+		return nil, ErrCode("failed to describe certificate "+arn, err)
+	}
+
+	return out.Certificate, nil
+}
+
+// Covers returns true if the certificate's domain name or one of its alternative names covers the name, a wildcard
+// name covers a single label
+func Covers(cert *acm.CertificateDetail, name string) bool {
+	if cert == nil {
+		return false
+	}
+
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	names := append([]*string{cert.DomainName}, cert.SubjectAlternativeNames...)
+	for _, n := range names {
+		certName := strings.ToLower(aws.StringValue(n))
+		if certName == name {
+			return true
+		}
+
+		if strings.HasPrefix(certName, "*.") {
+			if i := strings.Index(name, "."); i > 0 && name[i+1:] == certName[2:] {
+				return true
+			}
+		}
+	}
+
+	return false
+}
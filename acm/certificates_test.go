@@ -0,0 +1,86 @@
+package acm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/acm"
+)
+
+var testCertArn = "arn:aws:acm:us-east-1:12345678910:certificate/111111111-2222-3333-4444-555555555555"
+
+func (m *mockACMClient) DescribeCertificateWithContext(ctx aws.Context, input *acm.DescribeCertificateInput, opts ...request.Option) (*acm.DescribeCertificateOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if aws.StringValue(input.CertificateArn) != testCertArn {
+		return nil, awserr.New(acm.ErrCodeResourceNotFoundException, "not found", nil)
+	}
+
+	return &acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+		CertificateArn: input.CertificateArn,
+		DomainName:     aws.String("*.example.com"),
+		Status:         aws.String(acm.CertificateStatusIssued),
+	}}, nil
+}
+
+func TestGetCertificate(t *testing.T) {
+	a := ACM{Service: newMockACMClient(t, nil)}
+
+	out, err := a.GetCertificate(context.TODO(), testCertArn)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if aws.StringValue(out.DomainName) != "*.example.com" {
+		t.Errorf("expected domain name *.example.com, got %s", aws.StringValue(out.DomainName))
+	}
+
+	if _, err := a.GetCertificate(context.TODO(), ""); err == nil {
+		t.Error("expected error for an empty arn, got nil")
+	}
+
+	_, err = a.GetCertificate(context.TODO(), "arn:aws:acm:us-east-1:12345678910:certificate/nope")
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrNotFound {
+		t.Errorf("expected not found error, got %v", err)
+	}
+
+	a.Service = newMockACMClient(t, awserr.New(acm.ErrCodeAccessDeniedException, "denied", nil))
+	_, err = a.GetCertificate(context.TODO(), testCertArn)
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrForbidden {
+		t.Errorf("expected forbidden error, got %v", err)
+	}
+}
+
+func TestCovers(t *testing.T) {
+	cert := &acm.CertificateDetail{
+		DomainName:              aws.String("*.example.com"),
+		SubjectAlternativeNames: []*string{aws.String("*.example.com"), aws.String("example.org")},
+	}
+
+	tests := map[string]bool{
+		"www.example.com":      true,
+		"WWW.Example.com.":     true,
+		"example.org":          true,
+		"example.com":          false,
+		"a.b.example.com":      false,
+		"www.example.org":      false,
+		"www.notexample.com":   false,
+		"www.example.com.evil": false,
+	}
+
+	for name, expected := range tests {
+		if out := Covers(cert, name); out != expected {
+			t.Errorf("expected %s to be covered %t, got %t", name, expected, out)
+		}
+	}
+
+	if Covers(nil, "www.example.com") {
+		t.Error("expected a nil certificate not to cover anything")
+	}
+}
@@ -0,0 +1,47 @@
+package acm
+
+import (
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/acm"
+	"github.com/pkg/errors"
+)
+
+// ErrCode processes the error codes comming back from acm and converts them into apierror, a
+// standardized form consumable by downstream systems.
+func ErrCode(msg string, err error) error {
+	if aerr, ok := errors.Cause(err).(awserr.Error); ok {
+		switch aerr.Code() {
+		case
+			// acm.ErrCodeAccessDeniedException for service response error code
+			// "AccessDeniedException".
+			//
+			// You do not have access required to perform this action.
+			acm.ErrCodeAccessDeniedException:
+
+			return apierror.New(apierror.ErrForbidden, msg, aerr)
+		case
+			// acm.ErrCodeResourceNotFoundException for service response error code
+			// "ResourceNotFoundException".
+			//
+			// The specified certificate cannot be found in the caller's account or the
+			// caller's account cannot be found.
+			acm.ErrCodeResourceNotFoundException:
+
+			return apierror.New(apierror.ErrNotFound, msg, aerr)
+		case
+			// acm.ErrCodeThrottlingException for service response error code
+			// "ThrottlingException".
+			//
+			// The request was denied because it exceeded a quota.
+			acm.ErrCodeThrottlingException:
+
+			return apierror.New(apierror.ErrLimitExceeded, msg, aerr)
+		default:
+			m := msg + ": " + aerr.Message()
+			return apierror.New(apierror.ErrBadRequest, m, aerr)
+		}
+	}
+
+	return apierror.New(apierror.ErrInternalError, msg, err)
+}
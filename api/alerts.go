@@ -0,0 +1,130 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/YaleSpinup/s3-api/rollback"
+	"github.com/YaleSpinup/s3-api/webhook"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	orchestrationFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "s3_api",
+		Subsystem: "orchestration",
+		Name:      "failures_total",
+		Help:      "The number of operations that failed part way through and were rolled back",
+	}, []string{"operation"})
+
+	rollbacksExecuted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "s3_api",
+		Subsystem: "rollback",
+		Name:      "executions_total",
+		Help:      "The number of rollbacks executed by their status, rolled_back or failed",
+	}, []string{"operation", "status"})
+
+	rollbackStepFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "s3_api",
+		Subsystem: "rollback",
+		Name:      "step_failures_total",
+		Help:      "The number of rollback steps that failed by the kind of step",
+	}, []string{"operation", "kind"})
+)
+
+// rollbackAlert is the details of a rollback alert event
+type rollbackAlert struct {
+	// Rollback is the id of the rollback, failed rollbacks can be resumed or discarded with the rollbacks endpoints
+	Rollback  string
+	Operation string
+	Instance  string
+	Status    string
+	// Error is the error that caused the operation to be rolled back
+	Error string `json:",omitempty"`
+	// FailedSteps are the rollback steps that failed with their errors
+	FailedSteps []*rollback.Step `json:",omitempty"`
+	// OrphanedResources are the resources the failed steps left behind, ie. "iam.DeletePolicy policy_arn=arn:..."
+	OrphanedResources []string `json:",omitempty"`
+	// Steps is the total number of steps in the rollback
+	Steps int
+}
+
+// newRollbackAlert returns the alert details for an executed rollback and the error that caused it
+func newRollbackAlert(rb *rollback.Rollback, cause error) *rollbackAlert {
+	alert := rollbackAlert{
+		Rollback:          rb.ID,
+		Operation:         rb.Operation,
+		Instance:          rb.Instance,
+		Status:            rb.Status,
+		FailedSteps:       []*rollback.Step{},
+		OrphanedResources: []string{},
+		Steps:             len(rb.Steps),
+	}
+
+	if cause != nil {
+		alert.Error = cause.Error()
+	}
+
+	for _, step := range rb.Steps {
+		if step.Status == rollback.StepSucceeded {
+			continue
+		}
+
+		if step.Status == rollback.StepFailed {
+			alert.FailedSteps = append(alert.FailedSteps, step)
+		}
+		alert.OrphanedResources = append(alert.OrphanedResources, orphanedResource(step))
+	}
+
+	return &alert
+}
+
+// orphanedResource describes the resource a rollback step didn't clean up by its kind and params, ie.
+// "iam.DeleteGroup group=foo-BktAdmGrp"
+func orphanedResource(step *rollback.Step) string {
+	params := make([]string, 0, len(step.Params))
+	for k, v := range step.Params {
+		params = append(params, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(params)
+
+	if len(params) == 0 {
+		return step.Kind
+	}
+
+	return step.Kind + " " + strings.Join(params, ", ")
+}
+
+// reportRollback counts an executed rollback and sends an alert with the steps that failed and the resources they left
+// behind, cause is the error that caused the rollback (if any)
+func (s *server) reportRollback(rb *rollback.Rollback, cause error) {
+	alert := newRollbackAlert(rb, cause)
+
+	rollbacksExecuted.WithLabelValues(rb.Operation, rb.Status).Inc()
+	for _, step := range alert.FailedSteps {
+		rollbackStepFailures.WithLabelValues(rb.Operation, step.Kind).Inc()
+	}
+
+	eventType := webhook.EventRollbackExecuted
+	if rb.Status != rollback.StatusRolledBack {
+		eventType = webhook.EventRollbackFailed
+		log.Errorf("rollback %s (%s %s) left %d resources behind: %s", rb.ID, rb.Operation, rb.Resource, len(alert.OrphanedResources), strings.Join(alert.OrphanedResources, "; "))
+	}
+
+	s.alert(eventType, rb.Account, rb.Resource, alert)
+}
+
+// alert sends an alert event for a resource in an account to the alert webhooks and publishes it to the alert topic
+func (s *server) alert(eventType, account, resource string, details interface{}) {
+	e := webhook.NewEvent(eventType, s.org, account, resource, details)
+	s.alerter.Notify(e)
+
+	if s.alertTopic == "" {
+		return
+	}
+
+	s.publish(s.alertTopic, e)
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/YaleSpinup/s3-api/rollback"
+	"github.com/YaleSpinup/s3-api/webhook"
+)
+
+func TestNewRollbackAlert(t *testing.T) {
+	rb := rollback.New("bucket.create", "someaccount", "foobucket", func(ctx context.Context, step *rollback.Step) error {
+		if step.Kind == rollbackDeleteGroup {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	rb.Add("delete bucket foobucket", rollbackDeleteBucket, map[string]string{"bucket": "foobucket"})
+	rb.Add("delete group foobucket-BktAdmGrp", rollbackDeleteGroup, map[string]string{"group": "foobucket-BktAdmGrp"})
+
+	if err := rb.Execute(context.TODO()); err == nil {
+		t.Fatal("expected error executing rollback, got nil")
+	}
+
+	alert := newRollbackAlert(rb, errors.New("failed to create policy"))
+
+	if alert.Rollback != rb.ID || alert.Operation != "bucket.create" || alert.Status != rollback.StatusFailed || alert.Steps != 2 {
+		t.Errorf("unexpected alert %+v", alert)
+	}
+
+	if alert.Error != "failed to create policy" {
+		t.Errorf("expected error 'failed to create policy', got %s", alert.Error)
+	}
+
+	if len(alert.FailedSteps) != 1 || alert.FailedSteps[0].Error != "boom" {
+		t.Errorf("expected one failed step with error boom, got %+v", alert.FailedSteps)
+	}
+
+	expected := []string{"iam.DeleteGroup group=foobucket-BktAdmGrp"}
+	if !reflect.DeepEqual(expected, alert.OrphanedResources) {
+		t.Errorf("expected orphaned resources %v, got %v", expected, alert.OrphanedResources)
+	}
+}
+
+func TestOrphanedResource(t *testing.T) {
+	tests := []struct {
+		step     *rollback.Step
+		expected string
+	}{
+		{
+			step:     &rollback.Step{Kind: rollbackDeleteObject, Params: map[string]string{"key": "index.html", "bucket": "foobucket"}},
+			expected: "s3.DeleteObject bucket=foobucket, key=index.html",
+		},
+		{
+			step:     &rollback.Step{Kind: rollbackDeleteBucket},
+			expected: "s3.DeleteEmptyBucket",
+		},
+	}
+
+	for _, test := range tests {
+		if out := orphanedResource(test.step); out != test.expected {
+			t.Errorf("expected %s, got %s", test.expected, out)
+		}
+	}
+}
+
+func TestReportRollback(t *testing.T) {
+	events := make(chan webhook.Event, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		e := webhook.Event{}
+		if err := json.Unmarshal(body, &e); err != nil {
+			t.Errorf("failed to unmarshal alert event: %s", err)
+		}
+		events <- e
+	}))
+	defer ts.Close()
+
+	s := server{
+		org:     "localdev",
+		alerter: webhook.New([]common.Webhook{{URL: ts.URL}}, webhook.WithOrg("localdev"), webhook.WithAttempts(1)),
+	}
+
+	rb := rollback.New("user.create", "someaccount", "foo-user", func(ctx context.Context, step *rollback.Step) error {
+		return nil
+	})
+	rb.Add("delete user foo-user", rollbackDeleteUser, map[string]string{"user": "foo-user"})
+
+	s.finishRollback(rb, errors.New("failed to add user to group"))
+
+	select {
+	case e := <-events:
+		if e.Type != webhook.EventRollbackExecuted || e.Account != "someaccount" || e.Resource != "foo-user" {
+			t.Errorf("unexpected alert event %+v", e)
+		}
+
+		details, ok := e.Details.(map[string]interface{})
+		if !ok || details["Rollback"] != rb.ID || details["Error"] != "failed to add user to group" {
+			t.Errorf("unexpected alert details %+v", e.Details)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for alert event")
+	}
+
+	// completed operations aren't reported
+	rb = rollback.New("user.create", "someaccount", "foo-user", nil)
+	s.finishRollback(rb, nil)
+	s.alerter.Wait()
+
+	if len(events) != 0 {
+		t.Errorf("expected no alert for a completed operation, got %d", len(events))
+	}
+}
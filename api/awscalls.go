@@ -0,0 +1,72 @@
+package api
+
+import (
+	"time"
+
+	"github.com/YaleSpinup/s3-api/circuit"
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/YaleSpinup/s3-api/session"
+	"github.com/pkg/errors"
+)
+
+// newAWSCallOptions parses the timeouts and circuit breaker configuration into the options for the aws sessions.  The
+// breakers are created once and shared by all of the sessions, so the failures are counted across accounts.
+func newAWSCallOptions(config *common.AWSCalls) ([]session.SessionOption, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	opts := []session.SessionOption{}
+
+	timeouts := &session.Timeouts{Services: map[string]time.Duration{}}
+	if config.Timeout != "" {
+		d, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse aws call timeout %s", config.Timeout)
+		}
+		timeouts.Default = d
+	}
+
+	for service, timeout := range config.ServiceTimeouts {
+		d, err := time.ParseDuration(timeout)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse aws call timeout %s for %s", timeout, service)
+		}
+		timeouts.Services[service] = d
+	}
+
+	if timeouts.Default > 0 || len(timeouts.Services) > 0 {
+		opts = append(opts, session.WithTimeouts(timeouts))
+	}
+
+	if b := config.CircuitBreaker; b != nil {
+		if b.Threshold < 0 || b.Threshold > 1 {
+			return nil, errors.Errorf("invalid circuit breaker threshold %g, it must be between 0 and 1", b.Threshold)
+		}
+
+		breakerConfig := circuit.Config{
+			Threshold:   b.Threshold,
+			MinRequests: b.MinRequests,
+		}
+
+		if b.Window != "" {
+			d, err := time.ParseDuration(b.Window)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse circuit breaker window %s", b.Window)
+			}
+			breakerConfig.Window = d
+		}
+
+		if b.Cooldown != "" {
+			d, err := time.ParseDuration(b.Cooldown)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse circuit breaker cooldown %s", b.Cooldown)
+			}
+			breakerConfig.Cooldown = d
+		}
+
+		opts = append(opts, session.WithBreakers(circuit.NewBreakers(breakerConfig)))
+	}
+
+	return opts, nil
+}
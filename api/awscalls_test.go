@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/circuit"
+	"github.com/YaleSpinup/s3-api/common"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/YaleSpinup/s3-api/session"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/pkg/errors"
+)
+
+func TestNewAWSCallOptions(t *testing.T) {
+	opts, err := newAWSCallOptions(nil)
+	if err != nil || opts != nil {
+		t.Errorf("expected no options without configuration, got %v, %v", opts, err)
+	}
+
+	opts, err = newAWSCallOptions(&common.AWSCalls{
+		Timeout:         "30s",
+		ServiceTimeouts: map[string]string{"cloudfront": "1m"},
+		CircuitBreaker:  &common.CircuitBreaker{Threshold: 0.5, Window: "1m", Cooldown: "30s"},
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if len(opts) != 2 {
+		t.Errorf("expected timeout and breaker options, got %d options", len(opts))
+	}
+
+	for _, c := range []*common.AWSCalls{
+		{Timeout: "thirty"},
+		{ServiceTimeouts: map[string]string{"iam": "soon"}},
+		{CircuitBreaker: &common.CircuitBreaker{Threshold: 1.5}},
+		{CircuitBreaker: &common.CircuitBreaker{Window: "minute"}},
+		{CircuitBreaker: &common.CircuitBreaker{Cooldown: "-"}},
+	} {
+		if _, err := newAWSCallOptions(c); err == nil {
+			t.Errorf("expected error for %+v", c)
+		}
+	}
+}
+
+// newTestIAMService creates an iam service with the call options, calling the test server
+func newTestIAMService(url string, opts ...session.SessionOption) iamapi.IAM {
+	sess := session.New(append([]session.SessionOption{
+		session.WithCredentials("key", "secret", ""),
+		session.WithRegion("us-east-1"),
+	}, opts...)...)
+
+	return iamapi.IAM{Service: iam.New(sess.Session, aws.NewConfig().WithEndpoint(url).WithMaxRetries(0))}
+}
+
+func TestAWSCallBreaker(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	breakers := circuit.NewBreakers(circuit.Config{MinRequests: 2, Cooldown: time.Minute})
+	iamService := newTestIAMService(ts.URL, session.WithBreakers(breakers))
+
+	for i := 0; i < 3; i++ {
+		_, err := iamService.GetUser(context.TODO(), &iam.GetUserInput{UserName: aws.String("foo")})
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+
+		if i < 2 {
+			continue
+		}
+
+		var open *circuit.OpenError
+		if !errors.As(err, &open) {
+			t.Fatalf("expected open breaker error after the failures, got %s", err)
+		}
+
+		w := httptest.NewRecorder()
+		handleError(w, err)
+		if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "60" {
+			t.Errorf("expected 503 with Retry-After 60, got %d with Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+		}
+	}
+
+	if calls != 2 {
+		t.Errorf("expected 2 calls to the service before the breaker opened, got %d", calls)
+	}
+}
+
+func TestAWSCallTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	iamService := newTestIAMService(ts.URL, session.WithTimeouts(&session.Timeouts{Services: map[string]time.Duration{"iam": 20 * time.Millisecond}}))
+
+	_, err := iamService.GetUser(context.TODO(), &iam.GetUserInput{UserName: aws.String("foo")})
+	if !awsCallTimedOut(err) {
+		t.Fatalf("expected the call to time out, got %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	handleError(w, err)
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("expected status %d, got %d", http.StatusGatewayTimeout, w.Code)
+	}
+
+	if awsCallTimedOut(apierror.New(apierror.ErrBadRequest, "bad", nil)) {
+		t.Error("expected a bad request not to be a timeout")
+	}
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"time"
+
+	"github.com/YaleSpinup/s3-api/cloudfront"
+	"github.com/patrickmn/go-cache"
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultResourceCacheTTL is how long resource lookups are cached when the ttl isn't configured
+const defaultResourceCacheTTL = 1 * time.Minute
+
+// resourceCacheTTL parses the configured resource cache ttl, falling back to the default
+func resourceCacheTTL(ttl string) (time.Duration, error) {
+	if ttl == "" {
+		return defaultResourceCacheTTL, nil
+	}
+
+	return time.ParseDuration(ttl)
+}
+
+// distributionIndex returns the index of cloudfront distributions by alias for an account, creating it if it
+// doesn't exist.  It returns nil if caching is disabled.
+func (s *server) distributionIndex(accountId string) *cloudfront.DistributionIndex {
+	if s.resourceCacheTTL <= 0 {
+		return nil
+	}
+
+	s.resourceCachesMu.Lock()
+	defer s.resourceCachesMu.Unlock()
+
+	if s.distributionIndexes == nil {
+		s.distributionIndexes = map[string]*cloudfront.DistributionIndex{}
+	}
+
+	i, ok := s.distributionIndexes[accountId]
+	if !ok {
+		log.Debugf("creating cloudfront distribution index for account %s with max age %s", accountId, s.resourceCacheTTL)
+		i = cloudfront.NewDistributionIndex(s.resourceCacheTTL)
+		s.distributionIndexes[accountId] = i
+	}
+
+	return i
+}
+
+// resourceCache returns the cache of IAM and cloudfront lookups for an account, creating it if it
+// doesn't exist.  It returns nil if caching is disabled.
+func (s *server) resourceCache(accountId string) *cache.Cache {
+	if s.resourceCacheTTL <= 0 {
+		return nil
+	}
+
+	s.resourceCachesMu.Lock()
+	defer s.resourceCachesMu.Unlock()
+
+	if s.resourceCaches == nil {
+		s.resourceCaches = map[string]*cache.Cache{}
+	}
+
+	c, ok := s.resourceCaches[accountId]
+	if !ok {
+		log.Debugf("creating resource cache for account %s with ttl %s", accountId, s.resourceCacheTTL)
+		c = cache.New(s.resourceCacheTTL, 2*s.resourceCacheTTL)
+		s.resourceCaches[accountId] = c
+	}
+
+	return c
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResourceCacheTTL(t *testing.T) {
+	tests := []struct {
+		input  string
+		output time.Duration
+		err    bool
+	}{
+		{"", defaultResourceCacheTTL, false},
+		{"5m", 5 * time.Minute, false},
+		{"0s", 0, false},
+		{"foo", 0, true},
+	}
+
+	for _, test := range tests {
+		out, err := resourceCacheTTL(test.input)
+		if test.err && err == nil {
+			t.Errorf("expected error for %q, got nil", test.input)
+		} else if !test.err && err != nil {
+			t.Errorf("expected nil error for %q, got %s", test.input, err)
+		}
+
+		if out != test.output {
+			t.Errorf("expected %s for %q, got %s", test.output, test.input, out)
+		}
+	}
+}
+
+func TestResourceCache(t *testing.T) {
+	s := server{}
+	if c := s.resourceCache("12345678910"); c != nil {
+		t.Errorf("expected nil cache when caching is disabled, got %+v", c)
+	}
+
+	s.resourceCacheTTL = 1 * time.Minute
+	c1 := s.resourceCache("12345678910")
+	if c1 == nil {
+		t.Fatal("expected cache, got nil")
+	}
+
+	if c := s.resourceCache("12345678910"); c != c1 {
+		t.Error("expected the same cache for the same account")
+	}
+
+	if c := s.resourceCache("01987654321"); c == c1 {
+		t.Error("expected a different cache for a different account")
+	}
+}
+
+func TestDistributionIndex(t *testing.T) {
+	s := server{}
+	if i := s.distributionIndex("12345678910"); i != nil {
+		t.Errorf("expected nil index when caching is disabled, got %+v", i)
+	}
+
+	s.resourceCacheTTL = 1 * time.Minute
+	i1 := s.distributionIndex("12345678910")
+	if i1 == nil {
+		t.Fatal("expected index, got nil")
+	}
+
+	if i := s.distributionIndex("12345678910"); i != i1 {
+		t.Error("expected the same index for the same account")
+	}
+
+	if i := s.distributionIndex("01987654321"); i == i1 {
+		t.Error("expected a different index for a different account")
+	}
+}
@@ -1,31 +1,35 @@
 package api
 
 import (
+	"context"
 	"math/rand"
 	"time"
 
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	"github.com/YaleSpinup/s3-api/retry"
+	s3api "github.com/YaleSpinup/s3-api/s3"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/cloudfront"
 	log "github.com/sirupsen/logrus"
 )
 
-// cleanerInterval generates the cleaner interval from the baseInterval and a max splay
-func cleanerInterval(baseInterval, maxSplay string) (*time.Duration, error) {
+// splayInterval generates the interval for a periodic task from the baseInterval and a max splay
+func splayInterval(task, baseInterval, maxSplay string) (*time.Duration, error) {
 	base, err := time.ParseDuration(baseInterval)
 	if err != nil {
 		return nil, err
 	}
-	log.Debugf("cleaner: parsed base interval (%s) of %f seconds", baseInterval, base.Seconds())
+	log.Debugf("%s: parsed base interval (%s) of %f seconds", task, baseInterval, base.Seconds())
 
 	maxs, err := time.ParseDuration(maxSplay)
 	if err != nil {
 		return nil, err
 	}
-	log.Debugf("cleaner: parsed max splay interval (%s) of %f seconds", maxSplay, maxs.Seconds())
+	log.Debugf("%s: parsed max splay interval (%s) of %f seconds", task, maxSplay, maxs.Seconds())
 
 	r := rand.Int63n(int64(maxs))
 	interval := base + time.Duration(r)
-	log.Infof("cleaner: starting cleaner with interval of %fs", interval.Seconds())
+	log.Infof("%s: starting %s with interval of %fs", task, task, interval.Seconds())
 
 	return &interval, nil
 }
@@ -60,11 +64,27 @@ func (c *cleaner) run() {
 // 3. delete if orphaned
 func (c *cleaner) action() error {
 	log.Debugf("cleaner: starting cleanup action for account %s", c.account)
-	distributions, err := c.cloudFrontService.ListDistributionsWithFilter(c.context, func(dist *cloudfront.DistributionSummary) bool {
+
+	if c.server.inMaintenance(c.context) {
+		log.Infof("cleaner: skipping the cleanup for account %s in read-only maintenance mode", c.account)
+		return nil
+	}
+
+	accountId := c.server.mapAccountNumber(c.account)
+	session, err := c.server.sessionForAccount(c.context, c.account, "s3:ListBucket", "cloudfront:*")
+	if err != nil {
+		return err
+	}
+
+	s3Service := s3api.NewSession(session.Session, c.server.account, c.server.mapToAccountName(accountId))
+	cloudFrontService := cfapi.NewSession(session.Session, c.server.account, accountId)
+	cloudFrontService.Index = c.server.distributionIndex(accountId)
+
+	distributions, err := cloudFrontService.ListDistributionsWithFilter(c.context, func(dist *cloudfront.DistributionSummary) bool {
 		if aws.StringValue(dist.Status) == "Deployed" && !aws.BoolValue(dist.Enabled) {
 			log.Debugf("cleaner: distribution %s (%s) is deployed but disabled", aws.StringValue(dist.DomainName), aws.StringValue(dist.Comment))
 
-			tags, err := c.cloudFrontService.ListTags(c.context, aws.StringValue(dist.ARN))
+			tags, err := cloudFrontService.ListTags(c.context, aws.StringValue(dist.ARN))
 			if err != nil {
 				log.Errorf("cleaner: failed to list tags for resource %s: %s", aws.StringValue(dist.ARN), err)
 				return false
@@ -88,7 +108,7 @@ func (c *cleaner) action() error {
 	}
 
 	for _, dist := range distributions {
-		exists, err := c.s3Service.BucketExists(c.context, aws.StringValue(dist.DefaultCacheBehavior.TargetOriginId))
+		exists, err := s3Service.BucketExists(c.context, aws.StringValue(dist.DefaultCacheBehavior.TargetOriginId))
 		if err != nil {
 			return err
 		}
@@ -97,7 +117,9 @@ func (c *cleaner) action() error {
 			id := aws.StringValue(dist.Id)
 			origin := aws.StringValue(dist.DefaultCacheBehavior.TargetOriginId)
 			log.Infof("cleaner: cloudfront distribution (%s) is deployed, disabled. bucket %s doesn't exist. deleting.", id, origin)
-			if err := c.cloudFrontService.DeleteDistribution(c.context, id); err != nil {
+			if err := retry.Do(c.context, cloudFrontRetry, func(ctx context.Context) error {
+				return cloudFrontService.DeleteDistribution(ctx, id)
+			}); err != nil {
 				return err
 			}
 		}
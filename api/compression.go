@@ -0,0 +1,227 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/YaleSpinup/s3-api/common"
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultCompressionMinSize is the size of the smallest response that's compressed if it isn't configured
+const defaultCompressionMinSize = 1024
+
+// compressibleTypes are the content types of the responses that are compressed, the api's json and text responses
+var compressibleTypes = []string{
+	"application/json",
+	"application/problem+json",
+	"application/javascript",
+	"application/xml",
+	"application/x-yaml",
+	"text/",
+}
+
+// compressor gzips the responses larger than the minimum size for the clients that accept gzip.  The gzip writers
+// are pooled since they're expensive to allocate.
+type compressor struct {
+	minSize int
+	level   int
+	pool    sync.Pool
+}
+
+// newCompressor creates the response compressor from the configuration, it's nil if compression isn't configured
+func newCompressor(config *common.Compression) (*compressor, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	c := &compressor{
+		minSize: defaultCompressionMinSize,
+		level:   gzip.DefaultCompression,
+	}
+
+	if config.MinSize < 0 {
+		return nil, fmt.Errorf("compression min size %d cannot be negative", config.MinSize)
+	}
+
+	if config.MinSize > 0 {
+		c.minSize = config.MinSize
+	}
+
+	if config.Level != 0 {
+		if config.Level < gzip.BestSpeed || config.Level > gzip.BestCompression {
+			return nil, fmt.Errorf("compression level %d must be between %d and %d", config.Level, gzip.BestSpeed, gzip.BestCompression)
+		}
+		c.level = config.Level
+	}
+
+	c.pool.New = func() interface{} {
+		// the level is validated above so the writer can't fail to be created
+		gz, _ := gzip.NewWriterLevel(nil, c.level)
+		return gz
+	}
+
+	return c, nil
+}
+
+// acceptsGzip returns true if the Accept-Encoding header of a request accepts gzip, explicitly or with *
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+
+		// a coding with a zero quality isn't acceptable
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// compressible returns true if responses with the content type are compressed
+func compressible(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	for _, t := range compressibleTypes {
+		if strings.HasPrefix(contentType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// gzipResponseWriter buffers the start of a response until it's known if it's large enough to be compressed.  Once
+// the buffer reaches the minimum size the response is compressed, if the handler finishes first it's written as is.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	c       *compressor
+	status  int
+	buf     bytes.Buffer
+	gz      *gzip.Writer
+	decided bool
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, so an http.ResponseController can reach it
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// WriteHeader keeps the status until the response is written
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+// Write buffers the response until it reaches the minimum size, then starts compressing it
+func (w *gzipResponseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	if !w.decided {
+		w.buf.Write(p)
+		if w.buf.Len() < w.c.minSize {
+			return len(p), nil
+		}
+
+		if err := w.start(true); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	if w.gz != nil {
+		return w.gz.Write(p)
+	}
+
+	return w.ResponseWriter.Write(p)
+}
+
+// start writes the header and the buffered response, compressed if it's large enough and the content type and
+// status allow it
+func (w *gzipResponseWriter) start(large bool) error {
+	w.decided = true
+
+	h := w.Header()
+	if h.Get("Content-Type") == "" && w.buf.Len() > 0 {
+		h.Set("Content-Type", http.DetectContentType(w.buf.Bytes()))
+	}
+
+	if large && h.Get("Content-Encoding") == "" && compressible(h.Get("Content-Type")) &&
+		w.status != http.StatusNoContent && w.status != http.StatusNotModified {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+
+		w.gz = w.c.pool.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+
+		w.ResponseWriter.WriteHeader(w.status)
+		_, err := w.gz.Write(w.buf.Bytes())
+		return err
+	}
+
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+
+	if w.buf.Len() == 0 {
+		return nil
+	}
+
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	return err
+}
+
+// close writes out a response that's too small to compress, or finishes the compressed response
+func (w *gzipResponseWriter) close() {
+	if !w.decided {
+		if err := w.start(false); err != nil {
+			log.Errorf("failed to write response: %s", err)
+		}
+		return
+	}
+
+	if w.gz != nil {
+		if err := w.gz.Close(); err != nil {
+			log.Errorf("failed to finish compressed response: %s", err)
+		}
+		w.c.pool.Put(w.gz)
+		w.gz = nil
+	}
+}
+
+// middleware compresses the responses of the requests that accept gzip.  HEAD requests and the responses that are
+// already encoded, ie. the metrics, are passed through.  The requests are passed to the handler unchanged if
+// compression isn't configured.
+func (c *compressor) middleware(h http.Handler) http.Handler {
+	if c == nil {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		if r.Method == http.MethodHead || !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w, c: c}
+		defer gw.close()
+
+		h.ServeHTTP(gw, r)
+	})
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/YaleSpinup/s3-api/common"
+)
+
+func TestNewCompressor(t *testing.T) {
+	if c, err := newCompressor(nil); err != nil || c != nil {
+		t.Errorf("expected nil compressor and error without config, got %+v, %v", c, err)
+	}
+
+	c, err := newCompressor(&common.Compression{})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if c.minSize != defaultCompressionMinSize || c.level != gzip.DefaultCompression {
+		t.Errorf("expected the default min size and level, got %d and %d", c.minSize, c.level)
+	}
+
+	for _, config := range []*common.Compression{{MinSize: -1}, {Level: 10}, {Level: -2}} {
+		if _, err := newCompressor(config); err == nil {
+			t.Errorf("expected error for %+v, got nil", config)
+		}
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := map[string]bool{
+		"":                          false,
+		"gzip":                      true,
+		"deflate, gzip;q=1.0, br":   true,
+		"GZIP":                      true,
+		"*":                         true,
+		"br":                        false,
+		"gzip;q=0":                  false,
+		"identity, gzip; q=0.5":     true,
+		"deflate, gzip;q=0, *;q=1":  false,
+		"x-gzip":                    false,
+		"compress, deflate;q=0.5":   false,
+		"gzip ; q=0.000, identity ": false,
+	}
+
+	for header, expected := range tests {
+		if out := acceptsGzip(header); out != expected {
+			t.Errorf("%q: expected %t, got %t", header, expected, out)
+		}
+	}
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	c, err := newCompressor(&common.Compression{MinSize: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	large := `{"Buckets":["` + strings.Repeat("bucket", 100) + `"]}`
+
+	tests := []struct {
+		name        string
+		method      string
+		accept      string
+		contentType string
+		encoding    string
+		status      int
+		body        string
+		compressed  bool
+	}{
+		{name: "large json", method: http.MethodGet, accept: "gzip", contentType: "application/json", status: http.StatusOK, body: large, compressed: true},
+		{name: "large error", method: http.MethodGet, accept: "gzip", status: http.StatusBadRequest, body: strings.Repeat("bad request ", 20), compressed: true},
+		{name: "small json", method: http.MethodGet, accept: "gzip", contentType: "application/json", status: http.StatusOK, body: `{"Name":"bucket"}`},
+		{name: "not accepted", method: http.MethodGet, contentType: "application/json", status: http.StatusOK, body: large},
+		{name: "head", method: http.MethodHead, accept: "gzip", contentType: "application/json", status: http.StatusOK},
+		{name: "binary", method: http.MethodGet, accept: "gzip", contentType: "application/zip", status: http.StatusOK, body: large},
+		{name: "already encoded", method: http.MethodGet, accept: "gzip", contentType: "text/plain", encoding: "gzip", status: http.StatusOK, body: large},
+		{name: "no content", method: http.MethodDelete, accept: "gzip", status: http.StatusNoContent},
+		{name: "empty", method: http.MethodPut, accept: "gzip", contentType: "application/json", status: http.StatusOK},
+	}
+
+	for _, test := range tests {
+		h := c.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if test.contentType != "" {
+				w.Header().Set("Content-Type", test.contentType)
+			}
+			if test.encoding != "" {
+				w.Header().Set("Content-Encoding", test.encoding)
+			}
+			w.WriteHeader(test.status)
+
+			// the body is written in pieces to check the buffering
+			for i := 0; i < len(test.body); i += 30 {
+				end := i + 30
+				if end > len(test.body) {
+					end = len(test.body)
+				}
+				w.Write([]byte(test.body[i:end]))
+			}
+		}))
+
+		req := httptest.NewRequest(test.method, "/v1/s3/spinup/buckets", nil)
+		if test.accept != "" {
+			req.Header.Set("Accept-Encoding", test.accept)
+		}
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if rr.Code != test.status {
+			t.Errorf("%s: expected status %d, got %d", test.name, test.status, rr.Code)
+		}
+
+		if rr.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("%s: expected Vary Accept-Encoding, got %q", test.name, rr.Header().Get("Vary"))
+		}
+
+		body := rr.Body.Bytes()
+		if test.compressed {
+			if rr.Header().Get("Content-Encoding") != "gzip" {
+				t.Errorf("%s: expected gzip content encoding, got %q", test.name, rr.Header().Get("Content-Encoding"))
+				continue
+			}
+
+			gz, err := gzip.NewReader(bytes.NewReader(body))
+			if err != nil {
+				t.Errorf("%s: failed to read compressed body: %s", test.name, err)
+				continue
+			}
+
+			if body, err = io.ReadAll(gz); err != nil {
+				t.Errorf("%s: failed to decompress body: %s", test.name, err)
+				continue
+			}
+		} else if rr.Header().Get("Content-Encoding") != test.encoding {
+			t.Errorf("%s: expected content encoding %q, got %q", test.name, test.encoding, rr.Header().Get("Content-Encoding"))
+		}
+
+		if string(body) != test.body {
+			t.Errorf("%s: expected body %q, got %q", test.name, test.body, string(body))
+		}
+	}
+
+	// the writers are reused across responses
+	for i := 0; i < 3; i++ {
+		h := c.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(large))
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/s3/spinup/buckets", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		gz, err := gzip.NewReader(rr.Body)
+		if err != nil {
+			t.Fatalf("failed to read compressed body: %s", err)
+		}
+
+		if body, err := io.ReadAll(gz); err != nil || string(body) != large {
+			t.Errorf("expected the large body from a reused writer, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/YaleSpinup/apierror"
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// errPreconditionFailed is returned when the If-Match or If-None-Match header of an update doesn't match the
+	// current entity tag of the resource, ie. because it was changed by someone else
+	errPreconditionFailed = "PreconditionFailed"
+	// errPreconditionRequired is returned when an update is missing the If-Match header and it's required
+	errPreconditionRequired = "PreconditionRequired"
+)
+
+// responseETagRe matches the entity tag of a read endpoint's response that has the version of the resource as its
+// prefix, ie. "E2QWRUHAPOMQZL-0123456789abcdef"
+var responseETagRe = regexp.MustCompile(`^"(.+)-[0-9a-f]{16}"$`)
+
+// contentETag returns a strong entity tag for the content
+func contentETag(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return quoteETag(hex.EncodeToString(sum[:16]))
+}
+
+// quoteETag quotes an entity tag, aws returns some of them without the quotes
+func quoteETag(etag string) string {
+	if strings.HasPrefix(etag, `"`) {
+		return etag
+	}
+	return `"` + etag + `"`
+}
+
+// etagMatches returns true if the header, * or a list of entity tags, matches the entity tag.  Weak entity tags
+// only match when weak is true, If-Match uses the strong comparison and If-None-Match uses the weak comparison.
+func etagMatches(header, etag string, weak bool) bool {
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" {
+			return true
+		}
+
+		if strings.HasPrefix(t, "W/") {
+			if !weak {
+				continue
+			}
+			t = strings.TrimPrefix(t, "W/")
+		}
+
+		if t == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// responseETag returns the entity tag of a read endpoint's response, a hash of the serialized response.  If the
+// resource has a version entity tag, the one passed in If-Match to update it, it's kept as the prefix so the response
+// entity tag can be passed in If-Match too.
+func responseETag(version string, body []byte) string {
+	sum := sha256.Sum256(body)
+	hash := hex.EncodeToString(sum[:8])
+
+	if version == "" {
+		return quoteETag(hash)
+	}
+
+	return quoteETag(strings.Trim(version, `"`) + "-" + hash)
+}
+
+// versionETags replaces the response entity tags in an If-Match or If-None-Match header with the version entity tags
+// they start with, the other entity tags are left as is
+func versionETags(header string) string {
+	tags := strings.Split(header, ",")
+	for i, t := range tags {
+		t = strings.TrimSpace(t)
+		weak := strings.HasPrefix(t, "W/")
+		if m := responseETagRe.FindStringSubmatch(strings.TrimPrefix(t, "W/")); m != nil {
+			t = `"` + m[1] + `"`
+			if weak {
+				t = "W/" + t
+			}
+		}
+		tags[i] = t
+	}
+
+	return strings.Join(tags, ", ")
+}
+
+// writeConditionalJSON writes the json response of a read endpoint with its entity tag, or 304 Not Modified without
+// the response if the request's If-None-Match matches it, so polling clients don't download the same response again.
+// The entity tag set by the handler, the version of the resource for updates, is kept as the prefix of the response
+// entity tag.
+func writeConditionalJSON(w http.ResponseWriter, r *http.Request, output interface{}) {
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response (%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	etag := responseETag(w.Header().Get("ETag"), j)
+	w.Header().Set("ETag", etag)
+
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" && etagMatches(ifNoneMatch, etag, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// hasPreconditions returns true if the request has an If-Match or If-None-Match header
+func hasPreconditions(r *http.Request) bool {
+	return r.Header.Get("If-Match") != "" || r.Header.Get("If-None-Match") != ""
+}
+
+// checkPreconditions checks the If-Match and If-None-Match headers of an update against the current entity tag of
+// the resource.  The update can go ahead if there aren't any headers, unless If-Match is required by the config.  The
+// entity tag of a response from getting the resource matches if the version it starts with does.
+func (s *server) checkPreconditions(r *http.Request, etag string) error {
+	ifMatch := versionETags(r.Header.Get("If-Match"))
+	ifNoneMatch := versionETags(r.Header.Get("If-None-Match"))
+
+	if ifMatch == "" && ifNoneMatch == "" && s.requireIfMatch {
+		return apierror.New(errPreconditionRequired, "If-Match header with the current ETag is required", nil)
+	}
+
+	if ifMatch != "" && !etagMatches(ifMatch, etag, false) {
+		return apierror.New(errPreconditionFailed, "resource has changed, current ETag is "+etag, nil)
+	}
+
+	if ifNoneMatch != "" && etagMatches(ifNoneMatch, etag, true) {
+		return apierror.New(errPreconditionFailed, "resource matches If-None-Match "+ifNoneMatch, nil)
+	}
+
+	return nil
+}
+
+// bucketPolicyETag returns the entity tag of a bucket's policy, a hash of the policy document
+func bucketPolicyETag(ctx context.Context, s3Service s3api.S3, bucket string) (string, error) {
+	policy, err := s3Service.GetBucketPolicy(ctx, bucket)
+	if err != nil {
+		return "", err
+	}
+
+	return contentETag(policy), nil
+}
+
+// websiteETag returns the entity tag of a website, the ETag of its cloudfront distribution config
+func websiteETag(ctx context.Context, cloudFrontService cfapi.CloudFront, distribution *cloudfront.DistributionSummary) (string, error) {
+	if distribution == nil {
+		return "", apierror.New(apierror.ErrNotFound, "website distribution not found", nil)
+	}
+
+	etag, err := cloudFrontService.GetDistributionETag(ctx, aws.StringValue(distribution.Id))
+	if err != nil {
+		return "", err
+	}
+
+	return quoteETag(etag), nil
+}
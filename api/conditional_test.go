@@ -0,0 +1,204 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+)
+
+func TestContentETag(t *testing.T) {
+	a := contentETag(`{"Version":"2012-10-17","Statement":[]}`)
+	if a != contentETag(`{"Version":"2012-10-17","Statement":[]}`) {
+		t.Error("expected the same etag for the same content")
+	}
+
+	if a == contentETag("") {
+		t.Error("expected a different etag for different content")
+	}
+
+	if len(a) != 34 || a[0] != '"' || a[33] != '"' {
+		t.Errorf("expected a quoted 32 character etag, got %s", a)
+	}
+}
+
+func TestResponseETag(t *testing.T) {
+	body := []byte(`["foobucket","barbucket"]`)
+
+	a := responseETag("", body)
+	if !regexp.MustCompile(`^"[0-9a-f]{16}"$`).MatchString(a) {
+		t.Errorf("expected a quoted 16 character etag, got %s", a)
+	}
+
+	if a == responseETag("", []byte(`["foobucket"]`)) {
+		t.Error("expected a different etag for a different response")
+	}
+
+	v := responseETag(`"E2QWRUHAPOMQZL"`, body)
+	if v != `"E2QWRUHAPOMQZL-`+a[1:] {
+		t.Errorf("expected the version as the prefix of the etag, got %s", v)
+	}
+}
+
+func TestVersionETags(t *testing.T) {
+	tests := map[string]string{
+		"":                                     "",
+		`*`:                                    `*`,
+		`"abc"`:                                `"abc"`,
+		`"abc-0123456789abcdef"`:               `"abc"`,
+		`W/"abc-0123456789abcdef"`:             `W/"abc"`,
+		`"xyz","abc-0123456789abcdef"`:         `"xyz", "abc"`,
+		`"0123456789abcdef"`:                   `"0123456789abcdef"`,
+		`"abc-0123456789ABCDEF"`:               `"abc-0123456789ABCDEF"`,
+		`"a-b-c-0123456789abcdef", "fedcba98"`: `"a-b-c", "fedcba98"`,
+	}
+
+	for header, expected := range tests {
+		if out := versionETags(header); out != expected {
+			t.Errorf("%q: expected %q, got %q", header, expected, out)
+		}
+	}
+}
+
+func TestWriteConditionalJSON(t *testing.T) {
+	output := []string{"foobucket", "barbucket"}
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/s3/foo/buckets", nil)
+	w := httptest.NewRecorder()
+	writeConditionalJSON(w, r, output)
+
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || w.Body.String() != `["foobucket","barbucket"]` || etag == "" {
+		t.Fatalf("expected the response with an etag, got %d %q %q", w.Code, w.Body.String(), etag)
+	}
+
+	if w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("expected json content type, got %s", w.Header().Get("Content-Type"))
+	}
+
+	tests := []struct {
+		ifNoneMatch string
+		status      int
+	}{
+		{ifNoneMatch: etag, status: http.StatusNotModified},
+		{ifNoneMatch: "W/" + etag, status: http.StatusNotModified},
+		{ifNoneMatch: `"xyz", ` + etag, status: http.StatusNotModified},
+		{ifNoneMatch: `*`, status: http.StatusNotModified},
+		{ifNoneMatch: `"xyz"`, status: http.StatusOK},
+	}
+
+	for _, tc := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/v1/s3/foo/buckets", nil)
+		r.Header.Set("If-None-Match", tc.ifNoneMatch)
+
+		w := httptest.NewRecorder()
+		writeConditionalJSON(w, r, output)
+
+		if w.Code != tc.status {
+			t.Errorf("%s: expected status %d, got %d", tc.ifNoneMatch, tc.status, w.Code)
+		}
+
+		if w.Header().Get("ETag") != etag {
+			t.Errorf("%s: expected etag %s, got %s", tc.ifNoneMatch, etag, w.Header().Get("ETag"))
+		}
+
+		if tc.status == http.StatusNotModified && w.Body.Len() != 0 {
+			t.Errorf("%s: expected no body, got %q", tc.ifNoneMatch, w.Body.String())
+		}
+	}
+
+	// the version set by the handler is kept so the etag can be used to update the resource
+	r = httptest.NewRequest(http.MethodGet, "/v1/s3/foo/buckets/foobucket", nil)
+	w = httptest.NewRecorder()
+	w.Header().Set("ETag", `"abc"`)
+	writeConditionalJSON(w, r, output)
+
+	etag = w.Header().Get("ETag")
+	if etag != responseETag(`"abc"`, []byte(`["foobucket","barbucket"]`)) {
+		t.Errorf("expected the versioned etag, got %s", etag)
+	}
+
+	s := server{requireIfMatch: true}
+	u := httptest.NewRequest(http.MethodPut, "/v1/s3/foo/buckets/foobucket", nil)
+	u.Header.Set("If-Match", etag)
+	if err := s.checkPreconditions(u, `"abc"`); err != nil {
+		t.Errorf("expected the response etag to match the version, got %s", err)
+	}
+
+	if err := s.checkPreconditions(u, `"xyz"`); err == nil {
+		t.Error("expected the response etag not to match another version, got nil")
+	}
+}
+
+func TestEtagMatches(t *testing.T) {
+	tests := []struct {
+		header string
+		weak   bool
+		match  bool
+	}{
+		{header: `"abc"`, match: true},
+		{header: `"xyz", "abc"`, match: true},
+		{header: `*`, match: true},
+		{header: `"xyz"`, match: false},
+		{header: `abc`, match: false},
+		{header: `W/"abc"`, match: false},
+		{header: `W/"abc"`, weak: true, match: true},
+	}
+
+	for _, tc := range tests {
+		if out := etagMatches(tc.header, `"abc"`, tc.weak); out != tc.match {
+			t.Errorf("%s (weak %t): expected %t, got %t", tc.header, tc.weak, tc.match, out)
+		}
+	}
+}
+
+func TestCheckPreconditions(t *testing.T) {
+	tests := []struct {
+		ifMatch     string
+		ifNoneMatch string
+		required    bool
+		code        string
+	}{
+		{},
+		{required: true, code: errPreconditionRequired},
+		{ifMatch: `"abc"`, required: true},
+		{ifMatch: `"xyz"`, code: errPreconditionFailed},
+		{ifNoneMatch: `"xyz"`},
+		{ifNoneMatch: `*`, code: errPreconditionFailed},
+	}
+
+	for _, tc := range tests {
+		s := server{requireIfMatch: tc.required}
+
+		r := httptest.NewRequest(http.MethodPut, "/v1/s3/foo/buckets/foobucket", nil)
+		if tc.ifMatch != "" {
+			r.Header.Set("If-Match", tc.ifMatch)
+		}
+		if tc.ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", tc.ifNoneMatch)
+		}
+
+		err := s.checkPreconditions(r, `"abc"`)
+		if tc.code == "" {
+			if err != nil {
+				t.Errorf("%+v: expected nil error, got %s", tc, err)
+			}
+			continue
+		}
+
+		if aerr, ok := err.(apierror.Error); !ok || aerr.Code != tc.code {
+			t.Errorf("%+v: expected error code %s, got %v", tc, tc.code, err)
+		}
+	}
+
+	// the precondition errors are returned with their http status
+	for code, status := range map[string]int{errPreconditionFailed: http.StatusPreconditionFailed, errPreconditionRequired: http.StatusPreconditionRequired} {
+		w := httptest.NewRecorder()
+		handleError(w, apierror.New(code, "test", nil))
+		if w.Code != status {
+			t.Errorf("expected status %d for %s, got %d", status, code, w.Code)
+		}
+	}
+}
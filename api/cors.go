@@ -0,0 +1,199 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// defaultCORSMethods are the methods cross-origin requests can use if they aren't configured
+	defaultCORSMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	// defaultCORSHeaders are the request headers cross-origin requests can send if they aren't configured, the headers
+	// read by the api
+	defaultCORSHeaders = []string{
+		"Content-Type",
+		"X-Auth-Token",
+		"X-Request-Id",
+		"If-Match",
+		"If-None-Match",
+		idempotencyKeyHeader,
+		protectionOverrideHeader,
+		adminTokenHeader,
+		signedClientHeader,
+		signedTimestampHeader,
+		signatureHeader,
+	}
+	// defaultCORSExposedHeaders are the response headers cross-origin requests can read if they aren't configured,
+	// the headers set by the api
+	defaultCORSExposedHeaders = []string{"ETag", "Retry-After", "X-Request-Id", "Content-Disposition", idempotentReplayedHeader}
+)
+
+// corsPolicy answers the cross-origin preflight requests and adds the CORS headers to the responses for the allowed
+// origins, so browser based tools can call the api directly
+type corsPolicy struct {
+	origins map[string]bool
+	any     bool
+	methods []string
+	headers []string
+	exposed []string
+	maxAge  time.Duration
+}
+
+// newCORSPolicy creates the CORS policy from the configuration, it's nil if CORS isn't configured
+func newCORSPolicy(config *common.CORS) (*corsPolicy, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	if len(config.AllowedOrigins) == 0 {
+		return nil, errors.New("cors allowed origins cannot be empty")
+	}
+
+	c := &corsPolicy{
+		origins: map[string]bool{},
+		methods: defaultCORSMethods,
+		headers: defaultCORSHeaders,
+		exposed: defaultCORSExposedHeaders,
+	}
+
+	for _, o := range config.AllowedOrigins {
+		if o == "*" {
+			c.any = true
+			continue
+		}
+		c.origins[strings.TrimSuffix(o, "/")] = true
+	}
+
+	if len(config.AllowedMethods) > 0 {
+		c.methods = []string{}
+		for _, m := range config.AllowedMethods {
+			c.methods = append(c.methods, strings.ToUpper(m))
+		}
+	}
+
+	if len(config.AllowedHeaders) > 0 {
+		c.headers = config.AllowedHeaders
+	}
+
+	if len(config.ExposedHeaders) > 0 {
+		c.exposed = config.ExposedHeaders
+	}
+
+	if config.MaxAge != "" {
+		maxAge, err := time.ParseDuration(config.MaxAge)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse cors max age %s", config.MaxAge)
+		}
+		c.maxAge = maxAge
+	}
+
+	return c, nil
+}
+
+// allowedOrigin returns the value of the Access-Control-Allow-Origin header for the origin, it's empty if the origin
+// isn't allowed
+func (c *corsPolicy) allowedOrigin(origin string) string {
+	switch {
+	case origin == "":
+		return ""
+	case c.any:
+		return "*"
+	case c.origins[origin]:
+		return origin
+	}
+
+	return ""
+}
+
+// allowedHeaders returns true if all of the comma separated headers of a preflight request are allowed
+func (c *corsPolicy) allowedHeaders(requested string) bool {
+	for _, h := range strings.Split(requested, ",") {
+		h = strings.TrimSpace(h)
+		if h == "" {
+			continue
+		}
+
+		allowed := false
+		for _, a := range c.headers {
+			if strings.EqualFold(a, h) {
+				allowed = true
+				break
+			}
+		}
+
+		if !allowed {
+			return false
+		}
+	}
+
+	return true
+}
+
+// preflight answers a preflight request, it's forbidden if the origin, method or any of the headers aren't allowed
+func (c *corsPolicy) preflight(w http.ResponseWriter, r *http.Request, origin string) {
+	method := r.Header.Get("Access-Control-Request-Method")
+	requested := r.Header.Get("Access-Control-Request-Headers")
+
+	var reason string
+	switch {
+	case origin == "":
+		reason = fmt.Sprintf("origin %s is not allowed", r.Header.Get("Origin"))
+	case !contains(c.methods, strings.ToUpper(method)):
+		reason = fmt.Sprintf("method %s is not allowed", method)
+	case !c.allowedHeaders(requested):
+		reason = fmt.Sprintf("headers %s are not all allowed", requested)
+	}
+
+	if reason != "" {
+		log.Warnf("rejecting cors preflight request for %s: %s", r.URL.Path, reason)
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	w.Header().Set("Access-Control-Allow-Methods", strings.Join(c.methods, ", "))
+	w.Header().Set("Access-Control-Allow-Headers", strings.Join(c.headers, ", "))
+	if c.maxAge > 0 {
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(c.maxAge.Seconds())))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// middleware answers the preflight requests before they're authenticated and adds the CORS headers to the responses
+// for allowed origins, including the error responses so the browser can read them.  The requests are passed to the
+// handler unchanged if CORS isn't configured.
+func (c *corsPolicy) middleware(h http.Handler) http.Handler {
+	if c == nil {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the response depends on the origin unless every origin gets the same one
+		if !c.any {
+			w.Header().Add("Vary", "Origin")
+		}
+
+		origin := c.allowedOrigin(r.Header.Get("Origin"))
+
+		if r.Method == http.MethodOptions && r.Header.Get("Origin") != "" && r.Header.Get("Access-Control-Request-Method") != "" {
+			c.preflight(w, r, origin)
+			return
+		}
+
+		if origin != "" {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			if len(c.exposed) > 0 {
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(c.exposed, ", "))
+			}
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/YaleSpinup/s3-api/common"
+)
+
+func TestNewCORSPolicy(t *testing.T) {
+	if c, err := newCORSPolicy(nil); err != nil || c != nil {
+		t.Errorf("expected nil policy and error without config, got %+v, %v", c, err)
+	}
+
+	if _, err := newCORSPolicy(&common.CORS{}); err == nil {
+		t.Error("expected error without allowed origins, got nil")
+	}
+
+	if _, err := newCORSPolicy(&common.CORS{AllowedOrigins: []string{"*"}, MaxAge: "ten minutes"}); err == nil {
+		t.Error("expected error for invalid max age, got nil")
+	}
+
+	c, err := newCORSPolicy(&common.CORS{AllowedOrigins: []string{"https://admin.example.edu/"}, AllowedMethods: []string{"get", "post"}})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if !c.origins["https://admin.example.edu"] || c.any {
+		t.Errorf("expected only the admin origin to be allowed, got %+v", c)
+	}
+
+	if len(c.methods) != 2 || c.methods[0] != "GET" || c.methods[1] != "POST" {
+		t.Errorf("expected methods GET and POST, got %v", c.methods)
+	}
+
+	if len(c.headers) != len(defaultCORSHeaders) || len(c.exposed) != len(defaultCORSExposedHeaders) {
+		t.Errorf("expected the default headers, got %v and %v", c.headers, c.exposed)
+	}
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	c, err := newCORSPolicy(&common.CORS{
+		AllowedOrigins: []string{"https://admin.example.edu"},
+		AllowedMethods: []string{"GET", "POST", "DELETE"},
+		AllowedHeaders: []string{"X-Auth-Token", "Content-Type"},
+		ExposedHeaders: []string{"X-Request-Id"},
+		MaxAge:         "10m",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the handler stands in for the token middleware, it rejects anything without a token
+	called := false
+	h := c.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		if r.Header.Get("X-Auth-Token") == "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		status  int
+		called  bool
+		expect  map[string]string
+	}{
+		{
+			name:    "preflight",
+			method:  http.MethodOptions,
+			headers: map[string]string{"Origin": "https://admin.example.edu", "Access-Control-Request-Method": "DELETE", "Access-Control-Request-Headers": "x-auth-token, content-type"},
+			status:  http.StatusNoContent,
+			expect: map[string]string{
+				"Access-Control-Allow-Origin":  "https://admin.example.edu",
+				"Access-Control-Allow-Methods": "GET, POST, DELETE",
+				"Access-Control-Allow-Headers": "X-Auth-Token, Content-Type",
+				"Access-Control-Max-Age":       "600",
+				"Vary":                         "Origin",
+			},
+		},
+		{
+			name:    "preflight from another origin",
+			method:  http.MethodOptions,
+			headers: map[string]string{"Origin": "https://evil.example.com", "Access-Control-Request-Method": "GET"},
+			status:  http.StatusForbidden,
+			expect:  map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:    "preflight with a method that isn't allowed",
+			method:  http.MethodOptions,
+			headers: map[string]string{"Origin": "https://admin.example.edu", "Access-Control-Request-Method": "PUT"},
+			status:  http.StatusForbidden,
+		},
+		{
+			name:    "preflight with a header that isn't allowed",
+			method:  http.MethodOptions,
+			headers: map[string]string{"Origin": "https://admin.example.edu", "Access-Control-Request-Method": "GET", "Access-Control-Request-Headers": "X-Auth-Token, X-Admin-Token"},
+			status:  http.StatusForbidden,
+		},
+		{
+			name:    "request",
+			method:  http.MethodGet,
+			headers: map[string]string{"Origin": "https://admin.example.edu", "X-Auth-Token": "token"},
+			status:  http.StatusOK,
+			called:  true,
+			expect:  map[string]string{"Access-Control-Allow-Origin": "https://admin.example.edu", "Access-Control-Expose-Headers": "X-Request-Id"},
+		},
+		{
+			name:    "rejected request",
+			method:  http.MethodGet,
+			headers: map[string]string{"Origin": "https://admin.example.edu"},
+			status:  http.StatusForbidden,
+			called:  true,
+			expect:  map[string]string{"Access-Control-Allow-Origin": "https://admin.example.edu"},
+		},
+		{
+			name:    "request from another origin",
+			method:  http.MethodGet,
+			headers: map[string]string{"Origin": "https://evil.example.com", "X-Auth-Token": "token"},
+			status:  http.StatusOK,
+			called:  true,
+			expect:  map[string]string{"Access-Control-Allow-Origin": "", "Access-Control-Expose-Headers": ""},
+		},
+		{
+			name:   "options without an origin",
+			method: http.MethodOptions,
+			status: http.StatusForbidden,
+			called: true,
+		},
+	}
+
+	for _, test := range tests {
+		called = false
+
+		req := httptest.NewRequest(test.method, "/v1/s3/spinup/buckets", nil)
+		for k, v := range test.headers {
+			req.Header.Set(k, v)
+		}
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if rr.Code != test.status {
+			t.Errorf("%s: expected status %d, got %d", test.name, test.status, rr.Code)
+		}
+
+		if called != test.called {
+			t.Errorf("%s: expected handler called %t, got %t", test.name, test.called, called)
+		}
+
+		for k, v := range test.expect {
+			if got := rr.Header().Get(k); got != v {
+				t.Errorf("%s: expected header %s %q, got %q", test.name, k, v, got)
+			}
+		}
+	}
+
+	// every origin gets the same response with a wildcard
+	c, err = newCORSPolicy(&common.CORS{AllowedOrigins: []string{"*"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodOptions, "/v1/s3/spinup/buckets", nil)
+	req.Header.Set("Origin", "https://anywhere.example.org")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "Idempotency-Key")
+
+	rr := httptest.NewRecorder()
+	c.middleware(http.NotFoundHandler()).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNoContent || rr.Header().Get("Access-Control-Allow-Origin") != "*" || rr.Header().Get("Vary") != "" {
+		t.Errorf("expected wildcard preflight response, got %d %v", rr.Code, rr.Header())
+	}
+
+	// nothing changes if cors isn't configured
+	var none *corsPolicy
+	rr = httptest.NewRecorder()
+	none.middleware(http.NotFoundHandler()).ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotFound || rr.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("expected the request to be passed through, got %d %v", rr.Code, rr.Header())
+	}
+}
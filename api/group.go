@@ -0,0 +1,44 @@
+package api
+
+import (
+	"context"
+	"sync"
+)
+
+// errGroup runs a group of functions concurrently.  The first function to fail cancels the group
+// context and its error is returned from Wait.
+type errGroup struct {
+	wg     sync.WaitGroup
+	cancel context.CancelFunc
+	once   sync.Once
+	err    error
+}
+
+// newErrGroup returns a new errGroup and a context derived from ctx that's cancelled when a function
+// in the group fails or Wait returns
+func newErrGroup(ctx context.Context) (*errGroup, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &errGroup{cancel: cancel}, ctx
+}
+
+// Go runs the function in a new goroutine
+func (g *errGroup) Go(f func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+
+		if err := f(); err != nil {
+			g.once.Do(func() {
+				g.err = err
+				g.cancel()
+			})
+		}
+	}()
+}
+
+// Wait blocks until all of the functions have returned and returns the first error
+func (g *errGroup) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+	return g.err
+}
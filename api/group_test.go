@@ -0,0 +1,48 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestErrGroup(t *testing.T) {
+	g, _ := newErrGroup(context.TODO())
+
+	var count int32
+	for i := 0; i < 5; i++ {
+		g.Go(func() error {
+			atomic.AddInt32(&count, 1)
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	if count != 5 {
+		t.Errorf("expected 5 functions to run, got %d", count)
+	}
+
+	// test the first error is returned and the context is cancelled
+	g, ctx := newErrGroup(context.TODO())
+	g.Go(func() error {
+		return errors.New("boom")
+	})
+
+	g.Go(func() error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+			return errors.New("expected context to be cancelled")
+		}
+	})
+
+	if err := g.Wait(); err == nil || err.Error() != "boom" {
+		t.Errorf("expected boom error, got %v", err)
+	}
+}
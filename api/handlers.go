@@ -1,15 +1,35 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/circuit"
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
+// throttleRetryAfter is how long clients are told to wait before retrying a request that aws throttled
+const throttleRetryAfter = 5 * time.Second
+
+// errorBody is the json body of a limit exceeded or service unavailable response, so clients can tell if and when the
+// request can be retried.  RequestId is the X-Request-Id of the request.
+type errorBody struct {
+	Code      string
+	Message   string
+	RequestId string `json:",omitempty"`
+	Retryable bool
+}
+
 // PingHandler responds to ping requests
 func (s *server) PingHandler(w http.ResponseWriter, r *http.Request) {
 	w = LogWriter{w}
@@ -19,17 +39,20 @@ func (s *server) PingHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("pong"))
 }
 
+// versionResponse is the response to a version request
+type versionResponse struct {
+	Version    string `json:"version"`
+	GitHash    string `json:"githash"`
+	BuildStamp string `json:"buildstamp"`
+}
+
 // VersionHandler responds to version requests
 func (s *server) VersionHandler(w http.ResponseWriter, r *http.Request) {
 	w = LogWriter{w}
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Content-Type", "application/json")
 
-	data, err := json.Marshal(struct {
-		Version    string `json:"version"`
-		GitHash    string `json:"githash"`
-		BuildStamp string `json:"buildstamp"`
-	}{
+	data, err := json.Marshal(versionResponse{
 		Version:    fmt.Sprintf("%s%s", s.version.Version, s.version.VersionPrerelease),
 		GitHash:    s.version.GitHash,
 		BuildStamp: s.version.BuildStamp,
@@ -48,24 +71,97 @@ func (s *server) VersionHandler(w http.ResponseWriter, r *http.Request) {
 // handleError handles standard apierror return codes
 func handleError(w http.ResponseWriter, err error) {
 	log.Error(err.Error())
-	if aerr, ok := errors.Cause(err).(apierror.Error); ok {
-		switch aerr.Code {
-		case apierror.ErrForbidden:
-			w.WriteHeader(http.StatusForbidden)
-		case apierror.ErrNotFound:
-			w.WriteHeader(http.StatusNotFound)
-		case apierror.ErrConflict:
-			w.WriteHeader(http.StatusConflict)
-		case apierror.ErrBadRequest:
-			w.WriteHeader(http.StatusBadRequest)
-		case apierror.ErrLimitExceeded:
-			w.WriteHeader(http.StatusTooManyRequests)
-		default:
-			w.WriteHeader(http.StatusInternalServerError)
+
+	// a call to a service with an open circuit breaker fails fast, the client can retry once it lets calls through
+	var open *circuit.OpenError
+	if errors.As(err, &open) {
+		writeErrorBody(w, http.StatusServiceUnavailable, errorBody{
+			Code:      apierror.ErrServiceUnavailable,
+			Message:   open.Error(),
+			Retryable: true,
+		}, open.RetryAfter)
+		return
+	}
+
+	if awsCallTimedOut(err) {
+		w.WriteHeader(http.StatusGatewayTimeout)
+		w.Write([]byte("timed out waiting for a response from aws"))
+		return
+	}
+
+	// a request throttled by aws can be retried after backing off, whatever code its service mapped it to
+	if awsCallThrottled(err) {
+		msg := err.Error()
+		if aerr, ok := common.AsAPIError(err); ok {
+			msg = aerr.Message
+		}
+
+		writeErrorBody(w, http.StatusTooManyRequests, errorBody{
+			Code:      apierror.ErrLimitExceeded,
+			Message:   msg,
+			Retryable: true,
+		}, throttleRetryAfter)
+		return
+	}
+
+	if aerr, ok := common.AsAPIError(err); ok {
+		// limits that aren't throttling, ie. a service quota, won't go away by retrying
+		if aerr.Code == apierror.ErrLimitExceeded {
+			writeErrorBody(w, http.StatusTooManyRequests, errorBody{Code: aerr.Code, Message: aerr.Message}, 0)
+			return
 		}
+
+		w.WriteHeader(apiErrorStatus(aerr.Code))
 		w.Write([]byte(aerr.Message))
 	} else {
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte(err.Error()))
 	}
 }
+
+// writeErrorBody writes the error as a json body with the request id from the request log, and a Retry-After header
+// in seconds (rounded up) if retryAfter is set
+func writeErrorBody(w http.ResponseWriter, status int, body errorBody, retryAfter time.Duration) {
+	body.RequestId = w.Header().Get("X-Request-Id")
+
+	j, err := json.Marshal(body)
+	if err != nil {
+		log.Errorf("cannot marshal error body into JSON: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(j)
+}
+
+// awsCallThrottled returns true if the error is from a call to aws that was throttled, either with one of the
+// throttling error codes (ie. SlowDown from s3 or PriorRequestNotComplete from route53) or a 429 status
+func awsCallThrottled(err error) bool {
+	var aerr awserr.Error
+	if !errors.As(err, &aerr) {
+		return false
+	}
+
+	if request.IsErrorThrottle(aerr) || aerr.Code() == "SlowDown" {
+		return true
+	}
+
+	var rerr awserr.RequestFailure
+	return errors.As(err, &rerr) && rerr.StatusCode() == http.StatusTooManyRequests
+}
+
+// awsCallTimedOut returns true if the error is from a call to aws that hit its deadline, ie. the configured timeout
+func awsCallTimedOut(err error) bool {
+	var aerr awserr.Error
+	if !errors.As(err, &aerr) || aerr.Code() != request.CanceledErrorCode {
+		return false
+	}
+
+	return aerr.OrigErr() == context.DeadlineExceeded
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/YaleSpinup/apierror"
+	log "github.com/sirupsen/logrus"
+)
+
+// accountCapabilities are the features that are configured for an account
+type accountCapabilities struct {
+	// Websites is true if websites can be created in the account
+	Websites bool
+	Domains  []string
+	// SimpleWebsites is true if simple websites, without a distribution or dns record, can be created in the account
+	SimpleWebsites bool
+	// SFTP is true if sftp access can be enabled for the buckets in the account
+	SFTP bool
+	// VpcOnly is true if buckets in the account can be restricted to the configured vpc endpoints
+	VpcOnly bool
+	// IPAllowlist are the networks the bucket admin policies are limited to by default
+	IPAllowlist []string `json:",omitempty"`
+	// Scratch is true if scratch buckets, that are deleted at the end of their lifetime, can be created in the account
+	Scratch bool
+	// DataProtection is true if buckets in the account can be protected so only the break-glass role can delete data
+	DataProtection bool
+	// AccessLogging is true if bucket access logs are delivered to a logging bucket
+	AccessLogging   bool
+	AccessLogBucket string `json:",omitempty"`
+	ConsoleLogin    bool
+	RequireMFA      bool
+}
+
+// accountResponse is a configured account
+type accountResponse struct {
+	Name         string
+	AccountID    string
+	Region       string
+	Capabilities accountCapabilities
+}
+
+// capabilities returns the capabilities of an account from the configuration
+func (s *server) capabilities(accountId string) accountCapabilities {
+	domains := []string{}
+	for d := range s.account.Domains {
+		domains = append(domains, d)
+	}
+	sort.Strings(domains)
+
+	c := accountCapabilities{
+		Websites:       s.websitesEnabled(accountId),
+		Domains:        domains,
+		SimpleWebsites: s.account.SimpleWebsites != nil,
+		SFTP:           s.requireSFTP() == nil,
+		VpcOnly:        s.requireVpcEndpoints() == nil,
+		IPAllowlist:    s.account.IPAllowlist,
+		Scratch:        s.account.Scratch != nil,
+		DataProtection: s.requireBreakGlassRole() == nil,
+		ConsoleLogin:   s.account.EnableConsoleLogin,
+		RequireMFA:     s.account.RequireMFA,
+	}
+
+	if s.account.AccessLog.Bucket != "" {
+		c.AccessLogging = true
+		c.AccessLogBucket = s.account.AccessLog.GetBucket(accountId)
+	}
+
+	return c
+}
+
+// websitesEnabled returns true if websites are enabled for the account.  If the account doesn't have its features
+// configured, websites are enabled if there are domains configured.
+func (s *server) websitesEnabled(accountId string) bool {
+	if f, ok := s.account.Features[s.mapToAccountName(accountId)]; ok && f != nil {
+		return f.Websites && len(s.account.Domains) > 0
+	}
+
+	return len(s.account.Domains) > 0
+}
+
+// requireWebsites returns a bad request error if websites aren't enabled for the account
+func (s *server) requireWebsites(account string) error {
+	if s.websitesEnabled(s.mapAccountNumber(account)) {
+		return nil
+	}
+
+	msg := fmt.Sprintf("websites are not supported in account %s, create the website in an account with website support (see GET /v1/s3/accounts) or create a bucket instead", account)
+	return apierror.New(apierror.ErrBadRequest, msg, nil)
+}
+
+// AccountListHandler lists the configured accounts and their capabilities
+func (s *server) AccountListHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+
+	accounts := []accountResponse{}
+	for name, id := range s.accountsMap {
+		accounts = append(accounts, accountResponse{
+			Name:         name,
+			AccountID:    id,
+			Region:       s.account.Region,
+			Capabilities: s.capabilities(id),
+		})
+	}
+
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Name < accounts[j].Name
+	})
+
+	j, err := json.Marshal(accounts)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", accounts, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
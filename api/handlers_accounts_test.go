@@ -0,0 +1,173 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/gorilla/mux"
+)
+
+func TestAccountListHandler(t *testing.T) {
+	s := server{
+		account: common.Account{
+			Region: "us-east-1",
+			AccessLog: common.AccessLog{
+				Bucket: "logs-{account_id}",
+			},
+			Domains: map[string]*common.Domain{
+				"example.com": {},
+				"example.edu": {},
+			},
+			RequireMFA: true,
+			Features: map[string]*common.Features{
+				"spinupsbx": {Websites: false},
+			},
+		},
+		accountsMap: map[string]string{
+			"spinupsbx": "012345678910",
+			"spinup":    "109876543210",
+		},
+	}
+
+	req, err := http.NewRequest("GET", "/v1/s3/accounts", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(s.AccountListHandler).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var out []accountResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &out); err != nil {
+		t.Fatalf("failed to unmarshal response: %s", err)
+	}
+
+	expected := []accountResponse{
+		{
+			Name:      "spinup",
+			AccountID: "109876543210",
+			Region:    "us-east-1",
+			Capabilities: accountCapabilities{
+				Websites:        true,
+				Domains:         []string{"example.com", "example.edu"},
+				AccessLogging:   true,
+				AccessLogBucket: "logs-109876543210",
+				RequireMFA:      true,
+			},
+		},
+		{
+			Name:      "spinupsbx",
+			AccountID: "012345678910",
+			Region:    "us-east-1",
+			Capabilities: accountCapabilities{
+				Websites:        false,
+				Domains:         []string{"example.com", "example.edu"},
+				AccessLogging:   true,
+				AccessLogBucket: "logs-012345678910",
+				RequireMFA:      true,
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(out, expected) {
+		t.Errorf("expected %+v, got %+v", expected, out)
+	}
+}
+
+func TestWebsitesEnabled(t *testing.T) {
+	domains := map[string]*common.Domain{"example.com": {}}
+	accountsMap := map[string]string{"spinup": "109876543210"}
+
+	tests := []struct {
+		name     string
+		account  common.Account
+		expected bool
+	}{
+		{
+			name:     "no domains",
+			account:  common.Account{},
+			expected: false,
+		},
+		{
+			name:     "domains without features",
+			account:  common.Account{Domains: domains},
+			expected: true,
+		},
+		{
+			name: "domains with other account features",
+			account: common.Account{
+				Domains:  domains,
+				Features: map[string]*common.Features{"spinupsbx": {Websites: false}},
+			},
+			expected: true,
+		},
+		{
+			name: "websites disabled",
+			account: common.Account{
+				Domains:  domains,
+				Features: map[string]*common.Features{"spinup": {Websites: false}},
+			},
+			expected: false,
+		},
+		{
+			name: "websites enabled",
+			account: common.Account{
+				Domains:  domains,
+				Features: map[string]*common.Features{"spinup": {Websites: true}},
+			},
+			expected: true,
+		},
+		{
+			name: "websites enabled without domains",
+			account: common.Account{
+				Features: map[string]*common.Features{"spinup": {Websites: true}},
+			},
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := server{account: test.account, accountsMap: accountsMap}
+			if out := s.websitesEnabled("109876543210"); out != test.expected {
+				t.Errorf("expected %t, got %t", test.expected, out)
+			}
+		})
+	}
+}
+
+func TestCreateWebsiteHandlerWebsitesDisabled(t *testing.T) {
+	s := server{
+		account: common.Account{
+			Domains:  map[string]*common.Domain{"example.com": {}},
+			Features: map[string]*common.Features{"spinupsbx": {Websites: false}},
+		},
+		accountsMap: map[string]string{"spinupsbx": "012345678910"},
+	}
+
+	req, err := http.NewRequest("POST", "/v1/s3/spinupsbx/websites", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{"account": "spinupsbx"})
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(s.CreateWebsiteHandler).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	if !strings.Contains(rr.Body.String(), "websites are not supported in account spinupsbx") {
+		t.Errorf("expected website support error, got %s", rr.Body.String())
+	}
+}
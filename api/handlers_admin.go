@@ -0,0 +1,163 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// adminTokenHeader carries the bcrypt hashed admin token needed for the admin endpoints
+const adminTokenHeader = "X-Admin-Token"
+
+// OperationListHandler lists the mutating operations that are currently being executed
+func (s *server) OperationListHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+
+	if !s.admin(r) {
+		handleError(w, apierror.New(apierror.ErrForbidden, fmt.Sprintf("a valid %s header is required", adminTokenHeader), nil))
+		return
+	}
+
+	ops := s.operations.list()
+
+	j, err := json.Marshal(ops)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", ops, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// OperationCancelHandler cancels a stuck operation, the operation is rolled back the same as if it had failed
+func (s *server) OperationCancelHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+
+	if !s.admin(r) {
+		handleError(w, apierror.New(apierror.ErrForbidden, fmt.Sprintf("a valid %s header is required", adminTokenHeader), nil))
+		return
+	}
+
+	op, err := s.operations.cancel(vars["id"])
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	j, err := json.Marshal(op)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", op, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// maintenanceRequest is the request to enable the read-only maintenance mode
+type maintenanceRequest struct {
+	// Message is returned with the rejected mutating requests
+	Message string
+}
+
+// MaintenanceShowHandler returns the read-only maintenance mode
+func (s *server) MaintenanceShowHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+
+	if !s.admin(r) {
+		handleError(w, apierror.New(apierror.ErrForbidden, fmt.Sprintf("a valid %s header is required", adminTokenHeader), nil))
+		return
+	}
+
+	writeMaintenance(w, s.maintenance.get(r.Context()))
+}
+
+// MaintenanceEnableHandler enables the read-only maintenance mode, the mutating requests are rejected with 503 Service
+// Unavailable and the message until it's disabled
+func (s *server) MaintenanceEnableHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+
+	if !s.admin(r) {
+		handleError(w, apierror.New(apierror.ErrForbidden, fmt.Sprintf("a valid %s header is required", adminTokenHeader), nil))
+		return
+	}
+
+	var req maintenanceRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			msg := fmt.Sprintf("cannot decode body into maintenance input: %s", err)
+			handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+			return
+		}
+	}
+
+	status, err := s.maintenance.enable(r.Context(), req.Message)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	log.Warnf("enabled read-only maintenance mode: %s", status.Message)
+
+	writeMaintenance(w, status)
+}
+
+// MaintenanceDisableHandler disables the read-only maintenance mode
+func (s *server) MaintenanceDisableHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+
+	if !s.admin(r) {
+		handleError(w, apierror.New(apierror.ErrForbidden, fmt.Sprintf("a valid %s header is required", adminTokenHeader), nil))
+		return
+	}
+
+	status, err := s.maintenance.disable(r.Context())
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	log.Warn("disabled read-only maintenance mode")
+
+	writeMaintenance(w, status)
+}
+
+// writeMaintenance writes the maintenance mode to the response
+func writeMaintenance(w http.ResponseWriter, status maintenanceStatus) {
+	j, err := json.Marshal(status)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", status, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// admin returns true if the request carries the admin token, hashed with bcrypt the same as the auth token.  The
+// admin endpoints are disabled if there isn't an admin token configured.
+func (s *server) admin(r *http.Request) bool {
+	if len(s.adminToken) == 0 {
+		return false
+	}
+
+	header := r.Header.Get(adminTokenHeader)
+	if header == "" {
+		return false
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(header), s.adminToken) == nil
+}
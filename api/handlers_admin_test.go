@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// blockingTestHandler is a mutating handler that blocks until its request is canceled
+func (s *server) blockingTestHandler(w http.ResponseWriter, r *http.Request) {
+	<-r.Context().Done()
+	w.WriteHeader(http.StatusServiceUnavailable)
+}
+
+func TestOperations(t *testing.T) {
+	adminToken := []byte("adminsekret")
+	header, _ := bcrypt.GenerateFromPassword(adminToken, bcrypt.MinCost)
+
+	s := server{
+		router:     mux.NewRouter(),
+		adminToken: adminToken,
+		operations: newOperations(),
+	}
+	s.router.HandleFunc("/v1/s3/{account}/websites/{website}", s.blockingTestHandler).Methods(http.MethodDelete)
+	s.router.HandleFunc("/v1/s3/admin/operations", s.OperationListHandler).Methods(http.MethodGet)
+	s.router.HandleFunc("/v1/s3/admin/operations/{id}", s.OperationCancelHandler).Methods(http.MethodDelete)
+	s.router.Use(s.operations.middleware)
+
+	done := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/v1/s3/spinup/websites/www.example.com", nil))
+		done <- w.Code
+	}()
+
+	// wait for the operation to be registered
+	var ops []operation
+	for i := 0; i < 100 && len(ops) == 0; i++ {
+		ops = s.operations.list()
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// the admin endpoints require the admin token
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/s3/admin/operations", nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 without the admin token, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/s3/admin/operations", nil)
+	req.Header.Set(adminTokenHeader, string(header))
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	if err := json.Unmarshal(w.Body.Bytes(), &ops); err != nil {
+		t.Fatalf("failed to unmarshal operations: %s", err)
+	}
+
+	// the list request itself isn't an operation
+	if len(ops) != 1 {
+		t.Fatalf("expected 1 operation, got %+v", ops)
+	}
+
+	op := ops[0]
+	if op.Handler != "blockingTestHandler" || op.Account != "spinup" || op.Resource != "www.example.com" || op.Method != http.MethodDelete || op.Elapsed == "" {
+		t.Errorf("unexpected operation %+v", op)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/v1/s3/admin/operations/"+op.ID, nil)
+	req.Header.Set(adminTokenHeader, string(header))
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 canceling the operation, got %d", w.Code)
+	}
+
+	select {
+	case code := <-done:
+		if code != http.StatusServiceUnavailable {
+			t.Errorf("expected the canceled handler to return 503, got %d", code)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the operation to be canceled")
+	}
+
+	if ops := s.operations.list(); len(ops) != 0 {
+		t.Errorf("expected the finished operation to be removed, got %+v", ops)
+	}
+
+	// canceling an unknown operation is not found
+	req = httptest.NewRequest(http.MethodDelete, "/v1/s3/admin/operations/"+op.ID, nil)
+	req.Header.Set(adminTokenHeader, string(header))
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown operation, got %d", w.Code)
+	}
+}
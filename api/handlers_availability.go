@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// availabilityActions are the actions needed to check if a bucket name is available
+var availabilityActions = []string{
+	"s3:ListBucket",
+	"iam:GetGroup",
+	"iam:GetPolicy",
+	"cloudfront:ListDistributions",
+}
+
+// bucketAvailability is the result of checking a bucket name, Conflicts lists the existing resources that would
+// make a create fail
+type bucketAvailability struct {
+	Bucket    string
+	Available bool
+	Conflicts []string
+}
+
+// BucketAvailabilityHandler checks if a bucket or website can be created with the given name.  Besides the bucket
+// itself, the management groups and policies the api creates for the bucket and a cloudfront distribution using the
+// name as an alias would all make the create fail and roll back.
+func (s *server) BucketAvailabilityHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	bucket := vars["bucket"]
+
+	var errs fieldErrors
+	errs.bucketName("bucket", bucket)
+	if err := errs.err(); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	session, err := s.sessionForAccount(r.Context(), vars["account"], availabilityActions...)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+	iamService := iamapi.NewSession(session.Session, s.account)
+	iamService.Cache = s.resourceCache(accountId)
+	cloudFrontService := cfapi.NewSession(session.Session, s.account, accountId)
+	cloudFrontService.Index = s.distributionIndex(accountId)
+
+	output, err := bucketNameAvailability(r.Context(), s3Service, iamService, cloudFrontService, accountId, bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// bucketNameAvailability runs the bucket, iam and cloudfront checks concurrently and collects their conflicts
+func bucketNameAvailability(ctx context.Context, s3Service s3api.S3, iamService iamapi.IAM, cloudFrontService cfapi.CloudFront, accountId, bucket string) (*bucketAvailability, error) {
+	var bucketConflicts, iamConflicts, cloudFrontConflicts []string
+
+	g, ctx := newErrGroup(ctx)
+
+	g.Go(func() error {
+		exists, err := s3Service.BucketExists(ctx, bucket)
+		switch {
+		case exists && err != nil:
+			// the bucket exists, but it's owned by someone else
+			bucketConflicts = append(bucketConflicts, fmt.Sprintf("bucket %s is owned by another account", bucket))
+		case err != nil:
+			return err
+		case exists:
+			bucketConflicts = append(bucketConflicts, fmt.Sprintf("bucket %s already exists", bucket))
+		}
+		return nil
+	})
+
+	g.Go(func() error {
+		for _, group := range websiteUserGroups {
+			groupName := iamapi.FormatGroupName(bucket, "/", group)
+			if _, err := iamService.GetGroup(ctx, groupName); err == nil {
+				iamConflicts = append(iamConflicts, fmt.Sprintf("group %s already exists", groupName))
+			} else if !isNotFound(err) {
+				return err
+			}
+
+			policyName := iamapi.FormatPolicyName(bucket, "/", iamapi.GroupPolicyType(group))
+			if _, err := iamService.GetPolicy(ctx, iamService.PolicyArn(accountId, policyName)); err == nil {
+				iamConflicts = append(iamConflicts, fmt.Sprintf("policy %s already exists", policyName))
+			} else if !isNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	})
+
+	g.Go(func() error {
+		distribution, err := cloudFrontService.GetDistributionByName(ctx, bucket)
+		if err == nil {
+			cloudFrontConflicts = append(cloudFrontConflicts, fmt.Sprintf("cloudfront distribution %s already uses the alias %s", aws.StringValue(distribution.ARN), bucket))
+		} else if !isNotFound(err) {
+			return err
+		}
+		return nil
+	})
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	conflicts := append(append(append([]string{}, bucketConflicts...), iamConflicts...), cloudFrontConflicts...)
+
+	return &bucketAvailability{
+		Bucket:    bucket,
+		Available: len(conflicts) == 0,
+		Conflicts: conflicts,
+	}, nil
+}
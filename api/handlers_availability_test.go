@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/gorilla/mux"
+)
+
+// mockAvailabilityS3 is an s3 client that returns err from HeadBucket
+type mockAvailabilityS3 struct {
+	s3iface.S3API
+	err error
+}
+
+func (m *mockAvailabilityS3) HeadBucketWithContext(ctx context.Context, input *s3.HeadBucketInput, opts ...request.Option) (*s3.HeadBucketOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &s3.HeadBucketOutput{}, nil
+}
+
+func TestBucketNameAvailability(t *testing.T) {
+	tests := []struct {
+		name      string
+		bucket    string
+		headErr   error
+		policies  map[string]bool
+		groups    map[string][]string
+		conflicts []string
+	}{
+		{
+			name:      "available",
+			bucket:    "foobucket",
+			headErr:   awserr.New("NotFound", "not found", nil),
+			policies:  map[string]bool{},
+			groups:    map[string][]string{},
+			conflicts: []string{},
+		},
+		{
+			name:     "existing bucket and groups",
+			bucket:   "foobucket",
+			policies: map[string]bool{iamapi.FormatPolicyArn("012345678910", "/", "foobucket-BktROPlc"): true},
+			groups:   map[string][]string{"foobucket-BktAdmGrp": {}},
+			conflicts: []string{
+				"bucket foobucket already exists",
+				"group foobucket-BktAdmGrp already exists",
+				"policy foobucket-BktROPlc already exists",
+			},
+		},
+		{
+			name:     "owned by another account",
+			bucket:   "foobucket",
+			headErr:  awserr.New("Forbidden", "forbidden", nil),
+			policies: map[string]bool{},
+			groups:   map[string][]string{},
+			conflicts: []string{
+				"bucket foobucket is owned by another account",
+			},
+		},
+		{
+			name:     "distribution alias",
+			bucket:   "www.example.com",
+			headErr:  awserr.New("NotFound", "not found", nil),
+			policies: map[string]bool{},
+			groups:   map[string][]string{},
+			conflicts: []string{
+				"cloudfront distribution arn:aws:cloudfront::012345678910:distribution/ABC already uses the alias www.example.com",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		out, err := bucketNameAvailability(context.TODO(),
+			s3api.S3{Service: &mockAvailabilityS3{err: test.headErr}},
+			iamapi.IAM{Service: &mockRepairIAM{policies: test.policies, groups: test.groups}},
+			cfapi.CloudFront{Service: &mockTagSyncCloudFront{}},
+			"012345678910",
+			test.bucket,
+		)
+		if err != nil {
+			t.Errorf("%s: expected nil error, got %s", test.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(out.Conflicts, test.conflicts) {
+			t.Errorf("%s: expected conflicts %v, got %v", test.name, test.conflicts, out.Conflicts)
+		}
+
+		if out.Available != (len(test.conflicts) == 0) {
+			t.Errorf("%s: expected available to be %t, got %t", test.name, len(test.conflicts) == 0, out.Available)
+		}
+	}
+}
+
+func TestBucketAvailabilityHandlerValidation(t *testing.T) {
+	s := server{}
+
+	for _, bucket := range []string{"Foo_Bucket", "ab", "192.168.1.1"} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/s3/foo/buckets/"+bucket+"/available", nil)
+		req = mux.SetURLVars(req, map[string]string{"account": "foo", "bucket": bucket})
+		w := httptest.NewRecorder()
+
+		s.BucketAvailabilityHandler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d for bucket %s, got %d", http.StatusBadRequest, bucket, w.Code)
+		}
+	}
+}
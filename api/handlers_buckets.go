@@ -5,12 +5,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/YaleSpinup/apierror"
+	aaapi "github.com/YaleSpinup/s3-api/accessanalyzer"
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	cwapi "github.com/YaleSpinup/s3-api/cloudwatch"
+	"github.com/YaleSpinup/s3-api/common"
 	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/YaleSpinup/s3-api/retry"
+	"github.com/YaleSpinup/s3-api/rollback"
 	s3api "github.com/YaleSpinup/s3-api/s3"
+	s3controlapi "github.com/YaleSpinup/s3-api/s3control"
+	"github.com/YaleSpinup/s3-api/webhook"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/s3"
@@ -25,83 +32,191 @@ import (
 // 2. tag the bucket with given tags
 // 3. generate the default admin bucket policy
 // 4. create the admin bucket policy
-// 5. create the bucket admin group, '<bucketName>-BktAdmGrp'
-// 6. attach the bucket admin policy to the bucket admin group
+// 5. create the bucket admin group, '<bucketName>-BktAdmGrp', unless a shared admin group is given
+// 6. attach the bucket admin policy to the bucket admin group or the shared admin group
 // Note: this does _not_ create any users for managing the bucket
 func (s *server) BucketCreateHandler(w http.ResponseWriter, r *http.Request) {
 	w = LogWriter{w}
 	vars := mux.Vars(r)
 	accountId := s.mapAccountNumber(vars["account"])
-	role := fmt.Sprintf("arn:aws:iam::%s:role/%s", accountId, s.session.RoleName)
-	policy, err := generatePolicy("s3:*", "iam:*")
-	if err != nil {
-		log.Errorf("cannot generate policy: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
+	var req bucketCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		msg := fmt.Sprintf("cannot decode body into create bucket input: %s", err)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
 		return
 	}
 
-	session, err := s.assumeRole(
-		r.Context(),
-		s.session.ExternalID,
-		role,
-		policy,
-	)
+	if err := req.validate(s.requiredTags); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	session, err := s.sessionForScope(r.Context(), accountId, s.withSharedAdminGroups(bucketCreatePolicy, sharedAdminGroupActions...), policyScope{Bucket: aws.StringValue(req.BucketInput.Bucket)})
 	if err != nil {
-		log.Errorf("failed to assume role in account: %s", accountId)
-		w.WriteHeader(http.StatusInternalServerError)
+		handleError(w, err)
+		return
+	}
+
+	// the bucket admin group and policy are created with the bucket
+	if err := s.checkQuotas(r.Context(), session.Session, accountId, req.quotaNeeds()); err != nil {
+		handleError(w, err)
 		return
 	}
 
 	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
 	iamService := iamapi.NewSession(session.Session, s.account)
+	iamService.Cache = s.resourceCache(accountId)
 
-	var req struct {
-		Tags        []*s3.Tag
-		Lifecycle   *string
-		BucketInput s3.CreateBucketInput
+	output, _, err := s.createBucket(r.Context(), vars["account"], s3Service, iamService, req)
+	if err != nil {
+		handleError(w, err)
+		return
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		msg := fmt.Sprintf("cannot decode body into create bucket input: %s", err)
-		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal reasponse(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// bucketCreateRequest is the request to create a bucket
+type bucketCreateRequest struct {
+	Tags        []*s3.Tag
+	Lifecycle   *string
+	BucketInput s3.CreateBucketInput
+	// ObjectTags are the default tags for the objects created in the bucket by the api
+	ObjectTags []*s3.Tag `json:",omitempty"`
+	// VpcOnly restricts access to the bucket to the configured vpc endpoints
+	VpcOnly bool `json:",omitempty"`
+	// IPAllowlist limits the bucket admin policy to the networks, it defaults to the account's allowlist
+	IPAllowlist []string `json:",omitempty"`
+	// ScratchDays creates a scratch bucket that's emptied and deleted after the days
+	ScratchDays int `json:",omitempty"`
+	// AdminGroup is one of the configured shared admin groups that the bucket admin policy is attached to, instead
+	// of creating an admin group for the bucket
+	AdminGroup string `json:",omitempty"`
+}
+
+// quotaNeeds returns the IAM resources creating the bucket needs, it doesn't need a group with a shared admin group
+func (r *bucketCreateRequest) quotaNeeds() quotaNeeds {
+	if r.AdminGroup != "" {
+		return quotaNeeds{Policies: 1}
+	}
+	return quotaNeeds{Groups: 1, Policies: 1}
+}
+
+// validate validates the request to create a bucket with the required tags
+func (r *bucketCreateRequest) validate(required requiredTags) error {
+	f := fieldErrors{}
+	f.bucketName("BucketInput.Bucket", aws.StringValue(r.BucketInput.Bucket))
+	f.tags("Tags", r.Tags)
+	f.requiredTags("Tags", r.Tags, required)
+	f.lifecycle("Lifecycle", r.Lifecycle)
+	f.objectTags("ObjectTags", r.ObjectTags)
+	f.ipAllowlist("IPAllowlist", r.IPAllowlist)
+	if r.ScratchDays < 0 {
+		f.add("ScratchDays", "scratch days can't be negative")
+	}
+	return f.err()
+}
+
+// bucketCreateOutput is the response from creating a bucket
+type bucketCreateOutput struct {
+	Bucket *string
+	Policy *iam.Policy
+	Group  *iam.Group
+	// ScratchExpires is when a scratch bucket will be emptied and deleted
+	ScratchExpires *time.Time `json:",omitempty"`
+}
+
+// createBucket orchestrates the creation of a new s3 bucket and its admin group in an account, with rollback in the
+// event of failure.  The rollback is returned so that callers can report on it.
+func (s *server) createBucket(ctx context.Context, account string, s3Service s3api.S3, iamService iamapi.IAM, req bucketCreateRequest) (output *bucketCreateOutput, rb *rollback.Rollback, err error) {
 	// append org tag that will get applied to all resources that tag
 	req.Tags = append(req.Tags, &s3.Tag{
 		Key:   aws.String("spinup:org"),
 		Value: aws.String(Org),
 	})
 
-	// setup err var, rollback function list and defer execution
-	// var err error
-	var rollBackTasks []rollbackFunc
+	bucketName := aws.StringValue(req.BucketInput.Bucket)
+
+	if req.VpcOnly {
+		if err := s.requireVpcEndpoints(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// the shared admin group has to exist before the bucket is created
+	var sharedGroup *iam.Group
+	if req.AdminGroup != "" {
+		if err := s.requireSharedAdminGroup(req.AdminGroup); err != nil {
+			return nil, nil, err
+		}
+
+		if sharedGroup, err = iamService.GetGroup(ctx, req.AdminGroup); err != nil {
+			msg := fmt.Sprintf("failed to get shared admin group %s: %s", req.AdminGroup, err)
+			return nil, nil, errors.Wrap(err, msg)
+		}
+	}
+
+	bucketTags := withDefaultObjectTags(req.Tags, req.ObjectTags)
+
+	// the end date of a scratch bucket is recorded in its tags for the scratch reaper
+	var scratchExpires *time.Time
+	if req.ScratchDays > 0 {
+		if err := s.requireScratch(req.ScratchDays); err != nil {
+			return nil, nil, err
+		}
+
+		expires := time.Now().UTC().Add(time.Duration(req.ScratchDays) * 24 * time.Hour).Truncate(time.Second)
+		scratchExpires = &expires
+		bucketTags = append(bucketTags, &s3.Tag{
+			Key:   aws.String(scratchTagKey),
+			Value: aws.String(expires.Format(time.RFC3339)),
+		})
+	}
+
+	lease, err := s.lockResource(ctx, account, bucketName)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer lease.Release()
+
+	// a create that waited on the lock for another create of the same bucket fails here, instead of rolling back
+	// the bucket the other create made
+	if exists, _ := s3Service.BucketExists(ctx, bucketName); exists {
+		msg := fmt.Sprintf("bucket %s already exists", bucketName)
+		return nil, nil, apierror.New(apierror.ErrConflict, msg, nil)
+	}
+
+	// setup rollback and defer execution
+	rb = s.newRollback("bucket.create", account, bucketName, rollbackServices{s3: &s3Service, iam: &iamService})
 	defer func() {
+		s.finishRollback(rb, err)
 		if err != nil {
-			log.Errorf("recovering from error: %s, executing %d rollback tasks", err, len(rollBackTasks))
-			rollBack(&rollBackTasks)
+			s.notify(webhook.EventBucketRolledBack, account, bucketName, map[string]string{"Error": err.Error(), "Rollback": rb.ID})
 		}
 	}()
 
-	bucketName := aws.StringValue(req.BucketInput.Bucket)
 	var bucketOutput *s3.CreateBucketOutput
-	if bucketOutput, err = s3Service.CreateBucket(r.Context(), &req.BucketInput); err != nil {
+	if bucketOutput, err = s3Service.CreateBucket(ctx, &req.BucketInput); err != nil {
 		msg := fmt.Sprintf("failed to create bucket: %s", err)
-		handleError(w, errors.Wrap(err, msg))
-		return
+		return nil, rb, errors.Wrap(err, msg)
 	}
 
-	// append bucket delete to rollback tasks
-	rollBackTasks = append(rollBackTasks, func(ctx context.Context) error {
-		if err := s3Service.DeleteEmptyBucket(ctx, &s3.DeleteBucketInput{Bucket: aws.String(bucketName)}); err != nil {
-			return err
-		}
-		return nil
-	})
+	// append bucket delete to rollback
+	rb.Add("delete bucket "+bucketName, rollbackDeleteBucket, map[string]string{"bucket": bucketName})
 
 	// wait for the bucket to exist
-	if err = retry(3, 2*time.Second, func() error {
+	if err = retry.Do(ctx, s3ConsistencyRetry, func(ctx context.Context) error {
 		log.Infof("checking if bucket exists before continuing: %s", bucketName)
-		exists, err := s3Service.BucketExists(r.Context(), bucketName)
+		exists, err := s3Service.BucketExists(ctx, bucketName)
 		if err != nil {
 			return err
 		}
@@ -115,52 +230,50 @@ func (s *server) BucketCreateHandler(w http.ResponseWriter, r *http.Request) {
 		return errors.New(msg)
 	}); err != nil {
 		msg := fmt.Sprintf("failed to create bucket %s, timeout waiting for create: %s", bucketName, err.Error())
-		handleError(w, errors.Wrap(err, msg))
-		return
+		return nil, rb, errors.Wrap(err, msg)
 	}
 
-	// retry tagging
-	if err = retry(3, 2*time.Second, func() error {
-		if err := s3Service.TagBucket(r.Context(), bucketName, req.Tags); err != nil {
+	// retry tagging, the default object tags are only stored on the bucket
+	if err = retry.Do(ctx, s3ConsistencyRetry, func(ctx context.Context) error {
+		if err := s3Service.TagBucket(ctx, bucketName, bucketTags); err != nil {
 			log.Warnf("error tagging website bucket %s: %s", bucketName, err)
 			return err
 		}
 		return nil
 	}); err != nil {
 		msg := fmt.Sprintf("failed to tag bucket %s: %s", bucketName, err.Error())
-		handleError(w, errors.Wrap(err, msg))
-		return
+		return nil, rb, errors.Wrap(err, msg)
 	}
 
+	var rules []*s3.LifecycleRule
 	if req.Lifecycle != nil {
-		// Get the supported lifecycle and error if not
-		lifecycle := s3api.Lifecycles.GetLifecycle(*req.Lifecycle)
-		if lifecycle == nil {
-			handleError(w, errors.Wrap(errors.New("lifecycle doesnt exist in supported lifecycles"), ""))
-			return
+		// Get the rules of the supported lifecycle or template and error if not
+		if rules = s3api.Lifecycles.GetRules(*req.Lifecycle); rules == nil {
+			return nil, rb, errors.Wrap(errors.New("lifecycle doesnt exist in supported lifecycles"), "")
 		}
+	}
 
+	// a scratch bucket expires its objects by the end date, alongside the rules of its lifecycle
+	if req.ScratchDays > 0 {
+		rules = append(append([]*s3.LifecycleRule{}, rules...), s3api.ScratchExpirationRule(int64(req.ScratchDays)))
+	}
+
+	if len(rules) > 0 {
 		// Update the bucket lifecycle config
-		if err = s3Service.PutBucketLifecycleConfiguration(r.Context(), &s3.PutBucketLifecycleConfigurationInput{
+		if err = s3Service.PutBucketLifecycleConfiguration(ctx, &s3.PutBucketLifecycleConfigurationInput{
 			Bucket:                 aws.String(bucketName),
-			LifecycleConfiguration: &s3.BucketLifecycleConfiguration{Rules: []*s3.LifecycleRule{lifecycle}},
+			LifecycleConfiguration: &s3.BucketLifecycleConfiguration{Rules: rules},
 		}); err != nil {
 			msg := fmt.Sprintf("failed to update bucket lifecycle configuration%s: %s", bucketName, err.Error())
-			handleError(w, errors.Wrap(err, msg))
-			return
+			return nil, rb, errors.Wrap(err, msg)
 		}
 
-		// append bucket delete to rollback tasks
-		rollBackTasks = append(rollBackTasks, func(ctx context.Context) error {
-			if err := s3Service.DeleteBucketLifecycle(ctx, &s3.DeleteBucketLifecycleInput{Bucket: aws.String(bucketName)}); err != nil {
-				return err
-			}
-			return nil
-		})
+		// append lifecycle delete to rollback
+		rb.Add("delete lifecycle for bucket "+bucketName, rollbackDeleteBucketLifecycle, map[string]string{"bucket": bucketName})
 	}
 
 	// enable AWS managed serverside encryption for the bucket
-	if err = s3Service.UpdateBucketEncryption(r.Context(), &s3.PutBucketEncryptionInput{
+	if err = s3Service.UpdateBucketEncryption(ctx, &s3.PutBucketEncryptionInput{
 		Bucket: aws.String(bucketName),
 		ServerSideEncryptionConfiguration: &s3.ServerSideEncryptionConfiguration{
 			Rules: []*s3.ServerSideEncryptionRule{
@@ -173,95 +286,87 @@ func (s *server) BucketCreateHandler(w http.ResponseWriter, r *http.Request) {
 		},
 	}); err != nil {
 		msg := fmt.Sprintf("failed to enable encryption for bucket %s: %s", bucketName, err.Error())
-		handleError(w, errors.Wrap(err, msg))
-		return
+		return nil, rb, errors.Wrap(err, msg)
 	}
 
 	// enable logging access for the bucket to a central repo if the target bucket is set
 	fmt.Println("Bucket name ::::::::::::::::::::::::, ", s3Service.LoggingBucket)
 	if s3Service.LoggingBucket != "" {
-		if err = s3Service.UpdateBucketLogging(r.Context(), bucketName, s3Service.LoggingBucket, s3Service.LoggingBucketPrefix); err != nil {
+		if err = s3Service.UpdateBucketLogging(ctx, bucketName, s3Service.LoggingBucket, s3Service.LoggingBucketPrefix); err != nil {
 			msg := fmt.Sprintf("failed to enable logging for bucket %s: %s", bucketName, err.Error())
-			handleError(w, errors.Wrap(err, msg))
-			return
+			return nil, rb, errors.Wrap(err, msg)
+		}
+	}
+
+	// restrict the bucket to the vpc endpoints, the policy is deleted with the bucket if it's rolled back
+	if req.VpcOnly {
+		var policy string
+		if policy, _, err = s.vpcOnlyPolicy(s.mapAccountNumber(account), bucketName, "", true); err != nil {
+			msg := fmt.Sprintf("failed to build the vpc-only policy for bucket %s: %s", bucketName, err.Error())
+			return nil, rb, errors.Wrap(err, msg)
+		}
+
+		if err = s3Service.UpdateBucketPolicy(ctx, &s3.PutBucketPolicyInput{
+			Bucket: aws.String(bucketName),
+			Policy: aws.String(policy),
+		}); err != nil {
+			msg := fmt.Sprintf("failed to apply the vpc-only policy to bucket %s: %s", bucketName, err.Error())
+			return nil, rb, errors.Wrap(err, msg)
 		}
 	}
 
 	// build the default IAM bucket admin policy (from the config and known inputs)
 	var defaultPolicy []byte
-	if defaultPolicy, err = iamService.DefaultBucketAdminPolicy(aws.String(bucketName)); err != nil {
+	if defaultPolicy, err = iamService.DefaultBucketAdminPolicy(aws.String(bucketName), s.ipAllowlist(req.IPAllowlist)); err != nil {
 		msg := fmt.Sprintf("failed creating default IAM policy for bucket %s: %s", bucketName, err.Error())
-		handleError(w, errors.Wrap(err, msg))
-		return
+		return nil, rb, errors.Wrap(err, msg)
 	}
 
 	var iamPolicy *iam.Policy
-	if iamPolicy, err = iamService.CreatePolicy(r.Context(), &iam.CreatePolicyInput{
+	if iamPolicy, err = iamService.CreatePolicy(ctx, &iam.CreatePolicyInput{
 		Description:    aws.String(fmt.Sprintf("Admin policy for %s bucket", bucketName)),
 		PolicyDocument: aws.String(string(defaultPolicy)),
-		PolicyName:     aws.String(fmt.Sprintf("%s-BktAdmPlc", bucketName)),
+		PolicyName:     aws.String(iamapi.FormatPolicyName(bucketName, "/", "BktAdmPlc")),
+		Tags:           iamTags(req.Tags),
 	}); err != nil {
 		msg := fmt.Sprintf("failed to create policy: %s", err.Error())
-		handleError(w, errors.Wrap(err, msg))
-		return
+		return nil, rb, errors.Wrap(err, msg)
 	}
 
-	// append policy delete to rollback tasks
-	rollBackTasks = append(rollBackTasks, func(ctx context.Context) error {
-		if err := iamService.DeletePolicy(ctx, &iam.DeletePolicyInput{PolicyArn: iamPolicy.Arn}); err != nil {
-			return err
-		}
-		return nil
-	})
+	// append policy delete to rollback
+	rb.Add("delete policy "+aws.StringValue(iamPolicy.PolicyName), rollbackDeletePolicy, map[string]string{"policy_arn": aws.StringValue(iamPolicy.Arn)})
 
-	groupName := fmt.Sprintf("%s-BktAdmGrp", bucketName)
+	// the policy is attached to the shared admin group as is, the group is never created or deleted for a bucket
+	group := sharedGroup
+	if group == nil {
+		groupName := iamapi.FormatGroupName(bucketName, "/", "BktAdmGrp")
+		if group, err = iamService.CreateGroup(ctx, &iam.CreateGroupInput{
+			GroupName: aws.String(groupName),
+		}); err != nil {
+			msg := fmt.Sprintf("failed to create group: %s", err.Error())
+			return nil, rb, errors.Wrap(err, msg)
+		}
 
-	var group *iam.Group
-	if group, err = iamService.CreateGroup(r.Context(), &iam.CreateGroupInput{
-		GroupName: aws.String(groupName),
-	}); err != nil {
-		msg := fmt.Sprintf("failed to create group: %s", err.Error())
-		handleError(w, errors.Wrap(err, msg))
-		return
+		// append group delete to rollback
+		rb.Add("delete group "+groupName, rollbackDeleteGroup, map[string]string{"group": groupName})
 	}
 
-	// append group delete to rollback tasks
-	rollBackTasks = append(rollBackTasks, func(ctx context.Context) error {
-		if err := iamService.DeleteGroup(ctx, &iam.DeleteGroupInput{GroupName: aws.String(groupName)}); err != nil {
-			return err
-		}
-		return nil
-	})
-
-	if err = iamService.AttachGroupPolicy(r.Context(), &iam.AttachGroupPolicyInput{
-		GroupName: aws.String(groupName),
+	if err = iamService.AttachGroupPolicy(ctx, &iam.AttachGroupPolicyInput{
+		GroupName: group.GroupName,
 		PolicyArn: iamPolicy.Arn,
 	}); err != nil {
 		msg := fmt.Sprintf("failed to create group: %s", err.Error())
-		handleError(w, errors.Wrap(err, msg))
-		return
+		return nil, rb, errors.Wrap(err, msg)
 	}
 
-	output := struct {
-		Bucket *string
-		Policy *iam.Policy
-		Group  *iam.Group
-	}{
-		bucketOutput.Location,
-		iamPolicy,
-		group,
-	}
+	s.notify(webhook.EventBucketCreated, account, bucketName, nil)
 
-	j, err := json.Marshal(output)
-	if err != nil {
-		log.Errorf("cannot marshal reasponse(%v) into JSON: %s", output, err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(j)
+	return &bucketCreateOutput{
+		Bucket:         bucketOutput.Location,
+		Policy:         iamPolicy,
+		Group:          group,
+		ScratchExpires: scratchExpires,
+	}, rb, nil
 }
 
 // BucketListHandler gets a list of all buckets in the account
@@ -269,24 +374,9 @@ func (s *server) BucketListHandler(w http.ResponseWriter, r *http.Request) {
 	w = LogWriter{w}
 	vars := mux.Vars(r)
 	accountId := s.mapAccountNumber(vars["account"])
-	role := fmt.Sprintf("arn:aws:iam::%s:role/%s", accountId, s.session.RoleName)
-	policy, err := generatePolicy("s3:ListBucket")
+	session, err := s.sessionForAccountWithPolicies(r.Context(), accountId, []string{"arn:aws:iam::aws:policy/AmazonS3ReadOnlyAccess"}, "s3:ListBucket")
 	if err != nil {
-		log.Errorf("cannot generate policy: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	session, err := s.assumeRole(
-		r.Context(),
-		s.session.ExternalID,
-		role,
-		policy,
-		"arn:aws:iam::aws:policy/AmazonS3ReadOnlyAccess",
-	)
-	if err != nil {
-		log.Errorf("failed to assume role in account: %s", accountId)
-		w.WriteHeader(http.StatusInternalServerError)
+		handleError(w, err)
 		return
 	}
 
@@ -302,16 +392,7 @@ func (s *server) BucketListHandler(w http.ResponseWriter, r *http.Request) {
 		buckets = append(buckets, aws.StringValue(b.Name))
 	}
 
-	j, err := json.Marshal(buckets)
-	if err != nil {
-		log.Errorf("cannot marshal response (%v) into JSON: %s", buckets, err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(j)
+	writeConditionalJSON(w, r, buckets)
 }
 
 // BucketHeadHandler checks if a bucket exists
@@ -320,24 +401,9 @@ func (s *server) BucketHeadHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	accountId := s.mapAccountNumber(vars["account"])
 	bucket := vars["bucket"]
-	role := fmt.Sprintf("arn:aws:iam::%s:role/%s", accountId, s.session.RoleName)
-	policy, err := generatePolicy("s3:ListAllMyBuckets")
-	if err != nil {
-		log.Errorf("cannot generate policy: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	session, err := s.assumeRole(
-		r.Context(),
-		s.session.ExternalID,
-		role,
-		policy,
-		"arn:aws:iam::aws:policy/AmazonS3ReadOnlyAccess",
-	)
+	session, err := s.sessionForAccountWithPolicies(r.Context(), accountId, []string{"arn:aws:iam::aws:policy/AmazonS3ReadOnlyAccess"}, "s3:ListAllMyBuckets")
 	if err != nil {
-		log.Errorf("failed to assume role in account: %s", accountId)
-		w.WriteHeader(http.StatusInternalServerError)
+		handleError(w, err)
 		return
 	}
 
@@ -364,34 +430,57 @@ func (s *server) BucketHeadHandler(w http.ResponseWriter, r *http.Request) {
 // 2. a list of policies attached to the bucket admin group (<bucketName>-BktAdmGrp) is gathered
 // 3. each of those policies is detached from the group and if it starts with '<bucketName>-', it is deleted
 // 4. the bucket admin group is deleted
+// 5. the bucket admin policy is detached from any shared admin group and deleted, the shared group is left alone
+// Buckets with sftp enabled can't be deleted until it's disabled.
 func (s *server) BucketDeleteHandler(w http.ResponseWriter, r *http.Request) {
 	w = LogWriter{w}
 	vars := mux.Vars(r)
 	accountId := s.mapAccountNumber(vars["account"])
 	bucket := vars["bucket"]
 
-	role := fmt.Sprintf("arn:aws:iam::%s:role/%s", accountId, s.session.RoleName)
-	policy, err := generatePolicy("s3:*", "iam:*")
+	session, err := s.sessionForScope(r.Context(), accountId, s.withSharedAdminGroups(bucketDeletePolicy, "iam:DetachGroupPolicy"), policyScope{Bucket: bucket})
 	if err != nil {
-		log.Errorf("cannot generate policy: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		handleError(w, err)
 		return
 	}
 
-	session, err := s.assumeRole(
-		r.Context(),
-		s.session.ExternalID,
-		role,
-		policy,
-	)
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+	iamService := iamapi.NewSession(session.Session, s.account)
+	iamService.Cache = s.resourceCache(accountId)
+
+	lease, err := s.lockResource(r.Context(), vars["account"], bucket)
 	if err != nil {
-		log.Errorf("failed to assume role in account: %s", accountId)
-		w.WriteHeader(http.StatusInternalServerError)
+		handleError(w, err)
 		return
 	}
+	defer lease.Release()
 
-	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
-	iamService := iamapi.NewSession(session.Session, s.account)
+	if err := s.checkDeleteProtection(r.Context(), r, s3Service, bucket); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	// the sftp users of the bucket would be left without a bucket, so sftp has to be disabled first
+	if s.account.Transfer != nil {
+		if _, err := iamService.GetRole(r.Context(), sftpRoleName(bucket)); err == nil {
+			msg := fmt.Sprintf("sftp is enabled for bucket %s, disable it before deleting the bucket", bucket)
+			handleError(w, apierror.New(apierror.ErrConflict, msg, nil))
+			return
+		}
+	}
+
+	// the bucket policy denies the delete to the api too, so fail with a clear error instead of access denied
+	policy, err := s3Service.GetBucketPolicy(r.Context(), bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if hasDataProtection(policy) {
+		msg := fmt.Sprintf("data protection is enabled for bucket %s, disable it before deleting the bucket", bucket)
+		handleError(w, apierror.New(apierror.ErrConflict, msg, nil))
+		return
+	}
 
 	err = s3Service.DeleteEmptyBucket(r.Context(), &s3.DeleteBucketInput{Bucket: aws.String(bucket)})
 	if err != nil {
@@ -399,32 +488,48 @@ func (s *server) BucketDeleteHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := deleteBucketGroups(r.Context(), iamService, accountId, bucket); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s.notify(webhook.EventBucketDeleted, vars["account"], bucket, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte{})
+}
+
+// deleteBucketGroups deletes the management groups of a deleted bucket, along with their policies and the users in
+// them.  The admin policy of a bucket created with a shared admin group is removed from the group, which is kept.
+// Failures cleaning up the groups and policies are logged and the teardown continues.
+func deleteBucketGroups(ctx context.Context, iamService iamapi.IAM, accountId, bucket string) error {
 	for _, g := range []string{"BktAdmGrp", "BktRWGrp", "BktROGrp"} {
-		groupName := fmt.Sprintf("%s-%s", bucket, g)
+		groupName := iamapi.FormatGroupName(bucket, "/", g)
 
 		// TODO: if this fails with a NotFound, we should continue on because its probably a legacy bucket
-		policies, err := iamService.ListGroupPolicies(r.Context(), &iam.ListAttachedGroupPoliciesInput{GroupName: aws.String(groupName)})
+		policies, err := iamService.ListGroupPolicies(ctx, &iam.ListAttachedGroupPoliciesInput{GroupName: aws.String(groupName)})
 		if err != nil {
 			log.Warnf("failed to list group policies when deleting bucket %s: %s", bucket, err)
 			continue
 		}
 
 		for _, p := range policies {
-			if err := iamService.DetachGroupPolicy(r.Context(), &iam.DetachGroupPolicyInput{
+			if err := iamService.DetachGroupPolicy(ctx, &iam.DetachGroupPolicyInput{
 				GroupName: aws.String(groupName),
 				PolicyArn: p.PolicyArn,
 			}); err != nil {
 				log.Warnf("failed to detach policy %s from group %s when deleting bucket %s: %s", aws.StringValue(p.PolicyArn), groupName, bucket, err)
 			}
 
-			if strings.HasPrefix(aws.StringValue(p.PolicyName), bucket+"-") {
-				if err := iamService.DeletePolicy(r.Context(), &iam.DeletePolicyInput{PolicyArn: p.PolicyArn}); err != nil {
+			if iamapi.IsBucketPolicy(bucket, aws.StringValue(p.PolicyName)) {
+				if err := iamService.DeletePolicy(ctx, &iam.DeletePolicyInput{PolicyArn: p.PolicyArn}); err != nil {
 					log.Warnf("failed to delete group policy %s when deleting bucket %s: %s", aws.StringValue(p.PolicyArn), bucket, err)
 				}
 			}
 		}
 
-		users, err := iamService.ListGroupUsers(r.Context(), &iam.GetGroupInput{GroupName: aws.String(groupName)})
+		users, err := iamService.ListGroupUsers(ctx, &iam.GetGroupInput{GroupName: aws.String(groupName)})
 		if err != nil {
 			log.Warnf("failed to list group's users when deleting bucket %s: %s", bucket, err)
 			continue
@@ -432,37 +537,35 @@ func (s *server) BucketDeleteHandler(w http.ResponseWriter, r *http.Request) {
 
 		for _, u := range users {
 			// get a users access keys
-			keys, err := iamService.ListAccessKeys(r.Context(), &iam.ListAccessKeysInput{UserName: u.UserName})
+			keys, err := iamService.ListAccessKeys(ctx, &iam.ListAccessKeysInput{UserName: u.UserName})
 			if err != nil {
-				handleError(w, err)
-				return
+				return err
 			}
 
 			// delete the access keys
 			for _, k := range keys {
-				err = iamService.DeleteAccessKey(r.Context(), &iam.DeleteAccessKeyInput{UserName: u.UserName, AccessKeyId: k.AccessKeyId})
+				err = iamService.DeleteAccessKey(ctx, &iam.DeleteAccessKeyInput{UserName: u.UserName, AccessKeyId: k.AccessKeyId})
 				if err != nil {
-					handleError(w, err)
-					return
+					return err
 				}
 			}
 
-			if err := iamService.RemoveUserFromGroup(r.Context(), &iam.RemoveUserFromGroupInput{UserName: u.UserName, GroupName: aws.String(groupName)}); err != nil {
+			if err := iamService.RemoveUserFromGroup(ctx, &iam.RemoveUserFromGroupInput{UserName: u.UserName, GroupName: aws.String(groupName)}); err != nil {
 				log.Warnf("failed to remove user %s from group %s when deleting bucket %s: %s", aws.StringValue(u.UserName), groupName, bucket, err)
 			}
 		}
 
-		if err := iamService.DeleteGroup(r.Context(), &iam.DeleteGroupInput{GroupName: aws.String(groupName)}); err != nil {
+		if err := iamService.DeleteGroup(ctx, &iam.DeleteGroupInput{GroupName: aws.String(groupName)}); err != nil {
 			log.Warnf("failed to delete group %s when deleting bucket %s: %s", groupName, bucket, err)
 			continue
 		}
 
 		for _, u := range users {
-			_, err := iamService.GetUser(r.Context(), &iam.GetUserInput{
+			_, err := iamService.GetUser(ctx, &iam.GetUserInput{
 				UserName: u.UserName,
 			})
 			if err == nil {
-				err = iamService.DeleteUser(r.Context(), &iam.DeleteUserInput{UserName: u.UserName})
+				err = iamService.DeleteUserAndAttachments(ctx, aws.StringValue(u.UserName))
 				if err != nil {
 					log.Warnf("failed to delete user: %s, %s", aws.StringValue(u.UserName), err)
 				}
@@ -470,9 +573,11 @@ func (s *server) BucketDeleteHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte{})
+	if err := deleteSharedAdminPolicy(ctx, iamService, accountId, bucket); err != nil {
+		log.Warnf("failed to remove the admin policy from the shared admin group when deleting bucket %s: %s", bucket, err)
+	}
+
+	return nil
 }
 
 // BucketShowHandler returns information about a bucket
@@ -482,103 +587,204 @@ func (s *server) BucketShowHandler(w http.ResponseWriter, r *http.Request) {
 	accountId := s.mapAccountNumber(vars["account"])
 	bucket := vars["bucket"]
 
-	role := fmt.Sprintf("arn:aws:iam::%s:role/%s", accountId, s.session.RoleName)
-	policy, err := generatePolicy("s3:ListBucket")
+	actions := append([]string{"s3:ListBucket"}, bucketUsageActions...)
+	session, err := s.sessionForAccountWithPolicies(r.Context(), accountId, []string{"arn:aws:iam::aws:policy/AmazonS3ReadOnlyAccess"}, append(actions, bucketGroupActions...)...)
 	if err != nil {
-		log.Errorf("cannot generate policy: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		handleError(w, err)
 		return
 	}
 
-	session, err := s.assumeRole(
-		r.Context(),
-		s.session.ExternalID,
-		role,
-		policy,
-		"arn:aws:iam::aws:policy/AmazonS3ReadOnlyAccess",
-	)
+	s3Client := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+	cloudWatchService := cwapi.NewSession(session.Session, s.account)
+
+	output, err := getBucket(r.Context(), s3Client, cloudWatchService, bucket)
 	if err != nil {
-		log.Errorf("failed to assume role in account: %s", accountId)
-		w.WriteHeader(http.StatusInternalServerError)
+		handleError(w, err)
 		return
 	}
 
-	s3Client := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+	// the access points are informational, so a failure to list them isn't fatal
+	s3ControlService := s3controlapi.NewSession(session.Session, s.account, accountId)
+	if accessPoints, err := bucketAccessPoints(r.Context(), s3ControlService, bucket); err != nil {
+		log.Warnf("failed to list access points for bucket %s: %s", bucket, err)
+	} else if len(accessPoints) > 0 {
+		output.AccessPoints = accessPoints
+	}
+
+	// so are the management groups, with their users and policies
+	iamService := iamapi.NewSession(session.Session, s.account)
+	iamService.Cache = s.resourceCache(accountId)
+	if groups, err := bucketGroupDetails(r.Context(), iamService, bucket); err != nil {
+		log.Warnf("failed to get the management groups for bucket %s: %s", bucket, err)
+	} else {
+		output.Groups = groups
+	}
 
-	tags, err := s3Client.GetBucketTags(r.Context(), bucket)
+	// the policy etag is passed back in the If-Match header when updating the bucket policy
+	etag, err := bucketPolicyETag(r.Context(), s3Client, bucket)
 	if err != nil {
 		handleError(w, err)
 		return
 	}
 
-	empty, err := s3Client.BucketEmpty(r.Context(), bucket)
+	w.Header().Set("ETag", etag)
+	writeConditionalJSON(w, r, output)
+}
+
+// bucketShowOutput is the response from getting a bucket
+type bucketShowOutput struct {
+	Tags         []*s3.Tag
+	Logging      *s3.LoggingEnabled
+	Empty        bool
+	Usage        *bucketUsage
+	AccessPoints []*accessPointOutput `json:",omitempty"`
+	// Groups are the management groups of the bucket with their users and policies, they're only returned when
+	// getting a single bucket
+	Groups []*bucketGroupOutput `json:",omitempty"`
+	// VpcOnly is true if access to the bucket is restricted to the configured vpc endpoints
+	VpcOnly bool
+	// DataProtection is true if only the break-glass role can delete the bucket and its objects
+	DataProtection bool
+	// ScratchExpires is when a scratch bucket will be emptied and deleted
+	ScratchExpires *time.Time `json:",omitempty"`
+}
+
+const (
+	// bucketUsageSourceMetrics is the usage of a bucket from the daily cloudwatch storage metrics
+	bucketUsageSourceMetrics = "cloudwatch"
+	// bucketUsageSourceScan is the usage of a bucket from listing its objects
+	bucketUsageSourceScan = "scan"
+	// maxBucketUsageScan is the most objects counted when there aren't any storage metrics for a bucket
+	maxBucketUsageScan = 10000
+)
+
+// bucketUsageActions are the actions needed to get the storage metrics for a bucket
+var bucketUsageActions = []string{
+	"cloudwatch:GetMetricData",
+	"cloudwatch:ListMetrics",
+}
+
+// bucketUsage is the number of objects in a bucket and their total size.  The cloudwatch storage metrics
+// are published once a day so they can be behind, the timestamp is the time of the metrics.  When there
+// aren't any metrics the objects are counted, and the usage is truncated if there are too many to count.
+type bucketUsage struct {
+	Objects   int64
+	Bytes     int64
+	Source    string
+	Timestamp *time.Time `json:",omitempty"`
+	Truncated bool
+}
+
+// getBucket gets the tags, logging configuration, whether a bucket is empty, its storage usage and whether it's vpc-only
+func getBucket(ctx context.Context, s3Service s3api.S3, cloudWatchService cwapi.CloudWatch, bucket string) (*bucketShowOutput, error) {
+	tags, err := s3Service.GetBucketTags(ctx, bucket)
 	if err != nil {
-		handleError(w, err)
-		return
+		return nil, err
 	}
 
-	logging, err := s3Client.GetBucketLogging(r.Context(), bucket)
+	empty, err := s3Service.BucketEmpty(ctx, bucket)
 	if err != nil {
-		handleError(w, err)
-		return
+		return nil, err
+	}
+
+	logging, err := s3Service.GetBucketLogging(ctx, bucket)
+	if err != nil {
+		return nil, err
 	}
 
-	// setup output struct
-	output := struct {
-		Tags    []*s3.Tag
-		Logging *s3.LoggingEnabled
-		Empty   bool
-	}{
-		Tags:    tags,
-		Logging: logging,
-		Empty:   empty,
+	usage, err := getBucketUsage(ctx, s3Service, cloudWatchService, bucket, empty)
+	if err != nil {
+		return nil, err
 	}
 
-	j, err := json.Marshal(output)
+	policy, err := s3Service.GetBucketPolicy(ctx, bucket)
 	if err != nil {
-		log.Errorf("cannot marshal response (%v) into JSON: %s", output, err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
+		return nil, err
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(j)
+	output := &bucketShowOutput{
+		Tags:           tags,
+		Logging:        logging,
+		Empty:          empty,
+		Usage:          usage,
+		VpcOnly:        isVpcOnly(policy),
+		DataProtection: hasDataProtection(policy),
+	}
+
+	if expires, scratch := scratchExpireTime(tags); scratch {
+		output.ScratchExpires = &expires
+	}
+
+	return output, nil
+}
+
+// getBucketUsage gets the storage usage of a bucket from the cloudwatch storage metrics, falling back to
+// counting the objects if the metrics can't be read or the bucket is too new to have any.
+func getBucketUsage(ctx context.Context, s3Service s3api.S3, cloudWatchService cwapi.CloudWatch, bucket string, empty bool) (*bucketUsage, error) {
+	storage, err := cloudWatchService.GetBucketStorage(ctx, bucket)
+	if err != nil {
+		log.Warnf("failed to get storage metrics for bucket %s, counting the objects instead: %s", bucket, err)
+	}
+
+	if storage != nil {
+		return &bucketUsage{
+			Objects:   storage.Objects,
+			Bytes:     storage.Bytes,
+			Source:    bucketUsageSourceMetrics,
+			Timestamp: &storage.Timestamp,
+		}, nil
+	}
+
+	if empty {
+		return &bucketUsage{Source: bucketUsageSourceScan}, nil
+	}
+
+	objects, size, truncated, err := s3Service.BucketUsage(ctx, bucket, maxBucketUsageScan)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bucketUsage{
+		Objects:   objects,
+		Bytes:     size,
+		Source:    bucketUsageSourceScan,
+		Truncated: truncated,
+	}, nil
+}
+
+// bucketUpdateOutput is the response from updating a bucket
+type bucketUpdateOutput struct {
+	// PolicyFindings are the access analyzer findings about the bucket policy that didn't keep it from being applied
+	PolicyFindings []*aaapi.Finding `json:",omitempty"`
 }
 
 // BucketUpdateHandler handles updating making changes to a bucket.  Currently supports:
-// - Updating the bucket's tags
+// - Updating the bucket's tags, which are synced to the bucket's distribution and IAM resources
+// - Updating the bucket's policy
+// - Restricting the bucket to the configured vpc endpoints, or lifting the restriction
 func (s *server) BucketUpdateHandler(w http.ResponseWriter, r *http.Request) {
 	w = LogWriter{w}
 	vars := mux.Vars(r)
 	accountId := s.mapAccountNumber(vars["account"])
 	bucket := vars["bucket"]
-	role := fmt.Sprintf("arn:aws:iam::%s:role/%s", accountId, s.session.RoleName)
-	policy, err := generatePolicy("s3:PutBucketTagging", "s3:PutBucketPolicy")
+	session, err := s.sessionForAccountWithPolicies(r.Context(), accountId, []string{"arn:aws:iam::aws:policy/AmazonS3FullAccess"}, append(append([]string{"s3:PutBucketTagging", "s3:PutBucketPolicy"}, tagSyncActions...), policyValidationActions...)...)
 	if err != nil {
-		log.Errorf("cannot generate policy: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	session, err := s.assumeRole(
-		r.Context(),
-		s.session.ExternalID,
-		role,
-		policy,
-		"arn:aws:iam::aws:policy/AmazonS3FullAccess",
-	)
-	if err != nil {
-		log.Errorf("failed to assume role in account: %s", accountId)
-		w.WriteHeader(http.StatusInternalServerError)
+		handleError(w, err)
 		return
 	}
 
 	s3Client := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+	iamService := iamapi.NewSession(session.Session, s.account)
+	iamService.Cache = s.resourceCache(accountId)
+	cloudFrontService := cfapi.NewSession(session.Session, s.account, accountId)
+	cloudFrontService.Index = s.distributionIndex(accountId)
 
 	var req struct {
 		BucketPolicy *string
 		Tags         []*s3.Tag
+		// VpcOnly restricts the bucket to the configured vpc endpoints when true and lifts the restriction when
+		// false, the restriction is kept when a new policy is applied without it
+		VpcOnly *bool
 	}
 	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
@@ -587,34 +793,140 @@ func (s *server) BucketUpdateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// append org tag that will get applied to all resources that tag
-	req.Tags = append(req.Tags, &s3.Tag{
-		Key:   aws.String("spinup:org"),
-		Value: aws.String(Org),
-	})
+	f := fieldErrors{}
+	f.tags("Tags", req.Tags)
+	if len(req.Tags) > 0 {
+		f.requiredTags("Tags", req.Tags, s.requiredTags)
+	}
+	f.policyDocument("BucketPolicy", req.BucketPolicy)
+	if err = f.err(); err != nil {
+		handleError(w, err)
+		return
+	}
 
-	// If there are tags to update
+	// make sure the policy hasn't changed since it was read if there are preconditions, or they're required
+	if req.BucketPolicy != nil || hasPreconditions(r) {
+		etag, err := bucketPolicyETag(r.Context(), s3Client, bucket)
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		if err := s.checkPreconditions(r, etag); err != nil {
+			handleError(w, err)
+			return
+		}
+	}
+
+	// validate the policy before anything is changed
+	var output bucketUpdateOutput
+	if req.BucketPolicy != nil {
+		if output.PolicyFindings, err = s.validateBucketPolicy(r.Context(), session.Session, aws.StringValue(req.BucketPolicy)); err != nil {
+			handleError(w, err)
+			return
+		}
+	}
+
+	// If there are tags to update, they replace all of the tags on the bucket
 	if len(req.Tags) > 0 {
+		current, err := s3Client.GetBucketTags(r.Context(), bucket)
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		// append org tag that will get applied to all resources that tag, and keep the reserved tags
+		req.Tags = keepReservedTags(current, append(req.Tags, &s3.Tag{
+			Key:   aws.String("spinup:org"),
+			Value: aws.String(Org),
+		}))
+
 		err = s3Client.TagBucket(r.Context(), bucket, req.Tags)
 		if err != nil {
 			msg := fmt.Sprintf("failed to tag bucket %s: %s", bucket, err.Error())
 			handleError(w, apierror.New(apierror.ErrInternalError, msg, err))
 			return
 		}
+
+		if _, err = syncBucketTags(r.Context(), iamService, cloudFrontService, s.requiredTags, bucket, req.Tags); err != nil {
+			msg := fmt.Sprintf("failed to sync tags for bucket %s: %s", bucket, err.Error())
+			handleError(w, errors.Wrap(err, msg))
+			return
+		}
 	}
 
 	// If there is a policy to update
-	if req.BucketPolicy != nil {
-		if err = s3Client.UpdateBucketPolicy(r.Context(), &s3.PutBucketPolicyInput{
-			Bucket: aws.String(bucket),
-			Policy: aws.String(string(*req.BucketPolicy)),
-		}); err != nil {
+	if req.BucketPolicy != nil || req.VpcOnly != nil {
+		if err = s.updateBucketPolicy(r.Context(), s3Client, accountId, bucket, req.BucketPolicy, req.VpcOnly); err != nil {
 			handleError(w, err)
 			return
 		}
 	}
 
+	// the body is only returned if there are findings about the policy
+	if len(output.PolicyFindings) == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte{})
+		return
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	w.Write([]byte{})
+	w.Write(j)
+}
+
+// updateBucketPolicy applies a new bucket policy and/or the vpc-only restriction.  When the vpc-only setting isn't
+// passed, the current restriction is kept so a new policy can't accidentally open a vpc-only bucket.  The policy is
+// deleted if lifting the restriction leaves it empty.
+func (s *server) updateBucketPolicy(ctx context.Context, s3Service s3api.S3, accountId, bucket string, bucketPolicy *string, vpcOnly *bool) error {
+	current, err := s3Service.GetBucketPolicy(ctx, bucket)
+	if err != nil {
+		return err
+	}
+
+	policy := current
+	if bucketPolicy != nil {
+		policy = aws.StringValue(bucketPolicy)
+	}
+
+	// the policy is only rewritten when the restriction has to be added or removed
+	restrict := isVpcOnly(current)
+	if vpcOnly != nil {
+		restrict = aws.BoolValue(vpcOnly)
+	}
+
+	remaining := -1
+	if restrict || isVpcOnly(policy) {
+		if policy, remaining, err = s.vpcOnlyPolicy(accountId, bucket, policy, restrict); err != nil {
+			if _, ok := common.AsAPIError(err); ok {
+				return err
+			}
+			msg := fmt.Sprintf("failed to update the vpc-only policy for bucket %s", bucket)
+			return apierror.New(apierror.ErrBadRequest, msg, err)
+		}
+	}
+
+	if remaining == 0 {
+		if current == "" {
+			return nil
+		}
+		return s3Service.DeleteBucketPolicy(ctx, bucket)
+	}
+
+	if policy == current {
+		return nil
+	}
+
+	return s3Service.UpdateBucketPolicy(ctx, &s3.PutBucketPolicyInput{
+		Bucket: aws.String(bucket),
+		Policy: aws.String(policy),
+	})
 }
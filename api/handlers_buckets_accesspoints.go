@@ -0,0 +1,293 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/YaleSpinup/apierror"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	s3controlapi "github.com/YaleSpinup/s3-api/s3control"
+	"github.com/YaleSpinup/s3-api/webhook"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3control"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// accessPointRead and accessPointReadWrite are the levels of access granted through an access point
+	accessPointRead      = "read"
+	accessPointReadWrite = "readwrite"
+)
+
+// accessPointRequest is the request to create an access point for a bucket
+type accessPointRequest struct {
+	Name string
+	// VpcId restricts the access point to requests from the vpc, otherwise it's reachable from the internet
+	VpcId string
+	// Prefix limits the access point to the objects under the prefix
+	Prefix string
+	// Access is read (the default) or readwrite
+	Access string
+	// Principals are granted access through the access point, they default to the account
+	Principals []string
+}
+
+// validate validates the request to create an access point
+func (r *accessPointRequest) validate() error {
+	f := fieldErrors{}
+	f.accessPoint("Name", r.Name)
+	f.vpcId("VpcId", r.VpcId)
+	if strings.ContainsAny(r.Prefix, "*?") {
+		f.add("Prefix", "prefix %q can't contain wildcards", r.Prefix)
+	}
+	if r.Access != "" && r.Access != accessPointRead && r.Access != accessPointReadWrite {
+		f.add("Access", "unsupported access %q, must be one of %s, %s", r.Access, accessPointRead, accessPointReadWrite)
+	}
+	f.principals("Principals", r.Principals)
+	return f.err()
+}
+
+// accessPointOutput is an access point of a bucket
+type accessPointOutput struct {
+	Name          string
+	Alias         string
+	Arn           string
+	NetworkOrigin string
+	VpcId         string `json:",omitempty"`
+}
+
+// newAccessPointOutput returns the output for an access point in a list of access points
+func newAccessPointOutput(ap *s3control.AccessPoint) *accessPointOutput {
+	output := &accessPointOutput{
+		Name:          aws.StringValue(ap.Name),
+		Alias:         aws.StringValue(ap.Alias),
+		Arn:           aws.StringValue(ap.AccessPointArn),
+		NetworkOrigin: aws.StringValue(ap.NetworkOrigin),
+	}
+
+	if ap.VpcConfiguration != nil {
+		output.VpcId = aws.StringValue(ap.VpcConfiguration.VpcId)
+	}
+
+	return output
+}
+
+// accessPointServices returns the s3 and s3 control services for managing the access points of a bucket
+func (s *server) accessPointServices(ctx context.Context, account, bucket string) (s3api.S3, s3controlapi.S3Control, error) {
+	accountId := s.mapAccountNumber(account)
+
+	session, err := s.sessionForScope(ctx, accountId, bucketAccessPointPolicy, policyScope{Bucket: bucket})
+	if err != nil {
+		return s3api.S3{}, s3controlapi.S3Control{}, err
+	}
+
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+	s3ControlService := s3controlapi.NewSession(session.Session, s.account, accountId)
+
+	return s3Service, s3ControlService, nil
+}
+
+// BucketAccessPointListHandler lists the access points of a bucket
+func (s *server) BucketAccessPointListHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	_, s3ControlService, err := s.accessPointServices(r.Context(), vars["account"], bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	output, err := bucketAccessPoints(r.Context(), s3ControlService, bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// BucketAccessPointCreateHandler creates an access point for a bucket with a policy granting the principals read or
+// read-write access to the objects through the access point.  The access point is deleted if the policy can't be
+// applied.
+func (s *server) BucketAccessPointCreateHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	bucket := vars["bucket"]
+
+	var req accessPointRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		msg := fmt.Sprintf("cannot decode body into create access point input: %s", err)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+		return
+	}
+
+	if err := req.validate(); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s3Service, s3ControlService, err := s.accessPointServices(r.Context(), vars["account"], bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	lease, err := s.lockResource(r.Context(), vars["account"], bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	defer lease.Release()
+
+	output, err := s.createAccessPoint(r.Context(), s3Service, s3ControlService, vars["account"], accountId, bucket, req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s.notify(webhook.EventAccessPointCreated, vars["account"], bucket, map[string]string{"Name": output.Name, "Alias": output.Alias})
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// BucketAccessPointDeleteHandler deletes an access point of a bucket
+func (s *server) BucketAccessPointDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	name := vars["name"]
+
+	_, s3ControlService, err := s.accessPointServices(r.Context(), vars["account"], bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	lease, err := s.lockResource(r.Context(), vars["account"], bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	defer lease.Release()
+
+	if err := deleteAccessPoint(r.Context(), s3ControlService, bucket, name); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s.notify(webhook.EventAccessPointDeleted, vars["account"], bucket, map[string]string{"Name": name})
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte{})
+}
+
+// bucketAccessPoints returns the access points of a bucket
+func bucketAccessPoints(ctx context.Context, s3ControlService s3controlapi.S3Control, bucket string) ([]*accessPointOutput, error) {
+	accessPoints, err := s3ControlService.ListAccessPoints(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	output := []*accessPointOutput{}
+	for _, ap := range accessPoints {
+		output = append(output, newAccessPointOutput(ap))
+	}
+
+	return output, nil
+}
+
+// createAccessPoint creates an access point for a bucket and applies its policy, the access point is rolled back if
+// the policy fails
+func (s *server) createAccessPoint(ctx context.Context, s3Service s3api.S3, s3ControlService s3controlapi.S3Control, account, accountId, bucket string, req accessPointRequest) (output *accessPointOutput, err error) {
+	exists, err := s3Service.BucketExists(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		msg := fmt.Sprintf("bucket %s not found", bucket)
+		return nil, apierror.New(apierror.ErrNotFound, msg, nil)
+	}
+
+	principals := req.Principals
+	if len(principals) == 0 {
+		principals = []string{fmt.Sprintf("arn:aws:iam::%s:root", accountId)}
+	}
+
+	policyDoc, err := iamapi.AccessPointPolicy(s3ControlService.AccessPointArn(req.Name), req.Prefix, principals, req.Access == accessPointReadWrite)
+	if err != nil {
+		msg := fmt.Sprintf("failed building policy for access point %s: %s", req.Name, err)
+		return nil, apierror.New(apierror.ErrInternalError, msg, err)
+	}
+
+	rb := s.newRollback("accesspoint.create", account, bucket, rollbackServices{s3Control: &s3ControlService})
+	defer func() {
+		s.finishRollback(rb, err)
+	}()
+
+	arn, alias, err := s3ControlService.CreateAccessPoint(ctx, bucket, req.Name, req.VpcId)
+	if err != nil {
+		return nil, err
+	}
+
+	rb.Add("delete access point "+req.Name, rollbackDeleteAccessPoint, map[string]string{"name": req.Name})
+
+	if err = s3ControlService.PutAccessPointPolicy(ctx, req.Name, policyDoc); err != nil {
+		return nil, err
+	}
+
+	output = &accessPointOutput{
+		Name:          req.Name,
+		Alias:         alias,
+		Arn:           arn,
+		NetworkOrigin: s3control.NetworkOriginInternet,
+	}
+
+	if req.VpcId != "" {
+		output.NetworkOrigin = s3control.NetworkOriginVpc
+		output.VpcId = req.VpcId
+	}
+
+	return output, nil
+}
+
+// deleteAccessPoint deletes an access point, access points of other buckets are not found
+func deleteAccessPoint(ctx context.Context, s3ControlService s3controlapi.S3Control, bucket, name string) error {
+	ap, err := s3ControlService.GetAccessPoint(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if aws.StringValue(ap.Bucket) != bucket {
+		msg := fmt.Sprintf("access point %s not found for bucket %s", name, bucket)
+		return apierror.New(apierror.ErrNotFound, msg, nil)
+	}
+
+	return s3ControlService.DeleteAccessPoint(ctx, name)
+}
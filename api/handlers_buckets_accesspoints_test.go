@@ -0,0 +1,182 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	s3controlapi "github.com/YaleSpinup/s3-api/s3control"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3control"
+	"github.com/aws/aws-sdk-go/service/s3control/s3controliface"
+)
+
+// mockAccessPointS3Control is an s3 control client that tracks the access points of the account and their policies
+type mockAccessPointS3Control struct {
+	s3controliface.S3ControlAPI
+	accessPoints map[string]*s3control.AccessPoint
+	policies     map[string]string
+	failPolicy   bool
+}
+
+func (m *mockAccessPointS3Control) CreateAccessPointWithContext(ctx context.Context, input *s3control.CreateAccessPointInput, opts ...request.Option) (*s3control.CreateAccessPointOutput, error) {
+	name := aws.StringValue(input.Name)
+	if _, ok := m.accessPoints[name]; ok {
+		return nil, awserr.New("AccessPointAlreadyOwnedByYou", "exists", nil)
+	}
+
+	ap := &s3control.AccessPoint{
+		AccessPointArn: aws.String("arn:aws:s3:us-east-1:012345678910:accesspoint/" + name),
+		Alias:          aws.String(name + "-abcdef-s3alias"),
+		Bucket:         input.Bucket,
+		Name:           input.Name,
+		NetworkOrigin:  aws.String(s3control.NetworkOriginInternet),
+	}
+	if input.VpcConfiguration != nil {
+		ap.NetworkOrigin = aws.String(s3control.NetworkOriginVpc)
+		ap.VpcConfiguration = input.VpcConfiguration
+	}
+	m.accessPoints[name] = ap
+
+	return &s3control.CreateAccessPointOutput{AccessPointArn: ap.AccessPointArn, Alias: ap.Alias}, nil
+}
+
+func (m *mockAccessPointS3Control) GetAccessPointWithContext(ctx context.Context, input *s3control.GetAccessPointInput, opts ...request.Option) (*s3control.GetAccessPointOutput, error) {
+	ap, ok := m.accessPoints[aws.StringValue(input.Name)]
+	if !ok {
+		return nil, awserr.New("NoSuchAccessPoint", "not found", nil)
+	}
+	return &s3control.GetAccessPointOutput{Bucket: ap.Bucket, Name: ap.Name, NetworkOrigin: ap.NetworkOrigin}, nil
+}
+
+func (m *mockAccessPointS3Control) ListAccessPointsPagesWithContext(ctx context.Context, input *s3control.ListAccessPointsInput, fn func(*s3control.ListAccessPointsOutput, bool) bool, opts ...request.Option) error {
+	list := []*s3control.AccessPoint{}
+	for _, ap := range m.accessPoints {
+		if aws.StringValue(ap.Bucket) == aws.StringValue(input.Bucket) {
+			list = append(list, ap)
+		}
+	}
+	fn(&s3control.ListAccessPointsOutput{AccessPointList: list}, true)
+	return nil
+}
+
+func (m *mockAccessPointS3Control) DeleteAccessPointWithContext(ctx context.Context, input *s3control.DeleteAccessPointInput, opts ...request.Option) (*s3control.DeleteAccessPointOutput, error) {
+	delete(m.accessPoints, aws.StringValue(input.Name))
+	delete(m.policies, aws.StringValue(input.Name))
+	return &s3control.DeleteAccessPointOutput{}, nil
+}
+
+func (m *mockAccessPointS3Control) PutAccessPointPolicyWithContext(ctx context.Context, input *s3control.PutAccessPointPolicyInput, opts ...request.Option) (*s3control.PutAccessPointPolicyOutput, error) {
+	if m.failPolicy {
+		return nil, awserr.New("MalformedPolicy", "bad policy", nil)
+	}
+	m.policies[aws.StringValue(input.Name)] = aws.StringValue(input.Policy)
+	return &s3control.PutAccessPointPolicyOutput{}, nil
+}
+
+func TestAccessPointRequestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		req   accessPointRequest
+		valid bool
+	}{
+		{name: "minimal", req: accessPointRequest{Name: "team-a"}, valid: true},
+		{
+			name: "everything",
+			req: accessPointRequest{
+				Name:       "team-a",
+				VpcId:      "vpc-0123456789abcdef0",
+				Prefix:     "shared/",
+				Access:     accessPointReadWrite,
+				Principals: []string{"arn:aws:iam::012345678910:role/analysis", "arn:aws:iam::109876543210:root"},
+			},
+			valid: true,
+		},
+		{name: "uppercase name", req: accessPointRequest{Name: "Team-A"}},
+		{name: "trailing hyphen", req: accessPointRequest{Name: "team-"}},
+		{name: "bad vpc", req: accessPointRequest{Name: "team-a", VpcId: "vpc-xyz"}},
+		{name: "wildcard prefix", req: accessPointRequest{Name: "team-a", Prefix: "shared/*"}},
+		{name: "bad access", req: accessPointRequest{Name: "team-a", Access: "admin"}},
+		{name: "bad principal", req: accessPointRequest{Name: "team-a", Principals: []string{"*"}}},
+	}
+
+	for _, test := range tests {
+		err := test.req.validate()
+		if test.valid && err != nil {
+			t.Errorf("%s: expected valid request, got %s", test.name, err)
+		} else if !test.valid && !common.IsErrorCode(err, apierror.ErrBadRequest) {
+			t.Errorf("%s: expected bad request, got %v", test.name, err)
+		}
+	}
+}
+
+func TestAccessPoints(t *testing.T) {
+	s := &server{}
+	s3Service := s3api.S3{Service: &mockAdoptS3{}}
+	client := &mockAccessPointS3Control{
+		accessPoints: map[string]*s3control.AccessPoint{},
+		policies:     map[string]string{},
+	}
+	s3ControlService := s3controlapi.S3Control{Service: client, AccountID: "012345678910", Region: "us-east-1"}
+
+	out, err := s.createAccessPoint(context.TODO(), s3Service, s3ControlService, "spinup", "012345678910", "dataset", accessPointRequest{
+		Name:  "team-a",
+		VpcId: "vpc-0123456789abcdef0",
+	})
+	if err != nil {
+		t.Fatalf("expected nil error creating access point, got %s", err)
+	}
+
+	if out.Alias != "team-a-abcdef-s3alias" || out.NetworkOrigin != s3control.NetworkOriginVpc || out.VpcId != "vpc-0123456789abcdef0" {
+		t.Errorf("unexpected access point output %+v", out)
+	}
+
+	if _, ok := client.policies["team-a"]; !ok {
+		t.Error("expected a policy for access point team-a")
+	}
+
+	if _, err := s.createAccessPoint(context.TODO(), s3Service, s3ControlService, "spinup", "012345678910", "dataset", accessPointRequest{Name: "team-a"}); !common.IsErrorCode(err, apierror.ErrConflict) {
+		t.Errorf("expected conflict for an existing access point, got %v", err)
+	}
+
+	// a failure to apply the policy rolls back the access point
+	client.failPolicy = true
+	if _, err := s.createAccessPoint(context.TODO(), s3Service, s3ControlService, "spinup", "012345678910", "dataset", accessPointRequest{Name: "team-b"}); err == nil {
+		t.Error("expected error when the policy fails, got nil")
+	}
+
+	if _, ok := client.accessPoints["team-b"]; ok {
+		t.Error("expected access point team-b to be rolled back")
+	}
+	client.failPolicy = false
+
+	if _, err := s.createAccessPoint(context.TODO(), s3api.S3{Service: &mockAdoptS3{missing: true}}, s3ControlService, "spinup", "012345678910", "missing", accessPointRequest{Name: "team-c"}); !common.IsErrorCode(err, apierror.ErrNotFound) {
+		t.Errorf("expected not found for a missing bucket, got %v", err)
+	}
+
+	list, err := bucketAccessPoints(context.TODO(), s3ControlService, "dataset")
+	if err != nil {
+		t.Fatalf("expected nil error listing access points, got %s", err)
+	}
+
+	if len(list) != 1 || list[0].Name != "team-a" || list[0].VpcId != "vpc-0123456789abcdef0" {
+		t.Errorf("unexpected access points %+v", list)
+	}
+
+	// access points of other buckets aren't found through the bucket
+	if err := deleteAccessPoint(context.TODO(), s3ControlService, "other", "team-a"); !common.IsErrorCode(err, apierror.ErrNotFound) {
+		t.Errorf("expected not found deleting through another bucket, got %v", err)
+	}
+
+	if err := deleteAccessPoint(context.TODO(), s3ControlService, "dataset", "team-a"); err != nil {
+		t.Errorf("expected nil error deleting access point, got %s", err)
+	}
+
+	if len(client.accessPoints) != 0 {
+		t.Errorf("expected no access points, got %d", len(client.accessPoints))
+	}
+}
@@ -0,0 +1,234 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/YaleSpinup/apierror"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/YaleSpinup/s3-api/webhook"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// bucketAdoptRequest is the request to adopt an existing bucket
+type bucketAdoptRequest struct {
+	// Tags are added to the bucket's existing tags, replacing the existing tags with the same keys
+	Tags []*s3.Tag
+	// ObjectTags are the default tags for the objects created in the bucket by the api
+	ObjectTags []*s3.Tag `json:",omitempty"`
+}
+
+// validate validates the request to adopt a bucket, the required tags are checked once they're merged with the
+// bucket's existing tags
+func (r *bucketAdoptRequest) validate() error {
+	f := fieldErrors{}
+	f.tags("Tags", r.Tags)
+	f.objectTags("ObjectTags", r.ObjectTags)
+	return f.err()
+}
+
+// bucketAdoptOutput is the result of adopting a bucket, Changes lists the changes that were made
+type bucketAdoptOutput struct {
+	Bucket  string
+	Changes []string
+}
+
+func (o *bucketAdoptOutput) add(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	log.Infof("adopt bucket %s: %s", o.Bucket, msg)
+	o.Changes = append(o.Changes, msg)
+}
+
+// BucketAdoptHandler brings an existing bucket that wasn't created by the api under management.  The bucket gets
+// the same configuration as a bucket created by the api:
+// 1. enable AES256 default encryption if the bucket doesn't have default encryption
+// 2. enable access logging if it's configured and the bucket isn't logging
+// 3. create the admin bucket policy and group, '<bucketName>-BktAdmGrp', if they're missing and attach the policy
+// 4. tag the bucket with the passed tags and the org tag
+// Buckets that already have the org tag are managed and can't be adopted.  The bucket is tagged last, so an adoption
+// that fails part way through can be repeated.
+func (s *server) BucketAdoptHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	bucket := vars["bucket"]
+
+	var req bucketAdoptRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		msg := fmt.Sprintf("cannot decode body into adopt bucket input: %s", err)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+		return
+	}
+
+	if err := req.validate(); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	session, err := s.sessionForScope(r.Context(), accountId, bucketAdoptPolicy, policyScope{Bucket: bucket})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+	iamService := iamapi.NewSession(session.Session, s.account)
+	iamService.Cache = s.resourceCache(accountId)
+
+	lease, err := s.lockResource(r.Context(), vars["account"], bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	defer lease.Release()
+
+	output, err := s.adoptBucket(r.Context(), s3Service, iamService, accountId, bucket, req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s.notify(webhook.EventBucketAdopted, vars["account"], bucket, map[string]interface{}{"Changes": output.Changes})
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// adoptBucket applies the standard configuration, admin group and tags to an existing unmanaged bucket
+func (s *server) adoptBucket(ctx context.Context, s3Service s3api.S3, iamService iamapi.IAM, accountId, bucket string, req bucketAdoptRequest) (*bucketAdoptOutput, error) {
+	exists, err := s3Service.BucketExists(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		msg := fmt.Sprintf("bucket %s not found", bucket)
+		return nil, apierror.New(apierror.ErrNotFound, msg, nil)
+	}
+
+	current, err := s3Service.GetBucketTags(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	currentTags := s3TagMap(current)
+	if org, ok := currentTags["spinup:org"]; ok {
+		msg := fmt.Sprintf("bucket %s is already managed by %s", bucket, org)
+		return nil, apierror.New(apierror.ErrConflict, msg, nil)
+	}
+
+	tags := mergeTags(current, req.Tags)
+
+	f := fieldErrors{}
+	if len(tags) > maxTags {
+		f.add("Tags", "at most %d tags are allowed, the bucket would have %d", maxTags, len(tags))
+	}
+	f.requiredTags("Tags", tags, s.requiredTags)
+	if err := f.err(); err != nil {
+		return nil, err
+	}
+
+	// append org tag that will get applied to all resources that tag
+	tags = append(tags, &s3.Tag{
+		Key:   aws.String("spinup:org"),
+		Value: aws.String(Org),
+	})
+
+	output := &bucketAdoptOutput{Bucket: bucket, Changes: []string{}}
+
+	encryption, err := s3Service.GetBucketEncryption(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	// an existing default encryption configuration, ie. with a kms key, is kept
+	if encryptionAlgorithm(encryption) == "" {
+		if err := s3Service.UpdateBucketEncryption(ctx, &s3.PutBucketEncryptionInput{
+			Bucket: aws.String(bucket),
+			ServerSideEncryptionConfiguration: &s3.ServerSideEncryptionConfiguration{
+				Rules: []*s3.ServerSideEncryptionRule{
+					{
+						ApplyServerSideEncryptionByDefault: &s3.ServerSideEncryptionByDefault{
+							SSEAlgorithm: aws.String(s3.ServerSideEncryptionAes256),
+						},
+					},
+				},
+			},
+		}); err != nil {
+			return nil, err
+		}
+
+		output.add("enabled %s default encryption", s3.ServerSideEncryptionAes256)
+	}
+
+	if s3Service.LoggingBucket != "" {
+		logging, err := s3Service.GetBucketLogging(ctx, bucket)
+		if err != nil {
+			return nil, err
+		}
+
+		if logging == nil {
+			if err := s3Service.UpdateBucketLogging(ctx, bucket, s3Service.LoggingBucket, s3Service.LoggingBucketPrefix); err != nil {
+				return nil, err
+			}
+
+			output.add("enabled access logging to %s", s3Service.LoggingBucket)
+		}
+	}
+
+	policy, err := iamService.DefaultBucketAdminPolicy(aws.String(bucket), s.ipAllowlist(nil))
+	if err != nil {
+		msg := fmt.Sprintf("failed building default IAM policy for bucket %s: %s", bucket, err.Error())
+		return nil, apierror.New(apierror.ErrInternalError, msg, err)
+	}
+
+	if err := repairAdminGroup(ctx, iamService, output, accountId,
+		iamapi.FormatPolicyName(bucket, "/", "BktAdmPlc"),
+		fmt.Sprintf("Admin policy for %s bucket", bucket),
+		policy,
+		iamapi.FormatGroupName(bucket, "/", "BktAdmGrp"),
+		iamTags(tags),
+		false,
+	); err != nil {
+		return nil, err
+	}
+
+	bucketTags := tags
+	if req.ObjectTags != nil {
+		bucketTags = withDefaultObjectTags(tags, req.ObjectTags)
+	}
+
+	// the org tag marks the bucket as managed, so it's applied last
+	if err := s3Service.TagBucket(ctx, bucket, bucketTags); err != nil {
+		return nil, err
+	}
+
+	for _, t := range tags {
+		key := aws.StringValue(t.Key)
+		if value, ok := currentTags[key]; !ok {
+			output.add("added tag %s", key)
+		} else if value != aws.StringValue(t.Value) {
+			output.add("updated tag %s", key)
+		}
+	}
+
+	if len(req.ObjectTags) > 0 {
+		output.add("set %d default object tags", len(req.ObjectTags))
+	}
+
+	return output, nil
+}
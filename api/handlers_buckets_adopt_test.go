@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// mockAdoptS3 is an s3 client for a single existing bucket that keeps its tags, encryption and logging
+type mockAdoptS3 struct {
+	s3iface.S3API
+	missing    bool
+	tags       []*s3.Tag
+	encryption *s3.ServerSideEncryptionConfiguration
+	logging    *s3.LoggingEnabled
+}
+
+func (m *mockAdoptS3) HeadBucketWithContext(ctx context.Context, input *s3.HeadBucketInput, opts ...request.Option) (*s3.HeadBucketOutput, error) {
+	if m.missing {
+		return nil, awserr.New("NotFound", "not found", nil)
+	}
+	return &s3.HeadBucketOutput{}, nil
+}
+
+func (m *mockAdoptS3) GetBucketTaggingWithContext(ctx context.Context, input *s3.GetBucketTaggingInput, opts ...request.Option) (*s3.GetBucketTaggingOutput, error) {
+	if len(m.tags) == 0 {
+		return nil, awserr.New("NoSuchTagSet", "no tags", nil)
+	}
+	return &s3.GetBucketTaggingOutput{TagSet: m.tags}, nil
+}
+
+func (m *mockAdoptS3) PutBucketTaggingWithContext(ctx context.Context, input *s3.PutBucketTaggingInput, opts ...request.Option) (*s3.PutBucketTaggingOutput, error) {
+	m.tags = input.Tagging.TagSet
+	return &s3.PutBucketTaggingOutput{}, nil
+}
+
+func (m *mockAdoptS3) GetBucketEncryptionWithContext(ctx context.Context, input *s3.GetBucketEncryptionInput, opts ...request.Option) (*s3.GetBucketEncryptionOutput, error) {
+	if m.encryption == nil {
+		return nil, awserr.New("ServerSideEncryptionConfigurationNotFoundError", "no encryption", nil)
+	}
+	return &s3.GetBucketEncryptionOutput{ServerSideEncryptionConfiguration: m.encryption}, nil
+}
+
+func (m *mockAdoptS3) PutBucketEncryptionWithContext(ctx context.Context, input *s3.PutBucketEncryptionInput, opts ...request.Option) (*s3.PutBucketEncryptionOutput, error) {
+	m.encryption = input.ServerSideEncryptionConfiguration
+	return &s3.PutBucketEncryptionOutput{}, nil
+}
+
+func (m *mockAdoptS3) GetBucketLoggingWithContext(ctx context.Context, input *s3.GetBucketLoggingInput, opts ...request.Option) (*s3.GetBucketLoggingOutput, error) {
+	return &s3.GetBucketLoggingOutput{LoggingEnabled: m.logging}, nil
+}
+
+func (m *mockAdoptS3) PutBucketLoggingWithContext(ctx context.Context, input *s3.PutBucketLoggingInput, opts ...request.Option) (*s3.PutBucketLoggingOutput, error) {
+	m.logging = input.BucketLoggingStatus.LoggingEnabled
+	return &s3.PutBucketLoggingOutput{}, nil
+}
+
+func TestAdoptBucket(t *testing.T) {
+	Org = "testorg"
+	s := server{requiredTags: requiredTags{{key: "costcenter"}}}
+	policyArn := iamapi.FormatPolicyArn("012345678910", "/", "legacy-BktAdmPlc")
+
+	s3Client := &mockAdoptS3{tags: []*s3.Tag{
+		{Key: aws.String("Name"), Value: aws.String("old")},
+		{Key: aws.String("costcenter"), Value: aws.String("123456")},
+	}}
+	iamClient := &mockRepairIAM{policies: map[string]bool{policyArn: true}, groups: map[string][]string{}}
+	s3Service := s3api.S3{Service: s3Client, LoggingBucket: "access-logs"}
+	iamService := iamapi.IAM{Service: iamClient}
+
+	req := bucketAdoptRequest{
+		Tags:       []*s3.Tag{{Key: aws.String("Name"), Value: aws.String("legacy")}},
+		ObjectTags: []*s3.Tag{{Key: aws.String("project"), Value: aws.String("x")}},
+	}
+
+	out, err := s.adoptBucket(context.TODO(), s3Service, iamService, "012345678910", "legacy", req)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	expected := []string{
+		"enabled AES256 default encryption",
+		"enabled access logging to access-logs",
+		"created group legacy-BktAdmGrp",
+		"attached policy legacy-BktAdmPlc to group legacy-BktAdmGrp",
+		"updated tag Name",
+		"added tag spinup:org",
+		"set 1 default object tags",
+	}
+	if !reflect.DeepEqual(expected, out.Changes) {
+		t.Errorf("expected changes %v, got %v", expected, out.Changes)
+	}
+
+	expectedTags := map[string]string{"Name": "legacy", "costcenter": "123456", "spinup:org": "testorg", "spinup:object:project": "x"}
+	if tags := s3TagMap(s3Client.tags); !reflect.DeepEqual(expectedTags, tags) {
+		t.Errorf("expected tags %v, got %v", expectedTags, tags)
+	}
+
+	// the bucket is managed once it's adopted
+	if _, err := s.adoptBucket(context.TODO(), s3Service, iamService, "012345678910", "legacy", req); !common.IsErrorCode(err, apierror.ErrConflict) {
+		t.Errorf("expected conflict adopting a managed bucket, got %v", err)
+	}
+
+	// an existing kms encryption and logging configuration are kept
+	s3Client = &mockAdoptS3{
+		tags: []*s3.Tag{{Key: aws.String("costcenter"), Value: aws.String("123456")}},
+		encryption: &s3.ServerSideEncryptionConfiguration{Rules: []*s3.ServerSideEncryptionRule{
+			{ApplyServerSideEncryptionByDefault: &s3.ServerSideEncryptionByDefault{SSEAlgorithm: aws.String(s3.ServerSideEncryptionAwsKms)}},
+		}},
+		logging: &s3.LoggingEnabled{TargetBucket: aws.String("other-logs")},
+	}
+	s3Service.Service = s3Client
+
+	if out, err = s.adoptBucket(context.TODO(), s3Service, iamService, "012345678910", "kms", bucketAdoptRequest{}); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if algorithm := encryptionAlgorithm(s3Client.encryption); algorithm != s3.ServerSideEncryptionAwsKms {
+		t.Errorf("expected kms encryption to be kept, got %s", algorithm)
+	}
+
+	if target := aws.StringValue(s3Client.logging.TargetBucket); target != "other-logs" {
+		t.Errorf("expected logging to other-logs to be kept, got %s", target)
+	}
+
+	// the required tags are checked with the bucket's existing tags
+	s3Service.Service = &mockAdoptS3{}
+	if _, err := s.adoptBucket(context.TODO(), s3Service, iamService, "012345678910", "untagged", bucketAdoptRequest{}); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Errorf("expected bad request for a bucket missing the required tags, got %v", err)
+	}
+
+	s3Service.Service = &mockAdoptS3{missing: true}
+	if _, err := s.adoptBucket(context.TODO(), s3Service, iamService, "012345678910", "missing", bucketAdoptRequest{}); !common.IsErrorCode(err, apierror.ErrNotFound) {
+		t.Errorf("expected not found for a missing bucket, got %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/YaleSpinup/apierror"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// bucketAllowlistRequest is the request to change the ip allowlist of a bucket
+type bucketAllowlistRequest struct {
+	// IPAllowlist are the networks the bucket admin policy is limited to, an empty list resets it to the account's
+	// allowlist
+	IPAllowlist []string
+}
+
+// validate validates the request to change the ip allowlist of a bucket
+func (r *bucketAllowlistRequest) validate() error {
+	f := fieldErrors{}
+	f.ipAllowlist("IPAllowlist", r.IPAllowlist)
+	return f.err()
+}
+
+// bucketAllowlistOutput is the ip allowlist applied to a bucket
+type bucketAllowlistOutput struct {
+	Bucket      string
+	IPAllowlist []string
+}
+
+// validateIPAllowlist validates the configured ip allowlist
+func validateIPAllowlist(cidrs []string) error {
+	for _, c := range cidrs {
+		if _, _, err := net.ParseCIDR(c); err != nil {
+			return fmt.Errorf("invalid ip allowlist cidr %s: %s", c, err)
+		}
+	}
+
+	return nil
+}
+
+// ipAllowlist returns the requested ip allowlist, or the account's allowlist if none was requested
+func (s *server) ipAllowlist(requested []string) []string {
+	if len(requested) > 0 {
+		return requested
+	}
+
+	return s.account.IPAllowlist
+}
+
+// BucketAllowlistUpdateHandler replaces the ip allowlist of the admin policy of a bucket or website
+func (s *server) BucketAllowlistUpdateHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	bucket := vars["bucket"]
+
+	var req bucketAllowlistRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		msg := fmt.Sprintf("cannot decode body into update bucket allowlist input: %s", err)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+		return
+	}
+
+	if err := req.validate(); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	session, err := s.sessionForScope(r.Context(), accountId, bucketAllowlistPolicy, policyScope{Bucket: bucket})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	iamService := iamapi.NewSession(session.Session, s.account)
+
+	lease, err := s.lockResource(r.Context(), vars["account"], bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	defer lease.Release()
+
+	output, err := s.updateBucketAllowlist(r.Context(), iamService, accountId, bucket, req.IPAllowlist)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// updateBucketAllowlist regenerates the admin policy of a bucket limited to the ip allowlist and makes it the default
+// version of the policy
+func (s *server) updateBucketAllowlist(ctx context.Context, iamService iamapi.IAM, accountId, bucket string, requested []string) (*bucketAllowlistOutput, error) {
+	cidrs := s.ipAllowlist(requested)
+
+	document, err := iamService.DefaultBucketAdminPolicy(aws.String(bucket), cidrs)
+	if err != nil {
+		msg := fmt.Sprintf("failed building default IAM policy for bucket %s: %s", bucket, err.Error())
+		return nil, apierror.New(apierror.ErrInternalError, msg, err)
+	}
+
+	if err := iamService.UpdatePolicyDocument(ctx, iamService.PolicyArn(accountId, iamapi.FormatPolicyName(bucket, "/", "BktAdmPlc")), document); err != nil {
+		return nil, err
+	}
+
+	output := &bucketAllowlistOutput{
+		Bucket:      bucket,
+		IPAllowlist: cidrs,
+	}
+
+	if output.IPAllowlist == nil {
+		output.IPAllowlist = []string{}
+	}
+
+	return output, nil
+}
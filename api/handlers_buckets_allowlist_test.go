@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+)
+
+// mockAllowlistIAM is an iam client that records the new versions of the policies that exist
+type mockAllowlistIAM struct {
+	iamiface.IAMAPI
+	documents map[string]string
+}
+
+func (m *mockAllowlistIAM) ListPolicyVersionsWithContext(ctx context.Context, input *iam.ListPolicyVersionsInput, opts ...request.Option) (*iam.ListPolicyVersionsOutput, error) {
+	if _, ok := m.documents[aws.StringValue(input.PolicyArn)]; !ok {
+		return nil, awserr.New(iam.ErrCodeNoSuchEntityException, "policy not found", nil)
+	}
+	return &iam.ListPolicyVersionsOutput{Versions: []*iam.PolicyVersion{{VersionId: aws.String("v1"), IsDefaultVersion: aws.Bool(true)}}}, nil
+}
+
+func (m *mockAllowlistIAM) CreatePolicyVersionWithContext(ctx context.Context, input *iam.CreatePolicyVersionInput, opts ...request.Option) (*iam.CreatePolicyVersionOutput, error) {
+	m.documents[aws.StringValue(input.PolicyArn)] = aws.StringValue(input.PolicyDocument)
+	return &iam.CreatePolicyVersionOutput{PolicyVersion: &iam.PolicyVersion{VersionId: aws.String("v2")}}, nil
+}
+
+func TestBucketAllowlistRequestValidate(t *testing.T) {
+	req := bucketAllowlistRequest{IPAllowlist: []string{"10.0.0.0/8", "2001:db8::/32"}}
+	if err := req.validate(); err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	req = bucketAllowlistRequest{IPAllowlist: []string{"10.0.0.0/8", "10.0.0.1", "10.0.0.0/33"}}
+	err := req.validate()
+	if !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Fatalf("expected bad request, got %v", err)
+	}
+
+	for _, f := range []string{"IPAllowlist[1]", "IPAllowlist[2]"} {
+		if !strings.Contains(err.Error(), f) {
+			t.Errorf("expected an error for %s, got %s", f, err)
+		}
+	}
+
+	req = bucketAllowlistRequest{IPAllowlist: make([]string, maxIPAllowlist+1)}
+	for i := range req.IPAllowlist {
+		req.IPAllowlist[i] = "10.0.0.0/8"
+	}
+	if err := req.validate(); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Errorf("expected bad request for too many networks, got %v", err)
+	}
+}
+
+func TestUpdateBucketAllowlist(t *testing.T) {
+	policyArn := iamapi.FormatPolicyArn("012345678910", "/", "dataset-BktAdmPlc")
+	m := &mockAllowlistIAM{documents: map[string]string{policyArn: "{}"}}
+	iamService := iamapi.IAM{Service: m}
+	s := server{account: common.Account{IPAllowlist: []string{"10.0.0.0/8"}}}
+
+	output, err := s.updateBucketAllowlist(context.TODO(), iamService, "012345678910", "dataset", []string{"192.0.2.0/24"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if !reflect.DeepEqual(output, &bucketAllowlistOutput{Bucket: "dataset", IPAllowlist: []string{"192.0.2.0/24"}}) {
+		t.Errorf("unexpected output %+v", output)
+	}
+
+	if !strings.Contains(m.documents[policyArn], `"aws:SourceIp":["192.0.2.0/24"]`) {
+		t.Errorf("expected the policy to be limited to the requested allowlist, got %s", m.documents[policyArn])
+	}
+
+	// an empty allowlist resets the policy to the account's allowlist
+	if output, err = s.updateBucketAllowlist(context.TODO(), iamService, "012345678910", "dataset", nil); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if !reflect.DeepEqual(output.IPAllowlist, []string{"10.0.0.0/8"}) {
+		t.Errorf("expected the account allowlist, got %v", output.IPAllowlist)
+	}
+
+	if !strings.Contains(m.documents[policyArn], `"aws:SourceIp":["10.0.0.0/8"]`) {
+		t.Errorf("expected the policy to be limited to the account allowlist, got %s", m.documents[policyArn])
+	}
+
+	// without any allowlist the policy isn't limited
+	s.account.IPAllowlist = nil
+	if output, err = s.updateBucketAllowlist(context.TODO(), iamService, "012345678910", "dataset", nil); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if output.IPAllowlist == nil || len(output.IPAllowlist) != 0 {
+		t.Errorf("expected an empty allowlist, got %v", output.IPAllowlist)
+	}
+
+	if strings.Contains(m.documents[policyArn], "aws:SourceIp") {
+		t.Errorf("expected the policy not to be limited, got %s", m.documents[policyArn])
+	}
+
+	if _, err := s.updateBucketAllowlist(context.TODO(), iamService, "012345678910", "missing", nil); !common.IsErrorCode(err, apierror.ErrNotFound) {
+		t.Errorf("expected not found for a bucket without an admin policy, got %v", err)
+	}
+}
+
+func TestValidateIPAllowlist(t *testing.T) {
+	if err := validateIPAllowlist([]string{"10.0.0.0/8"}); err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	if err := validateIPAllowlist([]string{"10.0.0.0"}); err == nil {
+		t.Error("expected an error for an address without a prefix length")
+	}
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// bulkCreateMaxItems is the maximum number of buckets that can be created in one bulk request
+	bulkCreateMaxItems = 100
+	// bulkCreateConcurrency is the number of buckets in a bulk request that are created at the same time
+	bulkCreateConcurrency = 5
+
+	bulkStatusCreated = "created"
+	bulkStatusFailed  = "failed"
+)
+
+// bulkCreateResult is the result of creating an individual bucket in a bulk request
+type bulkCreateResult struct {
+	Bucket string
+	Status string
+	// Code is the http status code creating the bucket on its own would have returned
+	Code  int
+	Error string `json:",omitempty"`
+	// Rollback is the id of the rollback executed after a failure and RollbackStatus is its status, either
+	// rolled_back if everything that was created was cleaned up or failed if some of it was left behind
+	Rollback       string              `json:",omitempty"`
+	RollbackStatus string              `json:",omitempty"`
+	Output         *bucketCreateOutput `json:",omitempty"`
+}
+
+// bulkCreateOutput is the response from creating buckets in bulk
+type bulkCreateOutput struct {
+	Created int
+	Failed  int
+	Results []bulkCreateResult
+}
+
+// BucketBulkCreateHandler creates a list of buckets, each with the same orchestration and rollback as creating a
+// single bucket.  The buckets are created concurrently (up to bulkCreateConcurrency at a time) and a result is
+// returned for each bucket, in the order they were requested.  A failure creating one bucket doesn't affect the others.
+func (s *server) BucketBulkCreateHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+
+	var reqs []bucketCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+		msg := fmt.Sprintf("cannot decode body into list of create bucket inputs: %s", err)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+		return
+	}
+
+	if len(reqs) == 0 || len(reqs) > bulkCreateMaxItems {
+		msg := fmt.Sprintf("between 1 and %d buckets can be created in one request, got %d", bulkCreateMaxItems, len(reqs))
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, nil))
+		return
+	}
+
+	f := fieldErrors{}
+	seen := map[string]bool{}
+	for i, req := range reqs {
+		if err := req.validate(s.requiredTags); err != nil {
+			msg := err.Error()
+			if aerr, ok := common.AsAPIError(err); ok {
+				msg = aerr.Message
+			}
+			f.add(fmt.Sprintf("[%d]", i), "%s", msg)
+			continue
+		}
+
+		name := aws.StringValue(req.BucketInput.Bucket)
+		if seen[name] {
+			f.add(fmt.Sprintf("[%d].BucketInput.Bucket", i), "bucket %s is requested more than once", name)
+		}
+		seen[name] = true
+	}
+
+	if err := f.err(); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	session, err := s.sessionForAccount(r.Context(), accountId, append([]string{"s3:*", "iam:*"}, quotaCheckActions...)...)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	// check the quotas for all of the buckets up front, instead of failing part way through the batch
+	needs := quotaNeeds{}
+	for _, req := range reqs {
+		n := req.quotaNeeds()
+		needs.Groups += n.Groups
+		needs.Policies += n.Policies
+	}
+
+	if err := s.checkQuotas(r.Context(), session.Session, accountId, needs); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+	iamService := iamapi.NewSession(session.Session, s.account)
+	iamService.Cache = s.resourceCache(accountId)
+
+	log.Infof("bulk creating %d buckets in account %s", len(reqs), accountId)
+
+	results := make([]bulkCreateResult, len(reqs))
+	sem := make(chan struct{}, bulkCreateConcurrency)
+	wg := sync.WaitGroup{}
+	for i, req := range reqs {
+		wg.Add(1)
+		go func(i int, req bucketCreateRequest) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result := bulkCreateResult{
+				Bucket: aws.StringValue(req.BucketInput.Bucket),
+				Status: bulkStatusCreated,
+				Code:   http.StatusOK,
+			}
+
+			output, rb, err := s.createBucket(r.Context(), vars["account"], s3Service, iamService, req)
+			if err != nil {
+				log.Errorf("failed to create bucket %s in bulk request: %s", result.Bucket, err)
+
+				result.Status = bulkStatusFailed
+				result.Code = errorStatus(err)
+				result.Error = err.Error()
+				if rb != nil && rb.Len() > 0 {
+					result.Rollback = rb.ID
+					result.RollbackStatus = rb.Status
+				}
+			} else {
+				result.Output = output
+			}
+
+			results[i] = result
+		}(i, req)
+	}
+	wg.Wait()
+
+	output := bulkCreateOutput{
+		Results: results,
+	}
+
+	for _, result := range results {
+		if result.Status == bulkStatusCreated {
+			output.Created++
+		} else {
+			output.Failed++
+		}
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(multiStatusCode(output.Failed))
+	w.Write(j)
+}
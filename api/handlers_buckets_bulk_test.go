@@ -0,0 +1,43 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestBucketBulkCreateHandlerValidation(t *testing.T) {
+	s := server{router: mux.NewRouter()}
+	s.routes()
+
+	tooMany := make([]string, bulkCreateMaxItems+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf(`{"BucketInput": {"Bucket": "bucket-%d"}}`, i)
+	}
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid json", `{"BucketInput": `},
+		{"not a list", `{"BucketInput": {"Bucket": "foobucket"}}`},
+		{"empty list", `[]`},
+		{"too many buckets", "[" + strings.Join(tooMany, ",") + "]"},
+		{"missing bucket name", `[{"BucketInput": {"Bucket": "foobucket"}}, {"BucketInput": {}}]`},
+		{"duplicate bucket", `[{"BucketInput": {"Bucket": "foobucket"}}, {"BucketInput": {"Bucket": "foobucket"}}]`},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/v1/s3/someaccount/buckets/bulk", strings.NewReader(test.body))
+		rr := httptest.NewRecorder()
+		s.router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", test.name, http.StatusBadRequest, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/YaleSpinup/apierror"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// dataProtectionOutput is the data protection status of a bucket
+type dataProtectionOutput struct {
+	Bucket         string
+	DataProtection bool
+}
+
+// requireBreakGlassRole returns a bad request error if there isn't a break-glass role configured
+func (s *server) requireBreakGlassRole() error {
+	if s.account.BreakGlassRole != "" {
+		return nil
+	}
+
+	return apierror.New(apierror.ErrBadRequest, "data protection is not configured", nil)
+}
+
+// BucketDataProtectionEnableHandler adds the deny-delete statement to the bucket policy, so the bucket and its objects
+// can only be deleted by the break-glass role
+func (s *server) BucketDataProtectionEnableHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	bucket := vars["bucket"]
+
+	if err := s.requireBreakGlassRole(); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if err := s.setDataProtection(r.Context(), accountId, bucket, true); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	log.Infof("enabled data protection for bucket %s in account %s", bucket, accountId)
+
+	writeDataProtection(w, bucket, true)
+}
+
+// BucketDataProtectionDisableHandler removes the deny-delete statement from the bucket policy, it requires the
+// protection override so that the bucket's own admins can't lift it by accident
+func (s *server) BucketDataProtectionDisableHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	bucket := vars["bucket"]
+
+	if !s.protectionOverride(r) {
+		msg := fmt.Sprintf("disabling data protection for bucket %s requires a valid %s header", bucket, protectionOverrideHeader)
+		handleError(w, apierror.New(apierror.ErrForbidden, msg, nil))
+		return
+	}
+
+	if err := s.setDataProtection(r.Context(), accountId, bucket, false); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	log.Warnf("disabled data protection for bucket %s in account %s", bucket, accountId)
+
+	writeDataProtection(w, bucket, false)
+}
+
+// setDataProtection adds the deny-delete block to the policy of a bucket, or removes it
+func (s *server) setDataProtection(ctx context.Context, accountId, bucket string, enabled bool) error {
+	session, err := s.sessionForScope(ctx, accountId, bucketDataProtectionPolicy, policyScope{Bucket: bucket})
+	if err != nil {
+		return err
+	}
+
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+
+	return s.updateBucketPolicyBlock(ctx, s3Service, accountId, bucket, iamapi.BlockDenyDelete, enabled)
+}
+
+// writeDataProtection writes the data protection status of a bucket to the response
+func writeDataProtection(w http.ResponseWriter, bucket string, enabled bool) {
+	output := dataProtectionOutput{Bucket: bucket, DataProtection: enabled}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// hasDataProtection returns true if the bucket policy document denies deleting the bucket's data
+func hasDataProtection(policy string) bool {
+	return hasPolicyBlock(policy, iamapi.BlockDenyDelete)
+}
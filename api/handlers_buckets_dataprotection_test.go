@@ -0,0 +1,85 @@
+package api
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/YaleSpinup/s3-api/session"
+	"github.com/gorilla/mux"
+)
+
+func TestUpdateBucketPolicyBlockDataProtection(t *testing.T) {
+	s := &server{
+		account: common.Account{BreakGlassRole: "arn:aws:iam::{account_id}:role/BreakGlass"},
+		session: &session.Session{RoleName: "SpinupS3Role"},
+	}
+	userPolicy := `{"Version":"2012-10-17","Statement":[{"Sid":"AllowRead","Effect":"Allow","Principal":{"AWS":"arn:aws:iam::012345678910:root"},"Action":"s3:GetObject","Resource":"arn:aws:s3:::dataset/*"}]}`
+
+	s3Client := &mockSoftDeleteS3{policy: userPolicy}
+	s3Service := s3api.S3{Service: s3Client}
+
+	if err := s.updateBucketPolicyBlock(context.TODO(), s3Service, "012345678910", "dataset", iamapi.BlockDenyDelete, true); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if !hasDataProtection(s3Client.policy) || !hasPolicyStatement(s3Client.policy, "AllowRead") {
+		t.Errorf("expected the policy with data protection, got %s", s3Client.policy)
+	}
+
+	if !strings.Contains(s3Client.policy, "role/BreakGlass") || strings.Contains(s3Client.policy, "role/SpinupS3Role") {
+		t.Errorf("expected only the break-glass role to be exempt, got %s", s3Client.policy)
+	}
+
+	// disabling data protection keeps the rest of the policy
+	if err := s.updateBucketPolicyBlock(context.TODO(), s3Service, "012345678910", "dataset", iamapi.BlockDenyDelete, false); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if hasDataProtection(s3Client.policy) || !hasPolicyStatement(s3Client.policy, "AllowRead") {
+		t.Errorf("expected the policy without data protection, got %s", s3Client.policy)
+	}
+
+	// disabling data protection on a policy with nothing else deletes it
+	s3Client.policy = ""
+	if err := s.updateBucketPolicyBlock(context.TODO(), s3Service, "012345678910", "dataset", iamapi.BlockDenyDelete, true); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if err := s.updateBucketPolicyBlock(context.TODO(), s3Service, "012345678910", "dataset", iamapi.BlockDenyDelete, false); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if s3Client.policy != "" {
+		t.Errorf("expected the policy to be deleted, got %s", s3Client.policy)
+	}
+
+	// data protection can't be enabled without a break-glass role
+	s.account.BreakGlassRole = ""
+	if err := s.updateBucketPolicyBlock(context.TODO(), s3Service, "012345678910", "dataset", iamapi.BlockDenyDelete, true); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Errorf("expected bad request without a break-glass role, got %v", err)
+	}
+}
+
+func TestBucketDataProtectionHandlers(t *testing.T) {
+	s := &server{}
+
+	r := mux.SetURLVars(httptest.NewRequest("PUT", "/v1/s3/spinup/buckets/dataset/dataprotection", nil), map[string]string{"account": "spinup", "bucket": "dataset"})
+	w := httptest.NewRecorder()
+	s.BucketDataProtectionEnableHandler(w, r)
+	if w.Code != 400 {
+		t.Errorf("expected 400 when data protection isn't configured, got %d", w.Code)
+	}
+
+	r = mux.SetURLVars(httptest.NewRequest("DELETE", "/v1/s3/spinup/buckets/dataset/dataprotection", nil), map[string]string{"account": "spinup", "bucket": "dataset"})
+	w = httptest.NewRecorder()
+	s.BucketDataProtectionDisableHandler(w, r)
+	if w.Code != 403 {
+		t.Errorf("expected 403 without the protection override, got %d", w.Code)
+	}
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// bucketEncryption is the default server side encryption of a bucket
+type bucketEncryption struct {
+	SSEAlgorithm   string
+	KMSMasterKeyID string `json:",omitempty"`
+	// BucketKeyEnabled is true if the bucket uses an s3 bucket key for aws:kms encryption, which cuts the number of
+	// requests to kms
+	BucketKeyEnabled bool
+}
+
+// bucketEncryptionOutput is the default server side encryption of a bucket
+type bucketEncryptionOutput struct {
+	Bucket string
+	bucketEncryption
+}
+
+// BucketEncryptionShowHandler returns the default server side encryption of a bucket
+func (s *server) BucketEncryptionShowHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	bucket := vars["bucket"]
+
+	session, err := s.sessionForAccount(r.Context(), vars["account"], "s3:GetEncryptionConfiguration")
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+
+	enc, err := s3Service.GetBucketEncryption(r.Context(), bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	output := bucketEncryptionOutput{Bucket: bucket, bucketEncryption: toBucketEncryption(enc)}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// toBucketEncryption returns the default encryption from the first rule of an encryption configuration, the
+// algorithm is empty if the bucket doesn't have default encryption
+func toBucketEncryption(c *s3.ServerSideEncryptionConfiguration) bucketEncryption {
+	if c == nil {
+		return bucketEncryption{}
+	}
+
+	for _, r := range c.Rules {
+		if r.ApplyServerSideEncryptionByDefault != nil {
+			return bucketEncryption{
+				SSEAlgorithm:     aws.StringValue(r.ApplyServerSideEncryptionByDefault.SSEAlgorithm),
+				KMSMasterKeyID:   aws.StringValue(r.ApplyServerSideEncryptionByDefault.KMSMasterKeyID),
+				BucketKeyEnabled: aws.BoolValue(r.BucketKeyEnabled),
+			}
+		}
+	}
+
+	return bucketEncryption{}
+}
+
+// encryptionConfiguration returns the encryption configuration that applies the default encryption to a bucket
+func (e bucketEncryption) encryptionConfiguration() *s3.ServerSideEncryptionConfiguration {
+	sse := &s3.ServerSideEncryptionByDefault{SSEAlgorithm: aws.String(e.SSEAlgorithm)}
+	if e.KMSMasterKeyID != "" {
+		sse.KMSMasterKeyID = aws.String(e.KMSMasterKeyID)
+	}
+
+	rule := &s3.ServerSideEncryptionRule{ApplyServerSideEncryptionByDefault: sse}
+	if e.BucketKeyEnabled {
+		rule.BucketKeyEnabled = aws.Bool(true)
+	}
+
+	return &s3.ServerSideEncryptionConfiguration{Rules: []*s3.ServerSideEncryptionRule{rule}}
+}
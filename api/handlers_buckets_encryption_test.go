@@ -0,0 +1,42 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func TestToBucketEncryption(t *testing.T) {
+	if out := toBucketEncryption(nil); out != (bucketEncryption{}) {
+		t.Errorf("expected empty encryption without a configuration, got %+v", out)
+	}
+
+	c := &s3.ServerSideEncryptionConfiguration{
+		Rules: []*s3.ServerSideEncryptionRule{
+			{
+				ApplyServerSideEncryptionByDefault: &s3.ServerSideEncryptionByDefault{
+					SSEAlgorithm:   aws.String("aws:kms"),
+					KMSMasterKeyID: aws.String("alias/foo"),
+				},
+				BucketKeyEnabled: aws.Bool(true),
+			},
+		},
+	}
+
+	expected := bucketEncryption{SSEAlgorithm: "aws:kms", KMSMasterKeyID: "alias/foo", BucketKeyEnabled: true}
+	out := toBucketEncryption(c)
+	if out != expected {
+		t.Errorf("expected %+v, got %+v", expected, out)
+	}
+
+	// the encryption converts back to the same configuration
+	if back := toBucketEncryption(out.encryptionConfiguration()); back != expected {
+		t.Errorf("expected %+v, got %+v", expected, back)
+	}
+
+	rule := bucketEncryption{SSEAlgorithm: "AES256"}.encryptionConfiguration().Rules[0]
+	if rule.BucketKeyEnabled != nil || rule.ApplyServerSideEncryptionByDefault.KMSMasterKeyID != nil {
+		t.Errorf("expected AES256 encryption without a bucket key or kms key, got %+v", rule)
+	}
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/YaleSpinup/apierror"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// managedPolicyArnRe matches the arn of an aws or customer managed policy in the configured managed policies
+var managedPolicyArnRe = regexp.MustCompile(`^arn:aws:iam::(aws|\d{12}|\{account_id\}):policy/([\x21-\x7E]{0,510}/)?[\w+=,.@-]{1,128}$`)
+
+// bucketGroupPolicyRequest is the request to attach a managed policy to a management group of a bucket
+type bucketGroupPolicyRequest struct {
+	// PolicyArn is the arn of one of the account's managed policies
+	PolicyArn string
+}
+
+// bucketGroupPoliciesOutput is the list of policies attached to a management group of a bucket
+type bucketGroupPoliciesOutput struct {
+	Bucket   string
+	Group    string
+	Policies []string
+}
+
+// validateManagedPolicies validates the arns of the configured managed policies
+func validateManagedPolicies(arns []string) error {
+	for _, a := range arns {
+		if !managedPolicyArnRe.MatchString(a) {
+			return fmt.Errorf("invalid managed policy arn %q", a)
+		}
+	}
+
+	return nil
+}
+
+// managedPolicy validates that the group is a management group and the policy is one of the account's managed
+// policies.  The bucket's own policies aren't managed policies, so they can't be detached from its groups.
+func (s *server) managedPolicy(accountId, group, policyArn string) error {
+	f := fieldErrors{}
+	f.groups("group", []string{group}, websiteUserGroups)
+
+	if policyArn == "" {
+		f.add("PolicyArn", "is required")
+	} else if !contains(s.account.GetManagedPolicies(accountId), policyArn) {
+		f.add("PolicyArn", "%s is not one of the managed policies that can be attached to bucket groups", policyArn)
+	}
+
+	return f.err()
+}
+
+// BucketGroupPolicyAttachHandler attaches one of the account's managed policies to a management group of a bucket
+func (s *server) BucketGroupPolicyAttachHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	bucket := vars["bucket"]
+	group := vars["group"]
+
+	var req bucketGroupPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		msg := fmt.Sprintf("cannot decode body into attach group policy input: %s", err)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+		return
+	}
+
+	if err := s.managedPolicy(accountId, group, req.PolicyArn); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s.updateBucketGroupPolicies(w, r, accountId, bucket, group, func(ctx context.Context, iamService iamapi.IAM, groupName string) error {
+		return iamService.AttachGroupPolicy(ctx, &iam.AttachGroupPolicyInput{
+			GroupName: aws.String(groupName),
+			PolicyArn: aws.String(req.PolicyArn),
+		})
+	})
+}
+
+// BucketGroupPolicyDetachHandler detaches one of the account's managed policies from a management group of a bucket
+func (s *server) BucketGroupPolicyDetachHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	bucket := vars["bucket"]
+	group := vars["group"]
+	policyArn := r.URL.Query().Get("policyArn")
+
+	if err := s.managedPolicy(accountId, group, policyArn); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s.updateBucketGroupPolicies(w, r, accountId, bucket, group, func(ctx context.Context, iamService iamapi.IAM, groupName string) error {
+		return iamService.DetachGroupPolicy(ctx, &iam.DetachGroupPolicyInput{
+			GroupName: aws.String(groupName),
+			PolicyArn: aws.String(policyArn),
+		})
+	})
+}
+
+// updateBucketGroupPolicies locks the bucket, updates the policies attached to the management group and responds
+// with the policies attached to it
+func (s *server) updateBucketGroupPolicies(w http.ResponseWriter, r *http.Request, accountId, bucket, group string, update func(context.Context, iamapi.IAM, string) error) {
+	session, err := s.sessionForScope(r.Context(), accountId, bucketGroupPoliciesPolicy, policyScope{Bucket: bucket})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	iamService := iamapi.NewSession(session.Session, s.account)
+
+	lease, err := s.lockResource(r.Context(), mux.Vars(r)["account"], bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	defer lease.Release()
+
+	groupName := iamapi.FormatGroupName(bucket, "/", group)
+	if err := update(r.Context(), iamService, groupName); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	output, err := listBucketGroupPolicies(r.Context(), iamService, bucket, group)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// listBucketGroupPolicies lists the arns of the policies attached to a management group of a bucket
+func listBucketGroupPolicies(ctx context.Context, iamService iamapi.IAM, bucket, group string) (*bucketGroupPoliciesOutput, error) {
+	policies, err := iamService.ListGroupPolicies(ctx, &iam.ListAttachedGroupPoliciesInput{
+		GroupName: aws.String(iamapi.FormatGroupName(bucket, "/", group)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	output := &bucketGroupPoliciesOutput{
+		Bucket:   bucket,
+		Group:    group,
+		Policies: []string{},
+	}
+
+	for _, p := range policies {
+		output.Policies = append(output.Policies, aws.StringValue(p.PolicyArn))
+	}
+
+	return output, nil
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+)
+
+// mockGroupPoliciesIAM is an iam client that lists the policies attached to a group
+type mockGroupPoliciesIAM struct {
+	iamiface.IAMAPI
+	group    string
+	policies []string
+}
+
+func (m *mockGroupPoliciesIAM) ListAttachedGroupPoliciesWithContext(ctx context.Context, input *iam.ListAttachedGroupPoliciesInput, opts ...request.Option) (*iam.ListAttachedGroupPoliciesOutput, error) {
+	m.group = aws.StringValue(input.GroupName)
+
+	output := &iam.ListAttachedGroupPoliciesOutput{}
+	for _, p := range m.policies {
+		output.AttachedPolicies = append(output.AttachedPolicies, &iam.AttachedPolicy{PolicyArn: aws.String(p)})
+	}
+	return output, nil
+}
+
+func TestValidateManagedPolicies(t *testing.T) {
+	tests := []struct {
+		arns  []string
+		valid bool
+	}{
+		{nil, true},
+		{[]string{"arn:aws:iam::aws:policy/AmazonAthenaFullAccess", "arn:aws:iam::{account_id}:policy/GlueCrawlerAccess"}, true},
+		{[]string{"arn:aws:iam::012345678910:policy/service-role/GlueCrawlerAccess"}, true},
+		{[]string{"arn:aws:iam::aws:policy/*"}, false},
+		{[]string{"arn:aws:iam::012345678910:role/GlueCrawler"}, false},
+		{[]string{"AmazonAthenaFullAccess"}, false},
+	}
+
+	for _, tt := range tests {
+		if err := validateManagedPolicies(tt.arns); (err == nil) != tt.valid {
+			t.Errorf("expected %v to be valid: %t, got %v", tt.arns, tt.valid, err)
+		}
+	}
+}
+
+func TestManagedPolicy(t *testing.T) {
+	s := server{account: common.Account{ManagedPolicies: []string{"arn:aws:iam::{account_id}:policy/GlueCrawlerAccess"}}}
+
+	if err := s.managedPolicy("012345678910", "BktRWGrp", "arn:aws:iam::012345678910:policy/GlueCrawlerAccess"); err != nil {
+		t.Errorf("expected nil error for a managed policy, got %s", err)
+	}
+
+	tests := []struct {
+		group, policyArn string
+	}{
+		{"BktRWGrp", ""},
+		{"BktRWGrp", "arn:aws:iam::999999999999:policy/GlueCrawlerAccess"},
+		{"BktAdmGrp", "arn:aws:iam::012345678910:policy/foobucket-BktAdmPlc"},
+		{"Administrators", "arn:aws:iam::012345678910:policy/GlueCrawlerAccess"},
+	}
+
+	for _, tt := range tests {
+		err := s.managedPolicy("012345678910", tt.group, tt.policyArn)
+		if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrBadRequest {
+			t.Errorf("expected a bad request error for %s on %s, got %v", tt.policyArn, tt.group, err)
+		}
+	}
+}
+
+func TestListBucketGroupPolicies(t *testing.T) {
+	client := &mockGroupPoliciesIAM{policies: []string{"arn:aws:iam::012345678910:policy/foobucket-BktRWPlc", "arn:aws:iam::aws:policy/AmazonAthenaFullAccess"}}
+	iamService := iamapi.IAM{Service: client}
+
+	output, err := listBucketGroupPolicies(context.TODO(), iamService, "foobucket", "BktRWGrp")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if client.group != "foobucket-BktRWGrp" {
+		t.Errorf("expected the policies of foobucket-BktRWGrp to be listed, got %s", client.group)
+	}
+
+	expected := &bucketGroupPoliciesOutput{Bucket: "foobucket", Group: "BktRWGrp", Policies: client.policies}
+	if !reflect.DeepEqual(expected, output) {
+		t.Errorf("expected %+v, got %+v", expected, output)
+	}
+}
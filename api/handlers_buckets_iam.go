@@ -0,0 +1,85 @@
+package api
+
+import (
+	"context"
+	"sort"
+
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+// bucketGroupActions are the actions needed to get the management groups of a bucket, their users and policies
+var bucketGroupActions = []string{
+	"iam:ListGroups",
+	"iam:GetGroup",
+	"iam:ListAttachedGroupPolicies",
+}
+
+// bucketGroupOutput is one of the management groups of a bucket with its users and attached policies.  Type is the
+// kind of group, ie. BktAdmGrp, and Path is the path in the bucket the group manages, / for the whole bucket.
+type bucketGroupOutput struct {
+	GroupName string
+	Arn       string
+	Type      string
+	Path      string
+	Users     []string
+	Policies  []*iam.AttachedPolicy
+}
+
+// bucketGroupDetails gets the management groups of a bucket, including the groups for paths in the bucket, along with
+// the names of their users and their attached policies.  The users and policies of each group are fetched concurrently.
+func bucketGroupDetails(ctx context.Context, iamService iamapi.IAM, bucket string) ([]*bucketGroupOutput, error) {
+	groups, err := bucketGroups(ctx, iamService, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]*bucketGroupOutput, len(groups))
+	g, gctx := newErrGroup(ctx)
+	for i, group := range groups {
+		i, group := i, group
+
+		path, groupType, _ := iamapi.ParseGroupName(bucket, aws.StringValue(group.GroupName), iamapi.TrimPathPrefix(iamService.PathPrefix, aws.StringValue(group.Path)))
+		output[i] = &bucketGroupOutput{
+			GroupName: aws.StringValue(group.GroupName),
+			Arn:       aws.StringValue(group.Arn),
+			Type:      groupType,
+			Path:      path,
+			Users:     []string{},
+		}
+
+		g.Go(func() error {
+			users, err := iamService.ListGroupUsers(gctx, &iam.GetGroupInput{GroupName: group.GroupName})
+			if err != nil {
+				return err
+			}
+
+			for _, u := range users {
+				output[i].Users = append(output[i].Users, aws.StringValue(u.UserName))
+			}
+			sort.Strings(output[i].Users)
+
+			return nil
+		})
+
+		g.Go(func() error {
+			policies, err := iamService.ListGroupPolicies(gctx, &iam.ListAttachedGroupPoliciesInput{GroupName: group.GroupName})
+			if err != nil {
+				return err
+			}
+
+			output[i].Policies = policies
+
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(output, func(i, j int) bool { return output[i].GroupName < output[j].GroupName })
+
+	return output, nil
+}
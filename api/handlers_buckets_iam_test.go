@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+)
+
+// mockBucketGroupsIAM is a fake iam client with the groups of a bucket, their users and policies
+type mockBucketGroupsIAM struct {
+	iamiface.IAMAPI
+	groups   []*iam.Group
+	users    map[string][]string
+	policies map[string][]string
+}
+
+func (m *mockBucketGroupsIAM) ListGroupsWithContext(ctx context.Context, input *iam.ListGroupsInput, opts ...request.Option) (*iam.ListGroupsOutput, error) {
+	return &iam.ListGroupsOutput{Groups: m.groups}, nil
+}
+
+func (m *mockBucketGroupsIAM) GetGroupWithContext(ctx context.Context, input *iam.GetGroupInput, opts ...request.Option) (*iam.GetGroupOutput, error) {
+	users, ok := m.users[aws.StringValue(input.GroupName)]
+	if !ok {
+		return nil, awserr.New(iam.ErrCodeNoSuchEntityException, "not found", nil)
+	}
+
+	output := &iam.GetGroupOutput{Users: []*iam.User{}}
+	for _, u := range users {
+		output.Users = append(output.Users, &iam.User{UserName: aws.String(u)})
+	}
+
+	return output, nil
+}
+
+func (m *mockBucketGroupsIAM) ListAttachedGroupPoliciesWithContext(ctx context.Context, input *iam.ListAttachedGroupPoliciesInput, opts ...request.Option) (*iam.ListAttachedGroupPoliciesOutput, error) {
+	output := &iam.ListAttachedGroupPoliciesOutput{AttachedPolicies: []*iam.AttachedPolicy{}}
+	for _, p := range m.policies[aws.StringValue(input.GroupName)] {
+		output.AttachedPolicies = append(output.AttachedPolicies, &iam.AttachedPolicy{
+			PolicyArn:  aws.String("arn:aws:iam::012345678910:policy/" + p),
+			PolicyName: aws.String(p),
+		})
+	}
+
+	return output, nil
+}
+
+func TestBucketGroupDetails(t *testing.T) {
+	client := &mockBucketGroupsIAM{
+		groups: []*iam.Group{
+			{GroupName: aws.String("dataset-BktROGrp"), Arn: aws.String("arn:aws:iam::012345678910:group/dataset-BktROGrp"), Path: aws.String("/")},
+			{GroupName: aws.String("dataset-BktAdmGrp"), Arn: aws.String("arn:aws:iam::012345678910:group/dataset-BktAdmGrp"), Path: aws.String("/")},
+			{GroupName: aws.String("dataset-raw-BktRWGrp"), Arn: aws.String("arn:aws:iam::012345678910:group/raw/dataset-raw-BktRWGrp"), Path: aws.String("/raw/")},
+			{GroupName: aws.String("otherbucket-BktAdmGrp"), Path: aws.String("/")},
+		},
+		users: map[string][]string{
+			"dataset-BktAdmGrp":    {"dataset-admin2", "dataset-admin1"},
+			"dataset-BktROGrp":     {},
+			"dataset-raw-BktRWGrp": {"dataset-raw-loader"},
+		},
+		policies: map[string][]string{
+			"dataset-BktAdmGrp":    {"dataset-BktAdmPlc"},
+			"dataset-BktROGrp":     {"dataset-BktROPlc"},
+			"dataset-raw-BktRWGrp": {"dataset-raw-BktRWPlc"},
+		},
+	}
+
+	out, err := bucketGroupDetails(context.TODO(), iamapi.IAM{Service: client}, "dataset")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	policy := func(name string) []*iam.AttachedPolicy {
+		return []*iam.AttachedPolicy{{PolicyArn: aws.String("arn:aws:iam::012345678910:policy/" + name), PolicyName: aws.String(name)}}
+	}
+
+	expected := []*bucketGroupOutput{
+		{
+			GroupName: "dataset-BktAdmGrp",
+			Arn:       "arn:aws:iam::012345678910:group/dataset-BktAdmGrp",
+			Type:      "BktAdmGrp",
+			Path:      "/",
+			Users:     []string{"dataset-admin1", "dataset-admin2"},
+			Policies:  policy("dataset-BktAdmPlc"),
+		},
+		{
+			GroupName: "dataset-BktROGrp",
+			Arn:       "arn:aws:iam::012345678910:group/dataset-BktROGrp",
+			Type:      "BktROGrp",
+			Path:      "/",
+			Users:     []string{},
+			Policies:  policy("dataset-BktROPlc"),
+		},
+		{
+			GroupName: "dataset-raw-BktRWGrp",
+			Arn:       "arn:aws:iam::012345678910:group/raw/dataset-raw-BktRWGrp",
+			Type:      "BktRWGrp",
+			Path:      "/raw/",
+			Users:     []string{"dataset-raw-loader"},
+			Policies:  policy("dataset-raw-BktRWPlc"),
+		},
+	}
+
+	if !reflect.DeepEqual(out, expected) {
+		for _, o := range out {
+			t.Logf("got %+v", o)
+		}
+		t.Errorf("unexpected bucket groups")
+	}
+
+	// a group that disappears while it's being listed fails the details
+	client.groups = append(client.groups, &iam.Group{GroupName: aws.String("dataset-BktRWGrp"), Path: aws.String("/")})
+	if _, err := bucketGroupDetails(context.TODO(), iamapi.IAM{Service: client}, "dataset"); err == nil {
+		t.Error("expected an error for a missing group, got nil")
+	}
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// policyVersion is a version of one of the managed policies of a bucket
+type policyVersion struct {
+	VersionId  string
+	Default    bool
+	CreateDate *time.Time `json:",omitempty"`
+	Document   string
+}
+
+// policyVersionsOutput is the list of versions of one of the managed policies of a bucket, newest first
+type policyVersionsOutput struct {
+	Bucket   string
+	Policy   string
+	Versions []*policyVersion
+}
+
+// validatePolicyVersion validates the policy type and version id of a policy version request
+func validatePolicyVersion(policy, versionId string) error {
+	f := fieldErrors{}
+	if !contains(bucketPolicyTypes, policy) {
+		f.add("policy", "unsupported policy %s, must be one of %s", policy, strings.Join(bucketPolicyTypes, ", "))
+	}
+
+	if versionId != "" && !policyVersionRe.MatchString(versionId) {
+		f.add("version", "invalid policy version id %q", versionId)
+	}
+
+	return f.err()
+}
+
+// BucketPolicyVersionListHandler lists the versions of one of the managed policies of a bucket, with their documents
+func (s *server) BucketPolicyVersionListHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	bucket := vars["bucket"]
+	policy := vars["policy"]
+
+	if err := validatePolicyVersion(policy, ""); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	session, err := s.sessionForScope(r.Context(), accountId, bucketPolicyVersionsPolicy, policyScope{Bucket: bucket})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	iamService := iamapi.NewSession(session.Session, s.account)
+
+	output, err := listPolicyVersions(r.Context(), iamService, accountId, bucket, policy)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// BucketPolicyVersionRestoreHandler rolls one of the managed policies of a bucket back to a previous version by
+// making it the default version.  The newer versions are kept, so the rollback can be undone the same way.
+func (s *server) BucketPolicyVersionRestoreHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	bucket := vars["bucket"]
+	policy := vars["policy"]
+	versionId := vars["version"]
+
+	if err := validatePolicyVersion(policy, versionId); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	session, err := s.sessionForScope(r.Context(), accountId, bucketPolicyVersionsPolicy, policyScope{Bucket: bucket})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	iamService := iamapi.NewSession(session.Session, s.account)
+
+	lease, err := s.lockResource(r.Context(), vars["account"], bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	defer lease.Release()
+
+	policyArn := iamService.PolicyArn(accountId, iamapi.FormatPolicyName(bucket, "/", policy))
+	if err := iamService.SetDefaultPolicyVersion(r.Context(), policyArn, versionId); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	output, err := listPolicyVersions(r.Context(), iamService, accountId, bucket, policy)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// listPolicyVersions lists the versions of one of the managed policies of a bucket with their documents, newest first
+func listPolicyVersions(ctx context.Context, iamService iamapi.IAM, accountId, bucket, policy string) (*policyVersionsOutput, error) {
+	policyName := iamapi.FormatPolicyName(bucket, "/", policy)
+	policyArn := iamService.PolicyArn(accountId, policyName)
+
+	versions, err := iamService.ListPolicyVersions(ctx, policyArn)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &policyVersionsOutput{
+		Bucket:   bucket,
+		Policy:   policyName,
+		Versions: []*policyVersion{},
+	}
+
+	for _, v := range versions {
+		document, err := iamService.GetPolicyVersionDocument(ctx, policyArn, aws.StringValue(v.VersionId))
+		if err != nil {
+			return nil, err
+		}
+
+		output.Versions = append(output.Versions, &policyVersion{
+			VersionId:  aws.StringValue(v.VersionId),
+			Default:    aws.BoolValue(v.IsDefaultVersion),
+			CreateDate: v.CreateDate,
+			Document:   document,
+		})
+	}
+
+	sort.SliceStable(output.Versions, func(i, j int) bool {
+		return aws.TimeValue(output.Versions[i].CreateDate).After(aws.TimeValue(output.Versions[j].CreateDate))
+	})
+
+	return output, nil
+}
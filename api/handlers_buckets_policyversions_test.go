@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+)
+
+// mockPolicyVersionsIAM is an iam client with versions of a policy whose documents name the version
+type mockPolicyVersionsIAM struct {
+	iamiface.IAMAPI
+	policyArn string
+	versions  []*iam.PolicyVersion
+}
+
+func (m *mockPolicyVersionsIAM) ListPolicyVersionsWithContext(ctx context.Context, input *iam.ListPolicyVersionsInput, opts ...request.Option) (*iam.ListPolicyVersionsOutput, error) {
+	m.policyArn = aws.StringValue(input.PolicyArn)
+	return &iam.ListPolicyVersionsOutput{Versions: m.versions}, nil
+}
+
+func (m *mockPolicyVersionsIAM) GetPolicyVersionWithContext(ctx context.Context, input *iam.GetPolicyVersionInput, opts ...request.Option) (*iam.GetPolicyVersionOutput, error) {
+	document := fmt.Sprintf("%%7B%%22Sid%%22%%3A%%22%s%%22%%7D", aws.StringValue(input.VersionId))
+	return &iam.GetPolicyVersionOutput{PolicyVersion: &iam.PolicyVersion{VersionId: input.VersionId, Document: aws.String(document)}}, nil
+}
+
+func TestValidatePolicyVersion(t *testing.T) {
+	tests := []struct {
+		policy, version string
+		valid           bool
+	}{
+		{"BktAdmPlc", "", true},
+		{"WebAdmPlc", "v12", true},
+		{"SftpPlc", "", false},
+		{"BktAdmPlc", "v0", false},
+		{"BktAdmPlc", "1", false},
+		{"BktAdmPlc", "v1/../v2", false},
+	}
+
+	for _, tt := range tests {
+		err := validatePolicyVersion(tt.policy, tt.version)
+		if tt.valid && err != nil {
+			t.Errorf("expected %s %s to be valid, got %s", tt.policy, tt.version, err)
+		}
+
+		if aerr, ok := err.(apierror.Error); !tt.valid && (!ok || aerr.Code != apierror.ErrBadRequest) {
+			t.Errorf("expected a bad request error for %s %s, got %v", tt.policy, tt.version, err)
+		}
+	}
+}
+
+func TestListPolicyVersions(t *testing.T) {
+	base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	client := &mockPolicyVersionsIAM{
+		versions: []*iam.PolicyVersion{
+			{VersionId: aws.String("v1"), IsDefaultVersion: aws.Bool(false), CreateDate: aws.Time(base)},
+			{VersionId: aws.String("v3"), IsDefaultVersion: aws.Bool(false), CreateDate: aws.Time(base.Add(2 * time.Hour))},
+			{VersionId: aws.String("v2"), IsDefaultVersion: aws.Bool(true), CreateDate: aws.Time(base.Add(time.Hour))},
+		},
+	}
+
+	output, err := listPolicyVersions(context.TODO(), iamapi.IAM{Service: client}, "012345678910", "foobucket", "BktAdmPlc")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if client.policyArn != "arn:aws:iam::012345678910:policy/foobucket-BktAdmPlc" {
+		t.Errorf("expected the versions of the bucket admin policy to be listed, got %s", client.policyArn)
+	}
+
+	if output.Bucket != "foobucket" || output.Policy != "foobucket-BktAdmPlc" {
+		t.Errorf("unexpected output %+v", output)
+	}
+
+	// the versions are sorted newest first
+	expected := []string{"v3", "v2", "v1"}
+	if len(output.Versions) != len(expected) {
+		t.Fatalf("expected %d versions, got %d", len(expected), len(output.Versions))
+	}
+
+	for i, v := range output.Versions {
+		if v.VersionId != expected[i] {
+			t.Errorf("expected version %s at %d, got %s", expected[i], i, v.VersionId)
+		}
+
+		if v.Document != fmt.Sprintf(`{"Sid":"%s"}`, v.VersionId) {
+			t.Errorf("expected the decoded document of %s, got %s", v.VersionId, v.Document)
+		}
+
+		if v.Default != (v.VersionId == "v2") {
+			t.Errorf("expected only v2 to be the default version, got %+v", v)
+		}
+	}
+}
@@ -0,0 +1,705 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	transferapi "github.com/YaleSpinup/s3-api/transfer"
+	"github.com/YaleSpinup/s3-api/webhook"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/transfer"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// sftpUserRequest is the request to create an sftp user for a bucket
+type sftpUserRequest struct {
+	UserName      string
+	SshPublicKeys []string
+}
+
+// validate validates the request to create an sftp user, the user needs at least one key since the server only
+// supports key authentication
+func (r *sftpUserRequest) validate() error {
+	f := fieldErrors{}
+	f.sftpUser("UserName", r.UserName)
+	if len(r.SshPublicKeys) == 0 {
+		f.add("SshPublicKeys", "at least one ssh public key is required")
+	}
+	f.sshPublicKeys("SshPublicKeys", r.SshPublicKeys)
+	return f.err()
+}
+
+// sftpKeyRequest is the request to add an ssh public key to an sftp user
+type sftpKeyRequest struct {
+	SshPublicKey string
+}
+
+// sftpOutput is the sftp configuration of a bucket
+type sftpOutput struct {
+	Bucket   string
+	Endpoint string
+	Role     string
+	State    string `json:",omitempty"`
+	Users    []string
+}
+
+// sftpKeyOutput is an ssh public key of an sftp user
+type sftpKeyOutput struct {
+	Id           string
+	SshPublicKey string
+	DateImported *time.Time `json:",omitempty"`
+}
+
+// sftpUserOutput is an sftp user of a bucket
+type sftpUserOutput struct {
+	UserName      string
+	Bucket        string
+	Endpoint      string
+	SshPublicKeys []*sftpKeyOutput
+}
+
+// newSFTPUserOutput returns the output for a transfer user
+func newSFTPUserOutput(bucket, endpoint string, user *transfer.DescribedUser) *sftpUserOutput {
+	output := &sftpUserOutput{
+		UserName:      aws.StringValue(user.UserName),
+		Bucket:        bucket,
+		Endpoint:      endpoint,
+		SshPublicKeys: []*sftpKeyOutput{},
+	}
+
+	for _, k := range user.SshPublicKeys {
+		output.SshPublicKeys = append(output.SshPublicKeys, &sftpKeyOutput{
+			Id:           aws.StringValue(k.SshPublicKeyId),
+			SshPublicKey: aws.StringValue(k.SshPublicKeyBody),
+			DateImported: k.DateImported,
+		})
+	}
+
+	return output
+}
+
+// sftpRoleName is the name of the role the transfer server uses to access a bucket for its sftp users
+func sftpRoleName(bucket string) string {
+	return iamapi.FormatRoleName(bucket, "SftpRole")
+}
+
+// sftpPolicyName is the name of the policy attached to the sftp role of a bucket
+func sftpPolicyName(bucket string) string {
+	return iamapi.FormatPolicyName(bucket, "/", "SftpPlc")
+}
+
+// requireSFTP returns a bad request error if there's no transfer server configured
+func (s *server) requireSFTP() error {
+	if s.account.Transfer != nil && s.account.Transfer.ServerID != "" {
+		return nil
+	}
+
+	return apierror.New(apierror.ErrBadRequest, "sftp is not configured", nil)
+}
+
+// sftpServices returns the s3, iam and transfer services for managing the sftp access of a bucket
+func (s *server) sftpServices(ctx context.Context, account, bucket string) (s3api.S3, iamapi.IAM, transferapi.Transfer, error) {
+	accountId := s.mapAccountNumber(account)
+
+	session, err := s.sessionForScope(ctx, accountId, bucketSFTPPolicy, policyScope{Bucket: bucket})
+	if err != nil {
+		return s3api.S3{}, iamapi.IAM{}, transferapi.Transfer{}, err
+	}
+
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+	iamService := iamapi.NewSession(session.Session, s.account)
+	iamService.Cache = s.resourceCache(accountId)
+	transferService := transferapi.NewSession(session.Session, s.account)
+
+	return s3Service, iamService, transferService, nil
+}
+
+// BucketSFTPEnableHandler enables sftp access to a bucket through the transfer server.  The operations are
+// 1. create the sftp policy '<bucketName>-SftpPlc' allowing read-write access to the bucket
+// 2. create the sftp role '<bucketName>-SftpRole' that the transfer service can assume
+// 3. attach the policy to the role
+// Any failure rolls back the resources that were created.  Users are created for the bucket once sftp is enabled.
+func (s *server) BucketSFTPEnableHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	bucket := vars["bucket"]
+
+	if err := s.requireSFTP(); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s3Service, iamService, transferService, err := s.sftpServices(r.Context(), vars["account"], bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	lease, err := s.lockResource(r.Context(), vars["account"], bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	defer lease.Release()
+
+	role, err := s.enableSFTP(r.Context(), s3Service, iamService, vars["account"], accountId, bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s.notify(webhook.EventSFTPEnabled, vars["account"], bucket, map[string]string{"Role": aws.StringValue(role.Arn)})
+
+	output := sftpOutput{
+		Bucket:   bucket,
+		Endpoint: transferService.Hostname,
+		Role:     aws.StringValue(role.Arn),
+		Users:    []string{},
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// BucketSFTPShowHandler returns the sftp configuration of a bucket and its users
+func (s *server) BucketSFTPShowHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	if err := s.requireSFTP(); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	_, iamService, transferService, err := s.sftpServices(r.Context(), vars["account"], bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	role, err := sftpRole(r.Context(), iamService, bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	users, err := bucketSFTPUsers(r.Context(), transferService, role)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	output := sftpOutput{
+		Bucket:   bucket,
+		Endpoint: transferService.Hostname,
+		Role:     aws.StringValue(role.Arn),
+		Users:    []string{},
+	}
+
+	for _, u := range users {
+		output.Users = append(output.Users, aws.StringValue(u.UserName))
+	}
+
+	// the state of the server is informational, so a failure to get it isn't fatal
+	if server, err := transferService.DescribeServer(r.Context()); err != nil {
+		log.Warnf("failed to describe transfer server %s: %s", transferService.ServerID, err)
+	} else {
+		output.State = aws.StringValue(server.State)
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// BucketSFTPDisableHandler disables sftp access to a bucket.  The bucket's users are deleted from the transfer server,
+// then the sftp policy is detached from the sftp role and both are deleted.
+func (s *server) BucketSFTPDisableHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	bucket := vars["bucket"]
+
+	if err := s.requireSFTP(); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	_, iamService, transferService, err := s.sftpServices(r.Context(), vars["account"], bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	lease, err := s.lockResource(r.Context(), vars["account"], bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	defer lease.Release()
+
+	deleted, err := disableSFTP(r.Context(), iamService, transferService, accountId, bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s.notify(webhook.EventSFTPDisabled, vars["account"], bucket, map[string]interface{}{"Users": deleted})
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte{})
+}
+
+// BucketSFTPUserCreateHandler creates an sftp user for a bucket on the transfer server with its ssh public keys.
+// The user's home directory is the root of the bucket and it accesses the bucket with the bucket's sftp role.
+func (s *server) BucketSFTPUserCreateHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	if err := s.requireSFTP(); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	var req sftpUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		msg := fmt.Sprintf("cannot decode body into create sftp user input: %s", err)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+		return
+	}
+
+	if err := req.validate(); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s3Service, iamService, transferService, err := s.sftpServices(r.Context(), vars["account"], bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	user, err := s.createSFTPUser(r.Context(), s3Service, iamService, transferService, vars["account"], bucket, req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s.notify(webhook.EventSFTPUserCreated, vars["account"], bucket, map[string]string{"UserName": req.UserName})
+
+	output := newSFTPUserOutput(bucket, transferService.Hostname, user)
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// BucketSFTPUserShowHandler returns an sftp user of a bucket and its ssh public keys
+func (s *server) BucketSFTPUserShowHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	if err := s.requireSFTP(); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	_, iamService, transferService, err := s.sftpServices(r.Context(), vars["account"], bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	user, err := sftpUser(r.Context(), iamService, transferService, bucket, vars["user"])
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	output := newSFTPUserOutput(bucket, transferService.Hostname, user)
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// BucketSFTPUserDeleteHandler deletes an sftp user of a bucket and its ssh public keys from the transfer server
+func (s *server) BucketSFTPUserDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	if err := s.requireSFTP(); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	_, iamService, transferService, err := s.sftpServices(r.Context(), vars["account"], bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if _, err := sftpUser(r.Context(), iamService, transferService, bucket, vars["user"]); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if err := transferService.DeleteUser(r.Context(), vars["user"]); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s.notify(webhook.EventSFTPUserDeleted, vars["account"], bucket, map[string]string{"UserName": vars["user"]})
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte{})
+}
+
+// BucketSFTPKeyCreateHandler adds an ssh public key to an sftp user of a bucket, the user is returned with all of
+// its keys
+func (s *server) BucketSFTPKeyCreateHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	if err := s.requireSFTP(); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	var req sftpKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		msg := fmt.Sprintf("cannot decode body into add ssh public key input: %s", err)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+		return
+	}
+
+	f := fieldErrors{}
+	f.sshPublicKeys("SshPublicKey", []string{req.SshPublicKey})
+	if err := f.err(); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	_, iamService, transferService, err := s.sftpServices(r.Context(), vars["account"], bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if _, err := sftpUser(r.Context(), iamService, transferService, bucket, vars["user"]); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if _, err := transferService.ImportSSHPublicKey(r.Context(), vars["user"], strings.TrimSpace(req.SshPublicKey)); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	user, err := transferService.DescribeUser(r.Context(), vars["user"])
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	output := newSFTPUserOutput(bucket, transferService.Hostname, user)
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// BucketSFTPKeyDeleteHandler removes an ssh public key from an sftp user of a bucket
+func (s *server) BucketSFTPKeyDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	if err := s.requireSFTP(); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	_, iamService, transferService, err := s.sftpServices(r.Context(), vars["account"], bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if _, err := sftpUser(r.Context(), iamService, transferService, bucket, vars["user"]); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if err := transferService.DeleteSSHPublicKey(r.Context(), vars["user"], vars["key"]); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte{})
+}
+
+// enableSFTP creates the sftp policy and role for a bucket, they're rolled back if any step fails
+func (s *server) enableSFTP(ctx context.Context, s3Service s3api.S3, iamService iamapi.IAM, account, accountId, bucket string) (role *iam.Role, err error) {
+	exists, err := s3Service.BucketExists(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		msg := fmt.Sprintf("bucket %s not found", bucket)
+		return nil, apierror.New(apierror.ErrNotFound, msg, nil)
+	}
+
+	roleName := sftpRoleName(bucket)
+	if _, err := iamService.GetRole(ctx, roleName); err == nil {
+		msg := fmt.Sprintf("sftp is already enabled for bucket %s", bucket)
+		return nil, apierror.New(apierror.ErrConflict, msg, nil)
+	} else if !isNotFound(err) {
+		return nil, err
+	}
+
+	// the role and policy are tagged with the org and required tags from the bucket
+	bucketTags, err := s3Service.GetBucketTags(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+	tags := iamTags(s.requiredTags.inherited(bucketTags))
+
+	policyDoc, err := iamService.TransferBucketPolicy(bucket)
+	if err != nil {
+		msg := fmt.Sprintf("failed building sftp policy for bucket %s: %s", bucket, err)
+		return nil, apierror.New(apierror.ErrInternalError, msg, err)
+	}
+
+	trustDoc, err := iamapi.TransferTrustPolicy(accountId)
+	if err != nil {
+		msg := fmt.Sprintf("failed building sftp trust policy for bucket %s: %s", bucket, err)
+		return nil, apierror.New(apierror.ErrInternalError, msg, err)
+	}
+
+	rb := s.newRollback("sftp.enable", account, bucket, rollbackServices{iam: &iamService})
+	defer func() {
+		s.finishRollback(rb, err)
+	}()
+
+	policyName := sftpPolicyName(bucket)
+	policy, err := iamService.CreatePolicy(ctx, &iam.CreatePolicyInput{
+		Description:    aws.String(fmt.Sprintf("SFTP policy for %s bucket", bucket)),
+		PolicyDocument: aws.String(string(policyDoc)),
+		PolicyName:     aws.String(policyName),
+		Tags:           tags,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rb.Add("delete policy "+policyName, rollbackDeletePolicy, map[string]string{"policy_arn": aws.StringValue(policy.Arn)})
+
+	role, err = iamService.CreateRole(ctx, &iam.CreateRoleInput{
+		AssumeRolePolicyDocument: aws.String(string(trustDoc)),
+		Description:              aws.String(fmt.Sprintf("SFTP role for %s bucket", bucket)),
+		RoleName:                 aws.String(roleName),
+		Tags:                     tags,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rb.Add("delete role "+roleName, rollbackDeleteRole, map[string]string{"role": roleName})
+
+	if err = iamService.AttachRolePolicy(ctx, &iam.AttachRolePolicyInput{
+		PolicyArn: policy.Arn,
+		RoleName:  aws.String(roleName),
+	}); err != nil {
+		return nil, err
+	}
+
+	rb.Add("detach policy "+policyName+" from role "+roleName, rollbackDetachRolePolicy, map[string]string{"role": roleName, "policy_arn": aws.StringValue(policy.Arn)})
+
+	return role, nil
+}
+
+// disableSFTP deletes the sftp users of a bucket, then its sftp role and policy.  The names of the deleted users are
+// returned.  The role and policy that are already gone are skipped, so a disable that failed part way can be repeated.
+func disableSFTP(ctx context.Context, iamService iamapi.IAM, transferService transferapi.Transfer, accountId, bucket string) ([]string, error) {
+	role, err := sftpRole(ctx, iamService, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := bucketSFTPUsers(ctx, transferService, role)
+	if err != nil {
+		return nil, err
+	}
+
+	deleted := []string{}
+	for _, u := range users {
+		if err := transferService.DeleteUser(ctx, aws.StringValue(u.UserName)); err != nil && !isNotFound(err) {
+			return deleted, err
+		}
+		deleted = append(deleted, aws.StringValue(u.UserName))
+	}
+
+	roleName := aws.StringValue(role.RoleName)
+	policyArn := iamService.PolicyArn(accountId, sftpPolicyName(bucket))
+
+	if err := iamService.DetachRolePolicy(ctx, &iam.DetachRolePolicyInput{
+		PolicyArn: aws.String(policyArn),
+		RoleName:  aws.String(roleName),
+	}); err != nil && !isNotFound(err) {
+		return deleted, err
+	}
+
+	if err := iamService.DeleteRole(ctx, roleName); err != nil && !isNotFound(err) {
+		return deleted, err
+	}
+
+	if err := iamService.DeletePolicy(ctx, &iam.DeletePolicyInput{PolicyArn: aws.String(policyArn)}); err != nil && !isNotFound(err) {
+		return deleted, err
+	}
+
+	return deleted, nil
+}
+
+// createSFTPUser creates a transfer user for the bucket with the first ssh public key and imports the rest, the user
+// is rolled back if a key can't be imported
+func (s *server) createSFTPUser(ctx context.Context, s3Service s3api.S3, iamService iamapi.IAM, transferService transferapi.Transfer, account, bucket string, req sftpUserRequest) (user *transfer.DescribedUser, err error) {
+	role, err := sftpRole(ctx, iamService, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	bucketTags, err := s3Service.GetBucketTags(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := []*transfer.Tag{}
+	for _, t := range s.requiredTags.inherited(bucketTags) {
+		tags = append(tags, &transfer.Tag{Key: t.Key, Value: t.Value})
+	}
+
+	rb := s.newRollback("sftp.user.create", account, req.UserName, rollbackServices{transfer: &transferService})
+	defer func() {
+		s.finishRollback(rb, err)
+	}()
+
+	if _, err = transferService.CreateUser(ctx, req.UserName, bucket, aws.StringValue(role.Arn), strings.TrimSpace(req.SshPublicKeys[0]), tags); err != nil {
+		return nil, err
+	}
+
+	rb.Add("delete transfer user "+req.UserName, rollbackDeleteTransferUser, map[string]string{"server_id": transferService.ServerID, "user": req.UserName})
+
+	for _, k := range req.SshPublicKeys[1:] {
+		if _, err = transferService.ImportSSHPublicKey(ctx, req.UserName, strings.TrimSpace(k)); err != nil {
+			return nil, err
+		}
+	}
+
+	return transferService.DescribeUser(ctx, req.UserName)
+}
+
+// sftpRole gets the sftp role of a bucket, sftp isn't enabled for the bucket if it doesn't exist
+func sftpRole(ctx context.Context, iamService iamapi.IAM, bucket string) (*iam.Role, error) {
+	role, err := iamService.GetRole(ctx, sftpRoleName(bucket))
+	if err != nil {
+		if isNotFound(err) {
+			msg := fmt.Sprintf("sftp is not enabled for bucket %s", bucket)
+			return nil, apierror.New(apierror.ErrNotFound, msg, err)
+		}
+		return nil, err
+	}
+
+	return role, nil
+}
+
+// bucketSFTPUsers lists the transfer users that access the bucket with its sftp role
+func bucketSFTPUsers(ctx context.Context, transferService transferapi.Transfer, role *iam.Role) ([]*transfer.ListedUser, error) {
+	users, err := transferService.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bucketUsers := []*transfer.ListedUser{}
+	for _, u := range users {
+		if aws.StringValue(u.Role) == aws.StringValue(role.Arn) {
+			bucketUsers = append(bucketUsers, u)
+		}
+	}
+
+	return bucketUsers, nil
+}
+
+// sftpUser gets a transfer user of the bucket, users on the server that don't use the bucket's sftp role aren't
+// found so they can't be managed through another bucket
+func sftpUser(ctx context.Context, iamService iamapi.IAM, transferService transferapi.Transfer, bucket, userName string) (*transfer.DescribedUser, error) {
+	role, err := sftpRole(ctx, iamService, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := transferService.DescribeUser(ctx, userName)
+	if err != nil {
+		return nil, err
+	}
+
+	if aws.StringValue(user.Role) != aws.StringValue(role.Arn) {
+		msg := fmt.Sprintf("sftp user %s not found for bucket %s", userName, bucket)
+		return nil, apierror.New(apierror.ErrNotFound, msg, nil)
+	}
+
+	return user, nil
+}
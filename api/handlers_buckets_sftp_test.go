@@ -0,0 +1,259 @@
+package api
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	transferapi "github.com/YaleSpinup/s3-api/transfer"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/transfer"
+	"github.com/aws/aws-sdk-go/service/transfer/transferiface"
+)
+
+// mockSFTPIAM is an iam client that tracks the roles, the policies and the policies attached to roles
+type mockSFTPIAM struct {
+	mockRepairIAM
+	roles      map[string][]string
+	failAttach bool
+}
+
+func (m *mockSFTPIAM) GetRoleWithContext(ctx context.Context, input *iam.GetRoleInput, opts ...request.Option) (*iam.GetRoleOutput, error) {
+	if _, ok := m.roles[aws.StringValue(input.RoleName)]; !ok {
+		return nil, awserr.New(iam.ErrCodeNoSuchEntityException, "role not found", nil)
+	}
+	return &iam.GetRoleOutput{Role: sftpTestRole(aws.StringValue(input.RoleName))}, nil
+}
+
+func (m *mockSFTPIAM) CreateRoleWithContext(ctx context.Context, input *iam.CreateRoleInput, opts ...request.Option) (*iam.CreateRoleOutput, error) {
+	m.roles[aws.StringValue(input.RoleName)] = []string{}
+	return &iam.CreateRoleOutput{Role: sftpTestRole(aws.StringValue(input.RoleName))}, nil
+}
+
+func (m *mockSFTPIAM) DeleteRoleWithContext(ctx context.Context, input *iam.DeleteRoleInput, opts ...request.Option) (*iam.DeleteRoleOutput, error) {
+	if len(m.roles[aws.StringValue(input.RoleName)]) > 0 {
+		return nil, awserr.New(iam.ErrCodeDeleteConflictException, "role has policies attached", nil)
+	}
+	delete(m.roles, aws.StringValue(input.RoleName))
+	return &iam.DeleteRoleOutput{}, nil
+}
+
+func (m *mockSFTPIAM) AttachRolePolicyWithContext(ctx context.Context, input *iam.AttachRolePolicyInput, opts ...request.Option) (*iam.AttachRolePolicyOutput, error) {
+	if m.failAttach {
+		return nil, awserr.New(iam.ErrCodeServiceFailureException, "boom", nil)
+	}
+	name := aws.StringValue(input.RoleName)
+	m.roles[name] = append(m.roles[name], aws.StringValue(input.PolicyArn))
+	return &iam.AttachRolePolicyOutput{}, nil
+}
+
+func (m *mockSFTPIAM) DetachRolePolicyWithContext(ctx context.Context, input *iam.DetachRolePolicyInput, opts ...request.Option) (*iam.DetachRolePolicyOutput, error) {
+	name := aws.StringValue(input.RoleName)
+	policies := []string{}
+	for _, p := range m.roles[name] {
+		if p != aws.StringValue(input.PolicyArn) {
+			policies = append(policies, p)
+		}
+	}
+	m.roles[name] = policies
+	return &iam.DetachRolePolicyOutput{}, nil
+}
+
+func sftpTestRole(name string) *iam.Role {
+	return &iam.Role{
+		Arn:      aws.String("arn:aws:iam::012345678910:role/" + name),
+		RoleName: aws.String(name),
+	}
+}
+
+// mockSFTPTransfer is a transfer client that tracks the users on the server, keys with the body "bad" are rejected
+type mockSFTPTransfer struct {
+	transferiface.TransferAPI
+	users map[string]*transfer.DescribedUser
+}
+
+func (m *mockSFTPTransfer) CreateUserWithContext(ctx context.Context, input *transfer.CreateUserInput, opts ...request.Option) (*transfer.CreateUserOutput, error) {
+	if _, ok := m.users[aws.StringValue(input.UserName)]; ok {
+		return nil, awserr.New(transfer.ErrCodeResourceExistsException, "user exists", nil)
+	}
+
+	user := &transfer.DescribedUser{Role: input.Role, UserName: input.UserName, SshPublicKeys: []*transfer.SshPublicKey{}}
+	if input.SshPublicKeyBody != nil {
+		user.SshPublicKeys = append(user.SshPublicKeys, &transfer.SshPublicKey{SshPublicKeyId: aws.String("key-0"), SshPublicKeyBody: input.SshPublicKeyBody})
+	}
+	m.users[aws.StringValue(input.UserName)] = user
+
+	return &transfer.CreateUserOutput{UserName: input.UserName}, nil
+}
+
+func (m *mockSFTPTransfer) DescribeUserWithContext(ctx context.Context, input *transfer.DescribeUserInput, opts ...request.Option) (*transfer.DescribeUserOutput, error) {
+	user, ok := m.users[aws.StringValue(input.UserName)]
+	if !ok {
+		return nil, awserr.New(transfer.ErrCodeResourceNotFoundException, "user not found", nil)
+	}
+	return &transfer.DescribeUserOutput{User: user}, nil
+}
+
+func (m *mockSFTPTransfer) ListUsersPagesWithContext(ctx context.Context, input *transfer.ListUsersInput, fn func(*transfer.ListUsersOutput, bool) bool, opts ...request.Option) error {
+	users := []*transfer.ListedUser{}
+	for _, u := range m.users {
+		users = append(users, &transfer.ListedUser{Role: u.Role, UserName: u.UserName})
+	}
+	fn(&transfer.ListUsersOutput{Users: users}, true)
+	return nil
+}
+
+func (m *mockSFTPTransfer) DeleteUserWithContext(ctx context.Context, input *transfer.DeleteUserInput, opts ...request.Option) (*transfer.DeleteUserOutput, error) {
+	if _, ok := m.users[aws.StringValue(input.UserName)]; !ok {
+		return nil, awserr.New(transfer.ErrCodeResourceNotFoundException, "user not found", nil)
+	}
+	delete(m.users, aws.StringValue(input.UserName))
+	return &transfer.DeleteUserOutput{}, nil
+}
+
+func (m *mockSFTPTransfer) ImportSshPublicKeyWithContext(ctx context.Context, input *transfer.ImportSshPublicKeyInput, opts ...request.Option) (*transfer.ImportSshPublicKeyOutput, error) {
+	if aws.StringValue(input.SshPublicKeyBody) == "bad" {
+		return nil, awserr.New(transfer.ErrCodeInvalidRequestException, "invalid key", nil)
+	}
+
+	user := m.users[aws.StringValue(input.UserName)]
+	user.SshPublicKeys = append(user.SshPublicKeys, &transfer.SshPublicKey{SshPublicKeyId: aws.String("key-1"), SshPublicKeyBody: input.SshPublicKeyBody})
+	return &transfer.ImportSshPublicKeyOutput{SshPublicKeyId: aws.String("key-1"), UserName: input.UserName}, nil
+}
+
+func TestSFTPUserRequestValidate(t *testing.T) {
+	key := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIG8ko2HmqAdJwRfmZY2xQDCQeAZ2Hl4qKNnRfkbsUIPv researcher@laptop"
+
+	tests := []struct {
+		req   sftpUserRequest
+		valid bool
+	}{
+		{sftpUserRequest{UserName: "researcher", SshPublicKeys: []string{key}}, true},
+		{sftpUserRequest{UserName: "re.search-er@yale", SshPublicKeys: []string{key, "ssh-rsa AAAAB3NzaC1yc2E="}}, true},
+		{sftpUserRequest{UserName: "researcher"}, false},
+		{sftpUserRequest{UserName: "ab", SshPublicKeys: []string{key}}, false},
+		{sftpUserRequest{UserName: ".researcher", SshPublicKeys: []string{key}}, false},
+		{sftpUserRequest{UserName: "researcher", SshPublicKeys: []string{"ssh-dss AAAAB3NzaC1kc3M="}}, false},
+		{sftpUserRequest{UserName: "researcher", SshPublicKeys: []string{"not a key"}}, false},
+	}
+
+	for _, test := range tests {
+		if err := test.req.validate(); (err == nil) != test.valid {
+			t.Errorf("expected %+v valid to be %t, got %v", test.req, test.valid, err)
+		}
+	}
+}
+
+func TestSFTP(t *testing.T) {
+	Org = "testorg"
+	s := server{}
+
+	bucketTags := []*s3.Tag{{Key: aws.String("spinup:org"), Value: aws.String("testorg")}}
+	s3Service := s3api.S3{Service: &mockAdoptS3{tags: bucketTags}}
+	iamClient := &mockSFTPIAM{
+		mockRepairIAM: mockRepairIAM{policies: map[string]bool{}, groups: map[string][]string{}},
+		roles:         map[string][]string{},
+	}
+	iamService := iamapi.IAM{Service: iamClient}
+	transferClient := &mockSFTPTransfer{users: map[string]*transfer.DescribedUser{
+		"other": {Role: aws.String("arn:aws:iam::012345678910:role/other-SftpRole"), UserName: aws.String("other")},
+	}}
+	transferService := transferapi.Transfer{Service: transferClient, ServerID: "s-1234"}
+	policyArn := iamapi.FormatPolicyArn("012345678910", "/", "dropbox-SftpPlc")
+
+	role, err := s.enableSFTP(context.TODO(), s3Service, iamService, "spinup", "012345678910", "dropbox")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if aws.StringValue(role.RoleName) != "dropbox-SftpRole" {
+		t.Errorf("expected role dropbox-SftpRole, got %s", aws.StringValue(role.RoleName))
+	}
+
+	if !reflect.DeepEqual(iamClient.roles["dropbox-SftpRole"], []string{policyArn}) || !iamClient.policies[policyArn] {
+		t.Errorf("expected policy %s attached to the role, got %+v", policyArn, iamClient.roles)
+	}
+
+	if _, err := s.enableSFTP(context.TODO(), s3Service, iamService, "spinup", "012345678910", "dropbox"); !common.IsErrorCode(err, apierror.ErrConflict) {
+		t.Errorf("expected conflict enabling sftp twice, got %v", err)
+	}
+
+	key := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIG8ko2HmqAdJwRfmZY2xQDCQeAZ2Hl4qKNnRfkbsUIPv"
+	user, err := s.createSFTPUser(context.TODO(), s3Service, iamService, transferService, "spinup", "dropbox", sftpUserRequest{
+		UserName:      "researcher",
+		SshPublicKeys: []string{key, key + " second"},
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if out := newSFTPUserOutput("dropbox", "sftp.example.edu", user); len(out.SshPublicKeys) != 2 {
+		t.Errorf("expected user with 2 keys, got %+v", out)
+	}
+
+	// the user is rolled back if one of its keys can't be imported
+	if _, err := s.createSFTPUser(context.TODO(), s3Service, iamService, transferService, "spinup", "dropbox", sftpUserRequest{
+		UserName:      "rolledback",
+		SshPublicKeys: []string{key, "bad"},
+	}); err == nil {
+		t.Error("expected error for a bad key, got nil")
+	}
+
+	if _, ok := transferClient.users["rolledback"]; ok {
+		t.Error("expected user to be rolled back")
+	}
+
+	if _, err := sftpUser(context.TODO(), iamService, transferService, "dropbox", "researcher"); err != nil {
+		t.Errorf("expected nil error getting the bucket's user, got %s", err)
+	}
+
+	// users of other buckets aren't found through the bucket
+	if _, err := sftpUser(context.TODO(), iamService, transferService, "dropbox", "other"); !common.IsErrorCode(err, apierror.ErrNotFound) {
+		t.Errorf("expected not found for another bucket's user, got %v", err)
+	}
+
+	deleted, err := disableSFTP(context.TODO(), iamService, transferService, "012345678910", "dropbox")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if !reflect.DeepEqual(deleted, []string{"researcher"}) {
+		t.Errorf("expected researcher to be deleted, got %v", deleted)
+	}
+
+	remaining := []string{}
+	for u := range transferClient.users {
+		remaining = append(remaining, u)
+	}
+	sort.Strings(remaining)
+	if !reflect.DeepEqual(remaining, []string{"other"}) {
+		t.Errorf("expected only the other bucket's user to remain, got %v", remaining)
+	}
+
+	if len(iamClient.roles) != 0 || len(iamClient.policies) != 0 {
+		t.Errorf("expected the role and policy to be deleted, got %+v %+v", iamClient.roles, iamClient.policies)
+	}
+
+	if _, err := disableSFTP(context.TODO(), iamService, transferService, "012345678910", "dropbox"); !common.IsErrorCode(err, apierror.ErrNotFound) {
+		t.Errorf("expected not found disabling sftp twice, got %v", err)
+	}
+
+	// the role and policy are rolled back if the policy can't be attached
+	iamClient.failAttach = true
+	if _, err := s.enableSFTP(context.TODO(), s3Service, iamService, "spinup", "012345678910", "dropbox"); err == nil {
+		t.Error("expected error when the policy can't be attached, got nil")
+	}
+
+	if len(iamClient.roles) != 0 || len(iamClient.policies) != 0 {
+		t.Errorf("expected the role and policy to be rolled back, got %+v %+v", iamClient.roles, iamClient.policies)
+	}
+}
@@ -1,7 +1,130 @@
 package api
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cwapi "github.com/YaleSpinup/s3-api/cloudwatch"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
 
 func TestBucketCreateHandler(t *testing.T) {
 
 }
+
+// mockUsageS3 is an s3 client with a fixed number of objects of the same size
+type mockUsageS3 struct {
+	s3iface.S3API
+	objects int
+}
+
+func (m *mockUsageS3) ListObjectsV2PagesWithContext(ctx context.Context, input *s3.ListObjectsV2Input, fn func(*s3.ListObjectsV2Output, bool) bool, opts ...request.Option) error {
+	out := &s3.ListObjectsV2Output{}
+	for i := 0; i < m.objects; i++ {
+		out.Contents = append(out.Contents, &s3.Object{Key: aws.String("object"), Size: aws.Int64(10)})
+
+		// pages of 1000 objects like s3
+		if len(out.Contents) == 1000 || i == m.objects-1 {
+			if !fn(out, i == m.objects-1) {
+				return nil
+			}
+			out = &s3.ListObjectsV2Output{}
+		}
+	}
+	return nil
+}
+
+// mockUsageCloudWatch is a cloudwatch client that returns the storage metrics or an error
+type mockUsageCloudWatch struct {
+	cloudwatchiface.CloudWatchAPI
+	objects *float64
+	err     error
+}
+
+func (m *mockUsageCloudWatch) ListMetricsPagesWithContext(ctx context.Context, input *cloudwatch.ListMetricsInput, fn func(*cloudwatch.ListMetricsOutput, bool) bool, opts ...request.Option) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	fn(&cloudwatch.ListMetricsOutput{
+		Metrics: []*cloudwatch.Metric{
+			{Dimensions: []*cloudwatch.Dimension{{Name: aws.String("StorageType"), Value: aws.String("StandardStorage")}}},
+		},
+	}, true)
+	return nil
+}
+
+func (m *mockUsageCloudWatch) GetMetricDataWithContext(ctx context.Context, input *cloudwatch.GetMetricDataInput, opts ...request.Option) (*cloudwatch.GetMetricDataOutput, error) {
+	if m.objects == nil {
+		return &cloudwatch.GetMetricDataOutput{}, nil
+	}
+
+	return &cloudwatch.GetMetricDataOutput{
+		MetricDataResults: []*cloudwatch.MetricDataResult{
+			{Id: aws.String("objects"), Timestamps: []*time.Time{aws.Time(testTime)}, Values: []*float64{m.objects}},
+			{Id: aws.String("size1"), Timestamps: []*time.Time{aws.Time(testTime)}, Values: []*float64{aws.Float64(4096)}},
+		},
+	}, nil
+}
+
+func TestGetBucketUsage(t *testing.T) {
+	type test struct {
+		s3         *mockUsageS3
+		cloudWatch *mockUsageCloudWatch
+		empty      bool
+		expected   bucketUsage
+	}
+
+	tests := map[string]test{
+		"metrics": {
+			s3:         &mockUsageS3{objects: 5},
+			cloudWatch: &mockUsageCloudWatch{objects: aws.Float64(12)},
+			expected:   bucketUsage{Objects: 12, Bytes: 4096, Source: "cloudwatch", Timestamp: &testTime},
+		},
+		"no metrics": {
+			s3:         &mockUsageS3{objects: 5},
+			cloudWatch: &mockUsageCloudWatch{},
+			expected:   bucketUsage{Objects: 5, Bytes: 50, Source: "scan"},
+		},
+		"metrics error": {
+			s3:         &mockUsageS3{objects: 5},
+			cloudWatch: &mockUsageCloudWatch{err: errors.New("boom")},
+			expected:   bucketUsage{Objects: 5, Bytes: 50, Source: "scan"},
+		},
+		"truncated": {
+			s3:         &mockUsageS3{objects: maxBucketUsageScan + 1},
+			cloudWatch: &mockUsageCloudWatch{},
+			expected:   bucketUsage{Objects: maxBucketUsageScan, Bytes: maxBucketUsageScan * 10, Source: "scan", Truncated: true},
+		},
+		"empty": {
+			s3:         &mockUsageS3{},
+			cloudWatch: &mockUsageCloudWatch{},
+			empty:      true,
+			expected:   bucketUsage{Source: "scan"},
+		},
+	}
+
+	for name, tc := range tests {
+		out, err := getBucketUsage(context.TODO(), s3api.S3{Service: tc.s3}, cwapi.CloudWatch{Service: tc.cloudWatch}, "testbucket", tc.empty)
+		if err != nil {
+			t.Errorf("%s: expected nil error, got %s", name, err)
+			continue
+		}
+
+		if out.Objects != tc.expected.Objects || out.Bytes != tc.expected.Bytes || out.Source != tc.expected.Source || out.Truncated != tc.expected.Truncated {
+			t.Errorf("%s: expected %+v, got %+v", name, tc.expected, out)
+		}
+
+		if (tc.expected.Timestamp == nil) != (out.Timestamp == nil) || (out.Timestamp != nil && !out.Timestamp.Equal(*tc.expected.Timestamp)) {
+			t.Errorf("%s: expected timestamp %v, got %v", name, tc.expected.Timestamp, out.Timestamp)
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package api
 
 import (
-	"fmt"
 	"net/http"
 
 	"github.com/YaleSpinup/apierror"
@@ -25,24 +24,9 @@ func (s *server) BucketDuck(w http.ResponseWriter, r *http.Request) {
 		path = "/"
 	}
 
-	role := fmt.Sprintf("arn:aws:iam::%s:role/%s", accountId, s.session.RoleName)
-	policy, err := generatePolicy("s3:ListBucket")
+	session, err := s.sessionForAccountWithPolicies(r.Context(), accountId, []string{"arn:aws:iam::aws:policy/AmazonS3ReadOnlyAccess"}, "s3:ListBucket")
 	if err != nil {
-		log.Errorf("cannot generate policy: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	session, err := s.assumeRole(
-		r.Context(),
-		s.session.ExternalID,
-		role,
-		policy,
-		"arn:aws:iam::aws:policy/AmazonS3ReadOnlyAccess",
-	)
-	if err != nil {
-		log.Errorf("failed to assume role in account: %s", accountId)
-		w.WriteHeader(http.StatusInternalServerError)
+		handleError(w, err)
 		return
 	}
 
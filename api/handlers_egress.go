@@ -0,0 +1,247 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	cwapi "github.com/YaleSpinup/s3-api/cloudwatch"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// egressReportConcurrency is the number of buckets whose metrics are read at the same time for the egress report
+	egressReportConcurrency = 10
+	// defaultEgressDays and maxEgressDays are the default and longest windows of the egress report
+	defaultEgressDays = 7
+	maxEgressDays     = 90
+	// defaultEgressThreshold is the average bytes downloaded per day that flags a bucket if it's not configured
+	defaultEgressThreshold = 50 << 30
+)
+
+// egressActions are the actions needed for the egress report
+var egressActions = []string{
+	"s3:ListAllMyBuckets",
+	"s3:GetBucketTagging",
+	"cloudwatch:GetMetricData",
+	"cloudwatch:ListMetrics",
+}
+
+// bucketEgress is the data downloaded from a bucket and the requests made to it over the window of the egress report
+type bucketEgress struct {
+	Bucket string
+	// FilterId is the id of the bucket's metrics configuration the requests were read from
+	FilterId        string
+	BytesDownloaded int64
+	// DailyBytes is the average number of bytes downloaded per day
+	DailyBytes  int64
+	Requests    int64
+	GetRequests int64
+}
+
+// egressReport is the data downloaded from the buckets in an account over a window, the buckets that downloaded more
+// than the threshold per day on average are flagged
+type egressReport struct {
+	Start time.Time
+	End   time.Time
+	Days  int
+	// Threshold is the average number of bytes downloaded per day that flags a bucket
+	Threshold int64
+	Checked   int
+	// Exceeded are the buckets over the threshold, WithinThreshold are the rest of the buckets with request
+	// metrics, both are sorted by the bytes downloaded
+	Exceeded        []bucketEgress
+	WithinThreshold []bucketEgress
+	// WithoutMetrics are the buckets that don't have request metrics enabled
+	WithoutMetrics []string
+}
+
+// EgressReportHandler reports on the data downloaded from each of the org's buckets in an account over a window of
+// days, from the s3 request metrics.  The `days` query parameter sets the window (default 7) and the `threshold` query
+// parameter overrides the configured average bytes per day that flags a bucket.
+func (s *server) EgressReportHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+
+	days, threshold, err := s.egressReportParams(r)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	session, err := s.sessionForAccount(r.Context(), vars["account"], egressActions...)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+	cloudWatchService := cwapi.NewSession(session.Session, s.account)
+
+	// the window ends at the start of the day so the daily sums are complete
+	end := time.Now().UTC().Truncate(24 * time.Hour)
+	start := end.Add(-time.Duration(days) * 24 * time.Hour)
+
+	report, err := bucketEgressReport(r.Context(), s3Service, cloudWatchService, start, end, days, threshold)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	j, err := json.Marshal(report)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", report, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// egressReportParams returns the window and threshold of the egress report from the query parameters
+func (s *server) egressReportParams(r *http.Request) (int, int64, error) {
+	days := defaultEgressDays
+	if d := r.URL.Query().Get("days"); d != "" {
+		var err error
+		if days, err = strconv.Atoi(d); err != nil || days < 1 || days > maxEgressDays {
+			msg := fmt.Sprintf("days must be between 1 and %d, got %q", maxEgressDays, d)
+			return 0, 0, apierror.New(apierror.ErrBadRequest, msg, nil)
+		}
+	}
+
+	threshold := s.account.EgressThreshold
+	if threshold <= 0 {
+		threshold = defaultEgressThreshold
+	}
+
+	if t := r.URL.Query().Get("threshold"); t != "" {
+		var err error
+		if threshold, err = strconv.ParseInt(t, 10, 64); err != nil || threshold <= 0 {
+			msg := fmt.Sprintf("threshold must be a positive number of bytes, got %q", t)
+			return 0, 0, apierror.New(apierror.ErrBadRequest, msg, nil)
+		}
+	}
+
+	return days, threshold, nil
+}
+
+// bucketEgressReport reads the request metrics of each of the org's buckets over the window
+func bucketEgressReport(ctx context.Context, s3Service s3api.S3, cloudWatchService cwapi.CloudWatch, start, end time.Time, days int, threshold int64) (*egressReport, error) {
+	buckets, err := s3Service.ListBuckets(ctx, &s3.ListBucketsInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	owned := make([]bool, len(buckets))
+	usage := make([]*bucketEgress, len(buckets))
+
+	g, gctx := newErrGroup(ctx)
+	sem := make(chan struct{}, egressReportConcurrency)
+	for i, b := range buckets {
+		i, name := i, aws.StringValue(b.Name)
+		g.Go(func() error {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			tags, err := s3Service.GetBucketTags(gctx, name)
+			if err != nil {
+				return err
+			}
+
+			if s3TagMap(tags)["spinup:org"] != Org {
+				return nil
+			}
+			owned[i] = true
+
+			requests, err := cloudWatchService.GetBucketRequests(gctx, name, start, end)
+			if err != nil {
+				return err
+			}
+
+			if requests != nil {
+				usage[i] = &bucketEgress{
+					Bucket:          name,
+					FilterId:        requests.FilterId,
+					BytesDownloaded: requests.BytesDownloaded,
+					Requests:        requests.Requests,
+					GetRequests:     requests.GetRequests,
+				}
+			}
+
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	checked := []string{}
+	for i, b := range buckets {
+		if owned[i] {
+			checked = append(checked, aws.StringValue(b.Name))
+		}
+	}
+
+	return newEgressReport(start, end, days, threshold, checked, usage), nil
+}
+
+// newEgressReport flags the buckets that downloaded more than the threshold per day on average.  The checked buckets
+// that don't have any usage don't have request metrics.
+func newEgressReport(start, end time.Time, days int, threshold int64, checked []string, usage []*bucketEgress) *egressReport {
+	report := &egressReport{
+		Start:           start,
+		End:             end,
+		Days:            days,
+		Threshold:       threshold,
+		Checked:         len(checked),
+		Exceeded:        []bucketEgress{},
+		WithinThreshold: []bucketEgress{},
+		WithoutMetrics:  []string{},
+	}
+
+	measured := map[string]bool{}
+	for _, u := range usage {
+		if u == nil {
+			continue
+		}
+		measured[u.Bucket] = true
+
+		u.DailyBytes = u.BytesDownloaded / int64(days)
+		if u.DailyBytes > threshold {
+			report.Exceeded = append(report.Exceeded, *u)
+		} else {
+			report.WithinThreshold = append(report.WithinThreshold, *u)
+		}
+	}
+
+	for _, b := range checked {
+		if !measured[b] {
+			report.WithoutMetrics = append(report.WithoutMetrics, b)
+		}
+	}
+
+	for _, list := range [][]bucketEgress{report.Exceeded, report.WithinThreshold} {
+		sort.SliceStable(list, func(i, j int) bool {
+			if list[i].BytesDownloaded != list[j].BytesDownloaded {
+				return list[i].BytesDownloaded > list[j].BytesDownloaded
+			}
+			return list[i].Bucket < list[j].Bucket
+		})
+	}
+	sort.Strings(report.WithoutMetrics)
+
+	return report
+}
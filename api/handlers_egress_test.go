@@ -0,0 +1,85 @@
+package api
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+)
+
+func TestEgressReportParams(t *testing.T) {
+	s := server{}
+
+	days, threshold, err := s.egressReportParams(httptest.NewRequest("GET", "/v1/s3/spinup/reports/egress", nil))
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if days != defaultEgressDays || threshold != defaultEgressThreshold {
+		t.Errorf("expected the default window and threshold, got %d days and %d bytes", days, threshold)
+	}
+
+	s.account = common.Account{EgressThreshold: 1 << 30}
+	if _, threshold, _ = s.egressReportParams(httptest.NewRequest("GET", "/v1/s3/spinup/reports/egress", nil)); threshold != 1<<30 {
+		t.Errorf("expected the configured threshold, got %d", threshold)
+	}
+
+	days, threshold, err = s.egressReportParams(httptest.NewRequest("GET", "/v1/s3/spinup/reports/egress?days=30&threshold=1000", nil))
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if days != 30 || threshold != 1000 {
+		t.Errorf("expected 30 days and a threshold of 1000 bytes, got %d days and %d bytes", days, threshold)
+	}
+
+	for _, q := range []string{"days=0", "days=91", "days=week", "threshold=0", "threshold=lots"} {
+		if _, _, err := s.egressReportParams(httptest.NewRequest("GET", "/v1/s3/spinup/reports/egress?"+q, nil)); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+			t.Errorf("expected bad request for %s, got %v", q, err)
+		}
+	}
+}
+
+func TestNewEgressReport(t *testing.T) {
+	end := time.Date(2026, 5, 8, 0, 0, 0, 0, time.UTC)
+	start := end.Add(-7 * 24 * time.Hour)
+
+	usage := []*bucketEgress{
+		{Bucket: "videos", FilterId: "EntireBucket", BytesDownloaded: 700 << 30, Requests: 1000, GetRequests: 900},
+		nil,
+		{Bucket: "datasets", FilterId: "EntireBucket", BytesDownloaded: 7 << 30, Requests: 50, GetRequests: 40},
+		{Bucket: "lectures", FilterId: "EntireBucket", BytesDownloaded: 140 << 30, Requests: 200, GetRequests: 190},
+		nil,
+	}
+
+	report := newEgressReport(start, end, 7, 10<<30, []string{"videos", "scratch", "datasets", "lectures", "archive"}, usage)
+
+	expected := &egressReport{
+		Start:     start,
+		End:       end,
+		Days:      7,
+		Threshold: 10 << 30,
+		Checked:   5,
+		Exceeded: []bucketEgress{
+			{Bucket: "videos", FilterId: "EntireBucket", BytesDownloaded: 700 << 30, DailyBytes: 100 << 30, Requests: 1000, GetRequests: 900},
+			{Bucket: "lectures", FilterId: "EntireBucket", BytesDownloaded: 140 << 30, DailyBytes: 20 << 30, Requests: 200, GetRequests: 190},
+		},
+		WithinThreshold: []bucketEgress{
+			{Bucket: "datasets", FilterId: "EntireBucket", BytesDownloaded: 7 << 30, DailyBytes: 1 << 30, Requests: 50, GetRequests: 40},
+		},
+		WithoutMetrics: []string{"archive", "scratch"},
+	}
+
+	if !reflect.DeepEqual(expected, report) {
+		t.Errorf("expected %+v, got %+v", expected, report)
+	}
+
+	// an empty account has empty lists rather than nulls
+	report = newEgressReport(start, end, 7, 10<<30, []string{}, nil)
+	if report.Exceeded == nil || report.WithinThreshold == nil || report.WithoutMetrics == nil {
+		t.Errorf("expected empty lists, got %+v", report)
+	}
+}
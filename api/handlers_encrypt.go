@@ -0,0 +1,200 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/YaleSpinup/apierror"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/YaleSpinup/s3-api/task"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// encryptTaskKind is the kind of the task that encrypts the existing objects in a bucket
+	encryptTaskKind = "encrypt"
+	// encryptPageSize is the number of objects listed at a time, a fresh session is used for each page
+	encryptPageSize = 250
+	// encryptConcurrency is the number of objects encrypted at the same time
+	encryptConcurrency = 10
+	// maxCopyObjectSize is the largest object that can be copied in a single CopyObject request
+	maxCopyObjectSize = 5 << 30
+)
+
+// encryptActions are the actions needed to encrypt the existing objects in a bucket
+var encryptActions = []string{
+	"s3:GetEncryptionConfiguration",
+	"s3:ListBucket",
+	"s3:GetObject",
+	"s3:PutObject",
+	"s3:GetObjectTagging",
+	"s3:PutObjectTagging",
+	"kms:Decrypt",
+	"kms:GenerateDataKey",
+}
+
+// BucketEncryptObjectsHandler starts a background task that encrypts the existing unencrypted objects in a bucket
+// with the bucket's default encryption.  Changing the default encryption of a bucket only applies to new objects, this
+// remediates the buckets created before encryption was enforced.  The task is returned with 202 Accepted and its
+// progress can be followed with the task endpoints.
+func (s *server) BucketEncryptObjectsHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	account := vars["account"]
+	accountId := s.mapAccountNumber(account)
+	bucket := vars["bucket"]
+
+	// the task can outlive the credentials of an assumed role, so it gets a session for each page of objects
+	services := func(ctx context.Context) (s3api.S3, error) {
+		session, err := s.sessionForAccount(ctx, account, encryptActions...)
+		if err != nil {
+			return s3api.S3{}, err
+		}
+		return s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId)), nil
+	}
+
+	s3Service, err := services(r.Context())
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	exists, err := s3Service.BucketExists(r.Context(), bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if !exists {
+		handleError(w, apierror.New(apierror.ErrNotFound, "bucket not found", nil))
+		return
+	}
+
+	t, err := s.tasks.Start(encryptTaskKind, accountId, bucket, func(ctx context.Context, rep *task.Reporter) error {
+		return encryptObjects(ctx, rep, services, bucket)
+	})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeTask(w, http.StatusAccepted, t)
+}
+
+// encryptObjects encrypts the unencrypted objects in a bucket, a page at a time.  Objects that fail to encrypt are
+// reported on the task and skipped, the task only fails if the objects can't be listed.
+func encryptObjects(ctx context.Context, rep *task.Reporter, services func(context.Context) (s3api.S3, error), bucket string) error {
+	s3Service, err := services(ctx)
+	if err != nil {
+		return err
+	}
+
+	enc, err := s3Service.GetBucketEncryption(ctx, bucket)
+	if err != nil {
+		return err
+	}
+
+	sse := &s3.ServerSideEncryptionByDefault{SSEAlgorithm: aws.String(s3.ServerSideEncryptionAes256)}
+	if enc != nil {
+		for _, rule := range enc.Rules {
+			if rule.ApplyServerSideEncryptionByDefault != nil {
+				sse = rule.ApplyServerSideEncryptionByDefault
+				break
+			}
+		}
+	}
+
+	input := &s3.ListObjectsV2Input{
+		Bucket:  aws.String(bucket),
+		MaxKeys: aws.Int64(encryptPageSize),
+	}
+
+	for {
+		s3Service, err := services(ctx)
+		if err != nil {
+			return err
+		}
+
+		out, err := s3Service.ListObjects(ctx, input)
+		if err != nil {
+			return err
+		}
+
+		rep.Found(len(out.Contents))
+
+		g, gctx := newErrGroup(ctx)
+		sem := make(chan struct{}, encryptConcurrency)
+		for _, o := range out.Contents {
+			key := aws.StringValue(o.Key)
+			g.Go(func() error {
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				encryptObject(gctx, rep, s3Service, bucket, key, sse)
+				return nil
+			})
+		}
+		g.Wait()
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if !aws.BoolValue(out.IsTruncated) {
+			return nil
+		}
+		input.ContinuationToken = out.NextContinuationToken
+	}
+}
+
+// encryptObject copies an unencrypted object in place with the encryption.  The metadata, tags and storage class are
+// copied from the object, and the copy only happens if the object hasn't changed since it was checked.
+func encryptObject(ctx context.Context, rep *task.Reporter, s3Service s3api.S3, bucket, key string, sse *s3.ServerSideEncryptionByDefault) {
+	head, err := s3Service.HeadObject(ctx, bucket, key)
+	if err != nil {
+		rep.Failed("%s: %s", key, err)
+		return
+	}
+
+	if aws.StringValue(head.ServerSideEncryption) != "" {
+		rep.Skipped()
+		return
+	}
+
+	switch aws.StringValue(head.StorageClass) {
+	case s3.StorageClassGlacier, s3.StorageClassDeepArchive:
+		rep.Failed("%s: archived objects can't be copied without restoring them", key)
+		return
+	}
+
+	if aws.Int64Value(head.ContentLength) > maxCopyObjectSize {
+		rep.Failed("%s: objects larger than 5GB can't be copied in place", key)
+		return
+	}
+
+	input := &s3.CopyObjectInput{
+		Bucket:               aws.String(bucket),
+		Key:                  aws.String(key),
+		CopySource:           aws.String(url.PathEscape(fmt.Sprintf("%s/%s", bucket, key))),
+		CopySourceIfMatch:    head.ETag,
+		MetadataDirective:    aws.String(s3.MetadataDirectiveCopy),
+		TaggingDirective:     aws.String(s3.TaggingDirectiveCopy),
+		ServerSideEncryption: sse.SSEAlgorithm,
+		StorageClass:         head.StorageClass,
+	}
+
+	if aws.StringValue(sse.SSEAlgorithm) == s3.ServerSideEncryptionAwsKms {
+		input.SSEKMSKeyId = sse.KMSMasterKeyID
+	}
+
+	if _, err := s3Service.CopyObject(ctx, input); err != nil {
+		rep.Failed("%s: %s", key, err)
+		return
+	}
+
+	rep.Succeeded()
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/YaleSpinup/s3-api/task"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/gorilla/mux"
+)
+
+// mockEncryptS3 is an s3 client with two pages of objects that captures the copies
+type mockEncryptS3 struct {
+	s3iface.S3API
+	objects map[string]*s3.HeadObjectOutput
+	copies  []*s3.CopyObjectInput
+	mu      sync.Mutex
+}
+
+func (m *mockEncryptS3) GetBucketEncryptionWithContext(ctx context.Context, input *s3.GetBucketEncryptionInput, opts ...request.Option) (*s3.GetBucketEncryptionOutput, error) {
+	return &s3.GetBucketEncryptionOutput{
+		ServerSideEncryptionConfiguration: &s3.ServerSideEncryptionConfiguration{
+			Rules: []*s3.ServerSideEncryptionRule{
+				{
+					ApplyServerSideEncryptionByDefault: &s3.ServerSideEncryptionByDefault{
+						SSEAlgorithm:   aws.String("aws:kms"),
+						KMSMasterKeyID: aws.String("alias/foo"),
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+func (m *mockEncryptS3) ListObjectsV2WithContext(ctx context.Context, input *s3.ListObjectsV2Input, opts ...request.Option) (*s3.ListObjectsV2Output, error) {
+	keys := []string{}
+	for k := range m.objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	// the first two keys are on the first page
+	if input.ContinuationToken == nil {
+		return &s3.ListObjectsV2Output{
+			Contents:              []*s3.Object{{Key: aws.String(keys[0])}, {Key: aws.String(keys[1])}},
+			IsTruncated:           aws.Bool(true),
+			NextContinuationToken: aws.String("page2"),
+		}, nil
+	}
+
+	contents := []*s3.Object{}
+	for _, k := range keys[2:] {
+		contents = append(contents, &s3.Object{Key: aws.String(k)})
+	}
+
+	return &s3.ListObjectsV2Output{Contents: contents, IsTruncated: aws.Bool(false)}, nil
+}
+
+func (m *mockEncryptS3) HeadObjectWithContext(ctx context.Context, input *s3.HeadObjectInput, opts ...request.Option) (*s3.HeadObjectOutput, error) {
+	head, ok := m.objects[aws.StringValue(input.Key)]
+	if !ok {
+		return nil, awserr.New("NotFound", "not found", nil)
+	}
+	return head, nil
+}
+
+func (m *mockEncryptS3) CopyObjectWithContext(ctx context.Context, input *s3.CopyObjectInput, opts ...request.Option) (*s3.CopyObjectOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.copies = append(m.copies, input)
+	return &s3.CopyObjectOutput{}, nil
+}
+
+func TestEncryptObjects(t *testing.T) {
+	m := &mockEncryptS3{
+		objects: map[string]*s3.HeadObjectOutput{
+			"a.html":      {ETag: aws.String(`"a"`), ContentLength: aws.Int64(10)},
+			"b.html":      {ETag: aws.String(`"b"`), ContentLength: aws.Int64(10), ServerSideEncryption: aws.String("AES256")},
+			"c d.png":     {ETag: aws.String(`"c"`), ContentLength: aws.Int64(10), StorageClass: aws.String("STANDARD_IA")},
+			"d.tar":       {ETag: aws.String(`"d"`), ContentLength: aws.Int64(10), StorageClass: aws.String("GLACIER")},
+			"e-large.bin": {ETag: aws.String(`"e"`), ContentLength: aws.Int64(6 << 30)},
+		},
+	}
+
+	services := func(ctx context.Context) (s3api.S3, error) {
+		return s3api.S3{Service: m}, nil
+	}
+
+	manager := task.NewManager(context.Background())
+	started, err := manager.Start(encryptTaskKind, "012345678910", "foobucket", func(ctx context.Context, rep *task.Reporter) error {
+		return encryptObjects(ctx, rep, services, "foobucket")
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	var finished *task.Task
+	for i := 0; i < 100; i++ {
+		if finished, _ = manager.Get(started.ID); finished.Status != task.StatusRunning {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if finished.Status != task.StatusSucceeded {
+		t.Fatalf("expected succeeded task, got %+v", finished)
+	}
+
+	expected := task.Progress{Total: 5, Succeeded: 2, Skipped: 1, Failed: 2}
+	if finished.Progress != expected {
+		t.Errorf("expected progress %+v, got %+v", expected, finished.Progress)
+	}
+
+	sort.Slice(m.copies, func(i, j int) bool { return aws.StringValue(m.copies[i].Key) < aws.StringValue(m.copies[j].Key) })
+	if len(m.copies) != 2 {
+		t.Fatalf("expected 2 copies, got %d", len(m.copies))
+	}
+
+	for _, c := range m.copies {
+		if aws.StringValue(c.ServerSideEncryption) != "aws:kms" || aws.StringValue(c.SSEKMSKeyId) != "alias/foo" {
+			t.Errorf("expected kms encryption with alias/foo, got %+v", c)
+		}
+
+		if aws.StringValue(c.MetadataDirective) != "COPY" || aws.StringValue(c.TaggingDirective) != "COPY" {
+			t.Errorf("expected metadata and tags to be copied, got %+v", c)
+		}
+	}
+
+	if src := aws.StringValue(m.copies[1].CopySource); src != "foobucket%2Fc%20d.png" {
+		t.Errorf("expected escaped copy source, got %s", src)
+	}
+
+	if aws.StringValue(m.copies[1].StorageClass) != "STANDARD_IA" || aws.StringValue(m.copies[1].CopySourceIfMatch) != `"c"` {
+		t.Errorf("expected the storage class and etag to be kept, got %+v", m.copies[1])
+	}
+}
+
+func TestTaskShowHandlerOtherAccount(t *testing.T) {
+	s := server{
+		accountsMap: map[string]string{"foo": "012345678910", "bar": "109876543210"},
+		tasks:       task.NewManager(context.Background()),
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	started, err := s.tasks.Start(encryptTaskKind, "012345678910", "foobucket", func(ctx context.Context, rep *task.Reporter) error {
+		<-done
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	for account, code := range map[string]int{"foo": http.StatusOK, "bar": http.StatusNotFound} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/s3/"+account+"/tasks/"+started.ID, nil)
+		req = mux.SetURLVars(req, map[string]string{"account": account, "id": started.ID})
+		w := httptest.NewRecorder()
+
+		s.TaskShowHandler(w, req)
+
+		if w.Code != code {
+			t.Errorf("%s: expected %d, got %d", account, code, w.Code)
+		}
+
+		if code != http.StatusOK {
+			continue
+		}
+
+		var out task.Task
+		if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+			t.Fatalf("failed to unmarshal task: %s", err)
+		}
+
+		if out.ID != started.ID || out.Kind != encryptTaskKind || out.Status != task.StatusRunning {
+			t.Errorf("unexpected task %+v", out)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/YaleSpinup/apierror"
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	route53api "github.com/YaleSpinup/s3-api/route53"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// bucketExport is the exported definition of everything provisioned for a bucket or website
+type bucketExport struct {
+	Bucket string
+	// Spec can be applied with the bucket spec endpoint to recreate the bucket
+	Spec    bucketSpec
+	Groups  []*groupExport
+	Website *websiteExport `json:",omitempty"`
+}
+
+// groupExport is an exported management group and the bucket policies attached to it
+type groupExport struct {
+	GroupName string
+	Policies  []*policyExport
+}
+
+// policyExport is an exported managed policy
+type policyExport struct {
+	PolicyName string
+	PolicyArn  string
+	Document   string
+}
+
+// websiteExport is the exported cloudfront distribution and dns record for a website
+type websiteExport struct {
+	HostedZoneID string
+	Distribution *cloudfront.DistributionSummary
+	DNSRecord    *route53.ResourceRecordSet
+}
+
+// BucketExportHandler exports the definition of a bucket, or website, as a reusable template.  The bucket settings,
+// management groups and their policies are exported and, for websites, the cloudfront distribution and dns record.  The
+// `format` query parameter selects `json` (default) or `terraform` output.
+func (s *server) BucketExportHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+
+	bucket, isWebsite := vars["website"]
+	if !isWebsite {
+		bucket = vars["bucket"]
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+
+	if format != "json" && format != "terraform" {
+		handleError(w, apierror.New(apierror.ErrBadRequest, "unsupported export format "+format, nil))
+		return
+	}
+
+	session, err := s.sessionForAccount(r.Context(), accountId, "s3:Get*", "s3:List*", "iam:Get*", "iam:List*", "cloudfront:Get*", "cloudfront:List*", "route53:Get*", "route53:List*")
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+	iamService := iamapi.NewSession(session.Session, s.account)
+	iamService.Cache = s.resourceCache(accountId)
+
+	exists, err := s3Service.BucketExists(r.Context(), bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if !exists {
+		handleError(w, apierror.New(apierror.ErrNotFound, "bucket not found", nil))
+		return
+	}
+
+	export := bucketExport{Bucket: bucket, Groups: []*groupExport{}}
+
+	tags, err := s3Service.GetBucketTags(r.Context(), bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	// the org tag is added whenever a spec is applied and the reserved tags are kept
+	for _, t := range tags {
+		if k := aws.StringValue(t.Key); k != "spinup:org" && !isReservedTag(k) {
+			export.Spec.Tags = append(export.Spec.Tags, t)
+		}
+	}
+
+	if objectTags := defaultObjectTags(tags); len(objectTags) > 0 {
+		export.Spec.ObjectTags = objectTags
+	}
+
+	enc, err := s3Service.GetBucketEncryption(r.Context(), bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if e := toBucketEncryption(enc); e.SSEAlgorithm != "" {
+		export.Spec.Encryption = aws.String(e.SSEAlgorithm)
+		if e.SSEAlgorithm == s3.ServerSideEncryptionAwsKms {
+			export.Spec.BucketKey = aws.Bool(e.BucketKeyEnabled)
+		}
+	}
+
+	versioning, err := s3Service.GetBucketVersioning(r.Context(), bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if versioning != "" {
+		export.Spec.Versioning = aws.String(versioning)
+	}
+
+	rules, err := s3Service.GetBucketLifecycleConfiguration(r.Context(), bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	export.Spec.Lifecycle = aws.String(lifecycleName(rules))
+
+	logging, err := s3Service.GetBucketLogging(r.Context(), bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	export.Spec.Logging = aws.Bool(logging != nil)
+
+	bucketPolicy, err := s3Service.GetBucketPolicy(r.Context(), bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if bucketPolicy != "" {
+		export.Spec.BucketPolicy = aws.String(bucketPolicy)
+	}
+
+	groups, err := iamService.ListGroups(r.Context(), &iam.ListGroupsInput{}, bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	for _, g := range groups {
+		groupName := aws.StringValue(g.GroupName)
+		path, groupType, ok := iamapi.ParseGroupName(bucket, groupName, iamapi.TrimPathPrefix(iamService.PathPrefix, aws.StringValue(g.Path)))
+		if !ok {
+			continue
+		}
+
+		group := &groupExport{GroupName: groupName, Policies: []*policyExport{}}
+
+		// only bucket group names from the spec endpoint can be re-applied
+		if path == "/" {
+			switch groupType {
+			case "BktAdmGrp", "BktRWGrp", "BktROGrp":
+				export.Spec.Groups = append(export.Spec.Groups, groupType)
+			}
+		}
+
+		policies, err := iamService.ListGroupPolicies(r.Context(), &iam.ListAttachedGroupPoliciesInput{GroupName: g.GroupName})
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		for _, p := range policies {
+			if !iamapi.IsBucketPolicy(bucket, aws.StringValue(p.PolicyName)) {
+				continue
+			}
+
+			doc, err := iamService.GetPolicyDocument(r.Context(), aws.StringValue(p.PolicyArn))
+			if err != nil {
+				handleError(w, err)
+				return
+			}
+
+			group.Policies = append(group.Policies, &policyExport{
+				PolicyName: aws.StringValue(p.PolicyName),
+				PolicyArn:  aws.StringValue(p.PolicyArn),
+				Document:   doc,
+			})
+		}
+
+		export.Groups = append(export.Groups, group)
+	}
+
+	if isWebsite {
+		cloudFrontService := cfapi.NewSession(session.Session, s.account, accountId)
+		cloudFrontService.Index = s.distributionIndex(accountId)
+		route53Service := route53api.NewSession(session.Session, s.account)
+
+		zoneID, err := route53Service.ZoneIDForName(r.Context(), bucket)
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		dns, err := route53Service.GetRecordByName(r.Context(), zoneID, bucket, "A")
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		dist, err := cloudFrontService.GetDistributionByName(r.Context(), bucket)
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		export.Website = &websiteExport{
+			HostedZoneID: zoneID,
+			Distribution: dist,
+			DNSRecord:    dns,
+		}
+	}
+
+	if format == "terraform" {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(renderTerraform(&export)))
+		return
+	}
+
+	j, err := json.Marshal(export)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", export, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// lifecycleName returns the name of the supported lifecycle matching the rules, or an empty string
+func lifecycleName(rules []*s3.LifecycleRule) string {
+	return s3api.Lifecycles.Name(rules)
+}
+
+// terraformName converts a resource name into a valid terraform identifier
+func terraformName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// renderTerraform renders an export as terraform configuration.  The cloudfront distribution is emitted as an import block
+// since its configuration is better generated by terraform itself.
+func renderTerraform(e *bucketExport) string {
+	var b strings.Builder
+	name := terraformName(e.Bucket)
+
+	fmt.Fprintf(&b, "resource \"aws_s3_bucket\" %q {\n", name)
+	fmt.Fprintf(&b, "  bucket = %q\n", e.Bucket)
+	if len(e.Spec.Tags) > 0 {
+		b.WriteString("\n  tags = {\n")
+		for _, t := range e.Spec.Tags {
+			fmt.Fprintf(&b, "    %q = %q\n", aws.StringValue(t.Key), aws.StringValue(t.Value))
+		}
+		b.WriteString("  }\n")
+	}
+	b.WriteString("}\n")
+
+	if e.Spec.Encryption != nil {
+		fmt.Fprintf(&b, "\nresource \"aws_s3_bucket_server_side_encryption_configuration\" %q {\n", name)
+		fmt.Fprintf(&b, "  bucket = aws_s3_bucket.%s.id\n\n", name)
+		b.WriteString("  rule {\n    apply_server_side_encryption_by_default {\n")
+		fmt.Fprintf(&b, "      sse_algorithm = %q\n", aws.StringValue(e.Spec.Encryption))
+		b.WriteString("    }\n")
+		if aws.BoolValue(e.Spec.BucketKey) {
+			b.WriteString("    bucket_key_enabled = true\n")
+		}
+		b.WriteString("  }\n}\n")
+	}
+
+	if e.Spec.Versioning != nil {
+		fmt.Fprintf(&b, "\nresource \"aws_s3_bucket_versioning\" %q {\n", name)
+		fmt.Fprintf(&b, "  bucket = aws_s3_bucket.%s.id\n\n", name)
+		b.WriteString("  versioning_configuration {\n")
+		fmt.Fprintf(&b, "    status = %q\n", aws.StringValue(e.Spec.Versioning))
+		b.WriteString("  }\n}\n")
+	}
+
+	if e.Spec.BucketPolicy != nil {
+		fmt.Fprintf(&b, "\nresource \"aws_s3_bucket_policy\" %q {\n", name)
+		fmt.Fprintf(&b, "  bucket = aws_s3_bucket.%s.id\n", name)
+		fmt.Fprintf(&b, "  policy = <<POLICY\n%s\nPOLICY\n}\n", aws.StringValue(e.Spec.BucketPolicy))
+	}
+
+	for _, g := range e.Groups {
+		group := terraformName(g.GroupName)
+		fmt.Fprintf(&b, "\nresource \"aws_iam_group\" %q {\n", group)
+		fmt.Fprintf(&b, "  name = %q\n}\n", g.GroupName)
+
+		for _, p := range g.Policies {
+			policy := terraformName(p.PolicyName)
+			fmt.Fprintf(&b, "\nresource \"aws_iam_policy\" %q {\n", policy)
+			fmt.Fprintf(&b, "  name   = %q\n", p.PolicyName)
+			fmt.Fprintf(&b, "  policy = <<POLICY\n%s\nPOLICY\n}\n", p.Document)
+
+			fmt.Fprintf(&b, "\nresource \"aws_iam_group_policy_attachment\" %q {\n", group+"_"+policy)
+			fmt.Fprintf(&b, "  group      = aws_iam_group.%s.name\n", group)
+			fmt.Fprintf(&b, "  policy_arn = aws_iam_policy.%s.arn\n}\n", policy)
+		}
+	}
+
+	if e.Website != nil {
+		if d := e.Website.Distribution; d != nil {
+			b.WriteString("\n# generate the distribution configuration with `terraform plan -generate-config-out=distribution.tf`\n")
+			b.WriteString("import {\n")
+			fmt.Fprintf(&b, "  to = aws_cloudfront_distribution.%s\n", name)
+			fmt.Fprintf(&b, "  id = %q\n}\n", aws.StringValue(d.Id))
+		}
+
+		if r := e.Website.DNSRecord; r != nil && r.AliasTarget != nil {
+			fmt.Fprintf(&b, "\nresource \"aws_route53_record\" %q {\n", name)
+			fmt.Fprintf(&b, "  zone_id = %q\n", e.Website.HostedZoneID)
+			fmt.Fprintf(&b, "  name    = %q\n", strings.TrimSuffix(aws.StringValue(r.Name), "."))
+			fmt.Fprintf(&b, "  type    = %q\n\n", aws.StringValue(r.Type))
+			b.WriteString("  alias {\n")
+			fmt.Fprintf(&b, "    name                   = %q\n", aws.StringValue(r.AliasTarget.DNSName))
+			fmt.Fprintf(&b, "    zone_id                = %q\n", aws.StringValue(r.AliasTarget.HostedZoneId))
+			fmt.Fprintf(&b, "    evaluate_target_health = %t\n", aws.BoolValue(r.AliasTarget.EvaluateTargetHealth))
+			b.WriteString("  }\n}\n")
+		}
+	}
+
+	return b.String()
+}
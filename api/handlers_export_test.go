@@ -0,0 +1,112 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func TestTerraformName(t *testing.T) {
+	tests := map[string]string{
+		"foobucket":              "foobucket",
+		"foo-bucket.example.com": "foo_bucket_example_com",
+		"foobucket-BktAdmGrp":    "foobucket_BktAdmGrp",
+		"foo.bar-_baz":           "foo_bar__baz",
+	}
+
+	for input, expected := range tests {
+		if out := terraformName(input); out != expected {
+			t.Errorf("expected %s for %s, got %s", expected, input, out)
+		}
+	}
+}
+
+func TestLifecycleName(t *testing.T) {
+	if out := lifecycleName([]*s3.LifecycleRule{}); out != "" {
+		t.Errorf("expected empty lifecycle name, got %s", out)
+	}
+
+	if out := lifecycleName([]*s3.LifecycleRule{{ID: aws.String("deep-archive-rule")}}); out != "deep-archive" {
+		t.Errorf("expected deep-archive, got %s", out)
+	}
+
+	if out := lifecycleName([]*s3.LifecycleRule{{ID: aws.String("custom-rule")}}); out != "" {
+		t.Errorf("expected empty lifecycle name, got %s", out)
+	}
+}
+
+func TestRenderTerraform(t *testing.T) {
+	e := bucketExport{
+		Bucket: "foo.example.com",
+		Spec: bucketSpec{
+			Tags:       []*s3.Tag{{Key: aws.String("Application"), Value: aws.String("HowToGet")}},
+			Encryption: aws.String("AES256"),
+			Versioning: aws.String("Enabled"),
+		},
+		Groups: []*groupExport{
+			{
+				GroupName: "foo.example.com-BktAdmGrp",
+				Policies: []*policyExport{
+					{
+						PolicyName: "foo.example.com-BktAdmPlc",
+						PolicyArn:  "arn:aws:iam::012345678910:policy/foo.example.com-BktAdmPlc",
+						Document:   `{"Version":"2012-10-17","Statement":[]}`,
+					},
+				},
+			},
+		},
+		Website: &websiteExport{
+			HostedZoneID: "ABCDEFGHIJKL123",
+			Distribution: &cloudfront.DistributionSummary{Id: aws.String("ET123456ABCDE")},
+			DNSRecord: &route53.ResourceRecordSet{
+				Name: aws.String("foo.example.com."),
+				Type: aws.String("A"),
+				AliasTarget: &route53.AliasTarget{
+					DNSName:      aws.String("abc123.cloudfront.net"),
+					HostedZoneId: aws.String("Z2FDTNDATAQYW2"),
+				},
+			},
+		},
+	}
+
+	out := renderTerraform(&e)
+
+	expected := []string{
+		`resource "aws_s3_bucket" "foo_example_com" {`,
+		`bucket = "foo.example.com"`,
+		`"Application" = "HowToGet"`,
+		`sse_algorithm = "AES256"`,
+		`status = "Enabled"`,
+		`resource "aws_iam_group" "foo_example_com_BktAdmGrp" {`,
+		`resource "aws_iam_policy" "foo_example_com_BktAdmPlc" {`,
+		`policy_arn = aws_iam_policy.foo_example_com_BktAdmPlc.arn`,
+		`to = aws_cloudfront_distribution.foo_example_com`,
+		`id = "ET123456ABCDE"`,
+		`name    = "foo.example.com"`,
+		`zone_id                = "Z2FDTNDATAQYW2"`,
+	}
+
+	for _, e := range expected {
+		if !strings.Contains(out, e) {
+			t.Errorf("expected terraform output to contain %s, got\n%s", e, out)
+		}
+	}
+
+	if strings.Contains(out, "aws_s3_bucket_policy") {
+		t.Errorf("expected no bucket policy resource without a bucket policy, got\n%s", out)
+	}
+
+	if strings.Contains(out, "bucket_key_enabled") {
+		t.Errorf("expected no bucket key without one, got\n%s", out)
+	}
+
+	e.Spec.Encryption = aws.String("aws:kms")
+	e.Spec.BucketKey = aws.Bool(true)
+	if out := renderTerraform(&e); !strings.Contains(out, "bucket_key_enabled = true") {
+		t.Errorf("expected terraform output to enable the bucket key, got\n%s", out)
+	}
+}
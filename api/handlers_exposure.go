@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// exposureReportConcurrency is the number of buckets checked at the same time for the exposure report
+const exposureReportConcurrency = 10
+
+// exposureActions are the actions needed for the exposure report
+var exposureActions = []string{
+	"s3:ListAllMyBuckets",
+	"s3:GetBucketPolicyStatus",
+	"s3:GetBucketPublicAccessBlock",
+	"cloudfront:ListDistributions",
+}
+
+// exposedBucket is a bucket whose policy makes it public
+type exposedBucket struct {
+	Bucket string
+	// Distribution is the ARN of the cloudfront distribution serving the bucket as a website
+	Distribution string `json:",omitempty"`
+	// PublicAccessBlock is the bucket's public access block configuration, null if it doesn't have one
+	PublicAccessBlock *s3.PublicAccessBlockConfiguration
+}
+
+// exposureReport lists the public buckets in an account, split into the registered websites that are expected to be
+// public and the buckets that aren't websites
+type exposureReport struct {
+	Checked    int
+	Websites   []exposedBucket
+	Unexpected []exposedBucket
+}
+
+// ExposureReportHandler reports on the buckets in an account whose policy makes them public.  Each public bucket is
+// cross-referenced with the cloudfront distributions, a bucket that's the origin of a distribution with the bucket
+// name as an alias is a registered website and is expected to be public, any other public bucket is unexpected.
+func (s *server) ExposureReportHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+
+	session, err := s.sessionForAccount(r.Context(), vars["account"], exposureActions...)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+	cloudFrontService := cfapi.NewSession(session.Session, s.account, accountId)
+	cloudFrontService.Index = s.distributionIndex(accountId)
+
+	report, err := bucketExposureReport(r.Context(), s3Service, cloudFrontService)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	j, err := json.Marshal(report)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", report, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// bucketExposureReport checks the policy status of every bucket in the account and gets the public access block of
+// the public ones
+func bucketExposureReport(ctx context.Context, s3Service s3api.S3, cloudFrontService cfapi.CloudFront) (*exposureReport, error) {
+	buckets, err := s3Service.ListBuckets(ctx, &s3.ListBucketsInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	distributions, err := cloudFrontService.ListDistributions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// websites maps the distribution aliases to the distribution ARNs
+	websites := map[string]string{}
+	for _, d := range distributions {
+		if d.Aliases == nil {
+			continue
+		}
+
+		for _, alias := range d.Aliases.Items {
+			websites[strings.ToLower(aws.StringValue(alias))] = aws.StringValue(d.ARN)
+		}
+	}
+
+	exposed := make([]*exposedBucket, len(buckets))
+
+	g, gctx := newErrGroup(ctx)
+	sem := make(chan struct{}, exposureReportConcurrency)
+	for i, b := range buckets {
+		i, name := i, aws.StringValue(b.Name)
+		g.Go(func() error {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			public, err := s3Service.GetBucketPolicyStatus(gctx, name)
+			if err != nil {
+				return err
+			}
+
+			if !public {
+				return nil
+			}
+
+			block, err := s3Service.GetPublicAccessBlock(gctx, name)
+			if err != nil {
+				return err
+			}
+
+			exposed[i] = &exposedBucket{
+				Bucket:            name,
+				Distribution:      websites[name],
+				PublicAccessBlock: block,
+			}
+
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	report := exposureReport{
+		Checked:    len(buckets),
+		Websites:   []exposedBucket{},
+		Unexpected: []exposedBucket{},
+	}
+
+	for _, e := range exposed {
+		switch {
+		case e == nil:
+		case e.Distribution != "":
+			report.Websites = append(report.Websites, *e)
+		default:
+			report.Unexpected = append(report.Unexpected, *e)
+		}
+	}
+
+	sort.Slice(report.Websites, func(i, j int) bool { return report.Websites[i].Bucket < report.Websites[j].Bucket })
+	sort.Slice(report.Unexpected, func(i, j int) bool { return report.Unexpected[i].Bucket < report.Unexpected[j].Bucket })
+
+	return &report, nil
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/aws/aws-sdk-go/service/cloudfront/cloudfrontiface"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// mockExposureS3 is an s3 client with a set of buckets, the public ones and their public access blocks
+type mockExposureS3 struct {
+	s3iface.S3API
+	buckets []string
+	public  map[string]bool
+	blocks  map[string]*s3.PublicAccessBlockConfiguration
+}
+
+func (m *mockExposureS3) ListBucketsWithContext(ctx context.Context, input *s3.ListBucketsInput, opts ...request.Option) (*s3.ListBucketsOutput, error) {
+	buckets := []*s3.Bucket{}
+	for _, b := range m.buckets {
+		buckets = append(buckets, &s3.Bucket{Name: aws.String(b)})
+	}
+	return &s3.ListBucketsOutput{Buckets: buckets}, nil
+}
+
+func (m *mockExposureS3) GetBucketPolicyStatusWithContext(ctx context.Context, input *s3.GetBucketPolicyStatusInput, opts ...request.Option) (*s3.GetBucketPolicyStatusOutput, error) {
+	public, ok := m.public[aws.StringValue(input.Bucket)]
+	if !ok {
+		return nil, awserr.New("NoSuchBucketPolicy", "not found", nil)
+	}
+	return &s3.GetBucketPolicyStatusOutput{PolicyStatus: &s3.PolicyStatus{IsPublic: aws.Bool(public)}}, nil
+}
+
+func (m *mockExposureS3) GetPublicAccessBlockWithContext(ctx context.Context, input *s3.GetPublicAccessBlockInput, opts ...request.Option) (*s3.GetPublicAccessBlockOutput, error) {
+	block, ok := m.blocks[aws.StringValue(input.Bucket)]
+	if !ok {
+		return nil, awserr.New("NoSuchPublicAccessBlockConfiguration", "not found", nil)
+	}
+	return &s3.GetPublicAccessBlockOutput{PublicAccessBlockConfiguration: block}, nil
+}
+
+// mockExposureCloudFront is a cloudfront client with a single website distribution
+type mockExposureCloudFront struct {
+	cloudfrontiface.CloudFrontAPI
+}
+
+func (m *mockExposureCloudFront) ListDistributionsWithContext(ctx context.Context, input *cloudfront.ListDistributionsInput, opts ...request.Option) (*cloudfront.ListDistributionsOutput, error) {
+	return &cloudfront.ListDistributionsOutput{
+		DistributionList: &cloudfront.DistributionList{
+			Items: []*cloudfront.DistributionSummary{
+				{
+					ARN:     aws.String("arn:aws:cloudfront::012345678910:distribution/ABC"),
+					Aliases: &cloudfront.Aliases{Items: aws.StringSlice([]string{"www.example.com"})},
+				},
+			},
+		},
+	}, nil
+}
+
+func TestBucketExposureReport(t *testing.T) {
+	block := &s3.PublicAccessBlockConfiguration{BlockPublicAcls: aws.Bool(true)}
+
+	m := &mockExposureS3{
+		buckets: []string{"www.example.com", "private", "nopolicy", "oops", "leaky"},
+		public:  map[string]bool{"www.example.com": true, "private": false, "oops": true, "leaky": true},
+		blocks:  map[string]*s3.PublicAccessBlockConfiguration{"oops": block},
+	}
+
+	report, err := bucketExposureReport(context.TODO(), s3api.S3{Service: m}, cfapi.CloudFront{Service: &mockExposureCloudFront{}})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	expected := &exposureReport{
+		Checked: 5,
+		Websites: []exposedBucket{
+			{Bucket: "www.example.com", Distribution: "arn:aws:cloudfront::012345678910:distribution/ABC"},
+		},
+		Unexpected: []exposedBucket{
+			{Bucket: "leaky"},
+			{Bucket: "oops", PublicAccessBlock: block},
+		},
+	}
+
+	if !reflect.DeepEqual(report, expected) {
+		t.Errorf("expected %+v, got %+v", expected, report)
+	}
+}
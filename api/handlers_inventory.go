@@ -0,0 +1,300 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// inventoryConcurrency is the number of accounts whose inventory is assembled at the same time
+	inventoryConcurrency = 5
+	// inventoryCacheKey is the resource cache key for the inventory of an account
+	inventoryCacheKey = "inventory"
+)
+
+// inventoryActions are the actions needed to take the inventory of an account
+var inventoryActions = []string{
+	"s3:ListAllMyBuckets",
+	"s3:GetBucketTagging",
+	"cloudfront:ListDistributions",
+	"cloudfront:ListTagsForResource",
+	"iam:ListGroups",
+	"iam:ListUsers",
+	"iam:ListGroupsForUser",
+	"iam:ListUserTags",
+	"iam:ListAccessKeys",
+}
+
+// inventoryBucket is a bucket that belongs to the org
+type inventoryBucket struct {
+	Name string
+	Tags map[string]string
+}
+
+// inventoryWebsite is a website that belongs to the org, a simple website doesn't have a distribution
+type inventoryWebsite struct {
+	Name           string
+	DistributionId string `json:",omitempty"`
+	Simple         bool   `json:",omitempty"`
+}
+
+// inventoryDistribution is a cloudfront distribution that belongs to the org, its name is its first alias
+type inventoryDistribution struct {
+	Id   string
+	Name string
+	ARN  string
+}
+
+// accountInventory is the inventory of the resources the api manages for the org in an account.  If the inventory
+// couldn't be taken the error is set and the resources are empty, the other accounts are still returned.
+type accountInventory struct {
+	Name          string
+	AccountID     string
+	Buckets       []inventoryBucket
+	Websites      []inventoryWebsite
+	Distributions []inventoryDistribution
+	Groups        []string
+	Users         []accountUser
+	GeneratedAt   time.Time
+	Error         string `json:",omitempty"`
+}
+
+// inventoryOutput is the inventory of the org's resources across all of the accounts
+type inventoryOutput struct {
+	Org      string
+	Accounts []*accountInventory
+}
+
+// InventoryHandler returns every bucket, website, distribution and IAM group and user managed by the api for the org
+// across all of the configured accounts.  The accounts are inventoried concurrently and each account's inventory is
+// cached for the resource cache ttl, the `refresh` query parameter skips the cache.  The `org` query parameter
+// defaults to the api's org, the api only manages the resources of its own org.
+func (s *server) InventoryHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	q := r.URL.Query()
+
+	org := q.Get("org")
+	if org == "" {
+		org = s.org
+	}
+
+	if org != s.org {
+		msg := fmt.Sprintf("org %s is not managed by this api", org)
+		handleError(w, apierror.New(apierror.ErrNotFound, msg, nil))
+		return
+	}
+
+	refresh := false
+	if v := q.Get("refresh"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			handleError(w, apierror.New(apierror.ErrBadRequest, "invalid refresh parameter", err))
+			return
+		}
+		refresh = b
+	}
+
+	output := inventoryOutput{
+		Org:      org,
+		Accounts: s.inventory(r.Context(), refresh),
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// inventory takes the inventory of each configured account concurrently, sorted by account name.  An account that's
+// configured under more than one name is only inventoried once, under its first name.
+func (s *server) inventory(ctx context.Context, refresh bool) []*accountInventory {
+	names := make([]string, 0, len(s.accountsMap))
+	for name := range s.accountsMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	seen := map[string]bool{}
+	accounts := []*accountInventory{}
+	for _, name := range names {
+		id := s.accountsMap[name]
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		accounts = append(accounts, &accountInventory{Name: name, AccountID: id})
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, inventoryConcurrency)
+	for i := range accounts {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			accounts[i] = s.accountInventory(ctx, accounts[i].Name, accounts[i].AccountID, refresh)
+		}()
+	}
+	wg.Wait()
+
+	return accounts
+}
+
+// accountInventory returns the inventory of an account from the resource cache, or takes it if it isn't cached or a
+// refresh is requested.  Failed inventories aren't cached.
+func (s *server) accountInventory(ctx context.Context, name, accountId string, refresh bool) *accountInventory {
+	c := s.resourceCache(accountId)
+	if c != nil && !refresh {
+		if item, found := c.Get(inventoryCacheKey); found {
+			return item.(*accountInventory)
+		}
+	}
+
+	inventory, err := s.takeAccountInventory(ctx, name, accountId)
+	if err != nil {
+		log.Errorf("failed to take the inventory of account %s: %s", name, err)
+		return &accountInventory{
+			Name:          name,
+			AccountID:     accountId,
+			Buckets:       []inventoryBucket{},
+			Websites:      []inventoryWebsite{},
+			Distributions: []inventoryDistribution{},
+			Groups:        []string{},
+			Users:         []accountUser{},
+			GeneratedAt:   time.Now().UTC(),
+			Error:         err.Error(),
+		}
+	}
+
+	if c != nil {
+		c.SetDefault(inventoryCacheKey, inventory)
+	}
+
+	return inventory
+}
+
+// takeAccountInventory lists the org's tagged buckets and distributions and the managed IAM groups and users in an
+// account concurrently
+func (s *server) takeAccountInventory(ctx context.Context, name, accountId string) (*accountInventory, error) {
+	log.Infof("taking the inventory of account %s (%s)", name, accountId)
+
+	session, err := s.sessionForAccount(ctx, accountId, inventoryActions...)
+	if err != nil {
+		return nil, err
+	}
+
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+	cloudFrontService := cfapi.NewSession(session.Session, s.account, accountId)
+	iamService := iamapi.NewSession(session.Session, s.account)
+	iamService.Cache = s.resourceCache(accountId)
+
+	var resources []taggedResource
+	var groups []*iam.Group
+	var users []accountUser
+
+	g, gctx := newErrGroup(ctx)
+	g.Go(func() error {
+		var err error
+		resources, err = listTaggedResources(gctx, s3Service, cloudFrontService)
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		groups, err = iamService.ListGroups(gctx, &iam.ListGroupsInput{}, "")
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		users, err = listAccountUsers(gctx, iamService, "", time.Now())
+		return err
+	})
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return newAccountInventory(name, accountId, resources, groups, users, time.Now().UTC()), nil
+}
+
+// newAccountInventory assembles the inventory of an account from the org's tagged resources and the managed IAM
+// groups and users.  A bucket is a website if it's tagged as a simple website or a distribution has its name as an
+// alias.  The groups are limited to the bucket and website management groups.
+func newAccountInventory(name, accountId string, resources []taggedResource, groups []*iam.Group, users []accountUser, now time.Time) *accountInventory {
+	inventory := &accountInventory{
+		Name:          name,
+		AccountID:     accountId,
+		Buckets:       []inventoryBucket{},
+		Websites:      []inventoryWebsite{},
+		Distributions: []inventoryDistribution{},
+		Groups:        []string{},
+		Users:         users,
+		GeneratedAt:   now,
+	}
+
+	if inventory.Users == nil {
+		inventory.Users = []accountUser{}
+	}
+
+	distributions := map[string]string{}
+	for _, res := range resources {
+		if res.Type == "distribution" {
+			distributions[res.Name] = res.ID
+			inventory.Distributions = append(inventory.Distributions, inventoryDistribution{Id: res.ID, Name: res.Name, ARN: res.ARN})
+		}
+	}
+
+	for _, res := range resources {
+		if res.Type != "bucket" {
+			continue
+		}
+
+		inventory.Buckets = append(inventory.Buckets, inventoryBucket{Name: res.Name, Tags: res.Tags})
+
+		if id, ok := distributions[res.Name]; ok {
+			inventory.Websites = append(inventory.Websites, inventoryWebsite{Name: res.Name, DistributionId: id})
+		} else if res.Tags[simpleWebsiteTagKey] == "true" {
+			inventory.Websites = append(inventory.Websites, inventoryWebsite{Name: res.Name, Simple: true})
+		}
+	}
+
+	for _, g := range groups {
+		if groupName := aws.StringValue(g.GroupName); isManagementGroup(groupName) {
+			inventory.Groups = append(inventory.Groups, groupName)
+		}
+	}
+
+	sort.Slice(inventory.Buckets, func(i, j int) bool { return inventory.Buckets[i].Name < inventory.Buckets[j].Name })
+	sort.Slice(inventory.Websites, func(i, j int) bool { return inventory.Websites[i].Name < inventory.Websites[j].Name })
+	sort.Slice(inventory.Distributions, func(i, j int) bool {
+		return inventory.Distributions[i].Name < inventory.Distributions[j].Name
+	})
+	sort.Strings(inventory.Groups)
+
+	return inventory
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+func TestNewAccountInventory(t *testing.T) {
+	now := time.Now().UTC()
+
+	resources := []taggedResource{
+		{Type: "bucket", ID: "www.example.com", Name: "www.example.com", Tags: map[string]string{"spinup:org": "test"}},
+		{Type: "bucket", ID: "dataset", Name: "dataset", Tags: map[string]string{"spinup:org": "test"}},
+		{Type: "bucket", ID: "simple-site", Name: "simple-site", Tags: map[string]string{"spinup:org": "test", simpleWebsiteTagKey: "true"}},
+		{Type: "distribution", ID: "EDFDVBD632BHDS5", Name: "www.example.com", ARN: "arn:aws:cloudfront::012345678910:distribution/EDFDVBD632BHDS5"},
+	}
+
+	groups := []*iam.Group{
+		{GroupName: aws.String("dataset-BktAdmGrp")},
+		{GroupName: aws.String("www.example.com-WebAdmGrp")},
+		{GroupName: aws.String("SomeOtherGroup")},
+	}
+
+	users := []accountUser{{UserName: "dataset-BktAdmGrp-user", Groups: []string{"dataset-BktAdmGrp"}}}
+
+	expected := &accountInventory{
+		Name:      "spinup",
+		AccountID: "012345678910",
+		Buckets: []inventoryBucket{
+			{Name: "dataset", Tags: map[string]string{"spinup:org": "test"}},
+			{Name: "simple-site", Tags: map[string]string{"spinup:org": "test", simpleWebsiteTagKey: "true"}},
+			{Name: "www.example.com", Tags: map[string]string{"spinup:org": "test"}},
+		},
+		Websites: []inventoryWebsite{
+			{Name: "simple-site", Simple: true},
+			{Name: "www.example.com", DistributionId: "EDFDVBD632BHDS5"},
+		},
+		Distributions: []inventoryDistribution{
+			{Id: "EDFDVBD632BHDS5", Name: "www.example.com", ARN: "arn:aws:cloudfront::012345678910:distribution/EDFDVBD632BHDS5"},
+		},
+		Groups:      []string{"dataset-BktAdmGrp", "www.example.com-WebAdmGrp"},
+		Users:       users,
+		GeneratedAt: now,
+	}
+
+	out := newAccountInventory("spinup", "012345678910", resources, groups, users, now)
+	if !reflect.DeepEqual(out, expected) {
+		t.Errorf("expected %+v, got %+v", expected, out)
+	}
+
+	// an empty account has empty lists rather than nulls
+	out = newAccountInventory("spinup", "012345678910", nil, nil, nil, now)
+	if out.Buckets == nil || out.Websites == nil || out.Distributions == nil || out.Groups == nil || out.Users == nil {
+		t.Errorf("expected empty lists, got %+v", out)
+	}
+}
+
+func TestInventoryHandler(t *testing.T) {
+	s := server{
+		org: "test",
+		accountsMap: map[string]string{
+			"spinup":    "012345678910",
+			"spinupsbx": "109876543210",
+			"sandbox":   "109876543210",
+		},
+		resourceCacheTTL: time.Minute,
+	}
+
+	// the cached inventories are returned without taking them again
+	generated := time.Now().UTC().Truncate(time.Second)
+	for name, id := range map[string]string{"spinup": "012345678910", "sandbox": "109876543210"} {
+		s.resourceCache(id).SetDefault(inventoryCacheKey, &accountInventory{
+			Name:          name,
+			AccountID:     id,
+			Buckets:       []inventoryBucket{{Name: name + "-bucket", Tags: map[string]string{"spinup:org": "test"}}},
+			Websites:      []inventoryWebsite{},
+			Distributions: []inventoryDistribution{},
+			Groups:        []string{name + "-bucket-BktAdmGrp"},
+			Users:         []accountUser{},
+			GeneratedAt:   generated,
+		})
+	}
+
+	tests := []struct {
+		url    string
+		status int
+	}{
+		{"/v1/s3/inventory", http.StatusOK},
+		{"/v1/s3/inventory?org=test", http.StatusOK},
+		{"/v1/s3/inventory?org=other", http.StatusNotFound},
+		{"/v1/s3/inventory?refresh=sometimes", http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		req, err := http.NewRequest("GET", test.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(s.InventoryHandler).ServeHTTP(rr, req)
+
+		if rr.Code != test.status {
+			t.Errorf("%s: expected status %d, got %d", test.url, test.status, rr.Code)
+			continue
+		}
+
+		if test.status != http.StatusOK {
+			continue
+		}
+
+		var out inventoryOutput
+		if err := json.Unmarshal(rr.Body.Bytes(), &out); err != nil {
+			t.Fatalf("failed to unmarshal response: %s", err)
+		}
+
+		if out.Org != "test" || len(out.Accounts) != 2 {
+			t.Fatalf("%s: expected 2 accounts for org test, got %+v", test.url, out)
+		}
+
+		// the account configured under two names is only listed once, under the first name
+		if out.Accounts[0].Name != "sandbox" || out.Accounts[1].Name != "spinup" {
+			t.Errorf("%s: expected accounts sandbox and spinup, got %s and %s", test.url, out.Accounts[0].Name, out.Accounts[1].Name)
+		}
+
+		for _, a := range out.Accounts {
+			if len(a.Buckets) != 1 || a.Buckets[0].Name != a.Name+"-bucket" || !a.GeneratedAt.Equal(generated) {
+				t.Errorf("%s: expected the cached inventory for %s, got %+v", test.url, a.Name, a)
+			}
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// lastAccessedConcurrency is the number of users and groups whose last accessed jobs are started or read at the
+	// same time
+	lastAccessedConcurrency = 5
+	// defaultLastAccessedDays is the default number of days without using s3 that flags a user as inactive and
+	// maxLastAccessedDays is the longest, iam only tracks the last 400 days
+	defaultLastAccessedDays = 90
+	maxLastAccessedDays     = 400
+	// lastAccessedNamespace is the namespace of the service whose last use flags a user as inactive
+	lastAccessedNamespace = "s3"
+)
+
+var (
+	// lastAccessedWait is how long the report waits for the last accessed jobs to complete before asking the client
+	// to retry, and lastAccessedPollInterval is how often the jobs are checked while waiting
+	lastAccessedWait         = 20 * time.Second
+	lastAccessedPollInterval = 2 * time.Second
+)
+
+// lastAccessedService is when a user or group last used a service
+type lastAccessedService struct {
+	Namespace string
+	Name      string
+	// LastAccessed is empty if the service hasn't been used in the tracking period
+	LastAccessed *time.Time `json:",omitempty"`
+	// LastAuthenticatedEntity is the user that last used the service for a group
+	LastAuthenticatedEntity string `json:",omitempty"`
+	Region                  string `json:",omitempty"`
+}
+
+// lastAccessedPrincipal is a bucket user or management group in the last accessed report
+type lastAccessedPrincipal struct {
+	Name string
+	Arn  string
+	// Groups are the management groups of a user
+	Groups []string `json:",omitempty"`
+	// S3LastAccessed is when s3 was last used, Inactive is set if it's before the start of the window
+	S3LastAccessed *time.Time `json:",omitempty"`
+	Inactive       bool
+	Services       []lastAccessedService
+}
+
+// lastAccessedReport is when each bucket user and management group in an account last used the services its
+// policies allow, the users that haven't used s3 within the window are flagged as inactive
+type lastAccessedReport struct {
+	GeneratedTime time.Time
+	Days          int
+	Users         []lastAccessedPrincipal
+	Groups        []lastAccessedPrincipal
+	InactiveUsers []string
+}
+
+// LastAccessedReportHandler reports when each of the bucket users and management groups in an account last used
+// s3 and the other services their policies allow, from the iam service last accessed details.  The users that haven't
+// used s3 in the last `days` days (default 90) are flagged as inactive so they can be decommissioned.  The report can
+// be limited to a single bucket with the `bucket` query parameter.  If the jobs are still running after a short wait,
+// 202 Accepted is returned and the request should be retried.
+func (s *server) LastAccessedReportHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	bucket := r.URL.Query().Get("bucket")
+
+	days := defaultLastAccessedDays
+	if d := r.URL.Query().Get("days"); d != "" {
+		var err error
+		if days, err = strconv.Atoi(d); err != nil || days < 1 || days > maxLastAccessedDays {
+			msg := fmt.Sprintf("days must be between 1 and %d, got %q", maxLastAccessedDays, d)
+			handleError(w, apierror.New(apierror.ErrBadRequest, msg, nil))
+			return
+		}
+	}
+
+	iamService, err := s.iamServiceForAccount(r.Context(), vars["account"])
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	users, groups, err := lastAccessedPrincipals(r.Context(), iamService, bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	arns := make([]string, 0, len(users)+len(groups))
+	for _, u := range users {
+		arns = append(arns, u.Arn)
+	}
+	for _, g := range groups {
+		arns = append(arns, g.Arn)
+	}
+
+	details, err := lastAccessedDetails(r.Context(), iamService, arns)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if details == nil {
+		log.Infof("service last accessed details for account %s are being generated", vars["account"])
+		w.Header().Set("Retry-After", "10")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("last accessed report is being generated, try again shortly"))
+		return
+	}
+
+	report := newLastAccessedReport(users, groups, details, days, time.Now().UTC())
+
+	j, err := json.Marshal(report)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", report, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// lastAccessedPrincipals returns the bucket and website management groups in an account, or of a bucket, and the
+// users in them, sorted by name
+func lastAccessedPrincipals(ctx context.Context, iamService iamapi.IAM, bucket string) ([]lastAccessedPrincipal, []lastAccessedPrincipal, error) {
+	groupList, err := iamService.ListGroups(ctx, &iam.ListGroupsInput{}, bucket)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	groups := []lastAccessedPrincipal{}
+	userIndex := map[string]int{}
+	users := []lastAccessedPrincipal{}
+	for _, g := range groupList {
+		groupName := aws.StringValue(g.GroupName)
+		if !isManagementGroup(groupName) {
+			continue
+		}
+
+		groups = append(groups, lastAccessedPrincipal{Name: groupName, Arn: aws.StringValue(g.Arn)})
+
+		members, err := iamService.ListGroupUsers(ctx, &iam.GetGroupInput{GroupName: g.GroupName})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, u := range members {
+			userName := aws.StringValue(u.UserName)
+			i, ok := userIndex[userName]
+			if !ok {
+				i = len(users)
+				userIndex[userName] = i
+				users = append(users, lastAccessedPrincipal{Name: userName, Arn: aws.StringValue(u.Arn)})
+			}
+			users[i].Groups = append(users[i].Groups, groupName)
+		}
+	}
+
+	sort.Slice(users, func(i, j int) bool { return users[i].Name < users[j].Name })
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+
+	return users, groups, nil
+}
+
+// lastAccessedDetails starts a service last accessed job for each arn and waits for them to complete, it returns nil
+// if they're still running after the wait.  The jobs are cached by the iam service, so a retry picks up the same jobs.
+func lastAccessedDetails(ctx context.Context, iamService iamapi.IAM, arns []string) (map[string]*iamapi.ServiceLastAccessed, error) {
+	var mu sync.Mutex
+	jobs := map[string]string{}
+	details := map[string]*iamapi.ServiceLastAccessed{}
+
+	// each pass starts or reads the jobs that haven't completed
+	run := func(f func(ctx context.Context, arn string) error) error {
+		pending := []string{}
+		for _, arn := range arns {
+			if _, ok := details[arn]; !ok {
+				pending = append(pending, arn)
+			}
+		}
+
+		g, gctx := newErrGroup(ctx)
+		sem := make(chan struct{}, lastAccessedConcurrency)
+		for _, arn := range pending {
+			arn := arn
+			g.Go(func() error {
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				return f(gctx, arn)
+			})
+		}
+		return g.Wait()
+	}
+
+	err := run(func(ctx context.Context, arn string) error {
+		jobId, err := iamService.GenerateServiceLastAccessedDetails(ctx, arn)
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		jobs[arn] = jobId
+		mu.Unlock()
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(lastAccessedWait)
+	for {
+		err := run(func(ctx context.Context, arn string) error {
+			mu.Lock()
+			jobId := jobs[arn]
+			mu.Unlock()
+
+			d, err := iamService.GetServiceLastAccessedDetails(ctx, arn, jobId)
+			if err != nil {
+				return err
+			}
+
+			if d.Status == iam.JobStatusTypeCompleted {
+				mu.Lock()
+				details[arn] = d
+				mu.Unlock()
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(details) == len(arns) {
+			return details, nil
+		}
+
+		if time.Now().After(deadline) {
+			return nil, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lastAccessedPollInterval):
+		}
+	}
+}
+
+// newLastAccessedReport builds the report from the completed last accessed jobs, the users and groups that haven't
+// used s3 since the start of the window are inactive
+func newLastAccessedReport(users, groups []lastAccessedPrincipal, details map[string]*iamapi.ServiceLastAccessed, days int, now time.Time) *lastAccessedReport {
+	since := now.Add(-time.Duration(days) * 24 * time.Hour)
+
+	report := &lastAccessedReport{
+		GeneratedTime: now,
+		Days:          days,
+		Users:         []lastAccessedPrincipal{},
+		Groups:        []lastAccessedPrincipal{},
+		InactiveUsers: []string{},
+	}
+
+	principal := func(p lastAccessedPrincipal) lastAccessedPrincipal {
+		p.Services = []lastAccessedService{}
+		if d, ok := details[p.Arn]; ok {
+			for _, svc := range d.Services {
+				p.Services = append(p.Services, lastAccessedService{
+					Namespace:               aws.StringValue(svc.ServiceNamespace),
+					Name:                    aws.StringValue(svc.ServiceName),
+					LastAccessed:            svc.LastAuthenticated,
+					LastAuthenticatedEntity: aws.StringValue(svc.LastAuthenticatedEntity),
+					Region:                  aws.StringValue(svc.LastAuthenticatedRegion),
+				})
+
+				if aws.StringValue(svc.ServiceNamespace) == lastAccessedNamespace {
+					p.S3LastAccessed = svc.LastAuthenticated
+				}
+			}
+		}
+
+		p.Inactive = p.S3LastAccessed == nil || p.S3LastAccessed.Before(since)
+		return p
+	}
+
+	for _, u := range users {
+		p := principal(u)
+		report.Users = append(report.Users, p)
+		if p.Inactive {
+			report.InactiveUsers = append(report.InactiveUsers, p.Name)
+		}
+	}
+
+	for _, g := range groups {
+		report.Groups = append(report.Groups, principal(g))
+	}
+
+	return report
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+)
+
+// mockLastAccessedIAM has the management groups of two buckets and completes the last accessed jobs after a number
+// of reads
+type mockLastAccessedIAM struct {
+	iamiface.IAMAPI
+	mu    sync.Mutex
+	reads map[string]int
+	after int
+}
+
+func (m *mockLastAccessedIAM) ListGroupsWithContext(ctx context.Context, input *iam.ListGroupsInput, opts ...request.Option) (*iam.ListGroupsOutput, error) {
+	groups := []*iam.Group{}
+	for _, name := range []string{"dataset-BktAdmGrp", "dataset-BktROGrp", "www.example.com-WebAdmGrp", "SomeOtherGroup"} {
+		groups = append(groups, &iam.Group{GroupName: aws.String(name), Path: aws.String("/"), Arn: aws.String("arn:aws:iam::012345678910:group/" + name)})
+	}
+	return &iam.ListGroupsOutput{Groups: groups}, nil
+}
+
+func (m *mockLastAccessedIAM) GetGroupWithContext(ctx context.Context, input *iam.GetGroupInput, opts ...request.Option) (*iam.GetGroupOutput, error) {
+	members := map[string][]string{
+		"dataset-BktAdmGrp":         {"alice"},
+		"dataset-BktROGrp":          {"bob", "alice"},
+		"www.example.com-WebAdmGrp": {"carol"},
+	}
+
+	users := []*iam.User{}
+	for _, name := range members[aws.StringValue(input.GroupName)] {
+		users = append(users, &iam.User{UserName: aws.String(name), Arn: aws.String("arn:aws:iam::012345678910:user/" + name)})
+	}
+	return &iam.GetGroupOutput{Group: &iam.Group{GroupName: input.GroupName}, Users: users}, nil
+}
+
+func (m *mockLastAccessedIAM) GenerateServiceLastAccessedDetailsWithContext(ctx context.Context, input *iam.GenerateServiceLastAccessedDetailsInput, opts ...request.Option) (*iam.GenerateServiceLastAccessedDetailsOutput, error) {
+	return &iam.GenerateServiceLastAccessedDetailsOutput{JobId: input.Arn}, nil
+}
+
+func (m *mockLastAccessedIAM) GetServiceLastAccessedDetailsWithContext(ctx context.Context, input *iam.GetServiceLastAccessedDetailsInput, opts ...request.Option) (*iam.GetServiceLastAccessedDetailsOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	jobId := aws.StringValue(input.JobId)
+	m.reads[jobId]++
+	if m.reads[jobId] <= m.after {
+		return &iam.GetServiceLastAccessedDetailsOutput{JobStatus: aws.String(iam.JobStatusTypeInProgress)}, nil
+	}
+
+	return &iam.GetServiceLastAccessedDetailsOutput{
+		JobStatus:            aws.String(iam.JobStatusTypeCompleted),
+		ServicesLastAccessed: []*iam.ServiceLastAccessed{{ServiceNamespace: aws.String("s3"), ServiceName: aws.String("Amazon S3")}},
+	}, nil
+}
+
+func TestLastAccessedPrincipals(t *testing.T) {
+	iamService := iamapi.IAM{Service: &mockLastAccessedIAM{}}
+
+	users, groups, err := lastAccessedPrincipals(context.TODO(), iamService, "")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	expectedUsers := []lastAccessedPrincipal{
+		{Name: "alice", Arn: "arn:aws:iam::012345678910:user/alice", Groups: []string{"dataset-BktAdmGrp", "dataset-BktROGrp"}},
+		{Name: "bob", Arn: "arn:aws:iam::012345678910:user/bob", Groups: []string{"dataset-BktROGrp"}},
+		{Name: "carol", Arn: "arn:aws:iam::012345678910:user/carol", Groups: []string{"www.example.com-WebAdmGrp"}},
+	}
+	if !reflect.DeepEqual(expectedUsers, users) {
+		t.Errorf("expected users %+v, got %+v", expectedUsers, users)
+	}
+
+	if len(groups) != 3 || groups[0].Name != "dataset-BktAdmGrp" || groups[2].Name != "www.example.com-WebAdmGrp" {
+		t.Errorf("expected the three management groups, got %+v", groups)
+	}
+
+	// limited to a bucket
+	users, groups, err = lastAccessedPrincipals(context.TODO(), iamService, "dataset")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if len(users) != 2 || len(groups) != 2 {
+		t.Errorf("expected the dataset users and groups, got %+v and %+v", users, groups)
+	}
+}
+
+func TestLastAccessedDetails(t *testing.T) {
+	defer func(wait, interval time.Duration) {
+		lastAccessedWait, lastAccessedPollInterval = wait, interval
+	}(lastAccessedWait, lastAccessedPollInterval)
+	lastAccessedWait, lastAccessedPollInterval = 50*time.Millisecond, time.Millisecond
+
+	arns := []string{"arn:aws:iam::012345678910:user/alice", "arn:aws:iam::012345678910:group/dataset-BktAdmGrp"}
+
+	// the jobs complete while waiting
+	m := &mockLastAccessedIAM{reads: map[string]int{}, after: 2}
+	details, err := lastAccessedDetails(context.TODO(), iamapi.IAM{Service: m}, arns)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if len(details) != 2 || len(details[arns[0]].Services) != 1 {
+		t.Errorf("expected the details for both arns, got %+v", details)
+	}
+
+	// completed jobs aren't read again
+	for arn, reads := range m.reads {
+		if reads != 3 {
+			t.Errorf("expected 3 reads of %s, got %d", arn, reads)
+		}
+	}
+
+	// the jobs are still running after the wait
+	m = &mockLastAccessedIAM{reads: map[string]int{}, after: 1000000}
+	details, err = lastAccessedDetails(context.TODO(), iamapi.IAM{Service: m}, arns)
+	if err != nil || details != nil {
+		t.Errorf("expected nil details and error, got %+v, %v", details, err)
+	}
+}
+
+func TestNewLastAccessedReport(t *testing.T) {
+	now := time.Now().UTC()
+	recent := now.Add(-24 * time.Hour)
+	old := now.Add(-100 * 24 * time.Hour)
+
+	users := []lastAccessedPrincipal{
+		{Name: "alice", Arn: "arn:alice", Groups: []string{"dataset-BktAdmGrp"}},
+		{Name: "bob", Arn: "arn:bob", Groups: []string{"dataset-BktROGrp"}},
+		{Name: "carol", Arn: "arn:carol", Groups: []string{"dataset-BktROGrp"}},
+	}
+	groups := []lastAccessedPrincipal{{Name: "dataset-BktROGrp", Arn: "arn:group"}}
+
+	details := map[string]*iamapi.ServiceLastAccessed{
+		"arn:alice": {Services: []*iam.ServiceLastAccessed{
+			{ServiceNamespace: aws.String("s3"), ServiceName: aws.String("Amazon S3"), LastAuthenticated: aws.Time(recent), LastAuthenticatedRegion: aws.String("us-east-1")},
+		}},
+		"arn:bob": {Services: []*iam.ServiceLastAccessed{
+			{ServiceNamespace: aws.String("s3"), ServiceName: aws.String("Amazon S3"), LastAuthenticated: aws.Time(old)},
+			{ServiceNamespace: aws.String("cloudfront"), ServiceName: aws.String("Amazon CloudFront"), LastAuthenticated: aws.Time(recent)},
+		}},
+		"arn:carol": {Services: []*iam.ServiceLastAccessed{
+			{ServiceNamespace: aws.String("s3"), ServiceName: aws.String("Amazon S3")},
+		}},
+		"arn:group": {Services: []*iam.ServiceLastAccessed{
+			{ServiceNamespace: aws.String("s3"), ServiceName: aws.String("Amazon S3"), LastAuthenticated: aws.Time(old), LastAuthenticatedEntity: aws.String("arn:bob")},
+		}},
+	}
+
+	report := newLastAccessedReport(users, groups, details, 90, now)
+
+	if !reflect.DeepEqual([]string{"bob", "carol"}, report.InactiveUsers) {
+		t.Errorf("expected bob and carol to be inactive, got %v", report.InactiveUsers)
+	}
+
+	alice := report.Users[0]
+	expected := lastAccessedPrincipal{
+		Name:           "alice",
+		Arn:            "arn:alice",
+		Groups:         []string{"dataset-BktAdmGrp"},
+		S3LastAccessed: &recent,
+		Services:       []lastAccessedService{{Namespace: "s3", Name: "Amazon S3", LastAccessed: &recent, Region: "us-east-1"}},
+	}
+	if !reflect.DeepEqual(expected, alice) {
+		t.Errorf("expected %+v, got %+v", expected, alice)
+	}
+
+	// using another service doesn't make a user active
+	if !report.Users[1].Inactive || len(report.Users[1].Services) != 2 {
+		t.Errorf("expected bob to be inactive with both services, got %+v", report.Users[1])
+	}
+
+	if g := report.Groups[0]; !g.Inactive || g.Services[0].LastAuthenticatedEntity != "arn:bob" {
+		t.Errorf("expected an inactive group last used by bob, got %+v", g)
+	}
+
+	// a longer window includes bob
+	report = newLastAccessedReport(users, groups, details, 120, now)
+	if !reflect.DeepEqual([]string{"carol"}, report.InactiveUsers) {
+		t.Errorf("expected only carol to be inactive, got %v", report.InactiveUsers)
+	}
+}
+
+func TestLastAccessedReportHandlerDays(t *testing.T) {
+	s := server{}
+
+	for _, days := range []string{"0", "401", "ninety"} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/s3/spinup/reports/lastaccessed?days="+days, nil)
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(s.LastAccessedReportHandler).ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", days, http.StatusBadRequest, rr.Code)
+		}
+	}
+}
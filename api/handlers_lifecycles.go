@@ -0,0 +1,28 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	log "github.com/sirupsen/logrus"
+)
+
+// LifecycleListHandler lists the lifecycles that can be applied to buckets when they're created, the built in ones and
+// the configured templates, with the rules each of them installs
+func (s *server) LifecycleListHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+
+	output := s3api.Lifecycles.List()
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response (%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	s3api "github.com/YaleSpinup/s3-api/s3"
+)
+
+func TestLifecycleListHandler(t *testing.T) {
+	s := server{}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/s3/lifecycles", nil)
+	w := httptest.NewRecorder()
+
+	s.LifecycleListHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	out := []*s3api.Lifecycle{}
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatalf("expected json response, got %s", err)
+	}
+
+	found := false
+	for _, lc := range out {
+		if lc.Name == "deep-archive" && len(lc.Rules) == 1 && lc.Description != "" {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Errorf("expected the deep-archive lifecycle to be listed, got %s", w.Body.String())
+	}
+}
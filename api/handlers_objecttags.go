@@ -0,0 +1,124 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/YaleSpinup/apierror"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// objectTagsActions are the actions needed to get and set the default object tags of a bucket
+var objectTagsActions = []string{
+	"s3:GetBucketTagging",
+	"s3:PutBucketTagging",
+	"s3:DeleteBucketTagging",
+}
+
+// objectTagsRequest is the request to replace the default object tags of a bucket
+type objectTagsRequest struct {
+	Tags []*s3.Tag
+}
+
+// objectTagsOutput is the default object tags of a bucket
+type objectTagsOutput struct {
+	Bucket string
+	Tags   []*s3.Tag
+}
+
+// BucketObjectTagsShowHandler returns the default tags for the objects created in a bucket or website
+func (s *server) BucketObjectTagsShowHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	bucket := vars["bucket"]
+
+	session, err := s.sessionForAccount(r.Context(), vars["account"], objectTagsActions...)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+
+	tags, err := s3Service.GetBucketTags(r.Context(), bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeObjectTags(w, bucket, defaultObjectTags(tags))
+}
+
+// BucketObjectTagsUpdateHandler replaces the default tags for the objects created in a bucket or website.  The tags
+// are stored on the bucket with the spinup:object: prefix, an empty list of tags removes them.
+func (s *server) BucketObjectTagsUpdateHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	bucket := vars["bucket"]
+
+	var req objectTagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		msg := fmt.Sprintf("cannot decode body into object tags input: %s", err)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+		return
+	}
+
+	f := fieldErrors{}
+	f.objectTags("Tags", req.Tags)
+	if err := f.err(); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	session, err := s.sessionForAccount(r.Context(), vars["account"], objectTagsActions...)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+
+	current, err := s3Service.GetBucketTags(r.Context(), bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	// TagBucket doesn't do anything with an empty list of tags, the tag set has to be deleted instead
+	tags := withDefaultObjectTags(current, req.Tags)
+	if len(tags) == 0 {
+		if err := s3Service.DeleteBucketTags(r.Context(), bucket); err != nil {
+			handleError(w, err)
+			return
+		}
+	} else if err := s3Service.TagBucket(r.Context(), bucket, tags); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	log.Infof("updated the default object tags of bucket %s in account %s", bucket, accountId)
+
+	writeObjectTags(w, bucket, defaultObjectTags(tags))
+}
+
+// writeObjectTags writes the default object tags of a bucket to the response
+func writeObjectTags(w http.ResponseWriter, bucket string, tags []*s3.Tag) {
+	output := objectTagsOutput{Bucket: bucket, Tags: tags}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
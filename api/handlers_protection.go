@@ -0,0 +1,177 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/YaleSpinup/apierror"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// protectedTagKey is the bucket tag that marks a bucket or website as protected from deletion
+	protectedTagKey = "spinup:protected"
+	// protectionOverrideHeader carries the bcrypt hashed override token needed to delete or unprotect a protected bucket
+	protectionOverrideHeader = "X-Protection-Override"
+)
+
+// protectionActions are the actions needed to protect and unprotect a bucket
+var protectionActions = []string{
+	"s3:GetBucketTagging",
+	"s3:PutBucketTagging",
+}
+
+// protectionOutput is the protection status of a bucket or website
+type protectionOutput struct {
+	Bucket    string
+	Protected bool
+}
+
+// BucketProtectHandler protects a bucket or website from deletion by tagging it with spinup:protected
+func (s *server) BucketProtectHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	bucket := vars["bucket"]
+
+	session, err := s.sessionForAccount(r.Context(), vars["account"], protectionActions...)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+
+	tags, err := s3Service.GetBucketTags(r.Context(), bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if !isProtected(tags) {
+		tags = append(withoutTag(tags, protectedTagKey), &s3.Tag{
+			Key:   aws.String(protectedTagKey),
+			Value: aws.String("true"),
+		})
+
+		if err := s3Service.TagBucket(r.Context(), bucket, tags); err != nil {
+			handleError(w, err)
+			return
+		}
+
+		log.Infof("protected bucket %s in account %s from deletion", bucket, accountId)
+	}
+
+	writeProtection(w, bucket, true)
+}
+
+// BucketUnprotectHandler removes the deletion protection from a bucket or website, it requires the protection override
+func (s *server) BucketUnprotectHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	bucket := vars["bucket"]
+
+	if !s.protectionOverride(r) {
+		msg := fmt.Sprintf("removing the protection from bucket %s requires a valid %s header", bucket, protectionOverrideHeader)
+		handleError(w, apierror.New(apierror.ErrForbidden, msg, nil))
+		return
+	}
+
+	session, err := s.sessionForAccount(r.Context(), vars["account"], append(protectionActions, "s3:DeleteBucketTagging")...)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+
+	tags, err := s3Service.GetBucketTags(r.Context(), bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if isProtected(tags) {
+		// TagBucket doesn't do anything with an empty list of tags, the tag set has to be deleted instead
+		tags = withoutTag(tags, protectedTagKey)
+		if len(tags) == 0 {
+			if err := s3Service.DeleteBucketTags(r.Context(), bucket); err != nil {
+				handleError(w, err)
+				return
+			}
+		} else if err := s3Service.TagBucket(r.Context(), bucket, tags); err != nil {
+			handleError(w, err)
+			return
+		}
+
+		log.Warnf("removed the deletion protection from bucket %s in account %s", bucket, accountId)
+	}
+
+	writeProtection(w, bucket, false)
+}
+
+// writeProtection writes the protection status of a bucket to the response
+func writeProtection(w http.ResponseWriter, bucket string, protected bool) {
+	output := protectionOutput{Bucket: bucket, Protected: protected}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// checkDeleteProtection returns a forbidden error if the bucket is protected and the request doesn't carry a valid
+// protection override
+func (s *server) checkDeleteProtection(ctx context.Context, r *http.Request, s3Service s3api.S3, bucket string) error {
+	tags, err := s3Service.GetBucketTags(ctx, bucket)
+	if err != nil {
+		return err
+	}
+
+	if !isProtected(tags) {
+		return nil
+	}
+
+	if !s.protectionOverride(r) {
+		msg := fmt.Sprintf("bucket %s is protected from deletion, remove the protection or pass a valid %s header", bucket, protectionOverrideHeader)
+		return apierror.New(apierror.ErrForbidden, msg, nil)
+	}
+
+	log.Warnf("overriding the deletion protection for bucket %s", bucket)
+
+	return nil
+}
+
+// protectionOverride returns true if the request carries the protection override token, hashed with bcrypt the same
+// as the auth token.  Overrides are never allowed if there isn't an override token configured.
+func (s *server) protectionOverride(r *http.Request) bool {
+	if len(s.overrideToken) == 0 {
+		return false
+	}
+
+	header := r.Header.Get(protectionOverrideHeader)
+	if header == "" {
+		return false
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(header), s.overrideToken) == nil
+}
+
+// isProtected returns true if the bucket tags mark it as protected from deletion
+func isProtected(tags []*s3.Tag) bool {
+	return s3TagMap(tags)[protectedTagKey] == "true"
+}
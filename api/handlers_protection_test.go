@@ -0,0 +1,83 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gorilla/mux"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestIsProtected(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     []*s3.Tag
+		expected bool
+	}{
+		{"no tags", []*s3.Tag{}, false},
+		{"unprotected", []*s3.Tag{{Key: aws.String("spinup:org"), Value: aws.String("test")}}, false},
+		{"protected", []*s3.Tag{{Key: aws.String("spinup:org"), Value: aws.String("test")}, {Key: aws.String(protectedTagKey), Value: aws.String("true")}}, true},
+		{"not true", []*s3.Tag{{Key: aws.String(protectedTagKey), Value: aws.String("false")}}, false},
+	}
+
+	for _, tt := range tests {
+		if out := isProtected(tt.tags); out != tt.expected {
+			t.Errorf("%s: expected %t, got %t", tt.name, tt.expected, out)
+		}
+	}
+}
+
+func TestProtectionOverride(t *testing.T) {
+	token := []byte("SUPERSEKRET")
+	hash, err := bcrypt.GenerateFromPassword(token, bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wrong, err := bcrypt.GenerateFromPassword([]byte("SEKRET"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		token    []byte
+		header   string
+		expected bool
+	}{
+		{"valid override", token, string(hash), true},
+		{"wrong token", token, string(wrong), false},
+		{"missing header", token, "", false},
+		{"plain token", token, string(token), false},
+		{"no override token configured", nil, string(hash), false},
+	}
+
+	for _, tt := range tests {
+		s := server{overrideToken: tt.token}
+		req := httptest.NewRequest(http.MethodDelete, "/v1/s3/foo/buckets/foobucket", nil)
+		if tt.header != "" {
+			req.Header.Set(protectionOverrideHeader, tt.header)
+		}
+
+		if out := s.protectionOverride(req); out != tt.expected {
+			t.Errorf("%s: expected %t, got %t", tt.name, tt.expected, out)
+		}
+	}
+}
+
+func TestBucketUnprotectHandlerWithoutOverride(t *testing.T) {
+	s := server{overrideToken: []byte("SUPERSEKRET")}
+
+	req := httptest.NewRequest(http.MethodDelete, "/v1/s3/foo/buckets/foobucket/protection", nil)
+	req = mux.SetURLVars(req, map[string]string{"account": "foo", "bucket": "foobucket"})
+	w := httptest.NewRecorder()
+
+	s.BucketUnprotectHandler(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
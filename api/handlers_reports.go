@@ -0,0 +1,440 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// mfaReportUser is an individual bucket admin user in the MFA report
+type mfaReportUser struct {
+	UserName   string
+	Groups     []string
+	MFADevices []string
+	MFAEnabled bool
+}
+
+// mfaReport is the MFA report for the bucket admin users in an account
+type mfaReport struct {
+	Users      []mfaReportUser
+	WithoutMFA []string
+}
+
+// MFAReportHandler reports on the MFA status of all bucket admin users in an account.  Users in any
+// *-BktAdmGrp group are listed along with their MFA devices and flagged if they don't have MFA enabled.
+// The report can be limited to a single bucket with the `bucket` query parameter.
+func (s *server) MFAReportHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	bucket := r.URL.Query().Get("bucket")
+
+	iamService, err := s.iamServiceForAccount(r.Context(), vars["account"])
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	groups, err := iamService.ListGroups(r.Context(), &iam.ListGroupsInput{}, bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	// collect the admin groups for each user
+	userGroups := map[string][]string{}
+	for _, g := range groups {
+		groupName := aws.StringValue(g.GroupName)
+		if _, groupType, ok := iamapi.SplitGroupName(groupName); !ok || groupType != "BktAdmGrp" {
+			continue
+		}
+
+		users, err := iamService.ListGroupUsers(r.Context(), &iam.GetGroupInput{GroupName: g.GroupName})
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		for _, u := range users {
+			userName := aws.StringValue(u.UserName)
+			userGroups[userName] = append(userGroups[userName], groupName)
+		}
+	}
+
+	report := mfaReport{
+		Users:      []mfaReportUser{},
+		WithoutMFA: []string{},
+	}
+
+	userNames := make([]string, 0, len(userGroups))
+	for userName := range userGroups {
+		userNames = append(userNames, userName)
+	}
+	sort.Strings(userNames)
+
+	for _, userName := range userNames {
+		groups := userGroups[userName]
+		devices, err := listUserMFADevices(r.Context(), iamService, userName)
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		report.Users = append(report.Users, mfaReportUser{
+			UserName:   userName,
+			Groups:     groups,
+			MFADevices: devices,
+			MFAEnabled: len(devices) > 0,
+		})
+
+		if len(devices) == 0 {
+			report.WithoutMFA = append(report.WithoutMFA, userName)
+		}
+	}
+
+	j, err := json.Marshal(report)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", report, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// listUserMFADevices returns the serial numbers of the MFA devices for a user
+func listUserMFADevices(ctx context.Context, iamService iamapi.IAM, user string) ([]string, error) {
+	devices, err := iamService.ListMFADevices(ctx, &iam.ListMFADevicesInput{UserName: aws.String(user)})
+	if err != nil {
+		return nil, err
+	}
+
+	serials := make([]string, 0, len(devices))
+	for _, d := range devices {
+		serials = append(serials, aws.StringValue(d.SerialNumber))
+	}
+
+	return serials, nil
+}
+
+// tagsReportConcurrency is the number of resources whose tags are fetched at the same time for the tags report
+const tagsReportConcurrency = 10
+
+// taggedResource is a resource and its tags, the id of a distribution is its distribution id
+type taggedResource struct {
+	Type string
+	ID   string
+	Name string
+	ARN  string
+	Tags map[string]string
+}
+
+// tagsReportResource is a resource that is missing required tags or has tags with invalid values
+type tagsReportResource struct {
+	Type       string
+	Name       string
+	ARN        string `json:",omitempty"`
+	Violations []string
+}
+
+// tagsReport is the required tags compliance report for the buckets and cloudfront distributions in an account
+type tagsReport struct {
+	RequiredTags []string
+	Checked      int
+	Compliant    int
+	NonCompliant []tagsReportResource
+}
+
+// TagsReportHandler reports on the buckets and cloudfront distributions in an account that belong to the org and
+// are missing any of the configured required tags or have tags that don't match the required patterns.  The IAM
+// resources are tagged from their bucket, so they are compliant when the bucket is.
+func (s *server) TagsReportHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+
+	session, err := s.sessionForAccount(r.Context(), vars["account"],
+		"s3:ListAllMyBuckets",
+		"s3:GetBucketTagging",
+		"cloudfront:ListDistributions",
+		"cloudfront:ListTagsForResource",
+	)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+	cloudFrontService := cfapi.NewSession(session.Session, s.account, accountId)
+
+	resources, err := listTaggedResources(r.Context(), s3Service, cloudFrontService)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	report := tagsComplianceReport(s.requiredTags, resources)
+
+	j, err := json.Marshal(report)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", report, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// listTaggedResources lists the buckets and cloudfront distributions in an account that are tagged with the org,
+// along with their tags
+func listTaggedResources(ctx context.Context, s3Service s3api.S3, cloudFrontService cfapi.CloudFront) ([]taggedResource, error) {
+	buckets, err := s3Service.ListBuckets(ctx, &s3.ListBucketsInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	distributions, err := cloudFrontService.ListDistributions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]taggedResource, 0, len(buckets)+len(distributions))
+	for _, b := range buckets {
+		resources = append(resources, taggedResource{Type: "bucket", ID: aws.StringValue(b.Name), Name: aws.StringValue(b.Name)})
+	}
+
+	for _, d := range distributions {
+		name := aws.StringValue(d.Id)
+		if d.Aliases != nil && len(d.Aliases.Items) > 0 {
+			name = aws.StringValue(d.Aliases.Items[0])
+		}
+		resources = append(resources, taggedResource{Type: "distribution", ID: aws.StringValue(d.Id), Name: name, ARN: aws.StringValue(d.ARN)})
+	}
+
+	g, gctx := newErrGroup(ctx)
+	sem := make(chan struct{}, tagsReportConcurrency)
+	for i := range resources {
+		res := &resources[i]
+		g.Go(func() error {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			var tags map[string]string
+			if res.Type == "bucket" {
+				t, err := s3Service.GetBucketTags(gctx, res.Name)
+				if err != nil {
+					return err
+				}
+				tags = s3TagMap(t)
+			} else {
+				t, err := cloudFrontService.ListTags(gctx, res.ARN)
+				if err != nil {
+					return err
+				}
+				tags = cloudFrontTagMap(t)
+			}
+
+			res.Tags = tags
+
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	// only report on the resources that belong to the org
+	owned := []taggedResource{}
+	for _, res := range resources {
+		if res.Tags["spinup:org"] == Org {
+			owned = append(owned, res)
+		}
+	}
+
+	return owned, nil
+}
+
+// tagsComplianceReport checks each resource against the required tags
+func tagsComplianceReport(required requiredTags, resources []taggedResource) tagsReport {
+	report := tagsReport{
+		RequiredTags: required.keys(),
+		Checked:      len(resources),
+		NonCompliant: []tagsReportResource{},
+	}
+
+	for _, res := range resources {
+		violations := required.violations(res.Tags)
+		if len(violations) == 0 {
+			report.Compliant++
+			continue
+		}
+
+		report.NonCompliant = append(report.NonCompliant, tagsReportResource{
+			Type:       res.Type,
+			Name:       res.Name,
+			ARN:        res.ARN,
+			Violations: violations,
+		})
+	}
+
+	sort.Slice(report.NonCompliant, func(i, j int) bool {
+		if report.NonCompliant[i].Type != report.NonCompliant[j].Type {
+			return report.NonCompliant[i].Type < report.NonCompliant[j].Type
+		}
+		return report.NonCompliant[i].Name < report.NonCompliant[j].Name
+	})
+
+	return report
+}
+
+// credentialReport is the IAM credential report for the users managed by the api
+type credentialReport struct {
+	GeneratedTime time.Time
+	Users         []map[string]string
+}
+
+// CredentialReportHandler returns the IAM credential report for the users managed by the api, as JSON or CSV with
+// the `format` query parameter.  By default the report includes the members of the bucket and website management
+// groups, the `path` query parameter limits it to the users with the IAM path instead.  If the report is still
+// being generated, 202 Accepted is returned and the request should be retried.
+func (s *server) CredentialReportHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+
+	f := fieldErrors{}
+	if format != "json" && format != "csv" {
+		f.add("format", "unsupported format %s, must be json or csv", format)
+	}
+
+	path := r.URL.Query().Get("path")
+	if path != "" && !iamPathRe.MatchString(path) {
+		f.add("path", "path %q must begin and end with /", path)
+	}
+
+	if err := f.err(); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	iamService, err := s.iamServiceForAccount(r.Context(), vars["account"])
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	state, err := iamService.GenerateCredentialReport(r.Context())
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if state != iam.ReportStateTypeComplete {
+		log.Infof("iam credential report for account %s is %s", vars["account"], state)
+		w.Header().Set("Retry-After", "10")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("credential report is being generated, try again shortly"))
+		return
+	}
+
+	report, err := iamService.GetCredentialReport(r.Context())
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	var filtered *iamapi.CredentialReport
+	if path != "" {
+		filtered = report.Filter(func(_, arn string) bool {
+			return strings.Contains(arn, ":user"+path)
+		})
+	} else {
+		managed, err := managedUsers(r.Context(), iamService)
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		filtered = report.Filter(func(user, _ string) bool {
+			return managed[user]
+		})
+	}
+
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", "attachment; filename=credential-report.csv")
+		w.WriteHeader(http.StatusOK)
+		if err := filtered.WriteCSV(w); err != nil {
+			log.Errorf("failed to write credential report: %s", err)
+		}
+		return
+	}
+
+	output := credentialReport{
+		GeneratedTime: filtered.GeneratedTime,
+		Users:         filtered.Users(),
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// managedUsers returns the set of users in the bucket and website management groups in an account
+func managedUsers(ctx context.Context, iamService iamapi.IAM) (map[string]bool, error) {
+	groups, err := iamService.ListGroups(ctx, &iam.ListGroupsInput{}, "")
+	if err != nil {
+		return nil, err
+	}
+
+	users := map[string]bool{}
+	for _, g := range groups {
+		if !isManagementGroup(aws.StringValue(g.GroupName)) {
+			continue
+		}
+
+		members, err := iamService.ListGroupUsers(ctx, &iam.GetGroupInput{GroupName: g.GroupName})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, u := range members {
+			users[aws.StringValue(u.UserName)] = true
+		}
+	}
+
+	return users, nil
+}
+
+// isManagementGroup returns true if the group is one of the bucket or website management groups
+func isManagementGroup(group string) bool {
+	_, _, ok := iamapi.SplitGroupName(group)
+	return ok
+}
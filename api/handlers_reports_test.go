@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestIsManagementGroup(t *testing.T) {
+	tests := map[string]bool{
+		"foobucket-BktAdmGrp":                true,
+		"foobucket-BktRWGrp":                 true,
+		"foobucket-BktROGrp":                 true,
+		"foobar.example.com-WebAdmGrp":       true,
+		"foobucket-Admins":                   false,
+		"BktAdmGrp":                          false,
+		"foobucket-BktAdmGrp-decommissioned": false,
+	}
+
+	for group, expected := range tests {
+		if out := isManagementGroup(group); out != expected {
+			t.Errorf("expected isManagementGroup(%s) to be %t, got %t", group, expected, out)
+		}
+	}
+}
+
+func TestCredentialReportHandlerValidation(t *testing.T) {
+	s := server{}
+
+	for _, query := range []string{"format=xml", "path=spinup", "format=csv&path=/spinup"} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/s3/foo/reports/credentials?"+query, nil)
+		req = mux.SetURLVars(req, map[string]string{"account": "foo"})
+		w := httptest.NewRecorder()
+
+		s.CredentialReportHandler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d for query %s, got %d", http.StatusBadRequest, query, w.Code)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/rollback"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// RollbackListHandler lists the persisted rollbacks for an account.  These are rollbacks for operations that
+// are in progress, were interrupted (ie. by a crash) or failed to completely roll back.
+func (s *server) RollbackListHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+
+	rollbacks := []*rollback.Rollback{}
+	if s.rollbackStore != nil {
+		all, err := s.rollbackStore.List()
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		for _, rb := range all {
+			if s.sameAccount(rb.Account, vars["account"]) {
+				rollbacks = append(rollbacks, rb)
+			}
+		}
+	}
+
+	j, err := json.Marshal(rollbacks)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", rollbacks, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// RollbackShowHandler returns the details of a persisted rollback
+func (s *server) RollbackShowHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+
+	rb, err := s.getRollback(vars["account"], vars["id"])
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	j, err := json.Marshal(rb)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", rb, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// RollbackResumeHandler executes the remaining steps of an interrupted or failed rollback.  Rollbacks for
+// operations still in progress in this instance cannot be resumed.
+func (s *server) RollbackResumeHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+
+	rb, err := s.getRollback(vars["account"], vars["id"])
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if rb.Status == rollback.StatusPending && rb.Instance == s.instance {
+		handleError(w, apierror.New(apierror.ErrConflict, "operation is still in progress", nil))
+		return
+	}
+
+	if err := s.resumeRollback(r.Context(), rb); err != nil {
+		log.Errorf("failed to resume rollback %s: %s", rb.ID, err)
+	}
+
+	j, err := json.Marshal(rb)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", rb, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// RollbackDeleteHandler discards a persisted rollback without executing it, ie. after it's been cleaned up by hand
+func (s *server) RollbackDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+
+	rb, err := s.getRollback(vars["account"], vars["id"])
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if rb.Status == rollback.StatusPending && rb.Instance == s.instance {
+		handleError(w, apierror.New(apierror.ErrConflict, "operation is still in progress", nil))
+		return
+	}
+
+	if err := s.rollbackStore.Delete(rb.ID); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte{})
+}
+
+// getRollback gets a persisted rollback and verifies it belongs to the account
+func (s *server) getRollback(account, id string) (*rollback.Rollback, error) {
+	if s.rollbackStore == nil {
+		return nil, apierror.New(apierror.ErrNotFound, "rollback persistence is not enabled", nil)
+	}
+
+	rb, err := s.rollbackStore.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.sameAccount(rb.Account, account) {
+		return nil, apierror.New(apierror.ErrNotFound, "rollback not found", nil)
+	}
+
+	return rb, nil
+}
+
+// sameAccount returns true if both accounts, by name or number, are the same account
+func (s *server) sameAccount(a, b string) bool {
+	return s.mapAccountNumber(a) == s.mapAccountNumber(b)
+}
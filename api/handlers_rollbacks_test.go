@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/YaleSpinup/s3-api/rollback"
+	"github.com/gorilla/mux"
+)
+
+func TestRollbackHandlers(t *testing.T) {
+	store, err := rollback.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("expected nil error creating store, got %s", err)
+	}
+
+	s := server{
+		accountsMap:   map[string]string{"someaccount": "012345678901"},
+		rollbackStore: store,
+		instance:      "this-instance",
+		router:        mux.NewRouter(),
+	}
+	s.routes()
+
+	failing := func(ctx context.Context, step *rollback.Step) error { return context.DeadlineExceeded }
+
+	// an interrupted rollback from a previous instance
+	interrupted := rollback.New("bucket.create", "someaccount", "foobucket", failing, rollback.WithStore(store), rollback.WithInstance("old-instance"))
+	interrupted.Add("delete bucket foobucket", rollbackDeleteBucket, map[string]string{"bucket": "foobucket"})
+
+	// an in progress rollback in this instance
+	inProgress := rollback.New("bucket.create", "012345678901", "barbucket", failing, rollback.WithStore(store), rollback.WithInstance("this-instance"))
+	inProgress.Add("delete bucket barbucket", rollbackDeleteBucket, map[string]string{"bucket": "barbucket"})
+
+	// a rollback in another account
+	other := rollback.New("bucket.create", "otheraccount", "bazbucket", failing, rollback.WithStore(store))
+	other.Add("delete bucket bazbucket", rollbackDeleteBucket, map[string]string{"bucket": "bazbucket"})
+
+	// test listing by account name returns rollbacks by name and number
+	req := httptest.NewRequest(http.MethodGet, "/v1/s3/someaccount/rollbacks", nil)
+	rr := httptest.NewRecorder()
+	s.router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	list := []*rollback.Rollback{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+		t.Fatalf("failed to unmarshal response: %s", err)
+	}
+
+	if len(list) != 2 {
+		t.Errorf("expected 2 rollbacks, got %d", len(list))
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+	}{
+		{http.MethodGet, "/v1/s3/someaccount/rollbacks/" + interrupted.ID, http.StatusOK},
+		{http.MethodGet, "/v1/s3/someaccount/rollbacks/" + other.ID, http.StatusNotFound},
+		{http.MethodGet, "/v1/s3/someaccount/rollbacks/missing", http.StatusNotFound},
+		{http.MethodPost, "/v1/s3/someaccount/rollbacks/" + inProgress.ID, http.StatusConflict},
+		{http.MethodDelete, "/v1/s3/someaccount/rollbacks/" + inProgress.ID, http.StatusConflict},
+		{http.MethodDelete, "/v1/s3/someaccount/rollbacks/" + interrupted.ID, http.StatusOK},
+		{http.MethodGet, "/v1/s3/someaccount/rollbacks/" + interrupted.ID, http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		rr := httptest.NewRecorder()
+		s.router.ServeHTTP(rr, req)
+		if rr.Code != tc.status {
+			t.Errorf("%s %s: expected status %d, got %d", tc.method, tc.path, tc.status, rr.Code)
+		}
+	}
+
+	// test without a store
+	s.rollbackStore = nil
+	req = httptest.NewRequest(http.MethodGet, "/v1/s3/someaccount/rollbacks/"+other.ID, nil)
+	rr = httptest.NewRecorder()
+	s.router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected status %d without a store, got %d", http.StatusNotFound, rr.Code)
+	}
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultSimulationActions are the actions that are simulated if none are passed
+var defaultSimulationActions = []string{"s3:ListBucket", "s3:GetObject", "s3:PutObject", "s3:DeleteObject"}
+
+// simulationObjectActions are the s3 actions on objects that don't have Object in their name
+var simulationObjectActions = []string{"s3:AbortMultipartUpload", "s3:ListMultipartUploadParts", "s3:ReplicateDelete"}
+
+// simulationResult is the decision for an action on a resource
+type simulationResult struct {
+	Action   string
+	Resource string
+	// Decision is allowed, explicitDeny or implicitDeny
+	Decision string
+	Allowed  bool
+	// MatchedPolicies are the ids of the policies with statements that matched the action
+	MatchedPolicies []string `json:",omitempty"`
+	// MissingContextValues are the condition keys that affect the decision but weren't part of the simulation,
+	// ie. aws:MultiFactorAuthPresent
+	MissingContextValues []string `json:",omitempty"`
+}
+
+// simulationOutput is the result of simulating a user's access to a bucket
+type simulationOutput struct {
+	User    string
+	Bucket  string
+	Results []simulationResult
+}
+
+// BucketSimulateHandler answers whether a user can perform actions on a bucket, or an object in it, by simulating
+// the user's IAM policies.  The user is passed as the user query parameter, the actions as one or more action
+// parameters and the object key as the key parameter.
+func (s *server) BucketSimulateHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	bucket := vars["bucket"]
+
+	query := r.URL.Query()
+	user := query.Get("user")
+	actions := query["action"]
+	if len(actions) == 0 {
+		actions = defaultSimulationActions
+	}
+
+	key := query.Get("key")
+	if key == "" {
+		key = "*"
+	}
+
+	f := fieldErrors{}
+	if user == "" {
+		f.add("user", "user is required")
+	}
+	for i, a := range actions {
+		if !strings.HasPrefix(a, "s3:") || strings.Contains(a, "*") {
+			f.add(fmt.Sprintf("action[%d]", i), "action %s must be a single s3 action, ie. s3:GetObject", a)
+		}
+	}
+	if err := f.err(); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	session, err := s.sessionForAccount(r.Context(), vars["account"], "iam:GetUser", "iam:SimulatePrincipalPolicy")
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	iamService := iamapi.NewSession(session.Session, s.account)
+	iamService.Cache = s.resourceCache(accountId)
+
+	userOutput, err := iamService.GetUser(r.Context(), &iam.GetUserInput{UserName: aws.String(user)})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	userArn := aws.StringValue(userOutput.User.Arn)
+
+	// bucket and object actions have to be simulated against their own resources, otherwise a bucket action would
+	// be implicitly denied on the object and vice versa
+	bucketActions, objectActions := splitSimulationActions(actions)
+
+	results := []*iam.EvaluationResult{}
+	if len(bucketActions) > 0 {
+		out, err := iamService.SimulatePrincipalPolicy(r.Context(), userArn, bucketActions, []string{"arn:aws:s3:::" + bucket})
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+		results = append(results, out...)
+	}
+
+	if len(objectActions) > 0 {
+		out, err := iamService.SimulatePrincipalPolicy(r.Context(), userArn, objectActions, []string{"arn:aws:s3:::" + bucket + "/" + key})
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+		results = append(results, out...)
+	}
+
+	output := simulationOutput{
+		User:    user,
+		Bucket:  bucket,
+		Results: simulationResults(results),
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// splitSimulationActions splits the s3 actions into the actions on buckets and the actions on objects
+func splitSimulationActions(actions []string) ([]string, []string) {
+	bucketActions, objectActions := []string{}, []string{}
+	for _, a := range actions {
+		if strings.Contains(a, "Object") || contains(simulationObjectActions, a) {
+			objectActions = append(objectActions, a)
+			continue
+		}
+		bucketActions = append(bucketActions, a)
+	}
+
+	return bucketActions, objectActions
+}
+
+// simulationResults converts the iam evaluation results to simulation results
+func simulationResults(results []*iam.EvaluationResult) []simulationResult {
+	output := make([]simulationResult, 0, len(results))
+	for _, r := range results {
+		policies := map[string]bool{}
+		for _, s := range r.MatchedStatements {
+			policies[aws.StringValue(s.SourcePolicyId)] = true
+		}
+
+		decision := aws.StringValue(r.EvalDecision)
+		result := simulationResult{
+			Action:   aws.StringValue(r.EvalActionName),
+			Resource: aws.StringValue(r.EvalResourceName),
+			Decision: decision,
+			Allowed:  decision == iam.PolicyEvaluationDecisionTypeAllowed,
+		}
+
+		if len(policies) > 0 {
+			result.MatchedPolicies = sortedKeys(policies)
+		}
+
+		if len(r.MissingContextValues) > 0 {
+			result.MissingContextValues = aws.StringValueSlice(r.MissingContextValues)
+		}
+
+		output = append(output, result)
+	}
+
+	sort.SliceStable(output, func(i, j int) bool {
+		return output[i].Action < output[j].Action
+	})
+
+	return output
+}
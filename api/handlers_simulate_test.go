@@ -0,0 +1,78 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+func TestSplitSimulationActions(t *testing.T) {
+	bucketActions, objectActions := splitSimulationActions([]string{
+		"s3:ListBucket",
+		"s3:GetObject",
+		"s3:PutObjectAcl",
+		"s3:GetBucketPolicy",
+		"s3:AbortMultipartUpload",
+	})
+
+	if expected := []string{"s3:ListBucket", "s3:GetBucketPolicy"}; !reflect.DeepEqual(bucketActions, expected) {
+		t.Errorf("expected bucket actions %v, got %v", expected, bucketActions)
+	}
+
+	if expected := []string{"s3:GetObject", "s3:PutObjectAcl", "s3:AbortMultipartUpload"}; !reflect.DeepEqual(objectActions, expected) {
+		t.Errorf("expected object actions %v, got %v", expected, objectActions)
+	}
+}
+
+func TestSimulationResults(t *testing.T) {
+	results := []*iam.EvaluationResult{
+		{
+			EvalActionName:   aws.String("s3:PutObject"),
+			EvalResourceName: aws.String("arn:aws:s3:::testbucket/*"),
+			EvalDecision:     aws.String("implicitDeny"),
+		},
+		{
+			EvalActionName:   aws.String("s3:GetObject"),
+			EvalResourceName: aws.String("arn:aws:s3:::testbucket/*"),
+			EvalDecision:     aws.String("allowed"),
+			MatchedStatements: []*iam.Statement{
+				{SourcePolicyId: aws.String("testbucket-BktROPlc")},
+				{SourcePolicyId: aws.String("testbucket-BktAdmPlc")},
+				{SourcePolicyId: aws.String("testbucket-BktROPlc")},
+			},
+		},
+		{
+			EvalActionName:       aws.String("s3:DeleteObject"),
+			EvalResourceName:     aws.String("arn:aws:s3:::testbucket/*"),
+			EvalDecision:         aws.String("explicitDeny"),
+			MissingContextValues: aws.StringSlice([]string{"aws:MultiFactorAuthPresent"}),
+		},
+	}
+
+	expected := []simulationResult{
+		{
+			Action:               "s3:DeleteObject",
+			Resource:             "arn:aws:s3:::testbucket/*",
+			Decision:             "explicitDeny",
+			MissingContextValues: []string{"aws:MultiFactorAuthPresent"},
+		},
+		{
+			Action:          "s3:GetObject",
+			Resource:        "arn:aws:s3:::testbucket/*",
+			Decision:        "allowed",
+			Allowed:         true,
+			MatchedPolicies: []string{"testbucket-BktAdmPlc", "testbucket-BktROPlc"},
+		},
+		{
+			Action:   "s3:PutObject",
+			Resource: "arn:aws:s3:::testbucket/*",
+			Decision: "implicitDeny",
+		},
+	}
+
+	if out := simulationResults(results); !reflect.DeepEqual(out, expected) {
+		t.Errorf("expected %+v, got %+v", expected, out)
+	}
+}
@@ -0,0 +1,551 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/YaleSpinup/apierror"
+	aaapi "github.com/YaleSpinup/s3-api/accessanalyzer"
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	"github.com/YaleSpinup/s3-api/common"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/YaleSpinup/s3-api/retry"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// bucketSpec is the desired state of a bucket.  Fields that are left out of the spec are not managed.
+type bucketSpec struct {
+	// Tags is the full list of tags for the bucket, the spinup:org tag is always added
+	Tags []*s3.Tag
+	// Encryption is the default server side encryption algorithm (AES256 or aws:kms)
+	Encryption *string
+	// BucketKey enables (true) or disables (false) the s3 bucket key for aws:kms encryption, the account's default is
+	// used when it's left out
+	BucketKey *bool `json:",omitempty"`
+	// Lifecycle is the name of one of the supported lifecycles, an empty string removes the lifecycle configuration
+	Lifecycle *string
+	// Logging enables or disables access logging to the configured access log bucket
+	Logging *bool
+	// Versioning is the versioning status (Enabled or Suspended)
+	Versioning *string
+	// BucketPolicy is the bucket access policy document
+	BucketPolicy *string
+	// PolicyBlocks adds (true) or removes (false) the named statement blocks in the bucket policy, the blocks that
+	// aren't listed are left as they are
+	PolicyBlocks map[string]bool `json:",omitempty"`
+	// Groups are the management groups that should exist for the bucket (BktAdmGrp, BktRWGrp, BktROGrp)
+	Groups []string
+	// ObjectTags are the default tags for the objects created by the api, they're left as is when null and an empty
+	// list removes them
+	ObjectTags []*s3.Tag `json:",omitempty"`
+}
+
+// specChange is a single change needed to converge a bucket to its spec
+type specChange struct {
+	Resource string
+	Action   string
+	Current  interface{} `json:",omitempty"`
+	Desired  interface{} `json:",omitempty"`
+
+	apply func(ctx context.Context) error
+}
+
+// bucketSpecOutput is the report of the changes from applying a bucket spec, with the status of each change that
+// was applied
+type bucketSpecOutput struct {
+	Bucket  string
+	DryRun  bool
+	Changes []*specChange
+	// PolicyFindings are the access analyzer findings about the bucket policy that didn't keep it from being applied
+	PolicyFindings []*aaapi.Finding `json:",omitempty"`
+	multiStatus
+}
+
+// validate checks the spec for unsupported values and the required tags
+func (b *bucketSpec) validate(required requiredTags) error {
+	if b.Encryption != nil {
+		switch aws.StringValue(b.Encryption) {
+		case s3.ServerSideEncryptionAes256, s3.ServerSideEncryptionAwsKms:
+		default:
+			return apierror.New(apierror.ErrBadRequest, "unsupported encryption "+aws.StringValue(b.Encryption), nil)
+		}
+	}
+
+	if b.BucketKey != nil && aws.StringValue(b.Encryption) != s3.ServerSideEncryptionAwsKms {
+		return apierror.New(apierror.ErrBadRequest, "a bucket key requires aws:kms encryption", nil)
+	}
+
+	if b.Lifecycle != nil && aws.StringValue(b.Lifecycle) != "" {
+		if !s3api.Lifecycles.Supported(aws.StringValue(b.Lifecycle)) {
+			return apierror.New(apierror.ErrBadRequest, "unsupported lifecycle "+aws.StringValue(b.Lifecycle), nil)
+		}
+	}
+
+	if b.Versioning != nil {
+		switch aws.StringValue(b.Versioning) {
+		case s3.BucketVersioningStatusEnabled, s3.BucketVersioningStatusSuspended:
+		default:
+			return apierror.New(apierror.ErrBadRequest, "unsupported versioning status "+aws.StringValue(b.Versioning), nil)
+		}
+	}
+
+	for _, g := range b.Groups {
+		switch g {
+		case "BktAdmGrp", "BktRWGrp", "BktROGrp":
+		default:
+			return apierror.New(apierror.ErrBadRequest, "unsupported group "+g, nil)
+		}
+	}
+
+	for name := range b.PolicyBlocks {
+		if err := validateBucketPolicyBlock(name); err != nil {
+			return err
+		}
+	}
+
+	f := fieldErrors{}
+	f.tags("Tags", b.Tags)
+	f.requiredTags("Tags", b.Tags, required)
+	f.policyDocument("BucketPolicy", b.BucketPolicy)
+	f.objectTags("ObjectTags", b.ObjectTags)
+	return f.err()
+}
+
+// BucketSpecApplyHandler converges a bucket to the passed desired state document.  The bucket and any missing
+// management groups are created, and drifted configuration is updated.  A report of the changes is returned.  When
+// the `dryrun` query parameter is true, the changes are reported but not applied.  Applying the same spec again is
+// safe, so a failure part way through can be recovered by re-applying the spec.
+func (s *server) BucketSpecApplyHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	bucket := vars["bucket"]
+
+	dryRun := false
+	if d := r.URL.Query().Get("dryrun"); d != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(d); err != nil {
+			handleError(w, apierror.New(apierror.ErrBadRequest, "invalid dryrun parameter", err))
+			return
+		}
+	}
+
+	var spec bucketSpec
+	if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+		msg := fmt.Sprintf("cannot decode body into bucket spec: %s", err)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+		return
+	}
+
+	if err := spec.validate(s.requiredTags); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	session, err := s.sessionForAccount(r.Context(), accountId, append(append([]string{"s3:*", "iam:*"}, tagSyncActions...), policyValidationActions...)...)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+	iamService := iamapi.NewSession(session.Session, s.account)
+	iamService.Cache = s.resourceCache(accountId)
+
+	cloudFrontService := cfapi.NewSession(session.Session, s.account, accountId)
+	cloudFrontService.Index = s.distributionIndex(accountId)
+
+	output := bucketSpecOutput{Bucket: bucket, DryRun: dryRun, multiStatus: newMultiStatus()}
+	if spec.BucketPolicy != nil {
+		if output.PolicyFindings, err = s.validateBucketPolicy(r.Context(), session.Session, aws.StringValue(spec.BucketPolicy)); err != nil {
+			handleError(w, err)
+			return
+		}
+	}
+
+	changes, err := s.planBucketSpec(r.Context(), s3Service, iamService, cloudFrontService, accountId, bucket, &spec)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	output.Changes = changes
+
+	if !dryRun {
+		applySpecChanges(r.Context(), bucket, changes, &output.multiStatus)
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(output.code())
+	w.Write(j)
+}
+
+// applySpecChanges applies the changes of a bucket spec in order and records the status of each one.  A failed change
+// doesn't stop the others, except for the bucket itself since everything else needs it.
+func applySpecChanges(ctx context.Context, bucket string, changes []*specChange, status *multiStatus) {
+	for _, c := range changes {
+		// the groups are reported by name, everything else is part of the bucket
+		resource := bucket
+		if name, ok := c.Desired.(string); ok && c.Resource == "group" {
+			resource = name
+		}
+
+		log.Infof("applying spec change %s %s for bucket %s", c.Action, c.Resource, bucket)
+		if err := c.apply(ctx); err != nil {
+			status.fail(c.Resource, resource, c.Action, err)
+
+			if c.Resource == "bucket" {
+				return
+			}
+			continue
+		}
+
+		status.ok(c.Resource, resource, c.Action)
+	}
+}
+
+// planBucketSpec compares the current state of the bucket with the spec and returns the list of changes, in
+// the order they should be applied
+func (s *server) planBucketSpec(ctx context.Context, s3Service s3api.S3, iamService iamapi.IAM, cloudFrontService cfapi.CloudFront, accountId, bucket string, spec *bucketSpec) ([]*specChange, error) {
+	changes := []*specChange{}
+
+	exists, err := s3Service.BucketExists(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		changes = append(changes, &specChange{
+			Resource: "bucket",
+			Action:   "create",
+			Desired:  bucket,
+			apply: func(ctx context.Context) error {
+				if _, err := s3Service.CreateBucket(ctx, &s3.CreateBucketInput{Bucket: aws.String(bucket)}); err != nil {
+					return err
+				}
+
+				return retry.Do(ctx, s3ConsistencyRetry, func(ctx context.Context) error {
+					exists, err := s3Service.BucketExists(ctx, bucket)
+					if err != nil {
+						return err
+					}
+
+					if !exists {
+						return fmt.Errorf("s3 bucket (%s) doesn't exist", bucket)
+					}
+					return nil
+				})
+			},
+		})
+	}
+
+	// append org tag that will get applied to all resources that tag
+	tags := append(spec.Tags, &s3.Tag{
+		Key:   aws.String("spinup:org"),
+		Value: aws.String(Org),
+	})
+
+	currentTags := []*s3.Tag{}
+	if exists {
+		if currentTags, err = s3Service.GetBucketTags(ctx, bucket); err != nil {
+			return nil, err
+		}
+	}
+	tags = keepReservedTags(currentTags, tags)
+	if spec.ObjectTags != nil {
+		tags = withDefaultObjectTags(tags, spec.ObjectTags)
+	}
+
+	if !tagsEqual(currentTags, tags) {
+		changes = append(changes, &specChange{
+			Resource: "tags",
+			Action:   "update",
+			Current:  currentTags,
+			Desired:  tags,
+			apply: func(ctx context.Context) error {
+				if err := s3Service.TagBucket(ctx, bucket, tags); err != nil {
+					return err
+				}
+
+				_, err := syncBucketTags(ctx, iamService, cloudFrontService, s.requiredTags, bucket, tags)
+				return err
+			},
+		})
+	}
+
+	if spec.Encryption != nil {
+		var current bucketEncryption
+		if exists {
+			enc, err := s3Service.GetBucketEncryption(ctx, bucket)
+			if err != nil {
+				return nil, err
+			}
+			current = toBucketEncryption(enc)
+		}
+
+		desired := s.desiredEncryption(current, spec)
+		if current != desired {
+			changes = append(changes, &specChange{
+				Resource: "encryption",
+				Action:   "update",
+				Current:  current,
+				Desired:  desired,
+				apply: func(ctx context.Context) error {
+					return s3Service.UpdateBucketEncryption(ctx, &s3.PutBucketEncryptionInput{
+						Bucket:                            aws.String(bucket),
+						ServerSideEncryptionConfiguration: desired.encryptionConfiguration(),
+					})
+				},
+			})
+		}
+	}
+
+	if spec.Versioning != nil {
+		var current string
+		if exists {
+			if current, err = s3Service.GetBucketVersioning(ctx, bucket); err != nil {
+				return nil, err
+			}
+		}
+
+		// a bucket that was never versioned is already in the desired state if versioning should be suspended
+		desired := aws.StringValue(spec.Versioning)
+		if current != desired && !(current == "" && desired == s3.BucketVersioningStatusSuspended) {
+			changes = append(changes, &specChange{
+				Resource: "versioning",
+				Action:   "update",
+				Current:  current,
+				Desired:  desired,
+				apply: func(ctx context.Context) error {
+					return s3Service.UpdateBucketVersioning(ctx, bucket, desired)
+				},
+			})
+		}
+	}
+
+	if spec.Lifecycle != nil {
+		current := []*s3.LifecycleRule{}
+		if exists {
+			if current, err = s3Service.GetBucketLifecycleConfiguration(ctx, bucket); err != nil {
+				return nil, err
+			}
+		}
+
+		desired := aws.StringValue(spec.Lifecycle)
+		switch {
+		case desired == "" && len(current) > 0:
+			changes = append(changes, &specChange{
+				Resource: "lifecycle",
+				Action:   "delete",
+				Current:  current,
+				apply: func(ctx context.Context) error {
+					return s3Service.DeleteBucketLifecycle(ctx, &s3.DeleteBucketLifecycleInput{Bucket: aws.String(bucket)})
+				},
+			})
+		case desired != "":
+			// lifecycle rules are compared by id since s3 fills in defaults for the rules it returns
+			rules := s3api.Lifecycles.GetRules(desired)
+			if s3api.Lifecycles.Name(current) != desired {
+				changes = append(changes, &specChange{
+					Resource: "lifecycle",
+					Action:   "update",
+					Current:  current,
+					Desired:  desired,
+					apply: func(ctx context.Context) error {
+						return s3Service.PutBucketLifecycleConfiguration(ctx, &s3.PutBucketLifecycleConfigurationInput{
+							Bucket:                 aws.String(bucket),
+							LifecycleConfiguration: &s3.BucketLifecycleConfiguration{Rules: rules},
+						})
+					},
+				})
+			}
+		}
+	}
+
+	if spec.Logging != nil {
+		var current *s3.LoggingEnabled
+		if exists {
+			if current, err = s3Service.GetBucketLogging(ctx, bucket); err != nil {
+				return nil, err
+			}
+		}
+
+		switch {
+		case aws.BoolValue(spec.Logging) && current == nil:
+			if s3Service.LoggingBucket == "" {
+				return nil, apierror.New(apierror.ErrBadRequest, "access logging is not configured for this account", nil)
+			}
+
+			changes = append(changes, &specChange{
+				Resource: "logging",
+				Action:   "create",
+				Desired:  s3Service.LoggingBucket,
+				apply: func(ctx context.Context) error {
+					return s3Service.UpdateBucketLogging(ctx, bucket, s3Service.LoggingBucket, s3Service.LoggingBucketPrefix)
+				},
+			})
+		case !aws.BoolValue(spec.Logging) && current != nil:
+			changes = append(changes, &specChange{
+				Resource: "logging",
+				Action:   "delete",
+				Current:  aws.StringValue(current.TargetBucket),
+				apply: func(ctx context.Context) error {
+					return s3Service.DisableBucketLogging(ctx, bucket)
+				},
+			})
+		}
+	}
+
+	if spec.BucketPolicy != nil || len(spec.PolicyBlocks) > 0 {
+		var current string
+		if exists {
+			if current, err = s3Service.GetBucketPolicy(ctx, bucket); err != nil {
+				return nil, err
+			}
+		}
+
+		desired := current
+		if spec.BucketPolicy != nil {
+			desired = aws.StringValue(spec.BucketPolicy)
+		}
+
+		// the blocks are toggled in the desired policy in order of their names so the plan is the same every time
+		remaining := -1
+		for _, name := range sortedKeys(spec.PolicyBlocks) {
+			if desired, remaining, err = s.bucketPolicyBlock(accountId, bucket, desired, name, spec.PolicyBlocks[name]); err != nil {
+				if _, ok := common.AsAPIError(err); ok {
+					return nil, err
+				}
+				return nil, apierror.New(apierror.ErrBadRequest, "failed to update the policy blocks of the bucket policy", err)
+			}
+		}
+
+		equal, err := policiesEqual(current, desired)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case remaining == 0 && current != "":
+			changes = append(changes, &specChange{
+				Resource: "policy",
+				Action:   "delete",
+				Current:  current,
+				apply: func(ctx context.Context) error {
+					return s3Service.DeleteBucketPolicy(ctx, bucket)
+				},
+			})
+		case remaining != 0 && !equal:
+			changes = append(changes, &specChange{
+				Resource: "policy",
+				Action:   "update",
+				Current:  current,
+				Desired:  desired,
+				apply: func(ctx context.Context) error {
+					return s3Service.UpdateBucketPolicy(ctx, &s3.PutBucketPolicyInput{
+						Bucket: aws.String(bucket),
+						Policy: aws.String(desired),
+					})
+				},
+			})
+		}
+	}
+
+	for _, g := range spec.Groups {
+		group := g
+		groupName := iamapi.FormatGroupName(bucket, "/", group)
+		if _, err := iamService.GetGroup(ctx, groupName); err != nil {
+			if !isNotFound(err) {
+				return nil, err
+			}
+
+			changes = append(changes, &specChange{
+				Resource: "group",
+				Action:   "create",
+				Desired:  groupName,
+				apply: func(ctx context.Context) error {
+					_, err := s.CreateBucketGroupPolicy(ctx, iamService, bucket, group, iamTags(tags))
+					return err
+				},
+			})
+		}
+	}
+
+	return changes, nil
+}
+
+// encryptionAlgorithm returns the default SSE algorithm from an encryption configuration
+func encryptionAlgorithm(c *s3.ServerSideEncryptionConfiguration) string {
+	return toBucketEncryption(c).SSEAlgorithm
+}
+
+// desiredEncryption returns the default encryption the spec converges a bucket to.  The kms key of a bucket that's
+// already encrypted with aws:kms is kept, and the bucket key follows the spec or the account's default.
+func (s *server) desiredEncryption(current bucketEncryption, spec *bucketSpec) bucketEncryption {
+	desired := bucketEncryption{SSEAlgorithm: aws.StringValue(spec.Encryption)}
+	if desired.SSEAlgorithm != s3.ServerSideEncryptionAwsKms {
+		// the bucket key doesn't apply to other algorithms, so it isn't a change
+		if current.SSEAlgorithm == desired.SSEAlgorithm {
+			return current
+		}
+		return desired
+	}
+
+	if current.SSEAlgorithm == s3.ServerSideEncryptionAwsKms {
+		desired.KMSMasterKeyID = current.KMSMasterKeyID
+	}
+
+	desired.BucketKeyEnabled = s.account.BucketKey
+	if spec.BucketKey != nil {
+		desired.BucketKeyEnabled = aws.BoolValue(spec.BucketKey)
+	}
+
+	return desired
+}
+
+// tagsEqual compares two lists of tags regardless of order
+func tagsEqual(a, b []*s3.Tag) bool {
+	am := map[string]string{}
+	for _, t := range a {
+		am[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+
+	bm := map[string]string{}
+	for _, t := range b {
+		bm[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+
+	return reflect.DeepEqual(am, bm)
+}
+
+// policiesEqual compares two JSON policy documents, ignoring formatting
+func policiesEqual(a, b string) (bool, error) {
+	if a == "" || b == "" {
+		return a == b, nil
+	}
+
+	var ap, bp interface{}
+	if err := json.Unmarshal([]byte(a), &ap); err != nil {
+		return false, apierror.New(apierror.ErrInternalError, "failed to parse current bucket policy", err)
+	}
+
+	if err := json.Unmarshal([]byte(b), &bp); err != nil {
+		return false, apierror.New(apierror.ErrBadRequest, "failed to parse bucket policy", err)
+	}
+
+	return reflect.DeepEqual(ap, bp), nil
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func TestTagsEqual(t *testing.T) {
+	a := []*s3.Tag{
+		{Key: aws.String("foo"), Value: aws.String("bar")},
+		{Key: aws.String("baz"), Value: aws.String("biz")},
+	}
+
+	b := []*s3.Tag{
+		{Key: aws.String("baz"), Value: aws.String("biz")},
+		{Key: aws.String("foo"), Value: aws.String("bar")},
+	}
+
+	if !tagsEqual(a, b) {
+		t.Errorf("expected %+v to equal %+v", a, b)
+	}
+
+	c := []*s3.Tag{
+		{Key: aws.String("foo"), Value: aws.String("bar")},
+		{Key: aws.String("baz"), Value: aws.String("buz")},
+	}
+
+	if tagsEqual(a, c) {
+		t.Errorf("expected %+v not to equal %+v", a, c)
+	}
+
+	if tagsEqual(a, a[:1]) {
+		t.Errorf("expected %+v not to equal %+v", a, a[:1])
+	}
+}
+
+func TestPoliciesEqual(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+		err      bool
+	}{
+		{"", "", true, false},
+		{"", `{"Version":"2012-10-17"}`, false, false},
+		{`{"Version":"2012-10-17","Statement":[]}`, `{ "Statement": [], "Version": "2012-10-17" }`, true, false},
+		{`{"Version":"2012-10-17","Statement":[]}`, `{"Version":"2008-10-17","Statement":[]}`, false, false},
+		{`{"Version":"2012-10-17"}`, `{not json`, false, true},
+	}
+
+	for _, test := range tests {
+		out, err := policiesEqual(test.a, test.b)
+		if test.err && err == nil {
+			t.Errorf("expected error comparing %s and %s, got nil", test.a, test.b)
+		} else if !test.err && err != nil {
+			t.Errorf("expected nil error comparing %s and %s, got %s", test.a, test.b, err)
+		}
+
+		if out != test.expected {
+			t.Errorf("expected %t comparing %s and %s, got %t", test.expected, test.a, test.b, out)
+		}
+	}
+}
+
+func TestBucketSpecValidate(t *testing.T) {
+	valid := bucketSpec{
+		Encryption: aws.String("aws:kms"),
+		BucketKey:  aws.Bool(true),
+		Lifecycle:  aws.String("deep-archive"),
+		Versioning: aws.String("Enabled"),
+		Groups:     []string{"BktAdmGrp", "BktRWGrp", "BktROGrp"},
+		PolicyBlocks: map[string]bool{
+			"deny-delete":    true,
+			"vpc-restricted": false,
+		},
+	}
+
+	if err := valid.validate(nil); err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	invalid := []bucketSpec{
+		{Encryption: aws.String("rot13")},
+		{Encryption: aws.String("AES256"), BucketKey: aws.Bool(true)},
+		{BucketKey: aws.Bool(false)},
+		{Lifecycle: aws.String("forever")},
+		{Versioning: aws.String("Sometimes")},
+		{Groups: []string{"EveryoneGrp"}},
+		{PolicyBlocks: map[string]bool{"everything": true}},
+		{PolicyBlocks: map[string]bool{"admin": true}},
+	}
+
+	for _, spec := range invalid {
+		if err := spec.validate(nil); err == nil {
+			t.Errorf("expected error validating %+v, got nil", spec)
+		}
+	}
+}
+
+func TestDesiredEncryption(t *testing.T) {
+	s := &server{account: common.Account{BucketKey: true}}
+
+	kms := bucketEncryption{SSEAlgorithm: "aws:kms", KMSMasterKeyID: "alias/foo"}
+	aes := bucketEncryption{SSEAlgorithm: "AES256", BucketKeyEnabled: true}
+
+	tests := []struct {
+		current  bucketEncryption
+		spec     bucketSpec
+		expected bucketEncryption
+	}{
+		// the account default enables the bucket key and the kms key is kept
+		{kms, bucketSpec{Encryption: aws.String("aws:kms")}, bucketEncryption{SSEAlgorithm: "aws:kms", KMSMasterKeyID: "alias/foo", BucketKeyEnabled: true}},
+		// the spec overrides the account default
+		{kms, bucketSpec{Encryption: aws.String("aws:kms"), BucketKey: aws.Bool(false)}, kms},
+		// the kms key isn't carried over from another algorithm
+		{aes, bucketSpec{Encryption: aws.String("aws:kms")}, bucketEncryption{SSEAlgorithm: "aws:kms", BucketKeyEnabled: true}},
+		// the bucket key of an AES256 bucket isn't a change
+		{aes, bucketSpec{Encryption: aws.String("AES256")}, aes},
+		{kms, bucketSpec{Encryption: aws.String("AES256")}, bucketEncryption{SSEAlgorithm: "AES256"}},
+	}
+
+	for _, tt := range tests {
+		if out := s.desiredEncryption(tt.current, &tt.spec); out != tt.expected {
+			t.Errorf("expected %+v for %+v from %+v, got %+v", tt.expected, aws.StringValue(tt.spec.Encryption), tt.current, out)
+		}
+	}
+}
+
+func TestApplySpecChanges(t *testing.T) {
+	applied := []string{}
+	change := func(resource, action string, desired interface{}, err error) *specChange {
+		return &specChange{Resource: resource, Action: action, Desired: desired, apply: func(ctx context.Context) error {
+			applied = append(applied, resource)
+			return err
+		}}
+	}
+
+	status := newMultiStatus()
+	applySpecChanges(context.TODO(), "foobucket", []*specChange{
+		change("versioning", "update", "Enabled", nil),
+		change("policy", "update", "{}", apierror.New(apierror.ErrBadRequest, "invalid policy", nil)),
+		change("group", "create", "foobucket-BktROGrp", nil),
+	}, &status)
+
+	// a failed change doesn't stop the ones after it
+	if !reflect.DeepEqual(applied, []string{"versioning", "policy", "group"}) {
+		t.Errorf("expected all of the changes to be applied, got %v", applied)
+	}
+
+	expected := []resourceStatus{
+		{Type: "versioning", Resource: "foobucket", Action: "update", Code: http.StatusOK},
+		{Type: "policy", Resource: "foobucket", Action: "update", Code: http.StatusBadRequest, Error: "BadRequest: invalid policy ()"},
+		{Type: "group", Resource: "foobucket-BktROGrp", Action: "create", Code: http.StatusOK},
+	}
+	if !reflect.DeepEqual(status.Statuses, expected) || status.Succeeded != 2 || status.Failed != 1 || status.code() != http.StatusMultiStatus {
+		t.Errorf("expected statuses %+v, got %+v", expected, status)
+	}
+
+	// nothing else is applied if the bucket can't be created
+	applied = []string{}
+	status = newMultiStatus()
+	applySpecChanges(context.TODO(), "foobucket", []*specChange{
+		change("bucket", "create", "foobucket", apierror.New(apierror.ErrConflict, "bucket exists", nil)),
+		change("tags", "update", nil, nil),
+	}, &status)
+
+	if !reflect.DeepEqual(applied, []string{"bucket"}) || status.Failed != 1 || len(status.Statuses) != 1 {
+		t.Errorf("expected only the bucket create to be attempted, got %v and %+v", applied, status)
+	}
+}
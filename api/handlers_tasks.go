@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/task"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// TaskListHandler lists the running and recently finished background tasks for an account
+func (s *server) TaskListHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+
+	tasks := []*task.Task{}
+	for _, t := range s.tasks.List() {
+		if s.sameAccount(t.Account, vars["account"]) {
+			tasks = append(tasks, t)
+		}
+	}
+
+	j, err := json.Marshal(tasks)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", tasks, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// TaskShowHandler returns the status and progress of a background task
+func (s *server) TaskShowHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+
+	t, err := s.tasks.Get(vars["id"])
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if !s.sameAccount(t.Account, vars["account"]) {
+		handleError(w, apierror.New(apierror.ErrNotFound, "task not found", nil))
+		return
+	}
+
+	writeTask(w, http.StatusOK, t)
+}
+
+// writeTask writes the state of a task to the response with the status code
+func writeTask(w http.ResponseWriter, status int, t *task.Task) {
+	j, err := json.Marshal(t)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", t, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(j)
+}
@@ -1,11 +1,20 @@
 package api
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/circuit"
 	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/route53"
+	pkgerrors "github.com/pkg/errors"
 )
 
 func TestPingHandler(t *testing.T) {
@@ -60,3 +69,103 @@ func TestVersionHandler(t *testing.T) {
 			rr.Body.String(), expected)
 	}
 }
+
+func TestHandleError(t *testing.T) {
+	notFound := apierror.New(apierror.ErrNotFound, "bucket not found", errors.New("NoSuchBucket"))
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		body   string
+	}{
+		{"apierror", notFound, http.StatusNotFound, "bucket not found"},
+		{"pkg/errors wrapped", pkgerrors.Wrap(notFound, "failed to get bucket"), http.StatusNotFound, "bucket not found"},
+		{"fmt wrapped", fmt.Errorf("failed to get bucket: %w", notFound), http.StatusNotFound, "bucket not found"},
+		{"plain error", errors.New("boom"), http.StatusInternalServerError, "boom"},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		handleError(w, test.err)
+		if w.Code != test.status || w.Body.String() != test.body {
+			t.Errorf("%s: expected %d %q, got %d %q", test.name, test.status, test.body, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestHandleErrorRetryable(t *testing.T) {
+	slowDown := awserr.NewRequestFailure(awserr.New("SlowDown", "Please reduce your request rate.", nil), http.StatusServiceUnavailable, "AWSREQ1")
+	priorRequest := awserr.NewRequestFailure(awserr.New(route53.ErrCodePriorRequestNotComplete, "The request was rejected", nil), http.StatusBadRequest, "AWSREQ2")
+	tooMany := awserr.NewRequestFailure(awserr.New("SomethingNew", "Too many", nil), http.StatusTooManyRequests, "AWSREQ3")
+
+	tests := []struct {
+		name       string
+		err        error
+		status     int
+		retryAfter string
+		body       errorBody
+	}{
+		{
+			name:       "s3 slow down",
+			err:        apierror.New(apierror.ErrLimitExceeded, "failed to get bucket", slowDown),
+			status:     http.StatusTooManyRequests,
+			retryAfter: "5",
+			body:       errorBody{Code: apierror.ErrLimitExceeded, Message: "failed to get bucket", RequestId: "abc-123", Retryable: true},
+		},
+		{
+			name:       "route53 prior request not complete",
+			err:        fmt.Errorf("failed to update record: %w", apierror.New(apierror.ErrBadRequest, "failed to update record", priorRequest)),
+			status:     http.StatusTooManyRequests,
+			retryAfter: "5",
+			body:       errorBody{Code: apierror.ErrLimitExceeded, Message: "failed to update record", RequestId: "abc-123", Retryable: true},
+		},
+		{
+			name:       "too many requests status",
+			err:        apierror.New(apierror.ErrLimitExceeded, "failed", tooMany),
+			status:     http.StatusTooManyRequests,
+			retryAfter: "5",
+			body:       errorBody{Code: apierror.ErrLimitExceeded, Message: "failed", RequestId: "abc-123", Retryable: true},
+		},
+		{
+			name:   "quota",
+			err:    apierror.New(apierror.ErrLimitExceeded, "the IAM users quota would be exceeded", nil),
+			status: http.StatusTooManyRequests,
+			body:   errorBody{Code: apierror.ErrLimitExceeded, Message: "the IAM users quota would be exceeded", RequestId: "abc-123"},
+		},
+		{
+			name:       "open circuit breaker",
+			err:        &circuit.OpenError{Service: "iam", RetryAfter: 1500 * time.Millisecond},
+			status:     http.StatusServiceUnavailable,
+			retryAfter: "2",
+			body:       errorBody{Code: apierror.ErrServiceUnavailable, Message: (&circuit.OpenError{Service: "iam", RetryAfter: 1500 * time.Millisecond}).Error(), RequestId: "abc-123", Retryable: true},
+		},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		w.Header().Set("X-Request-Id", "abc-123")
+		handleError(w, test.err)
+
+		if w.Code != test.status {
+			t.Errorf("%s: expected status %d, got %d", test.name, test.status, w.Code)
+		}
+
+		if retryAfter := w.Header().Get("Retry-After"); retryAfter != test.retryAfter {
+			t.Errorf("%s: expected Retry-After %q, got %q", test.name, test.retryAfter, retryAfter)
+		}
+
+		if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("%s: expected json content type, got %q", test.name, ct)
+		}
+
+		body := errorBody{}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: failed to unmarshal error body %q: %s", test.name, w.Body.String(), err)
+		}
+
+		if body != test.body {
+			t.Errorf("%s: expected body %+v, got %+v", test.name, test.body, body)
+		}
+	}
+}
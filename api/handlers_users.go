@@ -5,12 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"strings"
-	"time"
 
 	"github.com/YaleSpinup/apierror"
-	"github.com/YaleSpinup/s3-api/common"
 	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/YaleSpinup/s3-api/retry"
+	"github.com/YaleSpinup/s3-api/rollback"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/YaleSpinup/s3-api/webhook"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awsutil"
 	"github.com/aws/aws-sdk-go/service/iam"
@@ -19,6 +20,35 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// userCreateRequest is the request to create a bucket or website user
+type userCreateRequest struct {
+	User   *iam.CreateUserInput
+	Groups []string
+}
+
+// userCreateResponse is the response from creating a bucket or website user
+type userCreateResponse struct {
+	User *iam.User
+	// AccessKey is the access key created with the user, it's only returned when the user is created in bulk
+	AccessKey *iam.AccessKey     `json:",omitempty"`
+	Delivery  *accessKeyDelivery `json:",omitempty"`
+}
+
+// userKeyRequest is the optional request to reset a user's access keys.  ReplaceInactiveKey makes room for the new
+// key when the user already has the maximum number of access keys, by deleting the inactive key used least recently.
+type userKeyRequest struct {
+	Delivery           *keyDelivery
+	ReplaceInactiveKey bool
+}
+
+// userKeyResponse is the response from resetting a user's access keys.  The SecretAccessKey is removed from the
+// AccessKey if it's delivered some other way, Delivery says where.
+type userKeyResponse struct {
+	DeletedKeyIds []*string
+	AccessKey     *iam.AccessKey
+	Delivery      *accessKeyDelivery `json:",omitempty"`
+}
+
 // UserCreateHandler creates a new user for a bucket
 func (s *server) UserCreateHandler(w http.ResponseWriter, r *http.Request) {
 	w = LogWriter{w}
@@ -26,64 +56,101 @@ func (s *server) UserCreateHandler(w http.ResponseWriter, r *http.Request) {
 	bucket := vars["bucket"]
 	accountId := s.mapAccountNumber(vars["account"])
 
-	role := fmt.Sprintf("arn:aws:iam::%s:role/%s", accountId, s.session.RoleName)
-	policy, err := generatePolicy("iam:*")
-	if err != nil {
-		log.Errorf("cannot generate policy: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
+	var req userCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		msg := fmt.Sprintf("cannot decode body into create user input: %s", err)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+		return
+	}
+
+	f := fieldErrors{}
+	f.user("User", req.User)
+	if len(req.Groups) == 0 {
+		f.add("Groups", "at least one group is required")
+	}
+	f.groups("Groups", req.Groups, bucketUserGroups)
+	if err := f.err(); err != nil {
+		handleError(w, err)
 		return
 	}
 
-	session, err := s.assumeRole(
-		r.Context(),
-		s.session.ExternalID,
-		role,
-		policy,
-	)
+	session, err := s.sessionForScope(r.Context(), accountId, userCreatePolicy, policyScope{Bucket: bucket})
 	if err != nil {
-		log.Errorf("failed to assume role in account: %s", accountId)
-		w.WriteHeader(http.StatusInternalServerError)
+		handleError(w, err)
 		return
 	}
 
 	iamService := iamapi.NewSession(session.Session, s.account)
+	iamService.Cache = s.resourceCache(accountId)
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
 
-	var req struct {
-		User   *iam.CreateUserInput
-		Groups []string
+	// the user and any group policies are tagged with the org and required tags from the bucket
+	bucketTags, err := s3Service.GetBucketTags(r.Context(), bucket)
+	if err != nil {
+		handleError(w, err)
+		return
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		msg := fmt.Sprintf("cannot decode body into create user input: %s", err)
-		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+	tags := iamTags(s.requiredTags.inherited(bucketTags))
+	req.User.Tags = mergeIAMTags(req.User.Tags, tags)
+
+	groupNames := []string{}
+	for _, group := range req.Groups {
+		groupNames = append(groupNames, iamapi.FormatGroupName(bucket, "/", group))
+	}
+
+	if err := s.checkUserQuotas(r.Context(), session.Session, accountId, iamService, groupNames); err != nil {
+		handleError(w, err)
 		return
 	}
 
-	if req.User == nil || req.Groups == nil {
-		handleError(w, apierror.New(apierror.ErrBadRequest, "user and groups input are required", nil))
+	output, _, err := s.createBucketUser(r.Context(), vars["account"], bucket, iamService, req, tags, nil)
+	if err != nil {
+		handleError(w, err)
 		return
 	}
 
-	// setup err var, rollback function list and defer execution
-	// var err error
-	var rollBackTasks []rollbackFunc
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal reasponse(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// createBucketUser creates a user for a bucket, tagged with the passed tags, and adds it to the bucket's management
+// groups.  The groups that don't exist are created.  When keys is set, an access key is created for the user and
+// delivered by it, it's the only time the secret is available.  Everything is rolled back if there's an error.
+func (s *server) createBucketUser(ctx context.Context, account, bucket string, iamService iamapi.IAM, req userCreateRequest, tags []*iam.Tag, keys *keyDeliverer) (output *userCreateResponse, rb *rollback.Rollback, err error) {
+	userName := aws.StringValue(req.User.UserName)
+
+	services := rollbackServices{iam: &iamService}
+	if keys != nil {
+		services.secretsManager = keys.secretsManager
+	}
+
+	// setup rollback and defer execution
+	rb = s.newRollback("user.create", account, userName, services)
 	defer func() {
+		s.finishRollback(rb, err)
 		if err != nil {
-			log.Errorf("recovering from error: %s, executing %d rollback tasks", err, len(rollBackTasks))
-			rollBack(&rollBackTasks)
+			s.notify(webhook.EventUserRolledBack, account, userName, map[string]string{"Bucket": bucket, "Error": err.Error(), "Rollback": rb.ID})
 		}
 	}()
 
-	userOutput, err := iamService.CreateUser(r.Context(), req.User)
+	userOutput, err := iamService.CreateUser(ctx, req.User)
 	if err != nil {
 		msg := fmt.Sprintf("failed to create user for bucket %s: %s", bucket, err)
-		handleError(w, errors.Wrap(err, msg))
-		return
+		return nil, rb, errors.Wrap(err, msg)
 	}
 
 	// wait for the user to exist
-	if err = retry(3, 2*time.Second, func() error {
+	if err = retry.Do(ctx, iamPropagationRetry, func(ctx context.Context) error {
 		log.Infof("checking if user exists before continuing: %s", aws.StringValue(userOutput.User.UserName))
-		out, err := iamService.GetUser(r.Context(), &iam.GetUserInput{
+		out, err := iamService.GetUser(ctx, &iam.GetUserInput{
 			UserName: userOutput.User.UserName,
 		})
 		if err != nil {
@@ -93,79 +160,66 @@ func (s *server) UserCreateHandler(w http.ResponseWriter, r *http.Request) {
 		log.Debugf("got user output: %s", awsutil.Prettify(out))
 		return nil
 	}); err != nil {
-		msg := fmt.Sprintf("failed to create user %s for bucket %s: timeout waiting for create %s", aws.StringValue(req.User.UserName), bucket, err)
-		handleError(w, errors.Wrap(err, msg))
-		return
+		msg := fmt.Sprintf("failed to create user %s for bucket %s: timeout waiting for create %s", userName, bucket, err)
+		return nil, rb, errors.Wrap(err, msg)
 	}
 
-	// append user delete to rollback tasks
-	rbfunc := func(ctx context.Context) error {
-		if err := iamService.DeleteUser(ctx, &iam.DeleteUserInput{UserName: req.User.UserName}); err != nil {
-			return err
-		}
-		return nil
-	}
-	rollBackTasks = append(rollBackTasks, rbfunc)
+	// append user delete to rollback
+	rb.Add("delete user "+userName, rollbackDeleteUser, map[string]string{"user": userName})
 
 	for _, group := range req.Groups {
-		groupName := fmt.Sprintf("%s-%s", bucket, group)
-		_, err = iamService.GetGroup(r.Context(), groupName)
-		if err != nil {
-			if aerr, ok := err.(apierror.Error); ok && aerr.Code == apierror.ErrNotFound {
-				var rbTasks []rollbackFunc
-				rbTasks, err = s.CreateBucketGroupPolicy(r.Context(), iamService, bucket, group)
-				if err != nil {
-					handleError(w, err)
-					return
-				}
-				rollBackTasks = append(rollBackTasks, rbTasks...)
-			} else {
-				handleError(w, err)
-				return
+		groupName := iamapi.FormatGroupName(bucket, "/", group)
+		if _, err = iamService.GetGroup(ctx, groupName); err != nil {
+			if !isNotFound(err) {
+				return nil, rb, err
 			}
+
+			var steps []*rollback.Step
+			if steps, err = s.CreateBucketGroupPolicy(ctx, iamService, bucket, group, tags); err != nil {
+				return nil, rb, err
+			}
+			rb.Append(steps...)
 		}
 
-		if err = iamService.AddUserToGroup(r.Context(), &iam.AddUserToGroupInput{
+		if err = iamService.AddUserToGroup(ctx, &iam.AddUserToGroupInput{
 			UserName:  userOutput.User.UserName,
 			GroupName: aws.String(groupName),
 		}); err != nil {
-			msg := fmt.Sprintf("failed to add user: %s to group %s for bucket %s", aws.StringValue(userOutput.User.UserName), group, bucket)
-			handleError(w, errors.Wrap(err, msg))
-			return
+			msg := fmt.Sprintf("failed to add user: %s to group %s for bucket %s", userName, group, bucket)
+			return nil, rb, errors.Wrap(err, msg)
 		}
 
-		// append detach group to rollback funciton
-		rbfunc = func(ctx context.Context) error {
-			if err := iamService.RemoveUserFromGroup(ctx, &iam.RemoveUserFromGroupInput{
-				UserName:  userOutput.User.UserName,
-				GroupName: aws.String(groupName),
-			}); err != nil {
-				return err
-			}
-			return nil
-		}
-		rollBackTasks = append(rollBackTasks, rbfunc)
+		// append remove user from group to rollback
+		rb.Add("remove user "+userName+" from group "+groupName, rollbackRemoveUserFromGroup, map[string]string{"user": userName, "group": groupName})
 	}
 
-	output := struct {
-		User *iam.User
-	}{
-		userOutput.User,
-	}
+	output = &userCreateResponse{User: userOutput.User}
 
-	j, err := json.Marshal(output)
-	if err != nil {
-		log.Errorf("cannot marshal reasponse(%v) into JSON: %s", output, err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
+	if keys != nil {
+		var keyOutput *iam.CreateAccessKeyOutput
+		if keyOutput, err = iamService.CreateAccessKey(ctx, &iam.CreateAccessKeyInput{UserName: userOutput.User.UserName}); err != nil {
+			msg := fmt.Sprintf("failed to create access key for user: %s, bucket %s", userName, bucket)
+			return nil, rb, errors.Wrap(err, msg)
+		}
+
+		// append access key delete to rollback
+		rb.Add("delete access key "+aws.StringValue(keyOutput.AccessKey.AccessKeyId), rollbackDeleteAccessKey, map[string]string{
+			"user":          userName,
+			"access_key_id": aws.StringValue(keyOutput.AccessKey.AccessKeyId),
+		})
+
+		if output.Delivery, err = keys.deliver(ctx, rb, keyOutput.AccessKey, tags); err != nil {
+			return nil, rb, err
+		}
+		output.AccessKey = keyOutput.AccessKey
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(j)
+	s.notify(webhook.EventUserCreated, account, userName, map[string]string{"Bucket": bucket})
+
+	return output, rb, nil
 }
 
-// UserDeleteHandler deletes an iam user and their keys
+// UserDeleteHandler deletes an iam user and everything attached to it
 func (s *server) UserDeleteHandler(w http.ResponseWriter, r *http.Request) {
 	w = LogWriter{w}
 	vars := mux.Vars(r)
@@ -173,89 +227,22 @@ func (s *server) UserDeleteHandler(w http.ResponseWriter, r *http.Request) {
 	user := vars["user"]
 	bucket := vars["bucket"]
 
-	role := fmt.Sprintf("arn:aws:iam::%s:role/%s", accountId, s.session.RoleName)
-	policy, err := generatePolicy("iam:*")
-	if err != nil {
-		log.Errorf("cannot generate policy: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	session, err := s.assumeRole(
-		r.Context(),
-		s.session.ExternalID,
-		role,
-		policy,
-	)
-	if err != nil {
-		log.Errorf("failed to assume role in account: %s", accountId)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	iamService := iamapi.NewSession(session.Session, s.account)
-
-	// get a users access keys
-	keys, err := iamService.ListAccessKeys(r.Context(), &iam.ListAccessKeysInput{UserName: aws.String(user)})
+	session, err := s.sessionForScope(r.Context(), accountId, userDeletePolicy, policyScope{Bucket: bucket})
 	if err != nil {
 		handleError(w, err)
 		return
 	}
 
-	// delete the access keys
-	for _, k := range keys {
-		err = iamService.DeleteAccessKey(r.Context(), &iam.DeleteAccessKeyInput{UserName: aws.String(user), AccessKeyId: k.AccessKeyId})
-		if err != nil {
-			handleError(w, err)
-			return
-		}
-	}
-
-	// get the list of groups that the user belongs to
-	groups, err := iamService.ListUserGroups(r.Context(), &iam.ListGroupsForUserInput{UserName: aws.String(user)})
-	if err != nil {
-		handleError(w, err)
-		return
-	}
-
-	// remove the user from all group membership
-	for _, g := range groups {
-		err = iamService.RemoveUserFromGroup(r.Context(), &iam.RemoveUserFromGroupInput{UserName: aws.String(user), GroupName: g.GroupName})
-		if err != nil {
-			handleError(w, err)
-			return
-		}
-	}
+	iamService := iamapi.NewSession(session.Session, s.account)
+	iamService.Cache = s.resourceCache(accountId)
 
-	// get a list of all of the attached user policies for a user.  this should be empty for "new" s3 buckets, but buckets created
-	// with the legacy service have the policy directly attached to the user with the same name as the user/bucket
-	policies, err := iamService.ListUserPolicies(r.Context(), &iam.ListAttachedUserPoliciesInput{UserName: aws.String(user)})
-	if err != nil {
+	// remove everything attached to the user (keys, policies, groups, login profile, mfa devices, etc) and delete it
+	if err := iamService.DeleteUserAndAttachments(r.Context(), user); err != nil {
 		handleError(w, err)
 		return
 	}
 
-	// detatch and delete all of the policies that we found if the name is the same as the bucket or if the name starts
-	// with the the name of the bucket
-	for _, p := range policies {
-		pname := aws.StringValue(p.PolicyName)
-		if strings.HasPrefix(pname, bucket+"-") || pname == bucket {
-			if err := iamService.DetachUserPolicy(r.Context(), &iam.DetachUserPolicyInput{
-				UserName:  aws.String(user),
-				PolicyArn: p.PolicyArn,
-			}); err != nil {
-				j, _ := json.Marshal("failed to detatch user policy: " + err.Error())
-				w.Write(j)
-				return
-			}
-		}
-	}
-
-	err = iamService.DeleteUser(r.Context(), &iam.DeleteUserInput{UserName: aws.String(user)})
-	if err != nil {
-		handleError(w, err)
-		return
-	}
+	s.notify(webhook.EventUserDeleted, vars["account"], user, map[string]string{"Bucket": bucket})
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -269,27 +256,36 @@ func (s *server) UserUpdateKeyHandler(w http.ResponseWriter, r *http.Request) {
 	bucket := vars["bucket"]
 	user := vars["user"]
 
-	role := fmt.Sprintf("arn:aws:iam::%s:role/%s", accountId, s.session.RoleName)
-	policy, err := generatePolicy("iam:*")
-	if err != nil {
-		log.Errorf("cannot generate policy: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
+	var req userKeyRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			msg := fmt.Sprintf("cannot decode body into update key input: %s", err)
+			handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+			return
+		}
+	}
+
+	f := fieldErrors{}
+	f.keyDelivery("Delivery", req.Delivery, s.account.CredentialSecrets)
+	if err := f.err(); err != nil {
+		handleError(w, err)
 		return
 	}
 
-	session, err := s.assumeRole(
-		r.Context(),
-		s.session.ExternalID,
-		role,
-		policy,
-	)
+	session, err := s.sessionForScope(r.Context(), accountId, s.withKeyDelivery(userKeyPolicy, req.Delivery), policyScope{Bucket: bucket})
 	if err != nil {
-		log.Errorf("failed to assume role in account: %s", accountId)
-		w.WriteHeader(http.StatusInternalServerError)
+		handleError(w, err)
 		return
 	}
 
 	iamService := iamapi.NewSession(session.Session, s.account)
+	iamService.Cache = s.resourceCache(accountId)
+
+	deliverer, err := s.newKeyDeliverer(session.Session, req.Delivery)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
 
 	// get a list of users access keys
 	keys, kerr := iamService.ListAccessKeys(r.Context(), &iam.ListAccessKeysInput{UserName: aws.String(user)})
@@ -298,37 +294,42 @@ func (s *server) UserUpdateKeyHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// setup err var, rollback function list and defer execution
-	var rollBackTasks []rollbackFunc
+	// setup rollback and defer execution
+	rb := s.newRollback("user.update_key", vars["account"], user, rollbackServices{iam: &iamService, secretsManager: deliverer.secretsManager})
 	defer func() {
-		if err != nil {
-			log.Errorf("recovering from error: %s, executing %d rollback tasks", err, len(rollBackTasks))
-			rollBack(&rollBackTasks)
-		}
+		s.finishRollback(rb, err)
 	}()
 
-	newKeyOutput, err := iamService.CreateAccessKey(r.Context(), &iam.CreateAccessKeyInput{UserName: aws.String(user)})
+	newKeyOutput, replacedKeyId, err := createAccessKey(r.Context(), iamService, user, req.ReplaceInactiveKey)
 	if err != nil {
 		msg := fmt.Sprintf("failed to create access key for user: %s, bucket %s", user, bucket)
 		handleError(w, errors.Wrap(err, msg))
 		return
 	}
 
-	// append access key delete to rollback tasks
-	rbfunc := func(ctx context.Context) error {
-		if err := iamService.DeleteAccessKey(ctx, &iam.DeleteAccessKeyInput{
-			UserName:    newKeyOutput.AccessKey.UserName,
-			AccessKeyId: newKeyOutput.AccessKey.AccessKeyId,
-		}); err != nil {
-			return err
-		}
-		return nil
+	// append access key delete to rollback
+	rb.Add("delete access key "+aws.StringValue(newKeyOutput.AccessKey.AccessKeyId), rollbackDeleteAccessKey, map[string]string{
+		"user":          aws.StringValue(newKeyOutput.AccessKey.UserName),
+		"access_key_id": aws.StringValue(newKeyOutput.AccessKey.AccessKeyId),
+	})
+
+	delivery, err := deliverer.deliver(r.Context(), rb, newKeyOutput.AccessKey, nil)
+	if err != nil {
+		handleError(w, err)
+		return
 	}
-	rollBackTasks = append(rollBackTasks, rbfunc)
 
 	deletedKeyIds := []*string{}
+	if replacedKeyId != nil {
+		deletedKeyIds = append(deletedKeyIds, replacedKeyId)
+	}
+
 	// delete the old access keys
 	for _, k := range keys {
+		if aws.StringValue(k.AccessKeyId) == aws.StringValue(replacedKeyId) {
+			continue
+		}
+
 		err = iamService.DeleteAccessKey(r.Context(), &iam.DeleteAccessKeyInput{UserName: aws.String(user), AccessKeyId: k.AccessKeyId})
 		if err != nil {
 			msg := fmt.Sprintf("unable to delete access key id %s for user %s", user, aws.StringValue(k.AccessKeyId))
@@ -338,12 +339,10 @@ func (s *server) UserUpdateKeyHandler(w http.ResponseWriter, r *http.Request) {
 		deletedKeyIds = append(deletedKeyIds, k.AccessKeyId)
 	}
 
-	var output = struct {
-		DeletedKeyIds []*string
-		AccessKey     *iam.AccessKey
-	}{
+	var output = userKeyResponse{
 		deletedKeyIds,
 		newKeyOutput.AccessKey,
+		delivery,
 	}
 
 	j, err := json.Marshal(output)
@@ -367,38 +366,83 @@ func (s *server) UserListHandler(w http.ResponseWriter, r *http.Request) {
 	bucket := vars["bucket"]
 	accountId := s.mapAccountNumber(vars["account"])
 
-	role := fmt.Sprintf("arn:aws:iam::%s:role/%s", accountId, s.session.RoleName)
-	policy, err := generatePolicy("s3:Get*", "iam:*")
+	session, err := s.sessionForAccount(r.Context(), accountId, "s3:Get*", "iam:*")
 	if err != nil {
-		log.Errorf("cannot generate policy: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		handleError(w, err)
+		return
+	}
+
+	iamService := iamapi.NewSession(session.Session, s.account)
+	iamService.Cache = s.resourceCache(accountId)
+
+	// TODO check if bucket exists and fail if it doesn't?
+	users := listBucketUsers(r.Context(), iamService, bucket)
+
+	writeConditionalJSON(w, r, users)
+}
+
+// UserShowHandler gets and returns details of a bucket user.  This is accomplished by getting all of the
+// users for a bucket's management groups and then comparing that to the passed user.  This would be more
+// efficient if we just GetUser for the passed in user, but then we can't be sure it's associated with the
+// bucket.
+func (s *server) UserShowHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	bucket := vars["bucket"]
+	user := vars["user"]
+
+	session, err := s.sessionForAccount(r.Context(), accountId, "iam:*")
+	if err != nil {
+		handleError(w, err)
 		return
 	}
 
-	session, err := s.assumeRole(
-		r.Context(),
-		s.session.ExternalID,
-		role,
-		policy,
-	)
+	iamService := iamapi.NewSession(session.Session, s.account)
+	iamService.Cache = s.resourceCache(accountId)
+
+	groupNames := []string{}
+	for _, g := range bucketUserGroups {
+		groupNames = append(groupNames, iamapi.FormatGroupName(bucket, "/", g))
+	}
+
+	userDetails, err := getBucketUser(r.Context(), iamService, bucket, user, groupNames)
 	if err != nil {
-		log.Errorf("failed to assume role in account: %s", accountId)
-		w.WriteHeader(http.StatusInternalServerError)
+		handleError(w, err)
 		return
 	}
 
-	iamService := iamapi.NewSession(session.Session, common.Account{})
-	// iamService, _ = s.iamServices[vars["account"]]
+	if userDetails != nil {
+		j, err := json.Marshal(userDetails)
+		if err != nil {
+			log.Errorf("cannot marshal reasponse(%v) into JSON: %s", userDetails.User, err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
 
-	// TODO check if bucket exists and fail if it doesn't?
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(j)
+
+		return
+	}
+
+	log.Warnf("requested user %s does not exist or does not belong to bucket %s", user, bucket)
+	w.WriteHeader(http.StatusNotFound)
+	w.Write([]byte{})
+}
+
+// listBucketUsers lists the users in the management groups for a bucket and the legacy user with the same
+// name as the bucket, if it exists
+func listBucketUsers(ctx context.Context, iamService iamapi.IAM, bucket string) []*iam.User {
 	users := []*iam.User{}
-	foundGroups, err := iamService.ListGroups(r.Context(), &iam.ListGroupsInput{}, bucket)
+	foundGroups, err := iamService.ListGroups(ctx, &iam.ListGroupsInput{}, bucket)
 	if err != nil {
 		log.Errorf("there was an error listing groups %s", err)
 	}
 
 	for _, foundGroup := range foundGroups {
-		u, err := iamService.ListGroupUsers(r.Context(), &iam.GetGroupInput{GroupName: foundGroup.GroupName})
+		u, err := iamService.ListGroupUsers(ctx, &iam.GetGroupInput{GroupName: foundGroup.GroupName})
 		if err != nil {
 			log.Warnf("error listing bucket %s group %s users %s", bucket, aws.StringValue(foundGroup.GroupName), err)
 			continue
@@ -407,65 +451,34 @@ func (s *server) UserListHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// check if there is a user with the same name as the bucket to support legacy buckets
-	user, err := iamService.GetUser(r.Context(), &iam.GetUserInput{UserName: aws.String(bucket)})
-	fmt.Println(err)
-	if err == nil {
+	if user, err := iamService.GetUser(ctx, &iam.GetUserInput{UserName: aws.String(bucket)}); err == nil {
 		users = append(users, user.User)
 	}
 
 	// remove potential duplicate users
-	users = iamapi.FilterDuplicateUsers(users)
-
-	j, err := json.Marshal(users)
-	if err != nil {
-		log.Errorf("cannot marshal reasponse(%v) into JSON: %s", users, err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(j)
+	return iamapi.FilterDuplicateUsers(users)
 }
 
-// UserShowHandler gets and returns details of a bucket user.  This is accomplished by getting all of the
-// users for a bucket's management groups and then comparing that to the passed user.  This would be more
-// efficient if we just GetUser for the passed in user, but then we can't be sure it's associated with the
-// bucket.
-func (s *server) UserShowHandler(w http.ResponseWriter, r *http.Request) {
-	w = LogWriter{w}
-	vars := mux.Vars(r)
-	accountId := s.mapAccountNumber(vars["account"])
-	bucket := vars["bucket"]
-	user := vars["user"]
-
-	role := fmt.Sprintf("arn:aws:iam::%s:role/%s", accountId, s.session.RoleName)
-	policy, err := generatePolicy("iam:*")
-	if err != nil {
-		log.Errorf("cannot generate policy: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	session, err := s.assumeRole(
-		r.Context(),
-		s.session.ExternalID,
-		role,
-		policy,
-	)
-	if err != nil {
-		log.Errorf("failed to assume role in account: %s", accountId)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	iamService := iamapi.NewSession(session.Session, s.account)
+// userShowOutput is the response from getting a bucket user
+type userShowOutput struct {
+	// Bucket is the bucket the user belongs to and PathPrefix is the IAM path prefix, they're used to find the
+	// path and type of the user's groups
+	Bucket     string `json:"-"`
+	PathPrefix string `json:"-"`
+	User       *iam.User
+	AccessKeys []*iam.AccessKeyMetadata
+	Groups     []*iam.Group
+	Policies   []*iam.AttachedPolicy
+}
 
+// getBucketUser gets the details of a user that belongs to one of the passed management groups for a bucket,
+// or is the legacy user with the same name as the bucket.  A nil output is returned if the user doesn't
+// belong to the bucket.
+func getBucketUser(ctx context.Context, iamService iamapi.IAM, bucket, user string, groupNames []string) (*userShowOutput, error) {
 	// collect the list of users in the various management groups
 	users := []*iam.User{}
-	for _, g := range []string{"BktAdmGrp", "BktRWGrp", "BktROGrp"} {
-		groupName := fmt.Sprintf("%s-%s", bucket, g)
-		grpUsers, err := iamService.ListGroupUsers(r.Context(), &iam.GetGroupInput{GroupName: aws.String(groupName)})
+	for _, groupName := range groupNames {
+		grpUsers, err := iamService.ListGroupUsers(ctx, &iam.GetGroupInput{GroupName: aws.String(groupName)})
 		if err != nil {
 			log.Warnf("error getting users for the %s goup", groupName)
 			continue
@@ -475,60 +488,39 @@ func (s *server) UserShowHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// check if there is a user with the same name as the bucket to support legacy buckets
-	u, err := iamService.GetUser(r.Context(), &iam.GetUserInput{UserName: aws.String(bucket)})
+	u, err := iamService.GetUser(ctx, &iam.GetUserInput{UserName: aws.String(bucket)})
 	if err == nil {
 		users = append(users, u.User)
 	}
 
 	// range over all of the users we found and return the user if it matches the requested user
 	for _, u := range users {
-		if aws.StringValue(u.UserName) == user {
-			var userDetails = struct {
-				User       *iam.User
-				AccessKeys []*iam.AccessKeyMetadata
-				Groups     []*iam.Group
-				Policies   []*iam.AttachedPolicy
-			}{
-				User: u,
-			}
-
-			keys, err := iamService.ListAccessKeys(r.Context(), &iam.ListAccessKeysInput{UserName: aws.String(user)})
-			if err != nil {
-				handleError(w, err)
-				return
-			}
-			userDetails.AccessKeys = keys
-
-			groups, err := iamService.ListUserGroups(r.Context(), &iam.ListGroupsForUserInput{UserName: aws.String(user)})
-			if err != nil {
-				handleError(w, err)
-				return
-			}
-			userDetails.Groups = groups
+		if aws.StringValue(u.UserName) != user {
+			continue
+		}
 
-			policies, err := iamService.ListUserPolicies(r.Context(), &iam.ListAttachedUserPoliciesInput{UserName: aws.String(user)})
-			if err != nil {
-				handleError(w, err)
-				return
-			}
-			userDetails.Policies = policies
+		output := &userShowOutput{Bucket: bucket, PathPrefix: iamService.PathPrefix, User: u}
 
-			j, err := json.Marshal(userDetails)
-			if err != nil {
-				log.Errorf("cannot marshal reasponse(%v) into JSON: %s", u, err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
+		keys, err := iamService.ListAccessKeys(ctx, &iam.ListAccessKeysInput{UserName: aws.String(user)})
+		if err != nil {
+			return nil, err
+		}
+		output.AccessKeys = keys
 
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusOK)
-			w.Write(j)
+		groups, err := iamService.ListUserGroups(ctx, &iam.ListGroupsForUserInput{UserName: aws.String(user)})
+		if err != nil {
+			return nil, err
+		}
+		output.Groups = groups
 
-			return
+		policies, err := iamService.ListUserPolicies(ctx, &iam.ListAttachedUserPoliciesInput{UserName: aws.String(user)})
+		if err != nil {
+			return nil, err
 		}
+		output.Policies = policies
+
+		return output, nil
 	}
 
-	log.Warnf("requested user %s does not exist or does not belong to bucket %s", user, bucket)
-	w.WriteHeader(http.StatusNotFound)
-	w.Write([]byte{})
+	return nil, nil
 }
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// accountUsersConcurrency is the number of users whose groups, tags and access keys are fetched at the same time
+const accountUsersConcurrency = 10
+
+// accountUserAccessKey is an access key of a user in the account users list
+type accountUserAccessKey struct {
+	AccessKeyId string
+	Status      string
+	CreatedAt   time.Time
+	// AgeDays is the number of whole days since the key was created
+	AgeDays int
+}
+
+// accountUser is a user managed by the api in the account users list
+type accountUser struct {
+	UserName   string
+	Path       string
+	Arn        string
+	CreatedAt  time.Time
+	Groups     []string
+	AccessKeys []accountUserAccessKey
+}
+
+// AccountUserListHandler lists the IAM users the api manages in an account along with their groups and the ages of
+// their access keys.  When an IAM path is configured all of the users under it are listed, otherwise the users in any
+// bucket or website management group or tagged with the org are.  The list can be limited to the users with an IAM
+// path with the `path` query parameter.
+func (s *server) AccountUserListHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	path := r.URL.Query().Get("path")
+
+	f := fieldErrors{}
+	if path != "" && !iamPathRe.MatchString(path) {
+		f.add("path", "path %q must begin and end with /", path)
+	}
+
+	if err := f.err(); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	iamService, err := s.iamServiceForAccount(r.Context(), vars["account"])
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	output, err := listAccountUsers(r.Context(), iamService, path, time.Now())
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// listAccountUsers lists the users the api manages with their groups and access keys, sorted by name.  The users
+// under the path, or the configured path prefix, are all managed by the api.  Otherwise the users are only managed
+// if they're in a management group or tagged with the org.  Users deleted while they're listed are skipped.
+func listAccountUsers(ctx context.Context, iamService iamapi.IAM, path string, now time.Time) ([]accountUser, error) {
+	input := &iam.ListUsersInput{}
+	if path != "" {
+		input.PathPrefix = aws.String(path)
+	}
+	scoped := path != "" || iamService.PathPrefix != ""
+
+	users, err := iamService.ListUsers(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*accountUser, len(users))
+
+	g, gctx := newErrGroup(ctx)
+	sem := make(chan struct{}, accountUsersConcurrency)
+	for i, u := range users {
+		i, u := i, u
+		g.Go(func() error {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			user, err := accountUserDetails(gctx, iamService, u, scoped, now)
+			if err != nil {
+				if isNotFound(err) {
+					log.Warnf("iam user %s was deleted while listing users", aws.StringValue(u.UserName))
+					return nil
+				}
+				return err
+			}
+
+			results[i] = user
+
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	output := []accountUser{}
+	for _, u := range results {
+		if u != nil {
+			output = append(output, *u)
+		}
+	}
+
+	sort.Slice(output, func(i, j int) bool {
+		return output[i].UserName < output[j].UserName
+	})
+
+	return output, nil
+}
+
+// accountUserDetails gets the groups and access keys of a user, it returns nil if the user isn't managed by the api
+func accountUserDetails(ctx context.Context, iamService iamapi.IAM, u *iam.User, managed bool, now time.Time) (*accountUser, error) {
+	groups, err := iamService.ListUserGroups(ctx, &iam.ListGroupsForUserInput{UserName: u.UserName})
+	if err != nil {
+		return nil, err
+	}
+
+	groupNames := []string{}
+	for _, g := range groups {
+		groupName := aws.StringValue(g.GroupName)
+		if isManagementGroup(groupName) {
+			managed = true
+		}
+		groupNames = append(groupNames, groupName)
+	}
+	sort.Strings(groupNames)
+
+	if !managed {
+		tags, err := iamService.ListUserTags(ctx, aws.StringValue(u.UserName))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range tags {
+			if aws.StringValue(t.Key) == "spinup:org" && aws.StringValue(t.Value) == Org {
+				managed = true
+			}
+		}
+	}
+
+	if !managed {
+		return nil, nil
+	}
+
+	keys, err := iamService.ListAccessKeys(ctx, &iam.ListAccessKeysInput{UserName: u.UserName})
+	if err != nil {
+		return nil, err
+	}
+
+	accessKeys := []accountUserAccessKey{}
+	for _, k := range keys {
+		created := aws.TimeValue(k.CreateDate)
+		accessKeys = append(accessKeys, accountUserAccessKey{
+			AccessKeyId: aws.StringValue(k.AccessKeyId),
+			Status:      aws.StringValue(k.Status),
+			CreatedAt:   created,
+			AgeDays:     int(now.Sub(created).Hours() / 24),
+		})
+	}
+
+	return &accountUser{
+		UserName:   aws.StringValue(u.UserName),
+		Path:       aws.StringValue(u.Path),
+		Arn:        aws.StringValue(u.Arn),
+		CreatedAt:  aws.TimeValue(u.CreateDate),
+		Groups:     groupNames,
+		AccessKeys: accessKeys,
+	}, nil
+}
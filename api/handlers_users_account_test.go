@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/gorilla/mux"
+)
+
+// mockAccountUsersIAM keeps the groups, tags and access keys of the users by name and records the users whose tags
+// were listed
+type mockAccountUsersIAM struct {
+	iamiface.IAMAPI
+	users  []*iam.User
+	groups map[string][]string
+	tags   map[string]map[string]string
+	keys   map[string][]*iam.AccessKeyMetadata
+	tagged []string
+	mu     sync.Mutex
+}
+
+func (m *mockAccountUsersIAM) ListUsersWithContext(ctx context.Context, input *iam.ListUsersInput, opts ...request.Option) (*iam.ListUsersOutput, error) {
+	return &iam.ListUsersOutput{Users: m.users}, nil
+}
+
+func (m *mockAccountUsersIAM) ListGroupsForUserWithContext(ctx context.Context, input *iam.ListGroupsForUserInput, opts ...request.Option) (*iam.ListGroupsForUserOutput, error) {
+	groups, ok := m.groups[aws.StringValue(input.UserName)]
+	if !ok {
+		return nil, awserr.New(iam.ErrCodeNoSuchEntityException, "user not found", nil)
+	}
+
+	out := []*iam.Group{}
+	for _, g := range groups {
+		out = append(out, &iam.Group{GroupName: aws.String(g)})
+	}
+	return &iam.ListGroupsForUserOutput{Groups: out}, nil
+}
+
+func (m *mockAccountUsersIAM) ListUserTagsWithContext(ctx context.Context, input *iam.ListUserTagsInput, opts ...request.Option) (*iam.ListUserTagsOutput, error) {
+	m.mu.Lock()
+	m.tagged = append(m.tagged, aws.StringValue(input.UserName))
+	m.mu.Unlock()
+
+	tags := []*iam.Tag{}
+	for k, v := range m.tags[aws.StringValue(input.UserName)] {
+		tags = append(tags, &iam.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	return &iam.ListUserTagsOutput{Tags: tags}, nil
+}
+
+func (m *mockAccountUsersIAM) ListAccessKeysWithContext(ctx context.Context, input *iam.ListAccessKeysInput, opts ...request.Option) (*iam.ListAccessKeysOutput, error) {
+	return &iam.ListAccessKeysOutput{AccessKeyMetadata: m.keys[aws.StringValue(input.UserName)]}, nil
+}
+
+func TestListAccountUsers(t *testing.T) {
+	Org = "test"
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	newMock := func() *mockAccountUsersIAM {
+		return &mockAccountUsersIAM{
+			users: []*iam.User{
+				{UserName: aws.String("foo-user"), Path: aws.String("/")},
+				{UserName: aws.String("admin")},
+				{UserName: aws.String("bar-user"), Path: aws.String("/")},
+				{UserName: aws.String("other-org-user")},
+				{UserName: aws.String("deleted-user")},
+			},
+			groups: map[string][]string{
+				"foo-user":       {"foo-BktRWGrp", "foo-BktAdmGrp"},
+				"admin":          {"Administrators"},
+				"bar-user":       {},
+				"other-org-user": {},
+			},
+			tags: map[string]map[string]string{
+				"bar-user":       {"spinup:org": "test"},
+				"other-org-user": {"spinup:org": "other"},
+			},
+			keys: map[string][]*iam.AccessKeyMetadata{
+				"foo-user": {
+					{AccessKeyId: aws.String("AKIA1"), Status: aws.String("Active"), CreateDate: aws.Time(now.Add(-100*24*time.Hour - time.Hour))},
+				},
+			},
+		}
+	}
+
+	m := newMock()
+	out, err := listAccountUsers(context.TODO(), iamapi.IAM{Service: m}, "", now)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	expected := []accountUser{
+		{UserName: "bar-user", Path: "/", Groups: []string{}, AccessKeys: []accountUserAccessKey{}},
+		{
+			UserName: "foo-user",
+			Path:     "/",
+			Groups:   []string{"foo-BktAdmGrp", "foo-BktRWGrp"},
+			AccessKeys: []accountUserAccessKey{
+				{AccessKeyId: "AKIA1", Status: "Active", CreatedAt: now.Add(-100*24*time.Hour - time.Hour), AgeDays: 100},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(expected, out) {
+		t.Errorf("expected %+v, got %+v", expected, out)
+	}
+
+	for _, u := range m.tagged {
+		if u == "foo-user" {
+			t.Error("expected the tags of a management group member not to be listed")
+		}
+	}
+
+	// all of the users under a path are managed, without checking their tags
+	m = newMock()
+	out, err = listAccountUsers(context.TODO(), iamapi.IAM{Service: m}, "/spinup/", now)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if len(out) != 4 || len(m.tagged) != 0 {
+		t.Errorf("expected 4 users without listing tags, got %d users and tags listed for %v", len(out), m.tagged)
+	}
+}
+
+func TestAccountUserListHandlerValidation(t *testing.T) {
+	s := server{}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/s3/foo/users?path=spinup", nil)
+	req = mux.SetURLVars(req, map[string]string{"account": "foo"})
+	w := httptest.NewRecorder()
+
+	s.AccountUserListHandler(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,288 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// bulkUserMaxItems is the maximum number of users that can be created in one bulk request
+	bulkUserMaxItems = 100
+	// bulkUserConcurrency is the number of users in a bulk request that are created at the same time
+	bulkUserConcurrency = 5
+)
+
+// userBulkCreateRequest is the request to create users for a bucket in bulk.  Group is the group the users are added
+// to unless the user sets its own, Delivery is how their access keys are delivered.
+type userBulkCreateRequest struct {
+	Group    string
+	Users    []userBulkCreateItem
+	Delivery *keyDelivery `json:",omitempty"`
+}
+
+// userBulkCreateItem is an individual user in a bulk request
+type userBulkCreateItem struct {
+	UserName string
+	Group    string `json:",omitempty"`
+}
+
+// userBulkCreateResult is the result of creating an individual user in a bulk request.  The access key is only
+// returned in this response, its secret can't be retrieved again unless it's delivered to secrets manager.
+type userBulkCreateResult struct {
+	UserName string
+	Group    string
+	Status   string
+	// Code is the http status code creating the user on its own would have returned
+	Code  int
+	Error string `json:",omitempty"`
+	// Rollback is the id of the rollback executed after a failure and RollbackStatus is its status, either
+	// rolled_back if everything that was created was cleaned up or failed if some of it was left behind
+	Rollback       string             `json:",omitempty"`
+	RollbackStatus string             `json:",omitempty"`
+	User           *iam.User          `json:",omitempty"`
+	AccessKey      *iam.AccessKey     `json:",omitempty"`
+	Delivery       *accessKeyDelivery `json:",omitempty"`
+}
+
+// userBulkCreateOutput is the response from creating users in bulk
+type userBulkCreateOutput struct {
+	Created int
+	Failed  int
+	Results []userBulkCreateResult
+}
+
+// validate sets the default group of the users and validates the request
+func (req *userBulkCreateRequest) validate(credentialSecrets *common.CredentialSecrets) error {
+	f := fieldErrors{}
+	f.keyDelivery("Delivery", req.Delivery, credentialSecrets)
+	if len(req.Users) == 0 || len(req.Users) > bulkUserMaxItems {
+		f.add("Users", "between 1 and %d users can be created in one request, got %d", bulkUserMaxItems, len(req.Users))
+	}
+
+	seen := map[string]bool{}
+	for i := range req.Users {
+		u := &req.Users[i]
+		field := fmt.Sprintf("Users[%d]", i)
+
+		if u.Group == "" {
+			u.Group = req.Group
+		}
+
+		f.user(field, &iam.CreateUserInput{UserName: aws.String(u.UserName)})
+		if u.Group == "" {
+			f.add(field+".Group", "a group is required for the user or the request")
+		} else {
+			f.groups(field+".Group", []string{u.Group}, bucketUserGroups)
+		}
+
+		if seen[u.UserName] {
+			f.add(field+".UserName", "user %s is requested more than once", u.UserName)
+		}
+		seen[u.UserName] = true
+	}
+
+	return f.err()
+}
+
+// groups returns the sorted list of distinct groups the users are added to
+func (req *userBulkCreateRequest) groups() []string {
+	seen := map[string]bool{}
+	groups := []string{}
+	for _, u := range req.Users {
+		if !seen[u.Group] {
+			seen[u.Group] = true
+			groups = append(groups, u.Group)
+		}
+	}
+	sort.Strings(groups)
+
+	return groups
+}
+
+// UserBulkCreateHandler creates a list of users for a bucket, each with an access key and the same orchestration and
+// rollback as creating a single user.  The groups the users are added to are created first, then the users are created
+// concurrently (up to bulkUserConcurrency at a time) and a result is returned for each user, in the order they were
+// requested.  A failure creating one user doesn't affect the others.
+func (s *server) UserBulkCreateHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	accountId := s.mapAccountNumber(vars["account"])
+
+	var req userBulkCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		msg := fmt.Sprintf("cannot decode body into bulk create users input: %s", err)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+		return
+	}
+
+	if err := req.validate(s.account.CredentialSecrets); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	actions := append([]string{"iam:*", "s3:GetBucketTagging"}, quotaCheckActions...)
+	session, err := s.sessionForAccount(r.Context(), accountId, append(actions, s.keyDeliveryActions(req.Delivery)...)...)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	iamService := iamapi.NewSession(session.Session, s.account)
+	iamService.Cache = s.resourceCache(accountId)
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+
+	keys, err := s.newKeyDeliverer(session.Session, req.Delivery)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	// the users and any group policies are tagged with the org and required tags from the bucket
+	bucketTags, err := s3Service.GetBucketTags(r.Context(), bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	tags := iamTags(s.requiredTags.inherited(bucketTags))
+
+	missing, err := missingBucketGroups(r.Context(), iamService, bucket, req.groups())
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	// check the quotas for all of the users up front, instead of failing part way through the batch
+	if err := s.checkQuotas(r.Context(), session.Session, accountId, quotaNeeds{Users: len(req.Users), Groups: len(missing), Policies: len(missing)}); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	// the groups are created before the users, so the users being created at the same time don't race to create them
+	if err := s.createBucketGroups(r.Context(), vars["account"], bucket, iamService, missing, tags); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	log.Infof("bulk creating %d users for bucket %s in account %s", len(req.Users), bucket, accountId)
+
+	results := make([]userBulkCreateResult, len(req.Users))
+	sem := make(chan struct{}, bulkUserConcurrency)
+	wg := sync.WaitGroup{}
+	for i, u := range req.Users {
+		wg.Add(1)
+		go func(i int, u userBulkCreateItem) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result := userBulkCreateResult{
+				UserName: u.UserName,
+				Group:    u.Group,
+				Status:   bulkStatusCreated,
+				Code:     http.StatusOK,
+			}
+
+			userReq := userCreateRequest{
+				User: &iam.CreateUserInput{
+					UserName: aws.String(u.UserName),
+					Tags:     tags,
+				},
+				Groups: []string{u.Group},
+			}
+
+			output, rb, err := s.createBucketUser(r.Context(), vars["account"], bucket, iamService, userReq, tags, keys)
+			if err != nil {
+				log.Errorf("failed to create user %s for bucket %s in bulk request: %s", u.UserName, bucket, err)
+
+				result.Status = bulkStatusFailed
+				result.Code = errorStatus(err)
+				result.Error = err.Error()
+				if rb != nil && rb.Len() > 0 {
+					result.Rollback = rb.ID
+					result.RollbackStatus = rb.Status
+				}
+			} else {
+				result.User = output.User
+				result.AccessKey = output.AccessKey
+				result.Delivery = output.Delivery
+			}
+
+			results[i] = result
+		}(i, u)
+	}
+	wg.Wait()
+
+	output := userBulkCreateOutput{
+		Results: results,
+	}
+
+	for _, result := range results {
+		if result.Status == bulkStatusCreated {
+			output.Created++
+		} else {
+			output.Failed++
+		}
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response for %d users into JSON: %s", len(results), err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(multiStatusCode(output.Failed))
+	w.Write(j)
+}
+
+// missingBucketGroups returns the bucket's management groups from the list that don't exist
+func missingBucketGroups(ctx context.Context, iamService iamapi.IAM, bucket string, groups []string) ([]string, error) {
+	missing := []string{}
+	for _, group := range groups {
+		if _, err := iamService.GetGroup(ctx, iamapi.FormatGroupName(bucket, "/", group)); err != nil {
+			if !isNotFound(err) {
+				return nil, err
+			}
+			missing = append(missing, group)
+		}
+	}
+
+	return missing, nil
+}
+
+// createBucketGroups creates the bucket's management groups and their policies.  The groups created are rolled back
+// if one of them fails.
+func (s *server) createBucketGroups(ctx context.Context, account, bucket string, iamService iamapi.IAM, groups []string, tags []*iam.Tag) (err error) {
+	if len(groups) == 0 {
+		return nil
+	}
+
+	rb := s.newRollback("group.create", account, bucket, rollbackServices{iam: &iamService})
+	defer func() { s.finishRollback(rb, err) }()
+
+	for _, group := range groups {
+		steps, err := s.CreateBucketGroupPolicy(ctx, iamService, bucket, group, tags)
+		if err != nil {
+			return err
+		}
+		rb.Append(steps...)
+	}
+
+	return nil
+}
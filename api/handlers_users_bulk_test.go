@@ -0,0 +1,73 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestUserBulkCreateHandlerValidation(t *testing.T) {
+	s := server{router: mux.NewRouter()}
+	s.routes()
+
+	tooMany := make([]string, bulkUserMaxItems+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf(`{"UserName": "student%03d"}`, i)
+	}
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid json", `{"Users": `},
+		{"empty list", `{"Group": "BktROGrp", "Users": []}`},
+		{"too many users", `{"Group": "BktROGrp", "Users": [` + strings.Join(tooMany, ",") + "]}"},
+		{"missing user name", `{"Group": "BktROGrp", "Users": [{"UserName": "student01"}, {}]}`},
+		{"invalid user name", `{"Group": "BktROGrp", "Users": [{"UserName": "student 01"}]}`},
+		{"missing group", `{"Users": [{"UserName": "student01"}]}`},
+		{"unsupported group", `{"Group": "WebAdmGrp", "Users": [{"UserName": "student01"}]}`},
+		{"duplicate user", `{"Group": "BktROGrp", "Users": [{"UserName": "student01"}, {"UserName": "student01", "Group": "BktRWGrp"}]}`},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/v1/s3/someaccount/buckets/somebucket/users/bulk", strings.NewReader(test.body))
+		rr := httptest.NewRecorder()
+		s.router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", test.name, http.StatusBadRequest, rr.Code)
+		}
+	}
+}
+
+func TestUserBulkCreateRequestGroups(t *testing.T) {
+	req := userBulkCreateRequest{
+		Group: "BktROGrp",
+		Users: []userBulkCreateItem{
+			{UserName: "student01"},
+			{UserName: "ta01", Group: "BktRWGrp"},
+			{UserName: "student02"},
+			{UserName: "instructor", Group: "BktAdmGrp"},
+		},
+	}
+
+	if err := req.validate(nil); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	for i, expected := range []string{"BktROGrp", "BktRWGrp", "BktROGrp", "BktAdmGrp"} {
+		if req.Users[i].Group != expected {
+			t.Errorf("expected user %s in group %s, got %s", req.Users[i].UserName, expected, req.Users[i].Group)
+		}
+	}
+
+	expected := []string{"BktAdmGrp", "BktROGrp", "BktRWGrp"}
+	if groups := req.groups(); !reflect.DeepEqual(groups, expected) {
+		t.Errorf("expected groups %v, got %v", expected, groups)
+	}
+}
@@ -0,0 +1,199 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/YaleSpinup/apierror"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// consoleLoginPasswordLength is the length of the generated one-time console password
+const consoleLoginPasswordLength = 20
+
+// loginCreateResponse is the response from enabling console login with the one-time password
+type loginCreateResponse struct {
+	LoginProfile *iam.LoginProfile
+	Password     string
+}
+
+// loginResetResponse is the response from resetting the console password with the new one-time password
+type loginResetResponse struct {
+	UserName string
+	Password string
+}
+
+// UserLoginCreateHandler enables console sign-in for a bucket user.  A one-time password is generated and
+// returned that must be reset at first sign-in.
+func (s *server) UserLoginCreateHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	user := vars["user"]
+
+	if !s.account.EnableConsoleLogin {
+		handleError(w, apierror.New(apierror.ErrForbidden, "console login is not enabled", nil))
+		return
+	}
+
+	iamService, err := s.iamServiceForAccount(r.Context(), vars["account"])
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	password, err := iamapi.GeneratePassword(consoleLoginPasswordLength)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	// setup rollback and defer execution
+	rb := s.newRollback("user.login.create", vars["account"], user, rollbackServices{iam: &iamService})
+	defer func() {
+		s.finishRollback(rb, err)
+	}()
+
+	profile, err := iamService.CreateLoginProfile(r.Context(), &iam.CreateLoginProfileInput{
+		UserName:              aws.String(user),
+		Password:              aws.String(password),
+		PasswordResetRequired: aws.Bool(true),
+	})
+	if err != nil {
+		msg := fmt.Sprintf("failed to create login profile for user %s, bucket %s", user, bucket)
+		handleError(w, errors.Wrap(err, msg))
+		return
+	}
+
+	// append login profile delete to rollback
+	rb.Add("delete login profile for user "+user, rollbackDeleteLoginProfile, map[string]string{"user": user})
+
+	// the user must be able to change their own password to satisfy the forced reset
+	if err = iamService.AttachUserPolicy(r.Context(), &iam.AttachUserPolicyInput{
+		UserName:  aws.String(user),
+		PolicyArn: aws.String(iamapi.ChangePasswordPolicyArn),
+	}); err != nil {
+		msg := fmt.Sprintf("failed to attach change password policy to user %s, bucket %s", user, bucket)
+		handleError(w, errors.Wrap(err, msg))
+		return
+	}
+
+	output := loginCreateResponse{
+		profile,
+		password,
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", profile, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// UserLoginResetHandler resets the console password for a bucket user to a new one-time password
+func (s *server) UserLoginResetHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	user := vars["user"]
+
+	if !s.account.EnableConsoleLogin {
+		handleError(w, apierror.New(apierror.ErrForbidden, "console login is not enabled", nil))
+		return
+	}
+
+	iamService, err := s.iamServiceForAccount(r.Context(), vars["account"])
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	password, err := iamapi.GeneratePassword(consoleLoginPasswordLength)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if err := iamService.UpdateLoginProfile(r.Context(), &iam.UpdateLoginProfileInput{
+		UserName:              aws.String(user),
+		Password:              aws.String(password),
+		PasswordResetRequired: aws.Bool(true),
+	}); err != nil {
+		msg := fmt.Sprintf("failed to reset login profile for user %s, bucket %s", user, bucket)
+		handleError(w, errors.Wrap(err, msg))
+		return
+	}
+
+	output := loginResetResponse{
+		user,
+		password,
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response for user %s into JSON: %s", user, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// UserLoginDeleteHandler disables console sign-in for a bucket user
+func (s *server) UserLoginDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	user := vars["user"]
+
+	iamService, err := s.iamServiceForAccount(r.Context(), vars["account"])
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if err := deleteUserLogin(r.Context(), iamService, user); err != nil {
+		msg := fmt.Sprintf("failed to delete login profile for user %s, bucket %s", user, bucket)
+		handleError(w, errors.Wrap(err, msg))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte{})
+}
+
+// deleteUserLogin removes the login profile and the change password policy from a user.  A user without
+// a login profile or the policy is not an error.
+func deleteUserLogin(ctx context.Context, iamService iamapi.IAM, user string) error {
+	if err := iamService.DeleteLoginProfile(ctx, &iam.DeleteLoginProfileInput{UserName: aws.String(user)}); err != nil {
+		if !isNotFound(err) {
+			return err
+		}
+	}
+
+	if err := iamService.DetachUserPolicy(ctx, &iam.DetachUserPolicyInput{
+		UserName:  aws.String(user),
+		PolicyArn: aws.String(iamapi.ChangePasswordPolicyArn),
+	}); err != nil {
+		if !isNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
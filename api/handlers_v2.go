@@ -0,0 +1,247 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/YaleSpinup/apierror"
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	cwapi "github.com/YaleSpinup/s3-api/cloudwatch"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	route53api "github.com/YaleSpinup/s3-api/route53"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// BucketListV2Handler lists the buckets in an account
+func (s *server) BucketListV2Handler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+
+	session, err := s.sessionForAccount(r.Context(), vars["account"], "s3:ListAllMyBuckets")
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+	output, err := s3Service.ListBuckets(r.Context(), &s3.ListBucketsInput{})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	buckets := []Bucket{}
+	for _, b := range output {
+		buckets = append(buckets, Bucket{
+			Name:      aws.StringValue(b.Name),
+			CreatedAt: b.CreationDate,
+		})
+	}
+
+	writeConditionalJSON(w, r, buckets)
+}
+
+// BucketCreateV2Handler creates a bucket the same way as the v1 api and returns the created bucket
+func (s *server) BucketCreateV2Handler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+
+	var req bucketCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		msg := fmt.Sprintf("cannot decode body into create bucket input: %s", err)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+		return
+	}
+
+	if err := req.validate(s.requiredTags); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	session, err := s.sessionForScope(r.Context(), vars["account"], s.withSharedAdminGroups(bucketCreatePolicy, sharedAdminGroupActions...), policyScope{Bucket: aws.StringValue(req.BucketInput.Bucket)})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if err := s.checkQuotas(r.Context(), session.Session, accountId, req.quotaNeeds()); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+	iamService := iamapi.NewSession(session.Session, s.account)
+	iamService.Cache = s.resourceCache(accountId)
+
+	output, _, err := s.createBucket(r.Context(), vars["account"], s3Service, iamService, req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	bucket := toCreatedBucket(aws.StringValue(req.BucketInput.Bucket), req.Tags, output)
+
+	j, err := json.Marshal(bucket)
+	if err != nil {
+		log.Errorf("cannot marshal response (%v) into JSON: %s", bucket, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// BucketShowV2Handler gets the details of a bucket
+func (s *server) BucketShowV2Handler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	bucket := vars["bucket"]
+
+	session, err := s.sessionForAccount(r.Context(), vars["account"], append([]string{"s3:Get*", "s3:List*"}, bucketUsageActions...)...)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+	cloudWatchService := cwapi.NewSession(session.Session, s.account)
+
+	output, err := getBucket(r.Context(), s3Service, cloudWatchService, bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	etag, err := bucketPolicyETag(r.Context(), s3Service, bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	b := toBucket(bucket, output)
+
+	w.Header().Set("ETag", etag)
+	writeConditionalJSON(w, r, b)
+}
+
+// WebsiteShowV2Handler gets the details of a website
+func (s *server) WebsiteShowV2Handler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	website := vars["website"]
+
+	session, err := s.sessionForAccount(r.Context(), vars["account"], "s3:Get*", "s3:List*", "cloudfront:Get*", "cloudfront:List*", "route53:Get*", "route53:List*")
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+	cloudFrontService := cfapi.NewSession(session.Session, s.account, accountId)
+	cloudFrontService.Index = s.distributionIndex(accountId)
+	route53Service := route53api.NewSession(session.Session, s.account)
+
+	output, err := getWebsite(r.Context(), s3Service, cloudFrontService, route53Service, website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	var etag string
+	if output.Distribution != nil {
+		if etag, err = websiteETag(r.Context(), cloudFrontService, output.Distribution); err != nil {
+			handleError(w, err)
+			return
+		}
+	}
+
+	site := toWebsite(website, output)
+
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	writeConditionalJSON(w, r, site)
+}
+
+// UserListV2Handler lists the users for a bucket or website
+func (s *server) UserListV2Handler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	iamService, err := s.iamServiceForAccount(r.Context(), vars["account"])
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	users := []User{}
+	for _, u := range listBucketUsers(r.Context(), iamService, bucket) {
+		users = append(users, toUser(u))
+	}
+
+	writeConditionalJSON(w, r, users)
+}
+
+// UserShowV2Handler gets the details of a bucket or website user.  Website user groups are named with the user's path.
+func (s *server) UserShowV2Handler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	user := vars["user"]
+
+	bucket, isWebsite := vars["website"]
+	if !isWebsite {
+		bucket = vars["bucket"]
+	}
+
+	iamService, err := s.iamServiceForAccount(r.Context(), vars["account"])
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	groupNames := []string{}
+	for _, g := range bucketUserGroups {
+		if isWebsite {
+			groupNames = append(groupNames, iamapi.FormatGroupName(bucket, iamapi.GetUsernamePath(bucket, user), g))
+		} else {
+			groupNames = append(groupNames, iamapi.FormatGroupName(bucket, "/", g))
+		}
+	}
+
+	output, err := getBucketUser(r.Context(), iamService, bucket, user, groupNames)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if output == nil {
+		msg := fmt.Sprintf("user %s does not exist or does not belong to %s", user, bucket)
+		handleError(w, apierror.New(apierror.ErrNotFound, msg, nil))
+		return
+	}
+
+	u := toUserDetails(output)
+
+	j, err := json.Marshal(u)
+	if err != nil {
+		log.Errorf("cannot marshal response (%v) into JSON: %s", u, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
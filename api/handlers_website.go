@@ -1,20 +1,22 @@
 package api
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/YaleSpinup/apierror"
 	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
 	"github.com/YaleSpinup/s3-api/common"
 	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/YaleSpinup/s3-api/retry"
+	"github.com/YaleSpinup/s3-api/rollback"
 	route53api "github.com/YaleSpinup/s3-api/route53"
 	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/YaleSpinup/s3-api/task"
+	"github.com/YaleSpinup/s3-api/webhook"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/cloudfront"
 	"github.com/aws/aws-sdk-go/service/iam"
@@ -25,11 +27,90 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// websiteCreateRequest is the request to create a website
+type websiteCreateRequest struct {
+	Tags                 []*s3.Tag
+	BucketInput          s3.CreateBucketInput
+	WebsiteConfiguration s3.WebsiteConfiguration
+	// DefaultIndex creates a default index.html page, unless one is passed in the content (default true)
+	DefaultIndex *bool `json:",omitempty"`
+	// Content is the initial content of the website, ie. the index and error pages
+	Content []*websiteContent `json:",omitempty"`
+	// StorageClass is the storage class of the content and the default index page (default STANDARD)
+	StorageClass string `json:",omitempty"`
+	// ObjectTags are the default tags for the objects created in the website bucket by the api
+	ObjectTags []*s3.Tag `json:",omitempty"`
+	// Redirect creates a redirect site that redirects all requests to another host instead of serving content
+	Redirect *websiteRedirect `json:",omitempty"`
+	// CacheBehaviors are additional cache behaviors for the distribution, ie. to send /api/* to an external origin
+	CacheBehaviors []*cfapi.CacheBehavior `json:",omitempty"`
+	// Signed requires all of the requests that don't match a cache behavior to be signed, ie. with a signed url
+	Signed bool `json:",omitempty"`
+	// ProtocolSettings override the configured http version and IPv6 defaults of the distribution
+	cfapi.ProtocolSettings
+	// Simple creates a simple website that's served from the s3 website endpoint to the configured networks, without
+	// a cloudfront distribution or dns record
+	Simple bool `json:",omitempty"`
+	// IPAllowlist limits the bucket admin policy to the networks, it defaults to the account's allowlist
+	IPAllowlist []string `json:",omitempty"`
+}
+
+// validate validates the request to create a website in one of the passed domains with the required tags
+func (r *websiteCreateRequest) validate(domains map[string]*common.Domain, required requiredTags) error {
+	f := fieldErrors{}
+	if r.Simple {
+		// a simple website isn't served from one of the domains, it only needs a valid bucket name
+		f.bucketName("BucketInput.Bucket", aws.StringValue(r.BucketInput.Bucket))
+	} else {
+		f.websiteName("BucketInput.Bucket", aws.StringValue(r.BucketInput.Bucket), domains)
+	}
+	f.tags("Tags", r.Tags)
+	f.requiredTags("Tags", r.Tags, required)
+	f.websiteContent("Content", r.Content)
+	if r.StorageClass != "" {
+		f.storageClass("StorageClass", r.StorageClass)
+	}
+	f.objectTags("ObjectTags", r.ObjectTags)
+	f.ipAllowlist("IPAllowlist", r.IPAllowlist)
+	f.cacheBehaviors("CacheBehaviors", r.CacheBehaviors)
+	f.httpVersion("HttpVersion", r.HttpVersion)
+	if r.Redirect != nil {
+		f.websiteRedirect("Redirect", r.Redirect, aws.StringValue(r.BucketInput.Bucket))
+		if len(r.Content) > 0 || aws.BoolValue(r.DefaultIndex) {
+			f.add("Content", "content cannot be created for a redirect site")
+		}
+		if r.WebsiteConfiguration.IndexDocument != nil || r.WebsiteConfiguration.ErrorDocument != nil || r.WebsiteConfiguration.RoutingRules != nil || r.WebsiteConfiguration.RedirectAllRequestsTo != nil {
+			f.add("WebsiteConfiguration", "website configuration cannot be set for a redirect site")
+		}
+		if len(r.CacheBehaviors) > 0 {
+			f.add("CacheBehaviors", "cache behaviors cannot be set for a redirect site")
+		}
+		if r.Signed {
+			f.add("Signed", "a redirect site cannot be signed")
+		}
+	}
+	if r.Simple {
+		if r.Redirect != nil {
+			f.add("Redirect", "a simple website cannot be a redirect site")
+		}
+		if len(r.CacheBehaviors) > 0 {
+			f.add("CacheBehaviors", "cache behaviors cannot be set for a simple website")
+		}
+		if r.Signed {
+			f.add("Signed", "a simple website cannot be signed")
+		}
+		if !r.ProtocolSettings.Empty() {
+			f.add("HttpVersion", "protocol settings cannot be set for a simple website")
+		}
+	}
+	return f.err()
+}
+
 // CreateWebsiteHandler orchestrates the creation of a new s3 bucket website with rollback in
 // the event of failure.  The operations are:
 // 1. create the bucket with the given name
 // 2. tag the bucket with given tags
-// 3. apply the website configuration to the bucket
+// 3. apply the website configuration to the bucket and seed it with the passed content and the default index page
 // 4. generate the default admin bucket policy
 // 5. create the admin bucket policy
 // 6. create the bucket admin group, '<bucketName>-BktAdmGrp'
@@ -39,70 +120,139 @@ import (
 // 10. attach the web admin policy to the web admin group
 // 11. create alias record in route53
 // Note: this does _not_ create any users for managing the bucket
+// If the request is for a redirect site, the website is created by createRedirectWebsite instead, and a simple
+// website is created by createSimpleWebsite.
 func (s *server) CreateWebsiteHandler(w http.ResponseWriter, r *http.Request) {
 	w = LogWriter{w}
 	vars := mux.Vars(r)
-	accountId := s.mapAccountNumber(vars["account"])
-	role := fmt.Sprintf("arn:aws:iam::%s:role/%s", accountId, s.session.RoleName)
-	policy, err := generatePolicy("s3:*", "iam:*", "cloudfront:*", "route53:*")
+
+	var req websiteCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		msg := fmt.Sprintf("cannot decode body into create website input: %s", err)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+		return
+	}
+
+	// simple websites don't need the domains, certificates and hosted zones that the other websites do
+	var err error
+	if req.Simple {
+		err = s.requireSimpleWebsites()
+	} else {
+		err = s.requireWebsites(vars["account"])
+	}
+
 	if err != nil {
-		log.Errorf("cannot generate policy: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		handleError(w, err)
 		return
 	}
 
-	session, err := s.assumeRole(
-		r.Context(),
-		s.session.ExternalID,
-		role,
-		policy,
-	)
+	wait, err := waitParam(r)
 	if err != nil {
-		log.Errorf("failed to assume role in account: %s", accountId)
-		w.WriteHeader(http.StatusInternalServerError)
+		handleError(w, err)
 		return
 	}
 
-	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
-	iamService := iamapi.NewSession(session.Session, s.account)
-	cloudFrontService := cfapi.NewSession(session.Session, s.account, accountId)
-	route53Service := route53api.NewSession(session.Session, s.account)
+	if err := req.validate(s.account.Domains, s.requiredTags); err != nil {
+		handleError(w, err)
+		return
+	}
 
-	var req struct {
-		Tags                 []*s3.Tag
-		BucketInput          s3.CreateBucketInput
-		WebsiteConfiguration s3.WebsiteConfiguration
+	s.createWebsite(w, r, &req, wait)
+}
+
+// createWebsite creates a website from a validated request, the operations are described on CreateWebsiteHandler
+func (s *server) createWebsite(w http.ResponseWriter, r *http.Request, req *websiteCreateRequest, wait bool) {
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+
+	// fail before creating anything if the website can't be signed
+	if s.signedURLs == nil && (req.Signed || signedBehaviors(req.CacheBehaviors)) {
+		handleError(w, apierror.New(apierror.ErrBadRequest, "signed urls are not configured", nil))
+		return
 	}
-	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
-		msg := fmt.Sprintf("cannot decode body into create website input: %s", err)
-		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+
+	session, err := s.sessionForScope(r.Context(), accountId, websiteCreatePolicy, policyScope{Bucket: aws.StringValue(req.BucketInput.Bucket)})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	// a redirect site only has a distribution and a simple website only has the bucket admin group and policy, other
+	// websites get both admin groups and policies and a distribution
+	needs := quotaNeeds{Groups: 2, Policies: 2, Distributions: 1}
+	if req.Redirect != nil {
+		needs = quotaNeeds{Distributions: 1}
+	} else if req.Simple {
+		needs = quotaNeeds{Groups: 1, Policies: 1}
+	}
+
+	if err := s.checkQuotas(r.Context(), session.Session, accountId, needs); err != nil {
+		handleError(w, err)
 		return
 	}
 
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+	iamService := iamapi.NewSession(session.Session, s.account)
+	iamService.Cache = s.resourceCache(accountId)
+	cloudFrontService := cfapi.NewSession(session.Session, s.account, accountId)
+	cloudFrontService.Index = s.distributionIndex(accountId)
+	route53Service := route53api.NewSession(session.Session, s.account)
+
 	// append org tag that will get applied to all resources that tag
 	req.Tags = append(req.Tags, &s3.Tag{
 		Key:   aws.String("spinup:org"),
 		Value: aws.String(Org),
 	})
 
-	// setup err var, rollback function list and defer execution
-	var rollBackTasks []rollbackFunc
+	bucketName := aws.StringValue(req.BucketInput.Bucket)
+
+	lease, err := s.lockResource(r.Context(), vars["account"], bucketName)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	defer lease.Release()
+
+	// a create that waited on the lock for another create of the same website fails here, instead of rolling back
+	// the bucket the other create made
+	if exists, _ := s3Service.BucketExists(r.Context(), bucketName); exists {
+		msg := fmt.Sprintf("website bucket %s already exists", bucketName)
+		handleError(w, apierror.New(apierror.ErrConflict, msg, nil))
+		return
+	}
+
+	if req.Redirect != nil {
+		s.createRedirectWebsite(w, r, s3Service, cloudFrontService, route53Service, req, lease, wait)
+		return
+	}
+
+	if req.Simple {
+		s.createSimpleWebsite(w, r, s3Service, iamService, cloudFrontService, req)
+		return
+	}
+
+	// setup rollback and defer execution
+	rb := s.newRollback("website.create", vars["account"], bucketName, rollbackServices{s3: &s3Service, iam: &iamService, cloudFront: &cloudFrontService})
 	defer func() {
+		s.finishRollback(rb, err)
 		if err != nil {
-			log.Errorf("recovering from error: %s, executing %d rollback tasks", err, len(rollBackTasks))
-			rollBack(&rollBackTasks)
+			s.notify(webhook.EventWebsiteRolledBack, vars["account"], bucketName, map[string]string{"Error": err.Error(), "Rollback": rb.ID})
 		}
 	}()
 
-	bucketName := aws.StringValue(req.BucketInput.Bucket)
-
-	var domain *common.Domain
-	if domain, err = cloudFrontService.WebsiteDomain(bucketName); err != nil {
+	if _, err = cloudFrontService.WebsiteDomain(bucketName); err != nil {
 		msg := fmt.Sprintf("failed to validate website domain %s", bucketName)
 		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
 		return
 	}
 
+	var zoneID string
+	if zoneID, err = route53Service.ZoneIDForName(r.Context(), bucketName); err != nil {
+		msg := fmt.Sprintf("failed to find the hosted zone for website %s", bucketName)
+		handleError(w, errors.Wrap(err, msg))
+		return
+	}
+
 	var bucketOutput *s3.CreateBucketOutput
 	if bucketOutput, err = s3Service.CreateBucket(r.Context(), &req.BucketInput); err != nil {
 		msg := fmt.Sprintf("failed to create bucket %s", bucketName)
@@ -120,17 +270,13 @@ func (s *server) CreateWebsiteHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// append bucket delete to rollback tasks
-	rbfunc := func(ctx context.Context) error {
-		err := s3Service.DeleteEmptyBucket(r.Context(), &s3.DeleteBucketInput{Bucket: aws.String(bucketName)})
-		return err
-	}
-	rollBackTasks = append(rollBackTasks, rbfunc)
+	// append bucket delete to rollback
+	rb.Add("delete bucket "+bucketName, rollbackDeleteBucket, map[string]string{"bucket": bucketName})
 
 	// wait for the bucket to exist
-	if err = retry(3, 2*time.Second, func() error {
+	if err = retry.Do(r.Context(), s3ConsistencyRetry, func(ctx context.Context) error {
 		log.Infof("checking if bucket exists before continuing: %s", bucketName)
-		exists, err := s3Service.BucketExists(r.Context(), bucketName)
+		exists, err := s3Service.BucketExists(ctx, bucketName)
 		if err != nil {
 			return err
 		}
@@ -147,263 +293,227 @@ func (s *server) CreateWebsiteHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// retry tagging
-	if err = retry(3, 2*time.Second, func() error {
-		if err := s3Service.TagBucket(r.Context(), bucketName, req.Tags); err != nil {
-			log.Warnf("error tagging website bucket %s: %s", bucketName, err)
-			return err
+	// the bucket configuration, the bucket admin group and the cloudfront distribution (along with the web
+	// admin group and dns record that depend on it) are independent of each other and are created concurrently
+	var (
+		bktPolicy, webPolicy *iam.Policy
+		bktGroup, webGroup   *iam.Group
+		distribution         *cloudfront.Distribution
+		dnsChange            *route53.ChangeInfo
+		created              []*websiteObject
+	)
+
+	g, ctx := newErrGroup(r.Context())
+
+	// configure the website bucket
+	g.Go(func() error {
+		// retry tagging, the default object tags are only stored on the bucket
+		if err := retry.Do(ctx, s3ConsistencyRetry, func(ctx context.Context) error {
+			if err := s3Service.TagBucket(ctx, bucketName, withDefaultObjectTags(req.Tags, req.ObjectTags)); err != nil {
+				log.Warnf("error tagging website bucket %s: %s", bucketName, err)
+				return err
+			}
+			return nil
+		}); err != nil {
+			msg := fmt.Sprintf("failed to tag website bucket %s: %s", bucketName, err.Error())
+			return errors.Wrap(err, msg)
 		}
-		return nil
-	}); err != nil {
-		msg := fmt.Sprintf("failed to tag website bucket %s: %s", bucketName, err.Error())
-		handleError(w, errors.Wrap(err, msg))
-		return
-	}
 
-	// enable AWS managed serverside encryption for the website/bucket
-	if err = s3Service.UpdateBucketEncryption(r.Context(), &s3.PutBucketEncryptionInput{
-		Bucket: aws.String(bucketName),
-		ServerSideEncryptionConfiguration: &s3.ServerSideEncryptionConfiguration{
-			Rules: []*s3.ServerSideEncryptionRule{
-				{
-					ApplyServerSideEncryptionByDefault: &s3.ServerSideEncryptionByDefault{
-						SSEAlgorithm: aws.String("AES256"),
+		// enable AWS managed serverside encryption for the website/bucket
+		if err := s3Service.UpdateBucketEncryption(ctx, &s3.PutBucketEncryptionInput{
+			Bucket: aws.String(bucketName),
+			ServerSideEncryptionConfiguration: &s3.ServerSideEncryptionConfiguration{
+				Rules: []*s3.ServerSideEncryptionRule{
+					{
+						ApplyServerSideEncryptionByDefault: &s3.ServerSideEncryptionByDefault{
+							SSEAlgorithm: aws.String("AES256"),
+						},
 					},
 				},
 			},
-		},
-	}); err != nil {
-		msg := fmt.Sprintf("failed to enable encryption for bucket %s: %s", bucketName, err.Error())
-		handleError(w, errors.Wrap(err, msg))
-		return
-	}
+		}); err != nil {
+			msg := fmt.Sprintf("failed to enable encryption for bucket %s: %s", bucketName, err.Error())
+			return errors.Wrap(err, msg)
+		}
 
-	// enable logging access for the website/bucket to a central repo
-	if s3Service.LoggingBucket != "" {
-		if err = s3Service.UpdateBucketLogging(r.Context(), bucketName, s3Service.LoggingBucket, s3Service.LoggingBucketPrefix); err != nil {
-			msg := fmt.Sprintf("failed to enable logging for bucket %s: %s", bucketName, err.Error())
-			handleError(w, errors.Wrap(err, msg))
-			return
+		// enable logging access for the website/bucket to a central repo
+		if s3Service.LoggingBucket != "" {
+			if err := s3Service.UpdateBucketLogging(ctx, bucketName, s3Service.LoggingBucket, s3Service.LoggingBucketPrefix); err != nil {
+				msg := fmt.Sprintf("failed to enable logging for bucket %s: %s", bucketName, err.Error())
+				return errors.Wrap(err, msg)
+			}
 		}
-	}
 
-	if err = s3Service.UpdateWebsiteConfig(r.Context(), &s3.PutBucketWebsiteInput{
-		Bucket:               aws.String(bucketName),
-		WebsiteConfiguration: &req.WebsiteConfiguration,
-	}); err != nil {
-		msg := fmt.Sprintf("failed to configure bucket %s as website: %s", bucketName, err.Error())
-		handleError(w, errors.Wrap(err, msg))
-		return
-	}
+		if err := s3Service.UpdateWebsiteConfig(ctx, &s3.PutBucketWebsiteInput{
+			Bucket:               aws.String(bucketName),
+			WebsiteConfiguration: &req.WebsiteConfiguration,
+		}); err != nil {
+			msg := fmt.Sprintf("failed to configure bucket %s as website: %s", bucketName, err.Error())
+			return errors.Wrap(err, msg)
+		}
 
-	var defaultWebsitePolicy []byte
-	if defaultWebsitePolicy, err = iamService.DefaultWebsiteAccessPolicy(aws.String(bucketName)); err != nil {
-		msg := fmt.Sprintf("failed building default website bucket access policy for %s: %s", bucketName, err.Error())
-		handleError(w, apierror.New(apierror.ErrInternalError, msg, err))
-		return
-	}
+		// the website access policy isn't limited to the allowlist since the distribution reads from the website
+		// endpoint from cloudfront's networks
+		defaultWebsitePolicy, err := iamService.DefaultWebsiteAccessPolicy(aws.String(bucketName), nil)
+		if err != nil {
+			msg := fmt.Sprintf("failed building default website bucket access policy for %s: %s", bucketName, err.Error())
+			return apierror.New(apierror.ErrInternalError, msg, err)
+		}
 
-	if err = s3Service.UpdateBucketPolicy(r.Context(), &s3.PutBucketPolicyInput{
-		Bucket: aws.String(bucketName),
-		Policy: aws.String(string(defaultWebsitePolicy)),
-	}); err != nil {
-		handleError(w, err)
-		return
-	}
+		if err := s3Service.UpdateBucketPolicy(ctx, &s3.PutBucketPolicyInput{
+			Bucket: aws.String(bucketName),
+			Policy: aws.String(string(defaultWebsitePolicy)),
+		}); err != nil {
+			return err
+		}
 
-	// build the default IAM bucket admin policy (from the config and known inputs)
-	var defaultBktPolicy []byte
-	if defaultBktPolicy, err = iamService.DefaultBucketAdminPolicy(aws.String(bucketName)); err != nil {
-		msg := fmt.Sprintf("failed building default IAM policy for bucket %s: %s", bucketName, err.Error())
-		handleError(w, apierror.New(apierror.ErrInternalError, msg, err))
-		return
-	}
+		// write the seed content and the default index file
+		objects, err := websiteSeedObjects(bucketName, req, s.account.CacheControl)
+		if err != nil {
+			return apierror.New(apierror.ErrBadRequest, "failed to decode website content", err)
+		}
 
-	var bktPolicy *iam.Policy
-	if bktPolicy, err = iamService.CreatePolicy(r.Context(), &iam.CreatePolicyInput{
-		Description:    aws.String(fmt.Sprintf("Admin policy for %s bucket", bucketName)),
-		PolicyDocument: aws.String(string(defaultBktPolicy)),
-		PolicyName:     aws.String(fmt.Sprintf("%s-BktAdmPlc", bucketName)),
-	}); err != nil {
-		msg := fmt.Sprintf("failed to create bucket admin policy: %s", err.Error())
-		handleError(w, errors.Wrap(err, msg))
-		return
-	}
+		for _, o := range objects {
+			key := aws.StringValue(o.Key)
+			if _, err := s3Service.CreateObject(ctx, o); err != nil {
+				msg := fmt.Sprintf("failed to create %s for website %s: %s", key, bucketName, err.Error())
+				return errors.Wrap(err, msg)
+			}
 
-	// append policy delete to rollback tasks
-	rbfunc = func(ctx context.Context) error {
-		err := iamService.DeletePolicy(r.Context(), &iam.DeletePolicyInput{PolicyArn: bktPolicy.Arn})
-		return err
-	}
-	rollBackTasks = append(rollBackTasks, rbfunc)
+			// append object delete to rollback so the bucket can be deleted
+			rb.Add("delete object "+key+" from bucket "+bucketName, rollbackDeleteObject, map[string]string{"bucket": bucketName, "key": key})
 
-	bktGroupName := fmt.Sprintf("%s-BktAdmGrp", bucketName)
+			created = append(created, newWebsiteObject(o))
+		}
 
-	var bktGroup *iam.Group
-	if bktGroup, err = iamService.CreateGroup(r.Context(), &iam.CreateGroupInput{
-		GroupName: aws.String(bktGroupName),
-	}); err != nil {
-		msg := fmt.Sprintf("failed to create bucket admin group: %s", err.Error())
-		handleError(w, errors.Wrap(err, msg))
-		return
-	}
+		return nil
+	})
 
-	// append group delete to rollback tasks
-	rbfunc = func(ctx context.Context) error {
-		return iamService.DeleteGroup(r.Context(), &iam.DeleteGroupInput{GroupName: aws.String(bktGroupName)})
-	}
-	rollBackTasks = append(rollBackTasks, rbfunc)
+	// create the bucket admin policy and group
+	g.Go(func() error {
+		// build the default IAM bucket admin policy (from the config and known inputs)
+		defaultBktPolicy, err := iamService.DefaultBucketAdminPolicy(aws.String(bucketName), s.ipAllowlist(req.IPAllowlist))
+		if err != nil {
+			msg := fmt.Sprintf("failed building default IAM policy for bucket %s: %s", bucketName, err.Error())
+			return apierror.New(apierror.ErrInternalError, msg, err)
+		}
 
-	if err = iamService.AttachGroupPolicy(r.Context(), &iam.AttachGroupPolicyInput{
-		GroupName: aws.String(bktGroupName),
-		PolicyArn: bktPolicy.Arn,
-	}); err != nil {
-		msg := fmt.Sprintf("failed to attach policy %s to group %s: %s", aws.StringValue(bktPolicy.Arn), bktGroupName, err.Error())
-		handleError(w, errors.Wrap(err, msg))
-		return
-	}
+		bktPolicy, bktGroup, err = createWebsiteAdminGroup(ctx, iamService, rb, "bucket admin",
+			iamapi.FormatPolicyName(bucketName, "/", "BktAdmPlc"),
+			fmt.Sprintf("Admin policy for %s bucket", bucketName),
+			defaultBktPolicy,
+			iamapi.FormatGroupName(bucketName, "/", "BktAdmGrp"),
+			iamTags(req.Tags),
+		)
+		return err
+	})
 
-	// append detach group policy to rollback tasks
-	rbfunc = func(ctx context.Context) error {
-		return iamService.DetachGroupPolicy(r.Context(), &iam.DetachGroupPolicyInput{
-			GroupName: aws.String(bktGroupName),
-			PolicyArn: bktPolicy.Arn,
-		})
-	}
-	rollBackTasks = append(rollBackTasks, rbfunc)
+	// create the cloudfront distribution, web admin policy and group and the dns record
+	g.Go(func() error {
+		// the signed website and cache behaviors trust the signed urls key group
+		var keyGroupId string
+		if req.Signed {
+			id, err := s.signedKeyGroup(ctx, cloudFrontService)
+			if err != nil {
+				return err
+			}
+			keyGroupId = id
+		}
 
-	// normalize tags
-	cfTags := []*cloudfront.Tag{}
-	for _, tag := range req.Tags {
-		t := &cloudfront.Tag{
-			Key:   tag.Key,
-			Value: tag.Value,
+		if err := s.signBehaviors(ctx, cloudFrontService, req.CacheBehaviors); err != nil {
+			return err
 		}
-		cfTags = append(cfTags, t)
-	}
 
-	var defaultWebsiteDistribution *cloudfront.DistributionConfig
-	if defaultWebsiteDistribution, err = cloudFrontService.DefaultWebsiteDistributionConfig(bucketName); err != nil {
-		msg := fmt.Sprintf("failed to generate default website distribution config for %s: %s", bucketName, err.Error())
-		handleError(w, errors.Wrap(err, msg))
-		return
-	}
+		defaultWebsiteDistribution, err := cloudFrontService.DefaultWebsiteDistributionConfig(bucketName, req.CacheBehaviors...)
+		if err != nil {
+			msg := fmt.Sprintf("failed to generate default website distribution config for %s: %s", bucketName, err.Error())
+			return errors.Wrap(err, msg)
+		}
 
-	var distribution *cloudfront.Distribution
-	if distribution, err = cloudFrontService.CreateDistribution(r.Context(), defaultWebsiteDistribution, &cloudfront.Tags{Items: cfTags}); err != nil {
-		msg := fmt.Sprintf("failed to create cloudfront distribution for website %s: %s", bucketName, err.Error())
-		handleError(w, errors.Wrap(err, msg))
-		return
-	}
+		// new websites get the security headers if they're enabled by default
+		if s.securityHeaders != nil && s.securityHeaders.Default {
+			policyId, err := s.securityHeadersPolicy(ctx, cloudFrontService)
+			if err != nil {
+				return err
+			}
+			cfapi.SetResponseHeadersPolicy(defaultWebsiteDistribution, policyId)
+		}
 
-	// append disable cloudfront distribution to rollback tasks
-	rbfunc = func(ctx context.Context) error {
-		_, err := cloudFrontService.DisableDistribution(r.Context(), aws.StringValue(distribution.Id))
-		return err
-	}
-	rollBackTasks = append(rollBackTasks, rbfunc)
+		if keyGroupId != "" {
+			cfapi.SetDefaultTrustedKeyGroup(defaultWebsiteDistribution, keyGroupId)
+		}
 
-	// build the default IAM web admin policy (from the config and known inputs)
-	var defaultWebPolicy []byte
-	if defaultWebPolicy, err = iamService.DefaultWebAdminPolicy(distribution.ARN); err != nil {
-		msg := fmt.Sprintf("failed building default IAM policy for cloudfront distribution %s: %s", aws.StringValue(distribution.ARN), err.Error())
-		handleError(w, apierror.New(apierror.ErrInternalError, msg, err))
-		return
-	}
+		req.ProtocolSettings.Apply(defaultWebsiteDistribution)
 
-	var webPolicy *iam.Policy
-	if webPolicy, err = iamService.CreatePolicy(r.Context(), &iam.CreatePolicyInput{
-		Description:    aws.String(fmt.Sprintf("Admin policy for %s web distribution", bucketName)),
-		PolicyDocument: aws.String(string(defaultWebPolicy)),
-		PolicyName:     aws.String(fmt.Sprintf("%s-WebAdmPlc", bucketName)),
-	}); err != nil {
-		msg := fmt.Sprintf("failed to create web admin policy: %s", err.Error())
-		handleError(w, errors.Wrap(err, msg))
-		return
-	}
+		if distribution, err = cloudFrontService.CreateDistribution(ctx, defaultWebsiteDistribution, &cloudfront.Tags{Items: cloudFrontTags(req.Tags)}); err != nil {
+			msg := fmt.Sprintf("failed to create cloudfront distribution for website %s: %s", bucketName, err.Error())
+			return errors.Wrap(err, msg)
+		}
 
-	// append policy delete to rollback tasks
-	rbfunc = func(ctx context.Context) error {
-		return iamService.DeletePolicy(r.Context(), &iam.DeletePolicyInput{PolicyArn: webPolicy.Arn})
-	}
-	rollBackTasks = append(rollBackTasks, rbfunc)
+		// append disable cloudfront distribution to rollback
+		rb.Add("disable distribution "+aws.StringValue(distribution.Id), rollbackDisableDistribution, map[string]string{"id": aws.StringValue(distribution.Id)})
 
-	webGroupName := fmt.Sprintf("%s-WebAdmGrp", bucketName)
+		// the web admin group and the dns record both depend on the distribution, but not on each other
+		cg, cctx := newErrGroup(ctx)
 
-	var webGroup *iam.Group
-	if webGroup, err = iamService.CreateGroup(r.Context(), &iam.CreateGroupInput{
-		GroupName: aws.String(webGroupName),
-	}); err != nil {
-		msg := fmt.Sprintf("failed to create web admin group: %s", err.Error())
-		handleError(w, errors.Wrap(err, msg))
-		return
-	}
+		cg.Go(func() error {
+			// build the default IAM web admin policy (from the config and known inputs)
+			defaultWebPolicy, err := iamService.DefaultWebAdminPolicy(distribution.ARN)
+			if err != nil {
+				msg := fmt.Sprintf("failed building default IAM policy for cloudfront distribution %s: %s", aws.StringValue(distribution.ARN), err.Error())
+				return apierror.New(apierror.ErrInternalError, msg, err)
+			}
 
-	// append group delete to rollback tasks
-	rbfunc = func(ctx context.Context) error {
-		return iamService.DeleteGroup(r.Context(), &iam.DeleteGroupInput{GroupName: aws.String(webGroupName)})
-	}
-	rollBackTasks = append(rollBackTasks, rbfunc)
+			webPolicy, webGroup, err = createWebsiteAdminGroup(cctx, iamService, rb, "web admin",
+				iamapi.FormatPolicyName(bucketName, "/", "WebAdmPlc"),
+				fmt.Sprintf("Admin policy for %s web distribution", bucketName),
+				defaultWebPolicy,
+				iamapi.FormatGroupName(bucketName, "/", "WebAdmGrp"),
+				iamTags(req.Tags),
+			)
+			return err
+		})
 
-	if err = iamService.AttachGroupPolicy(r.Context(), &iam.AttachGroupPolicyInput{
-		GroupName: aws.String(webGroupName),
-		PolicyArn: webPolicy.Arn,
-	}); err != nil {
-		msg := fmt.Sprintf("failed to attach policy %s to group %s: %s", aws.StringValue(bktPolicy.Arn), webGroupName, err.Error())
-		handleError(w, errors.Wrap(err, msg))
-		return
-	}
+		cg.Go(func() error {
+			// the A and AAAA alias records are created together
+			records := route53api.AliasRecordSets(bucketName, route53api.CloudFrontAliasTarget(aws.StringValue(distribution.DomainName)))
 
-	// append detach group policy to rollback tasks
-	rbfunc = func(ctx context.Context) error {
-		return iamService.DetachGroupPolicy(r.Context(), &iam.DetachGroupPolicyInput{
-			GroupName: aws.String(webGroupName),
-			PolicyArn: webPolicy.Arn,
+			var err error
+			if dnsChange, err = route53Service.ChangeRecords(cctx, zoneID, recordChanges(route53.ChangeActionCreate, records)); err != nil {
+				msg := fmt.Sprintf("failed to create route53 alias records for website %s: %s", bucketName, err.Error())
+				return errors.Wrap(err, msg)
+			}
+			return nil
 		})
-	}
-	rollBackTasks = append(rollBackTasks, rbfunc)
-
-	var dnsChange *route53.ChangeInfo
-	if dnsChange, err = route53Service.CreateRecord(r.Context(), domain.HostedZoneID, &route53.ResourceRecordSet{
-		AliasTarget: &route53.AliasTarget{
-			DNSName:              distribution.DomainName,
-			HostedZoneId:         aws.String("Z2FDTNDATAQYW2"),
-			EvaluateTargetHealth: aws.Bool(false),
-		},
-		Name: aws.String(bucketName),
-		Type: aws.String("A"),
-	}); err != nil {
-		msg := fmt.Sprintf("failed to create route53 alias record for website %s: %s", bucketName, err.Error())
-		handleError(w, errors.Wrap(err, msg))
-		return
-	}
 
-	// write index file
-	indexMessage := "Hello, " + bucketName + "!"
-	if _, err = s3Service.CreateObject(r.Context(), &s3.PutObjectInput{
-		Bucket:      aws.String(bucketName),
-		Body:        bytes.NewReader([]byte(indexMessage)),
-		ContentType: aws.String("text/html"),
-		Key:         aws.String("index.html"),
-		Tagging:     aws.String("yale:spinup=true"),
-	}); err != nil {
-		msg := fmt.Sprintf("failed to create default index file for website %s: %s", bucketName, err.Error())
-		handleError(w, errors.Wrap(err, msg))
+		return cg.Wait()
+	})
+
+	if err = g.Wait(); err != nil {
+		handleError(w, err)
 		return
 	}
 
+	s.notify(webhook.EventWebsiteCreated, vars["account"], bucketName, nil)
+
+	distribution, deployTask := s.deployWebsiteDistribution(r.Context(), w, wait, lease, vars["account"], bucketName, distribution)
+
 	output := struct {
 		Bucket       *string
 		Policies     []*iam.Policy
 		Groups       []*iam.Group
 		Distribution *cloudfront.Distribution
 		DnsChange    *route53.ChangeInfo
+		Objects      []*websiteObject
+		DeployTask   *task.Task `json:",omitempty"`
 	}{
 		bucketOutput.Location,
 		[]*iam.Policy{bktPolicy, webPolicy},
 		[]*iam.Group{bktGroup, webGroup},
 		distribution,
 		dnsChange,
+		created,
+		deployTask,
 	}
 
 	j, err := json.Marshal(output)
@@ -418,6 +528,48 @@ func (s *server) CreateWebsiteHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write(j)
 }
 
+// createWebsiteAdminGroup creates a (bucket or web) admin policy and group for a website and attaches the policy to the group,
+// adding the rollback steps as each resource is created.  The policy is tagged with the passed tags, groups can't be tagged.
+func createWebsiteAdminGroup(ctx context.Context, iamService iamapi.IAM, rb *rollback.Rollback, kind, policyName, description string, document []byte, groupName string, tags []*iam.Tag) (*iam.Policy, *iam.Group, error) {
+	policy, err := iamService.CreatePolicy(ctx, &iam.CreatePolicyInput{
+		Description:    aws.String(description),
+		PolicyDocument: aws.String(string(document)),
+		PolicyName:     aws.String(policyName),
+		Tags:           tags,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("failed to create %s policy: %s", kind, err.Error())
+		return nil, nil, errors.Wrap(err, msg)
+	}
+
+	// append policy delete to rollback
+	rb.Add("delete policy "+policyName, rollbackDeletePolicy, map[string]string{"policy_arn": aws.StringValue(policy.Arn)})
+
+	group, err := iamService.CreateGroup(ctx, &iam.CreateGroupInput{
+		GroupName: aws.String(groupName),
+	})
+	if err != nil {
+		msg := fmt.Sprintf("failed to create %s group: %s", kind, err.Error())
+		return nil, nil, errors.Wrap(err, msg)
+	}
+
+	// append group delete to rollback
+	rb.Add("delete group "+groupName, rollbackDeleteGroup, map[string]string{"group": groupName})
+
+	if err := iamService.AttachGroupPolicy(ctx, &iam.AttachGroupPolicyInput{
+		GroupName: aws.String(groupName),
+		PolicyArn: policy.Arn,
+	}); err != nil {
+		msg := fmt.Sprintf("failed to attach policy %s to group %s: %s", aws.StringValue(policy.Arn), groupName, err.Error())
+		return nil, nil, errors.Wrap(err, msg)
+	}
+
+	// append detach group policy to rollback
+	rb.Add("detach policy from group "+groupName, rollbackDetachGroupPolicy, map[string]string{"group": groupName, "policy_arn": aws.StringValue(policy.Arn)})
+
+	return policy, group, nil
+}
+
 // WebsiteShowHandler returns information about a static website.  Currently,
 // this includes:
 // - the tags
@@ -430,119 +582,115 @@ func (s *server) WebsiteShowHandler(w http.ResponseWriter, r *http.Request) {
 	accountId := s.mapAccountNumber(vars["account"])
 	website := vars["website"]
 
-	role := fmt.Sprintf("arn:aws:iam::%s:role/%s", accountId, s.session.RoleName)
-	policy, err := generatePolicy("s3:*", "cloudfront:*", "route53:*")
+	session, err := s.sessionForAccount(r.Context(), accountId, "s3:*", "cloudfront:*", "route53:*")
 	if err != nil {
-		log.Errorf("cannot generate policy: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	session, err := s.assumeRole(
-		r.Context(),
-		s.session.ExternalID,
-		role,
-		policy,
-	)
-	if err != nil {
-		log.Errorf("failed to assume role in account: %s", accountId)
-		w.WriteHeader(http.StatusInternalServerError)
+		handleError(w, err)
 		return
 	}
 
 	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
 	cloudFrontService := cfapi.NewSession(session.Session, s.account, accountId)
+	cloudFrontService.Index = s.distributionIndex(accountId)
 	route53Service := route53api.NewSession(session.Session, s.account)
 
-	// get the tags on the bucket backing the website
-	// TODO get tags for other resources (cloudfront, route53, etc)
-	tags, err := s3Service.GetBucketTags(r.Context(), website)
+	output, err := getWebsite(r.Context(), s3Service, cloudFrontService, route53Service, website)
 	if err != nil {
 		handleError(w, err)
 		return
 	}
 
-	// check if the bucket backing the website is empty, ignore the default index page (we'll clean it up)
-	empty, err := s3Service.BucketEmptyWithFilter(r.Context(), website, int64(2), func(key *string) bool {
-		log.Debugf("checking if object %s is 'index.html' and has 'yale:spinup=true' tag", aws.StringValue(key))
-
-		if aws.StringValue(key) != "index.html" {
-			return true
+	// the distribution etag is passed back in the If-Match header when updating the website, a redirect site may not
+	// have a distribution
+	var etag string
+	if output.Distribution != nil {
+		if etag, err = websiteETag(r.Context(), cloudFrontService, output.Distribution); err != nil {
+			handleError(w, err)
+			return
 		}
+	}
 
-		tagging, err := s3Service.Service.GetObjectTaggingWithContext(r.Context(), &s3.GetObjectTaggingInput{
-			Bucket: aws.String(website),
-			Key:    key,
-		})
-		if err != nil {
-			return true
-		}
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	writeConditionalJSON(w, r, output)
+}
 
-		for _, tag := range tagging.TagSet {
-			if aws.StringValue(tag.Key) == "yale:spinup" && aws.StringValue(tag.Value) == "true" {
-				return false
-			}
-		}
+// websiteShowOutput is the response from getting a website
+type websiteShowOutput struct {
+	Tags     []*s3.Tag
+	Logging  *s3.LoggingEnabled
+	Empty    bool
+	Redirect *s3.RedirectAllRequestsTo `json:",omitempty"`
+	// Simple is true for a simple website, it's served from the website endpoint and doesn't have a dns record or
+	// distribution
+	Simple          bool   `json:",omitempty"`
+	WebsiteEndpoint string `json:",omitempty"`
+	DNSRecord       *route53.ResourceRecordSet
+	Distribution    *cloudfront.DistributionSummary
+}
 
-		return true
-	})
+// getWebsite gets the details of the bucket, dns record and cloudfront distribution for a website.  A redirect site
+// may not have a dns record or distribution, and a simple website never does.
+func getWebsite(ctx context.Context, s3Service s3api.S3, cloudFrontService cfapi.CloudFront, route53Service route53api.Route53, website string) (*websiteShowOutput, error) {
+	// get the tags on the bucket backing the website
+	// TODO get tags for other resources (cloudfront, route53, etc)
+	tags, err := s3Service.GetBucketTags(ctx, website)
 	if err != nil {
-		handleError(w, err)
-		return
+		return nil, err
 	}
 
-	// get details about the logging configuration for the s3 bucket backing the website
-	logging, err := s3Service.GetBucketLogging(r.Context(), website)
+	// check if the bucket backing the website is empty, ignore the default index page (we'll clean it up)
+	empty, err := websiteEmpty(ctx, s3Service, website)
 	if err != nil {
-		handleError(w, err)
-		return
+		return nil, err
 	}
 
-	// determine which domain is being referenced
-	domain, err := cloudFrontService.WebsiteDomain(website)
+	// get details about the logging configuration for the s3 bucket backing the website
+	logging, err := s3Service.GetBucketLogging(ctx, website)
 	if err != nil {
-		handleError(w, err)
-		return
+		return nil, err
 	}
 
-	// get the route53 resource record details
-	dns, err := route53Service.GetRecordByName(r.Context(), domain.HostedZoneID, website, "A")
+	redirect, err := websiteRedirectTarget(ctx, s3Service, website)
 	if err != nil {
-		handleError(w, err)
-		return
+		return nil, err
+	}
+
+	if isSimpleWebsite(tags) {
+		return &websiteShowOutput{
+			Tags:            tags,
+			Logging:         logging,
+			Empty:           empty,
+			Simple:          true,
+			WebsiteEndpoint: website + "." + cloudFrontService.WebsiteEndpoint,
+		}, nil
 	}
 
-	dist, err := cloudFrontService.GetDistributionByName(r.Context(), website)
+	// determine which hosted zone the website is in
+	zoneID, err := route53Service.ZoneIDForName(ctx, website)
 	if err != nil {
-		handleError(w, err)
-		return
+		return nil, err
 	}
 
-	// setup output struct
-	output := struct {
-		Tags         []*s3.Tag
-		Logging      *s3.LoggingEnabled
-		Empty        bool
-		DNSRecord    *route53.ResourceRecordSet
-		Distribution *cloudfront.DistributionSummary
-	}{
+	// get the route53 resource record details
+	dns, err := route53Service.GetRecordByName(ctx, zoneID, website, "A")
+	if err != nil && (redirect == nil || !isNotFound(err)) {
+		return nil, err
+	}
+
+	dist, err := cloudFrontService.GetDistributionByName(ctx, website)
+	if err != nil && (redirect == nil || !isNotFound(err)) {
+		return nil, err
+	}
+
+	return &websiteShowOutput{
 		Tags:         tags,
 		Logging:      logging,
 		Empty:        empty,
+		Redirect:     redirect,
 		DNSRecord:    dns,
 		Distribution: dist,
-	}
-
-	j, err := json.Marshal(output)
-	if err != nil {
-		log.Errorf("cannot marshal response (%v) into JSON: %s", output, err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(j)
+	}, nil
 }
 
 // WebsiteDeleteHandler deletes all of the resources for a static website.  The operations are
@@ -561,62 +709,57 @@ func (s *server) WebsiteDeleteHandler(w http.ResponseWriter, r *http.Request) {
 	accountId := s.mapAccountNumber(vars["account"])
 	website := vars["website"]
 
-	role := fmt.Sprintf("arn:aws:iam::%s:role/%s", accountId, s.session.RoleName)
-	policy, err := generatePolicy("s3:*", "iam:*", "cloudfront:*", "route53:*")
+	session, err := s.sessionForScope(r.Context(), accountId, websiteDeletePolicy, policyScope{Bucket: website})
 	if err != nil {
-		log.Errorf("cannot generate policy: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	session, err := s.assumeRole(
-		r.Context(),
-		s.session.ExternalID,
-		role,
-		policy,
-	)
-	if err != nil {
-		log.Errorf("failed to assume role in account: %s", accountId)
-		w.WriteHeader(http.StatusInternalServerError)
+		handleError(w, err)
 		return
 	}
 
 	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
 	iamService := iamapi.NewSession(session.Session, s.account)
+	iamService.Cache = s.resourceCache(accountId)
 	cloudFrontService := cfapi.NewSession(session.Session, s.account, accountId)
+	cloudFrontService.Index = s.distributionIndex(accountId)
 	route53Service := route53api.NewSession(session.Session, s.account)
 
-	domain, err := cloudFrontService.WebsiteDomain(website)
+	// a simple website isn't in one of the domains and doesn't have a hosted zone
+	simple, err := simpleWebsite(r.Context(), s3Service, website)
 	if err != nil {
-		msg := fmt.Sprintf("failed to validate website domain %s", website)
-		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+		handleError(w, err)
 		return
 	}
 
-	// check if the bucket backing the website is empty, ignore the default index page (we'll clean it up)
-	empty, err := s3Service.BucketEmptyWithFilter(r.Context(), website, int64(2), func(key *string) bool {
-		log.Debugf("checking if object %s is 'index.html' and has 'yale:spinup=true' tag", aws.StringValue(key))
-
-		if aws.StringValue(key) != "index.html" {
-			return true
+	if !simple {
+		if _, err := cloudFrontService.WebsiteDomain(website); err != nil {
+			msg := fmt.Sprintf("failed to validate website domain %s", website)
+			handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+			return
 		}
+	}
 
-		tagging, err := s3Service.Service.GetObjectTaggingWithContext(r.Context(), &s3.GetObjectTaggingInput{
-			Bucket: aws.String(website),
-			Key:    key,
-		})
-		if err != nil {
-			return true
-		}
+	lease, err := s.lockResource(r.Context(), vars["account"], website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	defer lease.Release()
 
-		for _, tag := range tagging.TagSet {
-			if aws.StringValue(tag.Key) == "yale:spinup" && aws.StringValue(tag.Value) == "true" {
-				return false
-			}
+	if err := s.checkDeleteProtection(r.Context(), r, s3Service, website); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	var zoneID string
+	if !simple {
+		if zoneID, err = route53Service.ZoneIDForName(r.Context(), website); err != nil {
+			msg := fmt.Sprintf("failed to find the hosted zone for website %s", website)
+			handleError(w, errors.Wrap(err, msg))
+			return
 		}
+	}
 
-		return true
-	})
+	// check if the bucket backing the website is empty, ignore the default index page (we'll clean it up)
+	empty, err := websiteEmpty(r.Context(), s3Service, website)
 	if err != nil {
 		handleError(w, err)
 		return
@@ -628,173 +771,252 @@ func (s *server) WebsiteDeleteHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if _, err := s3Service.DeleteObject(r.Context(), &s3.DeleteObjectInput{
+	// quarantine the website if soft delete is enabled, unless the delete is forced
+	if days := s.softDeleteDays(); days > 0 && r.URL.Query().Get("force") != "true" {
+		output, err := softDeleteWebsite(r.Context(), s3Service, iamService, s.roleArn(accountId), website, days)
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		j, err := json.Marshal(output)
+		if err != nil {
+			log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		s.saveSoftDeleteRecord(r.Context(), vars["account"], output)
+		s.notify(webhook.EventWebsiteSoftDeleted, vars["account"], website, map[string]string{"DeleteAfter": output.DeleteAfter.Format(time.RFC3339)})
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write(j)
+		return
+	}
+
+	output, err := deleteWebsite(r.Context(), s3Service, iamService, cloudFrontService, route53Service, zoneID, website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal reasponse(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// a soft deleted website can be deleted immediately with force
+	s.deleteSoftDeleteRecord(r.Context(), vars["account"], website)
+	s.notify(webhook.EventWebsiteDeleted, vars["account"], website, nil)
+
+	// the bucket is gone, but some of the other resources may have been left behind
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(output.code())
+	w.Write(j)
+}
+
+// websiteDeleteOutput lists the resources removed when a website is deleted, along with the status of each change
+type websiteDeleteOutput struct {
+	Website      *string
+	Users        []*iam.User
+	Policies     []*string
+	Groups       []string
+	Distribution *cloudfront.Distribution
+	DnsChange    *route53.ChangeInfo
+	multiStatus
+}
+
+// websiteEmpty checks if the bucket backing a website is empty, ignoring the default index page since it's cleaned
+// up when the website is deleted
+func websiteEmpty(ctx context.Context, s3Service s3api.S3, website string) (bool, error) {
+	return s3Service.BucketEmptyExceptTagged(ctx, website, []string{defaultIndexKey}, spinupObjectTagKey, spinupObjectTagValue)
+}
+
+// deleteWebsite tears down an empty website: the bucket, the IAM groups, policies and users, the route53 records and
+// the cloudfront distribution, which is disabled and deleted by the cleaner once it's deployed.  An error is only
+// returned if nothing was deleted, once the bucket is gone a failure to delete any of the other resources is recorded
+// in the output's statuses and the teardown continues.  A simple website doesn't have a distribution or dns records,
+// so the zone id is ignored.
+func deleteWebsite(ctx context.Context, s3Service s3api.S3, iamService iamapi.IAM, cloudFrontService cfapi.CloudFront, route53Service route53api.Route53, zoneID, website string) (*websiteDeleteOutput, error) {
+	// a redirect site may have been created without a distribution and dns record
+	redirect, err := websiteRedirectTarget(ctx, s3Service, website)
+	if err != nil {
+		return nil, err
+	}
+
+	simple, err := simpleWebsite(ctx, s3Service, website)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s3Service.DeleteObject(ctx, &s3.DeleteObjectInput{
 		Bucket: aws.String(website),
-		Key:    aws.String("index.html"),
+		Key:    aws.String(defaultIndexKey),
 	}); err != nil {
 		log.Warnf("error trying to delete default index.html: %s", err)
 	}
 
-	if err := s3Service.DeleteEmptyBucket(r.Context(), &s3.DeleteBucketInput{Bucket: aws.String(website)}); err != nil {
-		handleError(w, err)
-		return
+	if err := s3Service.DeleteEmptyBucket(ctx, &s3.DeleteBucketInput{Bucket: aws.String(website)}); err != nil {
+		return nil, err
+	}
+
+	output := &websiteDeleteOutput{
+		Website:     aws.String(website),
+		multiStatus: newMultiStatus(),
 	}
+	output.ok("bucket", website, "delete")
 
-	var groupUsers []*iam.User
-	var groupNames []string
-	var deletedPolicies []*string
-	var users []*iam.User
+	output.Users, output.Policies, output.Groups = deleteWebsiteIAM(ctx, iamService, website, &output.multiStatus)
 
-	foundGroups, err := iamService.ListGroups(r.Context(), &iam.ListGroupsInput{MaxItems: aws.Int64(1000)}, website)
+	if simple {
+		log.Infof("simple website %s doesn't have a distribution", website)
+		return output, nil
+	}
+
+	// find the cloudfront distribution from the website name
+	distributionSummary, err := cloudFrontService.GetDistributionByName(ctx, website)
 	if err != nil {
-		log.Errorf("there was an error listing groups %s", err)
+		if redirect != nil && isNotFound(err) {
+			log.Infof("redirect website %s doesn't have a distribution", website)
+			return output, nil
+		}
+
+		output.fail("distribution", website, "find", err)
+		return output, nil
+	}
+
+	// delete the alias record from route53, along with the failover records and health check if there's a failover
+	if output.DnsChange, err = deleteWebsiteRecords(ctx, route53Service, zoneID, website); err != nil {
+		output.fail("dns", website, "delete", err)
+	} else {
+		output.ok("dns", website, "delete")
 	}
 
+	// disable the distribution, deletion will occur asynchronously
+	if err = retry.Do(ctx, cloudFrontRetry, func(ctx context.Context) error {
+		var err error
+		output.Distribution, err = cloudFrontService.DisableDistribution(ctx, aws.StringValue(distributionSummary.Id))
+		return err
+	}); err != nil {
+		output.fail("distribution", aws.StringValue(distributionSummary.Id), "disable", err)
+	} else {
+		output.ok("distribution", aws.StringValue(distributionSummary.Id), "disable")
+	}
+
+	return output, nil
+}
+
+// deleteWebsiteIAM deletes the website's groups and their users, along with the policies that were created for the
+// website.  It returns the website's users, the deleted policies and the website's groups, the change to each of them
+// is recorded in the status and a failure doesn't stop the others from being deleted.
+func deleteWebsiteIAM(ctx context.Context, iamService iamapi.IAM, website string, status *multiStatus) ([]*iam.User, []*string, []string) {
+	groupUsers := []*iam.User{}
+	groupNames := []string{}
+	deletedPolicies := []*string{}
+
+	foundGroups, err := iamService.ListGroups(ctx, &iam.ListGroupsInput{MaxItems: aws.Int64(1000)}, website)
+	if err != nil {
+		status.fail("group", website, "list", err)
+		return groupUsers, deletedPolicies, groupNames
+	}
+
+	seen := map[string]bool{}
 	for _, foundGroup := range foundGroups {
 		groupName := aws.StringValue(foundGroup.GroupName)
 		groupNames = append(groupNames, groupName)
 
-		policies, err := iamService.ListGroupPolicies(r.Context(), &iam.ListAttachedGroupPoliciesInput{GroupName: aws.String(groupName)})
+		policies, err := iamService.ListGroupPolicies(ctx, &iam.ListAttachedGroupPoliciesInput{GroupName: aws.String(groupName)})
 		if err != nil {
-			log.Warnf("failed to list group policies when deleting website %s: %s", website, err)
-			j, _ := json.Marshal("failed to list group policies: " + err.Error())
-			w.Write(j)
+			status.fail("group", groupName, "list policies", err)
 		}
 
 		for _, p := range policies {
-			if err := iamService.DetachGroupPolicy(r.Context(), &iam.DetachGroupPolicyInput{
+			if err := iamService.DetachGroupPolicy(ctx, &iam.DetachGroupPolicyInput{
 				GroupName: foundGroup.GroupName,
 				PolicyArn: p.PolicyArn,
 			}); err != nil {
-				log.Warnf("failed to detach policy %s from group %s when deleting website %s: %s", aws.StringValue(p.PolicyArn), policies, website, err)
-				j, _ := json.Marshal("failed to detatch group policy: " + err.Error())
-				w.Write(j)
+				status.fail("policy", aws.StringValue(p.PolicyArn), "detach", err)
 				continue
 			}
 
-			if strings.HasPrefix(aws.StringValue(p.PolicyName), website+"-") {
-				if err := iamService.DeletePolicy(r.Context(), &iam.DeletePolicyInput{PolicyArn: p.PolicyArn}); err != nil {
-					log.Warnf("failed to delete group policy %s when deleting website %s: %s", aws.StringValue(p.PolicyArn), website, err)
-					j, _ := json.Marshal("failed to delete group policy: " + err.Error())
-					w.Write(j)
+			if iamapi.IsBucketPolicy(website, aws.StringValue(p.PolicyName)) {
+				if err := iamService.DeletePolicy(ctx, &iam.DeletePolicyInput{PolicyArn: p.PolicyArn}); err != nil {
+					status.fail("policy", aws.StringValue(p.PolicyName), "delete", err)
 					continue
 				}
+				status.ok("policy", aws.StringValue(p.PolicyName), "delete")
 				deletedPolicies = append(deletedPolicies, p.PolicyName)
 			}
 		}
 
-		u, err := iamService.ListGroupUsers(r.Context(), &iam.GetGroupInput{GroupName: foundGroup.GroupName})
+		users, err := iamService.ListGroupUsers(ctx, &iam.GetGroupInput{GroupName: foundGroup.GroupName})
 		if err != nil {
-			log.Warnf("failed to list group's users when deleting website %s: %s", website, err)
-			j, _ := json.Marshal("failed to list group users: " + err.Error())
-			w.Write(j)
+			status.fail("group", groupName, "list users", err)
 		}
-		users = append(users, u...)
 
 		for _, u := range users {
-			// get a users access keys
-			keys, err := iamService.ListAccessKeys(r.Context(), &iam.ListAccessKeysInput{UserName: u.UserName})
-			if err != nil {
-				handleError(w, err)
-				return
+			userName := aws.StringValue(u.UserName)
+
+			// the user's access keys are deleted first so it can't be used if deleting it fails
+			if err := deleteUserAccessKeys(ctx, iamService, userName); err != nil {
+				status.fail("user", userName, "delete access keys", err)
 			}
 
-			// delete the access keys
-			for _, k := range keys {
-				err = iamService.DeleteAccessKey(r.Context(), &iam.DeleteAccessKeyInput{UserName: u.UserName, AccessKeyId: k.AccessKeyId})
-				if err != nil {
-					handleError(w, err)
-					return
-				}
+			log.Infof("removing user %s from group %s", userName, groupName)
+			if err := iamService.RemoveUserFromGroup(ctx, &iam.RemoveUserFromGroupInput{UserName: u.UserName, GroupName: aws.String(groupName)}); err != nil {
+				status.fail("user", userName, "remove from group "+groupName, err)
 			}
 
-			log.Infof("removing user %s from group %s", aws.StringValue(u.UserName), groupName)
-			if err := iamService.RemoveUserFromGroup(r.Context(), &iam.RemoveUserFromGroupInput{UserName: u.UserName, GroupName: aws.String(groupName)}); err != nil {
-				log.Warnf("failed to remove user %s from group %s when deleting website %s: %s", aws.StringValue(u.UserName), groupName, website, err)
-				j, _ := json.Marshal("failed to remove user from bucket admin group: " + err.Error())
-				w.Write(j)
-				return
+			// a user can be in more than one of the website's groups
+			if !seen[userName] {
+				seen[userName] = true
+				groupUsers = append(groupUsers, u)
 			}
 		}
 
-		groupUsers = append(groupUsers, users...)
-
-		if err := iamService.DeleteGroup(r.Context(), &iam.DeleteGroupInput{GroupName: aws.String(groupName)}); err != nil {
-			log.Warnf("failed to delete group %s when deleting website %s: %s", groupName, website, err)
-			j, _ := json.Marshal("failed to delete group: " + err.Error())
-			w.Write(j)
+		if err := iamService.DeleteGroup(ctx, &iam.DeleteGroupInput{GroupName: aws.String(groupName)}); err != nil {
+			status.fail("group", groupName, "delete", err)
+		} else {
+			status.ok("group", groupName, "delete")
 		}
 	}
 
 	for _, groupUser := range groupUsers {
-		_, err := iamService.GetUser(r.Context(), &iam.GetUserInput{
-			UserName: groupUser.UserName,
-		})
-		if err == nil {
-			err = iamService.DeleteUser(r.Context(), &iam.DeleteUserInput{UserName: groupUser.UserName})
-			if err != nil {
-				log.Warnf("failed to delete user: %s, %s", aws.StringValue(groupUser.UserName), err)
+		userName := aws.StringValue(groupUser.UserName)
+		if _, err := iamService.GetUser(ctx, &iam.GetUserInput{UserName: groupUser.UserName}); err != nil {
+			if !isNotFound(err) {
+				status.fail("user", userName, "get", err)
 			}
+			continue
 		}
-	}
 
-	// find the cloudfront distribution from the website name
-	distributionSummary, err := cloudFrontService.GetDistributionByName(r.Context(), website)
-	if err != nil {
-		handleError(w, err)
-		return
+		if err := iamService.DeleteUserAndAttachments(ctx, userName); err != nil {
+			status.fail("user", userName, "delete", err)
+			continue
+		}
+		status.ok("user", userName, "delete")
 	}
 
-	// delete the alias record from route53
-	dnsChange, err := route53Service.DeleteRecord(r.Context(), domain.HostedZoneID, &route53.ResourceRecordSet{
-		AliasTarget: &route53.AliasTarget{
-			DNSName:              distributionSummary.DomainName,
-			HostedZoneId:         aws.String("Z2FDTNDATAQYW2"),
-			EvaluateTargetHealth: aws.Bool(false),
-		},
-		Name: aws.String(website),
-		Type: aws.String("A"),
-	})
-	if err != nil {
-		msg := fmt.Sprintf("failed to delete route53 alias record for website %s: %s", website, err.Error())
-		handleError(w, errors.Wrap(err, msg))
-		return
-	}
+	return groupUsers, deletedPolicies, groupNames
+}
 
-	// disable the distribution, deletion will occur asynchronously
-	distribution, err := cloudFrontService.DisableDistribution(r.Context(), aws.StringValue(distributionSummary.Id))
+// deleteUserAccessKeys deletes all of the access keys of a user
+func deleteUserAccessKeys(ctx context.Context, iamService iamapi.IAM, userName string) error {
+	keys, err := iamService.ListAccessKeys(ctx, &iam.ListAccessKeysInput{UserName: aws.String(userName)})
 	if err != nil {
-		msg := fmt.Sprintf("failed to disable cloudfront distribution for website %s: %s", website, err.Error())
-		handleError(w, errors.Wrap(err, msg))
-		return
-	}
-
-	output := struct {
-		Website      *string
-		Users        []*iam.User
-		Policies     []*string
-		Groups       []string
-		Distribution *cloudfront.Distribution
-		DnsChange    *route53.ChangeInfo
-	}{
-		aws.String(website),
-		groupUsers,
-		deletedPolicies,
-		groupNames,
-		distribution,
-		dnsChange,
+		return err
 	}
 
-	j, err := json.Marshal(output)
-	if err != nil {
-		log.Errorf("cannot marshal reasponse(%v) into JSON: %s", output, err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
+	for _, k := range keys {
+		if err := iamService.DeleteAccessKey(ctx, &iam.DeleteAccessKeyInput{UserName: aws.String(userName), AccessKeyId: k.AccessKeyId}); err != nil {
+			return err
+		}
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(j)
+	return nil
 }
 
 func (s *server) WebsitePartialUpdateHandler(w http.ResponseWriter, r *http.Request) {
@@ -803,30 +1025,19 @@ func (s *server) WebsitePartialUpdateHandler(w http.ResponseWriter, r *http.Requ
 	accountId := s.mapAccountNumber(vars["account"])
 	website := vars["website"]
 
-	role := fmt.Sprintf("arn:aws:iam::%s:role/%s", accountId, s.session.RoleName)
-	policy, err := generatePolicy("cloudfront:*")
+	session, err := s.sessionForAccount(r.Context(), accountId, "cloudfront:*")
 	if err != nil {
-		log.Errorf("cannot generate policy: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	session, err := s.assumeRole(
-		r.Context(),
-		s.session.ExternalID,
-		role,
-		policy,
-	)
-	if err != nil {
-		log.Errorf("failed to assume role in account: %s", accountId)
-		w.WriteHeader(http.StatusInternalServerError)
+		handleError(w, err)
 		return
 	}
 
 	cloudFrontService := cfapi.NewSession(session.Session, s.account, accountId)
+	cloudFrontService.Index = s.distributionIndex(accountId)
 
 	var req struct {
 		CacheInvalidation []string
+		// Canary is invalidated and checked before the CacheInvalidation paths, in a background task
+		Canary *cacheCanary
 	}
 	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
@@ -835,6 +1046,19 @@ func (s *server) WebsitePartialUpdateHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	f := fieldErrors{}
+	f.invalidationPaths("CacheInvalidation", req.CacheInvalidation)
+
+	var wait time.Duration
+	if req.Canary != nil {
+		wait = req.Canary.validate(&f, "Canary")
+	}
+
+	if err = f.err(); err != nil {
+		handleError(w, err)
+		return
+	}
+
 	// find the cloudfront distribution from the website name
 	distributionSummary, err := cloudFrontService.GetDistributionByName(r.Context(), website)
 	if err != nil {
@@ -842,6 +1066,17 @@ func (s *server) WebsitePartialUpdateHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	if req.Canary != nil {
+		t, err := s.startCanaryInvalidation(vars["account"], website, aws.StringValue(distributionSummary.Id), req.CacheInvalidation, req.Canary, wait)
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		writeTask(w, http.StatusAccepted, t)
+		return
+	}
+
 	out, err := cloudFrontService.InvalidateCache(r.Context(), aws.StringValue(distributionSummary.Id), req.CacheInvalidation)
 	if err != nil {
 		handleError(w, err)
@@ -861,38 +1096,31 @@ func (s *server) WebsitePartialUpdateHandler(w http.ResponseWriter, r *http.Requ
 }
 
 // WebsiteUpdateHandler handles updating making changes to a website.  Currently supports:
-// - Updating the bucket's tags
-// - Update the cloudfront distribution's tags
+// - Updating the bucket's tags, which are synced to the cloudfront distribution and the website's IAM resources
+// - Updating the geo restriction of the cloudfront distribution
+// - Updating the http version and IPv6 settings of the cloudfront distribution
+// The tags are left as is if only distribution settings are passed
 func (s *server) WebsiteUpdateHandler(w http.ResponseWriter, r *http.Request) {
 	w = LogWriter{w}
 	vars := mux.Vars(r)
 	accountId := s.mapAccountNumber(vars["account"])
 	website := vars["website"]
-	role := fmt.Sprintf("arn:aws:iam::%s:role/%s", accountId, s.session.RoleName)
-	policy, err := generatePolicy("s3:*", "cloudfront:*")
-	if err != nil {
-		log.Errorf("cannot generate policy: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	session, err := s.assumeRole(
-		r.Context(),
-		s.session.ExternalID,
-		role,
-		policy,
-	)
+	session, err := s.sessionForAccount(r.Context(), accountId, append([]string{"s3:*", "cloudfront:*"}, tagSyncActions...)...)
 	if err != nil {
-		log.Errorf("failed to assume role in account: %s", accountId)
-		w.WriteHeader(http.StatusInternalServerError)
+		handleError(w, err)
 		return
 	}
 
 	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+	iamService := iamapi.NewSession(session.Session, s.account)
+	iamService.Cache = s.resourceCache(accountId)
 	cloudFrontService := cfapi.NewSession(session.Session, s.account, accountId)
+	cloudFrontService.Index = s.distributionIndex(accountId)
 
 	var req struct {
-		Tags []*s3.Tag
+		Tags           []*s3.Tag
+		GeoRestriction *cfapi.GeoRestriction
+		cfapi.ProtocolSettings
 	}
 	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
@@ -901,43 +1129,90 @@ func (s *server) WebsiteUpdateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// append org tag that will get applied to all resources that tag
-	req.Tags = append(req.Tags, &s3.Tag{
-		Key:   aws.String("spinup:org"),
-		Value: aws.String(Org),
-	})
+	updateTags := req.Tags != nil || (req.GeoRestriction == nil && req.ProtocolSettings.Empty())
 
-	// find the cloudfront distribution from the website name
-	distributionSummary, err := cloudFrontService.GetDistributionByName(r.Context(), website)
+	// the tags replace all of the tags on the website bucket, so they must include the required tags
+	f := fieldErrors{}
+	if updateTags {
+		f.tags("Tags", req.Tags)
+		f.requiredTags("Tags", req.Tags, s.requiredTags)
+	}
+
+	if req.GeoRestriction != nil {
+		f.geoRestriction("GeoRestriction", req.GeoRestriction)
+	}
+
+	f.httpVersion("HttpVersion", req.HttpVersion)
+
+	if err = f.err(); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	// make sure the website has a cloudfront distribution
+	distribution, err := cloudFrontService.GetDistributionByName(r.Context(), website)
 	if err != nil {
 		handleError(w, err)
 		return
 	}
 
-	if len(req.Tags) > 0 {
-		err = s3Service.TagBucket(r.Context(), website, req.Tags)
+	// make sure the distribution hasn't changed since it was read if there are preconditions, or they're required
+	if hasPreconditions(r) || s.requireIfMatch {
+		etag, err := websiteETag(r.Context(), cloudFrontService, distribution)
 		if err != nil {
-			msg := fmt.Sprintf("failed to tag website bucket %s: %s", website, err.Error())
-			handleError(w, apierror.New(apierror.ErrInternalError, msg, err))
+			handleError(w, err)
 			return
 		}
 
-		// normalize tags
-		cfTags := []*cloudfront.Tag{}
-		for _, tag := range req.Tags {
-			t := &cloudfront.Tag{
-				Key:   tag.Key,
-				Value: tag.Value,
-			}
-			cfTags = append(cfTags, t)
+		if err := s.checkPreconditions(r, etag); err != nil {
+			handleError(w, err)
+			return
 		}
+	}
+
+	if req.GeoRestriction != nil {
+		if _, err = cloudFrontService.UpdateGeoRestriction(r.Context(), aws.StringValue(distribution.Id), req.GeoRestriction); err != nil {
+			msg := fmt.Sprintf("failed to update geo restriction for website %s", website)
+			handleError(w, errors.Wrap(err, msg))
+			return
+		}
+	}
 
-		err = cloudFrontService.TagDistribution(r.Context(), aws.StringValue(distributionSummary.ARN), &cloudfront.Tags{Items: cfTags})
+	if !req.ProtocolSettings.Empty() {
+		if _, err = cloudFrontService.UpdateProtocolSettings(r.Context(), aws.StringValue(distribution.Id), &req.ProtocolSettings); err != nil {
+			msg := fmt.Sprintf("failed to update protocol settings for website %s", website)
+			handleError(w, errors.Wrap(err, msg))
+			return
+		}
+	}
+
+	if updateTags {
+		current, err := s3Service.GetBucketTags(r.Context(), website)
 		if err != nil {
-			msg := fmt.Sprintf("failed to tag website cloudfront distribution %s: %s", website, err.Error())
-			handleError(w, apierror.New(apierror.ErrInternalError, msg, err))
+			handleError(w, err)
 			return
 		}
+
+		// append org tag that will get applied to all resources that tag, and keep the reserved tags
+		req.Tags = keepReservedTags(current, append(req.Tags, &s3.Tag{
+			Key:   aws.String("spinup:org"),
+			Value: aws.String(Org),
+		}))
+
+		if len(req.Tags) > 0 {
+			err = s3Service.TagBucket(r.Context(), website, req.Tags)
+			if err != nil {
+				msg := fmt.Sprintf("failed to tag website bucket %s: %s", website, err.Error())
+				handleError(w, apierror.New(apierror.ErrInternalError, msg, err))
+				return
+			}
+
+			if _, err = syncBucketTags(r.Context(), iamService, cloudFrontService, s.requiredTags, website, req.Tags); err != nil {
+				msg := fmt.Sprintf("failed to sync tags for website %s: %s", website, err.Error())
+				handleError(w, errors.Wrap(err, msg))
+				return
+			}
+		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
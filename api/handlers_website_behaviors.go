@@ -0,0 +1,185 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/YaleSpinup/apierror"
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// websiteBehaviorsActions are the actions needed to manage the cache behaviors of a website
+var websiteBehaviorsActions = []string{
+	"cloudfront:ListDistributions",
+	"cloudfront:GetDistributionConfig",
+	"cloudfront:UpdateDistribution",
+	"cloudfront:ListPublicKeys",
+	"cloudfront:CreatePublicKey",
+	"cloudfront:ListKeyGroups",
+	"cloudfront:CreateKeyGroup",
+	"cloudfront:GetKeyGroupConfig",
+	"cloudfront:UpdateKeyGroup",
+}
+
+// websiteBehaviorsRequest is the request to replace the additional cache behaviors of a website
+type websiteBehaviorsRequest struct {
+	// CacheBehaviors are the additional cache behaviors in the order they're matched, an empty list removes them
+	CacheBehaviors []*cfapi.CacheBehavior
+}
+
+// websiteBehaviorsOutput is the additional cache behaviors of a website
+type websiteBehaviorsOutput struct {
+	Website        string
+	CacheBehaviors []*cfapi.CacheBehavior
+	// Status is the status of the distribution after the behaviors are updated
+	Status string `json:",omitempty"`
+}
+
+// WebsiteBehaviorsShowHandler returns the additional cache behaviors of a website's distribution
+func (s *server) WebsiteBehaviorsShowHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	website := vars["website"]
+
+	session, err := s.sessionForAccount(r.Context(), vars["account"], websiteBehaviorsActions...)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	cloudFrontService := cfapi.NewSession(session.Session, s.account, accountId)
+	cloudFrontService.Index = s.distributionIndex(accountId)
+
+	distribution, err := cloudFrontService.GetDistributionByName(r.Context(), website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	behaviors, err := cloudFrontService.GetCacheBehaviors(r.Context(), aws.StringValue(distribution.Id))
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	// the distribution etag is passed back in the If-Match header when updating the behaviors
+	etag, err := websiteETag(r.Context(), cloudFrontService, distribution)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	output := websiteBehaviorsOutput{
+		Website:        website,
+		CacheBehaviors: behaviors,
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag)
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// WebsiteBehaviorsUpdateHandler replaces the additional cache behaviors of a website's distribution.  The behaviors
+// are merged into the current distribution config, leaving the default cache behavior and the rest of the
+// configuration as is.  The origins are added for the external origins the behaviors use, and the external origins
+// that aren't used anymore are removed.
+func (s *server) WebsiteBehaviorsUpdateHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	website := vars["website"]
+
+	var req websiteBehaviorsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		msg := fmt.Sprintf("cannot decode body into website cache behaviors input: %s", err)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+		return
+	}
+
+	f := fieldErrors{}
+	f.cacheBehaviors("CacheBehaviors", req.CacheBehaviors)
+	if err := f.err(); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	session, err := s.sessionForAccount(r.Context(), vars["account"], websiteBehaviorsActions...)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	cloudFrontService := cfapi.NewSession(session.Session, s.account, accountId)
+	cloudFrontService.Index = s.distributionIndex(accountId)
+
+	lease, err := s.lockResource(r.Context(), vars["account"], website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	defer lease.Release()
+
+	distribution, err := cloudFrontService.GetDistributionByName(r.Context(), website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	// make sure the distribution hasn't changed since it was read if there are preconditions, or they're required
+	if hasPreconditions(r) || s.requireIfMatch {
+		etag, err := websiteETag(r.Context(), cloudFrontService, distribution)
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		if err := s.checkPreconditions(r, etag); err != nil {
+			handleError(w, err)
+			return
+		}
+	}
+
+	// the signed behaviors trust the signed urls key group, it's created in the account if it doesn't exist
+	if err := s.signBehaviors(r.Context(), cloudFrontService, req.CacheBehaviors); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	updated, err := cloudFrontService.UpdateCacheBehaviors(r.Context(), aws.StringValue(distribution.Id), website, req.CacheBehaviors)
+	if err != nil {
+		msg := fmt.Sprintf("failed to update cache behaviors for website %s", website)
+		handleError(w, errors.Wrap(err, msg))
+		return
+	}
+
+	output := websiteBehaviorsOutput{
+		Website:        website,
+		CacheBehaviors: cfapi.CacheBehaviors(updated.DistributionConfig),
+		Status:         aws.StringValue(updated.Status),
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
@@ -0,0 +1,341 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+	route53api "github.com/YaleSpinup/s3-api/route53"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultDNSRecordTTL is the ttl for website dns records if one isn't passed
+	defaultDNSRecordTTL = 300
+	// maxDNSRecordTTL is the maximum ttl for website dns records
+	maxDNSRecordTTL = 172800
+	// maxTXTValueLength is the maximum length of a TXT record string
+	maxTXTValueLength = 255
+)
+
+// websiteDNSRecordTypes are the types of dns records that can be managed for a website, the A and AAAA records are
+// address records with a ttl for names within the website, the website name itself is an alias for the distribution.
+// In a private zone, A and AAAA records can also be alias records for an internal load balancer or endpoint.
+var websiteDNSRecordTypes = []string{"CNAME", "TXT", "A", "AAAA"}
+
+// websiteDNSActions are the actions needed to manage the dns records for a website
+var websiteDNSActions = []string{
+	"s3:ListBucket",
+	"route53:ListHostedZones",
+	"route53:GetHostedZone",
+	"route53:ListResourceRecordSets",
+	"route53:ChangeResourceRecordSets",
+}
+
+// websiteDNSRecord is an additional dns record for a website, ie. a site verification TXT record or a CNAME
+// for a subdomain of the website
+type websiteDNSRecord struct {
+	// Name is the fully qualified name of the record, the website name or a name within it
+	Name string
+	// Type is CNAME, TXT, A or AAAA
+	Type string
+	// TTL is the record ttl in seconds (default 300)
+	TTL int64 `json:",omitempty"`
+	// Values are the record values, TXT values are quoted if they aren't already
+	Values []string
+	// AliasTarget makes an A or AAAA record in a private zone an alias for an internal load balancer or endpoint,
+	// an alias record doesn't have a ttl or values
+	AliasTarget *websiteDNSAliasTarget `json:",omitempty"`
+}
+
+// websiteDNSAliasTarget is the target of an alias record, ie. an internal application load balancer or an s3
+// interface endpoint that an internal-only website is served from
+type websiteDNSAliasTarget struct {
+	// DNSName is the dns name of the load balancer or endpoint
+	DNSName string
+	// HostedZoneId is the id of the load balancer's or endpoint's hosted zone, not the website's
+	HostedZoneId string
+	// EvaluateTargetHealth only answers with the target if it's healthy
+	EvaluateTargetHealth bool `json:",omitempty"`
+}
+
+// validate validates a dns record for the passed website, private is true if the website is in a private zone
+func (r *websiteDNSRecord) validate(website string, private bool) error {
+	f := fieldErrors{}
+	f.dnsRecord(r, website, private)
+	return f.err()
+}
+
+// resourceRecordSet returns the route53 resource record set for the record
+func (r *websiteDNSRecord) resourceRecordSet() *route53.ResourceRecordSet {
+	if r.AliasTarget != nil {
+		return &route53.ResourceRecordSet{
+			Name: aws.String(strings.ToLower(strings.TrimSuffix(r.Name, "."))),
+			Type: aws.String(r.Type),
+			AliasTarget: &route53.AliasTarget{
+				DNSName:              aws.String(strings.ToLower(strings.TrimSuffix(r.AliasTarget.DNSName, "."))),
+				HostedZoneId:         aws.String(r.AliasTarget.HostedZoneId),
+				EvaluateTargetHealth: aws.Bool(r.AliasTarget.EvaluateTargetHealth),
+			},
+		}
+	}
+
+	ttl := r.TTL
+	if ttl == 0 {
+		ttl = defaultDNSRecordTTL
+	}
+
+	records := []*route53.ResourceRecord{}
+	for _, v := range r.Values {
+		if r.Type == "TXT" && !strings.HasPrefix(v, `"`) {
+			v = `"` + v + `"`
+		}
+		records = append(records, &route53.ResourceRecord{Value: aws.String(v)})
+	}
+
+	return &route53.ResourceRecordSet{
+		Name:            aws.String(strings.ToLower(strings.TrimSuffix(r.Name, "."))),
+		Type:            aws.String(r.Type),
+		TTL:             aws.Int64(ttl),
+		ResourceRecords: records,
+	}
+}
+
+// websiteDNSRecordFromSet converts a route53 resource record set to a website dns record
+func websiteDNSRecordFromSet(rs *route53.ResourceRecordSet) websiteDNSRecord {
+	values := []string{}
+	for _, rr := range rs.ResourceRecords {
+		values = append(values, aws.StringValue(rr.Value))
+	}
+
+	record := websiteDNSRecord{
+		Name:   strings.TrimSuffix(aws.StringValue(rs.Name), "."),
+		Type:   aws.StringValue(rs.Type),
+		TTL:    aws.Int64Value(rs.TTL),
+		Values: values,
+	}
+
+	if rs.AliasTarget != nil {
+		record.AliasTarget = &websiteDNSAliasTarget{
+			DNSName:              strings.TrimSuffix(aws.StringValue(rs.AliasTarget.DNSName), "."),
+			HostedZoneId:         aws.StringValue(rs.AliasTarget.HostedZoneId),
+			EvaluateTargetHealth: aws.BoolValue(rs.AliasTarget.EvaluateTargetHealth),
+		}
+	}
+
+	return record
+}
+
+// websiteDNSRecords returns the CNAME, TXT, A and AAAA records within the website name, sorted by name and type.  The
+// alias records for the distribution are left out since they're managed with the website, the alias records in a
+// private zone are included since they're managed here.
+func websiteDNSRecords(website string, recordSets []*route53.ResourceRecordSet, private bool) []websiteDNSRecord {
+	records := []websiteDNSRecord{}
+	for _, rs := range recordSets {
+		if rs.AliasTarget != nil && !private {
+			continue
+		}
+
+		record := websiteDNSRecordFromSet(rs)
+		if contains(websiteDNSRecordTypes, record.Type) && inWebsiteName(record.Name, website) {
+			records = append(records, record)
+		}
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Name == records[j].Name {
+			return records[i].Type < records[j].Type
+		}
+		return records[i].Name < records[j].Name
+	})
+
+	return records
+}
+
+// inWebsiteName returns true if the name is the website name or a name within it
+func inWebsiteName(name, website string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	website = strings.ToLower(website)
+	return name == website || strings.HasSuffix(name, "."+website)
+}
+
+// privateDomain returns the configuration of the website's domain if it's a private zone, otherwise it's nil
+func (s *server) privateDomain(website string) *common.Domain {
+	_, domain := route53api.DomainForName(s.account.Domains, website)
+	if domain == nil || !domain.PrivateZone {
+		return nil
+	}
+
+	return domain
+}
+
+// websiteDNSServices assumes the role for managing the dns records of a website and returns the route53 service and
+// the id of the website's hosted zone, after checking the website exists.  If the website is in a private zone, the
+// zone must be associated with the vpcs configured for its domain.
+func (s *server) websiteDNSServices(r *http.Request, account, website string) (route53api.Route53, string, error) {
+	accountId := s.mapAccountNumber(account)
+
+	session, err := s.sessionForAccount(r.Context(), account, websiteDNSActions...)
+	if err != nil {
+		return route53api.Route53{}, "", err
+	}
+
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+	route53Service := route53api.NewSession(session.Session, s.account)
+
+	exists, err := s3Service.BucketExists(r.Context(), website)
+	if err != nil {
+		return route53api.Route53{}, "", err
+	}
+
+	if !exists {
+		msg := fmt.Sprintf("website %s not found", website)
+		return route53api.Route53{}, "", apierror.New(apierror.ErrNotFound, msg, nil)
+	}
+
+	zoneID, err := route53Service.ZoneIDForName(r.Context(), website)
+	if err != nil {
+		return route53api.Route53{}, "", err
+	}
+
+	if domain := s.privateDomain(website); domain != nil && len(domain.VpcIds) > 0 {
+		if err := route53Service.CheckVPCAssociations(r.Context(), zoneID, domain.VpcIds); err != nil {
+			return route53api.Route53{}, "", err
+		}
+	}
+
+	return route53Service, zoneID, nil
+}
+
+// WebsiteDNSListHandler lists the CNAME, TXT, A and AAAA records within a website's name
+func (s *server) WebsiteDNSListHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	website := vars["website"]
+
+	route53Service, zoneID, err := s.websiteDNSServices(r, vars["account"], website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	recordSets, err := route53Service.ListRecords(r.Context(), zoneID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	output := websiteDNSRecords(website, recordSets, s.privateDomain(website) != nil)
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// WebsiteDNSCreateHandler creates a CNAME, TXT, A or AAAA record within a website's name
+func (s *server) WebsiteDNSCreateHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	website := vars["website"]
+
+	var req websiteDNSRecord
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		msg := fmt.Sprintf("cannot decode body into website dns record: %s", err)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+		return
+	}
+	req.Type = strings.ToUpper(req.Type)
+
+	if err := req.validate(website, s.privateDomain(website) != nil); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	route53Service, zoneID, err := s.websiteDNSServices(r, vars["account"], website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	recordSet := req.resourceRecordSet()
+	if _, err := route53Service.CreateRecord(r.Context(), zoneID, recordSet); err != nil {
+		msg := fmt.Sprintf("failed to create %s record %s for website %s", req.Type, req.Name, website)
+		handleError(w, errors.Wrap(err, msg))
+		return
+	}
+
+	output := websiteDNSRecordFromSet(recordSet)
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// WebsiteDNSDeleteHandler deletes a CNAME, TXT, A or AAAA record within a website's name
+func (s *server) WebsiteDNSDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	website := vars["website"]
+	recordType := strings.ToUpper(vars["type"])
+	name := strings.ToLower(strings.TrimSuffix(vars["name"], "."))
+	private := s.privateDomain(website) != nil
+
+	f := fieldErrors{}
+	f.dnsRecordType("type", recordType)
+	f.dnsRecordName("name", name, recordType, website, private)
+	if err := f.err(); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	route53Service, zoneID, err := s.websiteDNSServices(r, vars["account"], website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	recordSet, err := route53Service.GetRecordByName(r.Context(), zoneID, name, recordType)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if recordSet.AliasTarget != nil && !private {
+		msg := fmt.Sprintf("%s record %s is an alias record, it can't be deleted", recordType, name)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, nil))
+		return
+	}
+
+	if _, err := route53Service.DeleteRecord(r.Context(), zoneID, recordSet); err != nil {
+		msg := fmt.Sprintf("failed to delete %s record %s for website %s", recordType, name, website)
+		handleError(w, errors.Wrap(err, msg))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte{})
+}
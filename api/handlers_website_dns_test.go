@@ -0,0 +1,151 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+func TestWebsiteDNSRecordResourceRecordSet(t *testing.T) {
+	tests := []struct {
+		record   websiteDNSRecord
+		expected *route53.ResourceRecordSet
+	}{
+		{
+			record: websiteDNSRecord{Name: "WWW.example.com.", Type: "TXT", Values: []string{"google-site-verification=abc123", `"already quoted"`}},
+			expected: &route53.ResourceRecordSet{
+				Name: aws.String("www.example.com"),
+				Type: aws.String("TXT"),
+				TTL:  aws.Int64(defaultDNSRecordTTL),
+				ResourceRecords: []*route53.ResourceRecord{
+					{Value: aws.String(`"google-site-verification=abc123"`)},
+					{Value: aws.String(`"already quoted"`)},
+				},
+			},
+		},
+		{
+			record: websiteDNSRecord{Name: "docs.www.example.com", Type: "CNAME", TTL: 60, Values: []string{"example.github.io"}},
+			expected: &route53.ResourceRecordSet{
+				Name: aws.String("docs.www.example.com"),
+				Type: aws.String("CNAME"),
+				TTL:  aws.Int64(60),
+				ResourceRecords: []*route53.ResourceRecord{
+					{Value: aws.String("example.github.io")},
+				},
+			},
+		},
+		{
+			record: websiteDNSRecord{Name: "Docs.internal.example.com", Type: "A", AliasTarget: &websiteDNSAliasTarget{DNSName: "Internal-Docs-ALB-123456789.us-east-1.elb.amazonaws.com.", HostedZoneId: "Z35SXDOTRQ7X7K"}},
+			expected: &route53.ResourceRecordSet{
+				Name: aws.String("docs.internal.example.com"),
+				Type: aws.String("A"),
+				AliasTarget: &route53.AliasTarget{
+					DNSName:              aws.String("internal-docs-alb-123456789.us-east-1.elb.amazonaws.com"),
+					HostedZoneId:         aws.String("Z35SXDOTRQ7X7K"),
+					EvaluateTargetHealth: aws.Bool(false),
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		if out := test.record.resourceRecordSet(); !reflect.DeepEqual(out, test.expected) {
+			t.Errorf("expected %+v, got %+v", test.expected, out)
+		}
+	}
+}
+
+func TestWebsiteDNSRecords(t *testing.T) {
+	recordSets := []*route53.ResourceRecordSet{
+		{
+			Name:        aws.String("www.example.com."),
+			Type:        aws.String("A"),
+			AliasTarget: &route53.AliasTarget{DNSName: aws.String("abcdefg1234567.cloudfront.net")},
+		},
+		{
+			Name:        aws.String("www.example.com."),
+			Type:        aws.String("AAAA"),
+			AliasTarget: &route53.AliasTarget{DNSName: aws.String("abcdefg1234567.cloudfront.net")},
+		},
+		{
+			Name:            aws.String("vpn.www.example.com."),
+			Type:            aws.String("A"),
+			TTL:             aws.Int64(60),
+			ResourceRecords: []*route53.ResourceRecord{{Value: aws.String("10.0.0.1")}},
+		},
+		{
+			Name:            aws.String("www.example.com."),
+			Type:            aws.String("TXT"),
+			TTL:             aws.Int64(300),
+			ResourceRecords: []*route53.ResourceRecord{{Value: aws.String(`"google-site-verification=abc123"`)}},
+		},
+		{
+			Name:            aws.String("_dmarc.www.example.com."),
+			Type:            aws.String("TXT"),
+			TTL:             aws.Int64(3600),
+			ResourceRecords: []*route53.ResourceRecord{{Value: aws.String(`"v=DMARC1; p=none"`)}},
+		},
+		{
+			Name:            aws.String("docs.www.example.com."),
+			Type:            aws.String("CNAME"),
+			TTL:             aws.Int64(300),
+			ResourceRecords: []*route53.ResourceRecord{{Value: aws.String("example.github.io")}},
+		},
+		{
+			Name:            aws.String("other.example.com."),
+			Type:            aws.String("TXT"),
+			TTL:             aws.Int64(300),
+			ResourceRecords: []*route53.ResourceRecord{{Value: aws.String(`"other"`)}},
+		},
+	}
+
+	expected := []websiteDNSRecord{
+		{Name: "_dmarc.www.example.com", Type: "TXT", TTL: 3600, Values: []string{`"v=DMARC1; p=none"`}},
+		{Name: "docs.www.example.com", Type: "CNAME", TTL: 300, Values: []string{"example.github.io"}},
+		{Name: "vpn.www.example.com", Type: "A", TTL: 60, Values: []string{"10.0.0.1"}},
+		{Name: "www.example.com", Type: "TXT", TTL: 300, Values: []string{`"google-site-verification=abc123"`}},
+	}
+
+	if out := websiteDNSRecords("www.example.com", recordSets, false); !reflect.DeepEqual(out, expected) {
+		t.Errorf("expected %+v, got %+v", expected, out)
+	}
+
+	// alias records are managed in a private zone
+	recordSets = []*route53.ResourceRecordSet{
+		{
+			Name: aws.String("docs.internal.example.com."),
+			Type: aws.String("A"),
+			AliasTarget: &route53.AliasTarget{
+				DNSName:              aws.String("internal-docs-alb-123456789.us-east-1.elb.amazonaws.com."),
+				HostedZoneId:         aws.String("Z35SXDOTRQ7X7K"),
+				EvaluateTargetHealth: aws.Bool(true),
+			},
+		},
+		{
+			Name:            aws.String("docs.internal.example.com."),
+			Type:            aws.String("TXT"),
+			TTL:             aws.Int64(300),
+			ResourceRecords: []*route53.ResourceRecord{{Value: aws.String(`"owner=abc123"`)}},
+		},
+	}
+
+	expected = []websiteDNSRecord{
+		{
+			Name:   "docs.internal.example.com",
+			Type:   "A",
+			Values: []string{},
+			AliasTarget: &websiteDNSAliasTarget{
+				DNSName:              "internal-docs-alb-123456789.us-east-1.elb.amazonaws.com",
+				HostedZoneId:         "Z35SXDOTRQ7X7K",
+				EvaluateTargetHealth: true,
+			},
+		},
+		{Name: "docs.internal.example.com", Type: "TXT", TTL: 300, Values: []string{`"owner=abc123"`}},
+	}
+
+	if out := websiteDNSRecords("docs.internal.example.com", recordSets, true); !reflect.DeepEqual(out, expected) {
+		t.Errorf("expected %+v, got %+v", expected, out)
+	}
+}
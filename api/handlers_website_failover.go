@@ -0,0 +1,336 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/YaleSpinup/apierror"
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	route53api "github.com/YaleSpinup/s3-api/route53"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// websiteFailoverActions are the actions needed to manage the dns failover for a website
+var websiteFailoverActions = []string{
+	"cloudfront:ListDistributions",
+	"route53:ListHostedZones",
+	"route53:ListResourceRecordSets",
+	"route53:ChangeResourceRecordSets",
+	"route53:CreateHealthCheck",
+	"route53:DeleteHealthCheck",
+	"route53:ChangeTagsForResource",
+}
+
+// websiteFailoverRequest is the request to fail a website over to a secondary distribution when it's unhealthy
+type websiteFailoverRequest struct {
+	// Secondary is a website managed by the api, ie. a maintenance site, or the cloudfront domain name of the
+	// distribution to fail over to.  It must serve the website's name.
+	Secondary string
+	// HealthCheckPath is the path that's checked on the website's distribution (default /)
+	HealthCheckPath string `json:",omitempty"`
+}
+
+// websiteFailoverOutput is the dns failover configuration for a website
+type websiteFailoverOutput struct {
+	Website       string
+	Primary       string
+	Secondary     string `json:",omitempty"`
+	HealthCheckID string `json:",omitempty"`
+	DnsChange     *route53.ChangeInfo
+}
+
+// validate validates the failover request for the website
+func (r *websiteFailoverRequest) validate(website string) error {
+	f := fieldErrors{}
+	switch {
+	case r.Secondary == "":
+		f.add("Secondary", "secondary website or cloudfront distribution is required")
+	case strings.EqualFold(r.Secondary, website):
+		f.add("Secondary", "secondary must be a different website")
+	}
+
+	if r.HealthCheckPath != "" && !strings.HasPrefix(r.HealthCheckPath, "/") {
+		f.add("HealthCheckPath", "path %q must begin with /", r.HealthCheckPath)
+	}
+
+	return f.err()
+}
+
+// WebsiteFailoverUpdateHandler replaces the alias record for a website with primary and secondary failover records.
+// Route53 answers with the website's distribution while its health check passes and with the secondary distribution
+// when it fails.  Updating the failover replaces the records and the health check.
+func (s *server) WebsiteFailoverUpdateHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	website := vars["website"]
+
+	var req websiteFailoverRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		msg := fmt.Sprintf("cannot decode body into website failover input: %s", err)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+		return
+	}
+
+	if err := req.validate(website); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	session, err := s.sessionForAccount(r.Context(), vars["account"], websiteFailoverActions...)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	cloudFrontService := cfapi.NewSession(session.Session, s.account, accountId)
+	cloudFrontService.Index = s.distributionIndex(accountId)
+	route53Service := route53api.NewSession(session.Session, s.account)
+
+	primary, err := cloudFrontService.GetDistributionByName(r.Context(), website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	secondary, err := failoverTarget(r.Context(), cloudFrontService, req.Secondary)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	zoneID, err := route53Service.ZoneIDForName(r.Context(), website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	current, err := route53Service.ListAliasRecords(r.Context(), zoneID, website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if !hasRecordType(current, route53.RRTypeA) {
+		msg := fmt.Sprintf("route53 alias record not found for website %s", website)
+		handleError(w, apierror.New(apierror.ErrNotFound, msg, nil))
+		return
+	}
+
+	healthCheck, err := route53Service.CreateHealthCheck(r.Context(), website, aws.StringValue(primary.DomainName), req.HealthCheckPath, []*route53.Tag{
+		{Key: aws.String("Name"), Value: aws.String(website)},
+		{Key: aws.String("spinup:org"), Value: aws.String(Org)},
+	})
+	if err != nil {
+		msg := fmt.Sprintf("failed to create health check for website %s", website)
+		handleError(w, errors.Wrap(err, msg))
+		return
+	}
+	healthCheckID := aws.StringValue(healthCheck.Id)
+
+	// the website's AAAA records fail over along with the A records
+	changes := recordChanges(route53.ChangeActionDelete, current)
+	for _, t := range route53api.AliasRecordTypes {
+		if !hasRecordType(current, t) {
+			continue
+		}
+
+		primaryRecord, secondaryRecord := route53api.FailoverRecordSets(website, t, route53api.CloudFrontAliasTarget(aws.StringValue(primary.DomainName)), route53api.CloudFrontAliasTarget(secondary), healthCheckID)
+		changes = append(changes, recordChanges(route53.ChangeActionCreate, []*route53.ResourceRecordSet{primaryRecord, secondaryRecord})...)
+	}
+
+	dnsChange, err := route53Service.ChangeRecords(r.Context(), zoneID, changes)
+	if err != nil {
+		if derr := route53Service.DeleteHealthCheck(r.Context(), healthCheckID); derr != nil {
+			log.Warnf("failed to clean up health check %s for website %s: %s", healthCheckID, website, derr)
+		}
+
+		msg := fmt.Sprintf("failed to create failover records for website %s", website)
+		handleError(w, errors.Wrap(err, msg))
+		return
+	}
+
+	// the health checks of the replaced failover records aren't used anymore
+	deleteHealthChecks(r.Context(), route53Service, current)
+
+	output := websiteFailoverOutput{
+		Website:       website,
+		Primary:       aws.StringValue(primary.DomainName),
+		Secondary:     secondary,
+		HealthCheckID: healthCheckID,
+		DnsChange:     dnsChange,
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// WebsiteFailoverDeleteHandler replaces the failover records for a website with a simple alias record for its
+// distribution and deletes the health check
+func (s *server) WebsiteFailoverDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	website := vars["website"]
+
+	session, err := s.sessionForAccount(r.Context(), vars["account"], websiteFailoverActions...)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	cloudFrontService := cfapi.NewSession(session.Session, s.account, accountId)
+	cloudFrontService.Index = s.distributionIndex(accountId)
+	route53Service := route53api.NewSession(session.Session, s.account)
+
+	primary, err := cloudFrontService.GetDistributionByName(r.Context(), website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	zoneID, err := route53Service.ZoneIDForName(r.Context(), website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	current, err := route53Service.ListAliasRecords(r.Context(), zoneID, website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if !hasFailover(current) {
+		msg := fmt.Sprintf("failover is not configured for website %s", website)
+		handleError(w, apierror.New(apierror.ErrNotFound, msg, nil))
+		return
+	}
+
+	changes := recordChanges(route53.ChangeActionDelete, current)
+	for _, rs := range route53api.AliasRecordSets(website, route53api.CloudFrontAliasTarget(aws.StringValue(primary.DomainName))) {
+		if hasRecordType(current, aws.StringValue(rs.Type)) {
+			changes = append(changes, recordChanges(route53.ChangeActionCreate, []*route53.ResourceRecordSet{rs})...)
+		}
+	}
+
+	dnsChange, err := route53Service.ChangeRecords(r.Context(), zoneID, changes)
+	if err != nil {
+		msg := fmt.Sprintf("failed to remove failover records for website %s", website)
+		handleError(w, errors.Wrap(err, msg))
+		return
+	}
+
+	deleteHealthChecks(r.Context(), route53Service, current)
+
+	output := websiteFailoverOutput{
+		Website:   website,
+		Primary:   aws.StringValue(primary.DomainName),
+		DnsChange: dnsChange,
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// failoverTarget returns the cloudfront domain name to fail over to, either the passed cloudfront domain name or the
+// domain name of the distribution for the passed website
+func failoverTarget(ctx context.Context, cloudFrontService cfapi.CloudFront, secondary string) (string, error) {
+	if strings.HasSuffix(secondary, ".cloudfront.net") {
+		return secondary, nil
+	}
+
+	distribution, err := cloudFrontService.GetDistributionByName(ctx, secondary)
+	if err != nil {
+		return "", err
+	}
+
+	return aws.StringValue(distribution.DomainName), nil
+}
+
+// deleteWebsiteRecords deletes the alias records for a website, including any failover records and their health
+// checks
+func deleteWebsiteRecords(ctx context.Context, route53Service route53api.Route53, zoneID, website string) (*route53.ChangeInfo, error) {
+	records, err := route53Service.ListAliasRecords(ctx, zoneID, website)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		msg := fmt.Sprintf("route53 alias record not found for website %s", website)
+		return nil, apierror.New(apierror.ErrNotFound, msg, nil)
+	}
+
+	change, err := route53Service.ChangeRecords(ctx, zoneID, recordChanges(route53.ChangeActionDelete, records))
+	if err != nil {
+		return nil, err
+	}
+
+	deleteHealthChecks(ctx, route53Service, records)
+
+	return change, nil
+}
+
+// deleteHealthChecks deletes the health checks associated with the records, failures are logged since the records
+// don't depend on them anymore
+func deleteHealthChecks(ctx context.Context, route53Service route53api.Route53, records []*route53.ResourceRecordSet) {
+	for _, rs := range records {
+		if id := aws.StringValue(rs.HealthCheckId); id != "" {
+			if err := route53Service.DeleteHealthCheck(ctx, id); err != nil {
+				log.Warnf("failed to delete health check %s: %s", id, err)
+			}
+		}
+	}
+}
+
+// hasFailover returns true if any of the records use failover routing
+func hasFailover(records []*route53.ResourceRecordSet) bool {
+	for _, rs := range records {
+		if rs.Failover != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// recordChanges returns a change with the action for each of the records
+func recordChanges(action string, records []*route53.ResourceRecordSet) []*route53.Change {
+	changes := make([]*route53.Change, 0, len(records))
+	for _, rs := range records {
+		changes = append(changes, &route53.Change{Action: aws.String(action), ResourceRecordSet: rs})
+	}
+	return changes
+}
+
+// hasRecordType returns true if any of the records have the type
+func hasRecordType(records []*route53.ResourceRecordSet, recordType string) bool {
+	for _, rs := range records {
+		if aws.StringValue(rs.Type) == recordType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+func TestWebsiteFailoverRequestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		req   websiteFailoverRequest
+		valid bool
+	}{
+		{"website", websiteFailoverRequest{Secondary: "maintenance.example.com"}, true},
+		{"distribution", websiteFailoverRequest{Secondary: "d111111abcdef8.cloudfront.net", HealthCheckPath: "/health"}, true},
+		{"missing secondary", websiteFailoverRequest{}, false},
+		{"same website", websiteFailoverRequest{Secondary: "WWW.example.com"}, false},
+		{"invalid path", websiteFailoverRequest{Secondary: "maintenance.example.com", HealthCheckPath: "health"}, false},
+	}
+
+	for _, test := range tests {
+		if err := test.req.validate("www.example.com"); (err == nil) != test.valid {
+			t.Errorf("%s: expected valid %t, got %v", test.name, test.valid, err)
+		}
+	}
+}
+
+func TestHasFailover(t *testing.T) {
+	simple := &route53.ResourceRecordSet{Name: aws.String("www.example.com."), Type: aws.String("A")}
+	primary := &route53.ResourceRecordSet{Name: aws.String("www.example.com."), Type: aws.String("A"), Failover: aws.String("PRIMARY")}
+
+	if hasFailover([]*route53.ResourceRecordSet{simple}) {
+		t.Error("expected no failover for a simple record")
+	}
+
+	if !hasFailover([]*route53.ResourceRecordSet{primary}) {
+		t.Error("expected failover for a failover record")
+	}
+}
+
+func TestRecordChanges(t *testing.T) {
+	records := []*route53.ResourceRecordSet{
+		{Name: aws.String("www.example.com."), Type: aws.String("A"), Failover: aws.String("PRIMARY")},
+		{Name: aws.String("www.example.com."), Type: aws.String("A"), Failover: aws.String("SECONDARY")},
+	}
+
+	changes := recordChanges(route53.ChangeActionDelete, records)
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %d", len(changes))
+	}
+
+	for i, c := range changes {
+		if aws.StringValue(c.Action) != "DELETE" || c.ResourceRecordSet != records[i] {
+			t.Errorf("unexpected change %+v", c)
+		}
+	}
+}
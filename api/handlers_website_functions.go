@@ -0,0 +1,288 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/YaleSpinup/apierror"
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// websiteFunctionsActions are the actions needed to manage the cloudfront functions associated with a website
+var websiteFunctionsActions = []string{
+	"cloudfront:ListDistributions",
+	"cloudfront:GetDistributionConfig",
+	"cloudfront:UpdateDistribution",
+	"cloudfront:DescribeFunction",
+	"cloudfront:GetFunction",
+	"cloudfront:CreateFunction",
+	"cloudfront:UpdateFunction",
+	"cloudfront:PublishFunction",
+}
+
+// functionNamePattern is the pattern of a valid cloudfront function name
+var functionNamePattern = regexp.MustCompile(`^[a-zA-Z0-9-_]{1,64}$`)
+
+// newFunctionTemplates validates the cloudfront function templates from the configuration
+func newFunctionTemplates(config map[string]*common.FunctionTemplate) (map[string]*common.FunctionTemplate, error) {
+	templates := map[string]*common.FunctionTemplate{}
+	for name, t := range config {
+		if !functionNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid function template name %s, it must be 1-64 letters, numbers, hyphens or underscores", name)
+		}
+
+		if t == nil || t.Code == "" {
+			return nil, fmt.Errorf("function template %s code cannot be empty", name)
+		}
+
+		if !contains(cfapi.FunctionEventTypes, t.EventType) {
+			return nil, fmt.Errorf("invalid event type '%s' for function template %s, expected one of %v", t.EventType, name, cfapi.FunctionEventTypes)
+		}
+
+		templates[name] = t
+	}
+
+	return templates, nil
+}
+
+// websiteFunctionsOutput is the cloudfront functions associated with a website
+type websiteFunctionsOutput struct {
+	Website   string
+	Functions []*cfapi.FunctionAssociation
+	// Status is the status of the distribution after the functions are updated
+	Status string `json:",omitempty"`
+}
+
+// WebsiteFunctionsShowHandler returns the cloudfront functions associated with a website's default cache behavior
+func (s *server) WebsiteFunctionsShowHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	website := vars["website"]
+
+	session, err := s.sessionForAccount(r.Context(), vars["account"], websiteFunctionsActions...)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	cloudFrontService := cfapi.NewSession(session.Session, s.account, accountId)
+	cloudFrontService.Index = s.distributionIndex(accountId)
+
+	distribution, err := cloudFrontService.GetDistributionByName(r.Context(), website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	functions, err := cloudFrontService.GetFunctionAssociations(r.Context(), aws.StringValue(distribution.Id))
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	// the distribution etag is passed back in the If-Match header when updating the functions
+	etag, err := websiteETag(r.Context(), cloudFrontService, distribution)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	output := websiteFunctionsOutput{
+		Website:   website,
+		Functions: functions,
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag)
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// WebsiteFunctionAssociateHandler associates a cloudfront function from a configured template with a website's
+// default cache behavior.  The function is created or updated from the template and published first, and it replaces
+// the function associated with the same event type.
+func (s *server) WebsiteFunctionAssociateHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	website := vars["website"]
+	function := vars["function"]
+
+	template, ok := s.functionTemplates[function]
+	if !ok {
+		msg := fmt.Sprintf("function template %s not found", function)
+		handleError(w, apierror.New(apierror.ErrNotFound, msg, nil))
+		return
+	}
+
+	session, err := s.sessionForAccount(r.Context(), vars["account"], websiteFunctionsActions...)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	cloudFrontService := cfapi.NewSession(session.Session, s.account, accountId)
+	cloudFrontService.Index = s.distributionIndex(accountId)
+
+	lease, err := s.lockResource(r.Context(), vars["account"], website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	defer lease.Release()
+
+	distribution, err := cloudFrontService.GetDistributionByName(r.Context(), website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	// make sure the distribution hasn't changed since it was read if there are preconditions, or they're required
+	if hasPreconditions(r) || s.requireIfMatch {
+		etag, err := websiteETag(r.Context(), cloudFrontService, distribution)
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		if err := s.checkPreconditions(r, etag); err != nil {
+			handleError(w, err)
+			return
+		}
+	}
+
+	arn, err := cloudFrontService.EnsureFunction(r.Context(), function, template.Comment, template.Code)
+	if err != nil {
+		msg := fmt.Sprintf("failed to publish cloudfront function %s", function)
+		handleError(w, errors.Wrap(err, msg))
+		return
+	}
+
+	updated, err := cloudFrontService.UpdateFunctionAssociation(r.Context(), aws.StringValue(distribution.Id), template.EventType, arn)
+	if err != nil {
+		msg := fmt.Sprintf("failed to associate cloudfront function %s with website %s", function, website)
+		handleError(w, errors.Wrap(err, msg))
+		return
+	}
+
+	output := websiteFunctionsOutput{
+		Website:   website,
+		Functions: cfapi.FunctionAssociations(updated.DistributionConfig),
+		Status:    aws.StringValue(updated.Status),
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// WebsiteFunctionDisassociateHandler removes a cloudfront function from a website's default cache behavior.  The
+// function itself is left in the account since other websites may use it.
+func (s *server) WebsiteFunctionDisassociateHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	website := vars["website"]
+	function := vars["function"]
+
+	session, err := s.sessionForAccount(r.Context(), vars["account"], websiteFunctionsActions...)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	cloudFrontService := cfapi.NewSession(session.Session, s.account, accountId)
+	cloudFrontService.Index = s.distributionIndex(accountId)
+
+	lease, err := s.lockResource(r.Context(), vars["account"], website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	defer lease.Release()
+
+	distribution, err := cloudFrontService.GetDistributionByName(r.Context(), website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if hasPreconditions(r) || s.requireIfMatch {
+		etag, err := websiteETag(r.Context(), cloudFrontService, distribution)
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		if err := s.checkPreconditions(r, etag); err != nil {
+			handleError(w, err)
+			return
+		}
+	}
+
+	functions, err := cloudFrontService.GetFunctionAssociations(r.Context(), aws.StringValue(distribution.Id))
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	var eventType string
+	for _, f := range functions {
+		if f.Name == function {
+			eventType = f.EventType
+			break
+		}
+	}
+
+	if eventType == "" {
+		msg := fmt.Sprintf("function %s is not associated with website %s", function, website)
+		handleError(w, apierror.New(apierror.ErrNotFound, msg, nil))
+		return
+	}
+
+	updated, err := cloudFrontService.UpdateFunctionAssociation(r.Context(), aws.StringValue(distribution.Id), eventType, "")
+	if err != nil {
+		msg := fmt.Sprintf("failed to remove cloudfront function %s from website %s", function, website)
+		handleError(w, errors.Wrap(err, msg))
+		return
+	}
+
+	output := websiteFunctionsOutput{
+		Website:   website,
+		Functions: cfapi.FunctionAssociations(updated.DistributionConfig),
+		Status:    aws.StringValue(updated.Status),
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/gorilla/mux"
+)
+
+func TestNewFunctionTemplates(t *testing.T) {
+	code := "function handler(event) { return event.request; }"
+
+	tests := []struct {
+		name   string
+		config map[string]*common.FunctionTemplate
+		err    bool
+	}{
+		{name: "empty", config: nil},
+		{name: "valid", config: map[string]*common.FunctionTemplate{
+			"index-rewrite":    {EventType: "viewer-request", Code: code},
+			"security_headers": {EventType: "viewer-response", Code: code},
+		}},
+		{name: "invalid name", config: map[string]*common.FunctionTemplate{"index.rewrite": {EventType: "viewer-request", Code: code}}, err: true},
+		{name: "missing code", config: map[string]*common.FunctionTemplate{"index-rewrite": {EventType: "viewer-request"}}, err: true},
+		{name: "nil template", config: map[string]*common.FunctionTemplate{"index-rewrite": nil}, err: true},
+		{name: "invalid event type", config: map[string]*common.FunctionTemplate{"index-rewrite": {EventType: "origin-request", Code: code}}, err: true},
+	}
+
+	for _, test := range tests {
+		templates, err := newFunctionTemplates(test.config)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected error, got nil", test.name)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: expected nil error, got %s", test.name, err)
+		}
+
+		if len(templates) != len(test.config) {
+			t.Errorf("%s: expected %d templates, got %d", test.name, len(test.config), len(templates))
+		}
+	}
+}
+
+func TestWebsiteFunctionAssociateHandlerUnknownTemplate(t *testing.T) {
+	s := server{functionTemplates: map[string]*common.FunctionTemplate{}}
+
+	req := httptest.NewRequest(http.MethodPut, "/v1/s3/foo/websites/www.example.com/functions/missing", nil)
+	req = mux.SetURLVars(req, map[string]string{"account": "foo", "website": "www.example.com", "function": "missing"})
+	w := httptest.NewRecorder()
+
+	s.WebsiteFunctionAssociateHandler(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,215 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/YaleSpinup/apierror"
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// websiteHeadersActions are the actions needed to manage the security headers of a website
+var websiteHeadersActions = []string{
+	"cloudfront:ListDistributions",
+	"cloudfront:GetDistributionConfig",
+	"cloudfront:UpdateDistribution",
+	"cloudfront:ListResponseHeadersPolicies",
+	"cloudfront:GetResponseHeadersPolicyConfig",
+	"cloudfront:CreateResponseHeadersPolicy",
+	"cloudfront:UpdateResponseHeadersPolicy",
+}
+
+// websiteHeadersOutput is the security headers status of a website
+type websiteHeadersOutput struct {
+	Website string
+	// SecurityHeaders is true if the security headers response headers policy is associated with the website
+	SecurityHeaders bool
+	// ResponseHeadersPolicyId is the id of the response headers policy associated with the website, it can be
+	// a policy other than the security headers if it was set outside of the api
+	ResponseHeadersPolicyId string `json:",omitempty"`
+	// Status is the status of the distribution after the security headers are updated
+	Status string `json:",omitempty"`
+}
+
+// securityHeadersPolicy creates or updates the security headers response headers policy from the configuration and
+// returns its id
+func (s *server) securityHeadersPolicy(ctx context.Context, cloudFrontService cfapi.CloudFront) (string, error) {
+	if s.securityHeaders == nil {
+		return "", apierror.New(apierror.ErrNotFound, "security headers are not configured", nil)
+	}
+
+	id, err := cloudFrontService.EnsureResponseHeadersPolicy(ctx, cfapi.SecurityHeadersPolicyConfig(s.securityHeaders))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create or update the security headers response headers policy")
+	}
+
+	return id, nil
+}
+
+// websiteHeadersOutputFor returns the security headers status of a website from its distribution config
+func websiteHeadersOutputFor(website, securityHeadersPolicyId string, config *cloudfront.DistributionConfig) websiteHeadersOutput {
+	id := cfapi.ResponseHeadersPolicyID(config)
+	return websiteHeadersOutput{
+		Website:                 website,
+		SecurityHeaders:         id != "" && id == securityHeadersPolicyId,
+		ResponseHeadersPolicyId: id,
+	}
+}
+
+// WebsiteHeadersShowHandler returns whether the security headers are enabled for a website
+func (s *server) WebsiteHeadersShowHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	website := vars["website"]
+
+	session, err := s.sessionForAccount(r.Context(), vars["account"], websiteHeadersActions...)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	cloudFrontService := cfapi.NewSession(session.Session, s.account, accountId)
+	cloudFrontService.Index = s.distributionIndex(accountId)
+
+	distribution, err := cloudFrontService.GetDistributionByName(r.Context(), website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	config, err := cloudFrontService.Service.GetDistributionConfigWithContext(r.Context(), &cloudfront.GetDistributionConfigInput{Id: distribution.Id})
+	if err != nil {
+		handleError(w, cfapi.ErrCode("failed to get details about cloudfront distribution Id: "+aws.StringValue(distribution.Id), err))
+		return
+	}
+
+	// the policy isn't created until the security headers are enabled for a website
+	var policyId string
+	if s.securityHeaders != nil {
+		name := aws.StringValue(cfapi.SecurityHeadersPolicyConfig(s.securityHeaders).Name)
+		if policyId, err = cloudFrontService.GetResponseHeadersPolicyID(r.Context(), name); err != nil {
+			handleError(w, err)
+			return
+		}
+	}
+
+	output := websiteHeadersOutputFor(website, policyId, config.DistributionConfig)
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", quoteETag(aws.StringValue(config.ETag)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// WebsiteHeadersUpdateHandler enables the security headers for a website, the response headers policy is created from
+// the configuration if it doesn't exist yet and associated with all of the website's cache behaviors
+func (s *server) WebsiteHeadersUpdateHandler(w http.ResponseWriter, r *http.Request) {
+	s.setWebsiteHeaders(w, r, true)
+}
+
+// WebsiteHeadersDeleteHandler disables the security headers for a website, the response headers policy is removed from
+// the website's cache behaviors
+func (s *server) WebsiteHeadersDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	s.setWebsiteHeaders(w, r, false)
+}
+
+// setWebsiteHeaders enables or disables the security headers response headers policy for a website
+func (s *server) setWebsiteHeaders(w http.ResponseWriter, r *http.Request, enabled bool) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	website := vars["website"]
+
+	if s.securityHeaders == nil {
+		handleError(w, apierror.New(apierror.ErrNotFound, "security headers are not configured", nil))
+		return
+	}
+
+	session, err := s.sessionForAccount(r.Context(), vars["account"], websiteHeadersActions...)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	cloudFrontService := cfapi.NewSession(session.Session, s.account, accountId)
+	cloudFrontService.Index = s.distributionIndex(accountId)
+
+	lease, err := s.lockResource(r.Context(), vars["account"], website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	defer lease.Release()
+
+	distribution, err := cloudFrontService.GetDistributionByName(r.Context(), website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	// make sure the distribution hasn't changed since it was read if there are preconditions, or they're required
+	if hasPreconditions(r) || s.requireIfMatch {
+		etag, err := websiteETag(r.Context(), cloudFrontService, distribution)
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		if err := s.checkPreconditions(r, etag); err != nil {
+			handleError(w, err)
+			return
+		}
+	}
+
+	// the policy is only created or updated when the security headers are enabled
+	var policyId, setId string
+	if enabled {
+		if policyId, err = s.securityHeadersPolicy(r.Context(), cloudFrontService); err != nil {
+			handleError(w, err)
+			return
+		}
+		setId = policyId
+	} else {
+		name := aws.StringValue(cfapi.SecurityHeadersPolicyConfig(s.securityHeaders).Name)
+		if policyId, err = cloudFrontService.GetResponseHeadersPolicyID(r.Context(), name); err != nil {
+			handleError(w, err)
+			return
+		}
+	}
+
+	updated, err := cloudFrontService.UpdateResponseHeadersPolicy(r.Context(), aws.StringValue(distribution.Id), setId)
+	if err != nil {
+		msg := fmt.Sprintf("failed to update security headers for website %s", website)
+		handleError(w, errors.Wrap(err, msg))
+		return
+	}
+
+	output := websiteHeadersOutputFor(website, policyId, updated.DistributionConfig)
+	output.Status = aws.StringValue(updated.Status)
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
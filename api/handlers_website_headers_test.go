@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/gorilla/mux"
+)
+
+func TestWebsiteHeadersOutputFor(t *testing.T) {
+	config := &cloudfront.DistributionConfig{
+		DefaultCacheBehavior: &cloudfront.DefaultCacheBehavior{ResponseHeadersPolicyId: aws.String("policy1")},
+	}
+
+	tests := []struct {
+		policyId string
+		config   *cloudfront.DistributionConfig
+		expected websiteHeadersOutput
+	}{
+		{policyId: "policy1", config: config, expected: websiteHeadersOutput{Website: "www.example.com", SecurityHeaders: true, ResponseHeadersPolicyId: "policy1"}},
+		{policyId: "policy2", config: config, expected: websiteHeadersOutput{Website: "www.example.com", ResponseHeadersPolicyId: "policy1"}},
+		{policyId: "", config: &cloudfront.DistributionConfig{}, expected: websiteHeadersOutput{Website: "www.example.com"}},
+	}
+
+	for _, test := range tests {
+		if out := websiteHeadersOutputFor("www.example.com", test.policyId, test.config); out != test.expected {
+			t.Errorf("expected %+v, got %+v", test.expected, out)
+		}
+	}
+}
+
+func TestWebsiteHeadersUpdateHandlerNotConfigured(t *testing.T) {
+	s := server{}
+
+	for _, method := range []string{http.MethodPut, http.MethodDelete} {
+		req := httptest.NewRequest(method, "/v1/s3/foo/websites/www.example.com/securityheaders", nil)
+		req = mux.SetURLVars(req, map[string]string{"account": "foo", "website": "www.example.com"})
+		w := httptest.NewRecorder()
+
+		if method == http.MethodPut {
+			s.WebsiteHeadersUpdateHandler(w, req)
+		} else {
+			s.WebsiteHeadersDeleteHandler(w, req)
+		}
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected %d, got %d", method, http.StatusNotFound, w.Code)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	acmapi "github.com/YaleSpinup/s3-api/acm"
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	route53api "github.com/YaleSpinup/s3-api/route53"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/acm"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	healthOK      = "ok"
+	healthWarning = "warning"
+	healthError   = "error"
+	healthSkipped = "skipped"
+
+	// certificateExpiryWarning is how long before the website certificate expires that it's reported as a warning
+	certificateExpiryWarning = 30 * 24 * time.Hour
+)
+
+// websiteHealthActions are the read only actions needed to check the health of a website
+var websiteHealthActions = []string{
+	"acm:DescribeCertificate",
+	"cloudfront:ListDistributions",
+	"route53:ListHostedZones",
+	"route53:ListResourceRecordSets",
+	"s3:GetBucketTagging",
+	"s3:GetBucketWebsite",
+}
+
+// websiteHealthComponent is the status of one of the pieces of a website
+type websiteHealthComponent struct {
+	Component string
+	Status    string
+	Message   string
+	Details   map[string]string `json:",omitempty"`
+}
+
+// set sets the status and message of the component
+func (c *websiteHealthComponent) set(status, format string, args ...interface{}) *websiteHealthComponent {
+	c.Status = status
+	c.Message = fmt.Sprintf(format, args...)
+	return c
+}
+
+// websiteHealthOutput is the health report for a website, Status is the worst status of its components
+type websiteHealthOutput struct {
+	Website    string
+	Status     string
+	Checked    time.Time
+	Components []*websiteHealthComponent
+}
+
+// add adds the component to the report and updates the overall status
+func (o *websiteHealthOutput) add(c *websiteHealthComponent) {
+	o.Components = append(o.Components, c)
+
+	switch {
+	case c.Status == healthError:
+		o.Status = healthError
+	case c.Status == healthWarning && o.Status != healthError:
+		o.Status = healthWarning
+	}
+}
+
+// WebsiteHealthHandler reports the health of each of the components of a website: the certificate for the website's
+// domain isn't expired or expiring soon, the distribution is deployed and enabled, the dns alias points to the
+// distribution and the bucket has a website configuration.  Simple websites are only served from the bucket, so only
+// the bucket is checked.
+func (s *server) WebsiteHealthHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	website := vars["website"]
+
+	session, err := s.sessionForAccount(r.Context(), vars["account"], websiteHealthActions...)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	acmService := acmapi.NewSession(session.Session, s.account)
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+	route53Service := route53api.NewSession(session.Session, s.account)
+
+	// the distribution index isn't used since the status of the distributions in it can be stale
+	cloudFrontService := cfapi.NewSession(session.Session, s.account, accountId)
+
+	output := websiteHealth(r.Context(), acmService, s3Service, cloudFrontService, route53Service, website, time.Now())
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// websiteHealth checks each of the components of the website, a failure to check a component is reported as an
+// error for that component
+func websiteHealth(ctx context.Context, acmService acmapi.ACM, s3Service s3api.S3, cloudFrontService cfapi.CloudFront, route53Service route53api.Route53, website string, now time.Time) *websiteHealthOutput {
+	output := &websiteHealthOutput{
+		Website:    website,
+		Status:     healthOK,
+		Checked:    now,
+		Components: []*websiteHealthComponent{},
+	}
+
+	origin, simple := checkWebsiteOrigin(ctx, s3Service, website)
+	if simple {
+		for _, c := range []string{"certificate", "distribution", "dns"} {
+			output.add((&websiteHealthComponent{Component: c}).set(healthSkipped, "simple websites are served from the s3 website endpoint"))
+		}
+	} else {
+		output.add(checkWebsiteCertificate(ctx, acmService, cloudFrontService, website, now))
+
+		distribution, summary := checkWebsiteDistribution(ctx, cloudFrontService, website)
+		output.add(distribution)
+		output.add(checkWebsiteRecord(ctx, route53Service, website, summary))
+	}
+	output.add(origin)
+
+	return output
+}
+
+// checkWebsiteCertificate checks that the certificate for the website's domain is issued, covers the website and
+// isn't expiring soon
+func checkWebsiteCertificate(ctx context.Context, acmService acmapi.ACM, cloudFrontService cfapi.CloudFront, website string, now time.Time) *websiteHealthComponent {
+	c := &websiteHealthComponent{Component: "certificate"}
+
+	domain, err := cloudFrontService.WebsiteDomain(website)
+	if err != nil {
+		return c.set(healthError, "no certificate is configured for the website: %s", err)
+	}
+
+	cert, err := acmService.GetCertificate(ctx, domain.CertArn)
+	if err != nil {
+		return c.set(healthError, "%s", err)
+	}
+
+	c.Details = map[string]string{
+		"CertificateArn": domain.CertArn,
+		"Status":         aws.StringValue(cert.Status),
+	}
+
+	if cert.NotAfter != nil {
+		c.Details["NotAfter"] = cert.NotAfter.UTC().Format(time.RFC3339)
+	}
+
+	switch {
+	case aws.StringValue(cert.Status) != acm.CertificateStatusIssued:
+		return c.set(healthError, "certificate status is %s", aws.StringValue(cert.Status))
+	case !acmapi.Covers(cert, website):
+		return c.set(healthError, "certificate doesn't cover %s", website)
+	case cert.NotAfter == nil:
+		return c.set(healthOK, "certificate is issued")
+	case !now.Before(aws.TimeValue(cert.NotAfter)):
+		return c.set(healthError, "certificate expired on %s", c.Details["NotAfter"])
+	}
+
+	days := int(aws.TimeValue(cert.NotAfter).Sub(now).Hours() / 24)
+	if aws.TimeValue(cert.NotAfter).Sub(now) < certificateExpiryWarning {
+		return c.set(healthWarning, "certificate expires in %d days", days)
+	}
+
+	return c.set(healthOK, "certificate expires in %d days", days)
+}
+
+// checkWebsiteDistribution checks that the website's distribution is enabled and deployed, the distribution is
+// returned if it's found
+func checkWebsiteDistribution(ctx context.Context, cloudFrontService cfapi.CloudFront, website string) (*websiteHealthComponent, *cloudfront.DistributionSummary) {
+	c := &websiteHealthComponent{Component: "distribution"}
+
+	summary, err := cloudFrontService.GetDistributionByName(ctx, website)
+	if err != nil {
+		return c.set(healthError, "%s", err), nil
+	}
+
+	c.Details = map[string]string{
+		"Id":         aws.StringValue(summary.Id),
+		"DomainName": aws.StringValue(summary.DomainName),
+		"Status":     aws.StringValue(summary.Status),
+	}
+
+	switch {
+	case !aws.BoolValue(summary.Enabled):
+		c.set(healthError, "distribution is disabled")
+	case aws.StringValue(summary.Status) != "Deployed":
+		c.set(healthWarning, "distribution changes are still being deployed")
+	default:
+		c.set(healthOK, "distribution is enabled and deployed")
+	}
+
+	return c, summary
+}
+
+// checkWebsiteRecord checks that the website's alias record points to its distribution.  A failover record pointing
+// to the distribution only as the secondary is a warning since the website isn't served by the distribution while
+// the primary is healthy.
+func checkWebsiteRecord(ctx context.Context, route53Service route53api.Route53, website string, summary *cloudfront.DistributionSummary) *websiteHealthComponent {
+	c := &websiteHealthComponent{Component: "dns"}
+
+	if summary == nil {
+		return c.set(healthSkipped, "the record can't be checked without the distribution")
+	}
+
+	zoneID, err := route53Service.ZoneIDForName(ctx, website)
+	if err != nil {
+		return c.set(healthError, "%s", err)
+	}
+
+	records, err := route53Service.ListRecordsByName(ctx, zoneID, website, "A")
+	if err != nil {
+		return c.set(healthError, "%s", err)
+	}
+
+	if len(records) == 0 {
+		return c.set(healthError, "no alias record for %s", website)
+	}
+
+	domainName := aws.StringValue(summary.DomainName)
+	c.Details = map[string]string{"HostedZoneId": zoneID}
+
+	secondary := false
+	for _, rs := range records {
+		if rs.AliasTarget == nil || !sameDomain(aws.StringValue(rs.AliasTarget.DNSName), domainName) {
+			continue
+		}
+
+		if aws.StringValue(rs.Failover) == route53.ResourceRecordSetFailoverSecondary {
+			secondary = true
+			continue
+		}
+
+		return c.set(healthOK, "alias record points to %s", domainName)
+	}
+
+	if secondary {
+		return c.set(healthWarning, "alias record only points to %s as the failover secondary", domainName)
+	}
+
+	target := "a non alias target"
+	if records[0].AliasTarget != nil {
+		target = aws.StringValue(records[0].AliasTarget.DNSName)
+	}
+
+	return c.set(healthError, "alias record points to %s instead of %s", target, domainName)
+}
+
+// checkWebsiteOrigin checks that the website bucket exists and has a website configuration, and returns whether
+// it's a simple website
+func checkWebsiteOrigin(ctx context.Context, s3Service s3api.S3, website string) (*websiteHealthComponent, bool) {
+	c := &websiteHealthComponent{Component: "origin"}
+
+	tags, err := s3Service.GetBucketTags(ctx, website)
+	if err != nil {
+		return c.set(healthError, "%s", err), false
+	}
+
+	config, err := s3Service.GetWebsiteConfig(ctx, website)
+	if err != nil {
+		if isNotFound(err) {
+			return c.set(healthError, "bucket %s has no website configuration", website), isSimpleWebsite(tags)
+		}
+		return c.set(healthError, "%s", err), isSimpleWebsite(tags)
+	}
+
+	c.Details = map[string]string{}
+	if config.IndexDocument != nil {
+		c.Details["IndexDocument"] = aws.StringValue(config.IndexDocument.Suffix)
+	}
+
+	if config.RedirectAllRequestsTo != nil {
+		c.Details["RedirectAllRequestsTo"] = aws.StringValue(config.RedirectAllRequestsTo.HostName)
+	}
+
+	if deleteAfter, pending := pendingDeleteTime(tags); pending {
+		return c.set(healthWarning, "website is pending deletion after %s", deleteAfter.UTC().Format(time.RFC3339)), isSimpleWebsite(tags)
+	}
+
+	return c.set(healthOK, "bucket website configuration exists"), isSimpleWebsite(tags)
+}
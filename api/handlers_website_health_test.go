@@ -0,0 +1,178 @@
+package api
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	acmapi "github.com/YaleSpinup/s3-api/acm"
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	"github.com/YaleSpinup/s3-api/common"
+	route53api "github.com/YaleSpinup/s3-api/route53"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/acm"
+	"github.com/aws/aws-sdk-go/service/acm/acmiface"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/aws/aws-sdk-go/service/cloudfront/cloudfrontiface"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// mockHealthACM is an acm client with a single wildcard certificate
+type mockHealthACM struct {
+	acmiface.ACMAPI
+	notAfter time.Time
+}
+
+func (m *mockHealthACM) DescribeCertificateWithContext(ctx context.Context, input *acm.DescribeCertificateInput, opts ...request.Option) (*acm.DescribeCertificateOutput, error) {
+	return &acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+		CertificateArn: input.CertificateArn,
+		DomainName:     aws.String("*.example.com"),
+		NotAfter:       aws.Time(m.notAfter),
+		Status:         aws.String(acm.CertificateStatusIssued),
+	}}, nil
+}
+
+// mockHealthCloudFront is a cloudfront client with a single website distribution
+type mockHealthCloudFront struct {
+	cloudfrontiface.CloudFrontAPI
+	status string
+}
+
+func (m *mockHealthCloudFront) ListDistributionsPagesWithContext(ctx context.Context, input *cloudfront.ListDistributionsInput, fn func(*cloudfront.ListDistributionsOutput, bool) bool, opts ...request.Option) error {
+	fn(&cloudfront.ListDistributionsOutput{DistributionList: &cloudfront.DistributionList{Items: []*cloudfront.DistributionSummary{
+		{
+			Id:         aws.String("E111"),
+			DomainName: aws.String("d111.cloudfront.net"),
+			Enabled:    aws.Bool(true),
+			Status:     aws.String(m.status),
+			Aliases:    &cloudfront.Aliases{Items: []*string{aws.String("www.example.com")}},
+		},
+	}}}, true)
+	return nil
+}
+
+// mockHealthS3 is an s3 client for a website bucket with an index document
+type mockHealthS3 struct {
+	s3iface.S3API
+	tags []*s3.Tag
+}
+
+func (m *mockHealthS3) GetBucketTaggingWithContext(ctx context.Context, input *s3.GetBucketTaggingInput, opts ...request.Option) (*s3.GetBucketTaggingOutput, error) {
+	return &s3.GetBucketTaggingOutput{TagSet: m.tags}, nil
+}
+
+func (m *mockHealthS3) GetBucketWebsiteWithContext(ctx context.Context, input *s3.GetBucketWebsiteInput, opts ...request.Option) (*s3.GetBucketWebsiteOutput, error) {
+	if m.tags == nil {
+		return nil, awserr.New("NoSuchWebsiteConfiguration", "no website", nil)
+	}
+	return &s3.GetBucketWebsiteOutput{IndexDocument: &s3.IndexDocument{Suffix: aws.String("index.html")}}, nil
+}
+
+func TestWebsiteHealth(t *testing.T) {
+	now := time.Now()
+	domains := map[string]*common.Domain{
+		"example.com": {CertArn: "arn:aws:acm:us-east-1:012345678910:certificate/1111", HostedZoneID: "Z123"},
+	}
+	alias := func(domain string, failover *string) *route53.ResourceRecordSet {
+		return &route53.ResourceRecordSet{
+			Name:        aws.String("www.example.com."),
+			Type:        aws.String("A"),
+			AliasTarget: route53api.CloudFrontAliasTarget(domain),
+			Failover:    failover,
+		}
+	}
+	websiteTags := []*s3.Tag{{Key: aws.String("spinup:org"), Value: aws.String("testorg")}}
+
+	tests := []struct {
+		name     string
+		notAfter time.Time
+		status   string
+		records  []*route53.ResourceRecordSet
+		tags     []*s3.Tag
+		expected string
+		statuses []string
+	}{
+		{
+			name:     "healthy",
+			notAfter: now.Add(90 * 24 * time.Hour),
+			status:   "Deployed",
+			records:  []*route53.ResourceRecordSet{alias("d111.cloudfront.net.", nil)},
+			tags:     websiteTags,
+			expected: healthOK,
+			statuses: []string{healthOK, healthOK, healthOK, healthOK},
+		},
+		{
+			name:     "expiring and deploying",
+			notAfter: now.Add(10 * 24 * time.Hour),
+			status:   "InProgress",
+			records:  []*route53.ResourceRecordSet{alias("d111.cloudfront.net.", nil)},
+			tags:     websiteTags,
+			expected: healthWarning,
+			statuses: []string{healthWarning, healthWarning, healthOK, healthOK},
+		},
+		{
+			name:     "failover secondary",
+			notAfter: now.Add(90 * 24 * time.Hour),
+			status:   "Deployed",
+			records: []*route53.ResourceRecordSet{
+				alias("d222.cloudfront.net.", aws.String("PRIMARY")),
+				alias("d111.cloudfront.net.", aws.String("SECONDARY")),
+			},
+			tags:     websiteTags,
+			expected: healthWarning,
+			statuses: []string{healthOK, healthOK, healthWarning, healthOK},
+		},
+		{
+			name:     "expired, wrong record and no website config",
+			notAfter: now.Add(-time.Hour),
+			status:   "Deployed",
+			records:  []*route53.ResourceRecordSet{alias("d222.cloudfront.net.", nil)},
+			expected: healthError,
+			statuses: []string{healthError, healthOK, healthError, healthError},
+		},
+		{
+			name:     "missing record",
+			notAfter: now.Add(90 * 24 * time.Hour),
+			status:   "Deployed",
+			tags:     websiteTags,
+			expected: healthError,
+			statuses: []string{healthOK, healthOK, healthError, healthOK},
+		},
+		{
+			name:     "simple website",
+			tags:     append(websiteTags, &s3.Tag{Key: aws.String(simpleWebsiteTagKey), Value: aws.String("true")}),
+			expected: healthOK,
+			statuses: []string{healthSkipped, healthSkipped, healthSkipped, healthOK},
+		},
+	}
+
+	for _, test := range tests {
+		out := websiteHealth(context.TODO(),
+			acmapi.ACM{Service: &mockHealthACM{notAfter: test.notAfter}},
+			s3api.S3{Service: &mockHealthS3{tags: test.tags}},
+			cfapi.CloudFront{Service: &mockHealthCloudFront{status: test.status}, Domains: domains},
+			route53api.Route53{Service: &mockRepairRoute53{records: test.records}, Domains: domains},
+			"www.example.com",
+			now,
+		)
+
+		if out.Status != test.expected {
+			t.Errorf("%s: expected status %s, got %s", test.name, test.expected, out.Status)
+		}
+
+		statuses := []string{}
+		for _, c := range out.Components {
+			statuses = append(statuses, c.Status)
+		}
+
+		if !reflect.DeepEqual(statuses, test.statuses) {
+			t.Errorf("%s: expected component statuses %v, got %v (%+v)", test.name, test.statuses, statuses, out.Components)
+		}
+	}
+}
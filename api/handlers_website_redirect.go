@@ -0,0 +1,225 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	"github.com/YaleSpinup/s3-api/lock"
+	"github.com/YaleSpinup/s3-api/retry"
+	route53api "github.com/YaleSpinup/s3-api/route53"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/YaleSpinup/s3-api/task"
+	"github.com/YaleSpinup/s3-api/webhook"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// websiteRedirectProtocols are the protocols a redirect site can redirect with
+var websiteRedirectProtocols = []string{s3.ProtocolHttp, s3.ProtocolHttps}
+
+// websiteRedirect is the target of a redirect site, a website that redirects all requests to another host
+type websiteRedirect struct {
+	// HostName is the host that all requests are redirected to
+	HostName string
+	// Protocol is the protocol of the redirect, http or https (default https)
+	Protocol string `json:",omitempty"`
+	// Distribution creates a cloudfront distribution and dns record for the redirect site so it's reachable over
+	// https (default true).  Without it, only the bucket is created and dns can be pointed at the website endpoint.
+	Distribution *bool `json:",omitempty"`
+}
+
+// distribution returns true if a cloudfront distribution and dns record should be created for the redirect site
+func (r *websiteRedirect) distribution() bool {
+	return r.Distribution == nil || aws.BoolValue(r.Distribution)
+}
+
+// websiteConfiguration returns the s3 website configuration that redirects all requests to the host
+func (r *websiteRedirect) websiteConfiguration() *s3.WebsiteConfiguration {
+	protocol := r.Protocol
+	if protocol == "" {
+		protocol = s3.ProtocolHttps
+	}
+
+	return &s3.WebsiteConfiguration{
+		RedirectAllRequestsTo: &s3.RedirectAllRequestsTo{
+			HostName: aws.String(strings.ToLower(strings.TrimSuffix(r.HostName, "."))),
+			Protocol: aws.String(protocol),
+		},
+	}
+}
+
+// createRedirectWebsite creates a website that redirects all requests to another host, with rollback in the event of
+// failure.  The operations are:
+// 1. create the bucket with the given name
+// 2. tag the bucket and configure its encryption, logging and the redirect
+// 3. create cloudfront distribution with s3 website origin (for https), unless it's disabled
+// 4. create alias record in route53 for the distribution
+// There isn't any content to manage, so the bucket isn't made public and the admin groups aren't created.
+func (s *server) createRedirectWebsite(w http.ResponseWriter, r *http.Request, s3Service s3api.S3, cloudFrontService cfapi.CloudFront, route53Service route53api.Route53, req *websiteCreateRequest, lease *lock.Lease, wait bool) {
+	vars := mux.Vars(r)
+	bucketName := aws.StringValue(req.BucketInput.Bucket)
+
+	var err error
+	rb := s.newRollback("website.create", vars["account"], bucketName, rollbackServices{s3: &s3Service, cloudFront: &cloudFrontService})
+	defer func() {
+		s.finishRollback(rb, err)
+		if err != nil {
+			s.notify(webhook.EventWebsiteRolledBack, vars["account"], bucketName, map[string]string{"Error": err.Error(), "Rollback": rb.ID})
+		}
+	}()
+
+	var zoneID string
+	if req.Redirect.distribution() {
+		if zoneID, err = route53Service.ZoneIDForName(r.Context(), bucketName); err != nil {
+			msg := fmt.Sprintf("failed to find the hosted zone for website %s", bucketName)
+			handleError(w, errors.Wrap(err, msg))
+			return
+		}
+	}
+
+	var bucketOutput *s3.CreateBucketOutput
+	if bucketOutput, err = s3Service.CreateBucket(r.Context(), &req.BucketInput); err != nil {
+		msg := fmt.Sprintf("failed to create bucket %s", bucketName)
+		handleError(w, errors.Wrap(err, msg))
+		return
+	}
+
+	// append bucket delete to rollback
+	rb.Add("delete bucket "+bucketName, rollbackDeleteBucket, map[string]string{"bucket": bucketName})
+
+	// wait for the bucket to exist and tag it
+	if err = retry.Do(r.Context(), s3ConsistencyRetry, func(ctx context.Context) error {
+		if err := s3Service.TagBucket(ctx, bucketName, req.Tags); err != nil {
+			log.Warnf("error tagging redirect website bucket %s: %s", bucketName, err)
+			return err
+		}
+		return nil
+	}); err != nil {
+		msg := fmt.Sprintf("failed to tag website bucket %s", bucketName)
+		handleError(w, errors.Wrap(err, msg))
+		return
+	}
+
+	if err = s3Service.UpdateBucketEncryption(r.Context(), &s3.PutBucketEncryptionInput{
+		Bucket: aws.String(bucketName),
+		ServerSideEncryptionConfiguration: &s3.ServerSideEncryptionConfiguration{
+			Rules: []*s3.ServerSideEncryptionRule{
+				{
+					ApplyServerSideEncryptionByDefault: &s3.ServerSideEncryptionByDefault{
+						SSEAlgorithm: aws.String("AES256"),
+					},
+				},
+			},
+		},
+	}); err != nil {
+		msg := fmt.Sprintf("failed to enable encryption for bucket %s", bucketName)
+		handleError(w, errors.Wrap(err, msg))
+		return
+	}
+
+	if s3Service.LoggingBucket != "" {
+		if err = s3Service.UpdateBucketLogging(r.Context(), bucketName, s3Service.LoggingBucket, s3Service.LoggingBucketPrefix); err != nil {
+			msg := fmt.Sprintf("failed to enable logging for bucket %s", bucketName)
+			handleError(w, errors.Wrap(err, msg))
+			return
+		}
+	}
+
+	websiteConfiguration := req.Redirect.websiteConfiguration()
+	if err = s3Service.UpdateWebsiteConfig(r.Context(), &s3.PutBucketWebsiteInput{
+		Bucket:               aws.String(bucketName),
+		WebsiteConfiguration: websiteConfiguration,
+	}); err != nil {
+		msg := fmt.Sprintf("failed to configure bucket %s to redirect to %s", bucketName, req.Redirect.HostName)
+		handleError(w, errors.Wrap(err, msg))
+		return
+	}
+
+	var (
+		distribution *cloudfront.Distribution
+		dnsChange    *route53.ChangeInfo
+	)
+
+	if req.Redirect.distribution() {
+		var config *cloudfront.DistributionConfig
+		if config, err = cloudFrontService.DefaultWebsiteDistributionConfig(bucketName); err != nil {
+			msg := fmt.Sprintf("failed to generate default website distribution config for %s", bucketName)
+			handleError(w, errors.Wrap(err, msg))
+			return
+		}
+		req.ProtocolSettings.Apply(config)
+
+		if distribution, err = cloudFrontService.CreateDistribution(r.Context(), config, &cloudfront.Tags{Items: cloudFrontTags(req.Tags)}); err != nil {
+			msg := fmt.Sprintf("failed to create cloudfront distribution for website %s", bucketName)
+			handleError(w, errors.Wrap(err, msg))
+			return
+		}
+
+		// append disable cloudfront distribution to rollback
+		rb.Add("disable distribution "+aws.StringValue(distribution.Id), rollbackDisableDistribution, map[string]string{"id": aws.StringValue(distribution.Id)})
+
+		records := route53api.AliasRecordSets(bucketName, route53api.CloudFrontAliasTarget(aws.StringValue(distribution.DomainName)))
+		if dnsChange, err = route53Service.ChangeRecords(r.Context(), zoneID, recordChanges(route53.ChangeActionCreate, records)); err != nil {
+			msg := fmt.Sprintf("failed to create route53 alias records for website %s", bucketName)
+			handleError(w, errors.Wrap(err, msg))
+			return
+		}
+	}
+
+	s.notify(webhook.EventWebsiteCreated, vars["account"], bucketName, map[string]string{"Redirect": req.Redirect.HostName})
+
+	var deployTask *task.Task
+	if distribution != nil {
+		distribution, deployTask = s.deployWebsiteDistribution(r.Context(), w, wait, lease, vars["account"], bucketName, distribution)
+	}
+
+	output := struct {
+		Bucket          *string
+		Redirect        *s3.RedirectAllRequestsTo
+		WebsiteEndpoint string
+		Distribution    *cloudfront.Distribution `json:",omitempty"`
+		DnsChange       *route53.ChangeInfo      `json:",omitempty"`
+		DeployTask      *task.Task               `json:",omitempty"`
+	}{
+		bucketOutput.Location,
+		websiteConfiguration.RedirectAllRequestsTo,
+		bucketName + "." + cloudFrontService.WebsiteEndpoint,
+		distribution,
+		dnsChange,
+		deployTask,
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// websiteRedirectTarget returns the redirect of a website that redirects all requests to another host, or nil if it
+// serves content
+func websiteRedirectTarget(ctx context.Context, s3Service s3api.S3, website string) (*s3.RedirectAllRequestsTo, error) {
+	config, err := s3Service.GetWebsiteConfig(ctx, website)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return config.RedirectAllRequestsTo, nil
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func TestWebsiteRedirectConfiguration(t *testing.T) {
+	r := websiteRedirect{HostName: "New.Example.edu."}
+	if !r.distribution() {
+		t.Error("expected a distribution by default")
+	}
+
+	config := r.websiteConfiguration()
+	if config.IndexDocument != nil || config.RedirectAllRequestsTo == nil {
+		t.Fatalf("expected only a redirect, got %+v", config)
+	}
+
+	if host := aws.StringValue(config.RedirectAllRequestsTo.HostName); host != "new.example.edu" {
+		t.Errorf("expected host new.example.edu, got %s", host)
+	}
+
+	if protocol := aws.StringValue(config.RedirectAllRequestsTo.Protocol); protocol != "https" {
+		t.Errorf("expected default protocol https, got %s", protocol)
+	}
+
+	r = websiteRedirect{HostName: "new.example.edu", Protocol: "http", Distribution: aws.Bool(false)}
+	if r.distribution() {
+		t.Error("expected no distribution")
+	}
+
+	if protocol := aws.StringValue(r.websiteConfiguration().RedirectAllRequestsTo.Protocol); protocol != "http" {
+		t.Errorf("expected protocol http, got %s", protocol)
+	}
+}
+
+func TestWebsiteCreateRequestValidateRedirect(t *testing.T) {
+	domains := map[string]*common.Domain{"example.com": {}}
+
+	req := websiteCreateRequest{
+		BucketInput: s3.CreateBucketInput{Bucket: aws.String("old.example.com")},
+		Redirect:    &websiteRedirect{HostName: "new.example.edu"},
+	}
+
+	if err := req.validate(domains, nil); err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	req.Content = []*websiteContent{{Key: "index.html", Body: "hello"}}
+	req.WebsiteConfiguration = s3.WebsiteConfiguration{IndexDocument: &s3.IndexDocument{Suffix: aws.String("index.html")}}
+	req.CacheBehaviors = []*cfapi.CacheBehavior{{PathPattern: "/api/*"}}
+
+	err := req.validate(domains, nil)
+	aerr, ok := err.(apierror.Error)
+	if !ok {
+		t.Fatalf("expected apierror.Error, got %T", err)
+	}
+
+	for _, field := range []string{"Content", "WebsiteConfiguration", "CacheBehaviors"} {
+		if !strings.Contains(aerr.Message, field+":") {
+			t.Errorf("expected error message to contain field %s, got %q", field, aerr.Message)
+		}
+	}
+}
@@ -0,0 +1,403 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/YaleSpinup/apierror"
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// releasesPrefix is the prefix in a website bucket that holds the releases of the website, each release is a
+	// prefix under it, ie. releases/2024-05-01/
+	releasesPrefix = "releases/"
+	// rootRelease is the release name for the root of the bucket, the content served before any release is activated
+	rootRelease = "/"
+	// previousReleaseTagKey tags the distribution of a website with the release that was active before the current
+	// one, so the switch can be rolled back
+	previousReleaseTagKey = "spinup:previous-release"
+)
+
+// websiteReleasesActions are the actions needed to list and switch the releases of a website
+var websiteReleasesActions = []string{
+	"s3:GetBucketTagging",
+	"s3:GetBucketWebsite",
+	"s3:ListBucket",
+	"s3:GetObject",
+	"cloudfront:ListDistributions",
+	"cloudfront:GetDistribution",
+	"cloudfront:GetDistributionConfig",
+	"cloudfront:UpdateDistribution",
+	"cloudfront:ListTagsForResource",
+	"cloudfront:TagResource",
+	"cloudfront:CreateInvalidation",
+}
+
+// websiteReleasesOutput is the active release of a website, the release it replaced and the releases in the bucket
+type websiteReleasesOutput struct {
+	Website  string
+	Active   string
+	Previous string `json:",omitempty"`
+	Releases []string
+	// Status is the status of the distribution after the release is switched
+	Status string `json:",omitempty"`
+}
+
+// validateRelease validates the name of a release
+func validateRelease(release string) error {
+	f := fieldErrors{}
+	if !releaseRe.MatchString(release) {
+		f.add("release", "invalid release %q, must be up to 100 letters, numbers, dots, dashes or underscores", release)
+	}
+	return f.err()
+}
+
+// releaseOriginPath returns the origin path that serves a release, the root release is served without one
+func releaseOriginPath(release string) string {
+	if release == rootRelease {
+		return ""
+	}
+	return "/" + releasesPrefix + release
+}
+
+// originPathRelease returns the release served by an origin path.  An origin path that wasn't set by the api is
+// returned as is.
+func originPathRelease(path string) string {
+	if path == "" || path == "/" {
+		return rootRelease
+	}
+
+	if release := strings.TrimPrefix(path, "/"+releasesPrefix); release != path {
+		return release
+	}
+
+	return path
+}
+
+// releaseKey returns the key of the index document of a release
+func releaseKey(release, index string) string {
+	if release == rootRelease {
+		return index
+	}
+	return releasesPrefix + release + "/" + index
+}
+
+// listReleases lists the releases in a website bucket, the prefixes under the releases prefix
+func listReleases(ctx context.Context, s3Service s3api.S3, website string) ([]string, error) {
+	releases := []string{}
+
+	input := &s3.ListObjectsV2Input{
+		Bucket:    aws.String(website),
+		Prefix:    aws.String(releasesPrefix),
+		Delimiter: aws.String("/"),
+	}
+
+	for {
+		out, err := s3Service.ListObjects(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range out.CommonPrefixes {
+			release := strings.TrimSuffix(strings.TrimPrefix(aws.StringValue(p.Prefix), releasesPrefix), "/")
+			releases = append(releases, release)
+		}
+
+		if !aws.BoolValue(out.IsTruncated) {
+			return releases, nil
+		}
+		input.ContinuationToken = out.NextContinuationToken
+	}
+}
+
+// checkRelease makes sure a release of a website can be served, simple websites and redirect sites don't have releases
+// and the release must have an index document
+func checkRelease(ctx context.Context, s3Service s3api.S3, website, release string) error {
+	tags, err := s3Service.GetBucketTags(ctx, website)
+	if err != nil {
+		return err
+	}
+
+	if isSimpleWebsite(tags) {
+		return apierror.New(apierror.ErrBadRequest, "simple websites don't have releases", nil)
+	}
+
+	config, err := s3Service.GetWebsiteConfig(ctx, website)
+	if err != nil {
+		return err
+	}
+
+	if config.RedirectAllRequestsTo != nil || config.IndexDocument == nil {
+		return apierror.New(apierror.ErrBadRequest, "redirect sites don't have releases", nil)
+	}
+
+	key := releaseKey(release, aws.StringValue(config.IndexDocument.Suffix))
+	if _, err := s3Service.HeadObject(ctx, website, key); err != nil {
+		if isNotFound(err) {
+			msg := fmt.Sprintf("release %s of website %s not found, %s doesn't exist", release, website, key)
+			return apierror.New(apierror.ErrNotFound, msg, err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// previousRelease returns the release a website was switched from, empty if it hasn't been switched
+func previousRelease(ctx context.Context, cloudFrontService cfapi.CloudFront, distribution *cloudfront.DistributionSummary) (string, error) {
+	tags, err := cloudFrontService.ListTags(ctx, aws.StringValue(distribution.ARN))
+	if err != nil {
+		return "", err
+	}
+
+	for _, t := range tags {
+		if aws.StringValue(t.Key) == previousReleaseTagKey {
+			return aws.StringValue(t.Value), nil
+		}
+	}
+
+	return "", nil
+}
+
+// switchRelease points the website origin of a distribution at a release and invalidates the cache, so the release is
+// served as soon as the distribution is deployed.  The active release is recorded on the distribution before it's
+// switched so the switch can be rolled back.  Nothing is changed if the release is already active.
+func switchRelease(ctx context.Context, cloudFrontService cfapi.CloudFront, distribution *cloudfront.DistributionSummary, website, release string) (*websiteReleasesOutput, error) {
+	id := aws.StringValue(distribution.Id)
+
+	current, err := cloudFrontService.GetDistribution(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	active := originPathRelease(cfapi.OriginPath(current.DistributionConfig, website))
+
+	if active == release {
+		previous, err := previousRelease(ctx, cloudFrontService, distribution)
+		if err != nil {
+			return nil, err
+		}
+
+		return &websiteReleasesOutput{
+			Website:  website,
+			Active:   active,
+			Previous: previous,
+			Status:   aws.StringValue(current.Status),
+		}, nil
+	}
+
+	if err := cloudFrontService.TagDistribution(ctx, aws.StringValue(distribution.ARN), &cloudfront.Tags{
+		Items: []*cloudfront.Tag{{Key: aws.String(previousReleaseTagKey), Value: aws.String(active)}},
+	}); err != nil {
+		return nil, err
+	}
+
+	updated, err := cloudFrontService.UpdateOriginPath(ctx, id, website, releaseOriginPath(release))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := cloudFrontService.InvalidateCache(ctx, id, []string{"/*"}); err != nil {
+		msg := fmt.Sprintf("switched website %s to release %s but failed to invalidate the cache", website, release)
+		return nil, errors.Wrap(err, msg)
+	}
+
+	return &websiteReleasesOutput{
+		Website:  website,
+		Active:   release,
+		Previous: active,
+		Status:   aws.StringValue(updated.Status),
+	}, nil
+}
+
+// websiteReleasesServices returns the s3 and cloudfront services to manage the releases of the websites in an account
+func (s *server) websiteReleasesServices(ctx context.Context, account string) (s3api.S3, cfapi.CloudFront, error) {
+	if err := s.requireWebsites(account); err != nil {
+		return s3api.S3{}, cfapi.CloudFront{}, err
+	}
+
+	accountId := s.mapAccountNumber(account)
+
+	session, err := s.sessionForAccount(ctx, account, websiteReleasesActions...)
+	if err != nil {
+		return s3api.S3{}, cfapi.CloudFront{}, err
+	}
+
+	cloudFrontService := cfapi.NewSession(session.Session, s.account, accountId)
+	cloudFrontService.Index = s.distributionIndex(accountId)
+
+	return s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId)), cloudFrontService, nil
+}
+
+// writeWebsiteReleases lists the releases of a website into the output and writes it
+func writeWebsiteReleases(ctx context.Context, w http.ResponseWriter, s3Service s3api.S3, output *websiteReleasesOutput) {
+	releases, err := listReleases(ctx, s3Service, output.Website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	output.Releases = releases
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// WebsiteReleaseListHandler lists the releases of a website with the active and previous release
+func (s *server) WebsiteReleaseListHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	website := vars["website"]
+
+	s3Service, cloudFrontService, err := s.websiteReleasesServices(r.Context(), vars["account"])
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	distribution, err := cloudFrontService.GetDistributionByName(r.Context(), website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	current, err := cloudFrontService.GetDistribution(r.Context(), aws.StringValue(distribution.Id))
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	previous, err := previousRelease(r.Context(), cloudFrontService, distribution)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeWebsiteReleases(r.Context(), w, s3Service, &websiteReleasesOutput{
+		Website:  website,
+		Active:   originPathRelease(cfapi.OriginPath(current.DistributionConfig, website)),
+		Previous: previous,
+		Status:   aws.StringValue(current.Status),
+	})
+}
+
+// WebsiteReleaseActivateHandler switches a website to one of its releases.  The content of the release is uploaded
+// under the releases prefix of the bucket beforehand, the switch only changes the origin path of the distribution.
+func (s *server) WebsiteReleaseActivateHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	website := vars["website"]
+	release := vars["release"]
+
+	if err := validateRelease(release); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s3Service, cloudFrontService, err := s.websiteReleasesServices(r.Context(), vars["account"])
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	lease, err := s.lockResource(r.Context(), vars["account"], website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	defer lease.Release()
+
+	if err := checkRelease(r.Context(), s3Service, website, release); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	distribution, err := cloudFrontService.GetDistributionByName(r.Context(), website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	output, err := switchRelease(r.Context(), cloudFrontService, distribution, website, release)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeWebsiteReleases(r.Context(), w, s3Service, output)
+}
+
+// WebsiteReleaseRollbackHandler switches a website back to the release that was active before the current one.  The
+// release that's rolled back from becomes the previous release, so rolling back again switches forward.
+func (s *server) WebsiteReleaseRollbackHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	website := vars["website"]
+
+	s3Service, cloudFrontService, err := s.websiteReleasesServices(r.Context(), vars["account"])
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	lease, err := s.lockResource(r.Context(), vars["account"], website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	defer lease.Release()
+
+	distribution, err := cloudFrontService.GetDistributionByName(r.Context(), website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	previous, err := previousRelease(r.Context(), cloudFrontService, distribution)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if previous == "" {
+		msg := fmt.Sprintf("website %s hasn't been switched to a release, there's nothing to roll back to", website)
+		handleError(w, apierror.New(apierror.ErrConflict, msg, nil))
+		return
+	}
+
+	if previous != rootRelease && validateRelease(previous) != nil {
+		msg := fmt.Sprintf("website %s was switched from origin path %s, it can't be rolled back by the api", website, previous)
+		handleError(w, apierror.New(apierror.ErrConflict, msg, nil))
+		return
+	}
+
+	if err := checkRelease(r.Context(), s3Service, website, previous); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	output, err := switchRelease(r.Context(), cloudFrontService, distribution, website, previous)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeWebsiteReleases(r.Context(), w, s3Service, output)
+}
@@ -0,0 +1,237 @@
+package api
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	"github.com/YaleSpinup/s3-api/common"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/aws/aws-sdk-go/service/cloudfront/cloudfrontiface"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// mockReleasesS3 is an s3 client with a website bucket whose releases are listed one at a time
+type mockReleasesS3 struct {
+	s3iface.S3API
+	tags     []*s3.Tag
+	config   *s3.GetBucketWebsiteOutput
+	keys     []string
+	releases []string
+}
+
+func (m *mockReleasesS3) GetBucketTaggingWithContext(ctx context.Context, input *s3.GetBucketTaggingInput, opts ...request.Option) (*s3.GetBucketTaggingOutput, error) {
+	return &s3.GetBucketTaggingOutput{TagSet: m.tags}, nil
+}
+
+func (m *mockReleasesS3) GetBucketWebsiteWithContext(ctx context.Context, input *s3.GetBucketWebsiteInput, opts ...request.Option) (*s3.GetBucketWebsiteOutput, error) {
+	return m.config, nil
+}
+
+func (m *mockReleasesS3) HeadObjectWithContext(ctx context.Context, input *s3.HeadObjectInput, opts ...request.Option) (*s3.HeadObjectOutput, error) {
+	if !contains(m.keys, aws.StringValue(input.Key)) {
+		return nil, awserr.New("NotFound", "Not Found", nil)
+	}
+	return &s3.HeadObjectOutput{}, nil
+}
+
+func (m *mockReleasesS3) ListObjectsV2WithContext(ctx context.Context, input *s3.ListObjectsV2Input, opts ...request.Option) (*s3.ListObjectsV2Output, error) {
+	i := 0
+	if input.ContinuationToken != nil {
+		for n, r := range m.releases {
+			if r == aws.StringValue(input.ContinuationToken) {
+				i = n
+			}
+		}
+	}
+
+	out := &s3.ListObjectsV2Output{
+		CommonPrefixes: []*s3.CommonPrefix{{Prefix: aws.String(aws.StringValue(input.Prefix) + m.releases[i] + "/")}},
+		IsTruncated:    aws.Bool(i+1 < len(m.releases)),
+	}
+	if i+1 < len(m.releases) {
+		out.NextContinuationToken = aws.String(m.releases[i+1])
+	}
+	return out, nil
+}
+
+// mockReleasesCloudFront is a cloudfront client with the distribution of www.example.com that keeps its origin path and
+// tags
+type mockReleasesCloudFront struct {
+	cloudfrontiface.CloudFrontAPI
+	originPath    string
+	tags          map[string]string
+	invalidations int
+}
+
+func (m *mockReleasesCloudFront) config() *cloudfront.DistributionConfig {
+	return &cloudfront.DistributionConfig{
+		Origins: &cloudfront.Origins{
+			Items: []*cloudfront.Origin{{Id: aws.String("www.example.com"), OriginPath: aws.String(m.originPath)}},
+		},
+	}
+}
+
+func (m *mockReleasesCloudFront) GetDistributionWithContext(ctx context.Context, input *cloudfront.GetDistributionInput, opts ...request.Option) (*cloudfront.GetDistributionOutput, error) {
+	return &cloudfront.GetDistributionOutput{
+		Distribution: &cloudfront.Distribution{Id: input.Id, Status: aws.String("Deployed"), DistributionConfig: m.config()},
+	}, nil
+}
+
+func (m *mockReleasesCloudFront) GetDistributionConfigWithContext(ctx context.Context, input *cloudfront.GetDistributionConfigInput, opts ...request.Option) (*cloudfront.GetDistributionConfigOutput, error) {
+	return &cloudfront.GetDistributionConfigOutput{DistributionConfig: m.config(), ETag: aws.String("ETAG")}, nil
+}
+
+func (m *mockReleasesCloudFront) UpdateDistributionWithContext(ctx context.Context, input *cloudfront.UpdateDistributionInput, opts ...request.Option) (*cloudfront.UpdateDistributionOutput, error) {
+	m.originPath = cfapi.OriginPath(input.DistributionConfig, "www.example.com")
+	return &cloudfront.UpdateDistributionOutput{
+		Distribution: &cloudfront.Distribution{Id: input.Id, Status: aws.String("InProgress"), DistributionConfig: input.DistributionConfig},
+	}, nil
+}
+
+func (m *mockReleasesCloudFront) ListTagsForResourceWithContext(ctx context.Context, input *cloudfront.ListTagsForResourceInput, opts ...request.Option) (*cloudfront.ListTagsForResourceOutput, error) {
+	tags := []*cloudfront.Tag{}
+	for k, v := range m.tags {
+		tags = append(tags, &cloudfront.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	return &cloudfront.ListTagsForResourceOutput{Tags: &cloudfront.Tags{Items: tags}}, nil
+}
+
+func (m *mockReleasesCloudFront) TagResourceWithContext(ctx context.Context, input *cloudfront.TagResourceInput, opts ...request.Option) (*cloudfront.TagResourceOutput, error) {
+	for _, t := range input.Tags.Items {
+		m.tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+	return &cloudfront.TagResourceOutput{}, nil
+}
+
+func (m *mockReleasesCloudFront) CreateInvalidationWithContext(ctx context.Context, input *cloudfront.CreateInvalidationInput, opts ...request.Option) (*cloudfront.CreateInvalidationOutput, error) {
+	m.invalidations++
+	return &cloudfront.CreateInvalidationOutput{}, nil
+}
+
+func TestReleaseOriginPath(t *testing.T) {
+	tests := []struct {
+		release, path string
+	}{
+		{"/", ""},
+		{"v1", "/releases/v1"},
+		{"2024-05-01.1", "/releases/2024-05-01.1"},
+	}
+
+	for _, tt := range tests {
+		if out := releaseOriginPath(tt.release); out != tt.path {
+			t.Errorf("expected origin path %q for %s, got %q", tt.path, tt.release, out)
+		}
+
+		if out := originPathRelease(tt.path); out != tt.release {
+			t.Errorf("expected release %s for origin path %q, got %s", tt.release, tt.path, out)
+		}
+	}
+
+	if out := originPathRelease("/legacy"); out != "/legacy" {
+		t.Errorf("expected an origin path that isn't a release to be returned as is, got %s", out)
+	}
+
+	for release, valid := range map[string]bool{"v1": true, "2024-05-01_rc.1": true, "": false, ".hidden": false, "a/b": false, "rollback/..": false} {
+		if err := validateRelease(release); (err == nil) != valid {
+			t.Errorf("expected release %q valid to be %t, got %v", release, valid, err)
+		}
+	}
+}
+
+func TestListReleases(t *testing.T) {
+	s3Service := s3api.S3{Service: &mockReleasesS3{releases: []string{"v1", "v2", "v3"}}}
+
+	out, err := listReleases(context.TODO(), s3Service, "www.example.com")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if !reflect.DeepEqual(out, []string{"v1", "v2", "v3"}) {
+		t.Errorf("expected releases v1, v2 and v3, got %v", out)
+	}
+}
+
+func TestCheckRelease(t *testing.T) {
+	m := &mockReleasesS3{
+		config: &s3.GetBucketWebsiteOutput{IndexDocument: &s3.IndexDocument{Suffix: aws.String("index.html")}},
+		keys:   []string{"index.html", "releases/v1/index.html"},
+	}
+	s3Service := s3api.S3{Service: m}
+
+	for _, release := range []string{"/", "v1"} {
+		if err := checkRelease(context.TODO(), s3Service, "www.example.com", release); err != nil {
+			t.Errorf("expected nil error for release %s, got %s", release, err)
+		}
+	}
+
+	if err := checkRelease(context.TODO(), s3Service, "www.example.com", "v2"); !common.IsErrorCode(err, apierror.ErrNotFound) {
+		t.Errorf("expected not found error for a release without an index document, got %v", err)
+	}
+
+	m.tags = []*s3.Tag{{Key: aws.String(simpleWebsiteTagKey), Value: aws.String("true")}}
+	if err := checkRelease(context.TODO(), s3Service, "www.example.com", "v1"); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Errorf("expected bad request error for a simple website, got %v", err)
+	}
+
+	m.tags = nil
+	m.config = &s3.GetBucketWebsiteOutput{RedirectAllRequestsTo: &s3.RedirectAllRequestsTo{HostName: aws.String("example.org")}}
+	if err := checkRelease(context.TODO(), s3Service, "www.example.com", "v1"); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Errorf("expected bad request error for a redirect site, got %v", err)
+	}
+}
+
+func TestSwitchRelease(t *testing.T) {
+	m := &mockReleasesCloudFront{tags: map[string]string{}}
+	cloudFrontService := cfapi.CloudFront{Service: m}
+	distribution := &cloudfront.DistributionSummary{
+		Id:  aws.String("E123"),
+		ARN: aws.String("arn:aws:cloudfront::012345678910:distribution/E123"),
+	}
+
+	steps := []struct {
+		release       string
+		expected      websiteReleasesOutput
+		originPath    string
+		invalidations int
+	}{
+		// switching from the root of the bucket to a release
+		{"v1", websiteReleasesOutput{Website: "www.example.com", Active: "v1", Previous: "/", Status: "InProgress"}, "/releases/v1", 1},
+		{"v2", websiteReleasesOutput{Website: "www.example.com", Active: "v2", Previous: "v1", Status: "InProgress"}, "/releases/v2", 2},
+		// switching to the active release doesn't change anything
+		{"v2", websiteReleasesOutput{Website: "www.example.com", Active: "v2", Previous: "v1", Status: "Deployed"}, "/releases/v2", 2},
+		// rolling back switches to the previous release, rolling back again switches forward
+		{"v1", websiteReleasesOutput{Website: "www.example.com", Active: "v1", Previous: "v2", Status: "InProgress"}, "/releases/v1", 3},
+		{"/", websiteReleasesOutput{Website: "www.example.com", Active: "/", Previous: "v1", Status: "InProgress"}, "", 4},
+	}
+
+	for _, step := range steps {
+		out, err := switchRelease(context.TODO(), cloudFrontService, distribution, "www.example.com", step.release)
+		if err != nil {
+			t.Fatalf("expected nil error switching to %s, got %s", step.release, err)
+		}
+
+		if !reflect.DeepEqual(*out, step.expected) {
+			t.Errorf("expected %+v switching to %s, got %+v", step.expected, step.release, *out)
+		}
+
+		if m.originPath != step.originPath {
+			t.Errorf("expected origin path %q after switching to %s, got %q", step.originPath, step.release, m.originPath)
+		}
+
+		if m.tags[previousReleaseTagKey] != step.expected.Previous {
+			t.Errorf("expected previous release tag %s after switching to %s, got %s", step.expected.Previous, step.release, m.tags[previousReleaseTagKey])
+		}
+
+		if m.invalidations != step.invalidations {
+			t.Errorf("expected %d invalidations after switching to %s, got %d", step.invalidations, step.release, m.invalidations)
+		}
+	}
+}
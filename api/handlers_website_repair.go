@@ -0,0 +1,408 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/YaleSpinup/apierror"
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	"github.com/YaleSpinup/s3-api/common"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/YaleSpinup/s3-api/retry"
+	route53api "github.com/YaleSpinup/s3-api/route53"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// websiteRepairTeardown is the repair action when the website bucket is gone and the rest of the website is deleted
+	websiteRepairTeardown = "teardown"
+	// websiteRepairReprovision is the repair action when the website bucket exists and the missing pieces are recreated
+	websiteRepairReprovision = "reprovision"
+)
+
+// websiteRepairOutput is the result of repairing a website, Repairs lists the changes that were made and is empty
+// if the website was already consistent.  The statuses of a teardown include the resources that couldn't be deleted.
+type websiteRepairOutput struct {
+	Website string
+	Action  string
+	Repairs []string
+	multiStatus
+}
+
+// changeRecorder records the changes made to bring resources to a consistent state
+type changeRecorder interface {
+	add(format string, args ...interface{})
+}
+
+func (o *websiteRepairOutput) add(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	log.Infof("repair website %s: %s", o.Website, msg)
+	o.Repairs = append(o.Repairs, msg)
+}
+
+// WebsiteRepairHandler returns a website that was left partially deleted or partially created to a consistent state.
+// If the website bucket is gone, the teardown is completed by deleting the groups, policies, users, dns records and
+// disabling the distribution.  If the bucket still exists, the missing admin groups and policies, the distribution
+// and the dns record are recreated and a disabled distribution is enabled again.
+func (s *server) WebsiteRepairHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	website := vars["website"]
+
+	session, err := s.sessionForAccount(r.Context(), vars["account"], "s3:*", "iam:*", "cloudfront:*", "route53:*")
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+	iamService := iamapi.NewSession(session.Session, s.account)
+	iamService.Cache = s.resourceCache(accountId)
+	cloudFrontService := cfapi.NewSession(session.Session, s.account, accountId)
+	cloudFrontService.Index = s.distributionIndex(accountId)
+	route53Service := route53api.NewSession(session.Session, s.account)
+
+	lease, err := s.lockResource(r.Context(), vars["account"], website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	defer lease.Release()
+
+	zoneID, err := route53Service.ZoneIDForName(r.Context(), website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	exists, err := s3Service.BucketExists(r.Context(), website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	var output *websiteRepairOutput
+	if exists {
+		output, err = reprovisionWebsite(r.Context(), s3Service, iamService, cloudFrontService, route53Service, accountId, zoneID, website, s.ipAllowlist(nil))
+	} else {
+		output, err = teardownWebsite(r.Context(), iamService, cloudFrontService, route53Service, accountId, zoneID, website)
+	}
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(output.code())
+	w.Write(j)
+}
+
+// teardownWebsite completes the deletion of a website whose bucket is already gone.  Each step tolerates the
+// resources that were already deleted, so the teardown can be repeated until it succeeds.  A failure to delete one of
+// the resources is recorded in the output's statuses and the teardown continues with the others.
+func teardownWebsite(ctx context.Context, iamService iamapi.IAM, cloudFrontService cfapi.CloudFront, route53Service route53api.Route53, accountId, zoneID, website string) (*websiteRepairOutput, error) {
+	output := &websiteRepairOutput{Website: website, Action: websiteRepairTeardown, Repairs: []string{}, multiStatus: newMultiStatus()}
+
+	deleteWebsiteIAM(ctx, iamService, website, &output.multiStatus)
+	for _, status := range output.Statuses {
+		if status.Error == "" {
+			output.add("deleted %s %s", status.Type, status.Resource)
+		}
+	}
+
+	// policies left behind by a group that's already gone can't be found through the groups
+	for _, name := range websitePolicyNames(website) {
+		if err := iamService.DeletePolicy(ctx, &iam.DeletePolicyInput{
+			PolicyArn: aws.String(iamService.PolicyArn(accountId, name)),
+		}); err != nil {
+			if !isNotFound(err) {
+				output.fail("policy", name, "delete", err)
+			}
+			continue
+		}
+
+		output.ok("policy", name, "delete")
+		output.add("deleted policy %s", name)
+	}
+
+	records, err := route53Service.ListAliasRecords(ctx, zoneID, website)
+	if err != nil {
+		output.fail("dns", website, "list", err)
+	} else if len(records) > 0 {
+		if _, err := route53Service.ChangeRecords(ctx, zoneID, recordChanges(route53.ChangeActionDelete, records)); err != nil {
+			output.fail("dns", website, "delete", err)
+		} else {
+			deleteHealthChecks(ctx, route53Service, records)
+
+			output.ok("dns", website, "delete")
+			output.add("deleted %d dns records", len(records))
+		}
+	}
+
+	summary, err := cloudFrontService.GetDistributionByName(ctx, website)
+	if err != nil {
+		if !isNotFound(err) {
+			output.fail("distribution", website, "find", err)
+		}
+		return output, nil
+	}
+
+	if aws.BoolValue(summary.Enabled) {
+		if err := retry.Do(ctx, cloudFrontRetry, func(ctx context.Context) error {
+			_, err := cloudFrontService.DisableDistribution(ctx, aws.StringValue(summary.Id))
+			return err
+		}); err != nil {
+			output.fail("distribution", aws.StringValue(summary.Id), "disable", err)
+			return output, nil
+		}
+
+		output.ok("distribution", aws.StringValue(summary.Id), "disable")
+		output.add("disabled distribution %s", aws.StringValue(summary.Id))
+	}
+
+	return output, nil
+}
+
+// reprovisionWebsite recreates the pieces of a website that are missing when the website bucket still exists.  Soft
+// deleted websites are consistent and have to be restored instead.
+func reprovisionWebsite(ctx context.Context, s3Service s3api.S3, iamService iamapi.IAM, cloudFrontService cfapi.CloudFront, route53Service route53api.Route53, accountId, zoneID, website string, ipAllowlist []string) (*websiteRepairOutput, error) {
+	tags, err := s3Service.GetBucketTags(ctx, website)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, pending := pendingDeleteTime(tags); pending {
+		msg := fmt.Sprintf("website %s is pending deletion, restore it instead", website)
+		return nil, apierror.New(apierror.ErrConflict, msg, nil)
+	}
+
+	output := &websiteRepairOutput{Website: website, Action: websiteRepairReprovision, Repairs: []string{}, multiStatus: newMultiStatus()}
+
+	// the distribution comes first since the web admin policy and the dns record refer to it
+	var distributionArn, domainName string
+	recreated := false
+	summary, err := cloudFrontService.GetDistributionByName(ctx, website)
+	switch {
+	case err == nil:
+		distributionArn = aws.StringValue(summary.ARN)
+		domainName = aws.StringValue(summary.DomainName)
+
+		if !aws.BoolValue(summary.Enabled) {
+			if err := retry.Do(ctx, cloudFrontRetry, func(ctx context.Context) error {
+				_, err := cloudFrontService.EnableDistribution(ctx, aws.StringValue(summary.Id))
+				return err
+			}); err != nil {
+				return nil, err
+			}
+
+			output.add("enabled distribution %s", aws.StringValue(summary.Id))
+		}
+	case isNotFound(err):
+		config, err := cloudFrontService.DefaultWebsiteDistributionConfig(website)
+		if err != nil {
+			msg := fmt.Sprintf("failed to generate default website distribution config for %s: %s", website, err.Error())
+			return nil, apierror.New(apierror.ErrInternalError, msg, err)
+		}
+
+		distribution, err := cloudFrontService.CreateDistribution(ctx, config, &cloudfront.Tags{Items: cloudFrontTags(tags)})
+		if err != nil {
+			return nil, err
+		}
+
+		distributionArn = aws.StringValue(distribution.ARN)
+		domainName = aws.StringValue(distribution.DomainName)
+		recreated = true
+
+		output.add("created distribution %s", aws.StringValue(distribution.Id))
+	default:
+		return nil, err
+	}
+
+	bktPolicy, err := iamService.DefaultBucketAdminPolicy(aws.String(website), ipAllowlist)
+	if err != nil {
+		msg := fmt.Sprintf("failed building default IAM policy for bucket %s: %s", website, err.Error())
+		return nil, apierror.New(apierror.ErrInternalError, msg, err)
+	}
+
+	if err := repairAdminGroup(ctx, iamService, output, accountId,
+		iamapi.FormatPolicyName(website, "/", "BktAdmPlc"),
+		fmt.Sprintf("Admin policy for %s bucket", website),
+		bktPolicy,
+		iamapi.FormatGroupName(website, "/", "BktAdmGrp"),
+		iamTags(tags),
+		false,
+	); err != nil {
+		return nil, err
+	}
+
+	webPolicy, err := iamService.DefaultWebAdminPolicy(aws.String(distributionArn))
+	if err != nil {
+		msg := fmt.Sprintf("failed building default IAM policy for cloudfront distribution %s: %s", distributionArn, err.Error())
+		return nil, apierror.New(apierror.ErrInternalError, msg, err)
+	}
+
+	// an existing web admin policy refers to the old distribution if the distribution was recreated
+	if err := repairAdminGroup(ctx, iamService, output, accountId,
+		iamapi.FormatPolicyName(website, "/", "WebAdmPlc"),
+		fmt.Sprintf("Admin policy for %s web distribution", website),
+		webPolicy,
+		iamapi.FormatGroupName(website, "/", "WebAdmGrp"),
+		iamTags(tags),
+		recreated,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := repairWebsiteRecord(ctx, route53Service, output, zoneID, website, domainName); err != nil {
+		return nil, err
+	}
+
+	return output, nil
+}
+
+// repairAdminGroup makes sure a bucket or website admin group exists and has its policy attached, creating the
+// policy and group if they're missing.  When replace is true, the document of an existing policy is replaced with a
+// new default version, so the previous document can be restored from its versions.
+func repairAdminGroup(ctx context.Context, iamService iamapi.IAM, output changeRecorder, accountId, policyName, description string, document []byte, groupName string, tags []*iam.Tag, replace bool) error {
+	policyArn := iamService.PolicyArn(accountId, policyName)
+
+	updated := false
+	if replace {
+		if err := iamService.UpdatePolicyDocument(ctx, policyArn, document); err != nil {
+			if !isNotFound(err) {
+				return err
+			}
+		} else {
+			output.add("updated policy %s", policyName)
+			updated = true
+		}
+	}
+
+	if !updated {
+		if _, err := iamService.CreatePolicy(ctx, &iam.CreatePolicyInput{
+			Description:    aws.String(description),
+			PolicyDocument: aws.String(string(document)),
+			PolicyName:     aws.String(policyName),
+			Tags:           tags,
+		}); err != nil {
+			if !common.IsErrorCode(err, apierror.ErrConflict) {
+				return err
+			}
+		} else {
+			output.add("created policy %s", policyName)
+		}
+	}
+
+	if _, err := iamService.GetGroup(ctx, groupName); err != nil {
+		if !isNotFound(err) {
+			return err
+		}
+
+		if _, err := iamService.CreateGroup(ctx, &iam.CreateGroupInput{GroupName: aws.String(groupName)}); err != nil {
+			return err
+		}
+
+		output.add("created group %s", groupName)
+	}
+
+	attached, err := iamService.ListGroupPolicies(ctx, &iam.ListAttachedGroupPoliciesInput{GroupName: aws.String(groupName)})
+	if err != nil {
+		return err
+	}
+
+	for _, p := range attached {
+		if aws.StringValue(p.PolicyArn) == policyArn {
+			return nil
+		}
+	}
+
+	if err := iamService.AttachGroupPolicy(ctx, &iam.AttachGroupPolicyInput{
+		GroupName: aws.String(groupName),
+		PolicyArn: aws.String(policyArn),
+	}); err != nil {
+		return err
+	}
+
+	output.add("attached policy %s to group %s", policyName, groupName)
+
+	return nil
+}
+
+// repairWebsiteRecord makes sure the website's alias record exists and points to the distribution.  Failover records
+// pointing elsewhere are left alone since they're managed with the failover endpoints.
+func repairWebsiteRecord(ctx context.Context, route53Service route53api.Route53, output *websiteRepairOutput, zoneID, website, domainName string) error {
+	records, err := route53Service.ListRecordsByName(ctx, zoneID, website, "A")
+	if err != nil {
+		return err
+	}
+
+	if len(records) == 0 {
+		if _, err := route53Service.CreateRecord(ctx, zoneID, &route53.ResourceRecordSet{
+			AliasTarget: route53api.CloudFrontAliasTarget(domainName),
+			Name:        aws.String(website),
+			Type:        aws.String("A"),
+		}); err != nil {
+			return err
+		}
+
+		output.add("created dns record %s", website)
+		return nil
+	}
+
+	for _, rs := range records {
+		if rs.AliasTarget != nil && sameDomain(aws.StringValue(rs.AliasTarget.DNSName), domainName) {
+			return nil
+		}
+	}
+
+	if hasFailover(records) {
+		log.Warnf("failover records for website %s don't point to distribution %s, leaving them", website, domainName)
+		return nil
+	}
+
+	record := records[0]
+	record.AliasTarget = route53api.CloudFrontAliasTarget(domainName)
+	if _, err := route53Service.ChangeRecords(ctx, zoneID, recordChanges(route53.ChangeActionUpsert, []*route53.ResourceRecordSet{record})); err != nil {
+		return err
+	}
+
+	output.add("updated dns record %s to point to %s", website, domainName)
+
+	return nil
+}
+
+// websitePolicyNames returns the names of the policies the api creates for a website
+func websitePolicyNames(website string) []string {
+	return []string{
+		iamapi.FormatPolicyName(website, "/", "BktAdmPlc"),
+		iamapi.FormatPolicyName(website, "/", "WebAdmPlc"),
+	}
+}
+
+// sameDomain compares two domain names, ignoring the case and the trailing dot
+func sameDomain(a, b string) bool {
+	return strings.EqualFold(strings.TrimSuffix(a, "."), strings.TrimSuffix(b, "."))
+}
+
+// isNotFound returns true if the error, or an error it wraps, is an apierror with the not found code
+func isNotFound(err error) bool {
+	return common.IsErrorCode(err, apierror.ErrNotFound)
+}
@@ -0,0 +1,245 @@
+package api
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	route53api "github.com/YaleSpinup/s3-api/route53"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53/route53iface"
+)
+
+// mockRepairIAM is an iam client that tracks the policies and groups that exist and the policies attached to groups
+type mockRepairIAM struct {
+	iamiface.IAMAPI
+	policies map[string]bool
+	groups   map[string][]string
+}
+
+func (m *mockRepairIAM) CreatePolicyWithContext(ctx context.Context, input *iam.CreatePolicyInput, opts ...request.Option) (*iam.CreatePolicyOutput, error) {
+	arn := iamapi.FormatPolicyArn("012345678910", "/", aws.StringValue(input.PolicyName))
+	if m.policies[arn] {
+		return nil, awserr.New(iam.ErrCodeEntityAlreadyExistsException, "policy exists", nil)
+	}
+	m.policies[arn] = true
+	return &iam.CreatePolicyOutput{Policy: &iam.Policy{Arn: aws.String(arn), PolicyName: input.PolicyName}}, nil
+}
+
+func (m *mockRepairIAM) DeletePolicyWithContext(ctx context.Context, input *iam.DeletePolicyInput, opts ...request.Option) (*iam.DeletePolicyOutput, error) {
+	if !m.policies[aws.StringValue(input.PolicyArn)] {
+		return nil, awserr.New(iam.ErrCodeNoSuchEntityException, "policy not found", nil)
+	}
+	delete(m.policies, aws.StringValue(input.PolicyArn))
+	return &iam.DeletePolicyOutput{}, nil
+}
+
+func (m *mockRepairIAM) ListPolicyVersionsWithContext(ctx context.Context, input *iam.ListPolicyVersionsInput, opts ...request.Option) (*iam.ListPolicyVersionsOutput, error) {
+	if !m.policies[aws.StringValue(input.PolicyArn)] {
+		return nil, awserr.New(iam.ErrCodeNoSuchEntityException, "policy not found", nil)
+	}
+	return &iam.ListPolicyVersionsOutput{Versions: []*iam.PolicyVersion{{VersionId: aws.String("v1"), IsDefaultVersion: aws.Bool(true)}}}, nil
+}
+
+func (m *mockRepairIAM) CreatePolicyVersionWithContext(ctx context.Context, input *iam.CreatePolicyVersionInput, opts ...request.Option) (*iam.CreatePolicyVersionOutput, error) {
+	return &iam.CreatePolicyVersionOutput{PolicyVersion: &iam.PolicyVersion{VersionId: aws.String("v2")}}, nil
+}
+
+func (m *mockRepairIAM) GetPolicyWithContext(ctx context.Context, input *iam.GetPolicyInput, opts ...request.Option) (*iam.GetPolicyOutput, error) {
+	if !m.policies[aws.StringValue(input.PolicyArn)] {
+		return nil, awserr.New(iam.ErrCodeNoSuchEntityException, "policy not found", nil)
+	}
+	return &iam.GetPolicyOutput{Policy: &iam.Policy{Arn: input.PolicyArn}}, nil
+}
+
+func (m *mockRepairIAM) GetGroupWithContext(ctx context.Context, input *iam.GetGroupInput, opts ...request.Option) (*iam.GetGroupOutput, error) {
+	if _, ok := m.groups[aws.StringValue(input.GroupName)]; !ok {
+		return nil, awserr.New(iam.ErrCodeNoSuchEntityException, "group not found", nil)
+	}
+	return &iam.GetGroupOutput{Group: &iam.Group{GroupName: input.GroupName}}, nil
+}
+
+func (m *mockRepairIAM) CreateGroupWithContext(ctx context.Context, input *iam.CreateGroupInput, opts ...request.Option) (*iam.CreateGroupOutput, error) {
+	m.groups[aws.StringValue(input.GroupName)] = []string{}
+	return &iam.CreateGroupOutput{Group: &iam.Group{GroupName: input.GroupName}}, nil
+}
+
+func (m *mockRepairIAM) ListAttachedGroupPoliciesWithContext(ctx context.Context, input *iam.ListAttachedGroupPoliciesInput, opts ...request.Option) (*iam.ListAttachedGroupPoliciesOutput, error) {
+	attached := []*iam.AttachedPolicy{}
+	for _, arn := range m.groups[aws.StringValue(input.GroupName)] {
+		attached = append(attached, &iam.AttachedPolicy{PolicyArn: aws.String(arn)})
+	}
+	return &iam.ListAttachedGroupPoliciesOutput{AttachedPolicies: attached}, nil
+}
+
+func (m *mockRepairIAM) AttachGroupPolicyWithContext(ctx context.Context, input *iam.AttachGroupPolicyInput, opts ...request.Option) (*iam.AttachGroupPolicyOutput, error) {
+	group := aws.StringValue(input.GroupName)
+	m.groups[group] = append(m.groups[group], aws.StringValue(input.PolicyArn))
+	return &iam.AttachGroupPolicyOutput{}, nil
+}
+
+func (m *mockRepairIAM) DetachGroupPolicyWithContext(ctx context.Context, input *iam.DetachGroupPolicyInput, opts ...request.Option) (*iam.DetachGroupPolicyOutput, error) {
+	group := aws.StringValue(input.GroupName)
+	remaining := []string{}
+	for _, arn := range m.groups[group] {
+		if arn != aws.StringValue(input.PolicyArn) {
+			remaining = append(remaining, arn)
+		}
+	}
+
+	if len(remaining) == len(m.groups[group]) {
+		return nil, awserr.New(iam.ErrCodeNoSuchEntityException, "policy not attached", nil)
+	}
+
+	m.groups[group] = remaining
+	return &iam.DetachGroupPolicyOutput{}, nil
+}
+
+// mockRepairRoute53 is a route53 client with a set of existing records that captures the changes
+type mockRepairRoute53 struct {
+	route53iface.Route53API
+	records []*route53.ResourceRecordSet
+	changes []*route53.Change
+}
+
+func (m *mockRepairRoute53) ListResourceRecordSetsPagesWithContext(ctx context.Context, input *route53.ListResourceRecordSetsInput, fn func(*route53.ListResourceRecordSetsOutput, bool) bool, opts ...request.Option) error {
+	fn(&route53.ListResourceRecordSetsOutput{ResourceRecordSets: m.records}, true)
+	return nil
+}
+
+func (m *mockRepairRoute53) ChangeResourceRecordSetsWithContext(ctx context.Context, input *route53.ChangeResourceRecordSetsInput, opts ...request.Option) (*route53.ChangeResourceRecordSetsOutput, error) {
+	m.changes = append(m.changes, input.ChangeBatch.Changes...)
+	return &route53.ChangeResourceRecordSetsOutput{ChangeInfo: &route53.ChangeInfo{Id: aws.String("C123")}}, nil
+}
+
+func TestRepairAdminGroup(t *testing.T) {
+	policyArn := iamapi.FormatPolicyArn("012345678910", "/", "www.example.com-WebAdmPlc")
+
+	tests := []struct {
+		name     string
+		policies map[string]bool
+		groups   map[string][]string
+		replace  bool
+		repairs  []string
+	}{
+		{
+			name:     "consistent",
+			policies: map[string]bool{policyArn: true},
+			groups:   map[string][]string{"www.example.com-WebAdmGrp": {policyArn}},
+			repairs:  []string{},
+		},
+		{
+			name:     "missing group",
+			policies: map[string]bool{policyArn: true},
+			groups:   map[string][]string{},
+			repairs: []string{
+				"created group www.example.com-WebAdmGrp",
+				"attached policy www.example.com-WebAdmPlc to group www.example.com-WebAdmGrp",
+			},
+		},
+		{
+			name:     "missing policy",
+			policies: map[string]bool{},
+			groups:   map[string][]string{"www.example.com-WebAdmGrp": {}},
+			repairs: []string{
+				"created policy www.example.com-WebAdmPlc",
+				"attached policy www.example.com-WebAdmPlc to group www.example.com-WebAdmGrp",
+			},
+		},
+		{
+			name:     "replace",
+			policies: map[string]bool{policyArn: true},
+			groups:   map[string][]string{"www.example.com-WebAdmGrp": {policyArn}},
+			replace:  true,
+			repairs:  []string{"updated policy www.example.com-WebAdmPlc"},
+		},
+		{
+			name:     "replace missing policy",
+			policies: map[string]bool{},
+			groups:   map[string][]string{"www.example.com-WebAdmGrp": {}},
+			replace:  true,
+			repairs: []string{
+				"created policy www.example.com-WebAdmPlc",
+				"attached policy www.example.com-WebAdmPlc to group www.example.com-WebAdmGrp",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		m := &mockRepairIAM{policies: test.policies, groups: test.groups}
+		output := &websiteRepairOutput{Website: "www.example.com", Repairs: []string{}}
+
+		if err := repairAdminGroup(context.TODO(), iamapi.IAM{Service: m}, output, "012345678910",
+			"www.example.com-WebAdmPlc", "Admin policy", []byte("{}"), "www.example.com-WebAdmGrp", nil, test.replace); err != nil {
+			t.Errorf("%s: expected nil error, got %s", test.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(output.Repairs, test.repairs) {
+			t.Errorf("%s: expected repairs %v, got %v", test.name, test.repairs, output.Repairs)
+		}
+
+		if !reflect.DeepEqual(m.groups["www.example.com-WebAdmGrp"], []string{policyArn}) {
+			t.Errorf("%s: expected the policy to be attached to the group, got %v", test.name, m.groups)
+		}
+	}
+}
+
+func TestRepairWebsiteRecord(t *testing.T) {
+	alias := func(domain string, failover *string) *route53.ResourceRecordSet {
+		return &route53.ResourceRecordSet{
+			Name:        aws.String("www.example.com."),
+			Type:        aws.String("A"),
+			AliasTarget: route53api.CloudFrontAliasTarget(domain),
+			Failover:    failover,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		records []*route53.ResourceRecordSet
+		action  string
+	}{
+		{"missing", nil, "CREATE"},
+		{"consistent", []*route53.ResourceRecordSet{alias("D111.CloudFront.net.", nil)}, ""},
+		{"stale", []*route53.ResourceRecordSet{alias("d222.cloudfront.net.", nil)}, "UPSERT"},
+		{"failover", []*route53.ResourceRecordSet{alias("d222.cloudfront.net.", aws.String("PRIMARY"))}, ""},
+	}
+
+	for _, test := range tests {
+		m := &mockRepairRoute53{records: test.records}
+		output := &websiteRepairOutput{Website: "www.example.com", Repairs: []string{}}
+
+		if err := repairWebsiteRecord(context.TODO(), route53api.Route53{Service: m}, output, "Z123", "www.example.com", "d111.cloudfront.net"); err != nil {
+			t.Errorf("%s: expected nil error, got %s", test.name, err)
+			continue
+		}
+
+		if test.action == "" {
+			if len(m.changes) != 0 || len(output.Repairs) != 0 {
+				t.Errorf("%s: expected no changes, got %v", test.name, m.changes)
+			}
+			continue
+		}
+
+		if len(m.changes) != 1 {
+			t.Errorf("%s: expected 1 change, got %d", test.name, len(m.changes))
+			continue
+		}
+
+		change := m.changes[0]
+		if aws.StringValue(change.Action) != test.action {
+			t.Errorf("%s: expected action %s, got %s", test.name, test.action, aws.StringValue(change.Action))
+		}
+
+		if dns := aws.StringValue(change.ResourceRecordSet.AliasTarget.DNSName); dns != "d111.cloudfront.net" {
+			t.Errorf("%s: expected the record to point to d111.cloudfront.net, got %s", test.name, dns)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+package api
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultSignedURLExpiration is how long a signed url or cookie is valid for if the expiration isn't passed
+	defaultSignedURLExpiration = time.Hour
+
+	// defaultSignedURLMaxExpiration is the longest a signed url or cookie can be valid for if it isn't configured
+	defaultSignedURLMaxExpiration = 24 * time.Hour
+
+	// maxSignedURLPaths is the most urls that can be signed in one request
+	maxSignedURLPaths = 100
+)
+
+// websiteSignedURLsActions are the actions needed to sign urls and cookies for a website
+var websiteSignedURLsActions = []string{
+	"cloudfront:ListDistributions",
+	"cloudfront:ListPublicKeys",
+}
+
+// signedURLs is the key for signing urls and cookies for the private content of websites
+type signedURLs struct {
+	keyName       string
+	privateKey    *rsa.PrivateKey
+	publicKey     string
+	maxExpiration time.Duration
+}
+
+// newSignedURLs parses the signed urls key from the configuration, it returns nil if it's not configured
+func newSignedURLs(config *common.SignedURLs) (*signedURLs, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	block, _ := pem.Decode([]byte(config.PrivateKey))
+	if block == nil {
+		return nil, errors.New("signed urls private key must be PEM encoded")
+	}
+
+	var key *rsa.PrivateKey
+	if k, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		key = k
+	} else {
+		k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse signed urls private key")
+		}
+
+		rsaKey, ok := k.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("signed urls private key must be an RSA key")
+		}
+		key = rsaKey
+	}
+
+	publicKey, err := cfapi.EncodePublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode signed urls public key")
+	}
+
+	s := &signedURLs{
+		keyName:       config.KeyName,
+		privateKey:    key,
+		publicKey:     publicKey,
+		maxExpiration: defaultSignedURLMaxExpiration,
+	}
+
+	if s.keyName == "" {
+		s.keyName = cfapi.DefaultSignedURLsKeyName
+	}
+
+	if config.MaxExpiration != "" {
+		if s.maxExpiration, err = time.ParseDuration(config.MaxExpiration); err != nil {
+			return nil, errors.Wrap(err, "invalid signed urls max expiration")
+		}
+
+		if s.maxExpiration <= 0 {
+			return nil, fmt.Errorf("signed urls max expiration must be positive, got %s", config.MaxExpiration)
+		}
+	}
+
+	return s, nil
+}
+
+// signedKeyGroup creates the public key and the key group for signed urls in the account if they don't exist and
+// returns the id of the key group
+func (s *server) signedKeyGroup(ctx context.Context, cloudFrontService cfapi.CloudFront) (string, error) {
+	if s.signedURLs == nil {
+		return "", apierror.New(apierror.ErrBadRequest, "signed urls are not configured", nil)
+	}
+
+	keyId, err := cloudFrontService.EnsurePublicKey(ctx, s.signedURLs.keyName, s.signedURLs.publicKey)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create the signed urls public key")
+	}
+
+	groupId, err := cloudFrontService.EnsureKeyGroup(ctx, s.signedURLs.keyName, keyId)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create the signed urls key group")
+	}
+
+	return groupId, nil
+}
+
+// signedBehaviors returns true if any of the cache behaviors are signed
+func signedBehaviors(behaviors []*cfapi.CacheBehavior) bool {
+	for _, b := range behaviors {
+		if b != nil && b.Signed {
+			return true
+		}
+	}
+	return false
+}
+
+// signBehaviors sets the trusted key group of the signed cache behaviors, the key group is only created if one of the
+// behaviors is signed
+func (s *server) signBehaviors(ctx context.Context, cloudFrontService cfapi.CloudFront, behaviors []*cfapi.CacheBehavior) error {
+	if !signedBehaviors(behaviors) {
+		return nil
+	}
+
+	groupId, err := s.signedKeyGroup(ctx, cloudFrontService)
+	if err != nil {
+		return err
+	}
+
+	for _, b := range behaviors {
+		if b.Signed {
+			b.KeyGroupID = groupId
+		}
+	}
+
+	return nil
+}
+
+// websiteSignedURLsRequest is the request to sign urls or cookies for the private content of a website
+type websiteSignedURLsRequest struct {
+	// Paths are the paths of the objects to sign urls for, ie. /private/syllabus.pdf
+	Paths []string `json:",omitempty"`
+	// CookiePath is the path the signed cookies allow access to, it can contain wildcards, ie. /private/*
+	CookiePath string `json:",omitempty"`
+	// Expires is how long the urls or cookies are valid for (default 1h)
+	Expires string `json:",omitempty"`
+}
+
+// validate validates the request to sign urls or cookies and returns the expiration
+func (r *websiteSignedURLsRequest) validate(maxExpiration time.Duration) (time.Duration, error) {
+	f := fieldErrors{}
+
+	switch {
+	case len(r.Paths) == 0 && r.CookiePath == "":
+		f.add("Paths", "paths or a cookie path is required")
+	case len(r.Paths) > 0 && r.CookiePath != "":
+		f.add("CookiePath", "paths and a cookie path cannot both be set")
+	case len(r.Paths) > maxSignedURLPaths:
+		f.add("Paths", "at most %d paths can be signed, got %d", maxSignedURLPaths, len(r.Paths))
+	}
+
+	for i, p := range r.Paths {
+		if !strings.HasPrefix(p, "/") || strings.ContainsAny(p, "* \t\n") {
+			f.add(fmt.Sprintf("Paths[%d]", i), "path must start with / and cannot contain wildcards or whitespace")
+		}
+	}
+
+	if r.CookiePath != "" && (!strings.HasPrefix(r.CookiePath, "/") || strings.ContainsAny(r.CookiePath, " \t\n")) {
+		f.add("CookiePath", "cookie path must start with / and cannot contain whitespace")
+	}
+
+	expires := defaultSignedURLExpiration
+	if r.Expires != "" {
+		d, err := time.ParseDuration(r.Expires)
+		switch {
+		case err != nil:
+			f.add("Expires", "invalid duration %s", r.Expires)
+		case d <= 0 || d > maxExpiration:
+			f.add("Expires", "expiration must be positive and at most %s", maxExpiration)
+		default:
+			expires = d
+		}
+	}
+
+	return expires, f.err()
+}
+
+// websiteSignedURLsOutput is the signed urls or cookies for a website
+type websiteSignedURLsOutput struct {
+	Website string
+	// Expires is when the signed urls or cookies expire
+	Expires time.Time
+	// URLs are the signed urls by path
+	URLs map[string]string `json:",omitempty"`
+	// Cookies are the signed cookies by name, they're set for the website's domain
+	Cookies map[string]string `json:",omitempty"`
+}
+
+// WebsiteSignedURLsHandler signs urls or cookies for the private content of a website, the signed content is the
+// content of the cache behaviors that are signed, or the whole website if it was created signed.  The urls and
+// cookies are signed with the configured key, which must be trusted by the website.
+func (s *server) WebsiteSignedURLsHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	website := vars["website"]
+
+	if s.signedURLs == nil {
+		handleError(w, apierror.New(apierror.ErrNotFound, "signed urls are not configured", nil))
+		return
+	}
+
+	var req websiteSignedURLsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		msg := fmt.Sprintf("cannot decode body into website signed urls input: %s", err)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+		return
+	}
+
+	expiration, err := req.validate(s.signedURLs.maxExpiration)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	session, err := s.sessionForAccount(r.Context(), vars["account"], websiteSignedURLsActions...)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	cloudFrontService := cfapi.NewSession(session.Session, s.account, accountId)
+	cloudFrontService.Index = s.distributionIndex(accountId)
+
+	if _, err := cloudFrontService.GetDistributionByName(r.Context(), website); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	// the public key is only created in the account when the first signed website or cache behavior is created
+	keyId, err := cloudFrontService.GetPublicKeyID(r.Context(), s.signedURLs.keyName)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if keyId == "" {
+		handleError(w, apierror.New(apierror.ErrNotFound, "signed urls public key doesn't exist in the account", nil))
+		return
+	}
+
+	output := websiteSignedURLsOutput{
+		Website: website,
+		Expires: time.Now().Add(expiration).UTC().Truncate(time.Second),
+	}
+
+	if len(req.Paths) > 0 {
+		output.URLs = map[string]string{}
+		for _, p := range req.Paths {
+			signed, err := cfapi.SignURL(keyId, s.signedURLs.privateKey, "https://"+website+p, output.Expires)
+			if err != nil {
+				handleError(w, apierror.New(apierror.ErrInternalError, "failed to sign url for path "+p, err))
+				return
+			}
+			output.URLs[p] = signed
+		}
+	} else {
+		cookies, err := cfapi.SignCookies(keyId, s.signedURLs.privateKey, "https://"+website+req.CookiePath, output.Expires)
+		if err != nil {
+			handleError(w, apierror.New(apierror.ErrInternalError, "failed to sign cookies for path "+req.CookiePath, err))
+			return
+		}
+
+		output.Cookies = map[string]string{}
+		for _, c := range cookies {
+			output.Cookies[c.Name] = c.Value
+		}
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
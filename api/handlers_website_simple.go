@@ -0,0 +1,244 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/YaleSpinup/apierror"
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	"github.com/YaleSpinup/s3-api/common"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/YaleSpinup/s3-api/retry"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/YaleSpinup/s3-api/webhook"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// simpleWebsiteTagKey marks the bucket of a simple website, a website served from the s3 website endpoint without a
+// cloudfront distribution or dns record
+const simpleWebsiteTagKey = "spinup:simple-website"
+
+// validateSimpleWebsites validates the simple websites configuration
+func validateSimpleWebsites(config *common.SimpleWebsites) error {
+	if config == nil {
+		return nil
+	}
+
+	if len(config.AllowedCIDRs) == 0 {
+		return errors.New("simple websites need at least one allowed cidr")
+	}
+
+	for _, c := range config.AllowedCIDRs {
+		if _, _, err := net.ParseCIDR(c); err != nil {
+			return fmt.Errorf("invalid simple websites allowed cidr %s: %s", c, err)
+		}
+	}
+
+	return nil
+}
+
+// requireSimpleWebsites returns a bad request error if simple websites aren't configured
+func (s *server) requireSimpleWebsites() error {
+	if s.account.SimpleWebsites != nil {
+		return nil
+	}
+
+	return apierror.New(apierror.ErrBadRequest, "simple websites are not configured", nil)
+}
+
+// isSimpleWebsite returns true if the bucket tags mark a simple website
+func isSimpleWebsite(tags []*s3.Tag) bool {
+	return s3TagMap(tags)[simpleWebsiteTagKey] == "true"
+}
+
+// simpleWebsite returns true if the website is a simple website
+func simpleWebsite(ctx context.Context, s3Service s3api.S3, website string) (bool, error) {
+	tags, err := s3Service.GetBucketTags(ctx, website)
+	if err != nil {
+		return false, err
+	}
+
+	return isSimpleWebsite(tags), nil
+}
+
+// createSimpleWebsite creates a website that's served from the s3 website endpoint to the configured networks, with
+// rollback in the event of failure.  The operations are:
+// 1. create the bucket with the given name
+// 2. tag the bucket and configure its encryption, logging and the website
+// 3. apply the bucket policy allowing the configured networks to read the content
+// 4. seed the bucket with the passed content and the default index page
+// 5. create the bucket admin policy and group, '<bucketName>-BktAdmGrp'
+// The bucket policy only allows fixed networks so it isn't public, and the cloudfront distribution, web admin group
+// and dns record aren't created.
+func (s *server) createSimpleWebsite(w http.ResponseWriter, r *http.Request, s3Service s3api.S3, iamService iamapi.IAM, cloudFrontService cfapi.CloudFront, req *websiteCreateRequest) {
+	vars := mux.Vars(r)
+	bucketName := aws.StringValue(req.BucketInput.Bucket)
+
+	var err error
+	rb := s.newRollback("website.create", vars["account"], bucketName, rollbackServices{s3: &s3Service, iam: &iamService})
+	defer func() {
+		s.finishRollback(rb, err)
+		if err != nil {
+			s.notify(webhook.EventWebsiteRolledBack, vars["account"], bucketName, map[string]string{"Error": err.Error(), "Rollback": rb.ID})
+		}
+	}()
+
+	var bucketOutput *s3.CreateBucketOutput
+	if bucketOutput, err = s3Service.CreateBucket(r.Context(), &req.BucketInput); err != nil {
+		msg := fmt.Sprintf("failed to create bucket %s", bucketName)
+		handleError(w, errors.Wrap(err, msg))
+		return
+	}
+
+	// append bucket delete to rollback
+	rb.Add("delete bucket "+bucketName, rollbackDeleteBucket, map[string]string{"bucket": bucketName})
+
+	// wait for the bucket to exist and tag it, the default object tags are only stored on the bucket
+	tags := append(withDefaultObjectTags(req.Tags, req.ObjectTags), &s3.Tag{
+		Key:   aws.String(simpleWebsiteTagKey),
+		Value: aws.String("true"),
+	})
+
+	if err = retry.Do(r.Context(), s3ConsistencyRetry, func(ctx context.Context) error {
+		if err := s3Service.TagBucket(ctx, bucketName, tags); err != nil {
+			log.Warnf("error tagging simple website bucket %s: %s", bucketName, err)
+			return err
+		}
+		return nil
+	}); err != nil {
+		msg := fmt.Sprintf("failed to tag website bucket %s", bucketName)
+		handleError(w, errors.Wrap(err, msg))
+		return
+	}
+
+	if err = s3Service.UpdateBucketEncryption(r.Context(), &s3.PutBucketEncryptionInput{
+		Bucket: aws.String(bucketName),
+		ServerSideEncryptionConfiguration: &s3.ServerSideEncryptionConfiguration{
+			Rules: []*s3.ServerSideEncryptionRule{
+				{
+					ApplyServerSideEncryptionByDefault: &s3.ServerSideEncryptionByDefault{
+						SSEAlgorithm: aws.String("AES256"),
+					},
+				},
+			},
+		},
+	}); err != nil {
+		msg := fmt.Sprintf("failed to enable encryption for bucket %s", bucketName)
+		handleError(w, errors.Wrap(err, msg))
+		return
+	}
+
+	if s3Service.LoggingBucket != "" {
+		if err = s3Service.UpdateBucketLogging(r.Context(), bucketName, s3Service.LoggingBucket, s3Service.LoggingBucketPrefix); err != nil {
+			msg := fmt.Sprintf("failed to enable logging for bucket %s", bucketName)
+			handleError(w, errors.Wrap(err, msg))
+			return
+		}
+	}
+
+	if err = s3Service.UpdateWebsiteConfig(r.Context(), &s3.PutBucketWebsiteInput{
+		Bucket:               aws.String(bucketName),
+		WebsiteConfiguration: &req.WebsiteConfiguration,
+	}); err != nil {
+		msg := fmt.Sprintf("failed to configure bucket %s as website", bucketName)
+		handleError(w, errors.Wrap(err, msg))
+		return
+	}
+
+	var policy []byte
+	if policy, err = iamService.SimpleWebsiteAccessPolicy(aws.String(bucketName), s.account.SimpleWebsites.AllowedCIDRs); err != nil {
+		msg := fmt.Sprintf("failed building simple website bucket access policy for %s", bucketName)
+		handleError(w, apierror.New(apierror.ErrInternalError, msg, err))
+		return
+	}
+
+	if err = s3Service.UpdateBucketPolicy(r.Context(), &s3.PutBucketPolicyInput{
+		Bucket: aws.String(bucketName),
+		Policy: aws.String(string(policy)),
+	}); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	var objects []*s3.PutObjectInput
+	if objects, err = websiteSeedObjects(bucketName, req, s.account.CacheControl); err != nil {
+		handleError(w, apierror.New(apierror.ErrBadRequest, "failed to decode website content", err))
+		return
+	}
+
+	created := []*websiteObject{}
+	for _, o := range objects {
+		key := aws.StringValue(o.Key)
+		if _, err = s3Service.CreateObject(r.Context(), o); err != nil {
+			msg := fmt.Sprintf("failed to create %s for website %s", key, bucketName)
+			handleError(w, errors.Wrap(err, msg))
+			return
+		}
+
+		// append object delete to rollback so the bucket can be deleted
+		rb.Add("delete object "+key+" from bucket "+bucketName, rollbackDeleteObject, map[string]string{"bucket": bucketName, "key": key})
+
+		created = append(created, newWebsiteObject(o))
+	}
+
+	var defaultBktPolicy []byte
+	if defaultBktPolicy, err = iamService.DefaultBucketAdminPolicy(aws.String(bucketName), s.ipAllowlist(req.IPAllowlist)); err != nil {
+		msg := fmt.Sprintf("failed building default IAM policy for bucket %s", bucketName)
+		handleError(w, apierror.New(apierror.ErrInternalError, msg, err))
+		return
+	}
+
+	var (
+		bktPolicy *iam.Policy
+		bktGroup  *iam.Group
+	)
+	if bktPolicy, bktGroup, err = createWebsiteAdminGroup(r.Context(), iamService, rb, "bucket admin",
+		iamapi.FormatPolicyName(bucketName, "/", "BktAdmPlc"),
+		fmt.Sprintf("Admin policy for %s bucket", bucketName),
+		defaultBktPolicy,
+		iamapi.FormatGroupName(bucketName, "/", "BktAdmGrp"),
+		iamTags(req.Tags),
+	); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	output := struct {
+		Bucket          *string
+		Simple          bool
+		WebsiteEndpoint string
+		AllowedCIDRs    []string
+		Policies        []*iam.Policy
+		Groups          []*iam.Group
+		Objects         []*websiteObject
+	}{
+		bucketOutput.Location,
+		true,
+		bucketName + "." + cloudFrontService.WebsiteEndpoint,
+		s.account.SimpleWebsites.AllowedCIDRs,
+		[]*iam.Policy{bktPolicy},
+		[]*iam.Group{bktGroup},
+		created,
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	s.notify(webhook.EventWebsiteCreated, vars["account"], bucketName, map[string]string{"Simple": "true"})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	"github.com/YaleSpinup/s3-api/common"
+	route53api "github.com/YaleSpinup/s3-api/route53"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// mockSimpleWebsiteS3 is an s3 client with an empty simple website bucket
+type mockSimpleWebsiteS3 struct {
+	s3iface.S3API
+}
+
+func (m *mockSimpleWebsiteS3) GetBucketTaggingWithContext(ctx context.Context, input *s3.GetBucketTaggingInput, opts ...request.Option) (*s3.GetBucketTaggingOutput, error) {
+	return &s3.GetBucketTaggingOutput{TagSet: []*s3.Tag{
+		{Key: aws.String("spinup:org"), Value: aws.String("test")},
+		{Key: aws.String(simpleWebsiteTagKey), Value: aws.String("true")},
+	}}, nil
+}
+
+func (m *mockSimpleWebsiteS3) ListObjectsV2WithContext(ctx context.Context, input *s3.ListObjectsV2Input, opts ...request.Option) (*s3.ListObjectsV2Output, error) {
+	return &s3.ListObjectsV2Output{}, nil
+}
+
+func (m *mockSimpleWebsiteS3) GetBucketLoggingWithContext(ctx context.Context, input *s3.GetBucketLoggingInput, opts ...request.Option) (*s3.GetBucketLoggingOutput, error) {
+	return &s3.GetBucketLoggingOutput{}, nil
+}
+
+func (m *mockSimpleWebsiteS3) GetBucketWebsiteWithContext(ctx context.Context, input *s3.GetBucketWebsiteInput, opts ...request.Option) (*s3.GetBucketWebsiteOutput, error) {
+	return &s3.GetBucketWebsiteOutput{IndexDocument: &s3.IndexDocument{Suffix: aws.String("index.html")}}, nil
+}
+
+func TestWebsiteCreateRequestValidateSimple(t *testing.T) {
+	domains := map[string]*common.Domain{"example.com": {}}
+
+	// a simple website doesn't have to be in one of the domains
+	req := websiteCreateRequest{
+		BucketInput: s3.CreateBucketInput{Bucket: aws.String("internal-site")},
+		Simple:      true,
+	}
+
+	if err := req.validate(domains, nil); err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	req.Redirect = &websiteRedirect{HostName: "new.example.edu"}
+	req.CacheBehaviors = []*cfapi.CacheBehavior{{PathPattern: "/api/*"}}
+	req.Signed = true
+	req.HttpVersion = "http2"
+
+	err := req.validate(domains, nil)
+	aerr, ok := err.(apierror.Error)
+	if !ok {
+		t.Fatalf("expected apierror.Error, got %T", err)
+	}
+
+	for _, field := range []string{"Redirect", "CacheBehaviors", "Signed", "HttpVersion"} {
+		if !strings.Contains(aerr.Message, field+": ") {
+			t.Errorf("expected error message to contain field %s, got %q", field, aerr.Message)
+		}
+	}
+
+	// the other websites still have to be in one of the domains
+	req = websiteCreateRequest{BucketInput: s3.CreateBucketInput{Bucket: aws.String("internal-site")}}
+	if err := req.validate(domains, nil); err == nil {
+		t.Error("expected error for a website that isn't in one of the domains, got nil")
+	}
+}
+
+func TestValidateSimpleWebsites(t *testing.T) {
+	if err := validateSimpleWebsites(nil); err != nil {
+		t.Errorf("expected nil error without a configuration, got %s", err)
+	}
+
+	if err := validateSimpleWebsites(&common.SimpleWebsites{AllowedCIDRs: []string{"10.0.0.0/8", "2001:db8::/32"}}); err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	if err := validateSimpleWebsites(&common.SimpleWebsites{}); err == nil {
+		t.Error("expected error without any allowed cidrs, got nil")
+	}
+
+	if err := validateSimpleWebsites(&common.SimpleWebsites{AllowedCIDRs: []string{"10.0.0.1"}}); err == nil {
+		t.Error("expected error for an invalid cidr, got nil")
+	}
+}
+
+func TestGetSimpleWebsite(t *testing.T) {
+	s3Service := s3api.S3{Service: &mockSimpleWebsiteS3{}}
+	cloudFrontService := cfapi.CloudFront{WebsiteEndpoint: "s3-website-us-east-1.amazonaws.com"}
+
+	// the route53 and cloudfront services don't have clients, a simple website doesn't have a dns record or distribution
+	output, err := getWebsite(context.TODO(), s3Service, cloudFrontService, route53api.Route53{}, "internal-site")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if !output.Simple || !output.Empty {
+		t.Errorf("expected an empty simple website, got %+v", output)
+	}
+
+	if output.WebsiteEndpoint != "internal-site.s3-website-us-east-1.amazonaws.com" {
+		t.Errorf("expected website endpoint internal-site.s3-website-us-east-1.amazonaws.com, got %s", output.WebsiteEndpoint)
+	}
+
+	if output.DNSRecord != nil || output.Distribution != nil {
+		t.Errorf("expected no dns record or distribution, got %+v", output)
+	}
+}
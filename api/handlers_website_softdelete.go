@@ -0,0 +1,526 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/YaleSpinup/s3-api/webhook"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// pendingDeleteTagKey marks a soft deleted website bucket, the value is the time after which it's torn down
+	pendingDeleteTagKey = "spinup:pending-delete"
+	// pendingDeleteKeysTagKey lists the access keys of a user that were deactivated when a website was soft deleted
+	pendingDeleteKeysTagKey = "spinup:pending-delete-keys"
+	// pendingDeleteSid is the sid of the bucket policy statement denying access to a soft deleted website
+	pendingDeleteSid = "SpinupPendingDelete"
+	// defaultSoftDeleteDays is how long a soft deleted website is kept if it's not configured
+	defaultSoftDeleteDays = 7
+	// softDeleteTable is the table the soft delete records are kept in
+	softDeleteTable = "soft-deletes"
+)
+
+// softDeleteRecord is the record of a soft deleted website kept in the state store, so the websites pending deletion
+// can be listed without reading the tags of every bucket in the account
+type softDeleteRecord struct {
+	Account     string
+	Website     string
+	Deleted     time.Time
+	DeleteAfter time.Time
+	// Users are the users whose access keys were deactivated
+	Users []string
+}
+
+// websiteSoftDeleteOutput is returned when a website is soft deleted
+type websiteSoftDeleteOutput struct {
+	Website     string
+	DeleteAfter time.Time
+	// Users are the users whose access keys were deactivated
+	Users []string
+}
+
+// websiteRestoreOutput is returned when a soft deleted website is restored
+type websiteRestoreOutput struct {
+	Website string
+	// Users are the users whose access keys were reactivated
+	Users []string
+}
+
+// softDeleteDays returns the number of days a soft deleted website is kept, or 0 if soft delete isn't enabled
+func (s *server) softDeleteDays() int {
+	if s.account.SoftDelete == nil {
+		return 0
+	}
+
+	if s.account.SoftDelete.Days <= 0 {
+		return defaultSoftDeleteDays
+	}
+
+	return s.account.SoftDelete.Days
+}
+
+// softDeleteWebsite quarantines a website instead of tearing it down.  The website bucket is tagged with the time
+// after which the reaper deletes it, a statement denying access to everyone but the api is added to the bucket policy
+// and the access keys of the website's users are deactivated.  The groups, policies, dns records and distribution are
+// left in place so the website can be restored.  Soft deleting a website that's already pending deletion returns the
+// existing deletion time.
+func softDeleteWebsite(ctx context.Context, s3Service s3api.S3, iamService iamapi.IAM, apiRole, website string, days int) (*websiteSoftDeleteOutput, error) {
+	tags, err := s3Service.GetBucketTags(ctx, website)
+	if err != nil {
+		return nil, err
+	}
+
+	deleteAfter, pending := pendingDeleteTime(tags)
+	if !pending {
+		deleteAfter = time.Now().UTC().Add(time.Duration(days) * 24 * time.Hour).Truncate(time.Second)
+		tags = append(withoutTag(tags, pendingDeleteTagKey), &s3.Tag{
+			Key:   aws.String(pendingDeleteTagKey),
+			Value: aws.String(deleteAfter.Format(time.RFC3339)),
+		})
+
+		if err := s3Service.TagBucket(ctx, website, tags); err != nil {
+			return nil, err
+		}
+	}
+
+	policy, err := s3Service.GetBucketPolicy(ctx, website)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err = addPolicyStatement(policy, pendingDeleteStatement(apiRole, website))
+	if err != nil {
+		msg := fmt.Sprintf("failed to add the pending delete statement to the policy for website %s", website)
+		return nil, apierror.New(apierror.ErrInternalError, msg, err)
+	}
+
+	if err := s3Service.UpdateBucketPolicy(ctx, &s3.PutBucketPolicyInput{
+		Bucket: aws.String(website),
+		Policy: aws.String(policy),
+	}); err != nil {
+		return nil, err
+	}
+
+	users, err := websiteUsers(ctx, iamService, website)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &websiteSoftDeleteOutput{Website: website, DeleteAfter: deleteAfter, Users: []string{}}
+	for _, user := range users {
+		keys, err := iamService.ListAccessKeys(ctx, &iam.ListAccessKeysInput{UserName: aws.String(user)})
+		if err != nil {
+			return nil, err
+		}
+
+		// keep the keys deactivated by an earlier attempt so they're all reactivated on restore
+		userTags, err := iamService.ListUserTags(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+
+		deactivated := strings.Fields(iamTagMap(userTags)[pendingDeleteKeysTagKey])
+		active := []string{}
+		for _, k := range keys {
+			if aws.StringValue(k.Status) == iam.StatusTypeActive {
+				active = append(active, aws.StringValue(k.AccessKeyId))
+			}
+		}
+
+		if len(active) == 0 {
+			continue
+		}
+
+		// the keys are recorded before they're deactivated so a failure part way through can still be restored
+		if err := iamService.TagUser(ctx, user, []*iam.Tag{
+			{Key: aws.String(pendingDeleteKeysTagKey), Value: aws.String(strings.Join(append(deactivated, active...), " "))},
+		}); err != nil {
+			return nil, err
+		}
+
+		for _, id := range active {
+			if err := iamService.UpdateAccessKey(ctx, &iam.UpdateAccessKeyInput{
+				UserName:    aws.String(user),
+				AccessKeyId: aws.String(id),
+				Status:      aws.String(iam.StatusTypeInactive),
+			}); err != nil {
+				return nil, err
+			}
+		}
+		output.Users = append(output.Users, user)
+	}
+
+	log.Infof("soft deleted website %s, it will be deleted after %s", website, deleteAfter)
+
+	return output, nil
+}
+
+// WebsiteSoftDeleteListHandler lists the soft deleted websites in an account that are waiting to be torn down
+func (s *server) WebsiteSoftDeleteListHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+
+	records, err := s.softDeleteRecords(r.Context(), vars["account"])
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	j, err := json.Marshal(records)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", records, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// saveSoftDeleteRecord records a soft deleted website in the state store, failures are logged since the website
+// bucket's tag is what marks it for deletion
+func (s *server) saveSoftDeleteRecord(ctx context.Context, account string, output *websiteSoftDeleteOutput) {
+	accountId := s.mapAccountNumber(account)
+	record, err := json.Marshal(softDeleteRecord{
+		Account:     accountId,
+		Website:     output.Website,
+		Deleted:     time.Now().UTC().Truncate(time.Second),
+		DeleteAfter: output.DeleteAfter,
+		Users:       output.Users,
+	})
+	if err != nil {
+		log.Errorf("failed to marshal soft delete record for website %s: %s", output.Website, err)
+		return
+	}
+
+	if err := s.state.Put(ctx, softDeleteTable, accountId+"/"+output.Website, record, time.Time{}); err != nil {
+		log.Errorf("failed to save soft delete record for website %s: %s", output.Website, err)
+	}
+}
+
+// deleteSoftDeleteRecord removes the record of a soft deleted website once it's restored or torn down
+func (s *server) deleteSoftDeleteRecord(ctx context.Context, account, website string) {
+	if err := s.state.Delete(ctx, softDeleteTable, s.mapAccountNumber(account)+"/"+website); err != nil {
+		log.Errorf("failed to delete soft delete record for website %s: %s", website, err)
+	}
+}
+
+// softDeleteRecords returns the records of the soft deleted websites in an account, the ones due to be torn down first
+func (s *server) softDeleteRecords(ctx context.Context, account string) ([]*softDeleteRecord, error) {
+	values, err := s.state.List(ctx, softDeleteTable)
+	if err != nil {
+		return nil, err
+	}
+
+	accountId := s.mapAccountNumber(account)
+	records := []*softDeleteRecord{}
+	for key, value := range values {
+		if !strings.HasPrefix(key, accountId+"/") {
+			continue
+		}
+
+		record := softDeleteRecord{}
+		if err := json.Unmarshal(value, &record); err != nil {
+			log.Warnf("skipping unreadable soft delete record %s: %s", key, err)
+			continue
+		}
+		records = append(records, &record)
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].DeleteAfter.Equal(records[j].DeleteAfter) {
+			return records[i].Website < records[j].Website
+		}
+		return records[i].DeleteAfter.Before(records[j].DeleteAfter)
+	})
+
+	return records, nil
+}
+
+// WebsiteRestoreHandler restores a soft deleted website before it's torn down
+func (s *server) WebsiteRestoreHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	website := vars["website"]
+
+	session, err := s.sessionForAccount(r.Context(), vars["account"], "s3:*", "iam:*")
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+	iamService := iamapi.NewSession(session.Session, s.account)
+	iamService.Cache = s.resourceCache(accountId)
+
+	lease, err := s.lockResource(r.Context(), vars["account"], website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	defer lease.Release()
+
+	output, err := restoreWebsite(r.Context(), s3Service, iamService, website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	s.deleteSoftDeleteRecord(r.Context(), vars["account"], website)
+	s.notify(webhook.EventWebsiteRestored, vars["account"], website, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// restoreWebsite reverses a soft delete, the deny statement is removed from the bucket policy, the access keys that
+// were deactivated are reactivated and the pending delete tag is removed
+func restoreWebsite(ctx context.Context, s3Service s3api.S3, iamService iamapi.IAM, website string) (*websiteRestoreOutput, error) {
+	tags, err := s3Service.GetBucketTags(ctx, website)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, pending := pendingDeleteTime(tags); !pending {
+		msg := fmt.Sprintf("website %s isn't pending deletion", website)
+		return nil, apierror.New(apierror.ErrConflict, msg, nil)
+	}
+
+	policy, err := s3Service.GetBucketPolicy(ctx, website)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, remaining, err := removePolicyStatement(policy, pendingDeleteSid)
+	if err != nil {
+		msg := fmt.Sprintf("failed to remove the pending delete statement from the policy for website %s", website)
+		return nil, apierror.New(apierror.ErrInternalError, msg, err)
+	}
+
+	if remaining == 0 {
+		if err := s3Service.DeleteBucketPolicy(ctx, website); err != nil {
+			return nil, err
+		}
+	} else if err := s3Service.UpdateBucketPolicy(ctx, &s3.PutBucketPolicyInput{
+		Bucket: aws.String(website),
+		Policy: aws.String(policy),
+	}); err != nil {
+		return nil, err
+	}
+
+	users, err := websiteUsers(ctx, iamService, website)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &websiteRestoreOutput{Website: website, Users: []string{}}
+	for _, user := range users {
+		userTags, err := iamService.ListUserTags(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+
+		keys, ok := iamTagMap(userTags)[pendingDeleteKeysTagKey]
+		if !ok {
+			continue
+		}
+
+		for _, id := range strings.Fields(keys) {
+			if err := iamService.UpdateAccessKey(ctx, &iam.UpdateAccessKeyInput{
+				UserName:    aws.String(user),
+				AccessKeyId: aws.String(id),
+				Status:      aws.String(iam.StatusTypeActive),
+			}); err != nil {
+				// the key may have been deleted since
+				if isNotFound(err) {
+					log.Warnf("access key %s for user %s no longer exists, not reactivating it", id, user)
+					continue
+				}
+				return nil, err
+			}
+		}
+
+		if err := iamService.UntagUser(ctx, user, []string{pendingDeleteKeysTagKey}); err != nil {
+			return nil, err
+		}
+		output.Users = append(output.Users, user)
+	}
+
+	if tags = withoutTag(tags, pendingDeleteTagKey); len(tags) == 0 {
+		if err := s3Service.DeleteBucketTags(ctx, website); err != nil {
+			return nil, err
+		}
+	} else if err := s3Service.TagBucket(ctx, website, tags); err != nil {
+		return nil, err
+	}
+
+	log.Infof("restored soft deleted website %s", website)
+
+	return output, nil
+}
+
+// websiteUsers returns the sorted names of the members of a website's groups
+func websiteUsers(ctx context.Context, iamService iamapi.IAM, website string) ([]string, error) {
+	groups, err := bucketGroups(ctx, iamService, website)
+	if err != nil {
+		return nil, err
+	}
+
+	users := map[string]bool{}
+	for _, g := range groups {
+		members, err := iamService.ListGroupUsers(ctx, &iam.GetGroupInput{GroupName: g.GroupName})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, u := range members {
+			users[aws.StringValue(u.UserName)] = true
+		}
+	}
+
+	return sortedKeys(users), nil
+}
+
+// pendingDeleteTime returns the time after which a soft deleted website is torn down and true if the bucket tags mark
+// it as pending deletion
+func pendingDeleteTime(tags []*s3.Tag) (time.Time, bool) {
+	value, ok := s3TagMap(tags)[pendingDeleteTagKey]
+	if !ok {
+		return time.Time{}, false
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		log.Warnf("invalid %s tag value %q, treating it as expired", pendingDeleteTagKey, value)
+		return time.Time{}, true
+	}
+
+	return t, true
+}
+
+// pendingDeleteStatement denies all access to a soft deleted website bucket, except for the api's role so the website
+// can be restored or torn down
+func pendingDeleteStatement(apiRole, website string) map[string]interface{} {
+	return map[string]interface{}{
+		"Sid":       pendingDeleteSid,
+		"Effect":    "Deny",
+		"Principal": "*",
+		"Action":    "s3:*",
+		"Resource": []string{
+			"arn:aws:s3:::" + website,
+			"arn:aws:s3:::" + website + "/*",
+		},
+		"Condition": map[string]interface{}{
+			"ArnNotLike": map[string]string{
+				"aws:PrincipalArn": apiRole,
+			},
+		},
+	}
+}
+
+// addPolicyStatement adds a statement to a bucket policy document, replacing any statement with the same sid
+func addPolicyStatement(policy string, statement map[string]interface{}) (string, error) {
+	policy, _, err := removePolicyStatement(policy, fmt.Sprint(statement["Sid"]))
+	if err != nil {
+		return "", err
+	}
+
+	doc := map[string]interface{}{"Version": "2012-10-17"}
+	if policy != "" {
+		if err := json.Unmarshal([]byte(policy), &doc); err != nil {
+			return "", err
+		}
+	}
+	doc["Statement"] = append(policyStatements(doc), statement)
+
+	j, err := json.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+
+	return string(j), nil
+}
+
+// removePolicyStatement removes the statement with the sid from a bucket policy document and returns the policy and
+// the number of statements left
+func removePolicyStatement(policy, sid string) (string, int, error) {
+	if policy == "" {
+		return "", 0, nil
+	}
+
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(policy), &doc); err != nil {
+		return "", 0, err
+	}
+
+	statements := []interface{}{}
+	for _, st := range policyStatements(doc) {
+		if m, ok := st.(map[string]interface{}); ok && m["Sid"] == sid {
+			continue
+		}
+		statements = append(statements, st)
+	}
+	doc["Statement"] = statements
+
+	j, err := json.Marshal(doc)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return string(j), len(statements), nil
+}
+
+// policyStatements returns the statements of a policy document, which can be a single statement or a list
+func policyStatements(doc map[string]interface{}) []interface{} {
+	switch st := doc["Statement"].(type) {
+	case []interface{}:
+		return st
+	case map[string]interface{}:
+		return []interface{}{st}
+	}
+
+	return []interface{}{}
+}
+
+// hasPolicyStatement returns true if a bucket policy document has a statement with the sid
+func hasPolicyStatement(policy, sid string) bool {
+	if policy == "" {
+		return false
+	}
+
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(policy), &doc); err != nil {
+		return false
+	}
+
+	for _, st := range policyStatements(doc) {
+		if m, ok := st.(map[string]interface{}); ok && m["Sid"] == sid {
+			return true
+		}
+	}
+
+	return false
+}
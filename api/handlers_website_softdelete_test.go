@@ -0,0 +1,293 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/YaleSpinup/s3-api/storage"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// mockSoftDeleteS3 is an s3 client that keeps the tags and policy of a single website bucket
+type mockSoftDeleteS3 struct {
+	s3iface.S3API
+	tags   []*s3.Tag
+	policy string
+}
+
+func (m *mockSoftDeleteS3) GetBucketTaggingWithContext(ctx context.Context, input *s3.GetBucketTaggingInput, opts ...request.Option) (*s3.GetBucketTaggingOutput, error) {
+	if len(m.tags) == 0 {
+		return nil, awserr.New("NoSuchTagSet", "no tags", nil)
+	}
+	return &s3.GetBucketTaggingOutput{TagSet: m.tags}, nil
+}
+
+func (m *mockSoftDeleteS3) PutBucketTaggingWithContext(ctx context.Context, input *s3.PutBucketTaggingInput, opts ...request.Option) (*s3.PutBucketTaggingOutput, error) {
+	m.tags = input.Tagging.TagSet
+	return &s3.PutBucketTaggingOutput{}, nil
+}
+
+func (m *mockSoftDeleteS3) GetBucketPolicyWithContext(ctx context.Context, input *s3.GetBucketPolicyInput, opts ...request.Option) (*s3.GetBucketPolicyOutput, error) {
+	if m.policy == "" {
+		return nil, awserr.New("NoSuchBucketPolicy", "no policy", nil)
+	}
+	return &s3.GetBucketPolicyOutput{Policy: aws.String(m.policy)}, nil
+}
+
+func (m *mockSoftDeleteS3) PutBucketPolicyWithContext(ctx context.Context, input *s3.PutBucketPolicyInput, opts ...request.Option) (*s3.PutBucketPolicyOutput, error) {
+	m.policy = aws.StringValue(input.Policy)
+	return &s3.PutBucketPolicyOutput{}, nil
+}
+
+func (m *mockSoftDeleteS3) DeleteBucketPolicyWithContext(ctx context.Context, input *s3.DeleteBucketPolicyInput, opts ...request.Option) (*s3.DeleteBucketPolicyOutput, error) {
+	m.policy = ""
+	return &s3.DeleteBucketPolicyOutput{}, nil
+}
+
+// mockSoftDeleteIAM is an IAM client with an admin group for the website with a single user
+type mockSoftDeleteIAM struct {
+	iamiface.IAMAPI
+	keys     map[string]string
+	userTags []*iam.Tag
+}
+
+func (m *mockSoftDeleteIAM) ListGroupsWithContext(ctx context.Context, input *iam.ListGroupsInput, opts ...request.Option) (*iam.ListGroupsOutput, error) {
+	return &iam.ListGroupsOutput{Groups: []*iam.Group{{GroupName: aws.String("www.example.com-BktAdmGrp")}}}, nil
+}
+
+func (m *mockSoftDeleteIAM) GetGroupWithContext(ctx context.Context, input *iam.GetGroupInput, opts ...request.Option) (*iam.GetGroupOutput, error) {
+	return &iam.GetGroupOutput{Users: []*iam.User{{UserName: aws.String("www-admin")}}}, nil
+}
+
+func (m *mockSoftDeleteIAM) ListAccessKeysWithContext(ctx context.Context, input *iam.ListAccessKeysInput, opts ...request.Option) (*iam.ListAccessKeysOutput, error) {
+	keys := []*iam.AccessKeyMetadata{}
+	for _, id := range sortedKeys(map[string]bool{"AKIA1": true, "AKIA2": true}) {
+		keys = append(keys, &iam.AccessKeyMetadata{AccessKeyId: aws.String(id), Status: aws.String(m.keys[id])})
+	}
+	return &iam.ListAccessKeysOutput{AccessKeyMetadata: keys}, nil
+}
+
+func (m *mockSoftDeleteIAM) UpdateAccessKeyWithContext(ctx context.Context, input *iam.UpdateAccessKeyInput, opts ...request.Option) (*iam.UpdateAccessKeyOutput, error) {
+	m.keys[aws.StringValue(input.AccessKeyId)] = aws.StringValue(input.Status)
+	return &iam.UpdateAccessKeyOutput{}, nil
+}
+
+func (m *mockSoftDeleteIAM) ListUserTagsWithContext(ctx context.Context, input *iam.ListUserTagsInput, opts ...request.Option) (*iam.ListUserTagsOutput, error) {
+	return &iam.ListUserTagsOutput{Tags: m.userTags}, nil
+}
+
+func (m *mockSoftDeleteIAM) TagUserWithContext(ctx context.Context, input *iam.TagUserInput, opts ...request.Option) (*iam.TagUserOutput, error) {
+	m.userTags = mergeIAMTags(m.userTags, input.Tags)
+	return &iam.TagUserOutput{}, nil
+}
+
+func (m *mockSoftDeleteIAM) UntagUserWithContext(ctx context.Context, input *iam.UntagUserInput, opts ...request.Option) (*iam.UntagUserOutput, error) {
+	tags := []*iam.Tag{}
+	for _, t := range m.userTags {
+		if !contains(aws.StringValueSlice(input.TagKeys), aws.StringValue(t.Key)) {
+			tags = append(tags, t)
+		}
+	}
+	m.userTags = tags
+	return &iam.UntagUserOutput{}, nil
+}
+
+func TestSoftDeleteAndRestoreWebsite(t *testing.T) {
+	website := "www.example.com"
+	role := "arn:aws:iam::012345678910:role/SpinupS3Role"
+	websitePolicy := `{"Version":"2012-10-17","Statement":[{"Sid":"AllowCloudFront","Effect":"Allow","Principal":{"Service":"cloudfront.amazonaws.com"},"Action":"s3:GetObject","Resource":"arn:aws:s3:::www.example.com/*"}]}`
+	tags := []*s3.Tag{{Key: aws.String("spinup:org"), Value: aws.String("testorg")}}
+
+	s3Client := &mockSoftDeleteS3{tags: tags, policy: websitePolicy}
+	iamClient := &mockSoftDeleteIAM{keys: map[string]string{"AKIA1": iam.StatusTypeActive, "AKIA2": iam.StatusTypeInactive}}
+	s3Service := s3api.S3{Service: s3Client}
+	iamService := iamapi.IAM{Service: iamClient}
+
+	before := time.Now()
+	out, err := softDeleteWebsite(context.TODO(), s3Service, iamService, role, website, 7)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if out.DeleteAfter.Before(before.Add(7*24*time.Hour).Truncate(time.Second)) || out.DeleteAfter.After(time.Now().Add(7*24*time.Hour)) {
+		t.Errorf("expected the website to be deleted after 7 days, got %s", out.DeleteAfter)
+	}
+
+	if !reflect.DeepEqual(out.Users, []string{"www-admin"}) {
+		t.Errorf("expected users [www-admin], got %v", out.Users)
+	}
+
+	if deleteAfter, ok := pendingDeleteTime(s3Client.tags); !ok || !deleteAfter.Equal(out.DeleteAfter) {
+		t.Errorf("expected the bucket to be tagged pending delete until %s, got %+v", out.DeleteAfter, s3Client.tags)
+	}
+
+	if !strings.Contains(s3Client.policy, pendingDeleteSid) || !strings.Contains(s3Client.policy, "AllowCloudFront") || !strings.Contains(s3Client.policy, role) {
+		t.Errorf("expected the deny statement to be added to the bucket policy, got %s", s3Client.policy)
+	}
+
+	if iamClient.keys["AKIA1"] != iam.StatusTypeInactive {
+		t.Errorf("expected the active access key to be deactivated, got %s", iamClient.keys["AKIA1"])
+	}
+
+	if keys := iamTagMap(iamClient.userTags)[pendingDeleteKeysTagKey]; keys != "AKIA1" {
+		t.Errorf("expected the deactivated key to be recorded on the user, got %q", keys)
+	}
+
+	// soft deleting again keeps the original deletion time and doesn't lose the deactivated keys
+	again, err := softDeleteWebsite(context.TODO(), s3Service, iamService, role, website, 30)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if !again.DeleteAfter.Equal(out.DeleteAfter) {
+		t.Errorf("expected the deletion time %s to be kept, got %s", out.DeleteAfter, again.DeleteAfter)
+	}
+
+	if keys := iamTagMap(iamClient.userTags)[pendingDeleteKeysTagKey]; keys != "AKIA1" {
+		t.Errorf("expected the deactivated key to still be recorded on the user, got %q", keys)
+	}
+
+	restored, err := restoreWebsite(context.TODO(), s3Service, iamService, website)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if !reflect.DeepEqual(restored.Users, []string{"www-admin"}) {
+		t.Errorf("expected users [www-admin], got %v", restored.Users)
+	}
+
+	if !reflect.DeepEqual(s3Client.tags, tags) {
+		t.Errorf("expected tags %+v, got %+v", tags, s3Client.tags)
+	}
+
+	var expected, policy interface{}
+	json.Unmarshal([]byte(websitePolicy), &expected)
+	json.Unmarshal([]byte(s3Client.policy), &policy)
+	if !reflect.DeepEqual(policy, expected) {
+		t.Errorf("expected the original bucket policy, got %s", s3Client.policy)
+	}
+
+	if !reflect.DeepEqual(iamClient.keys, map[string]string{"AKIA1": iam.StatusTypeActive, "AKIA2": iam.StatusTypeInactive}) {
+		t.Errorf("expected only the deactivated key to be reactivated, got %v", iamClient.keys)
+	}
+
+	if len(iamClient.userTags) != 0 {
+		t.Errorf("expected the user tags to be removed, got %+v", iamClient.userTags)
+	}
+
+	// restoring a website that isn't pending deletion is a conflict
+	_, err = restoreWebsite(context.TODO(), s3Service, iamService, website)
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrConflict {
+		t.Errorf("expected error code %s, got %v", apierror.ErrConflict, err)
+	}
+}
+
+func TestRemovePolicyStatement(t *testing.T) {
+	statement := pendingDeleteStatement("arn:aws:iam::012345678910:role/SpinupS3Role", "www.example.com")
+
+	// a policy with only the deny statement has nothing left
+	policy, err := addPolicyStatement("", statement)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if _, remaining, err := removePolicyStatement(policy, pendingDeleteSid); err != nil || remaining != 0 {
+		t.Errorf("expected no statements to remain, got %d (%v)", remaining, err)
+	}
+
+	// a single statement that isn't a list
+	single := `{"Version":"2012-10-17","Statement":{"Sid":"Other","Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"*"}}`
+	if policy, err = addPolicyStatement(single, statement); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if _, remaining, err := removePolicyStatement(policy, pendingDeleteSid); err != nil || remaining != 1 {
+		t.Errorf("expected 1 statement to remain, got %d (%v)", remaining, err)
+	}
+
+	if _, _, err := removePolicyStatement("not json", pendingDeleteSid); err == nil {
+		t.Error("expected error for an invalid policy, got nil")
+	}
+}
+
+func TestPendingDeleteTime(t *testing.T) {
+	if _, ok := pendingDeleteTime([]*s3.Tag{{Key: aws.String("spinup:org"), Value: aws.String("testorg")}}); ok {
+		t.Error("expected website without the tag not to be pending deletion")
+	}
+
+	deleteAfter, ok := pendingDeleteTime([]*s3.Tag{{Key: aws.String(pendingDeleteTagKey), Value: aws.String("2023-05-08T14:22:01Z")}})
+	if !ok || !deleteAfter.Equal(time.Date(2023, 5, 8, 14, 22, 1, 0, time.UTC)) {
+		t.Errorf("expected website to be pending deletion after 2023-05-08T14:22:01Z, got %s (%t)", deleteAfter, ok)
+	}
+
+	// an invalid time is treated as expired
+	deleteAfter, ok = pendingDeleteTime([]*s3.Tag{{Key: aws.String(pendingDeleteTagKey), Value: aws.String("soon")}})
+	if !ok || !deleteAfter.IsZero() {
+		t.Errorf("expected invalid time to be expired, got %s (%t)", deleteAfter, ok)
+	}
+}
+
+func TestSoftDeleteDays(t *testing.T) {
+	tests := []struct {
+		config   *common.SoftDelete
+		expected int
+	}{
+		{nil, 0},
+		{&common.SoftDelete{}, defaultSoftDeleteDays},
+		{&common.SoftDelete{Days: 30}, 30},
+	}
+
+	for _, tt := range tests {
+		s := server{account: common.Account{SoftDelete: tt.config}}
+		if out := s.softDeleteDays(); out != tt.expected {
+			t.Errorf("expected %d days for %+v, got %d", tt.expected, tt.config, out)
+		}
+	}
+}
+
+func TestSoftDeleteRecords(t *testing.T) {
+	s := server{
+		accountsMap: map[string]string{"spinup": "012345678910"},
+		state:       storage.NewMemoryStore(),
+	}
+
+	later := time.Date(2026, 1, 9, 0, 0, 0, 0, time.UTC)
+	sooner := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	s.saveSoftDeleteRecord(context.TODO(), "spinup", &websiteSoftDeleteOutput{Website: "later.example.com", DeleteAfter: later, Users: []string{}})
+	s.saveSoftDeleteRecord(context.TODO(), "spinup", &websiteSoftDeleteOutput{Website: "sooner.example.com", DeleteAfter: sooner, Users: []string{"foo-user"}})
+	s.saveSoftDeleteRecord(context.TODO(), "other", &websiteSoftDeleteOutput{Website: "other.example.com", DeleteAfter: sooner})
+
+	records, err := s.softDeleteRecords(context.TODO(), "012345678910")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if len(records) != 2 || records[0].Website != "sooner.example.com" || records[1].Website != "later.example.com" {
+		t.Fatalf("expected the account's records due soonest first, got %+v", records)
+	}
+
+	if records[0].Account != "012345678910" || !records[0].DeleteAfter.Equal(sooner) || len(records[0].Users) != 1 {
+		t.Errorf("unexpected record %+v", records[0])
+	}
+
+	s.deleteSoftDeleteRecord(context.TODO(), "spinup", "sooner.example.com")
+
+	records, _ = s.softDeleteRecords(context.TODO(), "spinup")
+	if len(records) != 1 || records[0].Website != "later.example.com" {
+		t.Errorf("expected the restored website's record to be removed, got %+v", records)
+	}
+}
@@ -0,0 +1,413 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/YaleSpinup/apierror"
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/YaleSpinup/s3-api/task"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// stagingTagKey marks the bucket of a staging website with the name of the website it stages
+	stagingTagKey = "spinup:staging-for"
+	// stagingPrefix is the hostname of a staging website in the zone of its website, ie. stage.example.com
+	stagingPrefix = "stage."
+	// promoteTaskKind is the kind of the task that promotes the content of a staging website to its website
+	promoteTaskKind = "promote"
+	// promotePageSize is the number of objects listed at a time, a fresh session is used for each page
+	promotePageSize = 500
+	// promoteConcurrency is the number of objects copied at the same time
+	promoteConcurrency = 10
+)
+
+// stagingActions are the actions needed to read the configuration of a website for its staging website
+var stagingActions = []string{
+	"s3:GetBucketTagging",
+	"s3:GetBucketWebsite",
+	"cloudfront:ListDistributions",
+	"cloudfront:GetDistribution",
+	"cloudfront:GetDistributionConfig",
+}
+
+// promoteActions are the actions needed to promote the content of a staging website to its website
+var promoteActions = []string{
+	"s3:GetBucketTagging",
+	"s3:ListBucket",
+	"s3:GetObject",
+	"s3:GetObjectTagging",
+	"s3:PutObject",
+	"s3:PutObjectTagging",
+	"s3:DeleteObject",
+	"cloudfront:ListDistributions",
+	"cloudfront:CreateInvalidation",
+}
+
+// stagingName returns the name of the staging website of a website, ie. stage.example.com for example.com.  It's
+// always inside the website's own zone, so the website has to be one of the domains to have a staging website.
+func stagingName(website string) string {
+	return stagingPrefix + website
+}
+
+// stagingWebsiteRequest builds the request to create the staging website of a website.  The staging website shares
+// the website configuration, cache behaviors, tags and default object tags of the website, it's created without a
+// default index page so it doesn't overwrite the website's index when it's promoted.
+func stagingWebsiteRequest(ctx context.Context, s3Service s3api.S3, cloudFrontService cfapi.CloudFront, website string) (*websiteCreateRequest, error) {
+	tags, err := s3Service.GetBucketTags(ctx, website)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case isSimpleWebsite(tags):
+		return nil, apierror.New(apierror.ErrBadRequest, "simple websites can't have a staging website", nil)
+	case s3TagMap(tags)[stagingTagKey] != "":
+		msg := fmt.Sprintf("%s is the staging website of %s", website, s3TagMap(tags)[stagingTagKey])
+		return nil, apierror.New(apierror.ErrBadRequest, msg, nil)
+	}
+
+	config, err := s3Service.GetWebsiteConfig(ctx, website)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.RedirectAllRequestsTo != nil {
+		return nil, apierror.New(apierror.ErrBadRequest, "redirect sites can't have a staging website", nil)
+	}
+
+	distribution, err := cloudFrontService.GetDistributionByName(ctx, website)
+	if err != nil {
+		return nil, err
+	}
+
+	behaviors, err := cloudFrontService.GetCacheBehaviors(ctx, aws.StringValue(distribution.Id))
+	if err != nil {
+		return nil, err
+	}
+
+	// the tags managed by the api aren't shared, the org tag is added when the website is created
+	stagingTags := []*s3.Tag{}
+	for _, t := range tags {
+		key := aws.StringValue(t.Key)
+		if key == "spinup:org" || isReservedTag(key) || strings.HasPrefix(strings.ToLower(key), "aws:") {
+			continue
+		}
+		stagingTags = append(stagingTags, t)
+	}
+
+	return &websiteCreateRequest{
+		Tags:        stagingTags,
+		BucketInput: s3.CreateBucketInput{Bucket: aws.String(stagingName(website))},
+		WebsiteConfiguration: s3.WebsiteConfiguration{
+			IndexDocument: config.IndexDocument,
+			ErrorDocument: config.ErrorDocument,
+			RoutingRules:  config.RoutingRules,
+		},
+		DefaultIndex:   aws.Bool(false),
+		ObjectTags:     defaultObjectTags(tags),
+		CacheBehaviors: behaviors,
+	}, nil
+}
+
+// WebsiteStagingCreateHandler creates the staging website of a website, a website named with the staging prefix in
+// the website's zone that shares the website's configuration.  The staging website is created like any other website and
+// is deleted with the website delete endpoint.
+func (s *server) WebsiteStagingCreateHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	accountId := s.mapAccountNumber(vars["account"])
+	website := vars["website"]
+
+	if err := s.requireWebsites(vars["account"]); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	wait, err := waitParam(r)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	session, err := s.sessionForAccount(r.Context(), accountId, stagingActions...)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+	cloudFrontService := cfapi.NewSession(session.Session, s.account, accountId)
+	cloudFrontService.Index = s.distributionIndex(accountId)
+
+	req, err := stagingWebsiteRequest(r.Context(), s3Service, cloudFrontService, website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if err := req.validate(s.account.Domains, s.requiredTags); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	// the tag links the staging website to the website, it's reserved so it's only set here
+	req.Tags = append(req.Tags, &s3.Tag{
+		Key:   aws.String(stagingTagKey),
+		Value: aws.String(website),
+	})
+
+	s.createWebsite(w, r, req, wait)
+}
+
+// WebsiteStagingPromoteHandler starts a background task that copies the new and changed content of the staging
+// website of a website to the website and invalidates the website's cache.  With the delete query parameter, the
+// objects that aren't in the staging website are deleted from the website too.  The task is returned with 202 Accepted
+// and its progress can be followed with the task endpoints.
+func (s *server) WebsiteStagingPromoteHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	account := vars["account"]
+	accountId := s.mapAccountNumber(account)
+	website := vars["website"]
+	staging := stagingName(website)
+
+	prune := false
+	if d := r.URL.Query().Get("delete"); d != "" {
+		b, err := strconv.ParseBool(d)
+		if err != nil {
+			handleError(w, apierror.New(apierror.ErrBadRequest, "invalid delete parameter", err))
+			return
+		}
+		prune = b
+	}
+
+	// the task can outlive the credentials of an assumed role, so it gets a session for each page of objects
+	services := func(ctx context.Context) (s3api.S3, cfapi.CloudFront, error) {
+		session, err := s.sessionForAccount(ctx, account, promoteActions...)
+		if err != nil {
+			return s3api.S3{}, cfapi.CloudFront{}, err
+		}
+
+		cloudFrontService := cfapi.NewSession(session.Session, s.account, accountId)
+		cloudFrontService.Index = s.distributionIndex(accountId)
+		return s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId)), cloudFrontService, nil
+	}
+
+	s3Service, cloudFrontService, err := services(r.Context())
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	tags, err := s3Service.GetBucketTags(r.Context(), staging)
+	if err != nil && !isNotFound(err) {
+		handleError(w, err)
+		return
+	}
+
+	if s3TagMap(tags)[stagingTagKey] != website {
+		msg := fmt.Sprintf("website %s doesn't have a staging website", website)
+		handleError(w, apierror.New(apierror.ErrNotFound, msg, nil))
+		return
+	}
+
+	distribution, err := cloudFrontService.GetDistributionByName(r.Context(), website)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	distributionId := aws.StringValue(distribution.Id)
+
+	t, err := s.tasks.Start(promoteTaskKind, accountId, website, func(ctx context.Context, rep *task.Reporter) error {
+		return promoteWebsite(ctx, rep, services, staging, website, distributionId, prune)
+	})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeTask(w, http.StatusAccepted, t)
+}
+
+// promoteWebsite copies the objects of a staging website that are missing or different in the website, a page at a
+// time, and deletes the objects that aren't staged if prune is true.  The website's cache is invalidated if anything
+// changed.  Objects that fail to copy are reported on the task and skipped, the task only fails if the objects can't
+// be listed or the cache can't be invalidated.
+func promoteWebsite(ctx context.Context, rep *task.Reporter, services func(context.Context) (s3api.S3, cfapi.CloudFront, error), staging, website, distributionId string, prune bool) error {
+	existing, err := objectETags(ctx, services, website)
+	if err != nil {
+		return err
+	}
+
+	var changed atomic.Bool
+	staged := map[string]bool{}
+
+	input := &s3.ListObjectsV2Input{
+		Bucket:  aws.String(staging),
+		MaxKeys: aws.Int64(promotePageSize),
+	}
+
+	for {
+		s3Service, _, err := services(ctx)
+		if err != nil {
+			return err
+		}
+
+		out, err := s3Service.ListObjects(ctx, input)
+		if err != nil {
+			return err
+		}
+
+		rep.Found(len(out.Contents))
+
+		g, gctx := newErrGroup(ctx)
+		sem := make(chan struct{}, promoteConcurrency)
+		for _, o := range out.Contents {
+			key := aws.StringValue(o.Key)
+			staged[key] = true
+
+			if etag, ok := existing[key]; ok && etag == aws.StringValue(o.ETag) {
+				rep.Skipped()
+				continue
+			}
+
+			object := o
+			g.Go(func() error {
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				if promoteObject(gctx, rep, s3Service, staging, website, object) {
+					changed.Store(true)
+				}
+				return nil
+			})
+		}
+		g.Wait()
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if !aws.BoolValue(out.IsTruncated) {
+			break
+		}
+		input.ContinuationToken = out.NextContinuationToken
+	}
+
+	if prune {
+		s3Service, _, err := services(ctx)
+		if err != nil {
+			return err
+		}
+
+		for key := range existing {
+			if staged[key] {
+				continue
+			}
+
+			rep.Found(1)
+			if _, err := s3Service.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: aws.String(website), Key: aws.String(key)}); err != nil {
+				rep.Failed("%s: %s", key, err)
+				continue
+			}
+
+			changed.Store(true)
+			rep.Succeeded()
+		}
+	}
+
+	if !changed.Load() {
+		return nil
+	}
+
+	_, cloudFrontService, err := services(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := cloudFrontService.InvalidateCache(ctx, distributionId, []string{"/*"}); err != nil {
+		rep.Failed("failed to invalidate the cache of %s: %s", website, err)
+		return err
+	}
+
+	return nil
+}
+
+// objectETags lists the etags of the objects in a bucket by key
+func objectETags(ctx context.Context, services func(context.Context) (s3api.S3, cfapi.CloudFront, error), bucket string) (map[string]string, error) {
+	etags := map[string]string{}
+
+	input := &s3.ListObjectsV2Input{
+		Bucket:  aws.String(bucket),
+		MaxKeys: aws.Int64(promotePageSize),
+	}
+
+	for {
+		s3Service, _, err := services(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		out, err := s3Service.ListObjects(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, o := range out.Contents {
+			etags[aws.StringValue(o.Key)] = aws.StringValue(o.ETag)
+		}
+
+		if !aws.BoolValue(out.IsTruncated) {
+			return etags, nil
+		}
+		input.ContinuationToken = out.NextContinuationToken
+	}
+}
+
+// promoteObject copies an object from the staging website to the website with its metadata, tags and storage class,
+// the copy only happens if the object hasn't changed since it was listed.  It returns true if the object was copied.
+func promoteObject(ctx context.Context, rep *task.Reporter, s3Service s3api.S3, staging, website string, object *s3.Object) bool {
+	key := aws.StringValue(object.Key)
+
+	switch aws.StringValue(object.StorageClass) {
+	case s3.StorageClassGlacier, s3.StorageClassDeepArchive:
+		rep.Failed("%s: archived objects can't be copied without restoring them", key)
+		return false
+	}
+
+	if aws.Int64Value(object.Size) > maxCopyObjectSize {
+		rep.Failed("%s: objects larger than 5GB can't be copied", key)
+		return false
+	}
+
+	input := &s3.CopyObjectInput{
+		Bucket:            aws.String(website),
+		Key:               aws.String(key),
+		CopySource:        aws.String(url.PathEscape(fmt.Sprintf("%s/%s", staging, key))),
+		CopySourceIfMatch: object.ETag,
+		MetadataDirective: aws.String(s3.MetadataDirectiveCopy),
+		TaggingDirective:  aws.String(s3.TaggingDirectiveCopy),
+	}
+
+	if class := aws.StringValue(object.StorageClass); class != "" && class != s3.StorageClassStandard {
+		input.StorageClass = object.StorageClass
+	}
+
+	if _, err := s3Service.CopyObject(ctx, input); err != nil {
+		rep.Failed("%s: %s", key, err)
+		return false
+	}
+
+	rep.Succeeded()
+	return true
+}
@@ -0,0 +1,297 @@
+package api
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	"github.com/YaleSpinup/s3-api/common"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/YaleSpinup/s3-api/task"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/aws/aws-sdk-go/service/cloudfront/cloudfrontiface"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// mockStagingS3 is an s3 client with the tags, website configuration and objects of buckets that captures the copies
+// and deletes
+type mockStagingS3 struct {
+	s3iface.S3API
+	tags    map[string][]*s3.Tag
+	config  *s3.GetBucketWebsiteOutput
+	objects map[string][]*s3.Object
+	copies  []string
+	deletes []string
+	mu      sync.Mutex
+}
+
+func (m *mockStagingS3) GetBucketTaggingWithContext(ctx context.Context, input *s3.GetBucketTaggingInput, opts ...request.Option) (*s3.GetBucketTaggingOutput, error) {
+	return &s3.GetBucketTaggingOutput{TagSet: m.tags[aws.StringValue(input.Bucket)]}, nil
+}
+
+func (m *mockStagingS3) GetBucketWebsiteWithContext(ctx context.Context, input *s3.GetBucketWebsiteInput, opts ...request.Option) (*s3.GetBucketWebsiteOutput, error) {
+	return m.config, nil
+}
+
+// ListObjectsV2WithContext lists the objects of a bucket one at a time
+func (m *mockStagingS3) ListObjectsV2WithContext(ctx context.Context, input *s3.ListObjectsV2Input, opts ...request.Option) (*s3.ListObjectsV2Output, error) {
+	objects := m.objects[aws.StringValue(input.Bucket)]
+	if len(objects) == 0 {
+		return &s3.ListObjectsV2Output{IsTruncated: aws.Bool(false)}, nil
+	}
+
+	i := 0
+	if input.ContinuationToken != nil {
+		for n, o := range objects {
+			if aws.StringValue(o.Key) == aws.StringValue(input.ContinuationToken) {
+				i = n
+			}
+		}
+	}
+
+	out := &s3.ListObjectsV2Output{Contents: objects[i : i+1], IsTruncated: aws.Bool(i+1 < len(objects))}
+	if i+1 < len(objects) {
+		out.NextContinuationToken = objects[i+1].Key
+	}
+	return out, nil
+}
+
+func (m *mockStagingS3) CopyObjectWithContext(ctx context.Context, input *s3.CopyObjectInput, opts ...request.Option) (*s3.CopyObjectOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.copies = append(m.copies, aws.StringValue(input.CopySource)+" "+aws.StringValue(input.Bucket)+"/"+aws.StringValue(input.Key))
+	return &s3.CopyObjectOutput{}, nil
+}
+
+func (m *mockStagingS3) DeleteObjectWithContext(ctx context.Context, input *s3.DeleteObjectInput, opts ...request.Option) (*s3.DeleteObjectOutput, error) {
+	m.deletes = append(m.deletes, aws.StringValue(input.Bucket)+"/"+aws.StringValue(input.Key))
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+// mockStagingCloudFront is a cloudfront client with the distribution of www.example.com that captures the invalidations
+type mockStagingCloudFront struct {
+	cloudfrontiface.CloudFrontAPI
+	invalidations [][]string
+}
+
+func (m *mockStagingCloudFront) ListDistributionsPagesWithContext(ctx context.Context, input *cloudfront.ListDistributionsInput, fn func(*cloudfront.ListDistributionsOutput, bool) bool, opts ...request.Option) error {
+	fn(&cloudfront.ListDistributionsOutput{
+		DistributionList: &cloudfront.DistributionList{
+			Items: []*cloudfront.DistributionSummary{
+				{Id: aws.String("E123"), Aliases: &cloudfront.Aliases{Items: aws.StringSlice([]string{"www.example.com"})}},
+			},
+		},
+	}, true)
+	return nil
+}
+
+func (m *mockStagingCloudFront) GetDistributionConfigWithContext(ctx context.Context, input *cloudfront.GetDistributionConfigInput, opts ...request.Option) (*cloudfront.GetDistributionConfigOutput, error) {
+	return &cloudfront.GetDistributionConfigOutput{
+		DistributionConfig: &cloudfront.DistributionConfig{
+			CacheBehaviors: &cloudfront.CacheBehaviors{
+				Items: []*cloudfront.CacheBehavior{{PathPattern: aws.String("/assets/*"), TargetOriginId: aws.String("www.example.com")}},
+			},
+		},
+	}, nil
+}
+
+func (m *mockStagingCloudFront) CreateInvalidationWithContext(ctx context.Context, input *cloudfront.CreateInvalidationInput, opts ...request.Option) (*cloudfront.CreateInvalidationOutput, error) {
+	m.invalidations = append(m.invalidations, aws.StringValueSlice(input.InvalidationBatch.Paths.Items))
+	return &cloudfront.CreateInvalidationOutput{}, nil
+}
+
+func TestStagingName(t *testing.T) {
+	tests := map[string]string{
+		"example.com":          "stage.example.com",
+		"www.example.com":      "stage.www.example.com",
+		"docs.spinup.yale.edu": "stage.docs.spinup.yale.edu",
+	}
+
+	for website, expected := range tests {
+		if out := stagingName(website); out != expected {
+			t.Errorf("expected %s for %s, got %s", expected, website, out)
+		}
+	}
+
+	// the staging website is in the website's zone, it's valid when the website is one of the domains
+	domains := map[string]*common.Domain{"example.com": {}}
+	for website, valid := range map[string]bool{"example.com": true, "www.example.com": false} {
+		f := fieldErrors{}
+		f.websiteName("Website", stagingName(website), domains)
+		if valid != (len(f) == 0) {
+			t.Errorf("expected staging website of %s valid to be %t, got %v", website, valid, f)
+		}
+	}
+}
+
+func TestStagingWebsiteRequest(t *testing.T) {
+	m := &mockStagingS3{
+		tags: map[string][]*s3.Tag{
+			"www.example.com": {
+				{Key: aws.String("spinup:org"), Value: aws.String("test")},
+				{Key: aws.String("spinup:protected"), Value: aws.String("true")},
+				{Key: aws.String("spinup:object:project"), Value: aws.String("docs")},
+				{Key: aws.String("Name"), Value: aws.String("docs site")},
+			},
+			"simple.example.com":    {{Key: aws.String(simpleWebsiteTagKey), Value: aws.String("true")}},
+			"stage.www.example.com": {{Key: aws.String(stagingTagKey), Value: aws.String("www.example.com")}},
+		},
+		config: &s3.GetBucketWebsiteOutput{
+			IndexDocument: &s3.IndexDocument{Suffix: aws.String("index.html")},
+			ErrorDocument: &s3.ErrorDocument{Key: aws.String("404.html")},
+		},
+	}
+	s3Service := s3api.S3{Service: m}
+	cloudFrontService := cfapi.CloudFront{Service: &mockStagingCloudFront{}}
+
+	req, err := stagingWebsiteRequest(context.TODO(), s3Service, cloudFrontService, "www.example.com")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if aws.StringValue(req.BucketInput.Bucket) != "stage.www.example.com" {
+		t.Errorf("expected the staging website stage.www.example.com, got %s", aws.StringValue(req.BucketInput.Bucket))
+	}
+
+	if !reflect.DeepEqual(req.Tags, []*s3.Tag{{Key: aws.String("Name"), Value: aws.String("docs site")}}) {
+		t.Errorf("expected only the tags that aren't managed by the api to be shared, got %+v", req.Tags)
+	}
+
+	if !reflect.DeepEqual(req.ObjectTags, []*s3.Tag{{Key: aws.String("project"), Value: aws.String("docs")}}) {
+		t.Errorf("expected the default object tags to be shared, got %+v", req.ObjectTags)
+	}
+
+	if aws.StringValue(req.WebsiteConfiguration.ErrorDocument.Key) != "404.html" || aws.BoolValue(req.DefaultIndex) {
+		t.Errorf("expected the website configuration without a default index, got %+v", req)
+	}
+
+	if len(req.CacheBehaviors) != 1 || req.CacheBehaviors[0].PathPattern != "/assets/*" {
+		t.Errorf("expected the cache behaviors to be shared, got %+v", req.CacheBehaviors)
+	}
+
+	for _, website := range []string{"simple.example.com", "stage.www.example.com"} {
+		_, err := stagingWebsiteRequest(context.TODO(), s3Service, cloudFrontService, website)
+		if !common.IsErrorCode(err, apierror.ErrBadRequest) {
+			t.Errorf("expected a bad request error for %s, got %v", website, err)
+		}
+	}
+
+	m.config = &s3.GetBucketWebsiteOutput{RedirectAllRequestsTo: &s3.RedirectAllRequestsTo{HostName: aws.String("example.org")}}
+	if _, err := stagingWebsiteRequest(context.TODO(), s3Service, cloudFrontService, "www.example.com"); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Errorf("expected a bad request error for a redirect site, got %v", err)
+	}
+}
+
+func TestPromoteWebsite(t *testing.T) {
+	tests := []struct {
+		name          string
+		prune         bool
+		production    []*s3.Object
+		copies        []string
+		deletes       []string
+		invalidations [][]string
+		progress      task.Progress
+	}{
+		{
+			name: "changed",
+			production: []*s3.Object{
+				{Key: aws.String("index.html"), ETag: aws.String(`"old"`)},
+				{Key: aws.String("logo.png"), ETag: aws.String(`"logo"`)},
+				{Key: aws.String("old.html"), ETag: aws.String(`"old"`)},
+			},
+			copies: []string{
+				"stage.www.example.com%2Fdocs%2Fa%20b.html www.example.com/docs/a b.html",
+				"stage.www.example.com%2Findex.html www.example.com/index.html",
+			},
+			invalidations: [][]string{{"/*"}},
+			progress:      task.Progress{Total: 3, Succeeded: 2, Skipped: 1},
+		},
+		{
+			name:  "prune",
+			prune: true,
+			production: []*s3.Object{
+				{Key: aws.String("docs/a b.html"), ETag: aws.String(`"a"`)},
+				{Key: aws.String("index.html"), ETag: aws.String(`"new"`)},
+				{Key: aws.String("logo.png"), ETag: aws.String(`"logo"`)},
+				{Key: aws.String("old.html"), ETag: aws.String(`"old"`)},
+			},
+			deletes:       []string{"www.example.com/old.html"},
+			invalidations: [][]string{{"/*"}},
+			progress:      task.Progress{Total: 4, Succeeded: 1, Skipped: 3},
+		},
+		{
+			name: "unchanged",
+			production: []*s3.Object{
+				{Key: aws.String("docs/a b.html"), ETag: aws.String(`"a"`)},
+				{Key: aws.String("index.html"), ETag: aws.String(`"new"`)},
+				{Key: aws.String("logo.png"), ETag: aws.String(`"logo"`)},
+			},
+			progress: task.Progress{Total: 3, Skipped: 3},
+		},
+	}
+
+	for _, tt := range tests {
+		m := &mockStagingS3{
+			objects: map[string][]*s3.Object{
+				"stage.www.example.com": {
+					{Key: aws.String("docs/a b.html"), ETag: aws.String(`"a"`), Size: aws.Int64(10)},
+					{Key: aws.String("index.html"), ETag: aws.String(`"new"`), Size: aws.Int64(10)},
+					{Key: aws.String("logo.png"), ETag: aws.String(`"logo"`), Size: aws.Int64(10)},
+				},
+				"www.example.com": tt.production,
+			},
+		}
+		cf := &mockStagingCloudFront{}
+
+		services := func(ctx context.Context) (s3api.S3, cfapi.CloudFront, error) {
+			return s3api.S3{Service: m}, cfapi.CloudFront{Service: cf}, nil
+		}
+
+		manager := task.NewManager(context.Background())
+		started, err := manager.Start(promoteTaskKind, "012345678910", "www.example.com", func(ctx context.Context, rep *task.Reporter) error {
+			return promoteWebsite(ctx, rep, services, "stage.www.example.com", "www.example.com", "E123", tt.prune)
+		})
+		if err != nil {
+			t.Fatalf("%s: expected nil error, got %s", tt.name, err)
+		}
+
+		var finished *task.Task
+		for i := 0; i < 100; i++ {
+			if finished, _ = manager.Get(started.ID); finished.Status != task.StatusRunning {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		if finished.Status != task.StatusSucceeded {
+			t.Errorf("%s: expected succeeded task, got %+v", tt.name, finished)
+			continue
+		}
+
+		sort.Strings(m.copies)
+		if !reflect.DeepEqual(m.copies, tt.copies) {
+			t.Errorf("%s: expected copies %v, got %v", tt.name, tt.copies, m.copies)
+		}
+
+		if !reflect.DeepEqual(m.deletes, tt.deletes) {
+			t.Errorf("%s: expected deletes %v, got %v", tt.name, tt.deletes, m.deletes)
+		}
+
+		if !reflect.DeepEqual(cf.invalidations, tt.invalidations) {
+			t.Errorf("%s: expected invalidations %v, got %v", tt.name, tt.invalidations, cf.invalidations)
+		}
+
+		if finished.Progress != tt.progress {
+			t.Errorf("%s: expected progress %+v, got %+v", tt.name, tt.progress, finished.Progress)
+		}
+	}
+}
@@ -8,10 +8,12 @@ import (
 	s3api "github.com/YaleSpinup/s3-api/s3"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"net/http"
-	"time"
 
 	"github.com/YaleSpinup/apierror"
 	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/YaleSpinup/s3-api/retry"
+	"github.com/YaleSpinup/s3-api/rollback"
+	"github.com/YaleSpinup/s3-api/webhook"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awsutil"
 	"github.com/aws/aws-sdk-go/service/iam"
@@ -27,47 +29,69 @@ func (s *server) WebsiteUserCreateHandler(w http.ResponseWriter, r *http.Request
 	accountId := s.mapAccountNumber(vars["account"])
 	website := vars["website"]
 
-	role := fmt.Sprintf("arn:aws:iam::%s:role/%s", accountId, s.session.RoleName)
-	policy, err := generatePolicy("iam:*", "s3:*")
-	if err != nil {
-		log.Errorf("cannot generate policy: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
+	// REQUEST
+	var req userCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		msg := fmt.Sprintf("cannot decode body into create user input: %s", err)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
 		return
 	}
 
-	session, err := s.assumeRole(
-		r.Context(),
-		s.session.ExternalID,
-		role,
-		policy,
-	)
+	f := fieldErrors{}
+	f.user("User", req.User)
+	f.groups("Groups", req.Groups, websiteUserGroups)
+	if err := f.err(); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	session, err := s.sessionForAccount(r.Context(), accountId, append([]string{"iam:*", "s3:*"}, quotaCheckActions...)...)
 	if err != nil {
-		log.Errorf("failed to assume role in account: %s", accountId)
-		w.WriteHeader(http.StatusInternalServerError)
+		handleError(w, err)
 		return
 	}
 
 	iamService := iamapi.NewSession(session.Session, s.account)
+	iamService.Cache = s.resourceCache(accountId)
 	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
 
-	// REQUEST
-	var req struct {
-		User   *iam.CreateUserInput
-		Groups []string
-	}
-	err = json.NewDecoder(r.Body).Decode(&req)
+	// the user and any group policies are tagged with the org and required tags from the website bucket
+	bucketTags, err := s3Service.GetBucketTags(r.Context(), website)
 	if err != nil {
-		msg := fmt.Sprintf("cannot decode body into create user input: %s", err)
-		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+		handleError(w, err)
 		return
 	}
+	tags := iamTags(s.requiredTags.inherited(bucketTags))
+	req.User.Tags = mergeIAMTags(req.User.Tags, tags)
+
+	userName := aws.StringValue(req.User.UserName)
+
+	groupNames := req.Groups
+	if groupNames == nil {
+		groupNames = []string{"BktAdmGrp"}
+	}
 
-	// setup err var, rollback function list and defer execution, note that we depend on the err variable defined above this
-	var rollBackTasks []rollbackFunc
+	path := "/"
+	if aws.StringValue(req.User.Path) != "" {
+		path = aws.StringValue(req.User.Path)
+	}
+
+	quotaGroups := []string{}
+	for _, group := range groupNames {
+		quotaGroups = append(quotaGroups, iamapi.FormatGroupName(website, path, group))
+	}
+
+	if err := s.checkUserQuotas(r.Context(), session.Session, accountId, iamService, quotaGroups); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	// setup rollback and defer execution, note that we depend on the err variable defined above this
+	rb := s.newRollback("user.create", vars["account"], userName, rollbackServices{iam: &iamService})
 	defer func() {
+		s.finishRollback(rb, err)
 		if err != nil {
-			log.Errorf("recovering from error: %s, executing %d rollback tasks", err, len(rollBackTasks))
-			rollBack(&rollBackTasks)
+			s.notify(webhook.EventUserRolledBack, vars["account"], userName, map[string]string{"Website": website, "Error": err.Error(), "Rollback": rb.ID})
 		}
 	}()
 
@@ -79,9 +103,9 @@ func (s *server) WebsiteUserCreateHandler(w http.ResponseWriter, r *http.Request
 	}
 
 	// wait for the user to exist
-	err = retry(3, 2*time.Second, func() error {
+	err = retry.Do(r.Context(), iamPropagationRetry, func(ctx context.Context) error {
 		log.Infof("checking if user exists before continuing: %s", aws.StringValue(userOutput.User.UserName))
-		out, err := iamService.GetUser(r.Context(), &iam.GetUserInput{
+		out, err := iamService.GetUser(ctx, &iam.GetUserInput{
 			UserName: userOutput.User.UserName,
 		})
 		if err != nil {
@@ -98,38 +122,22 @@ func (s *server) WebsiteUserCreateHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// append user delete to rollback tasks
-	rbfunc := func(ctx context.Context) error {
-		if err := iamService.DeleteUser(r.Context(), &iam.DeleteUserInput{UserName: req.User.UserName}); err != nil {
-			return err
-		}
-		return nil
-	}
-	rollBackTasks = append(rollBackTasks, rbfunc)
-
-	groupNames := req.Groups
-	if groupNames == nil {
-		groupNames = []string{"BktAdmGrp"}
-	}
-
-	path := "/"
-	if aws.StringValue(req.User.Path) != "" {
-		path = aws.StringValue(req.User.Path)
-	}
+	// append user delete to rollback
+	rb.Add("delete user "+userName, rollbackDeleteUser, map[string]string{"user": userName})
 
 	for _, group := range groupNames {
 		groupName := iamapi.FormatGroupName(website, path, group)
 
 		_, err := iamService.GetGroup(r.Context(), groupName)
 		if err != nil {
-			if aerr, ok := err.(apierror.Error); ok && aerr.Code == apierror.ErrNotFound {
-				var rbTasks []rollbackFunc
-				rbTasks, err = s.CreateWebsiteBucketPolicy(r.Context(), iamService, website, path, group)
+			if isNotFound(err) {
+				var steps []*rollback.Step
+				steps, err = s.CreateWebsiteBucketPolicy(r.Context(), iamService, website, path, group, tags)
 				if err != nil {
 					handleError(w, err)
 					return
 				}
-				rollBackTasks = append(rollBackTasks, rbTasks...)
+				rb.Append(steps...)
 			} else {
 				handleError(w, err)
 				return
@@ -158,17 +166,8 @@ func (s *server) WebsiteUserCreateHandler(w http.ResponseWriter, r *http.Request
 			}
 		}
 
-		// append detach group to rollback funciton
-		rbfunc = func(ctx context.Context) error {
-			if err := iamService.RemoveUserFromGroup(r.Context(), &iam.RemoveUserFromGroupInput{
-				UserName:  userOutput.User.UserName,
-				GroupName: aws.String(groupName),
-			}); err != nil {
-				return err
-			}
-			return nil
-		}
-		rollBackTasks = append(rollBackTasks, rbfunc)
+		// append remove user from group to rollback
+		rb.Add("remove user "+userName+" from group "+groupName, rollbackRemoveUserFromGroup, map[string]string{"user": userName, "group": groupName})
 	}
 
 	if path != "/" {
@@ -183,7 +182,7 @@ func (s *server) WebsiteUserCreateHandler(w http.ResponseWriter, r *http.Request
 				Body:        bytes.NewReader([]byte(indexMessage)),
 				ContentType: aws.String("text/html"),
 				Key:         aws.String(path + "index.html"),
-				Tagging:     aws.String("yale:spinup=true"),
+				Tagging:     aws.String(indexTagging(objectTagging(defaultObjectTags(bucketTags)))),
 			}); err != nil {
 				msg := fmt.Sprintf("failed to create default index file for website %s: %s", website, err.Error())
 				handleError(w, errors.Wrap(err, msg))
@@ -193,10 +192,8 @@ func (s *server) WebsiteUserCreateHandler(w http.ResponseWriter, r *http.Request
 		// write index file
 	}
 
-	output := struct {
-		User *iam.User
-	}{
-		userOutput.User,
+	output := userCreateResponse{
+		User: userOutput.User,
 	}
 
 	j, err := json.Marshal(output)
@@ -206,6 +203,8 @@ func (s *server) WebsiteUserCreateHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	s.notify(webhook.EventUserCreated, vars["account"], aws.StringValue(userOutput.User.UserName), map[string]string{"Website": website})
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	w.Write(j)
@@ -223,95 +222,40 @@ func (s *server) WebsiteUserShowHandler(w http.ResponseWriter, r *http.Request)
 	user := vars["user"]
 	path := iamapi.GetUsernamePath(bucket, user)
 
-	role := fmt.Sprintf("arn:aws:iam::%s:role/%s", accountId, s.session.RoleName)
-	policy, err := generatePolicy("iam:*")
+	session, err := s.sessionForAccount(r.Context(), accountId, "iam:*")
 	if err != nil {
-		log.Errorf("cannot generate policy: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	session, err := s.assumeRole(
-		r.Context(),
-		s.session.ExternalID,
-		role,
-		policy,
-	)
-	if err != nil {
-		log.Errorf("failed to assume role in account: %s", accountId)
-		w.WriteHeader(http.StatusInternalServerError)
+		handleError(w, err)
 		return
 	}
 
 	iamService := iamapi.NewSession(session.Session, s.account)
+	iamService.Cache = s.resourceCache(accountId)
 
-	// collect the list of users in the various management groups
-	users := []*iam.User{}
-	for _, g := range []string{"BktAdmGrp", "BktRWGrp", "BktROGrp"} {
+	groupNames := []string{}
+	for _, g := range bucketUserGroups {
 		log.Debugf("formatting group name with parts | bucket: %s, path: %s, group: %s", bucket, path, g)
-		groupName := iamapi.FormatGroupName(bucket, path, g)
-		log.Debugf("list group users for group name: %s", groupName)
-		grpUsers, err := iamService.ListGroupUsers(r.Context(), &iam.GetGroupInput{GroupName: aws.String(groupName)})
-		if err != nil {
-			log.Warnf("error getting users for the %s goup", groupName)
-			continue
-		}
-
-		users = append(users, grpUsers...)
+		groupNames = append(groupNames, iamapi.FormatGroupName(bucket, path, g))
 	}
 
-	// check if there is a user with the same name as the bucket to support legacy buckets
-	u, err := iamService.GetUser(r.Context(), &iam.GetUserInput{UserName: aws.String(bucket)})
-	if err == nil {
-		users = append(users, u.User)
+	userDetails, err := getBucketUser(r.Context(), iamService, bucket, user, groupNames)
+	if err != nil {
+		handleError(w, err)
+		return
 	}
 
-	// range over all of the users we found and return the user if it matches the requested user
-	for _, u := range users {
-		if aws.StringValue(u.UserName) == user {
-			var userDetails = struct {
-				User       *iam.User
-				AccessKeys []*iam.AccessKeyMetadata
-				Groups     []*iam.Group
-				Policies   []*iam.AttachedPolicy
-			}{
-				User: u,
-			}
-
-			keys, err := iamService.ListAccessKeys(r.Context(), &iam.ListAccessKeysInput{UserName: aws.String(user)})
-			if err != nil {
-				handleError(w, err)
-				return
-			}
-			userDetails.AccessKeys = keys
-
-			groups, err := iamService.ListUserGroups(r.Context(), &iam.ListGroupsForUserInput{UserName: aws.String(user)})
-			if err != nil {
-				handleError(w, err)
-				return
-			}
-			userDetails.Groups = groups
-
-			policies, err := iamService.ListUserPolicies(r.Context(), &iam.ListAttachedUserPoliciesInput{UserName: aws.String(user)})
-			if err != nil {
-				handleError(w, err)
-				return
-			}
-			userDetails.Policies = policies
-
-			j, err := json.Marshal(userDetails)
-			if err != nil {
-				log.Errorf("cannot marshal reasponse(%v) into JSON: %s", u, err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusOK)
-			w.Write(j)
-
+	if userDetails != nil {
+		j, err := json.Marshal(userDetails)
+		if err != nil {
+			log.Errorf("cannot marshal reasponse(%v) into JSON: %s", userDetails.User, err)
+			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(j)
+
+		return
 	}
 
 	log.Warnf("requested user %s does not exist or does not belong to bucket %s", user, bucket)
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	route53api "github.com/YaleSpinup/s3-api/route53"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// hostedZoneResponse is a route53 hosted zone available in an account
+type hostedZoneResponse struct {
+	ID          string
+	Name        string
+	Private     bool
+	RecordCount int64
+	// Configured is true if the zone is for one of the configured website domains
+	Configured bool
+}
+
+// ZoneListHandler lists the route53 hosted zones in an account
+func (s *server) ZoneListHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+
+	session, err := s.sessionForAccount(r.Context(), vars["account"], "route53:ListHostedZones")
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	route53Service := route53api.NewSession(session.Session, s.account)
+
+	zones, err := route53Service.ListHostedZones(r.Context())
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	output := s.hostedZonesResponse(zones)
+
+	j, err := json.Marshal(output)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", output, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// hostedZonesResponse converts the route53 hosted zones to the response, sorted by name
+func (s *server) hostedZonesResponse(zones []*route53.HostedZone) []hostedZoneResponse {
+	output := make([]hostedZoneResponse, 0, len(zones))
+	for _, z := range zones {
+		name := strings.TrimSuffix(aws.StringValue(z.Name), ".")
+		_, configured := s.account.Domains[name]
+
+		zone := hostedZoneResponse{
+			ID:          strings.TrimPrefix(aws.StringValue(z.Id), "/hostedzone/"),
+			Name:        name,
+			RecordCount: aws.Int64Value(z.ResourceRecordSetCount),
+			Configured:  configured,
+		}
+
+		if z.Config != nil {
+			zone.Private = aws.BoolValue(z.Config.PrivateZone)
+		}
+
+		output = append(output, zone)
+	}
+
+	sort.Slice(output, func(i, j int) bool {
+		if output[i].Name == output[j].Name {
+			return output[i].ID < output[j].ID
+		}
+		return output[i].Name < output[j].Name
+	})
+
+	return output
+}
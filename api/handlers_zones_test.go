@@ -0,0 +1,49 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+func TestHostedZonesResponse(t *testing.T) {
+	s := server{
+		account: common.Account{
+			Domains: map[string]*common.Domain{
+				"example.com": {},
+			},
+		},
+	}
+
+	zones := []*route53.HostedZone{
+		{
+			Id:                     aws.String("/hostedzone/Z2INTERNAL"),
+			Name:                   aws.String("internal.example.com."),
+			Config:                 &route53.HostedZoneConfig{PrivateZone: aws.Bool(true)},
+			ResourceRecordSetCount: aws.Int64(4),
+		},
+		{
+			Id:                     aws.String("/hostedzone/Z1EXAMPLE"),
+			Name:                   aws.String("example.com."),
+			Config:                 &route53.HostedZoneConfig{PrivateZone: aws.Bool(false)},
+			ResourceRecordSetCount: aws.Int64(12),
+		},
+		{
+			Id:   aws.String("/hostedzone/Z3OTHER"),
+			Name: aws.String("example.edu."),
+		},
+	}
+
+	expected := []hostedZoneResponse{
+		{ID: "Z1EXAMPLE", Name: "example.com", RecordCount: 12, Configured: true},
+		{ID: "Z3OTHER", Name: "example.edu"},
+		{ID: "Z2INTERNAL", Name: "internal.example.com", Private: true, RecordCount: 4},
+	}
+
+	if out := s.hostedZonesResponse(zones); !reflect.DeepEqual(out, expected) {
+		t.Errorf("expected %+v, got %+v", expected, out)
+	}
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// idempotencyKeyHeader is the header with the client's key for a create, a retry with the same key gets the
+	// response of the first request instead of creating the resource again
+	idempotencyKeyHeader = "Idempotency-Key"
+	// idempotentReplayedHeader is set on the responses replayed for an idempotency key
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	// idempotencyTable is the table the idempotency keys are kept in
+	idempotencyTable = "idempotency"
+	// idempotencyTTL is how long the response for an idempotency key is kept
+	idempotencyTTL = 24 * time.Hour
+	// idempotencyPendingTTL is how long an idempotency key is held by a request in progress, so a key isn't held
+	// forever if the instance handling the request crashes
+	idempotencyPendingTTL = 15 * time.Minute
+	// maxIdempotencyKeyLength is the longest idempotency key that's accepted
+	maxIdempotencyKeyLength = 255
+)
+
+// idempotencyRecord is the state of a request with an idempotency key
+type idempotencyRecord struct {
+	// Request is a hash of the method, path and body of the request, a key can't be reused for a different request
+	Request     string
+	Complete    bool
+	Status      int    `json:",omitempty"`
+	ContentType string `json:",omitempty"`
+	Body        []byte `json:",omitempty"`
+}
+
+// responseRecorder is an http.ResponseWriter that keeps a copy of the response it writes
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, so an http.ResponseController can reach it
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// WriteHeader keeps the status code and writes it to the response
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write keeps a copy of the body and writes it to the response
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
+
+// idempotencyMiddleware replays the response of a POST with an Idempotency-Key header when it's retried with the same
+// key, so a client retrying a create after a timeout doesn't create the resource twice.  The key is held while the
+// first request is in progress and retries get a conflict.  Responses with a server error aren't kept so the request
+// can be retried.  Keys are scoped to the account and kept in the state store for a day.
+func (s *server) idempotencyMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" || r.Method != http.MethodPost {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			handleError(w, apierror.New(apierror.ErrBadRequest, "idempotency key cannot be longer than 255 characters", nil))
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			handleError(w, apierror.New(apierror.ErrBadRequest, "failed to read request body", err))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		sum := sha256.Sum256(append([]byte(r.Method+" "+r.URL.Path+"\n"), body...))
+		request := hex.EncodeToString(sum[:])
+		storeKey := s.mapAccountNumber(mux.Vars(r)["account"]) + "/" + key
+
+		pending, err := json.Marshal(idempotencyRecord{Request: request})
+		if err != nil {
+			handleError(w, apierror.New(apierror.ErrInternalError, "failed to marshal idempotency record", err))
+			return
+		}
+
+		claimed, err := s.state.PutIfAbsent(r.Context(), idempotencyTable, storeKey, pending, time.Now().Add(idempotencyPendingTTL))
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		if !claimed {
+			s.replayIdempotentResponse(r.Context(), w, storeKey, request)
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(rec, r)
+
+		// the context of the request may be canceled by now
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if rec.status >= http.StatusInternalServerError {
+			if _, err := s.state.DeleteIf(ctx, idempotencyTable, storeKey, pending); err != nil {
+				log.Errorf("failed to release idempotency key %s: %s", key, err)
+			}
+			return
+		}
+
+		complete, err := json.Marshal(idempotencyRecord{
+			Request:     request,
+			Complete:    true,
+			Status:      rec.status,
+			ContentType: rec.Header().Get("Content-Type"),
+			Body:        rec.body.Bytes(),
+		})
+		if err != nil {
+			log.Errorf("failed to marshal response for idempotency key %s: %s", key, err)
+			return
+		}
+
+		if err := s.state.Put(ctx, idempotencyTable, storeKey, complete, time.Now().Add(idempotencyTTL)); err != nil {
+			log.Errorf("failed to save response for idempotency key %s: %s", key, err)
+		}
+	})
+}
+
+// replayIdempotentResponse writes the saved response for an idempotency key that was already used.  A key used for a
+// different request is rejected and a key held by a request that's still in progress is a conflict.
+func (s *server) replayIdempotentResponse(ctx context.Context, w http.ResponseWriter, storeKey, request string) {
+	value, err := s.state.Get(ctx, idempotencyTable, storeKey)
+	if err != nil {
+		// the first request failed and released the key after this one tried to claim it
+		if isNotFound(err) {
+			handleError(w, apierror.New(apierror.ErrConflict, "request with the idempotency key is in progress", nil))
+			return
+		}
+		handleError(w, err)
+		return
+	}
+
+	record := idempotencyRecord{}
+	if err := json.Unmarshal(value, &record); err != nil {
+		handleError(w, apierror.New(apierror.ErrInternalError, "failed to unmarshal idempotency record", err))
+		return
+	}
+
+	if record.Request != request {
+		handleError(w, apierror.New(apierror.ErrBadRequest, "idempotency key was already used for a different request", nil))
+		return
+	}
+
+	if !record.Complete {
+		handleError(w, apierror.New(apierror.ErrConflict, "request with the idempotency key is in progress", nil))
+		return
+	}
+
+	if record.ContentType != "" {
+		w.Header().Set("Content-Type", record.ContentType)
+	}
+	w.Header().Set(idempotentReplayedHeader, "true")
+	w.WriteHeader(record.Status)
+	w.Write(record.Body)
+}
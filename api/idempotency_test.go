@@ -0,0 +1,93 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/YaleSpinup/s3-api/storage"
+	"github.com/gorilla/mux"
+)
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	s := server{state: storage.NewMemoryStore()}
+
+	calls := 0
+	status := http.StatusOK
+	var during func()
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/s3/{account}/buckets", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if during != nil {
+			during()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(`{"Bucket":"foobucket"}`))
+	}).Methods(http.MethodPost)
+	router.Use(s.idempotencyMiddleware)
+
+	request := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/s3/spinup/buckets", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(idempotencyKeyHeader, key)
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// requests without a key aren't idempotent
+	request("", `{"Bucket":"foobucket"}`)
+	request("", `{"Bucket":"foobucket"}`)
+	if calls != 2 {
+		t.Errorf("expected 2 calls without a key, got %d", calls)
+	}
+
+	calls = 0
+	first := request("abc123", `{"Bucket":"foobucket"}`)
+	retry := request("abc123", `{"Bucket":"foobucket"}`)
+
+	if calls != 1 {
+		t.Errorf("expected 1 call for a retried key, got %d", calls)
+	}
+
+	if retry.Code != first.Code || retry.Body.String() != first.Body.String() || retry.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("expected the first response to be replayed, got %d %s", retry.Code, retry.Body.String())
+	}
+
+	if retry.Header().Get(idempotentReplayedHeader) != "true" {
+		t.Errorf("expected %s header on the replayed response", idempotentReplayedHeader)
+	}
+
+	// the key can't be used for a different request
+	if rr := request("abc123", `{"Bucket":"barbucket"}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected bad request reusing a key for a different request, got %d", rr.Code)
+	}
+
+	// a request in progress holds the key
+	inProgress := 0
+	during = func() {
+		during = nil
+		inProgress = request("inprogress", `{"Bucket":"foobucket"}`).Code
+	}
+	request("inprogress", `{"Bucket":"foobucket"}`)
+	if inProgress != http.StatusConflict {
+		t.Errorf("expected conflict for a key in progress, got %d", inProgress)
+	}
+
+	// server errors release the key so the request can be retried
+	calls = 0
+	status = http.StatusInternalServerError
+	request("def456", `{"Bucket":"foobucket"}`)
+	status = http.StatusOK
+	if rr := request("def456", `{"Bucket":"foobucket"}`); rr.Code != http.StatusOK || calls != 2 {
+		t.Errorf("expected the request to be retried after a server error, got %d with %d calls", rr.Code, calls)
+	}
+
+	if rr := request(strings.Repeat("a", 256), `{}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected bad request for a long key, got %d", rr.Code)
+	}
+}
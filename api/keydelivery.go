@@ -0,0 +1,205 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/rollback"
+	smapi "github.com/YaleSpinup/s3-api/secretsmanager"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+const (
+	// keyDeliveryResponse returns the secret access key in the response body, it's the default
+	keyDeliveryResponse = "response"
+	// keyDeliveryEncrypted returns the secret access key encrypted to the caller's rsa public key
+	keyDeliveryEncrypted = "encrypted"
+	// keyDeliverySecretsManager stores the access key in secrets manager in the account and returns the secret's arn
+	keyDeliverySecretsManager = "secretsmanager"
+
+	// defaultCredentialSecretPrefix is prepended to the names of the secrets access keys are delivered to
+	defaultCredentialSecretPrefix = "spinup/s3-api/"
+	// minDeliveryKeyBits is the smallest rsa public key a secret access key can be encrypted to
+	minDeliveryKeyBits = 2048
+)
+
+// keyDeliveryMethods are the ways a new access key can be delivered
+var keyDeliveryMethods = []string{keyDeliveryResponse, keyDeliveryEncrypted, keyDeliverySecretsManager}
+
+// keyDelivery is how the secret of a new access key is delivered to the caller
+type keyDelivery struct {
+	// Method is one of response (default), encrypted or secretsmanager
+	Method string
+	// PublicKey is the PEM encoded rsa public key the secret is encrypted to with the encrypted method
+	PublicKey string `json:",omitempty"`
+}
+
+// accessKeyDelivery is where the secret of a new access key was delivered, if it wasn't returned in the response.
+// EncryptedSecretAccessKey is the base64 encoded RSA-OAEP (SHA-256) ciphertext of the secret access key and SecretArn
+// is the arn of the secret holding the access key.
+type accessKeyDelivery struct {
+	Method                   string
+	EncryptedSecretAccessKey string `json:",omitempty"`
+	SecretArn                string `json:",omitempty"`
+}
+
+// credentialSecret is the value of the secret an access key is delivered to
+type credentialSecret struct {
+	AccessKeyId     string
+	SecretAccessKey string
+}
+
+// keyDeliverer delivers new access keys with the requested method
+type keyDeliverer struct {
+	method         string
+	publicKey      *rsa.PublicKey
+	secretsManager *smapi.SecretsManager
+	prefix         string
+	kmsKeyId       string
+}
+
+// parseDeliveryPublicKey parses a PEM encoded rsa public key, in either PKIX or PKCS #1 form
+func parseDeliveryPublicKey(data string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, fmt.Errorf("public key must be PEM encoded")
+	}
+
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %s, must be PUBLIC KEY or RSA PUBLIC KEY", block.Type)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %s", err)
+	}
+
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key must be an rsa key")
+	}
+
+	if rsaKey.N.BitLen() < minDeliveryKeyBits {
+		return nil, fmt.Errorf("rsa public key must be at least %d bits, got %d", minDeliveryKeyBits, rsaKey.N.BitLen())
+	}
+
+	return rsaKey, nil
+}
+
+// keyDeliveryActions returns the actions the session needs to deliver access keys with the method
+func (s *server) keyDeliveryActions(delivery *keyDelivery) []string {
+	if delivery == nil || delivery.Method != keyDeliverySecretsManager {
+		return nil
+	}
+
+	actions := []string{"secretsmanager:CreateSecret", "secretsmanager:DeleteSecret", "secretsmanager:TagResource"}
+	if s.account.CredentialSecrets != nil && s.account.CredentialSecrets.KmsKeyId != "" {
+		actions = append(actions, "kms:GenerateDataKey", "kms:Decrypt")
+	}
+
+	return actions
+}
+
+// withKeyDelivery adds a statement allowing the actions to deliver access keys with the method to a scoped policy,
+// the secrets and kms key aren't named for the bucket so they can't be scoped
+func (s *server) withKeyDelivery(policy scopedPolicy, delivery *keyDelivery) scopedPolicy {
+	actions := s.keyDeliveryActions(delivery)
+	if len(actions) == 0 {
+		return policy
+	}
+
+	return append(append(scopedPolicy{}, policy...), scopedStatement{Actions: actions, Resources: []string{"*"}})
+}
+
+// newKeyDeliverer creates the deliverer for a validated delivery, a nil delivery returns the keys in the response
+func (s *server) newKeyDeliverer(sess *session.Session, delivery *keyDelivery) (*keyDeliverer, error) {
+	d := &keyDeliverer{method: keyDeliveryResponse}
+	if delivery == nil {
+		return d, nil
+	}
+
+	switch delivery.Method {
+	case keyDeliveryEncrypted:
+		key, err := parseDeliveryPublicKey(delivery.PublicKey)
+		if err != nil {
+			return nil, apierror.New(apierror.ErrBadRequest, err.Error(), nil)
+		}
+		d.method, d.publicKey = keyDeliveryEncrypted, key
+	case keyDeliverySecretsManager:
+		config := s.account.CredentialSecrets
+		if config == nil {
+			return nil, apierror.New(apierror.ErrBadRequest, "delivering access keys to secrets manager is not configured", nil)
+		}
+
+		smService := smapi.NewSession(sess, s.account)
+		d.method, d.secretsManager, d.prefix, d.kmsKeyId = keyDeliverySecretsManager, &smService, config.Prefix, config.KmsKeyId
+		if d.prefix == "" {
+			d.prefix = defaultCredentialSecretPrefix
+		}
+	}
+
+	return d, nil
+}
+
+// deliver delivers the secret of a new access key and removes it from the key, unless it's returned in the response.
+// A secret created in secrets manager is added to the rollback.
+func (d *keyDeliverer) deliver(ctx context.Context, rb *rollback.Rollback, key *iam.AccessKey, tags []*iam.Tag) (*accessKeyDelivery, error) {
+	switch d.method {
+	case keyDeliveryEncrypted:
+		ciphertext, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, d.publicKey, []byte(aws.StringValue(key.SecretAccessKey)), nil)
+		if err != nil {
+			return nil, apierror.New(apierror.ErrInternalError, "failed to encrypt secret access key", err)
+		}
+		key.SecretAccessKey = nil
+
+		return &accessKeyDelivery{
+			Method:                   keyDeliveryEncrypted,
+			EncryptedSecretAccessKey: base64.StdEncoding.EncodeToString(ciphertext),
+		}, nil
+	case keyDeliverySecretsManager:
+		userName, keyId := aws.StringValue(key.UserName), aws.StringValue(key.AccessKeyId)
+
+		value, err := json.Marshal(credentialSecret{AccessKeyId: keyId, SecretAccessKey: aws.StringValue(key.SecretAccessKey)})
+		if err != nil {
+			return nil, apierror.New(apierror.ErrInternalError, "failed to marshal access key secret", err)
+		}
+
+		secretTags := map[string]string{}
+		for _, t := range tags {
+			secretTags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+		}
+
+		name := d.prefix + userName + "/" + keyId
+		arn, err := d.secretsManager.CreateSecret(ctx, name, "access key for user "+userName, string(value), d.kmsKeyId, secretTags)
+		if err != nil {
+			return nil, err
+		}
+		key.SecretAccessKey = nil
+
+		// append secret delete to rollback
+		rb.Add("delete secret "+name, rollbackDeleteSecret, map[string]string{"secret": name})
+
+		return &accessKeyDelivery{
+			Method:    keyDeliverySecretsManager,
+			SecretArn: arn,
+		}, nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/YaleSpinup/s3-api/rollback"
+	smapi "github.com/YaleSpinup/s3-api/secretsmanager"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+)
+
+// mockSecretsManager is a fake secrets manager client that keeps the secrets it creates
+type mockSecretsManager struct {
+	secretsmanageriface.SecretsManagerAPI
+	secrets map[string]*secretsmanager.CreateSecretInput
+}
+
+func (m *mockSecretsManager) CreateSecretWithContext(ctx aws.Context, input *secretsmanager.CreateSecretInput, opts ...request.Option) (*secretsmanager.CreateSecretOutput, error) {
+	m.secrets[aws.StringValue(input.Name)] = input
+	return &secretsmanager.CreateSecretOutput{ARN: aws.String("arn:aws:secretsmanager:us-east-1:012345678910:secret:" + aws.StringValue(input.Name))}, nil
+}
+
+func testDeliveryKey(t *testing.T, bits int) (*rsa.PrivateKey, string) {
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatalf("failed to generate rsa key: %s", err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %s", err)
+	}
+
+	return key, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func testAccessKey() *iam.AccessKey {
+	return &iam.AccessKey{
+		UserName:        aws.String("someuser"),
+		AccessKeyId:     aws.String("AKIA0123456789"),
+		SecretAccessKey: aws.String("sssshimsupersekret"),
+		Status:          aws.String("Active"),
+	}
+}
+
+func TestKeyDeliveryValidation(t *testing.T) {
+	privateKey, publicKey := testDeliveryKey(t, 2048)
+	_, weakKey := testDeliveryKey(t, 1024)
+	pkcs1 := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&privateKey.PublicKey)}))
+
+	config := &common.CredentialSecrets{}
+
+	tests := []struct {
+		name     string
+		delivery *keyDelivery
+		config   *common.CredentialSecrets
+		valid    bool
+	}{
+		{"no delivery", nil, nil, true},
+		{"default method", &keyDelivery{}, nil, true},
+		{"response", &keyDelivery{Method: keyDeliveryResponse}, nil, true},
+		{"encrypted", &keyDelivery{Method: keyDeliveryEncrypted, PublicKey: publicKey}, nil, true},
+		{"encrypted pkcs1", &keyDelivery{Method: keyDeliveryEncrypted, PublicKey: pkcs1}, nil, true},
+		{"encrypted without a key", &keyDelivery{Method: keyDeliveryEncrypted}, nil, false},
+		{"encrypted to a weak key", &keyDelivery{Method: keyDeliveryEncrypted, PublicKey: weakKey}, nil, false},
+		{"public key with response", &keyDelivery{Method: keyDeliveryResponse, PublicKey: publicKey}, nil, false},
+		{"secretsmanager", &keyDelivery{Method: keyDeliverySecretsManager}, config, true},
+		{"secretsmanager not configured", &keyDelivery{Method: keyDeliverySecretsManager}, nil, false},
+		{"unsupported method", &keyDelivery{Method: "email"}, config, false},
+	}
+
+	for _, test := range tests {
+		f := fieldErrors{}
+		f.keyDelivery("Delivery", test.delivery, test.config)
+		if err := f.err(); (err == nil) != test.valid {
+			t.Errorf("%s: expected valid %t, got %v", test.name, test.valid, err)
+		}
+	}
+}
+
+func TestKeyDelivererDeliver(t *testing.T) {
+	privateKey, publicKey := testDeliveryKey(t, 2048)
+
+	s := &server{}
+
+	// the response method leaves the secret in the key
+	d, err := s.newKeyDeliverer(nil, nil)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	key := testAccessKey()
+	if delivery, err := d.deliver(context.TODO(), nil, key, nil); err != nil || delivery != nil {
+		t.Errorf("expected no delivery, got %+v, %v", delivery, err)
+	}
+
+	if aws.StringValue(key.SecretAccessKey) != "sssshimsupersekret" {
+		t.Errorf("expected the secret in the key, got %s", aws.StringValue(key.SecretAccessKey))
+	}
+
+	// the encrypted method returns the secret encrypted to the public key
+	d, err = s.newKeyDeliverer(nil, &keyDelivery{Method: keyDeliveryEncrypted, PublicKey: publicKey})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	key = testAccessKey()
+	delivery, err := d.deliver(context.TODO(), nil, key, nil)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if key.SecretAccessKey != nil {
+		t.Errorf("expected the secret to be removed from the key, got %s", aws.StringValue(key.SecretAccessKey))
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(delivery.EncryptedSecretAccessKey)
+	if err != nil {
+		t.Fatalf("failed to decode encrypted secret: %s", err)
+	}
+
+	plaintext, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, privateKey, ciphertext, nil)
+	if err != nil {
+		t.Fatalf("failed to decrypt secret: %s", err)
+	}
+
+	if string(plaintext) != "sssshimsupersekret" {
+		t.Errorf("expected decrypted secret sssshimsupersekret, got %s", plaintext)
+	}
+
+	// the secretsmanager method stores the key in a secret and rolls it back
+	client := &mockSecretsManager{secrets: map[string]*secretsmanager.CreateSecretInput{}}
+	d = &keyDeliverer{
+		method:         keyDeliverySecretsManager,
+		secretsManager: &smapi.SecretsManager{Service: client},
+		prefix:         defaultCredentialSecretPrefix,
+		kmsKeyId:       "alias/spinup",
+	}
+
+	rb := rollback.New("user.update_key", "spinup", "someuser", nil)
+	key = testAccessKey()
+	delivery, err = d.deliver(context.TODO(), rb, key, []*iam.Tag{{Key: aws.String("spinup:org"), Value: aws.String("test")}})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	name := "spinup/s3-api/someuser/AKIA0123456789"
+	if delivery.Method != keyDeliverySecretsManager || delivery.SecretArn != "arn:aws:secretsmanager:us-east-1:012345678910:secret:"+name {
+		t.Errorf("unexpected delivery %+v", delivery)
+	}
+
+	if key.SecretAccessKey != nil {
+		t.Errorf("expected the secret to be removed from the key, got %s", aws.StringValue(key.SecretAccessKey))
+	}
+
+	input, ok := client.secrets[name]
+	if !ok {
+		t.Fatalf("expected secret %s to be created, got %v", name, client.secrets)
+	}
+
+	secret := credentialSecret{}
+	if err := json.Unmarshal([]byte(aws.StringValue(input.SecretString)), &secret); err != nil {
+		t.Fatalf("failed to unmarshal secret: %s", err)
+	}
+
+	if secret.AccessKeyId != "AKIA0123456789" || secret.SecretAccessKey != "sssshimsupersekret" {
+		t.Errorf("unexpected secret %+v", secret)
+	}
+
+	if aws.StringValue(input.KmsKeyId) != "alias/spinup" || len(input.Tags) != 1 {
+		t.Errorf("expected the secret encrypted with alias/spinup and tagged, got %s", input)
+	}
+
+	if rb.Len() != 1 {
+		t.Errorf("expected the secret delete in the rollback, got %d steps", rb.Len())
+	}
+}
+
+func TestWithKeyDelivery(t *testing.T) {
+	s := server{}
+	if out := s.withKeyDelivery(userKeyPolicy, &keyDelivery{Method: keyDeliveryEncrypted}); !reflect.DeepEqual(userKeyPolicy, out) {
+		t.Error("expected the policy to be unchanged for a key that isn't delivered with secrets manager")
+	}
+
+	s.account.CredentialSecrets = &common.CredentialSecrets{KmsKeyId: "alias/credentials"}
+	out := s.withKeyDelivery(userKeyPolicy, &keyDelivery{Method: keyDeliverySecretsManager})
+	if len(out) != len(userKeyPolicy)+1 || len(userKeyPolicy) != 1 {
+		t.Fatalf("expected a statement to be added to a copy of the policy, got %+v", out)
+	}
+
+	expected := scopedStatement{
+		Actions:   []string{"secretsmanager:CreateSecret", "secretsmanager:DeleteSecret", "secretsmanager:TagResource", "kms:GenerateDataKey", "kms:Decrypt"},
+		Resources: []string{"*"},
+	}
+	if !reflect.DeepEqual(expected, out[len(out)-1]) {
+		t.Errorf("expected statement %+v, got %+v", expected, out[len(out)-1])
+	}
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/YaleSpinup/s3-api/lock"
+	"github.com/YaleSpinup/s3-api/storage"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// newLocker creates the locker that serializes the creates and deletes of the same bucket or website.  The locks are
+// kept in the state store, or in memory if there isn't one, unless a dynamodb table is configured for them.
+func newLocker(config *common.Locks, account common.Account, store storage.Store) (*lock.Locker, error) {
+	var backend lock.Backend = lock.NewMemoryBackend()
+	if store != nil {
+		backend = lock.NewStoreBackend(store)
+	}
+
+	if config == nil {
+		return lock.New(backend), nil
+	}
+
+	opts := []lock.LockerOption{}
+	if config.TTL != "" {
+		ttl, err := time.ParseDuration(config.TTL)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse lock ttl %s", config.TTL)
+		}
+		opts = append(opts, lock.WithTTL(ttl))
+	}
+
+	if config.Wait != "" {
+		wait, err := time.ParseDuration(config.Wait)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse lock wait %s", config.Wait)
+		}
+		opts = append(opts, lock.WithWait(wait))
+	}
+
+	if config.Table != "" {
+		log.Infof("sharing locks in dynamodb table %s", config.Table)
+		backend = lock.NewDynamoDBBackend(nil, account, config.Table)
+	}
+
+	return lock.New(backend, opts...), nil
+}
+
+// lockResource acquires the lock on a bucket or website name in an account, waiting for another create or delete of
+// the same name to finish.  The returned lease must be released when the operation is done.
+func (s *server) lockResource(ctx context.Context, account, name string) (*lock.Lease, error) {
+	return s.locker.Acquire(ctx, s.mapAccountNumber(account)+"/"+name)
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/YaleSpinup/s3-api/lock"
+	"github.com/YaleSpinup/s3-api/storage"
+)
+
+func TestNewLocker(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *common.Locks
+		store  storage.Store
+		valid  bool
+	}{
+		{"default", nil, nil, true},
+		{"memory", &common.Locks{TTL: "10m", Wait: "1m"}, nil, true},
+		{"dynamodb", &common.Locks{Table: "s3-api-locks"}, nil, true},
+		{"state store", &common.Locks{TTL: "10m"}, storage.NewMemoryStore(), true},
+		{"invalid ttl", &common.Locks{TTL: "ten minutes"}, nil, false},
+		{"invalid wait", &common.Locks{Wait: "1 minute"}, nil, false},
+	}
+
+	for _, test := range tests {
+		l, err := newLocker(test.config, common.Account{}, test.store)
+		if (err == nil) != test.valid {
+			t.Errorf("%s: expected valid %t, got %v", test.name, test.valid, err)
+		}
+
+		if test.valid && l == nil {
+			t.Errorf("%s: expected a locker, got nil", test.name)
+		}
+	}
+}
+
+func TestLockResource(t *testing.T) {
+	s := server{
+		accountsMap: map[string]string{"spinup": "012345678910"},
+		locker:      lock.New(lock.NewMemoryBackend(), lock.WithWait(0)),
+	}
+
+	lease, err := s.lockResource(context.TODO(), "spinup", "foobucket")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if lease.Name != "012345678910/foobucket" {
+		t.Errorf("expected lock on 012345678910/foobucket, got %s", lease.Name)
+	}
+
+	// the account name and number lock the same resource
+	_, err = s.lockResource(context.TODO(), "012345678910", "foobucket")
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrConflict {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+
+	lease.Release()
+
+	// a server without a locker doesn't lock
+	s.locker = nil
+	if lease, err := s.lockResource(context.TODO(), "spinup", "foobucket"); lease != nil || err != nil {
+		t.Errorf("expected nil lease and error without a locker, got %v, %v", lease, err)
+	}
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/storage"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// maintenanceTable is the state store table the maintenance mode is kept in, so it's shared by the instances
+	maintenanceTable = "maintenance"
+	maintenanceKey   = "mode"
+	// maintenanceRefresh is how long an instance uses the maintenance mode before reading it from the store again
+	maintenanceRefresh = 5 * time.Second
+	// defaultMaintenanceMessage is returned with the mutating requests when maintenance mode is enabled without a message
+	defaultMaintenanceMessage = "the api is in read-only maintenance mode, try again later"
+	// adminPathPrefix is the path of the admin endpoints, they're available in maintenance mode
+	adminPathPrefix = "/v1/s3/admin/"
+)
+
+// maintenanceStatus is the state of the read-only maintenance mode
+type maintenanceStatus struct {
+	Enabled bool
+	Message string     `json:",omitempty"`
+	Since   *time.Time `json:",omitempty"`
+}
+
+// maintenanceMode is the read-only maintenance mode switch.  The mode is kept in the state store and each instance
+// reads it at most every maintenanceRefresh, so switching it applies to all of the instances sharing the store.
+type maintenanceMode struct {
+	store   storage.Store
+	refresh time.Duration
+	status  maintenanceStatus
+	checked time.Time
+	mu      sync.Mutex
+}
+
+// newMaintenanceMode creates the maintenance mode switch, the mode is enabled in the store if enabled is true
+func newMaintenanceMode(ctx context.Context, store storage.Store, enabled bool) (*maintenanceMode, error) {
+	m := &maintenanceMode{store: store, refresh: maintenanceRefresh}
+	if enabled {
+		if _, err := m.enable(ctx, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// get returns the maintenance mode, read from the store if it hasn't been for maintenanceRefresh.  The last known
+// mode is kept if it can't be read.
+func (m *maintenanceMode) get(ctx context.Context) maintenanceStatus {
+	if m == nil {
+		return maintenanceStatus{}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.checked.IsZero() && time.Since(m.checked) < m.refresh {
+		return m.status
+	}
+
+	value, err := m.store.Get(ctx, maintenanceTable, maintenanceKey)
+	if err != nil {
+		if !isNotFound(err) {
+			log.Errorf("failed to get the maintenance mode, keeping %+v: %s", m.status, err)
+			return m.status
+		}
+		value = []byte("{}")
+	}
+
+	status := maintenanceStatus{}
+	if err := json.Unmarshal(value, &status); err != nil {
+		log.Errorf("failed to unmarshal the maintenance mode, keeping %+v: %s", m.status, err)
+		return m.status
+	}
+
+	m.status, m.checked = status, time.Now()
+
+	return m.status
+}
+
+// enable enables the read-only maintenance mode with the message returned to the mutating requests
+func (m *maintenanceMode) enable(ctx context.Context, message string) (maintenanceStatus, error) {
+	if message == "" {
+		message = defaultMaintenanceMessage
+	}
+
+	since := time.Now().UTC()
+	return m.set(ctx, maintenanceStatus{Enabled: true, Message: message, Since: &since})
+}
+
+// disable disables the read-only maintenance mode
+func (m *maintenanceMode) disable(ctx context.Context) (maintenanceStatus, error) {
+	return m.set(ctx, maintenanceStatus{})
+}
+
+// set keeps the maintenance mode in the store
+func (m *maintenanceMode) set(ctx context.Context, status maintenanceStatus) (maintenanceStatus, error) {
+	value, err := json.Marshal(status)
+	if err != nil {
+		return maintenanceStatus{}, apierror.New(apierror.ErrInternalError, "failed to marshal the maintenance mode", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.store.Put(ctx, maintenanceTable, maintenanceKey, value, time.Time{}); err != nil {
+		return maintenanceStatus{}, err
+	}
+	m.status, m.checked = status, time.Now()
+
+	return status, nil
+}
+
+// middleware rejects the mutating requests with 503 Service Unavailable while maintenance mode is enabled.  Reads and
+// the admin endpoints, so maintenance mode can be disabled, are always passed through.
+func (m *maintenanceMode) middleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		if strings.HasPrefix(r.URL.Path, adminPathPrefix) {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		if status := m.get(r.Context()); status.Enabled {
+			handleError(w, apierror.New(apierror.ErrServiceUnavailable, status.Message, nil))
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// inMaintenance returns true if the read-only maintenance mode is enabled, the periodic tasks that change resources
+// skip their runs while it is
+func (s *server) inMaintenance(ctx context.Context) bool {
+	return s.maintenance.get(ctx).Enabled
+}
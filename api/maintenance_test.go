@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/YaleSpinup/s3-api/storage"
+	"github.com/gorilla/mux"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestMaintenanceMode(t *testing.T) {
+	adminToken := []byte("adminsekret")
+	header, _ := bcrypt.GenerateFromPassword(adminToken, bcrypt.MinCost)
+
+	m, err := newMaintenanceMode(context.TODO(), storage.NewMemoryStore(), false)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	s := server{
+		router:      mux.NewRouter(),
+		adminToken:  adminToken,
+		maintenance: m,
+	}
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	s.router.HandleFunc("/v1/s3/{account}/buckets", ok).Methods(http.MethodGet, http.MethodPost)
+	s.router.HandleFunc("/v2/s3/{account}/buckets/{bucket}", ok).Methods(http.MethodDelete)
+	s.router.HandleFunc("/v1/s3/admin/maintenance", s.MaintenanceShowHandler).Methods(http.MethodGet)
+	s.router.HandleFunc("/v1/s3/admin/maintenance", s.MaintenanceEnableHandler).Methods(http.MethodPut)
+	s.router.HandleFunc("/v1/s3/admin/maintenance", s.MaintenanceDisableHandler).Methods(http.MethodDelete)
+	s.router.Use(s.maintenance.middleware)
+
+	serve := func(method, path, body string, admin bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if admin {
+			req.Header.Set(adminTokenHeader, string(header))
+		}
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := serve(http.MethodPost, "/v1/s3/spinup/buckets", "", false); w.Code != http.StatusOK {
+		t.Errorf("expected 200 for a write outside of maintenance mode, got %d", w.Code)
+	}
+
+	// maintenance mode can only be switched by an admin
+	if w := serve(http.MethodPut, "/v1/s3/admin/maintenance", "", false); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 without the admin token, got %d", w.Code)
+	}
+
+	w := serve(http.MethodPut, "/v1/s3/admin/maintenance", `{"Message":"migrating the state store"}`, true)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 enabling maintenance mode, got %d", w.Code)
+	}
+
+	var status maintenanceStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+		t.Fatalf("failed to unmarshal the maintenance mode: %s", err)
+	}
+
+	if !status.Enabled || status.Message != "migrating the state store" || status.Since == nil {
+		t.Errorf("expected maintenance mode with the message, got %+v", status)
+	}
+
+	// writes are rejected with the message and reads keep working
+	w = serve(http.MethodPost, "/v1/s3/spinup/buckets", "", false)
+	if w.Code != http.StatusServiceUnavailable || w.Body.String() != "migrating the state store" {
+		t.Errorf("expected 503 with the message for a write, got %d %s", w.Code, w.Body.String())
+	}
+
+	if w := serve(http.MethodDelete, "/v2/s3/spinup/buckets/foo", "", false); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 for a v2 write, got %d", w.Code)
+	}
+
+	if w := serve(http.MethodGet, "/v1/s3/spinup/buckets", "", false); w.Code != http.StatusOK {
+		t.Errorf("expected 200 for a read in maintenance mode, got %d", w.Code)
+	}
+
+	if !s.inMaintenance(context.TODO()) {
+		t.Error("expected the server to be in maintenance mode")
+	}
+
+	// another instance sharing the store sees the mode once it refreshes
+	other := &maintenanceMode{store: m.store}
+	if !other.get(context.TODO()).Enabled {
+		t.Error("expected maintenance mode to be shared through the store")
+	}
+
+	if w := serve(http.MethodDelete, "/v1/s3/admin/maintenance", "", true); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 disabling maintenance mode, got %d", w.Code)
+	}
+
+	if w := serve(http.MethodPost, "/v1/s3/spinup/buckets", "", false); w.Code != http.StatusOK {
+		t.Errorf("expected 200 for a write after maintenance mode is disabled, got %d", w.Code)
+	}
+
+	// the default message is used without one and the configuration can start in maintenance mode
+	started, err := newMaintenanceMode(context.TODO(), storage.NewMemoryStore(), true)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if status := started.get(context.TODO()); !status.Enabled || status.Message != defaultMaintenanceMessage {
+		t.Errorf("expected maintenance mode with the default message, got %+v", status)
+	}
+
+	// a server without maintenance mode is never in it
+	if (&server{}).inMaintenance(context.TODO()) {
+		t.Error("expected a server without maintenance mode not to be in it")
+	}
+}
@@ -1,9 +1,16 @@
 package api
 
 import (
+	"context"
 	"net/http"
 	"net/url"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -48,3 +55,98 @@ func TokenMiddleware(psk []byte, public map[string]string, h http.Handler) http.
 		h.ServeHTTP(w, r)
 	})
 }
+
+// requestLog is the route details logged for a request, they're filled in once the router matches the request
+type requestLog struct {
+	handler  string
+	account  string
+	resource string
+}
+
+type requestLogKey struct{}
+
+// statusWriter is an http.ResponseWriter that keeps the status code of the response
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, so an http.ResponseController can reach it
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// WriteHeader keeps the status code and writes it to the response
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// RequestLogMiddleware logs each request as an entry with the standard fields: request_id, handler, account,
+// resource, method, path, status and duration in milliseconds.  The request id is taken from the X-Request-Id
+// header or generated, and it's returned in the response.  It replaces the combined access log when the logs are
+// json so they can be indexed.
+func RequestLogMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		id := r.Header.Get("X-Request-Id")
+		if id == "" {
+			id = uuid.New().String()
+		}
+		w.Header().Set("X-Request-Id", id)
+
+		details := &requestLog{}
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), requestLogKey{}, details)))
+
+		log.WithFields(log.Fields{
+			"request_id": id,
+			"handler":    details.handler,
+			"account":    details.account,
+			"resource":   details.resource,
+			"method":     r.Method,
+			"path":       r.URL.Path,
+			"status":     sw.status,
+			"duration":   float64(time.Since(start).Microseconds()) / 1000,
+		}).Info("request")
+	})
+}
+
+// routeLogMiddleware adds the handler, account and resource of the matched route to the request log
+func routeLogMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if details, ok := r.Context().Value(requestLogKey{}).(*requestLog); ok {
+			vars := mux.Vars(r)
+			details.account = vars["account"]
+			for _, k := range []string{"bucket", "website"} {
+				if v, ok := vars[k]; ok {
+					details.resource = v
+					break
+				}
+			}
+
+			if route := mux.CurrentRoute(r); route != nil {
+				details.handler = handlerName(route.GetHandler())
+			}
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// handlerName returns the name of the function or method handling a route, ie. BucketShowHandler
+func handlerName(h http.Handler) string {
+	f, ok := h.(http.HandlerFunc)
+	if !ok {
+		return reflect.TypeOf(h).String()
+	}
+
+	// methods are named like github.com/YaleSpinup/s3-api/api.(*server).BucketShowHandler-fm
+	name := strings.TrimSuffix(runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name(), "-fm")
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+
+	return name
+}
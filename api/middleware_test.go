@@ -1,11 +1,16 @@
 package api
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
 
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -91,3 +96,63 @@ func TestTokenMiddleware(t *testing.T) {
 		}
 	}
 }
+
+func TestRequestLogMiddleware(t *testing.T) {
+	out := &bytes.Buffer{}
+	log.SetOutput(out)
+	log.SetFormatter(&log.JSONFormatter{})
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFormatter(&log.TextFormatter{})
+	}()
+
+	s := server{router: mux.NewRouter()}
+	s.router.HandleFunc("/v1/s3/{account}/buckets/{bucket}", s.requestLogTestHandler).Methods(http.MethodHead)
+	s.router.Use(routeLogMiddleware)
+
+	req := httptest.NewRequest(http.MethodHead, "/v1/s3/spinup/buckets/foobucket", nil)
+	req.Header.Set("X-Request-Id", "abc-123")
+	w := httptest.NewRecorder()
+
+	RequestLogMiddleware(s.router).ServeHTTP(w, req)
+
+	if id := w.Header().Get("X-Request-Id"); id != "abc-123" {
+		t.Errorf("expected request id abc-123 in the response, got %s", id)
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("expected a json log entry, got %s", out.String())
+	}
+
+	expected := map[string]interface{}{
+		"request_id": "abc-123",
+		"handler":    "requestLogTestHandler",
+		"account":    "spinup",
+		"resource":   "foobucket",
+		"method":     "HEAD",
+		"status":     float64(http.StatusTeapot),
+	}
+
+	for k, v := range expected {
+		if entry[k] != v {
+			t.Errorf("expected %s to be %v, got %v", k, v, entry[k])
+		}
+	}
+
+	if _, ok := entry["duration"].(float64); !ok {
+		t.Errorf("expected a duration, got %v", entry["duration"])
+	}
+
+	// a generated request id is returned when one isn't passed
+	w = httptest.NewRecorder()
+	RequestLogMiddleware(s.router).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/s3/missing", nil))
+	if w.Header().Get("X-Request-Id") == "" {
+		t.Error("expected a generated request id in the response")
+	}
+}
+
+// requestLogTestHandler is a server method for checking the handler name in the request log
+func (s *server) requestLogTestHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusTeapot)
+}
@@ -0,0 +1,350 @@
+package api
+
+import (
+	"time"
+
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// The models below are the stable response models for the v2 api.  They are converted from the aws sdk
+// types so the responses don't change when the sdk is upgraded.  Fields should only ever be added.
+
+// Bucket is an s3 bucket
+type Bucket struct {
+	Name      string
+	Location  string     `json:",omitempty"`
+	CreatedAt *time.Time `json:",omitempty"`
+	Tags      []Tag      `json:",omitempty"`
+	Logging   *Logging   `json:",omitempty"`
+	Empty     *bool      `json:",omitempty"`
+	Groups    []Group    `json:",omitempty"`
+	Policies  []Policy   `json:",omitempty"`
+	Usage     *Usage     `json:",omitempty"`
+	VpcOnly   *bool      `json:",omitempty"`
+	// DataProtection is true if only the break-glass role can delete the bucket and its objects
+	DataProtection *bool `json:",omitempty"`
+	// ExpiresAt is when a scratch bucket will be emptied and deleted
+	ExpiresAt *time.Time `json:",omitempty"`
+}
+
+// Usage is the number of objects in a bucket and their total size
+type Usage struct {
+	Objects   int64
+	Bytes     int64
+	Source    string
+	Timestamp *time.Time `json:",omitempty"`
+	Truncated bool
+}
+
+// Tag is a key value tag on a resource
+type Tag struct {
+	Key   string
+	Value string
+}
+
+// Logging is the access logging configuration for a bucket
+type Logging struct {
+	TargetBucket string
+	TargetPrefix string
+}
+
+// Website is an s3 bucket served by a cloudfront distribution with a dns record
+type Website struct {
+	Name         string
+	Bucket       Bucket
+	Redirect     *Redirect     `json:",omitempty"`
+	DNSRecord    *DNSRecord    `json:",omitempty"`
+	Distribution *Distribution `json:",omitempty"`
+}
+
+// Redirect is the host a redirect site redirects all requests to
+type Redirect struct {
+	HostName string
+	Protocol string `json:",omitempty"`
+}
+
+// DNSRecord is a route53 dns record
+type DNSRecord struct {
+	Name    string
+	Type    string
+	TTL     int64    `json:",omitempty"`
+	Alias   string   `json:",omitempty"`
+	Records []string `json:",omitempty"`
+}
+
+// Distribution is a cloudfront distribution
+type Distribution struct {
+	ID           string
+	ARN          string
+	DomainName   string
+	Status       string
+	Enabled      bool
+	Aliases      []string
+	LastModified *time.Time `json:",omitempty"`
+	// GeoRestriction is the countries the distribution is restricted to or from, it's not set without a restriction
+	GeoRestriction *cfapi.GeoRestriction `json:",omitempty"`
+	// Signed is true if the requests that don't match a cache behavior must be signed
+	Signed bool `json:",omitempty"`
+	// HttpVersion is the maximum http version of the distribution
+	HttpVersion string `json:",omitempty"`
+	// IsIPV6Enabled is true if the distribution serves requests over IPv6
+	IsIPV6Enabled bool `json:",omitempty"`
+}
+
+// User is an IAM user
+type User struct {
+	Name             string
+	ID               string
+	ARN              string
+	Path             string
+	CreatedAt        *time.Time  `json:",omitempty"`
+	PasswordLastUsed *time.Time  `json:",omitempty"`
+	AccessKeys       []AccessKey `json:",omitempty"`
+	Groups           []Group     `json:",omitempty"`
+	Policies         []Policy    `json:",omitempty"`
+}
+
+// AccessKey is the metadata for an IAM access key, the secret is never included
+type AccessKey struct {
+	ID        string
+	Status    string
+	CreatedAt *time.Time `json:",omitempty"`
+}
+
+// Group is an IAM group
+type Group struct {
+	Name string
+	ARN  string
+	Path string
+	// Type is the type of the bucket management group, ie. BktAdmGrp
+	Type string `json:",omitempty"`
+	// BucketPath is the path in the bucket the management group manages, / for the whole bucket
+	BucketPath string `json:",omitempty"`
+}
+
+// Policy is a managed IAM policy
+type Policy struct {
+	Name string
+	ARN  string
+}
+
+// toTags converts s3 tags to tags
+func toTags(tags []*s3.Tag) []Tag {
+	out := []Tag{}
+	for _, t := range tags {
+		if t == nil {
+			continue
+		}
+		out = append(out, Tag{Key: aws.StringValue(t.Key), Value: aws.StringValue(t.Value)})
+	}
+	return out
+}
+
+// toLogging converts an s3 logging configuration to logging, nil if logging isn't enabled
+func toLogging(logging *s3.LoggingEnabled) *Logging {
+	if logging == nil {
+		return nil
+	}
+
+	return &Logging{
+		TargetBucket: aws.StringValue(logging.TargetBucket),
+		TargetPrefix: aws.StringValue(logging.TargetPrefix),
+	}
+}
+
+// toBucket converts the output from getting a bucket to a bucket
+func toBucket(name string, b *bucketShowOutput) Bucket {
+	return Bucket{
+		Name:           name,
+		Tags:           toTags(b.Tags),
+		Logging:        toLogging(b.Logging),
+		Empty:          aws.Bool(b.Empty),
+		Usage:          toUsage(b.Usage),
+		VpcOnly:        aws.Bool(b.VpcOnly),
+		ExpiresAt:      b.ScratchExpires,
+		DataProtection: aws.Bool(b.DataProtection),
+	}
+}
+
+// toUsage converts the storage usage of a bucket to usage, nil if the usage is unknown
+func toUsage(u *bucketUsage) *Usage {
+	if u == nil {
+		return nil
+	}
+
+	return &Usage{
+		Objects:   u.Objects,
+		Bytes:     u.Bytes,
+		Source:    u.Source,
+		Timestamp: u.Timestamp,
+		Truncated: u.Truncated,
+	}
+}
+
+// toCreatedBucket converts the output from creating a bucket to a bucket
+func toCreatedBucket(name string, tags []*s3.Tag, b *bucketCreateOutput) Bucket {
+	bucket := Bucket{
+		Name:     name,
+		Location: aws.StringValue(b.Bucket),
+		Tags:     toTags(tags),
+	}
+
+	if b.Group != nil {
+		bucket.Groups = []Group{toGroup(b.Group)}
+	}
+
+	if b.Policy != nil {
+		bucket.Policies = []Policy{{Name: aws.StringValue(b.Policy.PolicyName), ARN: aws.StringValue(b.Policy.Arn)}}
+	}
+
+	return bucket
+}
+
+// toWebsite converts the output from getting a website to a website
+func toWebsite(name string, w *websiteShowOutput) Website {
+	return Website{
+		Name: name,
+		Bucket: Bucket{
+			Name:    name,
+			Tags:    toTags(w.Tags),
+			Logging: toLogging(w.Logging),
+			Empty:   aws.Bool(w.Empty),
+		},
+		Redirect:     toRedirect(w.Redirect),
+		DNSRecord:    toDNSRecord(w.DNSRecord),
+		Distribution: toDistribution(w.Distribution),
+	}
+}
+
+// toRedirect converts an s3 website redirect to a redirect
+func toRedirect(r *s3.RedirectAllRequestsTo) *Redirect {
+	if r == nil {
+		return nil
+	}
+
+	return &Redirect{
+		HostName: aws.StringValue(r.HostName),
+		Protocol: aws.StringValue(r.Protocol),
+	}
+}
+
+// toDNSRecord converts a route53 resource record set to a dns record
+func toDNSRecord(r *route53.ResourceRecordSet) *DNSRecord {
+	if r == nil {
+		return nil
+	}
+
+	record := DNSRecord{
+		Name: aws.StringValue(r.Name),
+		Type: aws.StringValue(r.Type),
+		TTL:  aws.Int64Value(r.TTL),
+	}
+
+	if r.AliasTarget != nil {
+		record.Alias = aws.StringValue(r.AliasTarget.DNSName)
+	}
+
+	for _, rr := range r.ResourceRecords {
+		record.Records = append(record.Records, aws.StringValue(rr.Value))
+	}
+
+	return &record
+}
+
+// toDistribution converts a cloudfront distribution summary to a distribution
+func toDistribution(d *cloudfront.DistributionSummary) *Distribution {
+	if d == nil {
+		return nil
+	}
+
+	dist := Distribution{
+		ID:            aws.StringValue(d.Id),
+		ARN:           aws.StringValue(d.ARN),
+		DomainName:    aws.StringValue(d.DomainName),
+		Status:        aws.StringValue(d.Status),
+		Enabled:       aws.BoolValue(d.Enabled),
+		Aliases:       []string{},
+		LastModified:  d.LastModifiedTime,
+		HttpVersion:   aws.StringValue(d.HttpVersion),
+		IsIPV6Enabled: aws.BoolValue(d.IsIPV6Enabled),
+	}
+
+	if d.Aliases != nil {
+		dist.Aliases = aws.StringValueSlice(d.Aliases.Items)
+	}
+
+	if g := cfapi.GeoRestrictionFor(d.Restrictions); g.RestrictionType != cloudfront.GeoRestrictionTypeNone && g.RestrictionType != "" {
+		dist.GeoRestriction = g
+	}
+
+	if b := d.DefaultCacheBehavior; b != nil && b.TrustedKeyGroups != nil {
+		dist.Signed = aws.BoolValue(b.TrustedKeyGroups.Enabled)
+	}
+
+	return &dist
+}
+
+// toUser converts an IAM user to a user
+func toUser(u *iam.User) User {
+	return User{
+		Name:             aws.StringValue(u.UserName),
+		ID:               aws.StringValue(u.UserId),
+		ARN:              aws.StringValue(u.Arn),
+		Path:             aws.StringValue(u.Path),
+		CreatedAt:        u.CreateDate,
+		PasswordLastUsed: u.PasswordLastUsed,
+	}
+}
+
+// toUserDetails converts the output from getting a bucket user to a user
+func toUserDetails(u *userShowOutput) User {
+	user := toUser(u.User)
+
+	user.AccessKeys = []AccessKey{}
+	for _, k := range u.AccessKeys {
+		user.AccessKeys = append(user.AccessKeys, AccessKey{
+			ID:        aws.StringValue(k.AccessKeyId),
+			Status:    aws.StringValue(k.Status),
+			CreatedAt: k.CreateDate,
+		})
+	}
+
+	user.Groups = []Group{}
+	for _, g := range u.Groups {
+		user.Groups = append(user.Groups, toBucketGroup(u.Bucket, u.PathPrefix, g))
+	}
+
+	user.Policies = []Policy{}
+	for _, p := range u.Policies {
+		user.Policies = append(user.Policies, Policy{Name: aws.StringValue(p.PolicyName), ARN: aws.StringValue(p.PolicyArn)})
+	}
+
+	return user
+}
+
+// toGroup converts an IAM group to a group
+func toGroup(g *iam.Group) Group {
+	return Group{
+		Name: aws.StringValue(g.GroupName),
+		ARN:  aws.StringValue(g.Arn),
+		Path: aws.StringValue(g.Path),
+	}
+}
+
+// toBucketGroup converts an IAM group to a group with the path and type if it's one of the bucket's management groups,
+// the IAM path of the group is under the path prefix
+func toBucketGroup(bucket, pathPrefix string, g *iam.Group) Group {
+	group := toGroup(g)
+	if path, groupType, ok := iamapi.ParseGroupName(bucket, group.Name, iamapi.TrimPathPrefix(pathPrefix, group.Path)); ok {
+		group.BucketPath = path
+		group.Type = groupType
+	}
+	return group
+}
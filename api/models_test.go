@@ -0,0 +1,205 @@
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+var testTime = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func TestToBucket(t *testing.T) {
+	expected := Bucket{
+		Name:           "testbucket",
+		Tags:           []Tag{{Key: "foo", Value: "bar"}},
+		Logging:        &Logging{TargetBucket: "logbucket", TargetPrefix: "testbucket/"},
+		Empty:          aws.Bool(true),
+		Usage:          &Usage{Source: "cloudwatch", Objects: 10, Bytes: 2048, Timestamp: &testTime},
+		VpcOnly:        aws.Bool(true),
+		ExpiresAt:      &testTime,
+		DataProtection: aws.Bool(true),
+	}
+
+	out := toBucket("testbucket", &bucketShowOutput{
+		Tags:           []*s3.Tag{{Key: aws.String("foo"), Value: aws.String("bar")}, nil},
+		Logging:        &s3.LoggingEnabled{TargetBucket: aws.String("logbucket"), TargetPrefix: aws.String("testbucket/")},
+		Empty:          true,
+		Usage:          &bucketUsage{Source: "cloudwatch", Objects: 10, Bytes: 2048, Timestamp: &testTime},
+		VpcOnly:        true,
+		DataProtection: true,
+		ScratchExpires: &testTime,
+	})
+
+	if !reflect.DeepEqual(expected, out) {
+		t.Errorf("expected %+v, got %+v", expected, out)
+	}
+
+	// a bucket without tags or logging should have an empty tag list and no logging
+	out = toBucket("testbucket", &bucketShowOutput{})
+	if out.Logging != nil || len(out.Tags) != 0 || aws.BoolValue(out.Empty) || out.Usage != nil {
+		t.Errorf("expected empty bucket details, got %+v", out)
+	}
+}
+
+func TestToCreatedBucket(t *testing.T) {
+	expected := Bucket{
+		Name:     "testbucket",
+		Location: "/testbucket",
+		Tags:     []Tag{{Key: "spinup:org", Value: "test"}},
+		Groups:   []Group{{Name: "testbucket-BktAdmGrp", ARN: "arn:aws:iam::12345678910:group/testbucket-BktAdmGrp", Path: "/"}},
+		Policies: []Policy{{Name: "testbucket-BktAdmPlc", ARN: "arn:aws:iam::12345678910:policy/testbucket-BktAdmPlc"}},
+	}
+
+	out := toCreatedBucket("testbucket", []*s3.Tag{{Key: aws.String("spinup:org"), Value: aws.String("test")}}, &bucketCreateOutput{
+		Bucket: aws.String("/testbucket"),
+		Policy: &iam.Policy{PolicyName: aws.String("testbucket-BktAdmPlc"), Arn: aws.String("arn:aws:iam::12345678910:policy/testbucket-BktAdmPlc")},
+		Group:  &iam.Group{GroupName: aws.String("testbucket-BktAdmGrp"), Arn: aws.String("arn:aws:iam::12345678910:group/testbucket-BktAdmGrp"), Path: aws.String("/")},
+	})
+
+	if !reflect.DeepEqual(expected, out) {
+		t.Errorf("expected %+v, got %+v", expected, out)
+	}
+}
+
+func TestToWebsite(t *testing.T) {
+	expected := Website{
+		Name: "www.example.com",
+		Bucket: Bucket{
+			Name:  "www.example.com",
+			Tags:  []Tag{},
+			Empty: aws.Bool(false),
+		},
+		DNSRecord: &DNSRecord{
+			Name:  "www.example.com.",
+			Type:  "A",
+			Alias: "d111111abcdef8.cloudfront.net.",
+		},
+		Distribution: &Distribution{
+			ID:           "EDFDVBD6EXAMPLE",
+			ARN:          "arn:aws:cloudfront::12345678910:distribution/EDFDVBD6EXAMPLE",
+			DomainName:   "d111111abcdef8.cloudfront.net",
+			Status:       "Deployed",
+			Enabled:      true,
+			Aliases:      []string{"www.example.com"},
+			LastModified: &testTime,
+		},
+	}
+
+	out := toWebsite("www.example.com", &websiteShowOutput{
+		DNSRecord: &route53.ResourceRecordSet{
+			Name:        aws.String("www.example.com."),
+			Type:        aws.String("A"),
+			AliasTarget: &route53.AliasTarget{DNSName: aws.String("d111111abcdef8.cloudfront.net.")},
+		},
+		Distribution: &cloudfront.DistributionSummary{
+			Id:               aws.String("EDFDVBD6EXAMPLE"),
+			ARN:              aws.String("arn:aws:cloudfront::12345678910:distribution/EDFDVBD6EXAMPLE"),
+			DomainName:       aws.String("d111111abcdef8.cloudfront.net"),
+			Status:           aws.String("Deployed"),
+			Enabled:          aws.Bool(true),
+			Aliases:          &cloudfront.Aliases{Items: aws.StringSlice([]string{"www.example.com"})},
+			LastModifiedTime: &testTime,
+		},
+	})
+
+	if !reflect.DeepEqual(expected, out) {
+		t.Errorf("expected %+v, got %+v", expected, out)
+	}
+
+	restricted := toDistribution(&cloudfront.DistributionSummary{
+		Restrictions: &cloudfront.Restrictions{
+			GeoRestriction: &cloudfront.GeoRestriction{
+				Items:           aws.StringSlice([]string{"US"}),
+				Quantity:        aws.Int64(1),
+				RestrictionType: aws.String("whitelist"),
+			},
+		},
+	})
+	if !reflect.DeepEqual(&cfapi.GeoRestriction{RestrictionType: "whitelist", Locations: []string{"US"}}, restricted.GeoRestriction) {
+		t.Errorf("expected us only geo restriction, got %+v", restricted.GeoRestriction)
+	}
+
+	signed := toDistribution(&cloudfront.DistributionSummary{
+		DefaultCacheBehavior: &cloudfront.DefaultCacheBehavior{
+			TrustedKeyGroups: &cloudfront.TrustedKeyGroups{Enabled: aws.Bool(true), Items: aws.StringSlice([]string{"group1"}), Quantity: aws.Int64(1)},
+		},
+	})
+	if !signed.Signed || restricted.Signed {
+		t.Errorf("expected only the distribution with trusted key groups to be signed, got %+v and %+v", signed, restricted)
+	}
+
+	protocol := toDistribution(&cloudfront.DistributionSummary{HttpVersion: aws.String("http2and3"), IsIPV6Enabled: aws.Bool(true)})
+	if protocol.HttpVersion != "http2and3" || !protocol.IsIPV6Enabled {
+		t.Errorf("expected http2and3 with ipv6, got %+v", protocol)
+	}
+
+	if toDNSRecord(nil) != nil || toDistribution(nil) != nil || toRedirect(nil) != nil {
+		t.Error("expected nil dns record, distribution and redirect for nil input")
+	}
+
+	redirect := toRedirect(&s3.RedirectAllRequestsTo{HostName: aws.String("new.example.edu"), Protocol: aws.String("https")})
+	if !reflect.DeepEqual(&Redirect{HostName: "new.example.edu", Protocol: "https"}, redirect) {
+		t.Errorf("expected redirect to https new.example.edu, got %+v", redirect)
+	}
+}
+
+func TestToUserDetails(t *testing.T) {
+	expected := User{
+		Name:       "testuser",
+		ID:         "AIDAEXAMPLE",
+		ARN:        "arn:aws:iam::12345678910:user/testuser",
+		Path:       "/",
+		CreatedAt:  &testTime,
+		AccessKeys: []AccessKey{{ID: "AKIAEXAMPLE", Status: "Active", CreatedAt: &testTime}},
+		Groups:     []Group{{Name: "testbucket-BktAdmGrp", ARN: "arn:aws:iam::12345678910:group/testbucket-BktAdmGrp", Path: "/"}},
+		Policies:   []Policy{},
+	}
+
+	out := toUserDetails(&userShowOutput{
+		User: &iam.User{
+			UserName:   aws.String("testuser"),
+			UserId:     aws.String("AIDAEXAMPLE"),
+			Arn:        aws.String("arn:aws:iam::12345678910:user/testuser"),
+			Path:       aws.String("/"),
+			CreateDate: &testTime,
+		},
+		AccessKeys: []*iam.AccessKeyMetadata{{AccessKeyId: aws.String("AKIAEXAMPLE"), Status: aws.String("Active"), CreateDate: &testTime}},
+		Groups:     []*iam.Group{{GroupName: aws.String("testbucket-BktAdmGrp"), Arn: aws.String("arn:aws:iam::12345678910:group/testbucket-BktAdmGrp"), Path: aws.String("/")}},
+	})
+
+	if !reflect.DeepEqual(expected, out) {
+		t.Errorf("expected %+v, got %+v", expected, out)
+	}
+
+	// the groups of a bucket user have the path and type they manage in the bucket
+	groups := toUserDetails(&userShowOutput{
+		Bucket: "testbucket",
+		User:   &iam.User{UserName: aws.String("testuser")},
+		Groups: []*iam.Group{
+			{GroupName: aws.String("testbucket-docs-BktRWGrp"), Path: aws.String("/docs/")},
+			{GroupName: aws.String("testbucket-other-BktRWGrp"), Path: aws.String("/docs/")},
+		},
+	}).Groups
+	if groups[0].BucketPath != "/docs/" || groups[0].Type != "BktRWGrp" || groups[1].BucketPath != "" || groups[1].Type != "" {
+		t.Errorf("expected only the first group to be a management group for /docs/, got %+v", groups)
+	}
+
+	// the json field names are part of the api contract
+	j, err := json.Marshal(toUser(&iam.User{UserName: aws.String("testuser")}))
+	if err != nil {
+		t.Fatalf("unexpected error marshalling user: %s", err)
+	}
+
+	if string(j) != `{"Name":"testuser","ID":"","ARN":"","Path":""}` {
+		t.Errorf("unexpected user json %s", string(j))
+	}
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/circuit"
+	"github.com/YaleSpinup/s3-api/common"
+	log "github.com/sirupsen/logrus"
+)
+
+// resourceStatus is the result for one of the resources touched by a composite operation.  Code is the http status
+// code the change to the resource would have returned on its own, and Error is why it failed.
+type resourceStatus struct {
+	Type     string
+	Resource string
+	Action   string
+	Code     int
+	Error    string `json:",omitempty"`
+}
+
+// multiStatus collects the per-resource results of a composite operation that carries on when some of the resources
+// fail, ie. a website delete.  It's embedded in the operation's response, which is returned with 207 Multi-Status if
+// any of the resources failed and 200 OK otherwise.
+type multiStatus struct {
+	Succeeded int
+	Failed    int
+	Statuses  []resourceStatus
+}
+
+// newMultiStatus returns an empty multiStatus, so an operation that didn't touch anything has an empty list of
+// statuses instead of null
+func newMultiStatus() multiStatus {
+	return multiStatus{Statuses: []resourceStatus{}}
+}
+
+// ok records a change to a resource that succeeded
+func (m *multiStatus) ok(resourceType, resource, action string) {
+	m.Succeeded++
+	m.Statuses = append(m.Statuses, resourceStatus{
+		Type:     resourceType,
+		Resource: resource,
+		Action:   action,
+		Code:     http.StatusOK,
+	})
+}
+
+// fail records a change to a resource that failed
+func (m *multiStatus) fail(resourceType, resource, action string, err error) {
+	log.Warnf("failed to %s %s %s: %s", action, resourceType, resource, err)
+
+	m.Failed++
+	m.Statuses = append(m.Statuses, resourceStatus{
+		Type:     resourceType,
+		Resource: resource,
+		Action:   action,
+		Code:     errorStatus(err),
+		Error:    err.Error(),
+	})
+}
+
+// code returns the http status code of the composite operation
+func (m *multiStatus) code() int {
+	return multiStatusCode(m.Failed)
+}
+
+// multiStatusCode returns 207 Multi-Status if any of the resources in a composite operation failed, and 200 OK if
+// none of them did
+func multiStatusCode(failed int) int {
+	if failed > 0 {
+		return http.StatusMultiStatus
+	}
+	return http.StatusOK
+}
+
+// errorStatus returns the http status code handleError would respond with for an error
+func errorStatus(err error) int {
+	var open *circuit.OpenError
+	switch {
+	case errors.As(err, &open):
+		return http.StatusServiceUnavailable
+	case awsCallTimedOut(err):
+		return http.StatusGatewayTimeout
+	case awsCallThrottled(err):
+		return http.StatusTooManyRequests
+	}
+
+	if aerr, ok := common.AsAPIError(err); ok {
+		return apiErrorStatus(aerr.Code)
+	}
+
+	return http.StatusInternalServerError
+}
+
+// apiErrorStatus returns the http status code for an api error code
+func apiErrorStatus(code string) int {
+	switch code {
+	case apierror.ErrForbidden:
+		return http.StatusForbidden
+	case apierror.ErrNotFound:
+		return http.StatusNotFound
+	case apierror.ErrConflict:
+		return http.StatusConflict
+	case apierror.ErrBadRequest:
+		return http.StatusBadRequest
+	case apierror.ErrLimitExceeded:
+		return http.StatusTooManyRequests
+	case apierror.ErrServiceUnavailable:
+		return http.StatusServiceUnavailable
+	case errPreconditionFailed:
+		return http.StatusPreconditionFailed
+	case errPreconditionRequired:
+		return http.StatusPreconditionRequired
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+)
+
+func TestMultiStatus(t *testing.T) {
+	m := newMultiStatus()
+	if m.code() != http.StatusOK || m.Statuses == nil {
+		t.Fatalf("expected an empty multi status to be 200 OK with an empty list, got %d %v", m.code(), m.Statuses)
+	}
+
+	m.ok("group", "www.example.com-BktAdmGrp", "delete")
+	if m.code() != http.StatusOK {
+		t.Errorf("expected 200 OK when nothing failed, got %d", m.code())
+	}
+
+	m.fail("user", "www-admin", "delete", apierror.New(apierror.ErrConflict, "conflict", nil))
+	m.fail("dns", "www.example.com", "delete", errors.New("boom"))
+	if m.code() != http.StatusMultiStatus || m.Succeeded != 1 || m.Failed != 2 {
+		t.Errorf("expected 207 Multi-Status with 1 succeeded and 2 failed, got %d %+v", m.code(), m)
+	}
+
+	expected := []resourceStatus{
+		{Type: "group", Resource: "www.example.com-BktAdmGrp", Action: "delete", Code: http.StatusOK},
+		{Type: "user", Resource: "www-admin", Action: "delete", Code: http.StatusConflict, Error: "Conflict: conflict ()"},
+		{Type: "dns", Resource: "www.example.com", Action: "delete", Code: http.StatusInternalServerError, Error: "boom"},
+	}
+	if !reflect.DeepEqual(expected, m.Statuses) {
+		t.Errorf("expected statuses %+v, got %+v", expected, m.Statuses)
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected int
+	}{
+		{apierror.New(apierror.ErrNotFound, "not found", nil), http.StatusNotFound},
+		{apierror.New(apierror.ErrBadRequest, "bad request", nil), http.StatusBadRequest},
+		{apierror.New(apierror.ErrLimitExceeded, "limit exceeded", nil), http.StatusTooManyRequests},
+		{apierror.New(errPreconditionFailed, "precondition failed", nil), http.StatusPreconditionFailed},
+		{apierror.New(apierror.ErrInternalError, "internal error", nil), http.StatusInternalServerError},
+		{errors.New("boom"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		if out := errorStatus(tt.err); out != tt.expected {
+			t.Errorf("expected %d for %s, got %d", tt.expected, tt.err, out)
+		}
+	}
+}
+
+// mockWebsiteIAM is an IAM client with two groups for a website that share a user, deleting the web admin group fails.
+// The admin policy has an older version, it can't be deleted until the version is.
+type mockWebsiteIAM struct {
+	iamiface.IAMAPI
+	deletedVersions []string
+}
+
+func (m *mockWebsiteIAM) ListGroupsWithContext(ctx context.Context, input *iam.ListGroupsInput, opts ...request.Option) (*iam.ListGroupsOutput, error) {
+	return &iam.ListGroupsOutput{Groups: []*iam.Group{
+		{GroupName: aws.String("www.example.com-BktAdmGrp"), Path: aws.String("/")},
+		{GroupName: aws.String("www.example.com-WebAdmGrp"), Path: aws.String("/")},
+		{GroupName: aws.String("other.example.com-BktAdmGrp"), Path: aws.String("/")},
+	}}, nil
+}
+
+func (m *mockWebsiteIAM) ListAttachedGroupPoliciesWithContext(ctx context.Context, input *iam.ListAttachedGroupPoliciesInput, opts ...request.Option) (*iam.ListAttachedGroupPoliciesOutput, error) {
+	name := aws.StringValue(input.GroupName)
+	policy := name[:len(name)-len("Grp")] + "Plc"
+	return &iam.ListAttachedGroupPoliciesOutput{AttachedPolicies: []*iam.AttachedPolicy{
+		{PolicyName: aws.String(policy), PolicyArn: aws.String("arn:aws:iam::012345678910:policy/" + policy)},
+	}}, nil
+}
+
+func (m *mockWebsiteIAM) DetachGroupPolicyWithContext(ctx context.Context, input *iam.DetachGroupPolicyInput, opts ...request.Option) (*iam.DetachGroupPolicyOutput, error) {
+	return &iam.DetachGroupPolicyOutput{}, nil
+}
+
+func (m *mockWebsiteIAM) ListPolicyVersionsWithContext(ctx context.Context, input *iam.ListPolicyVersionsInput, opts ...request.Option) (*iam.ListPolicyVersionsOutput, error) {
+	versions := []*iam.PolicyVersion{{VersionId: aws.String("v2"), IsDefaultVersion: aws.Bool(true)}}
+	if strings.HasSuffix(aws.StringValue(input.PolicyArn), "-BktAdmPlc") {
+		versions = append(versions, &iam.PolicyVersion{VersionId: aws.String("v1"), IsDefaultVersion: aws.Bool(false)})
+	}
+	return &iam.ListPolicyVersionsOutput{Versions: versions}, nil
+}
+
+func (m *mockWebsiteIAM) DeletePolicyVersionWithContext(ctx context.Context, input *iam.DeletePolicyVersionInput, opts ...request.Option) (*iam.DeletePolicyVersionOutput, error) {
+	m.deletedVersions = append(m.deletedVersions, aws.StringValue(input.PolicyArn)+":"+aws.StringValue(input.VersionId))
+	return &iam.DeletePolicyVersionOutput{}, nil
+}
+
+func (m *mockWebsiteIAM) DeletePolicyWithContext(ctx context.Context, input *iam.DeletePolicyInput, opts ...request.Option) (*iam.DeletePolicyOutput, error) {
+	if strings.HasSuffix(aws.StringValue(input.PolicyArn), "-BktAdmPlc") && len(m.deletedVersions) == 0 {
+		return nil, awserr.New(iam.ErrCodeDeleteConflictException, "delete the non-default versions first", nil)
+	}
+	return &iam.DeletePolicyOutput{}, nil
+}
+
+func (m *mockWebsiteIAM) GetGroupWithContext(ctx context.Context, input *iam.GetGroupInput, opts ...request.Option) (*iam.GetGroupOutput, error) {
+	return &iam.GetGroupOutput{Users: []*iam.User{{UserName: aws.String("www-admin")}}}, nil
+}
+
+func (m *mockWebsiteIAM) ListAccessKeysWithContext(ctx context.Context, input *iam.ListAccessKeysInput, opts ...request.Option) (*iam.ListAccessKeysOutput, error) {
+	return &iam.ListAccessKeysOutput{}, nil
+}
+
+func (m *mockWebsiteIAM) RemoveUserFromGroupWithContext(ctx context.Context, input *iam.RemoveUserFromGroupInput, opts ...request.Option) (*iam.RemoveUserFromGroupOutput, error) {
+	return &iam.RemoveUserFromGroupOutput{}, nil
+}
+
+func (m *mockWebsiteIAM) DeleteGroupWithContext(ctx context.Context, input *iam.DeleteGroupInput, opts ...request.Option) (*iam.DeleteGroupOutput, error) {
+	if aws.StringValue(input.GroupName) == "www.example.com-WebAdmGrp" {
+		return nil, awserr.New(iam.ErrCodeServiceFailureException, "boom", nil)
+	}
+	return &iam.DeleteGroupOutput{}, nil
+}
+
+func (m *mockWebsiteIAM) GetUser(input *iam.GetUserInput) (*iam.GetUserOutput, error) {
+	// the user was already deleted along with another website
+	return nil, awserr.New(iam.ErrCodeNoSuchEntityException, "not found", nil)
+}
+
+func TestDeleteWebsiteIAM(t *testing.T) {
+	client := &mockWebsiteIAM{}
+	status := newMultiStatus()
+
+	users, policies, groups := deleteWebsiteIAM(context.TODO(), iamapi.IAM{Service: client}, "www.example.com", &status)
+
+	if len(users) != 1 || aws.StringValue(users[0].UserName) != "www-admin" {
+		t.Errorf("expected the shared user once, got %+v", users)
+	}
+
+	if !reflect.DeepEqual([]string{"www.example.com-BktAdmPlc", "www.example.com-WebAdmPlc"}, aws.StringValueSlice(policies)) {
+		t.Errorf("expected the website's policies to be deleted, got %v", aws.StringValueSlice(policies))
+	}
+
+	if !reflect.DeepEqual([]string{"arn:aws:iam::012345678910:policy/www.example.com-BktAdmPlc:v1"}, client.deletedVersions) {
+		t.Errorf("expected the older version of the admin policy to be deleted, got %v", client.deletedVersions)
+	}
+
+	if !reflect.DeepEqual([]string{"www.example.com-BktAdmGrp", "www.example.com-WebAdmGrp"}, groups) {
+		t.Errorf("expected the website's groups, got %v", groups)
+	}
+
+	if status.Succeeded != 3 || status.Failed != 1 || status.code() != http.StatusMultiStatus {
+		t.Fatalf("expected 3 succeeded and 1 failed, got %+v", status)
+	}
+
+	failed := status.Statuses[len(status.Statuses)-1]
+	if failed.Type != "group" || failed.Resource != "www.example.com-WebAdmGrp" || failed.Action != "delete" || failed.Code == http.StatusOK || failed.Error == "" {
+		t.Errorf("unexpected status for the group that failed to delete %+v", failed)
+	}
+}
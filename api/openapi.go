@@ -0,0 +1,489 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/openapi"
+	"github.com/YaleSpinup/s3-api/rollback"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/YaleSpinup/s3-api/task"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// routeDocs describes the request and response bodies of each route, keyed by the method and path template.  Every
+// route registered on the router must be documented here, the openapi tests fail otherwise.
+var routeDocs = map[string]openapi.Route{
+	"GET /v1/s3/ping":         {Summary: "Health check", Response: "pong", Public: true},
+	"GET /v1/s3/version":      {Summary: "Get the api version", Response: versionResponse{}, Public: true},
+	"GET /v1/s3/metrics":      {Summary: "Get prometheus metrics", Response: "", Public: true},
+	"GET /v1/s3/swagger.json": {Summary: "Get the openapi spec", Response: map[string]interface{}{}, Public: true},
+	"GET /v1/s3/swagger":      {Summary: "Swagger UI for the openapi spec", Response: "", ContentType: "text/html", Public: true},
+
+	// schemas
+	"GET /v1/s3/schemas":        {Summary: "Get the JSON schemas of every request and response type", Description: "A single JSON schema document with every type in its definitions", Tags: []string{"system"}, Response: map[string]interface{}{}},
+	"GET /v1/s3/schemas/{name}": {Summary: "Get the standalone JSON schema of a request or response type", Tags: []string{"system"}, Response: map[string]interface{}{}},
+
+	// accounts
+	"GET /v1/s3/accounts": {Summary: "List the configured accounts and their capabilities", Response: []accountResponse{}},
+
+	// inventory
+	"GET /v1/s3/inventory": {Summary: "List the org's buckets, websites, distributions and IAM groups and users in all of the accounts", Query: map[string]string{"org": "the org (default the api's org)", "refresh": "skip the cached inventories"}, Response: inventoryOutput{}},
+
+	// lifecycles
+	"GET /v1/s3/lifecycles": {Summary: "List the lifecycles that can be applied to buckets and the rules they install", Response: []*s3api.Lifecycle{}},
+
+	// admin
+	"GET /v1/s3/admin/operations":         {Summary: "List the in-flight mutating operations", Response: []operation{}},
+	"DELETE /v1/s3/admin/operations/{id}": {Summary: "Cancel an in-flight operation", Response: operation{}},
+	"GET /v1/s3/admin/maintenance":        {Summary: "Get the read-only maintenance mode", Response: maintenanceStatus{}},
+	"PUT /v1/s3/admin/maintenance": {
+		Summary:     "Enable the read-only maintenance mode",
+		Description: "Mutating requests are rejected with 503 Service Unavailable and the message while reads keep working",
+		Request:     maintenanceRequest{},
+		Response:    maintenanceStatus{},
+	},
+	"DELETE /v1/s3/admin/maintenance": {Summary: "Disable the read-only maintenance mode", Response: maintenanceStatus{}},
+
+	// buckets
+	"GET /v1/s3/{account}/buckets":           {Summary: "List buckets", Description: "Supports If-None-Match with the ETag of the response", Response: []string{}},
+	"POST /v1/s3/{account}/buckets":          {Summary: "Create a bucket", Request: bucketCreateRequest{}, Response: bucketCreateOutput{}},
+	"POST /v1/s3/{account}/buckets/bulk":     {Summary: "Create buckets in bulk", Description: "Responds with 207 Multi-Status if any of the buckets failed", Request: []bucketCreateRequest{}, Response: bulkCreateOutput{}},
+	"HEAD /v1/s3/{account}/buckets/{bucket}": {Summary: "Check if a bucket exists"},
+	"GET /v1/s3/{account}/buckets/{bucket}":  {Summary: "Get a bucket", Description: "Supports If-None-Match with the ETag of the response", Response: bucketShowOutput{}},
+	"PUT /v1/s3/{account}/buckets/{bucket}": {
+		Summary: "Update a bucket's tags, policy and vpc-only restriction",
+		Request: struct {
+			BucketPolicy *string
+			Tags         []*s3.Tag
+			VpcOnly      *bool
+		}{},
+		Response: bucketUpdateOutput{},
+	},
+	"DELETE /v1/s3/{account}/buckets/{bucket}":        {Summary: "Delete an empty bucket, protected buckets require the X-Protection-Override header"},
+	"GET /v1/s3/{account}/buckets/{bucket}/available": {Summary: "Check if a bucket or website name is available", Description: "Checks the bucket, the management groups and policies and the cloudfront aliases for conflicts", Response: bucketAvailability{}},
+	"GET /v1/s3/{account}/buckets/{bucket}/duck":      {Summary: "Get a cyberduck bookmark for a bucket", Response: "", ContentType: "application/octet-stream"},
+	"GET /v1/s3/{account}/buckets/{bucket}/export":    {Summary: "Export a bucket", Query: map[string]string{"format": "json (default) or terraform"}, Response: bucketExport{}},
+	"PUT /v1/s3/{account}/buckets/{bucket}/spec": {
+		Summary:  "Apply a bucket specification",
+		Query:    map[string]string{"dryrun": "report the changes without applying them"},
+		Request:  bucketSpec{},
+		Response: bucketSpecOutput{},
+	},
+	"POST /v1/s3/{account}/buckets/{bucket}/adopt": {
+		Summary:     "Adopt an existing bucket",
+		Description: "Brings a bucket that wasn't created by the api under management by applying the standard encryption, logging, admin group and tags",
+		Request:     bucketAdoptRequest{},
+		Response:    bucketAdoptOutput{},
+	},
+	"PUT /v1/s3/{account}/buckets/{bucket}/protection":    {Summary: "Protect a bucket from deletion", Response: protectionOutput{}},
+	"DELETE /v1/s3/{account}/buckets/{bucket}/protection": {Summary: "Remove a bucket's deletion protection, requires the X-Protection-Override header", Response: protectionOutput{}},
+	"POST /v1/s3/{account}/buckets/{bucket}/encrypt": {
+		Summary:     "Encrypt the existing objects in a bucket",
+		Description: "Starts a background task that copies the unencrypted objects in place with the bucket's default encryption",
+		Response:    task.Task{},
+	},
+	"GET /v1/s3/{account}/buckets/{bucket}/encryption": {Summary: "Get the default encryption of a bucket, including whether it uses an s3 bucket key", Response: bucketEncryptionOutput{}},
+	"GET /v1/s3/{account}/buckets/{bucket}/usage": {
+		Summary:     "Get the recorded object count and size of a bucket over time",
+		Description: "Returns the daily usage snapshots of the bucket, oldest first.  Supports If-None-Match with the ETag of the response",
+		Query:       map[string]string{"days": "limit the history to the last days (default the retention days)"},
+		Response:    bucketUsageHistory{},
+	},
+	"GET /v1/s3/{account}/buckets/{bucket}/objecttags": {Summary: "Get the default tags for the objects created in a bucket", Response: objectTagsOutput{}},
+	"PUT /v1/s3/{account}/buckets/{bucket}/objecttags": {Summary: "Replace the default tags for the objects created in a bucket", Request: objectTagsRequest{}, Response: objectTagsOutput{}},
+	"GET /v1/s3/{account}/buckets/{bucket}/simulate":   {Summary: "Simulate a user's access to a bucket", Query: map[string]string{"user": "the IAM user", "action": "an s3 action, can be repeated (default s3:ListBucket, s3:GetObject, s3:PutObject and s3:DeleteObject)", "key": "the object key for object actions (default *)"}, Response: simulationOutput{}},
+
+	// users
+	"GET /v1/s3/{account}/users": {
+		Summary:     "List the users managed by the api in an account",
+		Description: "Lists the users under the IAM path, or the management group members and users tagged with the org, with their groups and access key ages",
+		Query:       map[string]string{"path": "limit the list to users with the IAM path"},
+		Response:    []accountUser{},
+	},
+	"GET /v1/s3/{account}/users/cleanup": {
+		Summary:     "List the users in the unused user cleanup",
+		Description: "Lists the users that were flagged, deactivated, deleted or cleared by the cleanup with their history",
+		Query:       map[string]string{"state": "limit the list to users in the state (flagged, deactivated, deleted or cleared)"},
+		Response:    []*userCleanupRecord{},
+	},
+
+	// bucket users
+	"GET /v1/s3/{account}/buckets/{bucket}/users":                 {Summary: "List bucket users", Description: "Supports If-None-Match with the ETag of the response", Response: []*iam.User{}},
+	"POST /v1/s3/{account}/buckets/{bucket}/users":                {Summary: "Create a bucket user", Request: userCreateRequest{}, Response: userCreateResponse{}},
+	"POST /v1/s3/{account}/buckets/{bucket}/users/bulk":           {Summary: "Create bucket users in bulk", Description: "Responds with 207 Multi-Status if any of the users failed", Request: userBulkCreateRequest{}, Response: userBulkCreateOutput{}},
+	"GET /v1/s3/{account}/buckets/{bucket}/users/{user}":          {Summary: "Get a bucket user", Response: userShowOutput{}},
+	"PUT /v1/s3/{account}/buckets/{bucket}/users/{user}":          {Summary: "Reset a bucket user's access keys", Request: userKeyRequest{}, Response: userKeyResponse{}},
+	"DELETE /v1/s3/{account}/buckets/{bucket}/users/{user}":       {Summary: "Delete a bucket user"},
+	"POST /v1/s3/{account}/buckets/{bucket}/users/{user}/login":   {Summary: "Enable console login for a bucket user", Response: loginCreateResponse{}},
+	"PUT /v1/s3/{account}/buckets/{bucket}/users/{user}/login":    {Summary: "Reset the console password for a bucket user", Response: loginResetResponse{}},
+	"DELETE /v1/s3/{account}/buckets/{bucket}/users/{user}/login": {Summary: "Disable console login for a bucket user"},
+
+	// bucket sftp
+	"GET /v1/s3/{account}/buckets/{bucket}/sftp": {Summary: "Get the sftp configuration and users of a bucket", Response: sftpOutput{}},
+	"PUT /v1/s3/{account}/buckets/{bucket}/sftp": {
+		Summary:     "Enable sftp access to a bucket",
+		Description: "Creates the role and policy the transfer family server uses to access the bucket for its sftp users",
+		Response:    sftpOutput{},
+	},
+	"DELETE /v1/s3/{account}/buckets/{bucket}/sftp":                         {Summary: "Disable sftp access to a bucket and delete its sftp users"},
+	"POST /v1/s3/{account}/buckets/{bucket}/sftp/users":                     {Summary: "Create an sftp user for a bucket", Request: sftpUserRequest{}, Response: sftpUserOutput{}},
+	"GET /v1/s3/{account}/buckets/{bucket}/sftp/users/{user}":               {Summary: "Get an sftp user and its ssh public keys", Response: sftpUserOutput{}},
+	"DELETE /v1/s3/{account}/buckets/{bucket}/sftp/users/{user}":            {Summary: "Delete an sftp user"},
+	"POST /v1/s3/{account}/buckets/{bucket}/sftp/users/{user}/keys":         {Summary: "Add an ssh public key to an sftp user", Request: sftpKeyRequest{}, Response: sftpUserOutput{}},
+	"DELETE /v1/s3/{account}/buckets/{bucket}/sftp/users/{user}/keys/{key}": {Summary: "Remove an ssh public key from an sftp user"},
+
+	// bucket ip allowlist
+	"PUT /v1/s3/{account}/buckets/{bucket}/allowlist": {
+		Summary:     "Update the ip allowlist of a bucket",
+		Description: "Replaces the document of the bucket admin policy with one limited to the networks, an empty list resets it to the account's allowlist",
+		Request:     bucketAllowlistRequest{},
+		Response:    bucketAllowlistOutput{},
+	},
+
+	// bucket policy versions
+	"GET /v1/s3/{account}/buckets/{bucket}/policies/{policy}/versions": {
+		Summary:     "List the versions of a bucket policy",
+		Description: "Lists the versions of one of the managed policies of a bucket with their documents, newest first",
+		Response:    policyVersionsOutput{},
+	},
+	"POST /v1/s3/{account}/buckets/{bucket}/policies/{policy}/versions/{version}/restore": {
+		Summary:     "Restore a version of a bucket policy",
+		Description: "Makes a previous version of one of the managed policies of a bucket its default version, the newer versions are kept",
+		Response:    policyVersionsOutput{},
+	},
+
+	// bucket group policies
+	"POST /v1/s3/{account}/buckets/{bucket}/groups/{group}/policies": {
+		Summary:     "Attach a managed policy to a bucket group",
+		Description: "Attaches one of the account's configured managed policies to a management group of the bucket",
+		Request:     bucketGroupPolicyRequest{},
+		Response:    bucketGroupPoliciesOutput{},
+	},
+	"DELETE /v1/s3/{account}/buckets/{bucket}/groups/{group}/policies": {
+		Summary:     "Detach a managed policy from a bucket group",
+		Description: "Detaches the managed policy in the policyArn query parameter from a management group of the bucket",
+		Response:    bucketGroupPoliciesOutput{},
+	},
+
+	// bucket data protection
+	"PUT /v1/s3/{account}/buckets/{bucket}/dataprotection": {
+		Summary:     "Enable data protection for a bucket",
+		Description: "Adds a bucket policy statement denying deletes of the bucket and its objects to everyone but the break-glass role",
+		Response:    dataProtectionOutput{},
+	},
+	"DELETE /v1/s3/{account}/buckets/{bucket}/dataprotection": {
+		Summary:  "Disable data protection for a bucket, requires the X-Protection-Override header",
+		Response: dataProtectionOutput{},
+	},
+
+	// bucket access points
+	"GET /v1/s3/{account}/buckets/{bucket}/accesspoints": {Summary: "List the access points of a bucket", Response: []*accessPointOutput{}},
+	"POST /v1/s3/{account}/buckets/{bucket}/accesspoints": {
+		Summary:     "Create an access point for a bucket",
+		Description: "Creates an access point with a policy granting read or read-write access to the bucket, optionally restricted to a vpc and a prefix",
+		Request:     accessPointRequest{},
+		Response:    accessPointOutput{},
+	},
+	"DELETE /v1/s3/{account}/buckets/{bucket}/accesspoints/{name}": {Summary: "Delete an access point of a bucket"},
+
+	// reports
+	"GET /v1/s3/{account}/reports/mfa":  {Summary: "MFA report for bucket admins", Query: map[string]string{"bucket": "limit the report to a bucket"}, Response: mfaReport{}},
+	"GET /v1/s3/{account}/reports/tags": {Summary: "Required tags compliance report for buckets and distributions", Response: tagsReport{}},
+	"GET /v1/s3/{account}/reports/credentials": {
+		Summary:  "IAM credential report for the users managed by the api",
+		Query:    map[string]string{"format": "json (default) or csv", "path": "limit the report to users with the IAM path instead of the management group members"},
+		Response: credentialReport{},
+	},
+	"GET /v1/s3/{account}/reports/egress": {
+		Summary:     "Data egress report for the buckets in an account",
+		Description: "Lists the bytes downloaded from each bucket over a window from the s3 request metrics, flagging the buckets over the egress threshold",
+		Query:       map[string]string{"days": "the window in days, 1-90 (default 7)", "threshold": "override the average bytes downloaded per day that flags a bucket"},
+		Response:    egressReport{},
+	},
+	"GET /v1/s3/{account}/reports/lastaccessed": {
+		Summary:     "Service last accessed report for the bucket users and management groups in an account",
+		Description: "Lists when each user and group last used s3 and the other services its policies allow, flagging the users that haven't used s3 within the window.  Returns 202 Accepted while the report is being generated",
+		Query:       map[string]string{"bucket": "limit the report to a bucket", "days": "the days without using s3 that flag a user, 1-400 (default 90)"},
+		Response:    lastAccessedReport{},
+	},
+	"GET /v1/s3/{account}/exposure": {
+		Summary:     "Public exposure report for the buckets in an account",
+		Description: "Lists the buckets whose policy makes them public, split into the registered websites and the unexpected public buckets",
+		Tags:        []string{"v1 reports"},
+		Response:    exposureReport{},
+	},
+
+	// tasks
+	"GET /v1/s3/{account}/tasks":      {Summary: "List the running and recently finished background tasks", Response: []*task.Task{}},
+	"GET /v1/s3/{account}/tasks/{id}": {Summary: "Get the status and progress of a background task", Response: task.Task{}},
+
+	// rollbacks
+	"GET /v1/s3/{account}/rollbacks":         {Summary: "List pending and failed rollbacks", Response: []*rollback.Rollback{}},
+	"GET /v1/s3/{account}/rollbacks/{id}":    {Summary: "Get a rollback", Response: rollback.Rollback{}},
+	"POST /v1/s3/{account}/rollbacks/{id}":   {Summary: "Resume a rollback", Response: rollback.Rollback{}},
+	"DELETE /v1/s3/{account}/rollbacks/{id}": {Summary: "Discard a rollback"},
+
+	// zones
+	"GET /v1/s3/{account}/zones": {Summary: "List the route53 hosted zones in an account", Response: []hostedZoneResponse{}},
+
+	// websites
+	"POST /v1/s3/{account}/websites": {
+		Summary: "Create a website",
+		Query:   map[string]string{"wait": "true to respond once the distribution is deployed"},
+		Request: websiteCreateRequest{},
+		Response: struct {
+			Bucket       *string
+			Policies     []*iam.Policy
+			Groups       []*iam.Group
+			Distribution *cloudfront.Distribution
+			DnsChange    *route53.ChangeInfo
+			Objects      []*websiteObject
+			DeployTask   *task.Task `json:",omitempty"`
+		}{},
+	},
+	"GET /v1/s3/{account}/websites/deleted":   {Summary: "List the soft deleted websites waiting to be torn down", Response: []*softDeleteRecord{}},
+	"HEAD /v1/s3/{account}/websites/{bucket}": {Summary: "Check if a website exists"},
+	"GET /v1/s3/{account}/websites/{website}": {Summary: "Get a website", Description: "Supports If-None-Match with the ETag of the response", Response: websiteShowOutput{}},
+	"PUT /v1/s3/{account}/websites/{website}": {Summary: "Update a website's tags", Request: struct{ Tags []*s3.Tag }{}},
+	"PATCH /v1/s3/{account}/websites/{website}": {
+		Summary:     "Invalidate a website's cache",
+		Description: "With a Canary, the canary paths are invalidated and checked first and the rest of the paths are invalidated by a background task, which is returned with a 202",
+		Request: struct {
+			CacheInvalidation []string
+			Canary            *cacheCanary
+		}{},
+		Response: cloudfront.CreateInvalidationOutput{},
+	},
+	"DELETE /v1/s3/{account}/websites/{website}": {
+		Summary:     "Delete a website, protected websites require the X-Protection-Override header",
+		Description: "If soft delete is enabled the website is quarantined and a 202 is returned with the time it will be deleted.  Responds with 207 Multi-Status if the bucket was deleted but some of the other resources weren't",
+		Query:       map[string]string{"force": "true to delete the website immediately when soft delete is enabled"},
+		Response:    websiteDeleteOutput{},
+	},
+	"POST /v1/s3/{account}/websites/{website}/restore": {Summary: "Restore a soft deleted website", Response: websiteRestoreOutput{}},
+	"POST /v1/s3/{account}/websites/{website}/repair":  {Summary: "Repair a partially deleted or created website", Description: "Completes the teardown if the website bucket is gone, otherwise recreates the missing distribution, admin groups, policies and dns record.  Responds with 207 Multi-Status if some of the resources couldn't be torn down", Response: websiteRepairOutput{}},
+	"GET /v1/s3/{account}/websites/{website}/health":   {Summary: "Check the health of a website", Description: "Reports the status of the website's certificate, distribution, dns alias record and bucket website configuration", Response: websiteHealthOutput{}},
+	"POST /v1/s3/{account}/websites/{website}/staging": {
+		Summary:     "Create the staging website of a website",
+		Description: "Creates a website named stage.{website} that shares the website configuration, cache behaviors and tags of the website, the response is the same as creating a website",
+		Query:       map[string]string{"wait": "true to respond once the distribution is deployed"},
+	},
+	"POST /v1/s3/{account}/websites/{website}/staging/promote": {
+		Summary:     "Promote the content of a staging website",
+		Description: "Starts a background task that copies the new and changed objects of the staging website to the website and invalidates the website's cache",
+		Query:       map[string]string{"delete": "true to delete the objects that aren't in the staging website"},
+		Response:    task.Task{},
+	},
+	"GET /v1/s3/{account}/websites/{website}/releases": {Summary: "List the releases of a website", Description: "Lists the releases under the releases/ prefix of the website bucket with the active and previous release", Response: websiteReleasesOutput{}},
+	"PUT /v1/s3/{account}/websites/{website}/releases/{release}": {
+		Summary:     "Switch a website to a release",
+		Description: "Points the website's distribution at the releases/{release}/ prefix of the bucket and invalidates the cache, the active release is kept so the switch can be rolled back",
+		Response:    websiteReleasesOutput{},
+	},
+	"POST /v1/s3/{account}/websites/{website}/releases/rollback": {Summary: "Switch a website back to its previous release", Response: websiteReleasesOutput{}},
+
+	"GET /v1/s3/{account}/websites/{website}/duck":         {Summary: "Get a cyberduck bookmark for a website", Response: "", ContentType: "application/octet-stream"},
+	"GET /v1/s3/{account}/websites/{website}/export":       {Summary: "Export a website", Query: map[string]string{"format": "json (default) or terraform"}, Response: bucketExport{}},
+	"PUT /v1/s3/{account}/websites/{bucket}/protection":    {Summary: "Protect a website from deletion", Response: protectionOutput{}},
+	"DELETE /v1/s3/{account}/websites/{bucket}/protection": {Summary: "Remove a website's deletion protection, requires the X-Protection-Override header", Response: protectionOutput{}},
+	"GET /v1/s3/{account}/websites/{bucket}/objecttags":    {Summary: "Get the default tags for the objects created in a website", Response: objectTagsOutput{}},
+	"PUT /v1/s3/{account}/websites/{bucket}/objecttags":    {Summary: "Replace the default tags for the objects created in a website", Request: objectTagsRequest{}, Response: objectTagsOutput{}},
+	"GET /v1/s3/{account}/websites/{bucket}/simulate":      {Summary: "Simulate a user's access to a website bucket", Query: map[string]string{"user": "the IAM user", "action": "an s3 action, can be repeated (default s3:ListBucket, s3:GetObject, s3:PutObject and s3:DeleteObject)", "key": "the object key for object actions (default *)"}, Response: simulationOutput{}},
+
+	// website dns
+	"GET /v1/s3/{account}/websites/{website}/dns":                  {Summary: "List a website's CNAME, TXT, A and AAAA records", Response: []websiteDNSRecord{}},
+	"POST /v1/s3/{account}/websites/{website}/dns":                 {Summary: "Create a CNAME, TXT, A or AAAA record within a website's name", Request: websiteDNSRecord{}, Response: websiteDNSRecord{}},
+	"DELETE /v1/s3/{account}/websites/{website}/dns/{type}/{name}": {Summary: "Delete a CNAME, TXT, A or AAAA record within a website's name"},
+
+	// website cache behaviors
+	"GET /v1/s3/{account}/websites/{website}/behaviors": {Summary: "Get a website's additional cache behaviors", Response: websiteBehaviorsOutput{}},
+	"PUT /v1/s3/{account}/websites/{website}/behaviors": {Summary: "Replace a website's additional cache behaviors", Description: "Supports If-Match with the ETag of the website", Request: websiteBehaviorsRequest{}, Response: websiteBehaviorsOutput{}},
+
+	// website security headers
+	"GET /v1/s3/{account}/websites/{website}/securityheaders":    {Summary: "Get whether the security headers are enabled for a website", Response: websiteHeadersOutput{}},
+	"PUT /v1/s3/{account}/websites/{website}/securityheaders":    {Summary: "Enable the security headers response headers policy for a website", Description: "Supports If-Match with the ETag of the website", Response: websiteHeadersOutput{}},
+	"DELETE /v1/s3/{account}/websites/{website}/securityheaders": {Summary: "Disable the security headers response headers policy for a website", Description: "Supports If-Match with the ETag of the website", Response: websiteHeadersOutput{}},
+
+	// website cloudfront functions
+	"GET /v1/s3/{account}/websites/{website}/functions":               {Summary: "List the cloudfront functions associated with a website", Response: websiteFunctionsOutput{}},
+	"PUT /v1/s3/{account}/websites/{website}/functions/{function}":    {Summary: "Publish a cloudfront function from a configured template and associate it with a website", Description: "Supports If-Match with the ETag of the website", Response: websiteFunctionsOutput{}},
+	"DELETE /v1/s3/{account}/websites/{website}/functions/{function}": {Summary: "Remove a cloudfront function from a website", Description: "Supports If-Match with the ETag of the website", Response: websiteFunctionsOutput{}},
+
+	// website signed urls
+	"POST /v1/s3/{account}/websites/{website}/signedurls": {Summary: "Sign urls or cookies for a website's private content", Request: websiteSignedURLsRequest{}, Response: websiteSignedURLsOutput{}},
+
+	// website failover
+	"PUT /v1/s3/{account}/websites/{website}/failover":    {Summary: "Fail a website over to a secondary distribution when it's unhealthy", Request: websiteFailoverRequest{}, Response: websiteFailoverOutput{}},
+	"DELETE /v1/s3/{account}/websites/{website}/failover": {Summary: "Remove a website's failover", Response: websiteFailoverOutput{}},
+
+	// website users
+	"GET /v1/s3/{account}/websites/{bucket}/users":                 {Summary: "List website users", Description: "Supports If-None-Match with the ETag of the response", Response: []*iam.User{}},
+	"POST /v1/s3/{account}/websites/{website}/users":               {Summary: "Create a website user", Request: userCreateRequest{}, Response: userCreateResponse{}},
+	"GET /v1/s3/{account}/websites/{bucket}/users/{user}":          {Summary: "Get a website user", Response: userShowOutput{}},
+	"PUT /v1/s3/{account}/websites/{bucket}/users/{user}":          {Summary: "Reset a website user's access keys", Request: userKeyRequest{}, Response: userKeyResponse{}},
+	"DELETE /v1/s3/{account}/websites/{bucket}/users/{user}":       {Summary: "Delete a website user"},
+	"POST /v1/s3/{account}/websites/{bucket}/users/{user}/login":   {Summary: "Enable console login for a website user", Response: loginCreateResponse{}},
+	"PUT /v1/s3/{account}/websites/{bucket}/users/{user}/login":    {Summary: "Reset the console password for a website user", Response: loginResetResponse{}},
+	"DELETE /v1/s3/{account}/websites/{bucket}/users/{user}/login": {Summary: "Disable console login for a website user"},
+
+	// v2
+	"GET /v2/s3/ping":                                      {Summary: "Health check", Response: "pong", Public: true},
+	"GET /v2/s3/version":                                   {Summary: "Get the api version", Response: versionResponse{}, Public: true},
+	"GET /v2/s3/{account}/buckets":                         {Summary: "List buckets", Description: "Supports If-None-Match with the ETag of the response", Response: []Bucket{}},
+	"POST /v2/s3/{account}/buckets":                        {Summary: "Create a bucket", Request: bucketCreateRequest{}, Response: Bucket{}},
+	"GET /v2/s3/{account}/buckets/{bucket}":                {Summary: "Get a bucket", Description: "Supports If-None-Match with the ETag of the response", Response: Bucket{}},
+	"GET /v2/s3/{account}/buckets/{bucket}/users":          {Summary: "List bucket users", Description: "Supports If-None-Match with the ETag of the response", Response: []User{}},
+	"GET /v2/s3/{account}/buckets/{bucket}/users/{user}":   {Summary: "Get a bucket user", Response: User{}},
+	"GET /v2/s3/{account}/websites/{website}":              {Summary: "Get a website", Description: "Supports If-None-Match with the ETag of the response", Response: Website{}},
+	"GET /v2/s3/{account}/websites/{bucket}/users":         {Summary: "List website users", Description: "Supports If-None-Match with the ETag of the response", Response: []User{}},
+	"GET /v2/s3/{account}/websites/{website}/users/{user}": {Summary: "Get a website user", Response: User{}},
+}
+
+// openAPISpec generates the openapi document from the routes registered on the router
+func (s *server) openAPISpec() (*openapi.Document, error) {
+	spec := openapi.New("s3-api", fmt.Sprintf("%s%s", s.version.Version, s.version.VersionPrerelease),
+		openapi.WithDescription("Restful API access to Amazon's S3 service"),
+		openapi.WithHeaderAuth("X-Auth-Token"),
+	)
+
+	err := s.router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+
+		// routes without methods are the subrouter prefixes
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+
+		for _, method := range methods {
+			key := method + " " + path
+			doc, ok := routeDocs[key]
+			if !ok {
+				log.Warnf("route %s is missing from the openapi documentation", key)
+			}
+
+			if len(doc.Tags) == 0 {
+				doc.Tags = []string{routeTag(path)}
+			}
+
+			// website routes are registered with either a bucket or a website path parameter, but openapi
+			// doesn't allow the same path with different parameter names
+			spec.Add(method, strings.Replace(path, "/websites/{bucket}", "/websites/{website}", 1), doc)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return spec.Document(), nil
+}
+
+// routeTag groups a route by the type of resource it manages
+func routeTag(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 4 {
+		return "system"
+	}
+
+	tag := parts[3]
+	if tag != "users" && strings.Contains(path, "/users") {
+		tag = strings.TrimSuffix(tag, "s") + " users"
+	}
+
+	return parts[0] + " " + tag
+}
+
+// OpenAPIHandler serves the openapi spec for the api
+func (s *server) OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+
+	doc, err := s.openAPISpec()
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	j, err := json.Marshal(doc)
+	if err != nil {
+		log.Errorf("cannot marshal openapi spec into JSON: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// apiSchemas generates the JSON schemas of the request and response types of the documented routes
+func apiSchemas() *openapi.Schemas {
+	schemas := openapi.NewSchemas()
+	for _, doc := range routeDocs {
+		schemas.Add(doc.Request)
+		schemas.Add(doc.Response)
+	}
+	return schemas
+}
+
+// SchemaListHandler serves a JSON schema document with the schemas of every request and response type, for
+// generating typed clients
+func (s *server) SchemaListHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	writeConditionalJSON(w, r, apiSchemas().Bundle("s3-api"))
+}
+
+// SchemaShowHandler serves the standalone JSON schema of a request or response type
+func (s *server) SchemaShowHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	name := mux.Vars(r)["name"]
+
+	schema, ok := apiSchemas().Schema(name)
+	if !ok {
+		handleError(w, apierror.New(apierror.ErrNotFound, fmt.Sprintf("schema %s not found", name), nil))
+		return
+	}
+
+	writeConditionalJSON(w, r, schema)
+}
+
+// swaggerUI is a page that loads swagger ui from a cdn and points it at the openapi spec
+const swaggerUI = `<!DOCTYPE html>
+<html>
+<head>
+  <title>s3-api</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css" />
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.onload = function() {
+      SwaggerUIBundle({url: "swagger.json", dom_id: "#swagger-ui"});
+    };
+  </script>
+</body>
+</html>
+`
+
+// SwaggerUIHandler serves the swagger ui page
+func (s *server) SwaggerUIHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(swaggerUI))
+}
@@ -0,0 +1,217 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/YaleSpinup/s3-api/openapi"
+	"github.com/gorilla/mux"
+)
+
+// routeKeys returns the method and path template of every route registered on the router
+func routeKeys(t *testing.T, router *mux.Router) map[string]bool {
+	keys := map[string]bool{}
+	if err := router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+
+		for _, m := range methods {
+			keys[m+" "+path] = true
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error walking routes: %s", err)
+	}
+	return keys
+}
+
+func TestRouteDocs(t *testing.T) {
+	s := server{router: mux.NewRouter(), swaggerUI: true}
+	s.routes()
+
+	keys := routeKeys(t, s.router)
+	for k := range keys {
+		if _, ok := routeDocs[k]; !ok {
+			t.Errorf("route %s is missing from the openapi route docs", k)
+		}
+	}
+
+	for k, doc := range routeDocs {
+		if !keys[k] {
+			t.Errorf("openapi route docs has %s but there is no route", k)
+		}
+
+		path := strings.SplitN(k, " ", 2)[1]
+		if _, public := publicURLs[path]; public != doc.Public {
+			t.Errorf("route %s is public (%t) in the route docs, but not in the public urls", k, doc.Public)
+		}
+	}
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	s := server{
+		router:  mux.NewRouter(),
+		version: common.Version{Version: "1.2.3"},
+	}
+	s.routes()
+
+	req, err := http.NewRequest(http.MethodGet, "/v1/s3/swagger.json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	s.router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var doc struct {
+		OpenAPI string
+		Info    struct {
+			Version string
+		}
+		Paths      map[string]map[string]json.RawMessage
+		Components struct {
+			Schemas map[string]json.RawMessage
+		}
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("failed to unmarshal openapi spec: %s", err)
+	}
+
+	if doc.OpenAPI != "3.0.3" || doc.Info.Version != "1.2.3" {
+		t.Errorf("unexpected openapi version %s or api version %s", doc.OpenAPI, doc.Info.Version)
+	}
+
+	for _, p := range []struct{ path, method string }{
+		{"/v1/s3/{account}/buckets", "post"},
+		{"/v1/s3/{account}/websites/{website}/users/{user}", "get"},
+		{"/v1/s3/{account}/websites/{website}", "head"},
+		{"/v2/s3/{account}/buckets/{bucket}", "get"},
+	} {
+		if _, ok := doc.Paths[p.path][p.method]; !ok {
+			t.Errorf("expected %s %s in openapi paths", p.method, p.path)
+		}
+	}
+
+	if _, ok := doc.Paths["/v1/s3/{account}/websites/{bucket}"]; ok {
+		t.Error("expected website routes with a bucket parameter to be documented with a website parameter")
+	}
+
+	if _, ok := doc.Paths["/v1/s3/swagger"]; ok {
+		t.Error("expected swagger ui to not be routed when it's disabled")
+	}
+
+	for _, name := range []string{"api.Bucket", "api.bucketCreateRequest", "s3.Tag", "iam.User"} {
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("expected schema %s in openapi components", name)
+		}
+	}
+}
+
+func TestRouteTag(t *testing.T) {
+	tests := map[string]string{
+		"/v1/s3/ping":                                      "system",
+		"/v1/s3/{account}/buckets/{bucket}":                "v1 buckets",
+		"/v1/s3/{account}/websites/{website}/users/{user}": "v1 website users",
+		"/v2/s3/{account}/buckets/{bucket}/users":          "v2 bucket users",
+		"/v1/s3/{account}/users":                           "v1 users",
+	}
+
+	for path, expected := range tests {
+		if out := routeTag(path); out != expected {
+			t.Errorf("expected tag %s for %s, got %s", expected, path, out)
+		}
+	}
+}
+
+func TestSchemaHandlers(t *testing.T) {
+	s := server{router: mux.NewRouter()}
+	s.routes()
+
+	req, err := http.NewRequest(http.MethodGet, "/v1/s3/schemas", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	s.router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var bundle struct {
+		Schema      string `json:"$schema"`
+		Definitions map[string]json.RawMessage
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &bundle); err != nil {
+		t.Fatalf("failed to unmarshal schemas: %s", err)
+	}
+
+	if bundle.Schema != openapi.JSONSchemaVersion {
+		t.Errorf("expected json schema version %s, got %s", openapi.JSONSchemaVersion, bundle.Schema)
+	}
+
+	// the request and response types of the v1 and v2 apis are included
+	for _, name := range []string{"api.Bucket", "api.Website", "api.User", "api.bucketCreateRequest", "api.websiteShowOutput", "iam.User"} {
+		if _, ok := bundle.Definitions[name]; !ok {
+			t.Errorf("expected schema %s in the definitions", name)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{"api.Bucket", http.StatusOK},
+		{"api.bucketCreateRequest", http.StatusOK},
+		{"api.Missing", http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		req, err := http.NewRequest(http.MethodGet, "/v1/s3/schemas/"+test.name, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		s.router.ServeHTTP(rr, req)
+
+		if rr.Code != test.status {
+			t.Errorf("%s: expected status %d, got %d", test.name, test.status, rr.Code)
+			continue
+		}
+
+		if test.status != http.StatusOK {
+			continue
+		}
+
+		var schema struct {
+			Schema     string `json:"$schema"`
+			Title      string
+			Type       string
+			Properties map[string]json.RawMessage
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &schema); err != nil {
+			t.Fatalf("failed to unmarshal schema: %s", err)
+		}
+
+		if schema.Schema != openapi.JSONSchemaVersion || schema.Title != test.name || schema.Type != "object" || len(schema.Properties) == 0 {
+			t.Errorf("%s: expected a standalone object schema, got %+v", test.name, schema)
+		}
+	}
+}
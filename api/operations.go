@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// operation is a mutating request that's being executed
+type operation struct {
+	ID       string
+	Method   string
+	Path     string
+	Handler  string
+	Account  string `json:",omitempty"`
+	Resource string `json:",omitempty"`
+	Started  time.Time
+	// Elapsed is how long the operation has been running when it's listed
+	Elapsed  string
+	Canceled bool
+	cancel   context.CancelFunc
+}
+
+// operations keeps the mutating requests that are being executed so they can be listed and canceled
+type operations struct {
+	ops map[string]*operation
+	mu  sync.Mutex
+}
+
+// newOperations creates a new in-flight operations registry
+func newOperations() *operations {
+	return &operations{ops: map[string]*operation{}}
+}
+
+// middleware registers the mutating requests with a context that's canceled when the operation is canceled.  The
+// operation is removed when the request is done.
+func (o *operations) middleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+
+		// use the request id from the request log, if there is one, so the operation can be found in the logs
+		id := w.Header().Get("X-Request-Id")
+		if id == "" {
+			id = uuid.New().String()
+		}
+
+		vars := mux.Vars(r)
+		op := &operation{
+			ID:       id,
+			Method:   r.Method,
+			Path:     r.URL.Path,
+			Account:  vars["account"],
+			Resource: vars["bucket"],
+			Started:  time.Now().UTC(),
+			cancel:   cancel,
+		}
+
+		if website, ok := vars["website"]; ok {
+			op.Resource = website
+		}
+
+		if route := mux.CurrentRoute(r); route != nil {
+			op.Handler = handlerName(route.GetHandler())
+		}
+
+		o.mu.Lock()
+		o.ops[op.ID] = op
+		o.mu.Unlock()
+
+		defer func() {
+			o.mu.Lock()
+			delete(o.ops, op.ID)
+			o.mu.Unlock()
+		}()
+
+		h.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// list returns the in-flight operations, oldest first
+func (o *operations) list() []operation {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := time.Now()
+	ops := make([]operation, 0, len(o.ops))
+	for _, op := range o.ops {
+		out := *op
+		out.Elapsed = now.Sub(op.Started).Round(time.Millisecond).String()
+		ops = append(ops, out)
+	}
+
+	sort.Slice(ops, func(i, j int) bool { return ops[i].Started.Before(ops[j].Started) })
+
+	return ops
+}
+
+// cancel cancels the context of an in-flight operation.  The aws calls made with the context fail, which rolls back
+// the operation the same as any other failure.
+func (o *operations) cancel(id string) (*operation, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	op, ok := o.ops[id]
+	if !ok {
+		return nil, apierror.New(apierror.ErrNotFound, "operation not found", nil)
+	}
+
+	log.Warnf("canceling %s operation %s on %s in account %s after %s", op.Handler, op.ID, op.Resource, op.Account, time.Since(op.Started))
+
+	op.cancel()
+	op.Canceled = true
+
+	out := *op
+	out.Elapsed = time.Since(op.Started).Round(time.Millisecond).String()
+	return &out, nil
+}
@@ -4,49 +4,55 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
 	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/YaleSpinup/s3-api/rollback"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/iam"
 	log "github.com/sirupsen/logrus"
 )
 
+// groupPolicyBlocks are the statement blocks the policies of the management groups are composed of
+var groupPolicyBlocks = map[string]string{
+	"BktAdmGrp": iamapi.BlockAdmin,
+	"BktRWGrp":  iamapi.BlockReadWrite,
+	"BktROGrp":  iamapi.BlockReadOnly,
+}
+
 // CreateBucketGroupPolicy expects an acount, bucket name and the group name (without the bucket prefix).  It verifies the group
 // is one of our supported types and then generates a policy doc for the group and bucket.  Finally, it creates the group
-// and attaches the policy.  It returns a rollback function and will rollback itself if it encounters an error.
-func (s *server) CreateBucketGroupPolicy(ctx context.Context, iamService iamapi.IAM, bucket, group string) ([]rollbackFunc, error) {
+// and attaches the policy.  The policy is tagged with the passed tags.  It returns the rollback steps and will rollback itself if it
+// encounters an error.
+func (s *server) CreateBucketGroupPolicy(ctx context.Context, iamService iamapi.IAM, bucket, group string, tags []*iam.Tag) ([]*rollback.Step, error) {
 	var err error
-	var rollBackTasks []rollbackFunc
+	rb := rollback.New("group.create", "", iamapi.FormatGroupName(bucket, "/", group), rollbackServices{iam: &iamService}.execute)
 	defer func() {
 		if err != nil {
-			log.Errorf("recovering from error: %s, executing %d rollback tasks", err, len(rollBackTasks))
-			rollBack(&rollBackTasks)
+			s.finishRollback(rb, err)
 		}
 	}()
 
 	var policyName, policyDescription string
-	var policyDocument []byte
 	// TODO: add website groups
 	switch group {
 	case "BktAdmGrp":
-		policyName = fmt.Sprintf("%s-BktAdmPlc", bucket)
+		policyName = iamapi.FormatPolicyName(bucket, "/", "BktAdmPlc")
 		policyDescription = fmt.Sprintf("Admin policy for %s bucket", bucket)
-		if policyDocument, err = iamService.AdminBucketPolicy(bucket); err != nil {
-			return rollBackTasks, err
-		}
 	case "BktRWGrp":
-		policyName = fmt.Sprintf("%s-BktRWPlc", bucket)
+		policyName = iamapi.FormatPolicyName(bucket, "/", "BktRWPlc")
 		policyDescription = fmt.Sprintf("Read-Write policy for %s bucket", bucket)
-		if policyDocument, err = iamService.ReadWriteBucketPolicy(bucket); err != nil {
-			return rollBackTasks, err
-		}
 	case "BktROGrp":
-		policyName = fmt.Sprintf("%s-BktROPlc", bucket)
+		policyName = iamapi.FormatPolicyName(bucket, "/", "BktROPlc")
 		policyDescription = fmt.Sprintf("Read-Only policy for %s bucket", bucket)
-		if policyDocument, err = iamService.ReadOnlyBucketPolicy(bucket); err != nil {
-			return rollBackTasks, err
-		}
 	default:
-		return rollBackTasks, fmt.Errorf("invalid group name: %s", group)
+		return nil, fmt.Errorf("invalid group name: %s", group)
+	}
+
+	var policyDocument []byte
+	if policyDocument, err = iamService.ComposePolicyDocument(iamapi.BlockInput{Bucket: bucket}, groupPolicyBlocks[group]); err != nil {
+		return nil, err
 	}
 
 	var policyOutput *iam.Policy
@@ -54,102 +60,68 @@ func (s *server) CreateBucketGroupPolicy(ctx context.Context, iamService iamapi.
 		Description:    aws.String(policyDescription),
 		PolicyDocument: aws.String(string(policyDocument)),
 		PolicyName:     aws.String(policyName),
+		Tags:           tags,
 	}); err != nil {
-		return rollBackTasks, fmt.Errorf("failed to create iam policy for bucket %s: %s", bucket, err)
+		return nil, fmt.Errorf("failed to create iam policy for bucket %s: %s", bucket, err)
 	}
 
-	// append policy delete to rollback tasks
-	rbfunc := func(ctx context.Context) error {
-		if err := iamService.DeletePolicy(ctx, &iam.DeletePolicyInput{PolicyArn: policyOutput.Arn}); err != nil {
-			return err
-		}
-		return nil
-	}
-	rollBackTasks = append(rollBackTasks, rbfunc)
+	// append policy delete to rollback
+	rb.Add("delete policy "+policyName, rollbackDeletePolicy, map[string]string{"policy_arn": aws.StringValue(policyOutput.Arn)})
 
-	groupName := fmt.Sprintf("%s-%s", bucket, group)
+	groupName := iamapi.FormatGroupName(bucket, "/", group)
 
 	if _, err = iamService.CreateGroup(ctx, &iam.CreateGroupInput{
 		GroupName: aws.String(groupName),
 	}); err != nil {
-		return rollBackTasks, fmt.Errorf("failed to create group %s: %s", groupName, err)
+		return nil, fmt.Errorf("failed to create group %s: %s", groupName, err)
 	}
 
-	// append group delete to rollback tasks
-	rbfunc = func(ctx context.Context) error {
-		if err := iamService.DeleteGroup(ctx, &iam.DeleteGroupInput{GroupName: aws.String(groupName)}); err != nil {
-			return err
-		}
-		return nil
-	}
-	rollBackTasks = append(rollBackTasks, rbfunc)
+	// append group delete to rollback
+	rb.Add("delete group "+groupName, rollbackDeleteGroup, map[string]string{"group": groupName})
 
 	if err = iamService.AttachGroupPolicy(ctx, &iam.AttachGroupPolicyInput{
 		GroupName: aws.String(groupName),
 		PolicyArn: policyOutput.Arn,
 	}); err != nil {
-		return rollBackTasks, fmt.Errorf("failed to attach policy %s to group %s", aws.StringValue(policyOutput.Arn), groupName)
+		return nil, fmt.Errorf("failed to attach policy %s to group %s", aws.StringValue(policyOutput.Arn), groupName)
 	}
 
-	return rollBackTasks, nil
+	return rb.Steps, nil
 }
 
 // CreateWebsiteBucketPolicy expects an acount, bucket name and the group name (without the bucket prefix).  It verifies the group
 // is one of our supported types and then generates a policy doc for the group and bucket.  Finally, it creates the group
-// and attaches the policy.  It returns a rollback function and will rollback itself if it encounters an error.
-func (s *server) CreateWebsiteBucketPolicy(ctx context.Context, iamService iamapi.IAM, website, path string, group string) ([]rollbackFunc, error) {
+// and attaches the policy.  The policy is tagged with the passed tags.  It returns the rollback steps and will rollback itself if it
+// encounters an error.
+func (s *server) CreateWebsiteBucketPolicy(ctx context.Context, iamService iamapi.IAM, website, path string, group string, tags []*iam.Tag) ([]*rollback.Step, error) {
 	var err error
-	var rollBackTasks []rollbackFunc
+	rb := rollback.New("group.create", "", iamapi.FormatGroupName(website, path, group), rollbackServices{iam: &iamService}.execute)
 	defer func() {
 		if err != nil {
-			log.Errorf("recovering from error: %s, executing %d rollback tasks", err, len(rollBackTasks))
-			rollBack(&rollBackTasks)
+			s.finishRollback(rb, err)
 		}
 	}()
 
 	var policyName, policyDescription string
-	var policyDocument []byte
 	// TODO: add website groups
 	switch group {
 	case "BktAdmGrp":
-		policyName = iamapi.FormatGroupName(website, path, "BktAdmPlc")
+		policyName = iamapi.FormatPolicyName(website, path, "BktAdmPlc")
 		policyDescription = fmt.Sprintf("Admin policy for %s website", website)
-		if path != "/" {
-			if policyDocument, err = iamService.AdminBucketPolicyWithPath(website, path); err != nil {
-				return rollBackTasks, err
-			}
-		} else {
-			if policyDocument, err = iamService.AdminBucketPolicy(website); err != nil {
-				return rollBackTasks, err
-			}
-		}
 	case "BktRWGrp":
-		policyName = iamapi.FormatGroupName(website, path, "BktRWPlc")
+		policyName = iamapi.FormatPolicyName(website, path, "BktRWPlc")
 		policyDescription = fmt.Sprintf("Read-Write policy for %s website", website)
-		if path != "/" {
-			if policyDocument, err = iamService.ReadWriteBucketPolicyWithPath(website, path); err != nil {
-				return rollBackTasks, err
-			}
-		} else {
-			if policyDocument, err = iamService.ReadWriteBucketPolicy(website); err != nil {
-				return rollBackTasks, err
-			}
-		}
 	case "BktROGrp":
-		policyName = iamapi.FormatGroupName(website, path, "BktROPlc")
+		policyName = iamapi.FormatPolicyName(website, path, "BktROPlc")
 		policyDescription = fmt.Sprintf("Read-Only policy for %s website", website)
-
-		if path != "/" {
-			if policyDocument, err = iamService.ReadOnlyBucketPolicyWithPath(website, path); err != nil {
-				return rollBackTasks, err
-			}
-		} else {
-			if policyDocument, err = iamService.ReadOnlyBucketPolicy(website); err != nil {
-				return rollBackTasks, err
-			}
-		}
 	default:
-		return rollBackTasks, fmt.Errorf("invalid group name: %s", group)
+		return nil, fmt.Errorf("invalid group name: %s", group)
+	}
+
+	// the policy is limited to the path, unless it's the whole website
+	var policyDocument []byte
+	if policyDocument, err = iamService.ComposePolicyDocument(iamapi.BlockInput{Bucket: website, Path: path}, groupPolicyBlocks[group]); err != nil {
+		return nil, err
 	}
 
 	var policyOutput *iam.Policy
@@ -158,42 +130,83 @@ func (s *server) CreateWebsiteBucketPolicy(ctx context.Context, iamService iamap
 		PolicyDocument: aws.String(string(policyDocument)),
 		PolicyName:     aws.String(policyName),
 		Path:           aws.String(path),
+		Tags:           tags,
 	}); err != nil {
-		return rollBackTasks, fmt.Errorf("failed to create iam policy for website %s: %s", website, err)
+		return nil, fmt.Errorf("failed to create iam policy for website %s: %s", website, err)
 	}
 
-	// append policy delete to rollback tasks
-	rbfunc := func(ctx context.Context) error {
-		if err := iamService.DeletePolicy(ctx, &iam.DeletePolicyInput{PolicyArn: policyOutput.Arn}); err != nil {
-			return err
-		}
-		return nil
-	}
-	rollBackTasks = append(rollBackTasks, rbfunc)
+	// append policy delete to rollback
+	rb.Add("delete policy "+policyName, rollbackDeletePolicy, map[string]string{"policy_arn": aws.StringValue(policyOutput.Arn)})
 
 	groupName := iamapi.FormatGroupName(website, path, group)
 
+	// the group is created with the IAM path so it's only matched to this website, see iamapi.ParseGroupName
 	if _, err = iamService.CreateGroup(ctx, &iam.CreateGroupInput{
 		GroupName: aws.String(groupName),
+		Path:      aws.String(iamapi.EnforcePathFormat(path)),
 	}); err != nil {
-		return rollBackTasks, fmt.Errorf("failed to create group %s: %s", groupName, err)
+		return nil, fmt.Errorf("failed to create group %s: %s", groupName, err)
 	}
 
-	// append group delete to rollback tasks
-	rbfunc = func(ctx context.Context) error {
-		if err := iamService.DeleteGroup(ctx, &iam.DeleteGroupInput{GroupName: aws.String(groupName)}); err != nil {
-			return err
-		}
-		return nil
-	}
-	rollBackTasks = append(rollBackTasks, rbfunc)
+	// append group delete to rollback
+	rb.Add("delete group "+groupName, rollbackDeleteGroup, map[string]string{"group": groupName})
 
 	if err = iamService.AttachGroupPolicy(ctx, &iam.AttachGroupPolicyInput{
 		GroupName: aws.String(groupName),
 		PolicyArn: policyOutput.Arn,
 	}); err != nil {
-		return rollBackTasks, fmt.Errorf("failed to attach policy %s to group %s", aws.StringValue(policyOutput.Arn), groupName)
+		return nil, fmt.Errorf("failed to attach policy %s to group %s", aws.StringValue(policyOutput.Arn), groupName)
+	}
+
+	return rb.Steps, nil
+}
+
+// createAccessKey creates an access key for a user.  When the user already has as many access keys as iam allows and
+// replaceInactive is true, the inactive key that was used least recently is deleted and the key is created again,
+// otherwise it's a conflict.  The id of the deleted key is returned, it can't be restored if the operation is rolled
+// back.
+func createAccessKey(ctx context.Context, iamService iamapi.IAM, user string, replaceInactive bool) (*iam.CreateAccessKeyOutput, *string, error) {
+	output, err := iamService.CreateAccessKey(ctx, &iam.CreateAccessKeyInput{UserName: aws.String(user)})
+	if err == nil || !isAccessKeyLimitExceeded(err) {
+		return output, nil, err
+	}
+
+	if !replaceInactive {
+		msg := fmt.Sprintf("user %s already has the maximum number of access keys, deactivate one and set ReplaceInactiveKey to replace it", user)
+		return nil, nil, apierror.New(apierror.ErrConflict, msg, err)
+	}
+
+	oldest, err := iamService.OldestInactiveAccessKey(ctx, user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if oldest == nil {
+		msg := fmt.Sprintf("user %s already has the maximum number of access keys and none of them are inactive", user)
+		return nil, nil, apierror.New(apierror.ErrConflict, msg, nil)
+	}
+
+	log.Warnf("user %s has the maximum number of access keys, deleting inactive access key %s", user, aws.StringValue(oldest.AccessKeyId))
+
+	if err := iamService.DeleteAccessKey(ctx, &iam.DeleteAccessKeyInput{UserName: aws.String(user), AccessKeyId: oldest.AccessKeyId}); err != nil {
+		return nil, nil, err
+	}
+
+	if output, err = iamService.CreateAccessKey(ctx, &iam.CreateAccessKeyInput{UserName: aws.String(user)}); err != nil {
+		return nil, oldest.AccessKeyId, err
+	}
+
+	return output, oldest.AccessKeyId, nil
+}
+
+// isAccessKeyLimitExceeded returns true if the error is iam refusing to create an access key because the user already
+// has the maximum number of them, rather than a throttled request
+func isAccessKeyLimitExceeded(err error) bool {
+	aerr, ok := common.AsAPIError(err)
+	if !ok || aerr.Code != apierror.ErrLimitExceeded {
+		return false
 	}
 
-	return rollBackTasks, nil
+	origErr, ok := aerr.OrigErr.(awserr.Error)
+	return ok && origErr.Code() == iam.ErrCodeLimitExceededException
 }
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+)
+
+// mockAccessKeysIAM is a fake iam client for a user with a limited number of access keys
+type mockAccessKeysIAM struct {
+	iamiface.IAMAPI
+	keys     []*iam.AccessKeyMetadata
+	lastUsed map[string]time.Time
+	max      int
+	err      error
+	deleted  []string
+}
+
+func (m *mockAccessKeysIAM) CreateAccessKeyWithContext(ctx aws.Context, input *iam.CreateAccessKeyInput, opts ...request.Option) (*iam.CreateAccessKeyOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if len(m.keys) >= m.max {
+		return nil, awserr.New(iam.ErrCodeLimitExceededException, "Cannot exceed quota for AccessKeysPerUser: 2", nil)
+	}
+
+	key := &iam.AccessKey{UserName: input.UserName, AccessKeyId: aws.String("NEWKEY"), SecretAccessKey: aws.String("sssshhh"), Status: aws.String("Active")}
+	m.keys = append(m.keys, &iam.AccessKeyMetadata{UserName: key.UserName, AccessKeyId: key.AccessKeyId, Status: key.Status, CreateDate: aws.Time(time.Now())})
+
+	return &iam.CreateAccessKeyOutput{AccessKey: key}, nil
+}
+
+func (m *mockAccessKeysIAM) DeleteAccessKeyWithContext(ctx aws.Context, input *iam.DeleteAccessKeyInput, opts ...request.Option) (*iam.DeleteAccessKeyOutput, error) {
+	for i, k := range m.keys {
+		if aws.StringValue(k.AccessKeyId) == aws.StringValue(input.AccessKeyId) {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			m.deleted = append(m.deleted, aws.StringValue(input.AccessKeyId))
+			return &iam.DeleteAccessKeyOutput{}, nil
+		}
+	}
+
+	return nil, awserr.New(iam.ErrCodeNoSuchEntityException, "not found", nil)
+}
+
+func (m *mockAccessKeysIAM) ListAccessKeysWithContext(ctx aws.Context, input *iam.ListAccessKeysInput, opts ...request.Option) (*iam.ListAccessKeysOutput, error) {
+	return &iam.ListAccessKeysOutput{AccessKeyMetadata: m.keys}, nil
+}
+
+func (m *mockAccessKeysIAM) GetAccessKeyLastUsedWithContext(ctx aws.Context, input *iam.GetAccessKeyLastUsedInput, opts ...request.Option) (*iam.GetAccessKeyLastUsedOutput, error) {
+	used := &iam.AccessKeyLastUsed{}
+	if t, ok := m.lastUsed[aws.StringValue(input.AccessKeyId)]; ok {
+		used.LastUsedDate = aws.Time(t)
+	}
+
+	return &iam.GetAccessKeyLastUsedOutput{AccessKeyLastUsed: used}, nil
+}
+
+func TestCreateAccessKey(t *testing.T) {
+	created := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	newKeys := func(statuses ...string) []*iam.AccessKeyMetadata {
+		keys := []*iam.AccessKeyMetadata{}
+		for i, s := range statuses {
+			keys = append(keys, &iam.AccessKeyMetadata{
+				UserName:    aws.String("someuser"),
+				AccessKeyId: aws.String([]string{"KEY1", "KEY2"}[i]),
+				Status:      aws.String(s),
+				CreateDate:  aws.Time(created),
+			})
+		}
+		return keys
+	}
+
+	// test room for the key
+	client := &mockAccessKeysIAM{keys: newKeys("Active"), max: 2}
+	out, replaced, err := createAccessKey(context.TODO(), iamapi.IAM{Service: client}, "someuser", false)
+	if err != nil || aws.StringValue(out.AccessKey.AccessKeyId) != "NEWKEY" || replaced != nil {
+		t.Errorf("expected the new key without replacing one, got %v, %v, %v", out, replaced, err)
+	}
+
+	// test no room for the key without replacing
+	client = &mockAccessKeysIAM{keys: newKeys("Active", "Inactive"), max: 2}
+	if _, _, err := createAccessKey(context.TODO(), iamapi.IAM{Service: client}, "someuser", false); !common.IsErrorCode(err, apierror.ErrConflict) {
+		t.Errorf("expected error code %s, got %v", apierror.ErrConflict, err)
+	}
+
+	if len(client.deleted) != 0 {
+		t.Errorf("expected no keys to be deleted, got %v", client.deleted)
+	}
+
+	// test replacing the least recently used inactive key
+	client = &mockAccessKeysIAM{
+		keys:     newKeys("Inactive", "Inactive"),
+		lastUsed: map[string]time.Time{"KEY1": created.Add(48 * time.Hour), "KEY2": created.Add(24 * time.Hour)},
+		max:      2,
+	}
+	out, replaced, err = createAccessKey(context.TODO(), iamapi.IAM{Service: client}, "someuser", true)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if aws.StringValue(out.AccessKey.AccessKeyId) != "NEWKEY" || aws.StringValue(replaced) != "KEY2" {
+		t.Errorf("expected KEY2 to be replaced with NEWKEY, got %s replacing %s", aws.StringValue(out.AccessKey.AccessKeyId), aws.StringValue(replaced))
+	}
+
+	// test no inactive key to replace
+	client = &mockAccessKeysIAM{keys: newKeys("Active", "Active"), max: 2}
+	if _, _, err := createAccessKey(context.TODO(), iamapi.IAM{Service: client}, "someuser", true); !common.IsErrorCode(err, apierror.ErrConflict) {
+		t.Errorf("expected error code %s, got %v", apierror.ErrConflict, err)
+	}
+
+	if len(client.deleted) != 0 {
+		t.Errorf("expected no keys to be deleted, got %v", client.deleted)
+	}
+
+	// test throttling isn't mistaken for the key limit
+	client = &mockAccessKeysIAM{keys: newKeys("Active", "Inactive"), max: 2, err: awserr.New(iam.ErrCodeReportGenerationLimitExceededException, "slow down", nil)}
+	if _, _, err := createAccessKey(context.TODO(), iamapi.IAM{Service: client}, "someuser", true); !common.IsErrorCode(err, apierror.ErrLimitExceeded) {
+		t.Errorf("expected error code %s, got %v", apierror.ErrLimitExceeded, err)
+	}
+
+	if len(client.deleted) != 0 {
+		t.Errorf("expected no keys to be deleted, got %v", client.deleted)
+	}
+}
@@ -2,8 +2,14 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
 
+	"github.com/YaleSpinup/apierror"
 	"github.com/YaleSpinup/aws-go/services/iam"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -28,3 +34,568 @@ func generatePolicy(actions ...string) (string, error) {
 
 	return string(j), nil
 }
+
+// iamResources are the resource templates for a type of IAM resource named with one of the names of a policyScope
+// field, with or without an iam path, ie. arn:aws:iam::{{.Account}}:group/foo-BktAdmGrp
+func iamResources(resourceType, names string) []string {
+	return []string{
+		"{{range ." + names + "}}arn:aws:iam::{{$.Account}}:" + resourceType + "/{{.}} {{end}}",
+		"{{range ." + names + "}}arn:aws:iam::{{$.Account}}:" + resourceType + "/*/{{.}} {{end}}",
+	}
+}
+
+// scopeValuePattern is the pattern of the values a scoped policy can be filled in with, they can't widen the
+// resources with wildcards or policy variables
+var scopeValuePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// policyScope is what a scoped session policy is limited to
+type policyScope struct {
+	Account string
+	Bucket  string
+}
+
+// Groups are the names of the bucket's management groups, from the naming templates
+func (s policyScope) Groups() []string {
+	return iamapi.BucketGroupNames(s.Bucket)
+}
+
+// Policies are the names of the bucket's policies, from the naming templates
+func (s policyScope) Policies() []string {
+	return iamapi.BucketPolicyNames(s.Bucket)
+}
+
+// Roles are the names of the bucket's roles, from the naming templates
+func (s policyScope) Roles() []string {
+	return iamapi.BucketRoleNames(s.Bucket)
+}
+
+// scopedStatement is a statement in a scoped session policy.  The resources are templates filled in with the
+// policyScope of the request, ie. arn:aws:s3:::{{.Bucket}}.  A template can fill in several resources separated by
+// spaces, ie. one for each of the names of the bucket's groups.
+type scopedStatement struct {
+	Actions   []string
+	Resources []string
+}
+
+// scopedPolicy is a template for a session policy that only allows the actions an operation needs on the resources
+// it manages
+type scopedPolicy []scopedStatement
+
+// generate fills in the resources of the scoped policy with the scope and returns the session policy document
+func (p scopedPolicy) generate(scope policyScope) (string, error) {
+	for _, v := range []string{scope.Account, scope.Bucket} {
+		if !scopeValuePattern.MatchString(v) {
+			msg := fmt.Sprintf("invalid policy scope value '%s'", v)
+			return "", apierror.New(apierror.ErrBadRequest, msg, nil)
+		}
+	}
+
+	log.Debugf("generating scoped policy document for %+v", scope)
+
+	policy := iam.PolicyDocument{
+		Version:   "2012-10-17",
+		Statement: []iam.StatementEntry{},
+	}
+
+	for _, s := range p {
+		resources := []string{}
+		for _, r := range s.Resources {
+			t, err := template.New("resource").Option("missingkey=error").Parse(r)
+			if err != nil {
+				return "", apierror.New(apierror.ErrInternalError, "cannot generate policy", err)
+			}
+
+			var out strings.Builder
+			if err := t.Execute(&out, scope); err != nil {
+				return "", apierror.New(apierror.ErrInternalError, "cannot generate policy", err)
+			}
+			resources = append(resources, strings.Fields(out.String())...)
+		}
+
+		policy.Statement = append(policy.Statement, iam.StatementEntry{
+			Effect:   "Allow",
+			Action:   s.Actions,
+			Resource: resources,
+		})
+	}
+
+	j, err := json.Marshal(policy)
+	if err != nil {
+		return "", apierror.New(apierror.ErrInternalError, "cannot generate policy", err)
+	}
+
+	return string(j), nil
+}
+
+var (
+	// bucketResources are a bucket and its objects
+	bucketResources = []string{"arn:aws:s3:::{{.Bucket}}", "arn:aws:s3:::{{.Bucket}}/*"}
+
+	// bucketGroupResources are the management groups of a bucket, with or without an iam path
+	bucketGroupResources = iamResources("group", "Groups")
+
+	// bucketPolicyResources are the managed policies of a bucket, with or without an iam path
+	bucketPolicyResources = iamResources("policy", "Policies")
+
+	// bucketRoleResources are the roles of a bucket, with or without an iam path
+	bucketRoleResources = iamResources("role", "Roles")
+
+	// userCleanupActions are the actions needed to delete a user and everything attached to it.  Users aren't named
+	// for their buckets so they can't be scoped.
+	userCleanupActions = []string{
+		"iam:GetUser",
+		"iam:DeleteUser",
+		"iam:ListAccessKeys",
+		"iam:DeleteAccessKey",
+		"iam:ListSigningCertificates",
+		"iam:DeleteSigningCertificate",
+		"iam:ListSSHPublicKeys",
+		"iam:DeleteSSHPublicKey",
+		"iam:ListServiceSpecificCredentials",
+		"iam:DeleteServiceSpecificCredential",
+		"iam:ListMFADevices",
+		"iam:DeactivateMFADevice",
+		"iam:DeleteVirtualMFADevice",
+		"iam:DeleteLoginProfile",
+		"iam:ListUserPolicies",
+		"iam:DeleteUserPolicy",
+		"iam:ListAttachedUserPolicies",
+		"iam:DetachUserPolicy",
+		"iam:ListGroupsForUser",
+		"iam:RemoveUserFromGroup",
+	}
+
+	// bucketCreatePolicy allows creating a bucket and its admin group and policy, and rolling them back
+	bucketCreatePolicy = scopedPolicy{
+		{
+			Actions: []string{
+				"s3:CreateBucket",
+				"s3:DeleteBucket",
+				"s3:ListBucket",
+				"s3:GetBucket*",
+				"s3:PutBucket*",
+				"s3:GetLifecycleConfiguration",
+				"s3:PutLifecycleConfiguration",
+				"s3:GetEncryptionConfiguration",
+				"s3:PutEncryptionConfiguration",
+			},
+			Resources: bucketResources,
+		},
+		{
+			Actions: []string{
+				"iam:GetGroup",
+				"iam:CreateGroup",
+				"iam:DeleteGroup",
+				"iam:AttachGroupPolicy",
+				"iam:DetachGroupPolicy",
+				"iam:ListAttachedGroupPolicies",
+			},
+			Resources: bucketGroupResources,
+		},
+		{
+			Actions: []string{
+				"iam:GetPolicy",
+				"iam:CreatePolicy",
+				"iam:DeletePolicy",
+				"iam:ListPolicyVersions",
+				"iam:DeletePolicyVersion",
+				"iam:TagPolicy",
+			},
+			Resources: bucketPolicyResources,
+		},
+		{
+			Actions:   quotaCheckActions,
+			Resources: []string{"*"},
+		},
+	}
+
+	// bucketDeletePolicy allows deleting an empty bucket, its groups and policies, and the users in its groups
+	bucketDeletePolicy = scopedPolicy{
+		{
+			Actions: []string{
+				"s3:DeleteBucket",
+				"s3:ListBucket",
+				"s3:GetBucketPolicy",
+				"s3:GetBucketTagging",
+			},
+			Resources: bucketResources,
+		},
+		{
+			Actions: []string{
+				"iam:GetGroup",
+				"iam:DeleteGroup",
+				"iam:DetachGroupPolicy",
+				"iam:ListAttachedGroupPolicies",
+			},
+			Resources: bucketGroupResources,
+		},
+		{
+			Actions: []string{
+				"iam:DeletePolicy",
+				"iam:ListPolicyVersions",
+				"iam:DeletePolicyVersion",
+				"iam:ListEntitiesForPolicy",
+			},
+			Resources: bucketPolicyResources,
+		},
+		{
+			Actions: []string{
+				"iam:GetRole",
+			},
+			Resources: bucketRoleResources,
+		},
+		{
+			Actions:   userCleanupActions,
+			Resources: []string{"*"},
+		},
+	}
+
+	// bucketAdoptPolicy allows configuring and tagging an existing bucket and creating its admin group and policy
+	bucketAdoptPolicy = scopedPolicy{
+		{
+			Actions: []string{
+				"s3:ListBucket",
+				"s3:GetBucketTagging",
+				"s3:PutBucketTagging",
+				"s3:GetEncryptionConfiguration",
+				"s3:PutEncryptionConfiguration",
+				"s3:GetBucketLogging",
+				"s3:PutBucketLogging",
+			},
+			Resources: bucketResources,
+		},
+		{
+			Actions: []string{
+				"iam:GetGroup",
+				"iam:CreateGroup",
+				"iam:AttachGroupPolicy",
+				"iam:ListAttachedGroupPolicies",
+			},
+			Resources: bucketGroupResources,
+		},
+		{
+			Actions: []string{
+				"iam:CreatePolicy",
+				"iam:TagPolicy",
+			},
+			Resources: bucketPolicyResources,
+		},
+	}
+
+	// bucketSFTPPolicy allows managing the sftp role and policy of a bucket and the users on the transfer server.
+	// Transfer users aren't named for their buckets so they can't be scoped.
+	bucketSFTPPolicy = scopedPolicy{
+		{
+			Actions: []string{
+				"s3:ListBucket",
+				"s3:GetBucketTagging",
+			},
+			Resources: bucketResources,
+		},
+		{
+			Actions: []string{
+				"iam:GetRole",
+				"iam:CreateRole",
+				"iam:DeleteRole",
+				"iam:TagRole",
+				"iam:AttachRolePolicy",
+				"iam:DetachRolePolicy",
+				"iam:PassRole",
+			},
+			Resources: bucketRoleResources,
+		},
+		{
+			Actions: []string{
+				"iam:GetPolicy",
+				"iam:CreatePolicy",
+				"iam:DeletePolicy",
+				"iam:ListPolicyVersions",
+				"iam:DeletePolicyVersion",
+				"iam:TagPolicy",
+			},
+			Resources: bucketPolicyResources,
+		},
+		{
+			Actions: []string{
+				"transfer:DescribeServer",
+				"transfer:ListUsers",
+				"transfer:CreateUser",
+				"transfer:DescribeUser",
+				"transfer:DeleteUser",
+				"transfer:ImportSshPublicKey",
+				"transfer:DeleteSshPublicKey",
+				"transfer:TagResource",
+			},
+			Resources: []string{"*"},
+		},
+	}
+
+	// bucketAccessPointPolicy allows managing the access points of a bucket.  Access points aren't named for their
+	// buckets so they can't be scoped to the bucket, and listing them can't be scoped at all.
+	bucketAccessPointPolicy = scopedPolicy{
+		{
+			Actions: []string{
+				"s3:ListBucket",
+			},
+			Resources: bucketResources,
+		},
+		{
+			Actions: []string{
+				"s3:CreateAccessPoint",
+				"s3:GetAccessPoint",
+				"s3:DeleteAccessPoint",
+				"s3:GetAccessPointPolicy",
+				"s3:PutAccessPointPolicy",
+			},
+			Resources: []string{"arn:aws:s3:*:{{.Account}}:accesspoint/*"},
+		},
+		{
+			Actions: []string{
+				"s3:ListAccessPoints",
+			},
+			Resources: []string{"*"},
+		},
+	}
+
+	// bucketAllowlistPolicy allows replacing the document of the admin policy of a bucket
+	bucketAllowlistPolicy = scopedPolicy{
+		{
+			Actions: []string{
+				"iam:ListPolicyVersions",
+				"iam:CreatePolicyVersion",
+				"iam:DeletePolicyVersion",
+			},
+			Resources: bucketPolicyResources,
+		},
+	}
+
+	// bucketPolicyVersionsPolicy allows listing the versions of the managed policies of a bucket and restoring them
+	bucketPolicyVersionsPolicy = scopedPolicy{
+		{
+			Actions: []string{
+				"iam:ListPolicyVersions",
+				"iam:GetPolicyVersion",
+				"iam:SetDefaultPolicyVersion",
+			},
+			Resources: bucketPolicyResources,
+		},
+	}
+
+	// bucketGroupPoliciesPolicy allows attaching and detaching managed policies on the management groups of a bucket
+	bucketGroupPoliciesPolicy = scopedPolicy{
+		{
+			Actions: []string{
+				"iam:AttachGroupPolicy",
+				"iam:DetachGroupPolicy",
+				"iam:ListAttachedGroupPolicies",
+			},
+			Resources: bucketGroupResources,
+		},
+	}
+
+	// bucketDataProtectionPolicy allows adding and removing the deny-delete statement of a bucket policy
+	bucketDataProtectionPolicy = scopedPolicy{
+		{
+			Actions: []string{
+				"s3:GetBucketPolicy",
+				"s3:PutBucketPolicy",
+				"s3:DeleteBucketPolicy",
+			},
+			Resources: bucketResources,
+		},
+	}
+
+	// userDeletePolicy allows deleting a user and everything attached to it
+	userDeletePolicy = scopedPolicy{
+		{
+			Actions:   userCleanupActions,
+			Resources: []string{"*"},
+		},
+	}
+
+	// userCreatePolicy allows creating a user in the management groups of a bucket, creating the groups and their
+	// policies that don't exist, and rolling them back.  Users aren't named for their buckets so they can't be scoped.
+	userCreatePolicy = scopedPolicy{
+		{
+			Actions: []string{
+				"s3:GetBucketTagging",
+			},
+			Resources: bucketResources,
+		},
+		{
+			Actions: []string{
+				"iam:GetGroup",
+				"iam:CreateGroup",
+				"iam:DeleteGroup",
+				"iam:AttachGroupPolicy",
+				"iam:DetachGroupPolicy",
+				"iam:AddUserToGroup",
+				"iam:RemoveUserFromGroup",
+			},
+			Resources: bucketGroupResources,
+		},
+		{
+			Actions: []string{
+				"iam:CreatePolicy",
+				"iam:DeletePolicy",
+				"iam:ListPolicyVersions",
+				"iam:DeletePolicyVersion",
+				"iam:TagPolicy",
+			},
+			Resources: bucketPolicyResources,
+		},
+		{
+			Actions: []string{
+				"iam:GetUser",
+				"iam:CreateUser",
+				"iam:DeleteUser",
+				"iam:TagUser",
+			},
+			Resources: []string{"*"},
+		},
+		{
+			Actions:   quotaCheckActions,
+			Resources: []string{"*"},
+		},
+	}
+
+	// userKeyPolicy allows replacing the access keys of a user, the actions to deliver the new key are added with
+	// withKeyDelivery
+	userKeyPolicy = scopedPolicy{
+		{
+			Actions: []string{
+				"iam:ListAccessKeys",
+				"iam:CreateAccessKey",
+				"iam:DeleteAccessKey",
+				"iam:GetAccessKeyLastUsed",
+			},
+			Resources: []string{"*"},
+		},
+	}
+
+	// websiteCreatePolicy allows creating a website bucket, its admin groups and policies, its distribution and dns
+	// records, and rolling them back.  Distributions, the signing keys and response headers policies they share, and
+	// hosted zones aren't named for the website so they can't be scoped.
+	websiteCreatePolicy = scopedPolicy{
+		{
+			Actions: []string{
+				"s3:CreateBucket",
+				"s3:DeleteBucket",
+				"s3:ListBucket",
+				"s3:GetBucket*",
+				"s3:PutBucket*",
+				"s3:PutEncryptionConfiguration",
+				"s3:PutObject",
+				"s3:PutObjectTagging",
+				"s3:DeleteObject",
+			},
+			Resources: bucketResources,
+		},
+		{
+			Actions: []string{
+				"iam:GetGroup",
+				"iam:CreateGroup",
+				"iam:DeleteGroup",
+				"iam:AttachGroupPolicy",
+				"iam:DetachGroupPolicy",
+			},
+			Resources: bucketGroupResources,
+		},
+		{
+			Actions: []string{
+				"iam:CreatePolicy",
+				"iam:DeletePolicy",
+				"iam:ListPolicyVersions",
+				"iam:DeletePolicyVersion",
+				"iam:TagPolicy",
+			},
+			Resources: bucketPolicyResources,
+		},
+		{
+			Actions: []string{
+				"cloudfront:ListDistributions",
+				"cloudfront:CreateDistribution",
+				"cloudfront:GetDistribution",
+				"cloudfront:GetDistributionConfig",
+				"cloudfront:UpdateDistribution",
+				"cloudfront:TagResource",
+				"cloudfront:ListPublicKeys",
+				"cloudfront:CreatePublicKey",
+				"cloudfront:ListKeyGroups",
+				"cloudfront:GetKeyGroupConfig",
+				"cloudfront:CreateKeyGroup",
+				"cloudfront:UpdateKeyGroup",
+				"cloudfront:ListResponseHeadersPolicies",
+				"cloudfront:GetResponseHeadersPolicyConfig",
+				"cloudfront:CreateResponseHeadersPolicy",
+				"cloudfront:UpdateResponseHeadersPolicy",
+				"route53:ListHostedZones",
+				"route53:ChangeResourceRecordSets",
+			},
+			Resources: []string{"*"},
+		},
+		{
+			Actions:   quotaCheckActions,
+			Resources: []string{"*"},
+		},
+	}
+
+	// websiteDeletePolicy allows deleting or soft deleting an empty website bucket, its groups and policies, the users
+	// in its groups, its dns records and its distribution.  Listing groups can't be scoped and distributions and
+	// hosted zones aren't named for the website.
+	websiteDeletePolicy = scopedPolicy{
+		{
+			Actions: []string{
+				"s3:DeleteBucket",
+				"s3:ListBucket",
+				"s3:GetBucketPolicy",
+				"s3:PutBucketPolicy",
+				"s3:GetBucketTagging",
+				"s3:PutBucketTagging",
+				"s3:GetBucketWebsite",
+				"s3:GetObjectTagging",
+				"s3:DeleteObject",
+			},
+			Resources: bucketResources,
+		},
+		{
+			Actions: []string{
+				"iam:GetGroup",
+				"iam:DeleteGroup",
+				"iam:DetachGroupPolicy",
+				"iam:ListAttachedGroupPolicies",
+				"iam:RemoveUserFromGroup",
+			},
+			Resources: bucketGroupResources,
+		},
+		{
+			Actions: []string{
+				"iam:DeletePolicy",
+				"iam:ListPolicyVersions",
+				"iam:DeletePolicyVersion",
+			},
+			Resources: bucketPolicyResources,
+		},
+		{
+			Actions: append([]string{
+				"iam:ListGroups",
+				"iam:ListUserTags",
+				"iam:TagUser",
+				"iam:UpdateAccessKey",
+			}, userCleanupActions...),
+			Resources: []string{"*"},
+		},
+		{
+			Actions: []string{
+				"cloudfront:ListDistributions",
+				"cloudfront:GetDistributionConfig",
+				"cloudfront:UpdateDistribution",
+				"route53:ListHostedZones",
+				"route53:ListResourceRecordSets",
+				"route53:ChangeResourceRecordSets",
+				"route53:DeleteHealthCheck",
+			},
+			Resources: []string{"*"},
+		},
+	}
+)
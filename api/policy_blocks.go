@@ -0,0 +1,148 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// policyBlockInput returns what the statement blocks of a bucket's policies are generated from.  The api's role is
+// exempt from the deny blocks so the bucket can still be managed, except for the deny-delete block which only exempts
+// the break-glass role.
+func (s *server) policyBlockInput(accountId, bucket string) iamapi.BlockInput {
+	in := iamapi.BlockInput{
+		Bucket:           bucket,
+		ExemptPrincipals: []string{s.roleArn(accountId)},
+		VpcEndpoints:     s.account.VpcEndpoints,
+		IPAllowlist:      s.account.IPAllowlist,
+	}
+
+	if s.account.BreakGlassRole != "" {
+		in.BreakGlassPrincipals = []string{s.account.GetBreakGlassRole(accountId)}
+	}
+
+	return in
+}
+
+// bucketPolicyBlocks returns the names of the blocks that can be toggled in a bucket policy
+func bucketPolicyBlocks() []string {
+	names := []string{}
+	for name, block := range iamapi.StatementBlocks {
+		if block.BucketPolicy {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// validateBucketPolicyBlock returns a bad request error if the name isn't one of the blocks of a bucket policy
+func validateBucketPolicyBlock(name string) error {
+	if block, ok := iamapi.StatementBlocks[name]; !ok || !block.BucketPolicy {
+		msg := fmt.Sprintf("unsupported bucket policy block %s, expected one of %s", name, strings.Join(bucketPolicyBlocks(), ", "))
+		return apierror.New(apierror.ErrBadRequest, msg, nil)
+	}
+
+	return nil
+}
+
+// bucketPolicyBlock adds the statement of a bucket policy block to a bucket policy document, or removes it, and
+// returns the policy and the number of statements in it.  The statement replaces the block's current statement so
+// it's regenerated from the current configuration.
+func (s *server) bucketPolicyBlock(accountId, bucket, policy, name string, enabled bool) (string, int, error) {
+	if err := validateBucketPolicyBlock(name); err != nil {
+		return "", 0, err
+	}
+
+	if !enabled {
+		return removePolicyStatement(policy, iamapi.StatementBlocks[name].Sid)
+	}
+
+	statements, err := iamapi.ComposeStatements(s.policyBlockInput(accountId, bucket), name)
+	if err != nil {
+		return "", 0, err
+	}
+
+	for _, st := range statements {
+		statement, err := statementMap(st)
+		if err != nil {
+			return "", 0, err
+		}
+
+		if policy, err = addPolicyStatement(policy, statement); err != nil {
+			return "", 0, err
+		}
+	}
+
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(policy), &doc); err != nil {
+		return "", 0, err
+	}
+
+	return policy, len(policyStatements(doc)), nil
+}
+
+// updateBucketPolicyBlock adds a bucket policy block to the policy of a bucket, or removes it.  The policy is deleted
+// if removing the block leaves it empty.
+func (s *server) updateBucketPolicyBlock(ctx context.Context, s3Service s3api.S3, accountId, bucket, name string, enabled bool) error {
+	current, err := s3Service.GetBucketPolicy(ctx, bucket)
+	if err != nil {
+		return err
+	}
+
+	policy, remaining, err := s.bucketPolicyBlock(accountId, bucket, current, name, enabled)
+	if err != nil {
+		if _, ok := common.AsAPIError(err); ok {
+			return err
+		}
+		msg := fmt.Sprintf("failed to update the %s block of the policy for bucket %s", name, bucket)
+		return apierror.New(apierror.ErrBadRequest, msg, err)
+	}
+
+	if remaining == 0 {
+		if current == "" {
+			return nil
+		}
+		return s3Service.DeleteBucketPolicy(ctx, bucket)
+	}
+
+	// the policy isn't rewritten if the block is already in the state it should be
+	if equal, err := policiesEqual(current, policy); err == nil && equal {
+		return nil
+	}
+
+	return s3Service.UpdateBucketPolicy(ctx, &s3.PutBucketPolicyInput{
+		Bucket: aws.String(bucket),
+		Policy: aws.String(policy),
+	})
+}
+
+// hasPolicyBlock returns true if the bucket policy document has the statement of the bucket policy block
+func hasPolicyBlock(policy, name string) bool {
+	return hasPolicyStatement(policy, iamapi.StatementBlocks[name].Sid)
+}
+
+// statementMap converts a policy statement to the generic form the bucket policy documents are edited in
+func statementMap(st iamapi.PolicyStatement) (map[string]interface{}, error) {
+	j, err := json.Marshal(st)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := map[string]interface{}{}
+	if err := json.Unmarshal(j, &statement); err != nil {
+		return nil, err
+	}
+
+	return statement, nil
+}
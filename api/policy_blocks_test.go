@@ -0,0 +1,77 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/YaleSpinup/s3-api/session"
+)
+
+func TestBucketPolicyBlocks(t *testing.T) {
+	expected := "deny-delete,ip-restricted,vpc-restricted,website-read"
+	if out := strings.Join(bucketPolicyBlocks(), ","); out != expected {
+		t.Errorf("expected bucket policy blocks %s, got %s", expected, out)
+	}
+}
+
+func TestBucketPolicyBlock(t *testing.T) {
+	s := &server{
+		account: common.Account{
+			IPAllowlist:    []string{"10.0.0.0/8"},
+			BreakGlassRole: "arn:aws:iam::{account_id}:role/BreakGlass",
+		},
+		session: &session.Session{RoleName: "SpinupS3Role"},
+	}
+	userPolicy := `{"Version":"2012-10-17","Statement":[{"Sid":"AllowRead","Effect":"Allow","Principal":{"AWS":"arn:aws:iam::012345678910:root"},"Action":"s3:GetObject","Resource":"arn:aws:s3:::dataset/*"}]}`
+
+	policy, remaining, err := s.bucketPolicyBlock("012345678910", "dataset", userPolicy, iamapi.BlockDenyDelete, true)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if remaining != 2 || !hasPolicyBlock(policy, iamapi.BlockDenyDelete) || !hasPolicyStatement(policy, "AllowRead") {
+		t.Errorf("expected the policy with the deny-delete statement, got %s", policy)
+	}
+
+	if !strings.Contains(policy, `"aws:PrincipalArn":["arn:aws:iam::012345678910:role/BreakGlass"]`) {
+		t.Errorf("expected only the break-glass role to be exempt, got %s", policy)
+	}
+
+	// adding a block again replaces its statement
+	if policy, remaining, err = s.bucketPolicyBlock("012345678910", "dataset", policy, iamapi.BlockDenyDelete, true); err != nil || remaining != 2 {
+		t.Errorf("expected 2 statements, got %d (%v)", remaining, err)
+	}
+
+	if policy, remaining, err = s.bucketPolicyBlock("012345678910", "dataset", policy, iamapi.BlockIPRestricted, true); err != nil || remaining != 3 {
+		t.Errorf("expected 3 statements, got %d (%v)", remaining, err)
+	}
+
+	if !strings.Contains(policy, `"NotIpAddress":{"aws:SourceIp":["10.0.0.0/8"]}`) {
+		t.Errorf("expected the policy to be restricted to the account allowlist, got %s", policy)
+	}
+
+	if !strings.Contains(policy, `"aws:PrincipalArn":["arn:aws:iam::012345678910:role/SpinupS3Role"]`) {
+		t.Errorf("expected the api's role to be exempt from the ip restriction, got %s", policy)
+	}
+
+	// removing the blocks keeps the rest of the policy
+	for _, b := range []string{iamapi.BlockDenyDelete, iamapi.BlockIPRestricted} {
+		if policy, remaining, err = s.bucketPolicyBlock("012345678910", "dataset", policy, b, false); err != nil {
+			t.Fatalf("expected nil error, got %s", err)
+		}
+	}
+
+	if remaining != 1 || !hasPolicyStatement(policy, "AllowRead") {
+		t.Errorf("expected only the user statement to remain, got %s", policy)
+	}
+
+	// blocks that don't belong in a bucket policy and blocks that aren't configured are rejected
+	for _, b := range []string{iamapi.BlockAdmin, "everything", iamapi.BlockVpcRestricted} {
+		if _, _, err := s.bucketPolicyBlock("012345678910", "dataset", policy, b, true); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+			t.Errorf("expected bad request adding %s, got %v", b, err)
+		}
+	}
+}
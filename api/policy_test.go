@@ -0,0 +1,238 @@
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/aws-go/services/iam"
+	"github.com/YaleSpinup/s3-api/common"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+)
+
+func TestScopedPolicyGenerate(t *testing.T) {
+	policy := scopedPolicy{
+		{
+			Actions:   []string{"s3:DeleteBucket"},
+			Resources: bucketResources,
+		},
+		{
+			Actions:   []string{"iam:DeleteGroup"},
+			Resources: bucketGroupResources,
+		},
+		{
+			Actions:   []string{"iam:GetAccountSummary"},
+			Resources: []string{"*"},
+		},
+	}
+
+	out, err := policy.generate(policyScope{Account: "012345678910", Bucket: "foobucket"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	var doc iam.PolicyDocument
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("failed to unmarshal generated policy: %s", err)
+	}
+
+	expected := []iam.StatementEntry{
+		{
+			Effect:   "Allow",
+			Action:   []string{"s3:DeleteBucket"},
+			Resource: []string{"arn:aws:s3:::foobucket", "arn:aws:s3:::foobucket/*"},
+		},
+		{
+			Effect: "Allow",
+			Action: []string{"iam:DeleteGroup"},
+			Resource: []string{
+				"arn:aws:iam::012345678910:group/foobucket-BktAdmGrp",
+				"arn:aws:iam::012345678910:group/foobucket-BktRWGrp",
+				"arn:aws:iam::012345678910:group/foobucket-BktROGrp",
+				"arn:aws:iam::012345678910:group/foobucket-WebAdmGrp",
+				"arn:aws:iam::012345678910:group/*/foobucket-BktAdmGrp",
+				"arn:aws:iam::012345678910:group/*/foobucket-BktRWGrp",
+				"arn:aws:iam::012345678910:group/*/foobucket-BktROGrp",
+				"arn:aws:iam::012345678910:group/*/foobucket-WebAdmGrp",
+			},
+		},
+		{
+			Effect:   "Allow",
+			Action:   []string{"iam:GetAccountSummary"},
+			Resource: []string{"*"},
+		},
+	}
+
+	if doc.Version != "2012-10-17" {
+		t.Errorf("expected version 2012-10-17, got %s", doc.Version)
+	}
+
+	if !reflect.DeepEqual(expected, doc.Statement) {
+		t.Errorf("expected statements %+v, got %+v", expected, doc.Statement)
+	}
+
+	// values that would widen the resources are rejected
+	for _, scope := range []policyScope{
+		{Account: "012345678910", Bucket: "*"},
+		{Account: "012345678910", Bucket: "foo/*"},
+		{Account: "012345678910", Bucket: "${aws:username}"},
+		{Account: "012345678910", Bucket: ""},
+		{Account: "*", Bucket: "foobucket"},
+	} {
+		_, err := policy.generate(scope)
+		if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrBadRequest {
+			t.Errorf("expected bad request error for scope %+v, got %v", scope, err)
+		}
+	}
+
+	// a resource referencing a field that isn't in the scope is an error
+	bad := scopedPolicy{{Actions: []string{"s3:GetObject"}, Resources: []string{"arn:aws:s3:::{{.Website}}"}}}
+	if _, err := bad.generate(policyScope{Account: "012345678910", Bucket: "foobucket"}); err == nil {
+		t.Error("expected error for a resource with an unknown field, got nil")
+	}
+}
+
+func TestScopedPolicyNaming(t *testing.T) {
+	if err := iamapi.SetNaming(&common.Naming{Group: "s3-{bucket}-{type}", Types: map[string]string{"BktAdmGrp": "Admins"}}); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+	defer iamapi.SetNaming(nil)
+
+	policy := scopedPolicy{{Actions: []string{"iam:DeleteGroup"}, Resources: bucketGroupResources}}
+	out, err := policy.generate(policyScope{Account: "012345678910", Bucket: "foobucket"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	var doc iam.PolicyDocument
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("failed to unmarshal generated policy: %s", err)
+	}
+
+	expected := []iam.StatementEntry{
+		{
+			Effect: "Allow",
+			Action: []string{"iam:DeleteGroup"},
+			Resource: []string{
+				"arn:aws:iam::012345678910:group/s3-foobucket-Admins",
+				"arn:aws:iam::012345678910:group/s3-foobucket-BktRWGrp",
+				"arn:aws:iam::012345678910:group/s3-foobucket-BktROGrp",
+				"arn:aws:iam::012345678910:group/s3-foobucket-WebAdmGrp",
+				"arn:aws:iam::012345678910:group/*/s3-foobucket-Admins",
+				"arn:aws:iam::012345678910:group/*/s3-foobucket-BktRWGrp",
+				"arn:aws:iam::012345678910:group/*/s3-foobucket-BktROGrp",
+				"arn:aws:iam::012345678910:group/*/s3-foobucket-WebAdmGrp",
+			},
+		},
+	}
+	if !reflect.DeepEqual(expected, doc.Statement) {
+		t.Errorf("expected the group resources to follow the naming template %v, got %+v", expected, doc.Statement)
+	}
+}
+
+// matchesResource returns true if an IAM resource pattern matches the arn, * matches any characters and ? matches one
+func matchesResource(pattern, arn string) bool {
+	re := "^" + strings.NewReplacer(`\*`, ".*", `\?`, ".").Replace(regexp.QuoteMeta(pattern)) + "$"
+	return regexp.MustCompile(re).MatchString(arn)
+}
+
+func TestScopedPolicyOtherBucket(t *testing.T) {
+	defer iamapi.SetNaming(nil)
+
+	for _, config := range []*common.Naming{
+		nil,
+		{Group: "s3-{bucket}-{type}", Policy: "{type}-{bucket}", Role: "{type}-{bucket}"},
+	} {
+		if err := iamapi.SetNaming(config); err != nil {
+			t.Fatalf("expected nil error, got %s", err)
+		}
+
+		out, err := bucketDeletePolicy.generate(policyScope{Account: "012345678910", Bucket: "foo"})
+		if err != nil {
+			t.Fatalf("expected nil error, got %s", err)
+		}
+
+		var doc iam.PolicyDocument
+		if err := json.Unmarshal([]byte(out), &doc); err != nil {
+			t.Fatalf("failed to unmarshal generated policy: %s", err)
+		}
+
+		allowed := func(arn string) bool {
+			for _, s := range doc.Statement {
+				for _, r := range s.Resource {
+					if r != "*" && matchesResource(r, arn) {
+						return true
+					}
+				}
+			}
+			return false
+		}
+
+		resources := func(bucket string) []string {
+			arns := []string{"arn:aws:s3:::" + bucket, "arn:aws:s3:::" + bucket + "/index.html"}
+			for _, g := range iamapi.BucketGroupNames(bucket) {
+				arns = append(arns, "arn:aws:iam::012345678910:group/"+g, "arn:aws:iam::012345678910:group/spinup/"+g)
+			}
+			for _, p := range iamapi.BucketPolicyNames(bucket) {
+				arns = append(arns, "arn:aws:iam::012345678910:policy/"+p, "arn:aws:iam::012345678910:policy/spinup/"+p)
+			}
+			for _, r := range iamapi.BucketRoleNames(bucket) {
+				arns = append(arns, "arn:aws:iam::012345678910:role/"+r, "arn:aws:iam::012345678910:role/spinup/"+r)
+			}
+			return arns
+		}
+
+		for _, arn := range resources("foo") {
+			if !allowed(arn) {
+				t.Errorf("expected the foo session to reach %s with naming %+v", arn, config)
+			}
+		}
+
+		for _, arn := range resources("foo-bar") {
+			if allowed(arn) {
+				t.Errorf("expected the foo session not to reach %s with naming %+v", arn, config)
+			}
+		}
+	}
+}
+
+func TestScopedPolicyActions(t *testing.T) {
+	policies := map[string]scopedPolicy{
+		"bucketAdoptPolicy":   bucketAdoptPolicy,
+		"userCreatePolicy":    userCreatePolicy,
+		"userKeyPolicy":       userKeyPolicy,
+		"websiteCreatePolicy": websiteCreatePolicy,
+		"websiteDeletePolicy": websiteDeletePolicy,
+	}
+
+	for name, policy := range policies {
+		out, err := policy.generate(policyScope{Account: "012345678910", Bucket: "foo"})
+		if err != nil {
+			t.Fatalf("expected nil error for %s, got %s", name, err)
+		}
+
+		var doc iam.PolicyDocument
+		if err := json.Unmarshal([]byte(out), &doc); err != nil {
+			t.Fatalf("failed to unmarshal generated policy %s: %s", name, err)
+		}
+
+		for _, s := range doc.Statement {
+			for _, a := range s.Action {
+				if strings.HasSuffix(a, ":*") {
+					t.Errorf("expected %s not to allow all of the actions of a service, got %s", name, a)
+				}
+			}
+
+			for _, r := range s.Resource {
+				for _, arn := range []string{"arn:aws:s3:::foo-bar", "arn:aws:iam::012345678910:group/foo-bar-BktAdmGrp", "arn:aws:iam::012345678910:policy/foo-bar-BktAdmPlc"} {
+					if r != "*" && matchesResource(r, arn) {
+						t.Errorf("expected %s for foo not to reach %s, got resource %s", name, arn, r)
+					}
+				}
+			}
+		}
+	}
+}
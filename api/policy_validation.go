@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"strings"
+
+	"github.com/YaleSpinup/apierror"
+	aaapi "github.com/YaleSpinup/s3-api/accessanalyzer"
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/accessanalyzer"
+	"github.com/pkg/errors"
+)
+
+// policyValidationActions are the actions needed to validate bucket policies with access analyzer
+var policyValidationActions = []string{
+	"access-analyzer:ValidatePolicy",
+	"access-analyzer:CheckNoPublicAccess",
+}
+
+// validateBucketPolicy validates a bucket policy with access analyzer if policy validation is configured, and returns
+// the findings that don't keep it from being applied
+func (s *server) validateBucketPolicy(ctx context.Context, sess *session.Session, policy string) ([]*aaapi.Finding, error) {
+	if s.account.PolicyValidation == nil {
+		return nil, nil
+	}
+
+	analyzer := aaapi.NewSession(sess, s.account)
+	findings, err := analyzer.ValidateBucketPolicy(ctx, policy)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to validate bucket policy")
+	}
+
+	return checkPolicyFindings(s.account.PolicyValidation, findings)
+}
+
+// checkPolicyFindings rejects a policy with errors, or that grants public access or has security warnings if they
+// aren't allowed, with the findings that blocked it.  The rest of the findings are returned.
+func checkPolicyFindings(config *common.PolicyValidation, findings []*aaapi.Finding) ([]*aaapi.Finding, error) {
+	warnings := []*aaapi.Finding{}
+	blocking := []string{}
+	for _, f := range findings {
+		switch {
+		case f.Type == accessanalyzer.ValidatePolicyFindingTypeError,
+			f.Type == accessanalyzer.ValidatePolicyFindingTypeSecurityWarning && config.RejectSecurityWarnings,
+			f.Type == aaapi.FindingTypePublicAccess && !config.AllowPublic:
+			blocking = append(blocking, f.String())
+		default:
+			warnings = append(warnings, f)
+		}
+	}
+
+	if len(blocking) > 0 {
+		return nil, apierror.New(apierror.ErrBadRequest, "bucket policy rejected by access analyzer: "+strings.Join(blocking, "; "), nil)
+	}
+
+	return warnings, nil
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	aaapi "github.com/YaleSpinup/s3-api/accessanalyzer"
+	"github.com/YaleSpinup/s3-api/common"
+)
+
+func TestCheckPolicyFindings(t *testing.T) {
+	policyError := &aaapi.Finding{Type: "ERROR", IssueCode: "MISSING_VERSION", Details: "missing version"}
+	securityWarning := &aaapi.Finding{Type: "SECURITY_WARNING", IssueCode: "PASS_ROLE_WITH_STAR_IN_RESOURCE", Details: "pass role"}
+	suggestion := &aaapi.Finding{Type: "SUGGESTION", IssueCode: "EMPTY_ARRAY_ACTION", Details: "empty array"}
+	public := &aaapi.Finding{Type: aaapi.FindingTypePublicAccess, Details: "statement 0: public access"}
+
+	tests := []struct {
+		name     string
+		config   common.PolicyValidation
+		findings []*aaapi.Finding
+		expected []*aaapi.Finding
+		err      bool
+	}{
+		{name: "no findings", findings: []*aaapi.Finding{}, expected: []*aaapi.Finding{}},
+		{name: "warnings", findings: []*aaapi.Finding{securityWarning, suggestion}, expected: []*aaapi.Finding{securityWarning, suggestion}},
+		{name: "error", findings: []*aaapi.Finding{policyError, suggestion}, err: true},
+		{name: "rejected security warning", config: common.PolicyValidation{RejectSecurityWarnings: true}, findings: []*aaapi.Finding{securityWarning}, err: true},
+		{name: "public", findings: []*aaapi.Finding{public}, err: true},
+		{name: "allowed public", config: common.PolicyValidation{AllowPublic: true}, findings: []*aaapi.Finding{public}, expected: []*aaapi.Finding{public}},
+	}
+
+	for _, test := range tests {
+		out, err := checkPolicyFindings(&test.config, test.findings)
+		if test.err {
+			if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrBadRequest {
+				t.Errorf("%s: expected bad request error, got %v", test.name, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: expected nil error, got %s", test.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(test.expected, out) {
+			t.Errorf("%s: expected %+v, got %+v", test.name, test.expected, out)
+		}
+	}
+}
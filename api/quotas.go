@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/YaleSpinup/apierror"
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	sqapi "github.com/YaleSpinup/s3-api/servicequotas"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// quotaCheckActions are the actions needed to check the service quotas before creating resources
+var quotaCheckActions = []string{
+	"servicequotas:GetServiceQuota",
+	"servicequotas:GetAWSDefaultServiceQuota",
+	"iam:GetAccountSummary",
+	"cloudfront:ListDistributions",
+}
+
+// quotaNeeds are the number of quota limited resources a request will create
+type quotaNeeds struct {
+	Users         int
+	Groups        int
+	Policies      int
+	Distributions int
+}
+
+// checkQuotas checks that the account has room under its service quotas for the resources a request needs, if quota
+// checks are configured for the account
+func (s *server) checkQuotas(ctx context.Context, sess *session.Session, accountId string, needs quotaNeeds) error {
+	if !s.account.CheckQuotas {
+		return nil
+	}
+
+	quotaService := sqapi.NewSession(sess, s.account)
+	iamService := iamapi.NewSession(sess, s.account)
+	cloudFrontService := cfapi.NewSession(sess, s.account, accountId)
+
+	return checkQuotas(ctx, &quotaService, &iamService, &cloudFrontService, needs)
+}
+
+// checkUserQuotas checks that the account has room under its service quotas for a new user and the groups it's being
+// added to that don't exist yet, each of which is created with a policy
+func (s *server) checkUserQuotas(ctx context.Context, sess *session.Session, accountId string, iamService iamapi.IAM, groupNames []string) error {
+	if !s.account.CheckQuotas {
+		return nil
+	}
+
+	needs := quotaNeeds{Users: 1}
+	for _, g := range groupNames {
+		if _, err := iamService.GetGroup(ctx, g); err != nil {
+			if !isNotFound(err) {
+				return err
+			}
+			needs.Groups++
+			needs.Policies++
+		}
+	}
+
+	return s.checkQuotas(ctx, sess, accountId, needs)
+}
+
+// checkQuotas compares the current usage plus the needs with each of the quotas, and returns a limit exceeded error
+// naming the quota if a request would go over it
+func checkQuotas(ctx context.Context, quotaService *sqapi.ServiceQuotas, iamService *iamapi.IAM, cloudFrontService *cfapi.CloudFront, needs quotaNeeds) error {
+	type check struct {
+		quota sqapi.Quota
+		need  int
+		used  int
+	}
+
+	checks := []*check{}
+	if needs.Users > 0 || needs.Groups > 0 || needs.Policies > 0 {
+		summary, err := iamService.GetAccountSummary(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to check service quotas")
+		}
+
+		checks = append(checks,
+			&check{sqapi.IAMUsers, needs.Users, int(summary[iam.SummaryKeyTypeUsers])},
+			&check{sqapi.IAMGroups, needs.Groups, int(summary[iam.SummaryKeyTypeGroups])},
+			&check{sqapi.IAMPolicies, needs.Policies, int(summary[iam.SummaryKeyTypePolicies])},
+		)
+	}
+
+	if needs.Distributions > 0 {
+		distributions, err := cloudFrontService.ListDistributions(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to check service quotas")
+		}
+
+		checks = append(checks, &check{sqapi.CloudFrontDistributions, needs.Distributions, len(distributions)})
+	}
+
+	for _, c := range checks {
+		if c.need == 0 {
+			continue
+		}
+
+		limit, err := quotaService.GetQuotaValue(ctx, c.quota)
+		if err != nil {
+			return errors.Wrap(err, "failed to check service quotas")
+		}
+
+		log.Debugf("%s service quota %s: %d of %d used, %d needed", c.quota.ServiceCode, c.quota.Name, c.used, limit, c.need)
+
+		if c.used+c.need > limit {
+			msg := fmt.Sprintf("quota exceeded: %s (%d of %d used, %d needed)", c.quota.Name, c.used, limit, c.need)
+			return apierror.New(apierror.ErrLimitExceeded, msg, nil)
+		}
+	}
+
+	return nil
+}
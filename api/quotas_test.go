@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	sqapi "github.com/YaleSpinup/s3-api/servicequotas"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/aws/aws-sdk-go/service/cloudfront/cloudfrontiface"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go/service/servicequotas"
+	"github.com/aws/aws-sdk-go/service/servicequotas/servicequotasiface"
+	"github.com/pkg/errors"
+)
+
+// mockQuotasIAM returns the iam usage of the account
+type mockQuotasIAM struct {
+	iamiface.IAMAPI
+	summary map[string]int64
+}
+
+func (m *mockQuotasIAM) GetAccountSummaryWithContext(ctx context.Context, input *iam.GetAccountSummaryInput, opts ...request.Option) (*iam.GetAccountSummaryOutput, error) {
+	return &iam.GetAccountSummaryOutput{SummaryMap: aws.Int64Map(m.summary)}, nil
+}
+
+// mockQuotasCloudFront returns a number of distributions
+type mockQuotasCloudFront struct {
+	cloudfrontiface.CloudFrontAPI
+	distributions int
+}
+
+func (m *mockQuotasCloudFront) ListDistributionsWithContext(ctx context.Context, input *cloudfront.ListDistributionsInput, opts ...request.Option) (*cloudfront.ListDistributionsOutput, error) {
+	items := make([]*cloudfront.DistributionSummary, m.distributions)
+	return &cloudfront.ListDistributionsOutput{DistributionList: &cloudfront.DistributionList{Items: items, IsTruncated: aws.Bool(false)}}, nil
+}
+
+// mockQuotas returns the quota values by quota code
+type mockQuotas struct {
+	servicequotasiface.ServiceQuotasAPI
+	values map[string]float64
+}
+
+func (m *mockQuotas) GetServiceQuotaWithContext(ctx context.Context, input *servicequotas.GetServiceQuotaInput, opts ...request.Option) (*servicequotas.GetServiceQuotaOutput, error) {
+	return &servicequotas.GetServiceQuotaOutput{Quota: &servicequotas.ServiceQuota{Value: aws.Float64(m.values[aws.StringValue(input.QuotaCode)])}}, nil
+}
+
+func TestCheckQuotas(t *testing.T) {
+	quotaService := &sqapi.ServiceQuotas{Service: &mockQuotas{values: map[string]float64{
+		sqapi.IAMUsers.QuotaCode:                5000,
+		sqapi.IAMGroups.QuotaCode:               300,
+		sqapi.IAMPolicies.QuotaCode:             1500,
+		sqapi.CloudFrontDistributions.QuotaCode: 200,
+	}}}
+	iamService := &iamapi.IAM{Service: &mockQuotasIAM{summary: map[string]int64{
+		iam.SummaryKeyTypeUsers:    10,
+		iam.SummaryKeyTypeGroups:   299,
+		iam.SummaryKeyTypePolicies: 100,
+	}}}
+	cloudFrontService := &cfapi.CloudFront{Service: &mockQuotasCloudFront{distributions: 200}}
+
+	tests := []struct {
+		name  string
+		needs quotaNeeds
+		err   string
+	}{
+		{"under the quotas", quotaNeeds{Users: 1, Groups: 1, Policies: 1}, ""},
+		{"over the groups quota", quotaNeeds{Groups: 2, Policies: 2}, "quota exceeded: groups per account (299 of 300 used, 2 needed)"},
+		{"over the distributions quota", quotaNeeds{Distributions: 1}, "quota exceeded: web distributions per account (200 of 200 used, 1 needed)"},
+		{"nothing needed", quotaNeeds{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkQuotas(context.TODO(), quotaService, iamService, cloudFrontService, tt.needs)
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("expected nil error, got %s", err)
+				}
+				return
+			}
+
+			aerr, ok := errors.Cause(err).(apierror.Error)
+			if !ok || aerr.Code != apierror.ErrLimitExceeded {
+				t.Fatalf("expected limit exceeded error, got %v", err)
+			}
+
+			if aerr.Message != tt.err {
+				t.Errorf("expected message %q, got %q", tt.err, aerr.Message)
+			}
+		})
+	}
+}
+
+func TestCheckQuotasDisabled(t *testing.T) {
+	s := server{}
+	if err := s.checkQuotas(context.TODO(), nil, "12345678910", quotaNeeds{Groups: 1}); err != nil {
+		t.Errorf("expected nil error when quota checks aren't configured, got %s", err)
+	}
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	route53api "github.com/YaleSpinup/s3-api/route53"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/YaleSpinup/s3-api/webhook"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	log "github.com/sirupsen/logrus"
+)
+
+// websiteReaper periodically tears down the soft deleted websites in an account once their retention has passed.  It
+// assumes the api's role like the handlers, the deny statement on a soft deleted website's bucket only exempts the role.
+type websiteReaper struct {
+	account  string
+	interval time.Duration
+	server   *server
+	context  context.Context
+}
+
+// run starts the reaper and listens for a shutdown call
+func (w *websiteReaper) run() {
+	ticker := time.NewTicker(w.interval)
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if err := w.action(); err != nil {
+					log.Errorf("reaper: error reaping websites for account %s: %s", w.account, err)
+				}
+			case <-w.context.Done():
+				log.Debug("reaper: shutting down reaper timer")
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	log.Infof("reaper: started for account %s with interval %s", w.account, w.interval)
+}
+
+// action deletes each of the org's websites that's pending deletion and past its deletion time.  Websites that have
+// been protected or aren't empty are skipped, and a failure to delete a website is logged and the sweep continues.
+func (w *websiteReaper) action() error {
+	log.Debugf("reaper: looking for websites to delete in account %s", w.account)
+
+	if w.server.inMaintenance(w.context) {
+		log.Infof("reaper: skipping reaping websites for account %s in read-only maintenance mode", w.account)
+		return nil
+	}
+
+	accountId := w.server.mapAccountNumber(w.account)
+	session, err := w.server.sessionForAccount(w.context, w.account, "s3:*", "iam:*", "cloudfront:*", "route53:*")
+	if err != nil {
+		return err
+	}
+
+	s3Service := s3api.NewSession(session.Session, w.server.account, w.server.mapToAccountName(accountId))
+	iamService := iamapi.NewSession(session.Session, w.server.account)
+	iamService.Cache = w.server.resourceCache(accountId)
+	cloudFrontService := cfapi.NewSession(session.Session, w.server.account, accountId)
+	cloudFrontService.Index = w.server.distributionIndex(accountId)
+	route53Service := route53api.NewSession(session.Session, w.server.account)
+
+	buckets, err := s3Service.ListBuckets(w.context, &s3.ListBucketsInput{})
+	if err != nil {
+		return err
+	}
+
+	deleted, failed := 0, 0
+	for _, b := range buckets {
+		website := aws.StringValue(b.Name)
+		tags, err := s3Service.GetBucketTags(w.context, website)
+		if err != nil {
+			log.Warnf("reaper: failed to get tags for bucket %s: %s", website, err)
+			failed++
+			continue
+		}
+
+		deleteAfter, pending := pendingDeleteTime(tags)
+		if !pending || s3TagMap(tags)["spinup:org"] != Org || time.Now().Before(deleteAfter) {
+			continue
+		}
+
+		if isProtected(tags) {
+			log.Warnf("reaper: website %s is pending deletion but it's protected, skipping", website)
+			continue
+		}
+
+		if err := w.reap(s3Service, iamService, cloudFrontService, route53Service, website); err != nil {
+			log.Warnf("reaper: failed to delete website %s: %s", website, err)
+			failed++
+			continue
+		}
+
+		w.server.deleteSoftDeleteRecord(w.context, w.account, website)
+		w.server.notify(webhook.EventWebsiteDeleted, w.account, website, nil)
+		deleted++
+	}
+
+	log.Infof("reaper: deleted %d websites in account %s, %d failed", deleted, w.account, failed)
+
+	return nil
+}
+
+// reap tears down a soft deleted website
+func (w *websiteReaper) reap(s3Service s3api.S3, iamService iamapi.IAM, cloudFrontService cfapi.CloudFront, route53Service route53api.Route53, website string) error {
+	lease, err := w.server.lockResource(w.context, w.account, website)
+	if err != nil {
+		return err
+	}
+	defer lease.Release()
+
+	empty, err := websiteEmpty(w.context, s3Service, website)
+	if err != nil {
+		return err
+	}
+
+	if !empty {
+		return fmt.Errorf("website bucket %s isn't empty", website)
+	}
+
+	// a simple website doesn't have a hosted zone
+	simple, err := simpleWebsite(w.context, s3Service, website)
+	if err != nil {
+		return err
+	}
+
+	var zoneID string
+	if !simple {
+		if zoneID, err = route53Service.ZoneIDForName(w.context, website); err != nil {
+			return err
+		}
+	}
+
+	output, err := deleteWebsite(w.context, s3Service, iamService, cloudFrontService, route53Service, zoneID, website)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("reaper: deleted website %s with %d groups and %d users", website, len(output.Groups), len(output.Users))
+
+	if output.Failed > 0 {
+		log.Warnf("reaper: %d resources of website %s were left behind, it can be repaired to finish the teardown", output.Failed, website)
+	}
+
+	return nil
+}
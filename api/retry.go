@@ -0,0 +1,44 @@
+package api
+
+import (
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/retry"
+)
+
+// transientErrors are the apierror codes that usually clear up on their own
+var transientErrors = []string{
+	apierror.ErrLimitExceeded,
+	apierror.ErrServiceUnavailable,
+	apierror.ErrInternalError,
+}
+
+// s3ConsistencyRetry is used when waiting for a newly created bucket to be visible and usable.  S3 may
+// briefly return NoSuchBucket or OperationAborted (conflict) after a bucket is created.
+var s3ConsistencyRetry = retry.Policy{
+	Attempts:   5,
+	Backoff:    1 * time.Second,
+	MaxBackoff: 8 * time.Second,
+	Budget:     30 * time.Second,
+	RetryOn:    append([]string{apierror.ErrNotFound, apierror.ErrConflict}, transientErrors...),
+}
+
+// iamPropagationRetry is used when waiting for newly created IAM resources to propagate
+var iamPropagationRetry = retry.Policy{
+	Attempts:   6,
+	Backoff:    1 * time.Second,
+	MaxBackoff: 10 * time.Second,
+	Budget:     45 * time.Second,
+	RetryOn:    append([]string{apierror.ErrNotFound}, transientErrors...),
+}
+
+// cloudFrontRetry is used for cloudfront distribution changes, which are heavily rate limited and can
+// fail while a previous change to the distribution is still deploying
+var cloudFrontRetry = retry.Policy{
+	Attempts:   4,
+	Backoff:    5 * time.Second,
+	MaxBackoff: 30 * time.Second,
+	Budget:     2 * time.Minute,
+	RetryOn:    transientErrors,
+}
@@ -6,18 +6,20 @@ import (
 	"strings"
 	"time"
 
+	"github.com/YaleSpinup/apierror"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
 	"github.com/YaleSpinup/s3-api/session"
 	stsSvc "github.com/YaleSpinup/s3-api/sts"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/google/uuid"
-	"github.com/patrickmn/go-cache"
 	log "github.com/sirupsen/logrus"
 )
 
 // assumeRole assumes the passed role arn.  if an externalId is set in the account to be accessed, it can be passed with the request. inline
 // policy can be passed to limit the access for the session.  policy arns can also be passed to limit access for the session.
-// Note: sessions live for 900s and will be cached for 600 seconds, giving a 300s buffer to avoid terminated sessions inside of orchestration
+// Note: sessions live for 900s and are cached until 300s before their credentials expire, giving a buffer to avoid terminated
+// sessions inside of orchestration
 func (s *server) assumeRole(ctx context.Context, externalId, roleArn, inlinePolicy string, policyArns ...string) (*session.Session, error) {
 	start := time.Now()
 	defer func() {
@@ -41,16 +43,12 @@ func (s *server) assumeRole(ctx context.Context, externalId, roleArn, inlinePoli
 		},
 	}
 
-	cacheKey := fmt.Sprintf("spinup_%s_%s", s.org, roleArn)
-
 	if externalId != "" {
 		input.SetExternalId(externalId)
-		cacheKey = cacheKey + "_" + externalId
 	}
 
 	if inlinePolicy != "" {
 		input.SetPolicy(inlinePolicy)
-		cacheKey = cacheKey + "_" + inlinePolicy
 	}
 
 	if policyArns != nil {
@@ -61,18 +59,15 @@ func (s *server) assumeRole(ctx context.Context, externalId, roleArn, inlinePoli
 			})
 		}
 		input.SetPolicyArns(arns)
-
-		cacheKey = cacheKey + "_" + strings.Join(policyArns, "_")
 	}
 
-	log.Debugf("checking for item with cache key: '%s'", cacheKey)
+	accountId := roleAccountId(roleArn)
+	cacheKey := sessionCacheKey(accountId, roleArn, externalId, inlinePolicy, policyArns)
 
-	item, expire, found := s.sessionCache.GetWithExpiration(cacheKey)
-	if found {
-		if sess, ok := item.(*session.Session); ok {
-			log.Infof("using cached session (expire: %s)", expire.String())
-			return sess, nil
-		}
+	log.Debugf("checking for session with cache key: '%s'", cacheKey)
+
+	if sess, ok := s.sessionCache.get(accountId, cacheKey); ok {
+		return sess, nil
 	}
 
 	log.Debugf("assuming role %s with input %+v", roleArn, input)
@@ -87,18 +82,102 @@ func (s *server) assumeRole(ctx context.Context, externalId, roleArn, inlinePoli
 
 	log.Infof("got temporary creds %s, expiration: %s", akid, aws.TimeValue(out.Credentials.Expiration).String())
 
-	sess := session.New(
+	sess := session.New(append([]session.SessionOption{
 		session.WithCredentials(
 			akid,
 			aws.StringValue(out.Credentials.SecretAccessKey),
 			aws.StringValue(out.Credentials.SessionToken),
 		),
 		session.WithRegion("us-east-1"),
-	)
+	}, s.awsCallOptions...)...)
 
 	log.Debugf("caching session with cache key: '%s'", cacheKey)
 
-	s.sessionCache.Set(cacheKey, &sess, cache.DefaultExpiration)
+	s.sessionCache.set(cacheKey, &sess, aws.TimeValue(out.Credentials.Expiration))
 
 	return &sess, nil
 }
+
+// roleAccountId returns the account id from a role arn, ie. arn:aws:iam::012345678910:role/foo
+func roleAccountId(roleArn string) string {
+	parts := strings.Split(roleArn, ":")
+	if len(parts) < 5 {
+		return ""
+	}
+	return parts[4]
+}
+
+// sessionForAccount assumes the role in the given account with an inline policy allowing the passed actions.  The
+// account can be the account name from the accounts map or an account id.
+func (s *server) sessionForAccount(ctx context.Context, account string, actions ...string) (*session.Session, error) {
+	return s.sessionForAccountWithPolicies(ctx, account, nil, actions...)
+}
+
+// sessionForAccountWithPolicies assumes the role in the given account with an inline policy allowing the passed actions,
+// further limited by the passed managed policy arns
+func (s *server) sessionForAccountWithPolicies(ctx context.Context, account string, policyArns []string, actions ...string) (*session.Session, error) {
+	accountId := s.mapAccountNumber(account)
+
+	role := s.roleArn(accountId)
+	policy, err := generatePolicy(actions...)
+	if err != nil {
+		return nil, apierror.New(apierror.ErrInternalError, "cannot generate policy", err)
+	}
+
+	session, err := s.assumeRole(
+		ctx,
+		s.session.ExternalID,
+		role,
+		policy,
+		policyArns...,
+	)
+	if err != nil {
+		msg := fmt.Sprintf("failed to assume role in account: %s", accountId)
+		return nil, apierror.New(apierror.ErrInternalError, msg, err)
+	}
+
+	return session, nil
+}
+
+// iamServiceForAccount assumes the role in the given account with full IAM access and returns an IAM service
+func (s *server) iamServiceForAccount(ctx context.Context, account string) (iamapi.IAM, error) {
+	session, err := s.sessionForAccount(ctx, account, "iam:*")
+	if err != nil {
+		return iamapi.IAM{}, err
+	}
+
+	iamService := iamapi.NewSession(session.Session, s.account)
+	iamService.Cache = s.resourceCache(s.mapAccountNumber(account))
+
+	return iamService, nil
+}
+
+// sessionForScope assumes the role in the given account with a session policy generated from a scoped policy, limiting
+// the session to the resources in the scope
+func (s *server) sessionForScope(ctx context.Context, account string, policy scopedPolicy, scope policyScope) (*session.Session, error) {
+	accountId := s.mapAccountNumber(account)
+	scope.Account = accountId
+
+	document, err := policy.generate(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.assumeRole(
+		ctx,
+		s.session.ExternalID,
+		s.roleArn(accountId),
+		document,
+	)
+	if err != nil {
+		msg := fmt.Sprintf("failed to assume role in account: %s", accountId)
+		return nil, apierror.New(apierror.ErrInternalError, msg, err)
+	}
+
+	return session, nil
+}
+
+// roleArn returns the arn of the role the api assumes in an account
+func (s *server) roleArn(accountId string) string {
+	return fmt.Sprintf("arn:aws:iam::%s:role/%s", accountId, s.session.RoleName)
+}
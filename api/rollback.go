@@ -0,0 +1,200 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/YaleSpinup/s3-api/retry"
+	"github.com/YaleSpinup/s3-api/rollback"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	s3controlapi "github.com/YaleSpinup/s3-api/s3control"
+	smapi "github.com/YaleSpinup/s3-api/secretsmanager"
+	transferapi "github.com/YaleSpinup/s3-api/transfer"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/s3"
+	log "github.com/sirupsen/logrus"
+)
+
+// rollback step kinds, each kind is executed by rollbackServices.execute with the step params
+const (
+	rollbackDeleteBucket          = "s3.DeleteEmptyBucket"
+	rollbackDeleteBucketLifecycle = "s3.DeleteBucketLifecycle"
+	rollbackDeleteObject          = "s3.DeleteObject"
+	rollbackDeleteAccessPoint     = "s3control.DeleteAccessPoint"
+	rollbackDeletePolicy          = "iam.DeletePolicy"
+	rollbackDeleteGroup           = "iam.DeleteGroup"
+	rollbackDetachGroupPolicy     = "iam.DetachGroupPolicy"
+	rollbackDeleteUser            = "iam.DeleteUser"
+	rollbackRemoveUserFromGroup   = "iam.RemoveUserFromGroup"
+	rollbackDeleteAccessKey       = "iam.DeleteAccessKey"
+	rollbackDeleteLoginProfile    = "iam.DeleteLoginProfile"
+	rollbackDeleteRole            = "iam.DeleteRole"
+	rollbackDetachRolePolicy      = "iam.DetachRolePolicy"
+	rollbackDisableDistribution   = "cloudfront.DisableDistribution"
+	rollbackDeleteTransferUser    = "transfer.DeleteUser"
+	rollbackDeleteSecret          = "secretsmanager.DeleteSecret"
+)
+
+// rollbackServices are the services used to execute rollback steps in an account
+type rollbackServices struct {
+	s3         *s3api.S3
+	iam        *iamapi.IAM
+	cloudFront *cfapi.CloudFront
+	transfer   *transferapi.Transfer
+	s3Control  *s3controlapi.S3Control
+	// secretsManager is only set for the operations that deliver access keys to secrets manager
+	secretsManager *smapi.SecretsManager
+}
+
+// newRollback creates a new rollback for an operation, persisted to the rollback store if one is configured
+func (s *server) newRollback(operation, account, resource string, services rollbackServices) *rollback.Rollback {
+	opts := []rollback.RollbackOption{rollback.WithInstance(s.instance)}
+	if s.rollbackStore != nil {
+		opts = append(opts, rollback.WithStore(s.rollbackStore))
+	}
+
+	return rollback.New(operation, account, resource, services.execute, opts...)
+}
+
+// finishRollback executes the rollback if the operation failed, otherwise it's marked complete.  Executed rollbacks
+// are reported to the alert webhooks and topic.
+func (s *server) finishRollback(rb *rollback.Rollback, err error) {
+	if err == nil {
+		rb.Complete()
+		return
+	}
+
+	orchestrationFailures.WithLabelValues(rb.Operation).Inc()
+
+	log.Errorf("recovering from error: %s, executing %d rollback steps", err, rb.Len())
+	if rerr := rb.Execute(context.Background()); rerr != nil {
+		log.Errorf("rollback %s was not successful: %s", rb.ID, rerr)
+	}
+
+	s.reportRollback(rb, err)
+}
+
+// resumeRollback executes a persisted rollback with new sessions in the rollback's account
+func (s *server) resumeRollback(ctx context.Context, rb *rollback.Rollback) error {
+	accountId := s.mapAccountNumber(rb.Account)
+
+	session, err := s.sessionForAccount(ctx, accountId, "s3:*", "iam:*", "cloudfront:*", "transfer:*", "secretsmanager:DeleteSecret")
+	if err != nil {
+		return err
+	}
+
+	s3Service := s3api.NewSession(session.Session, s.account, s.mapToAccountName(accountId))
+	iamService := iamapi.NewSession(session.Session, s.account)
+	iamService.Cache = s.resourceCache(accountId)
+	cloudFrontService := cfapi.NewSession(session.Session, s.account, accountId)
+	cloudFrontService.Index = s.distributionIndex(accountId)
+	transferService := transferapi.NewSession(session.Session, s.account)
+	s3ControlService := s3controlapi.NewSession(session.Session, s.account, accountId)
+	smService := smapi.NewSession(session.Session, s.account)
+
+	services := rollbackServices{
+		s3:             &s3Service,
+		iam:            &iamService,
+		cloudFront:     &cloudFrontService,
+		transfer:       &transferService,
+		s3Control:      &s3ControlService,
+		secretsManager: &smService,
+	}
+	rb.SetExecutor(services.execute)
+
+	err = rb.Execute(ctx)
+	s.reportRollback(rb, nil)
+
+	return err
+}
+
+// execute executes a single rollback step
+func (r rollbackServices) execute(ctx context.Context, step *rollback.Step) error {
+	p := step.Params
+
+	switch step.Kind {
+	case rollbackDeleteBucket, rollbackDeleteBucketLifecycle, rollbackDeleteObject:
+		if r.s3 == nil {
+			return fmt.Errorf("no s3 service to execute %s", step.Kind)
+		}
+	case rollbackDeleteAccessPoint:
+		if r.s3Control == nil {
+			return fmt.Errorf("no s3 control service to execute %s", step.Kind)
+		}
+	case rollbackDisableDistribution:
+		if r.cloudFront == nil {
+			return fmt.Errorf("no cloudfront service to execute %s", step.Kind)
+		}
+	case rollbackDeleteTransferUser:
+		if r.transfer == nil {
+			return fmt.Errorf("no transfer service to execute %s", step.Kind)
+		}
+	case rollbackDeleteSecret:
+		if r.secretsManager == nil {
+			return fmt.Errorf("no secrets manager service to execute %s", step.Kind)
+		}
+	default:
+		if r.iam == nil {
+			return fmt.Errorf("no iam service to execute %s", step.Kind)
+		}
+	}
+
+	switch step.Kind {
+	case rollbackDeleteBucket:
+		return r.s3.DeleteEmptyBucket(ctx, &s3.DeleteBucketInput{Bucket: aws.String(p["bucket"])})
+	case rollbackDeleteBucketLifecycle:
+		return r.s3.DeleteBucketLifecycle(ctx, &s3.DeleteBucketLifecycleInput{Bucket: aws.String(p["bucket"])})
+	case rollbackDeleteObject:
+		_, err := r.s3.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: aws.String(p["bucket"]), Key: aws.String(p["key"])})
+		return err
+	case rollbackDeleteAccessPoint:
+		return r.s3Control.DeleteAccessPoint(ctx, p["name"])
+	case rollbackDeletePolicy:
+		return r.iam.DeletePolicy(ctx, &iam.DeletePolicyInput{PolicyArn: aws.String(p["policy_arn"])})
+	case rollbackDeleteGroup:
+		return r.iam.DeleteGroup(ctx, &iam.DeleteGroupInput{GroupName: aws.String(p["group"])})
+	case rollbackDetachGroupPolicy:
+		return r.iam.DetachGroupPolicy(ctx, &iam.DetachGroupPolicyInput{
+			GroupName: aws.String(p["group"]),
+			PolicyArn: aws.String(p["policy_arn"]),
+		})
+	case rollbackDeleteUser:
+		return r.iam.DeleteUser(ctx, &iam.DeleteUserInput{UserName: aws.String(p["user"])})
+	case rollbackRemoveUserFromGroup:
+		return r.iam.RemoveUserFromGroup(ctx, &iam.RemoveUserFromGroupInput{
+			UserName:  aws.String(p["user"]),
+			GroupName: aws.String(p["group"]),
+		})
+	case rollbackDeleteAccessKey:
+		return r.iam.DeleteAccessKey(ctx, &iam.DeleteAccessKeyInput{
+			UserName:    aws.String(p["user"]),
+			AccessKeyId: aws.String(p["access_key_id"]),
+		})
+	case rollbackDeleteLoginProfile:
+		return r.iam.DeleteLoginProfile(ctx, &iam.DeleteLoginProfileInput{UserName: aws.String(p["user"])})
+	case rollbackDeleteRole:
+		return r.iam.DeleteRole(ctx, p["role"])
+	case rollbackDetachRolePolicy:
+		return r.iam.DetachRolePolicy(ctx, &iam.DetachRolePolicyInput{
+			RoleName:  aws.String(p["role"]),
+			PolicyArn: aws.String(p["policy_arn"]),
+		})
+	case rollbackDisableDistribution:
+		return retry.Do(ctx, cloudFrontRetry, func(ctx context.Context) error {
+			_, err := r.cloudFront.DisableDistribution(ctx, p["id"])
+			return err
+		})
+	case rollbackDeleteTransferUser:
+		// the user is deleted from the server it was created on, even if the configured server changed since
+		t := *r.transfer
+		t.ServerID = p["server_id"]
+		return t.DeleteUser(ctx, p["user"])
+	case rollbackDeleteSecret:
+		return r.secretsManager.DeleteSecret(ctx, p["secret"])
+	}
+
+	return fmt.Errorf("unknown rollback step kind %s", step.Kind)
+}
@@ -0,0 +1,27 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/YaleSpinup/s3-api/rollback"
+)
+
+func TestRollbackServicesExecute(t *testing.T) {
+	services := rollbackServices{}
+
+	tests := []string{
+		rollbackDeleteBucket,
+		rollbackDeleteAccessPoint,
+		rollbackDeletePolicy,
+		rollbackDisableDistribution,
+		rollbackDeleteTransferUser,
+		"unknown.Kind",
+	}
+
+	for _, kind := range tests {
+		if err := services.execute(context.TODO(), &rollback.Step{Kind: kind}); err == nil {
+			t.Errorf("expected error executing %s without services, got nil", kind)
+		}
+	}
+}
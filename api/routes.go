@@ -11,31 +11,156 @@ func (s *server) routes() {
 	api.HandleFunc("/ping", s.PingHandler).Methods(http.MethodGet)
 	api.HandleFunc("/version", s.VersionHandler).Methods(http.MethodGet)
 	api.Handle("/metrics", promhttp.Handler()).Methods(http.MethodGet)
+	api.HandleFunc("/swagger.json", s.OpenAPIHandler).Methods(http.MethodGet)
+	if s.swaggerUI {
+		api.HandleFunc("/swagger", s.SwaggerUIHandler).Methods(http.MethodGet)
+	}
+
+	// schemas handlers
+	api.HandleFunc("/schemas", s.SchemaListHandler).Methods(http.MethodGet)
+	api.HandleFunc("/schemas/{name}", s.SchemaShowHandler).Methods(http.MethodGet)
+
+	// accounts handlers
+	api.HandleFunc("/accounts", s.AccountListHandler).Methods(http.MethodGet)
+
+	// inventory handlers
+	api.HandleFunc("/inventory", s.InventoryHandler).Methods(http.MethodGet)
+
+	// lifecycles handlers
+	api.HandleFunc("/lifecycles", s.LifecycleListHandler).Methods(http.MethodGet)
+
+	// admin handlers
+	api.HandleFunc("/admin/operations", s.OperationListHandler).Methods(http.MethodGet)
+	api.HandleFunc("/admin/operations/{id}", s.OperationCancelHandler).Methods(http.MethodDelete)
+	api.HandleFunc("/admin/maintenance", s.MaintenanceShowHandler).Methods(http.MethodGet)
+	api.HandleFunc("/admin/maintenance", s.MaintenanceEnableHandler).Methods(http.MethodPut)
+	api.HandleFunc("/admin/maintenance", s.MaintenanceDisableHandler).Methods(http.MethodDelete)
 
 	// buckets handlers
 	api.HandleFunc("/{account}/buckets", s.BucketListHandler).Methods(http.MethodGet)
 	api.HandleFunc("/{account}/buckets", s.BucketCreateHandler).Methods(http.MethodPost)
+	api.HandleFunc("/{account}/buckets/bulk", s.BucketBulkCreateHandler).Methods(http.MethodPost)
 	api.HandleFunc("/{account}/buckets/{bucket}", s.BucketHeadHandler).Methods(http.MethodHead)
 	api.HandleFunc("/{account}/buckets/{bucket}", s.BucketShowHandler).Methods(http.MethodGet)
 	api.HandleFunc("/{account}/buckets/{bucket}", s.BucketDeleteHandler).Methods(http.MethodDelete)
 	api.HandleFunc("/{account}/buckets/{bucket}", s.BucketUpdateHandler).Methods(http.MethodPut)
+	api.HandleFunc("/{account}/buckets/{bucket}/available", s.BucketAvailabilityHandler).Methods(http.MethodGet)
 	api.HandleFunc("/{account}/buckets/{bucket}/duck", s.BucketDuck).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/buckets/{bucket}/export", s.BucketExportHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/buckets/{bucket}/spec", s.BucketSpecApplyHandler).Methods(http.MethodPut)
+	api.HandleFunc("/{account}/buckets/{bucket}/adopt", s.BucketAdoptHandler).Methods(http.MethodPost)
+	api.HandleFunc("/{account}/buckets/{bucket}/simulate", s.BucketSimulateHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/buckets/{bucket}/protection", s.BucketProtectHandler).Methods(http.MethodPut)
+	api.HandleFunc("/{account}/buckets/{bucket}/protection", s.BucketUnprotectHandler).Methods(http.MethodDelete)
+	api.HandleFunc("/{account}/buckets/{bucket}/objecttags", s.BucketObjectTagsShowHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/buckets/{bucket}/objecttags", s.BucketObjectTagsUpdateHandler).Methods(http.MethodPut)
+	api.HandleFunc("/{account}/buckets/{bucket}/encrypt", s.BucketEncryptObjectsHandler).Methods(http.MethodPost)
+	api.HandleFunc("/{account}/buckets/{bucket}/encryption", s.BucketEncryptionShowHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/buckets/{bucket}/usage", s.BucketUsageHistoryHandler).Methods(http.MethodGet)
+
+	// users handlers
+	api.HandleFunc("/{account}/users", s.AccountUserListHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/users/cleanup", s.UserCleanupListHandler).Methods(http.MethodGet)
 
 	// bucket users handlers
 	api.HandleFunc("/{account}/buckets/{bucket}/users", s.UserListHandler).Methods(http.MethodGet)
 	api.HandleFunc("/{account}/buckets/{bucket}/users", s.UserCreateHandler).Methods(http.MethodPost)
+	api.HandleFunc("/{account}/buckets/{bucket}/users/bulk", s.UserBulkCreateHandler).Methods(http.MethodPost)
 	api.HandleFunc("/{account}/buckets/{bucket}/users/{user}", s.UserShowHandler).Methods(http.MethodGet)
 	api.HandleFunc("/{account}/buckets/{bucket}/users/{user}", s.UserDeleteHandler).Methods(http.MethodDelete)
 	api.HandleFunc("/{account}/buckets/{bucket}/users/{user}", s.UserUpdateKeyHandler).Methods(http.MethodPut)
+	api.HandleFunc("/{account}/buckets/{bucket}/users/{user}/login", s.UserLoginCreateHandler).Methods(http.MethodPost)
+	api.HandleFunc("/{account}/buckets/{bucket}/users/{user}/login", s.UserLoginResetHandler).Methods(http.MethodPut)
+	api.HandleFunc("/{account}/buckets/{bucket}/users/{user}/login", s.UserLoginDeleteHandler).Methods(http.MethodDelete)
+
+	// bucket sftp handlers
+	api.HandleFunc("/{account}/buckets/{bucket}/sftp", s.BucketSFTPShowHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/buckets/{bucket}/sftp", s.BucketSFTPEnableHandler).Methods(http.MethodPut)
+	api.HandleFunc("/{account}/buckets/{bucket}/sftp", s.BucketSFTPDisableHandler).Methods(http.MethodDelete)
+	api.HandleFunc("/{account}/buckets/{bucket}/sftp/users", s.BucketSFTPUserCreateHandler).Methods(http.MethodPost)
+	api.HandleFunc("/{account}/buckets/{bucket}/sftp/users/{user}", s.BucketSFTPUserShowHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/buckets/{bucket}/sftp/users/{user}", s.BucketSFTPUserDeleteHandler).Methods(http.MethodDelete)
+	api.HandleFunc("/{account}/buckets/{bucket}/sftp/users/{user}/keys", s.BucketSFTPKeyCreateHandler).Methods(http.MethodPost)
+	api.HandleFunc("/{account}/buckets/{bucket}/sftp/users/{user}/keys/{key}", s.BucketSFTPKeyDeleteHandler).Methods(http.MethodDelete)
+
+	// bucket ip allowlist handlers
+	api.HandleFunc("/{account}/buckets/{bucket}/allowlist", s.BucketAllowlistUpdateHandler).Methods(http.MethodPut)
+
+	// bucket policy version handlers
+	api.HandleFunc("/{account}/buckets/{bucket}/policies/{policy}/versions", s.BucketPolicyVersionListHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/buckets/{bucket}/policies/{policy}/versions/{version}/restore", s.BucketPolicyVersionRestoreHandler).Methods(http.MethodPost)
+
+	// bucket group policy handlers
+	api.HandleFunc("/{account}/buckets/{bucket}/groups/{group}/policies", s.BucketGroupPolicyAttachHandler).Methods(http.MethodPost)
+	api.HandleFunc("/{account}/buckets/{bucket}/groups/{group}/policies", s.BucketGroupPolicyDetachHandler).Methods(http.MethodDelete)
+
+	// bucket data protection handlers
+	api.HandleFunc("/{account}/buckets/{bucket}/dataprotection", s.BucketDataProtectionEnableHandler).Methods(http.MethodPut)
+	api.HandleFunc("/{account}/buckets/{bucket}/dataprotection", s.BucketDataProtectionDisableHandler).Methods(http.MethodDelete)
+
+	// bucket access point handlers
+	api.HandleFunc("/{account}/buckets/{bucket}/accesspoints", s.BucketAccessPointListHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/buckets/{bucket}/accesspoints", s.BucketAccessPointCreateHandler).Methods(http.MethodPost)
+	api.HandleFunc("/{account}/buckets/{bucket}/accesspoints/{name}", s.BucketAccessPointDeleteHandler).Methods(http.MethodDelete)
+
+	// reports handlers
+	api.HandleFunc("/{account}/reports/mfa", s.MFAReportHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/reports/tags", s.TagsReportHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/reports/credentials", s.CredentialReportHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/reports/egress", s.EgressReportHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/reports/lastaccessed", s.LastAccessedReportHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/exposure", s.ExposureReportHandler).Methods(http.MethodGet)
+
+	// tasks handlers
+	api.HandleFunc("/{account}/tasks", s.TaskListHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/tasks/{id}", s.TaskShowHandler).Methods(http.MethodGet)
+
+	// rollbacks handlers
+	api.HandleFunc("/{account}/rollbacks", s.RollbackListHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/rollbacks/{id}", s.RollbackShowHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/rollbacks/{id}", s.RollbackResumeHandler).Methods(http.MethodPost)
+	api.HandleFunc("/{account}/rollbacks/{id}", s.RollbackDeleteHandler).Methods(http.MethodDelete)
+
+	// zones handlers
+	api.HandleFunc("/{account}/zones", s.ZoneListHandler).Methods(http.MethodGet)
 
 	// websites handlers
 	api.HandleFunc("/{account}/websites", s.CreateWebsiteHandler).Methods(http.MethodPost)
+	api.HandleFunc("/{account}/websites/deleted", s.WebsiteSoftDeleteListHandler).Methods(http.MethodGet)
 	api.HandleFunc("/{account}/websites/{bucket}", s.BucketHeadHandler).Methods(http.MethodHead)
 	api.HandleFunc("/{account}/websites/{website}", s.WebsiteShowHandler).Methods(http.MethodGet)
 	api.HandleFunc("/{account}/websites/{website}", s.WebsiteDeleteHandler).Methods(http.MethodDelete)
 	api.HandleFunc("/{account}/websites/{website}", s.WebsiteUpdateHandler).Methods(http.MethodPut)
 	api.HandleFunc("/{account}/websites/{website}", s.WebsitePartialUpdateHandler).Methods(http.MethodPatch)
 	api.HandleFunc("/{account}/websites/{website}/duck", s.BucketDuck).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/websites/{website}/export", s.BucketExportHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/websites/{bucket}/simulate", s.BucketSimulateHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/websites/{bucket}/protection", s.BucketProtectHandler).Methods(http.MethodPut)
+	api.HandleFunc("/{account}/websites/{bucket}/protection", s.BucketUnprotectHandler).Methods(http.MethodDelete)
+	api.HandleFunc("/{account}/websites/{bucket}/objecttags", s.BucketObjectTagsShowHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/websites/{bucket}/objecttags", s.BucketObjectTagsUpdateHandler).Methods(http.MethodPut)
+	api.HandleFunc("/{account}/websites/{website}/dns", s.WebsiteDNSListHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/websites/{website}/dns", s.WebsiteDNSCreateHandler).Methods(http.MethodPost)
+	api.HandleFunc("/{account}/websites/{website}/dns/{type}/{name}", s.WebsiteDNSDeleteHandler).Methods(http.MethodDelete)
+	api.HandleFunc("/{account}/websites/{website}/behaviors", s.WebsiteBehaviorsShowHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/websites/{website}/behaviors", s.WebsiteBehaviorsUpdateHandler).Methods(http.MethodPut)
+	api.HandleFunc("/{account}/websites/{website}/securityheaders", s.WebsiteHeadersShowHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/websites/{website}/securityheaders", s.WebsiteHeadersUpdateHandler).Methods(http.MethodPut)
+	api.HandleFunc("/{account}/websites/{website}/securityheaders", s.WebsiteHeadersDeleteHandler).Methods(http.MethodDelete)
+	api.HandleFunc("/{account}/websites/{website}/functions", s.WebsiteFunctionsShowHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/websites/{website}/functions/{function}", s.WebsiteFunctionAssociateHandler).Methods(http.MethodPut)
+	api.HandleFunc("/{account}/websites/{website}/functions/{function}", s.WebsiteFunctionDisassociateHandler).Methods(http.MethodDelete)
+	api.HandleFunc("/{account}/websites/{website}/signedurls", s.WebsiteSignedURLsHandler).Methods(http.MethodPost)
+	api.HandleFunc("/{account}/websites/{website}/failover", s.WebsiteFailoverUpdateHandler).Methods(http.MethodPut)
+	api.HandleFunc("/{account}/websites/{website}/failover", s.WebsiteFailoverDeleteHandler).Methods(http.MethodDelete)
+	api.HandleFunc("/{account}/websites/{website}/restore", s.WebsiteRestoreHandler).Methods(http.MethodPost)
+	api.HandleFunc("/{account}/websites/{website}/repair", s.WebsiteRepairHandler).Methods(http.MethodPost)
+	api.HandleFunc("/{account}/websites/{website}/health", s.WebsiteHealthHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/websites/{website}/staging", s.WebsiteStagingCreateHandler).Methods(http.MethodPost)
+	api.HandleFunc("/{account}/websites/{website}/staging/promote", s.WebsiteStagingPromoteHandler).Methods(http.MethodPost)
+	api.HandleFunc("/{account}/websites/{website}/releases", s.WebsiteReleaseListHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/websites/{website}/releases/rollback", s.WebsiteReleaseRollbackHandler).Methods(http.MethodPost)
+	api.HandleFunc("/{account}/websites/{website}/releases/{release}", s.WebsiteReleaseActivateHandler).Methods(http.MethodPut)
 
 	// website users handlers
 	api.HandleFunc("/{account}/websites/{bucket}/users", s.UserListHandler).Methods(http.MethodGet)
@@ -43,4 +168,21 @@ func (s *server) routes() {
 	api.HandleFunc("/{account}/websites/{bucket}/users/{user}", s.WebsiteUserShowHandler).Methods(http.MethodGet)
 	api.HandleFunc("/{account}/websites/{bucket}/users/{user}", s.UserDeleteHandler).Methods(http.MethodDelete)
 	api.HandleFunc("/{account}/websites/{bucket}/users/{user}", s.UserUpdateKeyHandler).Methods(http.MethodPut)
+	api.HandleFunc("/{account}/websites/{bucket}/users/{user}/login", s.UserLoginCreateHandler).Methods(http.MethodPost)
+	api.HandleFunc("/{account}/websites/{bucket}/users/{user}/login", s.UserLoginResetHandler).Methods(http.MethodPut)
+	api.HandleFunc("/{account}/websites/{bucket}/users/{user}/login", s.UserLoginDeleteHandler).Methods(http.MethodDelete)
+
+	// v2 handlers return stable response models instead of the aws sdk types
+	v2 := s.router.PathPrefix("/v2/s3").Subrouter()
+	v2.HandleFunc("/ping", s.PingHandler).Methods(http.MethodGet)
+	v2.HandleFunc("/version", s.VersionHandler).Methods(http.MethodGet)
+
+	v2.HandleFunc("/{account}/buckets", s.BucketListV2Handler).Methods(http.MethodGet)
+	v2.HandleFunc("/{account}/buckets", s.BucketCreateV2Handler).Methods(http.MethodPost)
+	v2.HandleFunc("/{account}/buckets/{bucket}", s.BucketShowV2Handler).Methods(http.MethodGet)
+	v2.HandleFunc("/{account}/buckets/{bucket}/users", s.UserListV2Handler).Methods(http.MethodGet)
+	v2.HandleFunc("/{account}/buckets/{bucket}/users/{user}", s.UserShowV2Handler).Methods(http.MethodGet)
+	v2.HandleFunc("/{account}/websites/{website}", s.WebsiteShowV2Handler).Methods(http.MethodGet)
+	v2.HandleFunc("/{account}/websites/{bucket}/users", s.UserListV2Handler).Methods(http.MethodGet)
+	v2.HandleFunc("/{account}/websites/{website}/users/{user}", s.UserShowV2Handler).Methods(http.MethodGet)
 }
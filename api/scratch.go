@@ -0,0 +1,245 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/YaleSpinup/s3-api/webhook"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// scratchTagKey marks a scratch bucket, the value is the time after which it's emptied and deleted
+	scratchTagKey = "spinup:scratch-expires"
+	// scratchWarnedTagKey marks a scratch bucket whose owners were warned that it's about to be deleted
+	scratchWarnedTagKey = "spinup:scratch-warned"
+	// defaultScratchMaxDays is the longest lifetime of a scratch bucket if it's not configured
+	defaultScratchMaxDays = 365
+	// defaultScratchWarnDays is how long before a scratch bucket is deleted that its owners are warned if it's not
+	// configured
+	defaultScratchWarnDays = 7
+
+	// scratchActionWarn and scratchActionExpire are what the scratch reaper does with a bucket
+	scratchActionWarn   = "warn"
+	scratchActionExpire = "expire"
+)
+
+// requireScratch returns a bad request error if scratch buckets aren't configured or the days are longer than the
+// configured maximum
+func (s *server) requireScratch(days int) error {
+	if s.account.Scratch == nil {
+		return apierror.New(apierror.ErrBadRequest, "scratch buckets are not configured", nil)
+	}
+
+	maxDays := s.account.Scratch.MaxDays
+	if maxDays <= 0 {
+		maxDays = defaultScratchMaxDays
+	}
+
+	if days > maxDays {
+		msg := fmt.Sprintf("scratch buckets can be kept for at most %d days, got %d", maxDays, days)
+		return apierror.New(apierror.ErrBadRequest, msg, nil)
+	}
+
+	return nil
+}
+
+// scratchWarnDays returns how long before a scratch bucket is deleted that its owners are warned
+func (s *server) scratchWarnDays() int {
+	if s.account.Scratch == nil || s.account.Scratch.WarnDays <= 0 {
+		return defaultScratchWarnDays
+	}
+
+	return s.account.Scratch.WarnDays
+}
+
+// scratchExpireTime returns the time after which a scratch bucket is deleted and true if the bucket is a scratch
+// bucket.  A bucket with an invalid time isn't treated as a scratch bucket, so a bad tag never gets it deleted.
+func scratchExpireTime(tags []*s3.Tag) (time.Time, bool) {
+	value, ok := s3TagMap(tags)[scratchTagKey]
+	if !ok {
+		return time.Time{}, false
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		log.Warnf("invalid %s tag value %q, skipping the bucket", scratchTagKey, value)
+		return time.Time{}, false
+	}
+
+	return t, true
+}
+
+// scratchAction returns what the scratch reaper should do with a bucket at the time: expire it once its end date has
+// passed, warn its owners once if it's within the warning days of the end date, or nothing
+func scratchAction(tags []*s3.Tag, now time.Time, warnDays int) string {
+	expires, scratch := scratchExpireTime(tags)
+	if !scratch {
+		return ""
+	}
+
+	if !now.Before(expires) {
+		return scratchActionExpire
+	}
+
+	if _, warned := s3TagMap(tags)[scratchWarnedTagKey]; !warned && now.Add(time.Duration(warnDays)*24*time.Hour).After(expires) {
+		return scratchActionWarn
+	}
+
+	return ""
+}
+
+// scratchReaper periodically warns the owners of the scratch buckets in an account that are about to reach their end
+// date, and empties and deletes the ones that have
+type scratchReaper struct {
+	account  string
+	interval time.Duration
+	server   *server
+	context  context.Context
+}
+
+// run starts the scratch reaper and listens for a shutdown call
+func (w *scratchReaper) run() {
+	ticker := time.NewTicker(w.interval)
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if err := w.action(); err != nil {
+					log.Errorf("scratch: error reaping scratch buckets for account %s: %s", w.account, err)
+				}
+			case <-w.context.Done():
+				log.Debug("scratch: shutting down scratch reaper timer")
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	log.Infof("scratch: started for account %s with interval %s", w.account, w.interval)
+}
+
+// action warns about or deletes each of the org's scratch buckets that's near or past its end date.  Buckets that
+// have been protected are skipped, and a failure to warn about or delete a bucket is logged and the sweep continues.
+func (w *scratchReaper) action() error {
+	log.Debugf("scratch: looking for scratch buckets in account %s", w.account)
+
+	if w.server.inMaintenance(w.context) {
+		log.Infof("scratch: skipping reaping scratch buckets for account %s in read-only maintenance mode", w.account)
+		return nil
+	}
+
+	accountId := w.server.mapAccountNumber(w.account)
+	session, err := w.server.sessionForAccount(w.context, w.account, "s3:*", "iam:*")
+	if err != nil {
+		return err
+	}
+
+	s3Service := s3api.NewSession(session.Session, w.server.account, w.server.mapToAccountName(accountId))
+	iamService := iamapi.NewSession(session.Session, w.server.account)
+	iamService.Cache = w.server.resourceCache(accountId)
+
+	buckets, err := s3Service.ListBuckets(w.context, &s3.ListBucketsInput{})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	warned, deleted, failed := 0, 0, 0
+	for _, b := range buckets {
+		bucket := aws.StringValue(b.Name)
+		tags, err := s3Service.GetBucketTags(w.context, bucket)
+		if err != nil {
+			log.Warnf("scratch: failed to get tags for bucket %s: %s", bucket, err)
+			failed++
+			continue
+		}
+
+		if s3TagMap(tags)["spinup:org"] != Org {
+			continue
+		}
+
+		expires, _ := scratchExpireTime(tags)
+		switch scratchAction(tags, now, w.server.scratchWarnDays()) {
+		case scratchActionWarn:
+			if err := s3Service.TagBucket(w.context, bucket, append(withoutTag(tags, scratchWarnedTagKey), &s3.Tag{
+				Key:   aws.String(scratchWarnedTagKey),
+				Value: aws.String("true"),
+			})); err != nil {
+				log.Warnf("scratch: failed to mark scratch bucket %s as warned: %s", bucket, err)
+				failed++
+				continue
+			}
+
+			w.server.notify(webhook.EventBucketExpiring, w.account, bucket, map[string]string{"Expires": expires.Format(time.RFC3339)})
+			warned++
+		case scratchActionExpire:
+			if isProtected(tags) {
+				log.Warnf("scratch: scratch bucket %s has expired but it's protected, skipping", bucket)
+				continue
+			}
+
+			if err := w.expire(s3Service, iamService, bucket); err != nil {
+				log.Warnf("scratch: failed to delete scratch bucket %s: %s", bucket, err)
+				failed++
+				continue
+			}
+
+			w.server.notify(webhook.EventBucketDeleted, w.account, bucket, map[string]string{"Reason": "scratch bucket expired"})
+			deleted++
+		}
+	}
+
+	log.Infof("scratch: warned about %d and deleted %d scratch buckets in account %s, %d failed", warned, deleted, w.account, failed)
+
+	return nil
+}
+
+// expire empties and deletes an expired scratch bucket along with its groups, policies and users
+func (w *scratchReaper) expire(s3Service s3api.S3, iamService iamapi.IAM, bucket string) error {
+	lease, err := w.server.lockResource(w.context, w.account, bucket)
+	if err != nil {
+		return err
+	}
+	defer lease.Release()
+
+	// the sftp users of the bucket would be left without a bucket, so sftp has to be disabled first
+	if w.server.account.Transfer != nil {
+		if _, err := iamService.GetRole(w.context, sftpRoleName(bucket)); err == nil {
+			return fmt.Errorf("sftp is enabled for scratch bucket %s", bucket)
+		}
+	}
+
+	// only the break-glass role can delete the data in a bucket with data protection
+	policy, err := s3Service.GetBucketPolicy(w.context, bucket)
+	if err != nil {
+		return err
+	}
+
+	if hasDataProtection(policy) {
+		return fmt.Errorf("data protection is enabled for scratch bucket %s", bucket)
+	}
+
+	deleted, err := s3Service.EmptyBucket(w.context, bucket)
+	if err != nil {
+		return err
+	}
+
+	if err := s3Service.DeleteEmptyBucket(w.context, &s3.DeleteBucketInput{Bucket: aws.String(bucket)}); err != nil {
+		return err
+	}
+
+	if err := deleteBucketGroups(w.context, iamService, w.server.mapAccountNumber(w.account), bucket); err != nil {
+		return err
+	}
+
+	log.Infof("scratch: deleted scratch bucket %s and %d object versions", bucket, deleted)
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func TestRequireScratch(t *testing.T) {
+	s := server{}
+	if err := s.requireScratch(30); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Errorf("expected bad request when scratch buckets aren't configured, got %v", err)
+	}
+
+	s.account = common.Account{Scratch: &common.Scratch{MaxDays: 90}}
+	if err := s.requireScratch(90); err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	if err := s.requireScratch(91); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Errorf("expected bad request for more than the max days, got %v", err)
+	}
+
+	s.account = common.Account{Scratch: &common.Scratch{}}
+	if err := s.requireScratch(defaultScratchMaxDays); err != nil {
+		t.Errorf("expected nil error for the default max days, got %s", err)
+	}
+
+	if err := s.requireScratch(defaultScratchMaxDays + 1); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Errorf("expected bad request for more than the default max days, got %v", err)
+	}
+
+	if days := s.scratchWarnDays(); days != defaultScratchWarnDays {
+		t.Errorf("expected the default warn days %d, got %d", defaultScratchWarnDays, days)
+	}
+}
+
+func TestScratchAction(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	scratchTags := func(expires string, warned bool) []*s3.Tag {
+		tags := []*s3.Tag{
+			{Key: aws.String("spinup:org"), Value: aws.String("test")},
+			{Key: aws.String(scratchTagKey), Value: aws.String(expires)},
+		}
+		if warned {
+			tags = append(tags, &s3.Tag{Key: aws.String(scratchWarnedTagKey), Value: aws.String("true")})
+		}
+		return tags
+	}
+
+	tests := []struct {
+		name     string
+		tags     []*s3.Tag
+		expected string
+	}{
+		{"not scratch", []*s3.Tag{{Key: aws.String("spinup:org"), Value: aws.String("test")}}, ""},
+		{"far from the end date", scratchTags("2026-06-01T00:00:00Z", false), ""},
+		{"within the warning days", scratchTags("2026-05-05T00:00:00Z", false), scratchActionWarn},
+		{"already warned", scratchTags("2026-05-05T00:00:00Z", true), ""},
+		{"past the end date", scratchTags("2026-05-01T00:00:00Z", true), scratchActionExpire},
+		{"past the end date without a warning", scratchTags("2026-04-01T00:00:00Z", false), scratchActionExpire},
+		{"invalid end date", scratchTags("next week", false), ""},
+	}
+
+	for _, tt := range tests {
+		if out := scratchAction(tt.tags, now, 7); out != tt.expected {
+			t.Errorf("%s: expected action %q, got %q", tt.name, tt.expected, out)
+		}
+	}
+}
+
+func TestScratchExpireTime(t *testing.T) {
+	expires, scratch := scratchExpireTime([]*s3.Tag{{Key: aws.String(scratchTagKey), Value: aws.String("2026-06-01T00:00:00Z")}})
+	if !scratch || !expires.Equal(time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected a scratch bucket expiring 2026-06-01T00:00:00Z, got %t, %s", scratch, expires)
+	}
+
+	if _, scratch := scratchExpireTime(nil); scratch {
+		t.Error("expected a bucket without the scratch tag not to be a scratch bucket")
+	}
+
+	// a bad tag value mustn't get the bucket reaped
+	if _, scratch := scratchExpireTime([]*s3.Tag{{Key: aws.String(scratchTagKey), Value: aws.String("next week")}}); scratch {
+		t.Error("expected a bucket with an invalid scratch tag not to be a scratch bucket")
+	}
+}
+
+func TestBucketCreateRequestScratchDays(t *testing.T) {
+	req := bucketCreateRequest{
+		BucketInput: s3.CreateBucketInput{Bucket: aws.String("course-project")},
+		ScratchDays: -1,
+	}
+
+	if err := req.validate(nil); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Errorf("expected bad request for negative scratch days, got %v", err)
+	}
+
+	req.ScratchDays = 30
+	if err := req.validate(nil); err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+}
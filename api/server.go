@@ -2,18 +2,27 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"math/rand"
 	"net/http"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/YaleSpinup/s3-api/cloudfront"
 	"github.com/YaleSpinup/s3-api/common"
 	"github.com/YaleSpinup/s3-api/iam"
-	"github.com/YaleSpinup/s3-api/route53"
+	"github.com/YaleSpinup/s3-api/lock"
+	"github.com/YaleSpinup/s3-api/rollback"
 	"github.com/YaleSpinup/s3-api/s3"
 	"github.com/YaleSpinup/s3-api/session"
+	"github.com/YaleSpinup/s3-api/sns"
+	"github.com/YaleSpinup/s3-api/storage"
+	"github.com/YaleSpinup/s3-api/task"
+	"github.com/YaleSpinup/s3-api/webhook"
+	"github.com/google/uuid"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 	"github.com/patrickmn/go-cache"
@@ -21,23 +30,59 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// writeTimeout is how long the server has to write a response, handlers that wait longer extend the write deadline
+// of their response
+const writeTimeout = 15 * time.Second
+
 func init() {
 	rand.Seed(time.Now().UnixNano())
 }
 
 type server struct {
-	account            common.Account
-	accountsMap        map[string]string
-	s3Services         map[string]s3.S3
-	iamServices        map[string]iam.IAM
-	cloudFrontServices map[string]cloudfront.CloudFront
-	route53Services    map[string]route53.Route53
-	router             *mux.Router
-	version            common.Version
-	context            context.Context
-	session            *session.Session
-	sessionCache       *cache.Cache
-	org                string
+	account             common.Account
+	accountsMap         map[string]string
+	router              *mux.Router
+	version             common.Version
+	context             context.Context
+	session             *session.Session
+	sessionCache        *sessionCache
+	org                 string
+	notifier            *webhook.Notifier
+	alerter             *webhook.Notifier
+	alertTopic          string
+	snsService          sns.SNS
+	rollbackStore       rollback.Store
+	state               storage.Store
+	instance            string
+	resourceCacheTTL    time.Duration
+	resourceCaches      map[string]*cache.Cache
+	distributionIndexes map[string]*cloudfront.DistributionIndex
+	resourceCachesMu    sync.Mutex
+	swaggerUI           bool
+	requiredTags        requiredTags
+	overrideToken       []byte
+	locker              *lock.Locker
+	tasks               *task.Manager
+	requireIfMatch      bool
+	adminToken          []byte
+	operations          *operations
+	maintenance         *maintenanceMode
+	functionTemplates   map[string]*common.FunctionTemplate
+	securityHeaders     *common.SecurityHeaders
+	signedURLs          *signedURLs
+	// awsCallOptions are the timeouts and circuit breakers for the sessions in the accounts
+	awsCallOptions []session.SessionOption
+}
+
+// publicURLs are the routes that don't require a token
+var publicURLs = map[string]string{
+	"/v1/s3/ping":         "public",
+	"/v1/s3/version":      "public",
+	"/v1/s3/metrics":      "public",
+	"/v1/s3/swagger.json": "public",
+	"/v1/s3/swagger":      "public",
+	"/v2/s3/ping":         "public",
+	"/v2/s3/version":      "public",
 }
 
 // if we have an entry for the account name, return the associated account number
@@ -59,15 +104,47 @@ func (s *server) mapToAccountName(id string) string {
 	return id
 }
 
+// notify sends a lifecycle event for a resource in an account to the configured webhooks and publishes
+// it to the account's event topic
+func (s *server) notify(eventType, account, resource string, details interface{}) {
+	e := webhook.NewEvent(eventType, s.org, account, resource, details)
+	s.notifier.Notify(e)
+
+	if s.account.EventTopic == "" {
+		return
+	}
+
+	s.publish(s.account.GetEventTopic(s.mapAccountNumber(account)), e)
+}
+
+// publish asynchronously publishes an event to an sns topic
+func (s *server) publish(topic string, e webhook.Event) {
+	if s.snsService.Service == nil {
+		return
+	}
+
+	message, err := json.Marshal(e)
+	if err != nil {
+		log.Errorf("failed to marshal event %s: %s", e.Type, err)
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if _, err := s.snsService.Publish(ctx, topic, string(message), map[string]string{"event_type": e.Type}); err != nil {
+			log.Errorf("failed to publish event %s (%s) to %s: %s", e.Type, e.ID, topic, err)
+		}
+	}()
+}
+
 // cleaner will do its action once every interval
 type cleaner struct {
-	account           string
-	interval          time.Duration
-	s3Service         s3.S3
-	iamService        iam.IAM
-	cloudFrontService cloudfront.CloudFront
-	route53Services   route53.Route53
-	context           context.Context
+	account  string
+	interval time.Duration
+	server   *server
+	context  context.Context
 }
 
 // Org will carry throughout the api and get tagged on resources
@@ -83,66 +160,241 @@ func NewServer(config common.Config) error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	sess := session.New(
+	// the IAM resources are scoped to the org's path, ie. /spinup/{org}/
+	if config.Account.IAMPath != "" {
+		config.Account.IAMPath = iam.EnforcePathFormat(strings.Replace(config.Account.IAMPath, "{org}", config.Org, -1))
+	}
+
+	for i, g := range config.Account.SharedAdminGroups {
+		config.Account.SharedAdminGroups[i] = strings.Replace(g, "{org}", config.Org, -1)
+	}
+
+	if err := iam.SetNaming(config.Account.Naming); err != nil {
+		return err
+	}
+
+	awsCallOptions, err := newAWSCallOptions(config.AWSCalls)
+	if err != nil {
+		return err
+	}
+
+	sess := session.New(append([]session.SessionOption{
 		session.WithCredentials(config.Account.Akid, config.Account.Secret, ""),
 		session.WithRegion(config.Account.Region),
 		session.WithExternalID(config.Account.ExternalId),
 		session.WithExternalRoleName(config.Account.Role),
-	)
+	}, awsCallOptions...)...)
 	s := server{
-		account:            config.Account,
-		accountsMap:        config.AccountsMap,
-		s3Services:         make(map[string]s3.S3),
-		iamServices:        make(map[string]iam.IAM),
-		cloudFrontServices: make(map[string]cloudfront.CloudFront),
-		route53Services:    make(map[string]route53.Route53),
-		router:             mux.NewRouter(),
-		version:            config.Version,
-		context:            ctx,
-		session:            &sess,
-		org:                config.Org,
-		sessionCache:       cache.New(600*time.Second, 900*time.Second),
+		account:         config.Account,
+		accountsMap:     config.AccountsMap,
+		router:          mux.NewRouter(),
+		version:         config.Version,
+		context:         ctx,
+		session:         &sess,
+		org:             config.Org,
+		sessionCache:    newSessionCache(sessionRefreshBefore),
+		notifier:        webhook.New(config.Webhooks, webhook.WithOrg(config.Org)),
+		instance:        uuid.New().String(),
+		swaggerUI:       config.SwaggerUI,
+		overrideToken:   []byte(config.ProtectionOverrideToken),
+		requireIfMatch:  config.RequireIfMatch,
+		adminToken:      []byte(config.AdminToken),
+		operations:      newOperations(),
+		securityHeaders: config.SecurityHeaders,
+		awsCallOptions:  awsCallOptions,
+	}
+
+	ttl, err := resourceCacheTTL(config.CacheTTL)
+	if err != nil {
+		return err
+	}
+	s.resourceCacheTTL = ttl
+
+	if s.requiredTags, err = newRequiredTags(config.Account.RequiredTags); err != nil {
+		return err
+	}
+
+	if s.state, err = newStateStore(config.Storage, config.Account); err != nil {
+		return err
+	}
+	s.tasks = task.NewManager(ctx, task.WithStore(s.state))
+
+	if s.locker, err = newLocker(config.Locks, config.Account, s.state); err != nil {
+		return err
+	}
+
+	if s.maintenance, err = newMaintenanceMode(ctx, s.state, config.Maintenance); err != nil {
+		return err
+	}
+
+	if s.functionTemplates, err = newFunctionTemplates(config.FunctionTemplates); err != nil {
+		return err
+	}
+
+	if err := s3.Lifecycles.AddTemplates(config.LifecycleTemplates); err != nil {
+		return err
+	}
+
+	if err := validateSimpleWebsites(config.Account.SimpleWebsites); err != nil {
+		return err
+	}
+
+	if err := validateIPAllowlist(config.Account.IPAllowlist); err != nil {
+		return err
+	}
+
+	if err := validateSharedAdminGroups(config.Account.SharedAdminGroups); err != nil {
+		return err
+	}
+
+	if err := validateManagedPolicies(config.Account.ManagedPolicies); err != nil {
+		return err
+	}
+
+	if s.securityHeaders != nil {
+		if err := cloudfront.ValidateSecurityHeaders(s.securityHeaders); err != nil {
+			return err
+		}
+	}
+
+	if err := cloudfront.ValidateDistributionDefaults(config.Account.DistributionDefaults); err != nil {
+		return err
+	}
+
+	if s.signedURLs, err = newSignedURLs(config.SignedURLs); err != nil {
+		return err
+	}
+
+	if config.RollbackDir != "" {
+		store, err := rollback.NewFileStore(config.RollbackDir)
+		if err != nil {
+			return err
+		}
+		s.rollbackStore = store
+
+		// report rollbacks left behind by a previous instance, ie. after a crash
+		rollbacks, err := store.List()
+		if err != nil {
+			return err
+		}
+
+		for _, rb := range rollbacks {
+			log.Warnf("found %s rollback %s for %s %s in account %s with %d steps", rb.Status, rb.ID, rb.Operation, rb.Resource, rb.Account, len(rb.Steps))
+		}
 	}
-	Org = config.Org
 
-	// Create a shared S3 session
-	for name, accountId := range config.AccountsMap {
-		log.Debugf("Creating new S3 service for account '%s' with key '%s' in region '%s' (org: %s)", name, config.Account.Akid, config.Account.Region, Org)
+	if config.Alerts != nil {
+		log.Infof("sending rollback alerts to %d webhooks and sns topic '%s'", len(config.Alerts.Webhooks), config.Alerts.Topic)
+		s.alerter = webhook.New(config.Alerts.Webhooks, webhook.WithOrg(config.Org))
+		s.alertTopic = config.Alerts.Topic
+	}
 
-		s.s3Services[name] = s3.NewSession(nil, config.Account, name)
-		s.iamServices[name] = iam.NewSession(nil, config.Account)
-		s.cloudFrontServices[name] = cloudfront.NewSession(nil, config.Account, accountId)
-		s.route53Services[name] = route53.NewSession(nil, config.Account)
+	if config.Account.EventTopic != "" {
+		log.Infof("publishing lifecycle events to sns topic %s", config.Account.EventTopic)
+	}
 
+	if config.Account.EventTopic != "" || s.alertTopic != "" {
+		s.snsService = sns.NewSession(nil, config.Account)
+	}
+	Org = config.Org
+
+	// start the background tasks for each account, they assume the api's role in the account like the handlers
+	for name := range config.AccountsMap {
 		if config.Account.Cleaner != nil {
 			log.Infof("starting cleaner for account %s (org: %s)", name, Org)
 
-			interval, err := cleanerInterval(config.Account.Cleaner.Interval, config.Account.Cleaner.MaxSplay)
+			interval, err := splayInterval("cleaner", config.Account.Cleaner.Interval, config.Account.Cleaner.MaxSplay)
 
 			if err != nil {
 				return err
 			}
 
 			acctCleaner := &cleaner{
-				account:           name,
-				interval:          *interval,
-				s3Service:         s.s3Services[name],
-				iamService:        s.iamServices[name],
-				cloudFrontService: s.cloudFrontServices[name],
-				route53Services:   s.route53Services[name],
-				context:           ctx,
+				account:  name,
+				interval: *interval,
+				server:   &s,
+				context:  ctx,
 			}
 
 			log.Debugf("initialized cleaner %+v", acctCleaner)
 
 			acctCleaner.run()
 		}
-	}
 
-	publicURLs := map[string]string{
-		"/v1/s3/ping":    "public",
-		"/v1/s3/version": "public",
-		"/v1/s3/metrics": "public",
+		if config.Account.TagSync != nil {
+			interval, err := splayInterval("tagsync", config.Account.TagSync.Interval, config.Account.TagSync.MaxSplay)
+			if err != nil {
+				return err
+			}
+
+			syncer := &tagSyncer{
+				account:  name,
+				interval: *interval,
+				server:   &s,
+				context:  ctx,
+			}
+			syncer.run()
+		}
+
+		if config.Account.SoftDelete != nil {
+			interval, err := splayInterval("reaper", config.Account.SoftDelete.Interval, config.Account.SoftDelete.MaxSplay)
+			if err != nil {
+				return err
+			}
+
+			reaper := &websiteReaper{
+				account:  name,
+				interval: *interval,
+				server:   &s,
+				context:  ctx,
+			}
+			reaper.run()
+		}
+
+		if config.Account.Scratch != nil {
+			interval, err := splayInterval("scratch", config.Account.Scratch.Interval, config.Account.Scratch.MaxSplay)
+			if err != nil {
+				return err
+			}
+
+			reaper := &scratchReaper{
+				account:  name,
+				interval: *interval,
+				server:   &s,
+				context:  ctx,
+			}
+			reaper.run()
+		}
+
+		if config.Account.UserCleanup != nil {
+			interval, err := splayInterval("usercleanup", config.Account.UserCleanup.Interval, config.Account.UserCleanup.MaxSplay)
+			if err != nil {
+				return err
+			}
+
+			cleaner := &userCleaner{
+				account:  name,
+				interval: *interval,
+				server:   &s,
+				context:  ctx,
+			}
+			cleaner.run()
+		}
+
+		if config.Account.UsageSnapshots != nil {
+			interval, err := splayInterval("usage", config.Account.UsageSnapshots.Interval, config.Account.UsageSnapshots.MaxSplay)
+			if err != nil {
+				return err
+			}
+
+			snapshotter := &usageSnapshotter{
+				account:  name,
+				interval: *interval,
+				server:   &s,
+				context:  ctx,
+			}
+			snapshotter.run()
+		}
 	}
 
 	// load routes
@@ -151,11 +403,47 @@ func NewServer(config common.Config) error {
 	if config.ListenAddress == "" {
 		config.ListenAddress = ":8080"
 	}
-	handler := handlers.RecoveryHandler()(handlers.LoggingHandler(os.Stdout, TokenMiddleware([]byte(config.Token), publicURLs, s.router)))
+	// the mutating requests are rejected in read-only maintenance mode
+	s.router.Use(s.maintenance.middleware)
+	// the mutating requests are registered so they can be listed and canceled by an admin
+	s.router.Use(s.operations.middleware)
+	// creates retried with the same idempotency key get the response of the first request
+	s.router.Use(s.idempotencyMiddleware)
+
+	// requests are authenticated with the token, or with a signature if signed requests are configured
+	verifier, err := newRequestVerifier(config.SignedRequests, s.state)
+	if err != nil {
+		return err
+	}
+	auth := verifier.middleware(TokenMiddleware([]byte(config.Token), publicURLs, s.router), s.router)
+
+	// browser based tools on the allowed origins can call the api, the preflight requests are answered before the
+	// requests are authenticated
+	cors, err := newCORSPolicy(config.CORS)
+	if err != nil {
+		return err
+	}
+	auth = cors.middleware(auth)
+
+	// the larger responses are gzipped for the clients that accept it
+	compressor, err := newCompressor(config.Compression)
+	if err != nil {
+		return err
+	}
+	auth = compressor.middleware(auth)
+
+	// json logs get a structured entry for each request instead of the combined access log
+	var handler http.Handler
+	if config.Logging.JSON() {
+		s.router.Use(routeLogMiddleware)
+		handler = handlers.RecoveryHandler()(RequestLogMiddleware(auth))
+	} else {
+		handler = handlers.RecoveryHandler()(handlers.LoggingHandler(os.Stdout, auth))
+	}
 	srv := &http.Server{
 		Handler:      handler,
 		Addr:         config.ListenAddress,
-		WriteTimeout: 15 * time.Second,
+		WriteTimeout: writeTimeout,
 		ReadTimeout:  15 * time.Second,
 	}
 
@@ -172,6 +460,11 @@ type LogWriter struct {
 	http.ResponseWriter
 }
 
+// Unwrap returns the wrapped http.ResponseWriter, so an http.ResponseController can reach it
+func (w LogWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 // Write log message if http response writer returns an error
 func (w LogWriter) Write(p []byte) (n int, err error) {
 	n, err = w.ResponseWriter.Write(p)
@@ -180,63 +473,3 @@ func (w LogWriter) Write(p []byte) (n int, err error) {
 	}
 	return
 }
-
-type rollbackFunc func(ctx context.Context) error
-
-// rollBack executes functions from a stack of rollback functions
-func rollBack(t *[]rollbackFunc) {
-	if t == nil {
-		return
-	}
-
-	timeout, cancel := context.WithTimeout(context.Background(), 120*time.Second)
-	defer cancel()
-
-	done := make(chan string, 1)
-	go func() {
-		tasks := *t
-		log.Errorf("executing rollback of %d tasks", len(tasks))
-		for i := len(tasks) - 1; i >= 0; i-- {
-			f := tasks[i]
-			if funcerr := f(timeout); funcerr != nil {
-				log.Errorf("rollback task error: %s, continuing rollback", funcerr)
-			}
-			log.Infof("executed rollback task %d of %d", len(tasks)-i, len(tasks))
-		}
-		done <- "success"
-	}()
-
-	// wait for a done context
-	select {
-	case <-timeout.Done():
-		log.Error("timeout waiting for successful rollback")
-	case <-done:
-		log.Info("successfully rolled back")
-	}
-}
-
-type stop struct {
-	error
-}
-
-// retry is stolen from https://upgear.io/blog/simple-golang-retry-function/
-func retry(attempts int, sleep time.Duration, f func() error) error {
-	if err := f(); err != nil {
-		if s, ok := err.(stop); ok {
-			// Return the original error for later checking
-			return s.error
-		}
-
-		if attempts--; attempts > 0 {
-			// Add some randomness to prevent creating a Thundering Herd
-			jitter := time.Duration(rand.Int63n(int64(sleep)))
-			sleep = sleep + jitter/2
-
-			time.Sleep(sleep)
-			return retry(attempts, 2*sleep, f)
-		}
-		return err
-	}
-
-	return nil
-}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/YaleSpinup/s3-api/session"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	log "github.com/sirupsen/logrus"
+)
+
+// sessionRefreshBefore is how long before its credentials expire that a cached session is replaced, so that the
+// credentials don't expire in the middle of an orchestration
+const sessionRefreshBefore = 5 * time.Minute
+
+var (
+	sessionCacheHits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "s3_api",
+		Subsystem: "session_cache",
+		Name:      "hits_total",
+		Help:      "The number of assumed role sessions served from the cache",
+	}, []string{"account"})
+
+	sessionCacheMisses = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "s3_api",
+		Subsystem: "session_cache",
+		Name:      "misses_total",
+		Help:      "The number of roles assumed because there wasn't a cached session",
+	}, []string{"account"})
+
+	sessionCacheRefreshes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "s3_api",
+		Subsystem: "session_cache",
+		Name:      "refreshes_total",
+		Help:      "The number of roles assumed again because the credentials of the cached session were about to expire",
+	}, []string{"account"})
+)
+
+// sessionCache caches the assumed role sessions by account and policy until shortly before their credentials expire,
+// it's shared by all of the handlers
+type sessionCache struct {
+	mu            sync.Mutex
+	sessions      map[string]*cachedSession
+	refreshBefore time.Duration
+	now           func() time.Time
+}
+
+// cachedSession is an assumed role session and when its credentials expire
+type cachedSession struct {
+	session *session.Session
+	expires time.Time
+}
+
+// newSessionCache creates a new empty session cache
+func newSessionCache(refreshBefore time.Duration) *sessionCache {
+	return &sessionCache{
+		sessions:      map[string]*cachedSession{},
+		refreshBefore: refreshBefore,
+		now:           time.Now,
+	}
+}
+
+// sessionCacheKey is the account and a hash of everything that scopes the session, the role, external id, inline
+// policy and policy arns, so the key stays short with large inline policies
+func sessionCacheKey(accountId, roleArn, externalId, policy string, policyArns []string) string {
+	h := sha256.New()
+	for _, s := range []string{roleArn, externalId, policy, strings.Join(policyArns, ",")} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+
+	return accountId + "/" + hex.EncodeToString(h.Sum(nil))
+}
+
+// get returns the cached session for a key, if it's not within the refresh window of its expiration.  The metrics
+// are updated for the account.
+func (c *sessionCache) get(accountId, key string) (*session.Session, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.sessions[key]
+	if !ok {
+		sessionCacheMisses.WithLabelValues(accountId).Inc()
+		return nil, false
+	}
+
+	if remaining := cached.expires.Sub(c.now()); remaining <= c.refreshBefore {
+		log.Infof("refreshing cached session for account %s, the credentials expire in %s", accountId, remaining.Round(time.Second))
+		delete(c.sessions, key)
+		sessionCacheRefreshes.WithLabelValues(accountId).Inc()
+		return nil, false
+	}
+
+	log.Infof("using cached session for account %s (expires: %s)", accountId, cached.expires.String())
+	sessionCacheHits.WithLabelValues(accountId).Inc()
+
+	return cached.session, true
+}
+
+// set caches a session until its credentials expire, and removes the expired sessions
+func (c *sessionCache) set(key string, sess *session.Session, expires time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for k, s := range c.sessions {
+		if !s.expires.After(now) {
+			delete(c.sessions, k)
+		}
+	}
+
+	c.sessions[key] = &cachedSession{session: sess, expires: expires}
+}
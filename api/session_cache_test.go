@@ -0,0 +1,95 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/s3-api/session"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// counterValue returns the current value of a counter
+func counterValue(t *testing.T, c prometheus.Counter) float64 {
+	m := &dto.Metric{}
+	if err := c.Write(m); err != nil {
+		t.Fatalf("failed to read counter: %s", err)
+	}
+	return m.GetCounter().GetValue()
+}
+
+func TestSessionCacheKey(t *testing.T) {
+	role := "arn:aws:iam::012345678910:role/SpinupS3"
+
+	key := sessionCacheKey("012345678910", role, "ext", `{"Statement":[]}`, nil)
+	if key != sessionCacheKey("012345678910", role, "ext", `{"Statement":[]}`, nil) {
+		t.Error("expected the same key for the same session")
+	}
+
+	for _, other := range []string{
+		sessionCacheKey("012345678910", role, "ext", `{"Statement":[{}]}`, nil),
+		sessionCacheKey("012345678910", role, "other", `{"Statement":[]}`, nil),
+		sessionCacheKey("012345678910", role, "ext", `{"Statement":[]}`, []string{"arn:aws:iam::aws:policy/ReadOnlyAccess"}),
+	} {
+		if other == key {
+			t.Errorf("expected a different key than %s", key)
+		}
+	}
+
+	if len(key) != len("012345678910/")+64 {
+		t.Errorf("expected the account and a sha256 hash, got %s", key)
+	}
+
+	if out := roleAccountId(role); out != "012345678910" {
+		t.Errorf("expected account id 012345678910, got %s", out)
+	}
+}
+
+func TestSessionCache(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := newSessionCache(5 * time.Minute)
+	c.now = func() time.Time { return now }
+
+	account := "session-cache-test"
+
+	if _, ok := c.get(account, "key"); ok {
+		t.Fatal("expected a miss for an empty cache")
+	}
+
+	sess := &session.Session{RoleName: "test"}
+	c.set("key", sess, now.Add(15*time.Minute))
+
+	out, ok := c.get(account, "key")
+	if !ok || out != sess {
+		t.Fatalf("expected the cached session, got %v, %t", out, ok)
+	}
+
+	// within the refresh window of the expiration the role is assumed again
+	now = now.Add(11 * time.Minute)
+	if _, ok := c.get(account, "key"); ok {
+		t.Fatal("expected the session to be refreshed")
+	}
+
+	if _, ok := c.sessions["key"]; ok {
+		t.Error("expected the refreshed session to be removed")
+	}
+
+	// expired sessions are removed when a session is cached
+	c.set("expired", sess, now.Add(-time.Minute))
+	c.set("key", sess, now.Add(15*time.Minute))
+	if _, ok := c.sessions["expired"]; ok {
+		t.Error("expected the expired session to be removed")
+	}
+
+	if hits := counterValue(t, sessionCacheHits.WithLabelValues(account)); hits != 1 {
+		t.Errorf("expected 1 hit, got %g", hits)
+	}
+
+	if misses := counterValue(t, sessionCacheMisses.WithLabelValues(account)); misses != 1 {
+		t.Errorf("expected 1 miss, got %g", misses)
+	}
+
+	if refreshes := counterValue(t, sessionCacheRefreshes.WithLabelValues(account)); refreshes != 1 {
+		t.Errorf("expected 1 refresh, got %g", refreshes)
+	}
+}
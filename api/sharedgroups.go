@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/YaleSpinup/apierror"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	log "github.com/sirupsen/logrus"
+)
+
+// sharedAdminGroupActions are the actions on the shared admin group when a bucket is created with it
+var sharedAdminGroupActions = []string{"iam:GetGroup", "iam:AttachGroupPolicy", "iam:DetachGroupPolicy"}
+
+// validateSharedAdminGroups validates the names of the configured shared admin groups.  A shared group can't be named
+// like the management groups of a bucket, which are deleted with their bucket.
+func validateSharedAdminGroups(groups []string) error {
+	for _, g := range groups {
+		if !iamNameRe.MatchString(g) {
+			return fmt.Errorf("invalid shared admin group name %q", g)
+		}
+
+		if _, _, ok := iamapi.SplitGroupName(g); ok {
+			return fmt.Errorf("shared admin group %s is named like a bucket management group", g)
+		}
+	}
+
+	return nil
+}
+
+// requireSharedAdminGroup returns a bad request error unless the group is one of the configured shared admin groups
+func (s *server) requireSharedAdminGroup(group string) error {
+	for _, g := range s.account.SharedAdminGroups {
+		if g == group {
+			return nil
+		}
+	}
+
+	msg := fmt.Sprintf("%s is not a shared admin group", group)
+	return apierror.New(apierror.ErrBadRequest, msg, nil)
+}
+
+// withSharedAdminGroups adds a statement allowing the actions on the shared admin groups to a scoped policy, the
+// groups aren't named for the bucket so they aren't covered by its group resources
+func (s *server) withSharedAdminGroups(policy scopedPolicy, actions ...string) scopedPolicy {
+	if len(s.account.SharedAdminGroups) == 0 {
+		return policy
+	}
+
+	resources := []string{}
+	for _, g := range s.account.SharedAdminGroups {
+		resources = append(resources, "arn:aws:iam::{{.Account}}:group/"+g, "arn:aws:iam::{{.Account}}:group/*/"+g)
+	}
+
+	return append(append(scopedPolicy{}, policy...), scopedStatement{Actions: actions, Resources: resources})
+}
+
+// deleteSharedAdminPolicy detaches the admin policy of a deleted bucket from the shared admin groups it's attached to
+// and deletes it.  The groups are shared with other buckets, so they're left alone along with their users.  Nothing
+// is done if the policy was already deleted with the bucket's own admin group.
+func deleteSharedAdminPolicy(ctx context.Context, iamService iamapi.IAM, accountId, bucket string) error {
+	policyArn := iamService.PolicyArn(accountId, iamapi.FormatPolicyName(bucket, "/", "BktAdmPlc"))
+
+	groups, err := iamService.ListPolicyGroups(ctx, policyArn)
+	if err != nil {
+		if isNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, g := range groups {
+		log.Infof("detaching the admin policy of bucket %s from shared group %s", bucket, aws.StringValue(g.GroupName))
+
+		if err := iamService.DetachGroupPolicy(ctx, &iam.DetachGroupPolicyInput{
+			GroupName: g.GroupName,
+			PolicyArn: aws.String(policyArn),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return iamService.DeletePolicy(ctx, &iam.DeletePolicyInput{PolicyArn: aws.String(policyArn)})
+}
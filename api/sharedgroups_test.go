@@ -0,0 +1,145 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	awsiam "github.com/YaleSpinup/aws-go/services/iam"
+	"github.com/YaleSpinup/s3-api/common"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+)
+
+func TestValidateSharedAdminGroups(t *testing.T) {
+	tests := []struct {
+		groups []string
+		valid  bool
+	}{
+		{nil, true},
+		{[]string{"test-admins", "research@example"}, true},
+		{[]string{"test admins"}, false},
+		{[]string{"{{.Account}}"}, false},
+		{[]string{"foo-BktAdmGrp"}, false},
+	}
+
+	for _, tt := range tests {
+		if err := validateSharedAdminGroups(tt.groups); (err == nil) != tt.valid {
+			t.Errorf("expected %v to be valid: %t, got %v", tt.groups, tt.valid, err)
+		}
+	}
+}
+
+func TestRequireSharedAdminGroup(t *testing.T) {
+	s := server{account: common.Account{SharedAdminGroups: []string{"test-admins"}}}
+
+	if err := s.requireSharedAdminGroup("test-admins"); err != nil {
+		t.Errorf("expected nil error for a shared admin group, got %s", err)
+	}
+
+	err := s.requireSharedAdminGroup("Administrators")
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrBadRequest {
+		t.Errorf("expected a bad request error for a group that isn't shared, got %v", err)
+	}
+}
+
+func TestWithSharedAdminGroups(t *testing.T) {
+	s := server{}
+	if out := s.withSharedAdminGroups(bucketDeletePolicy, "iam:DetachGroupPolicy"); !reflect.DeepEqual(bucketDeletePolicy, out) {
+		t.Error("expected the policy to be unchanged without shared admin groups")
+	}
+
+	s.account.SharedAdminGroups = []string{"test-admins"}
+	out, err := s.withSharedAdminGroups(scopedPolicy{}, "iam:DetachGroupPolicy").generate(policyScope{Account: "012345678910", Bucket: "foobucket"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	var doc awsiam.PolicyDocument
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("failed to unmarshal generated policy: %s", err)
+	}
+
+	expected := []awsiam.StatementEntry{
+		{
+			Effect:   "Allow",
+			Action:   []string{"iam:DetachGroupPolicy"},
+			Resource: []string{"arn:aws:iam::012345678910:group/test-admins", "arn:aws:iam::012345678910:group/*/test-admins"},
+		},
+	}
+	if !reflect.DeepEqual(expected, doc.Statement) {
+		t.Errorf("expected statements %+v, got %+v", expected, doc.Statement)
+	}
+}
+
+// mockSharedGroupIAM is an IAM client where the admin policy of a bucket is attached to a shared group, unless the
+// policy was already deleted
+type mockSharedGroupIAM struct {
+	iamiface.IAMAPI
+	deleted  bool
+	detached []string
+}
+
+func (m *mockSharedGroupIAM) ListEntitiesForPolicyWithContext(ctx context.Context, input *iam.ListEntitiesForPolicyInput, opts ...request.Option) (*iam.ListEntitiesForPolicyOutput, error) {
+	if m.deleted {
+		return nil, awserr.New(iam.ErrCodeNoSuchEntityException, "not found", nil)
+	}
+	return &iam.ListEntitiesForPolicyOutput{PolicyGroups: []*iam.PolicyGroup{{GroupName: aws.String("test-admins")}}}, nil
+}
+
+func (m *mockSharedGroupIAM) DetachGroupPolicyWithContext(ctx context.Context, input *iam.DetachGroupPolicyInput, opts ...request.Option) (*iam.DetachGroupPolicyOutput, error) {
+	m.detached = append(m.detached, aws.StringValue(input.GroupName)+" "+aws.StringValue(input.PolicyArn))
+	return &iam.DetachGroupPolicyOutput{}, nil
+}
+
+func (m *mockSharedGroupIAM) ListPolicyVersionsWithContext(ctx context.Context, input *iam.ListPolicyVersionsInput, opts ...request.Option) (*iam.ListPolicyVersionsOutput, error) {
+	return &iam.ListPolicyVersionsOutput{Versions: []*iam.PolicyVersion{{VersionId: aws.String("v1"), IsDefaultVersion: aws.Bool(true)}}}, nil
+}
+
+func (m *mockSharedGroupIAM) DeletePolicyWithContext(ctx context.Context, input *iam.DeletePolicyInput, opts ...request.Option) (*iam.DeletePolicyOutput, error) {
+	m.deleted = true
+	return &iam.DeletePolicyOutput{}, nil
+}
+
+func TestDeleteSharedAdminPolicy(t *testing.T) {
+	client := &mockSharedGroupIAM{}
+	iamService := iamapi.IAM{Service: client}
+
+	if err := deleteSharedAdminPolicy(context.TODO(), iamService, "012345678910", "foobucket"); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if expected := []string{"test-admins arn:aws:iam::012345678910:policy/foobucket-BktAdmPlc"}; !reflect.DeepEqual(expected, client.detached) {
+		t.Errorf("expected the policy to be detached from the shared group %v, got %v", expected, client.detached)
+	}
+
+	if !client.deleted {
+		t.Error("expected the bucket admin policy to be deleted")
+	}
+
+	// the policy was deleted along with the bucket's own admin group
+	client.detached = nil
+	if err := deleteSharedAdminPolicy(context.TODO(), iamService, "012345678910", "foobucket"); err != nil {
+		t.Errorf("expected nil error when the policy is already deleted, got %s", err)
+	}
+
+	if len(client.detached) != 0 {
+		t.Errorf("expected nothing to be detached, got %v", client.detached)
+	}
+}
+
+func TestBucketCreateQuotaNeeds(t *testing.T) {
+	if out := (&bucketCreateRequest{}).quotaNeeds(); out != (quotaNeeds{Groups: 1, Policies: 1}) {
+		t.Errorf("expected a group and a policy, got %+v", out)
+	}
+
+	if out := (&bucketCreateRequest{AdminGroup: "test-admins"}).quotaNeeds(); out != (quotaNeeds{Policies: 1}) {
+		t.Errorf("expected only a policy with a shared admin group, got %+v", out)
+	}
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/YaleSpinup/s3-api/storage"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// signedClientHeader is the id of the client that signed the request
+	signedClientHeader = "X-Spinup-Client"
+	// signedTimestampHeader is the unix time the request was signed at
+	signedTimestampHeader = "X-Spinup-Timestamp"
+	// signatureHeader is the hex encoded HMAC-SHA256 signature of the request
+	signatureHeader = "X-Spinup-Signature"
+
+	// signatureTable is the state store table the signatures of the authenticated requests are kept in
+	signatureTable = "signatures"
+	// defaultSignatureMaxSkew is the furthest a request's timestamp can be from the time it's received
+	defaultSignatureMaxSkew = 5 * time.Minute
+	// defaultSignedMaxBodySize is the size of the largest body of a signed request if it isn't configured
+	defaultSignedMaxBodySize = 10 << 20
+)
+
+// requestVerifier authenticates requests signed with the shared key of a client.  The signatures of the authenticated
+// requests are kept in the state store until their timestamp is out of range, so a request can't be replayed.
+type requestVerifier struct {
+	keys        map[string][]byte
+	maxSkew     time.Duration
+	maxBodySize int64
+	store       storage.Store
+	now         func() time.Time
+}
+
+// newRequestVerifier creates the verifier for signed requests from the configuration, signed requests aren't
+// supported if it's not configured
+func newRequestVerifier(config *common.SignedRequests, store storage.Store) (*requestVerifier, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	v := &requestVerifier{
+		keys:        map[string][]byte{},
+		maxSkew:     defaultSignatureMaxSkew,
+		maxBodySize: defaultSignedMaxBodySize,
+		store:       store,
+		now:         time.Now,
+	}
+
+	for client, key := range config.Keys {
+		if key == "" {
+			return nil, fmt.Errorf("signing key for client %s cannot be empty", client)
+		}
+		v.keys[client] = []byte(key)
+	}
+
+	if config.MaxSkew != "" {
+		skew, err := time.ParseDuration(config.MaxSkew)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse signed requests max skew %s", config.MaxSkew)
+		}
+
+		if skew <= 0 {
+			return nil, fmt.Errorf("signed requests max skew %s must be positive", config.MaxSkew)
+		}
+		v.maxSkew = skew
+	}
+
+	if config.MaxBodySize < 0 {
+		return nil, fmt.Errorf("signed requests max body size %d cannot be negative", config.MaxBodySize)
+	}
+
+	if config.MaxBodySize > 0 {
+		v.maxBodySize = config.MaxBodySize
+	}
+
+	return v, nil
+}
+
+// requestSignature returns the hex encoded HMAC-SHA256 signature of a request.  The signed string is the method, the
+// request uri with its query, the timestamp and the hex encoded SHA-256 hash of the body, separated by newlines.
+func requestSignature(key []byte, method, uri, timestamp string, body []byte) string {
+	sum := sha256.Sum256(body)
+
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s", method, uri, timestamp, hex.EncodeToString(sum[:]))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verify checks the signature and timestamp of a request and that it hasn't been seen before.  The body is read, up
+// to the max body size, to check the signature and replaced so it can be read again by the handler.
+func (v *requestVerifier) verify(w http.ResponseWriter, r *http.Request) error {
+	client := r.Header.Get(signedClientHeader)
+	key, ok := v.keys[client]
+	if !ok {
+		return apierror.New(apierror.ErrForbidden, "unknown client "+client, nil)
+	}
+
+	timestamp := r.Header.Get(signedTimestampHeader)
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return apierror.New(apierror.ErrForbidden, "invalid timestamp "+timestamp, err)
+	}
+
+	now := v.now()
+	signed := time.Unix(unix, 0)
+	if signed.Before(now.Add(-v.maxSkew)) || signed.After(now.Add(v.maxSkew)) {
+		return apierror.New(apierror.ErrForbidden, "timestamp "+timestamp+" is out of range", nil)
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, v.maxBodySize))
+	if err != nil {
+		return apierror.New(apierror.ErrBadRequest, "failed to read request body", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	signature := r.Header.Get(signatureHeader)
+	expected := requestSignature(key, r.Method, r.URL.RequestURI(), timestamp, body)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return apierror.New(apierror.ErrForbidden, "invalid signature from client "+client, nil)
+	}
+
+	// the signature is remembered until its timestamp is out of range, with room for the clocks of the instances
+	fresh, err := v.store.PutIfAbsent(r.Context(), signatureTable, client+"/"+signature, []byte(timestamp), now.Add(2*v.maxSkew))
+	if err != nil {
+		return err
+	}
+
+	if !fresh {
+		return apierror.New(apierror.ErrForbidden, "replayed request from client "+client, nil)
+	}
+
+	return nil
+}
+
+// middleware authenticates the requests with a signature and passes them to the handler, the requests without a
+// signature are passed to the token middleware.  All of the requests go to the token middleware if signed requests
+// aren't configured.
+func (v *requestVerifier) middleware(token, h http.Handler) http.Handler {
+	if v == nil {
+		return token
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(signatureHeader) == "" {
+			token.ServeHTTP(w, r)
+			return
+		}
+
+		if err := v.verify(w, r); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				log.Warnf("Signed request body for '%s' is larger than %d bytes", r.URL, tooLarge.Limit)
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			if aerr, ok := common.AsAPIError(err); ok && aerr.Code == apierror.ErrForbidden {
+				log.Warnf("Unable to authenticate signed request for '%s': %s", r.URL, aerr.Message)
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			handleError(w, err)
+			return
+		}
+
+		log.Infof("Successfully authenticated signed request from client %s for URL '%s'", r.Header.Get(signedClientHeader), r.URL)
+
+		h.ServeHTTP(w, r)
+	})
+}
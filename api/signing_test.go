@@ -0,0 +1,143 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/YaleSpinup/s3-api/storage"
+)
+
+func TestNewRequestVerifier(t *testing.T) {
+	if v, err := newRequestVerifier(nil, nil); v != nil || err != nil {
+		t.Errorf("expected nil verifier and error without a configuration, got %v, %v", v, err)
+	}
+
+	v, err := newRequestVerifier(&common.SignedRequests{Keys: map[string]string{"spinup": "sekret"}}, nil)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if v.maxSkew != defaultSignatureMaxSkew || v.maxBodySize != defaultSignedMaxBodySize {
+		t.Errorf("expected default max skew %s and body size %d, got %s and %d", defaultSignatureMaxSkew, defaultSignedMaxBodySize, v.maxSkew, v.maxBodySize)
+	}
+
+	v, err = newRequestVerifier(&common.SignedRequests{MaxSkew: "2m", MaxBodySize: 1024}, nil)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if v.maxSkew != 2*time.Minute || v.maxBodySize != 1024 {
+		t.Errorf("expected max skew 2m and body size 1024, got %s and %d", v.maxSkew, v.maxBodySize)
+	}
+
+	invalid := []*common.SignedRequests{
+		{Keys: map[string]string{"spinup": ""}},
+		{MaxSkew: "forever"},
+		{MaxSkew: "0s"},
+		{MaxSkew: "-1m"},
+		{MaxBodySize: -1},
+	}
+
+	for _, c := range invalid {
+		if _, err := newRequestVerifier(c, nil); err == nil {
+			t.Errorf("expected error for %+v, got nil", c)
+		}
+	}
+}
+
+func TestRequestVerifierMiddleware(t *testing.T) {
+	// the store expires the signatures by the wall clock
+	now := time.Now().Truncate(time.Second)
+	v := &requestVerifier{
+		keys:        map[string][]byte{"spinup": []byte("sekret")},
+		maxSkew:     5 * time.Minute,
+		maxBodySize: 64,
+		store:       storage.NewMemoryStore(),
+		now:         func() time.Time { return now },
+	}
+
+	var body string
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.WriteHeader(http.StatusOK)
+	})
+	token := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	h := v.middleware(token, ok)
+
+	signed := func(client, key string, ts time.Time, method, uri, payload string) *http.Request {
+		r := httptest.NewRequest(method, uri, strings.NewReader(payload))
+		timestamp := strconv.FormatInt(ts.Unix(), 10)
+		r.Header.Set(signedClientHeader, client)
+		r.Header.Set(signedTimestampHeader, timestamp)
+		r.Header.Set(signatureHeader, requestSignature([]byte(key), method, uri, timestamp, []byte(payload)))
+		return r
+	}
+
+	// a signed request is authenticated and the handler can read the body
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, signed("spinup", "sekret", now, "PUT", "/v1/s3/spinup/buckets/foo/spec?dryrun=true", `{"Versioning":"Enabled"}`))
+	if w.Code != http.StatusOK || body != `{"Versioning":"Enabled"}` {
+		t.Errorf("expected 200 with the body for a signed request, got %d with %q", w.Code, body)
+	}
+
+	// the same request can't be replayed
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, signed("spinup", "sekret", now, "PUT", "/v1/s3/spinup/buckets/foo/spec?dryrun=true", `{"Versioning":"Enabled"}`))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a replayed request, got %d", w.Code)
+	}
+
+	// the body is limited before the signature is checked, so an unauthenticated request can't send a large body
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, signed("spinup", "guess", now, "PUT", "/v1/s3/spinup/buckets/foo/spec", strings.Repeat("x", 65)))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 for a body larger than the limit, got %d", w.Code)
+	}
+
+	// requests without a signature go to the token middleware
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/v1/s3/ping", nil))
+	if w.Code != http.StatusTeapot {
+		t.Errorf("expected an unsigned request to go to the token middleware, got %d", w.Code)
+	}
+
+	tampered := signed("spinup", "sekret", now, "PUT", "/v1/s3/spinup/buckets/foo/spec?dryrun=true", `{}`)
+	tampered.URL.RawQuery = "dryrun=false"
+
+	forbidden := map[string]*http.Request{
+		"unknown client":   signed("someone", "sekret", now, "GET", "/v1/s3/spinup/buckets", ""),
+		"wrong key":        signed("spinup", "guess", now, "GET", "/v1/s3/spinup/buckets", ""),
+		"old timestamp":    signed("spinup", "sekret", now.Add(-6*time.Minute), "GET", "/v1/s3/spinup/buckets", ""),
+		"future timestamp": signed("spinup", "sekret", now.Add(6*time.Minute), "GET", "/v1/s3/spinup/buckets", ""),
+		"tampered query":   tampered,
+	}
+
+	bad := signed("spinup", "sekret", now, "GET", "/v1/s3/spinup/buckets/bar", "")
+	bad.Header.Set(signedTimestampHeader, "yesterday")
+	forbidden["invalid timestamp"] = bad
+
+	for name, r := range forbidden {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s: expected 403, got %d", name, w.Code)
+		}
+	}
+
+	// without a verifier all of the requests go to the token middleware
+	var none *requestVerifier
+	w = httptest.NewRecorder()
+	none.middleware(token, ok).ServeHTTP(w, signed("spinup", "sekret", now, "GET", "/v1/s3/spinup/buckets", ""))
+	if w.Code != http.StatusTeapot {
+		t.Errorf("expected requests to go to the token middleware without a verifier, got %d", w.Code)
+	}
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/YaleSpinup/s3-api/storage"
+	log "github.com/sirupsen/logrus"
+)
+
+// newStateStore creates the store for the durable state of the api from the storage configuration, the state is kept
+// in memory if it's not configured
+func newStateStore(config *common.Storage, account common.Account) (storage.Store, error) {
+	if config == nil {
+		return storage.NewMemoryStore(), nil
+	}
+
+	switch config.Backend {
+	case "", storage.BackendMemory:
+		return storage.NewMemoryStore(), nil
+	case storage.BackendBolt:
+		if config.Path == "" {
+			return nil, fmt.Errorf("storage path is required for the %s backend", storage.BackendBolt)
+		}
+		return storage.NewBoltStore(config.Path)
+	case storage.BackendDynamoDB:
+		if config.Table == "" {
+			return nil, fmt.Errorf("storage table is required for the %s backend", storage.BackendDynamoDB)
+		}
+		log.Infof("keeping state in dynamodb table %s", config.Table)
+		return storage.NewDynamoDBStore(nil, account, config.Table), nil
+	default:
+		return nil, fmt.Errorf("invalid storage backend '%s', expected one of %s, %s or %s", config.Backend, storage.BackendMemory, storage.BackendBolt, storage.BackendDynamoDB)
+	}
+}
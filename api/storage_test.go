@@ -0,0 +1,48 @@
+package api
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/YaleSpinup/s3-api/storage"
+)
+
+func TestNewStateStore(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *common.Storage
+		valid  bool
+	}{
+		{"default", nil, true},
+		{"memory", &common.Storage{Backend: "memory"}, true},
+		{"bolt", &common.Storage{Backend: "bolt", Path: filepath.Join(t.TempDir(), "state.db")}, true},
+		{"bolt without a path", &common.Storage{Backend: "bolt"}, false},
+		{"dynamodb", &common.Storage{Backend: "dynamodb", Table: "spinup-s3-api-state"}, true},
+		{"dynamodb without a table", &common.Storage{Backend: "dynamodb"}, false},
+		{"invalid backend", &common.Storage{Backend: "redis"}, false},
+	}
+
+	for _, test := range tests {
+		store, err := newStateStore(test.config, common.Account{Region: "us-east-1"})
+		if (err == nil) != test.valid {
+			t.Errorf("%s: expected valid %t, got %v", test.name, test.valid, err)
+			continue
+		}
+
+		if !test.valid {
+			continue
+		}
+
+		if store == nil {
+			t.Errorf("%s: expected a store, got nil", test.name)
+			continue
+		}
+		store.Close()
+	}
+
+	store, _ := newStateStore(nil, common.Account{})
+	if _, ok := store.(*storage.MemoryStore); !ok {
+		t.Errorf("expected a memory store by default, got %T", store)
+	}
+}
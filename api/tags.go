@@ -0,0 +1,262 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// reservedTagKeys are the bucket tags managed by the api, they can't be passed in the tags of a bucket or website
+// and they're kept when the tags of a bucket are replaced
+var reservedTagKeys = []string{protectedTagKey, pendingDeleteTagKey, simpleWebsiteTagKey, scratchTagKey, scratchWarnedTagKey, stagingTagKey}
+
+// distributionTagKeys are the tags managed by the api on the cloudfront distribution of a website, they aren't bucket
+// tags so they're kept when the bucket's tags are synced to the distribution
+var distributionTagKeys = []string{previousReleaseTagKey}
+
+// objectTagPrefix prefixes the bucket tags that hold the default tags for new objects in the bucket, ie. the bucket
+// tag spinup:object:project=X tags the objects created by the api with project=X
+const objectTagPrefix = "spinup:object:"
+
+// isReservedTag returns true if the tag key is one of the reserved tags or a default object tag
+func isReservedTag(key string) bool {
+	return contains(reservedTagKeys, key) || strings.HasPrefix(key, objectTagPrefix)
+}
+
+// requiredTag is a tag that must be set on the resources created by the api
+type requiredTag struct {
+	key         string
+	pattern     *regexp.Regexp
+	description string
+}
+
+// requiredTags is the tag schema for an account
+type requiredTags []requiredTag
+
+// newRequiredTags compiles the required tags from the configuration
+func newRequiredTags(config []common.RequiredTag) (requiredTags, error) {
+	tags := requiredTags{}
+	for _, t := range config {
+		if t.Key == "" {
+			return nil, fmt.Errorf("required tag key cannot be empty")
+		}
+
+		if t.Key == "spinup:org" {
+			return nil, fmt.Errorf("required tag key spinup:org is reserved")
+		}
+
+		rt := requiredTag{key: t.Key, description: t.Description}
+		if t.Pattern != "" {
+			re, err := regexp.Compile(t.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern for required tag %s: %s", t.Key, err)
+			}
+			rt.pattern = re
+		}
+
+		tags = append(tags, rt)
+	}
+
+	return tags, nil
+}
+
+// keys returns the keys of the required tags
+func (r requiredTags) keys() []string {
+	keys := make([]string, 0, len(r))
+	for _, t := range r {
+		keys = append(keys, t.key)
+	}
+	return keys
+}
+
+// violations returns a message for each required tag that is missing from the tags or doesn't match its pattern
+func (r requiredTags) violations(tags map[string]string) []string {
+	violations := []string{}
+	for _, t := range r {
+		hint := ""
+		if t.description != "" {
+			hint = " (" + t.description + ")"
+		}
+
+		value, ok := tags[t.key]
+		switch {
+		case !ok || value == "":
+			violations = append(violations, fmt.Sprintf("required tag %s is missing%s", t.key, hint))
+		case t.pattern != nil && !t.pattern.MatchString(value):
+			violations = append(violations, fmt.Sprintf("tag %s value %q doesn't match %s%s", t.key, value, t.pattern, hint))
+		}
+	}
+
+	return violations
+}
+
+// inherited returns the spinup:org tag and the required tags from a bucket's tags, these are the tags propagated to
+// the IAM resources created for an existing bucket
+func (r requiredTags) inherited(tags []*s3.Tag) []*s3.Tag {
+	keys := append(r.keys(), "spinup:org")
+
+	inherited := []*s3.Tag{}
+	for _, t := range tags {
+		if contains(keys, aws.StringValue(t.Key)) {
+			inherited = append(inherited, t)
+		}
+	}
+
+	return inherited
+}
+
+// s3TagMap converts a list of s3 tags to a map of keys to values
+func s3TagMap(tags []*s3.Tag) map[string]string {
+	m := map[string]string{}
+	for _, t := range tags {
+		if t != nil {
+			m[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+		}
+	}
+	return m
+}
+
+// cloudFrontTagMap converts a list of cloudfront tags to a map of keys to values
+func cloudFrontTagMap(tags []*cloudfront.Tag) map[string]string {
+	m := map[string]string{}
+	for _, t := range tags {
+		if t != nil {
+			m[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+		}
+	}
+	return m
+}
+
+// cloudFrontTags converts s3 tags to cloudfront tags
+func cloudFrontTags(tags []*s3.Tag) []*cloudfront.Tag {
+	cfTags := []*cloudfront.Tag{}
+	for _, t := range tags {
+		cfTags = append(cfTags, &cloudfront.Tag{Key: t.Key, Value: t.Value})
+	}
+	return cfTags
+}
+
+// iamTags converts s3 tags to IAM tags
+func iamTags(tags []*s3.Tag) []*iam.Tag {
+	iTags := []*iam.Tag{}
+	for _, t := range tags {
+		iTags = append(iTags, &iam.Tag{Key: t.Key, Value: t.Value})
+	}
+	return iTags
+}
+
+// mergeIAMTags returns the tags with the overrides applied, replacing any tags with the same key
+func mergeIAMTags(tags, overrides []*iam.Tag) []*iam.Tag {
+	keys := map[string]bool{}
+	for _, t := range overrides {
+		keys[aws.StringValue(t.Key)] = true
+	}
+
+	merged := []*iam.Tag{}
+	for _, t := range tags {
+		if t != nil && !keys[aws.StringValue(t.Key)] {
+			merged = append(merged, t)
+		}
+	}
+
+	return append(merged, overrides...)
+}
+
+// mergeTags returns the s3 tags with the overrides applied, replacing any tags with the same key
+func mergeTags(tags, overrides []*s3.Tag) []*s3.Tag {
+	keys := map[string]bool{}
+	for _, t := range overrides {
+		keys[aws.StringValue(t.Key)] = true
+	}
+
+	merged := []*s3.Tag{}
+	for _, t := range tags {
+		if t != nil && !keys[aws.StringValue(t.Key)] {
+			merged = append(merged, t)
+		}
+	}
+
+	return append(merged, overrides...)
+}
+
+// keepReservedTags carries the reserved tags over from the current tags of a bucket to the tags replacing them, so
+// updating the tags of a bucket doesn't remove its protection, pending deletion or default object tags
+func keepReservedTags(current, tags []*s3.Tag) []*s3.Tag {
+	out := []*s3.Tag{}
+	for _, t := range tags {
+		if t != nil && !isReservedTag(aws.StringValue(t.Key)) {
+			out = append(out, t)
+		}
+	}
+
+	for _, t := range current {
+		if t != nil && isReservedTag(aws.StringValue(t.Key)) {
+			out = append(out, t)
+		}
+	}
+
+	return out
+}
+
+// defaultObjectTags returns the default object tags stored in the tags of a bucket
+func defaultObjectTags(bucketTags []*s3.Tag) []*s3.Tag {
+	tags := []*s3.Tag{}
+	for _, t := range bucketTags {
+		if t != nil && strings.HasPrefix(aws.StringValue(t.Key), objectTagPrefix) {
+			tags = append(tags, &s3.Tag{
+				Key:   aws.String(strings.TrimPrefix(aws.StringValue(t.Key), objectTagPrefix)),
+				Value: t.Value,
+			})
+		}
+	}
+	return tags
+}
+
+// withDefaultObjectTags returns the tags of a bucket with its default object tags replaced by the passed tags
+func withDefaultObjectTags(bucketTags, objectTags []*s3.Tag) []*s3.Tag {
+	tags := []*s3.Tag{}
+	for _, t := range bucketTags {
+		if t != nil && !strings.HasPrefix(aws.StringValue(t.Key), objectTagPrefix) {
+			tags = append(tags, t)
+		}
+	}
+
+	for _, t := range objectTags {
+		tags = append(tags, &s3.Tag{
+			Key:   aws.String(objectTagPrefix + aws.StringValue(t.Key)),
+			Value: t.Value,
+		})
+	}
+
+	return tags
+}
+
+// objectTagging encodes tags as the url query string expected by the Tagging parameter of an object upload
+func objectTagging(tags []*s3.Tag) string {
+	values := url.Values{}
+	for _, t := range tags {
+		if t != nil {
+			values.Set(aws.StringValue(t.Key), aws.StringValue(t.Value))
+		}
+	}
+	return values.Encode()
+}
+
+// withoutTag returns the tags without the tag with the given key
+func withoutTag(tags []*s3.Tag, key string) []*s3.Tag {
+	out := []*s3.Tag{}
+	for _, t := range tags {
+		if t != nil && aws.StringValue(t.Key) != key {
+			out = append(out, t)
+		}
+	}
+
+	return out
+}
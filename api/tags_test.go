@@ -0,0 +1,172 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func TestNewRequiredTags(t *testing.T) {
+	tests := []struct {
+		name   string
+		config []common.RequiredTag
+		err    bool
+	}{
+		{"empty", nil, false},
+		{"valid", []common.RequiredTag{{Key: "costcenter", Pattern: "^[0-9]+$"}, {Key: "owner-netid"}}, false},
+		{"missing key", []common.RequiredTag{{Pattern: "^[0-9]+$"}}, true},
+		{"reserved key", []common.RequiredTag{{Key: "spinup:org"}}, true},
+		{"invalid pattern", []common.RequiredTag{{Key: "costcenter", Pattern: "^[0-9+$"}}, true},
+	}
+
+	for _, test := range tests {
+		out, err := newRequiredTags(test.config)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected error, got nil", test.name)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: expected nil error, got %s", test.name, err)
+			continue
+		}
+
+		if len(out) != len(test.config) {
+			t.Errorf("%s: expected %d required tags, got %d", test.name, len(test.config), len(out))
+		}
+	}
+}
+
+func TestRequiredTagsInherited(t *testing.T) {
+	Org = "testorg"
+	required, _ := newRequiredTags([]common.RequiredTag{{Key: "costcenter"}})
+
+	tags := []*s3.Tag{
+		{Key: aws.String("spinup:org"), Value: aws.String("testorg")},
+		{Key: aws.String("costcenter"), Value: aws.String("123456")},
+		{Key: aws.String("Name"), Value: aws.String("foo")},
+	}
+
+	expected := []*s3.Tag{tags[0], tags[1]}
+	if out := required.inherited(tags); !reflect.DeepEqual(out, expected) {
+		t.Errorf("expected %+v, got %+v", expected, out)
+	}
+}
+
+func TestMergeIAMTags(t *testing.T) {
+	tags := []*iam.Tag{
+		{Key: aws.String("costcenter"), Value: aws.String("000000")},
+		{Key: aws.String("Name"), Value: aws.String("foo")},
+	}
+	overrides := []*iam.Tag{
+		{Key: aws.String("costcenter"), Value: aws.String("123456")},
+	}
+
+	expected := []*iam.Tag{tags[1], overrides[0]}
+	if out := mergeIAMTags(tags, overrides); !reflect.DeepEqual(out, expected) {
+		t.Errorf("expected %+v, got %+v", expected, out)
+	}
+}
+
+func TestTagsComplianceReport(t *testing.T) {
+	required, _ := newRequiredTags([]common.RequiredTag{{Key: "costcenter", Pattern: "^[0-9]{6}$"}})
+
+	resources := []taggedResource{
+		{Type: "bucket", Name: "compliant", Tags: map[string]string{"costcenter": "123456"}},
+		{Type: "distribution", Name: "www.example.com", ARN: "arn:aws:cloudfront::012345678910:distribution/ABC", Tags: map[string]string{}},
+		{Type: "bucket", Name: "invalid", Tags: map[string]string{"costcenter": "abc"}},
+	}
+
+	report := tagsComplianceReport(required, resources)
+	if report.Checked != 3 || report.Compliant != 1 {
+		t.Errorf("expected 3 checked and 1 compliant, got %d and %d", report.Checked, report.Compliant)
+	}
+
+	names := []string{}
+	for _, r := range report.NonCompliant {
+		names = append(names, r.Type+"/"+r.Name)
+		if len(r.Violations) != 1 {
+			t.Errorf("expected 1 violation for %s, got %v", r.Name, r.Violations)
+		}
+	}
+
+	if expected := []string{"bucket/invalid", "distribution/www.example.com"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected non compliant resources %v, got %v", expected, names)
+	}
+
+	if !reflect.DeepEqual(report.RequiredTags, []string{"costcenter"}) {
+		t.Errorf("expected required tags [costcenter], got %v", report.RequiredTags)
+	}
+}
+
+func TestKeepReservedTags(t *testing.T) {
+	org := &s3.Tag{Key: aws.String("spinup:org"), Value: aws.String("test")}
+	owner := &s3.Tag{Key: aws.String("owner"), Value: aws.String("someone")}
+	protected := &s3.Tag{Key: aws.String(protectedTagKey), Value: aws.String("true")}
+	pending := &s3.Tag{Key: aws.String(pendingDeleteTagKey), Value: aws.String("2023-05-08T14:22:01Z")}
+
+	if out := keepReservedTags([]*s3.Tag{org, protected, pending}, []*s3.Tag{owner, org}); !reflect.DeepEqual(out, []*s3.Tag{owner, org, protected, pending}) {
+		t.Errorf("expected reserved tags to be kept, got %+v", out)
+	}
+
+	if out := keepReservedTags([]*s3.Tag{org}, []*s3.Tag{owner, protected, org, pending}); !reflect.DeepEqual(out, []*s3.Tag{owner, org}) {
+		t.Errorf("expected reserved tags not to be added, got %+v", out)
+	}
+
+	project := &s3.Tag{Key: aws.String("spinup:object:project"), Value: aws.String("X")}
+	if out := keepReservedTags([]*s3.Tag{org, project}, []*s3.Tag{owner, org}); !reflect.DeepEqual(out, []*s3.Tag{owner, org, project}) {
+		t.Errorf("expected default object tags to be kept, got %+v", out)
+	}
+}
+
+func TestDefaultObjectTags(t *testing.T) {
+	org := &s3.Tag{Key: aws.String("spinup:org"), Value: aws.String("test")}
+	project := &s3.Tag{Key: aws.String("project"), Value: aws.String("X")}
+	owner := &s3.Tag{Key: aws.String("owner"), Value: aws.String("someone")}
+
+	bucketTags := withDefaultObjectTags([]*s3.Tag{org, {Key: aws.String("spinup:object:stale"), Value: aws.String("Y")}}, []*s3.Tag{project, owner})
+	expected := []*s3.Tag{
+		org,
+		{Key: aws.String("spinup:object:project"), Value: aws.String("X")},
+		{Key: aws.String("spinup:object:owner"), Value: aws.String("someone")},
+	}
+	if !reflect.DeepEqual(bucketTags, expected) {
+		t.Errorf("expected bucket tags %+v, got %+v", expected, bucketTags)
+	}
+
+	if out := defaultObjectTags(bucketTags); !reflect.DeepEqual(out, []*s3.Tag{project, owner}) {
+		t.Errorf("expected default object tags %+v, got %+v", []*s3.Tag{project, owner}, out)
+	}
+
+	if out := withDefaultObjectTags(bucketTags, nil); !reflect.DeepEqual(out, []*s3.Tag{org}) {
+		t.Errorf("expected the default object tags to be removed, got %+v", out)
+	}
+}
+
+func TestObjectTagging(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     []*s3.Tag
+		expected string
+	}{
+		{"no tags", nil, ""},
+		{"one tag", []*s3.Tag{{Key: aws.String("project"), Value: aws.String("X")}}, "project=X"},
+		{"escaped", []*s3.Tag{{Key: aws.String("owner"), Value: aws.String("Some One")}, {Key: aws.String("dept:name"), Value: aws.String("a/b")}}, "dept%3Aname=a%2Fb&owner=Some+One"},
+	}
+
+	for _, test := range tests {
+		if out := objectTagging(test.tags); out != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected, out)
+		}
+	}
+
+	if out := indexTagging("project=X"); out != "yale:spinup=true&project=X" {
+		t.Errorf("expected index tagging yale:spinup=true&project=X, got %s", out)
+	}
+}
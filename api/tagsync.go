@@ -0,0 +1,263 @@
+package api
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/s3"
+	log "github.com/sirupsen/logrus"
+)
+
+// tagSyncActions are the actions needed to propagate a bucket's tags to its associated resources
+var tagSyncActions = []string{
+	"cloudfront:ListDistributions",
+	"cloudfront:ListTagsForResource",
+	"cloudfront:TagResource",
+	"cloudfront:UntagResource",
+	"iam:ListGroups",
+	"iam:GetGroup",
+	"iam:ListAttachedGroupPolicies",
+	"iam:ListPolicyTags",
+	"iam:TagPolicy",
+	"iam:UntagPolicy",
+	"iam:TagUser",
+}
+
+// tagSyncResult lists the resources a bucket's tags were propagated to
+type tagSyncResult struct {
+	Bucket       string
+	Distribution string   `json:",omitempty"`
+	Policies     []string `json:",omitempty"`
+	Users        []string `json:",omitempty"`
+}
+
+// syncBucketTags fans the canonical tags of a bucket out to the resources associated with it.  The cloudfront
+// distribution, if the bucket is a website, and the IAM policies attached to the bucket's groups get the full set
+// of tags and any other tags are removed.  The bucket's users get the org and required tags, the same as when they
+// are created, and keep any tags of their own.  IAM groups don't support tags.
+func syncBucketTags(ctx context.Context, iamService iamapi.IAM, cloudFrontService cfapi.CloudFront, required requiredTags, bucket string, tags []*s3.Tag) (*tagSyncResult, error) {
+	result := &tagSyncResult{Bucket: bucket}
+	desired := s3TagMap(tags)
+
+	distribution, err := cloudFrontService.GetDistributionByName(ctx, bucket)
+	if err != nil {
+		if !isNotFound(err) {
+			return nil, err
+		}
+	}
+
+	if distribution != nil {
+		arn := aws.StringValue(distribution.ARN)
+		current, err := cloudFrontService.ListTags(ctx, arn)
+		if err != nil {
+			return nil, err
+		}
+
+		// the tags the api keeps on the distribution itself aren't bucket tags, they aren't stale
+		stale := []string{}
+		for _, k := range staleTagKeys(cloudFrontTagMap(current), desired) {
+			if !contains(distributionTagKeys, k) {
+				stale = append(stale, k)
+			}
+		}
+
+		if err := cloudFrontService.UntagDistribution(ctx, arn, stale); err != nil {
+			return nil, err
+		}
+
+		if err := cloudFrontService.TagDistribution(ctx, arn, &cloudfront.Tags{Items: cloudFrontTags(tags)}); err != nil {
+			return nil, err
+		}
+		result.Distribution = arn
+	}
+
+	groups, err := bucketGroups(ctx, iamService, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	policies := map[string]bool{}
+	users := map[string]bool{}
+	for _, g := range groups {
+		attached, err := iamService.ListGroupPolicies(ctx, &iam.ListAttachedGroupPoliciesInput{GroupName: g.GroupName})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range attached {
+			// aws managed policies can't be tagged
+			if arn := aws.StringValue(p.PolicyArn); !strings.HasPrefix(arn, "arn:aws:iam::aws:") {
+				policies[arn] = true
+			}
+		}
+
+		members, err := iamService.ListGroupUsers(ctx, &iam.GetGroupInput{GroupName: g.GroupName})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, u := range members {
+			users[aws.StringValue(u.UserName)] = true
+		}
+	}
+
+	for _, arn := range sortedKeys(policies) {
+		current, err := iamService.ListPolicyTags(ctx, arn)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := iamService.UntagPolicy(ctx, arn, staleTagKeys(iamTagMap(current), desired)); err != nil {
+			return nil, err
+		}
+
+		if err := iamService.TagPolicy(ctx, arn, iamTags(tags)); err != nil {
+			return nil, err
+		}
+		result.Policies = append(result.Policies, arn)
+	}
+
+	inherited := iamTags(required.inherited(tags))
+	for _, user := range sortedKeys(users) {
+		if err := iamService.TagUser(ctx, user, inherited); err != nil {
+			return nil, err
+		}
+		result.Users = append(result.Users, user)
+	}
+
+	log.Infof("synced tags for bucket %s to %d policies and %d users (distribution: %s)", bucket, len(result.Policies), len(result.Users), result.Distribution)
+
+	return result, nil
+}
+
+// bucketGroups returns the management groups for a bucket, including the website groups with a path
+func bucketGroups(ctx context.Context, iamService iamapi.IAM, bucket string) ([]*iam.Group, error) {
+	return iamService.ListGroups(ctx, &iam.ListGroupsInput{}, bucket)
+}
+
+// staleTagKeys returns the sorted keys of the current tags that aren't in the desired tags
+func staleTagKeys(current, desired map[string]string) []string {
+	stale := []string{}
+	for k := range current {
+		if _, ok := desired[k]; !ok {
+			stale = append(stale, k)
+		}
+	}
+	sort.Strings(stale)
+
+	return stale
+}
+
+// iamTagMap converts a list of IAM tags to a map of keys to values
+func iamTagMap(tags []*iam.Tag) map[string]string {
+	m := map[string]string{}
+	for _, t := range tags {
+		if t != nil {
+			m[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+		}
+	}
+	return m
+}
+
+// sortedKeys returns the sorted keys of a set
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// tagSyncer periodically reconciles the tags of the resources associated with each of the org's buckets, catching
+// changes made outside of the api and syncs that failed part way through
+type tagSyncer struct {
+	account  string
+	interval time.Duration
+	server   *server
+	context  context.Context
+}
+
+// run starts the tag syncer and listens for a shutdown call
+func (t *tagSyncer) run() {
+	ticker := time.NewTicker(t.interval)
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if err := t.action(); err != nil {
+					log.Errorf("tagsync: error syncing tags for account %s: %s", t.account, err)
+				}
+			case <-t.context.Done():
+				log.Debug("tagsync: shutting down tag sync timer")
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	log.Infof("tagsync: started for account %s with interval %s", t.account, t.interval)
+}
+
+// action syncs the tags of every bucket that belongs to the org.  A failure to sync a bucket is logged and the
+// sweep continues with the next bucket.
+func (t *tagSyncer) action() error {
+	log.Debugf("tagsync: starting tag sync for account %s", t.account)
+
+	if t.server.inMaintenance(t.context) {
+		log.Infof("tagsync: skipping syncing tags for account %s in read-only maintenance mode", t.account)
+		return nil
+	}
+
+	accountId := t.server.mapAccountNumber(t.account)
+	session, err := t.server.sessionForAccount(t.context, t.account, append([]string{"s3:ListAllMyBuckets", "s3:GetBucketTagging"}, tagSyncActions...)...)
+	if err != nil {
+		return err
+	}
+
+	s3Service := s3api.NewSession(session.Session, t.server.account, t.server.mapToAccountName(accountId))
+	iamService := iamapi.NewSession(session.Session, t.server.account)
+	iamService.Cache = t.server.resourceCache(accountId)
+	cloudFrontService := cfapi.NewSession(session.Session, t.server.account, accountId)
+	cloudFrontService.Index = t.server.distributionIndex(accountId)
+
+	buckets, err := s3Service.ListBuckets(t.context, &s3.ListBucketsInput{})
+	if err != nil {
+		return err
+	}
+
+	synced, failed := 0, 0
+	for _, b := range buckets {
+		bucket := aws.StringValue(b.Name)
+		tags, err := s3Service.GetBucketTags(t.context, bucket)
+		if err != nil {
+			log.Warnf("tagsync: failed to get tags for bucket %s: %s", bucket, err)
+			failed++
+			continue
+		}
+
+		if s3TagMap(tags)["spinup:org"] != Org {
+			continue
+		}
+
+		if _, err := syncBucketTags(t.context, iamService, cloudFrontService, t.server.requiredTags, bucket, tags); err != nil {
+			log.Warnf("tagsync: failed to sync tags for bucket %s: %s", bucket, err)
+			failed++
+			continue
+		}
+		synced++
+	}
+
+	log.Infof("tagsync: synced tags for %d buckets in account %s, %d failed", synced, t.account, failed)
+
+	return nil
+}
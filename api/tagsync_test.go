@@ -0,0 +1,173 @@
+package api
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/aws/aws-sdk-go/service/cloudfront/cloudfrontiface"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// mockTagSyncCloudFront is a cloudfront client with a single website distribution
+type mockTagSyncCloudFront struct {
+	cloudfrontiface.CloudFrontAPI
+	tagged   []*cloudfront.Tag
+	untagged []string
+}
+
+func (m *mockTagSyncCloudFront) ListDistributionsPagesWithContext(ctx context.Context, input *cloudfront.ListDistributionsInput, fn func(*cloudfront.ListDistributionsOutput, bool) bool, opts ...request.Option) error {
+	fn(&cloudfront.ListDistributionsOutput{
+		DistributionList: &cloudfront.DistributionList{
+			Items: []*cloudfront.DistributionSummary{
+				{
+					ARN:     aws.String("arn:aws:cloudfront::012345678910:distribution/ABC"),
+					Aliases: &cloudfront.Aliases{Items: aws.StringSlice([]string{"www.example.com"})},
+				},
+			},
+		},
+	}, true)
+	return nil
+}
+
+func (m *mockTagSyncCloudFront) ListTagsForResourceWithContext(ctx context.Context, input *cloudfront.ListTagsForResourceInput, opts ...request.Option) (*cloudfront.ListTagsForResourceOutput, error) {
+	return &cloudfront.ListTagsForResourceOutput{
+		Tags: &cloudfront.Tags{Items: []*cloudfront.Tag{
+			{Key: aws.String("stale"), Value: aws.String("old")},
+			{Key: aws.String(previousReleaseTagKey), Value: aws.String("v1")},
+		}},
+	}, nil
+}
+
+func (m *mockTagSyncCloudFront) TagResourceWithContext(ctx context.Context, input *cloudfront.TagResourceInput, opts ...request.Option) (*cloudfront.TagResourceOutput, error) {
+	m.tagged = input.Tags.Items
+	return &cloudfront.TagResourceOutput{}, nil
+}
+
+func (m *mockTagSyncCloudFront) UntagResourceWithContext(ctx context.Context, input *cloudfront.UntagResourceInput, opts ...request.Option) (*cloudfront.UntagResourceOutput, error) {
+	m.untagged = aws.StringValueSlice(input.TagKeys.Items)
+	return &cloudfront.UntagResourceOutput{}, nil
+}
+
+// mockTagSyncIAM is an IAM client with a group for the bucket and a group for a bucket with a similar name
+type mockTagSyncIAM struct {
+	iamiface.IAMAPI
+	policyTags map[string][]*iam.Tag
+	untagged   map[string][]string
+	userTags   map[string][]*iam.Tag
+}
+
+func (m *mockTagSyncIAM) ListGroupsWithContext(ctx context.Context, input *iam.ListGroupsInput, opts ...request.Option) (*iam.ListGroupsOutput, error) {
+	return &iam.ListGroupsOutput{
+		Groups: []*iam.Group{
+			{GroupName: aws.String("www.example.com-BktAdmGrp")},
+			{GroupName: aws.String("www.example.com.old-BktAdmGrp")},
+		},
+	}, nil
+}
+
+func (m *mockTagSyncIAM) ListAttachedGroupPoliciesWithContext(ctx context.Context, input *iam.ListAttachedGroupPoliciesInput, opts ...request.Option) (*iam.ListAttachedGroupPoliciesOutput, error) {
+	name := aws.StringValue(input.GroupName)
+	return &iam.ListAttachedGroupPoliciesOutput{
+		AttachedPolicies: []*iam.AttachedPolicy{
+			{PolicyArn: aws.String("arn:aws:iam::012345678910:policy/" + name[:len(name)-3] + "Plc")},
+			{PolicyArn: aws.String("arn:aws:iam::aws:policy/ReadOnlyAccess")},
+		},
+	}, nil
+}
+
+func (m *mockTagSyncIAM) GetGroupWithContext(ctx context.Context, input *iam.GetGroupInput, opts ...request.Option) (*iam.GetGroupOutput, error) {
+	return &iam.GetGroupOutput{Users: []*iam.User{{UserName: aws.String(aws.StringValue(input.GroupName) + "-user")}}}, nil
+}
+
+func (m *mockTagSyncIAM) ListPolicyTagsWithContext(ctx context.Context, input *iam.ListPolicyTagsInput, opts ...request.Option) (*iam.ListPolicyTagsOutput, error) {
+	return &iam.ListPolicyTagsOutput{Tags: []*iam.Tag{{Key: aws.String("stale"), Value: aws.String("old")}}}, nil
+}
+
+func (m *mockTagSyncIAM) TagPolicyWithContext(ctx context.Context, input *iam.TagPolicyInput, opts ...request.Option) (*iam.TagPolicyOutput, error) {
+	m.policyTags[aws.StringValue(input.PolicyArn)] = input.Tags
+	return &iam.TagPolicyOutput{}, nil
+}
+
+func (m *mockTagSyncIAM) UntagPolicyWithContext(ctx context.Context, input *iam.UntagPolicyInput, opts ...request.Option) (*iam.UntagPolicyOutput, error) {
+	m.untagged[aws.StringValue(input.PolicyArn)] = aws.StringValueSlice(input.TagKeys)
+	return &iam.UntagPolicyOutput{}, nil
+}
+
+func (m *mockTagSyncIAM) TagUserWithContext(ctx context.Context, input *iam.TagUserInput, opts ...request.Option) (*iam.TagUserOutput, error) {
+	m.userTags[aws.StringValue(input.UserName)] = input.Tags
+	return &iam.TagUserOutput{}, nil
+}
+
+func TestSyncBucketTags(t *testing.T) {
+	Org = "testorg"
+	required := requiredTags{{key: "costcenter"}}
+	tags := []*s3.Tag{
+		{Key: aws.String("spinup:org"), Value: aws.String("testorg")},
+		{Key: aws.String("costcenter"), Value: aws.String("123456")},
+		{Key: aws.String("Name"), Value: aws.String("www")},
+	}
+
+	cf := &mockTagSyncCloudFront{}
+	im := &mockTagSyncIAM{
+		policyTags: map[string][]*iam.Tag{},
+		untagged:   map[string][]string{},
+		userTags:   map[string][]*iam.Tag{},
+	}
+
+	out, err := syncBucketTags(context.TODO(), iamapi.IAM{Service: im}, cfapi.CloudFront{Service: cf}, required, "www.example.com", tags)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	policy := "arn:aws:iam::012345678910:policy/www.example.com-BktAdmPlc"
+	expected := &tagSyncResult{
+		Bucket:       "www.example.com",
+		Distribution: "arn:aws:cloudfront::012345678910:distribution/ABC",
+		Policies:     []string{policy},
+		Users:        []string{"www.example.com-BktAdmGrp-user"},
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Errorf("expected %+v, got %+v", expected, out)
+	}
+
+	if !reflect.DeepEqual(cf.tagged, cloudFrontTags(tags)) {
+		t.Errorf("expected distribution to be tagged with %+v, got %+v", cloudFrontTags(tags), cf.tagged)
+	}
+
+	if !reflect.DeepEqual(cf.untagged, []string{"stale"}) {
+		t.Errorf("expected stale tag to be removed from the distribution, got %v", cf.untagged)
+	}
+
+	if len(im.policyTags) != 1 || !reflect.DeepEqual(im.policyTags[policy], iamTags(tags)) {
+		t.Errorf("expected only %s to be tagged with %+v, got %+v", policy, iamTags(tags), im.policyTags)
+	}
+
+	if !reflect.DeepEqual(im.untagged[policy], []string{"stale"}) {
+		t.Errorf("expected stale tag to be removed from %s, got %v", policy, im.untagged)
+	}
+
+	if userTags := im.userTags["www.example.com-BktAdmGrp-user"]; !reflect.DeepEqual(userTags, iamTags(tags[:2])) {
+		t.Errorf("expected user to be tagged with the org and required tags, got %+v", userTags)
+	}
+}
+
+func TestStaleTagKeys(t *testing.T) {
+	current := map[string]string{"a": "1", "b": "2", "c": "3"}
+	desired := map[string]string{"b": "20"}
+
+	if out := staleTagKeys(current, desired); !reflect.DeepEqual(out, []string{"a", "c"}) {
+		t.Errorf("expected [a c], got %v", out)
+	}
+
+	if out := staleTagKeys(nil, desired); len(out) != 0 {
+		t.Errorf("expected no stale keys, got %v", out)
+	}
+}
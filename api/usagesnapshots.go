@@ -0,0 +1,248 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	cwapi "github.com/YaleSpinup/s3-api/cloudwatch"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// usageSnapshotTable is the table the usage snapshots of the buckets are kept in
+	usageSnapshotTable = "usage-snapshots"
+	// defaultUsageRetentionDays is how long the usage snapshots of a bucket are kept if it's not configured
+	defaultUsageRetentionDays = 365
+)
+
+// usageSnapshot is the number of objects in a bucket and their total size from the daily cloudwatch storage metrics,
+// the timestamp is the time of the metrics
+type usageSnapshot struct {
+	Timestamp time.Time
+	Objects   int64
+	Bytes     int64
+}
+
+// bucketUsageHistory is the usage of a bucket over time, oldest first
+type bucketUsageHistory struct {
+	Bucket    string
+	Snapshots []usageSnapshot
+}
+
+// usageRetentionDays returns how long the usage snapshots of a bucket are kept
+func (s *server) usageRetentionDays() int {
+	if s.account.UsageSnapshots == nil || s.account.UsageSnapshots.RetentionDays <= 0 {
+		return defaultUsageRetentionDays
+	}
+
+	return s.account.UsageSnapshots.RetentionDays
+}
+
+// addUsageSnapshot adds a snapshot to the end of a bucket's snapshots and drops the ones older than the cutoff.  The
+// storage metrics are only published once a day, so a snapshot that isn't newer than the latest one isn't added and
+// false is returned.
+func addUsageSnapshot(snapshots []usageSnapshot, snapshot usageSnapshot, cutoff time.Time) ([]usageSnapshot, bool) {
+	if n := len(snapshots); n > 0 && !snapshot.Timestamp.After(snapshots[n-1].Timestamp) {
+		return snapshots, false
+	}
+
+	out := []usageSnapshot{}
+	for _, s := range append(snapshots, snapshot) {
+		if s.Timestamp.Before(cutoff) {
+			continue
+		}
+		out = append(out, s)
+	}
+
+	return out, true
+}
+
+// usageSnapshots returns the usage snapshots of a bucket, oldest first
+func (s *server) usageSnapshots(ctx context.Context, account, bucket string) ([]usageSnapshot, error) {
+	value, err := s.state.Get(ctx, usageSnapshotTable, s.mapAccountNumber(account)+"/"+bucket)
+	if err != nil {
+		if isNotFound(err) {
+			return []usageSnapshot{}, nil
+		}
+		return nil, err
+	}
+
+	snapshots := []usageSnapshot{}
+	if err := json.Unmarshal(value, &snapshots); err != nil {
+		return nil, err
+	}
+
+	return snapshots, nil
+}
+
+// recordUsageSnapshot adds a snapshot to the ones kept for a bucket.  The snapshots expire after the retention days
+// unless another is recorded, so the history of a deleted bucket is eventually removed.
+func (s *server) recordUsageSnapshot(ctx context.Context, account, bucket string, snapshot usageSnapshot, now time.Time) (bool, error) {
+	snapshots, err := s.usageSnapshots(ctx, account, bucket)
+	if err != nil {
+		return false, err
+	}
+
+	retention := time.Duration(s.usageRetentionDays()) * 24 * time.Hour
+	snapshots, added := addUsageSnapshot(snapshots, snapshot, now.Add(-retention))
+	if !added {
+		return false, nil
+	}
+
+	value, err := json.Marshal(snapshots)
+	if err != nil {
+		return false, err
+	}
+
+	if err := s.state.Put(ctx, usageSnapshotTable, s.mapAccountNumber(account)+"/"+bucket, value, now.Add(retention)); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// usageSnapshotter periodically records the usage of each of the org's buckets in an account from the daily cloudwatch
+// storage metrics
+type usageSnapshotter struct {
+	account  string
+	interval time.Duration
+	server   *server
+	context  context.Context
+}
+
+// run starts the usage snapshotter and listens for a shutdown call
+func (w *usageSnapshotter) run() {
+	ticker := time.NewTicker(w.interval)
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if err := w.action(); err != nil {
+					log.Errorf("usage: error recording bucket usage for account %s: %s", w.account, err)
+				}
+			case <-w.context.Done():
+				log.Debug("usage: shutting down usage snapshotter timer")
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	log.Infof("usage: started for account %s with interval %s", w.account, w.interval)
+}
+
+// action records a snapshot of the usage of each of the org's buckets.  Buckets without storage metrics yet are
+// skipped, and a failure to record a bucket's usage is logged and the sweep continues.  It only writes to the state
+// store, so it keeps running in maintenance mode.
+func (w *usageSnapshotter) action() error {
+	log.Debugf("usage: recording bucket usage in account %s", w.account)
+
+	accountId := w.server.mapAccountNumber(w.account)
+	session, err := w.server.sessionForAccount(w.context, w.account, append([]string{"s3:ListAllMyBuckets", "s3:GetBucketTagging"}, bucketUsageActions...)...)
+	if err != nil {
+		return err
+	}
+
+	s3Service := s3api.NewSession(session.Session, w.server.account, w.server.mapToAccountName(accountId))
+	cloudWatchService := cwapi.NewSession(session.Session, w.server.account)
+
+	buckets, err := s3Service.ListBuckets(w.context, &s3.ListBucketsInput{})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	recorded, failed := 0, 0
+	for _, b := range buckets {
+		bucket := aws.StringValue(b.Name)
+		tags, err := s3Service.GetBucketTags(w.context, bucket)
+		if err != nil {
+			log.Warnf("usage: failed to get tags for bucket %s: %s", bucket, err)
+			failed++
+			continue
+		}
+
+		if s3TagMap(tags)["spinup:org"] != Org {
+			continue
+		}
+
+		storage, err := cloudWatchService.GetBucketStorage(w.context, bucket)
+		if err != nil {
+			log.Warnf("usage: failed to get storage metrics for bucket %s: %s", bucket, err)
+			failed++
+			continue
+		}
+
+		if storage == nil {
+			continue
+		}
+
+		added, err := w.server.recordUsageSnapshot(w.context, w.account, bucket, usageSnapshot{
+			Timestamp: storage.Timestamp,
+			Objects:   storage.Objects,
+			Bytes:     storage.Bytes,
+		}, now)
+		if err != nil {
+			log.Warnf("usage: failed to record usage of bucket %s: %s", bucket, err)
+			failed++
+			continue
+		}
+
+		if added {
+			recorded++
+		}
+	}
+
+	log.Infof("usage: recorded the usage of %d buckets in account %s, %d failed", recorded, w.account, failed)
+
+	return nil
+}
+
+// BucketUsageHistoryHandler returns the recorded usage of a bucket over time, optionally limited to the last `days`
+// days.  It's read from the state store, so it doesn't need access to cloudwatch.
+func (s *server) BucketUsageHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	if s.account.UsageSnapshots == nil {
+		handleError(w, apierror.New(apierror.ErrBadRequest, "usage snapshots are not configured", nil))
+		return
+	}
+
+	maxDays := s.usageRetentionDays()
+	days := maxDays
+	if d := r.URL.Query().Get("days"); d != "" {
+		var err error
+		if days, err = strconv.Atoi(d); err != nil || days < 1 || days > maxDays {
+			msg := fmt.Sprintf("days must be between 1 and %d, got %q", maxDays, d)
+			handleError(w, apierror.New(apierror.ErrBadRequest, msg, nil))
+			return
+		}
+	}
+
+	snapshots, err := s.usageSnapshots(r.Context(), vars["account"], bucket)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	since := time.Now().UTC().Add(-time.Duration(days) * 24 * time.Hour)
+	output := &bucketUsageHistory{Bucket: bucket, Snapshots: []usageSnapshot{}}
+	for _, snapshot := range snapshots {
+		if !snapshot.Timestamp.Before(since) {
+			output.Snapshots = append(output.Snapshots, snapshot)
+		}
+	}
+
+	writeConditionalJSON(w, r, output)
+}
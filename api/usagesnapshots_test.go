@@ -0,0 +1,134 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/YaleSpinup/s3-api/storage"
+	"github.com/gorilla/mux"
+)
+
+func TestAddUsageSnapshot(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 5, d, 0, 0, 0, 0, time.UTC) }
+	snapshots := []usageSnapshot{
+		{Timestamp: day(1), Objects: 10, Bytes: 100},
+		{Timestamp: day(2), Objects: 12, Bytes: 120},
+	}
+
+	out, added := addUsageSnapshot(snapshots, usageSnapshot{Timestamp: day(3), Objects: 15, Bytes: 150}, day(2))
+	expected := []usageSnapshot{
+		{Timestamp: day(2), Objects: 12, Bytes: 120},
+		{Timestamp: day(3), Objects: 15, Bytes: 150},
+	}
+	if !added || !reflect.DeepEqual(expected, out) {
+		t.Errorf("expected the snapshot to be added and the old one dropped, got %v %+v", added, out)
+	}
+
+	if out, added := addUsageSnapshot(snapshots, usageSnapshot{Timestamp: day(2), Objects: 13}, day(1)); added || !reflect.DeepEqual(snapshots, out) {
+		t.Errorf("expected a snapshot of the same day not to be added, got %v %+v", added, out)
+	}
+
+	if out, added := addUsageSnapshot(nil, usageSnapshot{Timestamp: day(1)}, day(1)); !added || len(out) != 1 {
+		t.Errorf("expected the first snapshot to be added, got %v %+v", added, out)
+	}
+}
+
+func TestUsageRetentionDays(t *testing.T) {
+	tests := []struct {
+		config   *common.UsageSnapshots
+		expected int
+	}{
+		{nil, 365},
+		{&common.UsageSnapshots{}, 365},
+		{&common.UsageSnapshots{RetentionDays: 30}, 30},
+	}
+
+	for _, tt := range tests {
+		s := server{account: common.Account{UsageSnapshots: tt.config}}
+		if out := s.usageRetentionDays(); out != tt.expected {
+			t.Errorf("expected %d days for %+v, got %d", tt.expected, tt.config, out)
+		}
+	}
+}
+
+func TestBucketUsageHistoryHandler(t *testing.T) {
+	s := server{
+		accountsMap: map[string]string{"spinup": "012345678910"},
+		account:     common.Account{UsageSnapshots: &common.UsageSnapshots{RetentionDays: 30}},
+		state:       storage.NewMemoryStore(),
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, d := range []int{40, 20, 5, 1} {
+		ts := now.Add(-time.Duration(d) * 24 * time.Hour)
+		if _, err := s.recordUsageSnapshot(context.TODO(), "spinup", "data", usageSnapshot{Timestamp: ts, Objects: int64(100 - d)}, now); err != nil {
+			t.Fatalf("expected nil error, got %s", err)
+		}
+	}
+
+	if added, _ := s.recordUsageSnapshot(context.TODO(), "spinup", "data", usageSnapshot{Timestamp: now.Add(-48 * time.Hour)}, now); added {
+		t.Error("expected an older snapshot not to be added")
+	}
+
+	tests := []struct {
+		query    string
+		status   int
+		expected []int64
+	}{
+		{"", http.StatusOK, []int64{80, 95, 99}},
+		{"?days=10", http.StatusOK, []int64{95, 99}},
+		{"?days=31", http.StatusBadRequest, nil},
+		{"?days=week", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/v1/s3/spinup/buckets/data/usage"+tt.query, nil)
+		req = mux.SetURLVars(req, map[string]string{"account": "spinup", "bucket": "data"})
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(s.BucketUsageHistoryHandler).ServeHTTP(rr, req)
+
+		if rr.Code != tt.status {
+			t.Errorf("%q: expected status %d, got %d", tt.query, tt.status, rr.Code)
+			continue
+		}
+
+		if tt.status != http.StatusOK {
+			continue
+		}
+
+		out := bucketUsageHistory{}
+		if err := json.Unmarshal(rr.Body.Bytes(), &out); err != nil {
+			t.Fatalf("failed to unmarshal response: %s", err)
+		}
+
+		objects := []int64{}
+		for _, snapshot := range out.Snapshots {
+			objects = append(objects, snapshot.Objects)
+		}
+
+		if out.Bucket != "data" || !reflect.DeepEqual(tt.expected, objects) {
+			t.Errorf("%q: expected snapshots %v, got %+v", tt.query, tt.expected, out)
+		}
+	}
+
+	// a bucket without any snapshots has an empty history
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/v1/s3/spinup/buckets/new/usage", nil), map[string]string{"account": "spinup", "bucket": "new"})
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(s.BucketUsageHistoryHandler).ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || rr.Body.String() != `{"Bucket":"new","Snapshots":[]}` {
+		t.Errorf("expected an empty history, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	s.account.UsageSnapshots = nil
+	rr = httptest.NewRecorder()
+	http.HandlerFunc(s.BucketUsageHistoryHandler).ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d when usage snapshots aren't configured, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
@@ -0,0 +1,432 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/YaleSpinup/s3-api/common"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/YaleSpinup/s3-api/webhook"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// userCleanupTable is the table the user cleanup records are kept in
+	userCleanupTable = "user-cleanup"
+	// defaultUserCleanupFlagDays is how long a user's credentials can go unused before it's flagged if it's not
+	// configured
+	defaultUserCleanupFlagDays = 90
+	// defaultUserCleanupDeactivateDays is how long after a user is flagged that its access keys are deactivated if
+	// it's not configured
+	defaultUserCleanupDeactivateDays = 14
+	// defaultUserCleanupDeleteDays is how long after a user's access keys are deactivated that it's deleted if it's
+	// not configured
+	defaultUserCleanupDeleteDays = 30
+
+	// userCleanupFlagged, userCleanupDeactivated, userCleanupDeleted and userCleanupCleared are the states of a user
+	// in the cleanup, a cleared user was flagged or deactivated and then used again or exempted
+	userCleanupFlagged     = "flagged"
+	userCleanupDeactivated = "deactivated"
+	userCleanupDeleted     = "deleted"
+	userCleanupCleared     = "cleared"
+
+	// userCleanupActionFlag, userCleanupActionDeactivate, userCleanupActionDelete and userCleanupActionClear are what
+	// the user cleaner does with a user
+	userCleanupActionFlag       = "flag"
+	userCleanupActionDeactivate = "deactivate"
+	userCleanupActionDelete     = "delete"
+	userCleanupActionClear      = "clear"
+)
+
+// userCleanupRecord is the record of a user in the cleanup kept in the state store.  The record is kept after the user
+// is cleared or deleted so its history is an audit trail of everything the cleanup did to the user.
+type userCleanupRecord struct {
+	Account string
+	User    string
+	// Bucket is the bucket whose management group the user is a member of
+	Bucket       string
+	State        string
+	StateChanged time.Time
+	LastActivity time.Time
+	// DeactivatedKeys are the access keys that were deactivated
+	DeactivatedKeys []string `json:",omitempty"`
+	History         []userCleanupEvent
+}
+
+// userCleanupEvent is an entry in the history of a user in the cleanup
+type userCleanupEvent struct {
+	Time    time.Time
+	Action  string
+	Message string
+}
+
+// pending returns true if the user is on its way to being deleted
+func (r *userCleanupRecord) pending() bool {
+	return r != nil && (r.State == userCleanupFlagged || r.State == userCleanupDeactivated)
+}
+
+// userCleanupConfig returns the user cleanup configuration with the defaults filled in
+func userCleanupConfig(config *common.UserCleanup) common.UserCleanup {
+	out := common.UserCleanup{
+		FlagDays:       defaultUserCleanupFlagDays,
+		DeactivateDays: defaultUserCleanupDeactivateDays,
+		DeleteDays:     defaultUserCleanupDeleteDays,
+	}
+
+	if config == nil {
+		return out
+	}
+
+	if config.FlagDays > 0 {
+		out.FlagDays = config.FlagDays
+	}
+
+	if config.DeactivateDays > 0 {
+		out.DeactivateDays = config.DeactivateDays
+	}
+
+	if config.DeleteDays > 0 {
+		out.DeleteDays = config.DeleteDays
+	}
+
+	out.Exempt = config.Exempt
+
+	return out
+}
+
+// userCleanupAction returns what the user cleaner should do with a user at the time given its record and the last
+// time it used its credentials: flag it once it's been inactive for the flag days, deactivate its access keys once
+// it's been flagged for the deactivate days, delete it once its keys have been deactivated for the delete days, clear
+// it if it's pending and has been used since, or nothing
+func userCleanupAction(record *userCleanupRecord, lastActivity, now time.Time, config common.UserCleanup) string {
+	days := func(n int) time.Duration { return time.Duration(n) * 24 * time.Hour }
+
+	if now.Sub(lastActivity) < days(config.FlagDays) {
+		if record.pending() {
+			return userCleanupActionClear
+		}
+		return ""
+	}
+
+	if !record.pending() {
+		return userCleanupActionFlag
+	}
+
+	switch record.State {
+	case userCleanupFlagged:
+		if !now.Before(record.StateChanged.Add(days(config.DeactivateDays))) {
+			return userCleanupActionDeactivate
+		}
+	case userCleanupDeactivated:
+		if !now.Before(record.StateChanged.Add(days(config.DeleteDays))) {
+			return userCleanupActionDelete
+		}
+	}
+
+	return ""
+}
+
+// userCleaner periodically flags the bucket users in an account whose credentials haven't been used in the configured
+// number of days, then deactivates their access keys and finally deletes them
+type userCleaner struct {
+	account  string
+	interval time.Duration
+	server   *server
+	context  context.Context
+}
+
+// run starts the user cleaner and listens for a shutdown call
+func (w *userCleaner) run() {
+	ticker := time.NewTicker(w.interval)
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if err := w.action(); err != nil {
+					log.Errorf("usercleanup: error cleaning up users for account %s: %s", w.account, err)
+				}
+			case <-w.context.Done():
+				log.Debug("usercleanup: shutting down user cleaner timer")
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	log.Infof("usercleanup: started for account %s with interval %s", w.account, w.interval)
+}
+
+// action sweeps the bucket users in the account
+func (w *userCleaner) action() error {
+	log.Debugf("usercleanup: looking for unused users in account %s", w.account)
+
+	if w.server.inMaintenance(w.context) {
+		log.Infof("usercleanup: skipping cleaning up users for account %s in read-only maintenance mode", w.account)
+		return nil
+	}
+
+	accountId := w.server.mapAccountNumber(w.account)
+	session, err := w.server.sessionForAccount(w.context, w.account, "iam:*")
+	if err != nil {
+		return err
+	}
+
+	iamService := iamapi.NewSession(session.Session, w.server.account)
+	iamService.Cache = w.server.resourceCache(accountId)
+
+	return w.sweep(iamService, time.Now().UTC().Truncate(time.Second))
+}
+
+// sweep flags, deactivates, deletes or clears each of the members of the management groups in the account as of the
+// time.  Exempt users are never cleaned up, and a failure to clean up a user is logged and the sweep continues.
+func (w *userCleaner) sweep(iamService iamapi.IAM, now time.Time) error {
+	config := userCleanupConfig(w.server.account.UserCleanup)
+
+	users, buckets, err := managementGroupMembers(w.context, iamService)
+	if err != nil {
+		return err
+	}
+
+	records, err := w.server.userCleanupRecords(w.context, w.account)
+	if err != nil {
+		return err
+	}
+
+	byUser := map[string]*userCleanupRecord{}
+	for _, r := range records {
+		byUser[r.User] = r
+	}
+
+	counts := map[string]int{}
+	for _, u := range users {
+		name := aws.StringValue(u.UserName)
+		record := byUser[name]
+		delete(byUser, name)
+
+		if contains(config.Exempt, name) {
+			if record.pending() {
+				w.clear(record, now, "user is exempt from the cleanup")
+				counts[userCleanupActionClear]++
+			}
+			continue
+		}
+
+		lastActivity, err := iamService.UserLastActivity(w.context, u)
+		if err != nil {
+			log.Warnf("usercleanup: failed to get the last activity of user %s: %s", name, err)
+			counts["failed"]++
+			continue
+		}
+
+		action := userCleanupAction(record, lastActivity, now, config)
+		if action == "" {
+			continue
+		}
+
+		if record == nil {
+			record = &userCleanupRecord{Account: w.server.mapAccountNumber(w.account), User: name}
+		}
+		record.Bucket = buckets[name]
+		record.LastActivity = lastActivity
+
+		if err := w.apply(iamService, record, action, now, config); err != nil {
+			log.Warnf("usercleanup: failed to %s user %s: %s", action, name, err)
+			counts["failed"]++
+			continue
+		}
+		counts[action]++
+	}
+
+	// users that were pending and aren't in a management group anymore were removed or deleted by someone else
+	for _, record := range byUser {
+		if record.pending() {
+			w.clear(record, now, "user is no longer a bucket user")
+			counts[userCleanupActionClear]++
+		}
+	}
+
+	log.Infof("usercleanup: flagged %d, deactivated %d, deleted %d and cleared %d users in account %s, %d failed",
+		counts[userCleanupActionFlag], counts[userCleanupActionDeactivate], counts[userCleanupActionDelete],
+		counts[userCleanupActionClear], w.account, counts["failed"])
+
+	return nil
+}
+
+// apply takes the action on a user, notifies about it and saves the user's record
+func (w *userCleaner) apply(iamService iamapi.IAM, record *userCleanupRecord, action string, now time.Time, config common.UserCleanup) error {
+	details := map[string]string{
+		"Bucket":       record.Bucket,
+		"LastActivity": record.LastActivity.Format(time.RFC3339),
+	}
+
+	switch action {
+	case userCleanupActionFlag:
+		deactivateAfter := now.Add(time.Duration(config.DeactivateDays) * 24 * time.Hour)
+		details["DeactivateAfter"] = deactivateAfter.Format(time.RFC3339)
+		record.transition(now, userCleanupFlagged, action,
+			fmt.Sprintf("credentials unused since %s, access keys will be deactivated after %s", details["LastActivity"], details["DeactivateAfter"]))
+		record.DeactivatedKeys = nil
+		w.server.notify(webhook.EventUserFlagged, w.account, record.User, details)
+	case userCleanupActionDeactivate:
+		keys, err := iamService.DeactivateAccessKeys(w.context, record.User)
+		if err != nil {
+			return err
+		}
+
+		deleteAfter := now.Add(time.Duration(config.DeleteDays) * 24 * time.Hour)
+		details["DeleteAfter"] = deleteAfter.Format(time.RFC3339)
+		details["AccessKeys"] = strings.Join(keys, ",")
+		record.DeactivatedKeys = keys
+		record.transition(now, userCleanupDeactivated, action,
+			fmt.Sprintf("deactivated %d access keys, user will be deleted after %s", len(keys), details["DeleteAfter"]))
+		w.server.notify(webhook.EventUserDeactivated, w.account, record.User, details)
+	case userCleanupActionDelete:
+		if err := iamService.DeleteUserAndAttachments(w.context, record.User); err != nil {
+			return err
+		}
+
+		details["Reason"] = "unused"
+		record.transition(now, userCleanupDeleted, action, "deleted unused user")
+		w.server.notify(webhook.EventUserDeleted, w.account, record.User, details)
+	case userCleanupActionClear:
+		record.transition(now, userCleanupCleared, action, fmt.Sprintf("credentials used at %s", details["LastActivity"]))
+	}
+
+	log.Infof("usercleanup: %s user %s in account %s: %s", action, record.User, w.account, record.History[len(record.History)-1].Message)
+
+	w.server.saveUserCleanupRecord(w.context, record)
+
+	return nil
+}
+
+// clear returns a pending user to active without checking its activity
+func (w *userCleaner) clear(record *userCleanupRecord, now time.Time, message string) {
+	record.transition(now, userCleanupCleared, userCleanupActionClear, message)
+	log.Infof("usercleanup: clear user %s in account %s: %s", record.User, w.account, message)
+	w.server.saveUserCleanupRecord(w.context, record)
+}
+
+// transition moves the record to the state and adds the action to its history
+func (r *userCleanupRecord) transition(now time.Time, state, action, message string) {
+	r.State = state
+	r.StateChanged = now
+	r.History = append(r.History, userCleanupEvent{Time: now, Action: action, Message: message})
+}
+
+// managementGroupMembers returns the members of the management groups in an account and the bucket each of them
+// manages.  A user in more than one group is returned once.
+func managementGroupMembers(ctx context.Context, iamService iamapi.IAM) ([]*iam.User, map[string]string, error) {
+	groups, err := iamService.ListGroups(ctx, &iam.ListGroupsInput{}, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	users := []*iam.User{}
+	buckets := map[string]string{}
+	for _, g := range groups {
+		bucket, _, ok := iamapi.SplitGroupName(aws.StringValue(g.GroupName))
+		if !ok {
+			continue
+		}
+
+		members, err := iamService.ListGroupUsers(ctx, &iam.GetGroupInput{GroupName: g.GroupName})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, u := range members {
+			name := aws.StringValue(u.UserName)
+			if _, ok := buckets[name]; ok {
+				continue
+			}
+
+			buckets[name] = bucket
+			users = append(users, u)
+		}
+	}
+
+	return users, buckets, nil
+}
+
+// saveUserCleanupRecord saves the record of a user in the cleanup, failures are logged and the user is picked up
+// again on the next sweep
+func (s *server) saveUserCleanupRecord(ctx context.Context, record *userCleanupRecord) {
+	value, err := json.Marshal(record)
+	if err != nil {
+		log.Errorf("failed to marshal user cleanup record for user %s: %s", record.User, err)
+		return
+	}
+
+	if err := s.state.Put(ctx, userCleanupTable, record.Account+"/"+record.User, value, time.Time{}); err != nil {
+		log.Errorf("failed to save user cleanup record for user %s: %s", record.User, err)
+	}
+}
+
+// userCleanupRecords returns the user cleanup records in an account sorted by user
+func (s *server) userCleanupRecords(ctx context.Context, account string) ([]*userCleanupRecord, error) {
+	values, err := s.state.List(ctx, userCleanupTable)
+	if err != nil {
+		return nil, err
+	}
+
+	accountId := s.mapAccountNumber(account)
+	records := []*userCleanupRecord{}
+	for key, value := range values {
+		if !strings.HasPrefix(key, accountId+"/") {
+			continue
+		}
+
+		record := userCleanupRecord{}
+		if err := json.Unmarshal(value, &record); err != nil {
+			log.Warnf("skipping unreadable user cleanup record %s: %s", key, err)
+			continue
+		}
+		records = append(records, &record)
+	}
+
+	sort.Slice(records, func(i, j int) bool { return records[i].User < records[j].User })
+
+	return records, nil
+}
+
+// UserCleanupListHandler lists the users in an account that have been flagged, deactivated, deleted or cleared by the
+// unused user cleanup along with their history
+func (s *server) UserCleanupListHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+
+	records, err := s.userCleanupRecords(r.Context(), vars["account"])
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if state := r.URL.Query().Get("state"); state != "" {
+		filtered := []*userCleanupRecord{}
+		for _, record := range records {
+			if record.State == state {
+				filtered = append(filtered, record)
+			}
+		}
+		records = filtered
+	}
+
+	j, err := json.Marshal(records)
+	if err != nil {
+		log.Errorf("cannot marshal response(%v) into JSON: %s", records, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
@@ -0,0 +1,225 @@
+package api
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/s3-api/common"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+	"github.com/YaleSpinup/s3-api/storage"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+)
+
+// mockUserCleanupIAM is an IAM client with a management group for a bucket, its members were created at the time in
+// created and have a single access key last used at the time in used
+type mockUserCleanupIAM struct {
+	iamiface.IAMAPI
+	created map[string]time.Time
+	used    map[string]time.Time
+	keys    map[string]string
+}
+
+func (m *mockUserCleanupIAM) ListGroupsWithContext(ctx context.Context, input *iam.ListGroupsInput, opts ...request.Option) (*iam.ListGroupsOutput, error) {
+	return &iam.ListGroupsOutput{Groups: []*iam.Group{
+		{GroupName: aws.String("data.example.com-BktAdmGrp")},
+		{GroupName: aws.String("SomeOtherGroup")},
+	}}, nil
+}
+
+func (m *mockUserCleanupIAM) GetGroupWithContext(ctx context.Context, input *iam.GetGroupInput, opts ...request.Option) (*iam.GetGroupOutput, error) {
+	users := []*iam.User{}
+	for _, name := range sortedKeys(mapKeys(m.created)) {
+		users = append(users, &iam.User{UserName: aws.String(name), CreateDate: aws.Time(m.created[name])})
+	}
+	return &iam.GetGroupOutput{Users: users}, nil
+}
+
+func (m *mockUserCleanupIAM) ListAccessKeysWithContext(ctx context.Context, input *iam.ListAccessKeysInput, opts ...request.Option) (*iam.ListAccessKeysOutput, error) {
+	user := aws.StringValue(input.UserName)
+	return &iam.ListAccessKeysOutput{AccessKeyMetadata: []*iam.AccessKeyMetadata{
+		{
+			UserName:    input.UserName,
+			AccessKeyId: aws.String(user + "-key"),
+			Status:      aws.String(m.keys[user+"-key"]),
+			CreateDate:  aws.Time(m.created[user]),
+		},
+	}}, nil
+}
+
+func (m *mockUserCleanupIAM) GetAccessKeyLastUsedWithContext(ctx context.Context, input *iam.GetAccessKeyLastUsedInput, opts ...request.Option) (*iam.GetAccessKeyLastUsedOutput, error) {
+	out := &iam.AccessKeyLastUsed{}
+	user := aws.StringValue(input.AccessKeyId)
+	if used, ok := m.used[user[:len(user)-len("-key")]]; ok {
+		out.LastUsedDate = aws.Time(used)
+	}
+	return &iam.GetAccessKeyLastUsedOutput{AccessKeyLastUsed: out}, nil
+}
+
+func (m *mockUserCleanupIAM) UpdateAccessKeyWithContext(ctx context.Context, input *iam.UpdateAccessKeyInput, opts ...request.Option) (*iam.UpdateAccessKeyOutput, error) {
+	m.keys[aws.StringValue(input.AccessKeyId)] = aws.StringValue(input.Status)
+	return &iam.UpdateAccessKeyOutput{}, nil
+}
+
+func mapKeys(m map[string]time.Time) map[string]bool {
+	out := map[string]bool{}
+	for k := range m {
+		out[k] = true
+	}
+	return out
+}
+
+func TestUserCleanupConfig(t *testing.T) {
+	tests := []struct {
+		config   *common.UserCleanup
+		expected common.UserCleanup
+	}{
+		{nil, common.UserCleanup{FlagDays: 90, DeactivateDays: 14, DeleteDays: 30}},
+		{&common.UserCleanup{}, common.UserCleanup{FlagDays: 90, DeactivateDays: 14, DeleteDays: 30}},
+		{
+			&common.UserCleanup{FlagDays: 180, DeactivateDays: 7, DeleteDays: 60, Exempt: []string{"svc"}},
+			common.UserCleanup{FlagDays: 180, DeactivateDays: 7, DeleteDays: 60, Exempt: []string{"svc"}},
+		},
+	}
+
+	for _, tt := range tests {
+		if out := userCleanupConfig(tt.config); !reflect.DeepEqual(tt.expected, out) {
+			t.Errorf("expected %+v for %+v, got %+v", tt.expected, tt.config, out)
+		}
+	}
+}
+
+func TestUserCleanupAction(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(n int) time.Time { return now.Add(-time.Duration(n) * 24 * time.Hour) }
+	config := userCleanupConfig(nil)
+
+	tests := []struct {
+		name         string
+		record       *userCleanupRecord
+		lastActivity time.Time
+		expected     string
+	}{
+		{"active", nil, daysAgo(10), ""},
+		{"inactive", nil, daysAgo(90), userCleanupActionFlag},
+		{"inactive after being cleared", &userCleanupRecord{State: userCleanupCleared, StateChanged: daysAgo(100)}, daysAgo(95), userCleanupActionFlag},
+		{"recreated after being deleted", &userCleanupRecord{State: userCleanupDeleted, StateChanged: daysAgo(100)}, daysAgo(1), ""},
+		{"recently flagged", &userCleanupRecord{State: userCleanupFlagged, StateChanged: daysAgo(13)}, daysAgo(103), ""},
+		{"flagged", &userCleanupRecord{State: userCleanupFlagged, StateChanged: daysAgo(14)}, daysAgo(104), userCleanupActionDeactivate},
+		{"used after being flagged", &userCleanupRecord{State: userCleanupFlagged, StateChanged: daysAgo(14)}, daysAgo(2), userCleanupActionClear},
+		{"recently deactivated", &userCleanupRecord{State: userCleanupDeactivated, StateChanged: daysAgo(29)}, daysAgo(133), ""},
+		{"deactivated", &userCleanupRecord{State: userCleanupDeactivated, StateChanged: daysAgo(30)}, daysAgo(134), userCleanupActionDelete},
+		{"new key after being deactivated", &userCleanupRecord{State: userCleanupDeactivated, StateChanged: daysAgo(30)}, daysAgo(1), userCleanupActionClear},
+	}
+
+	for _, tt := range tests {
+		if out := userCleanupAction(tt.record, tt.lastActivity, now, config); out != tt.expected {
+			t.Errorf("%s: expected action %q, got %q", tt.name, tt.expected, out)
+		}
+	}
+}
+
+func TestUserCleanerSweep(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(n int) time.Time { return now.Add(-time.Duration(n) * 24 * time.Hour) }
+
+	client := &mockUserCleanupIAM{
+		created: map[string]time.Time{
+			"active-user":    daysAgo(400),
+			"unused-user":    daysAgo(400),
+			"flagged-user":   daysAgo(400),
+			"returning-user": daysAgo(400),
+			"svc-backup":     daysAgo(400),
+		},
+		used: map[string]time.Time{
+			"active-user":    daysAgo(1),
+			"unused-user":    daysAgo(100),
+			"flagged-user":   daysAgo(120),
+			"returning-user": daysAgo(3),
+		},
+		keys: map[string]string{
+			"active-user-key":    "Active",
+			"unused-user-key":    "Active",
+			"flagged-user-key":   "Active",
+			"returning-user-key": "Active",
+			"svc-backup-key":     "Active",
+		},
+	}
+
+	s := &server{
+		accountsMap: map[string]string{"spinup": "012345678910"},
+		account:     common.Account{UserCleanup: &common.UserCleanup{Exempt: []string{"svc-backup"}}},
+		state:       storage.NewMemoryStore(),
+	}
+
+	for _, r := range []*userCleanupRecord{
+		{Account: "012345678910", User: "flagged-user", State: userCleanupFlagged, StateChanged: daysAgo(15)},
+		{Account: "012345678910", User: "returning-user", State: userCleanupFlagged, StateChanged: daysAgo(15)},
+		{Account: "012345678910", User: "svc-backup", State: userCleanupFlagged, StateChanged: daysAgo(15)},
+		{Account: "012345678910", User: "gone-user", State: userCleanupDeactivated, StateChanged: daysAgo(5)},
+		{Account: "109876543210", User: "other-user", State: userCleanupFlagged, StateChanged: daysAgo(15)},
+	} {
+		s.saveUserCleanupRecord(context.TODO(), r)
+	}
+
+	w := &userCleaner{account: "spinup", server: s, context: context.TODO()}
+	if err := w.sweep(iamapi.IAM{Service: client}, now); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	records, err := s.userCleanupRecords(context.TODO(), "spinup")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	states := map[string]string{}
+	for _, r := range records {
+		states[r.User] = r.State
+	}
+
+	expected := map[string]string{
+		"flagged-user":   userCleanupDeactivated,
+		"gone-user":      userCleanupCleared,
+		"returning-user": userCleanupCleared,
+		"svc-backup":     userCleanupCleared,
+		"unused-user":    userCleanupFlagged,
+	}
+	if !reflect.DeepEqual(expected, states) {
+		t.Errorf("expected states %v, got %v", expected, states)
+	}
+
+	for _, r := range records {
+		if len(r.History) != 1 || r.History[0].Time != now {
+			t.Errorf("expected a history entry for user %s, got %+v", r.User, r.History)
+		}
+
+		switch r.User {
+		case "flagged-user":
+			if !reflect.DeepEqual([]string{"flagged-user-key"}, r.DeactivatedKeys) || r.Bucket != "data.example.com" {
+				t.Errorf("unexpected record for the deactivated user %+v", r)
+			}
+		case "unused-user":
+			if !r.LastActivity.Equal(daysAgo(100)) || r.Bucket != "data.example.com" {
+				t.Errorf("unexpected record for the flagged user %+v", r)
+			}
+		}
+	}
+
+	if client.keys["flagged-user-key"] != "Inactive" || client.keys["unused-user-key"] != "Active" || client.keys["svc-backup-key"] != "Active" {
+		t.Errorf("expected only the flagged user's key to be deactivated, got %v", client.keys)
+	}
+
+	// a second sweep the same day doesn't change anything
+	if err := w.sweep(iamapi.IAM{Service: client}, now); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	again, _ := s.userCleanupRecords(context.TODO(), "spinup")
+	if !reflect.DeepEqual(records, again) {
+		t.Errorf("expected a second sweep to leave the records alone, got %+v", again)
+	}
+}
@@ -0,0 +1,684 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/YaleSpinup/apierror"
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	"github.com/YaleSpinup/s3-api/common"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+const (
+	// maxTags is the maximum number of tags on a bucket, one is reserved for the spinup:org tag
+	maxTags = 49
+	// maxTagKeyLength and maxTagValueLength are the maximum length of a tag key and value
+	maxTagKeyLength   = 128
+	maxTagValueLength = 256
+	// maxObjectTags is the maximum number of default object tags, one of the 10 object tags is reserved for the
+	// yale:spinup tag on the default index pages
+	maxObjectTags = 9
+	// maxCacheBehaviors is the maximum number of additional cache behaviors for a website distribution
+	maxCacheBehaviors = 25
+	// maxForwardHeaders is the maximum number of headers a cache behavior can forward to its origin
+	maxForwardHeaders = 10
+	// maxSSHPublicKeys is the maximum number of ssh public keys a transfer user can have
+	maxSSHPublicKeys = 50
+	// maxIPAllowlist is the maximum number of networks in an ip allowlist
+	maxIPAllowlist = 25
+)
+
+var (
+	bucketNameRe    = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+	hostLabelRe     = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+	dnsLabelRe      = regexp.MustCompile(`^[a-z0-9_]([a-z0-9_-]{0,61}[a-z0-9])?$`)
+	iamNameRe       = regexp.MustCompile(`^[\w+=,.@-]{1,64}$`)
+	iamPathRe       = regexp.MustCompile(`^/([\x21-\x7E]{0,510}/)?$`)
+	tagCharactersRe = regexp.MustCompile(`^[\p{L}\p{Z}\p{N}_.:/=+\-@]*$`)
+	sftpUserRe      = regexp.MustCompile(`^\w[\w@.-]{2,99}$`)
+	sshPublicKeyRe  = regexp.MustCompile(`^(ssh-rsa|ssh-ed25519|ecdsa-sha2-nistp(256|384|521)) [A-Za-z0-9+/]+={0,3}( [^\n]*)?$`)
+	accessPointRe   = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,48}[a-z0-9]$`)
+	vpcIdRe         = regexp.MustCompile(`^vpc-[0-9a-f]{8,17}$`)
+	iamPrincipalRe  = regexp.MustCompile(`^arn:aws:iam::\d{12}:(root|(user|role)/[\w+=,.@/-]+)$`)
+	hostedZoneIdRe  = regexp.MustCompile(`^Z[A-Z0-9]{1,31}$`)
+	policyVersionRe = regexp.MustCompile(`^v[1-9][0-9]*$`)
+	releaseRe       = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,99}$`)
+
+	// bucketUserGroups are the groups a bucket user can be added to
+	bucketUserGroups = []string{"BktAdmGrp", "BktRWGrp", "BktROGrp"}
+	// websiteUserGroups are the groups a website user can be added to
+	websiteUserGroups = []string{"BktAdmGrp", "BktRWGrp", "BktROGrp", "WebAdmGrp"}
+	// bucketPolicyTypes are the managed policies of a bucket or website whose versions can be managed
+	bucketPolicyTypes = []string{"BktAdmPlc", "BktRWPlc", "BktROPlc", "WebAdmPlc"}
+	// cacheBehaviorMethods are the sets of methods a cache behavior can allow
+	cacheBehaviorMethods = [][]string{
+		{"GET", "HEAD"},
+		{"GET", "HEAD", "OPTIONS"},
+		{"GET", "HEAD", "OPTIONS", "PUT", "POST", "PATCH", "DELETE"},
+	}
+)
+
+// fieldErrors collects the validation errors for the fields of a request
+type fieldErrors []string
+
+// add adds a validation error for a field
+func (f *fieldErrors) add(field, format string, args ...interface{}) {
+	*f = append(*f, field+": "+fmt.Sprintf(format, args...))
+}
+
+// err returns a bad request error listing all of the validation errors, or nil if there aren't any
+func (f fieldErrors) err() error {
+	if len(f) == 0 {
+		return nil
+	}
+
+	return apierror.New(apierror.ErrBadRequest, "invalid request: "+strings.Join(f, "; "), nil)
+}
+
+// bucketName validates a bucket name against the s3 bucket naming rules
+func (f *fieldErrors) bucketName(field, name string) {
+	switch {
+	case name == "":
+		f.add(field, "bucket name is required")
+	case len(name) < 3 || len(name) > 63:
+		f.add(field, "bucket name must be between 3 and 63 characters long")
+	case !bucketNameRe.MatchString(name):
+		f.add(field, "bucket name can only contain lowercase letters, numbers, dots and hyphens and must begin and end with a letter or number")
+	case strings.Contains(name, ".."):
+		f.add(field, "bucket name cannot contain two adjacent periods")
+	case net.ParseIP(name) != nil:
+		f.add(field, "bucket name cannot be formatted as an IP address")
+	case strings.HasPrefix(name, "xn--"):
+		f.add(field, "bucket name cannot begin with xn--")
+	case strings.HasSuffix(name, "-s3alias"), strings.HasSuffix(name, "--ol-s3"):
+		f.add(field, "bucket name cannot end with -s3alias or --ol-s3")
+	}
+}
+
+// websiteName validates a website name, it must be a single hostname label in one of the configured domains
+// since the certificates for the domains are wildcards
+func (f *fieldErrors) websiteName(field, name string, domains map[string]*common.Domain) {
+	f.bucketName(field, name)
+
+	parts := strings.SplitN(name, ".", 2)
+	if len(parts) < 2 {
+		f.add(field, "website name must be a hostname in one of the domains %s", domainNames(domains))
+		return
+	}
+
+	if !hostLabelRe.MatchString(parts[0]) {
+		f.add(field, "website hostname %s can only contain lowercase letters, numbers and hyphens", parts[0])
+	}
+
+	domain, ok := domains[parts[1]]
+	switch {
+	case !ok:
+		f.add(field, "website domain %s is not one of the domains %s", parts[1], domainNames(domains))
+	case domain != nil && domain.PrivateZone:
+		f.add(field, "website domain %s is a private zone, create a simple website and an alias record for its internal load balancer or endpoint instead", parts[1])
+	}
+}
+
+// tags validates a list of tags against the s3 tag restrictions
+func (f *fieldErrors) tags(field string, tags []*s3.Tag) {
+	if len(tags) > maxTags {
+		f.add(field, "at most %d tags are allowed, got %d", maxTags, len(tags))
+	}
+
+	keys := map[string]bool{}
+	for i, t := range tags {
+		tf := fmt.Sprintf("%s[%d]", field, i)
+		if t == nil {
+			f.add(tf, "tag cannot be null")
+			continue
+		}
+
+		key, value := aws.StringValue(t.Key), aws.StringValue(t.Value)
+		switch {
+		case key == "":
+			f.add(tf+".Key", "tag key is required")
+		case len(key) > maxTagKeyLength:
+			f.add(tf+".Key", "tag key %s is longer than %d characters", key, maxTagKeyLength)
+		case strings.HasPrefix(strings.ToLower(key), "aws:"):
+			f.add(tf+".Key", "tag key %s cannot begin with aws:", key)
+		case key == "spinup:org":
+			f.add(tf+".Key", "tag key spinup:org is reserved")
+		case isReservedTag(key):
+			f.add(tf+".Key", "tag key %s is reserved", key)
+		case !tagCharactersRe.MatchString(key):
+			f.add(tf+".Key", "tag key %s contains invalid characters", key)
+		case keys[key]:
+			f.add(tf+".Key", "duplicate tag key %s", key)
+		}
+		keys[key] = true
+
+		if len(value) > maxTagValueLength {
+			f.add(tf+".Value", "tag value for %s is longer than %d characters", key, maxTagValueLength)
+		} else if !tagCharactersRe.MatchString(value) {
+			f.add(tf+".Value", "tag value for %s contains invalid characters", key)
+		}
+	}
+}
+
+// requiredTags validates that the tags include each of the required tags with a valid value
+func (f *fieldErrors) requiredTags(field string, tags []*s3.Tag, required requiredTags) {
+	for _, v := range required.violations(s3TagMap(tags)) {
+		f.add(field, "%s", v)
+	}
+}
+
+// lifecycle validates that a lifecycle is one of the supported lifecycles
+func (f *fieldErrors) lifecycle(field string, lifecycle *string) {
+	if lifecycle == nil {
+		return
+	}
+
+	if !s3api.Lifecycles.Supported(aws.StringValue(lifecycle)) {
+		f.add(field, "unsupported lifecycle %s", aws.StringValue(lifecycle))
+	}
+}
+
+// policyDocument validates that a policy document is a JSON object
+func (f *fieldErrors) policyDocument(field string, document *string) {
+	if document == nil {
+		return
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(aws.StringValue(document)), &doc); err != nil {
+		f.add(field, "policy document is not a valid JSON object: %s", err)
+	}
+}
+
+// invalidationPaths validates a list of cloudfront invalidation paths
+func (f *fieldErrors) invalidationPaths(field string, paths []string) {
+	if len(paths) == 0 {
+		f.add(field, "at least one path is required")
+	}
+
+	for i, p := range paths {
+		if !strings.HasPrefix(p, "/") {
+			f.add(fmt.Sprintf("%s[%d]", field, i), "path %q must begin with /", p)
+		}
+	}
+}
+
+// websiteContent validates the content to seed a website with
+func (f *fieldErrors) websiteContent(field string, content []*websiteContent) {
+	keys := map[string]bool{}
+	size := 0
+	for i, c := range content {
+		cf := fmt.Sprintf("%s[%d]", field, i)
+		if c == nil {
+			f.add(cf, "content cannot be null")
+			continue
+		}
+
+		switch {
+		case c.Key == "":
+			f.add(cf+".Key", "key is required")
+		case strings.HasPrefix(c.Key, "/"):
+			f.add(cf+".Key", "key %s cannot begin with /", c.Key)
+		case len(c.Key) > 1024:
+			f.add(cf+".Key", "key is longer than 1024 characters")
+		case keys[c.Key]:
+			f.add(cf+".Key", "duplicate key %s", c.Key)
+		}
+		keys[c.Key] = true
+
+		if c.Encoding != "" && c.Encoding != websiteContentEncodingBase64 {
+			f.add(cf+".Encoding", "unsupported encoding %s, must be empty or %s", c.Encoding, websiteContentEncodingBase64)
+			continue
+		}
+
+		switch c.ContentEncoding {
+		case "", "gzip", "br", websiteContentEncodingIdentity:
+		default:
+			f.add(cf+".ContentEncoding", "unsupported content encoding %s, must be empty, gzip, br or identity", c.ContentEncoding)
+		}
+
+		body, err := c.decode()
+		if err != nil {
+			f.add(cf+".Body", "invalid %s content: %s", c.Encoding, err)
+			continue
+		}
+		size += len(body)
+
+		if c.StorageClass != "" {
+			f.storageClass(cf+".StorageClass", c.StorageClass)
+		}
+	}
+
+	if size > maxWebsiteContentBytes {
+		f.add(field, "content is %d bytes, at most %d bytes are allowed", size, maxWebsiteContentBytes)
+	}
+}
+
+// objectTags validates the default tags for new objects in a bucket.  They're stored as bucket tags with the
+// spinup:object: prefix, so the keys are limited to the length of a bucket tag key less the prefix.
+func (f *fieldErrors) objectTags(field string, tags []*s3.Tag) {
+	if len(tags) > maxObjectTags {
+		f.add(field, "at most %d object tags are allowed, got %d", maxObjectTags, len(tags))
+	}
+
+	maxKeyLength := maxTagKeyLength - len(objectTagPrefix)
+	keys := map[string]bool{}
+	for i, t := range tags {
+		tf := fmt.Sprintf("%s[%d]", field, i)
+		if t == nil {
+			f.add(tf, "tag cannot be null")
+			continue
+		}
+
+		key, value := aws.StringValue(t.Key), aws.StringValue(t.Value)
+		switch {
+		case key == "":
+			f.add(tf+".Key", "tag key is required")
+		case len(key) > maxKeyLength:
+			f.add(tf+".Key", "tag key %s is longer than %d characters", key, maxKeyLength)
+		case strings.HasPrefix(strings.ToLower(key), "aws:"):
+			f.add(tf+".Key", "tag key %s cannot begin with aws:", key)
+		case key == "yale:spinup":
+			f.add(tf+".Key", "tag key yale:spinup is reserved")
+		case !tagCharactersRe.MatchString(key):
+			f.add(tf+".Key", "tag key %s contains invalid characters", key)
+		case keys[key]:
+			f.add(tf+".Key", "duplicate tag key %s", key)
+		}
+		keys[key] = true
+
+		if len(value) > maxTagValueLength {
+			f.add(tf+".Value", "tag value for %s is longer than %d characters", key, maxTagValueLength)
+		} else if !tagCharactersRe.MatchString(value) {
+			f.add(tf+".Value", "tag value for %s contains invalid characters", key)
+		}
+	}
+}
+
+// storageClass validates that a storage class is one website content can be created with
+func (f *fieldErrors) storageClass(field, class string) {
+	if !contains(websiteStorageClasses, class) {
+		f.add(field, "unsupported storage class %s, must be one of %s", class, strings.Join(websiteStorageClasses, ", "))
+	}
+}
+
+// dnsRecordType validates that a dns record type is one of the types that can be managed for a website
+func (f *fieldErrors) dnsRecordType(field, recordType string) {
+	if !contains(websiteDNSRecordTypes, recordType) {
+		f.add(field, "unsupported record type %s, must be one of %s", recordType, strings.Join(websiteDNSRecordTypes, ", "))
+	}
+}
+
+// dnsRecordName validates that a dns record name is within the website name.  A CNAME, A or AAAA record can't be the
+// website name itself since that's the alias record for the website, unless the website is in a private zone where
+// there's no distribution.
+func (f *fieldErrors) dnsRecordName(field, name, recordType, website string, private bool) {
+	fqdn := strings.ToLower(strings.TrimSuffix(name, "."))
+	switch {
+	case fqdn == "":
+		f.add(field, "record name is required")
+		return
+	case !inWebsiteName(fqdn, website):
+		f.add(field, "record name %s must be %s or a name within it", name, website)
+		return
+	case fqdn == strings.ToLower(website):
+		if !private && (recordType == "CNAME" || recordType == "A" || recordType == "AAAA") {
+			f.add(field, "%s record name must be a name within %s, the website name is an alias for the distribution", recordType, website)
+		}
+		return
+	}
+
+	for _, label := range strings.Split(strings.TrimSuffix(fqdn, "."+strings.ToLower(website)), ".") {
+		if !dnsLabelRe.MatchString(label) {
+			f.add(field, "record name label %q can only contain letters, numbers, hyphens and underscores", label)
+		}
+	}
+}
+
+// dnsRecord validates a dns record for a website, private is true if the website is in a private zone
+func (f *fieldErrors) dnsRecord(record *websiteDNSRecord, website string, private bool) {
+	f.dnsRecordType("Type", record.Type)
+	f.dnsRecordName("Name", record.Name, record.Type, website, private)
+
+	if record.AliasTarget != nil {
+		f.dnsAliasTarget(record, private)
+		return
+	}
+
+	if record.TTL < 0 || record.TTL > maxDNSRecordTTL {
+		f.add("TTL", "ttl must be between 0 and %d seconds", maxDNSRecordTTL)
+	}
+
+	switch {
+	case len(record.Values) == 0:
+		f.add("Values", "at least one value is required")
+	case record.Type == "CNAME" && len(record.Values) > 1:
+		f.add("Values", "a CNAME record can only have one value")
+	}
+
+	for i, v := range record.Values {
+		vf := fmt.Sprintf("Values[%d]", i)
+		switch {
+		case v == "":
+			f.add(vf, "value cannot be empty")
+		case record.Type == "TXT" && len(strings.Trim(v, `"`)) > maxTXTValueLength:
+			f.add(vf, "TXT value is longer than %d characters", maxTXTValueLength)
+		case record.Type == "A" && (net.ParseIP(v) == nil || net.ParseIP(v).To4() == nil):
+			f.add(vf, "A value %s is not an IPv4 address", v)
+		case record.Type == "AAAA" && (net.ParseIP(v) == nil || net.ParseIP(v).To4() != nil):
+			f.add(vf, "AAAA value %s is not an IPv6 address", v)
+		}
+	}
+}
+
+// dnsAliasTarget validates an alias record.  Alias records can only be created in private zones, for the internal load
+// balancer or interface endpoint that an internal-only website is served from.
+func (f *fieldErrors) dnsAliasTarget(record *websiteDNSRecord, private bool) {
+	if !private {
+		f.add("AliasTarget", "alias records can only be created for websites in a private zone")
+	}
+
+	if record.Type != "A" && record.Type != "AAAA" {
+		f.add("AliasTarget", "only A and AAAA records can be alias records")
+	}
+
+	if record.TTL != 0 {
+		f.add("TTL", "an alias record can't have a ttl")
+	}
+
+	if len(record.Values) > 0 {
+		f.add("Values", "an alias record can't have values")
+	}
+
+	f.hostName("AliasTarget.DNSName", record.AliasTarget.DNSName)
+
+	if !hostedZoneIdRe.MatchString(record.AliasTarget.HostedZoneId) {
+		f.add("AliasTarget.HostedZoneId", "invalid hosted zone id %q", record.AliasTarget.HostedZoneId)
+	}
+}
+
+// hostName validates a fully qualified host name, the case and a trailing dot are ignored
+func (f *fieldErrors) hostName(field, name string) {
+	host := strings.ToLower(strings.TrimSuffix(name, "."))
+	switch {
+	case host == "":
+		f.add(field, "host name is required")
+		return
+	case len(host) > 253:
+		f.add(field, "host name cannot be longer than 253 characters")
+		return
+	}
+
+	for _, label := range strings.Split(host, ".") {
+		if !hostLabelRe.MatchString(label) {
+			f.add(field, "host name label %q can only contain letters, numbers and hyphens", label)
+			return
+		}
+	}
+}
+
+// cacheBehaviors validates the additional cache behaviors for a website distribution
+func (f *fieldErrors) cacheBehaviors(field string, behaviors []*cfapi.CacheBehavior) {
+	if len(behaviors) > maxCacheBehaviors {
+		f.add(field, "at most %d cache behaviors are allowed, got %d", maxCacheBehaviors, len(behaviors))
+	}
+
+	patterns := map[string]bool{}
+	for i, b := range behaviors {
+		bf := fmt.Sprintf("%s[%d]", field, i)
+		if b == nil {
+			f.add(bf, "cache behavior cannot be null")
+			continue
+		}
+
+		switch {
+		case b.PathPattern == "":
+			f.add(bf+".PathPattern", "path pattern is required")
+		case b.PathPattern == "*" || b.PathPattern == "/*":
+			f.add(bf+".PathPattern", "path pattern %s is the default cache behavior", b.PathPattern)
+		case len(b.PathPattern) > 255 || strings.ContainsAny(b.PathPattern, " \t\n"):
+			f.add(bf+".PathPattern", "path pattern must be at most 255 characters without whitespace")
+		case patterns[b.PathPattern]:
+			f.add(bf+".PathPattern", "duplicate path pattern %s", b.PathPattern)
+		}
+		patterns[b.PathPattern] = true
+
+		if b.Origin != "" {
+			f.hostName(bf+".Origin", b.Origin)
+		}
+
+		ttls := []int64{cfapi.DefaultBehaviorMinTTL, cfapi.DefaultBehaviorDefaultTTL, cfapi.DefaultBehaviorMaxTTL}
+		for j, ttl := range []*int64{b.MinTTL, b.DefaultTTL, b.MaxTTL} {
+			if ttl != nil {
+				ttls[j] = aws.Int64Value(ttl)
+			}
+		}
+
+		switch {
+		case ttls[0] < 0 || ttls[1] < 0 || ttls[2] < 0:
+			f.add(bf, "ttls cannot be negative")
+		case ttls[0] > ttls[1] || ttls[1] > ttls[2]:
+			f.add(bf, "ttls must be MinTTL <= DefaultTTL <= MaxTTL, got %d, %d and %d", ttls[0], ttls[1], ttls[2])
+		}
+
+		if len(b.AllowedMethods) > 0 && !allowedMethodsSupported(b.AllowedMethods) {
+			f.add(bf+".AllowedMethods", "allowed methods must be GET and HEAD, GET, HEAD and OPTIONS, or all of %s", strings.Join(cacheBehaviorMethods[2], ", "))
+		}
+
+		switch {
+		case len(b.ForwardHeaders) > maxForwardHeaders:
+			f.add(bf+".ForwardHeaders", "at most %d headers can be forwarded, got %d", maxForwardHeaders, len(b.ForwardHeaders))
+		case len(b.ForwardHeaders) > 1 && contains(b.ForwardHeaders, "*"):
+			f.add(bf+".ForwardHeaders", "* forwards all of the headers and can't be combined with other headers")
+		}
+	}
+}
+
+// allowedMethodsSupported returns true if the methods are one of the sets of methods supported by cloudfront
+func allowedMethodsSupported(methods []string) bool {
+	sorted := append([]string{}, methods...)
+	for i := range sorted {
+		sorted[i] = strings.ToUpper(sorted[i])
+	}
+	sort.Strings(sorted)
+
+	for _, supported := range cacheBehaviorMethods {
+		s := append([]string{}, supported...)
+		sort.Strings(s)
+		if reflect.DeepEqual(sorted, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// websiteRedirect validates the target of a redirect site, it must be a different host than the website
+func (f *fieldErrors) websiteRedirect(field string, redirect *websiteRedirect, website string) {
+	if strings.EqualFold(strings.TrimSuffix(redirect.HostName, "."), website) {
+		f.add(field+".HostName", "host name cannot be the website name")
+	} else {
+		f.hostName(field+".HostName", redirect.HostName)
+	}
+
+	if redirect.Protocol != "" && !contains(websiteRedirectProtocols, redirect.Protocol) {
+		f.add(field+".Protocol", "unsupported protocol %s, must be one of %s", redirect.Protocol, strings.Join(websiteRedirectProtocols, ", "))
+	}
+}
+
+// geoRestriction validates a website's geo restriction, the locations are required unless the restriction is removed
+func (f *fieldErrors) geoRestriction(field string, restriction *cfapi.GeoRestriction) {
+	if !contains(cfapi.GeoRestrictionTypes, restriction.RestrictionType) {
+		f.add(field+".RestrictionType", "unsupported restriction type '%s', must be one of %s", restriction.RestrictionType, strings.Join(cfapi.GeoRestrictionTypes, ", "))
+		return
+	}
+
+	if restriction.RestrictionType == "none" {
+		if len(restriction.Locations) > 0 {
+			f.add(field+".Locations", "locations cannot be set without a restriction")
+		}
+		return
+	}
+
+	if len(restriction.Locations) == 0 {
+		f.add(field+".Locations", "at least one location is required for a %s restriction", restriction.RestrictionType)
+		return
+	}
+
+	seen := map[string]bool{}
+	for i, l := range restriction.Locations {
+		if !cfapi.ValidCountryCode(l) {
+			f.add(fmt.Sprintf("%s.Locations[%d]", field, i), "'%s' is not an ISO 3166-1 alpha-2 country code", l)
+			continue
+		}
+
+		if seen[strings.ToUpper(l)] {
+			f.add(fmt.Sprintf("%s.Locations[%d]", field, i), "duplicate location %s", l)
+		}
+		seen[strings.ToUpper(l)] = true
+	}
+}
+
+// httpVersion validates the maximum http version of a website distribution
+func (f *fieldErrors) httpVersion(field, version string) {
+	if version != "" && !cfapi.ValidHttpVersion(version) {
+		f.add(field, "unsupported http version '%s', must be one of %s", version, strings.Join(cfapi.HttpVersions, ", "))
+	}
+}
+
+// user validates the input for creating an IAM user
+func (f *fieldErrors) user(field string, user *iam.CreateUserInput) {
+	if user == nil {
+		f.add(field, "user is required")
+		return
+	}
+
+	if name := aws.StringValue(user.UserName); !iamNameRe.MatchString(name) {
+		f.add(field+".UserName", "user name %q must be 1 to 64 letters, numbers or the characters +=,.@_-", name)
+	}
+
+	if user.Path != nil && !iamPathRe.MatchString(aws.StringValue(user.Path)) {
+		f.add(field+".Path", "path %q must begin and end with /", aws.StringValue(user.Path))
+	}
+}
+
+// sftpUser validates the name of a transfer user
+func (f *fieldErrors) sftpUser(field, name string) {
+	if !sftpUserRe.MatchString(name) {
+		f.add(field, "user name %q must be 3 to 100 letters, numbers or the characters _@.- and can't start with @.-", name)
+	}
+}
+
+// sshPublicKeys validates that each key is an rsa, ed25519 or ecdsa ssh public key in the openssh format
+func (f *fieldErrors) sshPublicKeys(field string, keys []string) {
+	if len(keys) > maxSSHPublicKeys {
+		f.add(field, "at most %d ssh public keys are allowed, got %d", maxSSHPublicKeys, len(keys))
+	}
+
+	for i, k := range keys {
+		if !sshPublicKeyRe.MatchString(strings.TrimSpace(k)) {
+			f.add(fmt.Sprintf("%s[%d]", field, i), "ssh public key must be an ssh-rsa, ssh-ed25519 or ecdsa-sha2-nistp key in the openssh format")
+		}
+	}
+}
+
+// accessPoint validates the name of an s3 access point
+func (f *fieldErrors) accessPoint(field, name string) {
+	if !accessPointRe.MatchString(name) {
+		f.add(field, "access point name %q must be 3 to 50 lowercase letters, numbers or hyphens and can't begin or end with a hyphen", name)
+	}
+}
+
+// vpcId validates the id of a vpc, it's optional
+func (f *fieldErrors) vpcId(field, id string) {
+	if id != "" && !vpcIdRe.MatchString(id) {
+		f.add(field, "invalid vpc id %q", id)
+	}
+}
+
+// principals validates that each principal is the arn of an account root, user or role
+func (f *fieldErrors) principals(field string, principals []string) {
+	for i, p := range principals {
+		if !iamPrincipalRe.MatchString(p) {
+			f.add(fmt.Sprintf("%s[%d]", field, i), "principal %q must be the arn of an account root, user or role", p)
+		}
+	}
+}
+
+// ipAllowlist validates that each network of an ip allowlist is a cidr
+func (f *fieldErrors) ipAllowlist(field string, cidrs []string) {
+	if len(cidrs) > maxIPAllowlist {
+		f.add(field, "at most %d networks are allowed, got %d", maxIPAllowlist, len(cidrs))
+	}
+
+	for i, c := range cidrs {
+		if _, _, err := net.ParseCIDR(c); err != nil {
+			f.add(fmt.Sprintf("%s[%d]", field, i), "invalid cidr %q", c)
+		}
+	}
+}
+
+// groups validates that each group is one of the allowed groups
+func (f *fieldErrors) groups(field string, groups, allowed []string) {
+	for i, g := range groups {
+		if !contains(allowed, g) {
+			f.add(fmt.Sprintf("%s[%d]", field, i), "unsupported group %s, must be one of %s", g, strings.Join(allowed, ", "))
+		}
+	}
+}
+
+// keyDelivery validates how new access keys are delivered, the secrets manager method is only allowed if it's
+// configured
+func (f *fieldErrors) keyDelivery(field string, delivery *keyDelivery, config *common.CredentialSecrets) {
+	if delivery == nil {
+		return
+	}
+
+	switch delivery.Method {
+	case "", keyDeliveryResponse:
+	case keyDeliveryEncrypted:
+		if _, err := parseDeliveryPublicKey(delivery.PublicKey); err != nil {
+			f.add(field+".PublicKey", "%s", err)
+		}
+	case keyDeliverySecretsManager:
+		if config == nil {
+			f.add(field+".Method", "delivering access keys to secrets manager is not configured")
+		}
+	default:
+		f.add(field+".Method", "unsupported delivery method %s, must be one of %s", delivery.Method, strings.Join(keyDeliveryMethods, ", "))
+	}
+
+	if delivery.Method != keyDeliveryEncrypted && delivery.PublicKey != "" {
+		f.add(field+".PublicKey", "a public key is only used with the %s method", keyDeliveryEncrypted)
+	}
+}
+
+// domainNames returns the sorted, comma separated list of configured domain names
+func domainNames(domains map[string]*common.Domain) string {
+	names := make([]string, 0, len(domains))
+	for d := range domains {
+		names = append(names, d)
+	}
+	sort.Strings(names)
+
+	return strings.Join(names, ", ")
+}
+
+// contains returns true if the list of strings contains the string
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,448 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func TestFieldErrors(t *testing.T) {
+	f := fieldErrors{}
+	if err := f.err(); err != nil {
+		t.Errorf("expected nil error for no field errors, got %s", err)
+	}
+
+	f.add("Foo", "foo is %s", "bad")
+	f.add("Bar", "bar is bad")
+
+	err := f.err()
+	aerr, ok := err.(apierror.Error)
+	if !ok {
+		t.Fatalf("expected apierror.Error, got %T", err)
+	}
+
+	if aerr.Code != apierror.ErrBadRequest {
+		t.Errorf("expected error code %s, got %s", apierror.ErrBadRequest, aerr.Code)
+	}
+
+	if expected := "invalid request: Foo: foo is bad; Bar: bar is bad"; aerr.Message != expected {
+		t.Errorf("expected message %q, got %q", expected, aerr.Message)
+	}
+}
+
+func TestValidateBucketName(t *testing.T) {
+	tests := map[string]bool{
+		"my-bucket":             true,
+		"my.bucket.123":         true,
+		"abc":                   true,
+		"":                      false,
+		"ab":                    false,
+		strings.Repeat("a", 64): false,
+		"My-Bucket":             false,
+		"-bucket":               false,
+		"bucket-":               false,
+		"my_bucket":             false,
+		"my..bucket":            false,
+		"192.168.1.1":           false,
+		"xn--bucket":            false,
+		"bucket-s3alias":        false,
+		"bucket--ol-s3":         false,
+	}
+
+	for name, valid := range tests {
+		f := fieldErrors{}
+		f.bucketName("Bucket", name)
+		if valid && len(f) > 0 {
+			t.Errorf("expected bucket name %q to be valid, got %v", name, f)
+		} else if !valid && len(f) == 0 {
+			t.Errorf("expected bucket name %q to be invalid", name)
+		}
+	}
+}
+
+func TestValidateWebsiteName(t *testing.T) {
+	domains := map[string]*common.Domain{
+		"example.com":          {},
+		"example.edu":          {},
+		"internal.example.com": {PrivateZone: true},
+	}
+
+	tests := map[string]bool{
+		"www.internal.example.com": false,
+		"www.example.com":          true,
+		"my-site.example.edu":      true,
+		"example":                  false,
+		"www.example.org":          false,
+		"a.b.example.com":          false,
+		"WWW.example.com":          false,
+	}
+
+	for name, valid := range tests {
+		f := fieldErrors{}
+		f.websiteName("Website", name, domains)
+		if valid && len(f) > 0 {
+			t.Errorf("expected website name %q to be valid, got %v", name, f)
+		} else if !valid && len(f) == 0 {
+			t.Errorf("expected website name %q to be invalid", name)
+		}
+	}
+}
+
+func TestValidateTags(t *testing.T) {
+	tag := func(k, v string) *s3.Tag {
+		return &s3.Tag{Key: aws.String(k), Value: aws.String(v)}
+	}
+
+	tooMany := []*s3.Tag{}
+	for i := 0; i <= maxTags; i++ {
+		tooMany = append(tooMany, tag(strings.Repeat("k", i+1), "v"))
+	}
+
+	tests := []struct {
+		name   string
+		tags   []*s3.Tag
+		errors int
+	}{
+		{"empty", nil, 0},
+		{"valid", []*s3.Tag{tag("Name", "foo"), tag("cost:center", "a/b+c=d@e")}, 0},
+		{"null tag", []*s3.Tag{nil}, 1},
+		{"missing key", []*s3.Tag{tag("", "foo")}, 1},
+		{"aws prefix", []*s3.Tag{tag("AWS:foo", "bar")}, 1},
+		{"reserved key", []*s3.Tag{tag("spinup:org", "bar")}, 1},
+		{"reserved protection key", []*s3.Tag{tag("spinup:protected", "true")}, 1},
+		{"reserved object tag key", []*s3.Tag{tag("spinup:object:project", "X")}, 1},
+		{"invalid key characters", []*s3.Tag{tag("foo!", "bar")}, 1},
+		{"invalid value characters", []*s3.Tag{tag("foo", "bar#")}, 1},
+		{"duplicate key", []*s3.Tag{tag("foo", "bar"), tag("foo", "baz")}, 1},
+		{"long key", []*s3.Tag{tag(strings.Repeat("k", maxTagKeyLength+1), "bar")}, 1},
+		{"long value", []*s3.Tag{tag("foo", strings.Repeat("v", maxTagValueLength+1))}, 1},
+		{"too many", tooMany, 1},
+	}
+
+	for _, test := range tests {
+		f := fieldErrors{}
+		f.tags("Tags", test.tags)
+		if len(f) != test.errors {
+			t.Errorf("%s: expected %d errors, got %d: %v", test.name, test.errors, len(f), f)
+		}
+	}
+}
+
+func TestValidateObjectTags(t *testing.T) {
+	tag := func(k, v string) *s3.Tag {
+		return &s3.Tag{Key: aws.String(k), Value: aws.String(v)}
+	}
+
+	tooMany := []*s3.Tag{}
+	for i := 0; i <= maxObjectTags; i++ {
+		tooMany = append(tooMany, tag(strings.Repeat("k", i+1), "v"))
+	}
+
+	tests := []struct {
+		name   string
+		tags   []*s3.Tag
+		errors int
+	}{
+		{"empty", nil, 0},
+		{"valid", []*s3.Tag{tag("project", "X"), tag("owner", "someone@example.com")}, 0},
+		{"null tag", []*s3.Tag{nil}, 1},
+		{"missing key", []*s3.Tag{tag("", "foo")}, 1},
+		{"aws prefix", []*s3.Tag{tag("aws:foo", "bar")}, 1},
+		{"reserved key", []*s3.Tag{tag("yale:spinup", "true")}, 1},
+		{"duplicate key", []*s3.Tag{tag("foo", "bar"), tag("foo", "baz")}, 1},
+		{"long key", []*s3.Tag{tag(strings.Repeat("k", maxTagKeyLength-len(objectTagPrefix)+1), "bar")}, 1},
+		{"invalid value characters", []*s3.Tag{tag("foo", "bar&baz")}, 1},
+		{"too many", tooMany, 1},
+	}
+
+	for _, test := range tests {
+		f := fieldErrors{}
+		f.objectTags("ObjectTags", test.tags)
+		if len(f) != test.errors {
+			t.Errorf("%s: expected %d errors, got %d: %v", test.name, test.errors, len(f), f)
+		}
+	}
+}
+
+func TestValidateRequiredTags(t *testing.T) {
+	required, err := newRequiredTags([]common.RequiredTag{
+		{Key: "costcenter", Pattern: "^[0-9]{6}$"},
+		{Key: "owner-netid"},
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	tests := []struct {
+		name   string
+		tags   []*s3.Tag
+		errors int
+	}{
+		{"valid", []*s3.Tag{{Key: aws.String("costcenter"), Value: aws.String("123456")}, {Key: aws.String("owner-netid"), Value: aws.String("abc123")}}, 0},
+		{"missing", []*s3.Tag{{Key: aws.String("foo"), Value: aws.String("bar")}}, 2},
+		{"invalid value", []*s3.Tag{{Key: aws.String("costcenter"), Value: aws.String("12")}, {Key: aws.String("owner-netid"), Value: aws.String("abc123")}}, 1},
+		{"empty value", []*s3.Tag{{Key: aws.String("costcenter"), Value: aws.String("123456")}, {Key: aws.String("owner-netid"), Value: aws.String("")}}, 1},
+	}
+
+	for _, test := range tests {
+		f := fieldErrors{}
+		f.requiredTags("Tags", test.tags, required)
+		if len(f) != test.errors {
+			t.Errorf("%s: expected %d errors, got %d: %v", test.name, test.errors, len(f), f)
+		}
+	}
+
+	f := fieldErrors{}
+	f.requiredTags("Tags", nil, nil)
+	if len(f) != 0 {
+		t.Errorf("expected no errors without required tags, got %v", f)
+	}
+}
+
+func TestValidateDNSRecord(t *testing.T) {
+	website := "www.example.com"
+	tests := []struct {
+		name   string
+		record websiteDNSRecord
+		errors int
+	}{
+		{"txt at website", websiteDNSRecord{Name: "www.example.com", Type: "TXT", Values: []string{"google-site-verification=abc123"}}, 0},
+		{"txt within website", websiteDNSRecord{Name: "_dmarc.www.example.com.", Type: "TXT", TTL: 3600, Values: []string{`"v=DMARC1; p=none"`}}, 0},
+		{"cname within website", websiteDNSRecord{Name: "docs.www.example.com", Type: "CNAME", Values: []string{"example.github.io"}}, 0},
+		{"cname at website", websiteDNSRecord{Name: "www.example.com", Type: "CNAME", Values: []string{"example.github.io"}}, 1},
+		{"outside website", websiteDNSRecord{Name: "mail.example.com", Type: "TXT", Values: []string{"foo"}}, 1},
+		{"suffix but not within website", websiteDNSRecord{Name: "awww.example.com", Type: "TXT", Values: []string{"foo"}}, 1},
+		{"invalid label", websiteDNSRecord{Name: "foo bar.www.example.com", Type: "TXT", Values: []string{"foo"}}, 1},
+		{"unsupported type", websiteDNSRecord{Name: "www.example.com", Type: "MX", Values: []string{"10 mail.example.com"}}, 1},
+		{"a at website", websiteDNSRecord{Name: "www.example.com", Type: "A", Values: []string{"10.0.0.1"}}, 1},
+		{"a within website", websiteDNSRecord{Name: "vpn.www.example.com", Type: "A", TTL: 60, Values: []string{"10.0.0.1", "10.0.0.2"}}, 0},
+		{"aaaa within website", websiteDNSRecord{Name: "vpn.www.example.com", Type: "AAAA", Values: []string{"2001:db8::1"}}, 0},
+		{"invalid a value", websiteDNSRecord{Name: "vpn.www.example.com", Type: "A", Values: []string{"2001:db8::1"}}, 1},
+		{"invalid aaaa value", websiteDNSRecord{Name: "vpn.www.example.com", Type: "AAAA", Values: []string{"10.0.0.1"}}, 1},
+		{"missing name and values", websiteDNSRecord{Type: "TXT"}, 2},
+		{"multiple cname values", websiteDNSRecord{Name: "docs.www.example.com", Type: "CNAME", Values: []string{"a.example.org", "b.example.org"}}, 1},
+		{"invalid ttl", websiteDNSRecord{Name: "www.example.com", Type: "TXT", TTL: -1, Values: []string{"foo"}}, 1},
+		{"long txt value", websiteDNSRecord{Name: "www.example.com", Type: "TXT", Values: []string{strings.Repeat("a", 256)}}, 1},
+		{"empty value", websiteDNSRecord{Name: "www.example.com", Type: "TXT", Values: []string{""}}, 1},
+	}
+
+	for _, test := range tests {
+		f := fieldErrors{}
+		f.dnsRecord(&test.record, website, false)
+		if len(f) != test.errors {
+			t.Errorf("%s: expected %d errors, got %d: %v", test.name, test.errors, len(f), f)
+		}
+	}
+}
+
+func TestValidatePrivateDNSRecord(t *testing.T) {
+	website := "docs.internal.example.com"
+	alb := &websiteDNSAliasTarget{DNSName: "internal-docs-alb-123456789.us-east-1.elb.amazonaws.com", HostedZoneId: "Z35SXDOTRQ7X7K"}
+	tests := []struct {
+		name    string
+		record  websiteDNSRecord
+		private bool
+		errors  int
+	}{
+		{"alias at website", websiteDNSRecord{Name: website, Type: "A", AliasTarget: alb}, true, 0},
+		{"aaaa alias within website", websiteDNSRecord{Name: "api.docs.internal.example.com", Type: "AAAA", AliasTarget: alb}, true, 0},
+		{"a at website", websiteDNSRecord{Name: website, Type: "A", Values: []string{"10.0.0.1"}}, true, 0},
+		{"cname at website", websiteDNSRecord{Name: website, Type: "CNAME", Values: []string{"internal-docs-alb-123456789.us-east-1.elb.amazonaws.com"}}, true, 0},
+		{"alias in public zone", websiteDNSRecord{Name: "api.docs.internal.example.com", Type: "A", AliasTarget: alb}, false, 1},
+		{"txt alias", websiteDNSRecord{Name: website, Type: "TXT", AliasTarget: alb}, true, 1},
+		{"alias with ttl and values", websiteDNSRecord{Name: website, Type: "A", TTL: 60, Values: []string{"10.0.0.1"}, AliasTarget: alb}, true, 2},
+		{"invalid alias target", websiteDNSRecord{Name: website, Type: "A", AliasTarget: &websiteDNSAliasTarget{DNSName: "https://internal-alb/", HostedZoneId: "z35sxdotrq7x7k"}}, true, 2},
+	}
+
+	for _, test := range tests {
+		f := fieldErrors{}
+		f.dnsRecord(&test.record, website, test.private)
+		if len(f) != test.errors {
+			t.Errorf("%s: expected %d errors, got %d: %v", test.name, test.errors, len(f), f)
+		}
+	}
+}
+
+func TestValidateWebsiteRedirect(t *testing.T) {
+	website := "old.example.com"
+	tests := []struct {
+		name     string
+		redirect websiteRedirect
+		errors   int
+	}{
+		{"host", websiteRedirect{HostName: "new.example.edu"}, 0},
+		{"host with protocol", websiteRedirect{HostName: "New.Example.edu.", Protocol: "http"}, 0},
+		{"missing host", websiteRedirect{}, 1},
+		{"same host", websiteRedirect{HostName: "OLD.example.com"}, 1},
+		{"invalid host", websiteRedirect{HostName: "new site.example.edu"}, 1},
+		{"url instead of host", websiteRedirect{HostName: "https://new.example.edu/"}, 1},
+		{"unsupported protocol", websiteRedirect{HostName: "new.example.edu", Protocol: "ftp"}, 1},
+	}
+
+	for _, test := range tests {
+		f := fieldErrors{}
+		f.websiteRedirect("Redirect", &test.redirect, website)
+		if len(f) != test.errors {
+			t.Errorf("%s: expected %d errors, got %d: %v", test.name, test.errors, len(f), f)
+		}
+	}
+}
+
+func TestValidateCacheBehaviors(t *testing.T) {
+	tests := []struct {
+		name      string
+		behaviors []*cfapi.CacheBehavior
+		errors    int
+	}{
+		{"none", nil, 0},
+		{"uncached external origin", []*cfapi.CacheBehavior{{PathPattern: "/api/*", Origin: "api.example.org", DefaultTTL: aws.Int64(0), MaxTTL: aws.Int64(0), AllowedMethods: []string{"GET", "HEAD", "OPTIONS", "PUT", "POST", "PATCH", "DELETE"}, ForwardHeaders: []string{"*"}}}, 0},
+		{"website origin", []*cfapi.CacheBehavior{{PathPattern: "/static/*", DefaultTTL: aws.Int64(86400)}, {PathPattern: "*.html", AllowedMethods: []string{"head", "get", "options"}}}, 0},
+		{"missing path pattern", []*cfapi.CacheBehavior{{}}, 1},
+		{"default path pattern", []*cfapi.CacheBehavior{{PathPattern: "/*"}}, 1},
+		{"duplicate path pattern", []*cfapi.CacheBehavior{{PathPattern: "/api/*"}, {PathPattern: "/api/*"}}, 1},
+		{"null behavior", []*cfapi.CacheBehavior{nil}, 1},
+		{"invalid origin", []*cfapi.CacheBehavior{{PathPattern: "/api/*", Origin: "https://api.example.org"}}, 1},
+		{"negative ttl", []*cfapi.CacheBehavior{{PathPattern: "/api/*", MinTTL: aws.Int64(-1)}}, 1},
+		{"default ttl above max", []*cfapi.CacheBehavior{{PathPattern: "/api/*", MaxTTL: aws.Int64(60)}}, 1},
+		{"unsupported methods", []*cfapi.CacheBehavior{{PathPattern: "/api/*", AllowedMethods: []string{"GET", "POST"}}}, 1},
+		{"all headers and another header", []*cfapi.CacheBehavior{{PathPattern: "/api/*", ForwardHeaders: []string{"*", "Host"}}}, 1},
+		{"too many headers", []*cfapi.CacheBehavior{{PathPattern: "/api/*", ForwardHeaders: strings.Split("a,b,c,d,e,f,g,h,i,j,k", ",")}}, 1},
+	}
+
+	for _, test := range tests {
+		f := fieldErrors{}
+		f.cacheBehaviors("CacheBehaviors", test.behaviors)
+		if len(f) != test.errors {
+			t.Errorf("%s: expected %d errors, got %d: %v", test.name, test.errors, len(f), f)
+		}
+	}
+
+	f := fieldErrors{}
+	f.cacheBehaviors("CacheBehaviors", make([]*cfapi.CacheBehavior, maxCacheBehaviors+1))
+	if len(f) != maxCacheBehaviors+2 {
+		t.Errorf("expected too many and null behavior errors, got %v", f)
+	}
+}
+
+func TestValidateGeoRestriction(t *testing.T) {
+	tests := []struct {
+		name        string
+		restriction *cfapi.GeoRestriction
+		errors      int
+	}{
+		{"us only", &cfapi.GeoRestriction{RestrictionType: "whitelist", Locations: []string{"US"}}, 0},
+		{"lowercase locations", &cfapi.GeoRestriction{RestrictionType: "blacklist", Locations: []string{"kp", "ir"}}, 0},
+		{"none", &cfapi.GeoRestriction{RestrictionType: "none"}, 0},
+		{"none with locations", &cfapi.GeoRestriction{RestrictionType: "none", Locations: []string{"US"}}, 1},
+		{"unsupported type", &cfapi.GeoRestriction{RestrictionType: "allow", Locations: []string{"US"}}, 1},
+		{"missing locations", &cfapi.GeoRestriction{RestrictionType: "whitelist"}, 1},
+		{"invalid locations", &cfapi.GeoRestriction{RestrictionType: "whitelist", Locations: []string{"USA", "XX", "CA"}}, 2},
+		{"duplicate locations", &cfapi.GeoRestriction{RestrictionType: "whitelist", Locations: []string{"US", "us"}}, 1},
+	}
+
+	for _, test := range tests {
+		f := fieldErrors{}
+		f.geoRestriction("GeoRestriction", test.restriction)
+		if len(f) != test.errors {
+			t.Errorf("%s: expected %d errors, got %d: %v", test.name, test.errors, len(f), f)
+		}
+	}
+}
+
+func TestValidateHttpVersion(t *testing.T) {
+	f := fieldErrors{}
+	for _, v := range []string{"", "http1.1", "http2", "http3", "http2and3"} {
+		f.httpVersion("HttpVersion", v)
+	}
+	if len(f) != 0 {
+		t.Errorf("expected no errors, got %v", f)
+	}
+
+	f.httpVersion("HttpVersion", "HTTP2")
+	f.httpVersion("HttpVersion", "http4")
+	if len(f) != 2 {
+		t.Errorf("expected 2 errors, got %d: %v", len(f), f)
+	}
+}
+
+func TestValidateLifecycleAndPolicy(t *testing.T) {
+	f := fieldErrors{}
+	f.lifecycle("Lifecycle", nil)
+	f.policyDocument("BucketPolicy", nil)
+	f.policyDocument("BucketPolicy", aws.String(`{"Version":"2012-10-17","Statement":[]}`))
+	if len(f) != 0 {
+		t.Errorf("expected no errors, got %v", f)
+	}
+
+	f.lifecycle("Lifecycle", aws.String("forever-and-a-day"))
+	f.policyDocument("BucketPolicy", aws.String(`{"Version":`))
+	f.policyDocument("BucketPolicy", aws.String(`[]`))
+	if len(f) != 3 {
+		t.Errorf("expected 3 errors, got %d: %v", len(f), f)
+	}
+}
+
+func TestValidateUserAndGroups(t *testing.T) {
+	tests := []struct {
+		name   string
+		user   *iam.CreateUserInput
+		groups []string
+		errors int
+	}{
+		{"valid", &iam.CreateUserInput{UserName: aws.String("bucket-user"), Path: aws.String("/spinup/")}, []string{"BktAdmGrp", "BktROGrp"}, 0},
+		{"missing user", nil, nil, 1},
+		{"missing user name", &iam.CreateUserInput{}, nil, 1},
+		{"invalid user name", &iam.CreateUserInput{UserName: aws.String("bucket user")}, nil, 1},
+		{"invalid path", &iam.CreateUserInput{UserName: aws.String("user"), Path: aws.String("spinup")}, nil, 1},
+		{"invalid group", &iam.CreateUserInput{UserName: aws.String("user")}, []string{"BktAdmGrp", "WebAdmGrp"}, 1},
+	}
+
+	for _, test := range tests {
+		f := fieldErrors{}
+		f.user("User", test.user)
+		f.groups("Groups", test.groups, bucketUserGroups)
+		if len(f) != test.errors {
+			t.Errorf("%s: expected %d errors, got %d: %v", test.name, test.errors, len(f), f)
+		}
+	}
+}
+
+func TestValidateInvalidationPaths(t *testing.T) {
+	f := fieldErrors{}
+	f.invalidationPaths("CacheInvalidation", []string{"/*", "/index.html"})
+	if len(f) != 0 {
+		t.Errorf("expected no errors, got %v", f)
+	}
+
+	f.invalidationPaths("CacheInvalidation", nil)
+	f.invalidationPaths("CacheInvalidation", []string{"index.html"})
+	if len(f) != 2 {
+		t.Errorf("expected 2 errors, got %d: %v", len(f), f)
+	}
+}
+
+func TestBucketCreateRequestValidate(t *testing.T) {
+	req := bucketCreateRequest{
+		BucketInput: s3.CreateBucketInput{Bucket: aws.String("Bad_Bucket")},
+		Tags:        []*s3.Tag{{Key: aws.String("aws:foo"), Value: aws.String("bar")}},
+		Lifecycle:   aws.String("nope"),
+	}
+
+	err := req.validate(nil)
+	aerr, ok := err.(apierror.Error)
+	if !ok {
+		t.Fatalf("expected apierror.Error, got %T", err)
+	}
+
+	for _, field := range []string{"BucketInput.Bucket", "Tags[0].Key", "Lifecycle"} {
+		if !strings.Contains(aerr.Message, field+":") {
+			t.Errorf("expected error message to contain field %s, got %q", field, aerr.Message)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"github.com/YaleSpinup/apierror"
+	iamapi "github.com/YaleSpinup/s3-api/iam"
+)
+
+// requireVpcEndpoints returns a bad request error if there aren't any vpc endpoints configured
+func (s *server) requireVpcEndpoints() error {
+	if len(s.account.VpcEndpoints) > 0 {
+		return nil
+	}
+
+	return apierror.New(apierror.ErrBadRequest, "vpc-only buckets are not configured", nil)
+}
+
+// vpcOnlyPolicy adds the vpc-restricted statement to a bucket policy document, or removes it, and returns the policy
+// and the number of statements in it
+func (s *server) vpcOnlyPolicy(accountId, bucket, policy string, vpcOnly bool) (string, int, error) {
+	if vpcOnly {
+		if err := s.requireVpcEndpoints(); err != nil {
+			return "", 0, err
+		}
+	}
+
+	return s.bucketPolicyBlock(accountId, bucket, policy, iamapi.BlockVpcRestricted, vpcOnly)
+}
+
+// isVpcOnly returns true if the bucket policy document restricts the bucket to the vpc endpoints
+func isVpcOnly(policy string) bool {
+	return hasPolicyBlock(policy, iamapi.BlockVpcRestricted)
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+	s3api "github.com/YaleSpinup/s3-api/s3"
+	"github.com/YaleSpinup/s3-api/session"
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestVpcOnlyPolicy(t *testing.T) {
+	s := &server{
+		account: common.Account{VpcEndpoints: []string{"vpce-1111", "vpce-2222"}},
+		session: &session.Session{RoleName: "SpinupS3Role"},
+	}
+
+	policy, remaining, err := s.vpcOnlyPolicy("012345678910", "dataset", "", true)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	expected := `{"Statement":[{"Action":["s3:*"],"Condition":{"ArnNotLike":{"aws:PrincipalArn":["arn:aws:iam::012345678910:role/SpinupS3Role"]},"StringNotEquals":{"aws:SourceVpce":["vpce-1111","vpce-2222"]}},"Effect":"Deny","Principal":"*","Resource":["arn:aws:s3:::dataset","arn:aws:s3:::dataset/*"],"Sid":"SpinupVpcOnly"}],"Version":"2012-10-17"}`
+	if policy != expected || remaining != 1 {
+		t.Errorf("expected: %s\ngot: %s (%d statements)", expected, policy, remaining)
+	}
+}
+
+func TestUpdateBucketPolicyVpcOnly(t *testing.T) {
+	s := &server{
+		account: common.Account{VpcEndpoints: []string{"vpce-1111"}},
+		session: &session.Session{RoleName: "SpinupS3Role"},
+	}
+	userPolicy := `{"Version":"2012-10-17","Statement":[{"Sid":"AllowRead","Effect":"Allow","Principal":{"AWS":"arn:aws:iam::012345678910:root"},"Action":"s3:GetObject","Resource":"arn:aws:s3:::dataset/*"}]}`
+
+	s3Client := &mockSoftDeleteS3{}
+	s3Service := s3api.S3{Service: s3Client}
+
+	// a bucket without a policy is restricted
+	if err := s.updateBucketPolicy(context.TODO(), s3Service, "012345678910", "dataset", nil, aws.Bool(true)); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if !isVpcOnly(s3Client.policy) {
+		t.Errorf("expected a vpc-only policy, got %s", s3Client.policy)
+	}
+
+	// a new policy without the restriction keeps it
+	if err := s.updateBucketPolicy(context.TODO(), s3Service, "012345678910", "dataset", aws.String(userPolicy), nil); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if !isVpcOnly(s3Client.policy) || !hasPolicyStatement(s3Client.policy, "AllowRead") {
+		t.Errorf("expected the new policy with the vpc-only restriction, got %s", s3Client.policy)
+	}
+
+	// lifting the restriction keeps the rest of the policy
+	if err := s.updateBucketPolicy(context.TODO(), s3Service, "012345678910", "dataset", nil, aws.Bool(false)); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if isVpcOnly(s3Client.policy) || !hasPolicyStatement(s3Client.policy, "AllowRead") {
+		t.Errorf("expected the policy without the vpc-only restriction, got %s", s3Client.policy)
+	}
+
+	// lifting the restriction from a policy with nothing else deletes it
+	s3Client.policy = ""
+	if err := s.updateBucketPolicy(context.TODO(), s3Service, "012345678910", "dataset", nil, aws.Bool(true)); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if err := s.updateBucketPolicy(context.TODO(), s3Service, "012345678910", "dataset", nil, aws.Bool(false)); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if s3Client.policy != "" {
+		t.Errorf("expected the policy to be deleted, got %s", s3Client.policy)
+	}
+
+	// buckets can't be restricted without vpc endpoints
+	s.account.VpcEndpoints = nil
+	if err := s.updateBucketPolicy(context.TODO(), s3Service, "012345678910", "dataset", nil, aws.Bool(true)); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Errorf("expected bad request without vpc endpoints, got %v", err)
+	}
+}
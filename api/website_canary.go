@@ -0,0 +1,170 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	"github.com/YaleSpinup/s3-api/task"
+	"github.com/aws/aws-sdk-go/aws"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// invalidateTaskKind is the kind of the task that invalidates the cache of a website after a canary
+	invalidateTaskKind = "invalidate"
+	// canaryCheckInterval is how often the status of the canary invalidation is checked
+	canaryCheckInterval = 15 * time.Second
+	// canaryTimeout is how long the canary invalidation is waited on before giving up
+	canaryTimeout = 30 * time.Minute
+	// maxCanaryWait is the longest a canary can be watched before the rest of the cache is invalidated
+	maxCanaryWait = time.Hour
+)
+
+// cacheCanaryActions are the actions needed to invalidate the cache of a website after a canary
+var cacheCanaryActions = []string{
+	"cloudfront:CreateInvalidation",
+	"cloudfront:GetInvalidation",
+}
+
+// canaryHTTPClient requests the canary paths from the website, redirects are returned rather than followed
+var canaryHTTPClient = &http.Client{
+	Timeout: 10 * time.Second,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// cacheCanary is a limited set of paths that are invalidated and checked before the rest of a website's cache
+type cacheCanary struct {
+	// Paths are invalidated first, the paths without wildcards are requested from the website once they're
+	// invalidated and must respond without an error, ie. /index.html
+	Paths []string
+	// Wait is how long to wait after the canary paths are checked before the rest of the cache is invalidated, ie. 5m
+	Wait string `json:",omitempty"`
+}
+
+// validate validates the canary and returns how long to wait after it
+func (c *cacheCanary) validate(f *fieldErrors, field string) time.Duration {
+	f.invalidationPaths(field+".Paths", c.Paths)
+
+	if c.Wait == "" {
+		return 0
+	}
+
+	wait, err := time.ParseDuration(c.Wait)
+	if err != nil || wait < 0 || wait > maxCanaryWait {
+		f.add(field+".Wait", "invalid wait %q, must be a duration up to %s", c.Wait, maxCanaryWait)
+		return 0
+	}
+
+	return wait
+}
+
+// checkCanaryPath requests a path from a website and fails if it doesn't respond or responds with an error
+func checkCanaryPath(ctx context.Context, website, path string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+website+path, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := canaryHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("responded with %s", res.Status)
+	}
+
+	return nil
+}
+
+// startCanaryInvalidation starts a background task that invalidates the canary paths of a website's cache, checks
+// them and then invalidates the rest of the paths
+func (s *server) startCanaryInvalidation(account, website, distributionId string, paths []string, canary *cacheCanary, wait time.Duration) (*task.Task, error) {
+	// the task can outlive the credentials of an assumed role, so it gets a session for each step
+	services := func(ctx context.Context) (cfapi.CloudFront, error) {
+		session, err := s.sessionForAccount(ctx, account, cacheCanaryActions...)
+		if err != nil {
+			return cfapi.CloudFront{}, err
+		}
+
+		return cfapi.NewSession(session.Session, s.account, s.mapAccountNumber(account)), nil
+	}
+
+	return s.tasks.Start(invalidateTaskKind, s.mapAccountNumber(account), website, func(ctx context.Context, rep *task.Reporter) error {
+		return canaryInvalidation(ctx, rep, services, checkCanaryPath, website, distributionId, paths, canary.Paths, wait)
+	})
+}
+
+// canaryInvalidation invalidates the canary paths of a website's cache and waits for the invalidation to complete.
+// The canary paths without wildcards are checked and the rest of the paths are invalidated after the wait, if all of
+// them passed.  Each invalidation is counted on the task, the task fails and the rest of the cache is left as it is
+// if the canary fails.
+func canaryInvalidation(ctx context.Context, rep *task.Reporter, services func(context.Context) (cfapi.CloudFront, error), check func(context.Context, string, string) error, website, distributionId string, paths, canaryPaths []string, wait time.Duration) error {
+	rep.Found(2)
+
+	cloudFrontService, err := services(ctx)
+	if err != nil {
+		return err
+	}
+
+	out, err := cloudFrontService.InvalidateCache(ctx, distributionId, canaryPaths)
+	if err != nil {
+		rep.Failed("failed to invalidate canary paths %s: %s", strings.Join(canaryPaths, ","), err)
+		return err
+	}
+
+	wctx, cancel := context.WithTimeout(ctx, canaryTimeout)
+	_, err = cloudFrontService.WaitUntilInvalidated(wctx, distributionId, aws.StringValue(out.Invalidation.Id), canaryCheckInterval)
+	cancel()
+	if err != nil {
+		rep.Failed("canary invalidation %s didn't complete: %s", aws.StringValue(out.Invalidation.Id), err)
+		return err
+	}
+
+	failures := []string{}
+	for _, p := range canaryPaths {
+		if strings.Contains(p, "*") {
+			continue
+		}
+
+		if err := check(ctx, website, p); err != nil {
+			failures = append(failures, fmt.Sprintf("%s %s", p, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		rep.Failed("canary paths failed: %s", strings.Join(failures, ", "))
+		return fmt.Errorf("%d canary paths failed, the rest of the cache wasn't invalidated", len(failures))
+	}
+	rep.Succeeded()
+
+	if wait > 0 {
+		log.Infof("waiting %s after the canary invalidation for website %s", wait, website)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+
+	cloudFrontService, err = services(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := cloudFrontService.InvalidateCache(ctx, distributionId, paths); err != nil {
+		rep.Failed("failed to invalidate paths %s: %s", strings.Join(paths, ","), err)
+		return err
+	}
+	rep.Succeeded()
+
+	return nil
+}
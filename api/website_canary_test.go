@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	"github.com/YaleSpinup/s3-api/task"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/aws/aws-sdk-go/service/cloudfront/cloudfrontiface"
+)
+
+// mockCanaryCloudFront is a cloudfront client that captures the invalidations, they're completed as soon as they're
+// created
+type mockCanaryCloudFront struct {
+	cloudfrontiface.CloudFrontAPI
+	invalidations [][]string
+}
+
+func (m *mockCanaryCloudFront) CreateInvalidationWithContext(ctx context.Context, input *cloudfront.CreateInvalidationInput, opts ...request.Option) (*cloudfront.CreateInvalidationOutput, error) {
+	m.invalidations = append(m.invalidations, aws.StringValueSlice(input.InvalidationBatch.Paths.Items))
+	return &cloudfront.CreateInvalidationOutput{Invalidation: &cloudfront.Invalidation{Id: aws.String("I123"), Status: aws.String("InProgress")}}, nil
+}
+
+func (m *mockCanaryCloudFront) GetInvalidationWithContext(ctx context.Context, input *cloudfront.GetInvalidationInput, opts ...request.Option) (*cloudfront.GetInvalidationOutput, error) {
+	return &cloudfront.GetInvalidationOutput{Invalidation: &cloudfront.Invalidation{Id: input.Id, Status: aws.String(cfapi.StatusCompleted)}}, nil
+}
+
+func TestCacheCanaryValidate(t *testing.T) {
+	tests := []struct {
+		canary cacheCanary
+		wait   time.Duration
+		valid  bool
+	}{
+		{cacheCanary{Paths: []string{"/index.html"}}, 0, true},
+		{cacheCanary{Paths: []string{"/index.html", "/assets/*"}, Wait: "5m"}, 5 * time.Minute, true},
+		{cacheCanary{}, 0, false},
+		{cacheCanary{Paths: []string{"index.html"}}, 0, false},
+		{cacheCanary{Paths: []string{"/index.html"}, Wait: "soon"}, 0, false},
+		{cacheCanary{Paths: []string{"/index.html"}, Wait: "2h"}, 0, false},
+		{cacheCanary{Paths: []string{"/index.html"}, Wait: "-1m"}, 0, false},
+	}
+
+	for _, tt := range tests {
+		f := fieldErrors{}
+		wait := tt.canary.validate(&f, "Canary")
+		if err := f.err(); (err == nil) != tt.valid {
+			t.Errorf("expected %+v valid to be %t, got %v", tt.canary, tt.valid, err)
+		}
+
+		if wait != tt.wait {
+			t.Errorf("expected wait %s for %+v, got %s", tt.wait, tt.canary, wait)
+		}
+	}
+}
+
+func TestCanaryInvalidation(t *testing.T) {
+	tests := []struct {
+		name          string
+		canaryPaths   []string
+		failing       string
+		checked       []string
+		invalidations [][]string
+		status        string
+		progress      task.Progress
+	}{
+		{
+			name:          "passed",
+			canaryPaths:   []string{"/index.html", "/assets/*"},
+			checked:       []string{"/index.html"},
+			invalidations: [][]string{{"/index.html", "/assets/*"}, {"/*"}},
+			status:        task.StatusSucceeded,
+			progress:      task.Progress{Total: 2, Succeeded: 2},
+		},
+		{
+			name:          "failed",
+			canaryPaths:   []string{"/index.html", "/about/"},
+			failing:       "/about/",
+			checked:       []string{"/index.html", "/about/"},
+			invalidations: [][]string{{"/index.html", "/about/"}},
+			status:        task.StatusFailed,
+			progress:      task.Progress{Total: 2, Failed: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		cf := &mockCanaryCloudFront{}
+		services := func(ctx context.Context) (cfapi.CloudFront, error) {
+			return cfapi.CloudFront{Service: cf}, nil
+		}
+
+		checked := []string{}
+		check := func(ctx context.Context, website, path string) error {
+			checked = append(checked, path)
+			if path == tt.failing {
+				return errors.New("responded with 404 Not Found")
+			}
+			return nil
+		}
+
+		manager := task.NewManager(context.Background())
+		started, err := manager.Start(invalidateTaskKind, "012345678910", "www.example.com", func(ctx context.Context, rep *task.Reporter) error {
+			return canaryInvalidation(ctx, rep, services, check, "www.example.com", "E123", []string{"/*"}, tt.canaryPaths, time.Millisecond)
+		})
+		if err != nil {
+			t.Fatalf("%s: expected nil error, got %s", tt.name, err)
+		}
+
+		var finished *task.Task
+		for i := 0; i < 100; i++ {
+			if finished, _ = manager.Get(started.ID); finished.Status != task.StatusRunning {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		if finished.Status != tt.status {
+			t.Errorf("%s: expected %s task, got %+v", tt.name, tt.status, finished)
+		}
+
+		if !reflect.DeepEqual(checked, tt.checked) {
+			t.Errorf("%s: expected checked paths %v, got %v", tt.name, tt.checked, checked)
+		}
+
+		if !reflect.DeepEqual(cf.invalidations, tt.invalidations) {
+			t.Errorf("%s: expected invalidations %v, got %v", tt.name, tt.invalidations, cf.invalidations)
+		}
+
+		if finished.Progress != tt.progress {
+			t.Errorf("%s: expected progress %+v, got %+v", tt.name, tt.progress, finished.Progress)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+const (
+	// maxWebsiteContentBytes is the maximum total size of the content a website can be seeded with
+	maxWebsiteContentBytes = 5 << 20
+
+	// websiteContentEncodingBase64 is the encoding for binary website content
+	websiteContentEncodingBase64 = "base64"
+	// websiteContentEncodingIdentity is the content encoding of content that isn't compressed
+	websiteContentEncodingIdentity = "identity"
+
+	// defaultIndexKey is the key of the default index page, it's cleaned up when the website is deleted if it's
+	// still tagged with spinupObjectTag
+	defaultIndexKey      = "index.html"
+	spinupObjectTagKey   = "yale:spinup"
+	spinupObjectTagValue = "true"
+	spinupObjectTag      = spinupObjectTagKey + "=" + spinupObjectTagValue
+)
+
+// websiteContentEncodings are the content encodings of pre-compressed website content, keyed by the extension of the
+// compressed files
+var websiteContentEncodings = map[string]string{
+	".gz": "gzip",
+	".br": "br",
+}
+
+// websiteStorageClasses are the storage classes website content can be created with.  The archive classes that
+// need a restore before objects can be read aren't allowed since the content is served by cloudfront.
+var websiteStorageClasses = []string{
+	s3.StorageClassStandard,
+	s3.StorageClassStandardIa,
+	s3.StorageClassOnezoneIa,
+	s3.StorageClassGlacierIr,
+}
+
+// websiteContent is an object to seed a new website with
+type websiteContent struct {
+	// Key is the object key, ie. index.html or images/logo.png
+	Key string
+	// Body is the content of the object, base64 encoded if Encoding is base64
+	Body string
+	// Encoding is empty for text content or base64 for binary content
+	Encoding string `json:",omitempty"`
+	// ContentType defaults to the type for the key's extension, or the type sniffed from the body
+	ContentType string `json:",omitempty"`
+	// ContentEncoding is gzip or br for pre-compressed content, it defaults to the encoding for a .gz or .br key.
+	// Pass identity for a .gz or .br file that should be downloaded as is.
+	ContentEncoding string `json:",omitempty"`
+	// CacheControl overrides the Cache-Control header from the account's cache control rules
+	CacheControl string `json:",omitempty"`
+	// StorageClass overrides the website's storage class for the object
+	StorageClass string `json:",omitempty"`
+}
+
+// websiteObject is an object created in a new website bucket
+type websiteObject struct {
+	Key             string
+	ContentType     string
+	ContentEncoding string `json:",omitempty"`
+	CacheControl    string `json:",omitempty"`
+	StorageClass    string
+}
+
+// decode returns the decoded content body
+func (c *websiteContent) decode() ([]byte, error) {
+	if c.Encoding == websiteContentEncodingBase64 {
+		return base64.StdEncoding.DecodeString(c.Body)
+	}
+	return []byte(c.Body), nil
+}
+
+// contentEncoding returns the content encoding of pre-compressed content, determined from the key's extension if it
+// isn't set
+func (c *websiteContent) contentEncoding() string {
+	switch c.ContentEncoding {
+	case "":
+		return websiteContentEncodings[path.Ext(c.Key)]
+	case websiteContentEncodingIdentity:
+		return ""
+	}
+	return c.ContentEncoding
+}
+
+// contentKey returns the key the content type and cache control are determined from, the key without the extension
+// of pre-compressed content, ie. app.js for app.js.gz
+func (c *websiteContent) contentKey() string {
+	if ext := path.Ext(c.Key); websiteContentEncodings[ext] != "" && c.ContentEncoding != websiteContentEncodingIdentity {
+		return strings.TrimSuffix(c.Key, ext)
+	}
+	return c.Key
+}
+
+// contentType returns the content type of the content.  If it isn't set, it's determined from the key's extension or
+// sniffed from the body, and text types get the utf-8 charset.
+func (c *websiteContent) contentType(body []byte) string {
+	if c.ContentType != "" {
+		return c.ContentType
+	}
+
+	t := mime.TypeByExtension(path.Ext(c.contentKey()))
+	if t == "" {
+		// a compressed body can't be sniffed
+		if len(body) == 0 || c.contentEncoding() != "" {
+			return "application/octet-stream"
+		}
+		t = http.DetectContentType(body)
+	}
+
+	if strings.HasPrefix(t, "text/") && !strings.Contains(t, "charset=") {
+		t += "; charset=utf-8"
+	}
+
+	return t
+}
+
+// cacheControl returns the Cache-Control header of the content from the first of the rules that matches its key if
+// it isn't set
+func (c *websiteContent) cacheControl(rules []*common.CacheControlRule) string {
+	if c.CacheControl != "" {
+		return c.CacheControl
+	}
+	return cacheControl(rules, c.contentKey())
+}
+
+// cacheControl returns the Cache-Control header for a key from the first of the rules that matches it, it's empty if
+// none of them match
+func cacheControl(rules []*common.CacheControlRule, key string) string {
+	for _, r := range rules {
+		if r.Matches(key) {
+			return r.Value
+		}
+	}
+	return ""
+}
+
+// storageClass returns the storage class of the content, falling back to the passed default if it isn't set
+func (c *websiteContent) storageClass(def string) string {
+	if c.StorageClass != "" {
+		return c.StorageClass
+	}
+	return def
+}
+
+// newWebsiteObject returns the details of an object created from the passed input.  S3 reports objects created
+// without a storage class as STANDARD.
+func newWebsiteObject(input *s3.PutObjectInput) *websiteObject {
+	class := aws.StringValue(input.StorageClass)
+	if class == "" {
+		class = s3.StorageClassStandard
+	}
+
+	return &websiteObject{
+		Key:             aws.StringValue(input.Key),
+		ContentType:     aws.StringValue(input.ContentType),
+		ContentEncoding: aws.StringValue(input.ContentEncoding),
+		CacheControl:    aws.StringValue(input.CacheControl),
+		StorageClass:    class,
+	}
+}
+
+// optionalString returns nil for an empty string so the parameter is left off the request
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return aws.String(s)
+}
+
+// indexTagging returns the tagging for a default index page, the spinup object tag followed by the passed tagging
+func indexTagging(tagging string) string {
+	if tagging == "" {
+		return spinupObjectTag
+	}
+	return spinupObjectTag + "&" + tagging
+}
+
+// websiteSeedObjects returns the objects to create in a new website bucket.  The passed content is created as is and
+// the default index page is added, tagged so it can be cleaned up on delete, unless it's disabled or an index page
+// is passed in the content.  Objects are created with the request's storage class unless the content overrides it and
+// they're tagged with the default object tags.  The Cache-Control header of each object comes from the first of the
+// cache control rules that matches its key.  The content should be validated before calling.
+func websiteSeedObjects(bucket string, req *websiteCreateRequest, rules []*common.CacheControlRule) ([]*s3.PutObjectInput, error) {
+	objects := []*s3.PutObjectInput{}
+	tagging := objectTagging(req.ObjectTags)
+	hasIndex := false
+	for _, c := range req.Content {
+		body, err := c.decode()
+		if err != nil {
+			return nil, err
+		}
+
+		if c.Key == defaultIndexKey {
+			hasIndex = true
+		}
+
+		objects = append(objects, &s3.PutObjectInput{
+			Bucket:          aws.String(bucket),
+			Body:            bytes.NewReader(body),
+			CacheControl:    optionalString(c.cacheControl(rules)),
+			ContentEncoding: optionalString(c.contentEncoding()),
+			ContentType:     aws.String(c.contentType(body)),
+			Key:             aws.String(c.Key),
+			StorageClass:    optionalString(c.storageClass(req.StorageClass)),
+			Tagging:         optionalString(tagging),
+		})
+	}
+
+	// the default index page is created unless it's explicitly disabled
+	if !hasIndex && (req.DefaultIndex == nil || aws.BoolValue(req.DefaultIndex)) {
+		objects = append(objects, &s3.PutObjectInput{
+			Bucket:       aws.String(bucket),
+			Body:         bytes.NewReader([]byte("Hello, " + bucket + "!")),
+			CacheControl: optionalString(cacheControl(rules, defaultIndexKey)),
+			ContentType:  aws.String("text/html; charset=utf-8"),
+			Key:          aws.String(defaultIndexKey),
+			StorageClass: optionalString(req.StorageClass),
+			Tagging:      aws.String(indexTagging(tagging)),
+		})
+	}
+
+	return objects, nil
+}
@@ -0,0 +1,270 @@
+package api
+
+import (
+	"encoding/base64"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func TestWebsiteContentType(t *testing.T) {
+	tests := map[websiteContent]string{
+		{Key: "index.html"}:                                "text/html; charset=utf-8",
+		{Key: "css/site.css"}:                              "text/css; charset=utf-8",
+		{Key: "logo.png"}:                                  "image/png",
+		{Key: "LICENSE"}:                                   "application/octet-stream",
+		{Key: "data.bin", ContentType: "foo/bar"}:          "foo/bar",
+		{Key: "js/app.js.gz"}:                              "text/javascript; charset=utf-8",
+		{Key: "site.css.br"}:                               "text/css; charset=utf-8",
+		{Key: "archive.gz"}:                                "application/octet-stream",
+		{Key: "data.json.gz", ContentEncoding: "identity"}: "application/gzip",
+	}
+
+	for c, expected := range tests {
+		if out := c.contentType(nil); out != expected {
+			t.Errorf("expected content type %s for %s, got %s", expected, c.Key, out)
+		}
+	}
+
+	// the content type is sniffed from the body of keys without a known extension
+	sniffed := map[string]string{
+		"<!DOCTYPE html><h1>hi</h1>":    "text/html; charset=utf-8",
+		"just some text":                "text/plain; charset=utf-8",
+		"\x89PNG\r\n\x1a\n\x00\x00\x00": "image/png",
+	}
+
+	for body, expected := range sniffed {
+		c := websiteContent{Key: "LICENSE"}
+		if out := c.contentType([]byte(body)); out != expected {
+			t.Errorf("expected sniffed content type %s for %q, got %s", expected, body, out)
+		}
+	}
+}
+
+func TestWebsiteContentEncoding(t *testing.T) {
+	tests := map[websiteContent]string{
+		{Key: "index.html"}:                                "",
+		{Key: "js/app.js.gz"}:                              "gzip",
+		{Key: "site.css.br"}:                               "br",
+		{Key: "app.js", ContentEncoding: "gzip"}:           "gzip",
+		{Key: "app.js.gz", ContentEncoding: "br"}:          "br",
+		{Key: "data.json.gz", ContentEncoding: "identity"}: "",
+	}
+
+	for c, expected := range tests {
+		if out := c.contentEncoding(); out != expected {
+			t.Errorf("expected content encoding %q for %s, got %q", expected, c.Key, out)
+		}
+	}
+}
+
+func TestWebsiteSeedObjectsHeaders(t *testing.T) {
+	rules := []*common.CacheControlRule{
+		{Prefix: "assets/", Value: "public, max-age=31536000, immutable"},
+		{Extensions: []string{".html"}, Value: "no-cache"},
+	}
+
+	req := websiteCreateRequest{
+		Content: []*websiteContent{
+			{Key: "error.html", Body: "<h1>Oops</h1>"},
+			{Key: "assets/app.js.gz", Body: "H4sI", Encoding: "base64"},
+			{Key: "assets/logo.png", Body: "", CacheControl: "no-store"},
+			{Key: "robots.txt", Body: "User-agent: *"},
+		},
+	}
+
+	objects, err := websiteSeedObjects("www.example.com", &req, rules)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := map[string]websiteObject{
+		"error.html":       {ContentType: "text/html; charset=utf-8", CacheControl: "no-cache"},
+		"assets/app.js.gz": {ContentType: "text/javascript; charset=utf-8", ContentEncoding: "gzip", CacheControl: "public, max-age=31536000, immutable"},
+		"assets/logo.png":  {ContentType: "image/png", CacheControl: "no-store"},
+		"robots.txt":       {ContentType: "text/plain; charset=utf-8"},
+		"index.html":       {ContentType: "text/html; charset=utf-8", CacheControl: "no-cache"},
+	}
+
+	for _, o := range objects {
+		key := aws.StringValue(o.Key)
+		e := expected[key]
+		e.Key, e.StorageClass = key, "STANDARD"
+		if out := newWebsiteObject(o); *out != e {
+			t.Errorf("expected %+v for %s, got %+v", e, key, *out)
+		}
+	}
+}
+
+func TestWebsiteSeedObjects(t *testing.T) {
+	png := []byte{0x89, 0x50, 0x4e, 0x47}
+
+	tests := []struct {
+		name     string
+		req      websiteCreateRequest
+		keys     []string
+		tagged   string
+		contents map[string]string
+	}{
+		{
+			name:     "default",
+			req:      websiteCreateRequest{},
+			keys:     []string{"index.html"},
+			tagged:   "index.html",
+			contents: map[string]string{"index.html": "Hello, www.example.com!"},
+		},
+		{
+			name: "default index disabled",
+			req:  websiteCreateRequest{DefaultIndex: aws.Bool(false)},
+			keys: []string{},
+		},
+		{
+			name: "custom index",
+			req: websiteCreateRequest{
+				Content: []*websiteContent{
+					{Key: "index.html", Body: "<h1>Welcome</h1>"},
+					{Key: "error.html", Body: "<h1>Oops</h1>"},
+					{Key: "logo.png", Body: base64.StdEncoding.EncodeToString(png), Encoding: "base64"},
+				},
+			},
+			keys:     []string{"index.html", "error.html", "logo.png"},
+			contents: map[string]string{"index.html": "<h1>Welcome</h1>", "logo.png": string(png)},
+		},
+		{
+			name: "content without index",
+			req: websiteCreateRequest{
+				Content: []*websiteContent{{Key: "error.html", Body: "<h1>Oops</h1>"}},
+			},
+			keys:   []string{"error.html", "index.html"},
+			tagged: "index.html",
+		},
+	}
+
+	for _, test := range tests {
+		objects, err := websiteSeedObjects("www.example.com", &test.req, nil)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+
+		keys := []string{}
+		for _, o := range objects {
+			key := aws.StringValue(o.Key)
+			keys = append(keys, key)
+
+			if aws.StringValue(o.Bucket) != "www.example.com" {
+				t.Errorf("%s: unexpected bucket %s for %s", test.name, aws.StringValue(o.Bucket), key)
+			}
+
+			if tagged := aws.StringValue(o.Tagging) == "yale:spinup=true"; tagged != (key == test.tagged) {
+				t.Errorf("%s: unexpected tagging %s for %s", test.name, aws.StringValue(o.Tagging), key)
+			}
+
+			if expected, ok := test.contents[key]; ok {
+				body, _ := io.ReadAll(o.Body)
+				if string(body) != expected {
+					t.Errorf("%s: expected body %q for %s, got %q", test.name, expected, key, string(body))
+				}
+			}
+		}
+
+		if strings.Join(keys, ",") != strings.Join(test.keys, ",") {
+			t.Errorf("%s: expected keys %v, got %v", test.name, test.keys, keys)
+		}
+	}
+}
+
+func TestWebsiteSeedObjectsStorageClass(t *testing.T) {
+	req := websiteCreateRequest{
+		StorageClass: "STANDARD_IA",
+		Content: []*websiteContent{
+			{Key: "error.html", Body: "<h1>Oops</h1>"},
+			{Key: "archive.zip", Body: "", StorageClass: "GLACIER_IR"},
+		},
+	}
+
+	objects, err := websiteSeedObjects("www.example.com", &req, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := map[string]string{"error.html": "STANDARD_IA", "archive.zip": "GLACIER_IR", "index.html": "STANDARD_IA"}
+	for _, o := range objects {
+		key := aws.StringValue(o.Key)
+		if class := aws.StringValue(o.StorageClass); class != expected[key] {
+			t.Errorf("expected storage class %s for %s, got %s", expected[key], key, class)
+		}
+
+		if class := newWebsiteObject(o).StorageClass; class != expected[key] {
+			t.Errorf("expected output storage class %s for %s, got %s", expected[key], key, class)
+		}
+	}
+
+	// objects are created without a storage class by default and reported as STANDARD
+	objects, err = websiteSeedObjects("www.example.com", &websiteCreateRequest{}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if objects[0].StorageClass != nil {
+		t.Errorf("expected nil storage class, got %s", aws.StringValue(objects[0].StorageClass))
+	}
+
+	if class := newWebsiteObject(objects[0]).StorageClass; class != "STANDARD" {
+		t.Errorf("expected output storage class STANDARD, got %s", class)
+	}
+}
+
+func TestWebsiteSeedObjectsTagging(t *testing.T) {
+	req := websiteCreateRequest{
+		ObjectTags: []*s3.Tag{{Key: aws.String("project"), Value: aws.String("X")}},
+		Content:    []*websiteContent{{Key: "error.html", Body: "<h1>Oops</h1>"}},
+	}
+
+	objects, err := websiteSeedObjects("www.example.com", &req, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := map[string]string{"error.html": "project=X", "index.html": "yale:spinup=true&project=X"}
+	for _, o := range objects {
+		key := aws.StringValue(o.Key)
+		if tagging := aws.StringValue(o.Tagging); tagging != expected[key] {
+			t.Errorf("expected tagging %s for %s, got %s", expected[key], key, tagging)
+		}
+	}
+}
+
+func TestValidateWebsiteContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []*websiteContent
+		errors  int
+	}{
+		{"empty", nil, 0},
+		{"valid", []*websiteContent{{Key: "index.html", Body: "hi"}, {Key: "a.bin", Body: "aGk=", Encoding: "base64"}}, 0},
+		{"null", []*websiteContent{nil}, 1},
+		{"missing key", []*websiteContent{{Body: "hi"}}, 1},
+		{"leading slash", []*websiteContent{{Key: "/index.html"}}, 1},
+		{"duplicate key", []*websiteContent{{Key: "index.html"}, {Key: "index.html"}}, 1},
+		{"bad encoding", []*websiteContent{{Key: "index.html", Encoding: "gzip"}}, 1},
+		{"bad base64", []*websiteContent{{Key: "a.bin", Body: "not base64!", Encoding: "base64"}}, 1},
+		{"too big", []*websiteContent{{Key: "big.html", Body: strings.Repeat("a", maxWebsiteContentBytes+1)}}, 1},
+		{"storage class", []*websiteContent{{Key: "index.html", StorageClass: "ONEZONE_IA"}}, 0},
+		{"bad storage class", []*websiteContent{{Key: "index.html", StorageClass: "GLACIER"}}, 1},
+		{"content encoding", []*websiteContent{{Key: "app.js", ContentEncoding: "br"}}, 0},
+		{"bad content encoding", []*websiteContent{{Key: "app.js", ContentEncoding: "zstd"}}, 1},
+	}
+
+	for _, test := range tests {
+		f := fieldErrors{}
+		f.websiteContent("Content", test.content)
+		if len(f) != test.errors {
+			t.Errorf("%s: expected %d errors, got %d: %v", test.name, test.errors, len(f), f)
+		}
+	}
+}
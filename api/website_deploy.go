@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	cfapi "github.com/YaleSpinup/s3-api/cloudfront"
+	"github.com/YaleSpinup/s3-api/lock"
+	"github.com/YaleSpinup/s3-api/task"
+	"github.com/YaleSpinup/s3-api/webhook"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// deployTaskKind is the kind of the task that waits for the distribution of a new website to deploy
+	deployTaskKind = "deploy"
+	// deployCheckInterval is how often the status of a deploying distribution is checked
+	deployCheckInterval = 30 * time.Second
+	// deploySessionWindow is how long a distribution is waited on with one session, the wait can outlive the
+	// credentials of an assumed role so a fresh session is used for each window.  A cached session can have as little
+	// as sessionRefreshBefore left, so the window is shorter than that.
+	deploySessionWindow = sessionRefreshBefore - time.Minute
+	// deployTimeout is how long a distribution is waited on in the background before giving up
+	deployTimeout = time.Hour
+	// deployWaitTimeout is how long a request that asked to wait waits for its distribution, the wait continues in
+	// the background after that
+	deployWaitTimeout = 10 * time.Minute
+)
+
+// waitParam parses the wait query parameter of a request, it's false if it isn't set
+func waitParam(r *http.Request) (bool, error) {
+	wait := r.URL.Query().Get("wait")
+	if wait == "" {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(wait)
+	if err != nil {
+		return false, apierror.New(apierror.ErrBadRequest, "invalid wait parameter", err)
+	}
+
+	return b, nil
+}
+
+// extendWriteDeadline extends the write deadline of a response past the server's write timeout, so a handler can
+// respond after a long wait.  It fails if the response writer doesn't support deadlines.
+func extendWriteDeadline(w http.ResponseWriter, d time.Duration) error {
+	return http.NewResponseController(w).SetWriteDeadline(time.Now().Add(d))
+}
+
+// waitForDistribution waits for a distribution to be deployed, up to the timeout
+func (s *server) waitForDistribution(ctx context.Context, account, id string, timeout time.Duration) (*cloudfront.Distribution, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		session, err := s.sessionForAccount(ctx, account, "cloudfront:GetDistribution")
+		if err != nil {
+			return nil, err
+		}
+		cloudFrontService := cfapi.NewSession(session.Session, s.account, s.mapAccountNumber(account))
+
+		wctx, wcancel := context.WithTimeout(ctx, deploySessionWindow)
+		distribution, err := cloudFrontService.WaitUntilDeployed(wctx, id, deployCheckInterval)
+		wcancel()
+
+		// only the end of the window is waited out with a new session, anything else ends the wait
+		if err != nil && ctx.Err() == nil && wctx.Err() == context.DeadlineExceeded {
+			continue
+		}
+
+		return distribution, err
+	}
+}
+
+// distributionDeployed sends the website.deployed event for a website once its distribution is deployed
+func (s *server) distributionDeployed(account, website string, distribution *cloudfront.Distribution) {
+	s.notify(webhook.EventWebsiteDeployed, account, website, map[string]string{
+		"DistributionId": aws.StringValue(distribution.Id),
+		"DomainName":     aws.StringValue(distribution.DomainName),
+	})
+}
+
+// watchDistributionDeploy starts a task that waits for the distribution of a new website to deploy and sends the
+// website.deployed event when it is, so the client can tell when the website is being served
+func (s *server) watchDistributionDeploy(account, website, id string) (*task.Task, error) {
+	return s.tasks.Start(deployTaskKind, s.mapAccountNumber(account), website, func(ctx context.Context, rep *task.Reporter) error {
+		rep.Found(1)
+
+		distribution, err := s.waitForDistribution(ctx, account, id, deployTimeout)
+		if err != nil {
+			rep.Failed("distribution %s was not deployed: %s", id, err)
+			return err
+		}
+
+		rep.Succeeded()
+		s.distributionDeployed(account, website, distribution)
+
+		return nil
+	})
+}
+
+// deployWebsiteDistribution follows the deployment of a new website's distribution.  If the client asked to wait, the
+// distribution is waited on for up to the deploy wait timeout before responding and the deployed distribution is
+// returned.  The server's write timeout is shorter than that, so the write deadline of the response is extended for
+// the wait.  Otherwise, or if the wait is cut short, a task is started that waits for it in the background and the
+// task is returned.
+func (s *server) deployWebsiteDistribution(ctx context.Context, w http.ResponseWriter, wait bool, lease *lock.Lease, account, website string, distribution *cloudfront.Distribution) (*cloudfront.Distribution, *task.Task) {
+	id := aws.StringValue(distribution.Id)
+
+	if wait {
+		// the website is created, it doesn't need the lock while its distribution deploys
+		lease.Release()
+
+		if err := extendWriteDeadline(w, deployWaitTimeout+writeTimeout); err != nil {
+			log.Warnf("can't extend the write deadline to wait for distribution %s of website %s, continuing in the background: %s", id, website, err)
+		} else {
+			deployed, err := s.waitForDistribution(ctx, account, id, deployWaitTimeout)
+			if err == nil {
+				s.distributionDeployed(account, website, deployed)
+				return deployed, nil
+			}
+
+			log.Warnf("stopped waiting for distribution %s of website %s to deploy, continuing in the background: %s", id, website, err)
+		}
+	}
+
+	t, err := s.watchDistributionDeploy(account, website, id)
+	if err != nil {
+		log.Errorf("failed to start the deploy task for distribution %s of website %s: %s", id, website, err)
+		return distribution, nil
+	}
+
+	return distribution, t
+}
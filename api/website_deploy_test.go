@@ -0,0 +1,68 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWaitParam(t *testing.T) {
+	tests := []struct {
+		query string
+		wait  bool
+		valid bool
+	}{
+		{"", false, true},
+		{"?wait=true", true, true},
+		{"?wait=1", true, true},
+		{"?wait=false", false, true},
+		{"?wait=forever", false, false},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("POST", "/v1/s3/someaccount/websites"+test.query, nil)
+		wait, err := waitParam(r)
+		if (err == nil) != test.valid || wait != test.wait {
+			t.Errorf("%q: expected %t (valid %t), got %t, %v", test.query, test.wait, test.valid, wait, err)
+		}
+	}
+}
+
+func TestExtendWriteDeadline(t *testing.T) {
+	// the handler responds after the server's write timeout, it's only delivered if the deadline is extended
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w = LogWriter{&statusWriter{ResponseWriter: w}}
+		if err := extendWriteDeadline(w, time.Second); err != nil {
+			t.Errorf("expected nil error extending the write deadline, got %s", err)
+		}
+
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("deployed"))
+	}))
+	srv.Config.WriteTimeout = 20 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected a response, got %s", err)
+	}
+	defer res.Body.Close()
+
+	if body, _ := io.ReadAll(res.Body); string(body) != "deployed" {
+		t.Errorf("expected the response body, got %q", body)
+	}
+
+	if err := extendWriteDeadline(httptest.NewRecorder(), time.Second); err == nil {
+		t.Error("expected error for a response writer without deadlines, got nil")
+	}
+}
+
+func TestDeploySessionWindow(t *testing.T) {
+	if deploySessionWindow >= sessionRefreshBefore {
+		t.Errorf("expected the deploy session window %s to be shorter than the life left in a cached session %s", deploySessionWindow, sessionRefreshBefore)
+	}
+}
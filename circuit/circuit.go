@@ -0,0 +1,201 @@
+package circuit
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultThreshold   = 0.5
+	defaultMinRequests = 20
+	defaultWindow      = 1 * time.Minute
+	defaultCooldown    = 30 * time.Second
+)
+
+// Config is the configuration of the circuit breakers, the zero values are replaced with the defaults
+type Config struct {
+	// Threshold is the rate of failed calls, between 0 and 1, that opens a breaker (default 0.5)
+	Threshold float64
+	// MinRequests is the number of calls in the window before the failure rate is checked (default 20)
+	MinRequests int
+	// Window is how long the calls are counted for before the counts are reset (default 1m)
+	Window time.Duration
+	// Cooldown is how long a breaker stays open before a call is let through to test the service (default 30s)
+	Cooldown time.Duration
+}
+
+// OpenError is returned instead of calling a service while its breaker is open
+type OpenError struct {
+	Service string
+	// RetryAfter is how long until the breaker lets a call through again
+	RetryAfter time.Duration
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("%s is unavailable, too many recent calls failed (retry after %s)", e.Service, e.RetryAfter.Round(time.Second))
+}
+
+type state int
+
+const (
+	closed state = iota
+	open
+	halfOpen
+)
+
+// Breaker stops calling a service once the rate of failed calls to it spikes.  While the breaker is open calls fail
+// fast, after the cooldown a single call is let through and the breaker closes again if it succeeds.
+type Breaker struct {
+	Service string
+	config  Config
+
+	mu          sync.Mutex
+	state       state
+	requests    int
+	failures    int
+	windowStart time.Time
+	openedAt    time.Time
+	probing     bool
+
+	now func() time.Time
+}
+
+// NewBreaker creates a new closed circuit breaker for a service
+func NewBreaker(service string, config Config) *Breaker {
+	if config.Threshold <= 0 || config.Threshold > 1 {
+		config.Threshold = defaultThreshold
+	}
+
+	if config.MinRequests <= 0 {
+		config.MinRequests = defaultMinRequests
+	}
+
+	if config.Window <= 0 {
+		config.Window = defaultWindow
+	}
+
+	if config.Cooldown <= 0 {
+		config.Cooldown = defaultCooldown
+	}
+
+	return &Breaker{
+		Service: service,
+		config:  config,
+		now:     time.Now,
+	}
+}
+
+// Allow returns an OpenError if a call to the service shouldn't be made.  Every allowed call must be followed by a
+// call to Done with its result.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	switch b.state {
+	case open:
+		if wait := b.openedAt.Add(b.config.Cooldown).Sub(now); wait > 0 {
+			return &OpenError{Service: b.Service, RetryAfter: wait}
+		}
+
+		log.Infof("circuit breaker for %s is half open, testing the service", b.Service)
+		b.state = halfOpen
+		b.probing = true
+		return nil
+	case halfOpen:
+		// only one call at a time tests the service
+		if b.probing {
+			return &OpenError{Service: b.Service, RetryAfter: b.config.Cooldown}
+		}
+
+		b.probing = true
+		return nil
+	}
+
+	if now.Sub(b.windowStart) > b.config.Window {
+		b.windowStart = now
+		b.requests = 0
+		b.failures = 0
+	}
+
+	return nil
+}
+
+// Done records the result of an allowed call
+func (b *Breaker) Done(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if b.state == halfOpen {
+		b.probing = false
+		if failed {
+			log.Warnf("circuit breaker for %s reopened, the test call failed", b.Service)
+			b.state = open
+			b.openedAt = now
+			return
+		}
+
+		log.Infof("circuit breaker for %s closed", b.Service)
+		b.state = closed
+		b.windowStart = now
+		b.requests = 0
+		b.failures = 0
+		return
+	}
+
+	if b.state != closed {
+		return
+	}
+
+	b.requests++
+	if failed {
+		b.failures++
+	}
+
+	if b.requests >= b.config.MinRequests && float64(b.failures)/float64(b.requests) >= b.config.Threshold {
+		log.Warnf("circuit breaker for %s opened, %d of %d calls failed", b.Service, b.failures, b.requests)
+		b.state = open
+		b.openedAt = now
+	}
+}
+
+// Open returns true if the breaker isn't letting calls through
+func (b *Breaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state != closed
+}
+
+// Breakers are the circuit breakers for the services, created the first time a service is called
+type Breakers struct {
+	config   Config
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+}
+
+// NewBreakers creates the circuit breakers for services with the same configuration
+func NewBreakers(config Config) *Breakers {
+	return &Breakers{
+		config:   config,
+		breakers: map[string]*Breaker{},
+	}
+}
+
+// Get returns the breaker for a service
+func (b *Breakers) Get(service string) *Breaker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	breaker, ok := b.breakers[service]
+	if !ok {
+		breaker = NewBreaker(service, b.config)
+		b.breakers[service] = breaker
+	}
+
+	return breaker
+}
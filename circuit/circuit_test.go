@@ -0,0 +1,110 @@
+package circuit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	b := NewBreaker("cloudfront", Config{Threshold: 0.5, MinRequests: 4, Window: time.Minute, Cooldown: 30 * time.Second})
+	b.now = func() time.Time { return now }
+
+	call := func(failed bool) error {
+		if err := b.Allow(); err != nil {
+			return err
+		}
+		b.Done(failed)
+		return nil
+	}
+
+	// failures under the minimum number of requests don't open the breaker
+	for i := 0; i < 3; i++ {
+		if err := call(true); err != nil {
+			t.Fatalf("expected call %d to be allowed, got %s", i, err)
+		}
+	}
+
+	if b.Open() {
+		t.Fatal("expected breaker to be closed before the minimum requests")
+	}
+
+	// the window resets the counts
+	now = now.Add(2 * time.Minute)
+	for _, failed := range []bool{false, false, true} {
+		if err := call(failed); err != nil {
+			t.Fatalf("expected call to be allowed, got %s", err)
+		}
+	}
+
+	if b.Open() {
+		t.Fatal("expected breaker to be closed after the window reset")
+	}
+
+	// 2 of 4 failed calls opens it
+	if err := call(true); err != nil {
+		t.Fatalf("expected call to be allowed, got %s", err)
+	}
+
+	if !b.Open() {
+		t.Fatal("expected breaker to be open")
+	}
+
+	now = now.Add(10 * time.Second)
+	err := b.Allow()
+	open, ok := err.(*OpenError)
+	if !ok {
+		t.Fatalf("expected open error, got %v", err)
+	}
+
+	if open.Service != "cloudfront" || open.RetryAfter != 20*time.Second {
+		t.Errorf("expected cloudfront to be retried after 20s, got %+v", open)
+	}
+
+	// after the cooldown one call tests the service, the others still fail fast
+	now = now.Add(20 * time.Second)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("expected the test call to be allowed, got %s", err)
+	}
+
+	if err := b.Allow(); err == nil {
+		t.Fatal("expected a second call to fail while the service is tested")
+	}
+
+	// a failed test call reopens it
+	b.Done(true)
+	if err := b.Allow(); err == nil {
+		t.Fatal("expected the breaker to reopen after a failed test call")
+	}
+
+	// a successful test call closes it
+	now = now.Add(30 * time.Second)
+	if err := call(false); err != nil {
+		t.Fatalf("expected the test call to be allowed, got %s", err)
+	}
+
+	if b.Open() {
+		t.Error("expected the breaker to close after a successful test call")
+	}
+}
+
+func TestNewBreakerDefaults(t *testing.T) {
+	b := NewBreaker("iam", Config{Threshold: 2})
+
+	expected := Config{Threshold: defaultThreshold, MinRequests: defaultMinRequests, Window: defaultWindow, Cooldown: defaultCooldown}
+	if b.config != expected {
+		t.Errorf("expected config %+v, got %+v", expected, b.config)
+	}
+}
+
+func TestBreakers(t *testing.T) {
+	b := NewBreakers(Config{})
+
+	if b.Get("iam") != b.Get("iam") {
+		t.Error("expected the same breaker for a service")
+	}
+
+	if b.Get("iam") == b.Get("cloudfront") {
+		t.Error("expected different breakers for different services")
+	}
+}
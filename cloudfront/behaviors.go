@@ -0,0 +1,301 @@
+package cloudfront
+
+import (
+	"context"
+	"strings"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// ExternalOriginPrefix is the prefix of the ids of the origins added for the cache behaviors with an external
+	// origin, the origins with the prefix are managed with the behaviors
+	ExternalOriginPrefix = "external-"
+
+	// DefaultBehaviorMinTTL, DefaultBehaviorDefaultTTL and DefaultBehaviorMaxTTL are the ttls of a cache behavior if
+	// they aren't set, they match the default cache behavior of a website
+	DefaultBehaviorMinTTL     = 0
+	DefaultBehaviorDefaultTTL = 3600
+	DefaultBehaviorMaxTTL     = 31536000
+)
+
+// CacheBehavior is an additional cache behavior for a website distribution, requests matching the path pattern are
+// sent to the website bucket or an external origin with the behavior's ttls instead of the default cache behavior
+type CacheBehavior struct {
+	// PathPattern is the pattern of the requests the behavior applies to, ie. /api/*
+	PathPattern string
+	// Origin is the domain name of an external https origin, the website bucket is the origin if it's empty
+	Origin string `json:",omitempty"`
+	// MinTTL, DefaultTTL and MaxTTL are the cache ttls in seconds (default 0, 3600 and 31536000)
+	MinTTL     *int64 `json:",omitempty"`
+	DefaultTTL *int64 `json:",omitempty"`
+	MaxTTL     *int64 `json:",omitempty"`
+	// AllowedMethods are the http methods that are forwarded to the origin (default GET and HEAD)
+	AllowedMethods []string `json:",omitempty"`
+	// ForwardQueryString forwards the query string to the origin and includes it in the cache key
+	ForwardQueryString bool `json:",omitempty"`
+	// ForwardCookies forwards all of the cookies to the origin and includes them in the cache key
+	ForwardCookies bool `json:",omitempty"`
+	// ForwardHeaders are the headers that are forwarded to the origin and included in the cache key, * forwards all
+	// of the headers
+	ForwardHeaders []string `json:",omitempty"`
+	// Signed requires the requests to be signed with the key group trusted by the website, ie. with a signed url or
+	// signed cookies
+	Signed bool `json:",omitempty"`
+	// KeyGroupID is the id of the trusted key group of a signed behavior, it's set by the api
+	KeyGroupID string `json:"-"`
+}
+
+// ttls returns the min, default and max ttls of the behavior
+func (b *CacheBehavior) ttls() (int64, int64, int64) {
+	min, def, max := int64(DefaultBehaviorMinTTL), int64(DefaultBehaviorDefaultTTL), int64(DefaultBehaviorMaxTTL)
+	if b.MinTTL != nil {
+		min = aws.Int64Value(b.MinTTL)
+	}
+	if b.DefaultTTL != nil {
+		def = aws.Int64Value(b.DefaultTTL)
+	}
+	if b.MaxTTL != nil {
+		max = aws.Int64Value(b.MaxTTL)
+	}
+	return min, def, max
+}
+
+// originID returns the id of the origin for the behavior, the website origin or the external origin
+func (b *CacheBehavior) originID(website string) string {
+	if b.Origin == "" {
+		return website
+	}
+	return ExternalOriginPrefix + strings.ToLower(b.Origin)
+}
+
+// cacheBehavior returns the cloudfront cache behavior for the behavior
+func (b *CacheBehavior) cacheBehavior(website string) *cloudfront.CacheBehavior {
+	min, def, max := b.ttls()
+
+	var keyGroupId string
+	if b.Signed {
+		keyGroupId = b.KeyGroupID
+	}
+
+	methods := []string{"GET", "HEAD"}
+	if len(b.AllowedMethods) > 0 {
+		methods = []string{}
+		for _, m := range b.AllowedMethods {
+			methods = append(methods, strings.ToUpper(m))
+		}
+	}
+
+	cookies := "none"
+	if b.ForwardCookies {
+		cookies = "all"
+	}
+
+	headers := &cloudfront.Headers{Quantity: aws.Int64(int64(len(b.ForwardHeaders)))}
+	if len(b.ForwardHeaders) > 0 {
+		headers.Items = aws.StringSlice(b.ForwardHeaders)
+	}
+
+	return &cloudfront.CacheBehavior{
+		PathPattern: aws.String(b.PathPattern),
+		AllowedMethods: &cloudfront.AllowedMethods{
+			Items:    aws.StringSlice(methods),
+			Quantity: aws.Int64(int64(len(methods))),
+			CachedMethods: &cloudfront.CachedMethods{
+				Items:    aws.StringSlice([]string{"GET", "HEAD"}),
+				Quantity: aws.Int64(2),
+			},
+		},
+		ForwardedValues: &cloudfront.ForwardedValues{
+			Cookies:     &cloudfront.CookiePreference{Forward: aws.String(cookies)},
+			Headers:     headers,
+			QueryString: aws.Bool(b.ForwardQueryString),
+		},
+		MinTTL:           aws.Int64(min),
+		DefaultTTL:       aws.Int64(def),
+		MaxTTL:           aws.Int64(max),
+		TargetOriginId:   aws.String(b.originID(website)),
+		TrustedKeyGroups: trustedKeyGroups(keyGroupId),
+		TrustedSigners: &cloudfront.TrustedSigners{
+			Enabled:  aws.Bool(false),
+			Quantity: aws.Int64(0),
+		},
+		ViewerProtocolPolicy: aws.String("redirect-to-https"),
+	}
+}
+
+// externalOrigin returns the cloudfront origin for an external https origin
+func externalOrigin(id, domain string) *cloudfront.Origin {
+	return &cloudfront.Origin{
+		DomainName: aws.String(strings.ToLower(domain)),
+		Id:         aws.String(id),
+		CustomOriginConfig: &cloudfront.CustomOriginConfig{
+			HTTPPort:             aws.Int64(80),
+			HTTPSPort:            aws.Int64(443),
+			OriginProtocolPolicy: aws.String("https-only"),
+			OriginSslProtocols: &cloudfront.OriginSslProtocols{
+				Items:    aws.StringSlice([]string{"TLSv1.2"}),
+				Quantity: aws.Int64(1),
+			},
+		},
+	}
+}
+
+// MergeCacheBehaviors replaces the additional cache behaviors in a website distribution config with the passed
+// behaviors, in order.  The origins are added for the external origins the behaviors use and the external origins
+// that aren't used anymore are removed.  The rest of the config, including the default cache behavior and the
+// website origin, is left as is.
+func MergeCacheBehaviors(config *cloudfront.DistributionConfig, website string, behaviors []*CacheBehavior) {
+	// the behaviors get the same response headers policy as the default cache behavior
+	var policyId *string
+	if config.DefaultCacheBehavior != nil {
+		policyId = config.DefaultCacheBehavior.ResponseHeadersPolicyId
+	}
+
+	items := []*cloudfront.CacheBehavior{}
+	used := map[string]string{}
+	for _, b := range behaviors {
+		cb := b.cacheBehavior(website)
+		cb.ResponseHeadersPolicyId = policyId
+		items = append(items, cb)
+		if b.Origin != "" {
+			used[b.originID(website)] = b.Origin
+		}
+	}
+
+	config.CacheBehaviors = &cloudfront.CacheBehaviors{Quantity: aws.Int64(int64(len(items)))}
+	if len(items) > 0 {
+		config.CacheBehaviors.Items = items
+	}
+
+	origins := []*cloudfront.Origin{}
+	if config.Origins != nil {
+		for _, o := range config.Origins.Items {
+			id := aws.StringValue(o.Id)
+			if !strings.HasPrefix(id, ExternalOriginPrefix) {
+				origins = append(origins, o)
+				continue
+			}
+
+			// keep the existing external origins that are still used
+			if _, ok := used[id]; ok {
+				origins = append(origins, o)
+				delete(used, id)
+			}
+		}
+	}
+
+	// add the new external origins in the order of the behaviors
+	for _, b := range behaviors {
+		id := b.originID(website)
+		if domain, ok := used[id]; ok {
+			origins = append(origins, externalOrigin(id, domain))
+			delete(used, id)
+		}
+	}
+
+	config.Origins = &cloudfront.Origins{
+		Items:    origins,
+		Quantity: aws.Int64(int64(len(origins))),
+	}
+}
+
+// CacheBehaviors returns the additional cache behaviors in a website distribution config
+func CacheBehaviors(config *cloudfront.DistributionConfig) []*CacheBehavior {
+	domains := map[string]string{}
+	if config.Origins != nil {
+		for _, o := range config.Origins.Items {
+			domains[aws.StringValue(o.Id)] = aws.StringValue(o.DomainName)
+		}
+	}
+
+	behaviors := []*CacheBehavior{}
+	if config.CacheBehaviors == nil {
+		return behaviors
+	}
+
+	for _, cb := range config.CacheBehaviors.Items {
+		b := &CacheBehavior{
+			PathPattern: aws.StringValue(cb.PathPattern),
+			MinTTL:      cb.MinTTL,
+			DefaultTTL:  cb.DefaultTTL,
+			MaxTTL:      cb.MaxTTL,
+		}
+
+		if id := aws.StringValue(cb.TargetOriginId); strings.HasPrefix(id, ExternalOriginPrefix) {
+			b.Origin = domains[id]
+		}
+
+		if cb.AllowedMethods != nil {
+			b.AllowedMethods = aws.StringValueSlice(cb.AllowedMethods.Items)
+		}
+
+		if fv := cb.ForwardedValues; fv != nil {
+			b.ForwardQueryString = aws.BoolValue(fv.QueryString)
+			b.ForwardCookies = fv.Cookies != nil && aws.StringValue(fv.Cookies.Forward) == "all"
+			if fv.Headers != nil && len(fv.Headers.Items) > 0 {
+				b.ForwardHeaders = aws.StringValueSlice(fv.Headers.Items)
+			}
+		}
+
+		if tkg := cb.TrustedKeyGroups; tkg != nil && aws.BoolValue(tkg.Enabled) {
+			b.Signed = true
+			if len(tkg.Items) > 0 {
+				b.KeyGroupID = aws.StringValue(tkg.Items[0])
+			}
+		}
+
+		behaviors = append(behaviors, b)
+	}
+
+	return behaviors
+}
+
+// GetCacheBehaviors gets the additional cache behaviors of a website distribution
+func (c *CloudFront) GetCacheBehaviors(ctx context.Context, id string) ([]*CacheBehavior, error) {
+	if id == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("getting cache behaviors for cloudfront distribution Id: %s", id)
+
+	out, err := c.Service.GetDistributionConfigWithContext(ctx, &cloudfront.GetDistributionConfigInput{Id: aws.String(id)})
+	if err != nil {
+		return nil, ErrCode("failed to get details about cloudfront distribution Id: "+id, err)
+	}
+
+	return CacheBehaviors(out.DistributionConfig), nil
+}
+
+// UpdateCacheBehaviors replaces the additional cache behaviors of a website distribution.  The behaviors are merged
+// into the current config, which is only updated if it hasn't changed since it was read.
+func (c *CloudFront) UpdateCacheBehaviors(ctx context.Context, id, website string, behaviors []*CacheBehavior) (*cloudfront.Distribution, error) {
+	if id == "" || website == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("updating %d cache behaviors for cloudfront distribution Id: %s", len(behaviors), id)
+
+	config, err := c.Service.GetDistributionConfigWithContext(ctx, &cloudfront.GetDistributionConfigInput{Id: aws.String(id)})
+	if err != nil {
+		return nil, ErrCode("failed to get details about cloudfront distribution Id: "+id, err)
+	}
+
+	MergeCacheBehaviors(config.DistributionConfig, website, behaviors)
+
+	out, err := c.Service.UpdateDistributionWithContext(ctx, &cloudfront.UpdateDistributionInput{
+		DistributionConfig: config.DistributionConfig,
+		IfMatch:            config.ETag,
+		Id:                 aws.String(id),
+	})
+	if err != nil {
+		return nil, ErrCode("failed to update cache behaviors for cloudfront distribution Id: "+id, err)
+	}
+
+	c.Index.Invalidate()
+
+	return out.Distribution, nil
+}
@@ -0,0 +1,144 @@
+package cloudfront
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+)
+
+func TestMergeCacheBehaviors(t *testing.T) {
+	config := &cloudfront.DistributionConfig{
+		DefaultCacheBehavior: &cloudfront.DefaultCacheBehavior{TargetOriginId: aws.String("www.example.com")},
+		Origins: &cloudfront.Origins{
+			Items: []*cloudfront.Origin{
+				{Id: aws.String("www.example.com"), DomainName: aws.String("www.example.com.s3-website-us-east-1.amazonaws.com")},
+				{Id: aws.String("external-old.example.org"), DomainName: aws.String("old.example.org")},
+				{Id: aws.String("external-api.example.org"), DomainName: aws.String("api.example.org"), OriginPath: aws.String("/v1")},
+			},
+			Quantity: aws.Int64(3),
+		},
+	}
+
+	MergeCacheBehaviors(config, "www.example.com", []*CacheBehavior{
+		{PathPattern: "/api/*", Origin: "api.example.org", MinTTL: aws.Int64(0), DefaultTTL: aws.Int64(0), MaxTTL: aws.Int64(0), AllowedMethods: []string{"GET", "HEAD", "OPTIONS", "PUT", "POST", "PATCH", "DELETE"}, ForwardQueryString: true, ForwardHeaders: []string{"Authorization"}},
+		{PathPattern: "/auth/*", Origin: "Auth.Example.org", ForwardCookies: true},
+		{PathPattern: "/static/*", DefaultTTL: aws.Int64(86400)},
+	})
+
+	if aws.StringValue(config.DefaultCacheBehavior.TargetOriginId) != "www.example.com" {
+		t.Errorf("expected the default cache behavior to be kept, got %+v", config.DefaultCacheBehavior)
+	}
+
+	if q := aws.Int64Value(config.CacheBehaviors.Quantity); q != 3 || len(config.CacheBehaviors.Items) != 3 {
+		t.Fatalf("expected 3 cache behaviors, got %d", q)
+	}
+
+	api := config.CacheBehaviors.Items[0]
+	if aws.StringValue(api.TargetOriginId) != "external-api.example.org" || aws.Int64Value(api.DefaultTTL) != 0 || aws.Int64Value(api.MaxTTL) != 0 {
+		t.Errorf("expected uncached behavior for the api origin, got %+v", api)
+	}
+
+	if aws.Int64Value(api.AllowedMethods.Quantity) != 7 || !aws.BoolValue(api.ForwardedValues.QueryString) || aws.Int64Value(api.ForwardedValues.Headers.Quantity) != 1 {
+		t.Errorf("expected all methods, the query string and the authorization header to be forwarded, got %+v", api)
+	}
+
+	auth := config.CacheBehaviors.Items[1]
+	if aws.StringValue(auth.TargetOriginId) != "external-auth.example.org" || aws.StringValue(auth.ForwardedValues.Cookies.Forward) != "all" {
+		t.Errorf("expected auth behavior forwarding cookies, got %+v", auth)
+	}
+
+	static := config.CacheBehaviors.Items[2]
+	if aws.StringValue(static.TargetOriginId) != "www.example.com" || aws.Int64Value(static.MinTTL) != 0 || aws.Int64Value(static.DefaultTTL) != 86400 || aws.Int64Value(static.MaxTTL) != DefaultBehaviorMaxTTL {
+		t.Errorf("expected static behavior for the website origin with default ttls, got %+v", static)
+	}
+
+	origins := []string{}
+	for _, o := range config.Origins.Items {
+		origins = append(origins, aws.StringValue(o.Id))
+	}
+
+	expected := []string{"www.example.com", "external-api.example.org", "external-auth.example.org"}
+	if !reflect.DeepEqual(expected, origins) || aws.Int64Value(config.Origins.Quantity) != 3 {
+		t.Errorf("expected origins %v, got %v", expected, origins)
+	}
+
+	// the existing origin is kept as is
+	if aws.StringValue(config.Origins.Items[1].OriginPath) != "/v1" {
+		t.Errorf("expected the existing api origin to be kept, got %+v", config.Origins.Items[1])
+	}
+
+	if p := aws.StringValue(config.Origins.Items[2].CustomOriginConfig.OriginProtocolPolicy); p != "https-only" {
+		t.Errorf("expected https-only external origin, got %s", p)
+	}
+
+	// removing the behaviors removes the external origins
+	MergeCacheBehaviors(config, "www.example.com", nil)
+	if aws.Int64Value(config.CacheBehaviors.Quantity) != 0 || config.CacheBehaviors.Items != nil {
+		t.Errorf("expected no cache behaviors, got %+v", config.CacheBehaviors)
+	}
+
+	if len(config.Origins.Items) != 1 || aws.StringValue(config.Origins.Items[0].Id) != "www.example.com" {
+		t.Errorf("expected only the website origin, got %+v", config.Origins.Items)
+	}
+}
+
+func TestCacheBehaviors(t *testing.T) {
+	behaviors := []*CacheBehavior{
+		{PathPattern: "/api/*", Origin: "api.example.org", MinTTL: aws.Int64(0), DefaultTTL: aws.Int64(0), MaxTTL: aws.Int64(0), AllowedMethods: []string{"GET", "HEAD", "OPTIONS"}, ForwardQueryString: true, ForwardCookies: true, ForwardHeaders: []string{"*"}},
+		{PathPattern: "/static/*", MinTTL: aws.Int64(60), DefaultTTL: aws.Int64(86400), MaxTTL: aws.Int64(604800), AllowedMethods: []string{"GET", "HEAD"}},
+		{PathPattern: "/private/*", MinTTL: aws.Int64(0), DefaultTTL: aws.Int64(3600), MaxTTL: aws.Int64(31536000), AllowedMethods: []string{"GET", "HEAD"}, Signed: true, KeyGroupID: "keygroup1"},
+	}
+
+	config := &cloudfront.DistributionConfig{}
+	MergeCacheBehaviors(config, "www.example.com", behaviors)
+
+	if out := CacheBehaviors(config); !reflect.DeepEqual(behaviors, out) {
+		t.Errorf("expected %+v, got %+v", behaviors, out)
+	}
+
+	if out := CacheBehaviors(&cloudfront.DistributionConfig{}); len(out) != 0 {
+		t.Errorf("expected no behaviors, got %+v", out)
+	}
+}
+
+func TestUpdateCacheBehaviors(t *testing.T) {
+	c := CloudFront{Service: newmockCloudFrontClient(t, nil)}
+
+	out, err := c.UpdateCacheBehaviors(context.TODO(), "AAAABBBBCCCCDDDD", "foobar1.bulldogs.cloud", []*CacheBehavior{{PathPattern: "/api/*", Origin: "api.example.org"}})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if q := aws.Int64Value(out.DistributionConfig.CacheBehaviors.Quantity); q != 1 {
+		t.Errorf("expected 1 cache behavior, got %d", q)
+	}
+
+	if q := aws.Int64Value(out.DistributionConfig.Origins.Quantity); q != 2 {
+		t.Errorf("expected the website and api origins, got %d", q)
+	}
+
+	// the test distribution's origins aren't modified
+	if q := aws.Int64Value(testDistribution1.Origins.Quantity); q != 1 {
+		t.Errorf("expected the original origins to be left as is, got %d", q)
+	}
+
+	behaviors, err := c.GetCacheBehaviors(context.TODO(), "AAAABBBBCCCCDDDD")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if len(behaviors) != 0 {
+		t.Errorf("expected no behaviors, got %+v", behaviors)
+	}
+
+	if _, err := c.UpdateCacheBehaviors(context.TODO(), "", "foobar1.bulldogs.cloud", nil); err == nil {
+		t.Error("expected error for empty id, got nil")
+	}
+
+	if _, err := c.UpdateCacheBehaviors(context.TODO(), "NOTFOUND", "foobar1.bulldogs.cloud", nil); err == nil {
+		t.Error("expected error for missing distribution, got nil")
+	}
+}
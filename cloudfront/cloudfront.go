@@ -21,6 +21,11 @@ type CloudFront struct {
 	Service         cloudfrontiface.CloudFrontAPI
 	Domains         map[string]*common.Domain
 	WebsiteEndpoint string
+	// Index is an optional index of distributions by alias.  It should be shared by all of the cloudfront
+	// services for an account so that changes made through any of them are reflected in it.
+	Index *DistributionIndex
+	// DistributionDefaults are the configured protocol settings for new website distributions
+	DistributionDefaults *common.DistributionDefaults
 }
 
 // NewSession creates a new cloudfront session
@@ -48,6 +53,7 @@ func NewSession(sess *session.Session, account common.Account, accountId string)
 
 	c.Service = cloudfront.New(sess, &cnf)
 	c.Domains = account.Domains
+	c.DistributionDefaults = account.DistributionDefaults
 	c.WebsiteEndpoint = "s3-website-" + account.Region + ".amazonaws.com"
 
 	return c
@@ -78,9 +84,10 @@ func (c *CloudFront) WebsiteDomain(name string) (*common.Domain, error) {
 	return domain, nil
 }
 
-// DefaultWebsiteDistributionConfig generates the cloudfront distribution configuration for an s3 website
+// DefaultWebsiteDistributionConfig generates the cloudfront distribution configuration for an s3 website, with the
+// passed additional cache behaviors
 // https://docs.aws.amazon.com/sdk-for-go/api/service/cloudfront/#DistributionConfig
-func (c *CloudFront) DefaultWebsiteDistributionConfig(name string) (*cloudfront.DistributionConfig, error) {
+func (c *CloudFront) DefaultWebsiteDistributionConfig(name string, behaviors ...*CacheBehavior) (*cloudfront.DistributionConfig, error) {
 	domain, err := c.WebsiteDomain(name)
 	if err != nil {
 		return nil, err
@@ -135,6 +142,12 @@ func (c *CloudFront) DefaultWebsiteDistributionConfig(name string) (*cloudfront.
 		},
 	}
 
+	c.defaultProtocolSettings().Apply(&config)
+
+	if len(behaviors) > 0 {
+		MergeCacheBehaviors(&config, name, behaviors)
+	}
+
 	log.Debugf("Generated Distribution Config: %+v", config)
 
 	return &config, nil
@@ -106,7 +106,9 @@ func TestDefaultWebsiteDistributionConfig(t *testing.T) {
 			},
 			Quantity: aws.Int64(1),
 		},
-		PriceClass: aws.String("PriceClass_100"),
+		HttpVersion:   aws.String("http2"),
+		IsIPV6Enabled: aws.Bool(true),
+		PriceClass:    aws.String("PriceClass_100"),
 		ViewerCertificate: &cloudfront.ViewerCertificate{
 			ACMCertificateArn:      aws.String("arn:aws:acm::12345678910:certificate/111111111-2222-3333-4444-555555555555"),
 			MinimumProtocolVersion: aws.String("TLSv1.1_2016"),
@@ -144,4 +146,15 @@ func TestDefaultWebsiteDistributionConfig(t *testing.T) {
 	if !reflect.DeepEqual(config, expected) {
 		t.Errorf("expected %+v, got %+v", expected, config)
 	}
+
+	// the configured defaults override the protocol settings
+	e.DistributionDefaults = &common.DistributionDefaults{HttpVersion: "http2and3", IsIPV6Enabled: aws.Bool(false)}
+	config, err = e.DefaultWebsiteDistributionConfig("im.hyper.converged")
+	if err != nil {
+		t.Errorf("expected success for valid domain, got error: %s", err)
+	}
+
+	if aws.StringValue(config.HttpVersion) != "http2and3" || aws.BoolValue(config.IsIPV6Enabled) {
+		t.Errorf("expected http2and3 without ipv6, got %s and %t", aws.StringValue(config.HttpVersion), aws.BoolValue(config.IsIPV6Enabled))
+	}
 }
@@ -0,0 +1,61 @@
+package cloudfront
+
+import (
+	"context"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	log "github.com/sirupsen/logrus"
+)
+
+// StatusDeployed is the status of a distribution once its changes are deployed to all of the edge locations
+const StatusDeployed = "Deployed"
+
+// GetDistribution gets a cloudfront distribution by id, including its current status
+func (c *CloudFront) GetDistribution(ctx context.Context, id string) (*cloudfront.Distribution, error) {
+	if id == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Debugf("getting cloudfront distribution Id: %s", id)
+
+	out, err := c.Service.GetDistributionWithContext(ctx, &cloudfront.GetDistributionInput{Id: aws.String(id)})
+	if err != nil {
+		return nil, ErrCode("failed to get cloudfront distribution Id: "+id, err)
+	}
+
+	return out.Distribution, nil
+}
+
+// WaitUntilDeployed checks the status of a cloudfront distribution every interval until it's Deployed, and returns
+// the deployed distribution.  New distributions and changes to existing ones usually take a few minutes to deploy,
+// but it can take much longer.  The wait is limited by the context, the error is the context's if it's done first.
+func (c *CloudFront) WaitUntilDeployed(ctx context.Context, id string, interval time.Duration) (*cloudfront.Distribution, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		distribution, err := c.GetDistribution(ctx, id)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, err
+		}
+
+		if aws.StringValue(distribution.Status) == StatusDeployed {
+			log.Infof("cloudfront distribution %s is deployed", id)
+			return distribution, nil
+		}
+
+		log.Debugf("waiting for cloudfront distribution %s to deploy, status is %s", id, aws.StringValue(distribution.Status))
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package cloudfront
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/aws/aws-sdk-go/service/cloudfront/cloudfrontiface"
+)
+
+// mockDeployClient is a fake cloudfront client for a distribution that goes through a list of statuses
+type mockDeployClient struct {
+	cloudfrontiface.CloudFrontAPI
+	statuses []string
+	calls    int
+	err      error
+}
+
+func (m *mockDeployClient) GetDistributionWithContext(ctx context.Context, input *cloudfront.GetDistributionInput, opts ...request.Option) (*cloudfront.GetDistributionOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	status := m.statuses[len(m.statuses)-1]
+	if m.calls < len(m.statuses) {
+		status = m.statuses[m.calls]
+	}
+	m.calls++
+
+	return &cloudfront.GetDistributionOutput{
+		Distribution: &cloudfront.Distribution{Id: input.Id, Status: aws.String(status)},
+	}, nil
+}
+
+func TestGetDistribution(t *testing.T) {
+	c := CloudFront{Service: &mockDeployClient{statuses: []string{"InProgress"}}}
+
+	if _, err := c.GetDistribution(context.TODO(), ""); err == nil {
+		t.Error("expected error for empty id, got nil")
+	}
+
+	out, err := c.GetDistribution(context.TODO(), "AAAABBBBCCCCDDDD")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if aws.StringValue(out.Id) != "AAAABBBBCCCCDDDD" || aws.StringValue(out.Status) != "InProgress" {
+		t.Errorf("unexpected distribution %+v", out)
+	}
+
+	c = CloudFront{Service: &mockDeployClient{err: awserr.New(cloudfront.ErrCodeNoSuchDistribution, "not found", nil)}}
+	if _, err := c.GetDistribution(context.TODO(), "AAAABBBBCCCCDDDD"); err == nil {
+		t.Error("expected error for missing distribution, got nil")
+	} else if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrNotFound {
+		t.Errorf("expected not found error, got %s", err)
+	}
+}
+
+func TestWaitUntilDeployed(t *testing.T) {
+	client := &mockDeployClient{statuses: []string{"InProgress", "InProgress", "Deployed"}}
+	c := CloudFront{Service: client}
+
+	out, err := c.WaitUntilDeployed(context.TODO(), "AAAABBBBCCCCDDDD", time.Millisecond)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if aws.StringValue(out.Status) != StatusDeployed || client.calls != 3 {
+		t.Errorf("expected deployed distribution after 3 checks, got %s after %d", aws.StringValue(out.Status), client.calls)
+	}
+
+	// the wait is limited by the context
+	c = CloudFront{Service: &mockDeployClient{statuses: []string{"InProgress"}}}
+	ctx, cancel := context.WithTimeout(context.TODO(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := c.WaitUntilDeployed(ctx, "AAAABBBBCCCCDDDD", 5*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+
+	// errors getting the distribution stop the wait
+	c = CloudFront{Service: &mockDeployClient{err: awserr.New(cloudfront.ErrCodeAccessDenied, "denied", nil)}}
+	if _, err := c.WaitUntilDeployed(context.TODO(), "AAAABBBBCCCCDDDD", time.Millisecond); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
@@ -28,6 +28,8 @@ func (c *CloudFront) CreateDistribution(ctx context.Context, distribution *cloud
 		return nil, ErrCode("failed to create cloudfront distribution", err)
 	}
 
+	c.Index.Invalidate()
+
 	return out.Distribution, nil
 }
 
@@ -55,6 +57,37 @@ func (c *CloudFront) DisableDistribution(ctx context.Context, id string) (*cloud
 		return nil, ErrCode("failed to disable cloudfront distribution Id:"+id, err)
 	}
 
+	c.Index.Invalidate()
+
+	return out.Distribution, nil
+}
+
+// EnableDistribution enables a disabled cloudfront distribution
+func (c *CloudFront) EnableDistribution(ctx context.Context, id string) (*cloudfront.Distribution, error) {
+	if id == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("enabling cloudfront distributions Id: %s", id)
+
+	// Get the distribution config from the passed distribution id.  This is required to get the most recent ETag for the distribution.
+	config, err := c.Service.GetDistributionConfigWithContext(ctx, &cloudfront.GetDistributionConfigInput{Id: aws.String(id)})
+	if err != nil {
+		return nil, ErrCode("failed to get details about cloudfront distribution Id: "+id, err)
+	}
+
+	config.DistributionConfig.Enabled = aws.Bool(true)
+	out, err := c.Service.UpdateDistributionWithContext(ctx, &cloudfront.UpdateDistributionInput{
+		DistributionConfig: config.DistributionConfig,
+		IfMatch:            config.ETag,
+		Id:                 aws.String(id),
+	})
+	if err != nil {
+		return nil, ErrCode("failed to enable cloudfront distribution Id:"+id, err)
+	}
+
+	c.Index.Invalidate()
+
 	return out.Distribution, nil
 }
 
@@ -80,9 +113,30 @@ func (c *CloudFront) DeleteDistribution(ctx context.Context, id string) error {
 		return ErrCode("failed to delete cloudfront distribution Id:"+id, err)
 	}
 
+	if c.Index != nil {
+		c.Index.remove(id)
+	}
+
 	return nil
 }
 
+// GetDistributionETag gets the current ETag of a cloudfront distribution's config.  It changes every time the
+// distribution is updated.
+func (c *CloudFront) GetDistributionETag(ctx context.Context, id string) (string, error) {
+	if id == "" {
+		return "", apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("getting the etag for cloudfront distribution Id: %s", id)
+
+	config, err := c.Service.GetDistributionConfigWithContext(ctx, &cloudfront.GetDistributionConfigInput{Id: aws.String(id)})
+	if err != nil {
+		return "", ErrCode("failed to get details about cloudfront distribution Id: "+id, err)
+	}
+
+	return aws.StringValue(config.ETag), nil
+}
+
 // TagDistribution updates the tags for a cloudfront distribution
 func (c *CloudFront) TagDistribution(ctx context.Context, arn string, tags *cloudfront.Tags) error {
 	if arn == "" {
@@ -102,6 +156,29 @@ func (c *CloudFront) TagDistribution(ctx context.Context, arn string, tags *clou
 	return nil
 }
 
+// UntagDistribution removes the tags with the passed keys from a distribution
+func (c *CloudFront) UntagDistribution(ctx context.Context, arn string, keys []string) error {
+	if arn == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	log.Infof("removing tags %v from cloudfront distribution ARN: %s", keys, arn)
+
+	_, err := c.Service.UntagResourceWithContext(ctx, &cloudfront.UntagResourceInput{
+		Resource: aws.String(arn),
+		TagKeys:  &cloudfront.TagKeys{Items: aws.StringSlice(keys)},
+	})
+	if err != nil {
+		return ErrCode("failed to untag cloudfront distribution ARN:"+arn, err)
+	}
+
+	return nil
+}
+
 // ListDistributions lists all cloudfront distributions.
 func (c *CloudFront) ListDistributions(ctx context.Context) ([]*cloudfront.DistributionSummary, error) {
 	distributions := []*cloudfront.DistributionSummary{}
@@ -153,8 +230,13 @@ func (c *CloudFront) ListDistributionsWithFilter(ctx context.Context, filter fun
 	return distributions, nil
 }
 
-// GetDistributionByName gets a cloudfront distribution by the name (by searching until it finds the matching alias)
+// GetDistributionByName gets a cloudfront distribution by the name, from the index if one is configured or by searching
+// until it finds the matching alias
 func (c *CloudFront) GetDistributionByName(ctx context.Context, name string) (*cloudfront.DistributionSummary, error) {
+	if c.Index != nil {
+		return c.indexedDistribution(ctx, name)
+	}
+
 	log.Infof("searching for cloudfront distribution %s", name)
 
 	input := &cloudfront.ListDistributionsInput{MaxItems: aws.Int64(100)}
@@ -180,10 +262,31 @@ func (c *CloudFront) GetDistributionByName(ctx context.Context, name string) (*c
 
 	if distribution == nil {
 		msg := fmt.Sprintf("cloudfront distribution not found with name %s", name)
-		err = apierror.New(apierror.ErrNotFound, msg, nil)
+		return nil, apierror.New(apierror.ErrNotFound, msg, nil)
+	}
+
+	return distribution, nil
+}
+
+// indexedDistribution gets a cloudfront distribution by name from the index, refreshing the index if it's stale
+func (c *CloudFront) indexedDistribution(ctx context.Context, name string) (*cloudfront.DistributionSummary, error) {
+	distribution, refresh := c.Index.lookup(name)
+	if refresh {
+		if err := c.RefreshIndex(ctx); err != nil {
+			return nil, err
+		}
+
+		distribution, _ = c.Index.lookup(name)
+	}
+
+	if distribution == nil {
+		msg := fmt.Sprintf("cloudfront distribution not found with name %s", name)
+		return nil, apierror.New(apierror.ErrNotFound, msg, nil)
 	}
 
-	return distribution, err
+	log.Debugf("found cloudfront distribution %s in index", name)
+
+	return distribution, nil
 }
 
 // InvalidateCache submits a cache invalidation request to cloudfront
@@ -249,6 +249,20 @@ func (m *mockCloudFrontClient) TagResourceWithContext(ctx context.Context, input
 	return nil, awserr.New(cloudfront.ErrCodeNoSuchDistribution, "Distribution Not Found", nil)
 }
 
+func (m *mockCloudFrontClient) UntagResourceWithContext(ctx context.Context, input *cloudfront.UntagResourceInput, opts ...request.Option) (*cloudfront.UntagResourceOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	for _, d := range []*cloudfront.DistributionSummary{testDistribution1, testDistribution2, testDistribution3} {
+		if aws.StringValue(d.ARN) == aws.StringValue(input.Resource) {
+			return &cloudfront.UntagResourceOutput{}, nil
+		}
+	}
+
+	return nil, awserr.New(cloudfront.ErrCodeNoSuchDistribution, "Distribution Not Found", nil)
+}
+
 func (m *mockCloudFrontClient) CreateInvalidationWithContext(ctx context.Context, input *cloudfront.CreateInvalidationInput, opts ...request.Option) (*cloudfront.CreateInvalidationOutput, error) {
 	if m.err != nil {
 		return nil, m.err
@@ -444,6 +458,28 @@ func TestCreateDistribution(t *testing.T) {
 	}
 }
 
+func TestGetDistributionETag(t *testing.T) {
+	c := CloudFront{Service: newmockCloudFrontClient(t, nil)}
+
+	etag, err := c.GetDistributionETag(context.TODO(), aws.StringValue(testDistribution1.Id))
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if etag != "ETAGETAGETAGETAG" {
+		t.Errorf("expected etag ETAGETAGETAGETAG, got %s", etag)
+	}
+
+	if _, err := c.GetDistributionETag(context.TODO(), ""); err == nil {
+		t.Error("expected error for empty id, got nil")
+	}
+
+	_, err = c.GetDistributionETag(context.TODO(), "missing")
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrNotFound {
+		t.Errorf("expected error code %s, got: %v", apierror.ErrNotFound, err)
+	}
+}
+
 func TestDisableDistribution(t *testing.T) {
 	c := CloudFront{
 		Service: newmockCloudFrontClient(t, nil),
@@ -525,6 +561,87 @@ func TestDisableDistribution(t *testing.T) {
 	}
 }
 
+func TestEnableDistribution(t *testing.T) {
+	c := CloudFront{
+		Service: newmockCloudFrontClient(t, nil),
+		Domains: map[string]*common.Domain{
+			"hyper.converged": {
+				CertArn: "arn:aws:acm::12345678910:certificate/111111111-2222-3333-4444-555555555555",
+			},
+		},
+		WebsiteEndpoint: "s3-website-us-east-1.amazonaws.com",
+	}
+
+	tests := map[*cloudfront.DistributionSummary]*cloudfront.Distribution{
+		testDistribution1: {
+			ARN: testDistribution1.ARN,
+			DistributionConfig: &cloudfront.DistributionConfig{
+				Aliases:              testDistribution1.Aliases,
+				Comment:              testDistribution1.Comment,
+				DefaultCacheBehavior: testDistribution1.DefaultCacheBehavior,
+				Origins:              testDistribution1.Origins,
+				Enabled:              aws.Bool(true),
+			},
+			Status: aws.String("InProgress"),
+		},
+		testDistribution2: {
+			ARN: testDistribution2.ARN,
+			DistributionConfig: &cloudfront.DistributionConfig{
+				Aliases:              testDistribution2.Aliases,
+				Comment:              testDistribution2.Comment,
+				DefaultCacheBehavior: testDistribution2.DefaultCacheBehavior,
+				Origins:              testDistribution2.Origins,
+				Enabled:              aws.Bool(true),
+			},
+			Status: aws.String("InProgress"),
+		},
+		testDistribution3: {
+			ARN: testDistribution3.ARN,
+			DistributionConfig: &cloudfront.DistributionConfig{
+				Aliases:              testDistribution3.Aliases,
+				Comment:              testDistribution3.Comment,
+				DefaultCacheBehavior: testDistribution3.DefaultCacheBehavior,
+				Origins:              testDistribution3.Origins,
+				Enabled:              aws.Bool(true),
+			},
+			Status: aws.String("InProgress"),
+		},
+	}
+
+	// test success
+	for _, testDist := range []*cloudfront.DistributionSummary{testDistribution1, testDistribution2, testDistribution3} {
+		expected := tests[testDist]
+		out, err := c.EnableDistribution(context.TODO(), aws.StringValue(testDist.Id))
+		if err != nil {
+			t.Errorf("expected nil error, got: %s", err)
+		}
+
+		if !reflect.DeepEqual(out, expected) {
+			t.Errorf("expected %+v, got %+v", expected, out)
+		}
+	}
+
+	// test empty id input
+	_, err := c.EnableDistribution(context.TODO(), "")
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrBadRequest {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrBadRequest, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+
+	// test not found id input
+	_, err = c.EnableDistribution(context.TODO(), "notfoundid")
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrNotFound {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrNotFound, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+}
+
 func TestDeleteDistribution(t *testing.T) {
 	c := CloudFront{
 		Service: newmockCloudFrontClient(t, nil),
@@ -616,6 +733,42 @@ func TestTagDistribution(t *testing.T) {
 	}
 }
 
+func TestUntagDistribution(t *testing.T) {
+	c := CloudFront{
+		Service: newmockCloudFrontClient(t, nil),
+	}
+
+	// test success
+	if err := c.UntagDistribution(context.TODO(), aws.StringValue(testDistribution1.ARN), []string{"foo"}); err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	// test no keys
+	if err := c.UntagDistribution(context.TODO(), "notfoundid", nil); err != nil {
+		t.Errorf("expected nil error for no keys, got: %s", err)
+	}
+
+	// test empty arn input
+	err := c.UntagDistribution(context.TODO(), "", []string{"foo"})
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrBadRequest {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrBadRequest, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+
+	// test not found arn input
+	err = c.UntagDistribution(context.TODO(), "notfoundid", []string{"foo"})
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrNotFound {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrNotFound, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+}
+
 func TestListDistribution(t *testing.T) {
 	c := CloudFront{
 		Service: newmockCloudFrontClient(t, nil),
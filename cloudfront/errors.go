@@ -140,6 +140,26 @@ func ErrCode(msg string, err error) error {
 			// per distribution.
 			cloudfront.ErrCodeTooManyLambdaFunctionAssociations,
 
+			// cloudfront.ErrCodeTooManyFunctions for service response error code
+			// "TooManyFunctions".
+			//
+			// You have reached the maximum number of CloudFront functions for this Amazon
+			// Web Services account.
+			cloudfront.ErrCodeTooManyFunctions,
+
+			// cloudfront.ErrCodeFunctionSizeLimitExceeded for service response error code
+			// "FunctionSizeLimitExceeded".
+			//
+			// The function is too large.
+			cloudfront.ErrCodeFunctionSizeLimitExceeded,
+
+			// cloudfront.ErrCodeTooManyResponseHeadersPolicies for service response error code
+			// "TooManyResponseHeadersPolicies".
+			//
+			// You have reached the maximum number of response headers policies for this
+			// Amazon Web Services account.
+			cloudfront.ErrCodeTooManyResponseHeadersPolicies,
+
 			// cloudfront.ErrCodeTooManyOriginCustomHeaders for service response error code
 			// "TooManyOriginCustomHeaders".
 			cloudfront.ErrCodeTooManyOriginCustomHeaders,
@@ -183,7 +203,27 @@ func ErrCode(msg string, err error) error {
 			// "TooManyTrustedSigners".
 			//
 			// Your request contains more trusted signers than are allowed per distribution.
-			cloudfront.ErrCodeTooManyTrustedSigners:
+			cloudfront.ErrCodeTooManyTrustedSigners,
+
+			// cloudfront.ErrCodeTooManyKeyGroups for service response error code
+			// "TooManyKeyGroups".
+			//
+			// You have reached the maximum number of key groups for this Amazon Web Services
+			// account.
+			cloudfront.ErrCodeTooManyKeyGroups,
+
+			// cloudfront.ErrCodeTooManyPublicKeysInKeyGroup for service response error code
+			// "TooManyPublicKeysInKeyGroup".
+			//
+			// The number of public keys in this key group is more than the maximum allowed.
+			cloudfront.ErrCodeTooManyPublicKeysInKeyGroup,
+
+			// cloudfront.ErrCodeTooManyDistributionsAssociatedToKeyGroup for service response error code
+			// "TooManyDistributionsAssociatedToKeyGroup".
+			//
+			// The number of distributions that reference this key group is more than the
+			// maximum allowed.
+			cloudfront.ErrCodeTooManyDistributionsAssociatedToKeyGroup:
 
 			return apierror.New(apierror.ErrLimitExceeded, msg, aerr)
 		case
@@ -241,12 +281,31 @@ func ErrCode(msg string, err error) error {
 			// The specified public key already exists.
 			cloudfront.ErrCodePublicKeyAlreadyExists,
 
+			// cloudfront.ErrCodeFunctionAlreadyExists for service response error code
+			// "FunctionAlreadyExists".
+			//
+			// A function with the same name already exists in this Amazon Web Services
+			// account.
+			cloudfront.ErrCodeFunctionAlreadyExists,
+
+			// cloudfront.ErrCodeResponseHeadersPolicyAlreadyExists for service response error code
+			// "ResponseHeadersPolicyAlreadyExists".
+			//
+			// A response headers policy with this name already exists.
+			cloudfront.ErrCodeResponseHeadersPolicyAlreadyExists,
+
 			// cloudfront.ErrCodePublicKeyInUse for service response error code
 			// "PublicKeyInUse".
 			//
 			// The specified public key is in use.
 			cloudfront.ErrCodePublicKeyInUse,
 
+			// cloudfront.ErrCodeKeyGroupAlreadyExists for service response error code
+			// "KeyGroupAlreadyExists".
+			//
+			// A key group with this name already exists.
+			cloudfront.ErrCodeKeyGroupAlreadyExists,
+
 			// cloudfront.ErrCodeStreamingDistributionAlreadyExists for service response error code
 			// "StreamingDistributionAlreadyExists".
 			cloudfront.ErrCodeStreamingDistributionAlreadyExists:
@@ -441,6 +500,18 @@ func ErrCode(msg string, err error) error {
 			// The specified distribution does not exist.
 			cloudfront.ErrCodeNoSuchDistribution,
 
+			// cloudfront.ErrCodeNoSuchFunctionExists for service response error code
+			// "NoSuchFunctionExists".
+			//
+			// The function does not exist.
+			cloudfront.ErrCodeNoSuchFunctionExists,
+
+			// cloudfront.ErrCodeNoSuchResponseHeadersPolicy for service response error code
+			// "NoSuchResponseHeadersPolicy".
+			//
+			// The response headers policy does not exist.
+			cloudfront.ErrCodeNoSuchResponseHeadersPolicy,
+
 			// cloudfront.ErrCodeNoSuchFieldLevelEncryptionConfig for service response error code
 			// "NoSuchFieldLevelEncryptionConfig".
 			//
@@ -471,6 +542,12 @@ func ErrCode(msg string, err error) error {
 			// The specified public key doesn't exist.
 			cloudfront.ErrCodeNoSuchPublicKey,
 
+			// cloudfront.ErrCodeTrustedKeyGroupDoesNotExist for service response error code
+			// "TrustedKeyGroupDoesNotExist".
+			//
+			// The specified key group does not exist.
+			cloudfront.ErrCodeTrustedKeyGroupDoesNotExist,
+
 			// cloudfront.ErrCodeNoSuchResource for service response error code
 			// "NoSuchResource".
 			cloudfront.ErrCodeNoSuchResource,
@@ -0,0 +1,232 @@
+package cloudfront
+
+import (
+	"context"
+	"strings"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// FunctionRuntime is the runtime of the cloudfront functions created for websites
+const FunctionRuntime = cloudfront.FunctionRuntimeCloudfrontJs10
+
+// FunctionEventTypes are the events a cloudfront function can be associated with
+var FunctionEventTypes = []string{cloudfront.EventTypeViewerRequest, cloudfront.EventTypeViewerResponse}
+
+// FunctionAssociation is a cloudfront function associated with the default cache behavior of a website
+type FunctionAssociation struct {
+	// EventType is the event the function runs on, viewer-request or viewer-response
+	EventType string
+	// Name is the name of the function
+	Name string
+	// ARN is the arn of the function
+	ARN string
+}
+
+// functionName returns the name of a function from its arn, ie. arn:aws:cloudfront::012345678910:function/foo
+func functionName(arn string) string {
+	return arn[strings.LastIndex(arn, "/")+1:]
+}
+
+// FunctionAssociations returns the cloudfront functions associated with the default cache behavior in a
+// distribution config
+func FunctionAssociations(config *cloudfront.DistributionConfig) []*FunctionAssociation {
+	associations := []*FunctionAssociation{}
+	if config.DefaultCacheBehavior == nil || config.DefaultCacheBehavior.FunctionAssociations == nil {
+		return associations
+	}
+
+	for _, a := range config.DefaultCacheBehavior.FunctionAssociations.Items {
+		associations = append(associations, &FunctionAssociation{
+			EventType: aws.StringValue(a.EventType),
+			Name:      functionName(aws.StringValue(a.FunctionARN)),
+			ARN:       aws.StringValue(a.FunctionARN),
+		})
+	}
+
+	return associations
+}
+
+// SetFunctionAssociation associates a function with the default cache behavior in a distribution config, replacing
+// the function associated with the same event type.  An empty arn removes the function associated with the event type.
+func SetFunctionAssociation(config *cloudfront.DistributionConfig, eventType, arn string) {
+	// copy the default cache behavior so the read config isn't modified
+	behavior := cloudfront.DefaultCacheBehavior{}
+	if config.DefaultCacheBehavior != nil {
+		behavior = *config.DefaultCacheBehavior
+	}
+	config.DefaultCacheBehavior = &behavior
+
+	items := []*cloudfront.FunctionAssociation{}
+	if fa := config.DefaultCacheBehavior.FunctionAssociations; fa != nil {
+		for _, a := range fa.Items {
+			if aws.StringValue(a.EventType) != eventType {
+				items = append(items, a)
+			}
+		}
+	}
+
+	if arn != "" {
+		items = append(items, &cloudfront.FunctionAssociation{
+			EventType:   aws.String(eventType),
+			FunctionARN: aws.String(arn),
+		})
+	}
+
+	config.DefaultCacheBehavior.FunctionAssociations = &cloudfront.FunctionAssociations{Quantity: aws.Int64(int64(len(items)))}
+	if len(items) > 0 {
+		config.DefaultCacheBehavior.FunctionAssociations.Items = items
+	}
+}
+
+// EnsureFunction creates a cloudfront function with the given code, or updates the existing function if the code or
+// comment changed, and publishes it so the live stage runs the code.  It returns the arn of the function.
+func (c *CloudFront) EnsureFunction(ctx context.Context, name, comment, code string) (string, error) {
+	if name == "" || code == "" {
+		return "", apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	config := &cloudfront.FunctionConfig{
+		Comment: aws.String(comment),
+		Runtime: aws.String(FunctionRuntime),
+	}
+
+	dev, err := c.Service.DescribeFunctionWithContext(ctx, &cloudfront.DescribeFunctionInput{
+		Name:  aws.String(name),
+		Stage: aws.String(cloudfront.FunctionStageDevelopment),
+	})
+	if err != nil {
+		if aerr, ok := errors.Cause(err).(awserr.Error); !ok || aerr.Code() != cloudfront.ErrCodeNoSuchFunctionExists {
+			return "", ErrCode("failed to describe cloudfront function "+name, err)
+		}
+
+		log.Infof("creating cloudfront function %s", name)
+
+		out, err := c.Service.CreateFunctionWithContext(ctx, &cloudfront.CreateFunctionInput{
+			FunctionCode:   []byte(code),
+			FunctionConfig: config,
+			Name:           aws.String(name),
+		})
+		if err != nil {
+			return "", ErrCode("failed to create cloudfront function "+name, err)
+		}
+
+		return c.publishFunction(ctx, name, out.ETag)
+	}
+
+	etag := dev.ETag
+
+	current, err := c.Service.GetFunctionWithContext(ctx, &cloudfront.GetFunctionInput{
+		Name:  aws.String(name),
+		Stage: aws.String(cloudfront.FunctionStageDevelopment),
+	})
+	if err != nil {
+		return "", ErrCode("failed to get cloudfront function "+name, err)
+	}
+
+	var devComment string
+	if dev.FunctionSummary != nil && dev.FunctionSummary.FunctionConfig != nil {
+		devComment = aws.StringValue(dev.FunctionSummary.FunctionConfig.Comment)
+	}
+
+	if string(current.FunctionCode) != code || devComment != comment {
+		log.Infof("updating cloudfront function %s", name)
+
+		out, err := c.Service.UpdateFunctionWithContext(ctx, &cloudfront.UpdateFunctionInput{
+			FunctionCode:   []byte(code),
+			FunctionConfig: config,
+			IfMatch:        etag,
+			Name:           aws.String(name),
+		})
+		if err != nil {
+			return "", ErrCode("failed to update cloudfront function "+name, err)
+		}
+
+		return c.publishFunction(ctx, name, out.ETag)
+	}
+
+	// the development stage is current, make sure it's been published
+	live, err := c.Service.GetFunctionWithContext(ctx, &cloudfront.GetFunctionInput{
+		Name:  aws.String(name),
+		Stage: aws.String(cloudfront.FunctionStageLive),
+	})
+	if err != nil {
+		if aerr, ok := errors.Cause(err).(awserr.Error); !ok || aerr.Code() != cloudfront.ErrCodeNoSuchFunctionExists {
+			return "", ErrCode("failed to get live cloudfront function "+name, err)
+		}
+	}
+
+	if live == nil || string(live.FunctionCode) != code {
+		return c.publishFunction(ctx, name, etag)
+	}
+
+	return aws.StringValue(dev.FunctionSummary.FunctionMetadata.FunctionARN), nil
+}
+
+// publishFunction publishes the development stage of a cloudfront function to the live stage and returns its arn
+func (c *CloudFront) publishFunction(ctx context.Context, name string, etag *string) (string, error) {
+	log.Infof("publishing cloudfront function %s", name)
+
+	out, err := c.Service.PublishFunctionWithContext(ctx, &cloudfront.PublishFunctionInput{
+		IfMatch: etag,
+		Name:    aws.String(name),
+	})
+	if err != nil {
+		return "", ErrCode("failed to publish cloudfront function "+name, err)
+	}
+
+	return aws.StringValue(out.FunctionSummary.FunctionMetadata.FunctionARN), nil
+}
+
+// GetFunctionAssociations gets the cloudfront functions associated with the default cache behavior of a website
+// distribution
+func (c *CloudFront) GetFunctionAssociations(ctx context.Context, id string) ([]*FunctionAssociation, error) {
+	if id == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("getting function associations for cloudfront distribution Id: %s", id)
+
+	out, err := c.Service.GetDistributionConfigWithContext(ctx, &cloudfront.GetDistributionConfigInput{Id: aws.String(id)})
+	if err != nil {
+		return nil, ErrCode("failed to get details about cloudfront distribution Id: "+id, err)
+	}
+
+	return FunctionAssociations(out.DistributionConfig), nil
+}
+
+// UpdateFunctionAssociation associates a function with the default cache behavior of a website distribution for the
+// event type, replacing the function associated with the event type.  An empty arn removes the association.  The
+// config is only updated if it hasn't changed since it was read.
+func (c *CloudFront) UpdateFunctionAssociation(ctx context.Context, id, eventType, arn string) (*cloudfront.Distribution, error) {
+	if id == "" || eventType == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("updating %s function association for cloudfront distribution Id: %s to '%s'", eventType, id, arn)
+
+	config, err := c.Service.GetDistributionConfigWithContext(ctx, &cloudfront.GetDistributionConfigInput{Id: aws.String(id)})
+	if err != nil {
+		return nil, ErrCode("failed to get details about cloudfront distribution Id: "+id, err)
+	}
+
+	SetFunctionAssociation(config.DistributionConfig, eventType, arn)
+
+	out, err := c.Service.UpdateDistributionWithContext(ctx, &cloudfront.UpdateDistributionInput{
+		DistributionConfig: config.DistributionConfig,
+		IfMatch:            config.ETag,
+		Id:                 aws.String(id),
+	})
+	if err != nil {
+		return nil, ErrCode("failed to update function associations for cloudfront distribution Id: "+id, err)
+	}
+
+	c.Index.Invalidate()
+
+	return out.Distribution, nil
+}
@@ -0,0 +1,194 @@
+package cloudfront
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/aws/aws-sdk-go/service/cloudfront/cloudfrontiface"
+)
+
+// mockFunctionsClient keeps the code of the development and live stages of the functions and records the calls
+type mockFunctionsClient struct {
+	cloudfrontiface.CloudFrontAPI
+	dev   map[string]string
+	live  map[string]string
+	calls []string
+}
+
+func (m *mockFunctionsClient) summary(name string) *cloudfront.FunctionSummary {
+	return &cloudfront.FunctionSummary{
+		FunctionConfig:   &cloudfront.FunctionConfig{Comment: aws.String("comment"), Runtime: aws.String(FunctionRuntime)},
+		FunctionMetadata: &cloudfront.FunctionMetadata{FunctionARN: aws.String("arn:aws:cloudfront::012345678910:function/" + name)},
+		Name:             aws.String(name),
+	}
+}
+
+func (m *mockFunctionsClient) DescribeFunctionWithContext(ctx context.Context, input *cloudfront.DescribeFunctionInput, opts ...request.Option) (*cloudfront.DescribeFunctionOutput, error) {
+	if _, ok := m.dev[aws.StringValue(input.Name)]; !ok {
+		return nil, awserr.New(cloudfront.ErrCodeNoSuchFunctionExists, "not found", nil)
+	}
+	return &cloudfront.DescribeFunctionOutput{ETag: aws.String("ETAG"), FunctionSummary: m.summary(aws.StringValue(input.Name))}, nil
+}
+
+func (m *mockFunctionsClient) GetFunctionWithContext(ctx context.Context, input *cloudfront.GetFunctionInput, opts ...request.Option) (*cloudfront.GetFunctionOutput, error) {
+	stage := m.dev
+	if aws.StringValue(input.Stage) == cloudfront.FunctionStageLive {
+		stage = m.live
+	}
+
+	code, ok := stage[aws.StringValue(input.Name)]
+	if !ok {
+		return nil, awserr.New(cloudfront.ErrCodeNoSuchFunctionExists, "not found", nil)
+	}
+	return &cloudfront.GetFunctionOutput{ETag: aws.String("ETAG"), FunctionCode: []byte(code)}, nil
+}
+
+func (m *mockFunctionsClient) CreateFunctionWithContext(ctx context.Context, input *cloudfront.CreateFunctionInput, opts ...request.Option) (*cloudfront.CreateFunctionOutput, error) {
+	m.calls = append(m.calls, "create")
+	m.dev[aws.StringValue(input.Name)] = string(input.FunctionCode)
+	return &cloudfront.CreateFunctionOutput{ETag: aws.String("ETAG"), FunctionSummary: m.summary(aws.StringValue(input.Name))}, nil
+}
+
+func (m *mockFunctionsClient) UpdateFunctionWithContext(ctx context.Context, input *cloudfront.UpdateFunctionInput, opts ...request.Option) (*cloudfront.UpdateFunctionOutput, error) {
+	if aws.StringValue(input.IfMatch) != "ETAG" {
+		return nil, awserr.New(cloudfront.ErrCodePreconditionFailed, "etag mismatch", nil)
+	}
+
+	m.calls = append(m.calls, "update")
+	m.dev[aws.StringValue(input.Name)] = string(input.FunctionCode)
+	return &cloudfront.UpdateFunctionOutput{ETag: aws.String("ETAG"), FunctionSummary: m.summary(aws.StringValue(input.Name))}, nil
+}
+
+func (m *mockFunctionsClient) PublishFunctionWithContext(ctx context.Context, input *cloudfront.PublishFunctionInput, opts ...request.Option) (*cloudfront.PublishFunctionOutput, error) {
+	if aws.StringValue(input.IfMatch) != "ETAG" {
+		return nil, awserr.New(cloudfront.ErrCodePreconditionFailed, "etag mismatch", nil)
+	}
+
+	m.calls = append(m.calls, "publish")
+	m.live[aws.StringValue(input.Name)] = m.dev[aws.StringValue(input.Name)]
+	return &cloudfront.PublishFunctionOutput{FunctionSummary: m.summary(aws.StringValue(input.Name))}, nil
+}
+
+func TestEnsureFunction(t *testing.T) {
+	m := &mockFunctionsClient{dev: map[string]string{}, live: map[string]string{}}
+	c := CloudFront{Service: m}
+
+	tests := []struct {
+		code  string
+		calls []string
+	}{
+		{code: "function handler(event) { return event.request; }", calls: []string{"create", "publish"}},
+		{code: "function handler(event) { return event.request; }", calls: nil},
+		{code: "function handler(event) { return event.response; }", calls: []string{"update", "publish"}},
+	}
+
+	for _, test := range tests {
+		m.calls = nil
+
+		arn, err := c.EnsureFunction(context.TODO(), "security-headers", "comment", test.code)
+		if err != nil {
+			t.Fatalf("expected nil error, got %s", err)
+		}
+
+		if arn != "arn:aws:cloudfront::012345678910:function/security-headers" {
+			t.Errorf("unexpected function arn %s", arn)
+		}
+
+		if !reflect.DeepEqual(test.calls, m.calls) {
+			t.Errorf("expected calls %v, got %v", test.calls, m.calls)
+		}
+
+		if m.live["security-headers"] != test.code {
+			t.Errorf("expected live code %s, got %s", test.code, m.live["security-headers"])
+		}
+	}
+
+	// an unpublished function is published
+	m.dev["index-rewrite"] = "function handler(event) {}"
+	m.calls = nil
+	if _, err := c.EnsureFunction(context.TODO(), "index-rewrite", "comment", "function handler(event) {}"); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if !reflect.DeepEqual([]string{"publish"}, m.calls) {
+		t.Errorf("expected the function to be published, got %v", m.calls)
+	}
+
+	if _, err := c.EnsureFunction(context.TODO(), "", "", "function handler(event) {}"); err == nil {
+		t.Error("expected error for empty name, got nil")
+	}
+}
+
+func TestSetFunctionAssociation(t *testing.T) {
+	behavior := &cloudfront.DefaultCacheBehavior{TargetOriginId: aws.String("www.example.com")}
+	config := &cloudfront.DistributionConfig{DefaultCacheBehavior: behavior}
+
+	SetFunctionAssociation(config, "viewer-request", "arn:aws:cloudfront::012345678910:function/index-rewrite")
+	SetFunctionAssociation(config, "viewer-response", "arn:aws:cloudfront::012345678910:function/old-headers")
+	SetFunctionAssociation(config, "viewer-response", "arn:aws:cloudfront::012345678910:function/security-headers")
+
+	if behavior.FunctionAssociations != nil {
+		t.Errorf("expected the original default cache behavior to be left as is, got %+v", behavior)
+	}
+
+	expected := []*FunctionAssociation{
+		{EventType: "viewer-request", Name: "index-rewrite", ARN: "arn:aws:cloudfront::012345678910:function/index-rewrite"},
+		{EventType: "viewer-response", Name: "security-headers", ARN: "arn:aws:cloudfront::012345678910:function/security-headers"},
+	}
+
+	if out := FunctionAssociations(config); !reflect.DeepEqual(expected, out) {
+		t.Errorf("expected %+v, got %+v", expected, out)
+	}
+
+	if q := aws.Int64Value(config.DefaultCacheBehavior.FunctionAssociations.Quantity); q != 2 {
+		t.Errorf("expected 2 function associations, got %d", q)
+	}
+
+	SetFunctionAssociation(config, "viewer-request", "")
+	SetFunctionAssociation(config, "viewer-response", "")
+	if fa := config.DefaultCacheBehavior.FunctionAssociations; aws.Int64Value(fa.Quantity) != 0 || fa.Items != nil {
+		t.Errorf("expected no function associations, got %+v", fa)
+	}
+
+	if out := FunctionAssociations(&cloudfront.DistributionConfig{}); len(out) != 0 {
+		t.Errorf("expected no function associations, got %+v", out)
+	}
+}
+
+func TestUpdateFunctionAssociation(t *testing.T) {
+	c := CloudFront{Service: newmockCloudFrontClient(t, nil)}
+
+	arn := "arn:aws:cloudfront::012345678910:function/security-headers"
+	out, err := c.UpdateFunctionAssociation(context.TODO(), "AAAABBBBCCCCDDDD", "viewer-response", arn)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	associations := FunctionAssociations(out.DistributionConfig)
+	if len(associations) != 1 || associations[0].ARN != arn {
+		t.Errorf("expected the function to be associated, got %+v", associations)
+	}
+
+	// the test distribution isn't modified
+	associations, err = c.GetFunctionAssociations(context.TODO(), "AAAABBBBCCCCDDDD")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if len(associations) != 0 {
+		t.Errorf("expected no function associations, got %+v", associations)
+	}
+
+	if _, err := c.UpdateFunctionAssociation(context.TODO(), "", "viewer-response", arn); err == nil {
+		t.Error("expected error for empty id, got nil")
+	}
+
+	if _, err := c.UpdateFunctionAssociation(context.TODO(), "NOTFOUND", "viewer-response", arn); err == nil {
+		t.Error("expected error for missing distribution, got nil")
+	}
+}
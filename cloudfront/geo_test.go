@@ -0,0 +1,57 @@
+package cloudfront
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestValidCountryCode(t *testing.T) {
+	for code, valid := range map[string]bool{"US": true, "us": true, "GB": true, "UK": false, "USA": false, "": false} {
+		if ValidCountryCode(code) != valid {
+			t.Errorf("expected %s valid to be %t", code, valid)
+		}
+	}
+}
+
+func TestGeoRestriction(t *testing.T) {
+	restriction := &GeoRestriction{RestrictionType: "whitelist", Locations: []string{"us", "CA"}}
+
+	out := GeoRestrictionFor(restriction.restrictions())
+	expected := &GeoRestriction{RestrictionType: "whitelist", Locations: []string{"US", "CA"}}
+	if !reflect.DeepEqual(expected, out) {
+		t.Errorf("expected %+v, got %+v", expected, out)
+	}
+
+	none := (&GeoRestriction{RestrictionType: "none"}).restrictions()
+	if aws.StringValue(none.GeoRestriction.RestrictionType) != "none" || aws.Int64Value(none.GeoRestriction.Quantity) != 0 || none.GeoRestriction.Items != nil {
+		t.Errorf("expected no restriction, got %+v", none.GeoRestriction)
+	}
+
+	if out := GeoRestrictionFor(nil); out.RestrictionType != "none" || len(out.Locations) != 0 {
+		t.Errorf("expected no restriction for nil restrictions, got %+v", out)
+	}
+}
+
+func TestUpdateGeoRestriction(t *testing.T) {
+	c := CloudFront{Service: newmockCloudFrontClient(t, nil)}
+
+	out, err := c.UpdateGeoRestriction(context.TODO(), "AAAABBBBCCCCDDDD", &GeoRestriction{RestrictionType: "whitelist", Locations: []string{"US"}})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if g := GeoRestrictionFor(out.DistributionConfig.Restrictions); g.RestrictionType != "whitelist" || !reflect.DeepEqual(g.Locations, []string{"US"}) {
+		t.Errorf("expected us only restriction, got %+v", g)
+	}
+
+	if _, err := c.UpdateGeoRestriction(context.TODO(), "AAAABBBBCCCCDDDD", nil); err == nil {
+		t.Error("expected error for nil restriction, got nil")
+	}
+
+	if _, err := c.UpdateGeoRestriction(context.TODO(), "NOTFOUND", &GeoRestriction{RestrictionType: "none"}); err == nil {
+		t.Error("expected error for missing distribution, got nil")
+	}
+}
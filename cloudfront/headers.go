@@ -0,0 +1,261 @@
+package cloudfront
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultSecurityHeadersPolicyName is the name of the security headers response headers policy if it isn't configured
+const DefaultSecurityHeadersPolicyName = "spinup-security-headers"
+
+// SecurityHeadersPolicyConfig returns the response headers policy config for the security headers configuration.  The
+// headers override the ones from the origin so they can't be left out by the website content.
+func SecurityHeadersPolicyConfig(h *common.SecurityHeaders) *cloudfront.ResponseHeadersPolicyConfig {
+	name := h.Name
+	if name == "" {
+		name = DefaultSecurityHeadersPolicyName
+	}
+
+	headers := &cloudfront.ResponseHeadersPolicySecurityHeadersConfig{}
+
+	if h.HSTSMaxAge > 0 {
+		headers.StrictTransportSecurity = &cloudfront.ResponseHeadersPolicyStrictTransportSecurity{
+			AccessControlMaxAgeSec: aws.Int64(h.HSTSMaxAge),
+			IncludeSubdomains:      aws.Bool(h.HSTSIncludeSubdomains),
+			Override:               aws.Bool(true),
+			Preload:                aws.Bool(h.HSTSPreload),
+		}
+	}
+
+	if h.ContentSecurityPolicy != "" {
+		headers.ContentSecurityPolicy = &cloudfront.ResponseHeadersPolicyContentSecurityPolicy{
+			ContentSecurityPolicy: aws.String(h.ContentSecurityPolicy),
+			Override:              aws.Bool(true),
+		}
+	}
+
+	if h.ContentTypeOptions {
+		headers.ContentTypeOptions = &cloudfront.ResponseHeadersPolicyContentTypeOptions{Override: aws.Bool(true)}
+	}
+
+	if h.FrameOptions != "" {
+		headers.FrameOptions = &cloudfront.ResponseHeadersPolicyFrameOptions{
+			FrameOption: aws.String(strings.ToUpper(h.FrameOptions)),
+			Override:    aws.Bool(true),
+		}
+	}
+
+	if h.ReferrerPolicy != "" {
+		headers.ReferrerPolicy = &cloudfront.ResponseHeadersPolicyReferrerPolicy{
+			ReferrerPolicy: aws.String(h.ReferrerPolicy),
+			Override:       aws.Bool(true),
+		}
+	}
+
+	if h.XSSProtection {
+		headers.XSSProtection = &cloudfront.ResponseHeadersPolicyXSSProtection{
+			ModeBlock:  aws.Bool(true),
+			Override:   aws.Bool(true),
+			Protection: aws.Bool(true),
+		}
+	}
+
+	return &cloudfront.ResponseHeadersPolicyConfig{
+		Comment:               aws.String("standard security headers for websites"),
+		Name:                  aws.String(name),
+		SecurityHeadersConfig: headers,
+	}
+}
+
+// ValidateSecurityHeaders checks the security headers configuration
+func ValidateSecurityHeaders(h *common.SecurityHeaders) error {
+	if h.FrameOptions != "" {
+		if o := strings.ToUpper(h.FrameOptions); o != cloudfront.FrameOptionsListDeny && o != cloudfront.FrameOptionsListSameorigin {
+			return fmt.Errorf("invalid security headers frame options %s, expected DENY or SAMEORIGIN", h.FrameOptions)
+		}
+	}
+
+	if h.ReferrerPolicy != "" {
+		valid := false
+		for _, p := range cloudfront.ReferrerPolicyList_Values() {
+			if p == h.ReferrerPolicy {
+				valid = true
+				break
+			}
+		}
+
+		if !valid {
+			return fmt.Errorf("invalid security headers referrer policy %s, expected one of %v", h.ReferrerPolicy, cloudfront.ReferrerPolicyList_Values())
+		}
+	}
+
+	if h.HSTSMaxAge < 0 {
+		return fmt.Errorf("invalid security headers hsts max age %d", h.HSTSMaxAge)
+	}
+
+	return nil
+}
+
+// GetResponseHeadersPolicyID returns the id of the custom response headers policy with the name, or an empty string
+// if it doesn't exist
+func (c *CloudFront) GetResponseHeadersPolicyID(ctx context.Context, name string) (string, error) {
+	if name == "" {
+		return "", apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	input := &cloudfront.ListResponseHeadersPoliciesInput{Type: aws.String(cloudfront.ResponseHeadersPolicyTypeCustom)}
+	for {
+		out, err := c.Service.ListResponseHeadersPoliciesWithContext(ctx, input)
+		if err != nil {
+			return "", ErrCode("failed to list response headers policies", err)
+		}
+
+		if out.ResponseHeadersPolicyList == nil {
+			return "", nil
+		}
+
+		for _, p := range out.ResponseHeadersPolicyList.Items {
+			if p.ResponseHeadersPolicy == nil || p.ResponseHeadersPolicy.ResponseHeadersPolicyConfig == nil {
+				continue
+			}
+
+			if aws.StringValue(p.ResponseHeadersPolicy.ResponseHeadersPolicyConfig.Name) == name {
+				return aws.StringValue(p.ResponseHeadersPolicy.Id), nil
+			}
+		}
+
+		if out.ResponseHeadersPolicyList.NextMarker == nil {
+			return "", nil
+		}
+		input.Marker = out.ResponseHeadersPolicyList.NextMarker
+	}
+}
+
+// EnsureResponseHeadersPolicy creates the custom response headers policy with the config's name, or updates the
+// existing policy if its config changed.  It returns the id of the policy.
+func (c *CloudFront) EnsureResponseHeadersPolicy(ctx context.Context, config *cloudfront.ResponseHeadersPolicyConfig) (string, error) {
+	if config == nil || aws.StringValue(config.Name) == "" {
+		return "", apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	name := aws.StringValue(config.Name)
+
+	id, err := c.GetResponseHeadersPolicyID(ctx, name)
+	if err != nil {
+		return "", err
+	}
+
+	if id == "" {
+		log.Infof("creating response headers policy %s", name)
+
+		out, err := c.Service.CreateResponseHeadersPolicyWithContext(ctx, &cloudfront.CreateResponseHeadersPolicyInput{
+			ResponseHeadersPolicyConfig: config,
+		})
+		if err != nil {
+			return "", ErrCode("failed to create response headers policy "+name, err)
+		}
+
+		return aws.StringValue(out.ResponseHeadersPolicy.Id), nil
+	}
+
+	current, err := c.Service.GetResponseHeadersPolicyConfigWithContext(ctx, &cloudfront.GetResponseHeadersPolicyConfigInput{Id: aws.String(id)})
+	if err != nil {
+		return "", ErrCode("failed to get response headers policy "+name, err)
+	}
+
+	if awsutil.DeepEqual(current.ResponseHeadersPolicyConfig, config) {
+		return id, nil
+	}
+
+	log.Infof("updating response headers policy %s (%s)", name, id)
+
+	if _, err := c.Service.UpdateResponseHeadersPolicyWithContext(ctx, &cloudfront.UpdateResponseHeadersPolicyInput{
+		Id:                          aws.String(id),
+		IfMatch:                     current.ETag,
+		ResponseHeadersPolicyConfig: config,
+	}); err != nil {
+		return "", ErrCode("failed to update response headers policy "+name, err)
+	}
+
+	return id, nil
+}
+
+// ResponseHeadersPolicyID returns the id of the response headers policy of the default cache behavior in a
+// distribution config, or an empty string if it doesn't have one
+func ResponseHeadersPolicyID(config *cloudfront.DistributionConfig) string {
+	if config.DefaultCacheBehavior == nil {
+		return ""
+	}
+	return aws.StringValue(config.DefaultCacheBehavior.ResponseHeadersPolicyId)
+}
+
+// SetResponseHeadersPolicy sets the response headers policy of the default and the additional cache behaviors in a
+// distribution config, an empty id removes it
+func SetResponseHeadersPolicy(config *cloudfront.DistributionConfig, id string) {
+	var policyId *string
+	if id != "" {
+		policyId = aws.String(id)
+	}
+
+	// copy the cache behaviors so the read config isn't modified
+	behavior := cloudfront.DefaultCacheBehavior{}
+	if config.DefaultCacheBehavior != nil {
+		behavior = *config.DefaultCacheBehavior
+	}
+	behavior.ResponseHeadersPolicyId = policyId
+	config.DefaultCacheBehavior = &behavior
+
+	if config.CacheBehaviors == nil {
+		return
+	}
+
+	items := []*cloudfront.CacheBehavior{}
+	for _, cb := range config.CacheBehaviors.Items {
+		b := *cb
+		b.ResponseHeadersPolicyId = policyId
+		items = append(items, &b)
+	}
+
+	config.CacheBehaviors = &cloudfront.CacheBehaviors{Quantity: config.CacheBehaviors.Quantity}
+	if len(items) > 0 {
+		config.CacheBehaviors.Items = items
+	}
+}
+
+// UpdateResponseHeadersPolicy sets the response headers policy of a website distribution, an empty policy id removes
+// it.  The config is only updated if it hasn't changed since it was read.
+func (c *CloudFront) UpdateResponseHeadersPolicy(ctx context.Context, id, policyId string) (*cloudfront.Distribution, error) {
+	if id == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("setting response headers policy for cloudfront distribution Id: %s to '%s'", id, policyId)
+
+	config, err := c.Service.GetDistributionConfigWithContext(ctx, &cloudfront.GetDistributionConfigInput{Id: aws.String(id)})
+	if err != nil {
+		return nil, ErrCode("failed to get details about cloudfront distribution Id: "+id, err)
+	}
+
+	SetResponseHeadersPolicy(config.DistributionConfig, policyId)
+
+	out, err := c.Service.UpdateDistributionWithContext(ctx, &cloudfront.UpdateDistributionInput{
+		DistributionConfig: config.DistributionConfig,
+		IfMatch:            config.ETag,
+		Id:                 aws.String(id),
+	})
+	if err != nil {
+		return nil, ErrCode("failed to update response headers policy for cloudfront distribution Id: "+id, err)
+	}
+
+	c.Index.Invalidate()
+
+	return out.Distribution, nil
+}
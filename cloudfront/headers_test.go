@@ -0,0 +1,172 @@
+package cloudfront
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/aws/aws-sdk-go/service/cloudfront/cloudfrontiface"
+)
+
+// mockHeadersClient keeps the response headers policies by id and records the calls
+type mockHeadersClient struct {
+	cloudfrontiface.CloudFrontAPI
+	policies map[string]*cloudfront.ResponseHeadersPolicyConfig
+	calls    []string
+}
+
+func (m *mockHeadersClient) ListResponseHeadersPoliciesWithContext(ctx context.Context, input *cloudfront.ListResponseHeadersPoliciesInput, opts ...request.Option) (*cloudfront.ListResponseHeadersPoliciesOutput, error) {
+	items := []*cloudfront.ResponseHeadersPolicySummary{}
+	for id, p := range m.policies {
+		items = append(items, &cloudfront.ResponseHeadersPolicySummary{
+			ResponseHeadersPolicy: &cloudfront.ResponseHeadersPolicy{Id: aws.String(id), ResponseHeadersPolicyConfig: p},
+			Type:                  aws.String(cloudfront.ResponseHeadersPolicyTypeCustom),
+		})
+	}
+	return &cloudfront.ListResponseHeadersPoliciesOutput{ResponseHeadersPolicyList: &cloudfront.ResponseHeadersPolicyList{Items: items}}, nil
+}
+
+func (m *mockHeadersClient) CreateResponseHeadersPolicyWithContext(ctx context.Context, input *cloudfront.CreateResponseHeadersPolicyInput, opts ...request.Option) (*cloudfront.CreateResponseHeadersPolicyOutput, error) {
+	m.calls = append(m.calls, "create")
+	m.policies["policy1"] = input.ResponseHeadersPolicyConfig
+	return &cloudfront.CreateResponseHeadersPolicyOutput{ResponseHeadersPolicy: &cloudfront.ResponseHeadersPolicy{Id: aws.String("policy1")}}, nil
+}
+
+func (m *mockHeadersClient) GetResponseHeadersPolicyConfigWithContext(ctx context.Context, input *cloudfront.GetResponseHeadersPolicyConfigInput, opts ...request.Option) (*cloudfront.GetResponseHeadersPolicyConfigOutput, error) {
+	p, ok := m.policies[aws.StringValue(input.Id)]
+	if !ok {
+		return nil, awserr.New(cloudfront.ErrCodeNoSuchResponseHeadersPolicy, "not found", nil)
+	}
+	return &cloudfront.GetResponseHeadersPolicyConfigOutput{ETag: aws.String("ETAG"), ResponseHeadersPolicyConfig: p}, nil
+}
+
+func (m *mockHeadersClient) UpdateResponseHeadersPolicyWithContext(ctx context.Context, input *cloudfront.UpdateResponseHeadersPolicyInput, opts ...request.Option) (*cloudfront.UpdateResponseHeadersPolicyOutput, error) {
+	if aws.StringValue(input.IfMatch) != "ETAG" {
+		return nil, awserr.New(cloudfront.ErrCodePreconditionFailed, "etag mismatch", nil)
+	}
+
+	m.calls = append(m.calls, "update")
+	m.policies[aws.StringValue(input.Id)] = input.ResponseHeadersPolicyConfig
+	return &cloudfront.UpdateResponseHeadersPolicyOutput{}, nil
+}
+
+func TestSecurityHeadersPolicyConfig(t *testing.T) {
+	config := SecurityHeadersPolicyConfig(&common.SecurityHeaders{
+		HSTSMaxAge:            63072000,
+		HSTSIncludeSubdomains: true,
+		ContentTypeOptions:    true,
+		FrameOptions:          "sameorigin",
+	})
+
+	if n := aws.StringValue(config.Name); n != DefaultSecurityHeadersPolicyName {
+		t.Errorf("expected default policy name, got %s", n)
+	}
+
+	headers := config.SecurityHeadersConfig
+	if hsts := headers.StrictTransportSecurity; aws.Int64Value(hsts.AccessControlMaxAgeSec) != 63072000 || !aws.BoolValue(hsts.IncludeSubdomains) || aws.BoolValue(hsts.Preload) {
+		t.Errorf("unexpected strict transport security %+v", hsts)
+	}
+
+	if aws.StringValue(headers.FrameOptions.FrameOption) != "SAMEORIGIN" || headers.ContentTypeOptions == nil {
+		t.Errorf("expected frame and content type options, got %+v", headers)
+	}
+
+	if headers.ContentSecurityPolicy != nil || headers.ReferrerPolicy != nil || headers.XSSProtection != nil {
+		t.Errorf("expected only the configured headers, got %+v", headers)
+	}
+}
+
+func TestValidateSecurityHeaders(t *testing.T) {
+	tests := []struct {
+		headers *common.SecurityHeaders
+		err     bool
+	}{
+		{headers: &common.SecurityHeaders{}},
+		{headers: &common.SecurityHeaders{FrameOptions: "deny", ReferrerPolicy: "strict-origin-when-cross-origin", HSTSMaxAge: 300}},
+		{headers: &common.SecurityHeaders{FrameOptions: "ALLOW-FROM"}, err: true},
+		{headers: &common.SecurityHeaders{ReferrerPolicy: "sometimes"}, err: true},
+		{headers: &common.SecurityHeaders{HSTSMaxAge: -1}, err: true},
+	}
+
+	for _, test := range tests {
+		if err := ValidateSecurityHeaders(test.headers); test.err != (err != nil) {
+			t.Errorf("unexpected error %v for %+v", err, test.headers)
+		}
+	}
+}
+
+func TestEnsureResponseHeadersPolicy(t *testing.T) {
+	m := &mockHeadersClient{policies: map[string]*cloudfront.ResponseHeadersPolicyConfig{}}
+	c := CloudFront{Service: m}
+
+	headers := &common.SecurityHeaders{HSTSMaxAge: 300}
+	tests := []struct {
+		headers *common.SecurityHeaders
+		calls   []string
+	}{
+		{headers: headers, calls: []string{"create"}},
+		{headers: headers, calls: nil},
+		{headers: &common.SecurityHeaders{HSTSMaxAge: 63072000}, calls: []string{"update"}},
+	}
+
+	for _, test := range tests {
+		m.calls = nil
+
+		id, err := c.EnsureResponseHeadersPolicy(context.TODO(), SecurityHeadersPolicyConfig(test.headers))
+		if err != nil {
+			t.Fatalf("expected nil error, got %s", err)
+		}
+
+		if id != "policy1" {
+			t.Errorf("expected policy1, got %s", id)
+		}
+
+		if !reflect.DeepEqual(test.calls, m.calls) {
+			t.Errorf("expected calls %v, got %v", test.calls, m.calls)
+		}
+	}
+
+	if _, err := c.EnsureResponseHeadersPolicy(context.TODO(), &cloudfront.ResponseHeadersPolicyConfig{}); err == nil {
+		t.Error("expected error for empty name, got nil")
+	}
+}
+
+func TestSetResponseHeadersPolicy(t *testing.T) {
+	config := &cloudfront.DistributionConfig{DefaultCacheBehavior: &cloudfront.DefaultCacheBehavior{TargetOriginId: aws.String("www.example.com")}}
+	MergeCacheBehaviors(config, "www.example.com", []*CacheBehavior{{PathPattern: "/api/*"}})
+
+	behavior := config.CacheBehaviors.Items[0]
+
+	SetResponseHeadersPolicy(config, "policy1")
+	if id := ResponseHeadersPolicyID(config); id != "policy1" {
+		t.Errorf("expected policy1, got %s", id)
+	}
+
+	if id := aws.StringValue(config.CacheBehaviors.Items[0].ResponseHeadersPolicyId); id != "policy1" {
+		t.Errorf("expected policy1 for the additional cache behavior, got %s", id)
+	}
+
+	if behavior.ResponseHeadersPolicyId != nil {
+		t.Errorf("expected the original cache behavior to be left as is, got %+v", behavior)
+	}
+
+	// new behaviors get the policy of the default cache behavior
+	MergeCacheBehaviors(config, "www.example.com", []*CacheBehavior{{PathPattern: "/static/*"}})
+	if id := aws.StringValue(config.CacheBehaviors.Items[0].ResponseHeadersPolicyId); id != "policy1" {
+		t.Errorf("expected policy1 for the new cache behavior, got %s", id)
+	}
+
+	SetResponseHeadersPolicy(config, "")
+	if id := ResponseHeadersPolicyID(config); id != "" {
+		t.Errorf("expected no policy, got %s", id)
+	}
+
+	if config.CacheBehaviors.Items[0].ResponseHeadersPolicyId != nil {
+		t.Errorf("expected no policy for the additional cache behavior, got %+v", config.CacheBehaviors.Items[0])
+	}
+}
@@ -0,0 +1,156 @@
+package cloudfront
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultIndexMissRefresh is the minimum age of the index before a lookup for an unknown alias refreshes it
+const DefaultIndexMissRefresh = 15 * time.Second
+
+// DistributionIndex is an index of cloudfront distribution summaries by alias.  It's rebuilt from a single listing
+// of all of the distributions when it's older than the max age, or when an alias isn't found and it's older than
+// the miss refresh interval, so that looking up a distribution by name doesn't page through every distribution.
+type DistributionIndex struct {
+	aliases     map[string]*cloudfront.DistributionSummary
+	refreshed   time.Time
+	maxAge      time.Duration
+	missRefresh time.Duration
+	mu          sync.RWMutex
+	refreshMu   sync.Mutex
+}
+
+type DistributionIndexOption func(*DistributionIndex)
+
+// NewDistributionIndex creates a new, empty distribution index that's refreshed when it's older than maxAge
+func NewDistributionIndex(maxAge time.Duration, opts ...DistributionIndexOption) *DistributionIndex {
+	i := DistributionIndex{
+		aliases:     map[string]*cloudfront.DistributionSummary{},
+		maxAge:      maxAge,
+		missRefresh: DefaultIndexMissRefresh,
+	}
+
+	for _, opt := range opts {
+		opt(&i)
+	}
+
+	return &i
+}
+
+// WithMissRefresh sets the minimum age of the index before a lookup for an unknown alias refreshes it
+func WithMissRefresh(d time.Duration) DistributionIndexOption {
+	return func(i *DistributionIndex) {
+		i.missRefresh = d
+	}
+}
+
+// lookup gets a distribution summary by alias and returns whether the index should be refreshed before trusting the result
+func (i *DistributionIndex) lookup(alias string) (*cloudfront.DistributionSummary, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	age := time.Since(i.refreshed)
+	if i.refreshed.IsZero() || age > i.maxAge {
+		return nil, true
+	}
+
+	d, ok := i.aliases[alias]
+	if !ok {
+		return nil, age > i.missRefresh
+	}
+
+	return d, false
+}
+
+// replace replaces the contents of the index with the given distributions
+func (i *DistributionIndex) replace(distributions []*cloudfront.DistributionSummary, refreshed time.Time) {
+	aliases := map[string]*cloudfront.DistributionSummary{}
+	for _, d := range distributions {
+		if d.Aliases == nil {
+			continue
+		}
+
+		for _, a := range d.Aliases.Items {
+			aliases[aws.StringValue(a)] = d
+		}
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.aliases = aliases
+	i.refreshed = refreshed
+}
+
+// remove removes a distribution from the index by id
+func (i *DistributionIndex) remove(id string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for alias, d := range i.aliases {
+		if aws.StringValue(d.Id) == id {
+			delete(i.aliases, alias)
+		}
+	}
+}
+
+// Invalidate marks the index as stale so that it's refreshed by the next lookup
+func (i *DistributionIndex) Invalidate() {
+	if i == nil {
+		return
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.refreshed = time.Time{}
+}
+
+// refreshedSince returns true if the index has been refreshed since the given time
+func (i *DistributionIndex) refreshedSince(t time.Time) bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return !i.refreshed.Before(t)
+}
+
+// RefreshIndex rebuilds the distribution index from a listing of all of the distributions.  Concurrent
+// refreshes are collapsed into a single listing.
+func (c *CloudFront) RefreshIndex(ctx context.Context) error {
+	if c.Index == nil {
+		return nil
+	}
+
+	start := time.Now()
+
+	c.Index.refreshMu.Lock()
+	defer c.Index.refreshMu.Unlock()
+
+	// another request refreshed the index while we were waiting
+	if c.Index.refreshedSince(start) {
+		return nil
+	}
+
+	log.Info("refreshing cloudfront distribution index")
+
+	distributions := []*cloudfront.DistributionSummary{}
+	input := &cloudfront.ListDistributionsInput{MaxItems: aws.Int64(100)}
+	if err := c.Service.ListDistributionsPagesWithContext(ctx, input,
+		func(out *cloudfront.ListDistributionsOutput, lastPage bool) bool {
+			distributions = append(distributions, out.DistributionList.Items...)
+			return true
+		}); err != nil {
+		return ErrCode("failed to list cloudfront distributions", err)
+	}
+
+	c.Index.replace(distributions, time.Now())
+
+	log.Infof("indexed %d cloudfront distributions", len(distributions))
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package cloudfront
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+)
+
+func TestGetDistributionByNameIndexed(t *testing.T) {
+	c := CloudFront{
+		Service: newmockCloudFrontClient(t, nil),
+		Index:   NewDistributionIndex(1 * time.Minute),
+	}
+
+	dist, err := c.GetDistributionByName(context.TODO(), "foobar1.bulldogs.cloud")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if dist != testDistribution1 {
+		t.Errorf("expected distribution %+v, got %+v", testDistribution1, dist)
+	}
+
+	// subsequent lookups, for any indexed alias, are served from the index
+	c.Service = newmockCloudFrontClient(t, errors.New("boom"))
+	dist, err = c.GetDistributionByName(context.TODO(), "foobar2.bulldogs.cloud")
+	if err != nil {
+		t.Fatalf("expected nil error from index, got %s", err)
+	}
+
+	if dist != testDistribution2 {
+		t.Errorf("expected distribution %+v, got %+v", testDistribution2, dist)
+	}
+
+	// unknown aliases aren't refreshed until the miss refresh interval has passed
+	_, err = c.GetDistributionByName(context.TODO(), "missing.bulldogs.cloud")
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrNotFound {
+		t.Errorf("expected not found error, got %s", err)
+	}
+
+	// deleting a distribution removes it from the index
+	c.Service = newmockCloudFrontClient(t, nil)
+	if err := c.DeleteDistribution(context.TODO(), "AAAABBBBCCCCDDDD"); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	_, err = c.GetDistributionByName(context.TODO(), "foobar1.bulldogs.cloud")
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrNotFound {
+		t.Errorf("expected not found error, got %s", err)
+	}
+
+	// invalidating the index refreshes it on the next lookup
+	c.Index.Invalidate()
+	c.Service = newmockCloudFrontClient(t, errors.New("boom"))
+	if _, err := c.GetDistributionByName(context.TODO(), "foobar2.bulldogs.cloud"); err == nil {
+		t.Error("expected error refreshing invalidated index, got nil")
+	}
+}
+
+func TestDistributionIndexLookup(t *testing.T) {
+	i := NewDistributionIndex(1*time.Minute, WithMissRefresh(0))
+
+	if _, refresh := i.lookup("foobar1.bulldogs.cloud"); !refresh {
+		t.Error("expected empty index to need a refresh")
+	}
+
+	i.replace([]*cloudfront.DistributionSummary{testDistribution1, testDistribution2, testDistribution3}, time.Now())
+
+	if d, refresh := i.lookup("foobar1.bulldogs.cloud"); refresh || d != testDistribution1 {
+		t.Errorf("expected %+v without refresh, got %+v (refresh: %t)", testDistribution1, d, refresh)
+	}
+
+	time.Sleep(1 * time.Millisecond)
+	if _, refresh := i.lookup("missing.bulldogs.cloud"); !refresh {
+		t.Error("expected a miss to need a refresh")
+	}
+
+	i.replace([]*cloudfront.DistributionSummary{testDistribution1, testDistribution2, testDistribution3}, time.Now().Add(-2*time.Minute))
+	if _, refresh := i.lookup("foobar1.bulldogs.cloud"); !refresh {
+		t.Error("expected an expired index to need a refresh")
+	}
+}
@@ -0,0 +1,63 @@
+package cloudfront
+
+import (
+	"context"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	log "github.com/sirupsen/logrus"
+)
+
+// StatusCompleted is the status of an invalidation once the paths are invalidated in all of the edge locations
+const StatusCompleted = "Completed"
+
+// GetInvalidation gets a cache invalidation of a cloudfront distribution by id, including its current status
+func (c *CloudFront) GetInvalidation(ctx context.Context, distributionId, id string) (*cloudfront.Invalidation, error) {
+	if distributionId == "" || id == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Debugf("getting invalidation %s for cloudfront distribution Id: %s", id, distributionId)
+
+	out, err := c.Service.GetInvalidationWithContext(ctx, &cloudfront.GetInvalidationInput{
+		DistributionId: aws.String(distributionId),
+		Id:             aws.String(id),
+	})
+	if err != nil {
+		return nil, ErrCode("failed to get invalidation "+id+" for cloudfront distribution Id: "+distributionId, err)
+	}
+
+	return out.Invalidation, nil
+}
+
+// WaitUntilInvalidated checks the status of a cache invalidation every interval until it's Completed, and returns the
+// completed invalidation.  The wait is limited by the context, the error is the context's if it's done first.
+func (c *CloudFront) WaitUntilInvalidated(ctx context.Context, distributionId, id string, interval time.Duration) (*cloudfront.Invalidation, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		invalidation, err := c.GetInvalidation(ctx, distributionId, id)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, err
+		}
+
+		if aws.StringValue(invalidation.Status) == StatusCompleted {
+			log.Infof("invalidation %s for cloudfront distribution %s is completed", id, distributionId)
+			return invalidation, nil
+		}
+
+		log.Debugf("waiting for invalidation %s for cloudfront distribution %s, status is %s", id, distributionId, aws.StringValue(invalidation.Status))
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package cloudfront
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/aws/aws-sdk-go/service/cloudfront/cloudfrontiface"
+)
+
+// mockInvalidationClient is a fake cloudfront client for an invalidation that goes through a list of statuses
+type mockInvalidationClient struct {
+	cloudfrontiface.CloudFrontAPI
+	statuses []string
+	calls    int
+	err      error
+}
+
+func (m *mockInvalidationClient) GetInvalidationWithContext(ctx context.Context, input *cloudfront.GetInvalidationInput, opts ...request.Option) (*cloudfront.GetInvalidationOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	status := m.statuses[len(m.statuses)-1]
+	if m.calls < len(m.statuses) {
+		status = m.statuses[m.calls]
+	}
+	m.calls++
+
+	return &cloudfront.GetInvalidationOutput{
+		Invalidation: &cloudfront.Invalidation{Id: input.Id, Status: aws.String(status)},
+	}, nil
+}
+
+func TestGetInvalidation(t *testing.T) {
+	c := CloudFront{Service: &mockInvalidationClient{statuses: []string{"InProgress"}}}
+
+	if _, err := c.GetInvalidation(context.TODO(), "AAAABBBBCCCCDDDD", ""); err == nil {
+		t.Error("expected error for empty id, got nil")
+	}
+
+	out, err := c.GetInvalidation(context.TODO(), "AAAABBBBCCCCDDDD", "GGHHIIJJKKLLOO")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if aws.StringValue(out.Id) != "GGHHIIJJKKLLOO" || aws.StringValue(out.Status) != "InProgress" {
+		t.Errorf("unexpected invalidation %+v", out)
+	}
+
+	c = CloudFront{Service: &mockInvalidationClient{err: awserr.New(cloudfront.ErrCodeNoSuchInvalidation, "not found", nil)}}
+	if _, err := c.GetInvalidation(context.TODO(), "AAAABBBBCCCCDDDD", "GGHHIIJJKKLLOO"); err == nil {
+		t.Error("expected error for missing invalidation, got nil")
+	} else if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrNotFound {
+		t.Errorf("expected not found error, got %s", err)
+	}
+}
+
+func TestWaitUntilInvalidated(t *testing.T) {
+	client := &mockInvalidationClient{statuses: []string{"InProgress", "InProgress", "Completed"}}
+	c := CloudFront{Service: client}
+
+	out, err := c.WaitUntilInvalidated(context.TODO(), "AAAABBBBCCCCDDDD", "GGHHIIJJKKLLOO", time.Millisecond)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if aws.StringValue(out.Status) != StatusCompleted || client.calls != 3 {
+		t.Errorf("expected completed invalidation after 3 checks, got %s after %d", aws.StringValue(out.Status), client.calls)
+	}
+
+	// the wait is limited by the context
+	c = CloudFront{Service: &mockInvalidationClient{statuses: []string{"InProgress"}}}
+	ctx, cancel := context.WithTimeout(context.TODO(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := c.WaitUntilInvalidated(ctx, "AAAABBBBCCCCDDDD", "GGHHIIJJKKLLOO", 5*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
@@ -0,0 +1,238 @@
+package cloudfront
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/aws/aws-sdk-go/service/cloudfront/sign"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultSignedURLsKeyName is the name of the public key and key group for signed urls if it isn't configured
+const DefaultSignedURLsKeyName = "spinup-signed-urls"
+
+// EncodePublicKey returns the PEM encoded public key the way cloudfront expects it
+func EncodePublicKey(key *rsa.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+// getPublicKey returns the summary of the public key with the name, or nil if it doesn't exist
+func (c *CloudFront) getPublicKey(ctx context.Context, name string) (*cloudfront.PublicKeySummary, error) {
+	input := &cloudfront.ListPublicKeysInput{}
+	for {
+		out, err := c.Service.ListPublicKeysWithContext(ctx, input)
+		if err != nil {
+			return nil, ErrCode("failed to list public keys", err)
+		}
+
+		if out.PublicKeyList == nil {
+			return nil, nil
+		}
+
+		for _, k := range out.PublicKeyList.Items {
+			if aws.StringValue(k.Name) == name {
+				return k, nil
+			}
+		}
+
+		if out.PublicKeyList.NextMarker == nil {
+			return nil, nil
+		}
+		input.Marker = out.PublicKeyList.NextMarker
+	}
+}
+
+// GetPublicKeyID returns the id of the public key with the name, or an empty string if it doesn't exist
+func (c *CloudFront) GetPublicKeyID(ctx context.Context, name string) (string, error) {
+	if name == "" {
+		return "", apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	key, err := c.getPublicKey(ctx, name)
+	if err != nil || key == nil {
+		return "", err
+	}
+
+	return aws.StringValue(key.Id), nil
+}
+
+// EnsurePublicKey creates the public key with the name if it doesn't exist and returns its id.  Public keys can't be
+// changed, so it's a conflict if a key with the name already exists with a different encoded key.
+func (c *CloudFront) EnsurePublicKey(ctx context.Context, name, encodedKey string) (string, error) {
+	if name == "" || encodedKey == "" {
+		return "", apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	key, err := c.getPublicKey(ctx, name)
+	if err != nil {
+		return "", err
+	}
+
+	if key != nil {
+		if strings.TrimSpace(aws.StringValue(key.EncodedKey)) != strings.TrimSpace(encodedKey) {
+			return "", apierror.New(apierror.ErrConflict, "public key "+name+" already exists with a different key", nil)
+		}
+		return aws.StringValue(key.Id), nil
+	}
+
+	log.Infof("creating public key %s", name)
+
+	out, err := c.Service.CreatePublicKeyWithContext(ctx, &cloudfront.CreatePublicKeyInput{
+		PublicKeyConfig: &cloudfront.PublicKeyConfig{
+			CallerReference: aws.String(uuid.New().String()),
+			Comment:         aws.String("public key for signed urls and cookies"),
+			EncodedKey:      aws.String(encodedKey),
+			Name:            aws.String(name),
+		},
+	})
+	if err != nil {
+		return "", ErrCode("failed to create public key "+name, err)
+	}
+
+	return aws.StringValue(out.PublicKey.Id), nil
+}
+
+// getKeyGroup returns the key group with the name, or nil if it doesn't exist
+func (c *CloudFront) getKeyGroup(ctx context.Context, name string) (*cloudfront.KeyGroup, error) {
+	input := &cloudfront.ListKeyGroupsInput{}
+	for {
+		out, err := c.Service.ListKeyGroupsWithContext(ctx, input)
+		if err != nil {
+			return nil, ErrCode("failed to list key groups", err)
+		}
+
+		if out.KeyGroupList == nil {
+			return nil, nil
+		}
+
+		for _, g := range out.KeyGroupList.Items {
+			if g.KeyGroup == nil || g.KeyGroup.KeyGroupConfig == nil {
+				continue
+			}
+
+			if aws.StringValue(g.KeyGroup.KeyGroupConfig.Name) == name {
+				return g.KeyGroup, nil
+			}
+		}
+
+		if out.KeyGroupList.NextMarker == nil {
+			return nil, nil
+		}
+		input.Marker = out.KeyGroupList.NextMarker
+	}
+}
+
+// EnsureKeyGroup creates the key group with the name and the public key if it doesn't exist, or adds the public key
+// to the existing key group if it isn't in it.  It returns the id of the key group.
+func (c *CloudFront) EnsureKeyGroup(ctx context.Context, name, publicKeyId string) (string, error) {
+	if name == "" || publicKeyId == "" {
+		return "", apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	group, err := c.getKeyGroup(ctx, name)
+	if err != nil {
+		return "", err
+	}
+
+	if group == nil {
+		log.Infof("creating key group %s with public key %s", name, publicKeyId)
+
+		out, err := c.Service.CreateKeyGroupWithContext(ctx, &cloudfront.CreateKeyGroupInput{
+			KeyGroupConfig: &cloudfront.KeyGroupConfig{
+				Comment: aws.String("key group for signed urls and cookies"),
+				Items:   aws.StringSlice([]string{publicKeyId}),
+				Name:    aws.String(name),
+			},
+		})
+		if err != nil {
+			return "", ErrCode("failed to create key group "+name, err)
+		}
+
+		return aws.StringValue(out.KeyGroup.Id), nil
+	}
+
+	id := aws.StringValue(group.Id)
+	for _, k := range group.KeyGroupConfig.Items {
+		if aws.StringValue(k) == publicKeyId {
+			return id, nil
+		}
+	}
+
+	log.Infof("adding public key %s to key group %s (%s)", publicKeyId, name, id)
+
+	current, err := c.Service.GetKeyGroupConfigWithContext(ctx, &cloudfront.GetKeyGroupConfigInput{Id: aws.String(id)})
+	if err != nil {
+		return "", ErrCode("failed to get key group "+name, err)
+	}
+
+	config := *current.KeyGroupConfig
+	config.Items = append(aws.StringSlice(aws.StringValueSlice(config.Items)), aws.String(publicKeyId))
+
+	if _, err := c.Service.UpdateKeyGroupWithContext(ctx, &cloudfront.UpdateKeyGroupInput{
+		Id:             aws.String(id),
+		IfMatch:        current.ETag,
+		KeyGroupConfig: &config,
+	}); err != nil {
+		return "", ErrCode("failed to update key group "+name, err)
+	}
+
+	return id, nil
+}
+
+// trustedKeyGroups returns the trusted key groups of a cache behavior, an empty key group id disables them
+func trustedKeyGroups(keyGroupId string) *cloudfront.TrustedKeyGroups {
+	if keyGroupId == "" {
+		return &cloudfront.TrustedKeyGroups{Enabled: aws.Bool(false), Quantity: aws.Int64(0)}
+	}
+
+	return &cloudfront.TrustedKeyGroups{
+		Enabled:  aws.Bool(true),
+		Items:    aws.StringSlice([]string{keyGroupId}),
+		Quantity: aws.Int64(1),
+	}
+}
+
+// SetDefaultTrustedKeyGroup sets the trusted key group of the default cache behavior in a distribution config so all
+// of the requests that don't match another cache behavior must be signed, an empty key group id removes it
+func SetDefaultTrustedKeyGroup(config *cloudfront.DistributionConfig, keyGroupId string) {
+	// copy the default cache behavior so the read config isn't modified
+	behavior := cloudfront.DefaultCacheBehavior{}
+	if config.DefaultCacheBehavior != nil {
+		behavior = *config.DefaultCacheBehavior
+	}
+	behavior.TrustedKeyGroups = trustedKeyGroups(keyGroupId)
+	config.DefaultCacheBehavior = &behavior
+}
+
+// DefaultSigned returns true if the default cache behavior in a distribution config requires signed requests
+func DefaultSigned(config *cloudfront.DistributionConfig) bool {
+	if config.DefaultCacheBehavior == nil || config.DefaultCacheBehavior.TrustedKeyGroups == nil {
+		return false
+	}
+	return aws.BoolValue(config.DefaultCacheBehavior.TrustedKeyGroups.Enabled)
+}
+
+// SignURL returns the url signed with a canned policy that expires at the passed time
+func SignURL(keyId string, key *rsa.PrivateKey, url string, expires time.Time) (string, error) {
+	return sign.NewURLSigner(keyId, key).Sign(url, expires)
+}
+
+// SignCookies returns the signed cookies that allow access to the resource until the passed time.  The resource can
+// contain wildcards, ie. https://www.example.com/private/*
+func SignCookies(keyId string, key *rsa.PrivateKey, resource string, expires time.Time) ([]*http.Cookie, error) {
+	return sign.NewCookieSigner(keyId, key).SignWithPolicy(sign.NewCannedPolicy(resource, expires))
+}
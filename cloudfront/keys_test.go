@@ -0,0 +1,211 @@
+package cloudfront
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/aws/aws-sdk-go/service/cloudfront/cloudfrontiface"
+)
+
+// mockKeysClient keeps the public keys and key groups by id and records the calls
+type mockKeysClient struct {
+	cloudfrontiface.CloudFrontAPI
+	keys   map[string]*cloudfront.PublicKeyConfig
+	groups map[string]*cloudfront.KeyGroupConfig
+	calls  []string
+}
+
+func (m *mockKeysClient) ListPublicKeysWithContext(ctx context.Context, input *cloudfront.ListPublicKeysInput, opts ...request.Option) (*cloudfront.ListPublicKeysOutput, error) {
+	items := []*cloudfront.PublicKeySummary{}
+	for id, k := range m.keys {
+		items = append(items, &cloudfront.PublicKeySummary{Id: aws.String(id), Name: k.Name, EncodedKey: k.EncodedKey})
+	}
+	return &cloudfront.ListPublicKeysOutput{PublicKeyList: &cloudfront.PublicKeyList{Items: items}}, nil
+}
+
+func (m *mockKeysClient) CreatePublicKeyWithContext(ctx context.Context, input *cloudfront.CreatePublicKeyInput, opts ...request.Option) (*cloudfront.CreatePublicKeyOutput, error) {
+	m.calls = append(m.calls, "createkey")
+	m.keys["key1"] = input.PublicKeyConfig
+	return &cloudfront.CreatePublicKeyOutput{PublicKey: &cloudfront.PublicKey{Id: aws.String("key1")}}, nil
+}
+
+func (m *mockKeysClient) ListKeyGroupsWithContext(ctx context.Context, input *cloudfront.ListKeyGroupsInput, opts ...request.Option) (*cloudfront.ListKeyGroupsOutput, error) {
+	items := []*cloudfront.KeyGroupSummary{}
+	for id, g := range m.groups {
+		items = append(items, &cloudfront.KeyGroupSummary{KeyGroup: &cloudfront.KeyGroup{Id: aws.String(id), KeyGroupConfig: g}})
+	}
+	return &cloudfront.ListKeyGroupsOutput{KeyGroupList: &cloudfront.KeyGroupList{Items: items}}, nil
+}
+
+func (m *mockKeysClient) CreateKeyGroupWithContext(ctx context.Context, input *cloudfront.CreateKeyGroupInput, opts ...request.Option) (*cloudfront.CreateKeyGroupOutput, error) {
+	m.calls = append(m.calls, "creategroup")
+	m.groups["group1"] = input.KeyGroupConfig
+	return &cloudfront.CreateKeyGroupOutput{KeyGroup: &cloudfront.KeyGroup{Id: aws.String("group1")}}, nil
+}
+
+func (m *mockKeysClient) GetKeyGroupConfigWithContext(ctx context.Context, input *cloudfront.GetKeyGroupConfigInput, opts ...request.Option) (*cloudfront.GetKeyGroupConfigOutput, error) {
+	g, ok := m.groups[aws.StringValue(input.Id)]
+	if !ok {
+		return nil, awserr.New(cloudfront.ErrCodeNoSuchResource, "not found", nil)
+	}
+	return &cloudfront.GetKeyGroupConfigOutput{ETag: aws.String("ETAG"), KeyGroupConfig: g}, nil
+}
+
+func (m *mockKeysClient) UpdateKeyGroupWithContext(ctx context.Context, input *cloudfront.UpdateKeyGroupInput, opts ...request.Option) (*cloudfront.UpdateKeyGroupOutput, error) {
+	if aws.StringValue(input.IfMatch) != "ETAG" {
+		return nil, awserr.New(cloudfront.ErrCodePreconditionFailed, "etag mismatch", nil)
+	}
+
+	m.calls = append(m.calls, "updategroup")
+	m.groups[aws.StringValue(input.Id)] = input.KeyGroupConfig
+	return &cloudfront.UpdateKeyGroupOutput{}, nil
+}
+
+func TestEnsurePublicKey(t *testing.T) {
+	m := &mockKeysClient{keys: map[string]*cloudfront.PublicKeyConfig{}}
+	c := CloudFront{Service: m}
+
+	for i, calls := range [][]string{{"createkey"}, nil} {
+		m.calls = nil
+
+		id, err := c.EnsurePublicKey(context.TODO(), "spinup-signed-urls", "PUBLICKEY\n")
+		if err != nil {
+			t.Fatalf("%d: expected nil error, got %s", i, err)
+		}
+
+		if id != "key1" {
+			t.Errorf("%d: expected key1, got %s", i, id)
+		}
+
+		if !reflect.DeepEqual(calls, m.calls) {
+			t.Errorf("%d: expected calls %v, got %v", i, calls, m.calls)
+		}
+	}
+
+	if id, err := c.GetPublicKeyID(context.TODO(), "spinup-signed-urls"); err != nil || id != "key1" {
+		t.Errorf("expected key1 and nil error, got %s and %v", id, err)
+	}
+
+	if id, err := c.GetPublicKeyID(context.TODO(), "missing"); err != nil || id != "" {
+		t.Errorf("expected no key and nil error, got %s and %v", id, err)
+	}
+
+	_, err := c.EnsurePublicKey(context.TODO(), "spinup-signed-urls", "OTHERKEY")
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrConflict {
+		t.Errorf("expected conflict error for a different key, got %v", err)
+	}
+}
+
+func TestEnsureKeyGroup(t *testing.T) {
+	m := &mockKeysClient{groups: map[string]*cloudfront.KeyGroupConfig{}}
+	c := CloudFront{Service: m}
+
+	tests := []struct {
+		key   string
+		calls []string
+		items []string
+	}{
+		{key: "key1", calls: []string{"creategroup"}, items: []string{"key1"}},
+		{key: "key1", calls: nil, items: []string{"key1"}},
+		{key: "key2", calls: []string{"updategroup"}, items: []string{"key1", "key2"}},
+	}
+
+	for _, test := range tests {
+		m.calls = nil
+
+		id, err := c.EnsureKeyGroup(context.TODO(), "spinup-signed-urls", test.key)
+		if err != nil {
+			t.Fatalf("expected nil error, got %s", err)
+		}
+
+		if id != "group1" {
+			t.Errorf("expected group1, got %s", id)
+		}
+
+		if !reflect.DeepEqual(test.calls, m.calls) {
+			t.Errorf("expected calls %v, got %v", test.calls, m.calls)
+		}
+
+		if items := aws.StringValueSlice(m.groups["group1"].Items); !reflect.DeepEqual(test.items, items) {
+			t.Errorf("expected keys %v, got %v", test.items, items)
+		}
+	}
+
+	if _, err := c.EnsureKeyGroup(context.TODO(), "spinup-signed-urls", ""); err == nil {
+		t.Error("expected error for empty public key id, got nil")
+	}
+}
+
+func TestSetDefaultTrustedKeyGroup(t *testing.T) {
+	behavior := &cloudfront.DefaultCacheBehavior{TargetOriginId: aws.String("www.example.com")}
+	config := &cloudfront.DistributionConfig{DefaultCacheBehavior: behavior}
+
+	SetDefaultTrustedKeyGroup(config, "group1")
+	if !DefaultSigned(config) {
+		t.Errorf("expected the default cache behavior to be signed, got %+v", config.DefaultCacheBehavior)
+	}
+
+	if items := aws.StringValueSlice(config.DefaultCacheBehavior.TrustedKeyGroups.Items); !reflect.DeepEqual(items, []string{"group1"}) {
+		t.Errorf("expected trusted key group group1, got %v", items)
+	}
+
+	if behavior.TrustedKeyGroups != nil {
+		t.Errorf("expected the original default cache behavior to be left as is, got %+v", behavior)
+	}
+
+	SetDefaultTrustedKeyGroup(config, "")
+	if DefaultSigned(config) {
+		t.Errorf("expected the default cache behavior not to be signed, got %+v", config.DefaultCacheBehavior)
+	}
+}
+
+func TestSignURLAndCookies(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+
+	if _, err := EncodePublicKey(&key.PublicKey); err != nil {
+		t.Errorf("expected nil error encoding the public key, got %s", err)
+	}
+
+	expires := time.Now().Add(time.Hour)
+
+	signed, err := SignURL("key1", key, "https://www.example.com/private/syllabus.pdf", expires)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("expected a valid url, got %s", signed)
+	}
+
+	if q := u.Query(); q.Get("Key-Pair-Id") != "key1" || q.Get("Signature") == "" || q.Get("Expires") == "" {
+		t.Errorf("expected signed url query, got %s", u.RawQuery)
+	}
+
+	cookies, err := SignCookies("key1", key, "https://www.example.com/private/*", expires)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	names := []string{}
+	for _, c := range cookies {
+		names = append(names, c.Name)
+	}
+
+	if expected := []string{"CloudFront-Policy", "CloudFront-Signature", "CloudFront-Key-Pair-Id"}; !reflect.DeepEqual(expected, names) {
+		t.Errorf("expected cookies %v, got %v", expected, names)
+	}
+}
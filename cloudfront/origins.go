@@ -0,0 +1,70 @@
+package cloudfront
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	log "github.com/sirupsen/logrus"
+)
+
+// websiteOrigin returns the origin of a distribution that serves the website bucket, nil if there isn't one
+func websiteOrigin(config *cloudfront.DistributionConfig, website string) *cloudfront.Origin {
+	if config == nil || config.Origins == nil {
+		return nil
+	}
+
+	for _, o := range config.Origins.Items {
+		if aws.StringValue(o.Id) == website {
+			return o
+		}
+	}
+
+	return nil
+}
+
+// OriginPath returns the path of the website origin of a distribution, the prefix in the bucket the website is served
+// from.  It's empty if the website is served from the root of the bucket.
+func OriginPath(config *cloudfront.DistributionConfig, website string) string {
+	if o := websiteOrigin(config, website); o != nil {
+		return aws.StringValue(o.OriginPath)
+	}
+	return ""
+}
+
+// UpdateOriginPath sets the path of the website origin of a distribution, an empty path serves the website from the
+// root of the bucket.  The config is only updated if it hasn't changed since it was read.
+func (c *CloudFront) UpdateOriginPath(ctx context.Context, id, website, path string) (*cloudfront.Distribution, error) {
+	if id == "" || website == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("updating origin path for cloudfront distribution Id: %s to %q", id, path)
+
+	config, err := c.Service.GetDistributionConfigWithContext(ctx, &cloudfront.GetDistributionConfigInput{Id: aws.String(id)})
+	if err != nil {
+		return nil, ErrCode("failed to get details about cloudfront distribution Id: "+id, err)
+	}
+
+	origin := websiteOrigin(config.DistributionConfig, website)
+	if origin == nil {
+		msg := fmt.Sprintf("origin %s not found in cloudfront distribution Id: %s", website, id)
+		return nil, apierror.New(apierror.ErrNotFound, msg, nil)
+	}
+	origin.OriginPath = aws.String(path)
+
+	out, err := c.Service.UpdateDistributionWithContext(ctx, &cloudfront.UpdateDistributionInput{
+		DistributionConfig: config.DistributionConfig,
+		IfMatch:            config.ETag,
+		Id:                 aws.String(id),
+	})
+	if err != nil {
+		return nil, ErrCode("failed to update origin path for cloudfront distribution Id: "+id, err)
+	}
+
+	c.Index.Invalidate()
+
+	return out.Distribution, nil
+}
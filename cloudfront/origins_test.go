@@ -0,0 +1,41 @@
+package cloudfront
+
+import (
+	"context"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+)
+
+func TestUpdateOriginPath(t *testing.T) {
+	// the mock returns the origins of the test distribution, so the path is reset for the other tests
+	defer func() { testDistribution3.Origins.Items[0].OriginPath = nil }()
+
+	c := CloudFront{Service: newmockCloudFrontClient(t, nil)}
+
+	out, err := c.UpdateOriginPath(context.TODO(), "IIIIJJJJKKKKLLLL", "foobar3.bulldogs.cloud", "/releases/v2")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if path := OriginPath(out.DistributionConfig, "foobar3.bulldogs.cloud"); path != "/releases/v2" {
+		t.Errorf("expected origin path /releases/v2, got %q", path)
+	}
+
+	if path := OriginPath(out.DistributionConfig, "foobar1.bulldogs.cloud"); path != "" {
+		t.Errorf("expected empty origin path for another website, got %q", path)
+	}
+
+	_, err = c.UpdateOriginPath(context.TODO(), "IIIIJJJJKKKKLLLL", "foobar1.bulldogs.cloud", "/releases/v2")
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrNotFound {
+		t.Errorf("expected not found error for a missing origin, got %v", err)
+	}
+
+	if _, err := c.UpdateOriginPath(context.TODO(), "", "foobar3.bulldogs.cloud", ""); err == nil {
+		t.Error("expected error for empty id, got nil")
+	}
+
+	if _, err := c.UpdateOriginPath(context.TODO(), "NOTFOUND", "foobar3.bulldogs.cloud", ""); err == nil {
+		t.Error("expected error for missing distribution, got nil")
+	}
+}
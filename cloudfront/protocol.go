@@ -0,0 +1,115 @@
+package cloudfront
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultHttpVersion is the maximum http version of a website distribution if it isn't configured
+const DefaultHttpVersion = cloudfront.HttpVersionHttp2
+
+// HttpVersions are the supported maximum http versions of a distribution
+var HttpVersions = cloudfront.HttpVersion_Values()
+
+// ValidHttpVersion returns true if the version is a supported maximum http version of a distribution
+func ValidHttpVersion(version string) bool {
+	for _, v := range HttpVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateDistributionDefaults validates the configured defaults for website distributions
+func ValidateDistributionDefaults(d *common.DistributionDefaults) error {
+	if d != nil && d.HttpVersion != "" && !ValidHttpVersion(d.HttpVersion) {
+		return fmt.Errorf("invalid distribution defaults http version %s, expected one of %v", d.HttpVersion, HttpVersions)
+	}
+	return nil
+}
+
+// ProtocolSettings are the http version and IPv6 settings of a website distribution, the settings that aren't set
+// are left as is
+type ProtocolSettings struct {
+	// HttpVersion is the maximum http version of the distribution, one of http1.1, http2, http3 or http2and3
+	HttpVersion string `json:",omitempty"`
+	// IsIPV6Enabled enables or disables IPv6 for the distribution
+	IsIPV6Enabled *bool `json:",omitempty"`
+}
+
+// Empty returns true if none of the settings are set
+func (p *ProtocolSettings) Empty() bool {
+	return p == nil || (p.HttpVersion == "" && p.IsIPV6Enabled == nil)
+}
+
+// Apply sets the protocol settings in a distribution config
+func (p *ProtocolSettings) Apply(config *cloudfront.DistributionConfig) {
+	if p.Empty() {
+		return
+	}
+
+	if p.HttpVersion != "" {
+		config.HttpVersion = aws.String(p.HttpVersion)
+	}
+
+	if p.IsIPV6Enabled != nil {
+		config.IsIPV6Enabled = aws.Bool(*p.IsIPV6Enabled)
+	}
+}
+
+// defaultProtocolSettings returns the protocol settings for new website distributions, from the configured defaults
+func (c *CloudFront) defaultProtocolSettings() *ProtocolSettings {
+	settings := &ProtocolSettings{
+		HttpVersion:   DefaultHttpVersion,
+		IsIPV6Enabled: aws.Bool(true),
+	}
+
+	if d := c.DistributionDefaults; d != nil {
+		if d.HttpVersion != "" {
+			settings.HttpVersion = d.HttpVersion
+		}
+
+		if d.IsIPV6Enabled != nil {
+			settings.IsIPV6Enabled = aws.Bool(*d.IsIPV6Enabled)
+		}
+	}
+
+	return settings
+}
+
+// UpdateProtocolSettings sets the http version and IPv6 settings of a website distribution.  The config is only
+// updated if it hasn't changed since it was read.
+func (c *CloudFront) UpdateProtocolSettings(ctx context.Context, id string, settings *ProtocolSettings) (*cloudfront.Distribution, error) {
+	if id == "" || settings.Empty() {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("updating protocol settings for cloudfront distribution Id: %s to http version '%s', ipv6 %v", id, settings.HttpVersion, aws.BoolValue(settings.IsIPV6Enabled))
+
+	config, err := c.Service.GetDistributionConfigWithContext(ctx, &cloudfront.GetDistributionConfigInput{Id: aws.String(id)})
+	if err != nil {
+		return nil, ErrCode("failed to get details about cloudfront distribution Id: "+id, err)
+	}
+
+	settings.Apply(config.DistributionConfig)
+
+	out, err := c.Service.UpdateDistributionWithContext(ctx, &cloudfront.UpdateDistributionInput{
+		DistributionConfig: config.DistributionConfig,
+		IfMatch:            config.ETag,
+		Id:                 aws.String(id),
+	})
+	if err != nil {
+		return nil, ErrCode("failed to update protocol settings for cloudfront distribution Id: "+id, err)
+	}
+
+	c.Index.Invalidate()
+
+	return out.Distribution, nil
+}
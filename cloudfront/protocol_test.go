@@ -0,0 +1,71 @@
+package cloudfront
+
+import (
+	"context"
+	"testing"
+
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+)
+
+func TestValidateDistributionDefaults(t *testing.T) {
+	tests := []struct {
+		defaults *common.DistributionDefaults
+		err      bool
+	}{
+		{defaults: nil},
+		{defaults: &common.DistributionDefaults{}},
+		{defaults: &common.DistributionDefaults{HttpVersion: "http2and3", IsIPV6Enabled: aws.Bool(false)}},
+		{defaults: &common.DistributionDefaults{HttpVersion: "http4"}, err: true},
+	}
+
+	for _, test := range tests {
+		err := ValidateDistributionDefaults(test.defaults)
+		if test.err && err == nil {
+			t.Errorf("expected error for %+v, got nil", test.defaults)
+		} else if !test.err && err != nil {
+			t.Errorf("expected nil error for %+v, got %s", test.defaults, err)
+		}
+	}
+}
+
+func TestProtocolSettingsApply(t *testing.T) {
+	config := &cloudfront.DistributionConfig{HttpVersion: aws.String("http2"), IsIPV6Enabled: aws.Bool(true)}
+
+	(&ProtocolSettings{HttpVersion: "http3"}).Apply(config)
+	if aws.StringValue(config.HttpVersion) != "http3" || !aws.BoolValue(config.IsIPV6Enabled) {
+		t.Errorf("expected http3 with ipv6 left as is, got %s and %t", aws.StringValue(config.HttpVersion), aws.BoolValue(config.IsIPV6Enabled))
+	}
+
+	(&ProtocolSettings{IsIPV6Enabled: aws.Bool(false)}).Apply(config)
+	if aws.StringValue(config.HttpVersion) != "http3" || aws.BoolValue(config.IsIPV6Enabled) {
+		t.Errorf("expected ipv6 disabled with http version left as is, got %s and %t", aws.StringValue(config.HttpVersion), aws.BoolValue(config.IsIPV6Enabled))
+	}
+
+	var empty *ProtocolSettings
+	if !empty.Empty() || !(&ProtocolSettings{}).Empty() {
+		t.Error("expected nil and zero protocol settings to be empty")
+	}
+}
+
+func TestUpdateProtocolSettings(t *testing.T) {
+	c := CloudFront{Service: newmockCloudFrontClient(t, nil)}
+
+	out, err := c.UpdateProtocolSettings(context.TODO(), "AAAABBBBCCCCDDDD", &ProtocolSettings{HttpVersion: "http2and3", IsIPV6Enabled: aws.Bool(false)})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if config := out.DistributionConfig; aws.StringValue(config.HttpVersion) != "http2and3" || aws.BoolValue(config.IsIPV6Enabled) {
+		t.Errorf("expected http2and3 without ipv6, got %s and %t", aws.StringValue(config.HttpVersion), aws.BoolValue(config.IsIPV6Enabled))
+	}
+
+	if _, err := c.UpdateProtocolSettings(context.TODO(), "AAAABBBBCCCCDDDD", &ProtocolSettings{}); err == nil {
+		t.Error("expected error for empty protocol settings, got nil")
+	}
+
+	if _, err := c.UpdateProtocolSettings(context.TODO(), "NOTFOUND", &ProtocolSettings{HttpVersion: "http2"}); err == nil {
+		t.Error("expected error for missing distribution, got nil")
+	}
+}
@@ -0,0 +1,30 @@
+package cloudwatch
+
+import (
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	log "github.com/sirupsen/logrus"
+)
+
+// CloudWatch is a wrapper around the aws cloudwatch service
+type CloudWatch struct {
+	Service cloudwatchiface.CloudWatchAPI
+}
+
+// NewSession creates a new cloudwatch session
+func NewSession(sess *session.Session, account common.Account) CloudWatch {
+	c := CloudWatch{}
+	if sess == nil {
+		log.Infof("creating new aws session for cloudwatch with key id %s in region %s", account.Akid, account.Region)
+		sess = session.Must(session.NewSession(&aws.Config{
+			Credentials: credentials.NewStaticCredentials(account.Akid, account.Secret, ""),
+			Region:      aws.String(account.Region),
+		}))
+	}
+	c.Service = cloudwatch.New(sess)
+	return c
+}
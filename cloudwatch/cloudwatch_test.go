@@ -0,0 +1,31 @@
+package cloudwatch
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+)
+
+// mockCloudWatchClient is a fake cloudwatch client
+type mockCloudWatchClient struct {
+	cloudwatchiface.CloudWatchAPI
+	t   *testing.T
+	err error
+}
+
+func newMockCloudWatchClient(t *testing.T, err error) cloudwatchiface.CloudWatchAPI {
+	return &mockCloudWatchClient{
+		t:   t,
+		err: err,
+	}
+}
+
+func TestNewSession(t *testing.T) {
+	e := NewSession(nil, common.Account{})
+	to := reflect.TypeOf(e).String()
+	if to != "cloudwatch.CloudWatch" {
+		t.Errorf("expected type to be 'cloudwatch.CloudWatch', got %s", to)
+	}
+}
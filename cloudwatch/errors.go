@@ -0,0 +1,77 @@
+package cloudwatch
+
+import (
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/pkg/errors"
+)
+
+// ErrCode processes the error codes comming back from cloudwatch and converts them into apierror, a
+// standardized form consumable by downstream systems.
+func ErrCode(msg string, err error) error {
+	if aerr, ok := errors.Cause(err).(awserr.Error); ok {
+		switch aerr.Code() {
+		case
+			// Access denied errors aren't modeled by the cloudwatch api, they are returned when the
+			// caller isn't allowed to read the metrics.
+			"AccessDenied",
+			"AccessDeniedException":
+
+			return apierror.New(apierror.ErrForbidden, msg, aerr)
+		case
+			// cloudwatch.ErrCodeResourceNotFound for service response error code
+			// "ResourceNotFound".
+			//
+			// The named resource does not exist.
+			cloudwatch.ErrCodeResourceNotFound,
+
+			// cloudwatch.ErrCodeResourceNotFoundException for service response error code
+			// "ResourceNotFoundException".
+			//
+			// The named resource does not exist.
+			cloudwatch.ErrCodeResourceNotFoundException:
+
+			return apierror.New(apierror.ErrNotFound, msg, aerr)
+		case
+			// cloudwatch.ErrCodeConcurrentModificationException for service response error code
+			// "ConcurrentModificationException".
+			//
+			// More than one process tried to modify a resource at the same time.
+			cloudwatch.ErrCodeConcurrentModificationException:
+
+			return apierror.New(apierror.ErrConflict, msg, aerr)
+		case
+			// cloudwatch.ErrCodeLimitExceededException for service response error code
+			// "LimitExceededException".
+			//
+			// The operation exceeded one or more limits.
+			cloudwatch.ErrCodeLimitExceededException,
+
+			// cloudwatch.ErrCodeLimitExceededFault for service response error code
+			// "LimitExceeded".
+			//
+			// The quota for alarms for this customer has already been reached.
+			cloudwatch.ErrCodeLimitExceededFault,
+
+			// Throttling errors aren't modeled by the cloudwatch api, they are returned when the
+			// request rate is too high.
+			"Throttling":
+
+			return apierror.New(apierror.ErrLimitExceeded, msg, aerr)
+		case
+			// cloudwatch.ErrCodeInternalServiceFault for service response error code
+			// "InternalServiceError".
+			//
+			// Request processing has failed due to some unknown error, exception, or failure.
+			cloudwatch.ErrCodeInternalServiceFault:
+
+			return apierror.New(apierror.ErrServiceUnavailable, msg, aerr)
+		default:
+			m := msg + ": " + aerr.Message()
+			return apierror.New(apierror.ErrBadRequest, m, aerr)
+		}
+	}
+
+	return apierror.New(apierror.ErrInternalError, msg, err)
+}
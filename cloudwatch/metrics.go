@@ -0,0 +1,233 @@
+package cloudwatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// s3Namespace is the namespace of the daily s3 storage metrics
+	s3Namespace = "AWS/S3"
+	// storageMetricsPeriod is the period of the s3 storage metrics, they are published once a day
+	storageMetricsPeriod = 86400
+	// storageMetricsWindow is how far back to look for the latest storage metrics, they can be a couple of days old
+	storageMetricsWindow = 3 * 24 * time.Hour
+	// requestMetricsPeriod is the period the s3 request metrics are summed over
+	requestMetricsPeriod = 86400
+	// entireBucketFilter is the id of the metrics configuration s3 creates when request metrics are enabled for the
+	// whole bucket from the console
+	entireBucketFilter = "EntireBucket"
+)
+
+// BucketStorage is the number of objects in a bucket and their total size from the daily s3 storage metrics
+type BucketStorage struct {
+	Objects   int64
+	Bytes     int64
+	Timestamp time.Time
+}
+
+// BucketRequests are the bytes downloaded from a bucket and the number of requests over a window, from the s3 request
+// metrics
+type BucketRequests struct {
+	// FilterId is the id of the bucket's metrics configuration the requests were read from
+	FilterId        string
+	BytesDownloaded int64
+	Requests        int64
+	GetRequests     int64
+}
+
+// GetBucketRequests gets the bytes downloaded from a bucket and the number of requests between the start and end times.
+// Request metrics are only published for buckets with a metrics configuration, the configuration for the entire bucket
+// is used if there's more than one.  Nil is returned if request metrics aren't enabled for the bucket.
+func (c *CloudWatch) GetBucketRequests(ctx context.Context, bucket string, start, end time.Time) (*BucketRequests, error) {
+	if bucket == "" || !start.Before(end) {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", errors.New("missing bucket name or invalid window"))
+	}
+
+	log.Infof("getting request metrics for bucket %s from %s to %s", bucket, start.Format(time.RFC3339), end.Format(time.RFC3339))
+
+	filters := []string{}
+	err := c.Service.ListMetricsPagesWithContext(ctx, &cloudwatch.ListMetricsInput{
+		Namespace:  aws.String(s3Namespace),
+		MetricName: aws.String("AllRequests"),
+		Dimensions: []*cloudwatch.DimensionFilter{
+			{Name: aws.String("BucketName"), Value: aws.String(bucket)},
+		},
+	}, func(out *cloudwatch.ListMetricsOutput, lastPage bool) bool {
+		for _, m := range out.Metrics {
+			for _, d := range m.Dimensions {
+				if aws.StringValue(d.Name) == "FilterId" {
+					filters = append(filters, aws.StringValue(d.Value))
+				}
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, ErrCode("failed to list request metrics for bucket "+bucket, err)
+	}
+
+	if len(filters) == 0 {
+		log.Debugf("no request metrics found for bucket %s", bucket)
+		return nil, nil
+	}
+
+	filter := filters[0]
+	for _, f := range filters {
+		if f == entireBucketFilter {
+			filter = f
+			break
+		}
+	}
+
+	out, err := c.Service.GetMetricDataWithContext(ctx, &cloudwatch.GetMetricDataInput{
+		StartTime: aws.Time(start),
+		EndTime:   aws.Time(end),
+		MetricDataQueries: []*cloudwatch.MetricDataQuery{
+			requestQuery("bytes", bucket, filter, "BytesDownloaded"),
+			requestQuery("requests", bucket, filter, "AllRequests"),
+			requestQuery("gets", bucket, filter, "GetRequests"),
+		},
+	})
+	if err != nil {
+		return nil, ErrCode("failed to get request metrics for bucket "+bucket, err)
+	}
+
+	requests := &BucketRequests{FilterId: filter}
+	for _, r := range out.MetricDataResults {
+		var sum float64
+		for _, v := range r.Values {
+			sum += aws.Float64Value(v)
+		}
+
+		switch aws.StringValue(r.Id) {
+		case "bytes":
+			requests.BytesDownloaded = int64(sum)
+		case "requests":
+			requests.Requests = int64(sum)
+		case "gets":
+			requests.GetRequests = int64(sum)
+		}
+	}
+
+	return requests, nil
+}
+
+// requestQuery is a query for the daily sum of an s3 request metric for a bucket and metrics configuration
+func requestQuery(id, bucket, filter, metric string) *cloudwatch.MetricDataQuery {
+	return &cloudwatch.MetricDataQuery{
+		Id: aws.String(id),
+		MetricStat: &cloudwatch.MetricStat{
+			Metric: &cloudwatch.Metric{
+				Namespace:  aws.String(s3Namespace),
+				MetricName: aws.String(metric),
+				Dimensions: []*cloudwatch.Dimension{
+					{Name: aws.String("BucketName"), Value: aws.String(bucket)},
+					{Name: aws.String("FilterId"), Value: aws.String(filter)},
+				},
+			},
+			Period: aws.Int64(requestMetricsPeriod),
+			Stat:   aws.String(cloudwatch.StatisticSum),
+		},
+	}
+}
+
+// GetBucketStorage gets the latest daily storage metrics for a bucket.  The size is reported separately for each
+// storage class, so it's the sum of the sizes of all of the storage types with metrics.  Nil is returned if there
+// aren't any metrics for the bucket yet, which is the case for the first day or two after it's created.
+func (c *CloudWatch) GetBucketStorage(ctx context.Context, bucket string) (*BucketStorage, error) {
+	if bucket == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", errors.New("missing bucket name"))
+	}
+
+	log.Infof("getting storage metrics for bucket %s", bucket)
+
+	queries := []*cloudwatch.MetricDataQuery{
+		storageQuery("objects", bucket, "NumberOfObjects", "AllStorageTypes"),
+	}
+
+	err := c.Service.ListMetricsPagesWithContext(ctx, &cloudwatch.ListMetricsInput{
+		Namespace:  aws.String(s3Namespace),
+		MetricName: aws.String("BucketSizeBytes"),
+		Dimensions: []*cloudwatch.DimensionFilter{
+			{Name: aws.String("BucketName"), Value: aws.String(bucket)},
+		},
+	}, func(out *cloudwatch.ListMetricsOutput, lastPage bool) bool {
+		for _, m := range out.Metrics {
+			for _, d := range m.Dimensions {
+				if aws.StringValue(d.Name) == "StorageType" {
+					id := fmt.Sprintf("size%d", len(queries))
+					queries = append(queries, storageQuery(id, bucket, "BucketSizeBytes", aws.StringValue(d.Value)))
+				}
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, ErrCode("failed to list storage metrics for bucket "+bucket, err)
+	}
+
+	now := time.Now().UTC()
+	out, err := c.Service.GetMetricDataWithContext(ctx, &cloudwatch.GetMetricDataInput{
+		StartTime:         aws.Time(now.Add(-storageMetricsWindow)),
+		EndTime:           aws.Time(now),
+		MetricDataQueries: queries,
+		ScanBy:            aws.String(cloudwatch.ScanByTimestampDescending),
+	})
+	if err != nil {
+		return nil, ErrCode("failed to get storage metrics for bucket "+bucket, err)
+	}
+
+	var storage *BucketStorage
+	var bytes int64
+	for _, r := range out.MetricDataResults {
+		// the results are newest first, only the latest value of each metric is used
+		if len(r.Values) == 0 || len(r.Timestamps) == 0 {
+			continue
+		}
+
+		if aws.StringValue(r.Id) == "objects" {
+			storage = &BucketStorage{
+				Objects:   int64(aws.Float64Value(r.Values[0])),
+				Timestamp: aws.TimeValue(r.Timestamps[0]),
+			}
+			continue
+		}
+
+		bytes += int64(aws.Float64Value(r.Values[0]))
+	}
+
+	if storage == nil {
+		log.Debugf("no storage metrics found for bucket %s", bucket)
+		return nil, nil
+	}
+	storage.Bytes = bytes
+
+	return storage, nil
+}
+
+// storageQuery is a query for the daily average of an s3 storage metric for a bucket and storage type
+func storageQuery(id, bucket, metric, storageType string) *cloudwatch.MetricDataQuery {
+	return &cloudwatch.MetricDataQuery{
+		Id: aws.String(id),
+		MetricStat: &cloudwatch.MetricStat{
+			Metric: &cloudwatch.Metric{
+				Namespace:  aws.String(s3Namespace),
+				MetricName: aws.String(metric),
+				Dimensions: []*cloudwatch.Dimension{
+					{Name: aws.String("BucketName"), Value: aws.String(bucket)},
+					{Name: aws.String("StorageType"), Value: aws.String(storageType)},
+				},
+			},
+			Period: aws.Int64(storageMetricsPeriod),
+			Stat:   aws.String(cloudwatch.StatisticAverage),
+		},
+	}
+}
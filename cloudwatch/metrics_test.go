@@ -0,0 +1,191 @@
+package cloudwatch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+)
+
+var testMetricsTimestamp = time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+func (m *mockCloudWatchClient) ListMetricsPagesWithContext(ctx aws.Context, input *cloudwatch.ListMetricsInput, fn func(*cloudwatch.ListMetricsOutput, bool) bool, opts ...request.Option) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	if aws.StringValue(input.Dimensions[0].Value) != "testbucket" {
+		fn(&cloudwatch.ListMetricsOutput{}, true)
+		return nil
+	}
+
+	out := &cloudwatch.ListMetricsOutput{}
+	for _, storageType := range []string{"StandardStorage", "StandardIAStorage"} {
+		out.Metrics = append(out.Metrics, &cloudwatch.Metric{
+			Namespace:  input.Namespace,
+			MetricName: input.MetricName,
+			Dimensions: []*cloudwatch.Dimension{
+				{Name: aws.String("BucketName"), Value: aws.String("testbucket")},
+				{Name: aws.String("StorageType"), Value: aws.String(storageType)},
+			},
+		})
+	}
+
+	fn(out, true)
+	return nil
+}
+
+func (m *mockCloudWatchClient) GetMetricDataWithContext(ctx aws.Context, input *cloudwatch.GetMetricDataInput, opts ...request.Option) (*cloudwatch.GetMetricDataOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if len(input.MetricDataQueries) == 1 {
+		return &cloudwatch.GetMetricDataOutput{
+			MetricDataResults: []*cloudwatch.MetricDataResult{{Id: aws.String("objects")}},
+		}, nil
+	}
+
+	out := &cloudwatch.GetMetricDataOutput{}
+	for _, q := range input.MetricDataQueries {
+		value := float64(1024)
+		if aws.StringValue(q.MetricStat.Metric.MetricName) == "NumberOfObjects" {
+			value = 42
+		}
+
+		out.MetricDataResults = append(out.MetricDataResults, &cloudwatch.MetricDataResult{
+			Id:         q.Id,
+			Timestamps: []*time.Time{aws.Time(testMetricsTimestamp), aws.Time(testMetricsTimestamp.Add(-24 * time.Hour))},
+			Values:     []*float64{aws.Float64(value), aws.Float64(1)},
+		})
+	}
+
+	return out, nil
+}
+
+func TestGetBucketStorage(t *testing.T) {
+	c := CloudWatch{Service: newMockCloudWatchClient(t, nil)}
+
+	// test success
+	out, err := c.GetBucketStorage(context.TODO(), "testbucket")
+	if err != nil {
+		t.Fatalf("expected nil error, got: %s", err)
+	}
+
+	expected := BucketStorage{Objects: 42, Bytes: 2048, Timestamp: testMetricsTimestamp}
+	if out == nil || *out != expected {
+		t.Errorf("expected %+v, got %+v", expected, out)
+	}
+
+	// test a bucket without metrics
+	out, err = c.GetBucketStorage(context.TODO(), "newbucket")
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if out != nil {
+		t.Errorf("expected nil storage for a bucket without metrics, got %+v", out)
+	}
+
+	// test empty input
+	if _, err := c.GetBucketStorage(context.TODO(), ""); err == nil {
+		t.Error("expected error for empty bucket, got nil")
+	}
+
+	// test non-aws error
+	c.Service.(*mockCloudWatchClient).err = errors.New("things blowing up!")
+	_, err = c.GetBucketStorage(context.TODO(), "testbucket")
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrInternalError {
+		t.Errorf("expected error code %s, got: %v", apierror.ErrInternalError, err)
+	}
+}
+
+// mockRequestMetricsClient is a cloudwatch client with the request metrics of a bucket for each of its filters
+type mockRequestMetricsClient struct {
+	cloudwatchiface.CloudWatchAPI
+	filters []string
+	queries []*cloudwatch.MetricDataQuery
+}
+
+func (m *mockRequestMetricsClient) ListMetricsPagesWithContext(ctx aws.Context, input *cloudwatch.ListMetricsInput, fn func(*cloudwatch.ListMetricsOutput, bool) bool, opts ...request.Option) error {
+	out := &cloudwatch.ListMetricsOutput{}
+	for _, f := range m.filters {
+		out.Metrics = append(out.Metrics, &cloudwatch.Metric{
+			Namespace:  input.Namespace,
+			MetricName: input.MetricName,
+			Dimensions: []*cloudwatch.Dimension{
+				{Name: aws.String("BucketName"), Value: input.Dimensions[0].Value},
+				{Name: aws.String("FilterId"), Value: aws.String(f)},
+			},
+		})
+	}
+
+	fn(out, true)
+	return nil
+}
+
+func (m *mockRequestMetricsClient) GetMetricDataWithContext(ctx aws.Context, input *cloudwatch.GetMetricDataInput, opts ...request.Option) (*cloudwatch.GetMetricDataOutput, error) {
+	m.queries = input.MetricDataQueries
+
+	values := map[string][]*float64{
+		"bytes":    {aws.Float64(1 << 30), aws.Float64(1 << 30)},
+		"requests": {aws.Float64(300), aws.Float64(200)},
+		"gets":     {aws.Float64(250), aws.Float64(150)},
+	}
+
+	out := &cloudwatch.GetMetricDataOutput{}
+	for _, q := range input.MetricDataQueries {
+		out.MetricDataResults = append(out.MetricDataResults, &cloudwatch.MetricDataResult{
+			Id:     q.Id,
+			Values: values[aws.StringValue(q.Id)],
+		})
+	}
+
+	return out, nil
+}
+
+func TestGetBucketRequests(t *testing.T) {
+	end := testMetricsTimestamp
+	start := end.Add(-7 * 24 * time.Hour)
+
+	m := &mockRequestMetricsClient{filters: []string{"images", "EntireBucket"}}
+	c := CloudWatch{Service: m}
+
+	out, err := c.GetBucketRequests(context.TODO(), "testbucket", start, end)
+	if err != nil {
+		t.Fatalf("expected nil error, got: %s", err)
+	}
+
+	expected := BucketRequests{FilterId: "EntireBucket", BytesDownloaded: 2 << 30, Requests: 500, GetRequests: 400}
+	if out == nil || *out != expected {
+		t.Errorf("expected %+v, got %+v", expected, out)
+	}
+
+	for _, q := range m.queries {
+		if f := aws.StringValue(q.MetricStat.Metric.Dimensions[1].Value); f != "EntireBucket" {
+			t.Errorf("expected the entire bucket filter to be queried, got %s", f)
+		}
+	}
+
+	// the only filter is used when there isn't one for the entire bucket
+	m.filters = []string{"images"}
+	if out, err = c.GetBucketRequests(context.TODO(), "testbucket", start, end); err != nil || out.FilterId != "images" {
+		t.Errorf("expected the images filter, got %+v (%v)", out, err)
+	}
+
+	// request metrics aren't enabled
+	m.filters = nil
+	if out, err = c.GetBucketRequests(context.TODO(), "testbucket", start, end); err != nil || out != nil {
+		t.Errorf("expected nil requests and error without request metrics, got %+v (%v)", out, err)
+	}
+
+	if _, err := c.GetBucketRequests(context.TODO(), "testbucket", end, start); err == nil {
+		t.Error("expected an error for an invalid window")
+	}
+}
@@ -18,6 +18,62 @@ type Config struct {
 	LogLevel      string
 	Version       Version
 	Org           string
+	Webhooks      []Webhook
+	// RollbackDir is the directory where pending and failed rollbacks are persisted
+	RollbackDir string
+	// CacheTTL is how long IAM group, attached policy and cloudfront distribution lookups are cached
+	// (default 1m).  Set it to 0s to disable caching.
+	CacheTTL string
+	// SwaggerUI serves a swagger ui page for the openapi spec at /v1/s3/swagger
+	SwaggerUI bool
+	// ProtectionOverrideToken is the token that allows deleting or unprotecting a protected bucket or website, it's
+	// passed bcrypt hashed in the X-Protection-Override header the same as the X-Auth-Token.  Protected resources
+	// can't be deleted through the api if it's not set.
+	ProtectionOverrideToken string
+	// Locks serializes the creates and deletes of the same bucket or website name
+	Locks *Locks
+	// RequireIfMatch rejects bucket policy and website updates without an If-Match header with the ETag from
+	// getting the bucket or website, so concurrent updates can't silently overwrite each other
+	RequireIfMatch bool
+	// Logging sets the log format and sampling, logs are text if it's not set
+	Logging *Logging
+	// AdminToken is the token that allows listing and canceling in-flight operations, it's passed bcrypt hashed in
+	// the X-Admin-Token header the same as the X-Auth-Token.  The admin endpoints are disabled if it's not set.
+	AdminToken string
+	// FunctionTemplates are the cloudfront functions that can be associated with websites, keyed by the function name.
+	// A function is created and published in an account the first time it's associated with a website there.
+	FunctionTemplates map[string]*FunctionTemplate
+	// SecurityHeaders is the template of the response headers policy that adds the standard security headers to the
+	// responses of a website.  The policy is created in an account the first time it's enabled for a website there.
+	SecurityHeaders *SecurityHeaders
+	// SignedURLs is the key used to sign urls and cookies for the private content of websites.  The public key and
+	// the key group trusted by the distributions are created in an account the first time a signed website or cache
+	// behavior is created there.  Signed content isn't supported if it's not set.
+	SignedURLs *SignedURLs
+	// LifecycleTemplates are the named lifecycles that can be applied to buckets in addition to the built in ones,
+	// keyed by the name passed as the Lifecycle when a bucket is created
+	LifecycleTemplates map[string]*LifecycleTemplate
+	// AWSCalls limits how long the calls to the aws services can take and stops calling the services that are failing
+	AWSCalls *AWSCalls
+	// Alerts are where the rollback failure events are sent so on-call is paged with the resources that were left
+	// behind, in addition to the lifecycle event webhooks and topic
+	Alerts *Alerts
+	// Storage is where the api keeps its durable state, the tasks, idempotency keys, soft delete records and locks.
+	// The state is kept in memory if it's not set.
+	Storage *Storage
+	// SignedRequests allows clients to authenticate each request with an HMAC signature instead of the X-Auth-Token,
+	// for deployments where the token can't be passed through the proxies in front of the api.  Requests can only be
+	// authenticated with the token if it's not set.
+	SignedRequests *SignedRequests
+	// Maintenance starts the api in read-only maintenance mode, the mutating requests are rejected until it's disabled
+	// with the admin endpoint
+	Maintenance bool
+	// CORS allows browser based tools on the configured origins to call the api directly.  Only the preflight requests
+	// are answered, for any origin, if it's not set.
+	CORS *CORS
+	// Compression gzips the larger responses for the clients that accept it, the responses aren't compressed if it's
+	// not set
+	Compression *Compression
 }
 
 // Account is the configuration for an individual account
@@ -34,6 +90,149 @@ type Account struct {
 	AccessLog                            AccessLog
 	Domains                              map[string]*Domain
 	Cleaner                              *Cleaner
+	EnableConsoleLogin                   bool
+	RequireMFA                           bool
+	// EventTopic is the ARN of the sns topic that lifecycle events are published to.  The string {account_id}
+	// is replaced with the id of the account the event happened in.
+	EventTopic string
+	// RequiredTags are the tags that must be passed when creating buckets and websites.  They're applied to
+	// the bucket, cloudfront distribution and IAM resources created for it.
+	RequiredTags []RequiredTag
+	// TagSync periodically propagates the tags of each bucket to its cloudfront distribution and IAM resources
+	TagSync *TagSync
+	// SoftDelete quarantines deleted websites for a number of days so they can be restored, a reaper tears them
+	// down once the retention has passed.  Websites are deleted immediately if it's not set.
+	SoftDelete *SoftDelete
+	// Features are the features enabled in each account, keyed by the account name from the AccountsMap.  Websites
+	// are enabled in accounts that aren't listed if there are domains configured.
+	Features map[string]*Features
+	// DistributionDefaults are the defaults for the cloudfront distributions of new websites, they can be
+	// overridden when the website is created or updated
+	DistributionDefaults *DistributionDefaults
+	// IAMPath is the IAM path the users, groups and policies are created under and listed from, ie. /spinup/{org}/.
+	// The string {org} is replaced with the org.  They're created at / if it isn't set.
+	IAMPath string
+	// PolicyValidation validates the bucket policies passed to the api with IAM Access Analyzer before they're
+	// applied.  Policies aren't validated if it's not set.
+	PolicyValidation *PolicyValidation
+	// CheckQuotas checks the IAM and CloudFront service quotas of the account before creating users, groups,
+	// policies and distributions, so that a create over a quota fails before anything is created
+	CheckQuotas bool
+	// SimpleWebsites allows websites that are served from the s3 website endpoint without a cloudfront
+	// distribution or dns record, ie. for internal sites.  Simple websites can't be created if it's not set.
+	SimpleWebsites *SimpleWebsites
+	// Transfer is the aws transfer family server that provides sftp access to buckets.  Sftp can't be enabled if
+	// it's not set.
+	Transfer *Transfer
+	// VpcEndpoints are the ids of the s3 vpc endpoints that vpc-only buckets can be reached through, ie.
+	// vpce-0123456789abcdef0.  Buckets can't be made vpc-only if it's not set.
+	VpcEndpoints []string
+	// IPAllowlist are the networks the bucket admin policies are limited to when a bucket or website is created
+	// without its own allowlist.  Access isn't limited if it's not set.
+	IPAllowlist []string
+	// Scratch allows scratch buckets that are emptied and deleted at the end of their lifetime, a reaper warns the
+	// owners before it deletes them.  Scratch buckets can't be created if it's not set.
+	Scratch *Scratch
+	// EgressThreshold is the average number of bytes downloaded from a bucket per day that flags it in the egress
+	// report (default 50GiB)
+	EgressThreshold int64
+	// BreakGlassRole is the arn of the role that can still delete the data in buckets with data protection, ie.
+	// arn:aws:iam::{account_id}:role/BreakGlass.  Data protection can't be enabled if it's not set.
+	BreakGlassRole string
+	// BucketKey enables s3 bucket keys when a bucket spec sets aws:kms encryption without a BucketKey of its own,
+	// bucket keys cut the number of kms requests and their cost on busy buckets
+	BucketKey bool
+	// CacheControl are the rules that set the Cache-Control header of the content websites are seeded with, ie. a
+	// long max-age for assets/ and no-cache for .html.  The first rule that matches a key applies.
+	CacheControl []*CacheControlRule
+	// CredentialSecrets allows new access keys to be delivered to secrets manager in the account instead of the
+	// response body.  Keys can't be delivered to secrets manager if it's not set.
+	CredentialSecrets *CredentialSecrets
+	// UserCleanup flags the bucket users whose credentials haven't been used in a number of days, then deactivates
+	// their access keys and finally deletes them.  Users are never cleaned up if it's not set.
+	UserCleanup *UserCleanup
+	// UsageSnapshots periodically records the object count and size of each bucket so their growth can be shown
+	// without reading cloudwatch.  Usage isn't recorded if it's not set.
+	UsageSnapshots *UsageSnapshots
+	// Naming are the templates for the names of the IAM groups, policies and roles created for buckets and websites,
+	// ie. foo-BktAdmGrp.  The names are <bucket>-<type> if it's not set.
+	Naming *Naming
+	// SharedAdminGroups are the existing IAM groups, ie. an org-wide admin group, that a bucket can be created with
+	// instead of its own admin group.  The string {org} is replaced with the org.  Buckets always get their own admin
+	// group if it's not set.
+	SharedAdminGroups []string
+	// ManagedPolicies are the arns of the managed policies that can be attached to the management groups of buckets
+	// on top of the bucket policies, ie. arn:aws:iam::aws:policy/AmazonAthenaFullAccess.  The string {account_id}
+	// is replaced with the id of the account.  Extra policies can't be attached if it's not set.
+	ManagedPolicies []string
+}
+
+// Transfer is the configuration for sftp access to buckets through an aws transfer family server
+type Transfer struct {
+	// ServerID is the id of the sftp server the bucket users are created on, ie. s-01234567890abcdef
+	ServerID string
+	// Hostname is the hostname users connect to, it defaults to the server's endpoint,
+	// ie. s-01234567890abcdef.server.transfer.us-east-1.amazonaws.com
+	Hostname string
+}
+
+// SimpleWebsites is the configuration for simple websites
+type SimpleWebsites struct {
+	// AllowedCIDRs are the networks that can read the content of simple websites, ie. the campus networks
+	AllowedCIDRs []string
+}
+
+// PolicyValidation is the configuration for validating bucket policies with IAM Access Analyzer.  Policies with
+// errors are always rejected, the other findings are returned with the response.
+type PolicyValidation struct {
+	// RejectSecurityWarnings rejects policies with security warnings
+	RejectSecurityWarnings bool
+	// AllowPublic allows policies that grant public access to the bucket, they're rejected by default
+	AllowPublic bool
+}
+
+// DistributionDefaults are the default protocol settings of website cloudfront distributions
+type DistributionDefaults struct {
+	// HttpVersion is the maximum http version of the distributions, one of http1.1, http2, http3 or http2and3
+	// (default http2)
+	HttpVersion string
+	// IsIPV6Enabled enables IPv6 for the distributions (default true)
+	IsIPV6Enabled *bool
+}
+
+// Features are the features enabled in an account
+type Features struct {
+	// Websites requires the domains, certificates and hosted zones to be set up for cloudfront and route53
+	Websites bool
+}
+
+// RequiredTag is a tag that must be set on the resources created by the api
+type RequiredTag struct {
+	Key string
+	// Pattern is an optional regular expression the tag value must match, the value just can't be empty if it's not set
+	Pattern string
+	// Description is a hint about the expected value, returned when the tag is missing or invalid
+	Description string
+}
+
+// GetEventTopic gets the event topic arn given an account id
+func (a *Account) GetEventTopic(id string) string {
+	return strings.Replace(a.EventTopic, "{account_id}", id, 1)
+}
+
+// GetBreakGlassRole gets the break-glass role arn given an account id
+func (a *Account) GetBreakGlassRole(id string) string {
+	return strings.Replace(a.BreakGlassRole, "{account_id}", id, 1)
+}
+
+// GetManagedPolicies gets the arns of the managed policies that can be attached to bucket groups given an account id
+func (a *Account) GetManagedPolicies(id string) []string {
+	policies := make([]string, 0, len(a.ManagedPolicies))
+	for _, p := range a.ManagedPolicies {
+		policies = append(policies, strings.Replace(p, "{account_id}", id, 1))
+	}
+
+	return policies
 }
 
 // AccessLog is the configuration for a bucket's access log
@@ -51,8 +250,16 @@ func (a *AccessLog) GetBucket(id string) string {
 
 // Domain is the domain configuration for an S3 site
 type Domain struct {
-	CertArn      string
+	CertArn string
+	// HostedZoneID is optional, the hosted zone for a website is discovered from route53 if it's not set
 	HostedZoneID string
+	// PrivateZone is true if the domain is a private hosted zone for internal-only sites.  There's no distribution
+	// for a site in a private zone, it's a simple website fronted by an internal load balancer or interface endpoint
+	// that the site's alias record points at.
+	PrivateZone bool
+	// VpcIds are the vpcs the private zone must be associated with, the dns records in the zone are only managed
+	// if it is (optional)
+	VpcIds []string
 }
 
 // Cleaner is the configuration for the periodic cleaner task
@@ -61,6 +268,311 @@ type Cleaner struct {
 	MaxSplay string
 }
 
+// TagSync is the configuration for the periodic tag sync task
+type TagSync struct {
+	Interval string
+	MaxSplay string
+}
+
+// SoftDelete is the configuration for soft deleting websites and the periodic reaper task
+type SoftDelete struct {
+	// Days is how long a deleted website is kept before it's torn down (default 7)
+	Days     int
+	Interval string
+	MaxSplay string
+}
+
+// Scratch is the configuration for scratch buckets and the periodic scratch reaper task
+type Scratch struct {
+	// MaxDays is the longest lifetime of a scratch bucket (default 365)
+	MaxDays int
+	// WarnDays is how long before a scratch bucket is deleted that its owners are warned (default 7)
+	WarnDays int
+	Interval string
+	MaxSplay string
+}
+
+// UserCleanup is the configuration for cleaning up unused bucket users and the periodic task that does it
+type UserCleanup struct {
+	// FlagDays is how long a user's credentials can go unused before it's flagged and its owners are notified
+	// (default 90)
+	FlagDays int
+	// DeactivateDays is how long after a user is flagged that its access keys are deactivated (default 14)
+	DeactivateDays int
+	// DeleteDays is how long after a user's access keys are deactivated that it's deleted (default 30)
+	DeleteDays int
+	// Exempt are the names of the users that are never cleaned up, ie. service accounts
+	Exempt   []string
+	Interval string
+	MaxSplay string
+}
+
+// UsageSnapshots is the configuration for the periodic task that records the usage of the buckets
+type UsageSnapshots struct {
+	// RetentionDays is how long the snapshots of a bucket are kept (default 365)
+	RetentionDays int
+	Interval      string
+	MaxSplay      string
+}
+
+// Naming is the naming convention of the IAM resources created for buckets and websites.  Changing it doesn't rename
+// the existing resources, the buckets created before it changed won't be found by their new names.
+type Naming struct {
+	// Group, Policy and Role are the templates for the names of the groups, policies and roles.  {bucket} is replaced
+	// with the bucket name, followed by the path in the bucket for path groups, and {type} with the type of the
+	// resource, ie. BktAdmGrp.  They default to {bucket}-{type}.
+	Group  string
+	Policy string
+	Role   string
+	// Types are the names of the types of resources in the names, ie. {"BktAdmGrp": "Admins"}.  The api still takes
+	// the types by their default names.
+	Types map[string]string
+}
+
+// CredentialSecrets is the configuration for delivering access keys to secrets manager
+type CredentialSecrets struct {
+	// Prefix is prepended to the names of the secrets, which are named <prefix><user>/<access key id>
+	// (default spinup/s3-api/)
+	Prefix string
+	// KmsKeyId is the id, arn or alias of the kms key the secrets are encrypted with (default aws/secretsmanager)
+	KmsKeyId string
+}
+
+// CacheControlRule sets the Cache-Control header of the website content with matching keys
+type CacheControlRule struct {
+	// Prefix matches the keys that begin with it, ie. assets/
+	Prefix string
+	// Extensions match the keys that end with one of them, ie. .html.  The extension of pre-compressed content is
+	// the extension before the .gz or .br.
+	Extensions []string
+	// Value is the Cache-Control header, ie. public, max-age=31536000, immutable
+	Value string
+}
+
+// Matches returns true if the key matches the rule's prefix and one of its extensions, a rule without a prefix or
+// extensions matches every key
+func (r *CacheControlRule) Matches(key string) bool {
+	if !strings.HasPrefix(key, r.Prefix) {
+		return false
+	}
+
+	if len(r.Extensions) == 0 {
+		return true
+	}
+
+	for _, ext := range r.Extensions {
+		if strings.HasSuffix(key, ext) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Locks is the configuration for the locks that serialize operations on the same resource name
+type Locks struct {
+	// TTL is how long a lock is held if it isn't released, ie. if the instance holding it crashes (default 5m)
+	TTL string
+	// Wait is how long a request waits for a lock held by another operation before failing with a conflict (default 30s)
+	Wait string
+	// Table is a dynamodb table for sharing the locks between instances of the api, they're kept in memory if it's not set
+	Table string
+}
+
+// Logging is the configuration for the logs
+type Logging struct {
+	// Format is text (default) or json.  The request logs have the standard fields when it's json.
+	Format string
+	// DebugSampleRate only logs one of every n debug entries, they're all logged if it's 0 or 1
+	DebugSampleRate int
+}
+
+// JSON returns true if the logs are formatted as json
+func (l *Logging) JSON() bool {
+	return l != nil && l.Format == "json"
+}
+
+// Webhook is the configuration for a webhook receiving lifecycle event notifications
+type Webhook struct {
+	URL    string
+	Secret string
+	// Events is the list of event types to deliver, ie. "bucket.created" or "bucket.*".  All events are delivered if empty.
+	Events []string
+}
+
+// Subscribed returns true if the webhook should receive the event type
+func (w *Webhook) Subscribed(eventType string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+
+	for _, e := range w.Events {
+		if e == "*" || e == eventType {
+			return true
+		}
+
+		if strings.HasSuffix(e, ".*") && strings.HasPrefix(eventType, strings.TrimSuffix(e, "*")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FunctionTemplate is the template of a cloudfront function that can be associated with websites, ie. to add security
+// headers to the responses or to rewrite requests for directories to their index.html
+type FunctionTemplate struct {
+	// EventType is the event the function runs on, viewer-request or viewer-response
+	EventType string
+	Comment   string
+	// Code is the javascript code of the function
+	Code string
+}
+
+// LifecycleTemplate is a named set of lifecycle rules that can be applied to buckets, ie. to expire the objects in
+// scratch buckets after 30 days
+type LifecycleTemplate struct {
+	Description string
+	Rules       []*LifecycleRule
+}
+
+// LifecycleRule is a lifecycle rule installed by a template, it applies to all of the objects in the bucket unless
+// a prefix is set
+type LifecycleRule struct {
+	// ID is the id of the rule, it defaults to <template>-rule or <template>-rule-<n> if there's more than one
+	ID     string
+	Prefix string
+	// ExpirationDays expires the objects the number of days after they're created
+	ExpirationDays int64
+	// Transitions move the objects to another storage class the number of days after they're created
+	Transitions []*LifecycleTransition
+	// NoncurrentExpirationDays expires the noncurrent object versions the number of days after they become noncurrent
+	NoncurrentExpirationDays int64
+	// AbortIncompleteMultipartUploadDays aborts the multipart uploads that haven't completed after the number of days
+	AbortIncompleteMultipartUploadDays int64
+}
+
+// LifecycleTransition moves objects to a storage class, ie. GLACIER, after a number of days
+type LifecycleTransition struct {
+	Days         int64
+	StorageClass string
+}
+
+// AWSCalls is the configuration of the timeouts and circuit breakers for the calls to the aws services
+type AWSCalls struct {
+	// Timeout is the longest a call to an aws service can take including its retries, ie. 30s.  Calls don't time out
+	// if it's not set.
+	Timeout string
+	// ServiceTimeouts override the timeout for services by name, ie. cloudfront or iam
+	ServiceTimeouts map[string]string
+	// CircuitBreaker fails the calls to a service fast once too many of them are failing, calls are always made if
+	// it's not set
+	CircuitBreaker *CircuitBreaker
+}
+
+// Alerts is the configuration for the rollback failure alerts
+type Alerts struct {
+	// Webhooks receive the alert events, they're signed and retried the same as the lifecycle event webhooks
+	Webhooks []Webhook
+	// Topic is the ARN of the sns topic that alert events are published to, ie. the on-call paging topic
+	Topic string
+}
+
+// CORS is the cross-origin resource sharing configuration of the api
+type CORS struct {
+	// AllowedOrigins are the origins that can call the api, ie. https://admin.example.edu, or * for any origin
+	AllowedOrigins []string
+	// AllowedMethods are the methods the origins can use (default GET, HEAD, POST, PUT, PATCH and DELETE)
+	AllowedMethods []string
+	// AllowedHeaders are the request headers the origins can send, in addition to the simple headers.  By default
+	// they're the headers the api reads, ie. X-Auth-Token, Content-Type, If-Match and Idempotency-Key.
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers the origins can read, in addition to the simple headers.  By default
+	// they're the headers the api sets, ie. ETag, Retry-After and X-Request-Id.
+	ExposedHeaders []string
+	// MaxAge is how long the browsers can cache the response to a preflight request, ie. 10m.  The browser's
+	// default is used if it's not set.
+	MaxAge string
+}
+
+// Compression is the configuration of the response compression
+type Compression struct {
+	// MinSize is the size in bytes of the smallest response that's compressed (default 1024), smaller responses
+	// aren't worth compressing
+	MinSize int
+	// Level is the gzip compression level from 1 (fastest) to 9 (smallest), the default level is used if it's 0
+	Level int
+}
+
+// Storage is the configuration of the backend that keeps the durable state of the api
+type Storage struct {
+	// Backend is one of memory, bolt (a local database file for a single instance) or dynamodb (shared by all of the
+	// instances of the api)
+	Backend string
+	// Path is the bolt database file
+	Path string
+	// Table is the dynamodb table, its partition key is the string attribute Table and its sort key is the string
+	// attribute Key
+	Table string
+}
+
+// SignedRequests is the configuration for authenticating requests with an HMAC signature
+type SignedRequests struct {
+	// Keys are the shared keys the clients sign their requests with, keyed by the client id passed in the
+	// X-Spinup-Client header
+	Keys map[string]string
+	// MaxSkew is the furthest a request's timestamp can be from the time it's received (default 5m).  Signatures are
+	// remembered for twice as long so a request can't be replayed.
+	MaxSkew string
+	// MaxBodySize is the size in bytes of the largest body of a signed request (default 10485760), the body is read
+	// before the signature is checked so it has to be limited
+	MaxBodySize int64
+}
+
+// CircuitBreaker is the configuration of the circuit breakers for the aws services
+type CircuitBreaker struct {
+	// Threshold is the rate of failed calls, between 0 and 1, that opens the breaker for a service (default 0.5)
+	Threshold float64
+	// MinRequests is the number of calls in the window before the failure rate is checked (default 20)
+	MinRequests int
+	// Window is how long the calls are counted for (default 1m)
+	Window string
+	// Cooldown is how long the breaker stays open before a call is let through to test the service (default 30s)
+	Cooldown string
+}
+
+// SecurityHeaders is the configuration of the security headers response headers policy
+type SecurityHeaders struct {
+	// Name is the name of the response headers policy (default spinup-security-headers)
+	Name string
+	// Default enables the security headers for the websites when they're created
+	Default bool
+	// HSTSMaxAge is the max-age of the Strict-Transport-Security header in seconds, the header isn't added if it's 0
+	HSTSMaxAge            int64
+	HSTSIncludeSubdomains bool
+	HSTSPreload           bool
+	// ContentSecurityPolicy is the value of the Content-Security-Policy header, the header isn't added if it's empty
+	ContentSecurityPolicy string
+	// ContentTypeOptions adds the X-Content-Type-Options: nosniff header
+	ContentTypeOptions bool
+	// FrameOptions is the value of the X-Frame-Options header, DENY or SAMEORIGIN
+	FrameOptions string
+	// ReferrerPolicy is the value of the Referrer-Policy header, ie. strict-origin-when-cross-origin
+	ReferrerPolicy string
+	// XSSProtection adds the X-XSS-Protection: 1; mode=block header
+	XSSProtection bool
+}
+
+// SignedURLs is the configuration for signing urls and cookies for the private content of websites
+type SignedURLs struct {
+	// PrivateKey is the PEM encoded RSA private key, the public key is derived from it
+	PrivateKey string
+	// KeyName is the name of the cloudfront public key and key group (default spinup-signed-urls)
+	KeyName string
+	// MaxExpiration is the longest a signed url or cookie can be valid for (default 24h)
+	MaxExpiration string
+}
+
 // Version carries around the API version information
 type Version struct {
 	Version           string
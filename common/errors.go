@@ -0,0 +1,43 @@
+package common
+
+import (
+	"errors"
+
+	"github.com/YaleSpinup/apierror"
+)
+
+// AsAPIError finds the first apierror in the error's chain.  The chain is followed through errors wrapped with
+// pkg/errors as well as fmt.Errorf and %w, so an apierror is found no matter how it was wrapped on its way up.
+func AsAPIError(err error) (apierror.Error, bool) {
+	var aerr apierror.Error
+	if err == nil || !errors.As(err, &aerr) {
+		return apierror.Error{}, false
+	}
+
+	return aerr, true
+}
+
+// ErrorCode returns the code of the first apierror in the error's chain, or an empty string if there isn't one
+func ErrorCode(err error) string {
+	if aerr, ok := AsAPIError(err); ok {
+		return aerr.Code
+	}
+	return ""
+}
+
+// IsErrorCode returns true if the first apierror in the error's chain has one of the codes.  It's the errors.Is for
+// apierror codes, apierror.Error can't be compared directly since its original error often isn't comparable.
+func IsErrorCode(err error, codes ...string) bool {
+	aerr, ok := AsAPIError(err)
+	if !ok {
+		return false
+	}
+
+	for _, code := range codes {
+		if aerr.Code == code {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,60 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	pkgerrors "github.com/pkg/errors"
+)
+
+func TestAsAPIError(t *testing.T) {
+	notFound := apierror.New(apierror.ErrNotFound, "thing not found", errors.New("boom"))
+
+	tests := []struct {
+		name string
+		err  error
+		code string
+		ok   bool
+	}{
+		{"nil", nil, "", false},
+		{"plain error", errors.New("boom"), "", false},
+		{"apierror", notFound, apierror.ErrNotFound, true},
+		{"pkg/errors wrapped", pkgerrors.Wrap(notFound, "failed to get thing"), apierror.ErrNotFound, true},
+		{"fmt wrapped", fmt.Errorf("failed to get thing: %w", notFound), apierror.ErrNotFound, true},
+		{"both wrapped", fmt.Errorf("outer: %w", pkgerrors.Wrap(notFound, "inner")), apierror.ErrNotFound, true},
+		{"not wrapped", fmt.Errorf("failed to get thing: %s", notFound), "", false},
+	}
+
+	for _, test := range tests {
+		aerr, ok := AsAPIError(test.err)
+		if ok != test.ok || aerr.Code != test.code {
+			t.Errorf("%s: expected %q, %t, got %q, %t", test.name, test.code, test.ok, aerr.Code, ok)
+		}
+
+		if code := ErrorCode(test.err); code != test.code {
+			t.Errorf("%s: expected code %q, got %q", test.name, test.code, code)
+		}
+	}
+}
+
+func TestIsErrorCode(t *testing.T) {
+	err := fmt.Errorf("failed: %w", apierror.New(apierror.ErrConflict, "already exists", nil))
+
+	if !IsErrorCode(err, apierror.ErrConflict) {
+		t.Error("expected wrapped conflict to have the conflict code")
+	}
+
+	if !IsErrorCode(err, apierror.ErrNotFound, apierror.ErrConflict) {
+		t.Error("expected wrapped conflict to match one of the codes")
+	}
+
+	if IsErrorCode(err, apierror.ErrNotFound) {
+		t.Error("expected wrapped conflict not to have the not found code")
+	}
+
+	if IsErrorCode(errors.New("conflict"), apierror.ErrConflict) {
+		t.Error("expected plain error not to have a code")
+	}
+}
@@ -0,0 +1,22 @@
+package iam
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	log "github.com/sirupsen/logrus"
+)
+
+// GetAccountSummary gets the usage of the iam entities in the account, ie. the number of users, groups and policies,
+// keyed by the iam summary key
+func (i *IAM) GetAccountSummary(ctx context.Context) (map[string]int64, error) {
+	log.Info("getting iam account summary")
+
+	output, err := i.Service.GetAccountSummaryWithContext(ctx, &iam.GetAccountSummaryInput{})
+	if err != nil {
+		return nil, ErrCode("failed to get iam account summary", err)
+	}
+
+	return aws.Int64ValueMap(output.SummaryMap), nil
+}
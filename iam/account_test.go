@@ -0,0 +1,49 @@
+package iam
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+func (m *mockIAMClient) GetAccountSummaryWithContext(ctx context.Context, input *iam.GetAccountSummaryInput, opts ...request.Option) (*iam.GetAccountSummaryOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return &iam.GetAccountSummaryOutput{SummaryMap: aws.Int64Map(map[string]int64{
+		iam.SummaryKeyTypeUsers:      12,
+		iam.SummaryKeyTypeUsersQuota: 5000,
+		iam.SummaryKeyTypeGroups:     30,
+	})}, nil
+}
+
+func TestGetAccountSummary(t *testing.T) {
+	i := IAM{Service: newMockIAMClient(t, nil)}
+
+	out, err := i.GetAccountSummary(context.TODO())
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	expected := map[string]int64{
+		iam.SummaryKeyTypeUsers:      12,
+		iam.SummaryKeyTypeUsersQuota: 5000,
+		iam.SummaryKeyTypeGroups:     30,
+	}
+	if !reflect.DeepEqual(expected, out) {
+		t.Errorf("expected %v, got %v", expected, out)
+	}
+
+	i.Service.(*mockIAMClient).err = awserr.New(iam.ErrCodeServiceFailureException, "boom", nil)
+	_, err = i.GetAccountSummary(context.TODO())
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrServiceUnavailable {
+		t.Errorf("expected service unavailable error, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package iam
+
+import (
+	"strings"
+
+	"github.com/patrickmn/go-cache"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// groupsCacheKey prefixes the cached list of groups, by path prefix
+	groupsCacheKey = "iam:groups:"
+	// groupPoliciesCacheKey prefixes the cached policies attached to a group, by group name
+	groupPoliciesCacheKey = "iam:group-policies:"
+	// lastAccessedJobCacheKey prefixes the cached service last accessed job ids, by principal arn
+	lastAccessedJobCacheKey = "iam:last-accessed-job:"
+)
+
+// cacheGet gets an item from the cache, if one is configured
+func (i *IAM) cacheGet(key string) (interface{}, bool) {
+	if i.Cache == nil {
+		return nil, false
+	}
+
+	item, found := i.Cache.Get(key)
+	if found {
+		log.Debugf("using cached item %s", key)
+	}
+
+	return item, found
+}
+
+// cacheSet stores an item in the cache with the default expiration, if one is configured
+func (i *IAM) cacheSet(key string, item interface{}) {
+	if i.Cache == nil {
+		return
+	}
+
+	i.Cache.Set(key, item, cache.DefaultExpiration)
+}
+
+// cacheDelete removes an item from the cache, if one is configured
+func (i *IAM) cacheDelete(key string) {
+	if i.Cache == nil {
+		return
+	}
+
+	i.Cache.Delete(key)
+}
+
+// invalidate removes all cached items with any of the given key prefixes
+func (i *IAM) invalidate(prefixes ...string) {
+	if i.Cache == nil {
+		return
+	}
+
+	for key := range i.Cache.Items() {
+		for _, p := range prefixes {
+			if strings.HasPrefix(key, p) {
+				log.Debugf("invalidating cached item %s", key)
+				i.Cache.Delete(key)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package iam
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/patrickmn/go-cache"
+)
+
+func TestListGroupsCache(t *testing.T) {
+	i := IAM{
+		Service: newMockIAMClient(t, nil),
+		Cache:   cache.New(1*time.Minute, 2*time.Minute),
+	}
+
+	groups, err := i.ListGroups(context.TODO(), &iam.ListGroupsInput{}, "testsite")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	// subsequent lookups are served from the cache
+	i.Service = newMockIAMClient(t, errors.New("boom"))
+	cached, err := i.ListGroups(context.TODO(), &iam.ListGroupsInput{}, "testsite")
+	if err != nil {
+		t.Fatalf("expected nil error from cached groups, got %s", err)
+	}
+
+	if len(cached) != len(groups) {
+		t.Errorf("expected %d cached groups, got %d", len(groups), len(cached))
+	}
+
+	// creating a group invalidates the cache
+	i.Service = newMockIAMClient(t, nil)
+	if _, err := i.CreateGroup(context.TODO(), &iam.CreateGroupInput{GroupName: aws.String("testsite-BktAdmGrp")}); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	i.Service = newMockIAMClient(t, errors.New("boom"))
+	if _, err := i.ListGroups(context.TODO(), &iam.ListGroupsInput{}, "testsite"); err == nil {
+		t.Error("expected error after cache invalidation, got nil")
+	}
+}
+
+func TestListGroupPoliciesCache(t *testing.T) {
+	i := IAM{
+		Service: newMockIAMClient(t, nil),
+		Cache:   cache.New(1*time.Minute, 2*time.Minute),
+	}
+
+	if _, err := i.ListGroupPolicies(context.TODO(), &iam.ListAttachedGroupPoliciesInput{GroupName: aws.String("testgroup")}); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	i.Service = newMockIAMClient(t, errors.New("boom"))
+	if _, err := i.ListGroupPolicies(context.TODO(), &iam.ListAttachedGroupPoliciesInput{GroupName: aws.String("testgroup")}); err != nil {
+		t.Fatalf("expected nil error from cached policies, got %s", err)
+	}
+
+	// attaching a policy to the group invalidates the cache
+	i.Service = newMockIAMClient(t, nil)
+	if err := i.AttachGroupPolicy(context.TODO(), &iam.AttachGroupPolicyInput{
+		GroupName: aws.String("testgroup"),
+		PolicyArn: aws.String("arn:aws:iam::12345678910:policy/testpolicy"),
+	}); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	i.Service = newMockIAMClient(t, errors.New("boom"))
+	if _, err := i.ListGroupPolicies(context.TODO(), &iam.ListAttachedGroupPoliciesInput{GroupName: aws.String("testgroup")}); err == nil {
+		t.Error("expected error after cache invalidation, got nil")
+	}
+}
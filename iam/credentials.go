@@ -0,0 +1,127 @@
+package iam
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"io"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	log "github.com/sirupsen/logrus"
+)
+
+// CredentialReport is a parsed IAM credential report
+type CredentialReport struct {
+	GeneratedTime time.Time
+	// Header is the list of columns in the report, ie. user, arn, password_enabled, mfa_active
+	Header []string
+	Rows   [][]string
+}
+
+// GenerateCredentialReport starts generating a credential report and returns the state of the report.  A new
+// report isn't generated if the current one is less than four hours old, the state is COMPLETE in that case.
+func (i *IAM) GenerateCredentialReport(ctx context.Context) (string, error) {
+	log.Info("generating iam credential report")
+
+	out, err := i.Service.GenerateCredentialReportWithContext(ctx, &iam.GenerateCredentialReportInput{})
+	if err != nil {
+		return "", ErrCode("failed to generate iam credential report", err)
+	}
+
+	return aws.StringValue(out.State), nil
+}
+
+// GetCredentialReport gets and parses the most recently generated credential report
+func (i *IAM) GetCredentialReport(ctx context.Context) (*CredentialReport, error) {
+	log.Info("getting iam credential report")
+
+	out, err := i.Service.GetCredentialReportWithContext(ctx, &iam.GetCredentialReportInput{})
+	if err != nil {
+		return nil, ErrCode("failed to get iam credential report", err)
+	}
+
+	return ParseCredentialReport(out.Content, aws.TimeValue(out.GeneratedTime))
+}
+
+// ParseCredentialReport parses the CSV content of a credential report
+func ParseCredentialReport(content []byte, generated time.Time) (*CredentialReport, error) {
+	records, err := csv.NewReader(bytes.NewReader(content)).ReadAll()
+	if err != nil {
+		return nil, apierror.New(apierror.ErrInternalError, "failed to parse iam credential report", err)
+	}
+
+	if len(records) == 0 {
+		return nil, apierror.New(apierror.ErrInternalError, "iam credential report is empty", nil)
+	}
+
+	return &CredentialReport{
+		GeneratedTime: generated,
+		Header:        records[0],
+		Rows:          records[1:],
+	}, nil
+}
+
+// Filter returns a credential report with only the rows for the users the function returns true for
+func (r *CredentialReport) Filter(fn func(user, arn string) bool) *CredentialReport {
+	userCol, arnCol := r.column("user"), r.column("arn")
+
+	filtered := &CredentialReport{GeneratedTime: r.GeneratedTime, Header: r.Header, Rows: [][]string{}}
+	for _, row := range r.Rows {
+		var user, arn string
+		if userCol >= 0 && userCol < len(row) {
+			user = row[userCol]
+		}
+		if arnCol >= 0 && arnCol < len(row) {
+			arn = row[arnCol]
+		}
+
+		if fn(user, arn) {
+			filtered.Rows = append(filtered.Rows, row)
+		}
+	}
+
+	return filtered
+}
+
+// Users returns the rows of the credential report as maps of column names to values
+func (r *CredentialReport) Users() []map[string]string {
+	users := make([]map[string]string, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		user := map[string]string{}
+		for i, h := range r.Header {
+			if i < len(row) {
+				user[h] = row[i]
+			}
+		}
+		users = append(users, user)
+	}
+
+	return users
+}
+
+// WriteCSV writes the credential report as CSV, including the header
+func (r *CredentialReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(r.Header); err != nil {
+		return err
+	}
+
+	if err := cw.WriteAll(r.Rows); err != nil {
+		return err
+	}
+
+	return cw.Error()
+}
+
+// column returns the index of the column in the report or -1 if it doesn't exist
+func (r *CredentialReport) column(name string) int {
+	for i, h := range r.Header {
+		if h == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,163 @@
+package iam
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+var testCredentialReport = []byte(`user,arn,user_creation_time,password_enabled,mfa_active,access_key_1_active
+<root_account>,arn:aws:iam::012345678910:root,2019-01-01T00:00:00+00:00,not_supported,true,false
+testbucket-admin,arn:aws:iam::012345678910:user/testbucket-admin,2020-01-01T00:00:00+00:00,true,false,true
+someone,arn:aws:iam::012345678910:user/other/someone,2020-01-01T00:00:00+00:00,false,false,true
+`)
+
+var testReportTime = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func (m *mockIAMClient) GenerateCredentialReportWithContext(ctx context.Context, input *iam.GenerateCredentialReportInput, opts ...request.Option) (*iam.GenerateCredentialReportOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return &iam.GenerateCredentialReportOutput{State: aws.String(iam.ReportStateTypeComplete)}, nil
+}
+
+func (m *mockIAMClient) GetCredentialReportWithContext(ctx context.Context, input *iam.GetCredentialReportInput, opts ...request.Option) (*iam.GetCredentialReportOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return &iam.GetCredentialReportOutput{
+		Content:       testCredentialReport,
+		GeneratedTime: aws.Time(testReportTime),
+		ReportFormat:  aws.String(iam.ReportFormatTypeTextCsv),
+	}, nil
+}
+
+func TestGenerateCredentialReport(t *testing.T) {
+	i := IAM{Service: newMockIAMClient(t, nil)}
+
+	state, err := i.GenerateCredentialReport(context.TODO())
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if state != iam.ReportStateTypeComplete {
+		t.Errorf("expected state %s, got %s", iam.ReportStateTypeComplete, state)
+	}
+
+	i.Service.(*mockIAMClient).err = awserr.New(iam.ErrCodeLimitExceededException, "limit exceeded", nil)
+	_, err = i.GenerateCredentialReport(context.TODO())
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrLimitExceeded {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrLimitExceeded, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+}
+
+func TestGetCredentialReport(t *testing.T) {
+	i := IAM{Service: newMockIAMClient(t, nil)}
+
+	out, err := i.GetCredentialReport(context.TODO())
+	if err != nil {
+		t.Fatalf("expected nil error, got: %s", err)
+	}
+
+	if !out.GeneratedTime.Equal(testReportTime) {
+		t.Errorf("expected generated time %s, got %s", testReportTime, out.GeneratedTime)
+	}
+
+	if len(out.Header) != 6 || len(out.Rows) != 3 {
+		t.Errorf("expected 6 columns and 3 rows, got %d and %d", len(out.Header), len(out.Rows))
+	}
+
+	i.Service.(*mockIAMClient).err = awserr.New(iam.ErrCodeCredentialReportNotPresentException, "not present", nil)
+	_, err = i.GetCredentialReport(context.TODO())
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrConflict {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrConflict, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+
+	i.Service.(*mockIAMClient).err = errors.New("things blowing up!")
+	if _, err := i.GetCredentialReport(context.TODO()); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func TestCredentialReportFilter(t *testing.T) {
+	report, err := ParseCredentialReport(testCredentialReport, testReportTime)
+	if err != nil {
+		t.Fatalf("expected nil error, got: %s", err)
+	}
+
+	filtered := report.Filter(func(user, arn string) bool {
+		return user == "testbucket-admin" || strings.Contains(arn, ":user/other/")
+	})
+
+	users := filtered.Users()
+	if len(users) != 2 {
+		t.Fatalf("expected 2 users, got %d", len(users))
+	}
+
+	expected := map[string]string{
+		"user":                "testbucket-admin",
+		"arn":                 "arn:aws:iam::012345678910:user/testbucket-admin",
+		"user_creation_time":  "2020-01-01T00:00:00+00:00",
+		"password_enabled":    "true",
+		"mfa_active":          "false",
+		"access_key_1_active": "true",
+	}
+	if !reflect.DeepEqual(users[0], expected) {
+		t.Errorf("expected %+v, got %+v", expected, users[0])
+	}
+
+	if users[1]["user"] != "someone" {
+		t.Errorf("expected someone, got %s", users[1]["user"])
+	}
+
+	// the original report isn't modified
+	if len(report.Rows) != 3 {
+		t.Errorf("expected the report to have 3 rows, got %d", len(report.Rows))
+	}
+}
+
+func TestCredentialReportWriteCSV(t *testing.T) {
+	report, err := ParseCredentialReport(testCredentialReport, testReportTime)
+	if err != nil {
+		t.Fatalf("expected nil error, got: %s", err)
+	}
+
+	var b bytes.Buffer
+	if err := report.WriteCSV(&b); err != nil {
+		t.Fatalf("expected nil error, got: %s", err)
+	}
+
+	if b.String() != string(testCredentialReport) {
+		t.Errorf("expected %s, got %s", testCredentialReport, b.String())
+	}
+}
+
+func TestParseCredentialReport(t *testing.T) {
+	if _, err := ParseCredentialReport([]byte{}, testReportTime); err == nil {
+		t.Error("expected error for an empty report, got nil")
+	}
+
+	if _, err := ParseCredentialReport([]byte("user,arn\n\"broken"), testReportTime); err == nil {
+		t.Error("expected error for an invalid report, got nil")
+	}
+}
@@ -19,11 +19,20 @@ func (i *IAM) CreateGroup(ctx context.Context, input *iam.CreateGroupInput) (*ia
 
 	log.Infof("creating IAM group: %s", aws.StringValue(input.GroupName))
 
+	// the input is copied so the caller's path isn't changed
+	if i.PathPrefix != "" {
+		scoped := *input
+		scoped.Path = aws.String(i.Path(aws.StringValue(input.Path)))
+		input = &scoped
+	}
+
 	output, err := i.Service.CreateGroupWithContext(ctx, input)
 	if err != nil {
 		return nil, ErrCode("failed to create iam group", err)
 	}
 
+	i.invalidate(groupsCacheKey)
+
 	log.Debugf("returning created group %s", awsutil.Prettify(output.Group))
 
 	return output.Group, nil
@@ -63,6 +72,8 @@ func (i *IAM) DeleteGroup(ctx context.Context, input *iam.DeleteGroupInput) erro
 		return ErrCode("failed to delete iam group", err)
 	}
 
+	i.invalidate(groupsCacheKey, groupPoliciesCacheKey+aws.StringValue(input.GroupName))
+
 	return nil
 }
 
@@ -79,6 +90,8 @@ func (i *IAM) AttachGroupPolicy(ctx context.Context, input *iam.AttachGroupPolic
 		return ErrCode("failed to attach policy to group", err)
 	}
 
+	i.invalidate(groupPoliciesCacheKey + aws.StringValue(input.GroupName))
+
 	return nil
 }
 
@@ -94,6 +107,8 @@ func (i *IAM) DetachGroupPolicy(ctx context.Context, input *iam.DetachGroupPolic
 		return ErrCode("failed to deattach policy from group", err)
 	}
 
+	i.invalidate(groupPoliciesCacheKey + aws.StringValue(input.GroupName))
+
 	return nil
 }
 
@@ -105,6 +120,15 @@ func (i *IAM) ListGroupPolicies(ctx context.Context, input *iam.ListAttachedGrou
 		return policies, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
 	}
 
+	// only complete listings are cached
+	paged := input.Marker != nil
+	cacheKey := groupPoliciesCacheKey + aws.StringValue(input.GroupName)
+	if !paged {
+		if item, found := i.cacheGet(cacheKey); found {
+			return append(policies, item.([]*iam.AttachedPolicy)...), nil
+		}
+	}
+
 	log.Infof("listing policies attached to group %s", aws.StringValue(input.GroupName))
 
 	truncated := true
@@ -118,11 +142,18 @@ func (i *IAM) ListGroupPolicies(ctx context.Context, input *iam.ListAttachedGrou
 		input.Marker = output.Marker
 	}
 
+	if !paged {
+		i.cacheSet(cacheKey, append([]*iam.AttachedPolicy{}, policies...))
+	}
+
 	log.Debugf("returning list of policies attached to group %s: %s", aws.StringValue(input.GroupName), awsutil.Prettify(policies))
 
 	return policies, nil
 }
 
+// ListGroups lists the management groups of a bucket, or all of the groups if the bucket is empty.  The groups are
+// matched to the bucket by the FormatGroupName scheme and their IAM path, so another bucket whose name starts with the
+// bucket name doesn't match, see ParseGroupName.
 func (i *IAM) ListGroups(ctx context.Context, input *iam.ListGroupsInput, bucket string) ([]*iam.Group, error) {
 	var groups []*iam.Group
 	var outGroups []*iam.Group
@@ -131,31 +162,94 @@ func (i *IAM) ListGroups(ctx context.Context, input *iam.ListGroupsInput, bucket
 		return []*iam.Group{}, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
 	}
 
-	log.Debugf("listing iam groups for account %+v", groups)
+	// only the groups under the path prefix are listed
+	if input.PathPrefix == nil && i.PathPrefix != "" {
+		input.PathPrefix = aws.String(i.Path("/"))
+	}
 
-	truncated := true
-	for truncated {
-		output, err := i.Service.ListGroupsWithContext(ctx, input)
-		if err != nil {
-			return []*iam.Group{}, apierror.New(apierror.ErrInternalError, "unknown error", err)
+	// the complete list of groups is cached and filtered for each request
+	paged := input.Marker != nil
+	cacheKey := groupsCacheKey + aws.StringValue(input.PathPrefix)
+	if item, found := i.cacheGet(cacheKey); found && !paged {
+		groups = item.([]*iam.Group)
+	} else {
+		log.Debugf("listing iam groups for account %+v", groups)
+
+		truncated := true
+		for truncated {
+			output, err := i.Service.ListGroupsWithContext(ctx, input)
+			if err != nil {
+				return []*iam.Group{}, apierror.New(apierror.ErrInternalError, "unknown error", err)
+			}
+			truncated = aws.BoolValue(output.IsTruncated)
+			groups = append(groups, output.Groups...)
+			input.Marker = output.Marker
+		}
+
+		if !paged {
+			i.cacheSet(cacheKey, groups)
 		}
-		truncated = aws.BoolValue(output.IsTruncated)
-		groups = append(groups, output.Groups...)
-		input.Marker = output.Marker
 	}
 
 	log.Infof("got %d groups", len(groups))
 
+	if bucket == "" {
+		return groups, nil
+	}
+
 	for _, group := range groups {
-		log.Debugf("checking if %s contains %s", aws.StringValue(group.GroupName), bucket)
-		if strings.Contains(aws.StringValue(group.GroupName), bucket) {
-			outGroups = append(outGroups, group)
+		groupPath := TrimPathPrefix(i.PathPrefix, aws.StringValue(group.Path))
+		path, _, ok := ParseGroupName(bucket, aws.StringValue(group.GroupName), groupPath)
+		if !ok {
+			continue
+		}
+
+		// groups with a path in the bucket that were created without an IAM path are ambiguous, ie. foo-bar-BktAdmGrp
+		// could be the /bar/ group of foo or the group of foo-bar, so they're matched by the path of their policies
+		if path != "/" && EnforcePathFormat(groupPath) == "/" {
+			legacy, err := i.legacyPathGroup(ctx, bucket, group)
+			if err != nil {
+				return []*iam.Group{}, err
+			}
+
+			if !legacy {
+				log.Debugf("group %s doesn't have a policy with a path in %s, skipping it", aws.StringValue(group.GroupName), bucket)
+				continue
+			}
 		}
+
+		outGroups = append(outGroups, group)
 	}
 
 	return outGroups, nil
 }
 
+// legacyPathGroup returns true if the group, created without an IAM path, is named for the IAM path of one of its
+// policies in the bucket
+func (i *IAM) legacyPathGroup(ctx context.Context, bucket string, group *iam.Group) (bool, error) {
+	policies, err := i.ListGroupPolicies(ctx, &iam.ListAttachedGroupPoliciesInput{GroupName: group.GroupName})
+	if err != nil {
+		return false, err
+	}
+
+	for _, p := range policies {
+		// arn:aws:iam::012345678901:policy/some/path/policy-name
+		parts := strings.SplitN(aws.StringValue(p.PolicyArn), ":policy/", 2)
+		if len(parts) != 2 || !strings.Contains(parts[1], "/") {
+			continue
+		}
+		path := TrimPathPrefix(i.PathPrefix, EnforcePathFormat(parts[1][:strings.LastIndex(parts[1], "/")]))
+
+		for _, t := range GroupTypes {
+			if aws.StringValue(group.GroupName) == FormatGroupName(bucket, path, t) {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
 // ListGroupUsers lists the users that belong to a group
 func (i *IAM) ListGroupUsers(ctx context.Context, input *iam.GetGroupInput) ([]*iam.User, error) {
 	users := []*iam.User{}
@@ -182,21 +276,6 @@ func (i *IAM) ListGroupUsers(ctx context.Context, input *iam.GetGroupInput) ([]*
 	return users, nil
 }
 
-func FormatGroupName(base string, path string, group string) string {
-	out := ""
-	path = EnforcePathFormat(path)
-
-	if path == "/" {
-		out = fmt.Sprintf("%s-%s", base, group)
-	} else {
-		path = RemoveCappingSlashes(path)
-		sanitizedPath := strings.Replace(path, "/", "_", -1)
-		out = fmt.Sprintf("%s-%s-%s", base, sanitizedPath, group)
-	}
-
-	return out
-}
-
 func EnforcePathFormat(str string) string {
 	strLen := len(str)
 
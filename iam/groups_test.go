@@ -11,6 +11,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
 )
 
 var testGroup = iam.Group{
@@ -257,6 +258,99 @@ func TestListGroups(t *testing.T) {
 	}
 }
 
+func TestParseGroupName(t *testing.T) {
+	tests := []struct {
+		bucket, name, iamPath string
+		path, groupType       string
+		ok                    bool
+	}{
+		{bucket: "foo", name: "foo-BktAdmGrp", iamPath: "/", path: "/", groupType: "BktAdmGrp", ok: true},
+		{bucket: "foo", name: "foo-WebAdmGrp", iamPath: "", path: "/", groupType: "WebAdmGrp", ok: true},
+		{bucket: "foo", name: "foo-bar-BktRWGrp", iamPath: "/bar/", path: "/bar/", groupType: "BktRWGrp", ok: true},
+		{bucket: "foo", name: "foo-one_two-BktROGrp", iamPath: "/one/two/", path: "/one/two/", groupType: "BktROGrp", ok: true},
+		{bucket: "foo", name: "foo-a_b-BktROGrp", iamPath: "/a_b/", path: "/a_b/", groupType: "BktROGrp", ok: true},
+		{bucket: "foo", name: "foo-bar-BktAdmGrp", iamPath: "/", path: "/bar/", groupType: "BktAdmGrp", ok: true},
+		{bucket: "foo", name: "foo-bar-BktAdmGrp", iamPath: "/baz/"},
+		{bucket: "foo-bar", name: "foo-bar-BktAdmGrp", iamPath: "/bar/"},
+		{bucket: "foo", name: "foo-bar-baz-BktAdmGrp", iamPath: "/"},
+		{bucket: "foo", name: "foobar-BktAdmGrp", iamPath: "/"},
+		{bucket: "foo", name: "foo-SomeOtherGrp", iamPath: "/"},
+		{bucket: "", name: "foo-BktAdmGrp", iamPath: "/"},
+	}
+
+	for _, test := range tests {
+		path, groupType, ok := ParseGroupName(test.bucket, test.name, test.iamPath)
+		if path != test.path || groupType != test.groupType || ok != test.ok {
+			t.Errorf("%s in %s (%s): expected %s, %s, %t, got %s, %s, %t", test.name, test.bucket, test.iamPath, test.path, test.groupType, test.ok, path, groupType, ok)
+		}
+	}
+}
+
+// mockGroupsClient lists the groups and the policies attached to them by group name
+type mockGroupsClient struct {
+	iamiface.IAMAPI
+	groups   []*iam.Group
+	policies map[string][]string
+}
+
+func (m *mockGroupsClient) ListGroupsWithContext(ctx context.Context, input *iam.ListGroupsInput, opts ...request.Option) (*iam.ListGroupsOutput, error) {
+	return &iam.ListGroupsOutput{Groups: m.groups}, nil
+}
+
+func (m *mockGroupsClient) ListAttachedGroupPoliciesWithContext(ctx context.Context, input *iam.ListAttachedGroupPoliciesInput, opts ...request.Option) (*iam.ListAttachedGroupPoliciesOutput, error) {
+	policies := []*iam.AttachedPolicy{}
+	for _, arn := range m.policies[aws.StringValue(input.GroupName)] {
+		policies = append(policies, &iam.AttachedPolicy{PolicyArn: aws.String(arn)})
+	}
+	return &iam.ListAttachedGroupPoliciesOutput{AttachedPolicies: policies}, nil
+}
+
+func TestListGroupsByPath(t *testing.T) {
+	m := &mockGroupsClient{
+		groups: []*iam.Group{
+			{GroupName: aws.String("foo-BktAdmGrp"), Path: aws.String("/")},
+			{GroupName: aws.String("foo-spinup-BktRWGrp"), Path: aws.String("/spinup/")},
+			{GroupName: aws.String("foo-legacy-BktROGrp"), Path: aws.String("/")},
+			{GroupName: aws.String("foo-bar-BktAdmGrp"), Path: aws.String("/")},
+			{GroupName: aws.String("foo-bar-baz-BktAdmGrp"), Path: aws.String("/")},
+			{GroupName: aws.String("foobar-BktAdmGrp"), Path: aws.String("/")},
+		},
+		policies: map[string][]string{
+			"foo-legacy-BktROGrp": {"arn:aws:iam::12345678910:policy/legacy/foo-legacy-BktROPlc"},
+			"foo-bar-BktAdmGrp":   {"arn:aws:iam::12345678910:policy/foo-bar-BktAdmPlc"},
+		},
+	}
+	i := IAM{Service: m}
+
+	tests := map[string][]string{
+		"foo":         {"foo-BktAdmGrp", "foo-spinup-BktRWGrp", "foo-legacy-BktROGrp"},
+		"foo-bar":     {"foo-bar-BktAdmGrp"},
+		"foo-bar-baz": {"foo-bar-baz-BktAdmGrp"},
+		"foobar":      {"foobar-BktAdmGrp"},
+		"fo":          nil,
+	}
+
+	for bucket, expected := range tests {
+		groups, err := i.ListGroups(context.TODO(), &iam.ListGroupsInput{}, bucket)
+		if err != nil {
+			t.Fatalf("expected nil error, got %s", err)
+		}
+
+		var names []string
+		for _, g := range groups {
+			names = append(names, aws.StringValue(g.GroupName))
+		}
+
+		if !reflect.DeepEqual(expected, names) {
+			t.Errorf("%s: expected groups %v, got %v", bucket, expected, names)
+		}
+	}
+
+	if groups, err := i.ListGroups(context.TODO(), &iam.ListGroupsInput{}, ""); err != nil || len(groups) != len(m.groups) {
+		t.Errorf("expected all groups without a bucket, got %d and %v", len(groups), err)
+	}
+}
+
 func TestCreateGroup(t *testing.T) {
 	i := IAM{
 		Service:                newMockIAMClient(t, nil),
@@ -4,12 +4,14 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/YaleSpinup/apierror"
 	"github.com/YaleSpinup/s3-api/common"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/patrickmn/go-cache"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -65,6 +67,17 @@ var (
 		"s3:GetObjectVersionTagging",
 	}
 
+	// DestructivePolicy are the actions denied without MFA when an account requires it
+	DestructivePolicy = []string{
+		"s3:DeleteBucketPolicy",
+		"s3:DeleteBucketWebsite",
+		"s3:DeleteObject",
+		"s3:DeleteObjectVersion",
+		"s3:PutBucketPolicy",
+		"s3:PutBucketVersioning",
+		"s3:PutLifecycleConfiguration",
+	}
+
 	BucketReadPolicy = []string{
 		"s3:GetAccelerateConfiguration",
 		"s3:GetBucketAcl",
@@ -97,6 +110,7 @@ var (
 
 // PolicyStatement is an individual IAM Policy statement
 type PolicyStatement struct {
+	Sid       string `json:",omitempty"`
 	Effect    string
 	Principal string `json:",omitempty"`
 	Action    []string
@@ -104,7 +118,8 @@ type PolicyStatement struct {
 	Condition map[string]PolicyCondition `json:",omitempty"`
 }
 
-type PolicyCondition map[string]string
+// PolicyCondition maps condition keys to a value or a list of values
+type PolicyCondition map[string]interface{}
 
 // PolicyDoc collects the policy statements
 type PolicyDoc struct {
@@ -118,6 +133,13 @@ type IAM struct {
 	DefaultS3BucketActions               []string
 	DefaultS3ObjectActions               []string
 	DefaultCloudfrontDistributionActions []string
+	RequireMFA                           bool
+	// Cache is an optional cache for group and attached policy lookups.  It should be shared by all
+	// of the IAM services for an account so that changes made through any of them invalidate it.
+	Cache *cache.Cache
+	// PathPrefix is the IAM path the users, groups and policies are created under and listed from, ie.
+	// /spinup/org/.  They're created at / if it's empty.
+	PathPrefix string
 }
 
 // NewSession creates a new IAM session
@@ -140,169 +162,205 @@ func NewSession(sess *session.Session, account common.Account) IAM {
 	i.DefaultS3BucketActions = account.DefaultS3BucketActions
 	i.DefaultS3ObjectActions = account.DefaultS3ObjectActions
 	i.DefaultCloudfrontDistributionActions = account.DefaultCloudfrontDistributionActions
+	i.RequireMFA = account.RequireMFA
+	i.PathPrefix = account.IAMPath
 
 	return i
 }
 
 // ReadOnlyBucketPolicy generates the read-only bucket policy
 func (i *IAM) ReadOnlyBucketPolicy(bucket string) ([]byte, error) {
+	log.Infof("generating read-only bucket policy document for %s", bucket)
+	return i.ComposePolicyDocument(BlockInput{Bucket: bucket}, BlockReadOnly)
+}
 
+// ReadOnlyBucketPolicyWithPath generates the read-only bucket policy
+func (i *IAM) ReadOnlyBucketPolicyWithPath(bucket string, path string) ([]byte, error) {
 	log.Infof("generating read-only bucket policy document for %s", bucket)
+	return i.ComposePolicyDocument(BlockInput{Bucket: bucket, Path: path}, BlockReadOnly)
+}
 
-	policyDoc, err := json.Marshal(PolicyDoc{
-		Version: "2012-10-17",
-		Statement: []PolicyStatement{
-			{
-				Effect:   "Allow",
-				Action:   BucketReadPolicy,
-				Resource: []string{fmt.Sprintf("arn:aws:s3:::%s", bucket)},
-			},
-			{
-				Effect:   "Allow",
-				Action:   ObjectReadPolicy,
-				Resource: []string{fmt.Sprintf("arn:aws:s3:::%s/*", bucket)},
+// ReadWriteBucketPolicy generates the read-write bucket policy
+func (i *IAM) ReadWriteBucketPolicy(bucket string) ([]byte, error) {
+	log.Infof("generating read-write bucket policy document for %s", bucket)
+	return i.ComposePolicyDocument(BlockInput{Bucket: bucket}, BlockReadWrite)
+}
+
+// ReadWriteBucketPolicyWithPath generates the read-write bucket policy
+func (i *IAM) ReadWriteBucketPolicyWithPath(bucket string, path string) ([]byte, error) {
+	log.Infof("generating read-write bucket policy document for %s", bucket)
+	return i.ComposePolicyDocument(BlockInput{Bucket: bucket, Path: path}, BlockReadWrite)
+}
+
+// AdminBucketPolicy generates the administrative bucket policy
+func (i *IAM) AdminBucketPolicy(bucket string) ([]byte, error) {
+	log.Infof("generating administrative bucket policy document for %s", bucket)
+	return i.ComposePolicyDocument(BlockInput{Bucket: bucket}, BlockAdmin)
+}
+
+// AdminBucketPolicyWithPath generates the administrative bucket policy
+func (i *IAM) AdminBucketPolicyWithPath(bucket string, path string) ([]byte, error) {
+	log.Infof("generating administrative bucket policy document for %s", bucket)
+	return i.ComposePolicyDocument(BlockInput{Bucket: bucket, Path: path}, BlockAdmin)
+}
+
+// requireMFA appends a statement denying destructive actions on the bucket when the request wasn't authenticated
+// with MFA, if the account is configured to require it.  Note that requests signed with long-term access keys
+// never carry MFA context so destructive actions are denied for them as well.
+func (i *IAM) requireMFA(bucket string, statements []PolicyStatement) []PolicyStatement {
+	if !i.RequireMFA {
+		return statements
+	}
+
+	return append(statements, PolicyStatement{
+		Effect: "Deny",
+		Action: DestructivePolicy,
+		Resource: []string{
+			fmt.Sprintf("arn:aws:s3:::%s", bucket),
+			fmt.Sprintf("arn:aws:s3:::%s/*", bucket),
+		},
+		Condition: map[string]PolicyCondition{
+			"BoolIfExists": {
+				"aws:MultiFactorAuthPresent": "false",
 			},
 		},
 	})
+}
 
-	if err != nil {
-		log.Errorf("failed to generate read-only bucket policy for %s: %s", bucket, err)
-		return []byte{}, err
+// sourceIPCondition returns the condition limiting a statement to requests from the networks, nil if there aren't any
+func sourceIPCondition(cidrs []string) map[string]PolicyCondition {
+	if len(cidrs) == 0 {
+		return nil
 	}
 
-	log.Debugf("generated policy document %s", string(policyDoc))
-
-	return policyDoc, nil
+	return map[string]PolicyCondition{
+		"IpAddress": {"aws:SourceIp": cidrs},
+	}
 }
 
-// ReadOnlyBucketPolicyWithPath generates the read-only bucket policy
-func (i *IAM) ReadOnlyBucketPolicyWithPath(bucket string, path string) ([]byte, error) {
-	log.Infof("generating read-only bucket policy document for %s", bucket)
-
-	path = RemoveCappingSlashes(path)
-
+// DefaultBucketAdminPolicy generates the default policy statement for s3 buckets.  When networks are passed, access
+// is only allowed from them.
+func (i *IAM) DefaultBucketAdminPolicy(bucket *string, cidrs []string) ([]byte, error) {
+	b := aws.StringValue(bucket)
+	log.Debugf("generating default bucket admin policy for %s", b)
 	policyDoc, err := json.Marshal(PolicyDoc{
 		Version: "2012-10-17",
 		Statement: []PolicyStatement{
 			{
-				Effect:   "Allow",
-				Action:   BucketReadPolicy,
-				Resource: []string{fmt.Sprintf("arn:aws:s3:::%s", bucket)},
-				Condition: map[string]PolicyCondition{
-					"StringLike": {
-						"s3:prefix": fmt.Sprintf("%s/*", path),
-					},
-				},
+				Effect:    "Allow",
+				Action:    i.DefaultS3BucketActions,
+				Resource:  []string{fmt.Sprintf("arn:aws:s3:::%s", b)},
+				Condition: sourceIPCondition(cidrs),
 			},
 			{
-				Effect:   "Allow",
-				Action:   ObjectReadPolicy,
-				Resource: []string{fmt.Sprintf("arn:aws:s3:::%s/%s/*", bucket, path)},
+				Effect:    "Allow",
+				Action:    i.DefaultS3ObjectActions,
+				Resource:  []string{fmt.Sprintf("arn:aws:s3:::%s/*", b)},
+				Condition: sourceIPCondition(cidrs),
 			},
 		},
 	})
 
 	if err != nil {
-		log.Errorf("failed to generate read-only bucket policy for %s: %s", bucket, err)
+		log.Errorf("failed to generate default bucket admin policy for %s: %s", b, err)
 		return []byte{}, err
 	}
-
-	log.Debugf("generated policy document %s", string(policyDoc))
+	log.Debugf("creating policy with document %s", string(policyDoc))
 
 	return policyDoc, nil
 }
 
-// ReadWriteBucketPolicy generates the read-write bucket policy
-func (i *IAM) ReadWriteBucketPolicy(bucket string) ([]byte, error) {
-
-	log.Infof("generating read-write bucket policy document for %s", bucket)
-
+// DefaultWebAdminPolicy generates the default policy statement for website admin
+func (i *IAM) DefaultWebAdminPolicy(distributionArn *string) ([]byte, error) {
+	d := aws.StringValue(distributionArn)
+	log.Debugf("generating default web admin policy for %s", d)
 	policyDoc, err := json.Marshal(PolicyDoc{
 		Version: "2012-10-17",
 		Statement: []PolicyStatement{
 			{
 				Effect:   "Allow",
-				Action:   BucketReadPolicy,
-				Resource: []string{fmt.Sprintf("arn:aws:s3:::%s", bucket)},
-			},
-			{
-				Effect:   "Allow",
-				Action:   ObjectReadPolicy,
-				Resource: []string{fmt.Sprintf("arn:aws:s3:::%s/*", bucket)},
-			},
-			{
-				Effect:   "Allow",
-				Action:   ObjectWritePolicy,
-				Resource: []string{fmt.Sprintf("arn:aws:s3:::%s/*", bucket)},
+				Action:   i.DefaultCloudfrontDistributionActions,
+				Resource: []string{aws.StringValue(distributionArn)},
 			},
 		},
 	})
 
 	if err != nil {
-		log.Errorf("failed to generate read-write bucket policy for %s: %s", bucket, err)
+		log.Errorf("failed to generate default web admin policy for %s: %s", d, err)
 		return []byte{}, err
 	}
-
-	log.Debugf("generated policy document %s", string(policyDoc))
+	log.Debugf("creating policy with document %s", string(policyDoc))
 
 	return policyDoc, nil
 }
 
-// ReadWriteBucketPolicyWithPath generates the read-write bucket policy
-func (i *IAM) ReadWriteBucketPolicyWithPath(bucket string, path string) ([]byte, error) {
-	log.Infof("generating read-write bucket policy document for %s", bucket)
+// DefaultWebsiteAccessPolicy generated the default website access policy statement for s3 websites
+//
+//	  {
+//	    "Version":"2012-10-17",
+//	    "Statement":[{
+//		     "Sid":"SpinupWebsiteRead",
+//			 "Effect":"Allow",
+//		     "Principal": "*",
+//		     "Action":["s3:GetObject"],
+//		     "Resource":["arn:aws:s3:::example-bucket/*"]
+//	    }]
+//	  }
+//
+// When networks are passed, the objects can only be read from them.
+func (i *IAM) DefaultWebsiteAccessPolicy(bucket *string, cidrs []string) ([]byte, error) {
+	log.Debugf("generating default bucket website policy for %s", aws.StringValue(bucket))
+	return i.ComposePolicyDocument(BlockInput{Bucket: aws.StringValue(bucket), IPAllowlist: cidrs}, BlockWebsiteRead)
+}
 
-	path = RemoveCappingSlashes(path)
+// SimpleWebsiteAccessPolicy generates the bucket policy for a simple website, it allows reading the objects in the
+// bucket from the passed networks.  There's a statement for each network since a request only has to match one.
+func (i *IAM) SimpleWebsiteAccessPolicy(bucket *string, cidrs []string) ([]byte, error) {
+	b := aws.StringValue(bucket)
+	if b == "" || len(cidrs) == 0 {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
 
-	policyDoc, err := json.Marshal(PolicyDoc{
-		Version: "2012-10-17",
-		Statement: []PolicyStatement{
-			{
-				Effect:   "Allow",
-				Action:   BucketReadPolicy,
-				Resource: []string{fmt.Sprintf("arn:aws:s3:::%s", bucket)},
-				Condition: map[string]PolicyCondition{
-					"StringLike": {
-						"s3:prefix": fmt.Sprintf("%s/*", path),
-					},
-				},
-			},
-			{
-				Effect:   "Allow",
-				Action:   ObjectReadPolicy,
-				Resource: []string{fmt.Sprintf("arn:aws:s3:::%s/%s/*", bucket, path)},
-			},
-			{
-				Effect:   "Allow",
-				Action:   ObjectWritePolicy,
-				Resource: []string{fmt.Sprintf("arn:aws:s3:::%s/%s/*", bucket, path)},
+	log.Debugf("generating simple website policy for %s allowing %v", b, cidrs)
+
+	statements := []PolicyStatement{}
+	for _, c := range cidrs {
+		statements = append(statements, PolicyStatement{
+			Effect:    "Allow",
+			Principal: "*",
+			Action:    []string{"s3:GetObject"},
+			Resource:  []string{fmt.Sprintf("arn:aws:s3:::%s/*", b)},
+			Condition: map[string]PolicyCondition{
+				"IpAddress": {"aws:SourceIp": c},
 			},
-		},
-	})
+		})
+	}
 
+	policyDoc, err := json.Marshal(PolicyDoc{
+		Version:   "2012-10-17",
+		Statement: statements,
+	})
 	if err != nil {
-		log.Errorf("failed to generate read-write bucket policy for %s: %s", bucket, err)
-		return []byte{}, err
+		log.Errorf("failed to generate simple website policy for %s: %s", b, err)
+		return nil, err
 	}
 
-	log.Debugf("generated policy document %s", string(policyDoc))
-
 	return policyDoc, nil
 }
 
-// AdminBucketPolicy generates the administrative bucket policy
-func (i *IAM) AdminBucketPolicy(bucket string) ([]byte, error) {
+// TransferBucketPolicy generates the policy for the role the aws transfer family uses to access the bucket for the
+// sftp users.  It's read-write without the MFA requirement, since the transfer family assumes the role and requests
+// are never made with MFA.
+func (i *IAM) TransferBucketPolicy(bucket string) ([]byte, error) {
+	if bucket == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
 
-	log.Infof("generating administrative bucket policy document for %s", bucket)
+	log.Infof("generating transfer bucket policy document for %s", bucket)
 
 	policyDoc, err := json.Marshal(PolicyDoc{
 		Version: "2012-10-17",
 		Statement: []PolicyStatement{
-			{
-				Effect:   "Allow",
-				Action:   BucketAdminPolicy,
-				Resource: []string{fmt.Sprintf("arn:aws:s3:::%s", bucket)},
-			},
 			{
 				Effect:   "Allow",
 				Action:   BucketReadPolicy,
@@ -320,10 +378,9 @@ func (i *IAM) AdminBucketPolicy(bucket string) ([]byte, error) {
 			},
 		},
 	})
-
 	if err != nil {
-		log.Errorf("failed to generate read-write bucket policy for %s: %s", bucket, err)
-		return []byte{}, err
+		log.Errorf("failed to generate transfer bucket policy for %s: %s", bucket, err)
+		return nil, err
 	}
 
 	log.Debugf("generated policy document %s", string(policyDoc))
@@ -331,143 +388,125 @@ func (i *IAM) AdminBucketPolicy(bucket string) ([]byte, error) {
 	return policyDoc, nil
 }
 
-// AdminBucketPolicyWithPath generates the administrative bucket policy
-func (i *IAM) AdminBucketPolicyWithPath(bucket string, path string) ([]byte, error) {
-	log.Infof("generating administrative bucket policy document for %s", bucket)
+// TransferTrustPolicy generates the trust policy that allows the aws transfer family to assume a role on behalf
+// of the servers in the account
+//
+//	{
+//	  "Version": "2012-10-17",
+//	  "Statement": [{
+//	    "Effect": "Allow",
+//	    "Principal": {"Service": "transfer.amazonaws.com"},
+//	    "Action": "sts:AssumeRole",
+//	    "Condition": {"StringEquals": {"aws:SourceAccount": "012345678910"}}
+//	  }]
+//	}
+func TransferTrustPolicy(accountId string) ([]byte, error) {
+	if accountId == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
 
-	path = RemoveCappingSlashes(path)
+	// the principal of a trust policy is a service, so the PolicyStatement type can't be used
+	type trustStatement struct {
+		Effect    string
+		Principal map[string]string
+		Action    string
+		Condition map[string]PolicyCondition
+	}
 
-	policyDoc, err := json.Marshal(PolicyDoc{
+	policyDoc, err := json.Marshal(struct {
+		Version   string
+		Statement []trustStatement
+	}{
 		Version: "2012-10-17",
-		Statement: []PolicyStatement{
-			{
-				Effect:   "Allow",
-				Action:   BucketAdminPolicy,
-				Resource: []string{fmt.Sprintf("arn:aws:s3:::%s", bucket)},
-				Condition: map[string]PolicyCondition{
-					"StringLike": {
-						"s3:prefix": fmt.Sprintf("%s/*", path),
-					},
-				},
-			},
+		Statement: []trustStatement{
 			{
-				Effect:   "Allow",
-				Action:   BucketReadPolicy,
-				Resource: []string{fmt.Sprintf("arn:aws:s3:::%s", bucket)},
+				Effect:    "Allow",
+				Principal: map[string]string{"Service": "transfer.amazonaws.com"},
+				Action:    "sts:AssumeRole",
 				Condition: map[string]PolicyCondition{
-					"StringLike": {
-						"s3:prefix": fmt.Sprintf("%s/*", path),
-					},
+					"StringEquals": {"aws:SourceAccount": accountId},
 				},
 			},
-			{
-				Effect:   "Allow",
-				Action:   ObjectReadPolicy,
-				Resource: []string{fmt.Sprintf("arn:aws:s3:::%s/%s/*", bucket, path)},
-			},
-			{
-				Effect:   "Allow",
-				Action:   ObjectWritePolicy,
-				Resource: []string{fmt.Sprintf("arn:aws:s3:::%s/%s/*", bucket, path)},
-			},
 		},
 	})
-
 	if err != nil {
-		log.Errorf("failed to generate read-write bucket policy for %s: %s", bucket, err)
-		return []byte{}, err
+		log.Errorf("failed to generate transfer trust policy: %s", err)
+		return nil, err
 	}
 
-	log.Debugf("generated policy document %s", string(policyDoc))
-
 	return policyDoc, nil
 }
 
-// DefaultBucketAdminPolicy generates the default policy statement for s3 buckets
-func (i *IAM) DefaultBucketAdminPolicy(bucket *string) ([]byte, error) {
-	b := aws.StringValue(bucket)
-	log.Debugf("generating default bucket admin policy for %s", b)
-	policyDoc, err := json.Marshal(PolicyDoc{
-		Version: "2012-10-17",
-		Statement: []PolicyStatement{
-			{
-				Effect:   "Allow",
-				Action:   i.DefaultS3BucketActions,
-				Resource: []string{fmt.Sprintf("arn:aws:s3:::%s", b)},
-			},
-			{
-				Effect:   "Allow",
-				Action:   i.DefaultS3ObjectActions,
-				Resource: []string{fmt.Sprintf("arn:aws:s3:::%s/*", b)},
-			},
-		},
-	})
+// AccessPointPolicy generates the resource policy for an s3 access point.  The principals are granted list access
+// through the access point and read access to the objects, limited to the prefix if one is passed.  Write access
+// to the objects is also granted if write is set.  Access through the access point must also be allowed (or
+// delegated to access points) by the bucket policy.
+func AccessPointPolicy(accessPointArn, prefix string, principals []string, write bool) ([]byte, error) {
+	if accessPointArn == "" || len(principals) == 0 {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
 
-	if err != nil {
-		log.Errorf("failed to generate default bucket admin policy for %s: %s", b, err)
-		return []byte{}, err
+	log.Infof("generating access point policy document for %s", accessPointArn)
+
+	// the principal of a resource policy is a map of aws principals, so the PolicyStatement type can't be used
+	type resourceStatement struct {
+		Effect    string
+		Principal map[string][]string
+		Action    []string
+		Resource  []string
+		Condition map[string]PolicyCondition `json:",omitempty"`
 	}
-	log.Debugf("creating policy with document %s", string(policyDoc))
 
-	return policyDoc, nil
-}
+	prefix = RemoveCappingSlashes(prefix)
+	objects := fmt.Sprintf("%s/object/*", accessPointArn)
+	if prefix != "" {
+		objects = fmt.Sprintf("%s/object/%s/*", accessPointArn, prefix)
+	}
 
-// DefaultWebAdminPolicy generates the default policy statement for website admin
-func (i *IAM) DefaultWebAdminPolicy(distributionArn *string) ([]byte, error) {
-	d := aws.StringValue(distributionArn)
-	log.Debugf("generating default web admin policy for %s", d)
-	policyDoc, err := json.Marshal(PolicyDoc{
-		Version: "2012-10-17",
-		Statement: []PolicyStatement{
-			{
-				Effect:   "Allow",
-				Action:   i.DefaultCloudfrontDistributionActions,
-				Resource: []string{aws.StringValue(distributionArn)},
-			},
+	principal := map[string][]string{"AWS": principals}
+	statements := []resourceStatement{
+		{
+			Effect:    "Allow",
+			Principal: principal,
+			Action:    []string{"s3:ListBucket"},
+			Resource:  []string{accessPointArn},
 		},
-	})
+		{
+			Effect:    "Allow",
+			Principal: principal,
+			Action:    ObjectReadPolicy,
+			Resource:  []string{objects},
+		},
+	}
 
-	if err != nil {
-		log.Errorf("failed to generate default web admin policy for %s: %s", d, err)
-		return []byte{}, err
+	if prefix != "" {
+		statements[0].Condition = map[string]PolicyCondition{
+			"StringLike": {"s3:prefix": fmt.Sprintf("%s/*", prefix)},
+		}
 	}
-	log.Debugf("creating policy with document %s", string(policyDoc))
 
-	return policyDoc, nil
-}
+	if write {
+		statements = append(statements, resourceStatement{
+			Effect:    "Allow",
+			Principal: principal,
+			Action:    ObjectWritePolicy,
+			Resource:  []string{objects},
+		})
+	}
 
-// DefaultWebsiteAccessPolicy generated the default website access policy statement for s3 websites
-//
-//	  {
-//	    "Version":"2012-10-17",
-//	    "Statement":[{
-//		     "Sid":"PublicReadGetObject",
-//			 "Effect":"Allow",
-//		     "Principal": "*",
-//		     "Action":["s3:GetObject"],
-//		     "Resource":["arn:aws:s3:::example-bucket/*"]
-//	    }]
-//	  }
-func (i *IAM) DefaultWebsiteAccessPolicy(bucket *string) ([]byte, error) {
-	b := aws.StringValue(bucket)
-	log.Debugf("generating default bucket website policy for %s", b)
-	policyDoc, err := json.Marshal(PolicyDoc{
-		Version: "2012-10-17",
-		Statement: []PolicyStatement{
-			{
-				Effect:    "Allow",
-				Principal: "*",
-				Action:    []string{"s3:GetObject"},
-				Resource:  []string{fmt.Sprintf("arn:aws:s3:::%s/*", b)},
-			},
-		},
+	policyDoc, err := json.Marshal(struct {
+		Version   string
+		Statement []resourceStatement
+	}{
+		Version:   "2012-10-17",
+		Statement: statements,
 	})
-
 	if err != nil {
-		log.Errorf("failed to generate default bucket website policy for %s: %s", b, err)
-		return []byte{}, err
+		log.Errorf("failed to generate access point policy for %s: %s", accessPointArn, err)
+		return nil, err
 	}
-	log.Debugf("creating policy with document %s", string(policyDoc))
+
+	log.Debugf("generated policy document %s", string(policyDoc))
 
 	return policyDoc, nil
 }
@@ -76,6 +76,7 @@ var defaultWebsitePolicyDoc = PolicyDoc{
 	Version: "2012-10-17",
 	Statement: []PolicyStatement{
 		{
+			Sid:       "SpinupWebsiteRead",
 			Effect:    "Allow",
 			Principal: "*",
 			Action:    []string{"s3:GetObject"},
@@ -122,13 +123,54 @@ func TestAdminBucketPolicy(t *testing.T) {
 	}
 }
 
+func TestAdminBucketPolicyRequireMFA(t *testing.T) {
+	mfa := &IAM{RequireMFA: true}
+
+	policyBytes, err := mfa.AdminBucketPolicy(bucket)
+	if err != nil {
+		t.Errorf("expected AdminBucketPolicy to return nil error, got %s", err)
+	}
+
+	var doc PolicyDoc
+	if err := json.Unmarshal(policyBytes, &doc); err != nil {
+		t.Fatalf("failed to unmarshal policy document: %s", err)
+	}
+
+	expected := PolicyStatement{
+		Effect:   "Deny",
+		Action:   DestructivePolicy,
+		Resource: []string{"arn:aws:s3:::vehicles", "arn:aws:s3:::vehicles/*"},
+		Condition: map[string]PolicyCondition{
+			"BoolIfExists": {"aws:MultiFactorAuthPresent": "false"},
+		},
+	}
+
+	if len(doc.Statement) != 5 {
+		t.Fatalf("expected 5 statements, got %d", len(doc.Statement))
+	}
+
+	if !reflect.DeepEqual(doc.Statement[4], expected) {
+		t.Errorf("expected: %+v\ngot: %+v", expected, doc.Statement[4])
+	}
+
+	// read-only policies don't allow destructive actions so they don't need the deny
+	policyBytes, err = mfa.ReadOnlyBucketPolicy(bucket)
+	if err != nil {
+		t.Errorf("expected ReadOnlyBucketPolicy to return nil error, got %s", err)
+	}
+
+	if bytes.Contains(policyBytes, []byte("aws:MultiFactorAuthPresent")) {
+		t.Errorf("expected read-only policy not to require mfa, got %s", policyBytes)
+	}
+}
+
 func TestDefaultBucketAdminPolicy(t *testing.T) {
 	p, err := json.Marshal(defaultPolicyDoc)
 	if err != nil {
 		t.Errorf("expected to marshall defaultPolicyDoc with nil error, got %s", err)
 	}
 
-	policyBytes, err := i.DefaultBucketAdminPolicy(&bucket)
+	policyBytes, err := i.DefaultBucketAdminPolicy(&bucket, nil)
 	if err != nil {
 		t.Errorf("expected DefaultBucketAdminPolicy to return nil error, got %s", err)
 	}
@@ -138,6 +180,24 @@ func TestDefaultBucketAdminPolicy(t *testing.T) {
 	}
 }
 
+func TestDefaultBucketAdminPolicyWithAllowlist(t *testing.T) {
+	policyBytes, err := i.DefaultBucketAdminPolicy(&bucket, []string{"10.0.0.0/8", "192.168.1.0/24"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	policy := PolicyDoc{}
+	if err := json.Unmarshal(policyBytes, &policy); err != nil {
+		t.Fatalf("failed to unmarshal policy: %s", err)
+	}
+
+	for _, st := range policy.Statement {
+		if !reflect.DeepEqual(st.Condition["IpAddress"]["aws:SourceIp"], []interface{}{"10.0.0.0/8", "192.168.1.0/24"}) {
+			t.Errorf("expected statement to be limited to the allowlist, got %+v", st.Condition)
+		}
+	}
+}
+
 func TestDefaultWebAdminPolicy(t *testing.T) {
 	p, err := json.Marshal(defaultWebPolicyDoc)
 	if err != nil {
@@ -160,7 +220,7 @@ func TestDefaultWebsiteAccessPolicy(t *testing.T) {
 		t.Errorf("expected to marshall defaultWebsitePolicyDoc with nil error, got %s", err)
 	}
 
-	policyBytes, err := i.DefaultWebsiteAccessPolicy(&bucket)
+	policyBytes, err := i.DefaultWebsiteAccessPolicy(&bucket, nil)
 	if err != nil {
 		t.Errorf("expected DefaultWebsiteAccessPolicy to return nil error, got %s", err)
 	}
@@ -169,3 +229,146 @@ func TestDefaultWebsiteAccessPolicy(t *testing.T) {
 		t.Errorf("expected: %+v\ngot: %s", defaultWebsitePolicyDoc, policyBytes)
 	}
 }
+
+func TestDefaultWebsiteAccessPolicyWithAllowlist(t *testing.T) {
+	expected := `{"Version":"2012-10-17","Statement":[{"Sid":"SpinupWebsiteRead","Effect":"Allow","Principal":"*","Action":["s3:GetObject"],"Resource":["arn:aws:s3:::vehicles/*"],"Condition":{"IpAddress":{"aws:SourceIp":["10.0.0.0/8"]}}}]}`
+
+	b := "vehicles"
+	policyBytes, err := i.DefaultWebsiteAccessPolicy(&b, []string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if string(policyBytes) != expected {
+		t.Errorf("expected: %s\ngot: %s", expected, policyBytes)
+	}
+}
+
+func TestSimpleWebsiteAccessPolicy(t *testing.T) {
+	expected := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":["s3:GetObject"],"Resource":["arn:aws:s3:::vehicles/*"],"Condition":{"IpAddress":{"aws:SourceIp":"10.0.0.0/8"}}},{"Effect":"Allow","Principal":"*","Action":["s3:GetObject"],"Resource":["arn:aws:s3:::vehicles/*"],"Condition":{"IpAddress":{"aws:SourceIp":"192.168.1.0/24"}}}]}`
+
+	policyBytes, err := i.SimpleWebsiteAccessPolicy(&bucket, []string{"10.0.0.0/8", "192.168.1.0/24"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if string(policyBytes) != expected {
+		t.Errorf("expected: %s\ngot: %s", expected, policyBytes)
+	}
+
+	if _, err := i.SimpleWebsiteAccessPolicy(&bucket, nil); err == nil {
+		t.Error("expected error without any networks, got nil")
+	}
+}
+
+func TestTransferBucketPolicy(t *testing.T) {
+	mfa := IAM{RequireMFA: true}
+	policyBytes, err := mfa.TransferBucketPolicy(bucket)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	policy := PolicyDoc{}
+	if err := json.Unmarshal(policyBytes, &policy); err != nil {
+		t.Fatalf("expected valid policy json, got %s", err)
+	}
+
+	if len(policy.Statement) != 3 {
+		t.Fatalf("expected 3 statements, got %d", len(policy.Statement))
+	}
+
+	for _, s := range policy.Statement {
+		if s.Effect != "Allow" {
+			t.Errorf("expected the transfer policy not to require mfa, got a %s statement", s.Effect)
+		}
+	}
+
+	if _, err := mfa.TransferBucketPolicy(""); err == nil {
+		t.Error("expected error for empty bucket, got nil")
+	}
+}
+
+func TestTransferTrustPolicy(t *testing.T) {
+	expected := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"transfer.amazonaws.com"},"Action":"sts:AssumeRole","Condition":{"StringEquals":{"aws:SourceAccount":"012345678910"}}}]}`
+
+	policyBytes, err := TransferTrustPolicy("012345678910")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if string(policyBytes) != expected {
+		t.Errorf("expected: %s\ngot: %s", expected, policyBytes)
+	}
+
+	if _, err := TransferTrustPolicy(""); err == nil {
+		t.Error("expected error for empty account, got nil")
+	}
+}
+
+func TestAccessPointPolicy(t *testing.T) {
+	arn := "arn:aws:s3:us-east-1:012345678910:accesspoint/team-a"
+	principals := []string{"arn:aws:iam::012345678910:root"}
+
+	policyBytes, err := AccessPointPolicy(arn, "/shared/", principals, false)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	policy := struct {
+		Statement []struct {
+			Effect    string
+			Principal map[string][]string
+			Action    []string
+			Resource  []string
+			Condition map[string]PolicyCondition
+		}
+	}{}
+	if err := json.Unmarshal(policyBytes, &policy); err != nil {
+		t.Fatalf("failed to unmarshal policy: %s", err)
+	}
+
+	if len(policy.Statement) != 2 {
+		t.Fatalf("expected 2 statements for read access, got %d", len(policy.Statement))
+	}
+
+	list := policy.Statement[0]
+	if !reflect.DeepEqual(list.Resource, []string{arn}) || list.Condition["StringLike"]["s3:prefix"] != "shared/*" {
+		t.Errorf("unexpected list statement %+v", list)
+	}
+
+	if !reflect.DeepEqual(list.Principal["AWS"], principals) {
+		t.Errorf("expected principals %v, got %v", principals, list.Principal)
+	}
+
+	read := policy.Statement[1]
+	if !reflect.DeepEqual(read.Action, ObjectReadPolicy) || !reflect.DeepEqual(read.Resource, []string{arn + "/object/shared/*"}) {
+		t.Errorf("unexpected read statement %+v", read)
+	}
+
+	policyBytes, err = AccessPointPolicy(arn, "", principals, true)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	policy.Statement = nil
+	if err := json.Unmarshal(policyBytes, &policy); err != nil {
+		t.Fatalf("failed to unmarshal policy: %s", err)
+	}
+
+	if len(policy.Statement) != 3 {
+		t.Fatalf("expected 3 statements for read-write access, got %d", len(policy.Statement))
+	}
+
+	if policy.Statement[0].Condition != nil {
+		t.Errorf("expected no prefix condition without a prefix, got %+v", policy.Statement[0].Condition)
+	}
+
+	write := policy.Statement[2]
+	if !reflect.DeepEqual(write.Action, ObjectWritePolicy) || !reflect.DeepEqual(write.Resource, []string{arn + "/object/*"}) {
+		t.Errorf("unexpected write statement %+v", write)
+	}
+
+	if _, err := AccessPointPolicy(arn, "", nil, false); err == nil {
+		t.Error("expected error without principals, got nil")
+	}
+}
@@ -0,0 +1,93 @@
+package iam
+
+import (
+	"context"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	log "github.com/sirupsen/logrus"
+)
+
+// ServiceLastAccessed is the result of a service last accessed job, when a user or group last used each of the
+// services its policies allow
+type ServiceLastAccessed struct {
+	JobId string
+	// Status is the status of the job, IN_PROGRESS, COMPLETED or FAILED
+	Status         string
+	CompletionDate *time.Time
+	Services       []*iam.ServiceLastAccessed
+}
+
+// GenerateServiceLastAccessedDetails starts a job reporting when a user or group last used each service and returns
+// the job id.  The job for the arn is reused while it's cached so retries don't start a new job every time.
+func (i *IAM) GenerateServiceLastAccessedDetails(ctx context.Context, arn string) (string, error) {
+	if arn == "" {
+		return "", apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	key := lastAccessedJobCacheKey + arn
+	if jobId, ok := i.cacheGet(key); ok {
+		return jobId.(string), nil
+	}
+
+	log.Infof("generating service last accessed details for %s", arn)
+
+	out, err := i.Service.GenerateServiceLastAccessedDetailsWithContext(ctx, &iam.GenerateServiceLastAccessedDetailsInput{
+		Arn:         aws.String(arn),
+		Granularity: aws.String(iam.AccessAdvisorUsageGranularityTypeServiceLevel),
+	})
+	if err != nil {
+		return "", ErrCode("failed to generate service last accessed details", err)
+	}
+
+	jobId := aws.StringValue(out.JobId)
+	i.cacheSet(key, jobId)
+
+	return jobId, nil
+}
+
+// GetServiceLastAccessedDetails gets the result of a service last accessed job, the services are only set once the
+// job is COMPLETED.  A job that failed is returned as an error and isn't reused for the arn.
+func (i *IAM) GetServiceLastAccessedDetails(ctx context.Context, arn, jobId string) (*ServiceLastAccessed, error) {
+	if jobId == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Debugf("getting service last accessed details job %s", jobId)
+
+	input := &iam.GetServiceLastAccessedDetailsInput{JobId: aws.String(jobId)}
+	details := &ServiceLastAccessed{JobId: jobId, Services: []*iam.ServiceLastAccessed{}}
+	for {
+		out, err := i.Service.GetServiceLastAccessedDetailsWithContext(ctx, input)
+		if err != nil {
+			return nil, ErrCode("failed to get service last accessed details", err)
+		}
+
+		details.Status = aws.StringValue(out.JobStatus)
+		details.CompletionDate = out.JobCompletionDate
+
+		switch details.Status {
+		case iam.JobStatusTypeFailed:
+			i.cacheDelete(lastAccessedJobCacheKey + arn)
+
+			msg := "service last accessed details job " + jobId + " failed"
+			if out.Error != nil {
+				msg += ": " + aws.StringValue(out.Error.Message)
+			}
+			return nil, apierror.New(apierror.ErrServiceUnavailable, msg, nil)
+		case iam.JobStatusTypeInProgress:
+			return details, nil
+		}
+
+		details.Services = append(details.Services, out.ServicesLastAccessed...)
+
+		if !aws.BoolValue(out.IsTruncated) {
+			break
+		}
+		input.Marker = out.Marker
+	}
+
+	return details, nil
+}
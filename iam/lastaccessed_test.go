@@ -0,0 +1,127 @@
+package iam
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/patrickmn/go-cache"
+)
+
+// mockLastAccessedClient starts a new job for every generate call and returns the pages of services for a job
+type mockLastAccessedClient struct {
+	iamiface.IAMAPI
+	jobs   int
+	status string
+	pages  [][]*iam.ServiceLastAccessed
+}
+
+func (m *mockLastAccessedClient) GenerateServiceLastAccessedDetailsWithContext(ctx context.Context, input *iam.GenerateServiceLastAccessedDetailsInput, opts ...request.Option) (*iam.GenerateServiceLastAccessedDetailsOutput, error) {
+	m.jobs++
+	return &iam.GenerateServiceLastAccessedDetailsOutput{JobId: aws.String(fmt.Sprintf("job%d", m.jobs))}, nil
+}
+
+func (m *mockLastAccessedClient) GetServiceLastAccessedDetailsWithContext(ctx context.Context, input *iam.GetServiceLastAccessedDetailsInput, opts ...request.Option) (*iam.GetServiceLastAccessedDetailsOutput, error) {
+	out := &iam.GetServiceLastAccessedDetailsOutput{JobStatus: aws.String(m.status)}
+
+	switch m.status {
+	case iam.JobStatusTypeFailed:
+		out.Error = &iam.ErrorDetails{Code: aws.String("Failed"), Message: aws.String("something went wrong")}
+		return out, nil
+	case iam.JobStatusTypeInProgress:
+		return out, nil
+	}
+
+	page := 0
+	if input.Marker != nil {
+		page, _ = strconv.Atoi(aws.StringValue(input.Marker))
+	}
+
+	out.JobCompletionDate = aws.Time(time.Now())
+	out.ServicesLastAccessed = m.pages[page]
+	if page+1 < len(m.pages) {
+		out.IsTruncated = aws.Bool(true)
+		out.Marker = aws.String(strconv.Itoa(page + 1))
+	}
+
+	return out, nil
+}
+
+func TestGenerateServiceLastAccessedDetails(t *testing.T) {
+	m := &mockLastAccessedClient{}
+	i := IAM{Service: m, Cache: cache.New(time.Minute, 2*time.Minute)}
+
+	arn := "arn:aws:iam::012345678910:user/foo"
+	jobId, err := i.GenerateServiceLastAccessedDetails(context.TODO(), arn)
+	if err != nil || jobId != "job1" {
+		t.Fatalf("expected job1, got %s, %v", jobId, err)
+	}
+
+	// the cached job is reused for the same arn
+	if jobId, _ := i.GenerateServiceLastAccessedDetails(context.TODO(), arn); jobId != "job1" || m.jobs != 1 {
+		t.Errorf("expected the cached job, got %s after %d jobs", jobId, m.jobs)
+	}
+
+	if jobId, _ := i.GenerateServiceLastAccessedDetails(context.TODO(), arn+"bar"); jobId != "job2" {
+		t.Errorf("expected a new job for another arn, got %s", jobId)
+	}
+
+	if _, err := i.GenerateServiceLastAccessedDetails(context.TODO(), ""); err == nil {
+		t.Error("expected error for empty arn, got nil")
+	}
+}
+
+func TestGetServiceLastAccessedDetails(t *testing.T) {
+	now := time.Now()
+	m := &mockLastAccessedClient{
+		status: iam.JobStatusTypeCompleted,
+		pages: [][]*iam.ServiceLastAccessed{
+			{{ServiceNamespace: aws.String("s3"), ServiceName: aws.String("Amazon S3"), LastAuthenticated: aws.Time(now)}},
+			{{ServiceNamespace: aws.String("cloudfront"), ServiceName: aws.String("Amazon CloudFront")}},
+		},
+	}
+	i := IAM{Service: m, Cache: cache.New(time.Minute, 2*time.Minute)}
+
+	arn := "arn:aws:iam::012345678910:user/foo"
+	jobId, err := i.GenerateServiceLastAccessedDetails(context.TODO(), arn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	details, err := i.GetServiceLastAccessedDetails(context.TODO(), arn, jobId)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if details.Status != iam.JobStatusTypeCompleted || details.CompletionDate == nil || len(details.Services) != 2 {
+		t.Errorf("expected both pages of services for the completed job, got %+v", details)
+	}
+
+	m.status = iam.JobStatusTypeInProgress
+	details, err = i.GetServiceLastAccessedDetails(context.TODO(), arn, jobId)
+	if err != nil || details.Status != iam.JobStatusTypeInProgress || len(details.Services) != 0 {
+		t.Errorf("expected an in progress job without services, got %+v, %v", details, err)
+	}
+
+	// a failed job isn't reused
+	m.status = iam.JobStatusTypeFailed
+	_, err = i.GetServiceLastAccessedDetails(context.TODO(), arn, jobId)
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrServiceUnavailable {
+		t.Errorf("expected service unavailable error, got %v", err)
+	}
+
+	if jobId, _ := i.GenerateServiceLastAccessedDetails(context.TODO(), arn); jobId != "job2" {
+		t.Errorf("expected a new job after the failure, got %s", jobId)
+	}
+
+	if _, err := i.GetServiceLastAccessedDetails(context.TODO(), arn, ""); err == nil {
+		t.Error("expected error for empty job id, got nil")
+	}
+}
@@ -0,0 +1,132 @@
+package iam
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	log "github.com/sirupsen/logrus"
+)
+
+// ChangePasswordPolicyArn is the AWS managed policy that allows a user to change their own password.  It's
+// required for users created with a one-time password that must be reset at first sign-in.
+const ChangePasswordPolicyArn = "arn:aws:iam::aws:policy/IAMUserChangePassword"
+
+const (
+	passwordLower   = "abcdefghijkmnopqrstuvwxyz"
+	passwordUpper   = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+	passwordDigits  = "23456789"
+	passwordSymbols = "!@#$%^&*()-_=+[]{}"
+)
+
+// CreateLoginProfile creates a console login profile (password) for an IAM user
+func (i *IAM) CreateLoginProfile(ctx context.Context, input *iam.CreateLoginProfileInput) (*iam.LoginProfile, error) {
+	if input == nil || aws.StringValue(input.UserName) == "" || aws.StringValue(input.Password) == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("creating login profile for iam user %s", aws.StringValue(input.UserName))
+
+	output, err := i.Service.CreateLoginProfileWithContext(ctx, input)
+	if err != nil {
+		return nil, ErrCode("failed to create login profile", err)
+	}
+
+	return output.LoginProfile, nil
+}
+
+// GetLoginProfile gets the console login profile for an IAM user
+func (i *IAM) GetLoginProfile(ctx context.Context, input *iam.GetLoginProfileInput) (*iam.LoginProfile, error) {
+	if input == nil || aws.StringValue(input.UserName) == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("getting login profile for iam user %s", aws.StringValue(input.UserName))
+
+	output, err := i.Service.GetLoginProfileWithContext(ctx, input)
+	if err != nil {
+		return nil, ErrCode("failed to get login profile", err)
+	}
+
+	return output.LoginProfile, nil
+}
+
+// UpdateLoginProfile updates the console login profile (password) for an IAM user
+func (i *IAM) UpdateLoginProfile(ctx context.Context, input *iam.UpdateLoginProfileInput) error {
+	if input == nil || aws.StringValue(input.UserName) == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("updating login profile for iam user %s", aws.StringValue(input.UserName))
+
+	if _, err := i.Service.UpdateLoginProfileWithContext(ctx, input); err != nil {
+		return ErrCode("failed to update login profile", err)
+	}
+
+	return nil
+}
+
+// DeleteLoginProfile deletes the console login profile for an IAM user, removing their ability to sign in to the console
+func (i *IAM) DeleteLoginProfile(ctx context.Context, input *iam.DeleteLoginProfileInput) error {
+	if input == nil || aws.StringValue(input.UserName) == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("deleting login profile for iam user %s", aws.StringValue(input.UserName))
+
+	if _, err := i.Service.DeleteLoginProfileWithContext(ctx, input); err != nil {
+		return ErrCode("failed to delete login profile", err)
+	}
+
+	return nil
+}
+
+// GeneratePassword generates a random password of the given length containing at least one lowercase
+// letter, uppercase letter, digit and symbol so it satisfies any reasonable account password policy
+func GeneratePassword(length int) (string, error) {
+	sets := []string{passwordLower, passwordUpper, passwordDigits, passwordSymbols}
+	if length < len(sets) {
+		return "", apierror.New(apierror.ErrBadRequest, "password length too short", nil)
+	}
+
+	all := ""
+	for _, s := range sets {
+		all = all + s
+	}
+
+	password := make([]byte, length)
+	for n := 0; n < length; n++ {
+		set := all
+		if n < len(sets) {
+			set = sets[n]
+		}
+
+		c, err := randomChar(set)
+		if err != nil {
+			return "", err
+		}
+		password[n] = c
+	}
+
+	// shuffle so the required characters aren't always at the start of the password
+	for n := len(password) - 1; n > 0; n-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(n+1)))
+		if err != nil {
+			return "", apierror.New(apierror.ErrInternalError, "failed to generate password", err)
+		}
+		password[n], password[j.Int64()] = password[j.Int64()], password[n]
+	}
+
+	return string(password), nil
+}
+
+func randomChar(set string) (byte, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(set))))
+	if err != nil {
+		return 0, apierror.New(apierror.ErrInternalError, "failed to generate password", err)
+	}
+	return set[n.Int64()], nil
+}
@@ -0,0 +1,240 @@
+package iam
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+var testLoginProfile = iam.LoginProfile{
+	CreateDate:            &testTime,
+	PasswordResetRequired: aws.Bool(true),
+	UserName:              aws.String("testuser"),
+}
+
+func (m *mockIAMClient) CreateLoginProfileWithContext(ctx context.Context, input *iam.CreateLoginProfileInput, opts ...request.Option) (*iam.CreateLoginProfileOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &iam.CreateLoginProfileOutput{LoginProfile: &testLoginProfile}, nil
+}
+
+func (m *mockIAMClient) GetLoginProfileWithContext(ctx context.Context, input *iam.GetLoginProfileInput, opts ...request.Option) (*iam.GetLoginProfileOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &iam.GetLoginProfileOutput{LoginProfile: &testLoginProfile}, nil
+}
+
+func (m *mockIAMClient) UpdateLoginProfileWithContext(ctx context.Context, input *iam.UpdateLoginProfileInput, opts ...request.Option) (*iam.UpdateLoginProfileOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &iam.UpdateLoginProfileOutput{}, nil
+}
+
+func (m *mockIAMClient) DeleteLoginProfileWithContext(ctx context.Context, input *iam.DeleteLoginProfileInput, opts ...request.Option) (*iam.DeleteLoginProfileOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &iam.DeleteLoginProfileOutput{}, nil
+}
+
+func TestCreateLoginProfile(t *testing.T) {
+	i := IAM{Service: newMockIAMClient(t, nil)}
+
+	// test success
+	out, err := i.CreateLoginProfile(context.TODO(), &iam.CreateLoginProfileInput{
+		UserName:              aws.String("testuser"),
+		Password:              aws.String("s3cr3t!Pass"),
+		PasswordResetRequired: aws.Bool(true),
+	})
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if !reflect.DeepEqual(out, &testLoginProfile) {
+		t.Errorf("expected %+v, got %+v", &testLoginProfile, out)
+	}
+
+	// test nil input
+	_, err = i.CreateLoginProfile(context.TODO(), nil)
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrBadRequest {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrBadRequest, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+
+	// test empty password
+	_, err = i.CreateLoginProfile(context.TODO(), &iam.CreateLoginProfileInput{UserName: aws.String("testuser")})
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrBadRequest {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrBadRequest, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+
+	// test ErrCodePasswordPolicyViolationException
+	i.Service.(*mockIAMClient).err = awserr.New(iam.ErrCodePasswordPolicyViolationException, "password policy violation", nil)
+	_, err = i.CreateLoginProfile(context.TODO(), &iam.CreateLoginProfileInput{UserName: aws.String("testuser"), Password: aws.String("short")})
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrBadRequest {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrBadRequest, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+
+	// test ErrCodeEntityAlreadyExistsException
+	i.Service.(*mockIAMClient).err = awserr.New(iam.ErrCodeEntityAlreadyExistsException, "already exists", nil)
+	_, err = i.CreateLoginProfile(context.TODO(), &iam.CreateLoginProfileInput{UserName: aws.String("testuser"), Password: aws.String("s3cr3t!Pass")})
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrConflict {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrConflict, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+
+	// test non-aws error
+	i.Service.(*mockIAMClient).err = errors.New("things blowing up!")
+	_, err = i.CreateLoginProfile(context.TODO(), &iam.CreateLoginProfileInput{UserName: aws.String("testuser"), Password: aws.String("s3cr3t!Pass")})
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrInternalError {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrInternalError, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+}
+
+func TestGetLoginProfile(t *testing.T) {
+	i := IAM{Service: newMockIAMClient(t, nil)}
+
+	// test success
+	out, err := i.GetLoginProfile(context.TODO(), &iam.GetLoginProfileInput{UserName: aws.String("testuser")})
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if !reflect.DeepEqual(out, &testLoginProfile) {
+		t.Errorf("expected %+v, got %+v", &testLoginProfile, out)
+	}
+
+	// test empty user name
+	_, err = i.GetLoginProfile(context.TODO(), &iam.GetLoginProfileInput{})
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrBadRequest {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrBadRequest, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+
+	// test ErrCodeNoSuchEntityException
+	i.Service.(*mockIAMClient).err = awserr.New(iam.ErrCodeNoSuchEntityException, "not found", nil)
+	_, err = i.GetLoginProfile(context.TODO(), &iam.GetLoginProfileInput{UserName: aws.String("testuser")})
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrNotFound {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrNotFound, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+}
+
+func TestUpdateLoginProfile(t *testing.T) {
+	i := IAM{Service: newMockIAMClient(t, nil)}
+
+	// test success
+	err := i.UpdateLoginProfile(context.TODO(), &iam.UpdateLoginProfileInput{UserName: aws.String("testuser"), Password: aws.String("s3cr3t!Pass")})
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	// test nil input
+	err = i.UpdateLoginProfile(context.TODO(), nil)
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrBadRequest {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrBadRequest, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+
+	// test ErrCodeEntityTemporarilyUnmodifiableException
+	i.Service.(*mockIAMClient).err = awserr.New(iam.ErrCodeEntityTemporarilyUnmodifiableException, "unmodifiable", nil)
+	err = i.UpdateLoginProfile(context.TODO(), &iam.UpdateLoginProfileInput{UserName: aws.String("testuser")})
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrBadRequest {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrBadRequest, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+}
+
+func TestDeleteLoginProfile(t *testing.T) {
+	i := IAM{Service: newMockIAMClient(t, nil)}
+
+	// test success
+	err := i.DeleteLoginProfile(context.TODO(), &iam.DeleteLoginProfileInput{UserName: aws.String("testuser")})
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	// test empty user name
+	err = i.DeleteLoginProfile(context.TODO(), &iam.DeleteLoginProfileInput{})
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrBadRequest {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrBadRequest, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+
+	// test ErrCodeNoSuchEntityException
+	i.Service.(*mockIAMClient).err = awserr.New(iam.ErrCodeNoSuchEntityException, "not found", nil)
+	err = i.DeleteLoginProfile(context.TODO(), &iam.DeleteLoginProfileInput{UserName: aws.String("testuser")})
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrNotFound {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrNotFound, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+}
+
+func TestGeneratePassword(t *testing.T) {
+	for _, length := range []int{4, 16, 32} {
+		p, err := GeneratePassword(length)
+		if err != nil {
+			t.Errorf("expected nil error, got: %s", err)
+		}
+
+		if len(p) != length {
+			t.Errorf("expected password length %d, got %d", length, len(p))
+		}
+
+		for _, set := range []string{passwordLower, passwordUpper, passwordDigits, passwordSymbols} {
+			if !strings.ContainsAny(p, set) {
+				t.Errorf("expected password %s to contain one of %s", p, set)
+			}
+		}
+	}
+
+	if _, err := GeneratePassword(3); err == nil {
+		t.Error("expected error for short password, got nil")
+	}
+}
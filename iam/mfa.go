@@ -0,0 +1,37 @@
+package iam
+
+import (
+	"context"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/service/iam"
+	log "github.com/sirupsen/logrus"
+)
+
+// ListMFADevices lists the MFA devices for a user
+func (i *IAM) ListMFADevices(ctx context.Context, input *iam.ListMFADevicesInput) ([]*iam.MFADevice, error) {
+	devices := []*iam.MFADevice{}
+
+	if input == nil || aws.StringValue(input.UserName) == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("listing mfa devices for iam user %s", aws.StringValue(input.UserName))
+
+	truncated := true
+	for truncated {
+		output, err := i.Service.ListMFADevicesWithContext(ctx, input)
+		if err != nil {
+			return nil, ErrCode("failed to list iam mfa devices", err)
+		}
+		truncated = aws.BoolValue(output.IsTruncated)
+		devices = append(devices, output.MFADevices...)
+		input.Marker = output.Marker
+	}
+
+	log.Debugf("list mfa devices output: %s", awsutil.Prettify(devices))
+
+	return devices, nil
+}
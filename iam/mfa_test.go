@@ -0,0 +1,90 @@
+package iam
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+var testMFADevices = []*iam.MFADevice{
+	{
+		EnableDate:   &testTime,
+		SerialNumber: aws.String("arn:aws:iam::012345678910:mfa/testuser"),
+		UserName:     aws.String("testuser"),
+	},
+}
+
+func (m *mockIAMClient) ListMFADevicesWithContext(ctx context.Context, input *iam.ListMFADevicesInput, opts ...request.Option) (*iam.ListMFADevicesOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if aws.StringValue(input.UserName) == "nomfa" {
+		return &iam.ListMFADevicesOutput{MFADevices: []*iam.MFADevice{}}, nil
+	}
+
+	return &iam.ListMFADevicesOutput{MFADevices: testMFADevices}, nil
+}
+
+func TestListMFADevices(t *testing.T) {
+	i := IAM{Service: newMockIAMClient(t, nil)}
+
+	// test success
+	out, err := i.ListMFADevices(context.TODO(), &iam.ListMFADevicesInput{UserName: aws.String("testuser")})
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if !reflect.DeepEqual(out, testMFADevices) {
+		t.Errorf("expected %+v, got %+v", testMFADevices, out)
+	}
+
+	// test user without mfa
+	out, err = i.ListMFADevices(context.TODO(), &iam.ListMFADevicesInput{UserName: aws.String("nomfa")})
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if len(out) != 0 {
+		t.Errorf("expected 0 mfa devices, got %d", len(out))
+	}
+
+	// test nil input
+	_, err = i.ListMFADevices(context.TODO(), nil)
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrBadRequest {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrBadRequest, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+
+	// test ErrCodeNoSuchEntityException
+	i.Service.(*mockIAMClient).err = awserr.New(iam.ErrCodeNoSuchEntityException, "not found", nil)
+	_, err = i.ListMFADevices(context.TODO(), &iam.ListMFADevicesInput{UserName: aws.String("testuser")})
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrNotFound {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrNotFound, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+
+	// test non-aws error
+	i.Service.(*mockIAMClient).err = errors.New("things blowing up!")
+	_, err = i.ListMFADevices(context.TODO(), &iam.ListMFADevicesInput{UserName: aws.String("testuser")})
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrInternalError {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrInternalError, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+}
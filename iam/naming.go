@@ -0,0 +1,240 @@
+package iam
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/YaleSpinup/s3-api/common"
+)
+
+// defaultNameTemplate is the template for the names of the groups, policies and roles if it isn't configured
+const defaultNameTemplate = "{bucket}-{type}"
+
+// GroupTypes are the types of the management groups for buckets and websites
+var GroupTypes = []string{"BktAdmGrp", "BktRWGrp", "BktROGrp", "WebAdmGrp"}
+
+// PolicyTypes are the types of the policies created for buckets and websites
+var PolicyTypes = []string{"BktAdmPlc", "BktRWPlc", "BktROPlc", "WebAdmPlc", "SftpPlc"}
+
+// RoleTypes are the types of the roles created for buckets
+var RoleTypes = []string{"SftpRole"}
+
+// nameCharacters are the characters IAM allows in the names of groups, policies and roles
+var nameCharacters = regexp.MustCompile(`^[\w+=,.@-]*$`)
+
+// naming is the naming convention of the IAM resources, set from the configuration by SetNaming
+var naming = common.Naming{}
+
+// SetNaming sets the templates the names of the IAM groups, policies and roles are formatted with.  The defaults are
+// used if it's nil.  It's set once when the api starts, before any names are formatted.
+func SetNaming(config *common.Naming) error {
+	if config == nil {
+		naming = common.Naming{}
+		return nil
+	}
+
+	for _, template := range []string{config.Group, config.Policy, config.Role} {
+		if template == "" {
+			continue
+		}
+
+		if strings.Count(template, "{bucket}") != 1 || strings.Count(template, "{type}") != 1 {
+			return fmt.Errorf("naming template %q must have {bucket} and {type} once", template)
+		}
+
+		if !nameCharacters.MatchString(strings.NewReplacer("{bucket}", "", "{type}", "").Replace(template)) {
+			return fmt.Errorf("naming template %q has characters that aren't allowed in IAM names", template)
+		}
+	}
+
+	known := map[string]bool{}
+	for _, kinds := range [][]string{GroupTypes, PolicyTypes, RoleTypes} {
+		for _, k := range kinds {
+			known[k] = true
+		}
+	}
+
+	for kind, name := range config.Types {
+		if !known[kind] {
+			return fmt.Errorf("unknown type %q in the naming types", kind)
+		}
+
+		if name == "" || !nameCharacters.MatchString(name) {
+			return fmt.Errorf("invalid name %q for type %s", name, kind)
+		}
+	}
+
+	// the names are parsed back into their type, so the types of a resource can't share a name
+	for _, kinds := range [][]string{GroupTypes, PolicyTypes, RoleTypes} {
+		names := map[string]string{}
+		for _, k := range kinds {
+			name := typeName(config, k)
+			if other, ok := names[name]; ok {
+				return fmt.Errorf("types %s and %s can't both be named %q", other, k, name)
+			}
+			names[name] = k
+		}
+	}
+
+	naming = *config
+
+	return nil
+}
+
+// typeName returns the name of a type of resource in the names
+func typeName(config *common.Naming, kind string) string {
+	if name, ok := config.Types[kind]; ok {
+		return name
+	}
+	return kind
+}
+
+// nameTemplate returns the template or the default template if it isn't set
+func nameTemplate(template string) string {
+	if template == "" {
+		return defaultNameTemplate
+	}
+	return template
+}
+
+// formatName formats a name from the template for a base and type of resource
+func formatName(template, base, kind string) string {
+	return strings.NewReplacer("{bucket}", base, "{type}", typeName(&naming, kind)).Replace(nameTemplate(template))
+}
+
+// splitName returns the base of a name formatted from the template for a type of resource, ok is false if the name
+// wasn't formatted for the type
+func splitName(template, name, kind string) (string, bool) {
+	parts := strings.SplitN(formatName(template, "\x00", kind), "\x00", 2)
+	prefix, suffix := parts[0], parts[1]
+
+	if len(name) <= len(prefix)+len(suffix) || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+		return "", false
+	}
+
+	return name[len(prefix) : len(name)-len(suffix)], true
+}
+
+// pathBase returns the base of the names of the resources for a path in a bucket, the bucket name for the whole
+// bucket or the bucket name and the path with its slashes replaced by underscores, ie. foo-docs_2024
+func pathBase(bucket, path string) string {
+	path = EnforcePathFormat(path)
+	if path == "/" {
+		return bucket
+	}
+
+	return fmt.Sprintf("%s-%s", bucket, strings.Replace(RemoveCappingSlashes(path), "/", "_", -1))
+}
+
+// FormatGroupName formats the name of a management group for a path in a bucket, ie. foo-docs-BktAdmGrp.  The path is
+// the path in the bucket, not the IAM path, so the names don't change with the path prefix.
+func FormatGroupName(base string, path string, group string) string {
+	return formatName(naming.Group, pathBase(base, path), group)
+}
+
+// FormatPolicyName formats the name of a policy for a path in a bucket, ie. foo-docs-BktAdmPlc
+func FormatPolicyName(base string, path string, policy string) string {
+	return formatName(naming.Policy, pathBase(base, path), policy)
+}
+
+// FormatRoleName formats the name of a role for a bucket, ie. foo-SftpRole
+func FormatRoleName(base string, role string) string {
+	return formatName(naming.Role, base, role)
+}
+
+// bucketNames returns the names formatted from the template for each of the types of resources of a bucket, ie.
+// foo-BktAdmGrp and foo-BktRWGrp for the groups.  They're the exact names, not patterns, so they don't match the
+// resources of another bucket whose name starts with the bucket name, like foo-bar.
+func bucketNames(template, bucket string, kinds []string) []string {
+	names := make([]string, 0, len(kinds))
+	for _, k := range kinds {
+		names = append(names, formatName(template, bucket, k))
+	}
+
+	return names
+}
+
+// BucketGroupNames returns the names of the management groups of a bucket, for each of the group types
+func BucketGroupNames(bucket string) []string {
+	return bucketNames(naming.Group, bucket, GroupTypes)
+}
+
+// BucketPolicyNames returns the names of the policies of a bucket, for each of the policy types
+func BucketPolicyNames(bucket string) []string {
+	return bucketNames(naming.Policy, bucket, PolicyTypes)
+}
+
+// BucketRoleNames returns the names of the roles of a bucket, for each of the role types
+func BucketRoleNames(bucket string) []string {
+	return bucketNames(naming.Role, bucket, RoleTypes)
+}
+
+// GroupPolicyType returns the type of the policy attached to a type of management group, ie. BktAdmPlc for BktAdmGrp
+func GroupPolicyType(group string) string {
+	return strings.TrimSuffix(group, "Grp") + "Plc"
+}
+
+// SplitGroupName splits the name of a management group of any bucket into its base, the bucket name with the path
+// in the bucket for a path group, and the group type.  ok is false if it isn't the name of a management group.
+func SplitGroupName(name string) (base string, groupType string, ok bool) {
+	for _, t := range GroupTypes {
+		if base, ok := splitName(naming.Group, name, t); ok {
+			return base, t, true
+		}
+	}
+
+	return "", "", false
+}
+
+// IsBucketPolicy returns true if the policy name was formatted for the bucket or a path in it, as opposed to a
+// policy shared between buckets
+func IsBucketPolicy(bucket, name string) bool {
+	for _, t := range PolicyTypes {
+		if base, ok := splitName(naming.Policy, name, t); ok && (base == bucket || strings.HasPrefix(base, bucket+"-")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseGroupName parses the name of one of the bucket's management groups, formatted by FormatGroupName, into the
+// path in the bucket that the group manages and the group type.  The IAM path is the group's path without the path
+// prefix, see TrimPathPrefix.  A group created with an IAM path must be named for that path.  A group without an IAM path is either a bucket group, or a legacy path group whose path can only be
+// derived from its name if the path is a single segment without dashes, so another bucket whose name starts with the
+// bucket name doesn't match.  ok is false if it isn't one of the bucket's groups.
+func ParseGroupName(bucket, name, iamPath string) (path string, groupType string, ok bool) {
+	if bucket == "" || name == "" {
+		return "", "", false
+	}
+
+	if iamPath == "" {
+		iamPath = "/"
+	}
+	iamPath = EnforcePathFormat(iamPath)
+
+	for _, t := range GroupTypes {
+		if name == FormatGroupName(bucket, iamPath, t) {
+			return iamPath, t, true
+		}
+
+		if iamPath != "/" {
+			continue
+		}
+
+		base, ok := splitName(naming.Group, name, t)
+		if !ok {
+			continue
+		}
+
+		sanitizedPath := strings.TrimPrefix(base, bucket+"-")
+		if sanitizedPath == base || sanitizedPath == "" || strings.Contains(sanitizedPath, "-") {
+			continue
+		}
+
+		return EnforcePathFormat(strings.Replace(sanitizedPath, "_", "/", -1)), t, true
+	}
+
+	return "", "", false
+}
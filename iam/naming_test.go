@@ -0,0 +1,120 @@
+package iam
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/s3-api/common"
+)
+
+func TestSetNaming(t *testing.T) {
+	defer SetNaming(nil)
+
+	tests := []struct {
+		config *common.Naming
+		valid  bool
+	}{
+		{nil, true},
+		{&common.Naming{}, true},
+		{&common.Naming{Group: "s3-{bucket}-{type}", Policy: "{type}.{bucket}", Types: map[string]string{"BktAdmGrp": "Admins"}}, true},
+		{&common.Naming{Group: "{bucket}-group"}, false},
+		{&common.Naming{Group: "{bucket}-{type}-{bucket}"}, false},
+		{&common.Naming{Policy: "{bucket}/{type}"}, false},
+		{&common.Naming{Types: map[string]string{"BktAdminGroup": "Admins"}}, false},
+		{&common.Naming{Types: map[string]string{"BktAdmGrp": ""}}, false},
+		{&common.Naming{Types: map[string]string{"BktAdmGrp": "Admins", "BktRWGrp": "Admins"}}, false},
+		{&common.Naming{Types: map[string]string{"BktAdmGrp": "BktRWGrp"}}, false},
+		{&common.Naming{Types: map[string]string{"BktAdmGrp": "Admins", "BktAdmPlc": "Admins"}}, true},
+	}
+
+	for _, tt := range tests {
+		if err := SetNaming(tt.config); (err == nil) != tt.valid {
+			t.Errorf("expected %+v to be valid: %t, got %v", tt.config, tt.valid, err)
+		}
+	}
+}
+
+func TestNamingTemplates(t *testing.T) {
+	defer SetNaming(nil)
+
+	if out := FormatPolicyName("foo", "/docs/2024/", "BktRWPlc"); out != "foo-docs_2024-BktRWPlc" {
+		t.Errorf("expected the default policy name foo-docs_2024-BktRWPlc, got %s", out)
+	}
+
+	if out := BucketGroupNames("foo"); !reflect.DeepEqual(out, []string{"foo-BktAdmGrp", "foo-BktRWGrp", "foo-BktROGrp", "foo-WebAdmGrp"}) {
+		t.Errorf("expected the default group names for foo, got %v", out)
+	}
+
+	if err := SetNaming(&common.Naming{
+		Group:  "s3-{bucket}-{type}",
+		Policy: "s3-{bucket}-{type}-policy",
+		Role:   "{type}-{bucket}",
+		Types:  map[string]string{"BktAdmGrp": "Admins", "BktAdmPlc": "Admins", "SftpRole": "Sftp"},
+	}); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	names := map[string]string{
+		FormatGroupName("foo", "/", "BktAdmGrp"):      "s3-foo-Admins",
+		FormatGroupName("foo", "/docs/", "BktROGrp"):  "s3-foo-docs-BktROGrp",
+		FormatPolicyName("foo", "/", "BktAdmPlc"):     "s3-foo-Admins-policy",
+		FormatPolicyName("foo", "/a/b/", "WebAdmPlc"): "s3-foo-a_b-WebAdmPlc-policy",
+		FormatRoleName("foo", "SftpRole"):             "Sftp-foo",
+	}
+	for out, expected := range names {
+		if out != expected {
+			t.Errorf("expected %s, got %s", expected, out)
+		}
+	}
+
+	lists := map[string][][]string{
+		"groups":   {BucketGroupNames("foo"), {"s3-foo-Admins", "s3-foo-BktRWGrp", "s3-foo-BktROGrp", "s3-foo-WebAdmGrp"}},
+		"policies": {BucketPolicyNames("foo"), {"s3-foo-Admins-policy", "s3-foo-BktRWPlc-policy", "s3-foo-BktROPlc-policy", "s3-foo-WebAdmPlc-policy", "s3-foo-SftpPlc-policy"}},
+		"roles":    {BucketRoleNames("foo"), {"Sftp-foo"}},
+	}
+	for kind, l := range lists {
+		if !reflect.DeepEqual(l[0], l[1]) {
+			t.Errorf("expected the %s names %v, got %v", kind, l[1], l[0])
+		}
+	}
+
+	parsed := []struct {
+		bucket, name, iamPath string
+		path, groupType       string
+		ok                    bool
+	}{
+		{bucket: "foo", name: "s3-foo-Admins", iamPath: "/", path: "/", groupType: "BktAdmGrp", ok: true},
+		{bucket: "foo", name: "s3-foo-docs-BktRWGrp", iamPath: "/docs/", path: "/docs/", groupType: "BktRWGrp", ok: true},
+		{bucket: "foo", name: "s3-foo-docs-Admins", iamPath: "/", path: "/docs/", groupType: "BktAdmGrp", ok: true},
+		{bucket: "foo", name: "foo-BktAdmGrp", iamPath: "/"},
+		{bucket: "foo", name: "s3-foobar-Admins", iamPath: "/"},
+	}
+	for _, tt := range parsed {
+		path, groupType, ok := ParseGroupName(tt.bucket, tt.name, tt.iamPath)
+		if path != tt.path || groupType != tt.groupType || ok != tt.ok {
+			t.Errorf("%s in %s (%s): expected %s, %s, %t, got %s, %s, %t", tt.name, tt.bucket, tt.iamPath, tt.path, tt.groupType, tt.ok, path, groupType, ok)
+		}
+	}
+
+	if base, groupType, ok := SplitGroupName("s3-foo.example.com-WebAdmGrp"); base != "foo.example.com" || groupType != "WebAdmGrp" || !ok {
+		t.Errorf("expected foo.example.com, WebAdmGrp, true, got %s, %s, %t", base, groupType, ok)
+	}
+
+	if _, _, ok := SplitGroupName("foo-Admins"); ok {
+		t.Error("expected a group that doesn't match the template not to be a management group")
+	}
+
+	policies := map[string]bool{
+		"s3-foo-Admins-policy":          true,
+		"s3-foo-docs-BktROPlc-policy":   true,
+		"s3-foobar-Admins-policy":       false,
+		"s3-foo-Admins":                 false,
+		"foo-BktAdmPlc":                 false,
+		"s3-foo-SomeOtherPolicy-policy": false,
+	}
+	for name, expected := range policies {
+		if out := IsBucketPolicy("foo", name); out != expected {
+			t.Errorf("expected IsBucketPolicy(foo, %s) to be %t, got %t", name, expected, out)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package iam
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Path returns the IAM path for a path in a bucket, under the path prefix the IAM resources are scoped to if one is
+// configured, ie. /docs/ is /spinup/org/docs/
+func (i *IAM) Path(path string) string {
+	if path == "" {
+		path = "/"
+	}
+	path = EnforcePathFormat(path)
+
+	if i.PathPrefix == "" || i.PathPrefix == "/" {
+		return path
+	}
+
+	return EnforcePathFormat(i.PathPrefix) + strings.TrimPrefix(path, "/")
+}
+
+// TrimPathPrefix returns the path in the bucket for an IAM path under the path prefix, ie. /spinup/org/docs/ is /docs/.
+// The IAM path is returned as is if it isn't under the prefix.
+func TrimPathPrefix(prefix, iamPath string) string {
+	if iamPath == "" {
+		iamPath = "/"
+	}
+
+	if prefix == "" || prefix == "/" {
+		return iamPath
+	}
+
+	prefix = EnforcePathFormat(prefix)
+	if !strings.HasPrefix(iamPath, prefix) {
+		return iamPath
+	}
+
+	return "/" + strings.TrimPrefix(iamPath, prefix)
+}
+
+// PolicyArn returns the ARN of a policy the api created, under the path prefix
+func (i *IAM) PolicyArn(accountId, policyName string) string {
+	return FormatPolicyArn(accountId, i.Path("/"), policyName)
+}
+
+// FormatPolicyArn returns the ARN of a customer managed policy with the IAM path
+func FormatPolicyArn(accountId, path, policyName string) string {
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("arn:aws:iam::%s:policy%s%s", accountId, EnforcePathFormat(path), policyName)
+}
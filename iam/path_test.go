@@ -0,0 +1,122 @@
+package iam
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+)
+
+func TestPath(t *testing.T) {
+	tests := []struct {
+		prefix, path, expected string
+	}{
+		{prefix: "", path: "", expected: "/"},
+		{prefix: "", path: "/docs/", expected: "/docs/"},
+		{prefix: "/", path: "docs", expected: "/docs/"},
+		{prefix: "/spinup/test/", path: "", expected: "/spinup/test/"},
+		{prefix: "/spinup/test/", path: "/", expected: "/spinup/test/"},
+		{prefix: "/spinup/test/", path: "/docs/", expected: "/spinup/test/docs/"},
+		{prefix: "/spinup/test", path: "one/two", expected: "/spinup/test/one/two/"},
+	}
+
+	for _, test := range tests {
+		i := IAM{PathPrefix: test.prefix}
+		if out := i.Path(test.path); out != test.expected {
+			t.Errorf("expected path %s with prefix %s to be %s, got %s", test.path, test.prefix, test.expected, out)
+		}
+
+		unscoped := (&IAM{}).Path(test.path)
+		if out := TrimPathPrefix(test.prefix, test.expected); out != unscoped {
+			t.Errorf("expected %s without prefix %s to be %s, got %s", test.expected, test.prefix, unscoped, out)
+		}
+	}
+
+	if out := TrimPathPrefix("/spinup/test/", "/other/"); out != "/other/" {
+		t.Errorf("expected a path outside of the prefix to be left as is, got %s", out)
+	}
+
+	if out := (&IAM{PathPrefix: "/spinup/test/"}).PolicyArn("12345678910", "foo-BktAdmPlc"); out != "arn:aws:iam::12345678910:policy/spinup/test/foo-BktAdmPlc" {
+		t.Errorf("unexpected policy arn %s", out)
+	}
+
+	if out := FormatPolicyArn("12345678910", "", "foo-BktAdmPlc"); out != "arn:aws:iam::12345678910:policy/foo-BktAdmPlc" {
+		t.Errorf("unexpected policy arn %s", out)
+	}
+}
+
+// mockPathClient records the paths the resources are created and listed with
+type mockPathClient struct {
+	iamiface.IAMAPI
+	paths []string
+}
+
+func (m *mockPathClient) CreateUserWithContext(ctx context.Context, input *iam.CreateUserInput, opts ...request.Option) (*iam.CreateUserOutput, error) {
+	m.paths = append(m.paths, aws.StringValue(input.Path))
+	return &iam.CreateUserOutput{User: &iam.User{UserName: input.UserName, Path: input.Path}}, nil
+}
+
+func (m *mockPathClient) CreateGroupWithContext(ctx context.Context, input *iam.CreateGroupInput, opts ...request.Option) (*iam.CreateGroupOutput, error) {
+	m.paths = append(m.paths, aws.StringValue(input.Path))
+	return &iam.CreateGroupOutput{Group: &iam.Group{GroupName: input.GroupName, Path: input.Path}}, nil
+}
+
+func (m *mockPathClient) CreatePolicyWithContext(ctx context.Context, input *iam.CreatePolicyInput, opts ...request.Option) (*iam.CreatePolicyOutput, error) {
+	m.paths = append(m.paths, aws.StringValue(input.Path))
+	return &iam.CreatePolicyOutput{Policy: &iam.Policy{PolicyName: input.PolicyName, Path: input.Path}}, nil
+}
+
+func (m *mockPathClient) ListGroupsWithContext(ctx context.Context, input *iam.ListGroupsInput, opts ...request.Option) (*iam.ListGroupsOutput, error) {
+	m.paths = append(m.paths, aws.StringValue(input.PathPrefix))
+	return &iam.ListGroupsOutput{Groups: []*iam.Group{
+		{GroupName: aws.String("foo-BktAdmGrp"), Path: aws.String("/spinup/test/")},
+		{GroupName: aws.String("foo-docs-BktRWGrp"), Path: aws.String("/spinup/test/docs/")},
+		{GroupName: aws.String("foo-bar-BktRWGrp"), Path: aws.String("/spinup/test/docs/")},
+	}}, nil
+}
+
+func TestPathPrefix(t *testing.T) {
+	m := &mockPathClient{}
+	i := IAM{Service: m, PathPrefix: "/spinup/test/"}
+
+	userInput := &iam.CreateUserInput{UserName: aws.String("foo-docs-user"), Path: aws.String("/docs/")}
+	if _, err := i.CreateUser(context.TODO(), userInput); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if aws.StringValue(userInput.Path) != "/docs/" {
+		t.Errorf("expected the input path to be left as is, got %s", aws.StringValue(userInput.Path))
+	}
+
+	if _, err := i.CreateGroup(context.TODO(), &iam.CreateGroupInput{GroupName: aws.String("foo-BktAdmGrp")}); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if _, err := i.CreatePolicy(context.TODO(), &iam.CreatePolicyInput{PolicyName: aws.String("foo-docs-BktRWPlc"), Path: aws.String("/docs/")}); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	groups, err := i.ListGroups(context.TODO(), &iam.ListGroupsInput{}, "foo")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if len(groups) != 2 || aws.StringValue(groups[0].GroupName) != "foo-BktAdmGrp" || aws.StringValue(groups[1].GroupName) != "foo-docs-BktRWGrp" {
+		t.Errorf("expected the root and docs groups of foo, got %+v", groups)
+	}
+
+	expected := []string{"/spinup/test/docs/", "/spinup/test/", "/spinup/test/docs/", "/spinup/test/"}
+	if len(m.paths) != len(expected) {
+		t.Fatalf("expected paths %v, got %v", expected, m.paths)
+	}
+
+	for n, p := range expected {
+		if m.paths[n] != p {
+			t.Errorf("expected paths %v, got %v", expected, m.paths)
+			break
+		}
+	}
+}
@@ -2,6 +2,7 @@ package iam
 
 import (
 	"context"
+	"net/url"
 
 	"github.com/YaleSpinup/apierror"
 	"github.com/aws/aws-sdk-go/aws"
@@ -18,6 +19,13 @@ func (i *IAM) CreatePolicy(ctx context.Context, input *iam.CreatePolicyInput) (*
 
 	log.Infof("creating iam policy: %s", *input.PolicyName)
 
+	// the input is copied so the caller's path isn't changed
+	if i.PathPrefix != "" {
+		scoped := *input
+		scoped.Path = aws.String(i.Path(aws.StringValue(input.Path)))
+		input = &scoped
+	}
+
 	output, err := i.Service.CreatePolicyWithContext(ctx, input)
 	if err != nil {
 		return nil, ErrCode("failed to create iam policy", err)
@@ -28,7 +36,8 @@ func (i *IAM) CreatePolicy(ctx context.Context, input *iam.CreatePolicyInput) (*
 	return output.Policy, nil
 }
 
-// DeletePolicy handles deleting IAM policy
+// DeletePolicy handles deleting IAM policy.  A policy can't be deleted while it has versions other than its default
+// version, so those are deleted first.
 func (i *IAM) DeletePolicy(ctx context.Context, input *iam.DeletePolicyInput) error {
 	if input == nil || aws.StringValue(input.PolicyArn) == "" {
 		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
@@ -36,9 +45,28 @@ func (i *IAM) DeletePolicy(ctx context.Context, input *iam.DeletePolicyInput) er
 
 	log.Infof("deleting iam policy %s", aws.StringValue(input.PolicyArn))
 
-	_, err := i.Service.DeletePolicyWithContext(ctx, input)
+	versions, err := i.ListPolicyVersions(ctx, aws.StringValue(input.PolicyArn))
 	if err != nil {
-		return ErrCode("failed to create iam policy", err)
+		return err
+	}
+
+	for _, v := range versions {
+		if aws.BoolValue(v.IsDefaultVersion) {
+			continue
+		}
+
+		log.Infof("deleting version %s of iam policy %s", aws.StringValue(v.VersionId), aws.StringValue(input.PolicyArn))
+
+		if _, err := i.Service.DeletePolicyVersionWithContext(ctx, &iam.DeletePolicyVersionInput{
+			PolicyArn: input.PolicyArn,
+			VersionId: v.VersionId,
+		}); err != nil {
+			return ErrCode("failed to delete iam policy version", err)
+		}
+	}
+
+	if _, err := i.Service.DeletePolicyWithContext(ctx, input); err != nil {
+		return ErrCode("failed to delete iam policy", err)
 	}
 
 	log.Debugf("deleted iam policy %s", aws.StringValue(input.PolicyArn))
@@ -55,6 +83,10 @@ func (i *IAM) ListPolicies(ctx context.Context, input *iam.ListPoliciesInput) ([
 
 	log.Info("listing iam policies")
 
+	if input.PathPrefix == nil && i.PathPrefix != "" {
+		input.PathPrefix = aws.String(i.Path("/"))
+	}
+
 	truncated := true
 	for truncated {
 		output, err := i.Service.ListPoliciesWithContext(ctx, input)
@@ -70,3 +102,189 @@ func (i *IAM) ListPolicies(ctx context.Context, input *iam.ListPoliciesInput) ([
 
 	return policies, nil
 }
+
+// GetPolicy gets the details of a managed policy
+func (i *IAM) GetPolicy(ctx context.Context, policyArn string) (*iam.Policy, error) {
+	if policyArn == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("getting iam policy %s", policyArn)
+
+	out, err := i.Service.GetPolicyWithContext(ctx, &iam.GetPolicyInput{PolicyArn: aws.String(policyArn)})
+	if err != nil {
+		return nil, ErrCode("failed to get iam policy", err)
+	}
+
+	return out.Policy, nil
+}
+
+// ListPolicyGroups lists the groups a managed policy is attached to
+func (i *IAM) ListPolicyGroups(ctx context.Context, policyArn string) ([]*iam.PolicyGroup, error) {
+	groups := []*iam.PolicyGroup{}
+	if policyArn == "" {
+		return groups, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("listing the groups iam policy %s is attached to", policyArn)
+
+	input := &iam.ListEntitiesForPolicyInput{
+		EntityFilter: aws.String(iam.EntityTypeGroup),
+		PolicyArn:    aws.String(policyArn),
+	}
+
+	truncated := true
+	for truncated {
+		output, err := i.Service.ListEntitiesForPolicyWithContext(ctx, input)
+		if err != nil {
+			return groups, ErrCode("failed to list entities for iam policy", err)
+		}
+		truncated = aws.BoolValue(output.IsTruncated)
+		groups = append(groups, output.PolicyGroups...)
+		input.Marker = output.Marker
+	}
+
+	return groups, nil
+}
+
+// GetPolicyDocument gets the default version of a managed policy document
+func (i *IAM) GetPolicyDocument(ctx context.Context, policyArn string) (string, error) {
+	if policyArn == "" {
+		return "", apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("getting iam policy document for %s", policyArn)
+
+	policy, err := i.Service.GetPolicyWithContext(ctx, &iam.GetPolicyInput{PolicyArn: aws.String(policyArn)})
+	if err != nil {
+		return "", ErrCode("failed to get iam policy", err)
+	}
+
+	version, err := i.Service.GetPolicyVersionWithContext(ctx, &iam.GetPolicyVersionInput{
+		PolicyArn: aws.String(policyArn),
+		VersionId: policy.Policy.DefaultVersionId,
+	})
+	if err != nil {
+		return "", ErrCode("failed to get iam policy version", err)
+	}
+
+	// policy documents are returned url encoded
+	doc, err := url.QueryUnescape(aws.StringValue(version.PolicyVersion.Document))
+	if err != nil {
+		return "", apierror.New(apierror.ErrInternalError, "failed to decode iam policy document", err)
+	}
+
+	return doc, nil
+}
+
+// maxPolicyVersions is the most versions a managed policy can have
+const maxPolicyVersions = 5
+
+// UpdatePolicyDocument replaces the document of a managed policy by creating a new default version.  When the policy
+// already has the most versions allowed, the oldest version that isn't the default is deleted first.
+func (i *IAM) UpdatePolicyDocument(ctx context.Context, policyArn string, document []byte) error {
+	if policyArn == "" || len(document) == 0 {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("updating iam policy document for %s", policyArn)
+
+	versions, err := i.ListPolicyVersions(ctx, policyArn)
+	if err != nil {
+		return err
+	}
+
+	if len(versions) >= maxPolicyVersions {
+		var oldest *iam.PolicyVersion
+		for _, v := range versions {
+			if aws.BoolValue(v.IsDefaultVersion) {
+				continue
+			}
+
+			if oldest == nil || aws.TimeValue(v.CreateDate).Before(aws.TimeValue(oldest.CreateDate)) {
+				oldest = v
+			}
+		}
+
+		if oldest != nil {
+			log.Infof("deleting version %s of iam policy %s", aws.StringValue(oldest.VersionId), policyArn)
+
+			if _, err := i.Service.DeletePolicyVersionWithContext(ctx, &iam.DeletePolicyVersionInput{
+				PolicyArn: aws.String(policyArn),
+				VersionId: oldest.VersionId,
+			}); err != nil {
+				return ErrCode("failed to delete iam policy version", err)
+			}
+		}
+	}
+
+	if _, err := i.Service.CreatePolicyVersionWithContext(ctx, &iam.CreatePolicyVersionInput{
+		PolicyArn:      aws.String(policyArn),
+		PolicyDocument: aws.String(string(document)),
+		SetAsDefault:   aws.Bool(true),
+	}); err != nil {
+		return ErrCode("failed to create iam policy version", err)
+	}
+
+	return nil
+}
+
+// ListPolicyVersions lists the versions of a managed policy, without their documents
+func (i *IAM) ListPolicyVersions(ctx context.Context, policyArn string) ([]*iam.PolicyVersion, error) {
+	if policyArn == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("listing versions of iam policy %s", policyArn)
+
+	out, err := i.Service.ListPolicyVersionsWithContext(ctx, &iam.ListPolicyVersionsInput{PolicyArn: aws.String(policyArn)})
+	if err != nil {
+		return nil, ErrCode("failed to list iam policy versions", err)
+	}
+
+	return out.Versions, nil
+}
+
+// GetPolicyVersionDocument gets the document of a version of a managed policy
+func (i *IAM) GetPolicyVersionDocument(ctx context.Context, policyArn, versionId string) (string, error) {
+	if policyArn == "" || versionId == "" {
+		return "", apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("getting version %s of iam policy document for %s", versionId, policyArn)
+
+	version, err := i.Service.GetPolicyVersionWithContext(ctx, &iam.GetPolicyVersionInput{
+		PolicyArn: aws.String(policyArn),
+		VersionId: aws.String(versionId),
+	})
+	if err != nil {
+		return "", ErrCode("failed to get iam policy version", err)
+	}
+
+	// policy documents are returned url encoded
+	doc, err := url.QueryUnescape(aws.StringValue(version.PolicyVersion.Document))
+	if err != nil {
+		return "", apierror.New(apierror.ErrInternalError, "failed to decode iam policy document", err)
+	}
+
+	return doc, nil
+}
+
+// SetDefaultPolicyVersion makes an existing version of a managed policy its default version, the other versions are
+// kept so the change can be undone the same way
+func (i *IAM) SetDefaultPolicyVersion(ctx context.Context, policyArn, versionId string) error {
+	if policyArn == "" || versionId == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("setting version %s as the default version of iam policy %s", versionId, policyArn)
+
+	if _, err := i.Service.SetDefaultPolicyVersionWithContext(ctx, &iam.SetDefaultPolicyVersionInput{
+		PolicyArn: aws.String(policyArn),
+		VersionId: aws.String(versionId),
+	}); err != nil {
+		return ErrCode("failed to set default iam policy version", err)
+	}
+
+	return nil
+}
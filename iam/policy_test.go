@@ -3,14 +3,17 @@ package iam
 import (
 	"context"
 	"errors"
+	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/YaleSpinup/apierror"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
 )
 
 var testPolicy = iam.Policy{
@@ -85,6 +88,13 @@ func (m *mockIAMClient) DeletePolicyWithContext(ctx context.Context, input *iam.
 	return &iam.DeletePolicyOutput{}, nil
 }
 
+func (m *mockIAMClient) ListPolicyVersionsWithContext(ctx context.Context, input *iam.ListPolicyVersionsInput, opts ...request.Option) (*iam.ListPolicyVersionsOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &iam.ListPolicyVersionsOutput{Versions: []*iam.PolicyVersion{{VersionId: aws.String("v1"), IsDefaultVersion: aws.Bool(true)}}}, nil
+}
+
 func (m *mockIAMClient) ListPoliciesWithContext(ctx context.Context, input *iam.ListPoliciesInput, opts ...request.Option) (*iam.ListPoliciesOutput, error) {
 	if m.err != nil {
 		return nil, m.err
@@ -92,6 +102,31 @@ func (m *mockIAMClient) ListPoliciesWithContext(ctx context.Context, input *iam.
 	return &iam.ListPoliciesOutput{Policies: testPolicies1}, nil
 }
 
+func (m *mockIAMClient) GetPolicyWithContext(ctx context.Context, input *iam.GetPolicyInput, opts ...request.Option) (*iam.GetPolicyOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &iam.GetPolicyOutput{Policy: &testPolicy}, nil
+}
+
+func (m *mockIAMClient) GetPolicyVersionWithContext(ctx context.Context, input *iam.GetPolicyVersionInput, opts ...request.Option) (*iam.GetPolicyVersionOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if aws.StringValue(input.VersionId) != "v1" {
+		return nil, awserr.New(iam.ErrCodeNoSuchEntityException, "not found", nil)
+	}
+
+	return &iam.GetPolicyVersionOutput{
+		PolicyVersion: &iam.PolicyVersion{
+			Document:         aws.String("%7B%22Version%22%3A%222012-10-17%22%2C%22Statement%22%3A%5B%5D%7D"),
+			IsDefaultVersion: aws.Bool(true),
+			VersionId:        input.VersionId,
+		},
+	}, nil
+}
+
 func TestCreatePolicy(t *testing.T) {
 	i := IAM{
 		Service:                newMockIAMClient(t, nil),
@@ -103,7 +138,7 @@ func TestCreatePolicy(t *testing.T) {
 	expected := &testPolicy
 
 	// build the default IAM bucket admin policy (from the config and known inputs)
-	defaultPolicy, err := i.DefaultBucketAdminPolicy(aws.String("testBucket"))
+	defaultPolicy, err := i.DefaultBucketAdminPolicy(aws.String("testBucket"), nil)
 	if err != nil {
 		t.Errorf("expected nil error creating default policy doc, got %s", err)
 	}
@@ -410,3 +445,311 @@ func TestListPolicies(t *testing.T) {
 		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
 	}
 }
+
+func (m *mockIAMClient) ListEntitiesForPolicyWithContext(ctx context.Context, input *iam.ListEntitiesForPolicyInput, opts ...request.Option) (*iam.ListEntitiesForPolicyOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if input.Marker == nil {
+		return &iam.ListEntitiesForPolicyOutput{
+			IsTruncated:  aws.Bool(true),
+			Marker:       aws.String("next"),
+			PolicyGroups: []*iam.PolicyGroup{{GroupName: aws.String("org-admins")}},
+		}, nil
+	}
+
+	return &iam.ListEntitiesForPolicyOutput{PolicyGroups: []*iam.PolicyGroup{{GroupName: aws.String("foo-BktAdmGrp")}}}, nil
+}
+
+func TestListPolicyGroups(t *testing.T) {
+	i := IAM{Service: newMockIAMClient(t, nil)}
+
+	out, err := i.ListPolicyGroups(context.TODO(), aws.StringValue(testPolicy.Arn))
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	names := []string{}
+	for _, g := range out {
+		names = append(names, aws.StringValue(g.GroupName))
+	}
+
+	if expected := []string{"org-admins", "foo-BktAdmGrp"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+
+	if _, err := i.ListPolicyGroups(context.TODO(), ""); err == nil {
+		t.Error("expected error for an empty arn, got nil")
+	}
+
+	i.Service.(*mockIAMClient).err = awserr.New(iam.ErrCodeNoSuchEntityException, "not found", nil)
+	_, err = i.ListPolicyGroups(context.TODO(), aws.StringValue(testPolicy.Arn))
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrNotFound {
+		t.Errorf("expected a not found apierror.Error, got: %v", err)
+	}
+}
+
+func TestGetPolicy(t *testing.T) {
+	i := IAM{Service: newMockIAMClient(t, nil)}
+
+	// test success
+	out, err := i.GetPolicy(context.TODO(), aws.StringValue(testPolicy.Arn))
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if !reflect.DeepEqual(out, &testPolicy) {
+		t.Errorf("expected %+v, got %+v", &testPolicy, out)
+	}
+
+	// test empty arn
+	_, err = i.GetPolicy(context.TODO(), "")
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrBadRequest {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrBadRequest, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+
+	// test ErrCodeNoSuchEntityException
+	i.Service.(*mockIAMClient).err = awserr.New(iam.ErrCodeNoSuchEntityException, "not found", nil)
+	_, err = i.GetPolicy(context.TODO(), aws.StringValue(testPolicy.Arn))
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrNotFound {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrNotFound, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+}
+
+func TestGetPolicyDocument(t *testing.T) {
+	i := IAM{Service: newMockIAMClient(t, nil)}
+
+	// test success
+	expected := `{"Version":"2012-10-17","Statement":[]}`
+	out, err := i.GetPolicyDocument(context.TODO(), aws.StringValue(testPolicy.Arn))
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if out != expected {
+		t.Errorf("expected %s, got %s", expected, out)
+	}
+
+	// test empty arn
+	_, err = i.GetPolicyDocument(context.TODO(), "")
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrBadRequest {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrBadRequest, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+
+	// test ErrCodeNoSuchEntityException
+	i.Service.(*mockIAMClient).err = awserr.New(iam.ErrCodeNoSuchEntityException, "not found", nil)
+	_, err = i.GetPolicyDocument(context.TODO(), aws.StringValue(testPolicy.Arn))
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrNotFound {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrNotFound, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+
+	// test non-aws error
+	i.Service.(*mockIAMClient).err = errors.New("things blowing up!")
+	_, err = i.GetPolicyDocument(context.TODO(), aws.StringValue(testPolicy.Arn))
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrInternalError {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrInternalError, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+}
+
+// mockPolicyVersionsClient is an iam client that tracks the versions of a policy
+type mockPolicyVersionsClient struct {
+	iamiface.IAMAPI
+	versions []*iam.PolicyVersion
+	document string
+}
+
+func (m *mockPolicyVersionsClient) ListPolicyVersionsWithContext(ctx context.Context, input *iam.ListPolicyVersionsInput, opts ...request.Option) (*iam.ListPolicyVersionsOutput, error) {
+	return &iam.ListPolicyVersionsOutput{Versions: m.versions}, nil
+}
+
+func (m *mockPolicyVersionsClient) DeletePolicyVersionWithContext(ctx context.Context, input *iam.DeletePolicyVersionInput, opts ...request.Option) (*iam.DeletePolicyVersionOutput, error) {
+	versions := []*iam.PolicyVersion{}
+	for _, v := range m.versions {
+		if aws.StringValue(v.VersionId) == aws.StringValue(input.VersionId) {
+			if aws.BoolValue(v.IsDefaultVersion) {
+				return nil, awserr.New(iam.ErrCodeDeleteConflictException, "can't delete the default version", nil)
+			}
+			continue
+		}
+		versions = append(versions, v)
+	}
+	m.versions = versions
+	return &iam.DeletePolicyVersionOutput{}, nil
+}
+
+func (m *mockPolicyVersionsClient) CreatePolicyVersionWithContext(ctx context.Context, input *iam.CreatePolicyVersionInput, opts ...request.Option) (*iam.CreatePolicyVersionOutput, error) {
+	if len(m.versions) >= 5 {
+		return nil, awserr.New(iam.ErrCodeLimitExceededException, "too many versions", nil)
+	}
+
+	for _, v := range m.versions {
+		v.IsDefaultVersion = aws.Bool(false)
+	}
+
+	version := &iam.PolicyVersion{
+		VersionId:        aws.String(fmt.Sprintf("v%d", len(m.versions)+10)),
+		IsDefaultVersion: input.SetAsDefault,
+		CreateDate:       aws.Time(time.Now()),
+	}
+	m.versions = append(m.versions, version)
+	m.document = aws.StringValue(input.PolicyDocument)
+
+	return &iam.CreatePolicyVersionOutput{PolicyVersion: version}, nil
+}
+
+func (m *mockPolicyVersionsClient) DeletePolicyWithContext(ctx context.Context, input *iam.DeletePolicyInput, opts ...request.Option) (*iam.DeletePolicyOutput, error) {
+	if len(m.versions) > 1 {
+		return nil, awserr.New(iam.ErrCodeDeleteConflictException, "delete the non-default versions first", nil)
+	}
+	m.versions = nil
+	return &iam.DeletePolicyOutput{}, nil
+}
+
+func TestDeletePolicyWithVersions(t *testing.T) {
+	client := &mockPolicyVersionsClient{}
+	for n := 1; n <= 3; n++ {
+		client.versions = append(client.versions, &iam.PolicyVersion{
+			VersionId:        aws.String(fmt.Sprintf("v%d", n)),
+			IsDefaultVersion: aws.Bool(n == 3),
+		})
+	}
+	i := IAM{Service: client}
+
+	if err := i.DeletePolicy(context.TODO(), &iam.DeletePolicyInput{PolicyArn: aws.String("arn:aws:iam::12345678910:policy/foo-BktAdmPlc")}); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if len(client.versions) != 0 {
+		t.Errorf("expected the policy and its versions to be deleted, got %d versions", len(client.versions))
+	}
+}
+
+func TestUpdatePolicyDocument(t *testing.T) {
+	base := time.Now().Add(-time.Hour)
+	client := &mockPolicyVersionsClient{}
+	for n := 1; n <= 5; n++ {
+		client.versions = append(client.versions, &iam.PolicyVersion{
+			VersionId:        aws.String(fmt.Sprintf("v%d", n)),
+			IsDefaultVersion: aws.Bool(n == 1),
+			CreateDate:       aws.Time(base.Add(time.Duration(n) * time.Minute)),
+		})
+	}
+
+	i := IAM{Service: client}
+	if err := i.UpdatePolicyDocument(context.TODO(), "arn:aws:iam::12345678910:policy/testpolicy", []byte(`{"Version":"2012-10-17"}`)); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	// the oldest version that isn't the default is deleted to make room
+	ids := []string{}
+	for _, v := range client.versions {
+		ids = append(ids, aws.StringValue(v.VersionId))
+	}
+
+	if !reflect.DeepEqual(ids, []string{"v1", "v3", "v4", "v5", "v14"}) {
+		t.Errorf("unexpected policy versions %v", ids)
+	}
+
+	if !aws.BoolValue(client.versions[4].IsDefaultVersion) || client.document != `{"Version":"2012-10-17"}` {
+		t.Errorf("expected the new version to be the default, got %+v", client.versions[4])
+	}
+
+	if err := i.UpdatePolicyDocument(context.TODO(), "", []byte(`{}`)); err == nil {
+		t.Error("expected error without a policy arn, got nil")
+	}
+}
+
+func (m *mockPolicyVersionsClient) SetDefaultPolicyVersionWithContext(ctx context.Context, input *iam.SetDefaultPolicyVersionInput, opts ...request.Option) (*iam.SetDefaultPolicyVersionOutput, error) {
+	found := false
+	for _, v := range m.versions {
+		if aws.StringValue(v.VersionId) == aws.StringValue(input.VersionId) {
+			found = true
+		}
+	}
+
+	if !found {
+		return nil, awserr.New(iam.ErrCodeNoSuchEntityException, "version not found", nil)
+	}
+
+	for _, v := range m.versions {
+		v.IsDefaultVersion = aws.Bool(aws.StringValue(v.VersionId) == aws.StringValue(input.VersionId))
+	}
+	return &iam.SetDefaultPolicyVersionOutput{}, nil
+}
+
+func TestSetDefaultPolicyVersion(t *testing.T) {
+	client := &mockPolicyVersionsClient{
+		versions: []*iam.PolicyVersion{
+			{VersionId: aws.String("v1"), IsDefaultVersion: aws.Bool(false)},
+			{VersionId: aws.String("v2"), IsDefaultVersion: aws.Bool(true)},
+		},
+	}
+
+	i := IAM{Service: client}
+	if err := i.SetDefaultPolicyVersion(context.TODO(), "arn:aws:iam::12345678910:policy/testpolicy", "v1"); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if !aws.BoolValue(client.versions[0].IsDefaultVersion) || aws.BoolValue(client.versions[1].IsDefaultVersion) {
+		t.Errorf("expected v1 to be the default version, got %+v", client.versions)
+	}
+
+	// the newer version is kept
+	versions, err := i.ListPolicyVersions(context.TODO(), "arn:aws:iam::12345678910:policy/testpolicy")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if len(versions) != 2 {
+		t.Errorf("expected 2 versions, got %d", len(versions))
+	}
+
+	err = i.SetDefaultPolicyVersion(context.TODO(), "arn:aws:iam::12345678910:policy/testpolicy", "v9")
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrNotFound {
+		t.Errorf("expected not found for a missing version, got %v", err)
+	}
+
+	if err := i.SetDefaultPolicyVersion(context.TODO(), "arn:aws:iam::12345678910:policy/testpolicy", ""); err == nil {
+		t.Error("expected error without a version, got nil")
+	}
+}
+
+func TestGetPolicyVersionDocument(t *testing.T) {
+	i := IAM{Service: newMockIAMClient(t, nil)}
+
+	expected := `{"Version":"2012-10-17","Statement":[]}`
+	out, err := i.GetPolicyVersionDocument(context.TODO(), aws.StringValue(testPolicy.Arn), "v1")
+	if err != nil {
+		t.Fatalf("expected nil error, got: %s", err)
+	}
+
+	if out != expected {
+		t.Errorf("expected %s, got %s", expected, out)
+	}
+
+	_, err = i.GetPolicyVersionDocument(context.TODO(), aws.StringValue(testPolicy.Arn), "v2")
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrNotFound {
+		t.Errorf("expected not found for a missing version, got %v", err)
+	}
+}
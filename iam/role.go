@@ -0,0 +1,97 @@
+package iam
+
+import (
+	"context"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/service/iam"
+	log "github.com/sirupsen/logrus"
+)
+
+// CreateRole creates an IAM role under the path prefix
+func (i *IAM) CreateRole(ctx context.Context, input *iam.CreateRoleInput) (*iam.Role, error) {
+	if input == nil || aws.StringValue(input.RoleName) == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("creating iam role: %s", aws.StringValue(input.RoleName))
+
+	// the input is copied so the caller's path isn't changed
+	if i.PathPrefix != "" {
+		scoped := *input
+		scoped.Path = aws.String(i.Path(aws.StringValue(input.Path)))
+		input = &scoped
+	}
+
+	output, err := i.Service.CreateRoleWithContext(ctx, input)
+	if err != nil {
+		return nil, ErrCode("failed to create iam role", err)
+	}
+
+	log.Debugf("returning created iam role %s", awsutil.Prettify(output.Role))
+
+	return output.Role, nil
+}
+
+// GetRole gets the details of an IAM role
+func (i *IAM) GetRole(ctx context.Context, name string) (*iam.Role, error) {
+	if name == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("getting iam role %s", name)
+
+	output, err := i.Service.GetRoleWithContext(ctx, &iam.GetRoleInput{RoleName: aws.String(name)})
+	if err != nil {
+		return nil, ErrCode("failed to get iam role", err)
+	}
+
+	return output.Role, nil
+}
+
+// DeleteRole deletes an IAM role, the role's policies must be detached first
+func (i *IAM) DeleteRole(ctx context.Context, name string) error {
+	if name == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("deleting iam role %s", name)
+
+	if _, err := i.Service.DeleteRoleWithContext(ctx, &iam.DeleteRoleInput{RoleName: aws.String(name)}); err != nil {
+		return ErrCode("failed to delete iam role", err)
+	}
+
+	return nil
+}
+
+// AttachRolePolicy attaches a managed policy to an IAM role
+func (i *IAM) AttachRolePolicy(ctx context.Context, input *iam.AttachRolePolicyInput) error {
+	if input == nil || aws.StringValue(input.RoleName) == "" || aws.StringValue(input.PolicyArn) == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("attaching policy %s to iam role %s", aws.StringValue(input.PolicyArn), aws.StringValue(input.RoleName))
+
+	if _, err := i.Service.AttachRolePolicyWithContext(ctx, input); err != nil {
+		return ErrCode("failed to attach policy to iam role", err)
+	}
+
+	return nil
+}
+
+// DetachRolePolicy detaches a managed policy from an IAM role
+func (i *IAM) DetachRolePolicy(ctx context.Context, input *iam.DetachRolePolicyInput) error {
+	if input == nil || aws.StringValue(input.RoleName) == "" || aws.StringValue(input.PolicyArn) == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("detaching policy %s from iam role %s", aws.StringValue(input.PolicyArn), aws.StringValue(input.RoleName))
+
+	if _, err := i.Service.DetachRolePolicyWithContext(ctx, input); err != nil {
+		return ErrCode("failed to detach policy from iam role", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package iam
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+var testRole = iam.Role{
+	Arn:      aws.String("arn:aws:iam::12345678910:role/testrole"),
+	Path:     aws.String("/"),
+	RoleId:   aws.String("TESTROLEID123"),
+	RoleName: aws.String("testrole"),
+}
+
+func (m *mockIAMClient) CreateRoleWithContext(ctx context.Context, input *iam.CreateRoleInput, opts ...request.Option) (*iam.CreateRoleOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	role := testRole
+	role.Path = input.Path
+	role.RoleName = input.RoleName
+	return &iam.CreateRoleOutput{Role: &role}, nil
+}
+
+func (m *mockIAMClient) GetRoleWithContext(ctx context.Context, input *iam.GetRoleInput, opts ...request.Option) (*iam.GetRoleOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &iam.GetRoleOutput{Role: &testRole}, nil
+}
+
+func (m *mockIAMClient) DeleteRoleWithContext(ctx context.Context, input *iam.DeleteRoleInput, opts ...request.Option) (*iam.DeleteRoleOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &iam.DeleteRoleOutput{}, nil
+}
+
+func (m *mockIAMClient) AttachRolePolicyWithContext(ctx context.Context, input *iam.AttachRolePolicyInput, opts ...request.Option) (*iam.AttachRolePolicyOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &iam.AttachRolePolicyOutput{}, nil
+}
+
+func (m *mockIAMClient) DetachRolePolicyWithContext(ctx context.Context, input *iam.DetachRolePolicyInput, opts ...request.Option) (*iam.DetachRolePolicyOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &iam.DetachRolePolicyOutput{}, nil
+}
+
+func TestCreateRole(t *testing.T) {
+	i := IAM{Service: newMockIAMClient(t, nil), PathPrefix: "/spinup/"}
+
+	input := &iam.CreateRoleInput{RoleName: aws.String("testrole")}
+	out, err := i.CreateRole(context.TODO(), input)
+	if err != nil {
+		t.Fatalf("expected nil error, got: %s", err)
+	}
+
+	if path := aws.StringValue(out.Path); path != "/spinup/" {
+		t.Errorf("expected role to be created under /spinup/, got %s", path)
+	}
+
+	if input.Path != nil {
+		t.Errorf("expected the input path not to be changed, got %s", aws.StringValue(input.Path))
+	}
+
+	if _, err := i.CreateRole(context.TODO(), nil); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Errorf("expected bad request for nil input, got %v", err)
+	}
+
+	i.Service.(*mockIAMClient).err = awserr.New(iam.ErrCodeEntityAlreadyExistsException, "exists", nil)
+	if _, err := i.CreateRole(context.TODO(), input); !common.IsErrorCode(err, apierror.ErrConflict) {
+		t.Errorf("expected conflict for an existing role, got %v", err)
+	}
+}
+
+func TestGetRole(t *testing.T) {
+	i := IAM{Service: newMockIAMClient(t, nil)}
+
+	out, err := i.GetRole(context.TODO(), "testrole")
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if !reflect.DeepEqual(out, &testRole) {
+		t.Errorf("expected %+v, got %+v", &testRole, out)
+	}
+
+	if _, err := i.GetRole(context.TODO(), ""); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Errorf("expected bad request for empty name, got %v", err)
+	}
+
+	i.Service.(*mockIAMClient).err = awserr.New(iam.ErrCodeNoSuchEntityException, "not found", nil)
+	if _, err := i.GetRole(context.TODO(), "testrole"); !common.IsErrorCode(err, apierror.ErrNotFound) {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
+
+func TestDeleteRole(t *testing.T) {
+	i := IAM{Service: newMockIAMClient(t, nil)}
+
+	if err := i.DeleteRole(context.TODO(), "testrole"); err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if err := i.DeleteRole(context.TODO(), ""); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Errorf("expected bad request for empty name, got %v", err)
+	}
+
+	i.Service.(*mockIAMClient).err = awserr.New(iam.ErrCodeDeleteConflictException, "attached", nil)
+	if err := i.DeleteRole(context.TODO(), "testrole"); !common.IsErrorCode(err, apierror.ErrConflict) {
+		t.Errorf("expected conflict, got %v", err)
+	}
+}
+
+func TestAttachDetachRolePolicy(t *testing.T) {
+	i := IAM{Service: newMockIAMClient(t, nil)}
+
+	if err := i.AttachRolePolicy(context.TODO(), &iam.AttachRolePolicyInput{
+		RoleName:  aws.String("testrole"),
+		PolicyArn: aws.String("arn:aws:iam::12345678910:policy/testpolicy"),
+	}); err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if err := i.DetachRolePolicy(context.TODO(), &iam.DetachRolePolicyInput{
+		RoleName:  aws.String("testrole"),
+		PolicyArn: aws.String("arn:aws:iam::12345678910:policy/testpolicy"),
+	}); err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if err := i.AttachRolePolicy(context.TODO(), &iam.AttachRolePolicyInput{RoleName: aws.String("testrole")}); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Errorf("expected bad request without a policy arn, got %v", err)
+	}
+
+	if err := i.DetachRolePolicy(context.TODO(), nil); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Errorf("expected bad request for nil input, got %v", err)
+	}
+
+	i.Service.(*mockIAMClient).err = awserr.New(iam.ErrCodeNoSuchEntityException, "not found", nil)
+	if err := i.DetachRolePolicy(context.TODO(), &iam.DetachRolePolicyInput{
+		RoleName:  aws.String("testrole"),
+		PolicyArn: aws.String("arn:aws:iam::12345678910:policy/testpolicy"),
+	}); !common.IsErrorCode(err, apierror.ErrNotFound) {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
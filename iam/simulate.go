@@ -0,0 +1,43 @@
+package iam
+
+import (
+	"context"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	log "github.com/sirupsen/logrus"
+)
+
+// SimulatePrincipalPolicy simulates whether the policies of a principal, including the policies of the groups a user
+// is in, allow the actions on the resources
+func (i *IAM) SimulatePrincipalPolicy(ctx context.Context, principalArn string, actions, resources []string) ([]*iam.EvaluationResult, error) {
+	if principalArn == "" || len(actions) == 0 {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("simulating iam policy for %s with actions %v on resources %v", principalArn, actions, resources)
+
+	input := iam.SimulatePrincipalPolicyInput{
+		ActionNames:     aws.StringSlice(actions),
+		PolicySourceArn: aws.String(principalArn),
+	}
+
+	if len(resources) > 0 {
+		input.ResourceArns = aws.StringSlice(resources)
+	}
+
+	results := []*iam.EvaluationResult{}
+	truncated := true
+	for truncated {
+		output, err := i.Service.SimulatePrincipalPolicyWithContext(ctx, &input)
+		if err != nil {
+			return nil, ErrCode("failed to simulate iam policy", err)
+		}
+		truncated = aws.BoolValue(output.IsTruncated)
+		results = append(results, output.EvaluationResults...)
+		input.Marker = output.Marker
+	}
+
+	return results, nil
+}
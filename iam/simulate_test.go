@@ -0,0 +1,107 @@
+package iam
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+var testEvaluationResults = []*iam.EvaluationResult{
+	{
+		EvalActionName:   aws.String("s3:GetObject"),
+		EvalResourceName: aws.String("arn:aws:s3:::testbucket/*"),
+		EvalDecision:     aws.String(iam.PolicyEvaluationDecisionTypeAllowed),
+		MatchedStatements: []*iam.Statement{
+			{SourcePolicyId: aws.String("testbucket-BktAdmPlc")},
+		},
+	},
+	{
+		EvalActionName:   aws.String("s3:DeleteBucket"),
+		EvalResourceName: aws.String("arn:aws:s3:::testbucket"),
+		EvalDecision:     aws.String(iam.PolicyEvaluationDecisionTypeImplicitDeny),
+	},
+}
+
+func (m *mockIAMClient) SimulatePrincipalPolicyWithContext(ctx context.Context, input *iam.SimulatePrincipalPolicyInput, opts ...request.Option) (*iam.SimulatePolicyResponse, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if aws.StringValue(input.PolicySourceArn) == "arn:aws:iam::012345678910:user/missing" {
+		return nil, awserr.New(iam.ErrCodeNoSuchEntityException, "not found", nil)
+	}
+
+	// return the results a page at a time
+	if input.Marker == nil {
+		return &iam.SimulatePolicyResponse{
+			EvaluationResults: testEvaluationResults[:1],
+			IsTruncated:       aws.Bool(true),
+			Marker:            aws.String("next"),
+		}, nil
+	}
+
+	return &iam.SimulatePolicyResponse{EvaluationResults: testEvaluationResults[1:]}, nil
+}
+
+func TestSimulatePrincipalPolicy(t *testing.T) {
+	i := IAM{Service: newMockIAMClient(t, nil)}
+
+	// test success
+	out, err := i.SimulatePrincipalPolicy(context.TODO(), "arn:aws:iam::012345678910:user/testuser", []string{"s3:GetObject", "s3:DeleteBucket"}, []string{"arn:aws:s3:::testbucket", "arn:aws:s3:::testbucket/*"})
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if !reflect.DeepEqual(out, testEvaluationResults) {
+		t.Errorf("expected %+v, got %+v", testEvaluationResults, out)
+	}
+
+	// test invalid input
+	_, err = i.SimulatePrincipalPolicy(context.TODO(), "", nil, nil)
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrBadRequest {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrBadRequest, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+
+	// test missing principal
+	_, err = i.SimulatePrincipalPolicy(context.TODO(), "arn:aws:iam::012345678910:user/missing", []string{"s3:GetObject"}, nil)
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrNotFound {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrNotFound, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+
+	// test policy evaluation error
+	i.Service.(*mockIAMClient).err = awserr.New(iam.ErrCodePolicyEvaluationException, "evaluation failed", nil)
+	_, err = i.SimulatePrincipalPolicy(context.TODO(), "arn:aws:iam::012345678910:user/testuser", []string{"s3:GetObject"}, nil)
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrBadRequest {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrBadRequest, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+
+	// test non-aws error
+	i.Service.(*mockIAMClient).err = errors.New("things blowing up!")
+	_, err = i.SimulatePrincipalPolicy(context.TODO(), "arn:aws:iam::012345678910:user/testuser", []string{"s3:GetObject"}, nil)
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrInternalError {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrInternalError, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+}
@@ -0,0 +1,322 @@
+package iam
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/YaleSpinup/apierror"
+	log "github.com/sirupsen/logrus"
+)
+
+// The names of the statement blocks that policies are composed of
+const (
+	BlockWebsiteRead   = "website-read"
+	BlockAdmin         = "admin"
+	BlockReadWrite     = "read-write"
+	BlockReadOnly      = "read-only"
+	BlockVpcRestricted = "vpc-restricted"
+	BlockIPRestricted  = "ip-restricted"
+	BlockDenyDelete    = "deny-delete"
+)
+
+// The sids of the bucket policy blocks, they're used to find a block's statement in a bucket policy document
+const (
+	SidWebsiteRead   = "SpinupWebsiteRead"
+	SidVpcRestricted = "SpinupVpcOnly"
+	SidIPRestricted  = "SpinupIpRestricted"
+	SidDenyDelete    = "SpinupDenyDelete"
+)
+
+// DeleteActions are the actions denied by the deny-delete block
+var DeleteActions = []string{
+	"s3:DeleteBucket",
+	"s3:DeleteObject",
+	"s3:DeleteObjectVersion",
+}
+
+// BlockInput is what the statement blocks are generated from
+type BlockInput struct {
+	Bucket string
+	// Path limits the blocks that allow access to a prefix of the bucket
+	Path string
+	// ExemptPrincipals are the principal arns the deny blocks don't apply to, ie. the api's role so the bucket can
+	// still be managed
+	ExemptPrincipals []string
+	// VpcEndpoints are the endpoints the vpc-restricted block allows requests through
+	VpcEndpoints []string
+	// IPAllowlist are the networks the ip-restricted and website-read blocks allow requests from
+	IPAllowlist []string
+	// BreakGlassPrincipals are the only principal arns the deny-delete block doesn't apply to, the exempt principals
+	// aren't exempt from it so data can't be deleted through the api either
+	BreakGlassPrincipals []string
+}
+
+// StatementBlock is a named building block of a policy
+type StatementBlock struct {
+	// BucketPolicy is true for the blocks that belong in a bucket policy, the rest belong in an iam policy
+	BucketPolicy bool
+	// Sid identifies the statement of a bucket policy block
+	Sid string
+	// Destructive is true for the blocks that allow destructive actions, they're denied without MFA when the account
+	// requires it
+	Destructive bool
+
+	statements func(in BlockInput) ([]PolicyStatement, error)
+}
+
+// StatementBlocks is the library of statement blocks, by name
+var StatementBlocks = map[string]StatementBlock{
+	BlockWebsiteRead: {
+		BucketPolicy: true,
+		Sid:          SidWebsiteRead,
+		statements:   websiteReadStatements,
+	},
+	BlockAdmin: {
+		Destructive: true,
+		statements:  adminStatements,
+	},
+	BlockReadWrite: {
+		Destructive: true,
+		statements:  readWriteStatements,
+	},
+	BlockReadOnly: {
+		statements: readOnlyStatements,
+	},
+	BlockVpcRestricted: {
+		BucketPolicy: true,
+		Sid:          SidVpcRestricted,
+		statements:   vpcRestrictedStatements,
+	},
+	BlockIPRestricted: {
+		BucketPolicy: true,
+		Sid:          SidIPRestricted,
+		statements:   ipRestrictedStatements,
+	},
+	BlockDenyDelete: {
+		BucketPolicy: true,
+		Sid:          SidDenyDelete,
+		statements:   denyDeleteStatements,
+	},
+}
+
+// ComposeStatements returns the statements of the named blocks, in order
+func ComposeStatements(in BlockInput, names ...string) ([]PolicyStatement, error) {
+	if in.Bucket == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+	in.Path = RemoveCappingSlashes(in.Path)
+
+	statements := []PolicyStatement{}
+	for _, name := range names {
+		block, ok := StatementBlocks[name]
+		if !ok {
+			return nil, apierror.New(apierror.ErrBadRequest, "unknown policy block "+name, nil)
+		}
+
+		st, err := block.statements(in)
+		if err != nil {
+			return nil, err
+		}
+
+		for n := range st {
+			st[n].Sid = block.Sid
+		}
+		statements = append(statements, st...)
+	}
+
+	return statements, nil
+}
+
+// ComposePolicy generates a policy document from the named blocks.  When the account requires MFA and any of the
+// blocks allow destructive actions, they're denied without MFA.
+func (i *IAM) ComposePolicy(in BlockInput, names ...string) (PolicyDoc, error) {
+	statements, err := ComposeStatements(in, names...)
+	if err != nil {
+		return PolicyDoc{}, err
+	}
+
+	for _, name := range names {
+		if StatementBlocks[name].Destructive {
+			statements = i.requireMFA(in.Bucket, statements)
+			break
+		}
+	}
+
+	return PolicyDoc{
+		Version:   "2012-10-17",
+		Statement: statements,
+	}, nil
+}
+
+// bucketArns returns the arns of a bucket and its objects
+func bucketArns(bucket string) []string {
+	return []string{
+		fmt.Sprintf("arn:aws:s3:::%s", bucket),
+		fmt.Sprintf("arn:aws:s3:::%s/*", bucket),
+	}
+}
+
+// objectArn returns the arn of the objects a block allows access to, limited to the path if there is one
+func (in BlockInput) objectArn() string {
+	if in.Path == "" {
+		return fmt.Sprintf("arn:aws:s3:::%s/*", in.Bucket)
+	}
+	return fmt.Sprintf("arn:aws:s3:::%s/%s/*", in.Bucket, in.Path)
+}
+
+// prefixCondition returns the condition limiting bucket actions to the path, nil if there isn't one
+func (in BlockInput) prefixCondition() map[string]PolicyCondition {
+	if in.Path == "" {
+		return nil
+	}
+
+	return map[string]PolicyCondition{
+		"StringLike": {
+			"s3:prefix": fmt.Sprintf("%s/*", in.Path),
+		},
+	}
+}
+
+// exemptCondition adds the condition exempting the principals from a deny statement to the conditions
+func (in BlockInput) exemptCondition(conditions map[string]PolicyCondition) map[string]PolicyCondition {
+	if len(in.ExemptPrincipals) > 0 {
+		conditions["ArnNotLike"] = PolicyCondition{"aws:PrincipalArn": in.ExemptPrincipals}
+	}
+	return conditions
+}
+
+// websiteReadStatements allows anyone to read the objects of a website bucket, from the allowlist if there is one
+func websiteReadStatements(in BlockInput) ([]PolicyStatement, error) {
+	return []PolicyStatement{
+		{
+			Effect:    "Allow",
+			Principal: "*",
+			Action:    []string{"s3:GetObject"},
+			Resource:  []string{in.objectArn()},
+			Condition: sourceIPCondition(in.IPAllowlist),
+		},
+	}, nil
+}
+
+// readOnlyStatements allows reading the bucket configuration and objects
+func readOnlyStatements(in BlockInput) ([]PolicyStatement, error) {
+	return []PolicyStatement{
+		{
+			Effect:    "Allow",
+			Action:    BucketReadPolicy,
+			Resource:  []string{fmt.Sprintf("arn:aws:s3:::%s", in.Bucket)},
+			Condition: in.prefixCondition(),
+		},
+		{
+			Effect:   "Allow",
+			Action:   ObjectReadPolicy,
+			Resource: []string{in.objectArn()},
+		},
+	}, nil
+}
+
+// readWriteStatements allows reading the bucket configuration and reading and writing objects
+func readWriteStatements(in BlockInput) ([]PolicyStatement, error) {
+	statements, err := readOnlyStatements(in)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(statements, PolicyStatement{
+		Effect:   "Allow",
+		Action:   ObjectWritePolicy,
+		Resource: []string{in.objectArn()},
+	}), nil
+}
+
+// adminStatements allows managing the bucket configuration and reading and writing objects
+func adminStatements(in BlockInput) ([]PolicyStatement, error) {
+	statements, err := readWriteStatements(in)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]PolicyStatement{
+		{
+			Effect:    "Allow",
+			Action:    BucketAdminPolicy,
+			Resource:  []string{fmt.Sprintf("arn:aws:s3:::%s", in.Bucket)},
+			Condition: in.prefixCondition(),
+		},
+	}, statements...), nil
+}
+
+// vpcRestrictedStatements denies all access to the bucket that doesn't come through one of the vpc endpoints
+func vpcRestrictedStatements(in BlockInput) ([]PolicyStatement, error) {
+	if len(in.VpcEndpoints) == 0 {
+		return nil, apierror.New(apierror.ErrBadRequest, "vpc endpoints are required to restrict a bucket to them", nil)
+	}
+
+	return []PolicyStatement{
+		{
+			Effect:    "Deny",
+			Principal: "*",
+			Action:    []string{"s3:*"},
+			Resource:  bucketArns(in.Bucket),
+			Condition: in.exemptCondition(map[string]PolicyCondition{
+				"StringNotEquals": {"aws:SourceVpce": in.VpcEndpoints},
+			}),
+		},
+	}, nil
+}
+
+// ipRestrictedStatements denies all access to the bucket that doesn't come from one of the networks in the allowlist
+func ipRestrictedStatements(in BlockInput) ([]PolicyStatement, error) {
+	if len(in.IPAllowlist) == 0 {
+		return nil, apierror.New(apierror.ErrBadRequest, "an ip allowlist is required to restrict a bucket to it", nil)
+	}
+
+	return []PolicyStatement{
+		{
+			Effect:    "Deny",
+			Principal: "*",
+			Action:    []string{"s3:*"},
+			Resource:  bucketArns(in.Bucket),
+			Condition: in.exemptCondition(map[string]PolicyCondition{
+				"NotIpAddress": {"aws:SourceIp": in.IPAllowlist},
+			}),
+		},
+	}, nil
+}
+
+// denyDeleteStatements denies deleting the bucket and its objects to everyone but the break-glass principals
+func denyDeleteStatements(in BlockInput) ([]PolicyStatement, error) {
+	if len(in.BreakGlassPrincipals) == 0 {
+		return nil, apierror.New(apierror.ErrBadRequest, "a break-glass role is required to deny deletes", nil)
+	}
+
+	return []PolicyStatement{
+		{
+			Effect:    "Deny",
+			Principal: "*",
+			Action:    DeleteActions,
+			Resource:  bucketArns(in.Bucket),
+			Condition: map[string]PolicyCondition{
+				"ArnNotLike": {"aws:PrincipalArn": in.BreakGlassPrincipals},
+			},
+		},
+	}, nil
+}
+
+// ComposePolicyDocument composes a policy from the named blocks and returns the JSON document
+func (i *IAM) ComposePolicyDocument(in BlockInput, names ...string) ([]byte, error) {
+	doc, err := i.ComposePolicy(in, names...)
+	if err != nil {
+		return nil, err
+	}
+
+	policyDoc, err := json.Marshal(doc)
+	if err != nil {
+		log.Errorf("failed to generate %v policy for %s: %s", names, in.Bucket, err)
+		return nil, err
+	}
+
+	log.Debugf("generated policy document %s", string(policyDoc))
+
+	return policyDoc, nil
+}
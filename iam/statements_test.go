@@ -0,0 +1,134 @@
+package iam
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+)
+
+func TestComposeStatements(t *testing.T) {
+	in := BlockInput{
+		Bucket:               "vehicles",
+		ExemptPrincipals:     []string{"arn:aws:iam::012345678910:role/SpinupS3Role"},
+		VpcEndpoints:         []string{"vpce-1111"},
+		IPAllowlist:          []string{"10.0.0.0/8"},
+		BreakGlassPrincipals: []string{"arn:aws:iam::012345678910:role/BreakGlass"},
+	}
+
+	tests := []struct {
+		block    string
+		expected string
+	}{
+		{
+			BlockWebsiteRead,
+			`[{"Sid":"SpinupWebsiteRead","Effect":"Allow","Principal":"*","Action":["s3:GetObject"],"Resource":["arn:aws:s3:::vehicles/*"],"Condition":{"IpAddress":{"aws:SourceIp":["10.0.0.0/8"]}}}]`,
+		},
+		{
+			BlockVpcRestricted,
+			`[{"Sid":"SpinupVpcOnly","Effect":"Deny","Principal":"*","Action":["s3:*"],"Resource":["arn:aws:s3:::vehicles","arn:aws:s3:::vehicles/*"],"Condition":{"ArnNotLike":{"aws:PrincipalArn":["arn:aws:iam::012345678910:role/SpinupS3Role"]},"StringNotEquals":{"aws:SourceVpce":["vpce-1111"]}}}]`,
+		},
+		{
+			BlockIPRestricted,
+			`[{"Sid":"SpinupIpRestricted","Effect":"Deny","Principal":"*","Action":["s3:*"],"Resource":["arn:aws:s3:::vehicles","arn:aws:s3:::vehicles/*"],"Condition":{"ArnNotLike":{"aws:PrincipalArn":["arn:aws:iam::012345678910:role/SpinupS3Role"]},"NotIpAddress":{"aws:SourceIp":["10.0.0.0/8"]}}}]`,
+		},
+		{
+			BlockDenyDelete,
+			`[{"Sid":"SpinupDenyDelete","Effect":"Deny","Principal":"*","Action":["s3:DeleteBucket","s3:DeleteObject","s3:DeleteObjectVersion"],"Resource":["arn:aws:s3:::vehicles","arn:aws:s3:::vehicles/*"],"Condition":{"ArnNotLike":{"aws:PrincipalArn":["arn:aws:iam::012345678910:role/BreakGlass"]}}}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		statements, err := ComposeStatements(in, tt.block)
+		if err != nil {
+			t.Fatalf("%s: expected nil error, got %s", tt.block, err)
+		}
+
+		j, err := json.Marshal(statements)
+		if err != nil {
+			t.Fatalf("%s: failed to marshal statements: %s", tt.block, err)
+		}
+
+		if string(j) != tt.expected {
+			t.Errorf("%s: expected: %s\ngot: %s", tt.block, tt.expected, j)
+		}
+	}
+
+	// blocks are composed in order
+	statements, err := ComposeStatements(in, BlockReadOnly, BlockDenyDelete)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if len(statements) != 3 || statements[2].Sid != SidDenyDelete {
+		t.Errorf("expected the read-only statements followed by the deny-delete statement, got %+v", statements)
+	}
+
+	invalid := []struct {
+		in     BlockInput
+		blocks []string
+	}{
+		{BlockInput{}, []string{BlockReadOnly}},
+		{in, []string{"everything"}},
+		{BlockInput{Bucket: "vehicles"}, []string{BlockVpcRestricted}},
+		{BlockInput{Bucket: "vehicles"}, []string{BlockIPRestricted}},
+		{BlockInput{Bucket: "vehicles", ExemptPrincipals: in.ExemptPrincipals}, []string{BlockDenyDelete}},
+	}
+
+	for _, tt := range invalid {
+		_, err := ComposeStatements(tt.in, tt.blocks...)
+		if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrBadRequest {
+			t.Errorf("expected bad request composing %v from %+v, got %v", tt.blocks, tt.in, err)
+		}
+	}
+}
+
+func TestComposeStatementsWithPath(t *testing.T) {
+	statements, err := ComposeStatements(BlockInput{Bucket: "vehicles", Path: "/trucks/"}, BlockAdmin)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if len(statements) != 4 {
+		t.Fatalf("expected 4 statements, got %d", len(statements))
+	}
+
+	prefix := map[string]PolicyCondition{"StringLike": {"s3:prefix": "trucks/*"}}
+	for _, st := range statements[:2] {
+		if !reflect.DeepEqual(st.Condition, prefix) {
+			t.Errorf("expected the bucket statement to be limited to the path, got %+v", st.Condition)
+		}
+	}
+
+	for _, st := range statements[2:] {
+		if !reflect.DeepEqual(st.Resource, []string{"arn:aws:s3:::vehicles/trucks/*"}) {
+			t.Errorf("expected the object statement to be limited to the path, got %v", st.Resource)
+		}
+	}
+}
+
+func TestComposePolicyRequireMFA(t *testing.T) {
+	mfa := &IAM{RequireMFA: true}
+
+	tests := []struct {
+		blocks   []string
+		expected int
+	}{
+		{[]string{BlockReadOnly}, 2},
+		{[]string{BlockReadWrite}, 4},
+		{[]string{BlockAdmin}, 5},
+		{[]string{BlockReadOnly, BlockReadWrite}, 6},
+	}
+
+	for _, tt := range tests {
+		doc, err := mfa.ComposePolicy(BlockInput{Bucket: "vehicles"}, tt.blocks...)
+		if err != nil {
+			t.Fatalf("expected nil error, got %s", err)
+		}
+
+		if len(doc.Statement) != tt.expected {
+			t.Errorf("expected %d statements for %v, got %d", tt.expected, tt.blocks, len(doc.Statement))
+		}
+	}
+}
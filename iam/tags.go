@@ -0,0 +1,146 @@
+package iam
+
+import (
+	"context"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	log "github.com/sirupsen/logrus"
+)
+
+// ListPolicyTags lists the tags on a managed policy
+func (i *IAM) ListPolicyTags(ctx context.Context, policyArn string) ([]*iam.Tag, error) {
+	if policyArn == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Debugf("listing tags for iam policy %s", policyArn)
+
+	tags := []*iam.Tag{}
+	input := iam.ListPolicyTagsInput{PolicyArn: aws.String(policyArn)}
+	truncated := true
+	for truncated {
+		output, err := i.Service.ListPolicyTagsWithContext(ctx, &input)
+		if err != nil {
+			return nil, ErrCode("failed to list iam policy tags", err)
+		}
+		truncated = aws.BoolValue(output.IsTruncated)
+		tags = append(tags, output.Tags...)
+		input.Marker = output.Marker
+	}
+
+	return tags, nil
+}
+
+// TagPolicy adds or overwrites tags on a managed policy
+func (i *IAM) TagPolicy(ctx context.Context, policyArn string, tags []*iam.Tag) error {
+	if policyArn == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+
+	log.Infof("tagging iam policy %s", policyArn)
+
+	if _, err := i.Service.TagPolicyWithContext(ctx, &iam.TagPolicyInput{
+		PolicyArn: aws.String(policyArn),
+		Tags:      tags,
+	}); err != nil {
+		return ErrCode("failed to tag iam policy", err)
+	}
+
+	return nil
+}
+
+// UntagPolicy removes the tags with the passed keys from a managed policy
+func (i *IAM) UntagPolicy(ctx context.Context, policyArn string, keys []string) error {
+	if policyArn == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	log.Infof("removing tags %v from iam policy %s", keys, policyArn)
+
+	if _, err := i.Service.UntagPolicyWithContext(ctx, &iam.UntagPolicyInput{
+		PolicyArn: aws.String(policyArn),
+		TagKeys:   aws.StringSlice(keys),
+	}); err != nil {
+		return ErrCode("failed to untag iam policy", err)
+	}
+
+	return nil
+}
+
+// ListUserTags lists the tags on a user
+func (i *IAM) ListUserTags(ctx context.Context, userName string) ([]*iam.Tag, error) {
+	if userName == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Debugf("listing tags for iam user %s", userName)
+
+	tags := []*iam.Tag{}
+	input := iam.ListUserTagsInput{UserName: aws.String(userName)}
+	truncated := true
+	for truncated {
+		output, err := i.Service.ListUserTagsWithContext(ctx, &input)
+		if err != nil {
+			return nil, ErrCode("failed to list iam user tags", err)
+		}
+		truncated = aws.BoolValue(output.IsTruncated)
+		tags = append(tags, output.Tags...)
+		input.Marker = output.Marker
+	}
+
+	return tags, nil
+}
+
+// TagUser adds or overwrites tags on a user
+func (i *IAM) TagUser(ctx context.Context, userName string, tags []*iam.Tag) error {
+	if userName == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+
+	log.Infof("tagging iam user %s", userName)
+
+	if _, err := i.Service.TagUserWithContext(ctx, &iam.TagUserInput{
+		UserName: aws.String(userName),
+		Tags:     tags,
+	}); err != nil {
+		return ErrCode("failed to tag iam user", err)
+	}
+
+	return nil
+}
+
+// UntagUser removes tags from a user
+func (i *IAM) UntagUser(ctx context.Context, userName string, keys []string) error {
+	if userName == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	log.Infof("removing tags %v from iam user %s", keys, userName)
+
+	if _, err := i.Service.UntagUserWithContext(ctx, &iam.UntagUserInput{
+		UserName: aws.String(userName),
+		TagKeys:  aws.StringSlice(keys),
+	}); err != nil {
+		return ErrCode("failed to untag iam user", err)
+	}
+
+	return nil
+}
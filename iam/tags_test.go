@@ -0,0 +1,157 @@
+package iam
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+var testIAMTags = []*iam.Tag{
+	{Key: aws.String("spinup:org"), Value: aws.String("testorg")},
+	{Key: aws.String("costcenter"), Value: aws.String("123456")},
+}
+
+func (m *mockIAMClient) ListPolicyTagsWithContext(ctx context.Context, input *iam.ListPolicyTagsInput, opts ...request.Option) (*iam.ListPolicyTagsOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &iam.ListPolicyTagsOutput{Tags: testIAMTags}, nil
+}
+
+func (m *mockIAMClient) TagPolicyWithContext(ctx context.Context, input *iam.TagPolicyInput, opts ...request.Option) (*iam.TagPolicyOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &iam.TagPolicyOutput{}, nil
+}
+
+func (m *mockIAMClient) UntagPolicyWithContext(ctx context.Context, input *iam.UntagPolicyInput, opts ...request.Option) (*iam.UntagPolicyOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &iam.UntagPolicyOutput{}, nil
+}
+
+func (m *mockIAMClient) ListUserTagsWithContext(ctx context.Context, input *iam.ListUserTagsInput, opts ...request.Option) (*iam.ListUserTagsOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &iam.ListUserTagsOutput{Tags: testIAMTags}, nil
+}
+
+func (m *mockIAMClient) TagUserWithContext(ctx context.Context, input *iam.TagUserInput, opts ...request.Option) (*iam.TagUserOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &iam.TagUserOutput{}, nil
+}
+
+func (m *mockIAMClient) UntagUserWithContext(ctx context.Context, input *iam.UntagUserInput, opts ...request.Option) (*iam.UntagUserOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &iam.UntagUserOutput{}, nil
+}
+
+func TestPolicyTags(t *testing.T) {
+	i := IAM{Service: newMockIAMClient(t, nil)}
+	arn := aws.StringValue(testPolicy.Arn)
+
+	out, err := i.ListPolicyTags(context.TODO(), arn)
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if !reflect.DeepEqual(out, testIAMTags) {
+		t.Errorf("expected %+v, got %+v", testIAMTags, out)
+	}
+
+	if err := i.TagPolicy(context.TODO(), arn, testIAMTags); err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if err := i.UntagPolicy(context.TODO(), arn, []string{"costcenter"}); err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	// test empty arn input
+	for _, err := range []error{
+		func() error { _, err := i.ListPolicyTags(context.TODO(), ""); return err }(),
+		i.TagPolicy(context.TODO(), "", testIAMTags),
+		i.UntagPolicy(context.TODO(), "", []string{"costcenter"}),
+	} {
+		if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrBadRequest {
+			t.Errorf("expected error code %s, got: %v", apierror.ErrBadRequest, err)
+		}
+	}
+
+	// test ErrCodeNoSuchEntityException
+	i.Service.(*mockIAMClient).err = awserr.New(iam.ErrCodeNoSuchEntityException, "not found", nil)
+	if err := i.TagPolicy(context.TODO(), arn, testIAMTags); err == nil {
+		t.Error("expected error, got nil")
+	} else if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrNotFound {
+		t.Errorf("expected error code %s, got: %s", apierror.ErrNotFound, err)
+	}
+
+	// no tags or keys is a no-op
+	if err := i.TagPolicy(context.TODO(), arn, nil); err != nil {
+		t.Errorf("expected nil error for no tags, got: %s", err)
+	}
+
+	if err := i.UntagPolicy(context.TODO(), arn, nil); err != nil {
+		t.Errorf("expected nil error for no keys, got: %s", err)
+	}
+}
+
+func TestUserTags(t *testing.T) {
+	i := IAM{Service: newMockIAMClient(t, nil)}
+
+	out, err := i.ListUserTags(context.TODO(), "testuser")
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if !reflect.DeepEqual(out, testIAMTags) {
+		t.Errorf("expected %+v, got %+v", testIAMTags, out)
+	}
+
+	if err := i.TagUser(context.TODO(), "testuser", testIAMTags); err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if err := i.UntagUser(context.TODO(), "testuser", []string{"costcenter"}); err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	// no keys is a no-op
+	if err := i.UntagUser(context.TODO(), "testuser", nil); err != nil {
+		t.Errorf("expected nil error for no keys, got: %s", err)
+	}
+
+	// test empty user input
+	if _, err := i.ListUserTags(context.TODO(), ""); err == nil {
+		t.Error("expected error for empty user, got nil")
+	}
+
+	if err := i.TagUser(context.TODO(), "", testIAMTags); err == nil {
+		t.Error("expected error for empty user, got nil")
+	}
+
+	if err := i.UntagUser(context.TODO(), "", []string{"costcenter"}); err == nil {
+		t.Error("expected error for empty user, got nil")
+	}
+
+	// test ErrCodeServiceFailureException
+	i.Service.(*mockIAMClient).err = awserr.New(iam.ErrCodeServiceFailureException, "failed", nil)
+	if _, err := i.ListUserTags(context.TODO(), "testuser"); err == nil {
+		t.Error("expected error, got nil")
+	} else if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrServiceUnavailable {
+		t.Errorf("expected error code %s, got: %s", apierror.ErrServiceUnavailable, err)
+	}
+}
@@ -3,6 +3,7 @@ package iam
 import (
 	"context"
 	"strings"
+	"time"
 
 	"github.com/YaleSpinup/apierror"
 	"github.com/aws/aws-sdk-go/aws"
@@ -19,6 +20,13 @@ func (i *IAM) CreateUser(ctx context.Context, input *iam.CreateUserInput) (*iam.
 
 	log.Infof("creating iam user: %s", aws.StringValue(input.UserName))
 
+	// the input is copied so the caller's path isn't changed
+	if i.PathPrefix != "" {
+		scoped := *input
+		scoped.Path = aws.String(i.Path(aws.StringValue(input.Path)))
+		input = &scoped
+	}
+
 	output, err := i.Service.CreateUserWithContext(ctx, input)
 	if err != nil {
 		return nil, ErrCode("failed to create iam user", err)
@@ -89,6 +97,35 @@ func (i *IAM) GetUser(ctx context.Context, input *iam.GetUserInput) (*iam.GetUse
 	return output, nil
 }
 
+// ListUsers lists the IAM users in the account, by default the users under the path prefix if one is configured
+func (i *IAM) ListUsers(ctx context.Context, input *iam.ListUsersInput) ([]*iam.User, error) {
+	users := []*iam.User{}
+	if input == nil {
+		return users, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	if input.PathPrefix == nil && i.PathPrefix != "" {
+		input.PathPrefix = aws.String(i.Path("/"))
+	}
+
+	log.Infof("listing iam users with path prefix %s", aws.StringValue(input.PathPrefix))
+
+	truncated := true
+	for truncated {
+		output, err := i.Service.ListUsersWithContext(ctx, input)
+		if err != nil {
+			return nil, ErrCode("failed to list iam users", err)
+		}
+		truncated = aws.BoolValue(output.IsTruncated)
+		users = append(users, output.Users...)
+		input.Marker = output.Marker
+	}
+
+	log.Debugf("returning list of iam users: %s", awsutil.Prettify(users))
+
+	return users, nil
+}
+
 // CreateAccessKey creates an access key for an IAM user
 func (i *IAM) CreateAccessKey(ctx context.Context, input *iam.CreateAccessKeyInput) (*iam.CreateAccessKeyOutput, error) {
 	if input == nil || aws.StringValue(input.UserName) == "" {
@@ -121,6 +158,147 @@ func (i *IAM) DeleteAccessKey(ctx context.Context, input *iam.DeleteAccessKeyInp
 	return nil
 }
 
+// UpdateAccessKey changes the status of an access key to Active or Inactive
+func (i *IAM) UpdateAccessKey(ctx context.Context, input *iam.UpdateAccessKeyInput) error {
+	if input == nil || aws.StringValue(input.UserName) == "" || aws.StringValue(input.AccessKeyId) == "" || aws.StringValue(input.Status) == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("updating access key id %s for iam user %s to %s", aws.StringValue(input.AccessKeyId), aws.StringValue(input.UserName), aws.StringValue(input.Status))
+
+	if _, err := i.Service.UpdateAccessKeyWithContext(ctx, input); err != nil {
+		return ErrCode("failed to update iam access key", err)
+	}
+
+	return nil
+}
+
+// GetAccessKeyLastUsed returns when an access key was last used, it's nil if the key has never been used
+func (i *IAM) GetAccessKeyLastUsed(ctx context.Context, keyId string) (*time.Time, error) {
+	if keyId == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("getting last used date for access key id %s", keyId)
+
+	output, err := i.Service.GetAccessKeyLastUsedWithContext(ctx, &iam.GetAccessKeyLastUsedInput{AccessKeyId: aws.String(keyId)})
+	if err != nil {
+		return nil, ErrCode("failed to get iam access key last used", err)
+	}
+
+	if output.AccessKeyLastUsed == nil {
+		return nil, nil
+	}
+
+	return output.AccessKeyLastUsed.LastUsedDate, nil
+}
+
+// OldestInactiveAccessKey returns the inactive access key of a user that was used least recently.  Keys that have
+// never been used come first and ties are broken by the oldest create date.  It's nil if the user doesn't have an
+// inactive key.
+func (i *IAM) OldestInactiveAccessKey(ctx context.Context, user string) (*iam.AccessKeyMetadata, error) {
+	keys, err := i.ListAccessKeys(ctx, &iam.ListAccessKeysInput{UserName: aws.String(user)})
+	if err != nil {
+		return nil, err
+	}
+
+	var oldest *iam.AccessKeyMetadata
+	var oldestUsed *time.Time
+	for _, k := range keys {
+		if aws.StringValue(k.Status) != iam.StatusTypeInactive {
+			continue
+		}
+
+		used, err := i.GetAccessKeyLastUsed(ctx, aws.StringValue(k.AccessKeyId))
+		if err != nil {
+			return nil, err
+		}
+
+		if oldest == nil || olderAccessKey(used, aws.TimeValue(k.CreateDate), oldestUsed, aws.TimeValue(oldest.CreateDate)) {
+			oldest, oldestUsed = k, used
+		}
+	}
+
+	return oldest, nil
+}
+
+// UserLastActivity returns the last time a user did anything with its credentials: the latest of when any of its access
+// keys were last used or created, when its password was last used and when the user was created.  A user whose keys
+// have never been used is as old as its newest key.
+func (i *IAM) UserLastActivity(ctx context.Context, user *iam.User) (time.Time, error) {
+	if user == nil || aws.StringValue(user.UserName) == "" {
+		return time.Time{}, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	last := aws.TimeValue(user.CreateDate)
+	if used := aws.TimeValue(user.PasswordLastUsed); used.After(last) {
+		last = used
+	}
+
+	keys, err := i.ListAccessKeys(ctx, &iam.ListAccessKeysInput{UserName: user.UserName})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	for _, k := range keys {
+		if created := aws.TimeValue(k.CreateDate); created.After(last) {
+			last = created
+		}
+
+		used, err := i.GetAccessKeyLastUsed(ctx, aws.StringValue(k.AccessKeyId))
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if used != nil && used.After(last) {
+			last = *used
+		}
+	}
+
+	return last, nil
+}
+
+// DeactivateAccessKeys sets all of the active access keys of a user to Inactive and returns their ids
+func (i *IAM) DeactivateAccessKeys(ctx context.Context, userName string) ([]string, error) {
+	keys, err := i.ListAccessKeys(ctx, &iam.ListAccessKeysInput{UserName: aws.String(userName)})
+	if err != nil {
+		return nil, err
+	}
+
+	deactivated := []string{}
+	for _, k := range keys {
+		if aws.StringValue(k.Status) != iam.StatusTypeActive {
+			continue
+		}
+
+		if err := i.UpdateAccessKey(ctx, &iam.UpdateAccessKeyInput{
+			UserName:    aws.String(userName),
+			AccessKeyId: k.AccessKeyId,
+			Status:      aws.String(iam.StatusTypeInactive),
+		}); err != nil {
+			return nil, err
+		}
+		deactivated = append(deactivated, aws.StringValue(k.AccessKeyId))
+	}
+
+	return deactivated, nil
+}
+
+// olderAccessKey returns true if a key last used at used and created at created is older than the other key, a key
+// that has never been used is older than one that has
+func olderAccessKey(used *time.Time, created time.Time, otherUsed *time.Time, otherCreated time.Time) bool {
+	switch {
+	case used == nil && otherUsed != nil:
+		return true
+	case used != nil && otherUsed == nil:
+		return false
+	case used != nil && !used.Equal(*otherUsed):
+		return used.Before(*otherUsed)
+	}
+
+	return created.Before(otherCreated)
+}
+
 // ListAccessKeys lists the access keys for a user
 func (i *IAM) ListAccessKeys(ctx context.Context, input *iam.ListAccessKeysInput) ([]*iam.AccessKeyMetadata, error) {
 	keys := []*iam.AccessKeyMetadata{}
@@ -267,3 +445,19 @@ func (i *IAM) DetachUserPolicy(ctx context.Context, input *iam.DetachUserPolicyI
 
 	return nil
 }
+
+// AttachUserPolicy attaches an IAM policy to a user
+func (i *IAM) AttachUserPolicy(ctx context.Context, input *iam.AttachUserPolicyInput) error {
+	if input == nil || aws.StringValue(input.UserName) == "" || aws.StringValue(input.PolicyArn) == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("attaching policy %s to user %s", aws.StringValue(input.PolicyArn), aws.StringValue(input.UserName))
+
+	_, err := i.Service.AttachUserPolicyWithContext(ctx, input)
+	if err != nil {
+		return ErrCode("failed to attach policy to user", err)
+	}
+
+	return nil
+}
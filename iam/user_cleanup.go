@@ -0,0 +1,255 @@
+package iam
+
+import (
+	"context"
+	"strings"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	log "github.com/sirupsen/logrus"
+)
+
+// DeleteUserAndAttachments removes everything attached to an IAM user that would keep it from being deleted and then
+// deletes the user.  The access keys, signing certificates, SSH public keys, service specific credentials, MFA devices,
+// login profile and inline policies are deleted, the managed policies are detached and the user is removed from its
+// groups.  Virtual MFA devices are deleted along with the user, hardware devices are only deactivated.
+func (i *IAM) DeleteUserAndAttachments(ctx context.Context, userName string) error {
+	if userName == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("cleaning up iam user %s before deleting it", userName)
+
+	steps := []struct {
+		name string
+		run  func(context.Context, string) error
+	}{
+		{"access keys", i.deleteUserAccessKeys},
+		{"signing certificates", i.deleteUserSigningCertificates},
+		{"ssh public keys", i.deleteUserSSHPublicKeys},
+		{"service specific credentials", i.deleteUserServiceSpecificCredentials},
+		{"mfa devices", i.deleteUserMFADevices},
+		{"login profile", i.deleteUserLoginProfile},
+		{"inline policies", i.deleteUserInlinePolicies},
+		{"managed policies", i.detachUserPolicies},
+		{"group memberships", i.removeUserFromGroups},
+	}
+
+	for _, step := range steps {
+		log.Debugf("removing %s for iam user %s", step.name, userName)
+
+		if err := step.run(ctx, userName); err != nil {
+			log.Errorf("failed to remove %s for iam user %s: %s", step.name, userName, err)
+			return err
+		}
+	}
+
+	return i.DeleteUser(ctx, &iam.DeleteUserInput{UserName: aws.String(userName)})
+}
+
+// deleteUserAccessKeys deletes all of the access keys for a user
+func (i *IAM) deleteUserAccessKeys(ctx context.Context, userName string) error {
+	keys, err := i.ListAccessKeys(ctx, &iam.ListAccessKeysInput{UserName: aws.String(userName)})
+	if err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		if err := i.DeleteAccessKey(ctx, &iam.DeleteAccessKeyInput{UserName: aws.String(userName), AccessKeyId: k.AccessKeyId}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteUserSigningCertificates deletes all of the X.509 signing certificates for a user
+func (i *IAM) deleteUserSigningCertificates(ctx context.Context, userName string) error {
+	input := &iam.ListSigningCertificatesInput{UserName: aws.String(userName)}
+
+	certificates := []*iam.SigningCertificate{}
+	truncated := true
+	for truncated {
+		output, err := i.Service.ListSigningCertificatesWithContext(ctx, input)
+		if err != nil {
+			return ErrCode("failed to list signing certificates for user", err)
+		}
+		truncated = aws.BoolValue(output.IsTruncated)
+		certificates = append(certificates, output.Certificates...)
+		input.Marker = output.Marker
+	}
+
+	for _, c := range certificates {
+		log.Infof("deleting signing certificate %s for iam user %s", aws.StringValue(c.CertificateId), userName)
+
+		if _, err := i.Service.DeleteSigningCertificateWithContext(ctx, &iam.DeleteSigningCertificateInput{
+			UserName:      aws.String(userName),
+			CertificateId: c.CertificateId,
+		}); err != nil {
+			return ErrCode("failed to delete signing certificate for user", err)
+		}
+	}
+
+	return nil
+}
+
+// deleteUserSSHPublicKeys deletes all of the SSH public keys for a user
+func (i *IAM) deleteUserSSHPublicKeys(ctx context.Context, userName string) error {
+	input := &iam.ListSSHPublicKeysInput{UserName: aws.String(userName)}
+
+	keys := []*iam.SSHPublicKeyMetadata{}
+	truncated := true
+	for truncated {
+		output, err := i.Service.ListSSHPublicKeysWithContext(ctx, input)
+		if err != nil {
+			return ErrCode("failed to list ssh public keys for user", err)
+		}
+		truncated = aws.BoolValue(output.IsTruncated)
+		keys = append(keys, output.SSHPublicKeys...)
+		input.Marker = output.Marker
+	}
+
+	for _, k := range keys {
+		log.Infof("deleting ssh public key %s for iam user %s", aws.StringValue(k.SSHPublicKeyId), userName)
+
+		if _, err := i.Service.DeleteSSHPublicKeyWithContext(ctx, &iam.DeleteSSHPublicKeyInput{
+			UserName:       aws.String(userName),
+			SSHPublicKeyId: k.SSHPublicKeyId,
+		}); err != nil {
+			return ErrCode("failed to delete ssh public key for user", err)
+		}
+	}
+
+	return nil
+}
+
+// deleteUserServiceSpecificCredentials deletes all of the service specific credentials for a user, ie. CodeCommit
+// git credentials
+func (i *IAM) deleteUserServiceSpecificCredentials(ctx context.Context, userName string) error {
+	output, err := i.Service.ListServiceSpecificCredentialsWithContext(ctx, &iam.ListServiceSpecificCredentialsInput{UserName: aws.String(userName)})
+	if err != nil {
+		return ErrCode("failed to list service specific credentials for user", err)
+	}
+
+	for _, c := range output.ServiceSpecificCredentials {
+		log.Infof("deleting %s credential %s for iam user %s", aws.StringValue(c.ServiceName), aws.StringValue(c.ServiceSpecificCredentialId), userName)
+
+		if _, err := i.Service.DeleteServiceSpecificCredentialWithContext(ctx, &iam.DeleteServiceSpecificCredentialInput{
+			UserName:                    aws.String(userName),
+			ServiceSpecificCredentialId: c.ServiceSpecificCredentialId,
+		}); err != nil {
+			return ErrCode("failed to delete service specific credential for user", err)
+		}
+	}
+
+	return nil
+}
+
+// deleteUserMFADevices deactivates all of the MFA devices for a user and deletes the virtual devices
+func (i *IAM) deleteUserMFADevices(ctx context.Context, userName string) error {
+	devices, err := i.ListMFADevices(ctx, &iam.ListMFADevicesInput{UserName: aws.String(userName)})
+	if err != nil {
+		return err
+	}
+
+	for _, d := range devices {
+		serial := aws.StringValue(d.SerialNumber)
+
+		log.Infof("deactivating mfa device %s for iam user %s", serial, userName)
+
+		if _, err := i.Service.DeactivateMFADeviceWithContext(ctx, &iam.DeactivateMFADeviceInput{
+			UserName:     aws.String(userName),
+			SerialNumber: d.SerialNumber,
+		}); err != nil {
+			return ErrCode("failed to deactivate mfa device for user", err)
+		}
+
+		// virtual devices have an arn for their serial number, ie. arn:aws:iam::012345678910:mfa/someuser
+		if !strings.Contains(serial, ":mfa/") {
+			continue
+		}
+
+		log.Infof("deleting virtual mfa device %s for iam user %s", serial, userName)
+
+		if _, err := i.Service.DeleteVirtualMFADeviceWithContext(ctx, &iam.DeleteVirtualMFADeviceInput{SerialNumber: d.SerialNumber}); err != nil {
+			return ErrCode("failed to delete virtual mfa device for user", err)
+		}
+	}
+
+	return nil
+}
+
+// deleteUserLoginProfile deletes the console login profile for a user, a user without one is not an error
+func (i *IAM) deleteUserLoginProfile(ctx context.Context, userName string) error {
+	if err := i.DeleteLoginProfile(ctx, &iam.DeleteLoginProfileInput{UserName: aws.String(userName)}); err != nil {
+		if !common.IsErrorCode(err, apierror.ErrNotFound) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteUserInlinePolicies deletes all of the inline policies embedded in a user
+func (i *IAM) deleteUserInlinePolicies(ctx context.Context, userName string) error {
+	input := &iam.ListUserPoliciesInput{UserName: aws.String(userName)}
+
+	names := []*string{}
+	truncated := true
+	for truncated {
+		output, err := i.Service.ListUserPoliciesWithContext(ctx, input)
+		if err != nil {
+			return ErrCode("failed to list inline policies for user", err)
+		}
+		truncated = aws.BoolValue(output.IsTruncated)
+		names = append(names, output.PolicyNames...)
+		input.Marker = output.Marker
+	}
+
+	for _, n := range names {
+		log.Infof("deleting inline policy %s for iam user %s", aws.StringValue(n), userName)
+
+		if _, err := i.Service.DeleteUserPolicyWithContext(ctx, &iam.DeleteUserPolicyInput{
+			UserName:   aws.String(userName),
+			PolicyName: n,
+		}); err != nil {
+			return ErrCode("failed to delete inline policy for user", err)
+		}
+	}
+
+	return nil
+}
+
+// detachUserPolicies detaches all of the managed policies from a user, the policies themselves are left as is
+func (i *IAM) detachUserPolicies(ctx context.Context, userName string) error {
+	policies, err := i.ListUserPolicies(ctx, &iam.ListAttachedUserPoliciesInput{UserName: aws.String(userName)})
+	if err != nil {
+		return err
+	}
+
+	for _, p := range policies {
+		if err := i.DetachUserPolicy(ctx, &iam.DetachUserPolicyInput{UserName: aws.String(userName), PolicyArn: p.PolicyArn}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeUserFromGroups removes a user from all of its groups
+func (i *IAM) removeUserFromGroups(ctx context.Context, userName string) error {
+	groups, err := i.ListUserGroups(ctx, &iam.ListGroupsForUserInput{UserName: aws.String(userName)})
+	if err != nil {
+		return err
+	}
+
+	for _, g := range groups {
+		if err := i.RemoveUserFromGroup(ctx, &iam.RemoveUserFromGroupInput{UserName: aws.String(userName), GroupName: g.GroupName}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
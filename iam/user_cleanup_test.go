@@ -0,0 +1,173 @@
+package iam
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+)
+
+// mockCleanupClient has one of every attachment for a user and records the calls removing them
+type mockCleanupClient struct {
+	iamiface.IAMAPI
+	calls        []string
+	loginProfile bool
+	deleteErr    error
+}
+
+func (m *mockCleanupClient) ListAccessKeysWithContext(ctx context.Context, input *iam.ListAccessKeysInput, opts ...request.Option) (*iam.ListAccessKeysOutput, error) {
+	return &iam.ListAccessKeysOutput{AccessKeyMetadata: []*iam.AccessKeyMetadata{{AccessKeyId: aws.String("AKIA1")}}}, nil
+}
+
+func (m *mockCleanupClient) DeleteAccessKeyWithContext(ctx context.Context, input *iam.DeleteAccessKeyInput, opts ...request.Option) (*iam.DeleteAccessKeyOutput, error) {
+	m.calls = append(m.calls, "DeleteAccessKey "+aws.StringValue(input.AccessKeyId))
+	return &iam.DeleteAccessKeyOutput{}, nil
+}
+
+func (m *mockCleanupClient) ListSigningCertificatesWithContext(ctx context.Context, input *iam.ListSigningCertificatesInput, opts ...request.Option) (*iam.ListSigningCertificatesOutput, error) {
+	return &iam.ListSigningCertificatesOutput{Certificates: []*iam.SigningCertificate{{CertificateId: aws.String("CERT1")}}}, nil
+}
+
+func (m *mockCleanupClient) DeleteSigningCertificateWithContext(ctx context.Context, input *iam.DeleteSigningCertificateInput, opts ...request.Option) (*iam.DeleteSigningCertificateOutput, error) {
+	m.calls = append(m.calls, "DeleteSigningCertificate "+aws.StringValue(input.CertificateId))
+	return &iam.DeleteSigningCertificateOutput{}, nil
+}
+
+func (m *mockCleanupClient) ListSSHPublicKeysWithContext(ctx context.Context, input *iam.ListSSHPublicKeysInput, opts ...request.Option) (*iam.ListSSHPublicKeysOutput, error) {
+	return &iam.ListSSHPublicKeysOutput{SSHPublicKeys: []*iam.SSHPublicKeyMetadata{{SSHPublicKeyId: aws.String("SSH1")}}}, nil
+}
+
+func (m *mockCleanupClient) DeleteSSHPublicKeyWithContext(ctx context.Context, input *iam.DeleteSSHPublicKeyInput, opts ...request.Option) (*iam.DeleteSSHPublicKeyOutput, error) {
+	m.calls = append(m.calls, "DeleteSSHPublicKey "+aws.StringValue(input.SSHPublicKeyId))
+	return &iam.DeleteSSHPublicKeyOutput{}, nil
+}
+
+func (m *mockCleanupClient) ListServiceSpecificCredentialsWithContext(ctx context.Context, input *iam.ListServiceSpecificCredentialsInput, opts ...request.Option) (*iam.ListServiceSpecificCredentialsOutput, error) {
+	return &iam.ListServiceSpecificCredentialsOutput{ServiceSpecificCredentials: []*iam.ServiceSpecificCredentialMetadata{
+		{ServiceName: aws.String("codecommit.amazonaws.com"), ServiceSpecificCredentialId: aws.String("CRED1")},
+	}}, nil
+}
+
+func (m *mockCleanupClient) DeleteServiceSpecificCredentialWithContext(ctx context.Context, input *iam.DeleteServiceSpecificCredentialInput, opts ...request.Option) (*iam.DeleteServiceSpecificCredentialOutput, error) {
+	m.calls = append(m.calls, "DeleteServiceSpecificCredential "+aws.StringValue(input.ServiceSpecificCredentialId))
+	return &iam.DeleteServiceSpecificCredentialOutput{}, nil
+}
+
+func (m *mockCleanupClient) ListMFADevicesWithContext(ctx context.Context, input *iam.ListMFADevicesInput, opts ...request.Option) (*iam.ListMFADevicesOutput, error) {
+	return &iam.ListMFADevicesOutput{MFADevices: []*iam.MFADevice{
+		{SerialNumber: aws.String("arn:aws:iam::012345678910:mfa/foo-user")},
+		{SerialNumber: aws.String("GAHT12345678")},
+	}}, nil
+}
+
+func (m *mockCleanupClient) DeactivateMFADeviceWithContext(ctx context.Context, input *iam.DeactivateMFADeviceInput, opts ...request.Option) (*iam.DeactivateMFADeviceOutput, error) {
+	m.calls = append(m.calls, "DeactivateMFADevice "+aws.StringValue(input.SerialNumber))
+	return &iam.DeactivateMFADeviceOutput{}, nil
+}
+
+func (m *mockCleanupClient) DeleteVirtualMFADeviceWithContext(ctx context.Context, input *iam.DeleteVirtualMFADeviceInput, opts ...request.Option) (*iam.DeleteVirtualMFADeviceOutput, error) {
+	m.calls = append(m.calls, "DeleteVirtualMFADevice "+aws.StringValue(input.SerialNumber))
+	return &iam.DeleteVirtualMFADeviceOutput{}, nil
+}
+
+func (m *mockCleanupClient) DeleteLoginProfileWithContext(ctx context.Context, input *iam.DeleteLoginProfileInput, opts ...request.Option) (*iam.DeleteLoginProfileOutput, error) {
+	if !m.loginProfile {
+		return nil, awserr.New(iam.ErrCodeNoSuchEntityException, "login profile not found", nil)
+	}
+	m.calls = append(m.calls, "DeleteLoginProfile")
+	return &iam.DeleteLoginProfileOutput{}, nil
+}
+
+func (m *mockCleanupClient) ListUserPoliciesWithContext(ctx context.Context, input *iam.ListUserPoliciesInput, opts ...request.Option) (*iam.ListUserPoliciesOutput, error) {
+	return &iam.ListUserPoliciesOutput{PolicyNames: aws.StringSlice([]string{"inline1"})}, nil
+}
+
+func (m *mockCleanupClient) DeleteUserPolicyWithContext(ctx context.Context, input *iam.DeleteUserPolicyInput, opts ...request.Option) (*iam.DeleteUserPolicyOutput, error) {
+	m.calls = append(m.calls, "DeleteUserPolicy "+aws.StringValue(input.PolicyName))
+	return &iam.DeleteUserPolicyOutput{}, nil
+}
+
+func (m *mockCleanupClient) ListAttachedUserPoliciesWithContext(ctx context.Context, input *iam.ListAttachedUserPoliciesInput, opts ...request.Option) (*iam.ListAttachedUserPoliciesOutput, error) {
+	return &iam.ListAttachedUserPoliciesOutput{AttachedPolicies: []*iam.AttachedPolicy{
+		{PolicyName: aws.String("foo-BktRWPlc"), PolicyArn: aws.String("arn:aws:iam::012345678910:policy/foo-BktRWPlc")},
+	}}, nil
+}
+
+func (m *mockCleanupClient) DetachUserPolicyWithContext(ctx context.Context, input *iam.DetachUserPolicyInput, opts ...request.Option) (*iam.DetachUserPolicyOutput, error) {
+	m.calls = append(m.calls, "DetachUserPolicy "+aws.StringValue(input.PolicyArn))
+	return &iam.DetachUserPolicyOutput{}, nil
+}
+
+func (m *mockCleanupClient) ListGroupsForUserWithContext(ctx context.Context, input *iam.ListGroupsForUserInput, opts ...request.Option) (*iam.ListGroupsForUserOutput, error) {
+	return &iam.ListGroupsForUserOutput{Groups: []*iam.Group{{GroupName: aws.String("foo-BktRWGrp")}}}, nil
+}
+
+func (m *mockCleanupClient) RemoveUserFromGroupWithContext(ctx context.Context, input *iam.RemoveUserFromGroupInput, opts ...request.Option) (*iam.RemoveUserFromGroupOutput, error) {
+	m.calls = append(m.calls, "RemoveUserFromGroup "+aws.StringValue(input.GroupName))
+	return &iam.RemoveUserFromGroupOutput{}, nil
+}
+
+func (m *mockCleanupClient) DeleteUserWithContext(ctx context.Context, input *iam.DeleteUserInput, opts ...request.Option) (*iam.DeleteUserOutput, error) {
+	if m.deleteErr != nil {
+		return nil, m.deleteErr
+	}
+	m.calls = append(m.calls, "DeleteUser "+aws.StringValue(input.UserName))
+	return &iam.DeleteUserOutput{}, nil
+}
+
+func TestDeleteUserAndAttachments(t *testing.T) {
+	m := &mockCleanupClient{loginProfile: true}
+	i := IAM{Service: m}
+
+	if err := i.DeleteUserAndAttachments(context.TODO(), "foo-user"); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	expected := []string{
+		"DeleteAccessKey AKIA1",
+		"DeleteSigningCertificate CERT1",
+		"DeleteSSHPublicKey SSH1",
+		"DeleteServiceSpecificCredential CRED1",
+		"DeactivateMFADevice arn:aws:iam::012345678910:mfa/foo-user",
+		"DeleteVirtualMFADevice arn:aws:iam::012345678910:mfa/foo-user",
+		"DeactivateMFADevice GAHT12345678",
+		"DeleteLoginProfile",
+		"DeleteUserPolicy inline1",
+		"DetachUserPolicy arn:aws:iam::012345678910:policy/foo-BktRWPlc",
+		"RemoveUserFromGroup foo-BktRWGrp",
+		"DeleteUser foo-user",
+	}
+
+	if !reflect.DeepEqual(expected, m.calls) {
+		t.Errorf("expected calls %v, got %v", expected, m.calls)
+	}
+
+	// a user without a login profile is not an error
+	m = &mockCleanupClient{}
+	i = IAM{Service: m}
+
+	if err := i.DeleteUserAndAttachments(context.TODO(), "foo-user"); err != nil {
+		t.Fatalf("expected nil error without a login profile, got %s", err)
+	}
+
+	if last := m.calls[len(m.calls)-1]; last != "DeleteUser foo-user" {
+		t.Errorf("expected the user to be deleted, got %s", last)
+	}
+
+	m.deleteErr = awserr.New(iam.ErrCodeDeleteConflictException, "conflict", nil)
+	err := i.DeleteUserAndAttachments(context.TODO(), "foo-user")
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrConflict {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+
+	err = i.DeleteUserAndAttachments(context.TODO(), "")
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrBadRequest {
+		t.Errorf("expected bad request error for an empty user name, got %v", err)
+	}
+}
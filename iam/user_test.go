@@ -5,12 +5,15 @@ import (
 	"errors"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
 )
 
 var testUser = iam.User{
@@ -217,6 +220,13 @@ func (m *mockIAMClient) DeleteAccessKeyWithContext(ctx context.Context, input *i
 	return &iam.DeleteAccessKeyOutput{}, nil
 }
 
+func (m *mockIAMClient) UpdateAccessKeyWithContext(ctx context.Context, input *iam.UpdateAccessKeyInput, opts ...request.Option) (*iam.UpdateAccessKeyOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &iam.UpdateAccessKeyOutput{}, nil
+}
+
 func (m *mockIAMClient) ListAccessKeysWithContext(ctx context.Context, input *iam.ListAccessKeysInput, opts ...request.Option) (*iam.ListAccessKeysOutput, error) {
 	if m.err != nil {
 		return nil, m.err
@@ -597,6 +607,76 @@ func TestGetUser(t *testing.T) {
 	}
 }
 
+// mockListUsersClient returns the users one page at a time and records the path prefixes they're listed with
+type mockListUsersClient struct {
+	iamiface.IAMAPI
+	users    []*iam.User
+	prefixes []string
+	err      error
+}
+
+func (m *mockListUsersClient) ListUsersWithContext(ctx context.Context, input *iam.ListUsersInput, opts ...request.Option) (*iam.ListUsersOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	m.prefixes = append(m.prefixes, aws.StringValue(input.PathPrefix))
+
+	next := 0
+	for input.Marker != nil && aws.StringValue(m.users[next].UserName) != aws.StringValue(input.Marker) {
+		next++
+	}
+
+	if next+1 < len(m.users) {
+		return &iam.ListUsersOutput{Users: m.users[next : next+1], IsTruncated: aws.Bool(true), Marker: m.users[next+1].UserName}, nil
+	}
+	return &iam.ListUsersOutput{Users: m.users[next:]}, nil
+}
+
+func TestListUsers(t *testing.T) {
+	users := []*iam.User{
+		{UserName: aws.String("foo-user1"), Path: aws.String("/spinup/test/")},
+		{UserName: aws.String("foo-user2"), Path: aws.String("/spinup/test/docs/")},
+		{UserName: aws.String("bar-user1"), Path: aws.String("/spinup/test/")},
+	}
+
+	m := &mockListUsersClient{users: users}
+	i := IAM{Service: m, PathPrefix: "/spinup/test/"}
+
+	out, err := i.ListUsers(context.TODO(), &iam.ListUsersInput{})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if !reflect.DeepEqual(users, out) {
+		t.Errorf("expected %+v, got %+v", users, out)
+	}
+
+	if expected := []string{"/spinup/test/", "/spinup/test/", "/spinup/test/"}; !reflect.DeepEqual(expected, m.prefixes) {
+		t.Errorf("expected users to be listed under the path prefix %v, got %v", expected, m.prefixes)
+	}
+
+	m.prefixes = nil
+	if _, err := i.ListUsers(context.TODO(), &iam.ListUsersInput{PathPrefix: aws.String("/other/")}); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if m.prefixes[0] != "/other/" {
+		t.Errorf("expected an explicit path prefix to be left as is, got %s", m.prefixes[0])
+	}
+
+	_, err = i.ListUsers(context.TODO(), nil)
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrBadRequest {
+		t.Errorf("expected bad request error for nil input, got %v", err)
+	}
+
+	m.err = awserr.New(iam.ErrCodeServiceFailureException, "failed", nil)
+	_, err = i.ListUsers(context.TODO(), &iam.ListUsersInput{})
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrServiceUnavailable {
+		t.Errorf("expected service unavailable error, got %v", err)
+	}
+}
+
 func TestFilterDuplicateUsers(t *testing.T) {
 	expected := []*iam.User{
 		{
@@ -1340,3 +1420,324 @@ func TestDetachUserPolicy(t *testing.T) {
 		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
 	}
 }
+
+func (m *mockIAMClient) AttachUserPolicyWithContext(ctx context.Context, input *iam.AttachUserPolicyInput, opts ...request.Option) (*iam.AttachUserPolicyOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &iam.AttachUserPolicyOutput{}, nil
+}
+
+func TestAttachUserPolicy(t *testing.T) {
+	i := IAM{Service: newMockIAMClient(t, nil)}
+	username := aws.String("testuser")
+	policyarn := aws.String("arn:aws:iam::aws:policy/IAMUserChangePassword")
+
+	// test success
+	err := i.AttachUserPolicy(context.TODO(), &iam.AttachUserPolicyInput{UserName: username, PolicyArn: policyarn})
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	// test nil input
+	err = i.AttachUserPolicy(context.TODO(), nil)
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrBadRequest {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrBadRequest, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+
+	// test empty policyarn
+	err = i.AttachUserPolicy(context.TODO(), &iam.AttachUserPolicyInput{UserName: username})
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrBadRequest {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrBadRequest, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+
+	// test ErrCodeNoSuchEntityException
+	i.Service.(*mockIAMClient).err = awserr.New(iam.ErrCodeNoSuchEntityException, "not found", nil)
+	err = i.AttachUserPolicy(context.TODO(), &iam.AttachUserPolicyInput{UserName: username, PolicyArn: policyarn})
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrNotFound {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrNotFound, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+
+	// test non-aws error
+	i.Service.(*mockIAMClient).err = errors.New("things blowing up!")
+	err = i.AttachUserPolicy(context.TODO(), &iam.AttachUserPolicyInput{UserName: username, PolicyArn: policyarn})
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrInternalError {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrInternalError, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+}
+
+func TestUpdateAccessKey(t *testing.T) {
+	i := IAM{Service: newMockIAMClient(t, nil)}
+
+	// test success
+	if err := i.UpdateAccessKey(context.TODO(), &iam.UpdateAccessKeyInput{
+		UserName:    aws.String("testuser"),
+		AccessKeyId: aws.String("SOMEACCESSKEYID"),
+		Status:      aws.String(iam.StatusTypeInactive),
+	}); err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	// test invalid input
+	for _, input := range []*iam.UpdateAccessKeyInput{
+		nil,
+		{},
+		{UserName: aws.String("testuser"), AccessKeyId: aws.String("SOMEACCESSKEYID")},
+	} {
+		err := i.UpdateAccessKey(context.TODO(), input)
+		if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrBadRequest {
+			t.Errorf("expected error code %s, got: %v", apierror.ErrBadRequest, err)
+		}
+	}
+
+	// test ErrCodeNoSuchEntityException
+	i.Service.(*mockIAMClient).err = awserr.New(iam.ErrCodeNoSuchEntityException, "not found", nil)
+	err := i.UpdateAccessKey(context.TODO(), &iam.UpdateAccessKeyInput{
+		UserName:    aws.String("testuser"),
+		AccessKeyId: aws.String("SOMEACCESSKEYID"),
+		Status:      aws.String(iam.StatusTypeActive),
+	})
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrNotFound {
+		t.Errorf("expected error code %s, got: %v", apierror.ErrNotFound, err)
+	}
+}
+
+// mockAccessKeysClient is a fake IAM client with a user's access keys and when they were last used
+type mockAccessKeysClient struct {
+	iamiface.IAMAPI
+	keys     []*iam.AccessKeyMetadata
+	lastUsed map[string]time.Time
+	updated  []string
+}
+
+func (m *mockAccessKeysClient) UpdateAccessKeyWithContext(ctx context.Context, input *iam.UpdateAccessKeyInput, opts ...request.Option) (*iam.UpdateAccessKeyOutput, error) {
+	m.updated = append(m.updated, aws.StringValue(input.AccessKeyId)+" "+aws.StringValue(input.Status))
+	return &iam.UpdateAccessKeyOutput{}, nil
+}
+
+func (m *mockAccessKeysClient) ListAccessKeysWithContext(ctx context.Context, input *iam.ListAccessKeysInput, opts ...request.Option) (*iam.ListAccessKeysOutput, error) {
+	return &iam.ListAccessKeysOutput{AccessKeyMetadata: m.keys}, nil
+}
+
+func (m *mockAccessKeysClient) GetAccessKeyLastUsedWithContext(ctx context.Context, input *iam.GetAccessKeyLastUsedInput, opts ...request.Option) (*iam.GetAccessKeyLastUsedOutput, error) {
+	id := aws.StringValue(input.AccessKeyId)
+	for _, k := range m.keys {
+		if aws.StringValue(k.AccessKeyId) != id {
+			continue
+		}
+
+		used := &iam.AccessKeyLastUsed{Region: aws.String("N/A"), ServiceName: aws.String("N/A")}
+		if t, ok := m.lastUsed[id]; ok {
+			used.LastUsedDate = aws.Time(t)
+		}
+
+		return &iam.GetAccessKeyLastUsedOutput{AccessKeyLastUsed: used, UserName: k.UserName}, nil
+	}
+
+	return nil, awserr.New(iam.ErrCodeNoSuchEntityException, "not found", nil)
+}
+
+func TestGetAccessKeyLastUsed(t *testing.T) {
+	used := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	client := &mockAccessKeysClient{
+		keys:     []*iam.AccessKeyMetadata{{AccessKeyId: aws.String("KEY1")}, {AccessKeyId: aws.String("KEY2")}},
+		lastUsed: map[string]time.Time{"KEY1": used},
+	}
+	i := IAM{Service: client}
+
+	out, err := i.GetAccessKeyLastUsed(context.TODO(), "KEY1")
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if out == nil || !out.Equal(used) {
+		t.Errorf("expected last used %s, got %v", used, out)
+	}
+
+	// test a key that's never been used
+	if out, err := i.GetAccessKeyLastUsed(context.TODO(), "KEY2"); err != nil || out != nil {
+		t.Errorf("expected nil last used and nil error, got %v, %v", out, err)
+	}
+
+	// test empty key id
+	if _, err := i.GetAccessKeyLastUsed(context.TODO(), ""); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Errorf("expected error code %s, got: %v", apierror.ErrBadRequest, err)
+	}
+
+	// test missing key
+	if _, err := i.GetAccessKeyLastUsed(context.TODO(), "KEY3"); !common.IsErrorCode(err, apierror.ErrNotFound) {
+		t.Errorf("expected error code %s, got: %v", apierror.ErrNotFound, err)
+	}
+}
+
+func TestOldestInactiveAccessKey(t *testing.T) {
+	older := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	key := func(id, status string, created time.Time) *iam.AccessKeyMetadata {
+		return &iam.AccessKeyMetadata{AccessKeyId: aws.String(id), Status: aws.String(status), CreateDate: aws.Time(created), UserName: aws.String("testuser")}
+	}
+
+	tests := []struct {
+		name     string
+		keys     []*iam.AccessKeyMetadata
+		lastUsed map[string]time.Time
+		expected string
+	}{
+		{
+			name:     "no inactive keys",
+			keys:     []*iam.AccessKeyMetadata{key("KEY1", "Active", older), key("KEY2", "Active", newer)},
+			expected: "",
+		},
+		{
+			name:     "only inactive key",
+			keys:     []*iam.AccessKeyMetadata{key("KEY1", "Active", older), key("KEY2", "Inactive", newer)},
+			lastUsed: map[string]time.Time{"KEY1": newer, "KEY2": newer},
+			expected: "KEY2",
+		},
+		{
+			name:     "least recently used",
+			keys:     []*iam.AccessKeyMetadata{key("KEY1", "Inactive", older), key("KEY2", "Inactive", older)},
+			lastUsed: map[string]time.Time{"KEY1": newer, "KEY2": older},
+			expected: "KEY2",
+		},
+		{
+			name:     "never used",
+			keys:     []*iam.AccessKeyMetadata{key("KEY1", "Inactive", older), key("KEY2", "Inactive", newer)},
+			lastUsed: map[string]time.Time{"KEY1": older},
+			expected: "KEY2",
+		},
+		{
+			name:     "oldest created",
+			keys:     []*iam.AccessKeyMetadata{key("KEY1", "Inactive", newer), key("KEY2", "Inactive", older)},
+			expected: "KEY2",
+		},
+	}
+
+	for _, test := range tests {
+		i := IAM{Service: &mockAccessKeysClient{keys: test.keys, lastUsed: test.lastUsed}}
+
+		out, err := i.OldestInactiveAccessKey(context.TODO(), "testuser")
+		if err != nil {
+			t.Errorf("%s: expected nil error, got: %s", test.name, err)
+			continue
+		}
+
+		id := ""
+		if out != nil {
+			id = aws.StringValue(out.AccessKeyId)
+		}
+
+		if id != test.expected {
+			t.Errorf("%s: expected key %q, got %q", test.name, test.expected, id)
+		}
+	}
+}
+
+func TestUserLastActivity(t *testing.T) {
+	created := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	keyCreated := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	used := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	password := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	key := func(id string, created time.Time) *iam.AccessKeyMetadata {
+		return &iam.AccessKeyMetadata{AccessKeyId: aws.String(id), Status: aws.String("Active"), CreateDate: aws.Time(created), UserName: aws.String("testuser")}
+	}
+
+	tests := []struct {
+		name     string
+		user     *iam.User
+		keys     []*iam.AccessKeyMetadata
+		lastUsed map[string]time.Time
+		expected time.Time
+	}{
+		{
+			name:     "no keys",
+			user:     &iam.User{UserName: aws.String("testuser"), CreateDate: aws.Time(created)},
+			expected: created,
+		},
+		{
+			name:     "unused key",
+			user:     &iam.User{UserName: aws.String("testuser"), CreateDate: aws.Time(created)},
+			keys:     []*iam.AccessKeyMetadata{key("KEY1", keyCreated)},
+			expected: keyCreated,
+		},
+		{
+			name:     "used key",
+			user:     &iam.User{UserName: aws.String("testuser"), CreateDate: aws.Time(created)},
+			keys:     []*iam.AccessKeyMetadata{key("KEY1", created), key("KEY2", keyCreated)},
+			lastUsed: map[string]time.Time{"KEY1": used},
+			expected: used,
+		},
+		{
+			name:     "password",
+			user:     &iam.User{UserName: aws.String("testuser"), CreateDate: aws.Time(created), PasswordLastUsed: aws.Time(password)},
+			keys:     []*iam.AccessKeyMetadata{key("KEY1", created)},
+			lastUsed: map[string]time.Time{"KEY1": used},
+			expected: password,
+		},
+	}
+
+	for _, test := range tests {
+		i := IAM{Service: &mockAccessKeysClient{keys: test.keys, lastUsed: test.lastUsed}}
+
+		out, err := i.UserLastActivity(context.TODO(), test.user)
+		if err != nil {
+			t.Errorf("%s: expected nil error, got: %s", test.name, err)
+			continue
+		}
+
+		if !out.Equal(test.expected) {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, out)
+		}
+	}
+
+	i := IAM{Service: &mockAccessKeysClient{}}
+	if _, err := i.UserLastActivity(context.TODO(), &iam.User{}); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Errorf("expected error code %s, got: %v", apierror.ErrBadRequest, err)
+	}
+}
+
+func TestDeactivateAccessKeys(t *testing.T) {
+	client := &mockAccessKeysClient{
+		keys: []*iam.AccessKeyMetadata{
+			{AccessKeyId: aws.String("KEY1"), Status: aws.String("Active")},
+			{AccessKeyId: aws.String("KEY2"), Status: aws.String("Inactive")},
+			{AccessKeyId: aws.String("KEY3"), Status: aws.String("Active")},
+		},
+	}
+	i := IAM{Service: client}
+
+	out, err := i.DeactivateAccessKeys(context.TODO(), "testuser")
+	if err != nil {
+		t.Fatalf("expected nil error, got: %s", err)
+	}
+
+	if !reflect.DeepEqual([]string{"KEY1", "KEY3"}, out) {
+		t.Errorf("expected KEY1 and KEY3 to be deactivated, got %v", out)
+	}
+
+	if !reflect.DeepEqual([]string{"KEY1 Inactive", "KEY3 Inactive"}, client.updated) {
+		t.Errorf("expected the active keys to be updated, got %v", client.updated)
+	}
+
+	if _, err := i.DeactivateAccessKeys(context.TODO(), ""); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Errorf("expected error code %s, got: %v", apierror.ErrBadRequest, err)
+	}
+}
@@ -0,0 +1,85 @@
+package lock
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/YaleSpinup/s3-api/storage"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	log "github.com/sirupsen/logrus"
+)
+
+// DynamoDBBackend keeps the locks in a dynamodb table so they're shared by all of the instances of the api.  The
+// table's partition key is the string attribute Name, and the Expires attribute (unix seconds) can be used as the
+// table's TTL attribute to clean up the locks that weren't released.
+type DynamoDBBackend struct {
+	Service dynamodbiface.DynamoDBAPI
+	Table   string
+}
+
+// NewDynamoDBBackend creates a new dynamodb lock backend for the table
+func NewDynamoDBBackend(sess *session.Session, account common.Account, table string) *DynamoDBBackend {
+	d := &DynamoDBBackend{Table: table}
+	if sess == nil {
+		log.Infof("creating new aws session for dynamodb with key id %s in region %s", account.Akid, account.Region)
+		sess = session.Must(session.NewSession(&aws.Config{
+			Credentials: credentials.NewStaticCredentials(account.Akid, account.Secret, ""),
+			Region:      aws.String(account.Region),
+		}))
+	}
+	d.Service = dynamodb.New(sess)
+	return d
+}
+
+// TryAcquire puts the lock item for name on the condition that it doesn't exist or it's expired
+func (d *DynamoDBBackend) TryAcquire(ctx context.Context, name, token string, expires time.Time) (bool, error) {
+	_, err := d.Service.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(d.Table),
+		Item: map[string]*dynamodb.AttributeValue{
+			"Name":    {S: aws.String(name)},
+			"Token":   {S: aws.String(token)},
+			"Expires": {N: aws.String(strconv.FormatInt(expires.Unix(), 10))},
+		},
+		ConditionExpression:      aws.String("attribute_not_exists(#name) OR #expires < :now"),
+		ExpressionAttributeNames: map[string]*string{"#name": aws.String("Name"), "#expires": aws.String("Expires")},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":now": {N: aws.String(strconv.FormatInt(time.Now().Unix(), 10))},
+		},
+	})
+	if err != nil {
+		if storage.ConditionFailed(err) {
+			return false, nil
+		}
+		return false, ErrCode("failed to acquire lock on "+name, err)
+	}
+
+	return true, nil
+}
+
+// Release deletes the lock item for name on the condition that it's held with the token
+func (d *DynamoDBBackend) Release(ctx context.Context, name, token string) error {
+	_, err := d.Service.DeleteItemWithContext(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(d.Table),
+		Key: map[string]*dynamodb.AttributeValue{
+			"Name": {S: aws.String(name)},
+		},
+		ConditionExpression:       aws.String("#token = :token"),
+		ExpressionAttributeNames:  map[string]*string{"#token": aws.String("Token")},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":token": {S: aws.String(token)}},
+	})
+	if err != nil {
+		// the lock expired and was taken by another operation
+		if storage.ConditionFailed(err) {
+			return nil
+		}
+		return ErrCode("failed to release lock on "+name, err)
+	}
+
+	return nil
+}
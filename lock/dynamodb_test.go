@@ -0,0 +1,139 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// mockDynamoDBClient is a fake dynamodb client that evaluates the lock conditions against a map of items
+type mockDynamoDBClient struct {
+	dynamodbiface.DynamoDBAPI
+	t     *testing.T
+	err   error
+	items map[string]map[string]*dynamodb.AttributeValue
+}
+
+func newMockDynamoDBClient(t *testing.T, err error) *mockDynamoDBClient {
+	return &mockDynamoDBClient{
+		t:     t,
+		err:   err,
+		items: map[string]map[string]*dynamodb.AttributeValue{},
+	}
+}
+
+func (m *mockDynamoDBClient) PutItemWithContext(ctx context.Context, input *dynamodb.PutItemInput, opts ...request.Option) (*dynamodb.PutItemOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	name := aws.StringValue(input.Item["Name"].S)
+	if item, ok := m.items[name]; ok {
+		expires, _ := strconv.ParseInt(aws.StringValue(item["Expires"].N), 10, 64)
+		now, _ := strconv.ParseInt(aws.StringValue(input.ExpressionAttributeValues[":now"].N), 10, 64)
+		if expires >= now {
+			return nil, awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "conditional check failed", nil)
+		}
+	}
+
+	m.items[name] = input.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (m *mockDynamoDBClient) DeleteItemWithContext(ctx context.Context, input *dynamodb.DeleteItemInput, opts ...request.Option) (*dynamodb.DeleteItemOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	name := aws.StringValue(input.Key["Name"].S)
+	if item, ok := m.items[name]; !ok || aws.StringValue(item["Token"].S) != aws.StringValue(input.ExpressionAttributeValues[":token"].S) {
+		return nil, awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "conditional check failed", nil)
+	}
+
+	delete(m.items, name)
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func TestNewDynamoDBBackend(t *testing.T) {
+	d := NewDynamoDBBackend(nil, common.Account{}, "locks")
+	if to := reflect.TypeOf(d).String(); to != "*lock.DynamoDBBackend" {
+		t.Errorf("expected type to be '*lock.DynamoDBBackend', got %s", to)
+	}
+}
+
+func TestDynamoDBBackend(t *testing.T) {
+	m := newMockDynamoDBClient(t, nil)
+	d := &DynamoDBBackend{Service: m, Table: "locks"}
+
+	ok, err := d.TryAcquire(context.TODO(), "foobucket", "token1", time.Now().Add(time.Minute))
+	if err != nil || !ok {
+		t.Fatalf("expected to acquire the lock, got %t, %v", ok, err)
+	}
+
+	ok, err = d.TryAcquire(context.TODO(), "foobucket", "token2", time.Now().Add(time.Minute))
+	if err != nil || ok {
+		t.Errorf("expected the lock to be held, got %t, %v", ok, err)
+	}
+
+	// releasing with another token leaves the lock in place
+	if err := d.Release(context.TODO(), "foobucket", "token2"); err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	if _, ok := m.items["foobucket"]; !ok {
+		t.Error("expected the lock to still be held")
+	}
+
+	if err := d.Release(context.TODO(), "foobucket", "token1"); err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	if _, ok := m.items["foobucket"]; ok {
+		t.Error("expected the lock to be released")
+	}
+
+	// an expired lock can be taken
+	m.items["barbucket"] = map[string]*dynamodb.AttributeValue{
+		"Name":    {S: aws.String("barbucket")},
+		"Token":   {S: aws.String("token1")},
+		"Expires": {N: aws.String("1")},
+	}
+
+	ok, err = d.TryAcquire(context.TODO(), "barbucket", "token2", time.Now().Add(time.Minute))
+	if err != nil || !ok {
+		t.Errorf("expected to acquire the expired lock, got %t, %v", ok, err)
+	}
+
+	// test missing table
+	m.err = awserr.New(dynamodb.ErrCodeResourceNotFoundException, "table not found", nil)
+	_, err = d.TryAcquire(context.TODO(), "foobucket", "token1", time.Now().Add(time.Minute))
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrNotFound {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrNotFound, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+
+	// test non-aws error
+	m.err = errors.New("things blowing up!")
+	err = d.Release(context.TODO(), "foobucket", "token1")
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrInternalError {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrInternalError, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+}
@@ -0,0 +1,136 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultTTL is how long a lock is held if it isn't released, ie. when the instance holding it crashes
+	DefaultTTL = 5 * time.Minute
+	// DefaultWait is how long to wait for a lock that's held by another operation
+	DefaultWait = 30 * time.Second
+	// DefaultPoll is how often a held lock is retried while waiting
+	DefaultPoll = 500 * time.Millisecond
+)
+
+// Backend keeps the locks.  TryAcquire takes the lock on name with the token if it isn't held or the current
+// holder's lock has expired and returns false if it's held.  Release releases the lock only if it's held with
+// the token, so a lock that expired and was taken by another operation isn't released.
+type Backend interface {
+	TryAcquire(ctx context.Context, name, token string, expires time.Time) (bool, error)
+	Release(ctx context.Context, name, token string) error
+}
+
+// Locker serializes operations on the same resource name with leases from a backend
+type Locker struct {
+	backend Backend
+	ttl     time.Duration
+	wait    time.Duration
+	poll    time.Duration
+}
+
+type LockerOption func(*Locker)
+
+// New creates a new locker with the backend
+func New(backend Backend, opts ...LockerOption) *Locker {
+	l := &Locker{
+		backend: backend,
+		ttl:     DefaultTTL,
+		wait:    DefaultWait,
+		poll:    DefaultPoll,
+	}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l
+}
+
+// WithTTL sets how long a lock is held if it isn't released
+func WithTTL(ttl time.Duration) LockerOption {
+	return func(l *Locker) {
+		log.Debugf("setting lock ttl to %s", ttl)
+		l.ttl = ttl
+	}
+}
+
+// WithWait sets how long to wait for a lock that's held by another operation
+func WithWait(wait time.Duration) LockerOption {
+	return func(l *Locker) {
+		log.Debugf("setting lock wait to %s", wait)
+		l.wait = wait
+	}
+}
+
+// WithPoll sets how often a held lock is retried while waiting
+func WithPoll(poll time.Duration) LockerOption {
+	return func(l *Locker) {
+		l.poll = poll
+	}
+}
+
+// Lease is a lock held on a resource name
+type Lease struct {
+	Name    string
+	Expires time.Time
+	token   string
+	backend Backend
+}
+
+// Acquire takes the lock on the name, waiting for it if it's held by another operation.  It returns a conflict
+// error if the lock isn't released within the wait.  A nil locker doesn't lock anything.
+func (l *Locker) Acquire(ctx context.Context, name string) (*Lease, error) {
+	if l == nil {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, l.wait)
+	defer cancel()
+
+	token := uuid.New().String()
+	for {
+		expires := time.Now().Add(l.ttl)
+		ok, err := l.backend.TryAcquire(ctx, name, token, expires)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			log.Debugf("acquired lock on %s until %s", name, expires)
+			return &Lease{Name: name, Expires: expires, token: token, backend: l.backend}, nil
+		}
+
+		log.Debugf("lock on %s is held, waiting", name)
+
+		select {
+		case <-ctx.Done():
+			msg := fmt.Sprintf("another operation on %s is in progress", name)
+			return nil, apierror.New(apierror.ErrConflict, msg, ctx.Err())
+		case <-time.After(l.poll):
+		}
+	}
+}
+
+// Release releases the lease, failures are logged since the lock expires anyway.  Releasing a nil lease is a no-op.
+func (l *Lease) Release() {
+	if l == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := l.backend.Release(ctx, l.Name, l.token); err != nil {
+		log.Warnf("failed to release lock on %s, it will expire at %s: %s", l.Name, l.Expires, err)
+		return
+	}
+
+	log.Debugf("released lock on %s", l.Name)
+}
@@ -0,0 +1,121 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+)
+
+func TestAcquireAndRelease(t *testing.T) {
+	l := New(NewMemoryBackend(), WithWait(50*time.Millisecond), WithPoll(5*time.Millisecond))
+
+	lease, err := l.Acquire(context.TODO(), "foobucket")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if lease.Name != "foobucket" {
+		t.Errorf("expected lease name foobucket, got %s", lease.Name)
+	}
+
+	// a different name isn't locked
+	other, err := l.Acquire(context.TODO(), "barbucket")
+	if err != nil {
+		t.Errorf("expected nil error for a different name, got %s", err)
+	}
+	other.Release()
+
+	// the held name times out with a conflict
+	_, err = l.Acquire(context.TODO(), "foobucket")
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrConflict {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+
+	lease.Release()
+
+	lease, err = l.Acquire(context.TODO(), "foobucket")
+	if err != nil {
+		t.Errorf("expected nil error after release, got %s", err)
+	}
+	lease.Release()
+}
+
+func TestAcquireWaits(t *testing.T) {
+	l := New(NewMemoryBackend(), WithWait(time.Second), WithPoll(5*time.Millisecond))
+
+	var mu sync.Mutex
+	running := 0
+	wg := sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			lease, err := l.Acquire(context.TODO(), "foobucket")
+			if err != nil {
+				t.Errorf("expected nil error, got %s", err)
+				return
+			}
+			defer lease.Release()
+
+			mu.Lock()
+			running++
+			if running > 1 {
+				t.Errorf("expected operations to be serialized, got %d running", running)
+			}
+			mu.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestAcquireExpired(t *testing.T) {
+	l := New(NewMemoryBackend(), WithTTL(10*time.Millisecond), WithWait(time.Second), WithPoll(5*time.Millisecond))
+
+	// the first lease is never released
+	if _, err := l.Acquire(context.TODO(), "foobucket"); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	lease, err := l.Acquire(context.TODO(), "foobucket")
+	if err != nil {
+		t.Errorf("expected nil error once the lock expired, got %s", err)
+	}
+	lease.Release()
+}
+
+func TestNilLocker(t *testing.T) {
+	var l *Locker
+	lease, err := l.Acquire(context.TODO(), "foobucket")
+	if err != nil || lease != nil {
+		t.Errorf("expected nil lease and error from a nil locker, got %v, %v", lease, err)
+	}
+
+	// releasing a nil lease doesn't panic
+	lease.Release()
+}
+
+func TestMemoryBackendRelease(t *testing.T) {
+	m := NewMemoryBackend()
+	if ok, _ := m.TryAcquire(context.TODO(), "foobucket", "token1", time.Now().Add(time.Minute)); !ok {
+		t.Fatal("expected to acquire the lock")
+	}
+
+	// releasing with another token leaves the lock in place
+	if err := m.Release(context.TODO(), "foobucket", "token2"); err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	if ok, _ := m.TryAcquire(context.TODO(), "foobucket", "token2", time.Now().Add(time.Minute)); ok {
+		t.Error("expected the lock to still be held")
+	}
+}
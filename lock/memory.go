@@ -0,0 +1,49 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryBackend keeps the locks in memory, they're only shared by the operations in a single instance of the api
+type MemoryBackend struct {
+	mu     sync.Mutex
+	leases map[string]memoryLease
+}
+
+type memoryLease struct {
+	token   string
+	expires time.Time
+}
+
+// NewMemoryBackend creates a new in memory lock backend
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{leases: map[string]memoryLease{}}
+}
+
+// TryAcquire takes the lock on name if it isn't held or it's expired
+func (m *MemoryBackend) TryAcquire(ctx context.Context, name, token string, expires time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if l, ok := m.leases[name]; ok && time.Now().Before(l.expires) {
+		return false, nil
+	}
+
+	m.leases[name] = memoryLease{token: token, expires: expires}
+
+	return true, nil
+}
+
+// Release releases the lock on name if it's held with the token
+func (m *MemoryBackend) Release(ctx context.Context, name, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if l, ok := m.leases[name]; ok && l.token == token {
+		delete(m.leases, name)
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package lock
+
+import (
+	"context"
+	"time"
+
+	"github.com/YaleSpinup/s3-api/storage"
+)
+
+// storeTable is the table the locks are kept in
+const storeTable = "locks"
+
+// StoreBackend keeps the locks in the api's state store, the value of each lock is the token it's held with
+type StoreBackend struct {
+	store storage.Store
+}
+
+// NewStoreBackend creates a new lock backend on the state store
+func NewStoreBackend(store storage.Store) *StoreBackend {
+	return &StoreBackend{store: store}
+}
+
+// TryAcquire puts the token for name if it isn't held or the lock has expired
+func (s *StoreBackend) TryAcquire(ctx context.Context, name, token string, expires time.Time) (bool, error) {
+	return s.store.PutIfAbsent(ctx, storeTable, name, []byte(token), expires)
+}
+
+// Release deletes the lock for name only if it's held with the token
+func (s *StoreBackend) Release(ctx context.Context, name, token string) error {
+	_, err := s.store.DeleteIf(ctx, storeTable, name, []byte(token))
+	return err
+}
@@ -0,0 +1,44 @@
+package lock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/s3-api/storage"
+)
+
+func TestStoreBackend(t *testing.T) {
+	b := NewStoreBackend(storage.NewMemoryStore())
+
+	ok, err := b.TryAcquire(context.TODO(), "foobucket", "token1", time.Now().Add(time.Minute))
+	if err != nil || !ok {
+		t.Fatalf("expected to acquire the lock, got %t (%v)", ok, err)
+	}
+
+	if ok, _ := b.TryAcquire(context.TODO(), "foobucket", "token2", time.Now().Add(time.Minute)); ok {
+		t.Error("expected a held lock not to be acquired")
+	}
+
+	// releasing with the wrong token leaves the lock held
+	if err := b.Release(context.TODO(), "foobucket", "token2"); err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	if ok, _ := b.TryAcquire(context.TODO(), "foobucket", "token2", time.Now().Add(time.Minute)); ok {
+		t.Error("expected the lock to still be held after releasing with the wrong token")
+	}
+
+	if err := b.Release(context.TODO(), "foobucket", "token1"); err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	if ok, _ := b.TryAcquire(context.TODO(), "foobucket", "token2", time.Now().Add(-time.Second)); !ok {
+		t.Error("expected the released lock to be acquired")
+	}
+
+	// the lock above expired as soon as it was taken
+	if ok, _ := b.TryAcquire(context.TODO(), "foobucket", "token3", time.Now().Add(time.Minute)); !ok {
+		t.Error("expected an expired lock to be acquired")
+	}
+}
@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/YaleSpinup/s3-api/common"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// FormatText is logrus' default text format
+	FormatText = "text"
+	// FormatJSON logs each entry as a json object, with the fields as keys
+	FormatJSON = "json"
+)
+
+// Configure sets the log formatter from the logging configuration, the text formatter is kept if it's not set
+func Configure(config *common.Logging) error {
+	if config == nil {
+		return nil
+	}
+
+	var formatter log.Formatter
+	switch config.Format {
+	case "", FormatText:
+		formatter = &log.TextFormatter{}
+	case FormatJSON:
+		formatter = &log.JSONFormatter{TimestampFormat: time.RFC3339Nano}
+	default:
+		return fmt.Errorf("invalid log format %q, must be %s or %s", config.Format, FormatText, FormatJSON)
+	}
+
+	if config.DebugSampleRate < 0 {
+		return fmt.Errorf("invalid debug sample rate %d, must be 0 or more", config.DebugSampleRate)
+	}
+
+	if config.DebugSampleRate > 1 {
+		log.Infof("sampling 1 of every %d debug log entries", config.DebugSampleRate)
+		formatter = NewSamplingFormatter(formatter, config.DebugSampleRate)
+	}
+
+	log.SetFormatter(formatter)
+
+	return nil
+}
+
+// SamplingFormatter only formats one of every rate debug and trace entries, the others are dropped.  Entries at
+// info and above are always formatted.
+type SamplingFormatter struct {
+	formatter log.Formatter
+	rate      uint64
+	count     uint64
+}
+
+// NewSamplingFormatter wraps a formatter with debug sampling
+func NewSamplingFormatter(formatter log.Formatter, rate int) *SamplingFormatter {
+	return &SamplingFormatter{
+		formatter: formatter,
+		rate:      uint64(rate),
+	}
+}
+
+// Format formats the entry with the wrapped formatter, or returns nothing if a debug entry isn't sampled
+func (f *SamplingFormatter) Format(e *log.Entry) ([]byte, error) {
+	if e.Level >= log.DebugLevel && f.rate > 1 {
+		if n := atomic.AddUint64(&f.count, 1); (n-1)%f.rate != 0 {
+			return nil, nil
+		}
+	}
+
+	return f.formatter.Format(e)
+}
@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/YaleSpinup/s3-api/common"
+	log "github.com/sirupsen/logrus"
+)
+
+func TestConfigure(t *testing.T) {
+	defer log.SetFormatter(&log.TextFormatter{})
+
+	if err := Configure(nil); err != nil {
+		t.Errorf("expected nil error for nil config, got %s", err)
+	}
+
+	if err := Configure(&common.Logging{Format: "xml"}); err == nil {
+		t.Error("expected error for invalid format, got nil")
+	}
+
+	if err := Configure(&common.Logging{Format: FormatJSON, DebugSampleRate: -1}); err == nil {
+		t.Error("expected error for negative sample rate, got nil")
+	}
+
+	if err := Configure(&common.Logging{Format: FormatJSON}); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if _, ok := log.StandardLogger().Formatter.(*log.JSONFormatter); !ok {
+		t.Errorf("expected json formatter, got %T", log.StandardLogger().Formatter)
+	}
+
+	if err := Configure(&common.Logging{Format: FormatJSON, DebugSampleRate: 10}); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if _, ok := log.StandardLogger().Formatter.(*SamplingFormatter); !ok {
+		t.Errorf("expected sampling formatter, got %T", log.StandardLogger().Formatter)
+	}
+}
+
+func TestSamplingFormatter(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := log.New()
+	logger.Out = out
+	logger.Level = log.DebugLevel
+	logger.Formatter = NewSamplingFormatter(&log.JSONFormatter{}, 3)
+
+	for i := 0; i < 6; i++ {
+		logger.WithField("account", "spinup").Debugf("debug %d", i)
+	}
+	logger.Info("info")
+	logger.Warn("warn")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected 2 sampled debug and 2 other entries, got %d: %s", len(lines), out.String())
+	}
+
+	expected := []string{"debug 0", "debug 3", "info", "warn"}
+	for i, line := range lines {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("expected json log entry, got %s", line)
+		}
+
+		if entry["msg"] != expected[i] {
+			t.Errorf("expected message %s, got %v", expected[i], entry["msg"])
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/YaleSpinup/s3-api/common"
 	"github.com/YaleSpinup/s3-api/api"
+	"github.com/YaleSpinup/s3-api/logging"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -72,6 +73,10 @@ func main() {
 		log.SetLevel(log.InfoLevel)
 	}
 
+	if err := logging.Configure(config.Logging); err != nil {
+		log.Fatalf("Unable to configure logging.  %s", err)
+	}
+
 	if config.LogLevel == "debug" {
 		log.Debug("Starting profiler on 127.0.0.1:6080")
 		go http.ListenAndServe("127.0.0.1:6080", nil)
@@ -0,0 +1,110 @@
+package openapi
+
+import (
+	"sort"
+	"strings"
+)
+
+// JSONSchemaVersion is the JSON schema draft of the standalone schemas, the one most client generators support
+const JSONSchemaVersion = "http://json-schema.org/draft-07/schema#"
+
+// definitionsRef is the prefix of the references to the definitions of a standalone schema
+const definitionsRef = "#/definitions/"
+
+// JSONSchema is a standalone JSON schema document for a type, with the definitions of the types it references
+type JSONSchema struct {
+	Version string `json:"$schema"`
+	Title   string `json:"title,omitempty"`
+	*Schema
+	Definitions map[string]*Schema `json:"definitions,omitempty"`
+}
+
+// Schemas generates standalone JSON schemas for the named struct types of the values added to it and the types they
+// reference, using the same rules as the openapi document
+type Schemas struct {
+	definitions map[string]*Schema
+	generator   *schemaGenerator
+}
+
+// NewSchemas creates an empty set of schemas
+func NewSchemas() *Schemas {
+	definitions := map[string]*Schema{}
+	g := newSchemaGenerator(definitions)
+	g.prefix = definitionsRef
+
+	return &Schemas{
+		definitions: definitions,
+		generator:   g,
+	}
+}
+
+// Add generates the schemas for the type of the value, values without named struct types don't add anything
+func (s *Schemas) Add(v interface{}) {
+	if v == nil {
+		return
+	}
+	s.generator.schemaFor(v)
+}
+
+// Names returns the sorted names of the schemas, ie. api.Bucket
+func (s *Schemas) Names() []string {
+	names := make([]string, 0, len(s.definitions))
+	for name := range s.definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Schema returns the standalone schema for the named type with the definitions of the types it references
+func (s *Schemas) Schema(name string) (*JSONSchema, bool) {
+	schema, ok := s.definitions[name]
+	if !ok {
+		return nil, false
+	}
+
+	definitions := map[string]*Schema{}
+	s.collect(schema, definitions)
+	if len(definitions) == 0 {
+		definitions = nil
+	}
+
+	return &JSONSchema{
+		Version:     JSONSchemaVersion,
+		Title:       name,
+		Schema:      schema,
+		Definitions: definitions,
+	}, true
+}
+
+// Bundle returns a single schema document with all of the types in its definitions
+func (s *Schemas) Bundle(title string) *JSONSchema {
+	return &JSONSchema{
+		Version:     JSONSchemaVersion,
+		Title:       title,
+		Definitions: s.definitions,
+	}
+}
+
+// collect adds the definitions of the types referenced by the schema, and the types they reference, to out
+func (s *Schemas) collect(schema *Schema, out map[string]*Schema) {
+	if schema == nil {
+		return
+	}
+
+	if schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, definitionsRef)
+		if _, ok := out[name]; !ok {
+			out[name] = s.definitions[name]
+			s.collect(s.definitions[name], out)
+		}
+		return
+	}
+
+	s.collect(schema.Items, out)
+	s.collect(schema.AdditionalProperties, out)
+	for _, p := range schema.Properties {
+		s.collect(p, out)
+	}
+}
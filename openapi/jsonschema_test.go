@@ -0,0 +1,65 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSchemas(t *testing.T) {
+	s := NewSchemas()
+	s.Add([]*testNode{})
+	s.Add(testTag{})
+	s.Add("pong")
+	s.Add(nil)
+
+	if names := s.Names(); !reflect.DeepEqual([]string{"openapi.testNode", "openapi.testTag"}, names) {
+		t.Errorf("expected the node and tag schemas, got %v", names)
+	}
+
+	tag, ok := s.Schema("openapi.testTag")
+	if !ok {
+		t.Fatal("expected the tag schema")
+	}
+
+	if tag.Version != JSONSchemaVersion || tag.Title != "openapi.testTag" || tag.Definitions != nil {
+		t.Errorf("expected a standalone tag schema without definitions, got %+v", tag)
+	}
+
+	node, ok := s.Schema("openapi.testNode")
+	if !ok {
+		t.Fatal("expected the node schema")
+	}
+
+	// the node references itself and the tag
+	if len(node.Definitions) != 2 || node.Definitions["openapi.testNode"] == nil || node.Definitions["openapi.testTag"] == nil {
+		t.Errorf("expected the node and tag definitions, got %+v", node.Definitions)
+	}
+
+	if ref := node.Properties["Tags"].Items.Ref; ref != "#/definitions/openapi.testTag" {
+		t.Errorf("expected a reference to the tag definition, got %s", ref)
+	}
+
+	if _, ok := s.Schema("openapi.missing"); ok {
+		t.Error("expected no schema for a missing type")
+	}
+
+	j, err := json.Marshal(node)
+	if err != nil {
+		t.Fatalf("unexpected error marshalling schema: %s", err)
+	}
+
+	var out map[string]interface{}
+	if err := json.Unmarshal(j, &out); err != nil {
+		t.Fatalf("unexpected error unmarshalling schema: %s", err)
+	}
+
+	if out["$schema"] != JSONSchemaVersion || out["type"] != "object" || out["properties"] == nil || out["definitions"] == nil {
+		t.Errorf("expected the schema properties at the top level, got %v", out)
+	}
+
+	bundle := s.Bundle("test-api")
+	if bundle.Title != "test-api" || bundle.Schema != nil || len(bundle.Definitions) != 2 {
+		t.Errorf("expected a bundle with every definition, got %+v", bundle)
+	}
+}
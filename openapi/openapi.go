@@ -0,0 +1,259 @@
+// Package openapi generates an OpenAPI 3 document describing the api.  Operations are added for each route and the
+// schemas for request and response bodies are generated from the go types, so the document stays in sync with the code.
+package openapi
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version is the version of the OpenAPI specification the document follows
+const Version = "3.0.3"
+
+var pathParamRe = regexp.MustCompile(`{([^}:]+)(:[^}]*)?}`)
+
+// Document is an OpenAPI document
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []map[string][]string `json:"security,omitempty"`
+}
+
+// Info is the metadata about the api
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem is the operations for a path, keyed by lower case http method
+type PathItem map[string]*Operation
+
+// Operation is a single api operation on a path
+type Operation struct {
+	OperationID string              `json:"operationId,omitempty"`
+	Summary     string              `json:"summary,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+	// Security overrides the document security, an empty list means no authentication is required
+	Security *[]map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter is a path or query parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is the body of a request
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is a response from an operation
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType is the schema for a content type
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components are the reusable schemas and security schemes
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describes how requests are authenticated
+type SecurityScheme struct {
+	Type string `json:"type"`
+	In   string `json:"in,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// Route describes the request and response of a route.  Request and Response are values of the go types that are
+// decoded from the request body and encoded in the response body, nil if there isn't a body.
+type Route struct {
+	Summary     string
+	Description string
+	Tags        []string
+	// Query are the query parameters and their descriptions
+	Query map[string]string
+	// Request is a value of the type of the request body
+	Request interface{}
+	// Response is a value of the type of the response body, a string is returned as text/plain
+	Response interface{}
+	// ContentType overrides the content type of the response body
+	ContentType string
+	// Status is the success status code, defaults to 200
+	Status int
+	// Public routes don't require authentication
+	Public bool
+}
+
+// Spec builds an OpenAPI document
+type Spec struct {
+	doc     Document
+	schemas *schemaGenerator
+	auth    string
+}
+
+type SpecOption func(*Spec)
+
+// New creates a new spec with options
+func New(title, version string, opts ...SpecOption) *Spec {
+	s := Spec{
+		doc: Document{
+			OpenAPI: Version,
+			Info: Info{
+				Title:   title,
+				Version: version,
+			},
+			Paths: map[string]PathItem{},
+			Components: Components{
+				Schemas: map[string]*Schema{},
+			},
+		},
+	}
+	s.schemas = newSchemaGenerator(s.doc.Components.Schemas)
+
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	return &s
+}
+
+// WithDescription sets the description of the api
+func WithDescription(description string) SpecOption {
+	return func(s *Spec) {
+		s.doc.Info.Description = description
+	}
+}
+
+// WithHeaderAuth requires an api key in the passed header for all non-public routes
+func WithHeaderAuth(header string) SpecOption {
+	return func(s *Spec) {
+		s.auth = "apiKey"
+		s.doc.Components.SecuritySchemes = map[string]SecurityScheme{
+			s.auth: {Type: "apiKey", In: "header", Name: header},
+		}
+		s.doc.Security = []map[string][]string{{s.auth: {}}}
+	}
+}
+
+// Add adds an operation for the http method and mux path template to the spec
+func (s *Spec) Add(method, path string, route Route) {
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	op := Operation{
+		OperationID: operationID(method, path),
+		Summary:     route.Summary,
+		Description: route.Description,
+		Tags:        route.Tags,
+		Responses: map[string]Response{
+			strconv.Itoa(status): s.response(http.StatusText(status), route.ContentType, route.Response),
+		},
+	}
+
+	if route.Public && s.auth != "" {
+		op.Security = &[]map[string][]string{}
+	}
+
+	for _, m := range pathParamRe.FindAllStringSubmatch(path, -1) {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:     m[1],
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+
+	query := make([]string, 0, len(route.Query))
+	for q := range route.Query {
+		query = append(query, q)
+	}
+	sort.Strings(query)
+
+	for _, q := range query {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:        q,
+			In:          "query",
+			Description: route.Query[q],
+			Schema:      &Schema{Type: "string"},
+		})
+	}
+
+	if route.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]MediaType{
+				"application/json": {Schema: s.schemas.schemaFor(route.Request)},
+			},
+		}
+	}
+
+	// strip any regular expressions from the path parameters
+	path = pathParamRe.ReplaceAllString(path, "{$1}")
+
+	item, ok := s.doc.Paths[path]
+	if !ok {
+		item = PathItem{}
+		s.doc.Paths[path] = item
+	}
+	item[strings.ToLower(method)] = &op
+}
+
+// Document returns the OpenAPI document
+func (s *Spec) Document() *Document {
+	return &s.doc
+}
+
+func (s *Spec) response(description, contentType string, body interface{}) Response {
+	if body == nil {
+		return Response{Description: description}
+	}
+
+	if contentType == "" {
+		contentType = "application/json"
+		if _, ok := body.(string); ok {
+			contentType = "text/plain"
+		}
+	}
+
+	return Response{
+		Description: description,
+		Content: map[string]MediaType{
+			contentType: {Schema: s.schemas.schemaFor(body)},
+		},
+	}
+}
+
+// operationID generates an operation id from the method and path, ie. GET /v1/s3/{account}/buckets -> getV1S3AccountBuckets
+func operationID(method, path string) string {
+	id := strings.ToLower(method)
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) {
+		id += strings.ToUpper(part[:1]) + part[1:]
+	}
+	return id
+}
@@ -0,0 +1,157 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testTag struct {
+	Key   string
+	Value string
+}
+
+type testEmbedded struct {
+	Embedded string
+}
+
+type testNode struct {
+	testEmbedded
+	Name     string `json:"name"`
+	Ignored  string `json:"-"`
+	Optional *int64 `json:",omitempty"`
+	Created  time.Time
+	Data     []byte
+	Tags     []*testTag
+	Labels   map[string]string
+	Children []*testNode
+	Any      interface{}
+
+	unexported string
+}
+
+func TestSchemaFor(t *testing.T) {
+	components := map[string]*Schema{}
+	g := newSchemaGenerator(components)
+
+	s := g.schemaFor([]*testNode{})
+	expected := &Schema{Type: "array", Items: &Schema{Ref: "#/components/schemas/openapi.testNode"}}
+	if !reflect.DeepEqual(expected, s) {
+		t.Errorf("expected %+v, got %+v", expected, s)
+	}
+
+	node, ok := components["openapi.testNode"]
+	if !ok {
+		t.Fatalf("expected openapi.testNode in components, got %v", components)
+	}
+
+	expectedNode := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"Embedded": {Type: "string"},
+			"name":     {Type: "string"},
+			"Optional": {Type: "integer", Format: "int64"},
+			"Created":  {Type: "string", Format: "date-time"},
+			"Data":     {Type: "string", Format: "byte"},
+			"Tags":     {Type: "array", Items: &Schema{Ref: "#/components/schemas/openapi.testTag"}},
+			"Labels":   {Type: "object", AdditionalProperties: &Schema{Type: "string"}},
+			"Children": {Type: "array", Items: &Schema{Ref: "#/components/schemas/openapi.testNode"}},
+			"Any":      {},
+		},
+	}
+	if !reflect.DeepEqual(expectedNode, node) {
+		t.Errorf("expected %+v, got %+v", expectedNode, node)
+	}
+
+	if _, ok := components["openapi.testTag"]; !ok {
+		t.Errorf("expected openapi.testTag in components, got %v", components)
+	}
+
+	// anonymous structs are inlined
+	s = g.schemaFor(struct{ Enabled bool }{})
+	expected = &Schema{Type: "object", Properties: map[string]*Schema{"Enabled": {Type: "boolean"}}}
+	if !reflect.DeepEqual(expected, s) {
+		t.Errorf("expected %+v, got %+v", expected, s)
+	}
+}
+
+func TestSpecAdd(t *testing.T) {
+	spec := New("test-api", "1.0.0", WithHeaderAuth("X-Auth-Token"))
+	spec.Add("GET", "/v1/test/ping", Route{Summary: "ping", Response: "pong", Public: true})
+	spec.Add("POST", "/v1/test/{account}/things", Route{
+		Request:  testTag{},
+		Response: []testTag{},
+		Status:   202,
+		Query:    map[string]string{"dryrun": "dry run", "async": "async"},
+	})
+	spec.Add("DELETE", "/v1/test/{account}/things/{id:[0-9]+}", Route{})
+
+	doc := spec.Document()
+
+	ping := doc.Paths["/v1/test/ping"]["get"]
+	if ping == nil {
+		t.Fatalf("expected get /v1/test/ping operation, got %+v", doc.Paths)
+	}
+
+	if ping.OperationID != "getV1TestPing" {
+		t.Errorf("unexpected operation id %s", ping.OperationID)
+	}
+
+	if ping.Security == nil || len(*ping.Security) != 0 {
+		t.Errorf("expected public route to override security with an empty list")
+	}
+
+	if _, ok := ping.Responses["200"].Content["text/plain"]; !ok {
+		t.Errorf("expected text/plain response, got %+v", ping.Responses)
+	}
+
+	create := doc.Paths["/v1/test/{account}/things"]["post"]
+	if create == nil {
+		t.Fatalf("expected post /v1/test/{account}/things operation, got %+v", doc.Paths)
+	}
+
+	if create.Security != nil {
+		t.Errorf("expected authenticated route to use the document security")
+	}
+
+	var params []string
+	for _, p := range create.Parameters {
+		params = append(params, p.In+":"+p.Name)
+	}
+	if !reflect.DeepEqual([]string{"path:account", "query:async", "query:dryrun"}, params) {
+		t.Errorf("unexpected parameters %v", params)
+	}
+
+	if create.RequestBody == nil || create.RequestBody.Content["application/json"].Schema.Ref != "#/components/schemas/openapi.testTag" {
+		t.Errorf("unexpected request body %+v", create.RequestBody)
+	}
+
+	if _, ok := create.Responses["202"]; !ok {
+		t.Errorf("expected 202 response, got %+v", create.Responses)
+	}
+
+	// regular expressions are stripped from path parameters
+	del := doc.Paths["/v1/test/{account}/things/{id}"]["delete"]
+	if del == nil {
+		t.Fatalf("expected delete /v1/test/{account}/things/{id} operation, got %+v", doc.Paths)
+	}
+
+	if len(del.Responses["200"].Content) != 0 {
+		t.Errorf("expected no response content, got %+v", del.Responses)
+	}
+
+	j, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("unexpected error marshalling document: %s", err)
+	}
+
+	var out map[string]interface{}
+	if err := json.Unmarshal(j, &out); err != nil {
+		t.Fatalf("unexpected error unmarshalling document: %s", err)
+	}
+
+	if out["openapi"] != Version {
+		t.Errorf("expected openapi version %s, got %v", Version, out["openapi"])
+	}
+}
@@ -0,0 +1,141 @@
+package openapi
+
+import (
+	"path"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// componentsRef is the prefix of the references to the schemas in the components of an openapi document
+const componentsRef = "#/components/schemas/"
+
+// Schema is a JSON schema for a value
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// schemaGenerator generates schemas from go types using the same rules as encoding/json.  Named struct types are
+// added to the components and referenced.
+type schemaGenerator struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+	prefix     string
+}
+
+func newSchemaGenerator(components map[string]*Schema) *schemaGenerator {
+	return &schemaGenerator{
+		components: components,
+		names:      map[reflect.Type]string{},
+		prefix:     componentsRef,
+	}
+}
+
+// schemaFor returns the schema for the type of the passed value
+func (g *schemaGenerator) schemaFor(v interface{}) *Schema {
+	return g.schema(reflect.TypeOf(v))
+}
+
+func (g *schemaGenerator) schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return g.ref(t)
+	}
+
+	// interfaces and anything else can be any value
+	return &Schema{}
+}
+
+// ref adds a named struct type to the components, if it isn't already, and returns a reference to it
+func (g *schemaGenerator) ref(t reflect.Type) *Schema {
+	name, ok := g.names[t]
+	if !ok {
+		name = path.Base(t.PkgPath()) + "." + t.Name()
+		g.names[t] = name
+
+		// register the name before generating the schema so recursive types terminate
+		g.components[name] = &Schema{}
+		*g.components[name] = *g.structSchema(t)
+	}
+
+	return &Schema{Ref: g.prefix + name}
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			tagName := strings.Split(tag, ",")[0]
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+
+		// promote the fields of embedded structs
+		if f.Anonymous && f.Tag.Get("json") == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+
+			if ft.Kind() == reflect.Struct {
+				for k, v := range g.structSchema(ft).Properties {
+					if _, exists := s.Properties[k]; !exists {
+						s.Properties[k] = v
+					}
+				}
+				continue
+			}
+		}
+
+		if f.PkgPath != "" {
+			continue
+		}
+
+		s.Properties[name] = g.schema(f.Type)
+	}
+
+	return s
+}
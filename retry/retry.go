@@ -0,0 +1,111 @@
+package retry
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/YaleSpinup/s3-api/common"
+	log "github.com/sirupsen/logrus"
+)
+
+// Policy describes how an operation is retried
+type Policy struct {
+	// Attempts is the maximum number of attempts, including the first one
+	Attempts int
+	// Backoff is the initial wait between attempts, it's doubled (plus some jitter) after every attempt
+	Backoff time.Duration
+	// MaxBackoff caps the wait between attempts, zero means no cap
+	MaxBackoff time.Duration
+	// Budget is the total time allowed for all attempts, zero means no budget
+	Budget time.Duration
+	// RetryOn limits retries to apierrors with one of these codes.  Errors that are not apierrors are
+	// always retried.  All errors are retried if RetryOn is empty.
+	RetryOn []string
+}
+
+type stop struct {
+	error
+}
+
+// Stop wraps an error to stop retrying immediately, the wrapped error is returned from Do
+func Stop(err error) error {
+	return stop{err}
+}
+
+// Do executes f until it succeeds, the attempts or budget for the policy are exhausted, the error isn't
+// retryable or the context is cancelled.  The last error is returned.
+func Do(ctx context.Context, p Policy, f func(context.Context) error) error {
+	if p.Budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Budget)
+		defer cancel()
+	}
+
+	attempts := p.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	backoff := p.Backoff
+	for attempt := 1; ; attempt++ {
+		err := f(ctx)
+		if err == nil {
+			return nil
+		}
+
+		if s, ok := err.(stop); ok {
+			// return the original error for later checking
+			return s.error
+		}
+
+		if attempt >= attempts || !p.retryable(err) {
+			return err
+		}
+
+		// add some randomness to prevent creating a thundering herd
+		sleep := backoff
+		if backoff > 0 {
+			sleep = backoff + time.Duration(rand.Int63n(int64(backoff)))/2
+		}
+
+		if p.MaxBackoff > 0 && sleep > p.MaxBackoff {
+			sleep = p.MaxBackoff
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < sleep {
+			log.Warnf("retry budget exhausted after %d attempts: %s", attempt, err)
+			return err
+		}
+
+		log.Debugf("attempt %d of %d failed, retrying in %s: %s", attempt, attempts, sleep, err)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(sleep):
+		}
+
+		backoff = 2 * backoff
+	}
+}
+
+// retryable returns true if the error can be retried with the policy
+func (p Policy) retryable(err error) bool {
+	if len(p.RetryOn) == 0 {
+		return true
+	}
+
+	aerr, ok := common.AsAPIError(err)
+	if !ok {
+		return true
+	}
+
+	for _, code := range p.RetryOn {
+		if aerr.Code == code {
+			return true
+		}
+	}
+
+	return false
+}
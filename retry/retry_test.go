@@ -0,0 +1,156 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+)
+
+func TestDo(t *testing.T) {
+	p := Policy{Attempts: 3, Backoff: time.Millisecond}
+
+	// test success after failures
+	calls := 0
+	err := Do(context.TODO(), p, func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+
+	// test attempts exhausted
+	calls = 0
+	err = Do(context.TODO(), p, func(ctx context.Context) error {
+		calls++
+		return errors.New("boom")
+	})
+	if err == nil || err.Error() != "boom" {
+		t.Errorf("expected boom error, got %v", err)
+	}
+
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+
+	// test stop
+	calls = 0
+	err = Do(context.TODO(), p, func(ctx context.Context) error {
+		calls++
+		return Stop(errors.New("stop"))
+	})
+	if err == nil || err.Error() != "stop" {
+		t.Errorf("expected stop error, got %v", err)
+	}
+
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+}
+
+func TestDoRetryOn(t *testing.T) {
+	p := Policy{Attempts: 3, Backoff: time.Millisecond, RetryOn: []string{apierror.ErrNotFound}}
+
+	// test retryable code
+	calls := 0
+	_ = Do(context.TODO(), p, func(ctx context.Context) error {
+		calls++
+		return apierror.New(apierror.ErrNotFound, "not found", nil)
+	})
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+
+	// test non-retryable code
+	calls = 0
+	err := Do(context.TODO(), p, func(ctx context.Context) error {
+		calls++
+		return apierror.New(apierror.ErrForbidden, "forbidden", nil)
+	})
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrForbidden {
+		t.Errorf("expected error code %s, got %v", apierror.ErrForbidden, err)
+	}
+
+	// test errors that aren't apierrors are retried
+	calls = 0
+	_ = Do(context.TODO(), p, func(ctx context.Context) error {
+		calls++
+		return errors.New("boom")
+	})
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+}
+
+func TestDoBudget(t *testing.T) {
+	p := Policy{Attempts: 10, Backoff: 50 * time.Millisecond, Budget: 20 * time.Millisecond}
+
+	calls := 0
+	start := time.Now()
+	err := Do(context.TODO(), p, func(ctx context.Context) error {
+		calls++
+		return errors.New("boom")
+	})
+	if err == nil {
+		t.Error("expected error, got nil")
+	}
+
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+
+	if time.Since(start) > 50*time.Millisecond {
+		t.Errorf("expected budget to be respected, took %s", time.Since(start))
+	}
+}
+
+func TestDoContextCancelled(t *testing.T) {
+	p := Policy{Attempts: 10, Backoff: 10 * time.Millisecond}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := Do(ctx, p, func(ctx context.Context) error {
+		calls++
+		cancel()
+		return errors.New("boom")
+	})
+	if err == nil {
+		t.Error("expected error, got nil")
+	}
+
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+}
+
+func TestDoMaxBackoff(t *testing.T) {
+	p := Policy{Attempts: 3, Backoff: time.Hour, MaxBackoff: time.Millisecond}
+
+	calls := 0
+	start := time.Now()
+	_ = Do(context.TODO(), p, func(ctx context.Context) error {
+		calls++
+		return errors.New("boom")
+	})
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+
+	if time.Since(start) > time.Second {
+		t.Errorf("expected max backoff to be respected, took %s", time.Since(start))
+	}
+}
@@ -0,0 +1,247 @@
+package rollback
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// StatusPending is a rollback for an operation that is still in progress (or was interrupted by a crash)
+	StatusPending = "pending"
+	// StatusRolledBack is a rollback where all of the steps were successfully executed
+	StatusRolledBack = "rolled_back"
+	// StatusFailed is a rollback where one or more steps failed
+	StatusFailed = "failed"
+
+	// StepPending is a step that hasn't been executed
+	StepPending = "pending"
+	// StepSucceeded is a step that was successfully executed
+	StepSucceeded = "succeeded"
+	// StepFailed is a step that failed to execute
+	StepFailed = "failed"
+
+	// DefaultStepTimeout is the default timeout for executing each step
+	DefaultStepTimeout = 60 * time.Second
+)
+
+// Step is a single named rollback step.  Steps are described by a kind and parameters, rather than a
+// closure, so that they can be persisted and executed after a restart.
+type Step struct {
+	Name   string
+	Kind   string
+	Params map[string]string
+	Status string
+	Error  string `json:",omitempty"`
+}
+
+// Executor executes a rollback step
+type Executor func(ctx context.Context, step *Step) error
+
+// Rollback is an ordered list of steps to undo an operation.  Steps are executed in the reverse
+// order they were added.
+type Rollback struct {
+	ID        string
+	Operation string
+	Account   string
+	Resource  string
+	Instance  string
+	Status    string
+	Created   time.Time
+	Updated   time.Time
+	Steps     []*Step
+
+	executor Executor
+	store    Store
+	timeout  time.Duration
+	mu       sync.Mutex
+}
+
+type RollbackOption func(*Rollback)
+
+// New creates a new rollback for an operation on a resource in an account with options
+func New(operation, account, resource string, executor Executor, opts ...RollbackOption) *Rollback {
+	now := time.Now().UTC()
+	r := Rollback{
+		ID:        uuid.New().String(),
+		Operation: operation,
+		Account:   account,
+		Resource:  resource,
+		Status:    StatusPending,
+		Created:   now,
+		Updated:   now,
+		Steps:     []*Step{},
+		executor:  executor,
+		timeout:   DefaultStepTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(&r)
+	}
+
+	return &r
+}
+
+// WithStore persists the rollback to the store as it changes
+func WithStore(store Store) RollbackOption {
+	return func(r *Rollback) {
+		r.store = store
+	}
+}
+
+// WithStepTimeout sets the timeout for executing each step
+func WithStepTimeout(timeout time.Duration) RollbackOption {
+	return func(r *Rollback) {
+		r.timeout = timeout
+	}
+}
+
+// WithInstance sets the id of the api instance running the operation
+func WithInstance(instance string) RollbackOption {
+	return func(r *Rollback) {
+		r.Instance = instance
+	}
+}
+
+// SetExecutor sets the step executor, this is required to execute a rollback loaded from a store
+func (r *Rollback) SetExecutor(executor Executor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.executor = executor
+}
+
+// SetStore sets the store for a rollback, ie. after it's loaded
+func (r *Rollback) SetStore(store Store) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.store = store
+}
+
+// Add adds a step to the rollback and persists it
+func (r *Rollback) Add(name, kind string, params map[string]string) {
+	r.Append(&Step{
+		Name:   name,
+		Kind:   kind,
+		Params: params,
+	})
+}
+
+// Append appends existing steps to the rollback, ie. the steps from a nested operation
+func (r *Rollback) Append(steps ...*Step) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, s := range steps {
+		s.Status = StepPending
+		s.Error = ""
+		log.Debugf("rollback %s (%s %s): adding step %d: %s", r.ID, r.Operation, r.Resource, len(r.Steps)+1, s.Name)
+		r.Steps = append(r.Steps, s)
+	}
+
+	r.save()
+}
+
+// Len returns the number of steps in the rollback
+func (r *Rollback) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.Steps)
+}
+
+// Complete marks the operation as successfully completed and removes the rollback from the store
+func (r *Rollback) Complete() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.store == nil {
+		return
+	}
+
+	if err := r.store.Delete(r.ID); err != nil {
+		log.Errorf("failed to delete completed rollback %s from store: %s", r.ID, err)
+	}
+}
+
+// Execute runs the steps that haven't already succeeded in reverse order, each with its own timeout.  Failed
+// steps are logged and execution continues.  If all steps succeed, the rollback is removed from the store,
+// otherwise it's saved as failed so it can be reported on and retried.
+func (r *Rollback) Execute(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.executor == nil {
+		return fmt.Errorf("rollback %s has no executor", r.ID)
+	}
+
+	log.Warnf("rollback %s (%s %s): executing %d steps", r.ID, r.Operation, r.Resource, len(r.Steps))
+
+	failed := []string{}
+	for i := len(r.Steps) - 1; i >= 0; i-- {
+		step := r.Steps[i]
+		if step.Status == StepSucceeded {
+			continue
+		}
+
+		n := len(r.Steps) - i
+		if err := r.execute(ctx, step); err != nil {
+			log.Errorf("rollback %s (%s %s): step %d of %d (%s) failed: %s, continuing rollback", r.ID, r.Operation, r.Resource, n, len(r.Steps), step.Name, err)
+			step.Status = StepFailed
+			step.Error = err.Error()
+			failed = append(failed, step.Name)
+		} else {
+			log.Infof("rollback %s (%s %s): executed step %d of %d (%s)", r.ID, r.Operation, r.Resource, n, len(r.Steps), step.Name)
+			step.Status = StepSucceeded
+			step.Error = ""
+		}
+
+		r.save()
+	}
+
+	if len(failed) > 0 {
+		r.Status = StatusFailed
+		r.save()
+		return fmt.Errorf("rollback %s failed steps: %s", r.ID, strings.Join(failed, ", "))
+	}
+
+	log.Infof("rollback %s (%s %s): successfully rolled back", r.ID, r.Operation, r.Resource)
+
+	r.Status = StatusRolledBack
+	if r.store != nil {
+		if err := r.store.Delete(r.ID); err != nil {
+			log.Errorf("failed to delete rollback %s from store: %s", r.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// execute runs a single step with the step timeout
+func (r *Rollback) execute(ctx context.Context, step *Step) error {
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+
+	return r.executor(ctx, step)
+}
+
+// save persists the rollback if it has a store, callers must hold the lock
+func (r *Rollback) save() {
+	if r.store == nil {
+		return
+	}
+
+	r.Updated = time.Now().UTC()
+	if err := r.store.Save(r); err != nil {
+		log.Errorf("failed to save rollback %s: %s", r.ID, err)
+	}
+}
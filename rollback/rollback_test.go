@@ -0,0 +1,129 @@
+package rollback
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	r := New("bucket.create", "someaccount", "foobucket", nil)
+	to := reflect.TypeOf(r).String()
+	if to != "*rollback.Rollback" {
+		t.Errorf("expected type to be '*rollback.Rollback', got %s", to)
+	}
+
+	if r.ID == "" {
+		t.Error("expected rollback id to be set")
+	}
+
+	if r.Status != StatusPending {
+		t.Errorf("expected status %s, got %s", StatusPending, r.Status)
+	}
+
+	r = New("bucket.create", "someaccount", "foobucket", nil, WithStepTimeout(5*time.Second), WithInstance("abc"))
+	if r.timeout != 5*time.Second {
+		t.Errorf("expected step timeout to be 5s, got %s", r.timeout)
+	}
+
+	if r.Instance != "abc" {
+		t.Errorf("expected instance to be abc, got %s", r.Instance)
+	}
+}
+
+func TestExecute(t *testing.T) {
+	executed := []string{}
+	executor := func(ctx context.Context, step *Step) error {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("expected step context to have a deadline")
+		}
+
+		executed = append(executed, step.Name)
+		if step.Kind == "fail" {
+			return errors.New("boom")
+		}
+		return nil
+	}
+
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("expected nil error creating store, got %s", err)
+	}
+
+	// test steps are executed in reverse order and removed from the store on success
+	r := New("bucket.create", "someaccount", "foobucket", executor, WithStore(store))
+	r.Add("delete bucket", "ok", map[string]string{"bucket": "foobucket"})
+	r.Add("delete policy", "ok", map[string]string{"policy": "foopolicy"})
+	r.Append(&Step{Name: "delete group", Kind: "ok"})
+
+	if r.Len() != 3 {
+		t.Errorf("expected 3 steps, got %d", r.Len())
+	}
+
+	if _, err := store.Get(r.ID); err != nil {
+		t.Errorf("expected rollback to be persisted, got %s", err)
+	}
+
+	if err := r.Execute(context.TODO()); err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	expected := []string{"delete group", "delete policy", "delete bucket"}
+	if !reflect.DeepEqual(executed, expected) {
+		t.Errorf("expected steps %v, got %v", expected, executed)
+	}
+
+	if r.Status != StatusRolledBack {
+		t.Errorf("expected status %s, got %s", StatusRolledBack, r.Status)
+	}
+
+	if _, err := store.Get(r.ID); err == nil {
+		t.Error("expected rollback to be removed from store")
+	}
+
+	// test failed steps are recorded and retried, successful steps are not
+	executed = []string{}
+	r = New("bucket.create", "someaccount", "foobucket", executor, WithStore(store))
+	r.Add("delete bucket", "ok", nil)
+	r.Add("delete policy", "fail", nil)
+
+	if err := r.Execute(context.TODO()); err == nil {
+		t.Error("expected error, got nil")
+	}
+
+	if r.Status != StatusFailed {
+		t.Errorf("expected status %s, got %s", StatusFailed, r.Status)
+	}
+
+	saved, err := store.Get(r.ID)
+	if err != nil {
+		t.Fatalf("expected failed rollback to be persisted, got %s", err)
+	}
+
+	if saved.Steps[0].Status != StepSucceeded || saved.Steps[1].Status != StepFailed || saved.Steps[1].Error != "boom" {
+		t.Errorf("unexpected persisted steps %+v %+v", saved.Steps[0], saved.Steps[1])
+	}
+
+	executed = []string{}
+	saved.SetExecutor(executor)
+	_ = saved.Execute(context.TODO())
+	if !reflect.DeepEqual(executed, []string{"delete policy"}) {
+		t.Errorf("expected only the failed step to be retried, got %v", executed)
+	}
+
+	// test complete removes the rollback
+	r = New("bucket.create", "someaccount", "foobucket", executor, WithStore(store))
+	r.Add("delete bucket", "ok", nil)
+	r.Complete()
+	if _, err := store.Get(r.ID); err == nil {
+		t.Error("expected completed rollback to be removed from store")
+	}
+
+	// test missing executor
+	r = New("bucket.create", "someaccount", "foobucket", nil)
+	if err := r.Execute(context.TODO()); err == nil {
+		t.Error("expected error for missing executor, got nil")
+	}
+}
@@ -0,0 +1,124 @@
+package rollback
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Store persists rollbacks so they can be reported on and resumed after a restart
+type Store interface {
+	Save(r *Rollback) error
+	Get(id string) (*Rollback, error)
+	Delete(id string) error
+	List() ([]*Rollback, error)
+}
+
+// FileStore is a Store that keeps each rollback as a JSON file in a directory
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a new file store in the given directory, creating the directory if it doesn't exist
+func NewFileStore(dir string) (*FileStore, error) {
+	log.Infof("creating new rollback file store in %s", dir)
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrapf(err, "failed to create rollback directory %s", dir)
+	}
+
+	return &FileStore{dir: dir}, nil
+}
+
+// Save writes the rollback to disk.  The rollback is written to a temporary file first and
+// renamed so a crash doesn't leave a partially written file behind.
+func (f *FileStore) Save(r *Rollback) error {
+	j, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal rollback %s", r.ID)
+	}
+
+	tmp, err := os.CreateTemp(f.dir, r.ID+".*.tmp")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create temporary file for rollback %s", r.ID)
+	}
+
+	if _, err := tmp.Write(j); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return errors.Wrapf(err, "failed to write rollback %s", r.ID)
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return errors.Wrapf(err, "failed to write rollback %s", r.ID)
+	}
+
+	return os.Rename(tmp.Name(), f.path(r.ID))
+}
+
+// Get reads a rollback from disk
+func (f *FileStore) Get(id string) (*Rollback, error) {
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid rollback id", nil)
+	}
+
+	j, err := os.ReadFile(f.path(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, apierror.New(apierror.ErrNotFound, "rollback not found", err)
+		}
+		return nil, errors.Wrapf(err, "failed to read rollback %s", id)
+	}
+
+	r := Rollback{}
+	if err := json.Unmarshal(j, &r); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal rollback %s", id)
+	}
+	r.store = f
+	r.timeout = DefaultStepTimeout
+
+	return &r, nil
+}
+
+// Delete removes a rollback from disk, deleting a rollback that doesn't exist is not an error
+func (f *FileStore) Delete(id string) error {
+	if err := os.Remove(f.path(id)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to delete rollback %s", id)
+	}
+	return nil
+}
+
+// List returns all of the rollbacks on disk, oldest first
+func (f *FileStore) List() ([]*Rollback, error) {
+	files, err := filepath.Glob(filepath.Join(f.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	rollbacks := make([]*Rollback, 0, len(files))
+	for _, file := range files {
+		r, err := f.Get(strings.TrimSuffix(filepath.Base(file), ".json"))
+		if err != nil {
+			log.Warnf("skipping unreadable rollback file %s: %s", file, err)
+			continue
+		}
+		rollbacks = append(rollbacks, r)
+	}
+
+	sort.Slice(rollbacks, func(i, j int) bool {
+		return rollbacks[i].Created.Before(rollbacks[j].Created)
+	})
+
+	return rollbacks, nil
+}
+
+func (f *FileStore) path(id string) string {
+	return filepath.Join(f.dir, id+".json")
+}
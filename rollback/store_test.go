@@ -0,0 +1,63 @@
+package rollback
+
+import (
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+)
+
+func TestFileStore(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("expected nil error creating store, got %s", err)
+	}
+
+	first := New("bucket.create", "someaccount", "foobucket", nil)
+	first.Created = time.Now().Add(-1 * time.Minute)
+	first.Add("delete bucket", "s3.DeleteEmptyBucket", map[string]string{"bucket": "foobucket"})
+
+	second := New("website.create", "someaccount", "foo.example.com", nil)
+
+	for _, r := range []*Rollback{second, first} {
+		if err := store.Save(r); err != nil {
+			t.Errorf("expected nil error saving rollback, got %s", err)
+		}
+	}
+
+	r, err := store.Get(first.ID)
+	if err != nil {
+		t.Fatalf("expected nil error getting rollback, got %s", err)
+	}
+
+	if r.Operation != "bucket.create" || len(r.Steps) != 1 || r.Steps[0].Params["bucket"] != "foobucket" {
+		t.Errorf("unexpected rollback %+v", r)
+	}
+
+	list, err := store.List()
+	if err != nil {
+		t.Errorf("expected nil error listing rollbacks, got %s", err)
+	}
+
+	if len(list) != 2 || list[0].ID != first.ID || list[1].ID != second.ID {
+		t.Errorf("expected rollbacks to be listed oldest first, got %+v", list)
+	}
+
+	if err := store.Delete(first.ID); err != nil {
+		t.Errorf("expected nil error deleting rollback, got %s", err)
+	}
+
+	if err := store.Delete(first.ID); err != nil {
+		t.Errorf("expected nil error deleting missing rollback, got %s", err)
+	}
+
+	_, err = store.Get(first.ID)
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrNotFound {
+		t.Errorf("expected error code %s, got %v", apierror.ErrNotFound, err)
+	}
+
+	_, err = store.Get("../etc/passwd")
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrBadRequest {
+		t.Errorf("expected error code %s, got %v", apierror.ErrBadRequest, err)
+	}
+}
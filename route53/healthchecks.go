@@ -0,0 +1,74 @@
+package route53
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// CreateHealthCheck creates an HTTPS route53 health check for the path on the fully qualified domain name and applies
+// the tags, a Name tag makes it identifiable in the console
+func (r *Route53) CreateHealthCheck(ctx context.Context, name, fqdn, path string, tags []*route53.Tag) (*route53.HealthCheck, error) {
+	if name == "" || fqdn == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	if path == "" {
+		path = "/"
+	}
+
+	log.Infof("creating route53 health check for %s (https://%s%s)", name, fqdn, path)
+
+	out, err := r.Service.CreateHealthCheckWithContext(ctx, &route53.CreateHealthCheckInput{
+		CallerReference: aws.String(fmt.Sprintf("%s-%d", name, time.Now().UnixNano())),
+		HealthCheckConfig: &route53.HealthCheckConfig{
+			EnableSNI:                aws.Bool(true),
+			FailureThreshold:         aws.Int64(3),
+			FullyQualifiedDomainName: aws.String(fqdn),
+			Port:                     aws.Int64(443),
+			RequestInterval:          aws.Int64(30),
+			ResourcePath:             aws.String(path),
+			Type:                     aws.String(route53.HealthCheckTypeHttps),
+		},
+	})
+	if err != nil {
+		return nil, ErrCode("failed to create route53 health check", err)
+	}
+
+	if len(tags) == 0 {
+		return out.HealthCheck, nil
+	}
+
+	if _, err := r.Service.ChangeTagsForResourceWithContext(ctx, &route53.ChangeTagsForResourceInput{
+		AddTags:      tags,
+		ResourceId:   out.HealthCheck.Id,
+		ResourceType: aws.String(route53.TagResourceTypeHealthcheck),
+	}); err != nil {
+		return nil, ErrCode("failed to tag route53 health check", err)
+	}
+
+	return out.HealthCheck, nil
+}
+
+// DeleteHealthCheck deletes a route53 health check
+func (r *Route53) DeleteHealthCheck(ctx context.Context, id string) error {
+	if id == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("deleting route53 health check %s", id)
+
+	if _, err := r.Service.DeleteHealthCheckWithContext(ctx, &route53.DeleteHealthCheckInput{
+		HealthCheckId: aws.String(id),
+	}); err != nil {
+		return ErrCode("failed to delete route53 health check", err)
+	}
+
+	return nil
+}
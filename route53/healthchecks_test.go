@@ -0,0 +1,110 @@
+package route53
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+var testHealthCheck = route53.HealthCheck{
+	Id: aws.String("abcdef-1234-5678"),
+	HealthCheckConfig: &route53.HealthCheckConfig{
+		FullyQualifiedDomainName: aws.String("abcdefg1234567.cloudfront.net"),
+		ResourcePath:             aws.String("/"),
+	},
+}
+
+func (m *mockRoute53Client) CreateHealthCheckWithContext(ctx context.Context, input *route53.CreateHealthCheckInput, opts ...request.Option) (*route53.CreateHealthCheckOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if aws.StringValue(input.CallerReference) == "" {
+		return nil, errors.New("expected caller reference")
+	}
+
+	config := input.HealthCheckConfig
+	if aws.StringValue(config.Type) != route53.HealthCheckTypeHttps || aws.Int64Value(config.Port) != 443 {
+		return nil, fmt.Errorf("expected https health check on port 443, got %+v", config)
+	}
+
+	return &route53.CreateHealthCheckOutput{
+		HealthCheck: &route53.HealthCheck{
+			Id:                aws.String("abcdef-1234-5678"),
+			HealthCheckConfig: &route53.HealthCheckConfig{FullyQualifiedDomainName: config.FullyQualifiedDomainName, ResourcePath: config.ResourcePath},
+		},
+	}, nil
+}
+
+func (m *mockRoute53Client) ChangeTagsForResourceWithContext(ctx context.Context, input *route53.ChangeTagsForResourceInput, opts ...request.Option) (*route53.ChangeTagsForResourceOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if aws.StringValue(input.ResourceType) != route53.TagResourceTypeHealthcheck || aws.StringValue(input.ResourceId) != "abcdef-1234-5678" {
+		return nil, fmt.Errorf("unexpected tag resource %s %s", aws.StringValue(input.ResourceType), aws.StringValue(input.ResourceId))
+	}
+
+	return &route53.ChangeTagsForResourceOutput{}, nil
+}
+
+func (m *mockRoute53Client) DeleteHealthCheckWithContext(ctx context.Context, input *route53.DeleteHealthCheckInput, opts ...request.Option) (*route53.DeleteHealthCheckOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return &route53.DeleteHealthCheckOutput{}, nil
+}
+
+func TestCreateHealthCheck(t *testing.T) {
+	r := Route53{Service: newmockRoute53Client(t, nil)}
+
+	// test success with the default path
+	out, err := r.CreateHealthCheck(context.TODO(), "foobar.hyper.converged", "abcdefg1234567.cloudfront.net", "", []*route53.Tag{
+		{Key: aws.String("Name"), Value: aws.String("foobar.hyper.converged")},
+	})
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if !reflect.DeepEqual(out, &testHealthCheck) {
+		t.Errorf("expected %+v, got %+v", testHealthCheck, out)
+	}
+
+	// test invalid input
+	if _, err := r.CreateHealthCheck(context.TODO(), "", "", "/", nil); err == nil {
+		t.Error("expected error, got nil")
+	} else if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrBadRequest {
+		t.Errorf("expected bad request error, got %s", err)
+	}
+
+	// test error
+	r.Service.(*mockRoute53Client).err = errors.New("things blowing up!")
+	if _, err := r.CreateHealthCheck(context.TODO(), "foobar.hyper.converged", "abcdefg1234567.cloudfront.net", "/", nil); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func TestDeleteHealthCheck(t *testing.T) {
+	r := Route53{Service: newmockRoute53Client(t, nil)}
+
+	if err := r.DeleteHealthCheck(context.TODO(), "abcdef-1234-5678"); err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if err := r.DeleteHealthCheck(context.TODO(), ""); err == nil {
+		t.Error("expected error, got nil")
+	}
+
+	r.Service.(*mockRoute53Client).err = errors.New("things blowing up!")
+	if err := r.DeleteHealthCheck(context.TODO(), "abcdef-1234-5678"); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
@@ -12,6 +12,34 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// CloudFrontHostedZoneID is the hosted zone id of the alias targets for all cloudfront distributions
+const CloudFrontHostedZoneID = "Z2FDTNDATAQYW2"
+
+// AliasRecordTypes are the types of the alias records for a website, IPv4 and IPv6
+var AliasRecordTypes = []string{route53.RRTypeA, route53.RRTypeAaaa}
+
+// CloudFrontAliasTarget returns the alias target for a cloudfront distribution domain name
+func CloudFrontAliasTarget(domainName string) *route53.AliasTarget {
+	return &route53.AliasTarget{
+		DNSName:              aws.String(domainName),
+		HostedZoneId:         aws.String(CloudFrontHostedZoneID),
+		EvaluateTargetHealth: aws.Bool(false),
+	}
+}
+
+// AliasRecordSets returns the A and AAAA alias records for a name
+func AliasRecordSets(name string, target *route53.AliasTarget) []*route53.ResourceRecordSet {
+	records := []*route53.ResourceRecordSet{}
+	for _, t := range AliasRecordTypes {
+		records = append(records, &route53.ResourceRecordSet{
+			AliasTarget: target,
+			Name:        aws.String(name),
+			Type:        aws.String(t),
+		})
+	}
+	return records
+}
+
 // CreateRecord creates a route53 resource record.  This will fail if the record already exists.
 func (r *Route53) CreateRecord(ctx context.Context, zoneID string, record *route53.ResourceRecordSet) (*route53.ChangeInfo, error) {
 	if record == nil {
@@ -128,3 +156,97 @@ func (r *Route53) ListRecords(ctx context.Context, zoneID string) ([]*route53.Re
 
 	return recordSets, nil
 }
+
+// ChangeRecords applies a batch of changes to the route53 resource records in a zone.  The changes are applied
+// atomically, either all of them succeed or none of them do.
+func (r *Route53) ChangeRecords(ctx context.Context, zoneID string, changes []*route53.Change) (*route53.ChangeInfo, error) {
+	if len(changes) == 0 {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	out, err := r.Service.ChangeResourceRecordSetsWithContext(ctx, &route53.ChangeResourceRecordSetsInput{
+		ChangeBatch: &route53.ChangeBatch{
+			Changes: changes,
+			Comment: aws.String("Changed by s3-api"),
+		},
+		HostedZoneId: aws.String(zoneID),
+	})
+
+	if err != nil {
+		return nil, ErrCode("failed to change route53 records", err)
+	}
+
+	return out.ChangeInfo, nil
+}
+
+// ListRecordsByName lists all of the route53 resource records with the name and type, there can be more than
+// one if the records use a routing policy like failover.
+func (r *Route53) ListRecordsByName(ctx context.Context, zoneID, name, recordType string) ([]*route53.ResourceRecordSet, error) {
+	log.Infof("listing route53 records for zone ID %s, name %s, type '%s'", zoneID, name, recordType)
+
+	if !strings.HasSuffix(name, ".") {
+		name = name + "."
+	}
+
+	input := &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String(zoneID),
+		StartRecordName: aws.String(name),
+		StartRecordType: aws.String(recordType),
+	}
+
+	recordSets := []*route53.ResourceRecordSet{}
+	err := r.Service.ListResourceRecordSetsPagesWithContext(ctx, input,
+		func(out *route53.ListResourceRecordSetsOutput, lastPage bool) bool {
+			for _, rs := range out.ResourceRecordSets {
+				if aws.StringValue(rs.Name) != name || aws.StringValue(rs.Type) != recordType {
+					// records are returned in order, so there aren't any more matches
+					return false
+				}
+				recordSets = append(recordSets, rs)
+			}
+			return true
+		})
+	if err != nil {
+		return nil, ErrCode("failed to list route53 resource record sets", err)
+	}
+
+	return recordSets, nil
+}
+
+// ListAliasRecords lists the A and AAAA records with the name, including any failover records
+func (r *Route53) ListAliasRecords(ctx context.Context, zoneID, name string) ([]*route53.ResourceRecordSet, error) {
+	recordSets := []*route53.ResourceRecordSet{}
+	for _, t := range AliasRecordTypes {
+		records, err := r.ListRecordsByName(ctx, zoneID, name, t)
+		if err != nil {
+			return nil, err
+		}
+		recordSets = append(recordSets, records...)
+	}
+
+	return recordSets, nil
+}
+
+// FailoverRecordSets returns the primary and secondary failover alias records of the type for a name.  Route53
+// answers with the primary target while its health check is healthy and fails over to the secondary target when it
+// isn't.
+func FailoverRecordSets(name, recordType string, primary, secondary *route53.AliasTarget, healthCheckID string) (*route53.ResourceRecordSet, *route53.ResourceRecordSet) {
+	p := &route53.ResourceRecordSet{
+		AliasTarget:   primary,
+		Failover:      aws.String(route53.ResourceRecordSetFailoverPrimary),
+		HealthCheckId: aws.String(healthCheckID),
+		Name:          aws.String(name),
+		SetIdentifier: aws.String(name + "-primary"),
+		Type:          aws.String(recordType),
+	}
+
+	s := &route53.ResourceRecordSet{
+		AliasTarget:   secondary,
+		Failover:      aws.String(route53.ResourceRecordSetFailoverSecondary),
+		Name:          aws.String(name),
+		SetIdentifier: aws.String(name + "-secondary"),
+		Type:          aws.String(recordType),
+	}
+
+	return p, s
+}
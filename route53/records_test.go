@@ -565,3 +565,112 @@ func TestGetRecordByName(t *testing.T) {
 		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
 	}
 }
+
+func TestChangeRecords(t *testing.T) {
+	r := Route53{Service: newmockRoute53Client(t, nil)}
+
+	out, err := r.ChangeRecords(context.TODO(), testHostedZoneID, []*route53.Change{
+		{Action: aws.String("DELETE"), ResourceRecordSet: &testResourceRecordSet},
+	})
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if !reflect.DeepEqual(out, &testChangeInfo) {
+		t.Errorf("expected %+v, got %+v", testChangeInfo, out)
+	}
+
+	// test empty changes
+	if _, err := r.ChangeRecords(context.TODO(), testHostedZoneID, nil); err == nil {
+		t.Error("expected error, got nil")
+	}
+
+	r.Service.(*mockRoute53Client).err = errors.New("things blowing up!")
+	if _, err := r.ChangeRecords(context.TODO(), testHostedZoneID, []*route53.Change{
+		{Action: aws.String("DELETE"), ResourceRecordSet: &testResourceRecordSet},
+	}); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func TestListRecordsByName(t *testing.T) {
+	r := Route53{Service: newmockRoute53Client(t, nil)}
+
+	out, err := r.ListRecordsByName(context.TODO(), testHostedZoneID, "foobar.hyper.converged", "A")
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	expected := []*route53.ResourceRecordSet{&testResourceRecordSet}
+	if !reflect.DeepEqual(out, expected) {
+		t.Errorf("expected %+v, got %+v", expected, out)
+	}
+
+	r.Service.(*mockRoute53Client).err = errors.New("things blowing up!")
+	if _, err := r.ListRecordsByName(context.TODO(), testHostedZoneID, "foobar.hyper.converged", "A"); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func TestFailoverRecordSets(t *testing.T) {
+	primaryTarget := &route53.AliasTarget{DNSName: aws.String("primary.cloudfront.net")}
+	secondaryTarget := &route53.AliasTarget{DNSName: aws.String("secondary.cloudfront.net")}
+
+	primary, secondary := FailoverRecordSets("foobar.hyper.converged", "AAAA", primaryTarget, secondaryTarget, "abcdef-1234-5678")
+
+	if aws.StringValue(primary.Failover) != "PRIMARY" || aws.StringValue(primary.HealthCheckId) != "abcdef-1234-5678" || primary.AliasTarget != primaryTarget {
+		t.Errorf("unexpected primary record %+v", primary)
+	}
+
+	if aws.StringValue(secondary.Failover) != "SECONDARY" || secondary.HealthCheckId != nil || secondary.AliasTarget != secondaryTarget {
+		t.Errorf("unexpected secondary record %+v", secondary)
+	}
+
+	if aws.StringValue(primary.Type) != "AAAA" || aws.StringValue(secondary.Type) != "AAAA" {
+		t.Errorf("expected AAAA records, got %s and %s", aws.StringValue(primary.Type), aws.StringValue(secondary.Type))
+	}
+
+	if aws.StringValue(primary.SetIdentifier) == aws.StringValue(secondary.SetIdentifier) {
+		t.Errorf("expected unique set identifiers, got %s", aws.StringValue(primary.SetIdentifier))
+	}
+}
+
+func TestListAliasRecords(t *testing.T) {
+	r := Route53{Service: newmockRoute53Client(t, nil)}
+
+	out, err := r.ListAliasRecords(context.TODO(), testHostedZoneID, "foobar.hyper.converged")
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	expected := []*route53.ResourceRecordSet{&testResourceRecordSet}
+	if !reflect.DeepEqual(out, expected) {
+		t.Errorf("expected %+v, got %+v", expected, out)
+	}
+
+	r.Service.(*mockRoute53Client).err = errors.New("things blowing up!")
+	if _, err := r.ListAliasRecords(context.TODO(), testHostedZoneID, "foobar.hyper.converged"); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func TestAliasRecordSets(t *testing.T) {
+	target := CloudFrontAliasTarget("abcdefg1234567.cloudfront.net")
+	if aws.StringValue(target.HostedZoneId) != CloudFrontHostedZoneID || aws.BoolValue(target.EvaluateTargetHealth) {
+		t.Errorf("unexpected cloudfront alias target %+v", target)
+	}
+
+	records := AliasRecordSets("foobar.hyper.converged", target)
+
+	types := []string{}
+	for _, rs := range records {
+		types = append(types, aws.StringValue(rs.Type))
+		if aws.StringValue(rs.Name) != "foobar.hyper.converged" || rs.AliasTarget != target {
+			t.Errorf("unexpected alias record %+v", rs)
+		}
+	}
+
+	if !reflect.DeepEqual([]string{"A", "AAAA"}, types) {
+		t.Errorf("expected A and AAAA records, got %v", types)
+	}
+}
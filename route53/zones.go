@@ -0,0 +1,180 @@
+package route53
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ListHostedZones lists the route53 hosted zones in the account
+func (r *Route53) ListHostedZones(ctx context.Context) ([]*route53.HostedZone, error) {
+	log.Info("listing route53 hosted zones")
+
+	zones := []*route53.HostedZone{}
+	err := r.Service.ListHostedZonesPagesWithContext(ctx, &route53.ListHostedZonesInput{},
+		func(out *route53.ListHostedZonesOutput, lastPage bool) bool {
+			zones = append(zones, out.HostedZones...)
+			return true
+		})
+	if err != nil {
+		return nil, ErrCode("failed to list route53 hosted zones", err)
+	}
+
+	return zones, nil
+}
+
+// HostedZoneForName returns the public hosted zone with the longest name that the passed name is in, ie.
+// www.site.example.com is in site.example.com rather than example.com if both zones exist.
+func (r *Route53) HostedZoneForName(ctx context.Context, name string) (*route53.HostedZone, error) {
+	return r.hostedZoneForName(ctx, name, false)
+}
+
+// PrivateHostedZoneForName returns the private hosted zone with the longest name that the passed name is in
+func (r *Route53) PrivateHostedZoneForName(ctx context.Context, name string) (*route53.HostedZone, error) {
+	return r.hostedZoneForName(ctx, name, true)
+}
+
+func (r *Route53) hostedZoneForName(ctx context.Context, name string, private bool) (*route53.HostedZone, error) {
+	if name == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	zones, err := r.ListHostedZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	zone := longestSuffixZone(zones, name, private)
+	if zone == nil {
+		kind := "public"
+		if private {
+			kind = "private"
+		}
+		msg := fmt.Sprintf("%s route53 hosted zone not found for name %s", kind, name)
+		return nil, apierror.New(apierror.ErrNotFound, msg, nil)
+	}
+
+	log.Debugf("found hosted zone %s (%s) for name %s", aws.StringValue(zone.Name), aws.StringValue(zone.Id), name)
+
+	return zone, nil
+}
+
+// ZoneIDForName returns the id of the hosted zone for the passed name.  The zone id configured for the name's domain
+// is used if there is one, otherwise the hosted zone is discovered with HostedZoneForName, or PrivateHostedZoneForName
+// if the domain is configured as a private zone.
+func (r *Route53) ZoneIDForName(ctx context.Context, name string) (string, error) {
+	_, domain := DomainForName(r.Domains, name)
+	if domain != nil && domain.HostedZoneID != "" {
+		return domain.HostedZoneID, nil
+	}
+
+	var zone *route53.HostedZone
+	var err error
+	if domain != nil && domain.PrivateZone {
+		zone, err = r.PrivateHostedZoneForName(ctx, name)
+	} else {
+		zone, err = r.HostedZoneForName(ctx, name)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimPrefix(aws.StringValue(zone.Id), "/hostedzone/"), nil
+}
+
+// CheckVPCAssociations checks that a hosted zone is a private zone that's associated with all of the vpcs, a record
+// in the zone only resolves in the vpcs it's associated with
+func (r *Route53) CheckVPCAssociations(ctx context.Context, zoneID string, vpcIds []string) error {
+	if zoneID == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("checking the vpc associations of route53 hosted zone %s", zoneID)
+
+	out, err := r.Service.GetHostedZoneWithContext(ctx, &route53.GetHostedZoneInput{Id: aws.String(zoneID)})
+	if err != nil {
+		return ErrCode("failed to get route53 hosted zone "+zoneID, err)
+	}
+
+	if out.HostedZone == nil || out.HostedZone.Config == nil || !aws.BoolValue(out.HostedZone.Config.PrivateZone) {
+		msg := fmt.Sprintf("route53 hosted zone %s is not a private zone", zoneID)
+		return apierror.New(apierror.ErrBadRequest, msg, nil)
+	}
+
+	associated := map[string]bool{}
+	for _, v := range out.VPCs {
+		associated[aws.StringValue(v.VPCId)] = true
+	}
+
+	missing := []string{}
+	for _, id := range vpcIds {
+		if !associated[id] {
+			missing = append(missing, id)
+		}
+	}
+
+	if len(missing) > 0 {
+		msg := fmt.Sprintf("route53 hosted zone %s is not associated with vpcs %s", zoneID, strings.Join(missing, ", "))
+		return apierror.New(apierror.ErrBadRequest, msg, nil)
+	}
+
+	return nil
+}
+
+// DomainForName returns the configured domain that the passed name is, or is in, and its configuration.  The domain
+// with the longest name wins if there's more than one, ie. www.internal.example.com is in internal.example.com rather
+// than example.com.  The configuration is nil if the name isn't in any of the domains.
+func DomainForName(domains map[string]*common.Domain, name string) (string, *common.Domain) {
+	fqdn := strings.ToLower(strings.TrimSuffix(name, "."))
+
+	var match string
+	for d, domain := range domains {
+		if domain == nil || (fqdn != d && !strings.HasSuffix(fqdn, "."+d)) {
+			continue
+		}
+
+		if match == "" || len(d) > len(match) {
+			match = d
+		}
+	}
+
+	if match == "" {
+		return "", nil
+	}
+
+	return match, domains[match]
+}
+
+// longestSuffixZone returns the public or private zone with the longest name that matches the end of the name
+func longestSuffixZone(zones []*route53.HostedZone, name string, private bool) *route53.HostedZone {
+	if !strings.HasSuffix(name, ".") {
+		name = name + "."
+	}
+	name = strings.ToLower(name)
+
+	var match *route53.HostedZone
+	for _, z := range zones {
+		if z == nil || (z.Config != nil && aws.BoolValue(z.Config.PrivateZone)) != private {
+			continue
+		}
+
+		zoneName := strings.ToLower(aws.StringValue(z.Name))
+		if name != zoneName && !strings.HasSuffix(name, "."+zoneName) {
+			continue
+		}
+
+		if match == nil || len(zoneName) > len(aws.StringValue(match.Name)) {
+			match = z
+		}
+	}
+
+	return match
+}
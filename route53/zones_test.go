@@ -0,0 +1,268 @@
+package route53
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+var testHostedZones = []*route53.HostedZone{
+	{
+		Id:     aws.String("/hostedzone/Z1PARENT"),
+		Name:   aws.String("hyper.converged."),
+		Config: &route53.HostedZoneConfig{PrivateZone: aws.Bool(false)},
+	},
+	{
+		Id:     aws.String("/hostedzone/Z2CHILD"),
+		Name:   aws.String("site.hyper.converged."),
+		Config: &route53.HostedZoneConfig{PrivateZone: aws.Bool(false)},
+	},
+	{
+		Id:     aws.String("/hostedzone/Z3PRIVATE"),
+		Name:   aws.String("internal.hyper.converged."),
+		Config: &route53.HostedZoneConfig{PrivateZone: aws.Bool(true)},
+	},
+	{
+		Id:     aws.String("/hostedzone/Z4OTHER"),
+		Name:   aws.String("converged."),
+		Config: &route53.HostedZoneConfig{PrivateZone: aws.Bool(false)},
+	},
+}
+
+func (m *mockRoute53Client) ListHostedZonesPagesWithContext(ctx aws.Context, input *route53.ListHostedZonesInput, fn func(*route53.ListHostedZonesOutput, bool) bool, opts ...request.Option) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	if !fn(&route53.ListHostedZonesOutput{HostedZones: testHostedZones[:2]}, false) {
+		return nil
+	}
+	_ = fn(&route53.ListHostedZonesOutput{HostedZones: testHostedZones[2:]}, true)
+
+	return nil
+}
+
+func (m *mockRoute53Client) GetHostedZoneWithContext(ctx aws.Context, input *route53.GetHostedZoneInput, opts ...request.Option) (*route53.GetHostedZoneOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	for _, z := range testHostedZones {
+		if aws.StringValue(z.Id) != "/hostedzone/"+aws.StringValue(input.Id) {
+			continue
+		}
+
+		out := &route53.GetHostedZoneOutput{HostedZone: z}
+		if aws.BoolValue(z.Config.PrivateZone) {
+			out.VPCs = []*route53.VPC{
+				{VPCId: aws.String("vpc-0123456789abcdef0"), VPCRegion: aws.String("us-east-1")},
+				{VPCId: aws.String("vpc-0fedcba9876543210"), VPCRegion: aws.String("us-east-1")},
+			}
+		}
+
+		return out, nil
+	}
+
+	return nil, awserr.New(route53.ErrCodeNoSuchHostedZone, "not found", nil)
+}
+
+func TestListHostedZones(t *testing.T) {
+	r := Route53{Service: newmockRoute53Client(t, nil)}
+
+	out, err := r.ListHostedZones(context.TODO())
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if !reflect.DeepEqual(out, testHostedZones) {
+		t.Errorf("expected %+v, got %+v", testHostedZones, out)
+	}
+
+	r.Service.(*mockRoute53Client).err = errors.New("things blowing up!")
+	if _, err := r.ListHostedZones(context.TODO()); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func TestHostedZoneForName(t *testing.T) {
+	r := Route53{Service: newmockRoute53Client(t, nil)}
+
+	tests := []struct {
+		name     string
+		expected string
+		err      string
+	}{
+		{name: "www.hyper.converged", expected: "/hostedzone/Z1PARENT"},
+		{name: "hyper.converged.", expected: "/hostedzone/Z1PARENT"},
+		{name: "www.site.hyper.converged", expected: "/hostedzone/Z2CHILD"},
+		{name: "WWW.Site.Hyper.Converged", expected: "/hostedzone/Z2CHILD"},
+		{name: "www.internal.hyper.converged", expected: "/hostedzone/Z1PARENT"},
+		{name: "foo.converged", expected: "/hostedzone/Z4OTHER"},
+		{name: "notsite.hyper.converged", expected: "/hostedzone/Z1PARENT"},
+		{name: "www.example.com", err: apierror.ErrNotFound},
+		{name: "", err: apierror.ErrBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out, err := r.HostedZoneForName(context.TODO(), test.name)
+			if test.err != "" {
+				if aerr, ok := err.(apierror.Error); !ok || aerr.Code != test.err {
+					t.Errorf("expected %s error, got %v", test.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected nil error, got: %s", err)
+			}
+
+			if id := aws.StringValue(out.Id); id != test.expected {
+				t.Errorf("expected zone %s, got %s", test.expected, id)
+			}
+		})
+	}
+}
+
+func TestPrivateHostedZoneForName(t *testing.T) {
+	r := Route53{Service: newmockRoute53Client(t, nil)}
+
+	out, err := r.PrivateHostedZoneForName(context.TODO(), "www.internal.hyper.converged")
+	if err != nil {
+		t.Fatalf("expected nil error, got: %s", err)
+	}
+
+	if id := aws.StringValue(out.Id); id != "/hostedzone/Z3PRIVATE" {
+		t.Errorf("expected zone /hostedzone/Z3PRIVATE, got %s", id)
+	}
+
+	// public zones aren't private zones for the name
+	if _, err := r.PrivateHostedZoneForName(context.TODO(), "www.site.hyper.converged"); err == nil {
+		t.Error("expected error, got nil")
+	} else if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrNotFound {
+		t.Errorf("expected not found error, got %s", err)
+	}
+}
+
+func TestZoneIDForName(t *testing.T) {
+	r := Route53{
+		Service: newmockRoute53Client(t, nil),
+		Domains: map[string]*common.Domain{
+			"hyper.converged": {
+				CertArn:      "arn:aws:acm::12345678910:certificate/111111111-2222-3333-4444-555555555555",
+				HostedZoneID: testHostedZoneID,
+			},
+			"site.converged": {
+				CertArn: "arn:aws:acm::12345678910:certificate/111111111-2222-3333-4444-555555555555",
+			},
+			"internal.hyper.converged": {
+				PrivateZone: true,
+			},
+		},
+	}
+
+	// configured zone id
+	out, err := r.ZoneIDForName(context.TODO(), "www.hyper.converged")
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if out != testHostedZoneID {
+		t.Errorf("expected %s, got %s", testHostedZoneID, out)
+	}
+
+	// discovered zone id
+	out, err = r.ZoneIDForName(context.TODO(), "www.site.converged")
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if out != "Z4OTHER" {
+		t.Errorf("expected Z4OTHER, got %s", out)
+	}
+
+	// discovered private zone id, the configured zone id of the parent domain isn't used
+	out, err = r.ZoneIDForName(context.TODO(), "www.internal.hyper.converged")
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if out != "Z3PRIVATE" {
+		t.Errorf("expected Z3PRIVATE, got %s", out)
+	}
+
+	// list error
+	r.Service.(*mockRoute53Client).err = errors.New("things blowing up!")
+	if _, err := r.ZoneIDForName(context.TODO(), "www.site.converged"); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func TestCheckVPCAssociations(t *testing.T) {
+	r := Route53{Service: newmockRoute53Client(t, nil)}
+
+	tests := []struct {
+		name   string
+		zoneID string
+		vpcIds []string
+		err    string
+	}{
+		{name: "associated", zoneID: "Z3PRIVATE", vpcIds: []string{"vpc-0123456789abcdef0", "vpc-0fedcba9876543210"}},
+		{name: "no vpcs", zoneID: "Z3PRIVATE"},
+		{name: "not associated", zoneID: "Z3PRIVATE", vpcIds: []string{"vpc-0123456789abcdef0", "vpc-0aaaaaaaaaaaaaaaa"}, err: apierror.ErrBadRequest},
+		{name: "public zone", zoneID: "Z1PARENT", err: apierror.ErrBadRequest},
+		{name: "missing zone", zoneID: "Z9MISSING", err: apierror.ErrNotFound},
+		{name: "empty zone id", err: apierror.ErrBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := r.CheckVPCAssociations(context.TODO(), test.zoneID, test.vpcIds)
+			if test.err == "" {
+				if err != nil {
+					t.Errorf("expected nil error, got: %s", err)
+				}
+				return
+			}
+
+			if aerr, ok := err.(apierror.Error); !ok || aerr.Code != test.err {
+				t.Errorf("expected %s error, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestDomainForName(t *testing.T) {
+	domains := map[string]*common.Domain{
+		"hyper.converged":          {HostedZoneID: testHostedZoneID},
+		"internal.hyper.converged": {PrivateZone: true},
+	}
+
+	tests := map[string]string{
+		"www.hyper.converged":             "hyper.converged",
+		"hyper.converged.":                "hyper.converged",
+		"www.internal.hyper.converged":    "internal.hyper.converged",
+		"WWW.Internal.Hyper.Converged.":   "internal.hyper.converged",
+		"www.notinternal.hyper.converged": "hyper.converged",
+		"www.converged":                   "",
+	}
+
+	for name, expected := range tests {
+		d, domain := DomainForName(domains, name)
+		if d != expected {
+			t.Errorf("%s: expected domain %q, got %q", name, expected, d)
+		}
+
+		if (expected == "") != (domain == nil) {
+			t.Errorf("%s: unexpected domain configuration %+v", name, domain)
+		}
+	}
+}
@@ -2,12 +2,11 @@ package s3
 
 import (
 	"context"
-	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/YaleSpinup/apierror"
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/s3"
 	log "github.com/sirupsen/logrus"
 )
@@ -17,18 +16,16 @@ func (s *S3) BucketExists(ctx context.Context, bucketName string) (bool, error)
 	if _, err := s.Service.HeadBucketWithContext(ctx, &s3.HeadBucketInput{
 		Bucket: aws.String(bucketName),
 	}); err != nil {
-		if aerr, ok := err.(awserr.Error); ok {
-			switch aerr.Code() {
-			case s3.ErrCodeNoSuchBucket, "NotFound":
-				return false, nil
-			case "Forbidden":
-				msg := fmt.Sprintf("forbidden to access requested bucket %s: %s", bucketName, aerr.Error())
-				return true, apierror.New(apierror.ErrForbidden, msg, err)
-			default:
-				return false, apierror.New(apierror.ErrBadRequest, aerr.Message(), err)
-			}
+		aerr := ErrCode("failed checking for bucket "+bucketName, err)
+		switch apiErrorCode(aerr) {
+		case apierror.ErrNotFound:
+			return false, nil
+		case apierror.ErrForbidden:
+			// the bucket exists, but we don't have access to it
+			return true, aerr
+		default:
+			return false, aerr
 		}
-		return false, apierror.New(apierror.ErrInternalError, "unexpected error checking for bucket", err)
 	}
 
 	// looks like the bucket exists and you have access to it
@@ -138,19 +135,11 @@ func (s *S3) GetBucketTags(ctx context.Context, bucket string) ([]*s3.Tag, error
 	log.Infof("getting tags for bucket %s", bucket)
 	output, err := s.Service.GetBucketTaggingWithContext(ctx, &s3.GetBucketTaggingInput{Bucket: aws.String(bucket)})
 	if err != nil {
-		if aerr, ok := err.(awserr.Error); ok {
-			switch aerr.Code() {
-			case s3.ErrCodeNoSuchBucket, "NotFound":
-				msg := fmt.Sprintf("bucket %s not found: %s", bucket, aerr.Error())
-				return []*s3.Tag{}, apierror.New(apierror.ErrNotFound, msg, err)
-			case "NoSuchTagSet":
-				return []*s3.Tag{}, nil
-			default:
-				return []*s3.Tag{}, apierror.New(apierror.ErrBadRequest, aerr.Message(), err)
-			}
+		if awsErrorCode(err) == "NoSuchTagSet" {
+			return []*s3.Tag{}, nil
 		}
 
-		return []*s3.Tag{}, apierror.New(apierror.ErrInternalError, "unknown error occurred", err)
+		return []*s3.Tag{}, ErrCode("failed to get tags for bucket "+bucket, err)
 	}
 
 	return output.TagSet, nil
@@ -174,7 +163,39 @@ func (s *S3) TagBucket(ctx context.Context, bucket string, tags []*s3.Tag) error
 	return nil
 }
 
-// UpdateWebsiteConfig sets the configuration for an s3 website, defaults index suffix to index.html
+// DeleteBucketTags removes all of the tags from a bucket
+func (s *S3) DeleteBucketTags(ctx context.Context, bucket string) error {
+	if bucket == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("deleting tags for bucket %s", bucket)
+
+	if _, err := s.Service.DeleteBucketTaggingWithContext(ctx, &s3.DeleteBucketTaggingInput{Bucket: aws.String(bucket)}); err != nil {
+		return ErrCode("failed to delete tags for bucket "+bucket, err)
+	}
+
+	return nil
+}
+
+// GetWebsiteConfig gets the website configuration for a bucket
+func (s *S3) GetWebsiteConfig(ctx context.Context, bucket string) (*s3.GetBucketWebsiteOutput, error) {
+	if bucket == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("getting website configuration for bucket %s", bucket)
+
+	out, err := s.Service.GetBucketWebsiteWithContext(ctx, &s3.GetBucketWebsiteInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		return nil, ErrCode("failed to get website config for bucket "+bucket, err)
+	}
+
+	return out, nil
+}
+
+// UpdateWebsiteConfig sets the configuration for an s3 website, defaults index suffix to index.html unless the website
+// redirects all requests to another host
 func (s *S3) UpdateWebsiteConfig(ctx context.Context, input *s3.PutBucketWebsiteInput) error {
 	if input == nil || aws.StringValue(input.Bucket) == "" || input.WebsiteConfiguration == nil {
 		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
@@ -182,8 +203,8 @@ func (s *S3) UpdateWebsiteConfig(ctx context.Context, input *s3.PutBucketWebsite
 
 	log.Infof("updating website configuration for bucket %s", aws.StringValue(input.Bucket))
 
-	// set the default index document to index.html
-	if input.WebsiteConfiguration.IndexDocument == nil || aws.StringValue(input.WebsiteConfiguration.IndexDocument.Suffix) == "" {
+	// set the default index document to index.html, unless all requests are redirected
+	if input.WebsiteConfiguration.RedirectAllRequestsTo == nil && (input.WebsiteConfiguration.IndexDocument == nil || aws.StringValue(input.WebsiteConfiguration.IndexDocument.Suffix) == "") {
 		log.Debugf("Index document not set for %s, setting to index.html", aws.StringValue(input.Bucket))
 		input.WebsiteConfiguration.IndexDocument = &s3.IndexDocument{Suffix: aws.String("index.html")}
 	}
@@ -208,6 +229,21 @@ func (s *S3) UpdateBucketPolicy(ctx context.Context, input *s3.PutBucketPolicyIn
 	return nil
 }
 
+// DeleteBucketPolicy removes the policy from a bucket
+func (s *S3) DeleteBucketPolicy(ctx context.Context, bucket string) error {
+	if bucket == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("deleting bucket policy for %s", bucket)
+
+	if _, err := s.Service.DeleteBucketPolicyWithContext(ctx, &s3.DeleteBucketPolicyInput{Bucket: aws.String(bucket)}); err != nil {
+		return ErrCode("failed to delete policy for bucket "+bucket, err)
+	}
+
+	return nil
+}
+
 // UpdateBucketEncryption sets the bucket encryption
 func (s *S3) UpdateBucketEncryption(ctx context.Context, input *s3.PutBucketEncryptionInput) error {
 	if input == nil || aws.StringValue(input.Bucket) == "" || input.ServerSideEncryptionConfiguration == nil {
@@ -324,3 +360,239 @@ func (s *S3) BucketEmptyWithFilter(ctx context.Context, bucket string, max int64
 
 	return empty, nil
 }
+
+// BucketEmptyExceptTagged checks if a bucket is empty, ignoring the objects with the passed keys if they're tagged
+// with the tag key and value.  The bucket can't be empty if it has more objects than there are keys to ignore, so
+// only a single page of objects is listed and the tags of the ignored objects are fetched in parallel.  An object
+// whose tags can't be fetched isn't ignored.
+func (s *S3) BucketEmptyExceptTagged(ctx context.Context, bucket string, keys []string, tagKey, tagValue string) (bool, error) {
+	if bucket == "" || tagKey == "" {
+		return false, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("checking if bucket %s is empty except for tagged objects %v", bucket, keys)
+
+	out, err := s.Service.ListObjectsV2WithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket:  aws.String(bucket),
+		MaxKeys: aws.Int64(int64(len(keys) + 1)),
+	})
+	if err != nil {
+		return false, ErrCode("failed to determine if bucket is empty for bucket "+bucket, err)
+	}
+
+	if aws.BoolValue(out.IsTruncated) {
+		return false, nil
+	}
+
+	ignore := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		ignore[k] = true
+	}
+
+	candidates := []string{}
+	for _, obj := range out.Contents {
+		key := aws.StringValue(obj.Key)
+		if !ignore[key] {
+			log.Debugf("found object %s in bucket %s when checking for empty", key, bucket)
+			return false, nil
+		}
+		candidates = append(candidates, key)
+	}
+
+	tagged := make([]bool, len(candidates))
+	var wg sync.WaitGroup
+	for i, key := range candidates {
+		wg.Add(1)
+		go func(i int, key string) {
+			defer wg.Done()
+			tagged[i] = s.objectHasTag(ctx, bucket, key, tagKey, tagValue)
+		}(i, key)
+	}
+	wg.Wait()
+
+	for i, ok := range tagged {
+		if !ok {
+			log.Debugf("object %s in bucket %s isn't tagged with %s=%s", candidates[i], bucket, tagKey, tagValue)
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// objectHasTag returns true if an object is tagged with the tag key and value, false if it isn't or the tags can't
+// be fetched
+func (s *S3) objectHasTag(ctx context.Context, bucket, key, tagKey, tagValue string) bool {
+	out, err := s.Service.GetObjectTaggingWithContext(ctx, &s3.GetObjectTaggingInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		log.Warnf("failed to get tags for object %s in bucket %s: %s", key, bucket, err)
+		return false
+	}
+
+	for _, tag := range out.TagSet {
+		if aws.StringValue(tag.Key) == tagKey && aws.StringValue(tag.Value) == tagValue {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DisableBucketLogging turns off access logging for a bucket
+func (s *S3) DisableBucketLogging(ctx context.Context, bucket string) error {
+	if bucket == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("disabling bucket logging for %s", bucket)
+
+	if _, err := s.Service.PutBucketLoggingWithContext(ctx, &s3.PutBucketLoggingInput{
+		Bucket:              aws.String(bucket),
+		BucketLoggingStatus: &s3.BucketLoggingStatus{},
+	}); err != nil {
+		return ErrCode("failed to disable logging for bucket "+bucket, err)
+	}
+
+	return nil
+}
+
+// GetBucketEncryption gets the default encryption configuration for a bucket.  A bucket without
+// an encryption configuration returns nil.
+func (s *S3) GetBucketEncryption(ctx context.Context, bucket string) (*s3.ServerSideEncryptionConfiguration, error) {
+	if bucket == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("getting the encryption configuration for bucket %s", bucket)
+
+	out, err := s.Service.GetBucketEncryptionWithContext(ctx, &s3.GetBucketEncryptionInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		if awsErrorCode(err) == "ServerSideEncryptionConfigurationNotFoundError" {
+			return nil, nil
+		}
+		return nil, ErrCode("failed to get bucket encryption for bucket "+bucket, err)
+	}
+
+	return out.ServerSideEncryptionConfiguration, nil
+}
+
+// GetBucketVersioning gets the versioning status for a bucket.  The status is empty if versioning
+// has never been enabled on the bucket.
+func (s *S3) GetBucketVersioning(ctx context.Context, bucket string) (string, error) {
+	if bucket == "" {
+		return "", apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("getting the versioning configuration for bucket %s", bucket)
+
+	out, err := s.Service.GetBucketVersioningWithContext(ctx, &s3.GetBucketVersioningInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		return "", ErrCode("failed to get bucket versioning for bucket "+bucket, err)
+	}
+
+	return aws.StringValue(out.Status), nil
+}
+
+// UpdateBucketVersioning sets the versioning status (Enabled or Suspended) for a bucket
+func (s *S3) UpdateBucketVersioning(ctx context.Context, bucket, status string) error {
+	if bucket == "" || (status != s3.BucketVersioningStatusEnabled && status != s3.BucketVersioningStatusSuspended) {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("setting versioning for bucket %s to %s", bucket, status)
+
+	if _, err := s.Service.PutBucketVersioningWithContext(ctx, &s3.PutBucketVersioningInput{
+		Bucket:                  aws.String(bucket),
+		VersioningConfiguration: &s3.VersioningConfiguration{Status: aws.String(status)},
+	}); err != nil {
+		return ErrCode("failed to update versioning for bucket "+bucket, err)
+	}
+
+	return nil
+}
+
+// GetBucketLifecycleConfiguration gets the lifecycle rules for a bucket.  A bucket without a lifecycle
+// configuration returns an empty list of rules.
+func (s *S3) GetBucketLifecycleConfiguration(ctx context.Context, bucket string) ([]*s3.LifecycleRule, error) {
+	if bucket == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("getting the lifecycle configuration for bucket %s", bucket)
+
+	out, err := s.Service.GetBucketLifecycleConfigurationWithContext(ctx, &s3.GetBucketLifecycleConfigurationInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		if awsErrorCode(err) == "NoSuchLifecycleConfiguration" {
+			return []*s3.LifecycleRule{}, nil
+		}
+		return nil, ErrCode("failed to get bucket lifecycle for bucket "+bucket, err)
+	}
+
+	return out.Rules, nil
+}
+
+// GetBucketPolicy gets the bucket access policy.  A bucket without a policy returns an empty string.
+func (s *S3) GetBucketPolicy(ctx context.Context, bucket string) (string, error) {
+	if bucket == "" {
+		return "", apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("getting the bucket policy for bucket %s", bucket)
+
+	out, err := s.Service.GetBucketPolicyWithContext(ctx, &s3.GetBucketPolicyInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		if awsErrorCode(err) == "NoSuchBucketPolicy" {
+			return "", nil
+		}
+		return "", ErrCode("failed to get bucket policy for bucket "+bucket, err)
+	}
+
+	return aws.StringValue(out.Policy), nil
+}
+
+// GetBucketPolicyStatus returns true if the bucket policy makes the bucket public.  A bucket without a policy isn't
+// public.
+func (s *S3) GetBucketPolicyStatus(ctx context.Context, bucket string) (bool, error) {
+	if bucket == "" {
+		return false, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("getting the policy status for bucket %s", bucket)
+
+	out, err := s.Service.GetBucketPolicyStatusWithContext(ctx, &s3.GetBucketPolicyStatusInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		if awsErrorCode(err) == "NoSuchBucketPolicy" {
+			return false, nil
+		}
+		return false, ErrCode("failed to get bucket policy status for bucket "+bucket, err)
+	}
+
+	if out.PolicyStatus == nil {
+		return false, nil
+	}
+
+	return aws.BoolValue(out.PolicyStatus.IsPublic), nil
+}
+
+// GetPublicAccessBlock gets the public access block configuration for a bucket.  A bucket without a public access
+// block returns nil.
+func (s *S3) GetPublicAccessBlock(ctx context.Context, bucket string) (*s3.PublicAccessBlockConfiguration, error) {
+	if bucket == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("getting the public access block for bucket %s", bucket)
+
+	out, err := s.Service.GetPublicAccessBlockWithContext(ctx, &s3.GetPublicAccessBlockInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		if awsErrorCode(err) == "NoSuchPublicAccessBlockConfiguration" {
+			return nil, nil
+		}
+		return nil, ErrCode("failed to get public access block for bucket "+bucket, err)
+	}
+
+	return out.PublicAccessBlockConfiguration, nil
+}
@@ -89,19 +89,51 @@ func (m *mockS3Client) PutBucketTaggingWithContext(ctx context.Context, input *s
 	return &s3.PutBucketTaggingOutput{}, nil
 }
 
+func (m *mockS3Client) DeleteBucketTaggingWithContext(ctx context.Context, input *s3.DeleteBucketTaggingInput, opts ...request.Option) (*s3.DeleteBucketTaggingOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &s3.DeleteBucketTaggingOutput{}, nil
+}
+
 func (m *mockS3Client) PutBucketWebsiteWithContext(ctx context.Context, input *s3.PutBucketWebsiteInput, opts ...request.Option) (*s3.PutBucketWebsiteOutput, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
 
-	// check that IndexDocument is set
-	if input.WebsiteConfiguration.IndexDocument == nil {
+	// check that IndexDocument is set, unless all requests are redirected
+	if input.WebsiteConfiguration.RedirectAllRequestsTo != nil {
+		if input.WebsiteConfiguration.IndexDocument != nil {
+			return nil, errors.New("expected IndexDocument not to be set with RedirectAllRequestsTo")
+		}
+	} else if input.WebsiteConfiguration.IndexDocument == nil {
 		return nil, errors.New("expected index.html to be set for IndexDocument")
 	}
 
 	return &s3.PutBucketWebsiteOutput{}, nil
 }
 
+func (m *mockS3Client) GetBucketWebsiteWithContext(ctx context.Context, input *s3.GetBucketWebsiteInput, opts ...request.Option) (*s3.GetBucketWebsiteOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if aws.StringValue(input.Bucket) == "testRedirectBucket" {
+		return &s3.GetBucketWebsiteOutput{
+			RedirectAllRequestsTo: &s3.RedirectAllRequestsTo{HostName: aws.String("example.com"), Protocol: aws.String("https")},
+		}, nil
+	}
+
+	return &s3.GetBucketWebsiteOutput{IndexDocument: &s3.IndexDocument{Suffix: aws.String("index.html")}}, nil
+}
+
+func (m *mockS3Client) DeleteBucketPolicyWithContext(ctx context.Context, input *s3.DeleteBucketPolicyInput, opts ...request.Option) (*s3.DeleteBucketPolicyOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &s3.DeleteBucketPolicyOutput{}, nil
+}
+
 func (m *mockS3Client) PutBucketPolicyWithContext(ctx context.Context, input *s3.PutBucketPolicyInput, opts ...request.Option) (*s3.PutBucketPolicyOutput, error) {
 	if m.err != nil {
 		return nil, m.err
@@ -185,8 +217,23 @@ func (m *mockS3Client) ListObjectsV2WithContext(ctx context.Context, input *s3.L
 		return nil, m.err
 	}
 
-	if aws.StringValue(input.Bucket) == "testBucketNotEmpty" {
-		return &s3.ListObjectsV2Output{KeyCount: aws.Int64(int64(1))}, nil
+	switch aws.StringValue(input.Bucket) {
+	case "testBucketNotEmpty":
+		return &s3.ListObjectsV2Output{
+			Contents: []*s3.Object{{Key: aws.String("brand.svg")}},
+			KeyCount: aws.Int64(int64(1)),
+		}, nil
+	case "testBucketIndexOnly":
+		return &s3.ListObjectsV2Output{
+			Contents: []*s3.Object{{Key: aws.String("index.html")}},
+			KeyCount: aws.Int64(int64(1)),
+		}, nil
+	case "testBucketTruncated":
+		return &s3.ListObjectsV2Output{
+			Contents:    []*s3.Object{{Key: aws.String("index.html")}},
+			KeyCount:    aws.Int64(int64(1)),
+			IsTruncated: aws.Bool(true),
+		}, nil
 	}
 
 	return &s3.ListObjectsV2Output{KeyCount: aws.Int64(int64(0))}, nil
@@ -206,10 +253,10 @@ func (m *mockS3Client) ListObjectsV2PagesWithContext(ctx context.Context, input
 	if aws.StringValue(input.Bucket) == "testBucketNotEmpty" {
 		output = &s3.ListObjectsV2Output{
 			Contents: []*s3.Object{
-				{Key: aws.String("brand.svg")},
-				{Key: aws.String("index.html")},
-				{Key: aws.String("errors.html")},
-				{Key: aws.String("favicon.ico")},
+				{Key: aws.String("brand.svg"), Size: aws.Int64(100)},
+				{Key: aws.String("index.html"), Size: aws.Int64(200)},
+				{Key: aws.String("errors.html"), Size: aws.Int64(300)},
+				{Key: aws.String("favicon.ico"), Size: aws.Int64(400)},
 			},
 			IsTruncated: aws.Bool(false),
 			KeyCount:    aws.Int64(4),
@@ -508,7 +555,7 @@ func TestGetBucketTags(t *testing.T) {
 	}
 
 	// test some unexpected AWS error
-	s.Service.(*mockS3Client).err = awserr.New(s3.ErrCodeNoSuchUpload, "no such upload", nil)
+	s.Service.(*mockS3Client).err = awserr.New("SomethingUnexpected", "something unexpected", nil)
 	_, err = s.GetBucketTags(context.TODO(), "testBucket1")
 	if aerr, ok := err.(apierror.Error); ok {
 		if aerr.Code != apierror.ErrBadRequest {
@@ -557,6 +604,36 @@ func TestTagBucket(t *testing.T) {
 	}
 }
 
+func TestDeleteBucketTags(t *testing.T) {
+	s := S3{Service: newMockS3Client(t, nil)}
+
+	// test success
+	if err := s.DeleteBucketTags(context.TODO(), "testBucket1"); err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	// test empty bucket
+	err := s.DeleteBucketTags(context.TODO(), "")
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrBadRequest {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrBadRequest, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+
+	// test aws error
+	s.Service.(*mockS3Client).err = awserr.New(s3.ErrCodeNoSuchBucket, "no such bucket", nil)
+	err = s.DeleteBucketTags(context.TODO(), "testBucket1")
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrNotFound {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrNotFound, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+}
+
 func TestUpdateWebsiteConfig(t *testing.T) {
 	s := S3{Service: newMockS3Client(t, nil)}
 
@@ -569,6 +646,17 @@ func TestUpdateWebsiteConfig(t *testing.T) {
 		t.Errorf("expected nil error, got: %s", err)
 	}
 
+	// test redirecting all requests, the index document isn't set
+	err = s.UpdateWebsiteConfig(context.TODO(), &s3.PutBucketWebsiteInput{
+		Bucket: aws.String("testbucket"),
+		WebsiteConfiguration: &s3.WebsiteConfiguration{
+			RedirectAllRequestsTo: &s3.RedirectAllRequestsTo{HostName: aws.String("example.com")},
+		},
+	})
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
 	// test nil input
 	err = s.UpdateWebsiteConfig(context.TODO(), nil)
 	if aerr, ok := err.(apierror.Error); ok {
@@ -604,6 +692,40 @@ func TestUpdateWebsiteConfig(t *testing.T) {
 	}
 }
 
+func TestGetWebsiteConfig(t *testing.T) {
+	s := S3{Service: newMockS3Client(t, nil)}
+
+	out, err := s.GetWebsiteConfig(context.TODO(), "testRedirectBucket")
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if out.RedirectAllRequestsTo == nil || aws.StringValue(out.RedirectAllRequestsTo.HostName) != "example.com" {
+		t.Errorf("expected redirect to example.com, got %+v", out)
+	}
+
+	out, err = s.GetWebsiteConfig(context.TODO(), "testbucket")
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if out.RedirectAllRequestsTo != nil || aws.StringValue(out.IndexDocument.Suffix) != "index.html" {
+		t.Errorf("expected index.html index document, got %+v", out)
+	}
+
+	// test empty bucket name
+	if _, err := s.GetWebsiteConfig(context.TODO(), ""); err == nil {
+		t.Error("expected error for empty bucket name, got nil")
+	}
+
+	// test missing website configuration
+	s.Service.(*mockS3Client).err = awserr.New("NoSuchWebsiteConfiguration", "The specified bucket does not have a website configuration", nil)
+	_, err = s.GetWebsiteConfig(context.TODO(), "testbucket")
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrNotFound {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
 func TestUpdateBucketPolicy(t *testing.T) {
 	s := S3{Service: newMockS3Client(t, nil)}
 
@@ -926,3 +1048,417 @@ func TestBucketEmptyWithFilter(t *testing.T) {
 		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
 	}
 }
+
+func (m *mockS3Client) GetBucketEncryptionWithContext(ctx context.Context, input *s3.GetBucketEncryptionInput, opts ...request.Option) (*s3.GetBucketEncryptionOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if aws.StringValue(input.Bucket) == "unencrypted" {
+		return nil, awserr.New("ServerSideEncryptionConfigurationNotFoundError", "not found", nil)
+	}
+
+	return &s3.GetBucketEncryptionOutput{ServerSideEncryptionConfiguration: &testEncryptionConfiguration}, nil
+}
+
+func (m *mockS3Client) GetBucketVersioningWithContext(ctx context.Context, input *s3.GetBucketVersioningInput, opts ...request.Option) (*s3.GetBucketVersioningOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if aws.StringValue(input.Bucket) == "unversioned" {
+		return &s3.GetBucketVersioningOutput{}, nil
+	}
+
+	return &s3.GetBucketVersioningOutput{Status: aws.String(s3.BucketVersioningStatusEnabled)}, nil
+}
+
+func (m *mockS3Client) PutBucketVersioningWithContext(ctx context.Context, input *s3.PutBucketVersioningInput, opts ...request.Option) (*s3.PutBucketVersioningOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &s3.PutBucketVersioningOutput{}, nil
+}
+
+func (m *mockS3Client) GetBucketLifecycleConfigurationWithContext(ctx context.Context, input *s3.GetBucketLifecycleConfigurationInput, opts ...request.Option) (*s3.GetBucketLifecycleConfigurationOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if aws.StringValue(input.Bucket) == "nolifecycle" {
+		return nil, awserr.New("NoSuchLifecycleConfiguration", "not found", nil)
+	}
+
+	rule := Lifecycles.Rules["deep-archive"]
+	return &s3.GetBucketLifecycleConfigurationOutput{Rules: []*s3.LifecycleRule{&rule}}, nil
+}
+
+func (m *mockS3Client) GetBucketPolicyWithContext(ctx context.Context, input *s3.GetBucketPolicyInput, opts ...request.Option) (*s3.GetBucketPolicyOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if aws.StringValue(input.Bucket) == "nopolicy" {
+		return nil, awserr.New("NoSuchBucketPolicy", "not found", nil)
+	}
+
+	return &s3.GetBucketPolicyOutput{Policy: aws.String(testBucketPolicy)}, nil
+}
+
+func (m *mockS3Client) GetBucketPolicyStatusWithContext(ctx context.Context, input *s3.GetBucketPolicyStatusInput, opts ...request.Option) (*s3.GetBucketPolicyStatusOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	switch aws.StringValue(input.Bucket) {
+	case "nopolicy":
+		return nil, awserr.New("NoSuchBucketPolicy", "not found", nil)
+	case "publicbucket":
+		return &s3.GetBucketPolicyStatusOutput{PolicyStatus: &s3.PolicyStatus{IsPublic: aws.Bool(true)}}, nil
+	}
+
+	return &s3.GetBucketPolicyStatusOutput{PolicyStatus: &s3.PolicyStatus{IsPublic: aws.Bool(false)}}, nil
+}
+
+func (m *mockS3Client) GetPublicAccessBlockWithContext(ctx context.Context, input *s3.GetPublicAccessBlockInput, opts ...request.Option) (*s3.GetPublicAccessBlockOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if aws.StringValue(input.Bucket) == "noblock" {
+		return nil, awserr.New("NoSuchPublicAccessBlockConfiguration", "not found", nil)
+	}
+
+	return &s3.GetPublicAccessBlockOutput{PublicAccessBlockConfiguration: &testPublicAccessBlock}, nil
+}
+
+var testPublicAccessBlock = s3.PublicAccessBlockConfiguration{
+	BlockPublicAcls:       aws.Bool(true),
+	BlockPublicPolicy:     aws.Bool(true),
+	IgnorePublicAcls:      aws.Bool(true),
+	RestrictPublicBuckets: aws.Bool(true),
+}
+
+var testEncryptionConfiguration = s3.ServerSideEncryptionConfiguration{
+	Rules: []*s3.ServerSideEncryptionRule{
+		{
+			ApplyServerSideEncryptionByDefault: &s3.ServerSideEncryptionByDefault{
+				SSEAlgorithm: aws.String("AES256"),
+			},
+		},
+	},
+}
+
+var testBucketPolicy = `{"Version":"2012-10-17","Statement":[]}`
+
+func TestBucketEmptyExceptTagged(t *testing.T) {
+	s := S3{Service: newMockS3Client(t, nil)}
+
+	tests := []struct {
+		bucket   string
+		tagValue string
+		empty    bool
+	}{
+		{bucket: "testBucket", tagValue: "Handsome", empty: true},
+		{bucket: "testBucketIndexOnly", tagValue: "Handsome", empty: true},
+		{bucket: "testBucketIndexOnly", tagValue: "Ugly", empty: false},
+		{bucket: "testBucketNotEmpty", tagValue: "Handsome", empty: false},
+		{bucket: "testBucketTruncated", tagValue: "Handsome", empty: false},
+	}
+
+	for _, tc := range tests {
+		empty, err := s.BucketEmptyExceptTagged(context.TODO(), tc.bucket, []string{"index.html"}, "FirstName", tc.tagValue)
+		if err != nil {
+			t.Errorf("%s: expected nil error, got %s", tc.bucket, err)
+		}
+
+		if empty != tc.empty {
+			t.Errorf("%s with tag value %s: expected empty %t, got %t", tc.bucket, tc.tagValue, tc.empty, empty)
+		}
+	}
+
+	// test empty input
+	if _, err := s.BucketEmptyExceptTagged(context.TODO(), "", []string{"index.html"}, "FirstName", "Handsome"); err == nil {
+		t.Error("expected error for empty bucket, got nil")
+	}
+
+	// test non-aws error
+	s.Service.(*mockS3Client).err = errors.New("things blowing up!")
+	_, err := s.BucketEmptyExceptTagged(context.TODO(), "testBucketIndexOnly", []string{"index.html"}, "FirstName", "Handsome")
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrInternalError {
+		t.Errorf("expected error code %s, got: %v", apierror.ErrInternalError, err)
+	}
+}
+
+func TestGetBucketEncryption(t *testing.T) {
+	s := S3{Service: newMockS3Client(t, nil)}
+
+	// test success
+	out, err := s.GetBucketEncryption(context.TODO(), "testbucket")
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if !reflect.DeepEqual(out, &testEncryptionConfiguration) {
+		t.Errorf("expected %+v, got %+v", &testEncryptionConfiguration, out)
+	}
+
+	// test no encryption configuration
+	out, err = s.GetBucketEncryption(context.TODO(), "unencrypted")
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if out != nil {
+		t.Errorf("expected nil encryption configuration, got %+v", out)
+	}
+
+	// test empty bucket
+	_, err = s.GetBucketEncryption(context.TODO(), "")
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrBadRequest {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrBadRequest, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+
+	// test non-aws error
+	s.Service.(*mockS3Client).err = errors.New("things blowing up!")
+	_, err = s.GetBucketEncryption(context.TODO(), "testbucket")
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrInternalError {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrInternalError, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+}
+
+func TestGetBucketVersioning(t *testing.T) {
+	s := S3{Service: newMockS3Client(t, nil)}
+
+	// test success
+	out, err := s.GetBucketVersioning(context.TODO(), "testbucket")
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if out != s3.BucketVersioningStatusEnabled {
+		t.Errorf("expected %s, got %s", s3.BucketVersioningStatusEnabled, out)
+	}
+
+	// test never versioned
+	out, err = s.GetBucketVersioning(context.TODO(), "unversioned")
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if out != "" {
+		t.Errorf("expected empty status, got %s", out)
+	}
+
+	// test non-aws error
+	s.Service.(*mockS3Client).err = errors.New("things blowing up!")
+	_, err = s.GetBucketVersioning(context.TODO(), "testbucket")
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrInternalError {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrInternalError, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+}
+
+func TestUpdateBucketVersioning(t *testing.T) {
+	s := S3{Service: newMockS3Client(t, nil)}
+
+	// test success
+	if err := s.UpdateBucketVersioning(context.TODO(), "testbucket", s3.BucketVersioningStatusEnabled); err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	// test invalid status
+	err := s.UpdateBucketVersioning(context.TODO(), "testbucket", "Sometimes")
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrBadRequest {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrBadRequest, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+
+	// test non-aws error
+	s.Service.(*mockS3Client).err = errors.New("things blowing up!")
+	err = s.UpdateBucketVersioning(context.TODO(), "testbucket", s3.BucketVersioningStatusSuspended)
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrInternalError {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrInternalError, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+}
+
+func TestGetBucketLifecycleConfiguration(t *testing.T) {
+	s := S3{Service: newMockS3Client(t, nil)}
+
+	// test success
+	out, err := s.GetBucketLifecycleConfiguration(context.TODO(), "testbucket")
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if len(out) != 1 || aws.StringValue(out[0].ID) != "deep-archive-rule" {
+		t.Errorf("expected deep-archive-rule, got %+v", out)
+	}
+
+	// test no lifecycle configuration
+	out, err = s.GetBucketLifecycleConfiguration(context.TODO(), "nolifecycle")
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if len(out) != 0 {
+		t.Errorf("expected empty lifecycle rules, got %+v", out)
+	}
+
+	// test non-aws error
+	s.Service.(*mockS3Client).err = errors.New("things blowing up!")
+	_, err = s.GetBucketLifecycleConfiguration(context.TODO(), "testbucket")
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrInternalError {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrInternalError, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+}
+
+func TestDeleteBucketPolicy(t *testing.T) {
+	s := S3{Service: newMockS3Client(t, nil)}
+
+	// test success
+	if err := s.DeleteBucketPolicy(context.TODO(), "testbucket"); err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	// test empty bucket
+	err := s.DeleteBucketPolicy(context.TODO(), "")
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrBadRequest {
+		t.Errorf("expected error code %s, got: %v", apierror.ErrBadRequest, err)
+	}
+
+	// test aws error
+	s.Service.(*mockS3Client).err = awserr.New(s3.ErrCodeNoSuchBucket, "no such bucket", nil)
+	err = s.DeleteBucketPolicy(context.TODO(), "testbucket")
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrNotFound {
+		t.Errorf("expected error code %s, got: %v", apierror.ErrNotFound, err)
+	}
+}
+
+func TestGetBucketPolicy(t *testing.T) {
+	s := S3{Service: newMockS3Client(t, nil)}
+
+	// test success
+	out, err := s.GetBucketPolicy(context.TODO(), "testbucket")
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if out != testBucketPolicy {
+		t.Errorf("expected %s, got %s", testBucketPolicy, out)
+	}
+
+	// test no policy
+	out, err = s.GetBucketPolicy(context.TODO(), "nopolicy")
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if out != "" {
+		t.Errorf("expected empty policy, got %s", out)
+	}
+
+	// test non-aws error
+	s.Service.(*mockS3Client).err = errors.New("things blowing up!")
+	_, err = s.GetBucketPolicy(context.TODO(), "testbucket")
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrInternalError {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrInternalError, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+}
+
+func TestGetBucketPolicyStatus(t *testing.T) {
+	s := S3{Service: newMockS3Client(t, nil)}
+
+	tests := map[string]bool{
+		"publicbucket": true,
+		"testbucket":   false,
+		"nopolicy":     false,
+	}
+
+	for bucket, expected := range tests {
+		out, err := s.GetBucketPolicyStatus(context.TODO(), bucket)
+		if err != nil {
+			t.Errorf("%s: expected nil error, got: %s", bucket, err)
+		}
+
+		if out != expected {
+			t.Errorf("%s: expected %t, got %t", bucket, expected, out)
+		}
+	}
+
+	if _, err := s.GetBucketPolicyStatus(context.TODO(), ""); err == nil {
+		t.Error("expected error for empty bucket name, got nil")
+	}
+
+	// test non-aws error
+	s.Service.(*mockS3Client).err = errors.New("things blowing up!")
+	_, err := s.GetBucketPolicyStatus(context.TODO(), "testbucket")
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrInternalError {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrInternalError, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+}
+
+func TestGetPublicAccessBlock(t *testing.T) {
+	s := S3{Service: newMockS3Client(t, nil)}
+
+	// test success
+	out, err := s.GetPublicAccessBlock(context.TODO(), "testbucket")
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if !reflect.DeepEqual(out, &testPublicAccessBlock) {
+		t.Errorf("expected %+v, got %+v", testPublicAccessBlock, out)
+	}
+
+	// test no public access block
+	out, err = s.GetPublicAccessBlock(context.TODO(), "noblock")
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if out != nil {
+		t.Errorf("expected nil public access block, got %+v", out)
+	}
+
+	// test non-aws error
+	s.Service.(*mockS3Client).err = errors.New("things blowing up!")
+	_, err = s.GetPublicAccessBlock(context.TODO(), "testbucket")
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrInternalError {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrInternalError, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+}
@@ -1,13 +1,19 @@
 package s3
 
 import (
+	"net/http"
+
 	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
+// ErrCode maps an s3 error to an apierror.  Throttling (SlowDown) and transient service errors (InternalError,
+// ServiceUnavailable, RequestTimeout) are mapped to ErrLimitExceeded and ErrServiceUnavailable so that they're
+// retried, OperationAborted is mapped to ErrConflict.  Codes that aren't listed are mapped by their http status.
 func ErrCode(msg string, err error) error {
 	if aerr, ok := errors.Cause(err).(awserr.Error); ok {
 		switch aerr.Code() {
@@ -71,9 +77,30 @@ func ErrCode(msg string, err error) error {
 			// The specified multipart upload does not exist.
 			s3.ErrCodeNoSuchUpload,
 
-			// The specified bucket does not exist.
+			// The specified bucket (or object) does not exist, returned by HEAD requests which don't have a body.
 			"NotFound",
 
+			// The specified bucket does not have any tags.
+			"NoSuchTagSet",
+
+			// The specified bucket does not have a CORS configuration.
+			"NoSuchCORSConfiguration",
+
+			// The specified bucket does not have a website configuration.
+			"NoSuchWebsiteConfiguration",
+
+			// The specified bucket does not have a default encryption configuration.
+			"ServerSideEncryptionConfigurationNotFoundError",
+
+			// The specified bucket does not have a public access block configuration.
+			"NoSuchPublicAccessBlockConfiguration",
+
+			// The specified bucket does not have a replication configuration.
+			"ReplicationConfigurationNotFoundError",
+
+			// The specified bucket does not have ownership controls.
+			"OwnershipControlsNotFoundError",
+
 			// The specified bucket does not have a bucket policy.
 			"NoSuchBucketPolicy",
 
@@ -98,6 +125,9 @@ func ErrCode(msg string, err error) error {
 			// only stored in Amazon Glacier.
 			s3.ErrCodeObjectNotInActiveTierError,
 
+			// The request is not valid, returned by HEAD requests which don't have a body.
+			"BadRequest",
+
 			// The email address you provided is associated with more than one account.
 			"AmbiguousGrantByEmailAddress",
 
@@ -308,10 +338,47 @@ func ErrCode(msg string, err error) error {
 			return apierror.New(apierror.ErrServiceUnavailable, msg, aerr)
 		default:
 			m := msg + ": " + aerr.Message()
-			return apierror.New(apierror.ErrBadRequest, m, aerr)
+			return apierror.New(errCodeFromStatus(err), m, aerr)
 		}
 	}
 
 	log.Warnf("uncaught error: %s, returning Internal Server Error", err)
 	return apierror.New(apierror.ErrInternalError, msg, err)
 }
+
+// errCodeFromStatus maps the http status code of a failed request to an apierror code for errors
+// without a known error code.  Errors without a status code are bad requests.
+func errCodeFromStatus(err error) string {
+	rerr, ok := errors.Cause(err).(awserr.RequestFailure)
+	if !ok {
+		return apierror.ErrBadRequest
+	}
+
+	switch status := rerr.StatusCode(); {
+	case status == http.StatusForbidden:
+		return apierror.ErrForbidden
+	case status == http.StatusNotFound:
+		return apierror.ErrNotFound
+	case status == http.StatusConflict:
+		return apierror.ErrConflict
+	case status == http.StatusTooManyRequests:
+		return apierror.ErrLimitExceeded
+	case status >= 500:
+		return apierror.ErrServiceUnavailable
+	default:
+		return apierror.ErrBadRequest
+	}
+}
+
+// awsErrorCode returns the aws error code of an error, or an empty string if it's not an aws error
+func awsErrorCode(err error) string {
+	if aerr, ok := errors.Cause(err).(awserr.Error); ok {
+		return aerr.Code()
+	}
+	return ""
+}
+
+// apiErrorCode returns the apierror code of an error, or an empty string if it's not an apierror
+func apiErrorCode(err error) string {
+	return common.ErrorCode(err)
+}
@@ -0,0 +1,82 @@
+package s3
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func TestErrCode(t *testing.T) {
+	apiErrorTestCases := map[string]string{
+		"AccessDenied":       apierror.ErrForbidden,
+		"Forbidden":          apierror.ErrForbidden,
+		"InvalidAccessKeyId": apierror.ErrForbidden,
+
+		s3.ErrCodeBucketAlreadyExists:     apierror.ErrConflict,
+		s3.ErrCodeBucketAlreadyOwnedByYou: apierror.ErrConflict,
+		"BucketNotEmpty":                  apierror.ErrConflict,
+		"OperationAborted":                apierror.ErrConflict,
+
+		s3.ErrCodeNoSuchBucket:                           apierror.ErrNotFound,
+		s3.ErrCodeNoSuchKey:                              apierror.ErrNotFound,
+		s3.ErrCodeNoSuchUpload:                           apierror.ErrNotFound,
+		"NotFound":                                       apierror.ErrNotFound,
+		"NoSuchBucketPolicy":                             apierror.ErrNotFound,
+		"NoSuchLifecycleConfiguration":                   apierror.ErrNotFound,
+		"NoSuchTagSet":                                   apierror.ErrNotFound,
+		"NoSuchCORSConfiguration":                        apierror.ErrNotFound,
+		"NoSuchWebsiteConfiguration":                     apierror.ErrNotFound,
+		"ServerSideEncryptionConfigurationNotFoundError": apierror.ErrNotFound,
+		"NoSuchPublicAccessBlockConfiguration":           apierror.ErrNotFound,
+
+		"BadRequest":      apierror.ErrBadRequest,
+		"InvalidArgument": apierror.ErrBadRequest,
+		"MalformedXML":    apierror.ErrBadRequest,
+
+		"SlowDown":           apierror.ErrLimitExceeded,
+		"ServiceUnavailable": apierror.ErrLimitExceeded,
+		"TooManyBuckets":     apierror.ErrLimitExceeded,
+
+		"InternalError":  apierror.ErrServiceUnavailable,
+		"RequestTimeout": apierror.ErrServiceUnavailable,
+
+		"SomethingUnexpected": apierror.ErrBadRequest,
+	}
+
+	for awsErr, apiErr := range apiErrorTestCases {
+		err := ErrCode("test error", awserr.New(awsErr, awsErr, nil))
+		if aerr, ok := err.(apierror.Error); ok {
+			if aerr.Code != apiErr {
+				t.Errorf("expected s3 error %s to be an apierror.Error %s, got %s", awsErr, apiErr, aerr.Code)
+			}
+		} else {
+			t.Errorf("expected s3 error %s to be an apierror.Error %s, got %s", awsErr, apiErr, err)
+		}
+	}
+
+	// unknown error codes are mapped by the http status code
+	statusTestCases := map[int]string{
+		400: apierror.ErrBadRequest,
+		403: apierror.ErrForbidden,
+		404: apierror.ErrNotFound,
+		409: apierror.ErrConflict,
+		429: apierror.ErrLimitExceeded,
+		500: apierror.ErrServiceUnavailable,
+		503: apierror.ErrServiceUnavailable,
+	}
+
+	for status, apiErr := range statusTestCases {
+		err := ErrCode("test error", awserr.NewRequestFailure(awserr.New("SomethingUnexpected", "something unexpected", nil), status, "request-id"))
+		if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apiErr {
+			t.Errorf("expected status %d to be an apierror.Error %s, got %s", status, apiErr, err)
+		}
+	}
+
+	err := ErrCode("test error", errors.New("Unknown"))
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrInternalError {
+		t.Errorf("expected unknown error to be an apierror.Error %s, got %s", apierror.ErrInternalError, err)
+	}
+}
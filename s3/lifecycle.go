@@ -1,6 +1,11 @@
 package s3
 
 import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/YaleSpinup/s3-api/common"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/s3"
 )
@@ -22,11 +27,33 @@ var (
 				},
 			},
 		},
+		Descriptions: map[string]string{
+			"deep-archive": "moves objects to glacier deep archive a day after they're created",
+		},
 	}
 )
 
+// ScratchRuleID is the id of the lifecycle rule that expires the objects in a scratch bucket, it's installed alongside
+// the rules of the bucket's lifecycle
+const ScratchRuleID = "spinup-scratch-expiration"
+
+// lifecycleNamePattern is the pattern of a valid lifecycle template name
+var lifecycleNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
 type SupportedLifecycles struct {
+	// Rules are the built in lifecycles, each installs a single rule
 	Rules map[string]s3.LifecycleRule
+	// Descriptions describe the built in lifecycles
+	Descriptions map[string]string
+	// Templates are the lifecycles from the configured templates, they can install any number of rules
+	Templates map[string]*Lifecycle
+}
+
+// Lifecycle is a named lifecycle and the rules it installs on a bucket
+type Lifecycle struct {
+	Name        string
+	Description string `json:",omitempty"`
+	Rules       []*s3.LifecycleRule
 }
 
 func (l *SupportedLifecycles) GetLifecycle(lifecycle string) *s3.LifecycleRule {
@@ -40,3 +67,231 @@ func (l *SupportedLifecycles) GetLifecycle(lifecycle string) *s3.LifecycleRule {
 
 	return &lifecycleRule
 }
+
+// Supported returns true if the lifecycle is one of the built in lifecycles or configured templates
+func (l *SupportedLifecycles) Supported(lifecycle string) bool {
+	if _, ok := l.Rules[lifecycle]; ok {
+		return true
+	}
+
+	_, ok := l.Templates[lifecycle]
+	return ok
+}
+
+// GetRules returns the rules installed by a lifecycle, or nil if the lifecycle isn't supported
+func (l *SupportedLifecycles) GetRules(lifecycle string) []*s3.LifecycleRule {
+	if t, ok := l.Templates[lifecycle]; ok {
+		return t.Rules
+	}
+
+	if _, ok := l.Rules[lifecycle]; ok {
+		return []*s3.LifecycleRule{l.GetLifecycle(lifecycle)}
+	}
+
+	return nil
+}
+
+// List returns all of the supported lifecycles sorted by name
+func (l *SupportedLifecycles) List() []*Lifecycle {
+	lifecycles := []*Lifecycle{}
+	for name := range l.Rules {
+		lifecycles = append(lifecycles, &Lifecycle{
+			Name:        name,
+			Description: l.Descriptions[name],
+			Rules:       l.GetRules(name),
+		})
+	}
+
+	for _, t := range l.Templates {
+		lifecycles = append(lifecycles, t)
+	}
+
+	sort.Slice(lifecycles, func(i, j int) bool { return lifecycles[i].Name < lifecycles[j].Name })
+
+	return lifecycles
+}
+
+// Name returns the name of the supported lifecycle that installs the rules, or an empty string.  The rules are
+// compared by id since s3 fills in defaults for the rules it returns, and the scratch expiration rule is ignored.
+func (l *SupportedLifecycles) Name(rules []*s3.LifecycleRule) string {
+	rules = withoutScratchRule(rules)
+	if len(rules) == 0 {
+		return ""
+	}
+
+	for _, lc := range l.List() {
+		if len(lc.Rules) != len(rules) {
+			continue
+		}
+
+		ids := map[string]bool{}
+		for _, r := range lc.Rules {
+			ids[aws.StringValue(r.ID)] = true
+		}
+
+		matches := true
+		for _, r := range rules {
+			if !ids[aws.StringValue(r.ID)] {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			return lc.Name
+		}
+	}
+
+	return ""
+}
+
+// ScratchExpirationRule returns the lifecycle rule for a scratch bucket, expiring the objects after the days along with
+// their noncurrent versions and incomplete multipart uploads
+func ScratchExpirationRule(days int64) *s3.LifecycleRule {
+	return &s3.LifecycleRule{
+		ID:     aws.String(ScratchRuleID),
+		Status: aws.String(s3.ExpirationStatusEnabled),
+		Filter: &s3.LifecycleRuleFilter{
+			Prefix: aws.String(""),
+		},
+		Expiration: &s3.LifecycleExpiration{
+			Days: aws.Int64(days),
+		},
+		NoncurrentVersionExpiration: &s3.NoncurrentVersionExpiration{
+			NoncurrentDays: aws.Int64(1),
+		},
+		AbortIncompleteMultipartUpload: &s3.AbortIncompleteMultipartUpload{
+			DaysAfterInitiation: aws.Int64(1),
+		},
+	}
+}
+
+// withoutScratchRule returns the lifecycle rules without the scratch expiration rule
+func withoutScratchRule(rules []*s3.LifecycleRule) []*s3.LifecycleRule {
+	out := []*s3.LifecycleRule{}
+	for _, r := range rules {
+		if aws.StringValue(r.ID) != ScratchRuleID {
+			out = append(out, r)
+		}
+	}
+
+	return out
+}
+
+// AddTemplates validates the lifecycle templates from the configuration and adds them to the supported lifecycles
+func (l *SupportedLifecycles) AddTemplates(templates map[string]*common.LifecycleTemplate) error {
+	lifecycles := map[string]*Lifecycle{}
+	for name, t := range templates {
+		if !lifecycleNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid lifecycle template name %s, it must be 1-63 lowercase letters, numbers or hyphens", name)
+		}
+
+		if _, ok := l.Rules[name]; ok {
+			return fmt.Errorf("lifecycle template %s has the same name as a built in lifecycle", name)
+		}
+
+		if t == nil || len(t.Rules) == 0 {
+			return fmt.Errorf("lifecycle template %s must have at least one rule", name)
+		}
+
+		rules, err := lifecycleTemplateRules(name, t.Rules)
+		if err != nil {
+			return err
+		}
+
+		lifecycles[name] = &Lifecycle{
+			Name:        name,
+			Description: t.Description,
+			Rules:       rules,
+		}
+	}
+
+	if l.Templates == nil {
+		l.Templates = map[string]*Lifecycle{}
+	}
+
+	for name, lc := range lifecycles {
+		l.Templates[name] = lc
+	}
+
+	return nil
+}
+
+// lifecycleTemplateRules converts the rules of a lifecycle template into s3 lifecycle rules
+func lifecycleTemplateRules(name string, templateRules []*common.LifecycleRule) ([]*s3.LifecycleRule, error) {
+	rules := []*s3.LifecycleRule{}
+	ids := map[string]bool{}
+	for i, r := range templateRules {
+		if r == nil {
+			return nil, fmt.Errorf("lifecycle template %s rule %d cannot be empty", name, i)
+		}
+
+		id := r.ID
+		if id == "" {
+			id = name + "-rule"
+			if len(templateRules) > 1 {
+				id = fmt.Sprintf("%s-rule-%d", name, i+1)
+			}
+		}
+
+		if ids[id] {
+			return nil, fmt.Errorf("lifecycle template %s has more than one rule with the id %s", name, id)
+		}
+		ids[id] = true
+
+		if r.ExpirationDays < 0 || r.NoncurrentExpirationDays < 0 || r.AbortIncompleteMultipartUploadDays < 0 {
+			return nil, fmt.Errorf("lifecycle template %s rule %s days cannot be negative", name, id)
+		}
+
+		rule := &s3.LifecycleRule{
+			ID:     aws.String(id),
+			Status: aws.String(s3.ExpirationStatusEnabled),
+			Filter: &s3.LifecycleRuleFilter{Prefix: aws.String(r.Prefix)},
+		}
+
+		if r.ExpirationDays > 0 {
+			rule.Expiration = &s3.LifecycleExpiration{Days: aws.Int64(r.ExpirationDays)}
+		}
+
+		for _, t := range r.Transitions {
+			if t == nil || t.Days <= 0 {
+				return nil, fmt.Errorf("lifecycle template %s rule %s transition days must be greater than 0", name, id)
+			}
+
+			if !validStorageClass(s3.TransitionStorageClass_Values(), t.StorageClass) {
+				return nil, fmt.Errorf("invalid storage class '%s' for lifecycle template %s rule %s, expected one of %v", t.StorageClass, name, id, s3.TransitionStorageClass_Values())
+			}
+
+			rule.Transitions = append(rule.Transitions, &s3.Transition{
+				Days:         aws.Int64(t.Days),
+				StorageClass: aws.String(t.StorageClass),
+			})
+		}
+
+		if r.NoncurrentExpirationDays > 0 {
+			rule.NoncurrentVersionExpiration = &s3.NoncurrentVersionExpiration{NoncurrentDays: aws.Int64(r.NoncurrentExpirationDays)}
+		}
+
+		if r.AbortIncompleteMultipartUploadDays > 0 {
+			rule.AbortIncompleteMultipartUpload = &s3.AbortIncompleteMultipartUpload{DaysAfterInitiation: aws.Int64(r.AbortIncompleteMultipartUploadDays)}
+		}
+
+		if rule.Expiration == nil && rule.Transitions == nil && rule.NoncurrentVersionExpiration == nil && rule.AbortIncompleteMultipartUpload == nil {
+			return nil, fmt.Errorf("lifecycle template %s rule %s doesn't do anything, it needs an expiration, transition or abort", name, id)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// validStorageClass returns true if the storage class is one of the valid storage classes
+func validStorageClass(classes []string, class string) bool {
+	for _, c := range classes {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package s3
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func TestAddTemplates(t *testing.T) {
+	l := SupportedLifecycles{Rules: Lifecycles.Rules, Descriptions: Lifecycles.Descriptions}
+
+	err := l.AddTemplates(map[string]*common.LifecycleTemplate{
+		"scratch-30d": {
+			Description: "expires objects after 30 days",
+			Rules:       []*common.LifecycleRule{{ExpirationDays: 30, AbortIncompleteMultipartUploadDays: 1}},
+		},
+		"archive-1y-glacier": {
+			Rules: []*common.LifecycleRule{
+				{Prefix: "logs/", Transitions: []*common.LifecycleTransition{{Days: 365, StorageClass: "GLACIER"}}},
+				{ID: "old-versions", NoncurrentExpirationDays: 7},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	expected := []*s3.LifecycleRule{
+		{
+			ID:                             aws.String("scratch-30d-rule"),
+			Status:                         aws.String("Enabled"),
+			Filter:                         &s3.LifecycleRuleFilter{Prefix: aws.String("")},
+			Expiration:                     &s3.LifecycleExpiration{Days: aws.Int64(30)},
+			AbortIncompleteMultipartUpload: &s3.AbortIncompleteMultipartUpload{DaysAfterInitiation: aws.Int64(1)},
+		},
+	}
+	if out := l.GetRules("scratch-30d"); !reflect.DeepEqual(expected, out) {
+		t.Errorf("expected %+v, got %+v", expected, out)
+	}
+
+	expected = []*s3.LifecycleRule{
+		{
+			ID:          aws.String("archive-1y-glacier-rule-1"),
+			Status:      aws.String("Enabled"),
+			Filter:      &s3.LifecycleRuleFilter{Prefix: aws.String("logs/")},
+			Transitions: []*s3.Transition{{Days: aws.Int64(365), StorageClass: aws.String("GLACIER")}},
+		},
+		{
+			ID:                          aws.String("old-versions"),
+			Status:                      aws.String("Enabled"),
+			Filter:                      &s3.LifecycleRuleFilter{Prefix: aws.String("")},
+			NoncurrentVersionExpiration: &s3.NoncurrentVersionExpiration{NoncurrentDays: aws.Int64(7)},
+		},
+	}
+	if out := l.GetRules("archive-1y-glacier"); !reflect.DeepEqual(expected, out) {
+		t.Errorf("expected %+v, got %+v", expected, out)
+	}
+
+	// the built in lifecycles are still supported
+	if out := l.GetRules("deep-archive"); len(out) != 1 || aws.StringValue(out[0].ID) != "deep-archive-rule" {
+		t.Errorf("expected the deep-archive rule, got %+v", out)
+	}
+
+	if l.Supported("forever") || l.GetRules("forever") != nil {
+		t.Error("expected an unknown lifecycle not to be supported")
+	}
+
+	names := []string{}
+	for _, lc := range l.List() {
+		names = append(names, lc.Name)
+	}
+	if !reflect.DeepEqual([]string{"archive-1y-glacier", "deep-archive", "scratch-30d"}, names) {
+		t.Errorf("expected the lifecycles sorted by name, got %v", names)
+	}
+
+	tests := []struct {
+		name      string
+		templates map[string]*common.LifecycleTemplate
+	}{
+		{"invalid name", map[string]*common.LifecycleTemplate{"Scratch": {Rules: []*common.LifecycleRule{{ExpirationDays: 1}}}}},
+		{"built in name", map[string]*common.LifecycleTemplate{"deep-archive": {Rules: []*common.LifecycleRule{{ExpirationDays: 1}}}}},
+		{"no rules", map[string]*common.LifecycleTemplate{"empty": {}}},
+		{"no actions", map[string]*common.LifecycleTemplate{"noop": {Rules: []*common.LifecycleRule{{Prefix: "foo/"}}}}},
+		{"invalid storage class", map[string]*common.LifecycleTemplate{"cold": {Rules: []*common.LifecycleRule{{Transitions: []*common.LifecycleTransition{{Days: 1, StorageClass: "FREEZER"}}}}}}},
+		{"duplicate ids", map[string]*common.LifecycleTemplate{"dupes": {Rules: []*common.LifecycleRule{{ID: "a", ExpirationDays: 1}, {ID: "a", ExpirationDays: 2}}}}},
+		{"negative days", map[string]*common.LifecycleTemplate{"negative": {Rules: []*common.LifecycleRule{{ExpirationDays: -1, NoncurrentExpirationDays: 1}}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := l.AddTemplates(tt.templates); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestLifecyclesName(t *testing.T) {
+	l := SupportedLifecycles{Rules: Lifecycles.Rules}
+	if err := l.AddTemplates(map[string]*common.LifecycleTemplate{
+		"two-rules": {Rules: []*common.LifecycleRule{{ExpirationDays: 30}, {AbortIncompleteMultipartUploadDays: 1}}},
+	}); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	tests := []struct {
+		rules    []*s3.LifecycleRule
+		expected string
+	}{
+		{[]*s3.LifecycleRule{}, ""},
+		{[]*s3.LifecycleRule{{ID: aws.String("deep-archive-rule")}}, "deep-archive"},
+		{[]*s3.LifecycleRule{{ID: aws.String("two-rules-rule-2")}, {ID: aws.String("two-rules-rule-1")}}, "two-rules"},
+		{[]*s3.LifecycleRule{{ID: aws.String("two-rules-rule-1")}}, ""},
+		{[]*s3.LifecycleRule{{ID: aws.String("custom-rule")}}, ""},
+		{[]*s3.LifecycleRule{{ID: aws.String("deep-archive-rule")}, ScratchExpirationRule(30)}, "deep-archive"},
+		{[]*s3.LifecycleRule{ScratchExpirationRule(30)}, ""},
+	}
+
+	for _, tt := range tests {
+		if out := l.Name(tt.rules); out != tt.expected {
+			t.Errorf("expected lifecycle name %q, got %q", tt.expected, out)
+		}
+	}
+}
+
+func TestScratchExpirationRule(t *testing.T) {
+	rule := ScratchExpirationRule(30)
+	if aws.StringValue(rule.ID) != ScratchRuleID {
+		t.Errorf("expected rule id %s, got %s", ScratchRuleID, aws.StringValue(rule.ID))
+	}
+
+	if days := aws.Int64Value(rule.Expiration.Days); days != 30 {
+		t.Errorf("expected objects to expire after 30 days, got %d", days)
+	}
+
+	if rule.NoncurrentVersionExpiration == nil || rule.AbortIncompleteMultipartUpload == nil {
+		t.Errorf("expected noncurrent versions and incomplete uploads to expire, got %+v", rule)
+	}
+}
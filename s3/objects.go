@@ -3,6 +3,7 @@ package s3
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/YaleSpinup/apierror"
@@ -112,3 +113,153 @@ func (s *S3) DeleteObject(ctx context.Context, input *s3.DeleteObjectInput) (*s3
 
 	return out, nil
 }
+
+// ListObjects lists a single page of objects in a bucket, the NextContinuationToken of the output is passed in the
+// input to get the next page
+func (s *S3) ListObjects(ctx context.Context, input *s3.ListObjectsV2Input) (*s3.ListObjectsV2Output, error) {
+	if input == nil || aws.StringValue(input.Bucket) == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", errors.New("missing bucket name"))
+	}
+
+	log.Debugf("listing objects in bucket %s", aws.StringValue(input.Bucket))
+
+	out, err := s.Service.ListObjectsV2WithContext(ctx, input)
+	if err != nil {
+		return nil, ErrCode("failed to list objects in bucket "+aws.StringValue(input.Bucket), err)
+	}
+
+	return out, nil
+}
+
+// BucketUsage counts the objects in a bucket and adds up their size, stopping after max objects.  The usage is
+// truncated if there are more objects in the bucket than were counted.
+func (s *S3) BucketUsage(ctx context.Context, bucket string, max int64) (objects, size int64, truncated bool, err error) {
+	if bucket == "" || max <= 0 {
+		return 0, 0, false, apierror.New(apierror.ErrBadRequest, "invalid input", errors.New("missing bucket name or max"))
+	}
+
+	log.Infof("counting up to %d objects in bucket %s", max, bucket)
+
+	input := &s3.ListObjectsV2Input{Bucket: aws.String(bucket)}
+	err = s.Service.ListObjectsV2PagesWithContext(ctx, input,
+		func(out *s3.ListObjectsV2Output, lastPage bool) bool {
+			for _, o := range out.Contents {
+				if objects >= max {
+					truncated = true
+					return false
+				}
+
+				objects++
+				size += aws.Int64Value(o.Size)
+			}
+
+			if objects >= max && !lastPage {
+				truncated = true
+				return false
+			}
+
+			return true
+		})
+	if err != nil {
+		return 0, 0, false, ErrCode("failed to count objects in bucket "+bucket, err)
+	}
+
+	return objects, size, truncated, nil
+}
+
+// HeadObject gets the metadata of an object, including its encryption and storage class
+func (s *S3) HeadObject(ctx context.Context, bucket, key string) (*s3.HeadObjectOutput, error) {
+	if bucket == "" || key == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", errors.New("missing bucket or key name"))
+	}
+
+	log.Debugf("getting metadata for object s3:%s/%s", bucket, key)
+
+	out, err := s.Service.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, ErrCode("failed to get metadata for object "+key, err)
+	}
+
+	return out, nil
+}
+
+// CopyObject copies an object, ie. in place to change its encryption
+func (s *S3) CopyObject(ctx context.Context, input *s3.CopyObjectInput) (*s3.CopyObjectOutput, error) {
+	if input == nil {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", errors.New("empty input"))
+	}
+
+	if aws.StringValue(input.Bucket) == "" || aws.StringValue(input.Key) == "" || aws.StringValue(input.CopySource) == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", errors.New("missing bucket, key or copy source"))
+	}
+
+	log.Infof("copying object %s to s3:%s/%s", aws.StringValue(input.CopySource), aws.StringValue(input.Bucket), aws.StringValue(input.Key))
+
+	out, err := s.Service.CopyObjectWithContext(ctx, input)
+	if err != nil {
+		return nil, ErrCode("failed to copy object "+aws.StringValue(input.Key), err)
+	}
+
+	return out, nil
+}
+
+// EmptyBucket deletes every object version and delete marker in a bucket, one page of versions at a time, and
+// returns the number deleted
+func (s *S3) EmptyBucket(ctx context.Context, bucket string) (int, error) {
+	if bucket == "" {
+		return 0, apierror.New(apierror.ErrBadRequest, "invalid input", errors.New("missing bucket name"))
+	}
+
+	log.Infof("emptying bucket %s", bucket)
+
+	deleted := 0
+	var deleteErr error
+	err := s.Service.ListObjectVersionsPagesWithContext(ctx, &s3.ListObjectVersionsInput{Bucket: aws.String(bucket)},
+		func(out *s3.ListObjectVersionsOutput, lastPage bool) bool {
+			objects := []*s3.ObjectIdentifier{}
+			for _, v := range out.Versions {
+				objects = append(objects, &s3.ObjectIdentifier{Key: v.Key, VersionId: v.VersionId})
+			}
+
+			for _, m := range out.DeleteMarkers {
+				objects = append(objects, &s3.ObjectIdentifier{Key: m.Key, VersionId: m.VersionId})
+			}
+
+			if len(objects) == 0 {
+				return true
+			}
+
+			result, err := s.Service.DeleteObjectsWithContext(ctx, &s3.DeleteObjectsInput{
+				Bucket: aws.String(bucket),
+				Delete: &s3.Delete{Objects: objects, Quiet: aws.Bool(true)},
+			})
+			if err != nil {
+				deleteErr = ErrCode("failed to delete objects from bucket "+bucket, err)
+				return false
+			}
+
+			if len(result.Errors) > 0 {
+				e := result.Errors[0]
+				msg := fmt.Sprintf("failed to delete %d objects from bucket %s, ie. %s: %s", len(result.Errors), bucket, aws.StringValue(e.Key), aws.StringValue(e.Message))
+				deleteErr = apierror.New(apierror.ErrInternalError, msg, nil)
+				return false
+			}
+
+			deleted += len(objects)
+			return true
+		})
+	if err != nil {
+		return deleted, ErrCode("failed to list object versions in bucket "+bucket, err)
+	}
+
+	if deleteErr != nil {
+		return deleted, deleteErr
+	}
+
+	log.Infof("deleted %d object versions from bucket %s", deleted, bucket)
+
+	return deleted, nil
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 )
 
 var testObjectTags = []*s3.Tag{
@@ -424,3 +425,218 @@ func TestDeleteObject(t *testing.T) {
 		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
 	}
 }
+
+func (m *mockS3Client) HeadObjectWithContext(ctx context.Context, input *s3.HeadObjectInput, opts ...request.Option) (*s3.HeadObjectOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return &s3.HeadObjectOutput{
+		ContentLength:        aws.Int64(2),
+		ServerSideEncryption: aws.String("AES256"),
+	}, nil
+}
+
+func (m *mockS3Client) CopyObjectWithContext(ctx context.Context, input *s3.CopyObjectInput, opts ...request.Option) (*s3.CopyObjectOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return &s3.CopyObjectOutput{ServerSideEncryption: input.ServerSideEncryption}, nil
+}
+
+func TestListObjects(t *testing.T) {
+	s := S3{Service: newMockS3Client(t, nil)}
+
+	out, err := s.ListObjects(context.TODO(), &s3.ListObjectsV2Input{Bucket: aws.String("testBucketNotEmpty")})
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if aws.Int64Value(out.KeyCount) != 1 {
+		t.Errorf("expected 1 key, got %d", aws.Int64Value(out.KeyCount))
+	}
+
+	if _, err := s.ListObjects(context.TODO(), &s3.ListObjectsV2Input{}); err == nil {
+		t.Error("expected error for missing bucket, got nil")
+	}
+
+	// test non-aws error
+	s.Service.(*mockS3Client).err = errors.New("things blowing up!")
+	_, err = s.ListObjects(context.TODO(), &s3.ListObjectsV2Input{Bucket: aws.String("testBucket")})
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrInternalError {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrInternalError, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+}
+
+func TestBucketUsage(t *testing.T) {
+	s := S3{Service: newMockS3Client(t, nil)}
+
+	objects, size, truncated, err := s.BucketUsage(context.TODO(), "testBucketNotEmpty", 10)
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if objects != 4 || size != 1000 || truncated {
+		t.Errorf("expected 4 objects with 1000 bytes, got %d objects with %d bytes (truncated %t)", objects, size, truncated)
+	}
+
+	// test stopping at the max
+	objects, size, truncated, err = s.BucketUsage(context.TODO(), "testBucketNotEmpty", 2)
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if objects != 2 || size != 300 || !truncated {
+		t.Errorf("expected 2 objects with 300 bytes truncated, got %d objects with %d bytes (truncated %t)", objects, size, truncated)
+	}
+
+	if _, _, _, err := s.BucketUsage(context.TODO(), "", 10); err == nil {
+		t.Error("expected error for missing bucket, got nil")
+	}
+
+	// test non-aws error
+	s.Service.(*mockS3Client).err = errors.New("things blowing up!")
+	_, _, _, err = s.BucketUsage(context.TODO(), "testBucketNotEmpty", 10)
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrInternalError {
+		t.Errorf("expected error code %s, got: %v", apierror.ErrInternalError, err)
+	}
+}
+
+func TestHeadObject(t *testing.T) {
+	s := S3{Service: newMockS3Client(t, nil)}
+
+	out, err := s.HeadObject(context.TODO(), "testBucket", "index.html")
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if aws.StringValue(out.ServerSideEncryption) != "AES256" {
+		t.Errorf("expected AES256 encryption, got %s", aws.StringValue(out.ServerSideEncryption))
+	}
+
+	if _, err := s.HeadObject(context.TODO(), "testBucket", ""); err == nil {
+		t.Error("expected error for missing key, got nil")
+	}
+
+	// test ErrCodeNoSuchKey
+	s.Service.(*mockS3Client).err = awserr.New(s3.ErrCodeNoSuchKey, "not found", nil)
+	_, err = s.HeadObject(context.TODO(), "testBucket", "index.html")
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrNotFound {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrNotFound, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+}
+
+func TestCopyObject(t *testing.T) {
+	s := S3{Service: newMockS3Client(t, nil)}
+
+	input := &s3.CopyObjectInput{
+		Bucket:               aws.String("testBucket"),
+		Key:                  aws.String("index.html"),
+		CopySource:           aws.String("testBucket/index.html"),
+		ServerSideEncryption: aws.String("AES256"),
+	}
+
+	out, err := s.CopyObject(context.TODO(), input)
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if aws.StringValue(out.ServerSideEncryption) != "AES256" {
+		t.Errorf("expected AES256 encryption, got %s", aws.StringValue(out.ServerSideEncryption))
+	}
+
+	if _, err := s.CopyObject(context.TODO(), nil); err == nil {
+		t.Error("expected error for nil input, got nil")
+	}
+
+	if _, err := s.CopyObject(context.TODO(), &s3.CopyObjectInput{Bucket: aws.String("testBucket"), Key: aws.String("index.html")}); err == nil {
+		t.Error("expected error for missing copy source, got nil")
+	}
+
+	// test non-aws error
+	s.Service.(*mockS3Client).err = errors.New("things blowing up!")
+	_, err = s.CopyObject(context.TODO(), input)
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrInternalError {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrInternalError, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %s", reflect.TypeOf(err).String())
+	}
+}
+
+// mockEmptyS3Client is an s3 client with a bucket of object versions that can be deleted
+type mockEmptyS3Client struct {
+	s3iface.S3API
+	versions []*s3.ObjectVersion
+	markers  []*s3.DeleteMarkerEntry
+	failKey  string
+}
+
+func (m *mockEmptyS3Client) ListObjectVersionsPagesWithContext(ctx context.Context, input *s3.ListObjectVersionsInput, fn func(*s3.ListObjectVersionsOutput, bool) bool, opts ...request.Option) error {
+	// return a page for each version so deletes are batched per page
+	for i, v := range m.versions {
+		out := &s3.ListObjectVersionsOutput{Versions: []*s3.ObjectVersion{v}}
+		if i == 0 {
+			out.DeleteMarkers = m.markers
+		}
+		if !fn(out, i == len(m.versions)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func (m *mockEmptyS3Client) DeleteObjectsWithContext(ctx context.Context, input *s3.DeleteObjectsInput, opts ...request.Option) (*s3.DeleteObjectsOutput, error) {
+	out := &s3.DeleteObjectsOutput{}
+	for _, o := range input.Delete.Objects {
+		if aws.StringValue(o.Key) == m.failKey {
+			out.Errors = append(out.Errors, &s3.Error{Key: o.Key, Message: aws.String("access denied")})
+		}
+	}
+	return out, nil
+}
+
+func TestEmptyBucket(t *testing.T) {
+	client := &mockEmptyS3Client{
+		versions: []*s3.ObjectVersion{
+			{Key: aws.String("a.txt"), VersionId: aws.String("1")},
+			{Key: aws.String("a.txt"), VersionId: aws.String("2")},
+			{Key: aws.String("b.txt"), VersionId: aws.String("1")},
+		},
+		markers: []*s3.DeleteMarkerEntry{{Key: aws.String("c.txt"), VersionId: aws.String("3")}},
+	}
+	s := S3{Service: client}
+
+	deleted, err := s.EmptyBucket(context.TODO(), "scratch")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if deleted != 4 {
+		t.Errorf("expected 4 versions to be deleted, got %d", deleted)
+	}
+
+	if _, err := s.EmptyBucket(context.TODO(), ""); err == nil {
+		t.Error("expected an error for an empty bucket name")
+	}
+
+	client.failKey = "b.txt"
+	deleted, err = s.EmptyBucket(context.TODO(), "scratch")
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrInternalError {
+		t.Errorf("expected an internal error when objects fail to delete, got %v", err)
+	}
+
+	if deleted != 3 {
+		t.Errorf("expected the 3 versions before the failure to be deleted, got %d", deleted)
+	}
+}
@@ -0,0 +1,146 @@
+package s3control
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3control"
+	log "github.com/sirupsen/logrus"
+)
+
+// AccessPointArn returns the arn of an access point in the account
+func (s *S3Control) AccessPointArn(name string) string {
+	return fmt.Sprintf("arn:aws:s3:%s:%s:accesspoint/%s", s.Region, s.AccountID, name)
+}
+
+// CreateAccessPoint creates an access point for a bucket, it's restricted to requests from the vpc if one is passed.
+// Public access is always blocked for the access point.  The arn and alias of the access point are returned.
+func (s *S3Control) CreateAccessPoint(ctx context.Context, bucket, name, vpcId string) (string, string, error) {
+	if bucket == "" || name == "" {
+		return "", "", apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("creating access point %s for bucket %s", name, bucket)
+
+	input := &s3control.CreateAccessPointInput{
+		AccountId: aws.String(s.AccountID),
+		Bucket:    aws.String(bucket),
+		Name:      aws.String(name),
+		PublicAccessBlockConfiguration: &s3control.PublicAccessBlockConfiguration{
+			BlockPublicAcls:       aws.Bool(true),
+			BlockPublicPolicy:     aws.Bool(true),
+			IgnorePublicAcls:      aws.Bool(true),
+			RestrictPublicBuckets: aws.Bool(true),
+		},
+	}
+
+	if vpcId != "" {
+		input.VpcConfiguration = &s3control.VpcConfiguration{VpcId: aws.String(vpcId)}
+	}
+
+	out, err := s.Service.CreateAccessPointWithContext(ctx, input)
+	if err != nil {
+		return "", "", ErrCode("failed to create access point "+name, err)
+	}
+
+	return aws.StringValue(out.AccessPointArn), aws.StringValue(out.Alias), nil
+}
+
+// GetAccessPoint gets the configuration of an access point
+func (s *S3Control) GetAccessPoint(ctx context.Context, name string) (*s3control.GetAccessPointOutput, error) {
+	if name == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("getting access point %s", name)
+
+	out, err := s.Service.GetAccessPointWithContext(ctx, &s3control.GetAccessPointInput{
+		AccountId: aws.String(s.AccountID),
+		Name:      aws.String(name),
+	})
+	if err != nil {
+		return nil, ErrCode("failed to get access point "+name, err)
+	}
+
+	return out, nil
+}
+
+// ListAccessPoints lists the access points for a bucket
+func (s *S3Control) ListAccessPoints(ctx context.Context, bucket string) ([]*s3control.AccessPoint, error) {
+	if bucket == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("listing access points for bucket %s", bucket)
+
+	accessPoints := []*s3control.AccessPoint{}
+	if err := s.Service.ListAccessPointsPagesWithContext(ctx, &s3control.ListAccessPointsInput{
+		AccountId: aws.String(s.AccountID),
+		Bucket:    aws.String(bucket),
+	}, func(out *s3control.ListAccessPointsOutput, lastPage bool) bool {
+		accessPoints = append(accessPoints, out.AccessPointList...)
+		return true
+	}); err != nil {
+		return nil, ErrCode("failed to list access points for bucket "+bucket, err)
+	}
+
+	return accessPoints, nil
+}
+
+// DeleteAccessPoint deletes an access point, the objects in the bucket aren't affected
+func (s *S3Control) DeleteAccessPoint(ctx context.Context, name string) error {
+	if name == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("deleting access point %s", name)
+
+	if _, err := s.Service.DeleteAccessPointWithContext(ctx, &s3control.DeleteAccessPointInput{
+		AccountId: aws.String(s.AccountID),
+		Name:      aws.String(name),
+	}); err != nil {
+		return ErrCode("failed to delete access point "+name, err)
+	}
+
+	return nil
+}
+
+// PutAccessPointPolicy replaces the policy of an access point
+func (s *S3Control) PutAccessPointPolicy(ctx context.Context, name string, policy []byte) error {
+	if name == "" || len(policy) == 0 {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("putting policy for access point %s", name)
+
+	if _, err := s.Service.PutAccessPointPolicyWithContext(ctx, &s3control.PutAccessPointPolicyInput{
+		AccountId: aws.String(s.AccountID),
+		Name:      aws.String(name),
+		Policy:    aws.String(string(policy)),
+	}); err != nil {
+		return ErrCode("failed to put policy for access point "+name, err)
+	}
+
+	return nil
+}
+
+// GetAccessPointPolicy gets the policy of an access point
+func (s *S3Control) GetAccessPointPolicy(ctx context.Context, name string) (string, error) {
+	if name == "" {
+		return "", apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("getting policy for access point %s", name)
+
+	out, err := s.Service.GetAccessPointPolicyWithContext(ctx, &s3control.GetAccessPointPolicyInput{
+		AccountId: aws.String(s.AccountID),
+		Name:      aws.String(name),
+	})
+	if err != nil {
+		return "", ErrCode("failed to get policy for access point "+name, err)
+	}
+
+	return aws.StringValue(out.Policy), nil
+}
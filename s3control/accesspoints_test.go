@@ -0,0 +1,174 @@
+package s3control
+
+import (
+	"context"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3control"
+)
+
+func (m *mockS3ControlClient) CreateAccessPointWithContext(ctx context.Context, input *s3control.CreateAccessPointInput, opts ...request.Option) (*s3control.CreateAccessPointOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if aws.StringValue(input.AccountId) != "012345678910" {
+		m.t.Errorf("expected account 012345678910, got %s", aws.StringValue(input.AccountId))
+	}
+
+	if pab := input.PublicAccessBlockConfiguration; pab == nil || !aws.BoolValue(pab.BlockPublicPolicy) {
+		m.t.Errorf("expected public access to be blocked, got %+v", pab)
+	}
+
+	return &s3control.CreateAccessPointOutput{
+		AccessPointArn: aws.String("arn:aws:s3:us-east-1:012345678910:accesspoint/" + aws.StringValue(input.Name)),
+		Alias:          aws.String(aws.StringValue(input.Name) + "-abcdef-s3alias"),
+	}, nil
+}
+
+func (m *mockS3ControlClient) GetAccessPointWithContext(ctx context.Context, input *s3control.GetAccessPointInput, opts ...request.Option) (*s3control.GetAccessPointOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &s3control.GetAccessPointOutput{Bucket: aws.String("dataset"), Name: input.Name, NetworkOrigin: aws.String(s3control.NetworkOriginInternet)}, nil
+}
+
+func (m *mockS3ControlClient) ListAccessPointsPagesWithContext(ctx context.Context, input *s3control.ListAccessPointsInput, fn func(*s3control.ListAccessPointsOutput, bool) bool, opts ...request.Option) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	if !fn(&s3control.ListAccessPointsOutput{AccessPointList: []*s3control.AccessPoint{{Name: aws.String("one"), Bucket: input.Bucket}}}, false) {
+		return nil
+	}
+	fn(&s3control.ListAccessPointsOutput{AccessPointList: []*s3control.AccessPoint{{Name: aws.String("two"), Bucket: input.Bucket}}}, true)
+	return nil
+}
+
+func (m *mockS3ControlClient) DeleteAccessPointWithContext(ctx context.Context, input *s3control.DeleteAccessPointInput, opts ...request.Option) (*s3control.DeleteAccessPointOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &s3control.DeleteAccessPointOutput{}, nil
+}
+
+func (m *mockS3ControlClient) PutAccessPointPolicyWithContext(ctx context.Context, input *s3control.PutAccessPointPolicyInput, opts ...request.Option) (*s3control.PutAccessPointPolicyOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &s3control.PutAccessPointPolicyOutput{}, nil
+}
+
+func (m *mockS3ControlClient) GetAccessPointPolicyWithContext(ctx context.Context, input *s3control.GetAccessPointPolicyInput, opts ...request.Option) (*s3control.GetAccessPointPolicyOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &s3control.GetAccessPointPolicyOutput{Policy: aws.String(`{"Version":"2012-10-17"}`)}, nil
+}
+
+func TestAccessPointArn(t *testing.T) {
+	s := S3Control{AccountID: "012345678910", Region: "us-east-1"}
+	if arn := s.AccessPointArn("team-a"); arn != "arn:aws:s3:us-east-1:012345678910:accesspoint/team-a" {
+		t.Errorf("unexpected access point arn %s", arn)
+	}
+}
+
+func TestCreateAccessPoint(t *testing.T) {
+	s := S3Control{Service: newMockS3ControlClient(t, nil), AccountID: "012345678910"}
+
+	arn, alias, err := s.CreateAccessPoint(context.TODO(), "dataset", "team-a", "vpc-1234")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if arn != "arn:aws:s3:us-east-1:012345678910:accesspoint/team-a" || alias != "team-a-abcdef-s3alias" {
+		t.Errorf("unexpected access point arn %s and alias %s", arn, alias)
+	}
+
+	if _, _, err := s.CreateAccessPoint(context.TODO(), "dataset", "", ""); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Errorf("expected bad request without a name, got %v", err)
+	}
+
+	s.Service.(*mockS3ControlClient).err = awserr.New("AccessPointAlreadyOwnedByYou", "exists", nil)
+	if _, _, err := s.CreateAccessPoint(context.TODO(), "dataset", "team-a", ""); !common.IsErrorCode(err, apierror.ErrConflict) {
+		t.Errorf("expected conflict for an existing access point, got %v", err)
+	}
+}
+
+func TestGetAccessPoint(t *testing.T) {
+	s := S3Control{Service: newMockS3ControlClient(t, nil), AccountID: "012345678910"}
+
+	out, err := s.GetAccessPoint(context.TODO(), "team-a")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if aws.StringValue(out.Bucket) != "dataset" {
+		t.Errorf("expected access point for bucket dataset, got %s", aws.StringValue(out.Bucket))
+	}
+
+	s.Service.(*mockS3ControlClient).err = awserr.New("NoSuchAccessPoint", "not found", nil)
+	if _, err := s.GetAccessPoint(context.TODO(), "team-a"); !common.IsErrorCode(err, apierror.ErrNotFound) {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
+
+func TestListAccessPoints(t *testing.T) {
+	s := S3Control{Service: newMockS3ControlClient(t, nil), AccountID: "012345678910"}
+
+	out, err := s.ListAccessPoints(context.TODO(), "dataset")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if len(out) != 2 {
+		t.Errorf("expected access points from both pages, got %d", len(out))
+	}
+
+	if _, err := s.ListAccessPoints(context.TODO(), ""); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Errorf("expected bad request without a bucket, got %v", err)
+	}
+
+	s.Service.(*mockS3ControlClient).err = awserr.New(s3control.ErrCodeTooManyRequestsException, "slow down", nil)
+	if _, err := s.ListAccessPoints(context.TODO(), "dataset"); !common.IsErrorCode(err, apierror.ErrLimitExceeded) {
+		t.Errorf("expected limit exceeded, got %v", err)
+	}
+}
+
+func TestDeleteAccessPoint(t *testing.T) {
+	s := S3Control{Service: newMockS3ControlClient(t, nil), AccountID: "012345678910"}
+
+	if err := s.DeleteAccessPoint(context.TODO(), "team-a"); err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	if err := s.DeleteAccessPoint(context.TODO(), ""); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Errorf("expected bad request without a name, got %v", err)
+	}
+}
+
+func TestAccessPointPolicy(t *testing.T) {
+	s := S3Control{Service: newMockS3ControlClient(t, nil), AccountID: "012345678910"}
+
+	if err := s.PutAccessPointPolicy(context.TODO(), "team-a", []byte(`{"Version":"2012-10-17"}`)); err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	if err := s.PutAccessPointPolicy(context.TODO(), "team-a", nil); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Errorf("expected bad request without a policy, got %v", err)
+	}
+
+	policy, err := s.GetAccessPointPolicy(context.TODO(), "team-a")
+	if err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	if policy != `{"Version":"2012-10-17"}` {
+		t.Errorf("unexpected policy %s", policy)
+	}
+}
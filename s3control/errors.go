@@ -0,0 +1,70 @@
+package s3control
+
+import (
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3control"
+	"github.com/pkg/errors"
+)
+
+// ErrCode processes the error codes comming back from s3 control and converts them into apierror, a
+// standardized form consumable by downstream systems.
+func ErrCode(msg string, err error) error {
+	if aerr, ok := errors.Cause(err).(awserr.Error); ok {
+		switch aerr.Code() {
+		case
+			// AccessDenied is returned when the request isn't allowed by the session's policies
+			"AccessDenied":
+
+			return apierror.New(apierror.ErrForbidden, msg, aerr)
+		case
+			// s3control.ErrCodeNotFoundException for service response error code
+			// "NotFoundException".
+			s3control.ErrCodeNotFoundException,
+
+			// NoSuchAccessPoint is returned when the access point doesn't exist
+			"NoSuchAccessPoint",
+
+			// NoSuchAccessPointPolicy is returned when the access point doesn't have a policy
+			"NoSuchAccessPointPolicy",
+
+			// NoSuchBucket is returned when the bucket for a new access point doesn't exist
+			"NoSuchBucket":
+
+			return apierror.New(apierror.ErrNotFound, msg, aerr)
+		case
+			// AccessPointAlreadyOwnedByYou is returned when an access point with the name already exists in
+			// the account
+			"AccessPointAlreadyOwnedByYou",
+
+			// s3control.ErrCodeIdempotencyException for service response error code
+			// "IdempotencyException".
+			s3control.ErrCodeIdempotencyException:
+
+			return apierror.New(apierror.ErrConflict, msg, aerr)
+		case
+			// s3control.ErrCodeTooManyRequestsException for service response error code
+			// "TooManyRequestsException".
+			s3control.ErrCodeTooManyRequestsException,
+
+			// SlowDown is returned when the request rate is too high
+			"SlowDown":
+
+			return apierror.New(apierror.ErrLimitExceeded, msg, aerr)
+		case
+			// s3control.ErrCodeInternalServiceException for service response error code
+			// "InternalServiceException".
+			s3control.ErrCodeInternalServiceException,
+
+			// ServiceUnavailable is returned when the service can't handle the request
+			"ServiceUnavailable":
+
+			return apierror.New(apierror.ErrServiceUnavailable, msg, aerr)
+		default:
+			m := msg + ": " + aerr.Message()
+			return apierror.New(apierror.ErrBadRequest, m, aerr)
+		}
+	}
+
+	return apierror.New(apierror.ErrInternalError, msg, err)
+}
@@ -0,0 +1,40 @@
+package s3control
+
+import (
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3control"
+	"github.com/aws/aws-sdk-go/service/s3control/s3controliface"
+	log "github.com/sirupsen/logrus"
+)
+
+// S3Control is a wrapper around the aws s3 control service for an account
+type S3Control struct {
+	Service s3controliface.S3ControlAPI
+	// AccountID is the id of the account the access points are managed in, every s3 control request is made
+	// on behalf of an account
+	AccountID string
+	// Region is the region of the buckets and their access points
+	Region string
+}
+
+// NewSession creates a new s3 control session for the account
+func NewSession(sess *session.Session, account common.Account, accountId string) S3Control {
+	s := S3Control{
+		AccountID: accountId,
+		Region:    account.Region,
+	}
+
+	if sess == nil {
+		log.Infof("creating new aws session for s3control with key id %s in region %s", account.Akid, account.Region)
+		sess = session.Must(session.NewSession(&aws.Config{
+			Credentials: credentials.NewStaticCredentials(account.Akid, account.Secret, ""),
+			Region:      aws.String(account.Region),
+		}))
+	}
+	s.Service = s3control.New(sess)
+
+	return s
+}
@@ -0,0 +1,35 @@
+package s3control
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/service/s3control/s3controliface"
+)
+
+// mockS3ControlClient is a fake s3 control client
+type mockS3ControlClient struct {
+	s3controliface.S3ControlAPI
+	t   *testing.T
+	err error
+}
+
+func newMockS3ControlClient(t *testing.T, err error) s3controliface.S3ControlAPI {
+	return &mockS3ControlClient{
+		t:   t,
+		err: err,
+	}
+}
+
+func TestNewSession(t *testing.T) {
+	e := NewSession(nil, common.Account{Region: "us-east-1"}, "012345678910")
+	to := reflect.TypeOf(e).String()
+	if to != "s3control.S3Control" {
+		t.Errorf("expected type to be 's3control.S3Control', got %s", to)
+	}
+
+	if e.AccountID != "012345678910" || e.Region != "us-east-1" {
+		t.Errorf("expected account 012345678910 in us-east-1, got %s in %s", e.AccountID, e.Region)
+	}
+}
@@ -0,0 +1,74 @@
+package secretsmanager
+
+import (
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/pkg/errors"
+)
+
+// ErrCode processes the error codes comming back from secrets manager and converts them into apierror, a
+// standardized form consumable by downstream systems.
+func ErrCode(msg string, err error) error {
+	if aerr, ok := errors.Cause(err).(awserr.Error); ok {
+		switch aerr.Code() {
+		case
+			// secretsmanager.ErrCodeEncryptionFailure for service response error code
+			// "EncryptionFailure".
+			//
+			// Secrets Manager can't encrypt the protected secret text using the provided
+			// KMS key.
+			secretsmanager.ErrCodeEncryptionFailure,
+
+			// secretsmanager.ErrCodeDecryptionFailure for service response error code
+			// "DecryptionFailure".
+			//
+			// Secrets Manager can't decrypt the protected secret text using the provided
+			// KMS key.
+			secretsmanager.ErrCodeDecryptionFailure,
+
+			"AccessDeniedException":
+
+			return apierror.New(apierror.ErrForbidden, msg, aerr)
+		case
+			// secretsmanager.ErrCodeResourceExistsException for service response error code
+			// "ResourceExistsException".
+			//
+			// A resource with the ID you requested already exists.
+			secretsmanager.ErrCodeResourceExistsException:
+
+			return apierror.New(apierror.ErrConflict, msg, aerr)
+		case
+			// secretsmanager.ErrCodeResourceNotFoundException for service response error code
+			// "ResourceNotFoundException".
+			//
+			// Secrets Manager can't find the resource that you asked for.
+			secretsmanager.ErrCodeResourceNotFoundException:
+
+			return apierror.New(apierror.ErrNotFound, msg, aerr)
+		case
+			// secretsmanager.ErrCodeLimitExceededException for service response error code
+			// "LimitExceededException".
+			//
+			// The request failed because it would exceed one of the Secrets Manager quotas.
+			secretsmanager.ErrCodeLimitExceededException,
+
+			"ThrottlingException":
+
+			return apierror.New(apierror.ErrLimitExceeded, msg, aerr)
+		case
+			// secretsmanager.ErrCodeInternalServiceError for service response error code
+			// "InternalServiceError".
+			//
+			// An error occurred on the server side.
+			secretsmanager.ErrCodeInternalServiceError:
+
+			return apierror.New(apierror.ErrServiceUnavailable, msg, aerr)
+		default:
+			m := msg + ": " + aerr.Message()
+			return apierror.New(apierror.ErrBadRequest, m, aerr)
+		}
+	}
+
+	return apierror.New(apierror.ErrInternalError, msg, err)
+}
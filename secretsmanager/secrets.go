@@ -0,0 +1,62 @@
+package secretsmanager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	log "github.com/sirupsen/logrus"
+)
+
+// CreateSecret creates a secret with a string value, encrypted with the kms key if one is passed, and returns its arn
+func (s *SecretsManager) CreateSecret(ctx context.Context, name, description, value, kmsKeyId string, tags map[string]string) (string, error) {
+	if name == "" || value == "" {
+		return "", apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("creating secret %s", name)
+
+	input := secretsmanager.CreateSecretInput{
+		Name:         aws.String(name),
+		SecretString: aws.String(value),
+	}
+
+	if description != "" {
+		input.Description = aws.String(description)
+	}
+
+	if kmsKeyId != "" {
+		input.KmsKeyId = aws.String(kmsKeyId)
+	}
+
+	for k, v := range tags {
+		input.Tags = append(input.Tags, &secretsmanager.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+
+	out, err := s.Service.CreateSecretWithContext(ctx, &input)
+	if err != nil {
+		return "", ErrCode(fmt.Sprintf("failed to create secret %s", name), err)
+	}
+
+	return aws.StringValue(out.ARN), nil
+}
+
+// DeleteSecret deletes a secret immediately, without a recovery window
+func (s *SecretsManager) DeleteSecret(ctx context.Context, name string) error {
+	if name == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("deleting secret %s", name)
+
+	if _, err := s.Service.DeleteSecretWithContext(ctx, &secretsmanager.DeleteSecretInput{
+		SecretId:                   aws.String(name),
+		ForceDeleteWithoutRecovery: aws.Bool(true),
+	}); err != nil {
+		return ErrCode(fmt.Sprintf("failed to delete secret %s", name), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package secretsmanager
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+)
+
+func (m *mockSecretsManagerClient) CreateSecretWithContext(ctx aws.Context, input *secretsmanager.CreateSecretInput, opts ...request.Option) (*secretsmanager.CreateSecretOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	name := aws.StringValue(input.Name)
+	if _, ok := m.secrets[name]; ok {
+		return nil, awserr.New(secretsmanager.ErrCodeResourceExistsException, "secret exists", nil)
+	}
+
+	if aws.StringValue(input.KmsKeyId) != "" && aws.StringValue(input.KmsKeyId) != "alias/spinup" {
+		m.t.Errorf("unexpected kms key %s", aws.StringValue(input.KmsKeyId))
+	}
+
+	m.secrets[name] = aws.StringValue(input.SecretString)
+
+	return &secretsmanager.CreateSecretOutput{
+		ARN:  aws.String("arn:aws:secretsmanager:us-east-1:012345678901:secret:" + name + "-AbCdEf"),
+		Name: input.Name,
+	}, nil
+}
+
+func (m *mockSecretsManagerClient) DeleteSecretWithContext(ctx aws.Context, input *secretsmanager.DeleteSecretInput, opts ...request.Option) (*secretsmanager.DeleteSecretOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if !aws.BoolValue(input.ForceDeleteWithoutRecovery) {
+		m.t.Error("expected the secret to be deleted without recovery")
+	}
+
+	name := aws.StringValue(input.SecretId)
+	if _, ok := m.secrets[name]; !ok {
+		return nil, awserr.New(secretsmanager.ErrCodeResourceNotFoundException, "secret not found", nil)
+	}
+	delete(m.secrets, name)
+
+	return &secretsmanager.DeleteSecretOutput{Name: input.SecretId}, nil
+}
+
+func TestCreateSecret(t *testing.T) {
+	client := newMockSecretsManagerClient(t, nil)
+	s := SecretsManager{Service: client}
+
+	// test success
+	arn, err := s.CreateSecret(context.TODO(), "spinup/s3-api/someuser/AKIA123", "credentials", `{"AccessKeyId":"AKIA123"}`, "alias/spinup", map[string]string{"spinup:org": "test"})
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if expected := "arn:aws:secretsmanager:us-east-1:012345678901:secret:spinup/s3-api/someuser/AKIA123-AbCdEf"; arn != expected {
+		t.Errorf("expected arn %s, got %s", expected, arn)
+	}
+
+	if v := client.secrets["spinup/s3-api/someuser/AKIA123"]; v != `{"AccessKeyId":"AKIA123"}` {
+		t.Errorf("unexpected secret value %s", v)
+	}
+
+	// test existing secret
+	if _, err := s.CreateSecret(context.TODO(), "spinup/s3-api/someuser/AKIA123", "", "foo", "", nil); !common.IsErrorCode(err, apierror.ErrConflict) {
+		t.Errorf("expected error code %s, got: %v", apierror.ErrConflict, err)
+	}
+
+	// test empty input
+	if _, err := s.CreateSecret(context.TODO(), "", "", "", "", nil); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Errorf("expected error code %s, got: %v", apierror.ErrBadRequest, err)
+	}
+
+	// test aws error
+	s.Service = newMockSecretsManagerClient(t, awserr.New(secretsmanager.ErrCodeInternalServiceError, "boom", nil))
+	if _, err := s.CreateSecret(context.TODO(), "foo", "", "bar", "", nil); !common.IsErrorCode(err, apierror.ErrServiceUnavailable) {
+		t.Errorf("expected error code %s, got: %v", apierror.ErrServiceUnavailable, err)
+	}
+
+	// test non-aws error
+	s.Service = newMockSecretsManagerClient(t, errors.New("things blowing up"))
+	if _, err := s.CreateSecret(context.TODO(), "foo", "", "bar", "", nil); !common.IsErrorCode(err, apierror.ErrInternalError) {
+		t.Errorf("expected error code %s, got: %v", apierror.ErrInternalError, err)
+	}
+}
+
+func TestDeleteSecret(t *testing.T) {
+	client := newMockSecretsManagerClient(t, nil)
+	client.secrets["spinup/s3-api/someuser/AKIA123"] = "foo"
+	s := SecretsManager{Service: client}
+
+	// test success
+	if err := s.DeleteSecret(context.TODO(), "spinup/s3-api/someuser/AKIA123"); err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if len(client.secrets) != 0 {
+		t.Errorf("expected the secret to be deleted, got %v", client.secrets)
+	}
+
+	// test missing secret
+	if err := s.DeleteSecret(context.TODO(), "spinup/s3-api/someuser/AKIA123"); !common.IsErrorCode(err, apierror.ErrNotFound) {
+		t.Errorf("expected error code %s, got: %v", apierror.ErrNotFound, err)
+	}
+
+	// test empty input
+	if err := s.DeleteSecret(context.TODO(), ""); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Errorf("expected error code %s, got: %v", apierror.ErrBadRequest, err)
+	}
+}
@@ -0,0 +1,30 @@
+package secretsmanager
+
+import (
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	log "github.com/sirupsen/logrus"
+)
+
+// SecretsManager is a wrapper around the aws secrets manager service
+type SecretsManager struct {
+	Service secretsmanageriface.SecretsManagerAPI
+}
+
+// NewSession creates a new secrets manager session
+func NewSession(sess *session.Session, account common.Account) SecretsManager {
+	s := SecretsManager{}
+	if sess == nil {
+		log.Infof("creating new aws session for secrets manager with key id %s in region %s", account.Akid, account.Region)
+		sess = session.Must(session.NewSession(&aws.Config{
+			Credentials: credentials.NewStaticCredentials(account.Akid, account.Secret, ""),
+			Region:      aws.String(account.Region),
+		}))
+	}
+	s.Service = secretsmanager.New(sess)
+	return s
+}
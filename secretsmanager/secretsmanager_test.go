@@ -0,0 +1,33 @@
+package secretsmanager
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+)
+
+// mockSecretsManagerClient is a fake secrets manager client
+type mockSecretsManagerClient struct {
+	secretsmanageriface.SecretsManagerAPI
+	t       *testing.T
+	err     error
+	secrets map[string]string
+}
+
+func newMockSecretsManagerClient(t *testing.T, err error) *mockSecretsManagerClient {
+	return &mockSecretsManagerClient{
+		t:       t,
+		err:     err,
+		secrets: map[string]string{},
+	}
+}
+
+func TestNewSession(t *testing.T) {
+	e := NewSession(nil, common.Account{})
+	to := reflect.TypeOf(e).String()
+	if to != "secretsmanager.SecretsManager" {
+		t.Errorf("expected type to be 'secretsmanager.SecretsManager', got %s", to)
+	}
+}
@@ -0,0 +1,61 @@
+package servicequotas
+
+import (
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/servicequotas"
+	"github.com/pkg/errors"
+)
+
+// ErrCode processes the error codes comming back from service quotas and converts them into apierror, a
+// standardized form consumable by downstream systems.
+func ErrCode(msg string, err error) error {
+	if aerr, ok := errors.Cause(err).(awserr.Error); ok {
+		switch aerr.Code() {
+		case
+			// servicequotas.ErrCodeAccessDeniedException for service response error code
+			// "AccessDeniedException".
+			//
+			// You do not have sufficient permission to perform this action.
+			servicequotas.ErrCodeAccessDeniedException,
+
+			// servicequotas.ErrCodeDependencyAccessDeniedException for service response error code
+			// "DependencyAccessDeniedException".
+			//
+			// You can't perform this action because a dependency does not have access.
+			servicequotas.ErrCodeDependencyAccessDeniedException:
+
+			return apierror.New(apierror.ErrForbidden, msg, aerr)
+		case
+			// servicequotas.ErrCodeNoSuchResourceException for service response error code
+			// "NoSuchResourceException".
+			//
+			// The specified resource does not exist.
+			servicequotas.ErrCodeNoSuchResourceException:
+
+			return apierror.New(apierror.ErrNotFound, msg, aerr)
+		case
+			// servicequotas.ErrCodeTooManyRequestsException for service response error code
+			// "TooManyRequestsException".
+			//
+			// Due to throttling, the request was denied. Slow down the rate of request
+			// calls, or request an increase for this quota.
+			servicequotas.ErrCodeTooManyRequestsException:
+
+			return apierror.New(apierror.ErrLimitExceeded, msg, aerr)
+		case
+			// servicequotas.ErrCodeServiceException for service response error code
+			// "ServiceException".
+			//
+			// Something went wrong.
+			servicequotas.ErrCodeServiceException:
+
+			return apierror.New(apierror.ErrServiceUnavailable, msg, aerr)
+		default:
+			m := msg + ": " + aerr.Message()
+			return apierror.New(apierror.ErrBadRequest, m, aerr)
+		}
+	}
+
+	return apierror.New(apierror.ErrInternalError, msg, err)
+}
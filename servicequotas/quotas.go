@@ -0,0 +1,64 @@
+package servicequotas
+
+import (
+	"context"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/servicequotas"
+	log "github.com/sirupsen/logrus"
+)
+
+// Quota identifies a service quota
+type Quota struct {
+	ServiceCode string
+	QuotaCode   string
+	// Name is the readable name of the quota returned in errors, ie. groups per account
+	Name string
+}
+
+var (
+	// IAMUsers is the quota for the number of IAM users in an account
+	IAMUsers = Quota{ServiceCode: "iam", QuotaCode: "L-F55AF5E4", Name: "users per account"}
+	// IAMGroups is the quota for the number of IAM groups in an account
+	IAMGroups = Quota{ServiceCode: "iam", QuotaCode: "L-F4A5425F", Name: "groups per account"}
+	// IAMPolicies is the quota for the number of customer managed IAM policies in an account
+	IAMPolicies = Quota{ServiceCode: "iam", QuotaCode: "L-E95E4862", Name: "customer managed policies per account"}
+	// CloudFrontDistributions is the quota for the number of cloudfront web distributions in an account
+	CloudFrontDistributions = Quota{ServiceCode: "cloudfront", QuotaCode: "L-24B04930", Name: "web distributions per account"}
+)
+
+// GetQuotaValue gets the value of a quota applied to the account, or the aws default value if the quota hasn't been
+// changed for the account
+func (s *ServiceQuotas) GetQuotaValue(ctx context.Context, quota Quota) (int, error) {
+	if quota.ServiceCode == "" || quota.QuotaCode == "" {
+		return 0, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("getting %s service quota %s (%s)", quota.ServiceCode, quota.QuotaCode, quota.Name)
+
+	out, err := s.Service.GetServiceQuotaWithContext(ctx, &servicequotas.GetServiceQuotaInput{
+		ServiceCode: aws.String(quota.ServiceCode),
+		QuotaCode:   aws.String(quota.QuotaCode),
+	})
+	if err == nil {
+		return int(aws.Float64Value(out.Quota.Value)), nil
+	}
+
+	if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != servicequotas.ErrCodeNoSuchResourceException {
+		return 0, ErrCode("failed to get service quota "+quota.Name, err)
+	}
+
+	log.Debugf("%s service quota %s isn't applied to the account, getting the default", quota.ServiceCode, quota.QuotaCode)
+
+	def, err := s.Service.GetAWSDefaultServiceQuotaWithContext(ctx, &servicequotas.GetAWSDefaultServiceQuotaInput{
+		ServiceCode: aws.String(quota.ServiceCode),
+		QuotaCode:   aws.String(quota.QuotaCode),
+	})
+	if err != nil {
+		return 0, ErrCode("failed to get default service quota "+quota.Name, err)
+	}
+
+	return int(aws.Float64Value(def.Quota.Value)), nil
+}
@@ -0,0 +1,82 @@
+package servicequotas
+
+import (
+	"context"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/servicequotas"
+)
+
+// the groups quota has been raised for the test account, the rest are the defaults
+func (m *mockServiceQuotasClient) GetServiceQuotaWithContext(ctx aws.Context, input *servicequotas.GetServiceQuotaInput, opts ...request.Option) (*servicequotas.GetServiceQuotaOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if aws.StringValue(input.QuotaCode) != IAMGroups.QuotaCode {
+		return nil, awserr.New(servicequotas.ErrCodeNoSuchResourceException, "not found", nil)
+	}
+
+	return &servicequotas.GetServiceQuotaOutput{Quota: &servicequotas.ServiceQuota{Value: aws.Float64(500)}}, nil
+}
+
+func (m *mockServiceQuotasClient) GetAWSDefaultServiceQuotaWithContext(ctx aws.Context, input *servicequotas.GetAWSDefaultServiceQuotaInput, opts ...request.Option) (*servicequotas.GetAWSDefaultServiceQuotaOutput, error) {
+	if aws.StringValue(input.QuotaCode) == "L-NOPE" {
+		return nil, awserr.New(servicequotas.ErrCodeNoSuchResourceException, "not found", nil)
+	}
+
+	return &servicequotas.GetAWSDefaultServiceQuotaOutput{Quota: &servicequotas.ServiceQuota{Value: aws.Float64(300)}}, nil
+}
+
+func TestGetQuotaValue(t *testing.T) {
+	s := ServiceQuotas{Service: newMockServiceQuotasClient(t, nil)}
+
+	// test an applied quota
+	out, err := s.GetQuotaValue(context.TODO(), IAMGroups)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if out != 500 {
+		t.Errorf("expected applied quota 500, got %d", out)
+	}
+
+	// test falling back to the default quota
+	out, err = s.GetQuotaValue(context.TODO(), IAMPolicies)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if out != 300 {
+		t.Errorf("expected default quota 300, got %d", out)
+	}
+
+	// test a quota that doesn't exist
+	_, err = s.GetQuotaValue(context.TODO(), Quota{ServiceCode: "iam", QuotaCode: "L-NOPE"})
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrNotFound {
+		t.Errorf("expected not found error, got %v", err)
+	}
+
+	// test invalid input
+	_, err = s.GetQuotaValue(context.TODO(), Quota{})
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrBadRequest {
+		t.Errorf("expected bad request error, got %v", err)
+	}
+
+	// test errors
+	s.Service.(*mockServiceQuotasClient).err = awserr.New(servicequotas.ErrCodeAccessDeniedException, "denied", nil)
+	_, err = s.GetQuotaValue(context.TODO(), IAMUsers)
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrForbidden {
+		t.Errorf("expected forbidden error, got %v", err)
+	}
+
+	s.Service.(*mockServiceQuotasClient).err = awserr.New(servicequotas.ErrCodeTooManyRequestsException, "slow down", nil)
+	_, err = s.GetQuotaValue(context.TODO(), IAMUsers)
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrLimitExceeded {
+		t.Errorf("expected limit exceeded error, got %v", err)
+	}
+}
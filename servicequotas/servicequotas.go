@@ -0,0 +1,33 @@
+package servicequotas
+
+import (
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/servicequotas"
+	"github.com/aws/aws-sdk-go/service/servicequotas/servicequotasiface"
+	log "github.com/sirupsen/logrus"
+)
+
+// globalRegion is the region the quotas of the global services, iam and cloudfront, are kept in
+const globalRegion = "us-east-1"
+
+// ServiceQuotas is a wrapper around the aws service quotas service
+type ServiceQuotas struct {
+	Service servicequotasiface.ServiceQuotasAPI
+}
+
+// NewSession creates a new service quotas session.  The quotas for iam and cloudfront are only available in us-east-1,
+// so the session is always for that region.
+func NewSession(sess *session.Session, account common.Account) ServiceQuotas {
+	s := ServiceQuotas{}
+	if sess == nil {
+		log.Infof("creating new aws session for service quotas with key id %s", account.Akid)
+		sess = session.Must(session.NewSession(&aws.Config{
+			Credentials: credentials.NewStaticCredentials(account.Akid, account.Secret, ""),
+		}))
+	}
+	s.Service = servicequotas.New(sess, aws.NewConfig().WithRegion(globalRegion))
+	return s
+}
@@ -0,0 +1,31 @@
+package servicequotas
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/service/servicequotas/servicequotasiface"
+)
+
+// mockServiceQuotasClient is a fake service quotas client
+type mockServiceQuotasClient struct {
+	servicequotasiface.ServiceQuotasAPI
+	t   *testing.T
+	err error
+}
+
+func newMockServiceQuotasClient(t *testing.T, err error) servicequotasiface.ServiceQuotasAPI {
+	return &mockServiceQuotasClient{
+		t:   t,
+		err: err,
+	}
+}
+
+func TestNewSession(t *testing.T) {
+	e := NewSession(nil, common.Account{})
+	to := reflect.TypeOf(e).String()
+	if to != "servicequotas.ServiceQuotas" {
+		t.Errorf("expected type to be 'servicequotas.ServiceQuotas', got %s", to)
+	}
+}
@@ -0,0 +1,59 @@
+package session
+
+import (
+	"context"
+
+	"github.com/YaleSpinup/s3-api/circuit"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// timeoutHandler sets a deadline on the context of each call for the service it's made to, the request handlers are
+// copied for each call so the cancel func is added to the call's complete handlers
+func timeoutHandler(timeouts *Timeouts) request.NamedHandler {
+	return request.NamedHandler{
+		Name: "spinup.TimeoutHandler",
+		Fn: func(r *request.Request) {
+			timeout := timeouts.For(r.ClientInfo.ServiceName)
+			if timeout <= 0 {
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			r.SetContext(ctx)
+			r.Handlers.Complete.PushBack(func(*request.Request) { cancel() })
+		},
+	}
+}
+
+// breakerHandler fails a call without making it while the breaker for the service is open, and records the result
+// of the calls that are made
+func breakerHandler(breakers *circuit.Breakers) request.NamedHandler {
+	return request.NamedHandler{
+		Name: "spinup.BreakerHandler",
+		Fn: func(r *request.Request) {
+			breaker := breakers.Get(r.ClientInfo.ServiceName)
+			if err := breaker.Allow(); err != nil {
+				r.Error = err
+				return
+			}
+
+			r.Handlers.Complete.PushBack(func(r *request.Request) {
+				breaker.Done(callFailed(r))
+			})
+		},
+	}
+}
+
+// callFailed returns true if a call failed because of the service, ie. it returned a server error, timed out or
+// couldn't be made at all.  Client errors and calls canceled by the caller aren't failures of the service.
+func callFailed(r *request.Request) bool {
+	if r.Error == nil {
+		return false
+	}
+
+	if r.Context().Err() == context.Canceled {
+		return false
+	}
+
+	return r.HTTPResponse == nil || r.HTTPResponse.StatusCode == 0 || r.HTTPResponse.StatusCode >= 500
+}
@@ -1,6 +1,9 @@
 package session
 
 import (
+	"time"
+
+	"github.com/YaleSpinup/s3-api/circuit"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -14,6 +17,8 @@ type Session struct {
 	ExternalID  string
 	credentials *credentials.Credentials
 	region      string
+	timeouts    *Timeouts
+	breakers    *circuit.Breakers
 }
 
 type SessionOption func(*Session)
@@ -36,6 +41,15 @@ func New(opts ...SessionOption) Session {
 	sess := session.Must(session.NewSession(&config))
 	s.Session = sess
 
+	if s.timeouts != nil {
+		sess.Handlers.Validate.PushFrontNamed(timeoutHandler(s.timeouts))
+	}
+
+	// the breaker is checked first so that a rejected call doesn't start its timeout
+	if s.breakers != nil {
+		sess.Handlers.Validate.PushFrontNamed(breakerHandler(s.breakers))
+	}
+
 	return s
 }
 
@@ -66,3 +80,36 @@ func WithExternalRoleName(role string) SessionOption {
 		s.RoleName = role
 	}
 }
+
+// WithTimeouts limits how long each call to an aws service can take, including its retries
+func WithTimeouts(timeouts *Timeouts) SessionOption {
+	return func(s *Session) {
+		log.Debugf("setting aws call timeouts to %+v", timeouts)
+		s.timeouts = timeouts
+	}
+}
+
+// WithBreakers stops calling the aws services that are failing, the breakers are shared by all of the sessions
+// created with them
+func WithBreakers(breakers *circuit.Breakers) SessionOption {
+	return func(s *Session) {
+		log.Debug("setting aws circuit breakers")
+		s.breakers = breakers
+	}
+}
+
+// Timeouts are the limits on how long the calls to aws services can take
+type Timeouts struct {
+	// Default is the timeout for the services without their own, calls don't time out if it's 0
+	Default time.Duration
+	// Services are the timeouts for services by name, ie. cloudfront
+	Services map[string]time.Duration
+}
+
+// For returns the timeout for a service
+func (t *Timeouts) For(service string) time.Duration {
+	if d, ok := t.Services[service]; ok {
+		return d
+	}
+	return t.Default
+}
@@ -0,0 +1,68 @@
+package sns
+
+import (
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/pkg/errors"
+)
+
+// ErrCode processes the error codes comming back from sns and converts them into apierror, a
+// standardized form consumable by downstream systems.
+func ErrCode(msg string, err error) error {
+	if aerr, ok := errors.Cause(err).(awserr.Error); ok {
+		switch aerr.Code() {
+		case
+			// sns.ErrCodeAuthorizationErrorException for service response error code
+			// "AuthorizationError".
+			//
+			// Indicates that the user has been denied access to the requested resource.
+			sns.ErrCodeAuthorizationErrorException,
+
+			// sns.ErrCodeKMSAccessDeniedException for service response error code
+			// "KMSAccessDenied".
+			//
+			// The ciphertext references a key that doesn't exist or that you don't have
+			// access to.
+			sns.ErrCodeKMSAccessDeniedException:
+
+			return apierror.New(apierror.ErrForbidden, msg, aerr)
+		case
+			// sns.ErrCodeNotFoundException for service response error code
+			// "NotFound".
+			//
+			// Indicates that the requested resource does not exist.
+			sns.ErrCodeNotFoundException:
+
+			return apierror.New(apierror.ErrNotFound, msg, aerr)
+		case
+			// sns.ErrCodeThrottledException for service response error code
+			// "Throttled".
+			//
+			// Indicates that the rate at which requests have been submitted for this action
+			// exceeds the limit for your Amazon Web Services account.
+			sns.ErrCodeThrottledException,
+
+			// sns.ErrCodeKMSThrottlingException for service response error code
+			// "KMSThrottling".
+			//
+			// The request was denied due to request throttling.
+			sns.ErrCodeKMSThrottlingException:
+
+			return apierror.New(apierror.ErrLimitExceeded, msg, aerr)
+		case
+			// sns.ErrCodeInternalErrorException for service response error code
+			// "InternalError".
+			//
+			// Indicates an internal service error.
+			sns.ErrCodeInternalErrorException:
+
+			return apierror.New(apierror.ErrServiceUnavailable, msg, aerr)
+		default:
+			m := msg + ": " + aerr.Message()
+			return apierror.New(apierror.ErrBadRequest, m, aerr)
+		}
+	}
+
+	return apierror.New(apierror.ErrInternalError, msg, err)
+}
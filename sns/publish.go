@@ -0,0 +1,43 @@
+package sns
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	log "github.com/sirupsen/logrus"
+)
+
+// Publish publishes a message to an sns topic with string message attributes and returns the message id.  The
+// attributes can be used by subscribers in subscription filter policies.
+func (s *SNS) Publish(ctx context.Context, topicArn, message string, attributes map[string]string) (string, error) {
+	if topicArn == "" || message == "" {
+		return "", apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("publishing message to sns topic %s", topicArn)
+
+	input := sns.PublishInput{
+		TopicArn: aws.String(topicArn),
+		Message:  aws.String(message),
+	}
+
+	if len(attributes) > 0 {
+		input.MessageAttributes = make(map[string]*sns.MessageAttributeValue, len(attributes))
+		for k, v := range attributes {
+			input.MessageAttributes[k] = &sns.MessageAttributeValue{
+				DataType:    aws.String("String"),
+				StringValue: aws.String(v),
+			}
+		}
+	}
+
+	out, err := s.Service.PublishWithContext(ctx, &input)
+	if err != nil {
+		return "", ErrCode(fmt.Sprintf("failed to publish message to topic %s", topicArn), err)
+	}
+
+	return aws.StringValue(out.MessageId), nil
+}
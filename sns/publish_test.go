@@ -0,0 +1,76 @@
+package sns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sns"
+)
+
+var testTopicArn = "arn:aws:sns:us-east-1:012345678901:spinup-events"
+
+func (m *mockSNSClient) PublishWithContext(ctx aws.Context, input *sns.PublishInput, opts ...request.Option) (*sns.PublishOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if aws.StringValue(input.TopicArn) != testTopicArn {
+		return nil, awserr.New(sns.ErrCodeNotFoundException, "topic not found", nil)
+	}
+
+	if a, ok := input.MessageAttributes["event_type"]; ok {
+		if aws.StringValue(a.DataType) != "String" || aws.StringValue(a.StringValue) != "bucket.created" {
+			return nil, fmt.Errorf("unexpected message attribute %+v", a)
+		}
+	}
+
+	return &sns.PublishOutput{MessageId: aws.String("abc-123")}, nil
+}
+
+func TestPublish(t *testing.T) {
+	s := SNS{Service: newMockSNSClient(t, nil)}
+
+	// test success
+	id, err := s.Publish(context.TODO(), testTopicArn, `{"Type":"bucket.created"}`, map[string]string{"event_type": "bucket.created"})
+	if err != nil {
+		t.Errorf("expected nil error, got: %s", err)
+	}
+
+	if id != "abc-123" {
+		t.Errorf("expected message id abc-123, got %s", id)
+	}
+
+	// test empty input
+	if _, err = s.Publish(context.TODO(), "", "", nil); err == nil {
+		t.Error("expected error for empty input, got nil")
+	} else if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrBadRequest {
+		t.Errorf("expected error code %s, got: %s", apierror.ErrBadRequest, err)
+	}
+
+	// test missing topic
+	_, err = s.Publish(context.TODO(), "arn:aws:sns:us-east-1:012345678901:missing", "foo", nil)
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrNotFound {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrNotFound, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %T", err)
+	}
+
+	// test non-aws error
+	s.Service.(*mockSNSClient).err = errors.New("things blowing up")
+	_, err = s.Publish(context.TODO(), testTopicArn, "foo", nil)
+	if aerr, ok := err.(apierror.Error); ok {
+		if aerr.Code != apierror.ErrInternalError {
+			t.Errorf("expected error code %s, got: %s", apierror.ErrInternalError, aerr.Code)
+		}
+	} else {
+		t.Errorf("expected apierror.Error, got: %T", err)
+	}
+}
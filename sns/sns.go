@@ -0,0 +1,30 @@
+package sns
+
+import (
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	log "github.com/sirupsen/logrus"
+)
+
+// SNS is a wrapper around the aws sns service
+type SNS struct {
+	Service snsiface.SNSAPI
+}
+
+// NewSession creates a new sns session
+func NewSession(sess *session.Session, account common.Account) SNS {
+	s := SNS{}
+	if sess == nil {
+		log.Infof("creating new aws session for sns with key id %s in region %s", account.Akid, account.Region)
+		sess = session.Must(session.NewSession(&aws.Config{
+			Credentials: credentials.NewStaticCredentials(account.Akid, account.Secret, ""),
+			Region:      aws.String(account.Region),
+		}))
+	}
+	s.Service = sns.New(sess)
+	return s
+}
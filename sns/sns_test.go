@@ -0,0 +1,31 @@
+package sns
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+)
+
+// mockSNSClient is a fake SNS client
+type mockSNSClient struct {
+	snsiface.SNSAPI
+	t   *testing.T
+	err error
+}
+
+func newMockSNSClient(t *testing.T, err error) snsiface.SNSAPI {
+	return &mockSNSClient{
+		t:   t,
+		err: err,
+	}
+}
+
+func TestNewSession(t *testing.T) {
+	e := NewSession(nil, common.Account{})
+	to := reflect.TypeOf(e).String()
+	if to != "sns.SNS" {
+		t.Errorf("expected type to be 'sns.SNS', got %s", to)
+	}
+}
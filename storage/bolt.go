@@ -0,0 +1,186 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+)
+
+// boltOpenTimeout is how long to wait for the lock on the database file, ie. if another instance has it open
+const boltOpenTimeout = 5 * time.Second
+
+// BoltStore keeps the state in a local bolt database file, each table is a bucket in the database.  The values are
+// stored with their expiration time, unix nanoseconds as 8 big endian bytes, in front of them.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore opens or creates the bolt database at the path
+func NewBoltStore(path string) (*BoltStore, error) {
+	log.Infof("opening bolt state store %s", path)
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open bolt database %s", path)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+// Get returns the value of the key in the table
+func (b *BoltStore) Get(ctx context.Context, table, key string) ([]byte, error) {
+	var value []byte
+	err := b.db.View(func(tx *bolt.Tx) error {
+		v, ok := boltGet(tx.Bucket([]byte(table)), key)
+		if !ok {
+			return apierror.New(apierror.ErrNotFound, "key not found", nil)
+		}
+
+		value = v
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// Put sets the value of the key in the table
+func (b *BoltStore) Put(ctx context.Context, table, key string, value []byte, expires time.Time) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return boltPut(tx, table, key, value, expires)
+	})
+}
+
+// PutIfAbsent sets the value of the key in the table if it doesn't exist or it's expired
+func (b *BoltStore) PutIfAbsent(ctx context.Context, table, key string, value []byte, expires time.Time) (bool, error) {
+	put := false
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		if _, ok := boltGet(tx.Bucket([]byte(table)), key); ok {
+			return nil
+		}
+
+		put = true
+		return boltPut(tx, table, key, value, expires)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return put, nil
+}
+
+// Delete deletes the key from the table
+func (b *BoltStore) Delete(ctx context.Context, table, key string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(table))
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.Delete([]byte(key))
+	})
+}
+
+// DeleteIf deletes the key from the table if it has the value
+func (b *BoltStore) DeleteIf(ctx context.Context, table, key string, value []byte) (bool, error) {
+	deleted := false
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(table))
+		if v, ok := boltGet(bucket, key); !ok || !bytes.Equal(v, value) {
+			return nil
+		}
+
+		deleted = true
+		return bucket.Delete([]byte(key))
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return deleted, nil
+}
+
+// List returns the values in the table that haven't expired
+func (b *BoltStore) List(ctx context.Context, table string) (map[string][]byte, error) {
+	values := map[string][]byte{}
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(table))
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.ForEach(func(k, v []byte) error {
+			if value, ok := boltDecode(v); ok {
+				values[string(k)] = value
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// Close closes the bolt database
+func (b *BoltStore) Close() error {
+	return b.db.Close()
+}
+
+// boltGet returns a copy of the value of the key in the bucket if it exists and hasn't expired
+func boltGet(bucket *bolt.Bucket, key string) ([]byte, bool) {
+	if bucket == nil {
+		return nil, false
+	}
+
+	v := bucket.Get([]byte(key))
+	if v == nil {
+		return nil, false
+	}
+
+	return boltDecode(v)
+}
+
+// boltPut puts the value of the key in the table's bucket, creating the bucket if it doesn't exist
+func boltPut(tx *bolt.Tx, table, key string, value []byte, expires time.Time) error {
+	bucket, err := tx.CreateBucketIfNotExists([]byte(table))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create bolt bucket %s", table)
+	}
+
+	v := make([]byte, 8+len(value))
+	if !expires.IsZero() {
+		binary.BigEndian.PutUint64(v, uint64(expires.UnixNano()))
+	}
+	copy(v[8:], value)
+
+	return bucket.Put([]byte(key), v)
+}
+
+// boltDecode returns a copy of a stored value if it hasn't expired, the byte slices from bolt are only valid for the
+// life of the transaction
+func boltDecode(v []byte) ([]byte, bool) {
+	if len(v) < 8 {
+		return nil, false
+	}
+
+	var expires time.Time
+	if n := binary.BigEndian.Uint64(v); n != 0 {
+		expires = time.Unix(0, int64(n))
+	}
+
+	if expired(expires, time.Now()) {
+		return nil, false
+	}
+
+	return append([]byte{}, v[8:]...), true
+}
@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// DynamoDBStore keeps the state in a dynamodb table so it's shared by all of the instances of the api.  The table's
+// partition key is the string attribute Table and its sort key is the string attribute Key, the value is the binary
+// attribute Value.  The Expires attribute (unix seconds) can be used as the table's TTL attribute to clean up the
+// expired values, they're ignored until dynamodb deletes them.  The attribute names are dynamodb reserved words so
+// they're always passed as expression attribute names.
+type DynamoDBStore struct {
+	Service dynamodbiface.DynamoDBAPI
+	Table   string
+}
+
+// NewDynamoDBStore creates a new dynamodb state store for the table
+func NewDynamoDBStore(sess *session.Session, account common.Account, table string) *DynamoDBStore {
+	d := &DynamoDBStore{Table: table}
+	if sess == nil {
+		log.Infof("creating new aws session for dynamodb with key id %s in region %s", account.Akid, account.Region)
+		sess = session.Must(session.NewSession(&aws.Config{
+			Credentials: credentials.NewStaticCredentials(account.Akid, account.Secret, ""),
+			Region:      aws.String(account.Region),
+		}))
+	}
+	d.Service = dynamodb.New(sess)
+	return d
+}
+
+// Get returns the value of the key in the table
+func (d *DynamoDBStore) Get(ctx context.Context, table, key string) ([]byte, error) {
+	output, err := d.Service.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(d.Table),
+		Key:            d.key(table, key),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, ErrCode("failed to get "+table+" "+key, err)
+	}
+
+	if output.Item == nil || dynamoDBExpired(output.Item, time.Now()) {
+		return nil, apierror.New(apierror.ErrNotFound, "key not found", nil)
+	}
+
+	return output.Item["Value"].B, nil
+}
+
+// Put sets the value of the key in the table
+func (d *DynamoDBStore) Put(ctx context.Context, table, key string, value []byte, expires time.Time) error {
+	if _, err := d.Service.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(d.Table),
+		Item:      d.item(table, key, value, expires),
+	}); err != nil {
+		return ErrCode("failed to put "+table+" "+key, err)
+	}
+
+	return nil
+}
+
+// PutIfAbsent puts the item for the key on the condition that it doesn't exist or it's expired
+func (d *DynamoDBStore) PutIfAbsent(ctx context.Context, table, key string, value []byte, expires time.Time) (bool, error) {
+	_, err := d.Service.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName:                aws.String(d.Table),
+		Item:                     d.item(table, key, value, expires),
+		ConditionExpression:      aws.String("attribute_not_exists(#key) OR #expires <= :now"),
+		ExpressionAttributeNames: map[string]*string{"#key": aws.String("Key"), "#expires": aws.String("Expires")},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":now": {N: aws.String(strconv.FormatInt(time.Now().Unix(), 10))},
+		},
+	})
+	if err != nil {
+		if ConditionFailed(err) {
+			return false, nil
+		}
+		return false, ErrCode("failed to put "+table+" "+key, err)
+	}
+
+	return true, nil
+}
+
+// Delete deletes the item for the key
+func (d *DynamoDBStore) Delete(ctx context.Context, table, key string) error {
+	if _, err := d.Service.DeleteItemWithContext(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(d.Table),
+		Key:       d.key(table, key),
+	}); err != nil {
+		return ErrCode("failed to delete "+table+" "+key, err)
+	}
+
+	return nil
+}
+
+// DeleteIf deletes the item for the key on the condition that it has the value
+func (d *DynamoDBStore) DeleteIf(ctx context.Context, table, key string, value []byte) (bool, error) {
+	_, err := d.Service.DeleteItemWithContext(ctx, &dynamodb.DeleteItemInput{
+		TableName:                 aws.String(d.Table),
+		Key:                       d.key(table, key),
+		ConditionExpression:       aws.String("#value = :value"),
+		ExpressionAttributeNames:  map[string]*string{"#value": aws.String("Value")},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":value": {B: value}},
+	})
+	if err != nil {
+		if ConditionFailed(err) {
+			return false, nil
+		}
+		return false, ErrCode("failed to delete "+table+" "+key, err)
+	}
+
+	return true, nil
+}
+
+// List queries the items in the table's partition and returns the values that haven't expired
+func (d *DynamoDBStore) List(ctx context.Context, table string) (map[string][]byte, error) {
+	input := &dynamodb.QueryInput{
+		TableName:                 aws.String(d.Table),
+		KeyConditionExpression:    aws.String("#table = :table"),
+		ExpressionAttributeNames:  map[string]*string{"#table": aws.String("Table")},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":table": {S: aws.String(table)}},
+		ConsistentRead:            aws.Bool(true),
+	}
+
+	now := time.Now()
+	values := map[string][]byte{}
+	for {
+		output, err := d.Service.QueryWithContext(ctx, input)
+		if err != nil {
+			return nil, ErrCode("failed to list "+table, err)
+		}
+
+		for _, item := range output.Items {
+			if dynamoDBExpired(item, now) || item["Key"] == nil {
+				continue
+			}
+			values[aws.StringValue(item["Key"].S)] = item["Value"].B
+		}
+
+		if len(output.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+
+	return values, nil
+}
+
+// Close is a no-op for the dynamodb store
+func (d *DynamoDBStore) Close() error {
+	return nil
+}
+
+// key returns the primary key of the item for the key in the table
+func (d *DynamoDBStore) key(table, key string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"Table": {S: aws.String(table)},
+		"Key":   {S: aws.String(key)},
+	}
+}
+
+// item returns the item for the value of the key in the table, the Expires attribute is only set if it expires
+func (d *DynamoDBStore) item(table, key string, value []byte, expires time.Time) map[string]*dynamodb.AttributeValue {
+	item := d.key(table, key)
+	item["Value"] = &dynamodb.AttributeValue{B: value}
+	if !expires.IsZero() {
+		item["Expires"] = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(expires.Unix(), 10))}
+	}
+
+	return item
+}
+
+// dynamoDBExpired returns true if the item has expired, dynamodb can take a while to delete expired items
+func dynamoDBExpired(item map[string]*dynamodb.AttributeValue, now time.Time) bool {
+	attr, ok := item["Expires"]
+	if !ok || attr.N == nil {
+		return false
+	}
+
+	expires, err := strconv.ParseInt(aws.StringValue(attr.N), 10, 64)
+	if err != nil {
+		return false
+	}
+
+	return expired(time.Unix(expires, 0), now)
+}
+
+// ConditionFailed returns true if the error is a failed dynamodb condition expression
+func ConditionFailed(err error) bool {
+	aerr, ok := errors.Cause(err).(awserr.Error)
+	return ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException
+}
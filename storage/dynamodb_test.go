@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// mockDynamoDBClient is a fake dynamodb client that evaluates the store conditions against a map of items
+type mockDynamoDBClient struct {
+	dynamodbiface.DynamoDBAPI
+	err   error
+	items map[string]map[string]*dynamodb.AttributeValue
+}
+
+func newMockDynamoDBClient(err error) *mockDynamoDBClient {
+	return &mockDynamoDBClient{
+		err:   err,
+		items: map[string]map[string]*dynamodb.AttributeValue{},
+	}
+}
+
+func mockItemKey(key map[string]*dynamodb.AttributeValue) string {
+	return aws.StringValue(key["Table"].S) + "/" + aws.StringValue(key["Key"].S)
+}
+
+func (m *mockDynamoDBClient) GetItemWithContext(ctx context.Context, input *dynamodb.GetItemInput, opts ...request.Option) (*dynamodb.GetItemOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return &dynamodb.GetItemOutput{Item: m.items[mockItemKey(input.Key)]}, nil
+}
+
+func (m *mockDynamoDBClient) PutItemWithContext(ctx context.Context, input *dynamodb.PutItemInput, opts ...request.Option) (*dynamodb.PutItemOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	key := mockItemKey(input.Item)
+	if input.ConditionExpression != nil {
+		if item, ok := m.items[key]; ok {
+			if item["Expires"] == nil {
+				return nil, awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "conditional check failed", nil)
+			}
+
+			now, _ := strconv.ParseInt(aws.StringValue(input.ExpressionAttributeValues[":now"].N), 10, 64)
+			if expires, _ := strconv.ParseInt(aws.StringValue(item["Expires"].N), 10, 64); expires > now {
+				return nil, awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "conditional check failed", nil)
+			}
+		}
+	}
+
+	m.items[key] = input.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (m *mockDynamoDBClient) DeleteItemWithContext(ctx context.Context, input *dynamodb.DeleteItemInput, opts ...request.Option) (*dynamodb.DeleteItemOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	key := mockItemKey(input.Key)
+	if input.ConditionExpression != nil {
+		if item, ok := m.items[key]; !ok || !bytes.Equal(item["Value"].B, input.ExpressionAttributeValues[":value"].B) {
+			return nil, awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "conditional check failed", nil)
+		}
+	}
+
+	delete(m.items, key)
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+// QueryWithContext returns one item per page to exercise the pagination
+func (m *mockDynamoDBClient) QueryWithContext(ctx context.Context, input *dynamodb.QueryInput, opts ...request.Option) (*dynamodb.QueryOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	table := aws.StringValue(input.ExpressionAttributeValues[":table"].S)
+	keys := []string{}
+	for k, item := range m.items {
+		if aws.StringValue(item["Table"].S) == table {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	start := 0
+	if input.ExclusiveStartKey != nil {
+		start = sort.SearchStrings(keys, mockItemKey(input.ExclusiveStartKey)) + 1
+	}
+
+	output := &dynamodb.QueryOutput{Items: []map[string]*dynamodb.AttributeValue{}}
+	if start < len(keys) {
+		item := m.items[keys[start]]
+		output.Items = append(output.Items, item)
+		output.LastEvaluatedKey = map[string]*dynamodb.AttributeValue{"Table": item["Table"], "Key": item["Key"]}
+	}
+
+	return output, nil
+}
+
+func TestDynamoDBStore(t *testing.T) {
+	testStore(t, &DynamoDBStore{Service: newMockDynamoDBClient(nil), Table: "spinup-s3-api-state"})
+}
+
+func TestDynamoDBStoreErrors(t *testing.T) {
+	store := &DynamoDBStore{
+		Service: newMockDynamoDBClient(awserr.New(dynamodb.ErrCodeResourceNotFoundException, "no table", nil)),
+		Table:   "missing",
+	}
+
+	_, err := store.Get(context.TODO(), "things", "foo")
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrNotFound {
+		t.Errorf("expected not found error for a missing table, got %v", err)
+	}
+
+	store.Service = newMockDynamoDBClient(errors.New("boom"))
+	if _, err := store.PutIfAbsent(context.TODO(), "things", "foo", []byte("bar"), time.Time{}); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
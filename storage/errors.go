@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/pkg/errors"
+)
+
+// ErrCode processes the error codes comming back from dynamodb and converts them into apierror, a
+// standardized form consumable by downstream systems.
+func ErrCode(msg string, err error) error {
+	if aerr, ok := errors.Cause(err).(awserr.Error); ok {
+		switch aerr.Code() {
+		case
+			// dynamodb.ErrCodeResourceNotFoundException for service response error code
+			// "ResourceNotFoundException".
+			//
+			// The operation tried to access a nonexistent table or index.
+			dynamodb.ErrCodeResourceNotFoundException:
+
+			return apierror.New(apierror.ErrNotFound, msg, aerr)
+		case
+			// dynamodb.ErrCodeProvisionedThroughputExceededException for service response error code
+			// "ProvisionedThroughputExceededException".
+			//
+			// Your request rate is too high.
+			dynamodb.ErrCodeProvisionedThroughputExceededException,
+
+			// dynamodb.ErrCodeRequestLimitExceeded for service response error code
+			// "RequestLimitExceeded".
+			//
+			// Throughput exceeds the current throughput quota for your account.
+			dynamodb.ErrCodeRequestLimitExceeded:
+
+			return apierror.New(apierror.ErrLimitExceeded, msg, aerr)
+		case
+			// dynamodb.ErrCodeInternalServerError for service response error code
+			// "InternalServerError".
+			//
+			// An error occurred on the server side.
+			dynamodb.ErrCodeInternalServerError:
+
+			return apierror.New(apierror.ErrServiceUnavailable, msg, aerr)
+		case
+			"AccessDeniedException":
+
+			return apierror.New(apierror.ErrForbidden, msg, aerr)
+		default:
+			m := msg + ": " + aerr.Message()
+			return apierror.New(apierror.ErrBadRequest, m, aerr)
+		}
+	}
+
+	return apierror.New(apierror.ErrInternalError, msg, err)
+}
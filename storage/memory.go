@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+)
+
+// MemoryStore keeps the state in memory, it's only shared by a single instance of the api and it's lost when the
+// api is restarted
+type MemoryStore struct {
+	mu     sync.Mutex
+	tables map[string]map[string]memoryItem
+}
+
+type memoryItem struct {
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryStore creates a new in memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tables: map[string]map[string]memoryItem{}}
+}
+
+// Get returns the value of the key in the table
+func (m *MemoryStore) Get(ctx context.Context, table, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, ok := m.get(table, key)
+	if !ok {
+		return nil, apierror.New(apierror.ErrNotFound, "key not found", nil)
+	}
+
+	return append([]byte(nil), item.value...), nil
+}
+
+// Put sets the value of the key in the table
+func (m *MemoryStore) Put(ctx context.Context, table, key string, value []byte, expires time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.put(table, key, value, expires)
+	return nil
+}
+
+// PutIfAbsent sets the value of the key in the table if it doesn't exist or it's expired
+func (m *MemoryStore) PutIfAbsent(ctx context.Context, table, key string, value []byte, expires time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.get(table, key); ok {
+		return false, nil
+	}
+
+	m.put(table, key, value, expires)
+	return true, nil
+}
+
+// Delete deletes the key from the table
+func (m *MemoryStore) Delete(ctx context.Context, table, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.tables[table], key)
+	return nil
+}
+
+// DeleteIf deletes the key from the table if it has the value
+func (m *MemoryStore) DeleteIf(ctx context.Context, table, key string, value []byte) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, ok := m.get(table, key)
+	if !ok || !bytes.Equal(item.value, value) {
+		return false, nil
+	}
+
+	delete(m.tables[table], key)
+	return true, nil
+}
+
+// List returns the values in the table that haven't expired
+func (m *MemoryStore) List(ctx context.Context, table string) (map[string][]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	values := map[string][]byte{}
+	for key, item := range m.tables[table] {
+		if expired(item.expires, now) {
+			delete(m.tables[table], key)
+			continue
+		}
+		values[key] = append([]byte(nil), item.value...)
+	}
+
+	return values, nil
+}
+
+// Close is a no-op for the memory store
+func (m *MemoryStore) Close() error {
+	return nil
+}
+
+// get returns the item for the key if it exists and hasn't expired, callers must hold the lock
+func (m *MemoryStore) get(table, key string) (memoryItem, bool) {
+	item, ok := m.tables[table][key]
+	if !ok || expired(item.expires, time.Now()) {
+		return memoryItem{}, false
+	}
+
+	return item, true
+}
+
+// put sets the item for the key, callers must hold the lock
+func (m *MemoryStore) put(table, key string, value []byte, expires time.Time) {
+	if _, ok := m.tables[table]; !ok {
+		m.tables[table] = map[string]memoryItem{}
+	}
+
+	m.tables[table][key] = memoryItem{value: append([]byte(nil), value...), expires: expires}
+}
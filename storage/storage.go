@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// BackendMemory keeps the state in memory, it's lost when the api is restarted
+	BackendMemory = "memory"
+	// BackendBolt keeps the state in a local bolt database file, it's kept across restarts of a single instance
+	BackendBolt = "bolt"
+	// BackendDynamoDB keeps the state in a dynamodb table, it's shared by all of the instances of the api
+	BackendDynamoDB = "dynamodb"
+)
+
+// Store keeps the durable state of the api, ie. the tasks, idempotency keys, soft delete records and locks.  Values
+// are kept by key in named tables and can expire, an expired value is treated as if it doesn't exist.  A zero expires
+// time never expires.
+type Store interface {
+	// Get returns the value of the key in the table, or a not found error
+	Get(ctx context.Context, table, key string) ([]byte, error)
+	// Put sets the value of the key in the table
+	Put(ctx context.Context, table, key string, value []byte, expires time.Time) error
+	// PutIfAbsent sets the value of the key in the table only if it doesn't exist, it returns false if it does
+	PutIfAbsent(ctx context.Context, table, key string, value []byte, expires time.Time) (bool, error)
+	// Delete deletes the key from the table, deleting a key that doesn't exist is not an error
+	Delete(ctx context.Context, table, key string) error
+	// DeleteIf deletes the key from the table only if it has the value, it returns false if it doesn't
+	DeleteIf(ctx context.Context, table, key string, value []byte) (bool, error)
+	// List returns the values in the table by key
+	List(ctx context.Context, table string) (map[string][]byte, error)
+	// Close releases the resources held by the store
+	Close() error
+}
+
+// expired returns true if a value that expires at the time has expired
+func expired(expires, now time.Time) bool {
+	return !expires.IsZero() && !now.Before(expires)
+}
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+)
+
+// testStore runs the same checks against each of the store backends
+func testStore(t *testing.T, store Store) {
+	ctx := context.TODO()
+
+	if _, err := store.Get(ctx, "things", "foo"); !isNotFound(err) {
+		t.Errorf("expected not found error for a missing key, got %v", err)
+	}
+
+	if err := store.Put(ctx, "things", "foo", []byte("bar"), time.Time{}); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if v, err := store.Get(ctx, "things", "foo"); err != nil || string(v) != "bar" {
+		t.Errorf("expected bar, got %s (%v)", v, err)
+	}
+
+	// keys are separate in each table
+	if _, err := store.Get(ctx, "others", "foo"); !isNotFound(err) {
+		t.Errorf("expected not found error for a key in another table, got %v", err)
+	}
+
+	if ok, err := store.PutIfAbsent(ctx, "things", "foo", []byte("baz"), time.Time{}); err != nil || ok {
+		t.Errorf("expected put if absent of an existing key to be false, got %t (%v)", ok, err)
+	}
+
+	if ok, err := store.PutIfAbsent(ctx, "things", "new", []byte("baz"), time.Now().Add(time.Hour)); err != nil || !ok {
+		t.Errorf("expected put if absent of a new key to be true, got %t (%v)", ok, err)
+	}
+
+	// expired values don't exist
+	if err := store.Put(ctx, "things", "old", []byte("gone"), time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if _, err := store.Get(ctx, "things", "old"); !isNotFound(err) {
+		t.Errorf("expected not found error for an expired key, got %v", err)
+	}
+
+	if ok, err := store.PutIfAbsent(ctx, "things", "old", []byte("back"), time.Time{}); err != nil || !ok {
+		t.Errorf("expected put if absent of an expired key to be true, got %t (%v)", ok, err)
+	}
+
+	if err := store.Put(ctx, "things", "expired", []byte("gone"), time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	values, err := store.List(ctx, "things")
+	if err != nil {
+		t.Fatalf("expected nil error listing, got %s", err)
+	}
+
+	expected := map[string][]byte{"foo": []byte("bar"), "new": []byte("baz"), "old": []byte("back")}
+	if !reflect.DeepEqual(expected, values) {
+		t.Errorf("expected values %s, got %s", expected, values)
+	}
+
+	if ok, err := store.DeleteIf(ctx, "things", "foo", []byte("nope")); err != nil || ok {
+		t.Errorf("expected delete if with the wrong value to be false, got %t (%v)", ok, err)
+	}
+
+	if ok, err := store.DeleteIf(ctx, "things", "foo", []byte("bar")); err != nil || !ok {
+		t.Errorf("expected delete if with the value to be true, got %t (%v)", ok, err)
+	}
+
+	if _, err := store.Get(ctx, "things", "foo"); !isNotFound(err) {
+		t.Errorf("expected not found error for a deleted key, got %v", err)
+	}
+
+	if err := store.Delete(ctx, "things", "new"); err != nil {
+		t.Errorf("expected nil error deleting, got %s", err)
+	}
+
+	if err := store.Delete(ctx, "missing", "new"); err != nil {
+		t.Errorf("expected nil error deleting a missing key, got %s", err)
+	}
+
+	if values, err := store.List(ctx, "missing"); err != nil || len(values) != 0 {
+		t.Errorf("expected no values for an empty table, got %s (%v)", values, err)
+	}
+
+	if err := store.Close(); err != nil {
+		t.Errorf("expected nil error closing, got %s", err)
+	}
+}
+
+func isNotFound(err error) bool {
+	aerr, ok := err.(apierror.Error)
+	return ok && aerr.Code == apierror.ErrNotFound
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestBoltStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.db")
+	store, err := NewBoltStore(path)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	testStore(t, store)
+
+	// values are kept when the database is reopened
+	store, err = NewBoltStore(path)
+	if err != nil {
+		t.Fatalf("expected nil error reopening, got %s", err)
+	}
+	defer store.Close()
+
+	if v, err := store.Get(context.TODO(), "things", "old"); err != nil || string(v) != "back" {
+		t.Errorf("expected back after reopening, got %s (%v)", v, err)
+	}
+}
@@ -0,0 +1,354 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/YaleSpinup/s3-api/storage"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// StatusRunning is a task that is still running
+	StatusRunning = "running"
+	// StatusSucceeded is a task that finished without an error, individual items may still have failed
+	StatusSucceeded = "succeeded"
+	// StatusFailed is a task that was stopped by an error
+	StatusFailed = "failed"
+
+	// DefaultRetention is how long finished tasks are kept
+	DefaultRetention = 24 * time.Hour
+
+	// maxFailures is the number of item failure messages kept for a task
+	maxFailures = 20
+
+	// storeTable is the table the tasks are kept in when the manager has a store
+	storeTable = "tasks"
+	// storeInterval is how often the progress of a running task is saved to the store
+	storeInterval = 5 * time.Second
+	// storeTimeout is how long saving or loading tasks from the store can take
+	storeTimeout = 10 * time.Second
+)
+
+// Progress counts the items a task has found and what happened to them
+type Progress struct {
+	Total     int64
+	Succeeded int64
+	Skipped   int64
+	Failed    int64
+}
+
+// Task is the state of an asynchronous operation on a resource in an account
+type Task struct {
+	ID       string
+	Kind     string
+	Account  string
+	Resource string
+	Status   string
+	Progress Progress
+	// Failures are the messages for the first items that failed
+	Failures []string `json:",omitempty"`
+	Error    string   `json:",omitempty"`
+	Created  time.Time
+	Updated  time.Time
+	Finished *time.Time `json:",omitempty"`
+}
+
+// Func is the work done by a task.  It reports progress with the reporter and returns an error if the task can't
+// continue.
+type Func func(ctx context.Context, r *Reporter) error
+
+// Manager runs tasks in the background and keeps their state so they can be reported on.  With a store, the state
+// of the tasks is also saved so the tasks run by other instances, or before a restart, can be reported on.
+type Manager struct {
+	ctx       context.Context
+	retention time.Duration
+	tasks     map[string]*Task
+	store     storage.Store
+	saved     map[string]time.Time
+	mu        sync.Mutex
+}
+
+type ManagerOption func(*Manager)
+
+// NewManager creates a new task manager, the tasks are cancelled when the context is done
+func NewManager(ctx context.Context, opts ...ManagerOption) *Manager {
+	m := &Manager{
+		ctx:       ctx,
+		retention: DefaultRetention,
+		tasks:     map[string]*Task{},
+		saved:     map[string]time.Time{},
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// WithStore saves the state of the tasks to the store, the progress of a running task is saved every few seconds
+func WithStore(store storage.Store) ManagerOption {
+	return func(m *Manager) {
+		m.store = store
+	}
+}
+
+// WithRetention sets how long finished tasks are kept
+func WithRetention(retention time.Duration) ManagerOption {
+	return func(m *Manager) {
+		log.Debugf("setting task retention to %s", retention)
+		m.retention = retention
+	}
+}
+
+// Start starts a task of the kind on a resource in an account and returns its initial state.  Only one task of a
+// kind can run on a resource at a time, a conflict is returned if one is already running.
+func (m *Manager) Start(kind, account, resource string, fn Func) (*Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.prune()
+
+	for _, t := range m.tasks {
+		if t.Kind == kind && t.Account == account && t.Resource == resource && t.Status == StatusRunning {
+			msg := fmt.Sprintf("%s task %s is already running on %s", kind, t.ID, resource)
+			return nil, apierror.New(apierror.ErrConflict, msg, nil)
+		}
+	}
+
+	now := time.Now().UTC()
+	t := &Task{
+		ID:       uuid.New().String(),
+		Kind:     kind,
+		Account:  account,
+		Resource: resource,
+		Status:   StatusRunning,
+		Created:  now,
+		Updated:  now,
+	}
+	m.tasks[t.ID] = t
+	m.saved[t.ID] = now
+
+	log.Infof("starting %s task %s on %s in account %s", kind, t.ID, resource, account)
+
+	out := t.copy()
+	m.save(out)
+
+	go m.run(t.ID, fn)
+
+	return out, nil
+}
+
+// run executes the task function and records the result
+func (m *Manager) run(id string, fn Func) {
+	err := fn(m.ctx, &Reporter{manager: m, id: id})
+
+	m.update(id, func(t *Task) {
+		now := time.Now().UTC()
+		t.Finished = &now
+		t.Status = StatusSucceeded
+		if err != nil {
+			t.Status = StatusFailed
+			t.Error = err.Error()
+		}
+
+		log.Infof("%s task %s on %s finished with status %s: %+v", t.Kind, t.ID, t.Resource, t.Status, t.Progress)
+	})
+}
+
+// Get returns the current state of a task, the tasks that aren't running in this instance are loaded from the store
+func (m *Manager) Get(id string) (*Task, error) {
+	m.mu.Lock()
+	t, ok := m.tasks[id]
+	if ok {
+		out := t.copy()
+		m.mu.Unlock()
+		return out, nil
+	}
+	m.mu.Unlock()
+
+	if m.store == nil {
+		return nil, apierror.New(apierror.ErrNotFound, "task not found", nil)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	value, err := m.store.Get(ctx, storeTable, id)
+	if err != nil {
+		if common.IsErrorCode(err, apierror.ErrNotFound) {
+			return nil, apierror.New(apierror.ErrNotFound, "task not found", nil)
+		}
+		return nil, err
+	}
+
+	out := Task{}
+	if err := json.Unmarshal(value, &out); err != nil {
+		return nil, apierror.New(apierror.ErrInternalError, "failed to unmarshal task "+id, err)
+	}
+
+	return &out, nil
+}
+
+// List returns the current state of the tasks, oldest first.  The tasks in the store that aren't running in this
+// instance are included, if the store can't be listed only the tasks in this instance are returned.
+func (m *Manager) List() []*Task {
+	m.mu.Lock()
+	m.prune()
+
+	tasks := make([]*Task, 0, len(m.tasks))
+	for _, t := range m.tasks {
+		tasks = append(tasks, t.copy())
+	}
+	m.mu.Unlock()
+
+	for _, t := range m.stored() {
+		found := false
+		for _, existing := range tasks {
+			if existing.ID == t.ID {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			tasks = append(tasks, t)
+		}
+	}
+
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].Created.Before(tasks[j].Created) })
+
+	return tasks
+}
+
+// update applies a change to a task under the lock, the task is saved when it's finished or every store interval
+func (m *Manager) update(id string, fn func(t *Task)) {
+	m.mu.Lock()
+
+	t, ok := m.tasks[id]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+
+	fn(t)
+	t.Updated = time.Now().UTC()
+
+	var out *Task
+	if t.Finished != nil || t.Updated.Sub(m.saved[id]) >= storeInterval {
+		m.saved[id] = t.Updated
+		out = t.copy()
+	}
+	m.mu.Unlock()
+
+	if out != nil {
+		m.save(out)
+	}
+}
+
+// save saves a copy of a task to the store, it expires a retention after it was last updated.  Failures are logged
+// since the task is still kept in memory.
+func (m *Manager) save(t *Task) {
+	if m.store == nil {
+		return
+	}
+
+	value, err := json.Marshal(t)
+	if err != nil {
+		log.Errorf("failed to marshal task %s: %s", t.ID, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	if err := m.store.Put(ctx, storeTable, t.ID, value, t.Updated.Add(m.retention)); err != nil {
+		log.Errorf("failed to save task %s: %s", t.ID, err)
+	}
+}
+
+// stored returns the tasks in the store
+func (m *Manager) stored() []*Task {
+	if m.store == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	values, err := m.store.List(ctx, storeTable)
+	if err != nil {
+		log.Errorf("failed to list tasks from the store: %s", err)
+		return nil
+	}
+
+	tasks := make([]*Task, 0, len(values))
+	for id, value := range values {
+		t := Task{}
+		if err := json.Unmarshal(value, &t); err != nil {
+			log.Warnf("skipping unreadable task %s in the store: %s", id, err)
+			continue
+		}
+		tasks = append(tasks, &t)
+	}
+
+	return tasks
+}
+
+// prune removes the tasks that finished longer than the retention ago, it must be called with the lock held
+func (m *Manager) prune() {
+	cutoff := time.Now().Add(-m.retention)
+	for id, t := range m.tasks {
+		if t.Finished != nil && t.Finished.Before(cutoff) {
+			delete(m.tasks, id)
+			delete(m.saved, id)
+		}
+	}
+}
+
+// copy returns a copy of the task that is safe to use without the lock
+func (t *Task) copy() *Task {
+	out := *t
+	out.Failures = append([]string(nil), t.Failures...)
+	return &out
+}
+
+// Reporter updates the progress of a running task
+type Reporter struct {
+	manager *Manager
+	id      string
+}
+
+// Found adds to the total number of items found by the task
+func (r *Reporter) Found(n int) {
+	r.manager.update(r.id, func(t *Task) { t.Progress.Total += int64(n) })
+}
+
+// Succeeded counts an item that was processed
+func (r *Reporter) Succeeded() {
+	r.manager.update(r.id, func(t *Task) { t.Progress.Succeeded++ })
+}
+
+// Skipped counts an item that didn't need to be processed
+func (r *Reporter) Skipped() {
+	r.manager.update(r.id, func(t *Task) { t.Progress.Skipped++ })
+}
+
+// Failed counts an item that failed and keeps the message if there's room for it
+func (r *Reporter) Failed(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	r.manager.update(r.id, func(t *Task) {
+		t.Progress.Failed++
+		if len(t.Failures) < maxFailures {
+			t.Failures = append(t.Failures, msg)
+		}
+	})
+}
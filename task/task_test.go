@@ -0,0 +1,185 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/storage"
+)
+
+// waitFor polls the manager until the task is finished
+func waitFor(t *testing.T, m *Manager, id string) *Task {
+	for i := 0; i < 100; i++ {
+		task, err := m.Get(id)
+		if err != nil {
+			t.Fatalf("expected nil error, got %s", err)
+		}
+
+		if task.Status != StatusRunning {
+			return task
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("task %s didn't finish", id)
+	return nil
+}
+
+func TestStart(t *testing.T) {
+	m := NewManager(context.Background())
+
+	task, err := m.Start("encrypt", "12345", "foobucket", func(ctx context.Context, r *Reporter) error {
+		r.Found(4)
+		r.Succeeded()
+		r.Succeeded()
+		r.Skipped()
+		r.Failed("object %s is too large", "big.bin")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if task.Status != StatusRunning || task.Kind != "encrypt" || task.Resource != "foobucket" {
+		t.Errorf("unexpected initial task %+v", task)
+	}
+
+	task = waitFor(t, m, task.ID)
+
+	if task.Status != StatusSucceeded || task.Finished == nil {
+		t.Errorf("expected succeeded task, got %+v", task)
+	}
+
+	expected := Progress{Total: 4, Succeeded: 2, Skipped: 1, Failed: 1}
+	if task.Progress != expected {
+		t.Errorf("expected progress %+v, got %+v", expected, task.Progress)
+	}
+
+	if len(task.Failures) != 1 || task.Failures[0] != "object big.bin is too large" {
+		t.Errorf("expected failure message, got %v", task.Failures)
+	}
+}
+
+func TestStartFailed(t *testing.T) {
+	m := NewManager(context.Background())
+
+	task, err := m.Start("encrypt", "12345", "foobucket", func(ctx context.Context, r *Reporter) error {
+		return errors.New("boom")
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	task = waitFor(t, m, task.ID)
+	if task.Status != StatusFailed || task.Error != "boom" {
+		t.Errorf("expected failed task with error boom, got %+v", task)
+	}
+}
+
+func TestStartConflict(t *testing.T) {
+	m := NewManager(context.Background())
+
+	done := make(chan struct{})
+	task, err := m.Start("encrypt", "12345", "foobucket", func(ctx context.Context, r *Reporter) error {
+		<-done
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	_, err = m.Start("encrypt", "12345", "foobucket", func(ctx context.Context, r *Reporter) error { return nil })
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrConflict {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+
+	// a different resource can run at the same time
+	other, err := m.Start("encrypt", "12345", "otherbucket", func(ctx context.Context, r *Reporter) error { return nil })
+	if err != nil {
+		t.Errorf("expected nil error for another bucket, got %s", err)
+	}
+
+	close(done)
+	waitFor(t, m, task.ID)
+	waitFor(t, m, other.ID)
+
+	// the task can run again once it's finished
+	if _, err := m.Start("encrypt", "12345", "foobucket", func(ctx context.Context, r *Reporter) error { return nil }); err != nil {
+		t.Errorf("expected nil error after the task finished, got %s", err)
+	}
+}
+
+func TestGetAndList(t *testing.T) {
+	m := NewManager(context.Background(), WithRetention(time.Hour))
+
+	if _, err := m.Get("missing"); err == nil {
+		t.Error("expected error for missing task, got nil")
+	} else if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrNotFound {
+		t.Errorf("expected not found error, got %s", err)
+	}
+
+	first, _ := m.Start("encrypt", "12345", "a", func(ctx context.Context, r *Reporter) error { return nil })
+	second, _ := m.Start("encrypt", "12345", "b", func(ctx context.Context, r *Reporter) error { return nil })
+	waitFor(t, m, first.ID)
+	waitFor(t, m, second.ID)
+
+	tasks := m.List()
+	if len(tasks) != 2 || tasks[0].ID != first.ID || tasks[1].ID != second.ID {
+		t.Errorf("expected both tasks oldest first, got %+v", tasks)
+	}
+
+	// finished tasks older than the retention are pruned
+	m.mu.Lock()
+	old := time.Now().Add(-2 * time.Hour)
+	m.tasks[first.ID].Finished = &old
+	m.mu.Unlock()
+
+	if tasks := m.List(); len(tasks) != 1 || tasks[0].ID != second.ID {
+		t.Errorf("expected the old task to be pruned, got %+v", tasks)
+	}
+}
+
+func TestStore(t *testing.T) {
+	store := storage.NewMemoryStore()
+	m := NewManager(context.Background(), WithStore(store))
+
+	task, err := m.Start("encrypt", "12345", "foobucket", func(ctx context.Context, r *Reporter) error {
+		r.Found(1)
+		r.Succeeded()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+	waitFor(t, m, task.ID)
+
+	// another instance, or this one after a restart, loads the finished task from the store
+	other := NewManager(context.Background(), WithStore(store))
+
+	saved, err := other.Get(task.ID)
+	if err != nil {
+		t.Fatalf("expected nil error getting the task from the store, got %s", err)
+	}
+
+	if saved.Status != StatusSucceeded || saved.Progress.Succeeded != 1 || saved.Finished == nil {
+		t.Errorf("expected the finished task from the store, got %+v", saved)
+	}
+
+	running, _ := other.Start("encrypt", "12345", "barbucket", func(ctx context.Context, r *Reporter) error { return nil })
+	waitFor(t, other, running.ID)
+
+	tasks := other.List()
+	if len(tasks) != 2 || tasks[0].ID != task.ID || tasks[1].ID != running.ID {
+		t.Errorf("expected the stored and running tasks oldest first, got %+v", tasks)
+	}
+
+	if _, err := other.Get("missing"); err == nil {
+		t.Error("expected error for missing task, got nil")
+	} else if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrNotFound {
+		t.Errorf("expected not found error, got %s", err)
+	}
+}
@@ -0,0 +1,79 @@
+package transfer
+
+import (
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/transfer"
+	"github.com/pkg/errors"
+)
+
+// ErrCode processes the error codes comming back from transfer and converts them into apierror, a
+// standardized form consumable by downstream systems.
+func ErrCode(msg string, err error) error {
+	if aerr, ok := errors.Cause(err).(awserr.Error); ok {
+		switch aerr.Code() {
+		case
+			// transfer.ErrCodeAccessDeniedException for service response error code
+			// "AccessDeniedException".
+			//
+			// You do not have sufficient access to perform this action.
+			transfer.ErrCodeAccessDeniedException:
+
+			return apierror.New(apierror.ErrForbidden, msg, aerr)
+		case
+			// transfer.ErrCodeResourceNotFoundException for service response error code
+			// "ResourceNotFoundException".
+			//
+			// This exception is thrown when a resource is not found by the Amazon Web
+			// ServicesTransfer Family service.
+			transfer.ErrCodeResourceNotFoundException:
+
+			return apierror.New(apierror.ErrNotFound, msg, aerr)
+		case
+			// transfer.ErrCodeResourceExistsException for service response error code
+			// "ResourceExistsException".
+			//
+			// The requested resource does not exist, or exists in a region other than
+			// the one specified for the command.
+			transfer.ErrCodeResourceExistsException,
+
+			// transfer.ErrCodeConflictException for service response error code
+			// "ConflictException".
+			//
+			// This exception is thrown when the UpdateServer is called for a file transfer
+			// protocol-enabled server that has VPC as the endpoint type and the server's
+			// VpcEndpointID is not in the available state.
+			transfer.ErrCodeConflictException:
+
+			return apierror.New(apierror.ErrConflict, msg, aerr)
+		case
+			// transfer.ErrCodeThrottlingException for service response error code
+			// "ThrottlingException".
+			//
+			// The request was denied due to request throttling.
+			transfer.ErrCodeThrottlingException:
+
+			return apierror.New(apierror.ErrLimitExceeded, msg, aerr)
+		case
+			// transfer.ErrCodeInternalServiceError for service response error code
+			// "InternalServiceError".
+			//
+			// This exception is thrown when an error occurs in the Transfer Family service.
+			transfer.ErrCodeInternalServiceError,
+
+			// transfer.ErrCodeServiceUnavailableException for service response error code
+			// "ServiceUnavailableException".
+			//
+			// The request has failed because the Amazon Web ServicesTransfer Family service
+			// is not available.
+			transfer.ErrCodeServiceUnavailableException:
+
+			return apierror.New(apierror.ErrServiceUnavailable, msg, aerr)
+		default:
+			m := msg + ": " + aerr.Message()
+			return apierror.New(apierror.ErrBadRequest, m, aerr)
+		}
+	}
+
+	return apierror.New(apierror.ErrInternalError, msg, err)
+}
@@ -0,0 +1,23 @@
+package transfer
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/transfer"
+	log "github.com/sirupsen/logrus"
+)
+
+// DescribeServer gets the details of the server, ie. its state
+func (t *Transfer) DescribeServer(ctx context.Context) (*transfer.DescribedServer, error) {
+	log.Infof("describing transfer server %s", t.ServerID)
+
+	out, err := t.Service.DescribeServerWithContext(ctx, &transfer.DescribeServerInput{
+		ServerId: aws.String(t.ServerID),
+	})
+	if err != nil {
+		return nil, ErrCode("failed to describe transfer server "+t.ServerID, err)
+	}
+
+	return out.Server, nil
+}
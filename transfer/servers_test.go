@@ -0,0 +1,41 @@
+package transfer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/transfer"
+)
+
+func (m *mockTransferClient) DescribeServerWithContext(ctx context.Context, input *transfer.DescribeServerInput, opts ...request.Option) (*transfer.DescribeServerOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &transfer.DescribeServerOutput{Server: &transfer.DescribedServer{
+		ServerId: input.ServerId,
+		State:    aws.String(transfer.StateOnline),
+	}}, nil
+}
+
+func TestDescribeServer(t *testing.T) {
+	tr := Transfer{Service: newMockTransferClient(t, nil), ServerID: "s-1234"}
+
+	out, err := tr.DescribeServer(context.TODO())
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if aws.StringValue(out.ServerId) != "s-1234" || aws.StringValue(out.State) != transfer.StateOnline {
+		t.Errorf("expected online server s-1234, got %+v", out)
+	}
+
+	tr.Service.(*mockTransferClient).err = awserr.New(transfer.ErrCodeResourceNotFoundException, "not found", nil)
+	_, err = tr.DescribeServer(context.TODO())
+	if aerr, ok := err.(apierror.Error); !ok || aerr.Code != apierror.ErrNotFound {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package transfer
+
+import (
+	"fmt"
+
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/transfer"
+	"github.com/aws/aws-sdk-go/service/transfer/transferiface"
+	log "github.com/sirupsen/logrus"
+)
+
+// Transfer is a wrapper around the aws transfer family service for a single sftp server
+type Transfer struct {
+	Service transferiface.TransferAPI
+	// ServerID is the id of the server the users are managed on
+	ServerID string
+	// Hostname is the hostname users connect to the server with
+	Hostname string
+}
+
+// NewSession creates a new transfer session for the server configured for the account
+func NewSession(sess *session.Session, account common.Account) Transfer {
+	t := Transfer{}
+	if sess == nil {
+		log.Infof("creating new aws session for transfer with key id %s in region %s", account.Akid, account.Region)
+		sess = session.Must(session.NewSession(&aws.Config{
+			Credentials: credentials.NewStaticCredentials(account.Akid, account.Secret, ""),
+			Region:      aws.String(account.Region),
+		}))
+	}
+	t.Service = transfer.New(sess)
+
+	if account.Transfer != nil {
+		t.ServerID = account.Transfer.ServerID
+		t.Hostname = account.Transfer.Hostname
+		if t.Hostname == "" {
+			t.Hostname = fmt.Sprintf("%s.server.transfer.%s.amazonaws.com", t.ServerID, account.Region)
+		}
+	}
+
+	return t
+}
@@ -0,0 +1,41 @@
+package transfer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/service/transfer/transferiface"
+)
+
+// mockTransferClient is a fake transfer client
+type mockTransferClient struct {
+	transferiface.TransferAPI
+	t   *testing.T
+	err error
+}
+
+func newMockTransferClient(t *testing.T, err error) transferiface.TransferAPI {
+	return &mockTransferClient{
+		t:   t,
+		err: err,
+	}
+}
+
+func TestNewSession(t *testing.T) {
+	e := NewSession(nil, common.Account{})
+	to := reflect.TypeOf(e).String()
+	if to != "transfer.Transfer" {
+		t.Errorf("expected type to be 'transfer.Transfer', got %s", to)
+	}
+
+	e = NewSession(nil, common.Account{Region: "us-east-1", Transfer: &common.Transfer{ServerID: "s-1234"}})
+	if e.Hostname != "s-1234.server.transfer.us-east-1.amazonaws.com" {
+		t.Errorf("expected default hostname for the server, got %s", e.Hostname)
+	}
+
+	e = NewSession(nil, common.Account{Region: "us-east-1", Transfer: &common.Transfer{ServerID: "s-1234", Hostname: "sftp.example.edu"}})
+	if e.Hostname != "sftp.example.edu" {
+		t.Errorf("expected configured hostname, got %s", e.Hostname)
+	}
+}
@@ -0,0 +1,141 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/transfer"
+	log "github.com/sirupsen/logrus"
+)
+
+// CreateUser creates a user on the server that's chrooted to the bucket and accesses it with the role.  The
+// user's home directory is a logical directory mapped to the root of the bucket, so the user can't see the
+// bucket name or leave the bucket.
+func (t *Transfer) CreateUser(ctx context.Context, user, bucket, role, sshPublicKey string, tags []*transfer.Tag) (*transfer.DescribedUser, error) {
+	if user == "" || bucket == "" || role == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("creating transfer user %s on server %s for bucket %s", user, t.ServerID, bucket)
+
+	input := &transfer.CreateUserInput{
+		HomeDirectoryType: aws.String(transfer.HomeDirectoryTypeLogical),
+		HomeDirectoryMappings: []*transfer.HomeDirectoryMapEntry{
+			{
+				Entry:  aws.String("/"),
+				Target: aws.String(fmt.Sprintf("/%s", bucket)),
+			},
+		},
+		Role:     aws.String(role),
+		ServerId: aws.String(t.ServerID),
+		UserName: aws.String(user),
+	}
+
+	if sshPublicKey != "" {
+		input.SshPublicKeyBody = aws.String(sshPublicKey)
+	}
+
+	if len(tags) > 0 {
+		input.Tags = tags
+	}
+
+	if _, err := t.Service.CreateUserWithContext(ctx, input); err != nil {
+		return nil, ErrCode("failed to create transfer user "+user, err)
+	}
+
+	return t.DescribeUser(ctx, user)
+}
+
+// DescribeUser gets the details of a user on the server, including their ssh public keys
+func (t *Transfer) DescribeUser(ctx context.Context, user string) (*transfer.DescribedUser, error) {
+	if user == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("describing transfer user %s on server %s", user, t.ServerID)
+
+	out, err := t.Service.DescribeUserWithContext(ctx, &transfer.DescribeUserInput{
+		ServerId: aws.String(t.ServerID),
+		UserName: aws.String(user),
+	})
+	if err != nil {
+		return nil, ErrCode("failed to describe transfer user "+user, err)
+	}
+
+	return out.User, nil
+}
+
+// ListUsers lists all of the users on the server
+func (t *Transfer) ListUsers(ctx context.Context) ([]*transfer.ListedUser, error) {
+	log.Infof("listing transfer users on server %s", t.ServerID)
+
+	users := []*transfer.ListedUser{}
+	if err := t.Service.ListUsersPagesWithContext(ctx, &transfer.ListUsersInput{ServerId: aws.String(t.ServerID)},
+		func(out *transfer.ListUsersOutput, lastPage bool) bool {
+			users = append(users, out.Users...)
+			return true
+		}); err != nil {
+		return nil, ErrCode("failed to list transfer users", err)
+	}
+
+	return users, nil
+}
+
+// DeleteUser deletes a user and their ssh public keys from the server
+func (t *Transfer) DeleteUser(ctx context.Context, user string) error {
+	if user == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("deleting transfer user %s on server %s", user, t.ServerID)
+
+	if _, err := t.Service.DeleteUserWithContext(ctx, &transfer.DeleteUserInput{
+		ServerId: aws.String(t.ServerID),
+		UserName: aws.String(user),
+	}); err != nil {
+		return ErrCode("failed to delete transfer user "+user, err)
+	}
+
+	return nil
+}
+
+// ImportSSHPublicKey adds an ssh public key to a user and returns the id of the key
+func (t *Transfer) ImportSSHPublicKey(ctx context.Context, user, sshPublicKey string) (string, error) {
+	if user == "" || sshPublicKey == "" {
+		return "", apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("importing ssh public key for transfer user %s on server %s", user, t.ServerID)
+
+	out, err := t.Service.ImportSshPublicKeyWithContext(ctx, &transfer.ImportSshPublicKeyInput{
+		ServerId:         aws.String(t.ServerID),
+		SshPublicKeyBody: aws.String(sshPublicKey),
+		UserName:         aws.String(user),
+	})
+	if err != nil {
+		return "", ErrCode("failed to import ssh public key for transfer user "+user, err)
+	}
+
+	return aws.StringValue(out.SshPublicKeyId), nil
+}
+
+// DeleteSSHPublicKey removes an ssh public key from a user
+func (t *Transfer) DeleteSSHPublicKey(ctx context.Context, user, keyId string) error {
+	if user == "" || keyId == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("deleting ssh public key %s for transfer user %s on server %s", keyId, user, t.ServerID)
+
+	if _, err := t.Service.DeleteSshPublicKeyWithContext(ctx, &transfer.DeleteSshPublicKeyInput{
+		ServerId:       aws.String(t.ServerID),
+		SshPublicKeyId: aws.String(keyId),
+		UserName:       aws.String(user),
+	}); err != nil {
+		return ErrCode("failed to delete ssh public key "+keyId, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+package transfer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/transfer"
+)
+
+func (m *mockTransferClient) CreateUserWithContext(ctx context.Context, input *transfer.CreateUserInput, opts ...request.Option) (*transfer.CreateUserOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if aws.StringValue(input.HomeDirectoryType) != transfer.HomeDirectoryTypeLogical {
+		m.t.Errorf("expected a logical home directory, got %s", aws.StringValue(input.HomeDirectoryType))
+	}
+
+	if len(input.HomeDirectoryMappings) != 1 || aws.StringValue(input.HomeDirectoryMappings[0].Target) != "/testbucket" {
+		m.t.Errorf("expected the home directory to be mapped to /testbucket, got %+v", input.HomeDirectoryMappings)
+	}
+
+	return &transfer.CreateUserOutput{ServerId: input.ServerId, UserName: input.UserName}, nil
+}
+
+func (m *mockTransferClient) DescribeUserWithContext(ctx context.Context, input *transfer.DescribeUserInput, opts ...request.Option) (*transfer.DescribeUserOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &transfer.DescribeUserOutput{ServerId: input.ServerId, User: &transfer.DescribedUser{
+		Role:     aws.String("arn:aws:iam::012345678910:role/testbucket-SftpRole"),
+		UserName: input.UserName,
+		SshPublicKeys: []*transfer.SshPublicKey{
+			{SshPublicKeyId: aws.String("key-1"), SshPublicKeyBody: aws.String("ssh-ed25519 AAAA")},
+		},
+	}}, nil
+}
+
+func (m *mockTransferClient) ListUsersPagesWithContext(ctx context.Context, input *transfer.ListUsersInput, fn func(*transfer.ListUsersOutput, bool) bool, opts ...request.Option) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	if !fn(&transfer.ListUsersOutput{Users: []*transfer.ListedUser{{UserName: aws.String("one")}}}, false) {
+		return nil
+	}
+	fn(&transfer.ListUsersOutput{Users: []*transfer.ListedUser{{UserName: aws.String("two")}}}, true)
+	return nil
+}
+
+func (m *mockTransferClient) DeleteUserWithContext(ctx context.Context, input *transfer.DeleteUserInput, opts ...request.Option) (*transfer.DeleteUserOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &transfer.DeleteUserOutput{}, nil
+}
+
+func (m *mockTransferClient) ImportSshPublicKeyWithContext(ctx context.Context, input *transfer.ImportSshPublicKeyInput, opts ...request.Option) (*transfer.ImportSshPublicKeyOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &transfer.ImportSshPublicKeyOutput{ServerId: input.ServerId, SshPublicKeyId: aws.String("key-2"), UserName: input.UserName}, nil
+}
+
+func (m *mockTransferClient) DeleteSshPublicKeyWithContext(ctx context.Context, input *transfer.DeleteSshPublicKeyInput, opts ...request.Option) (*transfer.DeleteSshPublicKeyOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &transfer.DeleteSshPublicKeyOutput{}, nil
+}
+
+func TestCreateUser(t *testing.T) {
+	tr := Transfer{Service: newMockTransferClient(t, nil), ServerID: "s-1234"}
+
+	out, err := tr.CreateUser(context.TODO(), "researcher", "testbucket", "arn:aws:iam::012345678910:role/testbucket-SftpRole", "ssh-ed25519 AAAA", nil)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if aws.StringValue(out.UserName) != "researcher" || len(out.SshPublicKeys) != 1 {
+		t.Errorf("expected user researcher with one key, got %+v", out)
+	}
+
+	if _, err := tr.CreateUser(context.TODO(), "researcher", "", "role", "", nil); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Errorf("expected bad request without a bucket, got %v", err)
+	}
+
+	tr.Service.(*mockTransferClient).err = awserr.New(transfer.ErrCodeResourceExistsException, "exists", nil)
+	if _, err := tr.CreateUser(context.TODO(), "researcher", "testbucket", "role", "", nil); !common.IsErrorCode(err, apierror.ErrConflict) {
+		t.Errorf("expected conflict for an existing user, got %v", err)
+	}
+}
+
+func TestDescribeUser(t *testing.T) {
+	tr := Transfer{Service: newMockTransferClient(t, nil), ServerID: "s-1234"}
+
+	out, err := tr.DescribeUser(context.TODO(), "researcher")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if aws.StringValue(out.UserName) != "researcher" {
+		t.Errorf("expected user researcher, got %s", aws.StringValue(out.UserName))
+	}
+
+	if _, err := tr.DescribeUser(context.TODO(), ""); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Errorf("expected bad request for an empty user, got %v", err)
+	}
+
+	tr.Service.(*mockTransferClient).err = awserr.New(transfer.ErrCodeResourceNotFoundException, "not found", nil)
+	if _, err := tr.DescribeUser(context.TODO(), "researcher"); !common.IsErrorCode(err, apierror.ErrNotFound) {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
+
+func TestListUsers(t *testing.T) {
+	tr := Transfer{Service: newMockTransferClient(t, nil), ServerID: "s-1234"}
+
+	out, err := tr.ListUsers(context.TODO())
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if len(out) != 2 {
+		t.Errorf("expected users from both pages, got %d", len(out))
+	}
+
+	tr.Service.(*mockTransferClient).err = awserr.New(transfer.ErrCodeThrottlingException, "slow down", nil)
+	if _, err := tr.ListUsers(context.TODO()); !common.IsErrorCode(err, apierror.ErrLimitExceeded) {
+		t.Errorf("expected limit exceeded, got %v", err)
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	tr := Transfer{Service: newMockTransferClient(t, nil), ServerID: "s-1234"}
+
+	if err := tr.DeleteUser(context.TODO(), "researcher"); err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	if err := tr.DeleteUser(context.TODO(), ""); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Errorf("expected bad request for an empty user, got %v", err)
+	}
+}
+
+func TestSSHPublicKeys(t *testing.T) {
+	tr := Transfer{Service: newMockTransferClient(t, nil), ServerID: "s-1234"}
+
+	id, err := tr.ImportSSHPublicKey(context.TODO(), "researcher", "ssh-ed25519 BBBB")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if id != "key-2" {
+		t.Errorf("expected key id key-2, got %s", id)
+	}
+
+	if err := tr.DeleteSSHPublicKey(context.TODO(), "researcher", id); err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	if _, err := tr.ImportSSHPublicKey(context.TODO(), "researcher", ""); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Errorf("expected bad request for an empty key, got %v", err)
+	}
+
+	if err := tr.DeleteSSHPublicKey(context.TODO(), "researcher", ""); !common.IsErrorCode(err, apierror.ErrBadRequest) {
+		t.Errorf("expected bad request for an empty key id, got %v", err)
+	}
+}
@@ -0,0 +1,247 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/YaleSpinup/s3-api/common"
+	"github.com/YaleSpinup/s3-api/retry"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	EventBucketCreated      = "bucket.created"
+	EventBucketDeleted      = "bucket.deleted"
+	EventBucketRolledBack   = "bucket.rolled_back"
+	EventBucketAdopted      = "bucket.adopted"
+	EventBucketExpiring     = "bucket.expiring"
+	EventWebsiteCreated     = "website.created"
+	EventWebsiteDeleted     = "website.deleted"
+	EventWebsiteRolledBack  = "website.rolled_back"
+	EventWebsiteSoftDeleted = "website.soft_deleted"
+	EventWebsiteRestored    = "website.restored"
+	EventWebsiteDeployed    = "website.deployed"
+	EventUserCreated        = "user.created"
+	EventUserDeleted        = "user.deleted"
+	EventUserRolledBack     = "user.rolled_back"
+	EventUserFlagged        = "user.flagged"
+	EventUserDeactivated    = "user.deactivated"
+	EventSFTPEnabled        = "sftp.enabled"
+	EventSFTPDisabled       = "sftp.disabled"
+	EventSFTPUserCreated    = "sftp.user_created"
+	EventSFTPUserDeleted    = "sftp.user_deleted"
+	EventAccessPointCreated = "accesspoint.created"
+	EventAccessPointDeleted = "accesspoint.deleted"
+
+	// EventRollbackExecuted and EventRollbackFailed are the alert events for rollbacks that were executed
+	// successfully and the ones that left resources behind
+	EventRollbackExecuted = "rollback.executed"
+	EventRollbackFailed   = "rollback.failed"
+
+	// SignatureHeader carries the hex encoded HMAC-SHA256 of the request body, signed with the webhook secret
+	SignatureHeader = "X-Spinup-Signature"
+	// EventHeader carries the event type
+	EventHeader = "X-Spinup-Event"
+)
+
+// Event is a lifecycle event delivered to webhooks
+type Event struct {
+	ID       string
+	Type     string
+	Org      string
+	Account  string
+	Resource string
+	Time     time.Time
+	Details  interface{} `json:",omitempty"`
+}
+
+// Notifier delivers events to the configured webhooks
+type Notifier struct {
+	hooks    []common.Webhook
+	client   *http.Client
+	org      string
+	attempts int
+	backoff  time.Duration
+	wg       sync.WaitGroup
+}
+
+type NotifierOption func(*Notifier)
+
+// New creates a new webhook notifier with options
+func New(hooks []common.Webhook, opts ...NotifierOption) *Notifier {
+	log.Infof("creating new webhook notifier for %d webhooks", len(hooks))
+
+	n := Notifier{
+		hooks:    hooks,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		attempts: 5,
+		backoff:  1 * time.Second,
+	}
+
+	for _, opt := range opts {
+		opt(&n)
+	}
+
+	return &n
+}
+
+func WithOrg(org string) NotifierOption {
+	return func(n *Notifier) {
+		log.Debugf("setting webhook org to %s", org)
+		n.org = org
+	}
+}
+
+func WithAttempts(attempts int) NotifierOption {
+	return func(n *Notifier) {
+		log.Debugf("setting webhook delivery attempts to %d", attempts)
+		n.attempts = attempts
+	}
+}
+
+func WithBackoff(backoff time.Duration) NotifierOption {
+	return func(n *Notifier) {
+		log.Debugf("setting webhook delivery backoff to %s", backoff)
+		n.backoff = backoff
+	}
+}
+
+func WithHTTPClient(client *http.Client) NotifierOption {
+	return func(n *Notifier) {
+		n.client = client
+	}
+}
+
+// NewEvent returns a new event with a generated id and the current time
+func NewEvent(eventType, org, account, resource string, details interface{}) Event {
+	return Event{
+		ID:       uuid.New().String(),
+		Type:     eventType,
+		Org:      org,
+		Account:  account,
+		Resource: resource,
+		Time:     time.Now().UTC(),
+		Details:  details,
+	}
+}
+
+// Notify asynchronously delivers an event to all of the webhooks subscribed to the event type.  The event id,
+// org and time are set if they are empty.  It's safe to call on a nil Notifier.
+func (n *Notifier) Notify(e Event) {
+	if n == nil || len(n.hooks) == 0 {
+		return
+	}
+
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+
+	if e.Org == "" {
+		e.Org = n.org
+	}
+
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+
+	body, err := json.Marshal(e)
+	if err != nil {
+		log.Errorf("failed to marshal webhook event %s: %s", e.Type, err)
+		return
+	}
+
+	for _, h := range n.hooks {
+		if !h.Subscribed(e.Type) {
+			continue
+		}
+
+		n.wg.Add(1)
+		go func(hook common.Webhook) {
+			defer n.wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			defer cancel()
+
+			if err := n.deliver(ctx, hook, e.Type, body); err != nil {
+				log.Errorf("failed to deliver webhook event %s (%s) to %s: %s", e.Type, e.ID, hook.URL, err)
+			}
+		}(h)
+	}
+}
+
+// Wait blocks until all in-flight deliveries are finished
+func (n *Notifier) Wait() {
+	if n == nil {
+		return
+	}
+	n.wg.Wait()
+}
+
+// deliver posts the event body to the webhook, retrying with exponential backoff on failure.  Client errors,
+// other than 429 Too Many Requests, are not retried.
+func (n *Notifier) deliver(ctx context.Context, hook common.Webhook, eventType string, body []byte) error {
+	policy := retry.Policy{
+		Attempts: n.attempts,
+		Backoff:  n.backoff,
+	}
+
+	if err := retry.Do(ctx, policy, func(ctx context.Context) error {
+		retryable, err := n.post(ctx, hook, eventType, body)
+		if err != nil && !retryable {
+			return retry.Stop(err)
+		}
+		return err
+	}); err != nil {
+		return err
+	}
+
+	log.Infof("delivered webhook event %s to %s", eventType, hook.URL)
+	return nil
+}
+
+// post makes a single delivery attempt and returns whether a failure can be retried
+func (n *Notifier) post(ctx context.Context, hook common.Webhook, eventType string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, eventType)
+	if hook.Secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(hook.Secret, body))
+	}
+
+	res, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return true, nil
+	}
+
+	err = fmt.Errorf("unexpected response status %d", res.StatusCode)
+	if res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests {
+		return true, err
+	}
+
+	return false, err
+}
+
+// Sign returns the hex encoded HMAC-SHA256 signature of the body using the secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
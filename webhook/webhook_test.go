@@ -0,0 +1,162 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/s3-api/common"
+)
+
+func TestNew(t *testing.T) {
+	n := New(nil)
+	to := reflect.TypeOf(n).String()
+	if to != "*webhook.Notifier" {
+		t.Errorf("expected type to be '*webhook.Notifier', got %s", to)
+	}
+
+	n = New(nil, WithOrg("testorg"), WithAttempts(2), WithBackoff(5*time.Millisecond))
+	if n.org != "testorg" {
+		t.Errorf("expected org to be testorg, got %s", n.org)
+	}
+
+	if n.attempts != 2 {
+		t.Errorf("expected attempts to be 2, got %d", n.attempts)
+	}
+
+	if n.backoff != 5*time.Millisecond {
+		t.Errorf("expected backoff to be 5ms, got %s", n.backoff)
+	}
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '{"foo":"bar"}' | openssl dgst -sha256 -hmac "sekret"
+	expected := "a20644eaa300d943c4fbb4d5b12d58f3c40b7f64b1d3b731173afeddd4e0f3d2"
+	if out := Sign("sekret", []byte(`{"foo":"bar"}`)); out != expected {
+		t.Errorf("expected signature %s, got %s", expected, out)
+	}
+
+	if Sign("sekret", []byte("foo")) == Sign("other", []byte("foo")) {
+		t.Error("expected signatures with different secrets to differ")
+	}
+}
+
+func TestNewEvent(t *testing.T) {
+	e := NewEvent(EventBucketCreated, "testorg", "someaccount", "foobucket", nil)
+	if e.ID == "" {
+		t.Error("expected event id to be set")
+	}
+
+	if e.Time.IsZero() {
+		t.Error("expected event time to be set")
+	}
+
+	if e.Type != EventBucketCreated || e.Org != "testorg" || e.Account != "someaccount" || e.Resource != "foobucket" {
+		t.Errorf("unexpected event %+v", e)
+	}
+}
+
+func TestNotify(t *testing.T) {
+	var mu sync.Mutex
+	received := []Event{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		if sig := r.Header.Get(SignatureHeader); sig != "sha256="+Sign("sekret", body) {
+			t.Errorf("unexpected signature %s", sig)
+		}
+
+		var e Event
+		if err := json.Unmarshal(body, &e); err != nil {
+			t.Errorf("failed to unmarshal event: %s", err)
+		}
+
+		if r.Header.Get(EventHeader) != e.Type {
+			t.Errorf("expected event header %s, got %s", e.Type, r.Header.Get(EventHeader))
+		}
+
+		mu.Lock()
+		received = append(received, e)
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	n := New([]common.Webhook{
+		{URL: srv.URL, Secret: "sekret", Events: []string{"bucket.*"}},
+	}, WithOrg("testorg"))
+
+	n.Notify(Event{Type: EventBucketCreated, Account: "spinup", Resource: "foobucket"})
+	n.Notify(Event{Type: EventUserCreated, Account: "spinup", Resource: "foouser"})
+	n.Wait()
+
+	if len(received) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(received))
+	}
+
+	e := received[0]
+	if e.Type != EventBucketCreated || e.Org != "testorg" || e.Resource != "foobucket" || e.ID == "" || e.Time.IsZero() {
+		t.Errorf("unexpected event %+v", e)
+	}
+
+	// nil notifiers are a noop
+	var nilNotifier *Notifier
+	nilNotifier.Notify(Event{Type: EventBucketCreated})
+	nilNotifier.Wait()
+}
+
+func TestDeliverRetry(t *testing.T) {
+	var count int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	n := New(nil, WithAttempts(3), WithBackoff(time.Millisecond))
+
+	// test success after retries
+	if err := n.deliver(context.TODO(), common.Webhook{URL: srv.URL}, EventBucketCreated, []byte("{}")); err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	if count != 3 {
+		t.Errorf("expected 3 attempts, got %d", count)
+	}
+
+	// test giving up after max attempts
+	atomic.StoreInt32(&count, -10)
+	if err := n.deliver(context.TODO(), common.Webhook{URL: srv.URL}, EventBucketCreated, []byte("{}")); err == nil {
+		t.Error("expected error after max attempts, got nil")
+	}
+}
+
+func TestDeliverNoRetryOnClientError(t *testing.T) {
+	var count int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	n := New(nil, WithAttempts(3), WithBackoff(time.Millisecond))
+	if err := n.deliver(context.TODO(), common.Webhook{URL: srv.URL}, EventBucketCreated, []byte("{}")); err == nil {
+		t.Error("expected error, got nil")
+	}
+
+	if count != 1 {
+		t.Errorf("expected 1 attempt, got %d", count)
+	}
+}